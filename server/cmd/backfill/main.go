@@ -0,0 +1,123 @@
+// Command backfill copies the clicks recorded in Postgres to ClickHouse, so a
+// deployment moving its analytics there keeps its history.
+//
+// Turn on ANALYTICS_DOUBLE_WRITE first, then copy everything from before that moment:
+//
+//	go run ./cmd/backfill -until 2026-10-15T09:00:00Z
+//
+// Progress is saved to the checkpoint file after every batch; run the command again
+// (the -until flag can be left out) to resume after an interruption.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/config"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+func main() {
+	untilFlag := flag.String("until", "", "copy clicks from before this time (RFC 3339), e.g. when double-writing started")
+	checkpointPath := flag.String("checkpoint", "backfill-checkpoint.json", "file progress is saved to and resumed from")
+	batchSize := flag.Int("batch", service.DefaultBackfillBatchSize, "clicks copied per insert")
+	flag.Parse()
+
+	var until time.Time
+	if *untilFlag != "" {
+		var err error
+		if until, err = time.Parse(time.RFC3339, *untilFlag); err != nil {
+			fmt.Println("Invalid -until:", err.Error())
+			os.Exit(2)
+		}
+	}
+
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		fmt.Println(cfgErr.Error())
+		os.Exit(1)
+	}
+	if cfg.ClickhouseURL == "" {
+		fmt.Println("CLICKHOUSE_URL is required")
+		os.Exit(1)
+	}
+
+	log, logErr := logger.New(cfg.AppEnv)
+	if logErr != nil {
+		fmt.Println(logErr.Error())
+		os.Exit(1)
+	}
+
+	defer func() {
+		_ = log.Sync() // Flush logs on exit
+	}()
+
+	// Stop after the current batch on Ctrl-C; the checkpoint is already saved
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := db.NewPool(ctx, cfg.PostgresConnectionString)
+	if err != nil {
+		log.Fatal("Failed to create Postgres pool",
+			zap.Error(err),
+		)
+	}
+	defer pool.Close()
+
+	clickHouse := analytics.NewClickHouseStore(analytics.ClickHouseOptions{
+		URL:      cfg.ClickhouseURL,
+		Username: cfg.ClickhouseUsername,
+		Password: cfg.ClickhousePassword,
+		Database: cfg.ClickhouseDatabase,
+	})
+	if err := clickHouse.CheckSchema(ctx); err != nil {
+		log.Fatal("ClickHouse isn't ready, run its migrations first (task migrate-clickhouse)",
+			zap.Error(err),
+		)
+	}
+
+	backfill := service.NewClickBackfill(db.New(pool), clickHouse, *checkpointPath, int32(*batchSize), log)
+
+	checkpoint, err := backfill.Checkpoint(until)
+	if err != nil {
+		log.Fatal("Failed to load checkpoint",
+			zap.Error(err),
+		)
+	}
+
+	log.Info("Backfill start",
+		zap.Time("until", checkpoint.Until),
+		zap.Int64("after_id", checkpoint.AfterID),
+		zap.Int64("copied", checkpoint.Copied),
+	)
+
+	checkpoint, err = backfill.Run(ctx, checkpoint)
+	if errors.Is(err, context.Canceled) {
+		log.Info("Backfill interrupted, run it again to resume",
+			zap.Int64("copied", checkpoint.Copied),
+			zap.String("checkpoint", *checkpointPath),
+		)
+		return
+	}
+	if err != nil {
+		log.Fatal("Backfill stopped",
+			zap.Error(err),
+			zap.Int64("copied", checkpoint.Copied),
+			zap.String("checkpoint", *checkpointPath),
+		)
+	}
+
+	log.Info("Backfill complete",
+		zap.Int64("copied", checkpoint.Copied),
+	)
+}
@@ -0,0 +1,209 @@
+// Command backup takes, lists and restores backups of the Postgres metadata in the
+// BACKUP_S3_BUCKET bucket (see service.BackupService). Without flags it takes one now,
+// as the server does every BACKUP_INTERVAL hours:
+//
+//	go run ./cmd/backup
+//	go run ./cmd/backup -list
+//
+// -restore restores the latest backup, or the one given with -key or -file, into
+// a freshly migrated database (task migrate) at the backup's migration:
+//
+//	go run ./cmd/backup -restore
+//	go run ./cmd/backup -restore -file backup-20261015T090000Z.gz
+//
+// Nothing is restored unless the whole backup is intact. With -verify the backup
+// is only checked.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/backup"
+	"github.com/styltsou/url-shortener/server/pkg/config"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/httpclient"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/objectstore"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+func main() {
+	list := flag.Bool("list", false, "list the backups in the bucket")
+	restore := flag.Bool("restore", false, "restore a backup into the database")
+	key := flag.String("key", "", "key of the backup to restore (default the latest)")
+	path := flag.String("file", "", "restore this backup file instead of one from the bucket")
+	verify := flag.Bool("verify", false, "with -restore, only check the backup, restoring nothing")
+	flag.Parse()
+
+	if (*key != "" || *path != "" || *verify) && !*restore {
+		fmt.Println("-key, -file and -verify go with -restore")
+		os.Exit(2)
+	}
+	if *key != "" && *path != "" {
+		fmt.Println("-key and -file can't both be set")
+		os.Exit(2)
+	}
+
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		fmt.Println(cfgErr.Error())
+		os.Exit(1)
+	}
+	if cfg.BackupS3Bucket == "" && *path == "" {
+		fmt.Println("BACKUP_S3_BUCKET is required")
+		os.Exit(1)
+	}
+
+	log, logErr := logger.New(cfg.AppEnv)
+	if logErr != nil {
+		fmt.Println(logErr.Error())
+		os.Exit(1)
+	}
+
+	defer func() {
+		_ = log.Sync() // Flush logs on exit
+	}()
+
+	// An interrupted restore rolls back, leaving the database empty
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var storage service.BackupStorage
+	if cfg.BackupS3Bucket != "" {
+		client, err := objectstore.New(cfg.BackupStorage(), httpclient.New(httpclient.Options{
+			Name:             "backup",
+			Timeout:          service.BackupTransferTimeout,
+			MaxResponseBytes: service.MaxBackupBytes,
+			AllowPrivate:     true,
+		}))
+		if err != nil {
+			log.Fatal("Invalid backup storage",
+				zap.Error(err),
+			)
+		}
+		storage = client
+	}
+	opts := service.BackupOptions{
+		Prefix:        cfg.BackupPrefix,
+		Retention:     time.Duration(cfg.BackupRetentionDays) * 24 * time.Hour,
+		IncludeClicks: cfg.BackupIncludeClicks,
+	}
+
+	if *list {
+		backups, err := service.NewBackupService(nil, storage, opts, log).List(ctx)
+		if err != nil {
+			log.Fatal("Failed to list backups",
+				zap.Error(err),
+			)
+		}
+		for _, b := range backups {
+			fmt.Printf("%s\t%d bytes\n", b.Key, b.Size)
+		}
+		return
+	}
+
+	var src io.ReadCloser
+	if *restore {
+		var err error
+		name := *path
+		if *path != "" {
+			src, err = os.Open(*path)
+		} else {
+			src, name, err = service.NewBackupService(nil, storage, opts, log).Fetch(ctx, *key)
+		}
+		if err != nil {
+			log.Fatal("Failed to open backup",
+				zap.Error(err),
+			)
+		}
+		defer src.Close()
+
+		log.Info("Backup opened",
+			zap.String("backup", name),
+		)
+		if *verify {
+			verifyBackup(src, log)
+			return
+		}
+	}
+
+	pool, err := db.NewPool(ctx, cfg.PostgresConnectionString)
+	if err != nil {
+		log.Fatal("Failed to create Postgres pool",
+			zap.Error(err),
+		)
+	}
+	defer pool.Close()
+
+	backups := service.NewBackupService(
+		repository.NewTransactor(db.NewStore(pool), func(q *db.Queries) repository.BackupQueries { return q }),
+		storage,
+		opts,
+		log,
+	)
+
+	if *restore {
+		header, tables, err := backups.Restore(ctx, src)
+		if err != nil {
+			log.Fatal("Restore failed, nothing was restored",
+				zap.Error(err),
+			)
+		}
+		for _, t := range tables {
+			fmt.Printf("%s\t%d rows\n", t.Name, t.Rows)
+		}
+		log.Info("Restore complete",
+			zap.Time("created_at", header.CreatedAt),
+		)
+		return
+	}
+
+	if _, err := backups.Backup(ctx); err != nil {
+		log.Fatal("Backup failed",
+			zap.Error(err),
+		)
+	}
+	if _, err := backups.Prune(ctx, time.Now()); err != nil {
+		log.Error("Backup pruning failed",
+			zap.Error(err),
+		)
+	}
+}
+
+// verifyBackup reads the whole backup, checking it against its footer
+func verifyBackup(r io.Reader, log logger.Logger) {
+	br, err := backup.NewReader(r)
+	if err != nil {
+		log.Fatal("Backup is damaged",
+			zap.Error(err),
+		)
+	}
+	for {
+		_, _, err := br.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Fatal("Backup is damaged",
+				zap.Error(err),
+			)
+		}
+	}
+
+	header := br.Header()
+	for _, t := range br.Tables() {
+		fmt.Printf("%s\t%d rows\n", t.Name, t.Rows)
+	}
+	fmt.Printf("Backup OK: created at %s, schema version %s\n",
+		header.CreatedAt.Format(time.RFC3339), header.SchemaVersion)
+}
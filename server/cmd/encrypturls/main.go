@@ -0,0 +1,105 @@
+// Command encrypturls rewrites the destination URLs stored in Postgres with the
+// keyring of URL_ENCRYPTION_KEYS (or URL_ENCRYPTION_KEYS_FILE).
+//
+// After turning encryption on, or putting a new key first in the keyring, restart
+// the servers so they write with the active key, then encrypt what's already stored:
+//
+//	go run ./cmd/encrypturls
+//
+// Once it completes, keys other than the first can be removed from the keyring.
+// It also corrects the http_destination flag of rows where it doesn't match the URL
+// (written by hand, say), so the HTTPS upgrade job finds their http:// destinations.
+// To turn encryption off, run it with -decrypt before removing the keyring.
+// Runs can be interrupted and started again: rows already done are skipped.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/styltsou/url-shortener/server/pkg/config"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+func main() {
+	decrypt := flag.Bool("decrypt", false, "write the URLs back in plain text, before turning encryption off")
+	batchSize := flag.Int("batch", service.DefaultURLEncryptionBatchSize, "rows read per batch")
+	flag.Parse()
+
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		fmt.Println(cfgErr.Error())
+		os.Exit(1)
+	}
+
+	keyring, err := cfg.URLEncryptionKeyring()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if keyring == nil {
+		fmt.Println("URL_ENCRYPTION_KEYS or URL_ENCRYPTION_KEYS_FILE is required")
+		os.Exit(1)
+	}
+
+	log, logErr := logger.New(cfg.AppEnv)
+	if logErr != nil {
+		fmt.Println(logErr.Error())
+		os.Exit(1)
+	}
+
+	defer func() {
+		_ = log.Sync() // Flush logs on exit
+	}()
+
+	// Stop on Ctrl-C; running again picks up the rest
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := db.NewPool(ctx, cfg.PostgresConnectionString)
+	if err != nil {
+		log.Fatal("Failed to create Postgres pool",
+			zap.Error(err),
+		)
+	}
+	defer pool.Close()
+
+	// The stored values are read and written as they are, not through db.Encrypted
+	encryption := service.NewURLEncryption(db.New(pool), keyring, *decrypt, int32(*batchSize), log)
+
+	log.Info("URL encryption start",
+		zap.String("active_key", keyring.ActiveKeyID()),
+		zap.Bool("decrypt", *decrypt),
+	)
+
+	tallies, err := encryption.Run(ctx)
+	var rewritten int64
+	for _, tally := range tallies {
+		rewritten += tally.Rewritten
+	}
+
+	if errors.Is(err, context.Canceled) {
+		log.Info("URL encryption interrupted, run it again to finish",
+			zap.Int64("rewritten", rewritten),
+		)
+		return
+	}
+	if err != nil {
+		log.Fatal("URL encryption stopped",
+			zap.Error(err),
+			zap.Int64("rewritten", rewritten),
+		)
+	}
+
+	log.Info("URL encryption complete",
+		zap.Int64("rewritten", rewritten),
+	)
+}
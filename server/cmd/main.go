@@ -3,16 +3,21 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
 	"time"
+	// Stats take any IANA time zone, even on hosts without a zone database
+	_ "time/tzdata"
 
 	server "github.com/styltsou/url-shortener/server/pkg"
+	"github.com/styltsou/url-shortener/server/pkg/certs"
 	"github.com/styltsou/url-shortener/server/pkg/config"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
 	"go.uber.org/zap"
 )
 
@@ -46,17 +51,75 @@ func main() {
 		ReadTimeout:  time.Duration(cfg.ServerReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.ServerWriteTimeout) * time.Second,
 		IdleTimeout:  time.Duration(cfg.ServerIdleTimeout) * time.Second,
+		Protocols:    serverProtocols(cfg),
 	}
 
+	var certProvider *certs.Provider
+	if cfg.TLSEnabled {
+		certProvider, err = certs.New(cfg)
+		if err != nil {
+			log.Fatal("Failed to configure TLS",
+				zap.Error(err),
+			)
+		}
+		httpServer.TLSConfig = certProvider.TLSConfig
+	}
+
+	// Internal endpoints (health, metrics, pprof) live on a separate port
+	// so they are never exposed through the public ingress
+	internalServer := &http.Server{
+		Addr:         ":" + strconv.Itoa(cfg.InternalPort),
+		Handler:      srv.InternalRouter,
+		ReadTimeout:  time.Duration(cfg.ServerReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.ServerWriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(cfg.ServerIdleTimeout) * time.Second,
+	}
+
+	// Under systemd socket activation the listeners are passed in and survive restarts
+	listeners, err := netutil.ActivatedListeners()
+	if err != nil {
+		log.Fatal("Failed to use activated sockets",
+			zap.Error(err),
+		)
+	}
+	publicListener, err := listen(listeners, netutil.PublicSocketName, httpServer.Addr, cfg.ServerReusePort)
+	if err != nil {
+		log.Fatal("Failed to listen",
+			zap.Int("port", cfg.Port),
+			zap.Error(err),
+		)
+	}
+	internalListener, err := listen(listeners, netutil.InternalSocketName, internalServer.Addr, cfg.ServerReusePort)
+	if err != nil {
+		log.Fatal("Failed to listen on internal port",
+			zap.Int("port", cfg.InternalPort),
+			zap.Error(err),
+		)
+	}
+
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
+
 		sigint := make(chan os.Signal, 1)
 		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 		<-sigint
 
+		// Fail readiness so load balancers stop routing here, and make clients
+		// open new connections (elsewhere) instead of reusing this instance
+		srv.Drain.Start()
+		httpServer.SetKeepAlivesEnabled(false)
+		if cfg.ShutdownDrainDelay > 0 {
+			log.Info("Draining before shutdown",
+				zap.Int("delay_seconds", cfg.ShutdownDrainDelay),
+			)
+			time.Sleep(time.Duration(cfg.ShutdownDrainDelay) * time.Second)
+		}
+
 		log.Info("Shutting down server...")
 
-		// TODO: Need to get more comfortable with what this does
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		// Stop accepting connections and wait for in-flight requests, up to the timeout
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
 		defer cancel()
 
 		if err := httpServer.Shutdown(ctx); err != nil {
@@ -65,19 +128,87 @@ func main() {
 			)
 		}
 
+		if err := internalServer.Shutdown(ctx); err != nil {
+			log.Error("Error while shutting down internal server",
+				zap.Error(err),
+			)
+		}
+
 		srv.CloseConnections()
 	}()
 
+	go func() {
+		log.Info("Internal server start",
+			zap.Int("port", cfg.InternalPort),
+		)
+
+		if err := internalServer.Serve(internalListener); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Internal server failed",
+				zap.Error(err),
+			)
+		}
+	}()
+
+	// Answer ACME HTTP-01 challenges and redirect plain HTTP traffic to HTTPS
+	if certProvider != nil && certProvider.ChallengeHandler != nil {
+		challengeServer := &http.Server{
+			Addr:              ":" + strconv.Itoa(cfg.TLSChallengePort),
+			Handler:           certProvider.ChallengeHandler,
+			ReadHeaderTimeout: time.Duration(cfg.ServerReadTimeout) * time.Second,
+		}
+
+		go func() {
+			if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("ACME challenge server failed",
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+
 	log.Info("Server start",
 		zap.Int("port", cfg.Port),
 		zap.String("env", cfg.AppEnv),
+		zap.Bool("tls", cfg.TLSEnabled),
+		zap.Bool("socket_activation", listeners != nil),
 	)
 
-	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := serve(httpServer, publicListener, certProvider); err != nil && err != http.ErrServerClosed {
 		log.Fatal("Server failed",
 			zap.Error(err),
 		)
 	}
 
+	// Serve returns as soon as shutdown starts; wait for in-flight requests to finish
+	<-shutdownDone
 	log.Info("Server stopped")
 }
+
+// serverProtocols enables HTTP/2 for the public listener.
+// Over TLS, HTTP/2 is negotiated via ALPN. Without TLS, cleartext HTTP/2 (h2c)
+// can be enabled for deployments where a proxy terminates TLS and speaks h2c upstream.
+func serverProtocols(cfg *config.Config) *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(!cfg.TLSEnabled && cfg.HTTP2Cleartext)
+	return protocols
+}
+
+// listen returns the socket systemd passed under name, or opens one on addr
+func listen(activated map[string]net.Listener, name, addr string, reusePort bool) (net.Listener, error) {
+	if ln, ok := activated[name]; ok {
+		return ln, nil
+	}
+	return netutil.Listen(context.Background(), addr, reusePort)
+}
+
+// serve serves the public listener with or without TLS
+func serve(srv *http.Server, ln net.Listener, certProvider *certs.Provider) error {
+	if certProvider == nil {
+		return srv.Serve(ln)
+	}
+
+	// With autocert, certificates come from TLSConfig.GetCertificate and the file paths are empty
+	return srv.ServeTLS(ln, certProvider.CertFile, certProvider.KeyFile)
+}
@@ -0,0 +1,265 @@
+// Command mockgen writes a mock for every interface of a Go file, in the style the
+// service tests use: one func field per method, called when set, and an
+// ErrNotImplemented error (or zero values) when not.
+//
+// It runs from go:generate, see pkg/repository:
+//
+//	go run ./cmd/mockgen -source repository.go -destination mocks/repository.go -package mocks
+//
+// Generic interfaces are skipped; their mocks are written by hand. The mocks
+// package must declare notImplemented(method string) error.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	source := flag.String("source", "", "Go file whose interfaces are mocked")
+	destination := flag.String("destination", "", "file the mocks are written to")
+	pkg := flag.String("package", "mocks", "package of the mocks")
+	flag.Parse()
+
+	if *source == "" || *destination == "" {
+		fmt.Println("-source and -destination are required")
+		os.Exit(2)
+	}
+
+	out, err := generate(*source, *pkg)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*destination), 0o755); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*destination, out, 0o644); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+type method struct {
+	name    string
+	params  []field
+	results []string
+}
+
+type field struct {
+	name string
+	typ  string
+}
+
+func generate(source, pkg string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, source, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := make(map[string]*ast.InterfaceType)
+	var names []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok || ts.TypeParams != nil {
+				continue
+			}
+			interfaces[ts.Name.Name] = iface
+			names = append(names, ts.Name.Name)
+		}
+	}
+
+	var body bytes.Buffer
+	for _, name := range names {
+		methods, err := methodsOf(name, interfaces)
+		if err != nil {
+			return nil, err
+		}
+		writeMock(&body, file.Name.Name, name, methods)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by cmd/mockgen from %s. DO NOT EDIT.\n\n", filepath.Base(source))
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	// Standard library first, then the rest, as goimports groups them
+	var std, others bytes.Buffer
+	for _, imp := range file.Imports {
+		if !usesImport(body.String(), imp) {
+			continue
+		}
+		group := &others
+		if !strings.Contains(strings.SplitN(imp.Path.Value, "/", 2)[0], ".") {
+			group = &std
+		}
+		if imp.Name != nil {
+			fmt.Fprintf(group, "\t%s %s\n", imp.Name.Name, imp.Path.Value)
+		} else {
+			fmt.Fprintf(group, "\t%s\n", imp.Path.Value)
+		}
+	}
+	buf.WriteString("import (\n")
+	buf.Write(std.Bytes())
+	if std.Len() > 0 && others.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	buf.Write(others.Bytes())
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+// methodsOf lists the methods of the interface, including those of interfaces it embeds from the same file
+func methodsOf(name string, interfaces map[string]*ast.InterfaceType) ([]method, error) {
+	var methods []method
+	for _, m := range interfaces[name].Methods.List {
+		switch t := m.Type.(type) {
+		case *ast.FuncType:
+			methods = append(methods, method{
+				name:    m.Names[0].Name,
+				params:  fieldsOf(t.Params, "p"),
+				results: typesOf(t.Results),
+			})
+		case *ast.Ident:
+			if _, ok := interfaces[t.Name]; !ok {
+				return nil, fmt.Errorf("%s embeds %s, which isn't declared in the same file", name, t.Name)
+			}
+			embedded, err := methodsOf(t.Name, interfaces)
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, embedded...)
+		default:
+			return nil, fmt.Errorf("%s embeds %s, which can't be mocked", name, types.ExprString(m.Type))
+		}
+	}
+	return methods, nil
+}
+
+// fieldsOf names unnamed parameters prefix0, prefix1, ...
+func fieldsOf(list *ast.FieldList, prefix string) []field {
+	var fields []field
+	if list == nil {
+		return fields
+	}
+	for _, f := range list.List {
+		typ := types.ExprString(f.Type)
+		if len(f.Names) == 0 {
+			fields = append(fields, field{name: prefix + strconv.Itoa(len(fields)), typ: typ})
+		}
+		for _, n := range f.Names {
+			fields = append(fields, field{name: n.Name, typ: typ})
+		}
+	}
+	return fields
+}
+
+func typesOf(list *ast.FieldList) []string {
+	var typs []string
+	for _, f := range fieldsOf(list, "r") {
+		typs = append(typs, f.typ)
+	}
+	return typs
+}
+
+func writeMock(w *bytes.Buffer, sourcePkg, name string, methods []method) {
+	fmt.Fprintf(w, "\n// %s is a mock of %s.%s\n", name, sourcePkg, name)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, m := range methods {
+		fmt.Fprintf(w, "\t%sFunc func%s\n", m.name, m.signature())
+	}
+	w.WriteString("}\n")
+
+	for _, m := range methods {
+		fmt.Fprintf(w, "\nfunc (m *%s) %s%s {\n", name, m.name, m.signature())
+		fmt.Fprintf(w, "\tif m.%sFunc != nil {\n", m.name)
+		if len(m.results) > 0 {
+			fmt.Fprintf(w, "\t\treturn m.%sFunc(%s)\n", m.name, m.args())
+		} else {
+			fmt.Fprintf(w, "\t\tm.%sFunc(%s)\n", m.name, m.args())
+		}
+		w.WriteString("\t}\n")
+
+		var zeros []string
+		for i, typ := range m.results {
+			if typ == "error" {
+				zeros = append(zeros, fmt.Sprintf("notImplemented(%q)", name+"."+m.name))
+				continue
+			}
+			zero := "r" + strconv.Itoa(i)
+			fmt.Fprintf(w, "\tvar %s %s\n", zero, typ)
+			zeros = append(zeros, zero)
+		}
+		if len(zeros) > 0 {
+			fmt.Fprintf(w, "\treturn %s\n", strings.Join(zeros, ", "))
+		}
+		w.WriteString("}\n")
+	}
+}
+
+func (m method) signature() string {
+	var params []string
+	for _, p := range m.params {
+		params = append(params, p.name+" "+p.typ)
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	switch len(m.results) {
+	case 0:
+		return sig
+	case 1:
+		return sig + " " + m.results[0]
+	default:
+		return sig + " (" + strings.Join(m.results, ", ") + ")"
+	}
+}
+
+func (m method) args() string {
+	var args []string
+	for _, p := range m.params {
+		if strings.HasPrefix(p.typ, "...") {
+			args = append(args, p.name+"...")
+		} else {
+			args = append(args, p.name)
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+// majorVersion matches the /vN suffix of a module path, which isn't part of the package name
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// usesImport reports whether the generated code refers to the import, whose
+// package name is guessed from its path unless it's renamed
+func usesImport(code string, imp *ast.ImportSpec) bool {
+	importPath, _ := strconv.Unquote(imp.Path.Value)
+	name := path.Base(importPath)
+	if majorVersion.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if imp.Name != nil {
+		name = imp.Name.Name
+	}
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\.`).MatchString(code)
+}
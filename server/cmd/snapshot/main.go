@@ -0,0 +1,110 @@
+// Command snapshot checks a mapping snapshot (GET /api/v1/admin/snapshot) and loads
+// it into the redirect cache, so a restored deployment serves its links before the
+// database is back, or as it was when the snapshot was taken:
+//
+//	go run ./cmd/snapshot -file snapshot.csv
+//
+// Diffs are loaded the same way, after the snapshot they're from. Deleted mappings
+// are published like the primary's own invalidations, so edges drop them too.
+// With -verify the snapshot is only checked.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/config"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"github.com/styltsou/url-shortener/server/pkg/snapshot"
+	"go.uber.org/zap"
+)
+
+func main() {
+	path := flag.String("file", "", "snapshot to load")
+	verify := flag.Bool("verify", false, "only check the snapshot, loading nothing")
+	// Like the entries the primary caches itself
+	ttl := flag.Duration("ttl", 24*time.Hour, "how long the loaded entries stay in the cache")
+	flag.Parse()
+
+	if *path == "" {
+		fmt.Println("-file is required")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	if *verify {
+		header, entries, err := snapshot.ReadAll(f)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Snapshot OK: taken at %s, diff %t, %d entries\n",
+			header.TakenAt.Format(time.RFC3339), header.IsDiff(), len(entries))
+		return
+	}
+
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		fmt.Println(cfgErr.Error())
+		os.Exit(1)
+	}
+
+	log, logErr := logger.New(cfg.AppEnv)
+	if logErr != nil {
+		fmt.Println(logErr.Error())
+		os.Exit(1)
+	}
+
+	defer func() {
+		_ = log.Sync() // Flush logs on exit
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:         cfg.RedisURL,
+		Username:     cfg.RedisUsername,
+		Password:     cfg.RedisPassword,
+		DB:           cfg.RedisDB,
+		MaxRetries:   cfg.RedisMaxRetries,
+		DialTimeout:  time.Duration(cfg.RedisDialTimeout) * time.Second,
+		ReadTimeout:  time.Duration(cfg.RedisReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.RedisWriteTimeout) * time.Second,
+	})
+	defer rdb.Close()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatal("Failed to connect to Redis",
+			zap.Error(err),
+		)
+	}
+
+	header, applied, err := service.LoadSnapshot(ctx, cache.NewBroadcast(cache.NewRedis(rdb), rdb), f, *ttl)
+	if err != nil {
+		log.Fatal("Snapshot load failed",
+			zap.Error(err),
+			zap.Int("applied", applied),
+		)
+	}
+
+	log.Info("Snapshot loaded",
+		zap.Time("taken_at", header.TakenAt),
+		zap.Bool("diff", header.IsDiff()),
+		zap.Int("entries", applied),
+	)
+}
@@ -0,0 +1,12 @@
+CREATE TABLE clicks (
+	id BIGSERIAL PRIMARY KEY,
+	link_id UUID NOT NULL,
+	clicked_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	referrer TEXT DEFAULT NULL,
+	user_agent TEXT DEFAULT NULL,
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Index for "clicks of a link over a period"
+CREATE INDEX idx_clicks_link_id_clicked_at ON clicks(link_id, clicked_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_campaign_links_link_id;
+
+DROP TABLE IF EXISTS campaign_links;
+
+DROP INDEX IF EXISTS index_campaigns_user_id_name;
+
+DROP TABLE IF EXISTS campaigns;
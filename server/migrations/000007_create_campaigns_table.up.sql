@@ -0,0 +1,27 @@
+CREATE TABLE campaigns (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id TEXT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	budget_note TEXT DEFAULT NULL,
+	starts_at TIMESTAMP DEFAULT NULL,
+	ends_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NULL,
+
+	CONSTRAINT campaigns_period_check CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+);
+
+-- Unique index to ensure that a user cannot have duplicate campaigns
+CREATE UNIQUE INDEX index_campaigns_user_id_name ON campaigns(user_id, name);
+
+CREATE TABLE campaign_links (
+	campaign_id UUID NOT NULL,
+	link_id UUID NOT NULL,
+
+	PRIMARY KEY (campaign_id, link_id),
+	FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Index for "get all campaigns of a link"
+CREATE INDEX idx_campaign_links_link_id ON campaign_links(link_id);
@@ -0,0 +1,3 @@
+-- Private links only redirect for their owner (session) or holders of a signed access token
+ALTER TABLE links ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+	CONSTRAINT links_visibility_check CHECK (visibility IN ('public', 'private'));
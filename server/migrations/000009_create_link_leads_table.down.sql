@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS link_leads;
+
+ALTER TABLE links DROP COLUMN IF EXISTS capture_email;
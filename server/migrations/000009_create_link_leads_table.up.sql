@@ -0,0 +1,13 @@
+ALTER TABLE links ADD COLUMN capture_email BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE link_leads (
+	id BIGSERIAL PRIMARY KEY,
+	link_id UUID NOT NULL,
+	email VARCHAR(254) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- A visitor submitting the same address twice is one lead
+CREATE UNIQUE INDEX index_link_leads_link_id_email ON link_leads(link_id, email);
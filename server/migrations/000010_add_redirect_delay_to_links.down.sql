@@ -0,0 +1,3 @@
+ALTER TABLE links
+	DROP COLUMN IF EXISTS interstitial_message,
+	DROP COLUMN IF EXISTS redirect_delay;
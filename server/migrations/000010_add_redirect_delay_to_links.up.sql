@@ -0,0 +1,4 @@
+ALTER TABLE links
+	ADD COLUMN redirect_delay INTEGER NOT NULL DEFAULT 0
+		CONSTRAINT links_redirect_delay_check CHECK (redirect_delay BETWEEN 0 AND 30),
+	ADD COLUMN interstitial_message TEXT DEFAULT NULL;
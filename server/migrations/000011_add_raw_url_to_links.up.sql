@@ -0,0 +1,3 @@
+-- The URL exactly as submitted; original_url holds its canonical form.
+-- NULL for links created before URLs were normalized.
+ALTER TABLE links ADD COLUMN raw_url TEXT DEFAULT NULL;
@@ -0,0 +1 @@
+ALTER TABLE clicks DROP COLUMN IF EXISTS click_id;
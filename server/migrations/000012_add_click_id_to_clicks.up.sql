@@ -0,0 +1,4 @@
+-- Public identifier of a click, substituted into destination templates as {click_id}
+ALTER TABLE clicks ADD COLUMN click_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE UNIQUE INDEX idx_clicks_click_id ON clicks(click_id);
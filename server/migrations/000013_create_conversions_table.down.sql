@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS conversions;
+
+ALTER TABLE links DROP COLUMN IF EXISTS append_click_id;
@@ -0,0 +1,19 @@
+-- Opt-in: append ?click_id= to the destination on every redirect
+ALTER TABLE links ADD COLUMN append_click_id BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE conversions (
+	id BIGSERIAL PRIMARY KEY,
+	click_id UUID NOT NULL,
+	event VARCHAR(100) NOT NULL,
+	revenue_cents BIGINT DEFAULT NULL,
+	external_id VARCHAR(255) DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (click_id) REFERENCES clicks(click_id) ON DELETE CASCADE
+);
+
+-- Index for "conversions of a click"
+CREATE INDEX idx_conversions_click_id ON conversions(click_id);
+
+-- Advertisers retrying a postback with the same external id don't double count
+CREATE UNIQUE INDEX index_conversions_click_id_external_id ON conversions(click_id, external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stats_rollup_state;
+DROP TABLE IF EXISTS link_daily_stats;
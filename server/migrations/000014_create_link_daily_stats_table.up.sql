@@ -0,0 +1,17 @@
+-- Clicks per link per day, rolled up from clicks so stats don't scan raw events for past days
+CREATE TABLE link_daily_stats (
+	link_id UUID NOT NULL,
+	day DATE NOT NULL,
+	clicks BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (link_id, day),
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Single row: days before rolled_up_until are complete in link_daily_stats
+CREATE TABLE stats_rollup_state (
+	id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+	rolled_up_until DATE NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+-- Slack users linked to an account, so /shorten creates links on their behalf
+CREATE TABLE slack_accounts (
+	team_id VARCHAR(32) NOT NULL,
+	slack_user_id VARCHAR(32) NOT NULL,
+	user_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (team_id, slack_user_id)
+);
+
+-- Index for "Slack users linked to an account"
+CREATE INDEX idx_slack_accounts_user_id ON slack_accounts(user_id);
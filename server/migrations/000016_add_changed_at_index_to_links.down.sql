@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_links_user_id_changed_at;
@@ -0,0 +1,2 @@
+-- Index for polling a user's link changes (GET /links/changes), in (changed_at, id) order
+CREATE INDEX idx_links_user_id_changed_at ON links(user_id, (COALESCE(deleted_at, updated_at, created_at)), id);
@@ -0,0 +1,2 @@
+-- Optional title of the destination page, e.g. sent by the browser extension
+ALTER TABLE links ADD COLUMN title VARCHAR(255) DEFAULT NULL;
@@ -0,0 +1,19 @@
+-- Endpoints CMSes call on publish to shorten the article URL on the owner's behalf
+CREATE TABLE publish_hooks (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id TEXT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	-- SHA-256 of the hook token; the token itself is only shown once
+	token_hash VARCHAR(64) NOT NULL,
+	tag_id UUID DEFAULT NULL,
+	callback_url TEXT DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMP DEFAULT NULL,
+
+	FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_publish_hooks_token_hash ON publish_hooks(token_hash);
+
+-- Index for "publish hooks of a user"
+CREATE INDEX idx_publish_hooks_user_id ON publish_hooks(user_id);
@@ -0,0 +1,10 @@
+-- Link preview overrides: what social crawlers are shown instead of the destination's OpenGraph tags
+CREATE TABLE link_previews (
+	link_id UUID PRIMARY KEY,
+	title VARCHAR(200) DEFAULT NULL,
+	description VARCHAR(500) DEFAULT NULL,
+	image_url TEXT DEFAULT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- Shortcodes held for a link whose destination doesn't exist yet (e.g. printed on a QR code).
+-- Creating a link with the shortcode consumes the reservation.
+CREATE TABLE shortcode_reservations (
+	shortcode VARCHAR(20) PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Index for "reservations of a user", newest first
+CREATE INDEX idx_shortcode_reservations_user_id ON shortcode_reservations(user_id, created_at DESC);
@@ -0,0 +1,14 @@
+-- Discussion threads on links. mentions holds the @handles found in the body when it was posted.
+CREATE TABLE link_comments (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	link_id UUID NOT NULL,
+	user_id TEXT NOT NULL,
+	body VARCHAR(2000) NOT NULL,
+	mentions TEXT[] NOT NULL DEFAULT '{}',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Index for "get the thread of a link", oldest comment first
+CREATE INDEX idx_link_comments_link_id_created_at ON link_comments(link_id, created_at);
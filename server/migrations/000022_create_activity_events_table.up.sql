@@ -0,0 +1,13 @@
+-- Audit log of changes made by users, read back as their activity feed.
+-- target_id has no foreign key: events outlive the links and tags they describe.
+CREATE TABLE activity_events (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id TEXT NOT NULL,
+	action VARCHAR(50) NOT NULL,
+	target_id UUID NOT NULL,
+	summary TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Index for "get the activity feed of a user", newest first
+CREATE INDEX idx_activity_events_user_id_created_at ON activity_events(user_id, created_at DESC, id DESC);
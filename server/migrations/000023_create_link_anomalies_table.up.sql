@@ -0,0 +1,18 @@
+-- Hours in which a link's clicks spiked or dropped compared to its recent hours
+CREATE TABLE link_anomalies (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	link_id UUID NOT NULL,
+	kind VARCHAR(10) NOT NULL,
+	hour TIMESTAMP NOT NULL,
+	clicks BIGINT NOT NULL,
+	-- Mean hourly clicks over the window the hour was compared against
+	baseline DOUBLE PRECISION NOT NULL,
+	z_score DOUBLE PRECISION NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT link_anomalies_kind_check CHECK (kind IN ('spike', 'drop')),
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- One anomaly per link and hour, so rerunning the detector doesn't notify twice
+CREATE UNIQUE INDEX index_link_anomalies_link_id_hour ON link_anomalies(link_id, hour);
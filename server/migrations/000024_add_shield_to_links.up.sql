@@ -0,0 +1,2 @@
+-- Shielded links challenge suspected bots with a JavaScript check before redirecting
+ALTER TABLE links ADD COLUMN shield BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+-- What the destination learns about where the click came from: 'default' leaves it to the browser,
+-- 'no-referrer' hides it, 'origin' shows only the short domain
+ALTER TABLE links ADD COLUMN referrer_policy VARCHAR(20) NOT NULL DEFAULT 'default'
+    CHECK (referrer_policy IN ('default', 'no-referrer', 'origin'));
@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN IF EXISTS sunset_url;
+ALTER TABLE links DROP COLUMN IF EXISTS sunset_message;
+ALTER TABLE links DROP COLUMN IF EXISTS retired_at;
@@ -0,0 +1,5 @@
+-- Retired links serve a sunset page instead of redirecting. They can't be edited or deleted,
+-- so their shortcode is never freed for reuse.
+ALTER TABLE links ADD COLUMN retired_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE links ADD COLUMN sunset_message VARCHAR(500) DEFAULT NULL;
+ALTER TABLE links ADD COLUMN sunset_url TEXT DEFAULT NULL;
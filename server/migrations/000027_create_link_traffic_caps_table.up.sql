@@ -0,0 +1,20 @@
+-- Traffic caps: once a link's destination got daily_cap clicks today or total_cap clicks overall,
+-- its clicks go to overflow_url instead
+CREATE TABLE link_traffic_caps (
+	link_id UUID PRIMARY KEY,
+	daily_cap INTEGER DEFAULT NULL,
+	total_cap INTEGER DEFAULT NULL,
+	overflow_url TEXT NOT NULL,
+	-- Clicks sent to the destination, counted in Redis and synced here (see service.TrafficCapSync)
+	total_clicks BIGINT NOT NULL DEFAULT 0,
+	day DATE NOT NULL DEFAULT CURRENT_DATE,
+	day_clicks BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT link_traffic_caps_cap_check CHECK (
+		(daily_cap IS NOT NULL OR total_cap IS NOT NULL)
+		AND (daily_cap IS NULL OR daily_cap > 0)
+		AND (total_cap IS NULL OR total_cap > 0)
+	),
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
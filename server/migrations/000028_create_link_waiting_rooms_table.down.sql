@@ -0,0 +1 @@
+DROP TABLE IF EXISTS link_waiting_rooms;
@@ -0,0 +1,17 @@
+-- Waiting rooms: while active, the link serves a holding page that retries every retry_after
+-- seconds instead of sending visitors on to its overloaded destination
+CREATE TABLE link_waiting_rooms (
+	link_id UUID PRIMARY KEY,
+	active BOOLEAN NOT NULL DEFAULT false,
+	message VARCHAR(500) DEFAULT NULL,
+	retry_after INTEGER NOT NULL DEFAULT 30,
+	-- SHA-256 of the token the destination's monitoring opens and closes the room with
+	webhook_token_hash VARCHAR(64) NOT NULL,
+	activated_at TIMESTAMP DEFAULT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT link_waiting_rooms_retry_after_check CHECK (retry_after BETWEEN 5 AND 600),
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_link_waiting_rooms_webhook_token_hash ON link_waiting_rooms(webhook_token_hash);
@@ -0,0 +1,16 @@
+-- Endpoints that receive signed event deliveries
+CREATE TABLE webhooks (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id TEXT NOT NULL,
+	url TEXT NOT NULL,
+	-- Signing secret; kept in plain text as every delivery is signed with it
+	secret VARCHAR(64) NOT NULL,
+	-- The secret replaced by the last rotation, which keeps signing deliveries until it expires
+	previous_secret VARCHAR(64) DEFAULT NULL,
+	previous_secret_expires_at TIMESTAMPTZ DEFAULT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	secret_rotated_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Index for "webhooks of a user"
+CREATE INDEX idx_webhooks_user_id ON webhooks(user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS failing_since;
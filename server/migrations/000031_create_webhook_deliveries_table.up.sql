@@ -0,0 +1,27 @@
+-- Webhooks failing since failing_since are disabled once that's too long ago; a successful
+-- test or retry delivery clears both
+ALTER TABLE webhooks ADD COLUMN failing_since TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE webhooks ADD COLUMN disabled_at TIMESTAMPTZ DEFAULT NULL;
+
+-- Every attempt to deliver an event to a webhook, with the payload as sent
+CREATE TABLE webhook_deliveries (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	webhook_id UUID NOT NULL,
+	event_id TEXT NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	payload JSONB NOT NULL,
+	-- NULL when the endpoint couldn't be reached
+	status_code INTEGER DEFAULT NULL,
+	duration_ms INTEGER NOT NULL,
+	-- Why the delivery failed, NULL when it succeeded
+	error TEXT DEFAULT NULL,
+	-- The delivery this one retried
+	retry_of UUID DEFAULT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+	FOREIGN KEY (retry_of) REFERENCES webhook_deliveries(id) ON DELETE SET NULL
+);
+
+-- Index for "deliveries of a webhook, newest first"
+CREATE INDEX idx_webhook_deliveries_webhook_id_created_at ON webhook_deliveries(webhook_id, created_at DESC);
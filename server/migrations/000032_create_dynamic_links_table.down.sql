@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS link_destination_changes;
+DROP TABLE IF EXISTS dynamic_links;
@@ -0,0 +1,34 @@
+-- Dynamic links: links whose destination can be swapped, now or on a schedule, while their
+-- shortcode (and so every printed QR code pointing at it) stays the same. Locked ones refuse
+-- destination changes until they're unlocked.
+CREATE TABLE dynamic_links (
+	link_id UUID PRIMARY KEY,
+	locked BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Destination changes of dynamic links: applied ones are the link's history, the others are
+-- scheduled for scheduled_at
+CREATE TABLE link_destination_changes (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	link_id UUID NOT NULL,
+	user_id TEXT NOT NULL,
+	-- The destination as submitted and its canonical form, like links.raw_url and original_url
+	raw_url TEXT NOT NULL,
+	url TEXT NOT NULL,
+	-- The destination it replaced, set once applied
+	previous_url TEXT DEFAULT NULL,
+	scheduled_at TIMESTAMPTZ NOT NULL,
+	applied_at TIMESTAMPTZ DEFAULT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Index for "changes of a link, latest first"
+CREATE INDEX idx_link_destination_changes_link_id ON link_destination_changes(link_id, scheduled_at DESC);
+-- Index for the scheduler's "changes due"
+CREATE INDEX idx_link_destination_changes_pending ON link_destination_changes(scheduled_at) WHERE applied_at IS NULL;
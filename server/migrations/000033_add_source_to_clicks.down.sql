@@ -0,0 +1,3 @@
+ALTER TABLE link_daily_stats DROP COLUMN IF EXISTS qr_clicks;
+
+ALTER TABLE clicks DROP COLUMN IF EXISTS source;
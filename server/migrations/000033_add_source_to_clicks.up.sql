@@ -0,0 +1,4 @@
+-- How the visitor reached the link: 'qr' for scans of its QR code (short URLs carrying ?src=qr), 'web' otherwise
+ALTER TABLE clicks ADD COLUMN source TEXT NOT NULL DEFAULT 'web';
+
+ALTER TABLE link_daily_stats ADD COLUMN qr_clicks BIGINT NOT NULL DEFAULT 0;
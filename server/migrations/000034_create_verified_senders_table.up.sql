@@ -0,0 +1,15 @@
+-- Verified senders: users, or destination domains, an admin has vouched for. Links created by
+-- a verified user, or pointing at a verified domain (or one of its subdomains), show a
+-- "verified sender" badge on their interstitial page and in their public preview.
+CREATE TABLE verified_senders (
+	-- 'user' (value is a user ID) or 'domain' (value is a lowercase hostname)
+	kind TEXT NOT NULL CHECK (kind IN ('user', 'domain')),
+	value TEXT NOT NULL,
+	-- Shown next to the badge, e.g. the organization's name
+	name TEXT DEFAULT NULL,
+	-- The admin who verified the sender
+	verified_by TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (kind, value)
+);
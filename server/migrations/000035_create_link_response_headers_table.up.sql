@@ -0,0 +1,10 @@
+-- Extra headers sent with a link's redirect, e.g. X-Robots-Tag or Cache-Control.
+-- Only names on the allowlist in service.allowedResponseHeaders are stored.
+CREATE TABLE link_response_headers (
+	link_id UUID PRIMARY KEY,
+	-- Header name (canonical form) to value
+	headers JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
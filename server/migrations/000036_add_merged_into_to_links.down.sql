@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_links_merged_into;
+ALTER TABLE links DROP COLUMN IF EXISTS merged_into;
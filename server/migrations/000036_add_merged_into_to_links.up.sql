@@ -0,0 +1,6 @@
+-- A link merged into another becomes its alias: its shortcode redirects with the other
+-- link's destination and settings, and its clicks are counted on the other link.
+ALTER TABLE links ADD COLUMN merged_into UUID DEFAULT NULL REFERENCES links(id) ON DELETE CASCADE;
+
+-- Index for "aliases of a link"
+CREATE INDEX idx_links_merged_into ON links(merged_into) WHERE merged_into IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Outcome of the latest HTTPS upgrade check of each http:// link, the fix-up job's report.
+-- status is upgraded (the destination was switched to https://), available (https:// is
+-- reachable but the job only reports), unreachable or skipped (destination templates).
+CREATE TABLE link_https_upgrades (
+	link_id UUID PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	-- The destination that was checked and its https:// variant
+	from_url TEXT NOT NULL,
+	to_url TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_link_https_upgrades_user_id ON link_https_upgrades(user_id, checked_at DESC);
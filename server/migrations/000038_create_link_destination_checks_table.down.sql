@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS link_destination_alerts;
+DROP TABLE IF EXISTS link_destination_checks;
@@ -0,0 +1,34 @@
+-- The latest snapshot of each monitored link's destination page. state is ok,
+-- redirected (it lands on another host), not_found, parked or unreachable.
+CREATE TABLE link_destination_checks (
+	link_id UUID PRIMARY KEY,
+	-- The destination that was fetched; a changed destination starts a new baseline
+	url TEXT NOT NULL,
+	final_url TEXT,
+	status_code INTEGER,
+	title TEXT,
+	-- Hash of the page's canonical URL and title
+	fingerprint TEXT,
+	state VARCHAR(20) NOT NULL,
+	checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Destinations that started redirecting elsewhere, returning 404 or were parked
+CREATE TABLE link_destination_alerts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	link_id UUID NOT NULL,
+	user_id TEXT NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	url TEXT NOT NULL,
+	final_url TEXT,
+	status_code INTEGER,
+	title TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT link_destination_alerts_kind_check CHECK (kind IN ('redirected', 'not_found', 'parked')),
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_link_destination_alerts_user_id ON link_destination_alerts(user_id, created_at DESC);
@@ -0,0 +1,8 @@
+-- Links whose owner made their stats page (/{shortcode}+) public.
+-- The page of any other link can only be opened with a signed token.
+CREATE TABLE link_public_stats (
+	link_id UUID PRIMARY KEY,
+	enabled_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+-- Credentials for CI systems and integrations. A service account works on its
+-- owner's links like the owner would, but only through the routes its scopes allow.
+CREATE TABLE service_accounts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	-- The user whose links the account works on
+	owner_id TEXT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	-- What the account may do, e.g. links:create or stats:read
+	scopes TEXT[] NOT NULL,
+	-- SHA-256 of the account's token; the token itself is only shown once
+	token_hash VARCHAR(64) NOT NULL,
+	-- The admin who created the account
+	created_by TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX idx_service_accounts_token_hash ON service_accounts(token_hash);
+
+-- Index for "service accounts of a user"
+CREATE INDEX idx_service_accounts_owner_id ON service_accounts(owner_id);
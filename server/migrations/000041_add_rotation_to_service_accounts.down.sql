@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_service_accounts_previous_token_hash;
+ALTER TABLE service_accounts DROP COLUMN IF EXISTS token_rotated_at;
+ALTER TABLE service_accounts DROP COLUMN IF EXISTS previous_token_last_used_at;
+ALTER TABLE service_accounts DROP COLUMN IF EXISTS previous_token_expires_at;
+ALTER TABLE service_accounts DROP COLUMN IF EXISTS previous_token_hash;
+ALTER TABLE service_accounts DROP COLUMN IF EXISTS use_count;
+ALTER TABLE service_accounts DROP COLUMN IF EXISTS last_used_ip;
@@ -0,0 +1,11 @@
+-- Where the account was last used from, and how often, for audits
+ALTER TABLE service_accounts ADD COLUMN last_used_ip TEXT DEFAULT NULL;
+ALTER TABLE service_accounts ADD COLUMN use_count BIGINT NOT NULL DEFAULT 0;
+-- The token replaced by the last rotation, which keeps working until it expires
+ALTER TABLE service_accounts ADD COLUMN previous_token_hash VARCHAR(64) DEFAULT NULL;
+ALTER TABLE service_accounts ADD COLUMN previous_token_expires_at TIMESTAMPTZ DEFAULT NULL;
+-- Whether clients still use the previous token, so it's safe to revoke
+ALTER TABLE service_accounts ADD COLUMN previous_token_last_used_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE service_accounts ADD COLUMN token_rotated_at TIMESTAMPTZ DEFAULT NULL;
+
+CREATE INDEX idx_service_accounts_previous_token_hash ON service_accounts(previous_token_hash);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_links_http_destination;
+ALTER TABLE link_destination_changes DROP COLUMN IF EXISTS http_destination;
+ALTER TABLE links DROP COLUMN IF EXISTS http_destination;
@@ -0,0 +1,11 @@
+-- Whether the destination starts with http://, so the HTTPS upgrade job can find its candidates
+-- when destinations are encrypted at rest (see db.Encrypted) and a LIKE on them finds nothing.
+-- Set by the app on every write of a destination.
+ALTER TABLE links ADD COLUMN http_destination BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE link_destination_changes ADD COLUMN http_destination BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE links SET http_destination = TRUE WHERE original_url LIKE 'http://%';
+UPDATE link_destination_changes SET http_destination = TRUE WHERE url LIKE 'http://%';
+
+-- Index for "live http:// links", the HTTPS upgrade job's candidates
+CREATE INDEX idx_links_http_destination ON links(id) WHERE http_destination AND deleted_at IS NULL;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS activity_events_append_only ON activity_events;
+DROP FUNCTION IF EXISTS activity_events_append_only();
+DROP TRIGGER IF EXISTS activity_events_chain ON activity_events;
+DROP FUNCTION IF EXISTS activity_events_chain();
+ALTER TABLE activity_events DROP CONSTRAINT IF EXISTS activity_events_user_id_seq_key;
+ALTER TABLE activity_events DROP COLUMN IF EXISTS hash;
+ALTER TABLE activity_events DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE activity_events DROP COLUMN IF EXISTS seq;
+DROP FUNCTION IF EXISTS activity_event_hash(TEXT, BIGINT, TEXT, TEXT, UUID, TEXT, TIMESTAMPTZ);
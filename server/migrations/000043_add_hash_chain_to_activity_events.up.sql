@@ -0,0 +1,89 @@
+-- Activity events form one hash chain per user, so a user can prove their audit log wasn't
+-- altered: each event stores its place in the user's log (seq), the hash of the event before it
+-- and its own hash over both and its fields. Editing, deleting or reordering events breaks the
+-- chain; ActivityService.VerifyChain recomputes it. Triggers fill the three columns on insert
+-- and refuse updates, deletes and truncation.
+ALTER TABLE activity_events ADD COLUMN seq BIGINT;
+ALTER TABLE activity_events ADD COLUMN prev_hash VARCHAR(64);
+ALTER TABLE activity_events ADD COLUMN hash VARCHAR(64);
+
+-- SHA-256 of the event, hex-encoded; text fields are length-prefixed so none can pass for
+-- another. Must match activityEventHash in the service layer.
+CREATE FUNCTION activity_event_hash(prev_hash TEXT, seq BIGINT, user_id TEXT, action TEXT, target_id UUID, summary TEXT, created_at TIMESTAMPTZ)
+RETURNS TEXT LANGUAGE sql STABLE AS $$
+	SELECT encode(sha256(convert_to(
+		prev_hash || E'\n' ||
+		seq::TEXT || E'\n' ||
+		octet_length(user_id)::TEXT || ':' || user_id || E'\n' ||
+		octet_length(action)::TEXT || ':' || action || E'\n' ||
+		target_id::TEXT || E'\n' ||
+		to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"') || E'\n' ||
+		octet_length(summary)::TEXT || ':' || summary,
+		'UTF8')), 'hex')
+$$;
+
+-- Chain the events recorded so far, oldest first
+DO $$
+DECLARE
+	ev RECORD;
+	last_user TEXT := NULL;
+	last_seq BIGINT;
+	last_hash TEXT;
+BEGIN
+	FOR ev IN SELECT id, user_id, action, target_id, summary, created_at FROM activity_events ORDER BY user_id, created_at, id LOOP
+		IF last_user IS DISTINCT FROM ev.user_id THEN
+			last_user := ev.user_id;
+			last_seq := 0;
+			last_hash := repeat('0', 64);
+		END IF;
+
+		UPDATE activity_events
+		SET seq = last_seq + 1,
+			prev_hash = last_hash,
+			hash = activity_event_hash(last_hash, last_seq + 1, ev.user_id, ev.action, ev.target_id, ev.summary, ev.created_at)
+		WHERE id = ev.id
+		RETURNING seq, hash INTO last_seq, last_hash;
+	END LOOP;
+END
+$$;
+
+ALTER TABLE activity_events ALTER COLUMN seq SET NOT NULL;
+ALTER TABLE activity_events ALTER COLUMN prev_hash SET NOT NULL;
+ALTER TABLE activity_events ALTER COLUMN hash SET NOT NULL;
+ALTER TABLE activity_events ADD CONSTRAINT activity_events_user_id_seq_key UNIQUE (user_id, seq);
+
+CREATE FUNCTION activity_events_chain() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+	last_seq BIGINT;
+	last_hash TEXT;
+BEGIN
+	-- One insert per user at a time, so two events never take the same place in the chain.
+	-- The lock is held until commit, and the query below then sees the event inserted before.
+	PERFORM pg_advisory_xact_lock(hashtextextended('activity_events:' || NEW.user_id, 0));
+
+	SELECT seq, hash INTO last_seq, last_hash
+	FROM activity_events
+	WHERE user_id = NEW.user_id
+	ORDER BY seq DESC
+	LIMIT 1;
+
+	NEW.seq := COALESCE(last_seq, 0) + 1;
+	NEW.prev_hash := COALESCE(last_hash, repeat('0', 64));
+	NEW.hash := activity_event_hash(NEW.prev_hash, NEW.seq, NEW.user_id, NEW.action, NEW.target_id, NEW.summary, NEW.created_at);
+	RETURN NEW;
+END
+$$;
+
+CREATE TRIGGER activity_events_chain
+BEFORE INSERT ON activity_events
+FOR EACH ROW EXECUTE FUNCTION activity_events_chain();
+
+CREATE FUNCTION activity_events_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+	RAISE EXCEPTION 'activity_events is append-only';
+END
+$$;
+
+CREATE TRIGGER activity_events_append_only
+BEFORE UPDATE OR DELETE OR TRUNCATE ON activity_events
+FOR EACH STATEMENT EXECUTE FUNCTION activity_events_append_only();
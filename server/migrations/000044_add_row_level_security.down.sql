@@ -0,0 +1,22 @@
+DROP POLICY IF EXISTS activity_events_user_isolation ON activity_events;
+ALTER TABLE activity_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE activity_events DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhooks_user_isolation ON webhooks;
+ALTER TABLE webhooks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhooks DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS campaigns_user_isolation ON campaigns;
+ALTER TABLE campaigns NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE campaigns DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tags_user_isolation ON tags;
+ALTER TABLE tags NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tags DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS links_user_isolation ON links;
+ALTER TABLE links NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE links DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS link_shortcode_live(VARCHAR);
+DROP FUNCTION IF EXISTS app_user_id();
@@ -0,0 +1,43 @@
+-- Row-level security for the tables keyed by user_id, the second line of defense behind the
+-- WHERE user_id = $1 of every query. API requests run their queries with app.user_id set to the
+-- user (see db.RowSecurity, turned on with POSTGRES_ROW_LEVEL_SECURITY): other users' rows are
+-- then hidden and can't be written. Without app.user_id (redirects, background jobs, or the mode
+-- off) every row is visible, as before. FORCE applies the policies to the tables' owner too;
+-- superusers and BYPASSRLS roles still skip them, so the app must connect as neither.
+
+-- The user the queries are scoped to, NULL when they aren't
+CREATE FUNCTION app_user_id() RETURNS TEXT LANGUAGE sql STABLE AS $$
+	SELECT NULLIF(current_setting('app.user_id', true), '')
+$$;
+
+-- Whether a live link holds the shortcode, whoever owns it: creating a link or reserving a
+-- shortcode must see other users' links, which the policies hide from them
+CREATE FUNCTION link_shortcode_live(code VARCHAR) RETURNS BOOLEAN LANGUAGE sql STABLE
+SET app.user_id = '' AS $$
+	SELECT EXISTS (SELECT 1 FROM links WHERE shortcode = code AND deleted_at IS NULL)
+$$;
+
+ALTER TABLE links ENABLE ROW LEVEL SECURITY;
+ALTER TABLE links FORCE ROW LEVEL SECURITY;
+CREATE POLICY links_user_isolation ON links
+	USING (app_user_id() IS NULL OR user_id = app_user_id());
+
+ALTER TABLE tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tags FORCE ROW LEVEL SECURITY;
+CREATE POLICY tags_user_isolation ON tags
+	USING (app_user_id() IS NULL OR user_id = app_user_id());
+
+ALTER TABLE campaigns ENABLE ROW LEVEL SECURITY;
+ALTER TABLE campaigns FORCE ROW LEVEL SECURITY;
+CREATE POLICY campaigns_user_isolation ON campaigns
+	USING (app_user_id() IS NULL OR user_id = app_user_id());
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhooks_user_isolation ON webhooks
+	USING (app_user_id() IS NULL OR user_id = app_user_id());
+
+ALTER TABLE activity_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE activity_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY activity_events_user_isolation ON activity_events
+	USING (app_user_id() IS NULL OR user_id = app_user_id());
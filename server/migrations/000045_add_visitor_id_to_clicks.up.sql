@@ -0,0 +1,3 @@
+-- Salted hash of the client's address and user agent, rotating daily, to count unique visitors
+-- without storing who they are. NULL for clicks recorded before it was.
+ALTER TABLE clicks ADD COLUMN visitor_id TEXT;
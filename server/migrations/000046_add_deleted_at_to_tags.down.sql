@@ -0,0 +1,10 @@
+-- Tags in the trash are deleted for good; their links were detached already
+DELETE FROM tags WHERE deleted_at IS NOT NULL;
+
+DROP INDEX idx_tags_user_id_deleted_at;
+DROP INDEX index_tags_user_id_name;
+CREATE UNIQUE INDEX index_tags_user_id_name ON tags(user_id, name);
+
+ALTER TABLE tags
+	DROP COLUMN detached_link_ids,
+	DROP COLUMN deleted_at;
@@ -0,0 +1,11 @@
+-- Deleted tags go to the trash and can be restored. Their links are detached on delete and
+-- remembered in detached_link_ids, so a restored tag is put back on them.
+ALTER TABLE tags
+	ADD COLUMN deleted_at TIMESTAMPTZ,
+	ADD COLUMN detached_link_ids UUID[];
+
+-- Names only need to be unique among live tags
+DROP INDEX index_tags_user_id_name;
+CREATE UNIQUE INDEX index_tags_user_id_name ON tags(user_id, name) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_tags_user_id_deleted_at ON tags(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Running click count of each link, counted in Redis on every redirect and added here in batches (see service.ClickCounter)
+CREATE TABLE link_click_counts (
+	link_id UUID PRIMARY KEY,
+	clicks BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
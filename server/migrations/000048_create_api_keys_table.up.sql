@@ -0,0 +1,37 @@
+-- Keys users create for scripts and other programmatic clients, sent as X-API-Key instead of a
+-- session token. A key works on its owner's links, limited to the routes its scopes allow, like a
+-- service account.
+CREATE TABLE api_keys (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id TEXT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	-- SHA-256 of the key; the key itself is only shown once
+	key_hash VARCHAR(64) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMPTZ DEFAULT NULL,
+	-- Revoked keys stop working but stay listed, so their owner can tell what was revoked when
+	revoked_at TIMESTAMPTZ DEFAULT NULL,
+	-- The routes the key can call (see auth.Scopes)
+	scopes TEXT[] NOT NULL,
+	-- Where the key was last used from, and how often, for audits
+	last_used_ip TEXT DEFAULT NULL,
+	use_count BIGINT NOT NULL DEFAULT 0,
+	-- The key replaced by the last rotation, which keeps working until it expires
+	previous_key_hash VARCHAR(64) DEFAULT NULL,
+	previous_key_expires_at TIMESTAMPTZ DEFAULT NULL,
+	-- Whether clients still use the previous key, so it's safe to revoke
+	previous_key_last_used_at TIMESTAMPTZ DEFAULT NULL,
+	key_rotated_at TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX idx_api_keys_previous_key_hash ON api_keys(previous_key_hash);
+
+-- Index for "API keys of a user"
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+
+-- Keys are looked up by hash before the request's user is known, when app.user_id isn't set
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY api_keys_user_isolation ON api_keys
+	USING (app_user_id() IS NULL OR user_id = app_user_id());
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_next_retry_at;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS pending;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS next_retry_at;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS attempt;
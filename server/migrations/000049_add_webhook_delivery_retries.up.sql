@@ -0,0 +1,12 @@
+-- Failed deliveries of link events are retried automatically: attempt numbers the deliveries of an
+-- event to a webhook, and next_retry_at is when the next one is due (NULL once it's been retried,
+-- or when it won't be)
+ALTER TABLE webhook_deliveries ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE webhook_deliveries ADD COLUMN next_retry_at TIMESTAMPTZ DEFAULT NULL;
+-- Events are recorded as pending deliveries before they're sent, so one that isn't sent right
+-- away (its webhook has too many deliveries in flight, or the instance stops) is sent by the
+-- retry job once next_retry_at is due. Sending it fills in the row and clears pending.
+ALTER TABLE webhook_deliveries ADD COLUMN pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Index for "retries that are due"
+CREATE INDEX idx_webhook_deliveries_next_retry_at ON webhook_deliveries(next_retry_at) WHERE next_retry_at IS NOT NULL;
@@ -0,0 +1,119 @@
+// Package analytics stores click events in the backend selected by config:
+// Postgres for small installs, ClickHouse for large ones, or nowhere at all.
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// Backends selectable with ANALYTICS_BACKEND
+const (
+	BackendPostgres   = "postgres"
+	BackendClickHouse = "clickhouse"
+	BackendNone       = "none"
+)
+
+// Sources of a click
+const (
+	// A click on the short URL anywhere else
+	SourceWeb = "web"
+	// A scan of the link's QR code, whose short URL carries ?src=qr
+	SourceQR = "qr"
+)
+
+// Click is a single redirect event
+type Click struct {
+	ID        uuid.UUID
+	Shortcode string
+	// Zero when unknown, e.g. for clicks backfilled from before it was recorded
+	LinkID    uuid.UUID
+	Referrer  string
+	UserAgent string
+	// SourceWeb or SourceQR; empty means SourceWeb
+	Source string
+	// ISO 3166 code of the client's country, empty when unknown
+	Country string
+	// Tells the clicks of one visitor on one day apart from others', see Visitors; empty when unknown
+	VisitorID string
+	ClickedAt time.Time
+}
+
+// Store records click events
+type Store interface {
+	RecordClick(ctx context.Context, click Click) error
+}
+
+// BatchStore is a store that records many clicks at once, e.g. in a single insert
+type BatchStore interface {
+	RecordClicks(ctx context.Context, clicks []Click) error
+}
+
+// recordClicks records the clicks in one batch when the store takes batches, one by one otherwise
+func recordClicks(ctx context.Context, store Store, clicks []Click) error {
+	if batch, ok := store.(BatchStore); ok {
+		return batch.RecordClicks(ctx, clicks)
+	}
+	for _, click := range clicks {
+		if err := store.RecordClick(ctx, click); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Noop discards clicks, for deployments that don't collect analytics
+type Noop struct{}
+
+func (Noop) RecordClick(ctx context.Context, click Click) error {
+	return nil
+}
+
+type PostgresQueries interface {
+	RecordClick(ctx context.Context, arg db.RecordClickParams) error
+}
+
+/*
+PostgresStore keeps clicks in the clicks table next to the links. It's the
+only backend the stats, exports and conversions endpoints can read from.
+
+Clicks on unknown or deleted shortcodes are silently ignored, and clicked_at
+is the insert time rather than Click.ClickedAt. Click.Country isn't kept.
+*/
+type PostgresStore struct {
+	queries PostgresQueries
+}
+
+func NewPostgresStore(queries PostgresQueries) *PostgresStore {
+	return &PostgresStore{queries: queries}
+}
+
+func (s *PostgresStore) RecordClick(ctx context.Context, click Click) error {
+	return s.queries.RecordClick(ctx, db.RecordClickParams{
+		ClickID:   click.ID,
+		Shortcode: click.Shortcode,
+		Referrer:  nullableString(click.Referrer),
+		UserAgent: nullableString(click.UserAgent),
+		Source:    source(click.Source),
+		VisitorID: nullableString(click.VisitorID),
+	})
+}
+
+// source defaults an unset click source to SourceWeb
+func source(s string) string {
+	if s == "" {
+		return SourceWeb
+	}
+	return s
+}
+
+// nullableString maps an empty string to NULL
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
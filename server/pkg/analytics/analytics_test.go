@@ -0,0 +1,223 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+type mockPostgresQueries struct {
+	got db.RecordClickParams
+}
+
+func (m *mockPostgresQueries) RecordClick(ctx context.Context, arg db.RecordClickParams) error {
+	m.got = arg
+	return nil
+}
+
+func TestPostgresStore_RecordClick(t *testing.T) {
+	queries := &mockPostgresQueries{}
+	store := NewPostgresStore(queries)
+
+	click := Click{ID: uuid.New(), Shortcode: "abc123", UserAgent: "curl/8.0"}
+	if err := store.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+
+	if queries.got.ClickID != click.ID || queries.got.Shortcode != "abc123" {
+		t.Errorf("RecordClick() params = %+v", queries.got)
+	}
+	if queries.got.Referrer != nil {
+		t.Errorf("empty referrer stored as %q, want NULL", *queries.got.Referrer)
+	}
+	if queries.got.UserAgent == nil || *queries.got.UserAgent != "curl/8.0" {
+		t.Errorf("user agent = %v, want %q", queries.got.UserAgent, "curl/8.0")
+	}
+	if queries.got.Source != SourceWeb {
+		t.Errorf("source = %q, want %q for a click without one", queries.got.Source, SourceWeb)
+	}
+}
+
+func TestClickHouseStore_RecordClick(t *testing.T) {
+	var (
+		query string
+		user  string
+		body  []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		user = r.Header.Get("X-ClickHouse-User")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	store := NewClickHouseStore(ClickHouseOptions{URL: srv.URL + "/", Username: "analytics", Password: "secret"})
+	click := Click{
+		ID:        uuid.New(),
+		Shortcode: "abc123",
+		LinkID:    uuid.New(),
+		Referrer:  "https://example.com",
+		Country:   "GR",
+		ClickedAt: time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC),
+	}
+	if err := store.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+
+	if query != insertClickQuery {
+		t.Errorf("query = %q, want %q", query, insertClickQuery)
+	}
+	if user != "analytics" {
+		t.Errorf("X-ClickHouse-User = %q, want %q", user, "analytics")
+	}
+
+	var row clickHouseClick
+	if err := json.Unmarshal(body, &row); err != nil {
+		t.Fatalf("invalid JSONEachRow body %q: %v", body, err)
+	}
+	want := clickHouseClick{
+		ClickID:   click.ID.String(),
+		Shortcode: "abc123",
+		LinkID:    click.LinkID.String(),
+		Referrer:  "https://example.com",
+		Source:    SourceWeb,
+		Country:   "GR",
+		ClickedAt: "2026-03-10 14:30:00.000",
+	}
+	if row != want {
+		t.Errorf("row = %+v, want %+v", row, want)
+	}
+}
+
+func TestClickHouseStore_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. DB::Exception: Table default.clicks does not exist", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	store := NewClickHouseStore(ClickHouseOptions{URL: srv.URL})
+	err := store.RecordClick(context.Background(), Click{ID: uuid.New()})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("RecordClick() error = %v, want the ClickHouse error message", err)
+	}
+}
+
+// fakeClickHouse answers the statements Migrate and CheckSchema run
+type fakeClickHouse struct {
+	applied []string
+	tables  []string
+	ran     []string
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	query := string(body)
+
+	switch {
+	case strings.HasPrefix(query, "SELECT version FROM schema_migrations"):
+		_, _ = io.WriteString(w, strings.Join(f.applied, "\n"))
+	case strings.HasPrefix(query, "SELECT name FROM system.tables"):
+		_, _ = io.WriteString(w, strings.Join(f.tables, "\n"))
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		f.applied = append(f.applied, strings.Trim(strings.TrimPrefix(query, "INSERT INTO schema_migrations (version) VALUES ("), "')"))
+	default:
+		f.ran = append(f.ran, query)
+	}
+}
+
+func TestClickHouseStore_Migrate(t *testing.T) {
+	migrations, err := loadClickHouseMigrations()
+	if err != nil {
+		t.Fatalf("loadClickHouseMigrations() error = %v", err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("loadClickHouseMigrations() = %d migrations, want at least 2", len(migrations))
+	}
+
+	fake := &fakeClickHouse{applied: []string{migrations[0].Version}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store := NewClickHouseStore(ClickHouseOptions{URL: srv.URL, Database: "analytics"})
+
+	applied, err := store.Migrate(context.Background())
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(applied) != len(migrations)-1 || applied[0] != migrations[1].File {
+		t.Errorf("Migrate() applied %v, want every migration after %s", applied, migrations[0].File)
+	}
+	// schema_migrations is created before the pending migrations run
+	if len(fake.ran) != len(migrations) || !strings.Contains(fake.ran[0], "schema_migrations") {
+		t.Errorf("Migrate() ran %d statements: %v", len(fake.ran), fake.ran)
+	}
+
+	// A second run has nothing left to do
+	applied, err = store.Migrate(context.Background())
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Migrate() again = %v, %v, want nothing applied", applied, err)
+	}
+}
+
+func TestSchemaGate(t *testing.T) {
+	fake := &fakeClickHouse{tables: []string{"clicks"}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store := NewClickHouseStore(ClickHouseOptions{URL: srv.URL})
+	gate := NewSchemaGate(Noop{}, store.CheckSchema)
+
+	if err := gate.RecordClick(context.Background(), Click{ID: uuid.New()}); err != ErrSchemaNotReady {
+		t.Errorf("RecordClick() before the check = %v, want ErrSchemaNotReady", err)
+	}
+
+	err := gate.Check(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "clicks_daily_mv") {
+		t.Errorf("Check() = %v, want the missing view named", err)
+	}
+	if err := gate.RecordClick(context.Background(), Click{ID: uuid.New()}); err != ErrSchemaNotReady {
+		t.Errorf("RecordClick() with an incomplete schema = %v, want ErrSchemaNotReady", err)
+	}
+
+	fake.tables = requiredClickHouseObjects
+	if err := gate.Check(context.Background()); err != nil {
+		t.Fatalf("Check() = %v, want nil", err)
+	}
+	if err := gate.RecordClick(context.Background(), Click{ID: uuid.New()}); err != nil {
+		t.Errorf("RecordClick() once the schema exists = %v, want nil", err)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) RecordClick(ctx context.Context, click Click) error {
+	return errors.New("clickhouse unavailable")
+}
+
+func TestDoubleWrite_SecondaryFailureIsIgnored(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := &mockPostgresQueries{}
+	store := NewDoubleWrite(NewPostgresStore(queries), failingStore{}, log)
+
+	click := Click{ID: uuid.New(), Shortcode: "abc123"}
+	if err := store.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("RecordClick() error = %v, want the primary's result", err)
+	}
+	if queries.got.ClickID != click.ID {
+		t.Error("RecordClick() didn't write to the primary")
+	}
+}
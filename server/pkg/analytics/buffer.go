@@ -0,0 +1,154 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBufferBatchSize     = 1000
+	defaultBufferFlushInterval = time.Second
+	// Longest a batch can take to write before its clicks are given up on
+	bufferWriteTimeout = 10 * time.Second
+)
+
+// ErrBufferFull means the click was dropped because the buffer's queue was full
+var ErrBufferFull = errors.New("click buffer is full")
+
+// BufferOptions configures a Buffer
+type BufferOptions struct {
+	// Most clicks written at once; 1000 when 0
+	BatchSize int
+	// Longest a click waits for its batch to fill; 1s when 0
+	FlushInterval time.Duration
+	// Clicks held while a batch is written, past which new ones are dropped; 10 batches when 0
+	QueueSize int
+}
+
+/*
+Buffer queues clicks in memory and writes them to a store in the background, in
+batches of BatchSize or every FlushInterval, whichever comes first. RecordClick
+never waits for the store, so a slow or unreachable backend can't hold up
+redirects: when the queue is full the click is dropped and counted instead.
+
+A batch that fails to write is dropped too, after logging. Close writes what's
+still queued; clicks queued after it, or when the process dies, are lost.
+*/
+type Buffer struct {
+	store     Store
+	opts      BufferOptions
+	queue     chan Click
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    logger.Logger
+}
+
+// NewBuffer starts writing clicks queued with RecordClick to store, until Close
+func NewBuffer(store Store, opts BufferOptions, logger logger.Logger) *Buffer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBufferBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultBufferFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10 * opts.BatchSize
+	}
+
+	b := &Buffer{
+		store:  store,
+		opts:   opts,
+		queue:  make(chan Click, opts.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+	go b.run()
+	return b
+}
+
+// RecordClick queues the click, returning ErrBufferFull when the queue has no room for it
+func (b *Buffer) RecordClick(ctx context.Context, click Click) error {
+	select {
+	case b.queue <- click:
+		return nil
+	default:
+		metrics.ClicksDropped.Add("buffer_full", 1)
+		return ErrBufferFull
+	}
+}
+
+// Close writes the queued clicks and stops, or gives up waiting when ctx ends
+func (b *Buffer) Close(ctx context.Context) error {
+	b.closeOnce.Do(func() { close(b.stop) })
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Buffer) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Click, 0, b.opts.BatchSize)
+	add := func(click Click) {
+		batch = append(batch, click)
+		if len(batch) >= b.opts.BatchSize {
+			batch = b.write(batch)
+		}
+	}
+
+	for {
+		select {
+		case click := <-b.queue:
+			add(click)
+		case <-ticker.C:
+			batch = b.write(batch)
+		case <-b.stop:
+			for {
+				select {
+				case click := <-b.queue:
+					add(click)
+				default:
+					b.write(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// write records the batch and returns it emptied for reuse
+func (b *Buffer) write(batch []Click) []Click {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bufferWriteTimeout)
+	defer cancel()
+
+	if err := recordClicks(ctx, b.store, batch); err != nil {
+		metrics.ClicksDropped.Add("write_failed", int64(len(batch)))
+		b.logger.Warn("Failed to write clicks",
+			zap.Error(err),
+			zap.Int("clicks", len(batch)),
+		)
+	} else {
+		metrics.ClicksWritten.Add(int64(len(batch)))
+	}
+
+	return batch[:0]
+}
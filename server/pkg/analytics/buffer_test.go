@@ -0,0 +1,127 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+// batchRecorder records each batch written to it, blocking writes until release is closed
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]Click
+	release chan struct{}
+}
+
+func (r *batchRecorder) RecordClick(ctx context.Context, click Click) error {
+	return r.RecordClicks(ctx, []Click{click})
+}
+
+func (r *batchRecorder) RecordClicks(ctx context.Context, clicks []Click) error {
+	if r.release != nil {
+		<-r.release
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]Click(nil), clicks...))
+	return nil
+}
+
+func (r *batchRecorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestBuffer_WritesBatches(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &batchRecorder{}
+	buffer := NewBuffer(store, BufferOptions{BatchSize: 2, FlushInterval: time.Hour}, log)
+
+	for range 5 {
+		if err := buffer.RecordClick(context.Background(), Click{ID: uuid.New()}); err != nil {
+			t.Fatalf("RecordClick() error = %v", err)
+		}
+	}
+	if err := buffer.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	sizes := store.sizes()
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("batch sizes = %v, want [2 2 1]", sizes)
+	}
+}
+
+func TestBuffer_FlushesOnInterval(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &batchRecorder{}
+	buffer := NewBuffer(store, BufferOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond}, log)
+	defer buffer.Close(context.Background())
+
+	if err := buffer.RecordClick(context.Background(), Click{ID: uuid.New()}); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(store.sizes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("click wasn't written after the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBuffer_DropsWhenFull(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first click is taken off the queue and held up writing, the next fills it
+	store := &batchRecorder{release: make(chan struct{})}
+	buffer := NewBuffer(store, BufferOptions{BatchSize: 1, FlushInterval: time.Hour, QueueSize: 1}, log)
+
+	if err := buffer.RecordClick(context.Background(), Click{ID: uuid.New()}); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(buffer.queue) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("first click wasn't taken off the queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := buffer.RecordClick(context.Background(), Click{ID: uuid.New()}); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+
+	if err := buffer.RecordClick(context.Background(), Click{ID: uuid.New()}); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("RecordClick() on a full buffer error = %v, want %v", err, ErrBufferFull)
+	}
+
+	close(store.release)
+	if err := buffer.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if sizes := store.sizes(); len(sizes) != 2 {
+		t.Errorf("batch sizes = %v, want the two queued clicks written", sizes)
+	}
+}
@@ -0,0 +1,176 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	clickHouseTimeout = 5 * time.Second
+	// ClickHouse parses DateTime64 values in this layout from JSONEachRow
+	clickHouseTimeLayout = "2006-01-02 15:04:05.000"
+	// Queries run by the store itself only return short lists
+	maxClickHouseOutput = 1 << 20
+)
+
+const insertClickQuery = "INSERT INTO clicks (click_id, shortcode, link_id, referrer, user_agent, source, country, visitor_id, clicked_at) FORMAT JSONEachRow"
+
+// ClickHouseOptions configures a ClickHouseStore
+type ClickHouseOptions struct {
+	// Base URL of the HTTP interface, e.g. http://localhost:8123
+	URL      string
+	Username string
+	Password string
+	// Empty uses the user's default database
+	Database string
+}
+
+/*
+ClickHouseStore writes clicks to ClickHouse over its HTTP interface, one
+JSONEachRow insert per call. Redirects should reach it through a Buffer, so
+clicks are inserted in batches as ClickHouse prefers. Its tables and views are
+created by the migrations in migrations/ (see Migrate).
+*/
+type ClickHouseStore struct {
+	client *http.Client
+	opts   ClickHouseOptions
+}
+
+func NewClickHouseStore(opts ClickHouseOptions) *ClickHouseStore {
+	opts.URL = strings.TrimRight(opts.URL, "/")
+
+	return &ClickHouseStore{
+		client: &http.Client{Timeout: clickHouseTimeout},
+		opts:   opts,
+	}
+}
+
+type clickHouseClick struct {
+	ClickID   string `json:"click_id"`
+	Shortcode string `json:"shortcode"`
+	LinkID    string `json:"link_id"`
+	Referrer  string `json:"referrer"`
+	UserAgent string `json:"user_agent"`
+	Source    string `json:"source"`
+	Country   string `json:"country"`
+	VisitorID string `json:"visitor_id"`
+	ClickedAt string `json:"clicked_at"`
+}
+
+func (s *ClickHouseStore) RecordClick(ctx context.Context, click Click) error {
+	return s.RecordClicks(ctx, []Click{click})
+}
+
+// RecordClicks writes the clicks in a single insert, e.g. when backfilling history
+func (s *ClickHouseStore) RecordClicks(ctx context.Context, clicks []Click) error {
+	var rows bytes.Buffer
+	enc := json.NewEncoder(&rows)
+	for _, click := range clicks {
+		if err := enc.Encode(clickHouseClick{
+			ClickID:   click.ID.String(),
+			Shortcode: click.Shortcode,
+			LinkID:    click.LinkID.String(),
+			Referrer:  click.Referrer,
+			UserAgent: click.UserAgent,
+			Source:    source(click.Source),
+			Country:   click.Country,
+			VisitorID: click.VisitorID,
+			ClickedAt: click.ClickedAt.UTC().Format(clickHouseTimeLayout),
+		}); err != nil {
+			return fmt.Errorf("failed to encode click: %w", err)
+		}
+	}
+
+	params := url.Values{"query": {insertClickQuery}}
+	if s.opts.Database != "" {
+		params.Set("database", s.opts.Database)
+	}
+	// Tables from before a migration adding a column take the insert without it
+	params.Set("input_format_skip_unknown_fields", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.URL+"/?"+params.Encode(), &rows)
+	if err != nil {
+		return fmt.Errorf("failed to build ClickHouse request: %w", err)
+	}
+	req.Header.Set("X-ClickHouse-User", s.opts.Username)
+	req.Header.Set("X-ClickHouse-Key", s.opts.Password)
+
+	return s.do(req)
+}
+
+// Ping checks that ClickHouse is reachable
+func (s *ClickHouseStore) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.opts.URL+"/ping", nil)
+	if err != nil {
+		return fmt.Errorf("failed to build ClickHouse request: %w", err)
+	}
+
+	return s.do(req)
+}
+
+// query runs a single statement and returns its output
+func (s *ClickHouseStore) query(ctx context.Context, query string) ([]byte, error) {
+	return s.queryParams(ctx, query, url.Values{})
+}
+
+// queryParams is query with URL parameters: settings, and the values of {name:Type} placeholders as param_<name>
+func (s *ClickHouseStore) queryParams(ctx context.Context, query string, params url.Values) ([]byte, error) {
+	if s.opts.Database != "" {
+		params.Set("database", s.opts.Database)
+	}
+	endpoint := s.opts.URL + "/"
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ClickHouse request: %w", err)
+	}
+	req.Header.Set("X-ClickHouse-User", s.opts.Username)
+	req.Header.Set("X-ClickHouse-Key", s.opts.Password)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ClickHouse request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, clickHouseError(resp)
+	}
+
+	out, err := io.ReadAll(io.LimitReader(resp.Body, maxClickHouseOutput))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ClickHouse response: %w", err)
+	}
+	return out, nil
+}
+
+func (s *ClickHouseStore) do(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ClickHouse request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return clickHouseError(resp)
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func clickHouseError(resp *http.Response) error {
+	// ClickHouse explains the failure in the body
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("ClickHouse returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
@@ -0,0 +1,119 @@
+package analytics
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+/*
+ClickHouse migrations are numbered like the Postgres ones, but forward-only and
+one statement per file, since the HTTP interface runs a single statement per
+request. Applied versions are recorded in ClickHouse's own schema_migrations
+table, shared with scripts/migrate-clickhouse.sh.
+*/
+//go:embed migrations/*.up.sql
+var clickHouseMigrations embed.FS
+
+// Tables and views clicks are written to; writes stay disabled until they all exist
+var requiredClickHouseObjects = []string{"clicks", "clicks_daily", "clicks_daily_mv"}
+
+const createSchemaMigrationsQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    String,
+    applied_at DateTime DEFAULT now()
+) ENGINE = MergeTree ORDER BY version`
+
+type clickHouseMigration struct {
+	Version string
+	File    string
+	Query   string
+}
+
+// loadClickHouseMigrations returns the embedded migrations in version order
+func loadClickHouseMigrations() ([]clickHouseMigration, error) {
+	files, err := fs.Glob(clickHouseMigrations, "migrations/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+
+	migrations := make([]clickHouseMigration, 0, len(files))
+	for _, file := range files {
+		query, err := fs.ReadFile(clickHouseMigrations, file)
+		if err != nil {
+			return nil, err
+		}
+
+		// e.g. 000001_create_clicks_table.up.sql -> 000001
+		name := path.Base(file)
+		version, _, _ := strings.Cut(name, "_")
+		migrations = append(migrations, clickHouseMigration{
+			Version: version,
+			File:    name,
+			Query:   string(query),
+		})
+	}
+	return migrations, nil
+}
+
+// Migrate applies the pending ClickHouse migrations in version order and returns the files it applied
+func (s *ClickHouseStore) Migrate(ctx context.Context) ([]string, error) {
+	migrations, err := loadClickHouseMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ClickHouse migrations: %w", err)
+	}
+
+	if _, err := s.query(ctx, createSchemaMigrationsQuery); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	out, err := s.query(ctx, "SELECT version FROM schema_migrations FORMAT TabSeparated")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	applied := strings.Fields(string(out))
+
+	var ran []string
+	for _, m := range migrations {
+		if slices.Contains(applied, m.Version) {
+			continue
+		}
+
+		if _, err := s.query(ctx, m.Query); err != nil {
+			return ran, fmt.Errorf("migration %s failed: %w", m.File, err)
+		}
+		// Versions are the digits before the first underscore of the file name
+		if _, err := s.query(ctx, "INSERT INTO schema_migrations (version) VALUES ('"+m.Version+"')"); err != nil {
+			return ran, fmt.Errorf("failed to record migration %s: %w", m.File, err)
+		}
+		ran = append(ran, m.File)
+	}
+
+	return ran, nil
+}
+
+// CheckSchema verifies that the tables and views clicks are written to exist.
+// It returns ErrSchemaNotReady naming the missing ones.
+func (s *ClickHouseStore) CheckSchema(ctx context.Context) error {
+	out, err := s.query(ctx, "SELECT name FROM system.tables WHERE database = currentDatabase() FORMAT TabSeparated")
+	if err != nil {
+		return fmt.Errorf("failed to list ClickHouse tables: %w", err)
+	}
+	existing := strings.Fields(string(out))
+
+	var missing []string
+	for _, name := range requiredClickHouseObjects {
+		if !slices.Contains(existing, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrSchemaNotReady, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
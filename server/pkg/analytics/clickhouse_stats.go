@@ -0,0 +1,166 @@
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+clickHouseLinkFilter selects the clicks of a link. Clicks recorded before link IDs
+were have the zero UUID and are matched by shortcode instead, so they still count
+unless the link's shortcode changed since.
+*/
+const clickHouseLinkFilter = `(link_id = {link_id:UUID} OR (link_id = toUUID('00000000-0000-0000-0000-000000000000') AND shortcode = {shortcode:String}))
+  AND clicked_at >= {from:DateTime64(3, 'UTC')}
+  AND clicked_at < {to:DateTime64(3, 'UTC')}`
+
+const clickHouseUniqueVisitors = `uniqExactIf(visitor_id, visitor_id != '')`
+
+// Functions truncating clicked_at to the start of its bucket, by granularity
+var clickHouseBucketFuncs = map[string]string{
+	GranularityDay:  "toStartOfDay",
+	GranularityHour: "toStartOfHour",
+}
+
+type clickHouseSummary struct {
+	Clicks         int64 `json:"clicks"`
+	UniqueVisitors int64 `json:"unique_visitors"`
+}
+
+type clickHouseReferrer struct {
+	Referrer string `json:"referrer"`
+	Clicks   int64  `json:"clicks"`
+}
+
+type clickHouseBucket struct {
+	// Unix seconds
+	Start          int64 `json:"start"`
+	Clicks         int64 `json:"clicks"`
+	UniqueVisitors int64 `json:"unique_visitors"`
+}
+
+// LinkStats aggregates the link's clicks with one query per part of the stats
+func (s *ClickHouseStore) LinkStats(ctx context.Context, q LinkStatsQuery) (*LinkStats, error) {
+	bucketFunc, ok := clickHouseBucketFuncs[q.Granularity]
+	if !ok {
+		return nil, fmt.Errorf("unknown granularity %q", q.Granularity)
+	}
+
+	params := func() url.Values {
+		return url.Values{
+			"param_link_id":   {q.LinkID.String()},
+			"param_shortcode": {q.Shortcode},
+			"param_from":      {q.From.UTC().Format(clickHouseTimeLayout)},
+			"param_to":        {q.To.UTC().Format(clickHouseTimeLayout)},
+			// Counts come back as JSON numbers rather than strings
+			"output_format_json_quote_64bit_integers": {"0"},
+		}
+	}
+
+	summaries, err := queryRows[clickHouseSummary](ctx, s, `SELECT count() AS clicks, `+clickHouseUniqueVisitors+` AS unique_visitors
+FROM clicks
+WHERE `+clickHouseLinkFilter+`
+FORMAT JSONEachRow`, params())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link click summary: %w", err)
+	}
+
+	referrers, err := queryRows[clickHouseReferrer](ctx, s, `SELECT replaceRegexpOne(lower(domain(referrer)), '^www\\.', '') AS referrer, count() AS clicks
+FROM clicks
+WHERE `+clickHouseLinkFilter+`
+GROUP BY referrer
+ORDER BY clicks DESC, referrer
+LIMIT `+strconv.Itoa(q.TopReferrers)+`
+FORMAT JSONEachRow`, params())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link top referrers: %w", err)
+	}
+
+	bucketParams := params()
+	bucketParams.Set("param_tz", q.Location.String())
+	buckets, err := queryRows[clickHouseBucket](ctx, s, `SELECT toUnixTimestamp(`+bucketFunc+`(clicked_at, {tz:String})) AS start, count() AS clicks, `+clickHouseUniqueVisitors+` AS unique_visitors
+FROM clicks
+WHERE `+clickHouseLinkFilter+`
+GROUP BY start
+ORDER BY start
+FORMAT JSONEachRow`, bucketParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link clicks over time: %w", err)
+	}
+
+	stats := &LinkStats{
+		TopReferrers: make([]ReferrerClicks, 0, len(referrers)),
+		Clicks:       make([]BucketClicks, 0, len(buckets)),
+	}
+	if len(summaries) > 0 {
+		stats.TotalClicks = summaries[0].Clicks
+		stats.UniqueVisitors = summaries[0].UniqueVisitors
+	}
+	for _, r := range referrers {
+		stats.TopReferrers = append(stats.TopReferrers, ReferrerClicks{Referrer: r.Referrer, Clicks: r.Clicks})
+	}
+	for _, b := range buckets {
+		stats.Clicks = append(stats.Clicks, BucketClicks{Start: time.Unix(b.Start, 0).UTC(), Clicks: b.Clicks, UniqueVisitors: b.UniqueVisitors})
+	}
+	return stats, nil
+}
+
+type clickHouseLinkClicks struct {
+	LinkID uuid.UUID `json:"link_id"`
+	Clicks int64     `json:"clicks"`
+}
+
+// ClicksByLink counts the clicks on each link. Clicks recorded before link IDs were
+// can't be told apart by link, so they're left out.
+func (s *ClickHouseStore) ClicksByLink(ctx context.Context, from, to time.Time) ([]LinkClicks, error) {
+	rows, err := queryRows[clickHouseLinkClicks](ctx, s, `SELECT link_id, count() AS clicks
+FROM clicks
+WHERE link_id != toUUID('00000000-0000-0000-0000-000000000000')
+  AND clicked_at >= {from:DateTime64(3, 'UTC')}
+  AND clicked_at < {to:DateTime64(3, 'UTC')}
+GROUP BY link_id
+FORMAT JSONEachRow`, url.Values{
+		"param_from": {from.UTC().Format(clickHouseTimeLayout)},
+		"param_to":   {to.UTC().Format(clickHouseTimeLayout)},
+		"output_format_json_quote_64bit_integers": {"0"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clicks by link: %w", err)
+	}
+
+	clicks := make([]LinkClicks, len(rows))
+	for i, row := range rows {
+		clicks[i] = LinkClicks(row)
+	}
+	return clicks, nil
+}
+
+// queryRows runs a JSONEachRow query and decodes a T from each line of its output
+func queryRows[T any](ctx context.Context, s *ClickHouseStore, query string, params url.Values) ([]T, error) {
+	out, err := s.queryParams(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []T
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var row T
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, fmt.Errorf("failed to decode ClickHouse row: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
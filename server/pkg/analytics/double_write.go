@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"context"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+/*
+DoubleWrite records every click in a primary and a secondary store, to move to a
+new backend without a gap: the secondary receives new clicks while history is
+backfilled into it (see cmd/backfill), and the backend is switched once both
+hold the same data.
+
+The primary's result is the click's result; secondary failures are only logged,
+so the new backend can't break click recording on the current one.
+*/
+type DoubleWrite struct {
+	primary   Store
+	secondary Store
+	logger    logger.Logger
+}
+
+func NewDoubleWrite(primary Store, secondary Store, logger logger.Logger) *DoubleWrite {
+	return &DoubleWrite{
+		primary:   primary,
+		secondary: secondary,
+		logger:    logger,
+	}
+}
+
+func (d *DoubleWrite) RecordClick(ctx context.Context, click Click) error {
+	if err := d.secondary.RecordClick(ctx, click); err != nil {
+		d.logger.Warn("Failed to double-write click",
+			zap.Error(err),
+			zap.String("shortcode", click.Shortcode),
+		)
+	}
+
+	return d.primary.RecordClick(ctx, click)
+}
@@ -0,0 +1,143 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// Granularities of the clicks over time of LinkStats
+const (
+	GranularityDay  = "day"
+	GranularityHour = "hour"
+)
+
+// LinkStatsQuery selects the clicks LinkStats aggregates
+type LinkStatsQuery struct {
+	LinkID uuid.UUID
+	// Matches the ClickHouse clicks recorded before their link ID was
+	Shortcode string
+	// Clicks in [From, To)
+	From time.Time
+	To   time.Time
+	// GranularityDay or GranularityHour; buckets start at midnight or on the hour in Location
+	Granularity string
+	Location    *time.Location
+	// Most referrers returned
+	TopReferrers int
+}
+
+// LinkStats are the aggregated clicks of a link over a period
+type LinkStats struct {
+	TotalClicks int64
+	// Distinct visitor IDs (see Visitors): a visitor coming back on another day counts again,
+	// and clicks recorded without an ID aren't counted
+	UniqueVisitors int64
+	// Most clicks first
+	TopReferrers []ReferrerClicks
+	// Buckets with clicks, oldest first
+	Clicks []BucketClicks
+}
+
+type ReferrerClicks struct {
+	// Host of the referring page without a leading www.; empty for clicks without a referrer
+	Referrer string
+	Clicks   int64
+}
+
+type BucketClicks struct {
+	Start          time.Time
+	Clicks         int64
+	UniqueVisitors int64
+}
+
+// StatsReader aggregates the clicks a backend holds
+type StatsReader interface {
+	LinkStats(ctx context.Context, q LinkStatsQuery) (*LinkStats, error)
+}
+
+// LinkClicks is the number of clicks on a link over a period
+type LinkClicks struct {
+	LinkID uuid.UUID
+	Clicks int64
+}
+
+// ClicksByLinkReader counts the clicks on each link over a period
+type ClicksByLinkReader interface {
+	// ClicksByLink counts the clicks in [from, to) of the links with clicks, in no particular order
+	ClicksByLink(ctx context.Context, from, to time.Time) ([]LinkClicks, error)
+}
+
+// LinkStats reports no clicks: Noop keeps none
+func (Noop) LinkStats(ctx context.Context, q LinkStatsQuery) (*LinkStats, error) {
+	return &LinkStats{}, nil
+}
+
+type PostgresStatsQueries interface {
+	GetLinkClickSummary(ctx context.Context, arg db.GetLinkClickSummaryParams) (db.GetLinkClickSummaryRow, error)
+	GetLinkClicksOverTime(ctx context.Context, arg db.GetLinkClicksOverTimeParams) ([]db.GetLinkClicksOverTimeRow, error)
+	GetLinkTopReferrers(ctx context.Context, arg db.GetLinkTopReferrersParams) ([]db.GetLinkTopReferrersRow, error)
+}
+
+// PostgresStats aggregates the raw clicks PostgresStore keeps. The daily rollups
+// don't hold visitors or referrers, so they aren't used.
+type PostgresStats struct {
+	queries PostgresStatsQueries
+}
+
+func NewPostgresStats(queries PostgresStatsQueries) *PostgresStats {
+	return &PostgresStats{queries: queries}
+}
+
+func (s *PostgresStats) LinkStats(ctx context.Context, q LinkStatsQuery) (*LinkStats, error) {
+	from := pgtype.Timestamptz{Time: q.From, Valid: true}
+	to := pgtype.Timestamptz{Time: q.To, Valid: true}
+
+	summary, err := s.queries.GetLinkClickSummary(ctx, db.GetLinkClickSummaryParams{
+		LinkID:   q.LinkID,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link click summary: %w", err)
+	}
+
+	referrers, err := s.queries.GetLinkTopReferrers(ctx, db.GetLinkTopReferrersParams{
+		LinkID:     q.LinkID,
+		FromTime:   from,
+		ToTime:     to,
+		MaxResults: int32(q.TopReferrers),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link top referrers: %w", err)
+	}
+
+	buckets, err := s.queries.GetLinkClicksOverTime(ctx, db.GetLinkClicksOverTimeParams{
+		Bucket:   q.Granularity,
+		TimeZone: q.Location.String(),
+		LinkID:   q.LinkID,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link clicks over time: %w", err)
+	}
+
+	stats := &LinkStats{
+		TotalClicks:    summary.Clicks,
+		UniqueVisitors: summary.UniqueVisitors,
+		TopReferrers:   make([]ReferrerClicks, 0, len(referrers)),
+		Clicks:         make([]BucketClicks, 0, len(buckets)),
+	}
+	for _, r := range referrers {
+		stats.TopReferrers = append(stats.TopReferrers, ReferrerClicks{Referrer: r.Referrer, Clicks: r.Clicks})
+	}
+	for _, b := range buckets {
+		stats.Clicks = append(stats.Clicks, BucketClicks{Start: b.BucketStart.Time, Clicks: b.Clicks, UniqueVisitors: b.UniqueVisitors})
+	}
+	return stats, nil
+}
@@ -0,0 +1,171 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func TestVisitors_ID(t *testing.T) {
+	v := NewVisitors("0123456789abcdef0123456789abcdef")
+	addr := netip.MustParseAddr("203.0.113.7")
+	morning := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	id := v.ID(addr, "curl/8.0", morning)
+	if len(id) != 32 {
+		t.Fatalf("ID() = %q, want 32 hex characters", id)
+	}
+	if got := v.ID(addr, "curl/8.0", morning.Add(10*time.Hour)); got != id {
+		t.Errorf("ID() later the same day = %q, want %q", got, id)
+	}
+	if got := v.ID(addr, "curl/8.0", morning.Add(24*time.Hour)); got == id {
+		t.Error("ID() the next day is the same, want a new one")
+	}
+	if got := v.ID(addr, "Mozilla/5.0", morning); got == id {
+		t.Error("ID() with another user agent is the same, want a different one")
+	}
+	if got := NewVisitors("another secret of at least 32 bytes").ID(addr, "curl/8.0", morning); got == id {
+		t.Error("ID() with another secret is the same, want a different one")
+	}
+	if got := v.ID(netip.Addr{}, "curl/8.0", morning); got != "" {
+		t.Errorf("ID() without an address = %q, want empty", got)
+	}
+
+	var none *Visitors
+	if got := none.ID(addr, "curl/8.0", morning); got != "" {
+		t.Errorf("nil Visitors.ID() = %q, want empty", got)
+	}
+}
+
+type mockPostgresStatsQueries struct {
+	gotOverTime db.GetLinkClicksOverTimeParams
+}
+
+func (m *mockPostgresStatsQueries) GetLinkClickSummary(ctx context.Context, arg db.GetLinkClickSummaryParams) (db.GetLinkClickSummaryRow, error) {
+	return db.GetLinkClickSummaryRow{Clicks: 7, UniqueVisitors: 4}, nil
+}
+
+func (m *mockPostgresStatsQueries) GetLinkClicksOverTime(ctx context.Context, arg db.GetLinkClicksOverTimeParams) ([]db.GetLinkClicksOverTimeRow, error) {
+	m.gotOverTime = arg
+	start := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	return []db.GetLinkClicksOverTimeRow{{BucketStart: pgtype.Timestamptz{Time: start, Valid: true}, Clicks: 7, UniqueVisitors: 4}}, nil
+}
+
+func (m *mockPostgresStatsQueries) GetLinkTopReferrers(ctx context.Context, arg db.GetLinkTopReferrersParams) ([]db.GetLinkTopReferrersRow, error) {
+	return []db.GetLinkTopReferrersRow{{Referrer: "example.com", Clicks: 5}, {Referrer: "", Clicks: 2}}, nil
+}
+
+func TestPostgresStats_LinkStats(t *testing.T) {
+	queries := &mockPostgresStatsQueries{}
+	athens, _ := time.LoadLocation("Europe/Athens")
+
+	stats, err := NewPostgresStats(queries).LinkStats(context.Background(), LinkStatsQuery{
+		LinkID:       uuid.New(),
+		From:         time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		Granularity:  GranularityHour,
+		Location:     athens,
+		TopReferrers: 10,
+	})
+	if err != nil {
+		t.Fatalf("LinkStats() error = %v", err)
+	}
+
+	if queries.gotOverTime.Bucket != "hour" || queries.gotOverTime.TimeZone != "Europe/Athens" {
+		t.Errorf("GetLinkClicksOverTime() params = %+v, want hour buckets in Europe/Athens", queries.gotOverTime)
+	}
+	if stats.TotalClicks != 7 || stats.UniqueVisitors != 4 || len(stats.TopReferrers) != 2 || len(stats.Clicks) != 1 {
+		t.Errorf("LinkStats() = %+v", stats)
+	}
+}
+
+// fakeClickHouseStats answers the queries of ClickHouseStore.LinkStats
+type fakeClickHouseStats struct {
+	params []string
+}
+
+func (f *fakeClickHouseStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	query := string(body)
+	f.params = append(f.params, r.URL.Query().Get("param_tz"))
+
+	switch {
+	case strings.Contains(query, "GROUP BY referrer"):
+		_, _ = io.WriteString(w, "{\"referrer\":\"example.com\",\"clicks\":5}\n{\"referrer\":\"\",\"clicks\":2}\n")
+	case strings.Contains(query, "GROUP BY start"):
+		_, _ = io.WriteString(w, "{\"start\":1773093600,\"clicks\":7,\"unique_visitors\":4}\n")
+	default:
+		_, _ = io.WriteString(w, "{\"clicks\":7,\"unique_visitors\":4}\n")
+	}
+}
+
+func TestClickHouseStore_LinkStats(t *testing.T) {
+	fake := &fakeClickHouseStats{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	athens, _ := time.LoadLocation("Europe/Athens")
+
+	stats, err := NewClickHouseStore(ClickHouseOptions{URL: srv.URL}).LinkStats(context.Background(), LinkStatsQuery{
+		LinkID:       uuid.New(),
+		Shortcode:    "abc123",
+		From:         time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		Granularity:  GranularityDay,
+		Location:     athens,
+		TopReferrers: 10,
+	})
+	if err != nil {
+		t.Fatalf("LinkStats() error = %v", err)
+	}
+
+	if stats.TotalClicks != 7 || stats.UniqueVisitors != 4 {
+		t.Errorf("LinkStats() totals = %d clicks, %d visitors, want 7, 4", stats.TotalClicks, stats.UniqueVisitors)
+	}
+	if len(stats.TopReferrers) != 2 || stats.TopReferrers[0] != (ReferrerClicks{Referrer: "example.com", Clicks: 5}) {
+		t.Errorf("LinkStats() top referrers = %+v", stats.TopReferrers)
+	}
+	wantStart := time.Date(2026, 3, 10, 0, 0, 0, 0, athens)
+	if len(stats.Clicks) != 1 || !stats.Clicks[0].Start.Equal(wantStart) {
+		t.Errorf("LinkStats() clicks = %+v, want one bucket at %s", stats.Clicks, wantStart)
+	}
+	if fake.params[len(fake.params)-1] != "Europe/Athens" {
+		t.Errorf("buckets queried in %q, want Europe/Athens", fake.params[len(fake.params)-1])
+	}
+
+	if _, err := NewSchemaGate(NewClickHouseStore(ClickHouseOptions{URL: srv.URL}), nil).LinkStats(context.Background(), LinkStatsQuery{}); err != ErrSchemaNotReady {
+		t.Errorf("SchemaGate.LinkStats() before the check = %v, want ErrSchemaNotReady", err)
+	}
+}
+
+func TestClickHouseStore_ClicksByLink(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	var from string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from = r.URL.Query().Get("param_from")
+		_, _ = io.WriteString(w, "{\"link_id\":\""+a.String()+"\",\"clicks\":5}\n{\"link_id\":\""+b.String()+"\",\"clicks\":2}\n")
+	}))
+	defer srv.Close()
+
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	clicks, err := NewClickHouseStore(ClickHouseOptions{URL: srv.URL}).ClicksByLink(context.Background(), day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("ClicksByLink() error = %v", err)
+	}
+
+	want := []LinkClicks{{LinkID: a, Clicks: 5}, {LinkID: b, Clicks: 2}}
+	if len(clicks) != len(want) || clicks[0] != want[0] || clicks[1] != want[1] {
+		t.Errorf("ClicksByLink() = %+v, want %+v", clicks, want)
+	}
+	if from != "2026-03-10 00:00:00.000" {
+		t.Errorf("clicks queried from %q, want 2026-03-10 00:00:00.000", from)
+	}
+}
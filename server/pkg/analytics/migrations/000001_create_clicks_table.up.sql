@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS clicks (
+    click_id   UUID,
+    shortcode  String,
+    referrer   String,
+    user_agent String,
+    clicked_at DateTime64(3, 'UTC')
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(clicked_at)
+ORDER BY (shortcode, clicked_at)
@@ -0,0 +1,8 @@
+-- Clicks per shortcode and day, filled by clicks_daily_mv
+CREATE TABLE IF NOT EXISTS clicks_daily (
+    shortcode String,
+    day       Date,
+    clicks    UInt64
+) ENGINE = SummingMergeTree
+PARTITION BY toYYYYMM(day)
+ORDER BY (shortcode, day)
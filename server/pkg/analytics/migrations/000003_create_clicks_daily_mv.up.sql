@@ -0,0 +1,7 @@
+CREATE MATERIALIZED VIEW IF NOT EXISTS clicks_daily_mv TO clicks_daily AS
+SELECT
+    shortcode,
+    toDate(clicked_at) AS day,
+    count() AS clicks
+FROM clicks
+GROUP BY shortcode, day
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSchemaNotReady means the backend is missing tables or views clicks are written to
+var ErrSchemaNotReady = errors.New("analytics schema is not ready")
+
+/*
+SchemaGate holds back writes to a store until its schema check passes, so clicks
+aren't sent to tables that don't exist yet. The gate starts closed; Check opens
+it, and closes it again only when the schema turns out incomplete. Other check
+failures (e.g. the backend being unreachable) leave it as it is.
+
+Check is meant to double as the backend's readiness check: a schema created
+after startup, e.g. with the migrate command, enables writes at the next probe.
+*/
+type SchemaGate struct {
+	store Store
+	check func(ctx context.Context) error
+	ready atomic.Bool
+}
+
+func NewSchemaGate(store Store, check func(ctx context.Context) error) *SchemaGate {
+	return &SchemaGate{store: store, check: check}
+}
+
+// Check runs the schema check and opens or closes the gate with its result
+func (g *SchemaGate) Check(ctx context.Context) error {
+	err := g.check(ctx)
+	switch {
+	case err == nil:
+		g.ready.Store(true)
+	case errors.Is(err, ErrSchemaNotReady):
+		g.ready.Store(false)
+	}
+	return err
+}
+
+func (g *SchemaGate) RecordClick(ctx context.Context, click Click) error {
+	if !g.ready.Load() {
+		return ErrSchemaNotReady
+	}
+	return g.store.RecordClick(ctx, click)
+}
+
+// LinkStats reads from the store when it's a StatsReader and its schema is ready
+func (g *SchemaGate) LinkStats(ctx context.Context, q LinkStatsQuery) (*LinkStats, error) {
+	reader, ok := g.store.(StatsReader)
+	if !ok {
+		return nil, fmt.Errorf("%T can't read stats", g.store)
+	}
+	if !g.ready.Load() {
+		return nil, ErrSchemaNotReady
+	}
+	return reader.LinkStats(ctx, q)
+}
+
+// ClicksByLink reads from the store when it's a ClicksByLinkReader and its schema is ready
+func (g *SchemaGate) ClicksByLink(ctx context.Context, from, to time.Time) ([]LinkClicks, error) {
+	reader, ok := g.store.(ClicksByLinkReader)
+	if !ok {
+		return nil, fmt.Errorf("%T can't count clicks by link", g.store)
+	}
+	if !g.ready.Load() {
+		return nil, ErrSchemaNotReady
+	}
+	return reader.ClicksByLink(ctx, from, to)
+}
+
+func (g *SchemaGate) RecordClicks(ctx context.Context, clicks []Click) error {
+	if !g.ready.Load() {
+		return ErrSchemaNotReady
+	}
+	return recordClicks(ctx, g.store, clicks)
+}
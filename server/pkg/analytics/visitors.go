@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"time"
+)
+
+/*
+Visitors derives the visitor IDs of clicks, to count unique visitors without
+storing who they are: an ID is an HMAC of the client's address and user agent
+and the UTC day of the click. The same visitor gets a new ID every day, so
+unique visitors are counted per day, and the address can't be recovered from
+the ID without the secret.
+*/
+type Visitors struct {
+	secret []byte
+}
+
+/*
+NewVisitors returns a Visitors hashing with secret. Without one it hashes with
+a random key, so a visitor's ID changes when the process restarts and differs
+between instances: set a secret when running more than one.
+*/
+func NewVisitors(secret string) *Visitors {
+	if secret == "" {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		return &Visitors{secret: key}
+	}
+	return &Visitors{secret: []byte(secret)}
+}
+
+// ID returns the visitor ID of a click at t; empty when the address is unknown or v is nil
+func (v *Visitors) ID(addr netip.Addr, userAgent string, t time.Time) string {
+	if v == nil || !addr.IsValid() {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(t.UTC().Format(time.DateOnly)))
+	mac.Write([]byte{0})
+	mac.Write(addr.Unmap().AsSlice())
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	// 128 bits are plenty to tell a day's visitors apart
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
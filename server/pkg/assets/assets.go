@@ -0,0 +1,68 @@
+/*
+Package assets stores the files the service generates for users to download
+later, like background clicks exports, on local disk or in an S3-compatible
+bucket.
+
+Keys are slash-separated paths like exports/<id>.csv; the first segment groups
+one kind of asset, so Cleanup can expire each kind on its own schedule.
+*/
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// Upper bound for storing or reading one asset from a bucket
+	TransferTimeout = 30 * time.Minute
+	// Largest asset read back from a bucket
+	MaxBytes = 4 << 30
+)
+
+// ErrNotFound is returned when there is no asset with the key
+var ErrNotFound = errors.New("asset not found")
+
+// Object describes a stored asset
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Store keeps generated assets
+type Store interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds when there is no asset with the key
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// DownloadURL returns a URL the asset can be downloaded from without credentials
+	// until ttl has passed, saved as filename, or "" when the store can't link to
+	// assets and they have to be served through the API.
+	DownloadURL(ctx context.Context, key, filename string, ttl time.Duration) (string, error)
+}
+
+// Cleanup deletes the assets under prefix last modified before cutoff, returning how many it deleted
+func Cleanup(ctx context.Context, store Store, prefix string, cutoff time.Time) (int, error) {
+	objects, err := store.List(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list assets: %w", err)
+	}
+
+	deleted := 0
+	for _, o := range objects {
+		if !o.LastModified.Before(cutoff) {
+			continue
+		}
+		if err := store.Delete(ctx, o.Key); err != nil {
+			return deleted, fmt.Errorf("failed to delete asset %s: %w", o.Key, err)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,93 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewDisk(filepath.Join(dir, "assets"))
+	if err != nil {
+		t.Fatalf("NewDisk() error = %v", err)
+	}
+
+	if err := store.Put(ctx, "exports/a.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	f, err := store.Open(ctx, "exports/a.csv")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	content, _ := io.ReadAll(f)
+	f.Close()
+	if string(content) != "a,b\n" {
+		t.Errorf("Open() = %q, want what was put", content)
+	}
+
+	objects, err := store.List(ctx, "exports/")
+	if err != nil || len(objects) != 1 || objects[0].Key != "exports/a.csv" || objects[0].Size != 4 {
+		t.Errorf("List() = %+v, %v, want the export", objects, err)
+	}
+	if url, err := store.DownloadURL(ctx, "exports/a.csv", "a.csv", time.Minute); url != "" || err != nil {
+		t.Errorf("DownloadURL() = %q, %v, want none", url, err)
+	}
+
+	if err := store.Delete(ctx, "exports/a.csv"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Open(ctx, "exports/a.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "exports/a.csv"); err != nil {
+		t.Errorf("Delete() of a missing asset error = %v", err)
+	}
+
+	// Keys can't reach outside the directory
+	os.WriteFile(filepath.Join(dir, "secret"), []byte("x"), 0o600)
+	for _, key := range []string{"../secret", "/etc/passwd", "exports/../../secret", "", "exports/.tmp-1"} {
+		if _, err := store.Open(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) error = %v, want the key refused", key, err)
+		}
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDisk() error = %v", err)
+	}
+
+	now := time.Now()
+	for key, age := range map[string]time.Duration{
+		"exports/old.csv": 48 * time.Hour,
+		"exports/new.csv": time.Hour,
+		"other/old.csv":   48 * time.Hour,
+	} {
+		if err := store.Put(ctx, key, strings.NewReader("x")); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		p, _ := store.path(key)
+		os.Chtimes(p, now.Add(-age), now.Add(-age))
+	}
+
+	deleted, err := Cleanup(ctx, store, "exports/", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Cleanup() = %d, want the old export deleted", deleted)
+	}
+	objects, _ := store.List(ctx, "")
+	if len(objects) != 2 || objects[0].Key != "exports/new.csv" || objects[1].Key != "other/old.csv" {
+		t.Errorf("List() = %+v, want the new export and the other prefix kept", objects)
+	}
+}
@@ -0,0 +1,57 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/objectstore"
+)
+
+// S3 keeps assets in an S3-compatible bucket (AWS S3, MinIO, R2, ...) under a
+// key prefix, and links to them with presigned URLs.
+type S3 struct {
+	client *objectstore.Client
+	prefix string
+}
+
+// NewS3 stores assets under prefix (e.g. "assets/") in the client's bucket
+func NewS3(client *objectstore.Client, prefix string) *S3 {
+	return &S3{client: client, prefix: prefix}
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	return s.client.Put(ctx, s.prefix+key, body)
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := s.client.Get(ctx, s.prefix+key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return body, err
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, s.prefix+key)
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	found, err := s.client.List(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]Object, len(found))
+	for i, o := range found {
+		objects[i] = Object{Key: strings.TrimPrefix(o.Key, s.prefix), Size: o.Size, LastModified: o.LastModified}
+	}
+	return objects, nil
+}
+
+func (s *S3) DownloadURL(ctx context.Context, key, filename string, ttl time.Duration) (string, error) {
+	return s.client.PresignGet(s.prefix+key, ttl, filename)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/clerk/clerk-sdk-go/v2/jwks"
+	"github.com/clerk/clerk-sdk-go/v2/jwt"
+)
+
+// How long a signing key fetched from Clerk is trusted before it's fetched again
+const clerkKeyTTL = time.Hour
+
+// Clerk verifies Clerk session tokens against the instance's signing keys
+type Clerk struct {
+	jwks *jwks.Client
+
+	mu   sync.Mutex
+	keys map[string]clerkKey
+}
+
+type clerkKey struct {
+	jwk       *clerk.JSONWebKey
+	fetchedAt time.Time
+}
+
+func NewClerk(secretKey string) *Clerk {
+	return &Clerk{
+		jwks: jwks.NewClient(&clerk.ClientConfig{BackendConfig: clerk.BackendConfig{Key: &secretKey}}),
+		keys: make(map[string]clerkKey),
+	}
+}
+
+func (c *Clerk) Authenticate(ctx context.Context, token string) (string, error) {
+	decoded, err := jwt.Decode(ctx, &jwt.DecodeParams{Token: token})
+	if err != nil {
+		return "", invalidToken(err)
+	}
+
+	jwk, err := c.key(ctx, decoded.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := jwt.Verify(ctx, &jwt.VerifyParams{Token: token, JWK: jwk})
+	if err != nil {
+		return "", invalidToken(err)
+	}
+	return claims.Subject, nil
+}
+
+// key returns the signing key with the ID, from the cache while it's fresh
+func (c *Clerk) key(ctx context.Context, keyID string) (*clerk.JSONWebKey, error) {
+	if keyID == "" {
+		return nil, invalidToken(fmt.Errorf("missing kid header"))
+	}
+
+	c.mu.Lock()
+	cached, ok := c.keys[keyID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < clerkKeyTTL {
+		return cached.jwk, nil
+	}
+
+	jwk, err := jwt.GetJSONWebKey(ctx, &jwt.GetJSONWebKeyParams{KeyID: keyID, JWKSClient: c.jwks})
+	if err != nil {
+		return nil, invalidToken(err)
+	}
+
+	c.mu.Lock()
+	c.keys[keyID] = clerkKey{jwk: jwk, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return jwk, nil
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	// Clock skew tolerated on exp, nbf and iat
+	oidcLeeway = time.Minute
+	// Least time between two fetches of the issuer's keys, so tokens with unknown key IDs can't hammer it
+	oidcMinRefresh = time.Minute
+	// Claim holding the user ID when OIDCOptions.UserIDClaim is empty
+	defaultUserIDClaim = "sub"
+)
+
+// Algorithms accepted on tokens; symmetric ones would let anyone holding a public key sign tokens
+var oidcAlgorithms = map[string]bool{
+	string(jose.RS256): true, string(jose.RS384): true, string(jose.RS512): true,
+	string(jose.PS256): true, string(jose.PS384): true, string(jose.PS512): true,
+	string(jose.ES256): true, string(jose.ES384): true, string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// OIDCOptions configures an OpenID Connect issuer
+type OIDCOptions struct {
+	// Issuer URL; its keys are found through {issuer}/.well-known/openid-configuration
+	Issuer string
+	// Audience tokens must be issued for, usually the client ID of the app
+	Audience string
+	// Claim holding the user ID; empty means "sub"
+	UserIDClaim string
+}
+
+/*
+OIDC verifies ID and access tokens signed by an OpenID Connect issuer, e.g.
+Keycloak, Authentik, Auth0 or Google. Its discovery document and keys are
+fetched on first use; the keys are fetched again when a token names one that
+isn't known yet, so key rotation needs no restart.
+*/
+type OIDC struct {
+	opts   OIDCOptions
+	client *http.Client
+
+	mu          sync.Mutex
+	jwksURI     string
+	keys        jose.JSONWebKeySet
+	refreshedAt time.Time
+}
+
+func NewOIDC(opts OIDCOptions, client *http.Client) *OIDC {
+	opts.Issuer = strings.TrimRight(opts.Issuer, "/")
+	if opts.UserIDClaim == "" {
+		opts.UserIDClaim = defaultUserIDClaim
+	}
+	return &OIDC{opts: opts, client: client}
+}
+
+func (o *OIDC) Authenticate(ctx context.Context, token string) (string, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", invalidToken(err)
+	}
+	if len(parsed.Headers) != 1 {
+		return "", invalidToken(errors.New("expected one signature"))
+	}
+	header := parsed.Headers[0]
+	if !oidcAlgorithms[header.Algorithm] {
+		return "", invalidToken(fmt.Errorf("algorithm %q not allowed", header.Algorithm))
+	}
+
+	key, err := o.key(ctx, header.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	var claims jwt.Claims
+	var all map[string]any
+	if err := parsed.Claims(key, &claims, &all); err != nil {
+		return "", invalidToken(err)
+	}
+	if err := claims.ValidateWithLeeway(jwt.Expected{
+		Issuer:   o.opts.Issuer,
+		Audience: jwt.Audience{o.opts.Audience},
+		Time:     time.Now(),
+	}, oidcLeeway); err != nil {
+		return "", invalidToken(err)
+	}
+	// Tokens without an expiry would stay valid forever
+	if claims.Expiry == nil {
+		return "", invalidToken(errors.New("missing exp claim"))
+	}
+
+	userID, _ := all[o.opts.UserIDClaim].(string)
+	if userID == "" {
+		return "", invalidToken(fmt.Errorf("missing %s claim", o.opts.UserIDClaim))
+	}
+	return userID, nil
+}
+
+// key returns the issuer's signing key with the ID, fetching the keys again when it isn't known
+func (o *OIDC) key(ctx context.Context, keyID string) (*jose.JSONWebKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if key := o.findKey(keyID); key != nil {
+		return key, nil
+	}
+	if !o.refreshedAt.IsZero() && time.Since(o.refreshedAt) < oidcMinRefresh {
+		return nil, invalidToken(fmt.Errorf("unknown key %q", keyID))
+	}
+
+	if err := o.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key := o.findKey(keyID); key != nil {
+		return key, nil
+	}
+	return nil, invalidToken(fmt.Errorf("unknown key %q", keyID))
+}
+
+// findKey returns the signing key with the ID; with no ID, the only signing key. Callers hold mu.
+func (o *OIDC) findKey(keyID string) *jose.JSONWebKey {
+	var found *jose.JSONWebKey
+	for i, key := range o.keys.Keys {
+		if key.Use == "enc" || (keyID != "" && key.KeyID != keyID) {
+			continue
+		}
+		if found != nil {
+			// Without an ID, several keys leave it ambiguous
+			return nil
+		}
+		found = &o.keys.Keys[i]
+	}
+	return found
+}
+
+// refresh fetches the issuer's keys, discovering where they are on first use. Callers hold mu.
+func (o *OIDC) refresh(ctx context.Context) error {
+	o.refreshedAt = time.Now()
+
+	if o.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := o.getJSON(ctx, o.opts.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("failed to discover OIDC issuer: %w", err)
+		}
+		if strings.TrimRight(discovery.Issuer, "/") != o.opts.Issuer {
+			return fmt.Errorf("OIDC discovery document is for issuer %q, want %q", discovery.Issuer, o.opts.Issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("OIDC discovery document has no jwks_uri")
+		}
+		o.jwksURI = discovery.JWKSURI
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := o.getJSON(ctx, o.jwksURI, &keys); err != nil {
+		return fmt.Errorf("failed to get OIDC keys: %w", err)
+	}
+	o.keys = keys
+	return nil
+}
+
+func (o *OIDC) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+// fakeIdP is an OpenID Connect issuer serving its discovery document and keys
+type fakeIdP struct {
+	*httptest.Server
+	keys       map[string]*rsa.PrivateKey
+	published  []string
+	jwksServed atomic.Int32
+}
+
+func newFakeIdP(t *testing.T, keyIDs ...string) *fakeIdP {
+	t.Helper()
+
+	idp := &fakeIdP{keys: make(map[string]*rsa.PrivateKey), published: keyIDs}
+	for _, kid := range append(keyIDs, "rotated") {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		idp.keys[kid] = key
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   idp.URL,
+			"jwks_uri": idp.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksServed.Add(1)
+		var set jose.JSONWebKeySet
+		for _, kid := range idp.published {
+			set.Keys = append(set.Keys, jose.JSONWebKey{Key: &idp.keys[kid].PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"})
+		}
+		json.NewEncoder(w).Encode(set)
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+
+	return idp
+}
+
+// sign returns a token with the claims, signed with the key
+func (idp *fakeIdP) sign(t *testing.T, kid string, claims any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: idp.keys[kid], KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestOIDC_Authenticate(t *testing.T) {
+	idp := newFakeIdP(t, "key-1")
+	now := time.Now()
+
+	valid := jwt.Claims{
+		Issuer:   idp.URL,
+		Subject:  "user_123",
+		Audience: jwt.Audience{"url-shortener"},
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+		IssuedAt: jwt.NewNumericDate(now),
+	}
+	with := func(change func(c *jwt.Claims)) jwt.Claims {
+		c := valid
+		change(&c)
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantUserID string
+		wantErr    error
+	}{
+		{name: "valid token", token: idp.sign(t, "key-1", valid), wantUserID: "user_123"},
+		{name: "other issuer", token: idp.sign(t, "key-1", with(func(c *jwt.Claims) { c.Issuer = "https://evil.example.com" })), wantErr: ErrInvalidToken},
+		{name: "other audience", token: idp.sign(t, "key-1", with(func(c *jwt.Claims) { c.Audience = jwt.Audience{"other-app"} })), wantErr: ErrInvalidToken},
+		{name: "expired", token: idp.sign(t, "key-1", with(func(c *jwt.Claims) { c.Expiry = jwt.NewNumericDate(now.Add(-time.Hour)) })), wantErr: ErrInvalidToken},
+		{name: "no expiry", token: idp.sign(t, "key-1", with(func(c *jwt.Claims) { c.Expiry = nil })), wantErr: ErrInvalidToken},
+		{name: "no subject", token: idp.sign(t, "key-1", with(func(c *jwt.Claims) { c.Subject = "" })), wantErr: ErrInvalidToken},
+		{name: "unpublished key", token: idp.sign(t, "rotated", valid), wantErr: ErrInvalidToken},
+		{name: "malformed", token: "not-a-jwt", wantErr: ErrInvalidToken},
+	}
+
+	provider := NewOIDC(OIDCOptions{Issuer: idp.URL + "/", Audience: "url-shortener"}, idp.Client())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, err := provider.Authenticate(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if userID != tt.wantUserID {
+				t.Errorf("Authenticate() = %q, want %q", userID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestOIDC_UserIDClaim(t *testing.T) {
+	idp := newFakeIdP(t, "key-1")
+	token := idp.sign(t, "key-1", map[string]any{
+		"iss":                idp.URL,
+		"sub":                "8d1c0a4e",
+		"aud":                "url-shortener",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "alex",
+	})
+
+	provider := NewOIDC(OIDCOptions{Issuer: idp.URL, Audience: "url-shortener", UserIDClaim: "preferred_username"}, idp.Client())
+
+	userID, err := provider.Authenticate(context.Background(), token)
+	if err != nil || userID != "alex" {
+		t.Errorf("Authenticate() = %q, %v, want alex, nil", userID, err)
+	}
+}
+
+// Keys the issuer rotates in are picked up without a restart, but unknown keys don't refetch on every request
+func TestOIDC_KeyRotation(t *testing.T) {
+	idp := newFakeIdP(t, "key-1")
+	provider := NewOIDC(OIDCOptions{Issuer: idp.URL, Audience: "url-shortener"}, idp.Client())
+
+	claims := jwt.Claims{
+		Issuer:   idp.URL,
+		Subject:  "user_123",
+		Audience: jwt.Audience{"url-shortener"},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+
+	if _, err := provider.Authenticate(context.Background(), idp.sign(t, "key-1", claims)); err != nil {
+		t.Fatalf("Authenticate() with the published key error = %v", err)
+	}
+	if _, err := provider.Authenticate(context.Background(), idp.sign(t, "rotated", claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Authenticate() with an unpublished key error = %v, want %v", err, ErrInvalidToken)
+	}
+	if served := idp.jwksServed.Load(); served != 1 {
+		t.Errorf("keys fetched %d times, want 1", served)
+	}
+
+	// Once the key is published and the refresh interval has passed, it's accepted
+	idp.published = append(idp.published, "rotated")
+	provider.refreshedAt = time.Now().Add(-oidcMinRefresh)
+
+	if userID, err := provider.Authenticate(context.Background(), idp.sign(t, "rotated", claims)); err != nil || userID != "user_123" {
+		t.Errorf("Authenticate() with the rotated key = %q, %v, want user_123, nil", userID, err)
+	}
+}
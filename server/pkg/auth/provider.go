@@ -0,0 +1,32 @@
+/*
+Package auth verifies the session tokens API clients send, so the middleware
+doesn't depend on a particular identity provider. Clerk is the default;
+self-hosted deployments can use any OpenID Connect issuer instead.
+*/
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Providers selectable with AUTH_PROVIDER
+const (
+	ProviderClerk = "clerk"
+	ProviderOIDC  = "oidc"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or not signed by the provider
+var ErrInvalidToken = errors.New("invalid session token")
+
+// Provider verifies session tokens
+type Provider interface {
+	// Authenticate returns the ID of the user the token belongs to, or an error wrapping ErrInvalidToken
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+// invalidToken wraps the reason a token was rejected in ErrInvalidToken
+func invalidToken(err error) error {
+	return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+}
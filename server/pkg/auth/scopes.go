@@ -0,0 +1,23 @@
+package auth
+
+// ServiceAccountTokenPrefix starts every service account token, telling them apart from session tokens
+const ServiceAccountTokenPrefix = "sa_"
+
+// Scopes a service account can be granted; each one opens a set of API routes
+const (
+	// List links and read their settings
+	ScopeLinksRead = "links:read"
+	// Create links, but not change existing ones
+	ScopeLinksCreate = "links:create"
+	// Update, retire and delete links, change their destination, settings and tags
+	ScopeLinksWrite = "links:write"
+	// Read and export stats
+	ScopeStatsRead = "stats:read"
+	// List tags
+	ScopeTagsRead = "tags:read"
+	// Create, rename and delete tags
+	ScopeTagsWrite = "tags:write"
+)
+
+// Scopes lists every scope, in the order they're documented
+var Scopes = []string{ScopeLinksRead, ScopeLinksCreate, ScopeLinksWrite, ScopeStatsRead, ScopeTagsRead, ScopeTagsWrite}
@@ -0,0 +1,315 @@
+/*
+Package backup writes and reads backups of the Postgres metadata: the rows of
+the backed up tables, as Postgres exports them with row_to_json.
+
+A backup is gzip-compressed text, one record per line:
+
+	backup 1 2026-10-15T09:00:00Z 000048
+	table links
+	{"id":"0b8f3c4e-5a49-4d2c-9a53-2f4c2a8e1d10","shortcode":"abc",...}
+	table tags
+	end 1 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+
+The header gives the format version, when the backup was taken and the last
+migration of the database it was taken from, which the one it's restored to
+must be at too. Each table's rows follow its name, in the order they can be
+inserted in. The footer gives the number of rows and the hex SHA-256 of every
+line before it, so truncated or corrupted backups are refused.
+*/
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Version of the format written
+	Version = 1
+
+	headerRecord = "backup"
+	tableRecord  = "table"
+	footerRecord = "end"
+	// Longest line read, well above the largest rows the tables hold
+	maxLineBytes = 16 << 20
+)
+
+var (
+	// The backup isn't in the format, or in a version this package doesn't read
+	ErrFormat = errors.New("invalid backup")
+	// The backup ends before its footer
+	ErrTruncated = errors.New("backup is truncated")
+	// The backup's checksum or row count doesn't match its footer
+	ErrChecksum = errors.New("backup checksum mismatch")
+)
+
+type Header struct {
+	Version   int
+	CreatedAt time.Time
+	// Last migration applied to the database backed up
+	SchemaVersion string
+}
+
+// Table is a table of a backup and the number of its rows
+type Table struct {
+	Name string
+	Rows int64
+}
+
+// Writer writes a backup. Call Close to finish it.
+type Writer struct {
+	out    io.Writer
+	gz     *gzip.Writer
+	buf    *bufio.Writer
+	sum    hash.Hash
+	line   bytes.Buffer
+	tables []Table
+	rows   int64
+}
+
+// NewWriter starts a backup with h, whose Version is set to Version
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	if !isName(h.SchemaVersion) {
+		return nil, fmt.Errorf("%w: schema version %q", ErrFormat, h.SchemaVersion)
+	}
+
+	gz := gzip.NewWriter(w)
+	bw := &Writer{
+		gz:  gz,
+		buf: bufio.NewWriter(gz),
+		sum: sha256.New(),
+	}
+	bw.out = io.MultiWriter(bw.buf, bw.sum)
+
+	header := fmt.Sprintf("%s %d %s %s\n", headerRecord, Version, h.CreatedAt.UTC().Format(time.RFC3339Nano), h.SchemaVersion)
+	if _, err := io.WriteString(bw.out, header); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Table starts the rows of the table name
+func (w *Writer) Table(name string) error {
+	if !isName(name) {
+		return fmt.Errorf("%w: table name %q", ErrFormat, name)
+	}
+	if _, err := io.WriteString(w.out, tableRecord+" "+name+"\n"); err != nil {
+		return err
+	}
+	w.tables = append(w.tables, Table{Name: name})
+	return nil
+}
+
+// Row writes a row of the current table, a JSON object
+func (w *Writer) Row(row []byte) error {
+	if len(w.tables) == 0 {
+		return fmt.Errorf("%w: row before the first table", ErrFormat)
+	}
+	// Compacted so it holds no line breaks, which json columns can keep as they were given
+	w.line.Reset()
+	if err := json.Compact(&w.line, row); err != nil || w.line.Len() == 0 || w.line.Bytes()[0] != '{' {
+		return fmt.Errorf("%w: row of %s isn't a JSON object", ErrFormat, w.tables[len(w.tables)-1].Name)
+	}
+	w.line.WriteByte('\n')
+
+	if _, err := w.out.Write(w.line.Bytes()); err != nil {
+		return err
+	}
+	w.tables[len(w.tables)-1].Rows++
+	w.rows++
+	return nil
+}
+
+// Tables returns the tables written so far
+func (w *Writer) Tables() []Table {
+	return w.tables
+}
+
+// Close writes the footer and finishes the compressed stream. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	// Not part of the checksum it holds
+	footer := fmt.Sprintf("%s %d %s\n", footerRecord, w.rows, hex.EncodeToString(w.sum.Sum(nil)))
+	if _, err := io.WriteString(w.buf, footer); err != nil {
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// Reader reads a backup, checking it against its footer
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	sum    hash.Hash
+	tables []Table
+	rows   int64
+	done   bool
+}
+
+// NewReader reads the header of the backup
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrTruncated
+		}
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	br := &Reader{
+		r:   bufio.NewReader(gz),
+		sum: sha256.New(),
+	}
+
+	line, err := br.line()
+	if err != nil {
+		return nil, err
+	}
+	br.sum.Write(line)
+
+	fields := strings.Fields(string(line))
+	if len(fields) != 4 || fields[0] != headerRecord {
+		return nil, fmt.Errorf("%w: missing header", ErrFormat)
+	}
+	if version, err := strconv.Atoi(fields[1]); err != nil || version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrFormat, fields[1])
+	}
+
+	br.header.Version = Version
+	if br.header.CreatedAt, err = time.Parse(time.RFC3339Nano, fields[2]); err != nil {
+		return nil, fmt.Errorf("%w: invalid created at time: %v", ErrFormat, err)
+	}
+	br.header.SchemaVersion = fields[3]
+	return br, nil
+}
+
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Tables returns the tables read so far, all of them once Next returned io.EOF
+func (r *Reader) Tables() []Table {
+	return r.tables
+}
+
+/*
+Next returns the next row and the table it's a row of, or io.EOF once the footer
+is read and matches. The row is only valid until the next call. Rows returned
+before an error can't be trusted: the backup is damaged.
+*/
+func (r *Reader) Next() (string, []byte, error) {
+	for {
+		if r.done {
+			return "", nil, io.EOF
+		}
+
+		// The footer isn't part of the checksum, so it's told apart before hashing
+		line, err := r.line()
+		if err != nil {
+			return "", nil, err
+		}
+		if bytes.HasPrefix(line, []byte(footerRecord+" ")) {
+			return "", nil, r.footer(line)
+		}
+		r.sum.Write(line)
+
+		if bytes.HasPrefix(line, []byte(tableRecord+" ")) {
+			name := strings.TrimSpace(string(line[len(tableRecord)+1:]))
+			if !isName(name) {
+				return "", nil, fmt.Errorf("%w: table name %q", ErrFormat, name)
+			}
+			r.tables = append(r.tables, Table{Name: name})
+			continue
+		}
+
+		if len(r.tables) == 0 || line[0] != '{' {
+			return "", nil, fmt.Errorf("%w: unexpected line", ErrFormat)
+		}
+		table := &r.tables[len(r.tables)-1]
+		table.Rows++
+		r.rows++
+		return table.Name, bytes.TrimSuffix(line, []byte("\n")), nil
+	}
+}
+
+func (r *Reader) footer(line []byte) error {
+	fields := strings.Fields(string(line))
+	if len(fields) != 3 {
+		return fmt.Errorf("%w: invalid footer", ErrFormat)
+	}
+	rows, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || rows != r.rows || fields[2] != hex.EncodeToString(r.sum.Sum(nil)) {
+		return ErrChecksum
+	}
+
+	// The compressed stream's own checksum is only checked at its end
+	if _, err := r.r.ReadByte(); !errors.Is(err, io.EOF) {
+		if err == nil {
+			return fmt.Errorf("%w: data after the footer", ErrFormat)
+		}
+		return r.streamError(err)
+	}
+
+	r.done = true
+	return io.EOF
+}
+
+// line returns the next line, its line break included
+func (r *Reader) line() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineBytes {
+			return nil, fmt.Errorf("%w: line too long", ErrFormat)
+		}
+		switch {
+		case err == nil:
+			return line, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			return nil, ErrTruncated
+		default:
+			return nil, r.streamError(err)
+		}
+	}
+}
+
+// streamError maps the errors of the compressed stream to the package's
+func (r *Reader) streamError(err error) error {
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrTruncated
+	case errors.Is(err, gzip.ErrChecksum):
+		return ErrChecksum
+	case errors.Is(err, gzip.ErrHeader):
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	return err
+}
+
+// isName reports whether s can be a table name or schema version: lowercase letters, digits and underscores
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') && ch != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func writeBackup(t *testing.T, h Header, tables map[string][]string, order ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	for _, name := range order {
+		if err := w.Table(name); err != nil {
+			t.Fatalf("Table() error = %v", err)
+		}
+		for _, row := range tables[name] {
+			if err := w.Row([]byte(row)); err != nil {
+				t.Fatalf("Row() error = %v", err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// readBackup reads every row of a backup, keyed by table
+func readBackup(data []byte) (Header, map[string][]string, []Table, error) {
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Header{}, nil, nil, err
+	}
+	rows := map[string][]string{}
+	for {
+		table, row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return r.Header(), rows, r.Tables(), nil
+		}
+		if err != nil {
+			return Header{}, nil, nil, err
+		}
+		rows[table] = append(rows[table], string(row))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	data := writeBackup(t, Header{CreatedAt: createdAt, SchemaVersion: "000048"}, map[string][]string{
+		"links": {`{"id":"a","shortcode":"abc"}`, "{\"id\":\"b\",\n \"title\":\"line\\nbreak\"}"},
+	}, "links", "tags")
+
+	h, rows, tables, err := readBackup(data)
+	if err != nil {
+		t.Fatalf("readBackup() error = %v", err)
+	}
+	if h.Version != Version || !h.CreatedAt.Equal(createdAt) || h.SchemaVersion != "000048" {
+		t.Errorf("Header = %+v", h)
+	}
+	if len(rows["links"]) != 2 || rows["links"][1] != `{"id":"b","title":"line\nbreak"}` {
+		t.Errorf("links rows = %q, want both, compacted", rows["links"])
+	}
+	if len(tables) != 2 || tables[0] != (Table{Name: "links", Rows: 2}) || tables[1] != (Table{Name: "tags"}) {
+		t.Errorf("Tables() = %+v, want links with 2 rows and an empty tags", tables)
+	}
+}
+
+func TestWriter_Rejects(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewWriter(&buf, Header{SchemaVersion: "48; DROP"}); !errors.Is(err, ErrFormat) {
+		t.Errorf("NewWriter() with an invalid schema version error = %v, want ErrFormat", err)
+	}
+
+	w, err := NewWriter(&buf, Header{SchemaVersion: "000048"})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := w.Row([]byte(`{"id":"a"}`)); !errors.Is(err, ErrFormat) {
+		t.Errorf("Row() before a table error = %v, want ErrFormat", err)
+	}
+	if err := w.Table(`links"; DROP TABLE links; --`); !errors.Is(err, ErrFormat) {
+		t.Errorf("Table() with an invalid name error = %v, want ErrFormat", err)
+	}
+	if err := w.Table("links"); err != nil {
+		t.Fatalf("Table() error = %v", err)
+	}
+	if err := w.Row([]byte(`["not", "an object"]`)); !errors.Is(err, ErrFormat) {
+		t.Errorf("Row() of an array error = %v, want ErrFormat", err)
+	}
+}
+
+func TestReader_Damaged(t *testing.T) {
+	data := writeBackup(t, Header{CreatedAt: time.Now(), SchemaVersion: "000048"}, map[string][]string{
+		"links": {`{"id":"a"}`, `{"id":"b"}`},
+	}, "links")
+
+	// Rewritten with a row changed but the footer kept
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	plain, _ := io.ReadAll(gz)
+	var tampered bytes.Buffer
+	zw := gzip.NewWriter(&tampered)
+	zw.Write(bytes.Replace(plain, []byte(`"b"`), []byte(`"c"`), 1))
+	zw.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"tampered", tampered.Bytes(), ErrChecksum},
+		{"truncated", data[:len(data)-12], ErrTruncated},
+		{"empty", nil, ErrTruncated},
+		{"not compressed", []byte("backup 1 2026-10-15T09:00:00Z 000048\n"), ErrFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := readBackup(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("readBackup() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel is the Redis pub/sub channel Broadcast publishes deleted keys on
+const InvalidationChannel = "cache:invalidations"
+
+/*
+Broadcast is a Cache that publishes the keys it deletes on InvalidationChannel,
+so caches kept elsewhere, like the local caches of edge instances, drop them too
+(see Subscribe). Publishing is fire-and-forget: subscribers that are
+disconnected miss the invalidation and keep the key until it expires.
+*/
+type Broadcast struct {
+	Cache
+	client *redis.Client
+}
+
+func NewBroadcast(c Cache, client *redis.Client) *Broadcast {
+	return &Broadcast{Cache: c, client: client}
+}
+
+// Del removes the keys from the wrapped cache, then publishes them
+func (b *Broadcast) Del(ctx context.Context, keys ...string) error {
+	err := b.Cache.Del(ctx, keys...)
+	if len(keys) == 0 {
+		return err
+	}
+
+	pipe := b.client.Pipeline()
+	for _, key := range keys {
+		pipe.Publish(ctx, InvalidationChannel, key)
+	}
+	_, pubErr := pipe.Exec(ctx)
+	return errors.Join(err, pubErr)
+}
+
+// Subscribe deletes the keys published on InvalidationChannel from c until ctx is done.
+// It returns once subscribed; on error the subscription is retried in the background.
+func Subscribe(ctx context.Context, client *redis.Client, c Cache) error {
+	sub := client.Subscribe(ctx, InvalidationChannel)
+	_, err := sub.Receive(ctx)
+
+	go func() {
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				// Local caches don't fail; a key left behind expires anyway
+				_ = c.Del(ctx, msg.Payload)
+			}
+		}
+	}()
+
+	return err
+}
@@ -0,0 +1,34 @@
+/*
+Package cache is the key-value cache services keep short-lived state in: the
+redirect cache and create deduplication of LinkService, and the cache
+invalidations of the jobs that change links behind its back.
+
+Redis backs it when several instances share the cache; Memory keeps it in the
+process, for tests and single-instance setups, and the local caches of edge
+instances, which Broadcast keeps in step with the shared one. Services take a
+nil Cache to mean running without one.
+*/
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get and TTL for keys that aren't cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache holds string values under string keys, each with an optional expiry
+type Cache interface {
+	// Get returns the value under key, or ErrMiss
+	Get(ctx context.Context, key string) (string, error)
+	// Set stores value under key, expiring after ttl; 0 keeps it until deleted
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX stores value under key only when the key isn't cached, reporting whether it was stored
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Del removes the keys, ignoring those that aren't cached
+	Del(ctx context.Context, keys ...string) error
+	// TTL returns how long until key expires, 0 when it doesn't, or ErrMiss
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
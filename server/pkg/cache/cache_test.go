@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testCache runs the same checks against each backend; advance moves its clock forward
+func testCache(t *testing.T, c Cache, advance func(time.Duration)) {
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get(missing) error = %v, want %v", err, ErrMiss)
+	}
+	if _, err := c.TTL(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("TTL(missing) error = %v, want %v", err, ErrMiss)
+	}
+
+	if err := c.Set(ctx, "short", "a", 10*time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ctx, "forever", "b", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := c.Get(ctx, "short"); err != nil || got != "a" {
+		t.Errorf("Get(short) = %q, %v, want a", got, err)
+	}
+	if ttl, err := c.TTL(ctx, "short"); err != nil || ttl <= 0 || ttl > 10*time.Second {
+		t.Errorf("TTL(short) = %v, %v, want up to 10s", ttl, err)
+	}
+	if ttl, err := c.TTL(ctx, "forever"); err != nil || ttl != 0 {
+		t.Errorf("TTL(forever) = %v, %v, want 0", ttl, err)
+	}
+
+	if ok, err := c.SetNX(ctx, "short", "c", time.Minute); err != nil || ok {
+		t.Errorf("SetNX() of a cached key = %v, %v, want false", ok, err)
+	}
+	if ok, err := c.SetNX(ctx, "claimed", "c", 5*time.Second); err != nil || !ok {
+		t.Errorf("SetNX() of a new key = %v, %v, want true", ok, err)
+	}
+
+	advance(11 * time.Second)
+	for _, key := range []string{"short", "claimed"} {
+		if _, err := c.Get(ctx, key); !errors.Is(err, ErrMiss) {
+			t.Errorf("Get(%s) after it expired error = %v, want %v", key, err, ErrMiss)
+		}
+	}
+	if ok, err := c.SetNX(ctx, "claimed", "d", 0); err != nil || !ok {
+		t.Errorf("SetNX() of an expired key = %v, %v, want true", ok, err)
+	}
+
+	if err := c.Del(ctx, "forever", "missing"); err != nil {
+		t.Fatalf("Del() error = %v", err)
+	}
+	if _, err := c.Get(ctx, "forever"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get(forever) after Del() error = %v, want %v", err, ErrMiss)
+	}
+	if err := c.Del(ctx); err != nil {
+		t.Errorf("Del() of no keys error = %v", err)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	testCache(t, NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})), mr.FastForward)
+}
+
+func TestMemory(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	testCache(t, m, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestMemory_SweepsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	for i := range memorySweepMin {
+		m.Set(ctx, fmt.Sprint(i), "v", time.Second)
+	}
+	now = now.Add(2 * time.Second)
+	m.Set(ctx, "live", "v", 0)
+
+	if len(m.entries) != 1 {
+		t.Errorf("entries after the sweep = %d, want only the live one", len(m.entries))
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	shared := NewBroadcast(NewRedis(client), client)
+	local := NewMemory()
+
+	if err := Subscribe(ctx, client, local); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	for _, c := range []Cache{shared, local} {
+		c.Set(ctx, "link:abc", "a", 0)
+		c.Set(ctx, "link:xyz", "b", 0)
+	}
+
+	if err := shared.Del(ctx, "link:abc"); err != nil {
+		t.Fatalf("Del() error = %v", err)
+	}
+	if _, err := shared.Get(ctx, "link:abc"); !errors.Is(err, ErrMiss) {
+		t.Errorf("shared Get() after Del() error = %v, want %v", err, ErrMiss)
+	}
+
+	// The subscriber deletes its copy asynchronously
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := local.Get(ctx, "link:abc"); errors.Is(err, ErrMiss) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("local copy wasn't invalidated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got, err := local.Get(ctx, "link:xyz"); err != nil || got != "b" {
+		t.Errorf("local Get(link:xyz) = %q, %v, want it kept", got, err)
+	}
+}
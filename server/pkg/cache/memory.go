@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Entries below which Memory doesn't bother sweeping expired ones
+const memorySweepMin = 1024
+
+type memoryEntry struct {
+	value string
+	// Zero when the entry doesn't expire
+	expires time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+/*
+Memory is a Cache in the process's memory, safe for concurrent use. Expired
+entries are dropped when read, and swept whenever the number of entries has
+doubled since the last sweep.
+*/
+type Memory struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	sweepSize int
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		now:       time.Now,
+		entries:   map[string]memoryEntry{},
+		sweepSize: memorySweepMin,
+	}
+}
+
+// entry returns the live entry under key; callers hold the lock
+func (m *Memory) entry(key string) (memoryEntry, bool) {
+	e, ok := m.entries[key]
+	if ok && e.expired(m.now()) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, ok
+}
+
+// set stores the entry, sweeping expired ones first when the cache has grown; callers hold the lock
+func (m *Memory) set(key, value string, ttl time.Duration) {
+	now := m.now()
+	if len(m.entries) >= m.sweepSize {
+		for k, e := range m.entries {
+			if e.expired(now) {
+				delete(m.entries, k)
+			}
+		}
+		m.sweepSize = max(2*len(m.entries), memorySweepMin)
+	}
+
+	e := memoryEntry{value: value}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	m.entries[key] = e
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entry(key)
+	if !ok {
+		return "", ErrMiss
+	}
+	return e.value, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, value, ttl)
+	return nil
+}
+
+func (m *Memory) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entry(key); ok {
+		return false, nil
+	}
+	m.set(key, value, ttl)
+	return true, nil
+}
+
+func (m *Memory) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entry(key)
+	if !ok {
+		return 0, ErrMiss
+	}
+	if e.expires.IsZero() {
+		return 0, nil
+	}
+	return e.expires.Sub(m.now()), nil
+}
@@ -0,0 +1,57 @@
+// Package certs builds the TLS configuration for the public listener.
+// Certificates either come from static files or are provisioned automatically
+// through Let's Encrypt (ACME) for the hosts of TLS_AUTOCERT_HOSTS only.
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/styltsou/url-shortener/server/pkg/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Provider holds the TLS config for the public listener and, when autocert
+// is enabled, the handler that answers ACME HTTP-01 challenges
+type Provider struct {
+	TLSConfig *tls.Config
+	// CertFile and KeyFile are empty when certificates are managed by autocert
+	CertFile string
+	KeyFile  string
+	// ChallengeHandler answers ACME HTTP-01 challenges and redirects everything else to HTTPS.
+	// It is nil when autocert is disabled.
+	ChallengeHandler http.Handler
+}
+
+// New creates a Provider from the configuration
+func New(cfg *config.Config) (*Provider, error) {
+	if !cfg.TLSEnabled {
+		return nil, fmt.Errorf("TLS is not enabled")
+	}
+
+	if !cfg.TLSAutocert {
+		return &Provider{
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+			CertFile:  cfg.TLSCertFile,
+			KeyFile:   cfg.TLSKeyFile,
+		}, nil
+	}
+
+	// Certificates are only requested for the configured hosts, so clients sending other
+	// SNI names can't make us hit Let's Encrypt rate limits
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+		Email:      cfg.TLSAutocertEmail,
+		HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertHosts...),
+	}
+
+	tlsConfig := m.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+
+	return &Provider{
+		TLSConfig:        tlsConfig,
+		ChallengeHandler: m.HTTPHandler(nil),
+	}, nil
+}
@@ -5,49 +5,208 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
+	"github.com/styltsou/url-shortener/server/pkg/objectstore"
+	"github.com/styltsou/url-shortener/server/pkg/urlcrypt"
+	"github.com/styltsou/url-shortener/server/pkg/urlnorm"
 )
 
 // TODO: What happens for omit empty??
-// TODO: Handle prod vs dev
 // What about logging here??
 
 type Config struct {
-	AppEnv                   string   `mapstructure:"APP_ENV" validate:"omitempty"`
-	Port                     int      `mapstructure:"PORT" validate:"min=1,max=65535"`
-	PostgresConnectionString string   `mapstructure:"POSTGRES_CONNECTION_STRING" validate:"required"`
-	ClickhouseURL            string   `mapstructure:"CLICKHOUSE_URL" validate:"required"`
-	ClickhouseUsername       string   `mapstructure:"CLICKHOUSE_USERNAME" validate:"required"`
-	ClickhousePassword       string   `mapstructure:"CLICKHOUSE_PASSWORD" validate:"required"`
-	RedisURL                 string   `mapstructure:"REDIS_URL" validate:"required"`
-	RedisUsername            string   `mapstructure:"REDIS_USERNAME" validate:"required"`
-	RedisPassword            string   `mapstructure:"REDIS_PASSWORD" validate:"required"`
-	RedisDB                  int      `mapstructure:"REDIS_DB" validate:"omitempty"`
-	RedisDialTimeout         int      `mapstructure:"REDIS_DIAL_TIMEOUT" validate:"omitempty"`
-	RedisReadTimeout         int      `mapstructure:"REDIS_READ_TIMEOUT" validate:"omitempty"`
-	RedisWriteTimeout        int      `mapstructure:"REDIS_WRITE_TIMEOUT" validate:"omitempty"`
-	RedisMaxRetries          int      `mapstructure:"REDIS_MAX_RETRIES" validate:"omitempty,min=1"`
-	ClerkSecretKey           string   `mapstructure:"CLERK_SECRET_KEY" validate:"required"`
-	CORSAllowedOrigins       []string `mapstructure:"CORS_ALLOWED_ORIGINS" validate:"omitempty"`
-	CORSAllowedMethods       []string `mapstructure:"CORS_ALLOWED_METHODS" validate:"omitempty"`
-	CORSAllowedHeaders       []string `mapstructure:"CORS_ALLOWED_HEADERS" validate:"omitempty"`
-	CORSExposedHeaders       []string `mapstructure:"CORS_EXPOSED_HEADERS" validate:"omitempty"`
-	CORSAllowCredentials     bool     `mapstructure:"CORS_ALLOW_CREDENTIALS" validate:"omitempty"`
-	CORSMaxAge               int      `mapstructure:"CORS_MAX_AGE" validate:"omitempty"`
-	ServerReadTimeout        int      `mapstructure:"SERVER_READ_TIMEOUT" validate:"min=1"`
-	ServerWriteTimeout       int      `mapstructure:"SERVER_WRITE_TIMEOUT" validate:"min=1"`
-	ServerIdleTimeout        int      `mapstructure:"SERVER_IDLE_TIMEOUT" validate:"min=1"`
+	AppEnv                      string   `mapstructure:"APP_ENV" validate:"omitempty"`
+	Port                        int      `mapstructure:"PORT" validate:"min=1,max=65535"`
+	InternalPort                int      `mapstructure:"INTERNAL_PORT" validate:"min=1,max=65535,nefield=Port"`
+	StorageBackend              string   `mapstructure:"STORAGE_BACKEND" validate:"oneof=postgres sqlite memory"`
+	PostgresConnectionString    string   `mapstructure:"POSTGRES_CONNECTION_STRING" validate:"required_if=StorageBackend postgres" redact:"true"`
+	PostgresRowLevelSecurity    bool     `mapstructure:"POSTGRES_ROW_LEVEL_SECURITY" validate:"omitempty"`
+	SQLitePath                  string   `mapstructure:"SQLITE_PATH" validate:"required_if=StorageBackend sqlite"`
+	AnalyticsBackend            string   `mapstructure:"ANALYTICS_BACKEND" validate:"oneof=postgres clickhouse none"`
+	AnalyticsDoubleWrite        bool     `mapstructure:"ANALYTICS_DOUBLE_WRITE" validate:"omitempty"`
+	ClickhouseURL               string   `mapstructure:"CLICKHOUSE_URL" validate:"required_if=AnalyticsBackend clickhouse,required_if=AnalyticsDoubleWrite true" redact:"userinfo"`
+	ClickhouseUsername          string   `mapstructure:"CLICKHOUSE_USERNAME" validate:"required_if=AnalyticsBackend clickhouse,required_if=AnalyticsDoubleWrite true"`
+	ClickhousePassword          string   `mapstructure:"CLICKHOUSE_PASSWORD" validate:"omitempty" redact:"true"`
+	ClickhouseDatabase          string   `mapstructure:"CLICKHOUSE_DATABASE" validate:"omitempty"`
+	ClickhouseMigrate           bool     `mapstructure:"CLICKHOUSE_MIGRATE" validate:"omitempty"`
+	ClickhouseBatchSize         int      `mapstructure:"CLICKHOUSE_BATCH_SIZE" validate:"min=1"`
+	ClickhouseFlushInterval     int      `mapstructure:"CLICKHOUSE_FLUSH_INTERVAL" validate:"min=1"`
+	ClickhouseQueueSize         int      `mapstructure:"CLICKHOUSE_QUEUE_SIZE" validate:"min=1"`
+	GeoIPCountryDB              string   `mapstructure:"GEOIP_COUNTRY_DB" validate:"omitempty"`
+	VisitorIDSecret             string   `mapstructure:"VISITOR_ID_SECRET" validate:"omitempty,min=32" redact:"true"`
+	RedisURL                    string   `mapstructure:"REDIS_URL" validate:"required" redact:"userinfo"`
+	RedisUsername               string   `mapstructure:"REDIS_USERNAME" validate:"required"`
+	RedisPassword               string   `mapstructure:"REDIS_PASSWORD" validate:"required" redact:"true"`
+	RedisDB                     int      `mapstructure:"REDIS_DB" validate:"omitempty"`
+	RedisDialTimeout            int      `mapstructure:"REDIS_DIAL_TIMEOUT" validate:"omitempty"`
+	RedisReadTimeout            int      `mapstructure:"REDIS_READ_TIMEOUT" validate:"omitempty"`
+	RedisWriteTimeout           int      `mapstructure:"REDIS_WRITE_TIMEOUT" validate:"omitempty"`
+	RedisMaxRetries             int      `mapstructure:"REDIS_MAX_RETRIES" validate:"omitempty,min=1"`
+	AuthProvider                string   `mapstructure:"AUTH_PROVIDER" validate:"oneof=clerk oidc"`
+	ClerkSecretKey              string   `mapstructure:"CLERK_SECRET_KEY" validate:"required_if=AuthProvider clerk" redact:"true"`
+	OIDCIssuerURL               string   `mapstructure:"OIDC_ISSUER_URL" validate:"required_if=AuthProvider oidc,omitempty,url"`
+	OIDCAudience                string   `mapstructure:"OIDC_AUDIENCE" validate:"required_if=AuthProvider oidc"`
+	OIDCUserIDClaim             string   `mapstructure:"OIDC_USER_ID_CLAIM" validate:"omitempty"`
+	CORSAllowedOrigins          []string `mapstructure:"CORS_ALLOWED_ORIGINS" validate:"omitempty"`
+	CORSAllowedMethods          []string `mapstructure:"CORS_ALLOWED_METHODS" validate:"omitempty"`
+	CORSAllowedHeaders          []string `mapstructure:"CORS_ALLOWED_HEADERS" validate:"omitempty"`
+	CORSExposedHeaders          []string `mapstructure:"CORS_EXPOSED_HEADERS" validate:"omitempty"`
+	CORSAllowCredentials        bool     `mapstructure:"CORS_ALLOW_CREDENTIALS" validate:"omitempty"`
+	CORSMaxAge                  int      `mapstructure:"CORS_MAX_AGE" validate:"omitempty"`
+	CORSPublicAllowedOrigins    []string `mapstructure:"CORS_PUBLIC_ALLOWED_ORIGINS" validate:"omitempty"`
+	ExtensionAllowedOrigins     []string `mapstructure:"EXTENSION_ALLOWED_ORIGINS" validate:"omitempty"`
+	ServerReadTimeout           int      `mapstructure:"SERVER_READ_TIMEOUT" validate:"min=1"`
+	ServerWriteTimeout          int      `mapstructure:"SERVER_WRITE_TIMEOUT" validate:"min=1"`
+	ServerIdleTimeout           int      `mapstructure:"SERVER_IDLE_TIMEOUT" validate:"min=1"`
+	RedirectMaxBodyBytes        int64    `mapstructure:"REDIRECT_MAX_BODY_BYTES" validate:"omitempty,min=0"`
+	RedirectTimeout             int      `mapstructure:"REDIRECT_TIMEOUT" validate:"omitempty,min=0"`
+	APIMaxBodyBytes             int64    `mapstructure:"API_MAX_BODY_BYTES" validate:"omitempty,min=0"`
+	APITimeout                  int      `mapstructure:"API_TIMEOUT" validate:"omitempty,min=0"`
+	BulkMaxBodyBytes            int64    `mapstructure:"BULK_MAX_BODY_BYTES" validate:"omitempty,min=0"`
+	BulkTimeout                 int      `mapstructure:"BULK_TIMEOUT" validate:"omitempty,min=0"`
+	ExportTimeout               int      `mapstructure:"EXPORT_TIMEOUT" validate:"omitempty,min=0"`
+	ServerReusePort             bool     `mapstructure:"SERVER_REUSE_PORT" validate:"omitempty"`
+	ShutdownDrainDelay          int      `mapstructure:"SHUTDOWN_DRAIN_DELAY" validate:"omitempty,min=0"`
+	ShutdownTimeout             int      `mapstructure:"SHUTDOWN_TIMEOUT" validate:"min=1"`
+	ShortDomains                []string `mapstructure:"SHORT_DOMAINS" validate:"omitempty"`
+	BaseShortURL                string   `mapstructure:"BASE_SHORT_URL" validate:"omitempty,url"`
+	ReservedPlaceholderURL      string   `mapstructure:"RESERVED_PLACEHOLDER_URL" validate:"omitempty,url"`
+	RobotsAllowCrawling         bool     `mapstructure:"ROBOTS_ALLOW_CRAWLING" validate:"omitempty"`
+	RobotsSitemapURL            string   `mapstructure:"ROBOTS_SITEMAP_URL" validate:"omitempty,url"`
+	FaviconURL                  string   `mapstructure:"FAVICON_URL" validate:"omitempty,url"`
+	WellKnownDir                string   `mapstructure:"WELL_KNOWN_DIR" validate:"omitempty"`
+	SlackSigningSecret          string   `mapstructure:"SLACK_SIGNING_SECRET" validate:"omitempty" redact:"true"`
+	SlackLinkURL                string   `mapstructure:"SLACK_LINK_URL" validate:"required_with=SlackSigningSecret"`
+	TrustedProxies              []string `mapstructure:"TRUSTED_PROXIES" validate:"omitempty"`
+	AdminUserIDs                []string `mapstructure:"ADMIN_USER_IDS" validate:"omitempty"`
+	DefaultLanguage             string   `mapstructure:"DEFAULT_LANGUAGE" validate:"required"`
+	DomainLanguages             []string `mapstructure:"DOMAIN_LANGUAGES" validate:"omitempty"`
+	APIHost                     string   `mapstructure:"API_HOST" validate:"omitempty"`
+	HTTP2Cleartext              bool     `mapstructure:"HTTP2_CLEARTEXT" validate:"omitempty"`
+	TLSEnabled                  bool     `mapstructure:"TLS_ENABLED" validate:"omitempty"`
+	TLSCertFile                 string   `mapstructure:"TLS_CERT_FILE" validate:"omitempty"`
+	TLSKeyFile                  string   `mapstructure:"TLS_KEY_FILE" validate:"omitempty"`
+	TLSAutocert                 bool     `mapstructure:"TLS_AUTOCERT" validate:"omitempty"`
+	TLSAutocertHosts            []string `mapstructure:"TLS_AUTOCERT_HOSTS" validate:"omitempty"`
+	TLSAutocertEmail            string   `mapstructure:"TLS_AUTOCERT_EMAIL" validate:"omitempty"`
+	TLSAutocertCacheDir         string   `mapstructure:"TLS_AUTOCERT_CACHE_DIR" validate:"omitempty"`
+	TLSChallengePort            int      `mapstructure:"TLS_CHALLENGE_PORT" validate:"omitempty,min=1,max=65535"`
+	LinkTokenSecret             string   `mapstructure:"LINK_TOKEN_SECRET" validate:"omitempty,min=32" redact:"true"`
+	EnumerationGuardEnabled     bool     `mapstructure:"ENUMERATION_GUARD_ENABLED" validate:"omitempty"`
+	EnumerationMaxNotFound      int      `mapstructure:"ENUMERATION_MAX_NOT_FOUND" validate:"omitempty,min=1"`
+	EnumerationWindow           int      `mapstructure:"ENUMERATION_WINDOW" validate:"omitempty,min=1"`
+	EnumerationBlockDuration    int      `mapstructure:"ENUMERATION_BLOCK_DURATION" validate:"omitempty,min=1"`
+	BotShieldSecret             string   `mapstructure:"BOT_SHIELD_SECRET" validate:"omitempty,min=32" redact:"true"`
+	BotShieldDatacenterCIDRs    []string `mapstructure:"BOT_SHIELD_DATACENTER_CIDRS" validate:"omitempty"`
+	BotShieldRateLimit          int      `mapstructure:"BOT_SHIELD_RATE_LIMIT" validate:"omitempty,min=0"`
+	BotShieldRateWindow         int      `mapstructure:"BOT_SHIELD_RATE_WINDOW" validate:"omitempty,min=1"`
+	BotShieldPassTTL            int      `mapstructure:"BOT_SHIELD_PASS_TTL" validate:"omitempty,min=1"`
+	DidYouMeanEnabled           bool     `mapstructure:"DID_YOU_MEAN_ENABLED" validate:"omitempty"`
+	DidYouMeanPublic            bool     `mapstructure:"DID_YOU_MEAN_PUBLIC" validate:"omitempty"`
+	DidYouMeanRateLimit         int      `mapstructure:"DID_YOU_MEAN_RATE_LIMIT" validate:"omitempty,min=0"`
+	DidYouMeanRateWindow        int      `mapstructure:"DID_YOU_MEAN_RATE_WINDOW" validate:"omitempty,min=1"`
+	AutoTagLinks                bool     `mapstructure:"AUTO_TAG_LINKS" validate:"omitempty"`
+	URLStripParams              []string `mapstructure:"URL_STRIP_PARAMS" validate:"omitempty"`
+	URLSortQueryParams          bool     `mapstructure:"URL_SORT_QUERY_PARAMS" validate:"omitempty"`
+	CreateDedupeWindow          int      `mapstructure:"CREATE_DEDUPE_WINDOW" validate:"omitempty,min=0,max=300"`
+	APIRateLimit                int      `mapstructure:"API_RATE_LIMIT" validate:"omitempty,min=0"`
+	APIRateLimitWindow          int      `mapstructure:"API_RATE_LIMIT_WINDOW" validate:"omitempty,min=1"`
+	LinkQuota                   int      `mapstructure:"LINK_QUOTA" validate:"omitempty,min=0"`
+	TagQuota                    int      `mapstructure:"TAG_QUOTA" validate:"omitempty,min=0"`
+	TagSlugs                    bool     `mapstructure:"TAG_SLUGS" validate:"omitempty"`
+	LinkPolicyAllowedDomains    []string `mapstructure:"LINK_POLICY_ALLOWED_DOMAINS" validate:"omitempty"`
+	LinkPolicyDeniedDomains     []string `mapstructure:"LINK_POLICY_DENIED_DOMAINS" validate:"omitempty"`
+	LinkPolicyForbiddenCodes    []string `mapstructure:"LINK_POLICY_FORBIDDEN_SHORTCODES" validate:"omitempty"`
+	LinkPolicyRequiredTags      []string `mapstructure:"LINK_POLICY_REQUIRED_TAGS" validate:"omitempty"`
+	LinkPolicyMaxExpiryDays     int      `mapstructure:"LINK_POLICY_MAX_EXPIRY_DAYS" validate:"omitempty,min=0"`
+	PaginationSecret            string   `mapstructure:"PAGINATION_SECRET" validate:"omitempty,min=32" redact:"true"`
+	URLEncryptionKeys           string   `mapstructure:"URL_ENCRYPTION_KEYS" validate:"omitempty" redact:"true"`
+	URLEncryptionKeysFile       string   `mapstructure:"URL_ENCRYPTION_KEYS_FILE" validate:"omitempty"`
+	StatsRollupInterval         int      `mapstructure:"STATS_ROLLUP_INTERVAL" validate:"omitempty,min=0"`
+	AnomalyCheckInterval        int      `mapstructure:"ANOMALY_CHECK_INTERVAL" validate:"omitempty,min=0"`
+	AnomalyWindowHours          int      `mapstructure:"ANOMALY_WINDOW_HOURS" validate:"omitempty,min=3,max=720"`
+	AnomalyZThreshold           float64  `mapstructure:"ANOMALY_Z_THRESHOLD" validate:"omitempty,gt=0"`
+	AnomalyMinClicks            int64    `mapstructure:"ANOMALY_MIN_CLICKS" validate:"omitempty,min=0"`
+	AnomalyWebhookURL           string   `mapstructure:"ANOMALY_WEBHOOK_URL" validate:"omitempty,url" redact:"true"`
+	TrafficCapSyncInterval      int      `mapstructure:"TRAFFIC_CAP_SYNC_INTERVAL" validate:"omitempty,min=0"`
+	ClickCountFlushInterval     int      `mapstructure:"CLICK_COUNT_FLUSH_INTERVAL" validate:"omitempty,min=0"`
+	DestinationScheduleInterval int      `mapstructure:"DESTINATION_SCHEDULE_INTERVAL" validate:"omitempty,min=0"`
+	HTTPSUpgradeInterval        int      `mapstructure:"HTTPS_UPGRADE_INTERVAL" validate:"omitempty,min=0"`
+	HTTPSUpgradeApply           bool     `mapstructure:"HTTPS_UPGRADE_APPLY" validate:"omitempty"`
+	HTTPSUpgradeRecheckDays     int      `mapstructure:"HTTPS_UPGRADE_RECHECK_DAYS" validate:"omitempty,min=1"`
+	DestinationMonitorInterval  int      `mapstructure:"DESTINATION_MONITOR_INTERVAL" validate:"omitempty,min=0"`
+	DestinationRecheckHours     int      `mapstructure:"DESTINATION_RECHECK_HOURS" validate:"omitempty,min=1"`
+	ActivityVerifyInterval      int      `mapstructure:"ACTIVITY_VERIFY_INTERVAL" validate:"omitempty,min=0"`
+	ExpensiveMaxConcurrency     int      `mapstructure:"EXPENSIVE_MAX_CONCURRENCY" validate:"omitempty,min=0"`
+	ExpensiveMaxQueue           int      `mapstructure:"EXPENSIVE_MAX_QUEUE" validate:"omitempty,min=0"`
+	ExpensiveQueueTimeout       int      `mapstructure:"EXPENSIVE_QUEUE_TIMEOUT" validate:"omitempty,min=1"`
+	ExpensiveMaxPerUser         int      `mapstructure:"EXPENSIVE_MAX_PER_USER" validate:"omitempty,min=0"`
+	LoadShedPoolWait            int      `mapstructure:"LOAD_SHED_POOL_WAIT" validate:"omitempty,min=0"`
+	LoadShedGoroutines          int      `mapstructure:"LOAD_SHED_GOROUTINES" validate:"omitempty,min=0"`
+	LoadShedMaxDelay            int      `mapstructure:"LOAD_SHED_MAX_DELAY" validate:"omitempty,min=0"`
+	LoadShedInterval            int      `mapstructure:"LOAD_SHED_INTERVAL" validate:"omitempty,min=100"`
+	JSONFieldNaming             string   `mapstructure:"JSON_FIELD_NAMING" validate:"oneof=snake_case camelCase"`
+	JSONNulls                   string   `mapstructure:"JSON_NULLS" validate:"oneof=include omit"`
+	WebhookDisableAfterDays     int      `mapstructure:"WEBHOOK_DISABLE_AFTER_DAYS" validate:"omitempty,min=0"`
+	WebhookRetryInterval        int      `mapstructure:"WEBHOOK_RETRY_INTERVAL" validate:"omitempty,min=0"`
+	OutboundAllowPrivate        bool     `mapstructure:"OUTBOUND_ALLOW_PRIVATE" validate:"omitempty"`
+	DNSCacheMaxTTL              int      `mapstructure:"DNS_CACHE_MAX_TTL" validate:"omitempty,min=1"`
+	DNSCacheNegativeTTL         int      `mapstructure:"DNS_CACHE_NEGATIVE_TTL" validate:"omitempty,min=1"`
+	ServerMode                  string   `mapstructure:"SERVER_MODE" validate:"oneof=primary edge"`
+	EdgeToken                   string   `mapstructure:"EDGE_TOKEN" validate:"required_if=ServerMode edge,omitempty,min=32" redact:"true"`
+	EdgePrimaryURL              string   `mapstructure:"EDGE_PRIMARY_URL" validate:"required_if=ServerMode edge,omitempty,url"`
+	EdgePrimaryInternalURL      string   `mapstructure:"EDGE_PRIMARY_INTERNAL_URL" validate:"required_if=ServerMode edge,omitempty,url"`
+	EdgeSnapshotInterval        int      `mapstructure:"EDGE_SNAPSHOT_INTERVAL" validate:"min=1"`
+	EdgeCacheTTL                int      `mapstructure:"EDGE_CACHE_TTL" validate:"gtfield=EdgeSnapshotInterval"`
+	EdgeSnapshotFile            string   `mapstructure:"EDGE_SNAPSHOT_FILE" validate:"omitempty,file"`
+	BackupS3Endpoint            string   `mapstructure:"BACKUP_S3_ENDPOINT" validate:"required_with=BackupS3Bucket,omitempty,url"`
+	BackupS3Region              string   `mapstructure:"BACKUP_S3_REGION" validate:"omitempty"`
+	BackupS3Bucket              string   `mapstructure:"BACKUP_S3_BUCKET" validate:"omitempty"`
+	BackupS3AccessKeyID         string   `mapstructure:"BACKUP_S3_ACCESS_KEY_ID" validate:"required_with=BackupS3Bucket"`
+	BackupS3SecretAccessKey     string   `mapstructure:"BACKUP_S3_SECRET_ACCESS_KEY" validate:"required_with=BackupS3Bucket" redact:"true"`
+	BackupPrefix                string   `mapstructure:"BACKUP_PREFIX" validate:"omitempty"`
+	BackupInterval              int      `mapstructure:"BACKUP_INTERVAL" validate:"omitempty,min=0"`
+	BackupRetentionDays         int      `mapstructure:"BACKUP_RETENTION_DAYS" validate:"omitempty,min=0"`
+	BackupIncludeClicks         bool     `mapstructure:"BACKUP_INCLUDE_CLICKS" validate:"omitempty"`
+	AssetsStorage               string   `mapstructure:"ASSETS_STORAGE" validate:"required,oneof=disk s3"`
+	AssetsDir                   string   `mapstructure:"ASSETS_DIR" validate:"omitempty"`
+	AssetsS3Endpoint            string   `mapstructure:"ASSETS_S3_ENDPOINT" validate:"required_if=AssetsStorage s3,omitempty,url"`
+	AssetsS3PublicEndpoint      string   `mapstructure:"ASSETS_S3_PUBLIC_ENDPOINT" validate:"omitempty,url"`
+	AssetsS3Region              string   `mapstructure:"ASSETS_S3_REGION" validate:"omitempty"`
+	AssetsS3Bucket              string   `mapstructure:"ASSETS_S3_BUCKET" validate:"required_if=AssetsStorage s3"`
+	AssetsS3AccessKeyID         string   `mapstructure:"ASSETS_S3_ACCESS_KEY_ID" validate:"required_if=AssetsStorage s3"`
+	AssetsS3SecretAccessKey     string   `mapstructure:"ASSETS_S3_SECRET_ACCESS_KEY" validate:"required_if=AssetsStorage s3" redact:"true"`
+	AssetsPrefix                string   `mapstructure:"ASSETS_PREFIX" validate:"omitempty"`
+	AssetsDownloadURLTTL        int      `mapstructure:"ASSETS_DOWNLOAD_URL_TTL" validate:"min=1,max=604800"`
+	AssetsCleanupInterval       int      `mapstructure:"ASSETS_CLEANUP_INTERVAL" validate:"min=1"`
 }
 
 var cfg *Config
 var validate = validator.New()
 
+// Settings edge instances don't use, as they don't connect to storage, analytics or the auth provider
+var edgeUnusedFields = []string{
+	"PostgresConnectionString",
+	"SQLitePath",
+	"ClickhouseURL",
+	"ClickhouseUsername",
+	"ClerkSecretKey",
+	"OIDCIssuerURL",
+	"OIDCAudience",
+}
+
 // validateConfig validates the configuration using struct tags
 func validateConfig(c *Config) error {
-	if err := validate.Struct(c); err != nil {
+	err := validate.Struct(c)
+	if c.ServerMode == "edge" {
+		err = validate.StructExcept(c, edgeUnusedFields...)
+	}
+	if err != nil {
 		validationErrors, ok := err.(validator.ValidationErrors)
 		if !ok {
 			return fmt.Errorf("config validation failed: %w", err)
@@ -63,18 +222,147 @@ func validateConfig(c *Config) error {
 		return fmt.Errorf("%s", strings.Join(errorMessages, "; "))
 	}
 
+	if c.StorageBackend != "postgres" && c.AnalyticsBackend == "postgres" {
+		return fmt.Errorf("AnalyticsBackend postgres needs StorageBackend postgres")
+	}
+
+	if c.AnalyticsDoubleWrite && c.AnalyticsBackend != "postgres" {
+		return fmt.Errorf("AnalyticsDoubleWrite only applies to the postgres backend")
+	}
+
+	if c.PostgresRowLevelSecurity && c.StorageBackend != "postgres" {
+		return fmt.Errorf("PostgresRowLevelSecurity needs StorageBackend postgres")
+	}
+
+	if c.BackupS3Bucket != "" && c.StorageBackend != "postgres" && c.ServerMode != "edge" {
+		return fmt.Errorf("BackupS3Bucket needs StorageBackend postgres")
+	}
+
+	if err := validateCORS(c); err != nil {
+		return err
+	}
+
+	if err := validateTLS(c); err != nil {
+		return err
+	}
+
+	if err := validateURLEncryption(c); err != nil {
+		return err
+	}
+
+	return validateProduction(c)
+}
+
+// validateCORS rejects * alongside credentials: a * origin is answered with the
+// request's own origin, so any site could make authenticated calls
+func validateCORS(c *Config) error {
+	if c.CORSAllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		return fmt.Errorf("CORSAllowedOrigins must not be * when CORSAllowCredentials is enabled")
+	}
+	return nil
+}
+
+// validateTLS checks the TLS settings that depend on each other
+func validateTLS(c *Config) error {
+	if !c.TLSEnabled {
+		return nil
+	}
+
+	if c.TLSAutocert {
+		if len(c.TLSAutocertHosts) == 0 {
+			return fmt.Errorf("TLSAutocertHosts is required when TLSAutocert is enabled")
+		}
+		return nil
+	}
+
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+		return fmt.Errorf("TLSCertFile and TLSKeyFile are required when TLS is enabled without autocert")
+	}
+
 	return nil
 }
 
+// validateURLEncryption checks the keyring can be loaded
+func validateURLEncryption(c *Config) error {
+	if c.URLEncryptionKeys == "" && c.URLEncryptionKeysFile == "" {
+		return nil
+	}
+
+	if c.URLEncryptionKeys != "" && c.URLEncryptionKeysFile != "" {
+		return fmt.Errorf("URLEncryptionKeys and URLEncryptionKeysFile can't both be set")
+	}
+	if c.StorageBackend != "postgres" {
+		return fmt.Errorf("URL encryption needs StorageBackend postgres")
+	}
+	_, err := c.URLEncryptionKeyring()
+	return err
+}
+
+// BackupStorage returns the bucket backups are kept in, see BACKUP_S3_BUCKET
+func (c *Config) BackupStorage() objectstore.Config {
+	return objectstore.Config{
+		Endpoint:        c.BackupS3Endpoint,
+		Region:          c.BackupS3Region,
+		Bucket:          c.BackupS3Bucket,
+		AccessKeyID:     c.BackupS3AccessKeyID,
+		SecretAccessKey: c.BackupS3SecretAccessKey,
+	}
+}
+
+// AssetsS3Storage returns the bucket generated assets are kept in when ASSETS_STORAGE is s3
+func (c *Config) AssetsS3Storage() objectstore.Config {
+	return objectstore.Config{
+		Endpoint:        c.AssetsS3Endpoint,
+		PublicEndpoint:  c.AssetsS3PublicEndpoint,
+		Region:          c.AssetsS3Region,
+		Bucket:          c.AssetsS3Bucket,
+		AccessKeyID:     c.AssetsS3AccessKeyID,
+		SecretAccessKey: c.AssetsS3SecretAccessKey,
+	}
+}
+
+// URLEncryptionKeyring returns the keyring destination URLs are encrypted with, or nil when they aren't
+func (c *Config) URLEncryptionKeyring() (*urlcrypt.Keyring, error) {
+	keys := c.URLEncryptionKeys
+	if c.URLEncryptionKeysFile != "" {
+		data, err := os.ReadFile(c.URLEncryptionKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read URLEncryptionKeysFile: %w", err)
+		}
+		keys = string(data)
+	}
+	if keys == "" {
+		return nil, nil
+	}
+
+	keyring, err := urlcrypt.ParseKeys(keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL encryption keys: %w", err)
+	}
+	return keyring, nil
+}
+
 // getValidationErrorMessage returns a user-friendly error message for validation errors
 func getValidationErrorMessage(err validator.FieldError) string {
 	switch err.Tag() {
 	case "required":
 		return "is required"
+	case "required_if":
+		return fmt.Sprintf("is required when %s", strings.Replace(err.Param(), " ", " is ", 1))
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", err.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
 	case "min":
 		return fmt.Sprintf("must be at least %s", err.Param())
 	case "max":
 		return fmt.Sprintf("must be at most %s", err.Param())
+	case "url":
+		return "must be a valid URL"
+	case "nefield":
+		return fmt.Sprintf("must be different from %s", err.Param())
+	case "gtfield":
+		return fmt.Sprintf("must be greater than %s", err.Param())
 	default:
 		return err.Error()
 	}
@@ -109,17 +397,335 @@ func Load() (*Config, error) {
 
 	v.SetDefault("APP_ENV", "development")
 	v.SetDefault("PORT", 8080)
+	v.SetDefault("INTERNAL_PORT", 9090)
+
+	// Where links and everything else are stored: postgres; sqlite (links and tags only, in
+	// SQLITE_PATH, for a single small server; needs a build with -tags sqlite); or memory
+	// (links and tags only, lost on restart; the local profile's default, for development)
+	v.SetDefault("STORAGE_BACKEND", "postgres")
+	v.SetDefault("SQLITE_PATH", "urlshortener.db")
+
+	// With POSTGRES_ROW_LEVEL_SECURITY, API requests run their queries scoped to the user, so the
+	// row-level security policies of links, tags, campaigns, webhooks and activity_events hide other
+	// users' rows even from a query that forgets to. Each scoped query runs in its own transaction,
+	// three round trips more. The app must not connect as a superuser, which skips the policies.
+	v.SetDefault("POSTGRES_ROW_LEVEL_SECURITY", false)
+
+	// Who verifies session tokens: clerk (CLERK_SECRET_KEY) or oidc, any OpenID Connect issuer at
+	// OIDC_ISSUER_URL. OIDC tokens must be issued for OIDC_AUDIENCE (usually the app's client ID),
+	// and users are identified by their OIDC_USER_ID_CLAIM claim
+	v.SetDefault("AUTH_PROVIDER", "clerk")
+	v.SetDefault("OIDC_ISSUER_URL", "")
+	v.SetDefault("OIDC_AUDIENCE", "")
+	v.SetDefault("OIDC_USER_ID_CLAIM", "sub")
 
-	v.SetDefault("CORS_ALLOWED_ORIGINS", "http://localhost:5173,http://localhost:3000")
+	// Where clicks are stored: postgres, clickhouse or none.
+	// Stats, exports and conversions read clicks from Postgres only.
+	v.SetDefault("ANALYTICS_BACKEND", "postgres")
+	// With the postgres backend, also write clicks to ClickHouse (best effort) while moving to it:
+	// turn it on, backfill the clicks from before with cmd/backfill, then switch the backend.
+	v.SetDefault("ANALYTICS_DOUBLE_WRITE", false)
+	// Apply pending ClickHouse migrations on startup; turn off where they're run with `task migrate-clickhouse`.
+	// Either way clicks are only written to ClickHouse once its tables and views exist.
+	v.SetDefault("CLICKHOUSE_MIGRATE", true)
+	// Clicks are written to ClickHouse in batches of up to CLICKHOUSE_BATCH_SIZE, at least every
+	// CLICKHOUSE_FLUSH_INTERVAL seconds. Up to CLICKHOUSE_QUEUE_SIZE clicks wait in memory while a
+	// batch is written; past that new clicks are dropped (clicks_dropped_total) rather than slowing redirects.
+	v.SetDefault("CLICKHOUSE_BATCH_SIZE", 1000)
+	v.SetDefault("CLICKHOUSE_FLUSH_INTERVAL", 1)
+	v.SetDefault("CLICKHOUSE_QUEUE_SIZE", 10000)
+	// CSV file of IP ranges and their countries (e.g. DB-IP's "IP to Country Lite"), loaded on startup
+	// to record the country of each click. Unset, the country isn't recorded.
+	v.SetDefault("GEOIP_COUNTRY_DB", "")
+	// Key of the daily visitor IDs unique visitors are counted by. Unset, a random one is
+	// used, so IDs change on restart and differ between instances.
+	v.SetDefault("VISITOR_ID_SECRET", "")
+
+	// Set per profile, see profileDefaults
+	v.SetDefault("CORS_ALLOWED_ORIGINS", "")
 	v.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
 	v.SetDefault("CORS_ALLOWED_HEADERS", "Accept,Authorization,Content-Type,X-CSRF-Token")
-	v.SetDefault("CORS_EXPOSED_HEADERS", "Link")
+	v.SetDefault("CORS_EXPOSED_HEADERS", "Link,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,X-Quota-Links-Remaining")
 	v.SetDefault("CORS_ALLOW_CREDENTIALS", true)
 	v.SetDefault("CORS_MAX_AGE", 300)
+	v.SetDefault("CORS_PUBLIC_ALLOWED_ORIGINS", "")
+
+	// Browser extension origins (e.g. chrome-extension://<id>) allowed to call /quick-shorten only
+	v.SetDefault("EXTENSION_ALLOWED_ORIGINS", "")
+
 	v.SetDefault("SERVER_READ_TIMEOUT", 15)
 	v.SetDefault("SERVER_WRITE_TIMEOUT", 15)
 	v.SetDefault("SERVER_IDLE_TIMEOUT", 60)
 
+	// Request body size (bytes) and handling time (seconds) of each route group; 0 removes a
+	// limit. Timeouts replace SERVER_WRITE_TIMEOUT for their group. Bulk routes take many items
+	// at once (QR batches, wrapping, bulk deletes); exports keep the API body limit.
+	v.SetDefault("REDIRECT_MAX_BODY_BYTES", 16<<10)
+	v.SetDefault("REDIRECT_TIMEOUT", 5)
+	v.SetDefault("API_MAX_BODY_BYTES", 1<<20)
+	v.SetDefault("API_TIMEOUT", 15)
+	v.SetDefault("BULK_MAX_BODY_BYTES", 10<<20)
+	v.SetDefault("BULK_TIMEOUT", 60)
+	v.SetDefault("EXPORT_TIMEOUT", 300)
+
+	// SO_REUSEPORT lets the next release bind the ports while this one drains
+	v.SetDefault("SERVER_REUSE_PORT", false)
+	// On SIGTERM readiness fails for SHUTDOWN_DRAIN_DELAY seconds, so load balancers stop routing
+	// here, then in-flight requests get up to SHUTDOWN_TIMEOUT seconds to finish
+	v.SetDefault("SHUTDOWN_DRAIN_DELAY", 0)
+	v.SetDefault("SHUTDOWN_TIMEOUT", 10)
+
+	v.SetDefault("HTTP2_CLEARTEXT", false)
+	v.SetDefault("TLS_ENABLED", false)
+	v.SetDefault("TLS_AUTOCERT", false)
+	v.SetDefault("TLS_AUTOCERT_CACHE_DIR", "certs")
+	v.SetDefault("TLS_CHALLENGE_PORT", 80)
+
+	// IPs or CIDRs of reverse proxies whose X-Forwarded-For identifies the client; empty trusts none
+	v.SetDefault("TRUSTED_PROXIES", "")
+
+	// User IDs allowed on the admin routes (/api/v1/admin/...), comma-separated; empty closes them to all
+	v.SetDefault("ADMIN_USER_IDS", "")
+
+	// Language of redirect pages (404, interstitial, ...) when Accept-Language has no supported match.
+	// DOMAIN_LANGUAGES overrides it per short domain, e.g. "go.example.de=de,go.example.fr=fr".
+	v.SetDefault("DEFAULT_LANGUAGE", "en")
+	v.SetDefault("DOMAIN_LANGUAGES", "")
+
+	// Shortcode enumeration guard (durations in seconds)
+	v.SetDefault("ENUMERATION_GUARD_ENABLED", true)
+	v.SetDefault("ENUMERATION_MAX_NOT_FOUND", 20)
+	v.SetDefault("ENUMERATION_WINDOW", 60)
+	v.SetDefault("ENUMERATION_BLOCK_DURATION", 900)
+
+	// Bot shield for links with shield mode; off without BOT_SHIELD_SECRET (32+ chars).
+	// BOT_SHIELD_DATACENTER_CIDRS lists hosting networks whose clients are challenged, e.g. the
+	// prefixes of cloud providers' ASNs. Over BOT_SHIELD_RATE_LIMIT requests to shielded links per
+	// BOT_SHIELD_RATE_WINDOW seconds (needs Redis) a client is challenged every time; 0 disables it.
+	// A solved challenge lets the client through for BOT_SHIELD_PASS_TTL minutes.
+	v.SetDefault("BOT_SHIELD_SECRET", "")
+	v.SetDefault("BOT_SHIELD_DATACENTER_CIDRS", "")
+	v.SetDefault("BOT_SHIELD_RATE_LIMIT", 30)
+	v.SetDefault("BOT_SHIELD_RATE_WINDOW", 60)
+	v.SetDefault("BOT_SHIELD_PASS_TTL", 30)
+
+	// "Did you mean" suggestions on 404 pages for shortcodes one typo away from a link (needs Redis).
+	// They reveal which shortcodes exist, so they're off by default and only cover the signed-in
+	// visitor's own links unless DID_YOU_MEAN_PUBLIC also includes everyone's public ones.
+	// A client gets at most DID_YOU_MEAN_RATE_LIMIT pages with suggestions per DID_YOU_MEAN_RATE_WINDOW
+	// seconds; 0 disables the limit.
+	v.SetDefault("DID_YOU_MEAN_ENABLED", false)
+	v.SetDefault("DID_YOU_MEAN_PUBLIC", false)
+	v.SetDefault("DID_YOU_MEAN_RATE_LIMIT", 10)
+	v.SetDefault("DID_YOU_MEAN_RATE_WINDOW", 60)
+
+	// Origin of the short_url of links in API responses and QR codes, e.g. "https://sho.rt".
+	// Empty uses the first SHORT_DOMAINS entry over https, else the API request's origin.
+	// SHORT_URL_BASE is its former name, still read when BASE_SHORT_URL isn't set.
+	v.SetDefault("BASE_SHORT_URL", "")
+	v.SetDefault("SHORT_URL_BASE", "")
+
+	// Page reserved shortcodes redirect to until a link is created with them; empty serves a built-in page
+	v.SetDefault("RESERVED_PLACEHOLDER_URL", "")
+
+	// robots.txt disallows crawling shortcodes (link-preview bots excepted) unless ROBOTS_ALLOW_CRAWLING.
+	// ROBOTS_SITEMAP_URL adds a Sitemap line; FAVICON_URL is where icon requests are redirected, else they 404.
+	v.SetDefault("ROBOTS_ALLOW_CRAWLING", false)
+	v.SetDefault("ROBOTS_SITEMAP_URL", "")
+	v.SetDefault("FAVICON_URL", "")
+
+	// Directory served under /.well-known/ on every host: ACME tokens in acme-challenge/, app association
+	// files at the top level, per-domain ones in domains/<host>/. Empty answers every such path with a 404.
+	v.SetDefault("WELL_KNOWN_DIR", "")
+
+	// Slack /shorten command; empty disables it. SLACK_LINK_URL is the web app page that
+	// redeems the token (?token=) connecting a Slack user to their account.
+	v.SetDefault("SLACK_SIGNING_SECRET", "")
+	v.SetDefault("SLACK_LINK_URL", "")
+
+	// Apply tag suggestions to new links unless the request sets auto_tag
+	v.SetDefault("AUTO_TAG_LINKS", false)
+
+	// URL normalization: tracking parameters removed before storage ("utm_*" matches by prefix)
+	v.SetDefault("URL_STRIP_PARAMS", strings.Join(urlnorm.DefaultStripParams, ","))
+	v.SetDefault("URL_SORT_QUERY_PARAMS", true)
+
+	// Seconds in which identical creates return the first link (needs Redis); 0 disables it
+	v.SetDefault("CREATE_DEDUPE_WINDOW", 0)
+
+	// Per-user API requests allowed per window (window in seconds, needs Redis); 0 disables it
+	v.SetDefault("API_RATE_LIMIT", 600)
+	v.SetDefault("API_RATE_LIMIT_WINDOW", 60)
+
+	// Most links a user can have; 0 means unlimited
+	v.SetDefault("LINK_QUOTA", 0)
+
+	// Most tags a user can have; 0 means unlimited. Tag names are unique per user without case and have
+	// their whitespace collapsed; TAG_SLUGS also turns them into slugs ("Q3 Launch" becomes "q3-launch")
+	v.SetDefault("TAG_QUOTA", 0)
+	v.SetDefault("TAG_SLUGS", false)
+
+	// Link policy of the deployment, enforced on every link created or updated. Destinations must be on
+	// one of LINK_POLICY_ALLOWED_DOMAINS (subdomains included; empty allows all) and on none of
+	// LINK_POLICY_DENIED_DOMAINS. Custom shortcodes can't match any of the LINK_POLICY_FORBIDDEN_SHORTCODES
+	// regular expressions, links must carry all LINK_POLICY_REQUIRED_TAGS, and they expire at most
+	// LINK_POLICY_MAX_EXPIRY_DAYS days after they're saved (0 means no limit)
+	v.SetDefault("LINK_POLICY_ALLOWED_DOMAINS", "")
+	v.SetDefault("LINK_POLICY_DENIED_DOMAINS", "")
+	v.SetDefault("LINK_POLICY_FORBIDDEN_SHORTCODES", "")
+	v.SetDefault("LINK_POLICY_REQUIRED_TAGS", "")
+	v.SetDefault("LINK_POLICY_MAX_EXPIRY_DAYS", 0)
+
+	// Signs the cursors of cursor-paginated endpoints (32+ chars). Empty uses a random key per
+	// process: cursors then break on restart and across instances
+	v.SetDefault("PAGINATION_SECRET", "")
+
+	// Destination URLs are stored encrypted (AES-256-GCM) with the keys of URL_ENCRYPTION_KEYS,
+	// "id:base64 key,...", each key 32 bytes, or of the file at URL_ENCRYPTION_KEYS_FILE, e.g. one
+	// written by a KMS or secrets agent; empty stores them in plain text (postgres storage only).
+	// The first key encrypts, the others only decrypt: to rotate, put a new key first, restart,
+	// then run cmd/encrypturls, which also encrypts the rows stored before encryption was turned on.
+	// The HTTPS upgrade job finds http:// destinations by a flag set on write, as it can't match
+	// encrypted ones.
+	v.SetDefault("URL_ENCRYPTION_KEYS", "")
+	v.SetDefault("URL_ENCRYPTION_KEYS_FILE", "")
+
+	// Minutes between daily stats rollup runs; 0 disables the job and stats are counted from raw clicks
+	v.SetDefault("STATS_ROLLUP_INTERVAL", 60)
+
+	// Minutes between click anomaly checks (postgres analytics only); 0 disables them. Each check compares
+	// the last complete hour against the ANOMALY_WINDOW_HOURS before it and flags it when it's more than
+	// ANOMALY_Z_THRESHOLD standard deviations off. ANOMALY_WEBHOOK_URL also receives every new anomaly.
+	v.SetDefault("ANOMALY_CHECK_INTERVAL", 60)
+	v.SetDefault("ANOMALY_WINDOW_HOURS", 24)
+	v.SetDefault("ANOMALY_Z_THRESHOLD", 3)
+	v.SetDefault("ANOMALY_MIN_CLICKS", 10)
+	v.SetDefault("ANOMALY_WEBHOOK_URL", "")
+
+	// Traffic caps are counted in Redis; their counters are copied to the database every
+	// TRAFFIC_CAP_SYNC_INTERVAL seconds (0 disables it, then they're only seeded from it)
+	v.SetDefault("TRAFFIC_CAP_SYNC_INTERVAL", 60)
+
+	// Links' running click counts, shown in link responses, are counted in Redis on every redirect and
+	// added to Postgres every CLICK_COUNT_FLUSH_INTERVAL seconds and on shutdown (0 disables the counts)
+	v.SetDefault("CLICK_COUNT_FLUSH_INTERVAL", 10)
+
+	// Scheduled destination changes of dynamic links are applied every
+	// DESTINATION_SCHEDULE_INTERVAL seconds (0 disables it)
+	v.SetDefault("DESTINATION_SCHEDULE_INTERVAL", 60)
+
+	// Every HTTPS_UPGRADE_INTERVAL minutes (0 disables it), a batch of http:// destinations is checked
+	// for a reachable https:// variant, again HTTPS_UPGRADE_RECHECK_DAYS after their last check. Users
+	// see the outcome in their HTTPS upgrade report; with HTTPS_UPGRADE_APPLY the links are also switched.
+	v.SetDefault("HTTPS_UPGRADE_INTERVAL", 0)
+	v.SetDefault("HTTPS_UPGRADE_APPLY", false)
+	v.SetDefault("HTTPS_UPGRADE_RECHECK_DAYS", 30)
+
+	// Every DESTINATION_MONITOR_INTERVAL minutes (0 disables it), a batch of destination pages is fetched,
+	// each again DESTINATION_RECHECK_HOURS after its last check, and owners are alerted when one starts
+	// redirecting to another host, returns 404 or is parked
+	v.SetDefault("DESTINATION_MONITOR_INTERVAL", 0)
+	v.SetDefault("DESTINATION_RECHECK_HOURS", 24)
+
+	// Every ACTIVITY_VERIFY_INTERVAL minutes (0 disables it), every user's activity hash chain is
+	// recomputed and broken ones are logged as errors
+	v.SetDefault("ACTIVITY_VERIFY_INTERVAL", 0)
+
+	// Exports and stats aggregation share EXPENSIVE_MAX_CONCURRENCY weight units (an export
+	// weighs 4, stats 1; 0 disables throttling). Up to EXPENSIVE_MAX_QUEUE more wait at most
+	// EXPENSIVE_QUEUE_TIMEOUT seconds for room, and each user gets EXPENSIVE_MAX_PER_USER at a time.
+	v.SetDefault("EXPENSIVE_MAX_CONCURRENCY", 8)
+	v.SetDefault("EXPENSIVE_MAX_QUEUE", 16)
+	v.SetDefault("EXPENSIVE_QUEUE_TIMEOUT", 10)
+	v.SetDefault("EXPENSIVE_MAX_PER_USER", 2)
+
+	// The server is saturated while Postgres connections took over LOAD_SHED_POOL_WAIT ms on
+	// average to acquire, or over LOAD_SHED_GOROUTINES goroutines run (0 disables a signal),
+	// sampled every LOAD_SHED_INTERVAL ms. API requests then wait up to LOAD_SHED_MAX_DELAY ms
+	// for it to recover before they're shed; redirects are never held back.
+	v.SetDefault("LOAD_SHED_POOL_WAIT", 100)
+	v.SetDefault("LOAD_SHED_GOROUTINES", 10000)
+	v.SetDefault("LOAD_SHED_MAX_DELAY", 500)
+	v.SetDefault("LOAD_SHED_INTERVAL", 1000)
+
+	// Shape of JSON responses: field naming (snake_case or camelCase) and whether empty optionals
+	// are sent as null (include) or left out (omit). Clients can pick their own with an Accept
+	// profile, e.g. application/json; profile="camelCase omit-nulls"
+	v.SetDefault("JSON_FIELD_NAMING", "snake_case")
+	v.SetDefault("JSON_NULLS", "include")
+
+	// Webhooks whose deliveries keep failing for WEBHOOK_DISABLE_AFTER_DAYS days are disabled
+	// and their owners notified in their activity feed (0 never disables them)
+	v.SetDefault("WEBHOOK_DISABLE_AFTER_DAYS", 3)
+	// Every WEBHOOK_RETRY_INTERVAL seconds (0 disables it), failed deliveries of link events
+	// whose retry is due are sent again, and the pending ones their webhook had no room for sent
+	v.SetDefault("WEBHOOK_RETRY_INTERVAL", 30)
+
+	// Outbound requests to URLs users give (webhooks, publish callbacks) can't reach private,
+	// loopback or link-local addresses, so they can't be aimed at internal services. Set
+	// OUTBOUND_ALLOW_PRIVATE for local development against endpoints on localhost
+	v.SetDefault("OUTBOUND_ALLOW_PRIVATE", false)
+
+	// Outbound checks resolve names through a cache, keeping answers for their TTL but at most
+	// DNS_CACHE_MAX_TTL seconds, and names that don't exist at most DNS_CACHE_NEGATIVE_TTL seconds
+	v.SetDefault("DNS_CACHE_MAX_TTL", 300)
+	v.SetDefault("DNS_CACHE_NEGATIVE_TTL", 30)
+
+	// SERVER_MODE edge runs a redirect-only instance for another region: no API, redirects resolved
+	// from a local cache of the primary's redirect cache (read from REDIS_URL, which can be a replica
+	// of the primary's Redis), kept fresh by its invalidations and a snapshot every
+	// EDGE_SNAPSHOT_INTERVAL seconds; entries live EDGE_CACHE_TTL seconds at most, which bounds
+	// how stale a missed invalidation leaves them. Misses and everything else are forwarded to the
+	// primary's EDGE_PRIMARY_URL, and clicks to its internal port at EDGE_PRIMARY_INTERNAL_URL,
+	// authenticated with EDGE_TOKEN. The primary accepts clicks when it has the same EDGE_TOKEN,
+	// and must list its edges in TRUSTED_PROXIES
+	v.SetDefault("SERVER_MODE", "primary")
+	v.SetDefault("EDGE_TOKEN", "")
+	v.SetDefault("EDGE_PRIMARY_URL", "")
+	v.SetDefault("EDGE_PRIMARY_INTERNAL_URL", "")
+	v.SetDefault("EDGE_SNAPSHOT_INTERVAL", 60)
+	v.SetDefault("EDGE_CACHE_TTL", 300)
+	// EDGE_SNAPSHOT_FILE is a mapping snapshot (GET /api/v1/admin/snapshot) an edge loads on start,
+	// before its first snapshot of the redirect cache, so it serves the links in it straight away
+	v.SetDefault("EDGE_SNAPSHOT_FILE", "")
+
+	// With BACKUP_S3_BUCKET set, the Postgres metadata is backed up every BACKUP_INTERVAL hours
+	// (0 only allows manual backups with task backup) to that bucket of the S3-compatible service
+	// at BACKUP_S3_ENDPOINT (e.g. https://s3.eu-west-1.amazonaws.com or a MinIO at
+	// http://localhost:9000), under BACKUP_PREFIX. Backups older than BACKUP_RETENTION_DAYS days
+	// are deleted, except the latest (0 keeps them all). Raw clicks are left out unless
+	// BACKUP_INCLUDE_CLICKS is set. task restore-backup restores one into a freshly migrated database
+	v.SetDefault("BACKUP_S3_ENDPOINT", "")
+	v.SetDefault("BACKUP_S3_REGION", "us-east-1")
+	v.SetDefault("BACKUP_S3_BUCKET", "")
+	v.SetDefault("BACKUP_S3_ACCESS_KEY_ID", "")
+	v.SetDefault("BACKUP_S3_SECRET_ACCESS_KEY", "")
+	v.SetDefault("BACKUP_PREFIX", "")
+	v.SetDefault("BACKUP_INTERVAL", 24)
+	v.SetDefault("BACKUP_RETENTION_DAYS", 30)
+	v.SetDefault("BACKUP_INCLUDE_CLICKS", false)
+
+	// Where generated files users download later (background clicks exports) are kept:
+	// "disk" keeps them in ASSETS_DIR (empty uses the system temp dir; EXPORT_DIR is its former
+	// name, still read when ASSETS_DIR isn't set) and serves them from the instance that wrote
+	// them. "s3" keeps them in ASSETS_S3_BUCKET of the S3-compatible service at ASSETS_S3_ENDPOINT,
+	// under ASSETS_PREFIX, and redirects downloads to presigned URLs valid for
+	// ASSETS_DOWNLOAD_URL_TTL seconds, on ASSETS_S3_PUBLIC_ENDPOINT when clients reach the service
+	// elsewhere (e.g. a MinIO in docker compose). Expired files are deleted every
+	// ASSETS_CLEANUP_INTERVAL minutes.
+	v.SetDefault("ASSETS_STORAGE", "disk")
+	v.SetDefault("ASSETS_DIR", "")
+	v.SetDefault("EXPORT_DIR", "")
+	v.SetDefault("ASSETS_S3_ENDPOINT", "")
+	v.SetDefault("ASSETS_S3_PUBLIC_ENDPOINT", "")
+	v.SetDefault("ASSETS_S3_REGION", "us-east-1")
+	v.SetDefault("ASSETS_S3_BUCKET", "")
+	v.SetDefault("ASSETS_S3_ACCESS_KEY_ID", "")
+	v.SetDefault("ASSETS_S3_SECRET_ACCESS_KEY", "")
+	v.SetDefault("ASSETS_PREFIX", "")
+	v.SetDefault("ASSETS_DOWNLOAD_URL_TTL", 300)
+	v.SetDefault("ASSETS_CLEANUP_INTERVAL", 60)
+
 	v.SetDefault("REDIS_DB", 0)
 	v.SetDefault("REDIS_DIAL_TIMEOUT", 5)
 	v.SetDefault("REDIS_READ_TIMEOUT", 3)
@@ -137,14 +743,43 @@ func Load() (*Config, error) {
 		return cfg, fmt.Errorf(".env file not found: %w", err)
 	}
 
+	// APP_ENV selects the profile: its defaults, then .env.<profile> on top of .env
+	profile, err := resolveProfile(v.GetString("APP_ENV"))
+	if err != nil {
+		return cfg, err
+	}
+	if err := applyProfile(v, profile); err != nil {
+		return cfg, err
+	}
+
 	if err := v.Unmarshal(cfg); err != nil {
 		return cfg, fmt.Errorf("Failed to unmarshal config: %w", err)
 	}
+	cfg.AppEnv = profile
 
 	cfg.CORSAllowedOrigins = parseCommaSeparated(v.GetString("CORS_ALLOWED_ORIGINS"))
 	cfg.CORSAllowedMethods = parseCommaSeparated(v.GetString("CORS_ALLOWED_METHODS"))
 	cfg.CORSAllowedHeaders = parseCommaSeparated(v.GetString("CORS_ALLOWED_HEADERS"))
 	cfg.CORSExposedHeaders = parseCommaSeparated(v.GetString("CORS_EXPOSED_HEADERS"))
+	cfg.CORSPublicAllowedOrigins = parseCommaSeparated(v.GetString("CORS_PUBLIC_ALLOWED_ORIGINS"))
+	cfg.ExtensionAllowedOrigins = parseCommaSeparated(v.GetString("EXTENSION_ALLOWED_ORIGINS"))
+	cfg.ShortDomains = parseCommaSeparated(v.GetString("SHORT_DOMAINS"))
+	if cfg.BaseShortURL == "" {
+		cfg.BaseShortURL = v.GetString("SHORT_URL_BASE")
+	}
+	if cfg.AssetsDir == "" {
+		cfg.AssetsDir = v.GetString("EXPORT_DIR")
+	}
+	cfg.TrustedProxies = parseCommaSeparated(v.GetString("TRUSTED_PROXIES"))
+	cfg.AdminUserIDs = parseCommaSeparated(v.GetString("ADMIN_USER_IDS"))
+	cfg.BotShieldDatacenterCIDRs = parseCommaSeparated(v.GetString("BOT_SHIELD_DATACENTER_CIDRS"))
+	cfg.DomainLanguages = parseCommaSeparated(v.GetString("DOMAIN_LANGUAGES"))
+	cfg.URLStripParams = parseCommaSeparated(v.GetString("URL_STRIP_PARAMS"))
+	cfg.TLSAutocertHosts = parseCommaSeparated(v.GetString("TLS_AUTOCERT_HOSTS"))
+	cfg.LinkPolicyAllowedDomains = parseCommaSeparated(v.GetString("LINK_POLICY_ALLOWED_DOMAINS"))
+	cfg.LinkPolicyDeniedDomains = parseCommaSeparated(v.GetString("LINK_POLICY_DENIED_DOMAINS"))
+	cfg.LinkPolicyForbiddenCodes = parseCommaSeparated(v.GetString("LINK_POLICY_FORBIDDEN_SHORTCODES"))
+	cfg.LinkPolicyRequiredTags = parseCommaSeparated(v.GetString("LINK_POLICY_REQUIRED_TAGS"))
 
 	if err := validateConfig(cfg); err != nil {
 		return cfg, fmt.Errorf("Config validation failed: %w", err)
@@ -152,3 +787,15 @@ func Load() (*Config, error) {
 
 	return cfg, nil
 }
+
+// ShortURLBase returns the origin the short_url of links is built on: BASE_SHORT_URL, else the first
+// of SHORT_DOMAINS over https. Empty means each API request's own origin.
+func (c *Config) ShortURLBase() string {
+	if c.BaseShortURL != "" {
+		return strings.TrimRight(c.BaseShortURL, "/")
+	}
+	if len(c.ShortDomains) > 0 {
+		return "https://" + c.ShortDomains[0]
+	}
+	return ""
+}
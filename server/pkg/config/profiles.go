@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Profiles selectable with APP_ENV
+const (
+	// Development without Postgres or Redis: links and tags in memory, an in-process Redis
+	ProfileLocal       = "local"
+	ProfileDevelopment = "development"
+	ProfileStaging     = "staging"
+	ProfileProduction  = "production"
+)
+
+// APP_ENV values and the profile they select
+var profileNames = map[string]string{
+	"local":       ProfileLocal,
+	"dev":         ProfileDevelopment,
+	"development": ProfileDevelopment,
+	"staging":     ProfileStaging,
+	"prod":        ProfileProduction,
+	"production":  ProfileProduction,
+}
+
+/*
+profileDefaults replace the base defaults set in Load for one profile. Settings
+in .env apply to every profile, and .env.<profile> (e.g. .env.production)
+overrides them for its own.
+*/
+var profileDefaults = map[string]map[string]any{
+	ProfileLocal: {
+		"CORS_ALLOWED_ORIGINS": "http://localhost:5173,http://localhost:3000",
+		"STORAGE_BACKEND":      "memory",
+		"ANALYTICS_BACKEND":    "none",
+	},
+	ProfileDevelopment: {
+		// The web app's dev servers
+		"CORS_ALLOWED_ORIGINS": "http://localhost:5173,http://localhost:3000",
+	},
+	ProfileStaging: {
+		"CORS_ALLOWED_ORIGINS": "",
+	},
+	ProfileProduction: {
+		"CORS_ALLOWED_ORIGINS": "",
+	},
+}
+
+// Shown instead of the value of secret settings in Dump
+const redacted = "[REDACTED]"
+
+// resolveProfile maps APP_ENV to its profile
+func resolveProfile(appEnv string) (string, error) {
+	profile, ok := profileNames[strings.ToLower(strings.TrimSpace(appEnv))]
+	if !ok {
+		return "", fmt.Errorf("APP_ENV must be one of: local, dev, staging, prod (got %q)", appEnv)
+	}
+	return profile, nil
+}
+
+// applyProfile sets the profile's defaults and merges its .env.<profile> file, if there is one
+func applyProfile(v *viper.Viper, profile string) error {
+	for key, value := range profileDefaults[profile] {
+		v.SetDefault(key, value)
+	}
+
+	v.SetConfigName(".env." + profile)
+	if err := v.MergeInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to read .env.%s: %w", profile, err)
+	}
+
+	return nil
+}
+
+/*
+validateProduction rejects settings that are fine while developing but unsafe
+on a public deployment: CORS open to any origin, or to local or plain-HTTP
+ones, trusting X-Forwarded-For from every address, and in-memory storage.
+*/
+func validateProduction(c *Config) error {
+	if c.AppEnv != ProfileProduction {
+		return nil
+	}
+
+	var errs []error
+
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			errs = append(errs, errors.New("CORSAllowedOrigins must list origins in production, not *"))
+			continue
+		}
+		if u, err := url.Parse(origin); err == nil && (u.Scheme != "https" || isLocalHost(u.Hostname())) {
+			errs = append(errs, fmt.Errorf("CORSAllowedOrigins must not include local or plain-HTTP origins in production: %s", origin))
+		}
+	}
+
+	if slices.Contains(c.TrustedProxies, "0.0.0.0/0") || slices.Contains(c.TrustedProxies, "::/0") {
+		errs = append(errs, errors.New("TrustedProxies must not include every address in production"))
+	}
+
+	if c.StorageBackend == "memory" {
+		errs = append(errs, errors.New("StorageBackend memory loses all links on restart, use postgres in production"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || strings.HasSuffix(host, ".localhost") || host == "127.0.0.1" || host == "::1"
+}
+
+/*
+Dump returns the configuration keyed by setting name, for the internal config
+endpoint. Secret settings (tagged redact:"true") show as [REDACTED] when set
+and as empty when not, so it's visible whether they're configured. Connection
+URLs (tagged redact:"userinfo") keep their host but not the credentials they
+may embed, e.g. redis://[REDACTED]@host:6379.
+*/
+func (c *Config) Dump() map[string]any {
+	dump := make(map[string]any)
+
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+
+		value := v.Field(i).Interface()
+		switch field.Tag.Get("redact") {
+		case "true":
+			if !v.Field(i).IsZero() {
+				value = redacted
+			}
+		case "userinfo":
+			value = redactUserinfo(v.Field(i).String())
+		}
+		dump[name] = value
+	}
+
+	return dump
+}
+
+// redactUserinfo hides the credentials of a URL. A value that can't be parsed
+// but has an @ may still hold them, so it's hidden whole.
+func redactUserinfo(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || (u.User == nil && strings.Contains(value, "@")) {
+		return redacted
+	}
+	if u.User == nil {
+		return value
+	}
+
+	u.User = nil
+	return strings.Replace(u.String(), "//", "//"+redacted+"@", 1)
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Queries of backups and restores (see service.BackupService). Table names can't be query
+// parameters, so they're written by hand; the names only ever come from BackupTables.
+
+// BackupTable is a table backups hold
+type BackupTable struct {
+	Name string
+	// Order rows are exported, and so restored, in when it matters: after the rows they reference
+	// in the same table, and for activity_events in the order of its hash chains
+	OrderBy string
+	// BIGSERIAL column whose sequence a restore moves past the restored rows
+	Serial string
+	// Raw click data, only backed up when asked to (see BackupOptions.IncludeClicks)
+	Clicks bool
+}
+
+/*
+BackupTables are the tables backups hold, in an order their rows can be inserted
+in (referenced tables first, so in the order of the migrations creating them).
+A table added by a migration must be added here too, or backups leave it out.
+schema_migrations isn't backed up: a restore goes to a database at the same
+migration as the backup.
+*/
+var BackupTables = []BackupTable{
+	{Name: "links", OrderBy: "merged_into IS NOT NULL, id"},
+	{Name: "tags"},
+	{Name: "link_tags"},
+	{Name: "clicks", OrderBy: "id", Serial: "id", Clicks: true},
+	{Name: "campaigns"},
+	{Name: "campaign_links"},
+	{Name: "link_leads", OrderBy: "id", Serial: "id"},
+	{Name: "conversions", OrderBy: "id", Serial: "id", Clicks: true},
+	{Name: "link_daily_stats"},
+	{Name: "stats_rollup_state"},
+	{Name: "slack_accounts"},
+	{Name: "publish_hooks"},
+	{Name: "link_previews"},
+	{Name: "shortcode_reservations"},
+	{Name: "link_comments"},
+	// Inserting an event chains it after the user's last one, recomputing the seq and hashes
+	// it was exported with: in seq order they come out the same
+	{Name: "activity_events", OrderBy: "user_id, seq"},
+	{Name: "link_anomalies"},
+	{Name: "link_traffic_caps"},
+	{Name: "link_waiting_rooms"},
+	{Name: "webhooks"},
+	// Retries reference the delivery they retry, which is older
+	{Name: "webhook_deliveries", OrderBy: "created_at, id"},
+	{Name: "dynamic_links"},
+	{Name: "link_destination_changes"},
+	{Name: "verified_senders"},
+	{Name: "link_response_headers"},
+	{Name: "link_https_upgrades"},
+	{Name: "link_destination_checks"},
+	{Name: "link_destination_alerts"},
+	{Name: "link_public_stats"},
+	{Name: "service_accounts"},
+	{Name: "link_click_counts"},
+	{Name: "api_keys"},
+}
+
+// LookupBackupTable returns the BackupTables entry of the table name
+func LookupBackupTable(name string) (BackupTable, bool) {
+	for _, t := range BackupTables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return BackupTable{}, false
+}
+
+const beginBackupSnapshot = `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`
+
+// BeginBackupSnapshot makes the transaction it's the first statement of read one snapshot of the
+// database, so a backup's tables are consistent with each other
+func (q *Queries) BeginBackupSnapshot(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, beginBackupSnapshot)
+	return err
+}
+
+const getSchemaVersion = `SELECT COALESCE(MAX(version), '')::TEXT FROM schema_migrations`
+
+// GetSchemaVersion returns the last migration applied (see scripts/migrate-up.sh)
+func (q *Queries) GetSchemaVersion(ctx context.Context) (string, error) {
+	row := q.db.QueryRow(ctx, getSchemaVersion)
+	var version string
+	err := row.Scan(&version)
+	return version, err
+}
+
+// ExportBackupTable calls fn with each row of the table, as a JSON object
+func (q *Queries) ExportBackupTable(ctx context.Context, table BackupTable, fn func(row []byte) error) error {
+	query := fmt.Sprintf(`SELECT row_to_json(t)::TEXT AS row FROM %s AS t`, table.Name)
+	if table.OrderBy != "" {
+		query += " ORDER BY " + table.OrderBy
+	}
+
+	rows, err := q.db.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// IsBackupTableEmpty reports whether the table has no rows
+func (q *Queries) IsBackupTableEmpty(ctx context.Context, table BackupTable) (bool, error) {
+	row := q.db.QueryRow(ctx, fmt.Sprintf(`SELECT NOT EXISTS (SELECT 1 FROM %s)`, table.Name))
+	var empty bool
+	err := row.Scan(&empty)
+	return empty, err
+}
+
+// RestoreBackupRows inserts rows of the table, JSON objects as ExportBackupTable gives them, in their order
+func (q *Queries) RestoreBackupRows(ctx context.Context, table BackupTable, rows [][]byte) error {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(row)
+	}
+	b.WriteByte(']')
+
+	// COPY FROM would be faster, but row-level security rules it out
+	_, err := q.db.Exec(ctx, fmt.Sprintf(`INSERT INTO %[1]s
+SELECT r.* FROM json_array_elements($1::JSON) WITH ORDINALITY AS e(row, n)
+CROSS JOIN LATERAL json_populate_record(NULL::%[1]s, e.row) AS r
+ORDER BY e.n`, table.Name), b.String())
+	return err
+}
+
+// ResetBackupSerial moves the sequence of the table's Serial column past the rows it holds
+func (q *Queries) ResetBackupSerial(ctx context.Context, table BackupTable) error {
+	if table.Serial == "" {
+		return nil
+	}
+	_, err := q.db.Exec(ctx, fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), COALESCE(MAX(%[2]s), 0) + 1, false) FROM %[1]s`,
+		table.Name, table.Serial))
+	return err
+}
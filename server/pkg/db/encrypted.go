@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/urlcrypt"
+)
+
+// encryptedArgs lists, by query, the positions ($n) of the arguments holding destination URLs.
+// TestEncryptedArgs checks them against the generated queries, and that no query passing a
+// URL is missing.
+var encryptedArgs = map[string][]int{
+	"TryCreateLink":                 {2, 9},
+	"GetUserLinkByURL":              {2},
+	"ChangeLinkDestination":         {3, 4},
+	"ScheduleLinkDestinationChange": {3, 4},
+	"UpsertDestinationCheck":        {2, 3},
+	"CreateDestinationAlert":        {4, 5},
+	"UpgradeLinkToHTTPS":            {1, 2, 4},
+	"UpsertHTTPSUpgradeCheck":       {3, 4},
+}
+
+// encryptedColumns are the result columns that can hold destination URLs
+var encryptedColumns = map[string]bool{
+	"original_url": true,
+	"raw_url":      true,
+	"url":          true,
+	"previous_url": true,
+	"final_url":    true,
+	"destination":  true,
+	"checked_url":  true,
+	"from_url":     true,
+	"to_url":       true,
+}
+
+// EncryptedDB is a DBTX whose queries store destination URLs encrypted, see Encrypted
+type EncryptedDB struct {
+	db interface {
+		DBTX
+		TxBeginner
+	}
+	keys *urlcrypt.Keyring
+}
+
+/*
+Encrypted wraps db so destination URLs are encrypted with the keyring on their
+way in and decrypted on their way out: the queries and the services using them
+only ever see plain URLs. Encryption is deterministic, so queries comparing or
+grouping URLs keep working; ones that look inside them (tag suggestions by host)
+find nothing, which is why the HTTPS upgrade job goes by links.http_destination.
+
+Values that aren't encrypted are read as they are, so rows written before
+encryption was turned on keep working until cmd/encrypturls rewrites them.
+*/
+func Encrypted(db interface {
+	DBTX
+	TxBeginner
+}, keys *urlcrypt.Keyring) *EncryptedDB {
+	return &EncryptedDB{db: db, keys: keys}
+}
+
+func (e *EncryptedDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return e.db.Exec(ctx, sql, encryptArgs(e.keys, sql, args)...)
+}
+
+func (e *EncryptedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return queryDecrypted(ctx, e.db, e.keys, sql, args)
+}
+
+func (e *EncryptedDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return queryRowDecrypted(ctx, e.db, e.keys, sql, args)
+}
+
+// Begin starts a transaction whose queries are encrypted too, so Store.WithTx keeps encrypting
+func (e *EncryptedDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := e.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{Tx: tx, keys: e.keys}, nil
+}
+
+// encryptedTx is a transaction of an EncryptedDB. Methods sqlc doesn't use go to the embedded transaction.
+type encryptedTx struct {
+	pgx.Tx
+	keys *urlcrypt.Keyring
+}
+
+func (tx *encryptedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return tx.Tx.Exec(ctx, sql, encryptArgs(tx.keys, sql, args)...)
+}
+
+func (tx *encryptedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return queryDecrypted(ctx, tx.Tx, tx.keys, sql, args)
+}
+
+func (tx *encryptedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return queryRowDecrypted(ctx, tx.Tx, tx.keys, sql, args)
+}
+
+func (tx *encryptedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	nested, err := tx.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{Tx: nested, keys: tx.keys}, nil
+}
+
+func queryDecrypted(ctx context.Context, db DBTX, keys *urlcrypt.Keyring, sql string, args []interface{}) (pgx.Rows, error) {
+	rows, err := db.Query(ctx, sql, encryptArgs(keys, sql, args)...)
+	if err != nil {
+		return rows, err
+	}
+	return &decryptedRows{Rows: rows, keys: keys}, nil
+}
+
+// queryRowDecrypted runs QueryRow through Query, which tells the result's column names
+func queryRowDecrypted(ctx context.Context, db DBTX, keys *urlcrypt.Keyring, sql string, args []interface{}) pgx.Row {
+	rows, err := queryDecrypted(ctx, db, keys, sql, args)
+	return firstRow{rows: rows, err: err}
+}
+
+// encryptArgs returns args with the destination URLs of the query encrypted
+func encryptArgs(keys *urlcrypt.Keyring, sql string, args []interface{}) []interface{} {
+	positions := encryptedArgs[queryName(sql)]
+	if len(positions) == 0 {
+		return args
+	}
+
+	encrypted := make([]interface{}, len(args))
+	copy(encrypted, args)
+	for _, pos := range positions {
+		if pos > len(encrypted) {
+			continue
+		}
+		switch v := encrypted[pos-1].(type) {
+		case string:
+			encrypted[pos-1] = keys.Encrypt(v)
+		case *string:
+			if v != nil {
+				s := keys.Encrypt(*v)
+				encrypted[pos-1] = &s
+			}
+		}
+	}
+	return encrypted
+}
+
+// queryName returns the name sqlc gives a query in its leading "-- name: X :kind" comment
+func queryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// decryptedRows decrypts the destination URLs scanned from its rows
+type decryptedRows struct {
+	pgx.Rows
+	keys *urlcrypt.Keyring
+}
+
+func (r *decryptedRows) Scan(dest ...any) error {
+	if err := r.Rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	fields := r.Rows.FieldDescriptions()
+	for i, d := range dest {
+		if i >= len(fields) || !encryptedColumns[fields[i].Name] {
+			continue
+		}
+		if err := decryptDest(r.keys, d); err != nil {
+			return fmt.Errorf("failed to decrypt column %s: %w", fields[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func decryptDest(keys *urlcrypt.Keyring, dest any) error {
+	switch d := dest.(type) {
+	case *string:
+		plain, err := keys.Decrypt(*d)
+		if err != nil {
+			return err
+		}
+		*d = plain
+	case **string:
+		if *d == nil {
+			return nil
+		}
+		plain, err := keys.Decrypt(**d)
+		if err != nil {
+			return err
+		}
+		*d = &plain
+	}
+	return nil
+}
+
+// firstRow is the first row of a query, scanned the way pgx scans QueryRow results
+type firstRow struct {
+	rows pgx.Rows
+	err  error
+}
+
+func (r firstRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	r.rows.Close()
+	return r.rows.Err()
+}
@@ -0,0 +1,292 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/urlcrypt"
+)
+
+// recordingConn records the arguments of the queries run on it and answers them with
+// its rows. It's also its own transaction; methods the tests don't use are left to the
+// embedded (nil) interface.
+type recordingConn struct {
+	pgx.Tx
+	args    [][]any
+	columns []string
+	rows    [][]any
+}
+
+func (c *recordingConn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	c.args = append(c.args, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *recordingConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.args = append(c.args, args)
+	return &fakeRows{columns: c.columns, rows: c.rows, index: -1}, nil
+}
+
+func (c *recordingConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	panic("QueryRow called, want it run through Query")
+}
+
+func (c *recordingConn) Begin(ctx context.Context) (pgx.Tx, error) { return c, nil }
+func (c *recordingConn) Commit(ctx context.Context) error          { return nil }
+func (c *recordingConn) Rollback(ctx context.Context) error        { return nil }
+
+type fakeRows struct {
+	pgx.Rows
+	columns []string
+	rows    [][]any
+	index   int
+}
+
+func (r *fakeRows) Next() bool { r.index++; return r.index < len(r.rows) }
+func (r *fakeRows) Close()     {}
+func (r *fakeRows) Err() error { return nil }
+func (r *fakeRows) Scan(dest ...any) error {
+	for i, value := range r.rows[r.index] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, name := range r.columns {
+		fields[i].Name = name
+	}
+	return fields
+}
+
+func testKeyring(t *testing.T) *urlcrypt.Keyring {
+	t.Helper()
+	keys, err := urlcrypt.ParseKeys("k1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", urlcrypt.KeySize))))
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	return keys
+}
+
+func ptr(s string) *string { return &s }
+
+func TestEncrypted_EncryptsArgs(t *testing.T) {
+	keys := testKeyring(t)
+	conn := &recordingConn{}
+	q := New(Encrypted(conn, keys))
+
+	_, err := q.TryCreateLink(context.Background(), TryCreateLinkParams{
+		Shortcode:   "abc123",
+		OriginalUrl: "https://example.com/",
+		UserID:      "user_123",
+		RawUrl:      ptr("https://example.com/{path}"),
+		Title:       ptr("https://example.com/"),
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("TryCreateLink() error = %v, want %v", err, pgx.ErrNoRows)
+	}
+
+	args := conn.args[0]
+	if args[0] != "abc123" || *args[10].(*string) != "https://example.com/" {
+		t.Errorf("shortcode and title = %v, %v, want them as they were", args[0], *args[10].(*string))
+	}
+	if original := args[1].(string); original != keys.Encrypt("https://example.com/") {
+		t.Errorf("original_url = %q, want it encrypted", original)
+	}
+	if raw := args[8].(*string); raw == nil || *raw != keys.Encrypt("https://example.com/{path}") {
+		t.Errorf("raw_url = %v, want it encrypted", raw)
+	}
+
+	// Queries without URL arguments are passed through
+	if _, err := q.GetLinkForRedirect(context.Background(), "abc123"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("GetLinkForRedirect() error = %v, want %v", err, pgx.ErrNoRows)
+	}
+	if conn.args[1][0] != "abc123" {
+		t.Errorf("shortcode = %v, want it as it was", conn.args[1][0])
+	}
+}
+
+func TestEncrypted_DecryptsColumns(t *testing.T) {
+	keys := testKeyring(t)
+	id := uuid.New()
+	conn := &recordingConn{
+		columns: []string{"id", "user_id", "shortcode", "destination", "checked_url", "state", "final_url", "fingerprint"},
+		rows: [][]any{{
+			id, "user_123", "abc123",
+			keys.Encrypt("https://example.com/"),
+			// Stored before encryption was turned on
+			ptr("https://example.com/old"),
+			// Not a URL column, left as it is
+			ptr("enc:v1:k1:not-a-url"),
+			(*string)(nil),
+			ptr("f1"),
+		}},
+	}
+
+	rows, err := New(Encrypted(conn, keys)).ListDestinationsToMonitor(context.Background(), ListDestinationsToMonitorParams{RowLimit: 10})
+	if err != nil {
+		t.Fatalf("ListDestinationsToMonitor() error = %v", err)
+	}
+
+	row := rows[0]
+	if row.Destination != "https://example.com/" || *row.CheckedUrl != "https://example.com/old" || row.FinalUrl != nil {
+		t.Errorf("destination, checked_url, final_url = %q, %q, %v, want them in plain text", row.Destination, *row.CheckedUrl, row.FinalUrl)
+	}
+	if *row.State != "enc:v1:k1:not-a-url" {
+		t.Errorf("state = %q, want it as it was", *row.State)
+	}
+}
+
+func TestEncrypted_UnknownKey(t *testing.T) {
+	conn := &recordingConn{
+		columns: []string{"id", "url", "final_url"},
+		rows:    [][]any{{uuid.New(), "enc:v1:retired:AAAA", (*string)(nil)}},
+	}
+
+	_, err := New(Encrypted(conn, testKeyring(t))).ListDestinationAlertURLs(context.Background(), ListDestinationAlertURLsParams{RowLimit: 10})
+	if !errors.Is(err, urlcrypt.ErrUnknownKey) {
+		t.Errorf("ListDestinationAlertURLs() error = %v, want %v", err, urlcrypt.ErrUnknownKey)
+	}
+}
+
+func TestEncrypted_WithTx(t *testing.T) {
+	keys := testKeyring(t)
+	conn := &recordingConn{}
+	store := NewStore(Encrypted(conn, keys))
+
+	err := store.WithTx(context.Background(), func(q *Queries) error {
+		_, err := q.GetUserLinkByURL(context.Background(), GetUserLinkByURLParams{UserID: "user_123", OriginalUrl: "https://example.com/"})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if url := conn.args[0][1]; url != keys.Encrypt("https://example.com/") {
+		t.Errorf("original_url in the transaction = %v, want it encrypted", url)
+	}
+}
+
+// plainURLArgs are the queries passing URLs that are stored as they are, and why
+var plainURLArgs = map[string]string{
+	"UpsertLinkPreview":    "preview image of the destination's page, not a destination",
+	"UpsertLinkTrafficCap": "overflow page, not covered by encryption at rest",
+	"RetireLink":           "sunset page, not covered by encryption at rest",
+	"CreatePublishHook":    "integration endpoint",
+	"CreateWebhook":        "integration endpoint",
+	// cmd/encrypturls runs these on the stored values, outside Encrypted
+	"SetLinkURLs":              "writes stored values",
+	"SetDestinationChangeURLs": "writes stored values",
+	"SetDestinationCheckURLs":  "writes stored values",
+	"SetDestinationAlertURLs":  "writes stored values",
+	"SetHTTPSUpgradeURLs":      "writes stored values",
+}
+
+// TestEncryptedArgs checks encryptedArgs against the arguments the generated queries pass:
+// each listed position must hold a URL, and every URL a query passes must be listed, unless
+// the query is in plainURLArgs. Adding or reordering parameters can't shift them unnoticed.
+func TestEncryptedArgs(t *testing.T) {
+	files, err := filepath.Glob("*.sql.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no generated queries found: %v", err)
+	}
+
+	found := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", path, err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			args := queryArgNames(fn)
+			if args == nil {
+				continue
+			}
+			found[fn.Name.Name] = true
+
+			var urls []int
+			for i, name := range args {
+				if strings.HasSuffix(strings.ToLower(name), "url") {
+					urls = append(urls, i+1)
+				}
+			}
+
+			positions, listed := encryptedArgs[fn.Name.Name]
+			_, plain := plainURLArgs[fn.Name.Name]
+			switch {
+			case listed && plain:
+				t.Errorf("%s is in both encryptedArgs and plainURLArgs", fn.Name.Name)
+			case listed && !reflect.DeepEqual(positions, urls):
+				t.Errorf("encryptedArgs[%q] = %v, but the query passes URLs at %v (arguments %v)", fn.Name.Name, positions, urls, args)
+			case !listed && !plain && len(urls) > 0:
+				t.Errorf("%s passes URLs at %v (arguments %v): add it to encryptedArgs, or to plainURLArgs if they're stored in plain text",
+					fn.Name.Name, urls, args)
+			}
+		}
+	}
+
+	for name := range encryptedArgs {
+		if !found[name] {
+			t.Errorf("encryptedArgs lists %s, which isn't a query", name)
+		}
+	}
+	for name := range plainURLArgs {
+		if !found[name] {
+			t.Errorf("plainURLArgs lists %s, which isn't a query", name)
+		}
+	}
+}
+
+// queryArgNames returns the names of the arguments a generated query method passes for $1, $2, ...:
+// the fields of its params struct or its parameters. It's nil for other functions.
+func queryArgNames(fn *ast.FuncDecl) []string {
+	var names []string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || names != nil {
+			return names == nil
+		}
+		method, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		conn, ok := method.X.(*ast.SelectorExpr)
+		if !ok || conn.Sel.Name != "db" || len(call.Args) < 2 {
+			return true
+		}
+
+		names = []string{}
+		for _, arg := range call.Args[2:] {
+			switch a := arg.(type) {
+			case *ast.SelectorExpr:
+				names = append(names, a.Sel.Name)
+			case *ast.Ident:
+				names = append(names, a.Name)
+			default:
+				names = append(names, "")
+			}
+		}
+		return false
+	})
+	return names
+}
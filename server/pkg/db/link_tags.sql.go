@@ -20,7 +20,7 @@ WHERE EXISTS (
 )
 AND EXISTS (
     SELECT 1 FROM tags t
-    WHERE t.id = ANY($3::uuid[]) AND t.user_id = $2
+    WHERE t.id = ANY($3::uuid[]) AND t.user_id = $2 AND t.deleted_at IS NULL
 )
 ON CONFLICT (link_id, tag_id) DO NOTHING
 `
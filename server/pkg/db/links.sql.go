@@ -17,6 +17,7 @@ SELECT COUNT(DISTINCT l.id) as total
 FROM links l
 WHERE l.user_id = $1 
   AND l.deleted_at IS NULL
+  AND l.merged_into IS NULL
   AND (
     -- If is_active filter is NULL, show all links
     $2::boolean IS NULL
@@ -61,8 +62,8 @@ func (q *Queries) CountUserLinks(ctx context.Context, arg CountUserLinksParams)
 const deleteLink = `-- name: DeleteLink :one
 UPDATE links
 SET deleted_at = NOW(), updated_at = NOW()
-WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
-RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
 `
 
 type DeleteLinkParams struct {
@@ -71,13 +72,25 @@ type DeleteLinkParams struct {
 }
 
 type DeleteLinkRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	IsActive    bool             `json:"is_active"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	IsActive            bool               `json:"is_active"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
 }
 
 func (q *Queries) DeleteLink(ctx context.Context, arg DeleteLinkParams) (DeleteLinkRow, error) {
@@ -91,12 +104,24 @@ func (q *Queries) DeleteLink(ctx context.Context, arg DeleteLinkParams) (DeleteL
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
 	)
 	return i, err
 }
 
 const getLinkByIdAndUser = `-- name: GetLinkByIdAndUser :one
-SELECT id, shortcode, original_url, expires_at, is_active, created_at, updated_at
+SELECT id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
 FROM links
 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 LIMIT 1
@@ -108,13 +133,25 @@ type GetLinkByIdAndUserParams struct {
 }
 
 type GetLinkByIdAndUserRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	IsActive    bool             `json:"is_active"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
 }
 
 func (q *Queries) GetLinkByIdAndUser(ctx context.Context, arg GetLinkByIdAndUserParams) (GetLinkByIdAndUserRow, error) {
@@ -128,6 +165,18 @@ func (q *Queries) GetLinkByIdAndUser(ctx context.Context, arg GetLinkByIdAndUser
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
 	)
 	return i, err
 }
@@ -141,6 +190,18 @@ SELECT
     l.is_active,
     l.created_at,
     l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
     COALESCE(
         json_agg(
             json_build_object(
@@ -166,14 +227,26 @@ type GetLinkByIdAndUserWithTagsParams struct {
 }
 
 type GetLinkByIdAndUserWithTagsRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	IsActive    bool             `json:"is_active"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	Tags        interface{}      `json:"tags"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+	Tags                interface{}        `json:"tags"`
 }
 
 func (q *Queries) GetLinkByIdAndUserWithTags(ctx context.Context, arg GetLinkByIdAndUserWithTagsParams) (GetLinkByIdAndUserWithTagsRow, error) {
@@ -187,6 +260,18 @@ func (q *Queries) GetLinkByIdAndUserWithTags(ctx context.Context, arg GetLinkByI
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
 		&i.Tags,
 	)
 	return i, err
@@ -201,6 +286,18 @@ SELECT
     l.is_active,
     l.created_at,
     l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
     COALESCE(
         json_agg(
             json_build_object(
@@ -226,14 +323,26 @@ type GetLinkByShortcodeAndUserParams struct {
 }
 
 type GetLinkByShortcodeAndUserRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	IsActive    bool             `json:"is_active"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	Tags        interface{}      `json:"tags"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+	Tags                interface{}        `json:"tags"`
 }
 
 func (q *Queries) GetLinkByShortcodeAndUser(ctx context.Context, arg GetLinkByShortcodeAndUserParams) (GetLinkByShortcodeAndUserRow, error) {
@@ -247,33 +356,357 @@ func (q *Queries) GetLinkByShortcodeAndUser(ctx context.Context, arg GetLinkBySh
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
 		&i.Tags,
 	)
 	return i, err
 }
 
 const getLinkForRedirect = `-- name: GetLinkForRedirect :one
-SELECT id, original_url
-FROM links
-WHERE shortcode = $1
-AND deleted_at IS NULL
-AND is_active = true
-AND (expires_at IS NULL OR expires_at > NOW())
+SELECT l.id, COALESCE(l.raw_url, l.original_url) AS original_url, l.user_id, l.visibility, l.capture_email, l.redirect_delay, l.interstitial_message, l.append_click_id, l.shield, l.referrer_policy, l.retired_at, l.sunset_message, l.sunset_url, c.daily_cap, c.total_cap, c.overflow_url, COALESCE(w.active, false)::BOOLEAN AS waiting_room, w.message AS waiting_room_message, w.retry_after AS waiting_room_retry_after, h.headers AS response_headers, (s.merged_into IS NOT NULL)::BOOLEAN AS merged
+FROM links s
+JOIN links l ON l.id = COALESCE(s.merged_into, s.id)
+LEFT JOIN link_traffic_caps c ON c.link_id = l.id
+LEFT JOIN link_waiting_rooms w ON w.link_id = l.id
+LEFT JOIN link_response_headers h ON h.link_id = l.id
+WHERE s.shortcode = $1
+AND s.deleted_at IS NULL
+AND l.deleted_at IS NULL
+AND (
+    l.retired_at IS NOT NULL
+    OR (l.is_active = true AND (l.expires_at IS NULL OR l.expires_at > NOW()))
+)
 LIMIT 1
 `
 
 type GetLinkForRedirectRow struct {
-	ID          uuid.UUID `json:"id"`
-	OriginalUrl string    `json:"original_url"`
+	ID                    uuid.UUID          `json:"id"`
+	OriginalUrl           string             `json:"original_url"`
+	UserID                string             `json:"user_id"`
+	Visibility            string             `json:"visibility"`
+	CaptureEmail          bool               `json:"capture_email"`
+	RedirectDelay         int32              `json:"redirect_delay"`
+	InterstitialMessage   *string            `json:"interstitial_message"`
+	AppendClickID         bool               `json:"append_click_id"`
+	Shield                bool               `json:"shield"`
+	ReferrerPolicy        string             `json:"referrer_policy"`
+	RetiredAt             pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage         *string            `json:"sunset_message"`
+	SunsetUrl             *string            `json:"sunset_url"`
+	DailyCap              *int32             `json:"daily_cap"`
+	TotalCap              *int32             `json:"total_cap"`
+	OverflowUrl           *string            `json:"overflow_url"`
+	WaitingRoom           bool               `json:"waiting_room"`
+	WaitingRoomMessage    *string            `json:"waiting_room_message"`
+	WaitingRoomRetryAfter *int32             `json:"waiting_room_retry_after"`
+	ResponseHeaders       []byte             `json:"response_headers"`
+	Merged                bool               `json:"merged"`
 }
 
+// Redirects go to the URL as submitted, tracking parameters included.
+// Retired links are returned whatever their state, for the sunset page.
+// Traffic caps come along so capped redirects don't need another query.
+// So does the waiting room; waiting_room_retry_after is only set for links that have one.
+// And the extra response headers, null for links without any.
+// The shortcode of a merged link redirects as the link it was merged into, which is returned.
 func (q *Queries) GetLinkForRedirect(ctx context.Context, shortcode string) (GetLinkForRedirectRow, error) {
 	row := q.db.QueryRow(ctx, getLinkForRedirect, shortcode)
 	var i GetLinkForRedirectRow
-	err := row.Scan(&i.ID, &i.OriginalUrl)
+	err := row.Scan(
+		&i.ID,
+		&i.OriginalUrl,
+		&i.UserID,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.AppendClickID,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
+		&i.DailyCap,
+		&i.TotalCap,
+		&i.OverflowUrl,
+		&i.WaitingRoom,
+		&i.WaitingRoomMessage,
+		&i.WaitingRoomRetryAfter,
+		&i.ResponseHeaders,
+		&i.Merged,
+	)
 	return i, err
 }
 
+const getUserLinkByURL = `-- name: GetUserLinkByURL :one
+SELECT id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
+FROM links
+WHERE user_id = $1
+  AND original_url = $2
+  AND deleted_at IS NULL
+  AND is_active = true
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND visibility = 'public'
+  AND capture_email = false
+  AND redirect_delay = 0
+  AND append_click_id = false
+  AND shield = false
+  AND referrer_policy = 'default'
+  AND retired_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM link_traffic_caps c WHERE c.link_id = links.id)
+  AND NOT EXISTS (SELECT 1 FROM link_response_headers h WHERE h.link_id = links.id)
+  AND merged_into IS NULL
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetUserLinkByURLParams struct {
+	UserID      string `json:"user_id"`
+	OriginalUrl string `json:"original_url"`
+}
+
+type GetUserLinkByURLRow struct {
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+}
+
+// The user's newest live link to the URL that redirects with default settings
+func (q *Queries) GetUserLinkByURL(ctx context.Context, arg GetUserLinkByURLParams) (GetUserLinkByURLRow, error) {
+	row := q.db.QueryRow(ctx, getUserLinkByURL, arg.UserID, arg.OriginalUrl)
+	var i GetUserLinkByURLRow
+	err := row.Scan(
+		&i.ID,
+		&i.Shortcode,
+		&i.OriginalUrl,
+		&i.ExpiresAt,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
+	)
+	return i, err
+}
+
+const listLinkChanges = `-- name: ListLinkChanges :many
+SELECT
+    id,
+    shortcode,
+    original_url,
+    expires_at,
+    is_active,
+    created_at,
+    updated_at,
+    deleted_at,
+    visibility,
+    capture_email,
+    redirect_delay,
+    interstitial_message,
+    raw_url,
+    append_click_id,
+    title,
+    shield,
+    referrer_policy,
+    retired_at,
+    sunset_message,
+    sunset_url,
+    (CASE
+        WHEN deleted_at IS NOT NULL THEN 'deleted'
+        WHEN created_at > $1::TIMESTAMPTZ THEN 'created'
+        ELSE 'updated'
+    END)::TEXT AS change,
+    COALESCE(deleted_at, updated_at, created_at)::TIMESTAMPTZ AS changed_at
+FROM links
+WHERE user_id = $2::TEXT
+  AND (COALESCE(deleted_at, updated_at, created_at), id) > ($3::TIMESTAMPTZ, $4::UUID)
+  AND COALESCE(deleted_at, updated_at, created_at) <= NOW() - INTERVAL '5 seconds'
+ORDER BY COALESCE(deleted_at, updated_at, created_at), id
+LIMIT $5::INT
+`
+
+type ListLinkChangesParams struct {
+	Since     pgtype.Timestamptz `json:"since"`
+	UserID    string             `json:"user_id"`
+	AfterTime pgtype.Timestamptz `json:"after_time"`
+	AfterID   uuid.UUID          `json:"after_id"`
+	RowLimit  int32              `json:"row_limit"`
+}
+
+type ListLinkChangesRow struct {
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt           pgtype.Timestamptz `json:"deleted_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+	Change              string             `json:"change"`
+	ChangedAt           pgtype.Timestamptz `json:"changed_at"`
+}
+
+// Links created, updated or deleted after the (changed_at, id) position, oldest change first.
+// Changes of the last few seconds are held back so rows from transactions still committing,
+// stamped with an earlier NOW(), can't land behind a position a client already polled past.
+func (q *Queries) ListLinkChanges(ctx context.Context, arg ListLinkChangesParams) ([]ListLinkChangesRow, error) {
+	rows, err := q.db.Query(ctx, listLinkChanges,
+		arg.Since,
+		arg.UserID,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLinkChangesRow
+	for rows.Next() {
+		var i ListLinkChangesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Shortcode,
+			&i.OriginalUrl,
+			&i.ExpiresAt,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.CaptureEmail,
+			&i.RedirectDelay,
+			&i.InterstitialMessage,
+			&i.RawUrl,
+			&i.AppendClickID,
+			&i.Title,
+			&i.Shield,
+			&i.ReferrerPolicy,
+			&i.RetiredAt,
+			&i.SunsetMessage,
+			&i.SunsetUrl,
+			&i.Change,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShortcodeSuggestions = `-- name: ListShortcodeSuggestions :many
+SELECT shortcode, COALESCE(raw_url, original_url) AS original_url, capture_email
+FROM links
+WHERE shortcode = ANY($1::TEXT[])
+  AND deleted_at IS NULL
+  AND retired_at IS NULL
+  AND is_active = true
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (
+    user_id = $2::TEXT
+    OR ($3::BOOLEAN AND visibility = 'public')
+  )
+ORDER BY shortcode
+LIMIT $4::INT
+`
+
+type ListShortcodeSuggestionsParams struct {
+	Candidates    []string `json:"candidates"`
+	ViewerID      string   `json:"viewer_id"`
+	IncludePublic bool     `json:"include_public"`
+	RowLimit      int32    `json:"row_limit"`
+}
+
+type ListShortcodeSuggestionsRow struct {
+	Shortcode    string `json:"shortcode"`
+	OriginalUrl  string `json:"original_url"`
+	CaptureEmail bool   `json:"capture_email"`
+}
+
+// Live links whose shortcode is one of the candidates, the near misses of a shortcode
+// that wasn't found: the viewer's own, and other users' public ones with include_public.
+func (q *Queries) ListShortcodeSuggestions(ctx context.Context, arg ListShortcodeSuggestionsParams) ([]ListShortcodeSuggestionsRow, error) {
+	rows, err := q.db.Query(ctx, listShortcodeSuggestions,
+		arg.Candidates,
+		arg.ViewerID,
+		arg.IncludePublic,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListShortcodeSuggestionsRow
+	for rows.Next() {
+		var i ListShortcodeSuggestionsRow
+		if err := rows.Scan(
+			&i.Shortcode,
+			&i.OriginalUrl,
+			&i.CaptureEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUserLinks = `-- name: ListUserLinks :many
 SELECT 
     l.id,
@@ -283,6 +716,18 @@ SELECT
     l.is_active,
     l.created_at,
     l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
     COALESCE(
         json_agg(
             json_build_object(
@@ -298,6 +743,7 @@ LEFT JOIN link_tags lt ON l.id = lt.link_id
 LEFT JOIN tags t ON lt.tag_id = t.id
 WHERE l.user_id = $1 
   AND l.deleted_at IS NULL
+  AND l.merged_into IS NULL
   AND (
     -- If is_active filter is NULL, show all links
     $2::boolean IS NULL
@@ -334,14 +780,26 @@ type ListUserLinksParams struct {
 }
 
 type ListUserLinksRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	IsActive    bool             `json:"is_active"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	Tags        interface{}      `json:"tags"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+	Tags                interface{}        `json:"tags"`
 }
 
 func (q *Queries) ListUserLinks(ctx context.Context, arg ListUserLinksParams) ([]ListUserLinksRow, error) {
@@ -367,6 +825,18 @@ func (q *Queries) ListUserLinks(ctx context.Context, arg ListUserLinksParams) ([
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Visibility,
+			&i.CaptureEmail,
+			&i.RedirectDelay,
+			&i.InterstitialMessage,
+			&i.RawUrl,
+			&i.AppendClickID,
+			&i.Title,
+			&i.Shield,
+			&i.ReferrerPolicy,
+			&i.RetiredAt,
+			&i.SunsetMessage,
+			&i.SunsetUrl,
 			&i.Tags,
 		); err != nil {
 			return nil, err
@@ -379,40 +849,261 @@ func (q *Queries) ListUserLinks(ctx context.Context, arg ListUserLinksParams) ([
 	return items, nil
 }
 
+const listUserLinksByIDs = `-- name: ListUserLinksByIDs :many
+SELECT 
+    l.id,
+    l.shortcode,
+    l.original_url,
+    l.expires_at,
+    l.is_active,
+    l.created_at,
+    l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', t.id,
+                'name', t.name,
+                'created_at', t.created_at
+            )
+        ) FILTER (WHERE t.id IS NOT NULL),
+        '[]'::json
+    ) as tags
+FROM links l
+LEFT JOIN link_tags lt ON l.id = lt.link_id
+LEFT JOIN tags t ON lt.tag_id = t.id
+WHERE l.user_id = $1
+  AND l.id = ANY($2::uuid[])
+  AND l.deleted_at IS NULL
+GROUP BY l.id
+ORDER BY l.created_at DESC
+`
+
+type ListUserLinksByIDsParams struct {
+	UserID string      `json:"user_id"`
+	Ids    []uuid.UUID `json:"ids"`
+}
+
+type ListUserLinksByIDsRow struct {
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+	Tags                interface{}        `json:"tags"`
+}
+
+// Batch lookup of the user's links; IDs that don't exist or aren't theirs are skipped
+func (q *Queries) ListUserLinksByIDs(ctx context.Context, arg ListUserLinksByIDsParams) ([]ListUserLinksByIDsRow, error) {
+	rows, err := q.db.Query(ctx, listUserLinksByIDs, arg.UserID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserLinksByIDsRow
+	for rows.Next() {
+		var i ListUserLinksByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Shortcode,
+			&i.OriginalUrl,
+			&i.ExpiresAt,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Visibility,
+			&i.CaptureEmail,
+			&i.RedirectDelay,
+			&i.InterstitialMessage,
+			&i.RawUrl,
+			&i.AppendClickID,
+			&i.Title,
+			&i.Shield,
+			&i.ReferrerPolicy,
+			&i.RetiredAt,
+			&i.SunsetMessage,
+			&i.SunsetUrl,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retireLink = `-- name: RetireLink :one
+UPDATE links
+SET retired_at = COALESCE(retired_at, NOW()),
+    sunset_message = $3,
+    sunset_url = $4,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
+`
+
+type RetireLinkParams struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        string    `json:"user_id"`
+	SunsetMessage *string   `json:"sunset_message"`
+	SunsetUrl     *string   `json:"sunset_url"`
+}
+
+type RetireLinkRow struct {
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	IsActive            bool               `json:"is_active"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+}
+
+// Retiring again only changes the sunset page
+func (q *Queries) RetireLink(ctx context.Context, arg RetireLinkParams) (RetireLinkRow, error) {
+	row := q.db.QueryRow(ctx, retireLink,
+		arg.ID,
+		arg.UserID,
+		arg.SunsetMessage,
+		arg.SunsetUrl,
+	)
+	var i RetireLinkRow
+	err := row.Scan(
+		&i.ID,
+		&i.Shortcode,
+		&i.OriginalUrl,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
+	)
+	return i, err
+}
+
 const tryCreateLink = `-- name: TryCreateLink :one
-INSERT INTO links (shortcode, original_url, user_id, expires_at)
-SELECT $1::VARCHAR(20), $2::TEXT, $3::TEXT, $4
-WHERE NOT EXISTS (
-    SELECT 1 FROM links 
-    WHERE shortcode = $1::VARCHAR(20) AND deleted_at IS NULL
+WITH claimed AS (
+    DELETE FROM shortcode_reservations
+    WHERE shortcode = $1::VARCHAR(20) AND user_id = $3::TEXT
+)
+INSERT INTO links (shortcode, original_url, user_id, expires_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, http_destination)
+SELECT $1::VARCHAR(20), $2::TEXT, $3::TEXT, $4, $5::TEXT, $6::BOOLEAN, $7::INTEGER, $8, $9, $10::BOOLEAN, $11, $12::BOOLEAN, $13::VARCHAR(20), $14::BOOLEAN
+WHERE NOT link_shortcode_live($1::VARCHAR(20))
+AND NOT EXISTS (
+    SELECT 1 FROM shortcode_reservations
+    WHERE shortcode = $1::VARCHAR(20) AND user_id <> $3::TEXT
 )
-RETURNING id, shortcode, original_url, expires_at, is_active, created_at, updated_at
+RETURNING id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
 `
 
 type TryCreateLinkParams struct {
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	UserID      string           `json:"user_id"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	UserID              string             `json:"user_id"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	HttpDestination     bool               `json:"http_destination"`
 }
 
 type TryCreateLinkRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	IsActive    bool             `json:"is_active"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
 }
 
-// sqlc.arg(shortcode) sqlc.arg(original_url) sqlc.arg(user_id) sqlc.narg(expires_at)
+// sqlc.arg(shortcode) sqlc.arg(original_url) sqlc.arg(user_id) sqlc.narg(expires_at) sqlc.arg(visibility) sqlc.arg(capture_email) sqlc.arg(redirect_delay) sqlc.narg(interstitial_message) sqlc.narg(raw_url) sqlc.arg(append_click_id) sqlc.narg(title)
+// A shortcode reserved by another user is taken; the user's own reservation is consumed by the link.
 func (q *Queries) TryCreateLink(ctx context.Context, arg TryCreateLinkParams) (TryCreateLinkRow, error) {
 	row := q.db.QueryRow(ctx, tryCreateLink,
 		arg.Shortcode,
 		arg.OriginalUrl,
 		arg.UserID,
 		arg.ExpiresAt,
+		arg.Visibility,
+		arg.CaptureEmail,
+		arg.RedirectDelay,
+		arg.InterstitialMessage,
+		arg.RawUrl,
+		arg.AppendClickID,
+		arg.Title,
+		arg.Shield,
+		arg.ReferrerPolicy,
+		arg.HttpDestination,
 	)
 	var i TryCreateLinkRow
 	err := row.Scan(
@@ -423,39 +1114,83 @@ func (q *Queries) TryCreateLink(ctx context.Context, arg TryCreateLinkParams) (T
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
 	)
 	return i, err
 }
 
 const updateLink = `-- name: UpdateLink :one
+WITH claimed AS (
+    DELETE FROM shortcode_reservations
+    WHERE shortcode = $3 AND user_id = $2
+    AND EXISTS (SELECT 1 FROM links WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL)
+)
 UPDATE links
 SET 
     shortcode = COALESCE($3, shortcode),
     is_active = COALESCE($4, is_active),
     expires_at = COALESCE($5, expires_at),
+    visibility = COALESCE($6, visibility),
+    capture_email = COALESCE($7, capture_email),
+    redirect_delay = COALESCE($8, redirect_delay),
+    interstitial_message = COALESCE($9, interstitial_message),
+    append_click_id = COALESCE($10, append_click_id),
+    shield = COALESCE($11, shield),
+    referrer_policy = COALESCE($12, referrer_policy),
     updated_at = NOW()
-WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
-RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
 `
 
 type UpdateLinkParams struct {
-	ID        uuid.UUID        `json:"id"`
-	UserID    string           `json:"user_id"`
-	Shortcode *string          `json:"shortcode"`
-	IsActive  *bool            `json:"is_active"`
-	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	ID                  uuid.UUID          `json:"id"`
+	UserID              string             `json:"user_id"`
+	Shortcode           *string            `json:"shortcode"`
+	IsActive            *bool              `json:"is_active"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	Visibility          *string            `json:"visibility"`
+	CaptureEmail        *bool              `json:"capture_email"`
+	RedirectDelay       *int32             `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	AppendClickID       *bool              `json:"append_click_id"`
+	Shield              *bool              `json:"shield"`
+	ReferrerPolicy      *string            `json:"referrer_policy"`
 }
 
 type UpdateLinkRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	IsActive    bool             `json:"is_active"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	IsActive            bool               `json:"is_active"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
 }
 
+// The user's reservation of the new shortcode, if any, is consumed by the link
 func (q *Queries) UpdateLink(ctx context.Context, arg UpdateLinkParams) (UpdateLinkRow, error) {
 	row := q.db.QueryRow(ctx, updateLink,
 		arg.ID,
@@ -463,6 +1198,13 @@ func (q *Queries) UpdateLink(ctx context.Context, arg UpdateLinkParams) (UpdateL
 		arg.Shortcode,
 		arg.IsActive,
 		arg.ExpiresAt,
+		arg.Visibility,
+		arg.CaptureEmail,
+		arg.RedirectDelay,
+		arg.InterstitialMessage,
+		arg.AppendClickID,
+		arg.Shield,
+		arg.ReferrerPolicy,
 	)
 	var i UpdateLinkRow
 	err := row.Scan(
@@ -473,6 +1215,18 @@ func (q *Queries) UpdateLink(ctx context.Context, arg UpdateLinkParams) (UpdateL
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Visibility,
+		&i.CaptureEmail,
+		&i.RedirectDelay,
+		&i.InterstitialMessage,
+		&i.RawUrl,
+		&i.AppendClickID,
+		&i.Title,
+		&i.Shield,
+		&i.ReferrerPolicy,
+		&i.RetiredAt,
+		&i.SunsetMessage,
+		&i.SunsetUrl,
 	)
 	return i, err
 }
@@ -9,16 +9,227 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ActivityEvent struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    string             `json:"user_id"`
+	Action    string             `json:"action"`
+	TargetID  uuid.UUID          `json:"target_id"`
+	Summary   string             `json:"summary"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	Seq       int64              `json:"seq"`
+	PrevHash  string             `json:"prev_hash"`
+	Hash      string             `json:"hash"`
+}
+
+type ApiKey struct {
+	ID                    uuid.UUID          `json:"id"`
+	UserID                string             `json:"user_id"`
+	Name                  string             `json:"name"`
+	KeyHash               string             `json:"key_hash"`
+	CreatedAt             pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt            pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt             pgtype.Timestamptz `json:"revoked_at"`
+	Scopes                []string           `json:"scopes"`
+	LastUsedIp            *string            `json:"last_used_ip"`
+	UseCount              int64              `json:"use_count"`
+	PreviousKeyHash       *string            `json:"previous_key_hash"`
+	PreviousKeyExpiresAt  pgtype.Timestamptz `json:"previous_key_expires_at"`
+	PreviousKeyLastUsedAt pgtype.Timestamptz `json:"previous_key_last_used_at"`
+	KeyRotatedAt          pgtype.Timestamptz `json:"key_rotated_at"`
+}
+
+type Campaign struct {
+	ID         uuid.UUID          `json:"id"`
+	UserID     string             `json:"user_id"`
+	Name       string             `json:"name"`
+	BudgetNote *string            `json:"budget_note"`
+	StartsAt   pgtype.Timestamptz `json:"starts_at"`
+	EndsAt     pgtype.Timestamptz `json:"ends_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CampaignLink struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	LinkID     uuid.UUID `json:"link_id"`
+}
+
+type Click struct {
+	ID        int64              `json:"id"`
+	LinkID    uuid.UUID          `json:"link_id"`
+	ClickedAt pgtype.Timestamptz `json:"clicked_at"`
+	Referrer  *string            `json:"referrer"`
+	UserAgent *string            `json:"user_agent"`
+	ClickID   uuid.UUID          `json:"click_id"`
+	Source    string             `json:"source"`
+}
+
+type Conversion struct {
+	ID           int64              `json:"id"`
+	ClickID      uuid.UUID          `json:"click_id"`
+	Event        string             `json:"event"`
+	RevenueCents *int64             `json:"revenue_cents"`
+	ExternalID   *string            `json:"external_id"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type DynamicLink struct {
+	LinkID    uuid.UUID          `json:"link_id"`
+	Locked    bool               `json:"locked"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Link struct {
-	ID          uuid.UUID        `json:"id"`
-	Shortcode   string           `json:"shortcode"`
-	OriginalUrl string           `json:"original_url"`
-	UserID      string           `json:"user_id"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	DeletedAt   pgtype.Timestamp `json:"deleted_at"`
-	IsActive    bool             `json:"is_active"`
+	ID                  uuid.UUID          `json:"id"`
+	Shortcode           string             `json:"shortcode"`
+	OriginalUrl         string             `json:"original_url"`
+	UserID              string             `json:"user_id"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt           pgtype.Timestamptz `json:"deleted_at"`
+	IsActive            bool               `json:"is_active"`
+	Visibility          string             `json:"visibility"`
+	CaptureEmail        bool               `json:"capture_email"`
+	RedirectDelay       int32              `json:"redirect_delay"`
+	InterstitialMessage *string            `json:"interstitial_message"`
+	RawUrl              *string            `json:"raw_url"`
+	AppendClickID       bool               `json:"append_click_id"`
+	Title               *string            `json:"title"`
+	Shield              bool               `json:"shield"`
+	ReferrerPolicy      string             `json:"referrer_policy"`
+	RetiredAt           pgtype.Timestamptz `json:"retired_at"`
+	SunsetMessage       *string            `json:"sunset_message"`
+	SunsetUrl           *string            `json:"sunset_url"`
+	MergedInto          pgtype.UUID        `json:"merged_into"`
+	HttpDestination     bool               `json:"http_destination"`
+}
+
+type LinkAnomaly struct {
+	ID        uuid.UUID          `json:"id"`
+	LinkID    uuid.UUID          `json:"link_id"`
+	Kind      string             `json:"kind"`
+	Hour      pgtype.Timestamptz `json:"hour"`
+	Clicks    int64              `json:"clicks"`
+	Baseline  float64            `json:"baseline"`
+	ZScore    float64            `json:"z_score"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type LinkClickCount struct {
+	LinkID    uuid.UUID          `json:"link_id"`
+	Clicks    int64              `json:"clicks"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type LinkComment struct {
+	ID        uuid.UUID          `json:"id"`
+	LinkID    uuid.UUID          `json:"link_id"`
+	UserID    string             `json:"user_id"`
+	Body      string             `json:"body"`
+	Mentions  []string           `json:"mentions"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type LinkDailyStat struct {
+	LinkID    uuid.UUID          `json:"link_id"`
+	Day       pgtype.Date        `json:"day"`
+	Clicks    int64              `json:"clicks"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	QrClicks  int64              `json:"qr_clicks"`
+}
+
+type LinkDestinationAlert struct {
+	ID         uuid.UUID          `json:"id"`
+	LinkID     uuid.UUID          `json:"link_id"`
+	UserID     string             `json:"user_id"`
+	Kind       string             `json:"kind"`
+	Url        string             `json:"url"`
+	FinalUrl   *string            `json:"final_url"`
+	StatusCode *int32             `json:"status_code"`
+	Title      *string            `json:"title"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type LinkDestinationChange struct {
+	ID              uuid.UUID          `json:"id"`
+	LinkID          uuid.UUID          `json:"link_id"`
+	UserID          string             `json:"user_id"`
+	RawUrl          string             `json:"raw_url"`
+	Url             string             `json:"url"`
+	PreviousUrl     *string            `json:"previous_url"`
+	ScheduledAt     pgtype.Timestamptz `json:"scheduled_at"`
+	AppliedAt       pgtype.Timestamptz `json:"applied_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	HttpDestination bool               `json:"http_destination"`
+}
+
+type LinkDestinationCheck struct {
+	LinkID      uuid.UUID          `json:"link_id"`
+	Url         string             `json:"url"`
+	FinalUrl    *string            `json:"final_url"`
+	StatusCode  *int32             `json:"status_code"`
+	Title       *string            `json:"title"`
+	Fingerprint *string            `json:"fingerprint"`
+	State       string             `json:"state"`
+	CheckedAt   pgtype.Timestamptz `json:"checked_at"`
+}
+
+type LinkHttpsUpgrade struct {
+	LinkID    uuid.UUID          `json:"link_id"`
+	UserID    string             `json:"user_id"`
+	FromUrl   string             `json:"from_url"`
+	ToUrl     string             `json:"to_url"`
+	Status    string             `json:"status"`
+	CheckedAt pgtype.Timestamptz `json:"checked_at"`
+}
+
+type LinkLead struct {
+	ID        int64              `json:"id"`
+	LinkID    uuid.UUID          `json:"link_id"`
+	Email     string             `json:"email"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type LinkPreview struct {
+	LinkID      uuid.UUID          `json:"link_id"`
+	Title       *string            `json:"title"`
+	Description *string            `json:"description"`
+	ImageUrl    *string            `json:"image_url"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type LinkPublicStat struct {
+	LinkID    uuid.UUID          `json:"link_id"`
+	EnabledAt pgtype.Timestamptz `json:"enabled_at"`
+}
+
+type LinkResponseHeader struct {
+	LinkID    uuid.UUID          `json:"link_id"`
+	Headers   []byte             `json:"headers"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type LinkWaitingRoom struct {
+	LinkID           uuid.UUID          `json:"link_id"`
+	Active           bool               `json:"active"`
+	Message          *string            `json:"message"`
+	RetryAfter       int32              `json:"retry_after"`
+	WebhookTokenHash string             `json:"webhook_token_hash"`
+	ActivatedAt      pgtype.Timestamptz `json:"activated_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+type LinkTrafficCap struct {
+	LinkID      uuid.UUID          `json:"link_id"`
+	DailyCap    *int32             `json:"daily_cap"`
+	TotalCap    *int32             `json:"total_cap"`
+	OverflowUrl string             `json:"overflow_url"`
+	TotalClicks int64              `json:"total_clicks"`
+	Day         pgtype.Date        `json:"day"`
+	DayClicks   int64              `json:"day_clicks"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
 type LinkTag struct {
@@ -26,10 +237,96 @@ type LinkTag struct {
 	TagID  uuid.UUID `json:"tag_id"`
 }
 
+type PublishHook struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      string             `json:"user_id"`
+	Name        string             `json:"name"`
+	TokenHash   string             `json:"token_hash"`
+	TagID       pgtype.UUID        `json:"tag_id"`
+	CallbackUrl *string            `json:"callback_url"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+}
+
+type ServiceAccount struct {
+	ID                      uuid.UUID          `json:"id"`
+	OwnerID                 string             `json:"owner_id"`
+	Name                    string             `json:"name"`
+	Scopes                  []string           `json:"scopes"`
+	TokenHash               string             `json:"token_hash"`
+	CreatedBy               string             `json:"created_by"`
+	CreatedAt               pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt              pgtype.Timestamptz `json:"last_used_at"`
+	LastUsedIp              *string            `json:"last_used_ip"`
+	UseCount                int64              `json:"use_count"`
+	PreviousTokenHash       *string            `json:"previous_token_hash"`
+	PreviousTokenExpiresAt  pgtype.Timestamptz `json:"previous_token_expires_at"`
+	PreviousTokenLastUsedAt pgtype.Timestamptz `json:"previous_token_last_used_at"`
+	TokenRotatedAt          pgtype.Timestamptz `json:"token_rotated_at"`
+}
+
+type ShortcodeReservation struct {
+	Shortcode string             `json:"shortcode"`
+	UserID    string             `json:"user_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type SlackAccount struct {
+	TeamID      string             `json:"team_id"`
+	SlackUserID string             `json:"slack_user_id"`
+	UserID      string             `json:"user_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type StatsRollupState struct {
+	ID            bool               `json:"id"`
+	RolledUpUntil pgtype.Date        `json:"rolled_up_until"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Tag struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	UserID    string           `json:"user_id"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID              uuid.UUID          `json:"id"`
+	Name            string             `json:"name"`
+	UserID          string             `json:"user_id"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt       pgtype.Timestamptz `json:"deleted_at"`
+	DetachedLinkIds []uuid.UUID        `json:"detached_link_ids"`
+}
+
+type VerifiedSender struct {
+	Kind       string             `json:"kind"`
+	Value      string             `json:"value"`
+	Name       *string            `json:"name"`
+	VerifiedBy string             `json:"verified_by"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type Webhook struct {
+	ID                      uuid.UUID          `json:"id"`
+	UserID                  string             `json:"user_id"`
+	Url                     string             `json:"url"`
+	Secret                  string             `json:"secret"`
+	PreviousSecret          *string            `json:"previous_secret"`
+	PreviousSecretExpiresAt pgtype.Timestamptz `json:"previous_secret_expires_at"`
+	CreatedAt               pgtype.Timestamptz `json:"created_at"`
+	SecretRotatedAt         pgtype.Timestamptz `json:"secret_rotated_at"`
+	FailingSince            pgtype.Timestamptz `json:"failing_since"`
+	DisabledAt              pgtype.Timestamptz `json:"disabled_at"`
+}
+
+type WebhookDelivery struct {
+	ID          uuid.UUID          `json:"id"`
+	WebhookID   uuid.UUID          `json:"webhook_id"`
+	EventID     string             `json:"event_id"`
+	EventType   string             `json:"event_type"`
+	Payload     []byte             `json:"payload"`
+	StatusCode  *int32             `json:"status_code"`
+	DurationMs  int32              `json:"duration_ms"`
+	Error       *string            `json:"error"`
+	RetryOf     pgtype.UUID        `json:"retry_of"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	Attempt     int32              `json:"attempt"`
+	NextRetryAt pgtype.Timestamptz `json:"next_retry_at"`
+	Pending     bool               `json:"pending"`
 }
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// setRowSecurityUser sets the setting the row-level security policies compare user_id with, until the transaction ends
+const setRowSecurityUser = "SELECT set_config('app.user_id', $1, true)"
+
+type rowSecurityUserKey struct{}
+
+// WithRowSecurityUser scopes the queries run with ctx through a RowSecurityDB to the user's rows
+func WithRowSecurityUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, rowSecurityUserKey{}, userID)
+}
+
+func rowSecurityUser(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(rowSecurityUserKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// RowSecurityDB is a DBTX whose queries are scoped by Postgres row-level security, see RowSecurity
+type RowSecurityDB struct {
+	db interface {
+		DBTX
+		TxBeginner
+	}
+}
+
+/*
+RowSecurity wraps db so the queries of a context given WithRowSecurityUser run
+in a transaction that sets app.user_id to the user: the row-level security
+policies of the tables keyed by user_id (see the migrations) then hide and
+refuse every other user's rows, even to a query missing its WHERE user_id = $1.
+Queries of other contexts (redirects, background jobs) see every row, as without it.
+
+Each scoped query costs three more round trips (BEGIN, set_config, COMMIT).
+The policies guard against mistakes in the queries, not against arbitrary SQL,
+which could set app.user_id itself.
+*/
+func RowSecurity(db interface {
+	DBTX
+	TxBeginner
+}) *RowSecurityDB {
+	return &RowSecurityDB{db: db}
+}
+
+func (s *RowSecurityDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	userID, ok := rowSecurityUser(ctx)
+	if !ok {
+		return s.db.Exec(ctx, sql, args...)
+	}
+
+	tx, err := s.begin(ctx, userID)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return tag, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return tag, fmt.Errorf("failed to commit row security transaction: %w", err)
+	}
+	return tag, nil
+}
+
+func (s *RowSecurityDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	userID, ok := rowSecurityUser(ctx)
+	if !ok {
+		return s.db.Query(ctx, sql, args...)
+	}
+
+	tx, err := s.begin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return &rowSecurityRows{Rows: rows, ctx: ctx, tx: tx}, nil
+}
+
+func (s *RowSecurityDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if _, ok := rowSecurityUser(ctx); !ok {
+		return s.db.QueryRow(ctx, sql, args...)
+	}
+
+	rows, err := s.Query(ctx, sql, args...)
+	return firstRow{rows: rows, err: err}
+}
+
+// Begin starts a transaction scoped like the queries of ctx, so Store.WithTx keeps the scope
+func (s *RowSecurityDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	userID, ok := rowSecurityUser(ctx)
+	if !ok {
+		return s.db.Begin(ctx)
+	}
+	return s.begin(ctx, userID)
+}
+
+func (s *RowSecurityDB) begin(ctx context.Context, userID string) (pgx.Tx, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin row security transaction: %w", err)
+	}
+	if _, err := tx.Exec(ctx, setRowSecurityUser, userID); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to set row security user: %w", err)
+	}
+	return tx, nil
+}
+
+// rowSecurityRows ends the transaction of its query once read: on the last Next or on Close
+type rowSecurityRows struct {
+	pgx.Rows
+	ctx  context.Context
+	tx   pgx.Tx
+	err  error
+	done bool
+}
+
+func (r *rowSecurityRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *rowSecurityRows) Close() {
+	r.finish()
+}
+
+// Err also reports a failed commit, which could have undone the writes of a RETURNING query
+func (r *rowSecurityRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+func (r *rowSecurityRows) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+
+	r.Rows.Close()
+	if r.Rows.Err() != nil {
+		_ = r.tx.Rollback(r.ctx)
+		return
+	}
+	if err := r.tx.Commit(r.ctx); err != nil {
+		r.err = fmt.Errorf("failed to commit row security transaction: %w", err)
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// txLog is a connection that logs what's run on it, transactions included
+type txLog struct {
+	pgx.Tx
+	log       []string
+	commitErr error
+	rows      [][]any
+}
+
+func (c *txLog) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if sql == setRowSecurityUser {
+		c.log = append(c.log, "set "+args[0].(string))
+	} else {
+		c.log = append(c.log, queryName(sql))
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *txLog) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.log = append(c.log, queryName(sql))
+	return &fakeRows{columns: []string{"name"}, rows: c.rows, index: -1}, nil
+}
+
+func (c *txLog) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	c.log = append(c.log, queryName(sql))
+	return firstRow{err: pgx.ErrNoRows}
+}
+
+func (c *txLog) Begin(ctx context.Context) (pgx.Tx, error) {
+	c.log = append(c.log, "begin")
+	return c, nil
+}
+
+func (c *txLog) Commit(ctx context.Context) error {
+	c.log = append(c.log, "commit")
+	return c.commitErr
+}
+
+func (c *txLog) Rollback(ctx context.Context) error {
+	c.log = append(c.log, "rollback")
+	return nil
+}
+
+func TestRowSecurity_ScopesQueries(t *testing.T) {
+	ctx := WithRowSecurityUser(context.Background(), "user_123")
+
+	t.Run("single query", func(t *testing.T) {
+		conn := &txLog{rows: [][]any{{"news"}, {"docs"}}}
+		names, err := New(RowSecurity(conn)).ListUserTagNamesByIDs(ctx, ListUserTagNamesByIDsParams{UserID: "user_123"})
+		if err != nil {
+			t.Fatalf("ListUserTagNamesByIDs() error = %v", err)
+		}
+		if len(names) != 2 {
+			t.Errorf("ListUserTagNamesByIDs() = %v, want both rows", names)
+		}
+
+		want := []string{"begin", "set user_123", "ListUserTagNamesByIDs", "commit"}
+		if !slices.Equal(conn.log, want) {
+			t.Errorf("ran %v, want %v", conn.log, want)
+		}
+	})
+
+	t.Run("single row", func(t *testing.T) {
+		conn := &txLog{}
+		_, err := New(RowSecurity(conn)).GetLinkForRedirect(ctx, "abc123")
+		if !errors.Is(err, pgx.ErrNoRows) {
+			t.Fatalf("GetLinkForRedirect() error = %v, want %v", err, pgx.ErrNoRows)
+		}
+
+		want := []string{"begin", "set user_123", "GetLinkForRedirect", "commit"}
+		if !slices.Equal(conn.log, want) {
+			t.Errorf("ran %v, want %v", conn.log, want)
+		}
+	})
+
+	t.Run("unit of work", func(t *testing.T) {
+		conn := &txLog{}
+		err := NewStore(RowSecurity(conn)).WithTx(ctx, func(q *Queries) error {
+			return q.CreateActivityEvent(ctx, CreateActivityEventParams{UserID: "user_123"})
+		})
+		if err != nil {
+			t.Fatalf("WithTx() error = %v", err)
+		}
+
+		want := []string{"begin", "set user_123", "CreateActivityEvent", "commit"}
+		if !slices.Equal(conn.log, want) {
+			t.Errorf("ran %v, want %v", conn.log, want)
+		}
+	})
+
+	t.Run("failed commit", func(t *testing.T) {
+		conn := &txLog{rows: [][]any{{"news"}}, commitErr: errors.New("serialization failure")}
+		if _, err := New(RowSecurity(conn)).ListUserTagNamesByIDs(ctx, ListUserTagNamesByIDsParams{}); err == nil {
+			t.Error("ListUserTagNamesByIDs() error = nil, want the commit's")
+		}
+	})
+}
+
+func TestRowSecurity_UnscopedQueries(t *testing.T) {
+	conn := &txLog{}
+	_, _ = New(RowSecurity(conn)).GetLinkForRedirect(context.Background(), "abc123")
+
+	want := []string{"GetLinkForRedirect"}
+	if !slices.Equal(conn.log, want) {
+		t.Errorf("ran %v, want %v", conn.log, want)
+	}
+}
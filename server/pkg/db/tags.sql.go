@@ -12,6 +12,46 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countUserTagsByIDs = `-- name: CountUserTagsByIDs :one
+SELECT COUNT(*) FROM tags
+WHERE user_id = $1
+  AND deleted_at IS NULL
+  AND id = ANY($2::uuid[])
+`
+
+type CountUserTagsByIDsParams struct {
+	UserID string      `json:"user_id"`
+	Ids    []uuid.UUID `json:"ids"`
+}
+
+// Number of the given tags that belong to the user
+func (q *Queries) CountUserTagsByIDs(ctx context.Context, arg CountUserTagsByIDsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUserTagsByIDs, arg.UserID, arg.Ids)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUserTagsByPrefix = `-- name: CountUserTagsByPrefix :one
+SELECT COUNT(*) AS total FROM tags
+WHERE user_id = $1::TEXT
+  AND deleted_at IS NULL
+  AND starts_with(lower(name), lower($2::TEXT))
+`
+
+type CountUserTagsByPrefixParams struct {
+	UserID string `json:"user_id"`
+	Prefix string `json:"prefix"`
+}
+
+// Number of the user's tags whose name starts with prefix, without case
+func (q *Queries) CountUserTagsByPrefix(ctx context.Context, arg CountUserTagsByPrefixParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUserTagsByPrefix, arg.UserID, arg.Prefix)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const createTag = `-- name: CreateTag :one
 INSERT INTO tags (name, user_id)
 VALUES ($1, $2)
@@ -24,10 +64,10 @@ type CreateTagParams struct {
 }
 
 type CreateTagRow struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) (CreateTagRow, error) {
@@ -43,8 +83,18 @@ func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) (CreateTag
 }
 
 const deleteTag = `-- name: DeleteTag :one
-DELETE FROM tags
-WHERE id = $1 AND user_id = $2
+WITH detached AS (
+    DELETE FROM link_tags lt
+    USING tags t
+    WHERE lt.tag_id = t.id
+      AND t.id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL
+    RETURNING lt.link_id
+)
+UPDATE tags
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    detached_link_ids = ARRAY(SELECT link_id FROM detached)
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 RETURNING id, name, created_at, updated_at
 `
 
@@ -54,12 +104,13 @@ type DeleteTagParams struct {
 }
 
 type DeleteTagRow struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
+// Moves the tag to the trash, detaching it from its links and remembering them for RestoreTag
 func (q *Queries) DeleteTag(ctx context.Context, arg DeleteTagParams) (DeleteTagRow, error) {
 	row := q.db.QueryRow(ctx, deleteTag, arg.ID, arg.UserID)
 	var i DeleteTagRow
@@ -73,8 +124,18 @@ func (q *Queries) DeleteTag(ctx context.Context, arg DeleteTagParams) (DeleteTag
 }
 
 const deleteTags = `-- name: DeleteTags :many
-DELETE FROM tags
-WHERE id = ANY($1::uuid[]) AND user_id = $2
+WITH detached AS (
+    DELETE FROM link_tags lt
+    USING tags t
+    WHERE lt.tag_id = t.id
+      AND t.id = ANY($1::uuid[]) AND t.user_id = $2 AND t.deleted_at IS NULL
+    RETURNING lt.tag_id, lt.link_id
+)
+UPDATE tags
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    detached_link_ids = ARRAY(SELECT d.link_id FROM detached d WHERE d.tag_id = tags.id)
+WHERE id = ANY($1::uuid[]) AND user_id = $2 AND deleted_at IS NULL
 RETURNING id, name, created_at, updated_at
 `
 
@@ -84,12 +145,13 @@ type DeleteTagsParams struct {
 }
 
 type DeleteTagsRow struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
+// DeleteTag for several tags
 func (q *Queries) DeleteTags(ctx context.Context, arg DeleteTagsParams) ([]DeleteTagsRow, error) {
 	rows, err := q.db.Query(ctx, deleteTags, arg.TagIDs, arg.UserID)
 	if err != nil {
@@ -115,17 +177,92 @@ func (q *Queries) DeleteTags(ctx context.Context, arg DeleteTagsParams) ([]Delet
 	return items, nil
 }
 
+const getTagByIdAndUser = `-- name: GetTagByIdAndUser :one
+SELECT id, name, created_at, updated_at FROM tags
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetTagByIdAndUserParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID string    `json:"user_id"`
+}
+
+type GetTagByIdAndUserRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) GetTagByIdAndUser(ctx context.Context, arg GetTagByIdAndUserParams) (GetTagByIdAndUserRow, error) {
+	row := q.db.QueryRow(ctx, getTagByIdAndUser, arg.ID, arg.UserID)
+	var i GetTagByIdAndUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDeletedTags = `-- name: ListDeletedTags :many
+SELECT id, name, created_at, updated_at, deleted_at,
+    COALESCE(cardinality(detached_link_ids), 0)::BIGINT AS link_count
+FROM tags
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, name
+`
+
+type ListDeletedTagsRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+	LinkCount int64              `json:"link_count"`
+}
+
+// The user's tags in the trash, most recently deleted first, with the number of links they were detached from
+func (q *Queries) ListDeletedTags(ctx context.Context, userID string) ([]ListDeletedTagsRow, error) {
+	rows, err := q.db.Query(ctx, listDeletedTags, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeletedTagsRow
+	for rows.Next() {
+		var i ListDeletedTagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.LinkCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUserTags = `-- name: ListUserTags :many
 SELECT id, name, created_at, updated_at FROM tags
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY name
 `
 
 type ListUserTagsRow struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 func (q *Queries) ListUserTags(ctx context.Context, userID string) ([]ListUserTagsRow, error) {
@@ -153,12 +290,210 @@ func (q *Queries) ListUserTags(ctx context.Context, userID string) ([]ListUserTa
 	return items, nil
 }
 
+const listUserTagsPage = `-- name: ListUserTagsPage :many
+SELECT t.id, t.name, t.created_at, t.updated_at, COUNT(l.id) AS link_count
+FROM tags t
+LEFT JOIN link_tags lt ON lt.tag_id = t.id
+LEFT JOIN links l ON l.id = lt.link_id AND l.deleted_at IS NULL
+WHERE t.user_id = $1::TEXT
+  AND t.deleted_at IS NULL
+  AND starts_with(lower(t.name), lower($2::TEXT))
+GROUP BY t.id
+ORDER BY
+    CASE WHEN $3::TEXT = 'usage' THEN COUNT(l.id) END DESC,
+    CASE WHEN $3::TEXT = 'created_at' THEN t.created_at END DESC,
+    t.name, t.id
+LIMIT $4::INT OFFSET $5::INT
+`
+
+type ListUserTagsPageParams struct {
+	UserID    string `json:"user_id"`
+	Prefix    string `json:"prefix"`
+	Sort      string `json:"sort"`
+	RowLimit  int32  `json:"row_limit"`
+	RowOffset int32  `json:"row_offset"`
+}
+
+type ListUserTagsPageRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	LinkCount int64              `json:"link_count"`
+}
+
+// A page of the user's tags whose name starts with prefix (without case), each with the
+// number of live links carrying it. sort is name, created_at (newest first) or usage (most used first).
+func (q *Queries) ListUserTagsPage(ctx context.Context, arg ListUserTagsPageParams) ([]ListUserTagsPageRow, error) {
+	rows, err := q.db.Query(ctx, listUserTagsPage,
+		arg.UserID,
+		arg.Prefix,
+		arg.Sort,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserTagsPageRow
+	for rows.Next() {
+		var i ListUserTagsPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LinkCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTagNamesByIDs = `-- name: ListUserTagNamesByIDs :many
+SELECT name FROM tags
+WHERE user_id = $1
+  AND deleted_at IS NULL
+  AND id = ANY($2::uuid[])
+`
+
+type ListUserTagNamesByIDsParams struct {
+	UserID string      `json:"user_id"`
+	Ids    []uuid.UUID `json:"ids"`
+}
+
+// Names of the given tags that belong to the user
+func (q *Queries) ListUserTagNamesByIDs(ctx context.Context, arg ListUserTagNamesByIDsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listUserTagNamesByIDs, arg.UserID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreTag = `-- name: RestoreTag :one
+WITH trashed AS (
+    SELECT id, user_id, detached_link_ids FROM tags
+    WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+    FOR UPDATE
+),
+restored AS (
+    UPDATE tags
+    SET deleted_at = NULL,
+        updated_at = NOW(),
+        detached_link_ids = NULL
+    FROM trashed
+    WHERE tags.id = trashed.id
+    RETURNING tags.id, tags.name, tags.created_at, tags.updated_at
+),
+reattached AS (
+    INSERT INTO link_tags (link_id, tag_id)
+    SELECT l.id, t.id
+    FROM trashed t
+    JOIN links l ON l.id = ANY(t.detached_link_ids) AND l.user_id = t.user_id
+    ON CONFLICT (link_id, tag_id) DO NOTHING
+)
+SELECT id, name, created_at, updated_at FROM restored
+`
+
+type RestoreTagParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID string    `json:"user_id"`
+}
+
+type RestoreTagRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Takes the tag out of the trash and puts it back on the links it was detached from that still exist
+func (q *Queries) RestoreTag(ctx context.Context, arg RestoreTagParams) (RestoreTagRow, error) {
+	row := q.db.QueryRow(ctx, restoreTag, arg.ID, arg.UserID)
+	var i RestoreTagRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const suggestTagsForHost = `-- name: SuggestTagsForHost :many
+SELECT t.id, t.name, COUNT(*) AS link_count
+FROM links l
+JOIN link_tags lt ON lt.link_id = l.id
+JOIN tags t ON t.id = lt.tag_id
+WHERE l.user_id = $1
+  AND l.deleted_at IS NULL
+  AND regexp_replace(
+        lower(substring(l.original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)')),
+        '^www\.', ''
+      ) = $2::TEXT
+GROUP BY t.id, t.name
+ORDER BY link_count DESC, t.name
+LIMIT $3
+`
+
+type SuggestTagsForHostParams struct {
+	UserID     string `json:"user_id"`
+	Host       string `json:"host"`
+	MaxResults int32  `json:"max_results"`
+}
+
+type SuggestTagsForHostRow struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	LinkCount int64     `json:"link_count"`
+}
+
+// Tags the user put on their links to the same host (ignoring a leading www.), most used first
+func (q *Queries) SuggestTagsForHost(ctx context.Context, arg SuggestTagsForHostParams) ([]SuggestTagsForHostRow, error) {
+	rows, err := q.db.Query(ctx, suggestTagsForHost, arg.UserID, arg.Host, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SuggestTagsForHostRow
+	for rows.Next() {
+		var i SuggestTagsForHostRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.LinkCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTag = `-- name: UpdateTag :one
 UPDATE tags
 SET 
 	name = $1, 
 	updated_at = NOW()
-WHERE id = $2 AND user_id = $3
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
 RETURNING id, name, created_at, updated_at
 `
 
@@ -169,10 +504,10 @@ type UpdateTagParams struct {
 }
 
 type UpdateTagRow struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (UpdateTagRow, error) {
@@ -186,3 +521,84 @@ func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (UpdateTag
 	)
 	return i, err
 }
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (name, user_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) WHERE deleted_at IS NULL DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name, created_at, updated_at, (xmax = 0)::boolean AS created
+`
+
+type UpsertTagParams struct {
+	Name   string `json:"name"`
+	UserID string `json:"user_id"`
+}
+
+type UpsertTagRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	Created   bool               `json:"created"`
+}
+
+// Creates the tag or returns the user's existing tag with that name.
+// created is false when the row already existed (xmax is set by the no-op update).
+func (q *Queries) UpsertTag(ctx context.Context, arg UpsertTagParams) (UpsertTagRow, error) {
+	row := q.db.QueryRow(ctx, upsertTag, arg.Name, arg.UserID)
+	var i UpsertTagRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Created,
+	)
+	return i, err
+}
+
+const upsertTagsByName = `-- name: UpsertTagsByName :many
+INSERT INTO tags (name, user_id)
+SELECT DISTINCT unnest($1::text[]), $2::text
+ON CONFLICT (user_id, name) WHERE deleted_at IS NULL DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name, created_at, updated_at
+`
+
+type UpsertTagsByNameParams struct {
+	Names  []string `json:"names"`
+	UserID string   `json:"user_id"`
+}
+
+type UpsertTagsByNameRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Creates the user's tags that don't exist yet and returns all of them
+// (the no-op update makes RETURNING include existing tags)
+func (q *Queries) UpsertTagsByName(ctx context.Context, arg UpsertTagsByNameParams) ([]UpsertTagsByNameRow, error) {
+	rows, err := q.db.Query(ctx, upsertTagsByName, arg.Names, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UpsertTagsByNameRow
+	for rows.Next() {
+		var i UpsertTagsByNameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
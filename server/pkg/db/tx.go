@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxBeginner starts transactions; *pgxpool.Pool, *pgx.Conn and pgx.Tx (savepoints) implement it
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Store is Queries plus the ability to run several of them as one unit of work.
+// It can be used anywhere Queries is.
+type Store struct {
+	*Queries
+	db TxBeginner
+}
+
+func NewStore(db interface {
+	DBTX
+	TxBeginner
+}) *Store {
+	return &Store{
+		Queries: New(db),
+		db:      db,
+	}
+}
+
+// WithTx runs fn with queries bound to a single transaction. The transaction is
+// committed when fn returns nil and rolled back when it returns an error or panics
+// (the panic is re-raised after the rollback).
+//
+// fn must only use the queries it is given: queries made through the Store itself
+// run outside the transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(q *Queries) error) (err error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(s.Queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeTx records the statements run in it and how it ended.
+// Methods WithTx doesn't use are left to the embedded (nil) interface.
+type fakeTx struct {
+	pgx.Tx
+	statements []string
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tx.statements = append(tx.statements, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if tx.committed {
+		return pgx.ErrTxClosed
+	}
+	tx.rolledBack = true
+	return nil
+}
+
+// fakePool hands out one fakeTx; queries made outside a transaction fail the test
+type fakePool struct {
+	DBTX
+	tx       *fakeTx
+	beginErr error
+}
+
+func (p *fakePool) Begin(ctx context.Context) (pgx.Tx, error) {
+	if p.beginErr != nil {
+		return nil, p.beginErr
+	}
+	return p.tx, nil
+}
+
+func TestStore_WithTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits when fn succeeds", func(t *testing.T) {
+		pool := &fakePool{tx: &fakeTx{}}
+		store := NewStore(pool)
+
+		err := store.WithTx(ctx, func(q *Queries) error {
+			return q.CreateLinkLead(ctx, CreateLinkLeadParams{Email: "a@example.com"})
+		})
+		if err != nil {
+			t.Fatalf("WithTx() error = %v, want nil", err)
+		}
+
+		if !pool.tx.committed || pool.tx.rolledBack {
+			t.Errorf("committed = %v, rolledBack = %v, want commit only", pool.tx.committed, pool.tx.rolledBack)
+		}
+		if len(pool.tx.statements) != 1 {
+			t.Errorf("statements run in the transaction = %d, want 1", len(pool.tx.statements))
+		}
+	})
+
+	t.Run("rolls back when fn fails", func(t *testing.T) {
+		pool := &fakePool{tx: &fakeTx{}}
+		store := NewStore(pool)
+		fnErr := errors.New("second statement failed")
+
+		err := store.WithTx(ctx, func(q *Queries) error {
+			if err := q.CreateLinkLead(ctx, CreateLinkLeadParams{Email: "a@example.com"}); err != nil {
+				return err
+			}
+			return fnErr
+		})
+		if !errors.Is(err, fnErr) {
+			t.Fatalf("WithTx() error = %v, want %v", err, fnErr)
+		}
+
+		if pool.tx.committed || !pool.tx.rolledBack {
+			t.Errorf("committed = %v, rolledBack = %v, want rollback only", pool.tx.committed, pool.tx.rolledBack)
+		}
+	})
+
+	t.Run("rolls back and re-panics when fn panics", func(t *testing.T) {
+		pool := &fakePool{tx: &fakeTx{}}
+		store := NewStore(pool)
+
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the original panic", p)
+			}
+			if pool.tx.committed || !pool.tx.rolledBack {
+				t.Errorf("committed = %v, rolledBack = %v, want rollback only", pool.tx.committed, pool.tx.rolledBack)
+			}
+		}()
+
+		_ = store.WithTx(ctx, func(q *Queries) error {
+			panic("boom")
+		})
+	})
+
+	t.Run("reports begin and commit failures", func(t *testing.T) {
+		beginErr := errors.New("pool closed")
+		store := NewStore(&fakePool{beginErr: beginErr})
+
+		called := false
+		err := store.WithTx(ctx, func(q *Queries) error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, beginErr) || called {
+			t.Errorf("WithTx() error = %v, fn called = %v, want begin error without calling fn", err, called)
+		}
+
+		commitErr := errors.New("serialization failure")
+		store = NewStore(&fakePool{tx: &fakeTx{commitErr: commitErr}})
+		if err := store.WithTx(ctx, func(q *Queries) error { return nil }); !errors.Is(err, commitErr) {
+			t.Errorf("WithTx() error = %v, want %v", err, commitErr)
+		}
+	})
+}
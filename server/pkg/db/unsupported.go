@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrUnsupported is returned by queries the configured storage backend doesn't implement
+var ErrUnsupported = errors.New("not supported by this storage backend")
+
+// Unsupported returns a DBTX on which every query fails with ErrUnsupported.
+// Backends other than Postgres embed New(Unsupported()) for the queries they don't implement.
+func Unsupported() DBTX {
+	return unsupportedDB{}
+}
+
+type unsupportedDB struct{}
+
+func (unsupportedDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, ErrUnsupported
+}
+
+func (unsupportedDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, ErrUnsupported
+}
+
+func (unsupportedDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return unsupportedRow{}
+}
+
+type unsupportedRow struct{}
+
+func (unsupportedRow) Scan(...any) error {
+	return ErrUnsupported
+}
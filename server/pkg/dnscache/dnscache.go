@@ -0,0 +1,314 @@
+/*
+Package dnscache resolves hostnames and TXT records through a cache, so the
+outbound checks that resolve the same names over and over (SSRF guards,
+reachability checks, domain verification) don't pay a lookup each time or
+flood the resolver.
+
+Answers are cached for their records' TTL, within the resolver's bounds.
+Names that don't exist are cached too (negative caching), for the TTL their
+zone's SOA gives them. Concurrent lookups of the same name share one query.
+
+The TTLs are read off the DNS responses, so they're only known when Go's own
+resolver answers over UDP; answers from /etc/hosts, or truncated ones retried
+over TCP, are cached for DefaultTTL.
+*/
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sync/singleflight"
+)
+
+// Defaults of the zero Options
+const (
+	DefaultMinTTL      = 5 * time.Second
+	DefaultMaxTTL      = 5 * time.Minute
+	DefaultTTL         = time.Minute
+	DefaultNegativeTTL = 30 * time.Second
+	DefaultMaxEntries  = 10000
+)
+
+// Options configures a Resolver
+type Options struct {
+	// Shortest time an answer is cached, however small its TTL
+	MinTTL time.Duration
+	// Longest time an answer is cached, however large its TTL
+	MaxTTL time.Duration
+	// How long answers whose TTL isn't known are cached
+	DefaultTTL time.Duration
+	// Longest time a name that doesn't exist is cached
+	NegativeTTL time.Duration
+	// Cached names beyond which expired, then arbitrary, entries are evicted
+	MaxEntries int
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinTTL <= 0 {
+		o.MinTTL = DefaultMinTTL
+	}
+	if o.MaxTTL <= 0 {
+		o.MaxTTL = DefaultMaxTTL
+	}
+	if o.DefaultTTL <= 0 {
+		o.DefaultTTL = DefaultTTL
+	}
+	if o.NegativeTTL <= 0 {
+		o.NegativeTTL = DefaultNegativeTTL
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = DefaultMaxEntries
+	}
+	return o
+}
+
+// Resolver is a caching DNS resolver, safe for concurrent use
+type Resolver struct {
+	opts     Options
+	resolver *net.Resolver
+	group    singleflight.Group
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+type entry struct {
+	addrs   []netip.Addr
+	txt     []string
+	err     error
+	expires time.Time
+}
+
+// New returns a Resolver querying the system's nameservers
+func New(opts Options) *Resolver {
+	return newResolver(opts, (&net.Dialer{}).DialContext)
+}
+
+// newResolver returns a Resolver whose queries go through dial
+func newResolver(opts Options, dial func(ctx context.Context, network, address string) (net.Conn, error)) *Resolver {
+	return &Resolver{
+		opts: opts.withDefaults(),
+		resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				conn, err := dial(ctx, network, address)
+				if err != nil {
+					return nil, err
+				}
+				return recording(ctx, conn), nil
+			},
+		},
+		now:     time.Now,
+		entries: make(map[string]entry),
+	}
+}
+
+// LookupNetIP returns the IPv4 and IPv6 addresses of host
+func (r *Resolver) LookupNetIP(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+
+	e, err := r.lookup(ctx, "ip:"+host, func(ctx context.Context) (entry, error) {
+		addrs, err := r.resolver.LookupNetIP(ctx, "ip", host)
+		return entry{addrs: addrs}, err
+	})
+	return e.addrs, err
+}
+
+// LookupTXT returns the TXT records of name
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	e, err := r.lookup(ctx, "txt:"+name, func(ctx context.Context) (entry, error) {
+		txt, err := r.resolver.LookupTXT(ctx, name)
+		return entry{txt: txt}, err
+	})
+	return e.txt, err
+}
+
+// lookup answers key from the cache, or with query, caching its answer
+func (r *Resolver) lookup(ctx context.Context, key string, query func(ctx context.Context) (entry, error)) (entry, error) {
+	if e, ok := r.cached(key); ok {
+		metrics.DNSCacheHits.Add(1)
+		return e, e.err
+	}
+	metrics.DNSCacheMisses.Add(1)
+
+	// Shared by concurrent lookups, so it mustn't be cut short by the first caller's context
+	ch := r.group.DoChan(key, func() (any, error) {
+		rec := &ttlRecorder{}
+		e, err := query(context.WithValue(context.WithoutCancel(ctx), ttlRecorderKey{}, rec))
+
+		var dnsErr *net.DNSError
+		switch {
+		case err == nil:
+			e.expires = r.now().Add(r.ttl(rec.positive()))
+		case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+			e.err = err
+			e.expires = r.now().Add(min(r.ttl(rec.negative()), r.opts.NegativeTTL))
+		default:
+			// Timeouts and server failures are the resolver's trouble, not answers
+			return entry{}, err
+		}
+
+		r.store(key, e)
+		return e, e.err
+	})
+
+	select {
+	case <-ctx.Done():
+		return entry{}, ctx.Err()
+	case res := <-ch:
+		if res.Val == nil {
+			return entry{}, res.Err
+		}
+		return res.Val.(entry), res.Err
+	}
+}
+
+// ttl bounds a record's TTL, or defaults it when it's unknown
+func (r *Resolver) ttl(ttl time.Duration, known bool) time.Duration {
+	if !known {
+		return r.opts.DefaultTTL
+	}
+	return min(max(ttl, r.opts.MinTTL), r.opts.MaxTTL)
+}
+
+func (r *Resolver) cached(key string) (entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok || !r.now().Before(e.expires) {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func (r *Resolver) store(key string, e entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) >= r.opts.MaxEntries {
+		now := r.now()
+		for k, old := range r.entries {
+			if !now.Before(old.expires) {
+				delete(r.entries, k)
+			}
+		}
+	}
+	if len(r.entries) >= r.opts.MaxEntries {
+		for k := range r.entries {
+			delete(r.entries, k)
+			break
+		}
+	}
+	r.entries[key] = e
+}
+
+type ttlRecorderKey struct{}
+
+/*
+ttlRecorder collects the TTLs of the DNS responses of one lookup, which can
+send several queries (A and AAAA, each search domain).
+*/
+type ttlRecorder struct {
+	mu          sync.Mutex
+	answerTTL   time.Duration
+	answered    bool
+	negativeTTL time.Duration
+	denied      bool
+}
+
+func (rec *ttlRecorder) positive() (time.Duration, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.answerTTL, rec.answered
+}
+
+func (rec *ttlRecorder) negative() (time.Duration, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.negativeTTL, rec.denied
+}
+
+/*
+record reads a response's TTLs: the smallest of its answers, or for responses
+without answers the negative TTL of their SOA (the smaller of its TTL and
+minimum field, per RFC 2308).
+*/
+func (rec *ttlRecorder) record(msg []byte) {
+	var p dnsmessage.Parser
+	if _, err := p.Start(msg); err != nil {
+		return
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(answers) > 0 {
+		for _, a := range answers {
+			ttl := time.Duration(a.Header.TTL) * time.Second
+			if !rec.answered || ttl < rec.answerTTL {
+				rec.answerTTL = ttl
+			}
+			rec.answered = true
+		}
+		return
+	}
+
+	authorities, err := p.AllAuthorities()
+	if err != nil {
+		return
+	}
+	for _, a := range authorities {
+		soa, ok := a.Body.(*dnsmessage.SOAResource)
+		if !ok {
+			continue
+		}
+		ttl := time.Duration(min(a.Header.TTL, soa.MinTTL)) * time.Second
+		if !rec.denied || ttl < rec.negativeTTL {
+			rec.negativeTTL = ttl
+		}
+		rec.denied = true
+	}
+}
+
+// recording wraps UDP connections to nameservers so the lookup's ttlRecorder sees their responses
+func recording(ctx context.Context, conn net.Conn) net.Conn {
+	rec, ok := ctx.Value(ttlRecorderKey{}).(*ttlRecorder)
+	udp, isUDP := conn.(*net.UDPConn)
+	if !ok || !isUDP {
+		return conn
+	}
+	return &recordingConn{UDPConn: udp, rec: rec}
+}
+
+// recordingConn is still a net.PacketConn, which tells the resolver each Read is a whole message
+type recordingConn struct {
+	*net.UDPConn
+	rec *ttlRecorder
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.UDPConn.Read(b)
+	if err == nil {
+		c.rec.record(b[:n])
+	}
+	return n, err
+}
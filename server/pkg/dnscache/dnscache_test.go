@@ -0,0 +1,157 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// nameserver answers A queries for known.test. and NXDOMAIN for anything else, counting the queries
+type nameserver struct {
+	conn    net.PacketConn
+	queries atomic.Int32
+}
+
+func startNameserver(t *testing.T) *nameserver {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	ns := &nameserver{conn: conn}
+	t.Cleanup(func() { conn.Close() })
+	go ns.serve()
+	return ns
+}
+
+func (ns *nameserver) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := ns.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var p dnsmessage.Parser
+		header, err := p.Start(buf[:n])
+		if err != nil {
+			continue
+		}
+		q, err := p.Question()
+		if err != nil {
+			continue
+		}
+		ns.queries.Add(1)
+
+		b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true})
+		b.EnableCompression()
+		_ = b.StartQuestions()
+		_ = b.Question(q)
+
+		switch {
+		case q.Name.String() == "known.test." && q.Type == dnsmessage.TypeA:
+			_ = b.StartAnswers()
+			_ = b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 120},
+				dnsmessage.AResource{A: [4]byte{93, 184, 216, 34}})
+		case q.Name.String() == "known.test.":
+			// No AAAA record: an empty answer
+		default:
+			b = dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeNameError})
+			_ = b.StartQuestions()
+			_ = b.Question(q)
+			_ = b.StartAuthorities()
+			_ = b.SOAResource(dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("test."), Class: dnsmessage.ClassINET, TTL: 3600},
+				dnsmessage.SOAResource{NS: dnsmessage.MustNewName("ns.test."), MBox: dnsmessage.MustNewName("admin.test."), MinTTL: 10})
+		}
+
+		msg, err := b.Finish()
+		if err != nil {
+			continue
+		}
+		_, _ = ns.conn.WriteTo(msg, addr)
+	}
+}
+
+func newTestResolver(t *testing.T, ns *nameserver) (*Resolver, *time.Time) {
+	t.Helper()
+	r := newResolver(Options{NegativeTTL: time.Minute}, func(ctx context.Context, network, _ string) (net.Conn, error) {
+		if network != "udp" {
+			t.Errorf("query over %s, want udp", network)
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "udp", ns.conn.LocalAddr().String())
+	})
+	now := time.Unix(1700000000, 0)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestResolver_CachesForTheRecordTTL(t *testing.T) {
+	ns := startNameserver(t)
+	r, now := newTestResolver(t, ns)
+	ctx := context.Background()
+
+	for range 3 {
+		addrs, err := r.LookupNetIP(ctx, "known.test.")
+		if err != nil {
+			t.Fatalf("LookupNetIP() error = %v", err)
+		}
+		if len(addrs) != 1 || addrs[0] != netip.MustParseAddr("93.184.216.34") {
+			t.Fatalf("LookupNetIP() = %v, want [93.184.216.34]", addrs)
+		}
+	}
+	// One A and one AAAA query
+	if got := ns.queries.Load(); got != 2 {
+		t.Errorf("queries = %d, want 2", got)
+	}
+
+	*now = now.Add(119 * time.Second)
+	if _, err := r.LookupNetIP(ctx, "known.test."); err != nil {
+		t.Fatalf("LookupNetIP() error = %v", err)
+	}
+	if got := ns.queries.Load(); got != 2 {
+		t.Errorf("queries within the TTL = %d, want 2", got)
+	}
+
+	*now = now.Add(2 * time.Second)
+	if _, err := r.LookupNetIP(ctx, "known.test."); err != nil {
+		t.Fatalf("LookupNetIP() error = %v", err)
+	}
+	if got := ns.queries.Load(); got != 4 {
+		t.Errorf("queries after the TTL = %d, want 4", got)
+	}
+}
+
+func TestResolver_NegativeCaching(t *testing.T) {
+	ns := startNameserver(t)
+	r, now := newTestResolver(t, ns)
+	ctx := context.Background()
+
+	lookup := func() {
+		t.Helper()
+		_, err := r.LookupNetIP(ctx, "missing.test.")
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Fatalf("LookupNetIP() error = %v, want a not found DNSError", err)
+		}
+	}
+
+	lookup()
+	queries := ns.queries.Load()
+	lookup()
+	if got := ns.queries.Load(); got != queries {
+		t.Errorf("queries = %d, want %d: the missing name should be cached", got, queries)
+	}
+
+	// The SOA's minimum (10s) bounds the negative TTL, not its TTL (1h)
+	*now = now.Add(11 * time.Second)
+	lookup()
+	if got := ns.queries.Load(); got == queries {
+		t.Error("missing name still cached after its negative TTL")
+	}
+}
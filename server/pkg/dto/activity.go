@@ -0,0 +1,13 @@
+package dto
+
+// ActivityChainVerification is the outcome of checking a user's activity log against its hash chain
+type ActivityChainVerification struct {
+	Valid  bool  `json:"valid"`
+	Events int64 `json:"events"`
+	// The last verified event; save both to pass back as anchor_seq and anchor_hash later
+	HeadSeq  int64  `json:"head_seq"`
+	HeadHash string `json:"head_hash"`
+	// Where the chain breaks and why, null when it's valid
+	BrokenAtSeq *int64  `json:"broken_at_seq"`
+	Reason      *string `json:"reason"`
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey may leave out Scopes: the key then gets those of the links routes
+type CreateAPIKey struct {
+	Name   string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" validate:"omitempty,min=1,dive,oneof=links:read links:create links:write stats:read tags:read tags:write"`
+}
+
+// RotateAPIKey may be empty: the previous key then keeps working for a day
+type RotateAPIKey struct {
+	// Seconds the previous key keeps working next to the new one; 0 revokes it at once
+	PreviousKeyTTL *int `json:"previous_key_ttl" validate:"omitempty,min=0,max=604800"`
+}
+
+// APIKey is a key for scripts and other programmatic clients; Key is only set in the responses that create or rotate it
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// Set once the key is revoked; revoked keys no longer work
+	RevokedAt *time.Time `json:"revoked_at"`
+	Key       string     `json:"key,omitempty"`
+}
+
+// APIKeyUsage is what's recorded about an API key's use, for audits and safe rotation
+type APIKeyUsage struct {
+	ID         uuid.UUID  `json:"id"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// Address of the client that last used the key
+	LastUsedIP *string `json:"last_used_ip"`
+	// Requests made with the key, counting its previous one
+	UseCount     int64      `json:"use_count"`
+	KeyRotatedAt *time.Time `json:"key_rotated_at"`
+	// The key replaced by the last rotation while it still works, nil otherwise
+	PreviousKey *PreviousAPIKey `json:"previous_key"`
+}
+
+// PreviousAPIKey is a rotated-out key in its grace period
+type PreviousAPIKey struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	// Nil while no client has used it since the rotation, when it's safe to revoke
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
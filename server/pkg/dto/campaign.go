@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// For custom validation logic, implement the Validator interface
+// defined in pkg/middleware/request_validator.go
+
+type CreateCampaign struct {
+	Name       string     `json:"name" validate:"required,min=1,max=100"`
+	BudgetNote *string    `json:"budget_note" validate:"omitempty,max=1000"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+}
+
+func (dto *CreateCampaign) Validate() error {
+	dto.Name = strings.TrimSpace(dto.Name)
+
+	if dto.Name == "" {
+		return errors.New("campaign name cannot be empty")
+	}
+
+	if dto.StartsAt != nil && dto.EndsAt != nil && !dto.StartsAt.Before(*dto.EndsAt) {
+		return errors.New("starts_at must be before ends_at")
+	}
+
+	return nil
+}
+
+type UpdateCampaign struct {
+	Name       *string    `json:"name" validate:"omitempty,min=1,max=100"`
+	BudgetNote *string    `json:"budget_note" validate:"omitempty,max=1000"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+}
+
+func (dto *UpdateCampaign) Validate() error {
+	if dto.Name == nil && dto.BudgetNote == nil && dto.StartsAt == nil && dto.EndsAt == nil {
+		return errors.New("At least one of the following fields must be provided: name | budget_note | starts_at | ends_at")
+	}
+
+	if dto.Name != nil {
+		name := strings.TrimSpace(*dto.Name)
+		if name == "" {
+			return errors.New("campaign name cannot be empty")
+		}
+		dto.Name = &name
+	}
+
+	if dto.StartsAt != nil && dto.EndsAt != nil && !dto.StartsAt.Before(*dto.EndsAt) {
+		return errors.New("starts_at must be before ends_at")
+	}
+
+	return nil
+}
+
+type AddLinksToCampaign struct {
+	LinkIDs []uuid.UUID `json:"link_ids" validate:"required,min=1"`
+}
+
+type RemoveLinksFromCampaign struct {
+	LinkIDs []uuid.UUID `json:"link_ids" validate:"required,min=1"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/google/uuid"
+
+// CreateConversion is a postback for a click on a link with append_click_id
+type CreateConversion struct {
+	ClickID uuid.UUID `json:"click_id" validate:"required"`
+	// Name of the event, e.g. "signup" or "purchase"
+	Event        string `json:"event" validate:"required,min=1,max=100"`
+	RevenueCents *int64 `json:"revenue_cents" validate:"omitempty,min=0"`
+	// Deduplicates retried postbacks: a click records an external ID only once
+	ExternalID *string `json:"external_id" validate:"omitempty,min=1,max=255"`
+}
@@ -2,33 +2,78 @@ package dto
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 // For custom validation logic, implement the Validator interface
-// defined in pkg/middleware/request_validator.go
+// defined in pkg/middleware/request_validator.go. Rules shared by several
+// DTOs (httpurl, shortcode, future_time) are validator tags, see pkg/validation.
 
 type CreateLink struct {
-	URL       string     `json:"url" validate:"required"`
-	Shortcode *string    `json:"shortcode" validate:"omitempty,min=1"`
-	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty"`
+	URL                 string     `json:"url" validate:"required,httpurl"`
+	Shortcode           *string    `json:"shortcode" validate:"omitempty,shortcode"`
+	ExpiresAt           *time.Time `json:"expires_at" validate:"omitempty,future_time"`
+	Visibility          *string    `json:"visibility" validate:"omitempty,oneof=public private"`
+	CaptureEmail        *bool      `json:"capture_email"`
+	RedirectDelay       *int32     `json:"redirect_delay" validate:"omitempty,min=0,max=30"`
+	InterstitialMessage *string    `json:"interstitial_message" validate:"omitempty,max=500"`
+	// Page title of the destination, shown in link lists
+	Title *string `json:"title" validate:"omitempty,max=255"`
+	// Add ?click_id= to the destination for conversion tracking
+	AppendClickID *bool `json:"append_click_id"`
+	// Challenge suspected bots before redirecting, see service.BotShield
+	Shield *bool `json:"shield"`
+	// What the destination sees as the referrer, see service.ReferrerPolicyDefault
+	ReferrerPolicy *string `json:"referrer_policy" validate:"omitempty,oneof=default no-referrer origin"`
+	// Apply suggested tags to the new link; defaults to the server's AUTO_TAG_LINKS setting
+	AutoTag *bool `json:"auto_tag"`
+	// Existing tags to add to the new link
+	TagIDs []uuid.UUID `json:"tag_ids" validate:"omitempty,max=20"`
+	// Tags to add by name, created if the user doesn't have them yet
+	TagNames []string `json:"tag_names" validate:"omitempty,max=20,dive,min=1,max=30"`
+	// Confirms an http:// destination may be switched to https:// when that's reachable
+	UpgradeHTTPS bool `json:"upgrade_https"`
+}
+
+func (dto *CreateLink) Validate() error {
+	for _, id := range dto.TagIDs {
+		if id == uuid.Nil {
+			return errors.New("all tag_ids must be valid UUIDs")
+		}
+	}
+
+	// Tag names follow the same rules as POST /tags
+	for i, name := range dto.TagNames {
+		dto.TagNames[i] = strings.TrimSpace(name)
+		if dto.TagNames[i] == "" {
+			return errors.New("tag name cannot be empty")
+		}
+	}
+
+	return nil
 }
 
 type UpdateLink struct {
-	Shortcode *string    `json:"shortcode"`
-	IsActive  *bool      `json:"is_active"`
-	ExpiresAt *time.Time `json:"expires_at"`
+	Shortcode           *string    `json:"shortcode" validate:"omitempty,shortcode"`
+	IsActive            *bool      `json:"is_active"`
+	ExpiresAt           *time.Time `json:"expires_at" validate:"omitempty,future_time"`
+	Visibility          *string    `json:"visibility" validate:"omitempty,oneof=public private"`
+	CaptureEmail        *bool      `json:"capture_email"`
+	RedirectDelay       *int32     `json:"redirect_delay" validate:"omitempty,min=0,max=30"`
+	InterstitialMessage *string    `json:"interstitial_message" validate:"omitempty,max=500"`
+	AppendClickID       *bool      `json:"append_click_id"`
+	Shield              *bool      `json:"shield"`
+	ReferrerPolicy      *string    `json:"referrer_policy" validate:"omitempty,oneof=default no-referrer origin"`
 }
 
 func (dto UpdateLink) Validate() error {
-	if dto.Shortcode == nil && dto.IsActive == nil && dto.ExpiresAt == nil {
-		return errors.New("At least one of the following fields must be provided: shortcode | is_active | expires_at")
-	}
-
-	if dto.ExpiresAt != nil && dto.ExpiresAt.Before(time.Now()) {
-		return errors.New("expires_at must be set to a future time")
+	if dto.Shortcode == nil && dto.IsActive == nil && dto.ExpiresAt == nil && dto.Visibility == nil &&
+		dto.CaptureEmail == nil && dto.RedirectDelay == nil && dto.InterstitialMessage == nil && dto.AppendClickID == nil &&
+		dto.Shield == nil && dto.ReferrerPolicy == nil {
+		return errors.New("At least one of the following fields must be provided: shortcode | is_active | expires_at | visibility | capture_email | redirect_delay | interstitial_message | append_click_id | shield | referrer_policy")
 	}
 
 	return nil
@@ -41,3 +86,259 @@ type AddTagsToLink struct {
 type RemoveTagsFromLink struct {
 	TagIDs []uuid.UUID `json:"tag_ids" validate:"required,min=1"`
 }
+
+const (
+	// Lifetime of an access token when the client doesn't provide expires_at
+	DefaultAccessTokenTTL = 24 * time.Hour
+	// Longest lifetime an access token can be issued for
+	MaxAccessTokenTTL = 30 * 24 * time.Hour
+)
+
+type CreateAccessToken struct {
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,future_time"`
+}
+
+func (dto CreateAccessToken) Validate() error {
+	if dto.ExpiresAt == nil {
+		return nil
+	}
+
+	if time.Until(*dto.ExpiresAt) > MaxAccessTokenTTL {
+		return errors.New("expires_at cannot be more than 30 days in the future")
+	}
+
+	return nil
+}
+
+// AccessToken grants access to a private link: append it as ?token= to the short URL
+type AccessToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TagSuggestion is an existing tag suggested for a link, see GET /api/v1/links/suggest-tags
+type TagSuggestion struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Reason string    `json:"reason"`
+}
+
+type QRBatch struct {
+	LinkIDs []uuid.UUID `json:"link_ids" validate:"required,min=1,max=100"`
+	// zip (PNG images, the default) or pdf (a printable sheet)
+	Format string `json:"format" validate:"omitempty,oneof=zip pdf"`
+	// Pixels per QR module in PNG images, defaults to 10
+	Scale int `json:"scale" validate:"omitempty,min=2,max=32"`
+}
+
+// SetLinkPreview replaces what social crawlers are shown for a link; omitted fields aren't overridden
+type SetLinkPreview struct {
+	Title       *string `json:"title" validate:"omitempty,max=200"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+	ImageURL    *string `json:"image_url" validate:"omitempty,httpurl"`
+}
+
+func (dto SetLinkPreview) Validate() error {
+	if dto.Title == nil && dto.Description == nil && dto.ImageURL == nil {
+		return errors.New("At least one of the following fields must be provided: title | description | image_url")
+	}
+
+	return nil
+}
+
+// RetireLink takes a link out of service; its shortcode then serves a sunset page
+type RetireLink struct {
+	// Shown on the sunset page instead of the default text
+	SunsetMessage *string `json:"sunset_message" validate:"omitempty,max=500"`
+	// Where the sunset page points visitors instead
+	SunsetURL *string `json:"sunset_url" validate:"omitempty,httpurl"`
+}
+
+// SetTrafficCap caps the clicks a link sends to its destination; over a cap they go to the overflow URL
+type SetTrafficCap struct {
+	// Clicks per day (UTC)
+	DailyCap    *int32 `json:"daily_cap" validate:"omitempty,min=1"`
+	TotalCap    *int32 `json:"total_cap" validate:"omitempty,min=1"`
+	OverflowURL string `json:"overflow_url" validate:"required,httpurl"`
+}
+
+func (dto SetTrafficCap) Validate() error {
+	if dto.DailyCap == nil && dto.TotalCap == nil {
+		return errors.New("At least one of the following fields must be provided: daily_cap | total_cap")
+	}
+
+	return nil
+}
+
+// TrafficCap is a link's traffic cap and the clicks counted against it
+type TrafficCap struct {
+	DailyCap    *int32 `json:"daily_cap"`
+	TotalCap    *int32 `json:"total_cap"`
+	OverflowURL string `json:"overflow_url"`
+	// Clicks sent to the destination today (UTC) and overall
+	DailyClicks int64 `json:"daily_clicks"`
+	TotalClicks int64 `json:"total_clicks"`
+	// Clicks currently go to the overflow URL
+	Reached bool `json:"reached"`
+}
+
+// SetResponseHeaders replaces the extra headers sent with a link's redirect
+type SetResponseHeaders struct {
+	// Header name to value; only allowlisted names (X-Robots-Tag, Cache-Control, ...) are accepted
+	Headers map[string]string `json:"headers" validate:"required,min=1,max=10"`
+}
+
+// ResponseHeaders are the extra headers sent with a link's redirect
+type ResponseHeaders struct {
+	Headers   map[string]string `json:"headers"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// PublicStats tells where a link's public stats page is: the short URL followed by +
+type PublicStats struct {
+	URL       string    `json:"url"`
+	EnabledAt time.Time `json:"enabled_at"`
+}
+
+// PublicStatsToken opens a link's stats page, public or not, until it expires
+type PublicStatsToken struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SetWaitingRoom configures a link's waiting room; while active, redirects serve a holding page
+type SetWaitingRoom struct {
+	Active bool `json:"active"`
+	// Shown on the holding page instead of the default text
+	Message *string `json:"message" validate:"omitempty,max=500"`
+	// Seconds between retries, 30 when omitted
+	RetryAfter *int32 `json:"retry_after" validate:"omitempty,min=5,max=600"`
+}
+
+// WaitingRoom is a link's waiting room
+type WaitingRoom struct {
+	Active      bool       `json:"active"`
+	Message     *string    `json:"message"`
+	RetryAfter  int32      `json:"retry_after"`
+	ActivatedAt *time.Time `json:"activated_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Only returned when the waiting room is created
+	WebhookToken string `json:"webhook_token,omitempty"`
+}
+
+// WaitingRoomEvent is sent by a destination's monitoring to open or close a link's waiting room
+type WaitingRoomEvent struct {
+	Active *bool `json:"active" validate:"required"`
+}
+
+type SetDynamicLink struct {
+	// Locked links refuse destination changes until they're unlocked
+	Locked bool `json:"locked"`
+}
+
+// DynamicLink is a link's dynamic mode
+type DynamicLink struct {
+	Locked    bool      `json:"locked"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ChangeDestination struct {
+	URL string `json:"url" validate:"required,httpurl"`
+}
+
+type ScheduleDestinationChange struct {
+	URL         string    `json:"url" validate:"required,httpurl"`
+	ScheduledAt time.Time `json:"scheduled_at" validate:"required,future_time"`
+}
+
+// DestinationChange is a scheduled or applied destination change of a dynamic link
+type DestinationChange struct {
+	ID  uuid.UUID `json:"id"`
+	URL string    `json:"url"`
+	// The destination it replaced, once applied
+	PreviousURL *string    `json:"previous_url"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	AppliedAt   *time.Time `json:"applied_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// HTTPSUpgrade is the outcome of the latest HTTPS upgrade check of a link
+type HTTPSUpgrade struct {
+	LinkID    uuid.UUID `json:"link_id"`
+	Shortcode string    `json:"shortcode"`
+	ShortURL  string    `json:"short_url"`
+	FromURL   string    `json:"from_url"`
+	ToURL     string    `json:"to_url"`
+	// upgraded, available, unreachable or skipped, see service.HTTPSUpgradeUpgraded
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// DestinationAlert tells the owner a link's destination started redirecting elsewhere, returning 404 or was parked
+type DestinationAlert struct {
+	ID        uuid.UUID `json:"id"`
+	LinkID    uuid.UUID `json:"link_id"`
+	Shortcode string    `json:"shortcode"`
+	ShortURL  string    `json:"short_url"`
+	// redirected, not_found or parked
+	Kind string `json:"kind"`
+	// The destination that was fetched and where it landed
+	URL        string    `json:"url"`
+	FinalURL   *string   `json:"final_url"`
+	StatusCode *int32    `json:"status_code"`
+	Title      *string   `json:"title"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateLinkComment struct {
+	// @handles in the body are recorded as mentions
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+func (dto *CreateLinkComment) Validate() error {
+	dto.Body = strings.TrimSpace(dto.Body)
+
+	if dto.Body == "" {
+		return errors.New("comment body cannot be empty")
+	}
+
+	return nil
+}
+
+type QuickShorten struct {
+	URL string `json:"url" validate:"required,httpurl"`
+	// Page title of the destination, as the browser reports it
+	Title *string `json:"title" validate:"omitempty,max=255"`
+}
+
+type QuickShortenResult struct {
+	ID          uuid.UUID `json:"id"`
+	Shortcode   string    `json:"shortcode"`
+	ShortURL    string    `json:"short_url"`
+	OriginalURL string    `json:"original_url"`
+	Title       *string   `json:"title"`
+	// An existing link to the URL was returned instead of creating one
+	Deduplicated bool `json:"deduplicated"`
+}
+
+type WrapLinks struct {
+	HTML string `json:"html" validate:"required"`
+	// Campaign the email's short links are grouped under
+	CampaignID uuid.UUID `json:"campaign_id" validate:"required"`
+}
+
+// WrappedLink maps a URL linked from the email to the short link that replaced it
+type WrappedLink struct {
+	URL          string    `json:"url"`
+	LinkID       uuid.UUID `json:"link_id"`
+	Shortcode    string    `json:"shortcode"`
+	ShortURL     string    `json:"short_url"`
+	Deduplicated bool      `json:"deduplicated"`
+}
+
+type WrappedEmail struct {
+	HTML  string        `json:"html"`
+	Links []WrappedLink `json:"links"`
+}
@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// DuplicateLinks are the user's links to one destination, see GET /api/v1/links/duplicates
+type DuplicateLinks struct {
+	// The normalized destination the links share
+	Destination string          `json:"destination"`
+	Links       []DuplicateLink `json:"links"`
+}
+
+// DuplicateLink is one of several links to the same destination
+type DuplicateLink struct {
+	ID        uuid.UUID  `json:"id"`
+	Shortcode string     `json:"shortcode"`
+	ShortURL  string     `json:"short_url"`
+	IsActive  bool       `json:"is_active"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RetiredAt *time.Time `json:"retired_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewDuplicateLinks maps a group of the duplicates report
+func NewDuplicateLinks(destination string, rows []db.ListDuplicateLinksRow, urls LinkURLs) DuplicateLinks {
+	links := make([]DuplicateLink, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, DuplicateLink{
+			ID:        row.ID,
+			Shortcode: row.Shortcode,
+			ShortURL:  urls.ShortURLBase + "/" + row.Shortcode,
+			IsActive:  row.IsActive,
+			ExpiresAt: timePtr(row.ExpiresAt),
+			RetiredAt: timePtr(row.RetiredAt),
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return DuplicateLinks{
+		Destination: destination,
+		Links:       links,
+	}
+}
+
+// MergeLinks merges links into another of the user's links, whose aliases they become
+type MergeLinks struct {
+	Into    uuid.UUID   `json:"into" validate:"required"`
+	LinkIDs []uuid.UUID `json:"link_ids" validate:"required,min=1,max=100"`
+}
+
+// LinkAlias is a shortcode that redirects as the link it was merged into
+type LinkAlias struct {
+	ID        uuid.UUID `json:"id"`
+	Shortcode string    `json:"shortcode"`
+	ShortURL  string    `json:"short_url"`
+}
+
+// MergedLinks is the outcome of POST /api/v1/links/merge
+type MergedLinks struct {
+	Link        LinkResponse `json:"link"`
+	Aliases     []LinkAlias  `json:"aliases"`
+	ClicksMoved int64        `json:"clicks_moved"`
+}
+
+func NewMergedLinks(link db.GetLinkByIdAndUserRow, aliases []db.MergeLinksRow, clicksMoved int64, urls LinkURLs) MergedLinks {
+	merged := MergedLinks{
+		Link:        NewLinkResponse(db.TryCreateLinkRow(link), urls),
+		Aliases:     make([]LinkAlias, 0, len(aliases)),
+		ClicksMoved: clicksMoved,
+	}
+	for _, alias := range aliases {
+		merged.Aliases = append(merged.Aliases, LinkAlias{
+			ID:        alias.ID,
+			Shortcode: alias.Shortcode,
+			ShortURL:  urls.ShortURLBase + "/" + alias.Shortcode,
+		})
+	}
+	return merged
+}
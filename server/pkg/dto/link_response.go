@@ -0,0 +1,216 @@
+package dto
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/routes"
+)
+
+/*
+LinkResponse is a link as the API returns it. Handlers map query rows onto it
+instead of serializing them, so the response shape doesn't follow column names
+and fields computed outside SQL can be added here.
+
+The link queries return the same columns in two orders, so there is one
+converter per order; the other rows convert to one of them
+(e.g. db.UpdateLinkRow(deleted)).
+*/
+type LinkResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Shortcode string    `json:"shortcode"`
+	// Fully qualified, e.g. https://sho.rt/abc123
+	ShortURL            string     `json:"short_url"`
+	OriginalURL         string     `json:"original_url"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+	IsActive            bool       `json:"is_active"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at"`
+	Visibility          string     `json:"visibility"`
+	CaptureEmail        bool       `json:"capture_email"`
+	RedirectDelay       int32      `json:"redirect_delay"`
+	InterstitialMessage *string    `json:"interstitial_message"`
+	// The destination exactly as submitted; OriginalURL is its canonical form
+	RawURL         *string    `json:"raw_url"`
+	AppendClickID  bool       `json:"append_click_id"`
+	Title          *string    `json:"title"`
+	Shield         bool       `json:"shield"`
+	ReferrerPolicy string     `json:"referrer_policy"`
+	RetiredAt      *time.Time `json:"retired_at"`
+	SunsetMessage  *string    `json:"sunset_message"`
+	SunsetURL      *string    `json:"sunset_url"`
+	// Running count of the link's clicks, omitted when click counting is off or unavailable
+	ClickCount *int64 `json:"click_count,omitempty"`
+
+	Links LinkRelations `json:"_links"`
+}
+
+// LinkRelations are the hypermedia links of a link resource
+type LinkRelations struct {
+	Self  Hyperlink `json:"self"`
+	Edit  Hyperlink `json:"edit"`
+	Stats Hyperlink `json:"stats"`
+	QR    Hyperlink `json:"qr"`
+}
+
+// Hyperlink is a link relation's target. Method is only set when it isn't GET.
+type Hyperlink struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+// LinkURLs are what the URLs of a link response are built on
+type LinkURLs struct {
+	// Origin of short URLs, without a trailing slash (e.g. https://sho.rt)
+	ShortURLBase string
+	// Mount of the API version serving the request (e.g. /api/v1); _links are relative to the API host
+	API string
+}
+
+// newLinkRelations builds the _links of a link from the patterns its routes are registered with
+func newLinkRelations(api string, id uuid.UUID, shortcode string) LinkRelations {
+	links := api + routes.Links
+	return LinkRelations{
+		Self:  Hyperlink{Href: links + routes.Path(routes.Link, "shortcode", shortcode)},
+		Edit:  Hyperlink{Href: links + routes.Path(routes.LinkByID, "id", id.String()), Method: http.MethodPatch},
+		Stats: Hyperlink{Href: links + routes.Path(routes.LinkStats, "id", id.String())},
+		QR:    Hyperlink{Href: links + routes.Path(routes.LinkQR, "id", id.String())},
+	}
+}
+
+// LinkTag is a tag as listed on a link
+type LinkTag struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LinkWithTagsResponse is a link and its tags, as link listings and lookups return it
+type LinkWithTagsResponse struct {
+	LinkResponse
+	Tags []LinkTag `json:"tags"`
+}
+
+// NewLinkResponse maps the row of a link query that selects expires_at before is_active
+func NewLinkResponse(row db.TryCreateLinkRow, urls LinkURLs) LinkResponse {
+	return LinkResponse{
+		ID:                  row.ID,
+		Shortcode:           row.Shortcode,
+		ShortURL:            urls.ShortURLBase + "/" + row.Shortcode,
+		OriginalURL:         row.OriginalUrl,
+		ExpiresAt:           timePtr(row.ExpiresAt),
+		IsActive:            row.IsActive,
+		CreatedAt:           row.CreatedAt.Time,
+		UpdatedAt:           timePtr(row.UpdatedAt),
+		Visibility:          row.Visibility,
+		CaptureEmail:        row.CaptureEmail,
+		RedirectDelay:       row.RedirectDelay,
+		InterstitialMessage: row.InterstitialMessage,
+		RawURL:              row.RawUrl,
+		AppendClickID:       row.AppendClickID,
+		Title:               row.Title,
+		Shield:              row.Shield,
+		ReferrerPolicy:      row.ReferrerPolicy,
+		RetiredAt:           timePtr(row.RetiredAt),
+		SunsetMessage:       row.SunsetMessage,
+		SunsetURL:           row.SunsetUrl,
+		Links:               newLinkRelations(urls.API, row.ID, row.Shortcode),
+	}
+}
+
+// NewUpdatedLinkResponse maps the row of a link query that selects is_active before
+// expires_at: updates, deletes and retirements
+func NewUpdatedLinkResponse(row db.UpdateLinkRow, urls LinkURLs) LinkResponse {
+	return NewLinkResponse(db.TryCreateLinkRow{
+		ID:                  row.ID,
+		Shortcode:           row.Shortcode,
+		OriginalUrl:         row.OriginalUrl,
+		ExpiresAt:           row.ExpiresAt,
+		IsActive:            row.IsActive,
+		CreatedAt:           row.CreatedAt,
+		UpdatedAt:           row.UpdatedAt,
+		Visibility:          row.Visibility,
+		CaptureEmail:        row.CaptureEmail,
+		RedirectDelay:       row.RedirectDelay,
+		InterstitialMessage: row.InterstitialMessage,
+		RawUrl:              row.RawUrl,
+		AppendClickID:       row.AppendClickID,
+		Title:               row.Title,
+		Shield:              row.Shield,
+		ReferrerPolicy:      row.ReferrerPolicy,
+		RetiredAt:           row.RetiredAt,
+		SunsetMessage:       row.SunsetMessage,
+		SunsetUrl:           row.SunsetUrl,
+	}, urls)
+}
+
+// NewLinkWithTagsResponse maps the row of a link query that aggregates the link's tags.
+// Tags that can't be decoded are left out.
+func NewLinkWithTagsResponse(row db.ListUserLinksRow, urls LinkURLs) LinkWithTagsResponse {
+	return LinkWithTagsResponse{
+		LinkResponse: NewLinkResponse(db.TryCreateLinkRow{
+			ID:                  row.ID,
+			Shortcode:           row.Shortcode,
+			OriginalUrl:         row.OriginalUrl,
+			ExpiresAt:           row.ExpiresAt,
+			IsActive:            row.IsActive,
+			CreatedAt:           row.CreatedAt,
+			UpdatedAt:           row.UpdatedAt,
+			Visibility:          row.Visibility,
+			CaptureEmail:        row.CaptureEmail,
+			RedirectDelay:       row.RedirectDelay,
+			InterstitialMessage: row.InterstitialMessage,
+			RawUrl:              row.RawUrl,
+			AppendClickID:       row.AppendClickID,
+			Title:               row.Title,
+			Shield:              row.Shield,
+			ReferrerPolicy:      row.ReferrerPolicy,
+			RetiredAt:           row.RetiredAt,
+			SunsetMessage:       row.SunsetMessage,
+			SunsetUrl:           row.SunsetUrl,
+		}, urls),
+		Tags: decodeLinkTags(row.Tags),
+	}
+}
+
+// NewLinkWithTagsResponses maps a listing; it's never nil, so empty listings encode as []
+func NewLinkWithTagsResponses(rows []db.ListUserLinksRow, urls LinkURLs) []LinkWithTagsResponse {
+	links := make([]LinkWithTagsResponse, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, NewLinkWithTagsResponse(row, urls))
+	}
+	return links
+}
+
+/*
+decodeLinkTags reads the tags column of a link query. Its Go value depends on
+the store (decoded JSON on Postgres, raw JSON on SQLite, structs in memory), so
+it's read back through its JSON encoding.
+*/
+func decodeLinkTags(column any) []LinkTag {
+	tags := []LinkTag{}
+	if column == nil {
+		return tags
+	}
+
+	encoded, err := json.Marshal(column)
+	if err != nil {
+		return tags
+	}
+	if err := json.Unmarshal(encoded, &tags); err != nil || tags == nil {
+		return []LinkTag{}
+	}
+	return tags
+}
+
+// timePtr returns nil for a NULL timestamp
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
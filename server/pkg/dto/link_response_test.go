@@ -0,0 +1,166 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+var testURLs = LinkURLs{ShortURLBase: "https://sho.rt", API: "/api/v1"}
+
+func TestNewLinkResponse(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	row := db.TryCreateLinkRow{
+		ID:             uuid.New(),
+		Shortcode:      "abc123",
+		OriginalUrl:    "https://example.com",
+		IsActive:       true,
+		CreatedAt:      pgtype.Timestamptz{Time: created, Valid: true},
+		Visibility:     "public",
+		ReferrerPolicy: "default",
+	}
+
+	link := NewLinkResponse(row, testURLs)
+	if link.ID != row.ID || link.OriginalURL != row.OriginalUrl || !link.CreatedAt.Equal(created) {
+		t.Errorf("NewLinkResponse() = %+v, want the row's values", link)
+	}
+	if link.ShortURL != "https://sho.rt/abc123" {
+		t.Errorf("ShortURL = %q, want https://sho.rt/abc123", link.ShortURL)
+	}
+	if link.ExpiresAt != nil || link.UpdatedAt != nil || link.RetiredAt != nil {
+		t.Errorf("NewLinkResponse() NULL timestamps = %v, %v, %v, want nil", link.ExpiresAt, link.UpdatedAt, link.RetiredAt)
+	}
+
+	encoded, err := json.Marshal(link)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if fields["original_url"] != "https://example.com" || fields["expires_at"] != nil || fields["created_at"] != "2025-01-01T12:00:00Z" {
+		t.Errorf("encoded link = %s", encoded)
+	}
+}
+
+func TestNewLinkResponse_Links(t *testing.T) {
+	id := uuid.MustParse("6f1c7d1e-2b1a-4c4e-9d7a-3b2f1e0a9c8d")
+	link := NewLinkResponse(db.TryCreateLinkRow{ID: id, Shortcode: "my code"}, testURLs)
+
+	want := LinkRelations{
+		Self:  Hyperlink{Href: "/api/v1/links/my%20code"},
+		Edit:  Hyperlink{Href: "/api/v1/links/" + id.String(), Method: "PATCH"},
+		Stats: Hyperlink{Href: "/api/v1/links/" + id.String() + "/stats/export"},
+		QR:    Hyperlink{Href: "/api/v1/links/" + id.String() + "/qr"},
+	}
+	if link.Links != want {
+		t.Errorf("Links = %+v, want %+v", link.Links, want)
+	}
+}
+
+func TestNewUpdatedLinkResponse(t *testing.T) {
+	expires := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	sunsetURL := "https://example.com/gone"
+	row := db.UpdateLinkRow{
+		ID:          uuid.New(),
+		Shortcode:   "abc123",
+		OriginalUrl: "https://example.com",
+		ExpiresAt:   pgtype.Timestamptz{Time: expires, Valid: true},
+		CreatedAt:   pgtype.Timestamptz{Time: expires.Add(-time.Hour), Valid: true},
+		SunsetUrl:   &sunsetURL,
+	}
+
+	link := NewUpdatedLinkResponse(row, testURLs)
+	if link.ExpiresAt == nil || !link.ExpiresAt.Equal(expires) {
+		t.Errorf("ExpiresAt = %v, want %v", link.ExpiresAt, expires)
+	}
+	if link.SunsetURL == nil || *link.SunsetURL != "https://example.com/gone" {
+		t.Errorf("SunsetURL = %v, want the row's sunset_url", link.SunsetURL)
+	}
+}
+
+func TestNewLinkWithTagsResponse(t *testing.T) {
+	tagID := uuid.New()
+	want := []LinkTag{{ID: tagID, Name: "work", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}}
+
+	tests := []struct {
+		name string
+		tags any
+		want []LinkTag
+	}{
+		{
+			name: "decoded json (postgres)",
+			tags: []any{map[string]any{"id": tagID.String(), "name": "work", "created_at": "2025-01-01T00:00:00+00:00"}},
+			want: want,
+		},
+		{
+			name: "raw json (sqlite)",
+			tags: json.RawMessage(`[{"id":"` + tagID.String() + `","name":"work","created_at":"2025-01-01T00:00:00Z"}]`),
+			want: want,
+		},
+		{name: "no tags", tags: json.RawMessage(`[]`), want: []LinkTag{}},
+		{name: "null column", tags: nil, want: []LinkTag{}},
+		{name: "undecodable", tags: "not tags", want: []LinkTag{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := NewLinkWithTagsResponse(db.ListUserLinksRow{ID: uuid.New(), Tags: tt.tags}, testURLs)
+
+			if len(link.Tags) != len(tt.want) {
+				t.Fatalf("Tags = %+v, want %+v", link.Tags, tt.want)
+			}
+			for i := range tt.want {
+				if link.Tags[i].ID != tt.want[i].ID || link.Tags[i].Name != tt.want[i].Name || !link.Tags[i].CreatedAt.Equal(tt.want[i].CreatedAt) {
+					t.Errorf("Tags[%d] = %+v, want %+v", i, link.Tags[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewLinkWithTagsResponses_Empty(t *testing.T) {
+	encoded, err := json.Marshal(NewLinkWithTagsResponses(nil, testURLs))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(encoded) != "[]" {
+		t.Errorf("encoded empty listing = %s, want []", encoded)
+	}
+}
+
+// The response keeps the JSON fields the handlers served when they encoded rows, plus the computed ones
+func TestLinkWithTagsResponse_Fields(t *testing.T) {
+	rowFields := jsonFields(reflect.TypeFor[db.ListUserLinksRow]())
+	rowFields["short_url"] = true
+	rowFields["_links"] = true
+	rowFields["click_count"] = true
+	respFields := jsonFields(reflect.TypeFor[LinkWithTagsResponse]())
+
+	if !reflect.DeepEqual(rowFields, respFields) {
+		t.Errorf("response fields = %v, want %v", respFields, rowFields)
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name := range jsonFields(field.Type) {
+				fields[name] = true
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreatePublishHook struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+	// Tag applied to every link the hook creates or reuses
+	TagID *uuid.UUID `json:"tag_id"`
+	// Where the short URL is posted once the link is ready
+	CallbackURL *string `json:"callback_url" validate:"omitempty,httpurl"`
+}
+
+// PublishHook is a CMS publish hook; Token is only set in the response that creates it
+type PublishHook struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	TagID       *uuid.UUID `json:"tag_id"`
+	CallbackURL *string    `json:"callback_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	Token       string     `json:"token,omitempty"`
+}
+
+// PublishEvent is sent by the CMS when an article is published
+type PublishEvent struct {
+	URL   string  `json:"url" validate:"required,httpurl"`
+	Title *string `json:"title" validate:"omitempty,max=255"`
+}
@@ -2,13 +2,17 @@ package dto
 
 import (
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
 )
 
 // SuccessResponse represents a successful API response
-// Pagination is optional - only included for paginated endpoints
+// Pagination, Cursor and Links are optional - only included for paginated endpoints
 type SuccessResponse[T any] struct {
 	Data       T               `json:"data"`
 	Pagination *PaginationMeta `json:"pagination,omitempty"`
+	Cursor     *CursorMeta     `json:"cursor,omitempty"`
+	// The same relations as the Link header
+	Links *PageLinks `json:"_links,omitempty"`
 }
 
 // PaginatedResponse is deprecated - use SuccessResponse with Pagination field instead
@@ -19,11 +23,16 @@ type PaginatedResponse[T any] struct {
 }
 
 // PaginationMeta contains pagination metadata
-type PaginationMeta struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"total_pages"`
+type PaginationMeta = pagination.Meta
+
+// PageLinks are the first, prev, next and last relations of a paginated response
+type PageLinks = pagination.Links
+
+// CursorMeta contains the position of a cursor-paginated response
+type CursorMeta struct {
+	// Pass as ?cursor= to continue after this response
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
 }
 
 // ErrorResponse represents an error API response
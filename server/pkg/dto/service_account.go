@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateServiceAccount creates a service account working on the links of OwnerID
+type CreateServiceAccount struct {
+	// The user whose links the account works on
+	OwnerID string   `json:"owner_id" validate:"required,max=255"`
+	Name    string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes  []string `json:"scopes" validate:"required,min=1,dive,oneof=links:read links:create links:write stats:read tags:read tags:write"`
+}
+
+// UpdateServiceAccount renames a service account or replaces its scopes
+type UpdateServiceAccount struct {
+	Name   *string  `json:"name" validate:"omitempty,min=1,max=100"`
+	Scopes []string `json:"scopes" validate:"omitempty,min=1,dive,oneof=links:read links:create links:write stats:read tags:read tags:write"`
+}
+
+// RotateServiceAccountToken may be empty: the previous token then keeps working for a day
+type RotateServiceAccountToken struct {
+	// Seconds the previous token keeps working next to the new one; 0 revokes it at once
+	PreviousTokenTTL *int `json:"previous_token_ttl" validate:"omitempty,min=0,max=604800"`
+}
+
+// ServiceAccount is a credential for CI systems and integrations; Token is only set in the response that creates it
+type ServiceAccount struct {
+	ID         uuid.UUID  `json:"id"`
+	OwnerID    string     `json:"owner_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// Only set in the responses that create the account or rotate its token
+	Token string `json:"token,omitempty"`
+}
+
+// ServiceAccountUsage is what's recorded about a service account's use, for audits and safe rotation
+type ServiceAccountUsage struct {
+	ID         uuid.UUID  `json:"id"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// Address of the client that last used the account
+	LastUsedIP *string `json:"last_used_ip"`
+	// Requests made with the account's tokens
+	UseCount       int64      `json:"use_count"`
+	TokenRotatedAt *time.Time `json:"token_rotated_at"`
+	// The token replaced by the last rotation while it still works, nil otherwise
+	PreviousToken *PreviousServiceAccountToken `json:"previous_token"`
+}
+
+// PreviousServiceAccountToken is a rotated-out token in its grace period
+type PreviousServiceAccountToken struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	// Nil while no client has used it since the rotation, when it's safe to revoke
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
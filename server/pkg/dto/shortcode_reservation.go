@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+// ReserveShortcode holds a shortcode for a link created later; a random one is reserved when omitted
+type ReserveShortcode struct {
+	Shortcode *string `json:"shortcode" validate:"omitempty,shortcode"`
+}
+
+// ShortcodeReservation is a shortcode held for the user that no link uses yet
+type ShortcodeReservation struct {
+	Shortcode string    `json:"shortcode"`
+	ShortURL  string    `json:"short_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// SlackMessage answers a slash command
+type SlackMessage struct {
+	// in_channel (everyone sees it) or ephemeral (only the caller does)
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// LinkSlackAccount redeems the link token sent to an unlinked Slack user
+type LinkSlackAccount struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SlackAccount is a Slack user linked to the account
+type SlackAccount struct {
+	TeamID      string    `json:"team_id"`
+	SlackUserID string    `json:"slack_user_id"`
+	LinkedAt    time.Time `json:"linked_at"`
+}
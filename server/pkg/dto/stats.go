@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailyClicks is a single point of a clicks-over-time series
+type DailyClicks struct {
+	Day    time.Time `json:"day"`
+	Clicks int64     `json:"clicks"`
+	// Of the clicks, scans of the links' QR codes
+	QRClicks int64 `json:"qr_clicks"`
+}
+
+// LinkClicks is a link with the number of clicks it received over a period
+type LinkClicks struct {
+	ID          uuid.UUID `json:"id"`
+	Shortcode   string    `json:"shortcode"`
+	OriginalURL string    `json:"original_url"`
+	Clicks      int64     `json:"clicks"`
+}
+
+// TopLinks are the user's links with the most clicks on a day
+type TopLinks struct {
+	// Midnight UTC of the day
+	Day   time.Time    `json:"day"`
+	Links []LinkClicks `json:"links"`
+}
+
+// BucketClicks is a single point of a link's clicks-over-time series
+type BucketClicks struct {
+	Start          time.Time `json:"start"`
+	Clicks         int64     `json:"clicks"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+}
+
+// ReferrerClicks is a referring host with the number of clicks it sent
+type ReferrerClicks struct {
+	// Host without a leading www.; empty for clicks without a referrer
+	Referrer string `json:"referrer"`
+	Clicks   int64  `json:"clicks"`
+}
+
+// LinkStats aggregates the clicks of a single link
+type LinkStats struct {
+	LinkID      uuid.UUID `json:"link_id"`
+	Shortcode   string    `json:"shortcode"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Granularity string    `json:"granularity"`
+	TotalClicks int64     `json:"total_clicks"`
+	// Counted per day: a visitor coming back on another day counts again
+	UniqueVisitors int64            `json:"unique_visitors"`
+	TopReferrers   []ReferrerClicks `json:"top_referrers"`
+	// Buckets without clicks are left out
+	Clicks []BucketClicks `json:"clicks"`
+}
+
+// TagStats aggregates clicks across all links carrying a tag
+type TagStats struct {
+	TagID       uuid.UUID `json:"tag_id"`
+	TagName     string    `json:"tag_name"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	TotalClicks int64     `json:"total_clicks"`
+	// Of the clicks, scans of the links' QR codes; the rest came from the web
+	QRClicks     int64 `json:"qr_clicks"`
+	LinksClicked int64 `json:"links_clicked"`
+	// Conversions posted back for the clicks, see POST /api/v1/conversions
+	Conversions  int64 `json:"conversions"`
+	RevenueCents int64 `json:"revenue_cents"`
+	// Conversions per click; 0 when there are no clicks
+	ConversionRate float64       `json:"conversion_rate"`
+	ClicksByDay    []DailyClicks `json:"clicks_by_day"`
+	TopLinks       []LinkClicks  `json:"top_links"`
+}
+
+// CampaignStats aggregates clicks across all links attached to a campaign
+type CampaignStats struct {
+	CampaignID   uuid.UUID `json:"campaign_id"`
+	CampaignName string    `json:"campaign_name"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	TotalClicks  int64     `json:"total_clicks"`
+	// Of the clicks, scans of the links' QR codes; the rest came from the web
+	QRClicks     int64 `json:"qr_clicks"`
+	LinksClicked int64 `json:"links_clicked"`
+	// Conversions posted back for the clicks, see POST /api/v1/conversions
+	Conversions  int64 `json:"conversions"`
+	RevenueCents int64 `json:"revenue_cents"`
+	// Conversions per click; 0 when there are no clicks
+	ConversionRate float64       `json:"conversion_rate"`
+	ClicksByDay    []DailyClicks `json:"clicks_by_day"`
+	TopLinks       []LinkClicks  `json:"top_links"`
+}
+
+// ExportJob is a clicks export running in the background
+type ExportJob struct {
+	ID          uuid.UUID `json:"id"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	DownloadURL string    `json:"download_url"`
+}
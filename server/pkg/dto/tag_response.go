@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// TagResponse is a tag as the API returns it. The tag queries all return the same
+// columns, so their rows convert to db.ListUserTagsRow (e.g. db.ListUserTagsRow(created)).
+type TagResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	// Live links carrying the tag, or for tags in the trash the links it was detached from; only in tag listings
+	LinkCount *int64 `json:"link_count,omitempty"`
+	// When the tag was moved to the trash; only for tags in it
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func NewTagResponse(row db.ListUserTagsRow) TagResponse {
+	return TagResponse{
+		ID:        row.ID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: timePtr(row.UpdatedAt),
+	}
+}
+
+// NewTagResponses maps a listing; it's never nil, so empty listings encode as []
+func NewTagResponses(rows []db.ListUserTagsRow) []TagResponse {
+	tags := make([]TagResponse, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, NewTagResponse(row))
+	}
+	return tags
+}
+
+// NewDeletedTagResponses maps the trash listing; it's never nil, so an empty trash encodes as []
+func NewDeletedTagResponses(rows []db.ListDeletedTagsRow) []TagResponse {
+	tags := make([]TagResponse, 0, len(rows))
+	for _, row := range rows {
+		tag := NewTagResponse(db.ListUserTagsRow{
+			ID:        row.ID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+		tag.LinkCount = &row.LinkCount
+		tag.DeletedAt = timePtr(row.DeletedAt)
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NewTagListResponses maps a listing with link counts; it's never nil, so empty listings encode as []
+func NewTagListResponses(rows []db.ListUserTagsPageRow) []TagResponse {
+	tags := make([]TagResponse, 0, len(rows))
+	for _, row := range rows {
+		tag := NewTagResponse(db.ListUserTagsRow{
+			ID:        row.ID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+		tag.LinkCount = &row.LinkCount
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func TestNewTagResponse(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	row := db.CreateTagRow{
+		ID:        uuid.New(),
+		Name:      "work",
+		CreatedAt: pgtype.Timestamptz{Time: created, Valid: true},
+	}
+
+	tag := NewTagResponse(db.ListUserTagsRow(row))
+	if tag.ID != row.ID || tag.Name != "work" || !tag.CreatedAt.Equal(created) || tag.UpdatedAt != nil {
+		t.Errorf("NewTagResponse() = %+v, want the row's values", tag)
+	}
+}
+
+func TestNewTagResponses_Empty(t *testing.T) {
+	encoded, err := json.Marshal(NewTagResponses(nil))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(encoded) != "[]" {
+		t.Errorf("encoded empty listing = %s, want []", encoded)
+	}
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// VerifySender marks a user, or a destination domain, as a verified sender
+type VerifySender struct {
+	Kind string `json:"kind" validate:"required,oneof=user domain"`
+	// A user ID, or a hostname whose subdomains are verified with it
+	Value string `json:"value" validate:"required,max=255"`
+	// Shown next to the badge, e.g. the organization's name
+	Name *string `json:"name" validate:"omitempty,min=1,max=100"`
+}
+
+// VerifiedSender is a user or destination domain whose links show a "verified sender" badge
+type VerifiedSender struct {
+	Kind       string    `json:"kind"`
+	Value      string    `json:"value"`
+	Name       *string   `json:"name"`
+	VerifiedBy string    `json:"verified_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PublicLinkPreview is what anyone can see of a short link before following it
+type PublicLinkPreview struct {
+	Shortcode string `json:"shortcode"`
+	ShortURL  string `json:"short_url"`
+	// Null for email-gated links, which only reveal it once the form is submitted
+	Destination *string `json:"destination"`
+	Verified    bool    `json:"verified"`
+	// The verified sender's name, when it has one
+	VerifiedSender *string `json:"verified_sender"`
+}
@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateWebhook struct {
+	// Where events are posted
+	URL string `json:"url" validate:"required,httpurl"`
+}
+
+// RotateWebhookSecret may be empty: the previous secret then keeps signing deliveries for a day
+type RotateWebhookSecret struct {
+	// Seconds the previous secret keeps signing deliveries next to the new one; 0 drops it at once
+	PreviousSecretTTL *int `json:"previous_secret_ttl" validate:"omitempty,min=0,max=604800"`
+}
+
+// Webhook is a webhook endpoint; Secret is only set in the responses that create or rotate it
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	// When the secret was last rotated, null if never
+	SecretRotatedAt *time.Time `json:"secret_rotated_at"`
+	// Until when the previous secret still signs deliveries, null when only one secret is in use
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at"`
+	// Start of the current run of failed deliveries, null while deliveries succeed
+	FailingSince *time.Time `json:"failing_since"`
+	// When the webhook was disabled for failing too long, null while enabled
+	DisabledAt *time.Time `json:"disabled_at"`
+	Secret     string     `json:"secret,omitempty"`
+}
+
+// WebhookDelivery is an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID        uuid.UUID `json:"id"`
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	// The body as sent, or to be sent
+	Payload json.RawMessage `json:"payload"`
+	// Not sent yet: the status, duration and error are filled in once it is
+	Pending   bool `json:"pending"`
+	Succeeded bool `json:"succeeded"`
+	// The endpoint's response status, null when it couldn't be reached
+	StatusCode *int32  `json:"status_code"`
+	DurationMs int32   `json:"duration_ms"`
+	Error      *string `json:"error"`
+	// The delivery this one retried
+	RetryOf *uuid.UUID `json:"retry_of"`
+	// Numbers the deliveries of the event to the webhook, from 1
+	Attempt int32 `json:"attempt"`
+	// When the failed delivery is retried automatically, or the pending one sent by the retry job,
+	// null when it isn't
+	NextRetryAt *time.Time `json:"next_retry_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
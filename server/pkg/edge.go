@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/config"
+	"github.com/styltsou/url-shortener/server/pkg/handlers"
+	"github.com/styltsou/url-shortener/server/pkg/httpclient"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+	"github.com/styltsou/url-shortener/server/pkg/router"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+/*
+newEdge sets the server up as an edge instance: a redirect-only node for another
+region, without storage or the management API. Redirects of links in the
+primary's redirect cache are served from a local copy of it (see
+service.EdgeLinks), with their clicks sent on to the primary; everything else,
+misses included, is forwarded to the primary.
+
+Unlike the primary, an edge doesn't start without Redis: it's where the links
+it serves come from.
+*/
+func (s *Server) newEdge(config *config.Config) error {
+	primaryURL, err := url.Parse(config.EdgePrimaryURL)
+	if err != nil {
+		return fmt.Errorf("invalid EDGE_PRIMARY_URL: %w", err)
+	}
+	trustedProxies, err := netutil.ParsePrefixes(config.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	if err := s.connectRedis(config, config.RedisURL); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	local := cache.NewMemory()
+	s.Cache = local
+	edgeLinks := service.NewEdgeLinks(s.RedisClient, local, time.Duration(config.EdgeCacheTTL)*time.Second, s.Logger)
+	if config.EdgeSnapshotFile != "" {
+		// The redirect cache fills in the rest, so the edge starts without it
+		if err := s.loadEdgeSnapshot(edgeLinks, config.EdgeSnapshotFile); err != nil {
+			s.Logger.Warn("Failed to load EDGE_SNAPSHOT_FILE",
+				zap.Error(err),
+				zap.String("path", config.EdgeSnapshotFile),
+			)
+		}
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(s.Context)
+	s.stopJobs = stopJobs
+	edgeLinks.Start(jobsCtx, time.Duration(config.EdgeSnapshotInterval)*time.Second)
+
+	// The primary's internal port is the operator's, so it's on a private network
+	s.edgeClicks = service.NewEdgeClicks(strings.TrimRight(config.EdgePrimaryInternalURL, "/"), config.EdgeToken, httpclient.New(httpclient.Options{
+		Name:         "edge_clicks",
+		AllowPrivate: true,
+	}), s.Logger)
+
+	edgeHandler := handlers.NewEdgeHandler(edgeLinks, s.edgeClicks, primaryURL, s.Logger)
+	s.Router.Use(chimw.RequestID)
+	s.Router.Use(reqctx.Middleware(trustedProxies))
+	s.Router.Use(middleware.RequestLogger(s.Logger))
+	s.Router.Use(chimw.Recoverer)
+	s.Router.Mount("/", router.NewEdge(edgeHandler, s.Logger))
+
+	checks := map[string]router.HealthCheck{
+		"redis": func(ctx context.Context) error {
+			return s.RedisClient.Ping(ctx).Err()
+		},
+	}
+	s.InternalRouter = chi.NewRouter()
+	s.InternalRouter.Use(chimw.Recoverer)
+	s.Drain = &router.Drain{}
+	s.InternalRouter.Mount("/", router.NewInternal(checks, config.Dump(), s.Drain, s.Logger))
+
+	s.Logger.Info("Running as an edge instance",
+		zap.String("primary_url", config.EdgePrimaryURL),
+	)
+	return nil
+}
+
+func (s *Server) loadEdgeSnapshot(edgeLinks *service.EdgeLinks, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = edgeLinks.Load(s.Context, f)
+	return err
+}
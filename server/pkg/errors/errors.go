@@ -9,8 +9,11 @@ type ErrorCode string
 const (
 	CodeInvalidRequest ErrorCode = "invalid_request"
 
-	CodeAuthRequired ErrorCode = "authentication_required"
-	CodeAuthFailed   ErrorCode = "authentication_failed"
+	CodeAuthRequired  ErrorCode = "authentication_required"
+	CodeAuthFailed    ErrorCode = "authentication_failed"
+	CodeAdminRequired ErrorCode = "admin_required"
+	// A service account called a route its scopes don't cover
+	CodeInsufficientScope ErrorCode = "insufficient_scope"
 
 	CodeInvalidID ErrorCode = "invalid id"
 
@@ -18,8 +21,81 @@ const (
 	CodeInvalidURL   ErrorCode = "invalid_url"
 	CodeLinkExpired  ErrorCode = "link_expired"
 	CodeCodeTaken    ErrorCode = "code_taken"
+	CodeCodeReserved ErrorCode = "code_reserved"
 	CodeTagNotFound  ErrorCode = "tag_not_found"
 	CodeTagNameTaken ErrorCode = "tag_name_taken"
+	CodeLinkRetired  ErrorCode = "link_retired"
+
+	// The user has as many tags as TAG_QUOTA allows
+	CodeTagQuotaExceeded ErrorCode = "tag_quota_exceeded"
+	CodeInvalidTagName   ErrorCode = "invalid_tag_name"
+
+	CodeLinkPreviewNotFound ErrorCode = "link_preview_not_found"
+
+	CodeTrafficCapNotFound ErrorCode = "traffic_cap_not_found"
+
+	CodeResponseHeadersNotFound ErrorCode = "response_headers_not_found"
+	CodeInvalidResponseHeader   ErrorCode = "invalid_response_header"
+
+	CodeWaitingRoomNotFound     ErrorCode = "waiting_room_not_found"
+	CodeInvalidWaitingRoomToken ErrorCode = "invalid_waiting_room_token"
+
+	CodeDynamicLinkNotFound       ErrorCode = "dynamic_link_not_found"
+	CodeLinkNotDynamic            ErrorCode = "link_not_dynamic"
+	CodeLinkDestinationLocked     ErrorCode = "link_destination_locked"
+	CodeDestinationChangeNotFound ErrorCode = "destination_change_not_found"
+
+	CodeLinksNotMergeable ErrorCode = "links_not_mergeable"
+
+	CodePublicStatsNotEnabled ErrorCode = "public_stats_not_enabled"
+
+	CodeReservationNotFound ErrorCode = "reservation_not_found"
+
+	CodeCommentNotFound ErrorCode = "comment_not_found"
+
+	CodeVerifiedSenderNotFound ErrorCode = "verified_sender_not_found"
+	CodeInvalidDomain          ErrorCode = "invalid_domain"
+
+	CodeAccessTokensDisabled ErrorCode = "access_tokens_disabled"
+	CodeLeaderboardDisabled  ErrorCode = "leaderboard_disabled"
+
+	CodeCampaignNotFound      ErrorCode = "campaign_not_found"
+	CodeCampaignNameTaken     ErrorCode = "campaign_name_taken"
+	CodeInvalidCampaignPeriod ErrorCode = "invalid_campaign_period"
+
+	CodeClickNotFound             ErrorCode = "click_not_found"
+	CodeConversionAlreadyRecorded ErrorCode = "conversion_already_recorded"
+
+	CodeExportNotFound ErrorCode = "export_not_found"
+
+	CodeInvalidCursor ErrorCode = "invalid_cursor"
+
+	CodeInvalidSignature      ErrorCode = "invalid_signature"
+	CodeInvalidSlackLinkToken ErrorCode = "invalid_slack_link_token"
+
+	CodePublishHookNotFound     ErrorCode = "publish_hook_not_found"
+	CodeInvalidPublishHookToken ErrorCode = "invalid_publish_hook_token"
+
+	CodeInvalidEdgeToken ErrorCode = "invalid_edge_token"
+
+	CodeServiceAccountNotFound ErrorCode = "service_account_not_found"
+
+	CodeAPIKeyNotFound ErrorCode = "api_key_not_found"
+
+	CodeWebhookNotFound         ErrorCode = "webhook_not_found"
+	CodeWebhookDeliveryNotFound ErrorCode = "webhook_delivery_not_found"
+
+	CodeTooManyLinks ErrorCode = "too_many_links"
+
+	CodeRateLimited       ErrorCode = "rate_limited"
+	CodeServerBusy        ErrorCode = "server_busy"
+	CodeLinkQuotaExceeded ErrorCode = "link_quota_exceeded"
+	CodeTimeout           ErrorCode = "timeout"
+
+	CodePolicyDestinationNotAllowed ErrorCode = "policy_destination_not_allowed"
+	CodePolicyShortcodeForbidden    ErrorCode = "policy_shortcode_forbidden"
+	CodePolicyRequiredTagsMissing   ErrorCode = "policy_required_tags_missing"
+	CodePolicyExpiryTooLate         ErrorCode = "policy_expiry_too_late"
 
 	CodeNotFound         ErrorCode = "not_found"
 	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
@@ -31,13 +107,101 @@ const (
 var (
 	AuthRequired = errors.New("Authentication required")
 	AuthFailed   = errors.New("Authentication failed")
+	// The route is limited to the users listed in ADMIN_USER_IDS
+	AdminRequired = errors.New("Admin access required")
+	// The service account's scopes don't cover the route
+	InsufficientScope = errors.New("Insufficient scope")
 
 	LinkNotFound       = errors.New("Link not found")
 	InvalidURL         = errors.New("Invalid URL")
 	LinkExpired        = errors.New("Link expired")
 	LinkShortcodeTaken = errors.New("Shortcode already taken")
+	ShortcodeReserved  = errors.New("Shortcode is reserved")
 	TagNotFound        = errors.New("Tag not found")
 	TagNameTaken       = errors.New("Tag name already taken")
+	LinkRetired        = errors.New("Link is retired")
+
+	TagQuotaExceeded = errors.New("Tag quota exceeded")
+	// Nothing is left of the name once normalized, see service.TagPolicy
+	InvalidTagName = errors.New("Invalid tag name")
+
+	LinkPreviewNotFound = errors.New("Link preview not found")
+
+	TrafficCapNotFound = errors.New("Traffic cap not found")
+
+	ResponseHeadersNotFound = errors.New("Response headers not found")
+	// The header isn't on the allowlist, or its value isn't valid
+	InvalidResponseHeader = errors.New("Invalid response header")
+
+	WaitingRoomNotFound     = errors.New("Waiting room not found")
+	InvalidWaitingRoomToken = errors.New("Invalid waiting room token")
+
+	DynamicLinkNotFound = errors.New("Dynamic link not found")
+	// Only dynamic links can change destination
+	LinkNotDynamic = errors.New("Link is not dynamic")
+	// The dynamic link is locked against destination changes
+	LinkDestinationLocked     = errors.New("Link destination is locked")
+	DestinationChangeNotFound = errors.New("Destination change not found")
+
+	// The links have different destinations, or one of them is already merged
+	LinksNotMergeable = errors.New("Links can't be merged")
+
+	// The link's stats page isn't public; it can still be shared with a signed token
+	PublicStatsNotEnabled = errors.New("Public stats not enabled")
+
+	ReservationNotFound = errors.New("Shortcode reservation not found")
+	// The shortcode is reserved but no link has been created with it yet
+	LinkPending = errors.New("Link has no destination yet")
+
+	CommentNotFound = errors.New("Comment not found")
+
+	VerifiedSenderNotFound = errors.New("Verified sender not found")
+	InvalidDomain          = errors.New("Invalid domain")
+
+	AccessTokensDisabled = errors.New("Link access tokens are not configured")
+	LeaderboardDisabled  = errors.New("Top links leaderboard is not configured")
+	InvalidEmail         = errors.New("Invalid email address")
+
+	CampaignNotFound      = errors.New("Campaign not found")
+	CampaignNameTaken     = errors.New("Campaign name already taken")
+	InvalidCampaignPeriod = errors.New("Campaign must start before it ends")
+
+	ClickNotFound             = errors.New("Click not found")
+	ConversionAlreadyRecorded = errors.New("Conversion already recorded")
+
+	ExportNotFound = errors.New("Export not found")
+
+	InvalidCursor = errors.New("Invalid cursor")
+
+	InvalidSignature      = errors.New("Invalid request signature")
+	SlackAccountNotLinked = errors.New("Slack account not linked")
+	InvalidSlackLinkToken = errors.New("Invalid or expired Slack link token")
+
+	PublishHookNotFound     = errors.New("Publish hook not found")
+	InvalidPublishHookToken = errors.New("Invalid publish hook token")
+
+	InvalidEdgeToken = errors.New("Invalid edge token")
+
+	ServiceAccountNotFound     = errors.New("Service account not found")
+	InvalidServiceAccountToken = errors.New("Invalid service account token")
+
+	APIKeyNotFound = errors.New("API key not found")
+	InvalidAPIKey  = errors.New("Invalid API key")
+
+	WebhookNotFound         = errors.New("Webhook not found")
+	WebhookDeliveryNotFound = errors.New("Webhook delivery not found")
+
+	TooManyLinks = errors.New("Too many links")
+
+	RateLimited       = errors.New("Too many requests")
+	ServerBusy        = errors.New("Server busy")
+	LinkQuotaExceeded = errors.New("Link quota exceeded")
+
+	// Link policy violations, see service.LinkPolicy
+	PolicyDestinationNotAllowed = errors.New("Destination not allowed by link policy")
+	PolicyShortcodeForbidden    = errors.New("Shortcode forbidden by link policy")
+	PolicyRequiredTagsMissing   = errors.New("Tags required by link policy missing")
+	PolicyExpiryTooLate         = errors.New("Expiry later than link policy allows")
 
 	InternalError = errors.New("Internal server error")
 )
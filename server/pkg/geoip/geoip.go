@@ -0,0 +1,119 @@
+/*
+Package geoip tells which country an IP address is in, from a CSV file of address
+ranges such as the free DB-IP "IP to Country Lite" database. Each line holds the
+first and last address of a range and its ISO 3166 country code:
+
+	1.0.0.0,1.0.0.255,AU
+	2001:200::,2001:200:ffff:ffff:ffff:ffff:ffff:ffff,JP
+
+Ranges are kept in memory, sorted, and looked up with a binary search.
+*/
+package geoip
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+type countryRange struct {
+	first   netip.Addr
+	last    netip.Addr
+	country string
+}
+
+// Countries maps addresses to countries; a nil *Countries knows none
+type Countries struct {
+	ranges []countryRange
+}
+
+// Open loads the CSV file at path
+func Open(path string) (*Countries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open country database: %w", err)
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Load reads ranges from r, failing on lines that aren't a valid range. Extra columns are ignored.
+func Load(r io.Reader) (*Countries, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var ranges []countryRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read country database: %w", err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("country database line %d: want first address, last address and country", line)
+		}
+
+		first, err1 := netip.ParseAddr(strings.TrimSpace(record[0]))
+		last, err2 := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, fmt.Errorf("country database line %d: %w", line, err)
+		}
+		first, last = first.Unmap(), last.Unmap()
+		if first.BitLen() != last.BitLen() || last.Less(first) {
+			return nil, fmt.Errorf("country database line %d: %s-%s is not a range", line, first, last)
+		}
+
+		// ZZ and the like mark unassigned ranges
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if len(country) != 2 || country == "ZZ" {
+			continue
+		}
+		ranges = append(ranges, countryRange{first: first, last: last, country: country})
+	}
+
+	slices.SortFunc(ranges, func(a, b countryRange) int { return a.first.Compare(b.first) })
+	return &Countries{ranges: ranges}, nil
+}
+
+// Country returns the ISO 3166 code of the address's country, empty when it isn't known
+func (c *Countries) Country(addr netip.Addr) string {
+	if c == nil || !addr.IsValid() {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// The last range starting at or before addr is the only one that can hold it
+	i, found := slices.BinarySearchFunc(c.ranges, addr, func(r countryRange, addr netip.Addr) int {
+		return r.first.Compare(addr)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return ""
+	}
+
+	r := c.ranges[i]
+	if r.last.BitLen() != addr.BitLen() || r.last.Less(addr) {
+		return ""
+	}
+	return r.country
+}
+
+// Len returns the number of ranges loaded
+func (c *Countries) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.ranges)
+}
@@ -0,0 +1,62 @@
+package geoip
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testDatabase = `1.0.0.0,1.0.0.255,AU
+1.0.4.0,1.0.7.255,au
+2.0.0.0,2.0.0.255,ZZ
+"2001:200::","2001:200:ffff:ffff:ffff:ffff:ffff:ffff","JP","extra"
+`
+
+func TestCountries_Country(t *testing.T) {
+	countries, err := Load(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if countries.Len() != 3 {
+		t.Errorf("Len() = %d, want 3 (unassigned ranges skipped)", countries.Len())
+	}
+
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "1.0.0.0", want: "AU"},
+		{addr: "1.0.0.255", want: "AU"},
+		{addr: "1.0.1.0", want: ""},
+		{addr: "1.0.5.9", want: "AU"},
+		{addr: "::ffff:1.0.0.7", want: "AU"},
+		{addr: "0.0.0.1", want: ""},
+		{addr: "2.0.0.1", want: ""},
+		{addr: "2001:200:1::1", want: "JP"},
+		{addr: "2001:201::1", want: ""},
+		{addr: "::1", want: ""},
+	}
+	for _, tt := range tests {
+		if got := countries.Country(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Country(%s) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	var none *Countries
+	if got := none.Country(netip.MustParseAddr("1.0.0.1")); got != "" {
+		t.Errorf("nil Countries.Country() = %q, want empty", got)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, db := range []string{
+		"1.0.0.0,AU\n",
+		"1.0.0.x,1.0.0.255,AU\n",
+		"1.0.0.255,1.0.0.0,AU\n",
+		"1.0.0.0,2001:200::,AU\n",
+	} {
+		if _, err := Load(strings.NewReader(db)); err == nil {
+			t.Errorf("Load(%q) error = nil, want an error", db)
+		}
+	}
+}
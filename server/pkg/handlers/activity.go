@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// ActivityService defines the service methods needed by ActivityHandler
+type ActivityService interface {
+	ListActivity(ctx context.Context, userID string, page, limit int) (*service.ListActivityResult, error)
+	VerifyChain(ctx context.Context, userID string, anchor *service.ActivityChainAnchor) (*service.ActivityChainVerification, error)
+}
+
+type ActivityHandler struct {
+	ActivityService ActivityService
+	logger          logger.Logger
+}
+
+func NewActivityHandler(activityService ActivityService, logger logger.Logger) *ActivityHandler {
+	return &ActivityHandler{
+		ActivityService: activityService,
+		logger:          logger,
+	}
+}
+
+// ListActivity: GET /api/v1/activity?page=1&limit=20
+// The user's changes to links and tags, newest first, each with a readable summary.
+func (h *ActivityHandler) ListActivity(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.ActivityService.ListActivity(r.Context(), userID, page, limit)
+	if err != nil {
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+		return
+	}
+
+	// Ensure we always return an empty array (not null) when there is no activity
+	if result.Events == nil {
+		result.Events = []db.ActivityEvent{}
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ActivityEvent]{
+		Data:       result.Events,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
+
+// VerifyActivity: GET /api/v1/activity/verify?anchor_seq=42&anchor_hash=...
+// Recomputes the hash chain of the user's activity log. The anchor, a head_seq and head_hash
+// returned earlier and saved outside the service, also catches a chain rewritten since.
+func (h *ActivityHandler) VerifyActivity(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	anchor, ok := parseActivityAnchor(r)
+	if !ok {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid anchor",
+				Detail: "anchor_seq (a positive integer) and anchor_hash (64 hex characters) must be given together",
+			},
+		})
+		return
+	}
+
+	result, err := h.ActivityService.VerifyChain(r.Context(), userID, anchor)
+	if err != nil {
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+		return
+	}
+
+	response := dto.ActivityChainVerification{
+		Valid:    result.Valid,
+		Events:   result.Events,
+		HeadSeq:  result.HeadSeq,
+		HeadHash: result.HeadHash,
+	}
+	if !result.Valid {
+		response.BrokenAtSeq = &result.BrokenAtSeq
+		response.Reason = &result.Reason
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ActivityChainVerification]{
+		Data: response,
+	})
+}
+
+// parseActivityAnchor reads the optional anchor; ok is false when it's malformed or half given
+func parseActivityAnchor(r *http.Request) (anchor *service.ActivityChainAnchor, ok bool) {
+	seqStr := r.URL.Query().Get("anchor_seq")
+	hash := r.URL.Query().Get("anchor_hash")
+	if seqStr == "" && hash == "" {
+		return nil, true
+	}
+
+	seq, err := strconv.ParseInt(seqStr, 10, 64)
+	if err != nil || seq < 1 {
+		return nil, false
+	}
+	if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != 32 {
+		return nil, false
+	}
+
+	return &service.ActivityChainAnchor{Seq: seq, Hash: strings.ToLower(hash)}, true
+}
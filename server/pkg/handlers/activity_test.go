@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type mockActivityService struct {
+	err    error
+	broken bool
+	anchor *service.ActivityChainAnchor
+}
+
+func (m *mockActivityService) ListActivity(ctx context.Context, userID string, page, limit int) (*service.ListActivityResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.ListActivityResult{
+		Events: []db.ActivityEvent{
+			{ID: uuid.New(), UserID: userID, Action: service.ActivityLinkCreated, Summary: "Created link spring to https://example.com"},
+		},
+		Meta: pagination.Meta{Page: page, Limit: limit, Total: 1, TotalPages: 1},
+	}, nil
+}
+
+func (m *mockActivityService) VerifyChain(ctx context.Context, userID string, anchor *service.ActivityChainAnchor) (*service.ActivityChainVerification, error) {
+	m.anchor = anchor
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.broken {
+		return &service.ActivityChainVerification{Events: 2, HeadSeq: 2, BrokenAtSeq: 3, Reason: service.ActivityChainHashMismatch}, nil
+	}
+	return &service.ActivityChainVerification{Valid: true, Events: 3, HeadSeq: 3}, nil
+}
+
+func TestActivityHandler_ListActivity(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "returns the feed", expectedStatus: http.StatusOK},
+		{name: "service error", err: errors.New("database is down"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewActivityHandler(&mockActivityService{err: tt.err}, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/activity?limit=10", nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.ListActivity(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var resp dto.SuccessResponse[[]db.ActivityEvent]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Data) != 1 || resp.Pagination == nil || resp.Pagination.Limit != 10 {
+				t.Errorf("response = %+v, want one event and the requested limit", resp)
+			}
+		})
+	}
+}
+
+func TestActivityHandler_VerifyActivity(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name           string
+		query          string
+		broken         bool
+		err            error
+		expectedStatus int
+		wantAnchor     *service.ActivityChainAnchor
+		wantReason     string
+	}{
+		{name: "valid chain", expectedStatus: http.StatusOK},
+		{name: "with anchor", query: "?anchor_seq=2&anchor_hash=" + strings.ToUpper(hash), expectedStatus: http.StatusOK, wantAnchor: &service.ActivityChainAnchor{Seq: 2, Hash: hash}},
+		{name: "broken chain", broken: true, expectedStatus: http.StatusOK, wantReason: service.ActivityChainHashMismatch},
+		{name: "anchor without hash", query: "?anchor_seq=2", expectedStatus: http.StatusBadRequest},
+		{name: "anchor hash too short", query: "?anchor_seq=2&anchor_hash=abcd", expectedStatus: http.StatusBadRequest},
+		{name: "anchor seq not positive", query: "?anchor_seq=0&anchor_hash=" + hash, expectedStatus: http.StatusBadRequest},
+		{name: "service error", err: errors.New("database is down"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockActivityService{err: tt.err, broken: tt.broken}
+			handler := NewActivityHandler(svc, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/activity/verify"+tt.query, nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.VerifyActivity(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			if (svc.anchor == nil) != (tt.wantAnchor == nil) || (svc.anchor != nil && *svc.anchor != *tt.wantAnchor) {
+				t.Errorf("anchor = %+v, want %+v", svc.anchor, tt.wantAnchor)
+			}
+
+			var resp dto.SuccessResponse[dto.ActivityChainVerification]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.Valid == tt.broken {
+				t.Errorf("valid = %v, want %v", resp.Data.Valid, !tt.broken)
+			}
+			if tt.wantReason == "" && (resp.Data.Reason != nil || resp.Data.BrokenAtSeq != nil) {
+				t.Errorf("reason = %v, want null for a valid chain", resp.Data.Reason)
+			}
+			if tt.wantReason != "" && (resp.Data.Reason == nil || *resp.Data.Reason != tt.wantReason) {
+				t.Errorf("reason = %v, want %q", resp.Data.Reason, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// AnomalyService defines the service methods needed by AnomalyHandler
+type AnomalyService interface {
+	ListLinkAnomalies(ctx context.Context, userID string, linkID uuid.UUID) ([]db.LinkAnomaly, error)
+}
+
+type AnomalyHandler struct {
+	AnomalyService AnomalyService
+	logger         logger.Logger
+}
+
+func NewAnomalyHandler(anomalyService AnomalyService, logger logger.Logger) *AnomalyHandler {
+	return &AnomalyHandler{
+		AnomalyService: anomalyService,
+		logger:         logger,
+	}
+}
+
+// ListLinkAnomalies: GET /api/v1/links/{id}/anomalies
+// The latest click spikes and drops detected on the link, newest first.
+func (h *AnomalyHandler) ListLinkAnomalies(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	anomalies, err := h.AnomalyService.ListLinkAnomalies(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Ensure we always return an empty array (not null) when nothing was detected
+	if anomalies == nil {
+		anomalies = []db.LinkAnomaly{}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.LinkAnomaly]{
+		Data: anomalies,
+	})
+}
+
+func (h *AnomalyHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.LinkNotFound):
+		h.logger.Warn("Link not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkNotFound,
+				Title:  apperrors.LinkNotFound.Error(),
+				Detail: "Unable to find link with the provided ID",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
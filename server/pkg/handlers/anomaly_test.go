@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+)
+
+type mockAnomalyService struct {
+	err error
+}
+
+func (m *mockAnomalyService) ListLinkAnomalies(ctx context.Context, userID string, linkID uuid.UUID) ([]db.LinkAnomaly, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []db.LinkAnomaly{{ID: uuid.New(), LinkID: linkID, Kind: "spike", Clicks: 540, Baseline: 12, ZScore: 42}}, nil
+}
+
+func TestAnomalyHandler_ListLinkAnomalies(t *testing.T) {
+	tests := []struct {
+		name           string
+		linkID         string
+		err            error
+		expectedStatus int
+	}{
+		{name: "lists anomalies", linkID: uuid.NewString(), expectedStatus: http.StatusOK},
+		{name: "invalid id", linkID: "nope", expectedStatus: http.StatusBadRequest},
+		{name: "other user's link", linkID: uuid.NewString(), err: fmt.Errorf("%w: no rows", apperrors.LinkNotFound), expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAnomalyHandler(&mockAnomalyService{err: tt.err}, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/links/"+tt.linkID+"/anomalies", nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/links/{id}/anomalies", handler.ListLinkAnomalies)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
+}
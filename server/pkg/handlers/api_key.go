@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// APIKeyService defines the service methods needed by APIKeyHandler
+type APIKeyService interface {
+	Create(ctx context.Context, userID string, name string, scopes []string) (db.ApiKey, string, error)
+	List(ctx context.Context, userID string) ([]db.ApiKey, error)
+	Get(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error)
+	Rotate(ctx context.Context, userID string, id uuid.UUID, grace time.Duration) (db.ApiKey, string, error)
+	RevokePrevious(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error)
+	Revoke(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error)
+}
+
+// APIKeyHandler serves the routes users manage their API keys with
+type APIKeyHandler struct {
+	APIKeyService APIKeyService
+	logger        logger.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService APIKeyService, logger logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		APIKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// ListAPIKeys: GET /api/v1/api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	keys, err := h.APIKeyService.List(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := make([]dto.APIKey, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, apiKeyResponse(key))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.APIKey]{
+		Data: data,
+	})
+}
+
+/*
+CreateAPIKey: POST /api/v1/api-keys
+
+Creates an API key working on the user's links within its scopes, by default
+those of the links routes. The key is only returned here; it's sent in the
+X-API-Key header, in place of a session token.
+*/
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateAPIKey](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	apiKey, key, err := h.APIKeyService.Create(r.Context(), userID, reqBody.Name, reqBody.Scopes)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := apiKeyResponse(apiKey)
+	data.Key = key
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.APIKey]{
+		Data: data,
+	})
+}
+
+/*
+RotateAPIKey: POST /api/v1/api-keys/{id}/rotate
+
+Returns the key's new key. The previous one keeps working for
+previous_key_ttl seconds (a day by default) so clients can switch over;
+GET .../usage shows whether they still use it.
+*/
+func (h *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.RotateAPIKey](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	keyID, ok := h.parseAPIKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	grace := service.DefaultAPIKeyGrace
+	if reqBody.PreviousKeyTTL != nil {
+		grace = time.Duration(*reqBody.PreviousKeyTTL) * time.Second
+	}
+
+	apiKey, key, err := h.APIKeyService.Rotate(r.Context(), userID, keyID, grace)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := apiKeyResponse(apiKey)
+	data.Key = key
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.APIKey]{
+		Data: data,
+	})
+}
+
+// RevokePreviousAPIKey: DELETE /api/v1/api-keys/{id}/previous-key
+func (h *APIKeyHandler) RevokePreviousAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	keyID, ok := h.parseAPIKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	apiKey, err := h.APIKeyService.RevokePrevious(r.Context(), userID, keyID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.APIKeyUsage]{
+		Data: apiKeyUsageResponse(apiKey, time.Now()),
+	})
+}
+
+// GetUsage: GET /api/v1/api-keys/{id}/usage
+func (h *APIKeyHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	keyID, ok := h.parseAPIKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	apiKey, err := h.APIKeyService.Get(r.Context(), userID, keyID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.APIKeyUsage]{
+		Data: apiKeyUsageResponse(apiKey, time.Now()),
+	})
+}
+
+// RevokeAPIKey: DELETE /api/v1/api-keys/{id}
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	keyID, ok := h.parseAPIKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	apiKey, err := h.APIKeyService.Revoke(r.Context(), userID, keyID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.APIKey]{
+		Data: apiKeyResponse(apiKey),
+	})
+}
+
+// parseAPIKeyID reads the {id} URL parameter, answering with a 400 when it isn't a UUID
+func (h *APIKeyHandler) parseAPIKeyID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return uuid.UUID{}, false
+	}
+
+	return id, true
+}
+
+func apiKeyResponse(key db.ApiKey) dto.APIKey {
+	resp := dto.APIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt.Time,
+	}
+	if key.LastUsedAt.Valid {
+		resp.LastUsedAt = &key.LastUsedAt.Time
+	}
+	if key.RevokedAt.Valid {
+		resp.RevokedAt = &key.RevokedAt.Time
+	}
+	return resp
+}
+
+// apiKeyUsageResponse reports the key's use; a previous key whose grace period ended at now is left out
+func apiKeyUsageResponse(key db.ApiKey, now time.Time) dto.APIKeyUsage {
+	resp := dto.APIKeyUsage{
+		ID:         key.ID,
+		LastUsedIP: key.LastUsedIp,
+		UseCount:   key.UseCount,
+	}
+	if key.LastUsedAt.Valid {
+		resp.LastUsedAt = &key.LastUsedAt.Time
+	}
+	if key.KeyRotatedAt.Valid {
+		resp.KeyRotatedAt = &key.KeyRotatedAt.Time
+	}
+	if key.PreviousKeyHash != nil && key.PreviousKeyExpiresAt.Valid && key.PreviousKeyExpiresAt.Time.After(now) {
+		resp.PreviousKey = &dto.PreviousAPIKey{ExpiresAt: key.PreviousKeyExpiresAt.Time}
+		if key.PreviousKeyLastUsedAt.Valid {
+			resp.PreviousKey.LastUsedAt = &key.PreviousKeyLastUsedAt.Time
+		}
+	}
+	return resp
+}
+
+func (h *APIKeyHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.APIKeyNotFound):
+		h.logger.Warn("API key not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeAPIKeyNotFound,
+				Title:  apperrors.APIKeyNotFound.Error(),
+				Detail: "Unable to find an unrevoked API key with the provided ID",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
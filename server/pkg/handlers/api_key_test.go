@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+)
+
+type mockAPIKeyService struct {
+	id uuid.UUID
+}
+
+func (m *mockAPIKeyService) Create(ctx context.Context, userID string, name string, scopes []string) (db.ApiKey, string, error) {
+	return db.ApiKey{ID: m.id, Name: name, Scopes: scopes}, "ak_secret", nil
+}
+
+func (m *mockAPIKeyService) List(ctx context.Context, userID string) ([]db.ApiKey, error) {
+	return []db.ApiKey{{ID: m.id, Name: "Deploy script"}}, nil
+}
+
+func (m *mockAPIKeyService) Get(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error) {
+	if id != m.id {
+		return db.ApiKey{}, apperrors.APIKeyNotFound
+	}
+	ip := "203.0.113.7"
+	previous := "hash"
+	now := time.Now()
+	return db.ApiKey{
+		ID:                    id,
+		LastUsedAt:            pgtype.Timestamptz{Time: now, Valid: true},
+		LastUsedIp:            &ip,
+		UseCount:              3,
+		PreviousKeyHash:       &previous,
+		PreviousKeyExpiresAt:  pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true},
+		PreviousKeyLastUsedAt: pgtype.Timestamptz{Time: now, Valid: true},
+	}, nil
+}
+
+func (m *mockAPIKeyService) Rotate(ctx context.Context, userID string, id uuid.UUID, grace time.Duration) (db.ApiKey, string, error) {
+	if id != m.id {
+		return db.ApiKey{}, "", apperrors.APIKeyNotFound
+	}
+	return db.ApiKey{ID: id, Name: "Deploy script"}, "ak_rotated", nil
+}
+
+func (m *mockAPIKeyService) RevokePrevious(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error) {
+	if id != m.id {
+		return db.ApiKey{}, apperrors.APIKeyNotFound
+	}
+	return db.ApiKey{ID: id}, nil
+}
+
+func (m *mockAPIKeyService) Revoke(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error) {
+	if id != m.id {
+		return db.ApiKey{}, apperrors.APIKeyNotFound
+	}
+	return db.ApiKey{ID: id, Name: "Deploy script"}, nil
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	keyID := uuid.New()
+	handler := NewAPIKeyHandler(&mockAPIKeyService{id: keyID}, createTestLogger())
+
+	r := chi.NewRouter()
+	r.Get("/api-keys", handler.ListAPIKeys)
+	r.Post("/api-keys", handler.CreateAPIKey)
+	r.Delete("/api-keys/{id}", handler.RevokeAPIKey)
+	r.Get("/api-keys/{id}/usage", handler.GetUsage)
+	r.Post("/api-keys/{id}/rotate", handler.RotateAPIKey)
+	r.Delete("/api-keys/{id}/previous-key", handler.RevokePreviousAPIKey)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		ctx := middleware.WithUserID(req.Context(), "user_123")
+		if strings.HasSuffix(path, "/rotate") {
+			ctx = middleware.WithRequestBody(ctx, dto.RotateAPIKey{})
+		} else {
+			ctx = middleware.WithRequestBody(ctx, dto.CreateAPIKey{Name: "Deploy script"})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req.WithContext(ctx))
+		return w
+	}
+
+	// The key is only in the response that creates it
+	w := serve(http.MethodPost, "/api-keys")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	var created dto.SuccessResponse[dto.APIKey]
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if created.Data.ID != keyID || created.Data.Key != "ak_secret" {
+		t.Errorf("created = %+v, want key %s with its secret", created.Data, keyID)
+	}
+
+	w = serve(http.MethodGet, "/api-keys")
+	var listed dto.SuccessResponse[[]map[string]json.RawMessage]
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(listed.Data) != 1 {
+		t.Fatalf("listed %d keys, want 1", len(listed.Data))
+	}
+	if _, ok := listed.Data[0]["key"]; ok {
+		t.Error("listed key includes its secret")
+	}
+
+	w = serve(http.MethodGet, "/api-keys/"+keyID.String()+"/usage")
+	if w.Code != http.StatusOK {
+		t.Fatalf("usage status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var usage dto.SuccessResponse[dto.APIKeyUsage]
+	if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if usage.Data.UseCount != 3 || usage.Data.LastUsedIP == nil || *usage.Data.LastUsedIP != "203.0.113.7" ||
+		usage.Data.PreviousKey == nil || usage.Data.PreviousKey.LastUsedAt == nil {
+		t.Errorf("usage = %+v, want 3 uses from 203.0.113.7 and the previous key in use", usage.Data)
+	}
+
+	// The new key is only in the response that rotates it
+	w = serve(http.MethodPost, "/api-keys/"+keyID.String()+"/rotate")
+	var rotated dto.SuccessResponse[dto.APIKey]
+	if err := json.Unmarshal(w.Body.Bytes(), &rotated); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || rotated.Data.Key != "ak_rotated" {
+		t.Errorf("rotate = %d %+v, want the new key", w.Code, rotated.Data)
+	}
+
+	if w := serve(http.MethodDelete, "/api-keys/"+keyID.String()+"/previous-key"); w.Code != http.StatusOK {
+		t.Errorf("revoke previous key status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{name: "revoked", id: keyID.String(), expectedStatus: http.StatusOK},
+		{name: "unknown key", id: uuid.NewString(), expectedStatus: http.StatusNotFound},
+		{name: "invalid ID", id: "nope", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(http.MethodDelete, "/api-keys/"+tt.id); w.Code != tt.expectedStatus {
+				t.Errorf("revoke status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
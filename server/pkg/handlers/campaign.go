@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// CampaignService defines the service methods needed by CampaignHandler
+type CampaignService interface {
+	ListCampaigns(ctx context.Context, userID string) ([]db.ListUserCampaignsRow, error)
+	GetCampaign(ctx context.Context, userID string, id uuid.UUID) (db.GetCampaignByIdAndUserRow, error)
+	CreateCampaign(ctx context.Context, userID string, name string, budgetNote *string, startsAt *time.Time, endsAt *time.Time) (db.CreateCampaignRow, error)
+	UpdateCampaign(ctx context.Context, userID string, id uuid.UUID, name *string, budgetNote *string, startsAt *time.Time, endsAt *time.Time) (db.UpdateCampaignRow, error)
+	DeleteCampaign(ctx context.Context, userID string, id uuid.UUID) (db.DeleteCampaignRow, error)
+	ListCampaignLinks(ctx context.Context, userID string, id uuid.UUID) ([]db.ListCampaignLinksRow, error)
+	AddLinksToCampaign(ctx context.Context, userID string, id uuid.UUID, linkIDs []uuid.UUID) ([]db.ListCampaignLinksRow, error)
+	RemoveLinksFromCampaign(ctx context.Context, userID string, id uuid.UUID, linkIDs []uuid.UUID) ([]db.ListCampaignLinksRow, error)
+}
+
+type CampaignHandler struct {
+	CampaignService CampaignService
+	logger          logger.Logger
+}
+
+func NewCampaignHandler(campaignService CampaignService, logger logger.Logger) *CampaignHandler {
+	return &CampaignHandler{
+		CampaignService: campaignService,
+		logger:          logger,
+	}
+}
+
+// ListCampaigns: GET /api/v1/campaigns
+func (h *CampaignHandler) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaigns, err := h.CampaignService.ListCampaigns(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ListUserCampaignsRow]{
+		Data: campaigns,
+	})
+}
+
+// CreateCampaign: POST /api/v1/campaigns
+func (h *CampaignHandler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateCampaign](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	createdCampaign, err := h.CampaignService.CreateCampaign(
+		r.Context(),
+		userID,
+		reqBody.Name,
+		reqBody.BudgetNote,
+		reqBody.StartsAt,
+		reqBody.EndsAt,
+	)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.logger.Info("Campaign created successfully",
+		zap.String("user_id", userID),
+		zap.String("campaign_id", createdCampaign.ID.String()),
+		zap.String("campaign_name", createdCampaign.Name),
+	)
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[db.CreateCampaignRow]{
+		Data: createdCampaign,
+	})
+}
+
+// GetCampaign: GET /api/v1/campaigns/{id}
+func (h *CampaignHandler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, ok := h.parseCampaignID(w, r)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.CampaignService.GetCampaign(r.Context(), userID, campaignID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.GetCampaignByIdAndUserRow]{
+		Data: campaign,
+	})
+}
+
+// UpdateCampaign: PATCH /api/v1/campaigns/{id}
+func (h *CampaignHandler) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, ok := h.parseCampaignID(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody, err := mw.GetRequestBodyFromContext[dto.UpdateCampaign](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	updatedCampaign, err := h.CampaignService.UpdateCampaign(
+		r.Context(),
+		userID,
+		campaignID,
+		reqBody.Name,
+		reqBody.BudgetNote,
+		reqBody.StartsAt,
+		reqBody.EndsAt,
+	)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.UpdateCampaignRow]{
+		Data: updatedCampaign,
+	})
+}
+
+// DeleteCampaign: DELETE /api/v1/campaigns/{id}
+// Attached links are detached, not deleted.
+func (h *CampaignHandler) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, ok := h.parseCampaignID(w, r)
+	if !ok {
+		return
+	}
+
+	deletedCampaign, err := h.CampaignService.DeleteCampaign(r.Context(), userID, campaignID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.DeleteCampaignRow]{
+		Data: deletedCampaign,
+	})
+}
+
+// ListCampaignLinks: GET /api/v1/campaigns/{id}/links
+func (h *CampaignHandler) ListCampaignLinks(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, ok := h.parseCampaignID(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.CampaignService.ListCampaignLinks(r.Context(), userID, campaignID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ListCampaignLinksRow]{
+		Data: links,
+	})
+}
+
+// AddLinksToCampaign: POST /api/v1/campaigns/{id}/links
+func (h *CampaignHandler) AddLinksToCampaign(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, ok := h.parseCampaignID(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody, err := mw.GetRequestBodyFromContext[dto.AddLinksToCampaign](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	links, err := h.CampaignService.AddLinksToCampaign(r.Context(), userID, campaignID, reqBody.LinkIDs)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ListCampaignLinksRow]{
+		Data: links,
+	})
+}
+
+// RemoveLinksFromCampaign: POST /api/v1/campaigns/{id}/links/remove
+func (h *CampaignHandler) RemoveLinksFromCampaign(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, ok := h.parseCampaignID(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody, err := mw.GetRequestBodyFromContext[dto.RemoveLinksFromCampaign](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	links, err := h.CampaignService.RemoveLinksFromCampaign(r.Context(), userID, campaignID, reqBody.LinkIDs)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ListCampaignLinksRow]{
+		Data: links,
+	})
+}
+
+// parseCampaignID reads the {id} URL param, writing a 400 response if it isn't a UUID
+func (h *CampaignHandler) parseCampaignID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	campaignID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return campaignID, true
+}
+
+// handleError maps errors to HTTP responses and writes them directly
+func (h *CampaignHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.CampaignNotFound):
+		h.logger.Warn("Campaign not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCampaignNotFound,
+				Title:  apperrors.CampaignNotFound.Error(),
+				Detail: "Unable to find campaign with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.CampaignNameTaken):
+		h.logger.Warn("Campaign name already taken",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict) // 409 Conflict
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCampaignNameTaken,
+				Title:  apperrors.CampaignNameTaken.Error(),
+				Detail: "A campaign with this name already exists",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidCampaignPeriod):
+		h.logger.Warn("Invalid campaign period",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidCampaignPeriod,
+				Title:  apperrors.InvalidCampaignPeriod.Error(),
+				Detail: "starts_at must be before ends_at",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
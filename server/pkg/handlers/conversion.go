@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// ConversionService defines the service methods needed by ConversionHandler
+type ConversionService interface {
+	CreateConversion(ctx context.Context, userID string, clickID uuid.UUID, event string, revenueCents *int64, externalID *string) (db.CreateConversionRow, error)
+}
+
+type ConversionHandler struct {
+	ConversionService ConversionService
+	logger            logger.Logger
+}
+
+func NewConversionHandler(conversionService ConversionService, logger logger.Logger) *ConversionHandler {
+	return &ConversionHandler{
+		ConversionService: conversionService,
+		logger:            logger,
+	}
+}
+
+// CreateConversion: POST /api/v1/conversions
+func (h *ConversionHandler) CreateConversion(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateConversion](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	conversion, err := h.ConversionService.CreateConversion(
+		r.Context(),
+		userID,
+		reqBody.ClickID,
+		reqBody.Event,
+		reqBody.RevenueCents,
+		reqBody.ExternalID,
+	)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.logger.Info("Conversion recorded successfully",
+		zap.String("user_id", userID),
+		zap.String("click_id", conversion.ClickID.String()),
+		zap.String("event", conversion.Event),
+	)
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[db.CreateConversionRow]{
+		Data: conversion,
+	})
+}
+
+func (h *ConversionHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.ClickNotFound):
+		h.logger.Warn("Click not found for conversion",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeClickNotFound,
+				Title:  apperrors.ClickNotFound.Error(),
+				Detail: "No click with this ID was recorded for your links",
+			},
+		})
+
+	case errors.Is(err, apperrors.ConversionAlreadyRecorded):
+		h.logger.Warn("Conversion already recorded",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict) // 409 Conflict
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeConversionAlreadyRecorded,
+				Title:  apperrors.ConversionAlreadyRecorded.Error(),
+				Detail: "A conversion with this external_id was already recorded for the click",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
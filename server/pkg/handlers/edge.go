@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// Largest batch of clicks an edge instance can send, well above MaxEdgeClicksBatch clicks
+const maxEdgeClicksBytes = 2 << 20
+
+// ClickSink takes the clicks of redirects served elsewhere
+type ClickSink interface {
+	RecordClick(ctx context.Context, click service.Click) error
+}
+
+// EdgeLinkResolver resolves redirects from an edge instance's local cache, see service.EdgeLinks
+type EdgeLinkResolver interface {
+	Resolve(ctx context.Context, code string) (db.GetLinkForRedirectRow, bool)
+}
+
+/*
+EdgeHandler serves an edge instance: redirects of links in its local cache are
+answered on the spot, and every other request is forwarded to the primary, so
+visitors get the same response either way.
+*/
+type EdgeHandler struct {
+	links   EdgeLinkResolver
+	clicks  ClickSink
+	primary http.Handler
+	logger  logger.Logger
+}
+
+func NewEdgeHandler(links EdgeLinkResolver, clicks ClickSink, primary *url.URL, logger logger.Logger) *EdgeHandler {
+	h := &EdgeHandler{
+		links:  links,
+		clicks: clicks,
+		logger: logger,
+	}
+	h.primary = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(primary)
+			// Short domains are told apart by host
+			pr.Out.Host = pr.In.Host
+			pr.SetXForwarded()
+			// The primary trusts the edge, not the proxies in front of it, so it gets the client the edge resolved
+			if rc, err := reqctx.From(pr.In.Context()); err == nil && rc.ClientIP.IsValid() {
+				pr.Out.Header.Set("X-Forwarded-For", rc.ClientIP.String())
+			}
+		},
+		ErrorHandler: h.proxyError,
+	}
+	return h
+}
+
+/*
+Redirect: GET /{shortcode}
+
+Redirects straight away when the link is cached locally; only links that
+redirect without further checks are, see service.LinkService.GetOriginalURL.
+Link-preview crawlers are forwarded so they get the link's own preview.
+*/
+func (h *EdgeHandler) Redirect(w http.ResponseWriter, r *http.Request) {
+	shortcode := chi.URLParam(r, "shortcode")
+
+	link, ok := h.links.Resolve(r.Context(), shortcode)
+	if !ok || isPreviewCrawler(r.UserAgent()) {
+		h.Forward(w, r)
+		return
+	}
+
+	rc, _ := reqctx.From(r.Context())
+	click := service.Click{
+		ID:        uuid.New(),
+		Shortcode: shortcode,
+		LinkID:    link.ID,
+		UserID:    link.UserID,
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+		Source:    service.ClickSource(r.URL.Query()),
+		ClientIP:  rc.ClientIP,
+	}
+	// Only queued, so it doesn't hold up the redirect
+	if err := h.clicks.RecordClick(r.Context(), click); err != nil {
+		h.logger.Warn("Failed to record click",
+			zap.Error(err),
+			zap.String("shortcode", shortcode),
+		)
+	}
+
+	destination := service.ExpandDestination(link.OriginalUrl, service.DestinationVars{
+		ClickID:   click.ID,
+		Shortcode: shortcode,
+		Time:      time.Now(),
+	})
+	http.Redirect(w, r, destination, http.StatusFound)
+}
+
+// Forward hands the request to the primary
+func (h *EdgeHandler) Forward(w http.ResponseWriter, r *http.Request) {
+	h.primary.ServeHTTP(w, r)
+}
+
+func (h *EdgeHandler) proxyError(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error("Failed to forward request to the primary",
+		zap.Error(err),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+	http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+}
+
+// EdgeClicksHandler takes the clicks edge instances send the primary
+type EdgeClicksHandler struct {
+	clicks ClickSink
+	token  string
+	logger logger.Logger
+}
+
+func NewEdgeClicksHandler(clicks ClickSink, token string, logger logger.Logger) *EdgeClicksHandler {
+	return &EdgeClicksHandler{
+		clicks: clicks,
+		token:  token,
+		logger: logger,
+	}
+}
+
+/*
+RecordClicks: POST /edge/clicks (internal port)
+
+Records a batch of clicks served by an edge instance, like the primary's own.
+Edges authenticate with EDGE_TOKEN as a bearer token.
+*/
+func (h *EdgeClicksHandler) RecordClicks(w http.ResponseWriter, r *http.Request) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		h.logger.Warn("Invalid edge token",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidEdgeToken,
+				Title:  apperrors.InvalidEdgeToken.Error(),
+				Detail: "The edge token is missing or doesn't match EDGE_TOKEN",
+			},
+		})
+		return
+	}
+
+	var clicks []service.Click
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEdgeClicksBytes)).Decode(&clicks)
+	if err != nil || len(clicks) > service.MaxEdgeClicksBatch {
+		h.logger.Warn("Invalid edge clicks batch",
+			zap.Error(err),
+			zap.Int("clicks", len(clicks)),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid request body",
+				Detail: "The body must be a JSON array of clicks",
+			},
+		})
+		return
+	}
+
+	for _, click := range clicks {
+		if err := h.clicks.RecordClick(r.Context(), click); err != nil {
+			h.logger.Warn("Failed to record edge click",
+				zap.Error(err),
+				zap.String("shortcode", click.Shortcode),
+			)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
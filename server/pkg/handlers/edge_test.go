@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type recordedClicks []service.Click
+
+func (c *recordedClicks) RecordClick(ctx context.Context, click service.Click) error {
+	*c = append(*c, click)
+	return nil
+}
+
+func TestEdgeClicksHandler_RecordClicks(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+	clicks := &recordedClicks{}
+	h := NewEdgeClicksHandler(clicks, token, createTestLogger())
+
+	request := func(authorization, body string) int {
+		req := httptest.NewRequest(http.MethodPost, service.EdgeClicksPath, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		h.RecordClicks(w, req)
+		return w.Code
+	}
+
+	batch := `[{"id":"5f0c6a3e-8d7b-4d8e-9b52-1f0f4b7c2a10","shortcode":"abc","user_id":"user_1","client_ip":"203.0.113.7"},{"shortcode":"xyz"}]`
+	if code := request("Bearer wrong", batch); code != http.StatusUnauthorized {
+		t.Errorf("wrong token = %d, want 401", code)
+	}
+	if code := request("", batch); code != http.StatusUnauthorized {
+		t.Errorf("no token = %d, want 401", code)
+	}
+	if code := request("Bearer "+token, `{"shortcode":"abc"}`); code != http.StatusBadRequest {
+		t.Errorf("not an array = %d, want 400", code)
+	}
+	if len(*clicks) != 0 {
+		t.Fatalf("recorded %d clicks of rejected requests", len(*clicks))
+	}
+
+	if code := request("Bearer "+token, batch); code != http.StatusNoContent {
+		t.Fatalf("valid batch = %d, want 204", code)
+	}
+	if len(*clicks) != 2 || (*clicks)[0].UserID != "user_1" || (*clicks)[0].ClientIP.String() != "203.0.113.7" || (*clicks)[1].Shortcode != "xyz" {
+		t.Errorf("recorded %+v, want the batch", *clicks)
+	}
+}
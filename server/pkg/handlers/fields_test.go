@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "no selection", target: "/api/v1/links", expected: nil},
+		{name: "selected fields", target: "/api/v1/links?fields=id,%20shortcode,original_url,", expected: []string{"id", "shortcode", "original_url"}},
+		{name: "unknown field", target: "/api/v1/links?fields=id,password", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+
+			fields, err := parseFields[db.ListUserLinksRow](req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("parseFields() = %v, want %v", fields, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	id := uuid.New()
+	links := []db.ListUserLinksRow{
+		{ID: id, Shortcode: "abc123", OriginalUrl: "https://example.com/"},
+	}
+
+	projected, err := selectFields(links, []string{"id", "shortcode"})
+	if err != nil {
+		t.Fatalf("selectFields() error = %v", err)
+	}
+
+	encoded, err := json.Marshal(projected)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	expected := `[{"id":"` + id.String() + `","shortcode":"abc123"}]`
+	if string(encoded) != expected {
+		t.Errorf("selectFields() = %s, want %s", encoded, expected)
+	}
+}
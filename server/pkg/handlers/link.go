@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -15,8 +19,12 @@ import (
 	"github.com/styltsou/url-shortener/server/pkg/db"
 	"github.com/styltsou/url-shortener/server/pkg/dto"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
 	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
 	"github.com/styltsou/url-shortener/server/pkg/service"
 	"go.uber.org/zap"
 )
@@ -27,27 +35,108 @@ import (
 // - Include context (method, path, user_id, etc.) in log entries
 // - Use structured logging with zap fields
 
+const (
+	// Upper bound for recording a click in the background
+	clickRecordTimeout = 5 * time.Second
+	// Largest lead form body accepted on email-gated links
+	maxLeadFormBytes = 4 << 10
+)
+
 // LinkServiceInterface defines the service methods needed by LinkHandler
 type LinkService interface {
 	GetOriginalURL(ctx context.Context, code string) (db.GetLinkForRedirectRow, error)
-	CreateShortLink(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time) (db.TryCreateLinkRow, error)
+	CreateShortLink(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error)
 	ListAllLinks(ctx context.Context, userID string, isActive *bool, tagIDs []uuid.UUID, page, limit int) (*service.ListLinksResult, error)
+	GetLinksByIDs(ctx context.Context, userID string, ids []uuid.UUID) ([]db.ListUserLinksByIDsRow, error)
 	GetLinkByShortcode(ctx context.Context, userID string, shortcode string) (db.GetLinkByShortcodeAndUserRow, error)
-	UpdateLink(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error)
+	UpdateLink(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error)
 	DeleteLink(ctx context.Context, userID string, id uuid.UUID) (db.DeleteLinkRow, error)
+	RetireLink(ctx context.Context, userID string, id uuid.UUID, message *string, alternativeURL *string) (db.RetireLinkRow, error)
 	AddTagsToLink(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
 	RemoveTagsFromLink(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
+	CanAccessPrivateLink(link db.GetLinkForRedirectRow, viewerID string, token string) bool
+	CreateAccessToken(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (string, error)
+	CaptureLead(ctx context.Context, linkID uuid.UUID, email string) error
+	ListLeads(ctx context.Context, userID string, linkID uuid.UUID) ([]db.ListLinkLeadsRow, error)
+	QRCodes(ctx context.Context, userID string, ids []uuid.UUID, baseURL string) ([]service.QRCode, error)
+	ListLinkChanges(ctx context.Context, userID string, since time.Time, cursor string, limit int) (*service.LinkChangesResult, error)
+	QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error)
+	SetPreview(ctx context.Context, userID string, linkID uuid.UUID, title *string, description *string, imageURL *string) (db.LinkPreview, error)
+	GetPreview(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error)
+	DeletePreview(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error)
+	PreviewForRedirect(ctx context.Context, shortcode string) (db.LinkPreview, bool, error)
+	SetTrafficCap(ctx context.Context, userID string, linkID uuid.UUID, dailyCap *int32, totalCap *int32, overflowURL string) (db.LinkTrafficCap, error)
+	GetTrafficCap(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error)
+	DeleteTrafficCap(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error)
+	TakeTrafficCap(ctx context.Context, link db.GetLinkForRedirectRow, now time.Time) bool
+	SetResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID, headers map[string]string) (db.LinkResponseHeader, error)
+	GetResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error)
+	DeleteResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error)
+	EnablePublicStats(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error)
+	GetPublicStats(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error)
+	DisablePublicStats(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error)
+	CreatePublicStatsToken(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (service.PublicStatsToken, error)
+	SetWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID, active bool, message *string, retryAfter int32) (db.UpsertLinkWaitingRoomRow, string, error)
+	GetWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID) (db.GetLinkWaitingRoomRow, error)
+	DeleteWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID) (db.DeleteLinkWaitingRoomRow, error)
+	SetWaitingRoomActiveByToken(ctx context.Context, token string, active bool) (db.SetLinkWaitingRoomActiveByTokenRow, error)
+	AddComment(ctx context.Context, userID string, linkID uuid.UUID, body string) (db.LinkComment, error)
+	ListComments(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*service.ListCommentsResult, error)
+	DeleteComment(ctx context.Context, userID string, linkID uuid.UUID, commentID uuid.UUID) (db.LinkComment, error)
+	SetDynamicLink(ctx context.Context, userID string, linkID uuid.UUID, locked bool) (db.DynamicLink, error)
+	GetDynamicLink(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error)
+	DeleteDynamicLink(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error)
+	ChangeDestination(ctx context.Context, userID string, linkID uuid.UUID, destination string) (db.UpdateLinkRow, error)
+	ScheduleDestinationChange(ctx context.Context, userID string, linkID uuid.UUID, destination string, at time.Time) (db.LinkDestinationChange, error)
+	ListDestinationChanges(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*service.ListDestinationChangesResult, error)
+	CancelDestinationChange(ctx context.Context, userID string, linkID uuid.UUID, changeID uuid.UUID) (db.LinkDestinationChange, error)
+	SenderVerification(ctx context.Context, shortcode string, destination string) (db.VerifiedSender, bool, error)
+	ListDuplicateLinks(ctx context.Context, userID string, page, limit int) (*service.ListDuplicateLinksResult, error)
+	MergeLinks(ctx context.Context, userID string, into uuid.UUID, ids []uuid.UUID) (*service.MergeLinksResult, error)
+	UpgradeToHTTPS(ctx context.Context, destination string) string
+	ListHTTPSUpgrades(ctx context.Context, userID string, page, limit int) (*service.ListHTTPSUpgradesResult, error)
+	ListDestinationAlerts(ctx context.Context, userID string, page, limit int) (*service.ListDestinationAlertsResult, error)
+}
+
+// TagSuggester suggests existing tags for a destination URL
+type TagSuggester interface {
+	SuggestTags(ctx context.Context, userID string, rawURL string) ([]service.TagSuggestion, error)
+}
+
+// ClickRecorder records redirect events for analytics and counts them per link
+type ClickRecorder interface {
+	RecordClick(ctx context.Context, click service.Click) error
+	LinkClickCounts(ctx context.Context, links []service.CountedLink) (map[uuid.UUID]int64, error)
 }
 
 type LinkHandler struct {
 	LinkService LinkService
-	logger      logger.Logger
+	clicks      ClickRecorder
+	tags        TagSuggester
+	// Apply tag suggestions to new links unless the request says otherwise
+	autoTag bool
+	// Origin short URLs are built on (e.g. "https://sho.rt"), the request's origin when empty
+	shortURLBase string
+	// Page reserved shortcodes redirect to until a link is created with them, the built-in page when empty
+	placeholderURL string
+	// Challenges suspected bots on links with shield mode; nil (or without a secret) lets everyone through
+	shield *service.BotShield
+	// Suggests near-miss shortcodes on 404 pages; nil (or disabled) shows none
+	suggester *service.ShortcodeSuggester
+	logger    logger.Logger
 }
 
-func NewLinkHandler(linkService LinkService, logger logger.Logger) *LinkHandler {
+func NewLinkHandler(linkService LinkService, clicks ClickRecorder, tags TagSuggester, autoTag bool, shortURLBase string, placeholderURL string, shield *service.BotShield, suggester *service.ShortcodeSuggester, logger logger.Logger) *LinkHandler {
 	return &LinkHandler{
-		LinkService: linkService,
-		logger:      logger,
+		LinkService:    linkService,
+		clicks:         clicks,
+		tags:           tags,
+		autoTag:        autoTag,
+		shortURLBase:   shortURLBase,
+		placeholderURL: placeholderURL,
+		shield:         shield,
+		suggester:      suggester,
+		logger:         logger,
 	}
 }
 
@@ -55,7 +144,147 @@ func NewLinkHandler(linkService LinkService, logger logger.Logger) *LinkHandler
 func (h *LinkHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 	shortcode := chi.URLParam(r, "shortcode")
 
+	link, ok := h.resolveRedirect(w, r, shortcode)
+	if !ok {
+		return
+	}
+	setResponseHeaders(w, link)
+
+	// Social crawlers get the link's own preview, if it has one, instead of the destination's
+	if isPreviewCrawler(r.UserAgent()) && h.servePreview(w, r, shortcode) {
+		return
+	}
+
+	if link.Shield && h.challengeBot(w, r, shortcode) {
+		return
+	}
+
+	// While the destination is overloaded visitors wait on the holding page, which retries this URL
+	if link.WaitingRoom {
+		h.renderWaitingRoom(w, r, link)
+		return
+	}
+
+	// Email-gated links forward only once the visitor submits the form (see CaptureLead)
+	if link.CaptureEmail {
+		h.renderLeadForm(w, r, http.StatusOK, "")
+		return
+	}
+
+	h.forward(w, r, shortcode, link, http.StatusFound)
+}
+
+// Lead form submission: POST /{shortcode}
+// Stores the visitor's email and forwards them to the original URL.
+func (h *LinkHandler) CaptureLead(w http.ResponseWriter, r *http.Request) {
+	shortcode := chi.URLParam(r, "shortcode")
+
+	link, ok := h.resolveRedirect(w, r, shortcode)
+	if !ok {
+		return
+	}
+	setResponseHeaders(w, link)
+
+	// Bots could post the form without loading it
+	if link.Shield && h.challengeBot(w, r, shortcode) {
+		return
+	}
+
+	if link.WaitingRoom {
+		h.renderWaitingRoom(w, r, link)
+		return
+	}
+
+	if link.CaptureEmail {
+		r.Body = http.MaxBytesReader(w, r.Body, maxLeadFormBytes)
+
+		if err := h.LinkService.CaptureLead(r.Context(), link.ID, r.PostFormValue("email")); err != nil {
+			if errors.Is(err, apperrors.InvalidEmail) {
+				h.logger.Warn("Invalid email submitted on lead form",
+					zap.String("shortcode", shortcode),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+				h.renderLeadForm(w, r, http.StatusBadRequest, "lead.invalid_email")
+				return
+			}
+
+			h.logger.Error("Failed to capture lead",
+				zap.Error(err),
+				zap.String("shortcode", shortcode),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+			h.renderLeadForm(w, r, http.StatusInternalServerError, "lead.error")
+			return
+		}
+	}
+
+	// 303 so the browser follows up with a GET on the original URL
+	h.forward(w, r, shortcode, link, http.StatusSeeOther)
+}
+
+// forward records the click and sends the visitor to the destination,
+// through the interstitial page when the link has a redirect delay.
+// Links with append_click_id get the click ID added so conversions can be posted back,
+// links with a referrer policy go through a page on the short domain that applies it.
+// Links over their traffic cap forward to their overflow URL instead.
+func (h *LinkHandler) forward(w http.ResponseWriter, r *http.Request, shortcode string, link db.GetLinkForRedirectRow, status int) {
+	// Over its traffic cap the link's clicks go to the overflow URL, with the link's settings
+	if link.OverflowUrl != nil && !h.LinkService.TakeTrafficCap(r.Context(), link, time.Now()) {
+		link.OriginalUrl = *link.OverflowUrl
+		metrics.TrafficCapOverflow.Add(1)
+	}
+
+	rc, _ := reqctx.From(r.Context())
+	click := service.Click{
+		ID:        uuid.New(),
+		Shortcode: shortcode,
+		LinkID:    link.ID,
+		UserID:    link.UserID,
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+		Source:    service.ClickSource(r.URL.Query()),
+		ClientIP:  rc.ClientIP,
+	}
+	h.recordClick(r, click)
+
+	destination := service.ExpandDestination(link.OriginalUrl, service.DestinationVars{
+		ClickID:   click.ID,
+		Shortcode: shortcode,
+		Time:      time.Now(),
+	})
+	if link.AppendClickID {
+		destination = service.AppendClickID(destination, click.ID)
+	}
+
+	hideReferrer := link.ReferrerPolicy != "" && link.ReferrerPolicy != service.ReferrerPolicyDefault
+	if hideReferrer {
+		w.Header().Set("Referrer-Policy", link.ReferrerPolicy)
+	}
+
+	if link.RedirectDelay > 0 {
+		h.renderInterstitial(w, r, shortcode, link, destination)
+		return
+	}
+
+	// A plain redirect would pass on the referrer of the page the link was clicked on
+	if hideReferrer {
+		h.renderReferrerHop(w, r, link.ReferrerPolicy, destination)
+		return
+	}
+
+	http.Redirect(w, r, destination, status)
+}
+
+// resolveRedirect looks up the link behind a shortcode and checks the visitor may follow it.
+// When it returns false, an HTML error page (or a retired link's sunset page) has already been written.
+func (h *LinkHandler) resolveRedirect(w http.ResponseWriter, r *http.Request, shortcode string) (db.GetLinkForRedirectRow, bool) {
 	link, err := h.LinkService.GetOriginalURL(r.Context(), shortcode)
+	if errors.Is(err, apperrors.LinkPending) {
+		h.renderPending(w, r)
+		return db.GetLinkForRedirectRow{}, false
+	}
 	if err != nil {
 		h.logger.Warn("Link not found for redirect",
 			zap.Error(err),
@@ -64,32 +293,321 @@ func (h *LinkHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 			zap.String("path", r.URL.Path),
 			zap.String("remote_addr", r.RemoteAddr),
 		)
-		render.Status(r, http.StatusNotFound)
-		render.HTML(w, r, `<!DOCTYPE html>
-<html>
-	<head><title>Link Not Found</title></head>
+		h.renderNotFound(w, r, shortcode)
+		return db.GetLinkForRedirectRow{}, false
+	}
+
+	if link.Visibility == service.LinkVisibilityPrivate {
+		viewerID, _ := mw.GetOptionalUserIDFromContext(r.Context())
+
+		if !h.LinkService.CanAccessPrivateLink(link, viewerID, r.URL.Query().Get("token")) {
+			h.logger.Warn("Unauthorized access to private link",
+				zap.String("shortcode", shortcode),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remote_addr", r.RemoteAddr),
+			)
+			h.renderStatusPage(w, r, http.StatusUnauthorized, "private")
+			return db.GetLinkForRedirectRow{}, false
+		}
+	}
+
+	if link.RetiredAt.Valid {
+		h.renderSunset(w, r, link)
+		return db.GetLinkForRedirectRow{}, false
+	}
+
+	return link, true
+}
+
+// renderPending answers a reserved shortcode that has no link yet, with the configured placeholder
+// page or the built-in one. Not cached, so visitors see the destination as soon as it's attached.
+func (h *LinkHandler) renderPending(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if h.placeholderURL != "" {
+		http.Redirect(w, r, h.placeholderURL, http.StatusFound)
+		return
+	}
+
+	h.renderStatusPage(w, r, http.StatusOK, "pending")
+}
+
+// statusPageTemplate is the page for redirects that can't be followed (404, 401).
+// 404 pages may list "did you mean" suggestions.
+var statusPageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head><title>{{.Title}}</title></head>
+	<body>
+		<h1>{{.Heading}}</h1>
+		<p>{{.Message}}</p>
+		{{- with .Suggestions}}
+		<p>{{$.DidYouMean}}</p>
+		<ul>
+			{{- range .}}
+			<li><a href="{{.URL}}">{{.URL}}</a>{{with .Destination}} &rarr; {{.}}{{end}}</li>
+			{{- end}}
+		</ul>
+		{{- end}}
+	</body>
+</html>`))
+
+// renderStatusPage writes a status page whose texts are the "<page>.title", "<page>.heading"
+// and "<page>.message" messages of the visitor's language
+func (h *LinkHandler) renderStatusPage(w http.ResponseWriter, r *http.Request, status int, page string) {
+	h.writeStatusPage(w, r, status, page, nil)
+}
+
+// writeStatusPage writes a status page, listing the suggestions if there are any
+func (h *LinkHandler) writeStatusPage(w http.ResponseWriter, r *http.Request, status int, page string, suggestions []shortcodeSuggestion) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	var buf bytes.Buffer
+	if err := statusPageTemplate.Execute(&buf, map[string]any{
+		"Lang":        lang,
+		"Title":       i18n.T(lang, page+".title"),
+		"Heading":     i18n.T(lang, page+".heading"),
+		"Message":     i18n.T(lang, page+".message"),
+		"DidYouMean":  i18n.T(lang, "not_found.did_you_mean"),
+		"Suggestions": suggestions,
+	}); err != nil {
+		h.logger.Error("Failed to render status page",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	render.Status(r, status)
+	render.HTML(w, r, buf.String())
+}
+
+// interstitialTemplate is the page shown for links with a redirect delay.
+// The meta refresh works without JavaScript; the script keeps the countdown in sync.
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head>
+		<title>{{.Title}}</title>
+		<meta http-equiv="refresh" content="{{.Delay}};url={{.URL}}">
+	</head>
+	<body>
+		{{- with .Badge}}
+		<p class="verified-sender">&#10004; {{.}}</p>
+		{{- end}}
+		<p>{{.Message}}</p>
+		<p>{{.Countdown}} <a href="{{.URL}}">{{.Continue}}</a></p>
+		<script>
+			(function () {
+				var remaining = {{.Delay}};
+				var countdown = document.getElementById("countdown");
+				var timer = setInterval(function () {
+					remaining--;
+					countdown.textContent = remaining;
+					if (remaining <= 0) {
+						clearInterval(timer);
+						window.location.replace({{.URL}});
+					}
+				}, 1000);
+			})();
+		</script>
+	</body>
+</html>`))
+
+// renderInterstitial writes the "you will be redirected in N seconds" page, with a badge for verified senders.
+// The link's own message is shown as is; everything else follows the visitor's language.
+func (h *LinkHandler) renderInterstitial(w http.ResponseWriter, r *http.Request, shortcode string, link db.GetLinkForRedirectRow, destination string) {
+	lang := mw.GetLanguageFromContext(r.Context())
+	delay := link.RedirectDelay
+
+	message := i18n.T(lang, "interstitial.default_message")
+	if link.InterstitialMessage != nil && *link.InterstitialMessage != "" {
+		message = *link.InterstitialMessage
+	}
+
+	// The countdown element goes where the translation has its {seconds} placeholder
+	countdown := strings.Replace(
+		template.HTMLEscapeString(i18n.T(lang, "interstitial.countdown")),
+		"{seconds}",
+		fmt.Sprintf(`<span id="countdown">%d</span>`, delay),
+		1,
+	)
+
+	var buf bytes.Buffer
+	if err := interstitialTemplate.Execute(&buf, struct {
+		Lang      string
+		Title     string
+		Badge     string
+		Delay     int32
+		URL       string
+		Message   string
+		Countdown template.HTML
+		Continue  string
+	}{
+		Lang:      lang,
+		Title:     i18n.T(lang, "interstitial.title"),
+		Badge:     h.verifiedBadge(r, shortcode, link.OriginalUrl, lang),
+		Delay:     delay,
+		URL:       destination,
+		Message:   message,
+		Countdown: template.HTML(countdown),
+		Continue:  i18n.T(lang, "interstitial.continue"),
+	}); err != nil {
+		h.logger.Error("Failed to render interstitial, redirecting directly",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Redirect(w, r, destination, http.StatusFound)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.HTML(w, r, buf.String())
+}
+
+// referrerHopTemplate forwards to the destination under the link's referrer policy.
+// The meta refresh works without JavaScript; the script skips the history entry.
+var referrerHopTemplate = template.Must(template.New("referrer-hop").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head>
+		<title>{{.Title}}</title>
+		<meta name="referrer" content="{{.Policy}}">
+		<meta http-equiv="refresh" content="0;url={{.URL}}">
+	</head>
+	<body>
+		<p><a href="{{.URL}}">{{.Continue}}</a></p>
+		<script>location.replace({{.URL}});</script>
+	</body>
+</html>`))
+
+// renderReferrerHop writes the page that forwards to the destination under the referrer policy
+func (h *LinkHandler) renderReferrerHop(w http.ResponseWriter, r *http.Request, policy string, destination string) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	var buf bytes.Buffer
+	if err := referrerHopTemplate.Execute(&buf, map[string]string{
+		"Lang":     lang,
+		"Title":    i18n.T(lang, "interstitial.title"),
+		"Policy":   policy,
+		"URL":      destination,
+		"Continue": i18n.T(lang, "interstitial.continue"),
+	}); err != nil {
+		h.logger.Error("Failed to render referrer hop, redirecting directly",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Redirect(w, r, destination, http.StatusFound)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.HTML(w, r, buf.String())
+}
+
+// leadFormTemplate is the email form shown in front of email-gated links.
+// The form posts back to the current URL, so an access token in the query string is kept.
+var leadFormTemplate = template.Must(template.New("lead").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head><title>{{.Title}}</title></head>
 	<body>
-		<h1>404 - Link Not Found</h1>
-		<p>This link may have expired or been deleted.</p>
+		<h1>{{.Heading}}</h1>{{if .Error}}
+		<p role="alert">{{.Error}}</p>{{end}}
+		<form method="post">
+			<input type="email" name="email" required maxlength="254" autocomplete="email" placeholder="you@example.com">
+			<button type="submit">{{.Submit}}</button>
+		</form>
 	</body>
-</html>`)
+</html>`))
+
+// renderLeadForm writes the lead form, with the errorKey message above it when set
+func (h *LinkHandler) renderLeadForm(w http.ResponseWriter, r *http.Request, status int, errorKey string) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	errorMessage := ""
+	if errorKey != "" {
+		errorMessage = i18n.T(lang, errorKey)
+	}
+
+	var buf bytes.Buffer
+	if err := leadFormTemplate.Execute(&buf, map[string]string{
+		"Lang":    lang,
+		"Title":   i18n.T(lang, "lead.title"),
+		"Heading": i18n.T(lang, "lead.heading"),
+		"Error":   errorMessage,
+		"Submit":  i18n.T(lang, "lead.submit"),
+	}); err != nil {
+		h.logger.Error("Failed to render lead form",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, status)
+	render.HTML(w, r, buf.String())
+}
+
+// recordClick stores the click in the background so analytics never slow down the redirect
+func (h *LinkHandler) recordClick(r *http.Request, click service.Click) {
+	if h.clicks == nil {
 		return
 	}
 
-	http.Redirect(w, r, link.OriginalUrl, http.StatusFound)
+	// Detach from the request context: it is canceled as soon as the redirect is written
+	ctx := context.WithoutCancel(r.Context())
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, clickRecordTimeout)
+		defer cancel()
+
+		if err := h.clicks.RecordClick(ctx, click); err != nil {
+			h.logger.Warn("Failed to record click",
+				zap.Error(err),
+				zap.String("shortcode", click.Shortcode),
+			)
+		}
+	}()
 }
 
 // Create link: POST /api/v1/links
 func (h *LinkHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
-	reqBody := mw.GetRequestBodyFromContext[dto.CreateLink](r.Context())
-	userID := mw.GetUserIDFromContext(r.Context())
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateLink](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	destination := reqBody.URL
+	if reqBody.UpgradeHTTPS {
+		destination = h.LinkService.UpgradeToHTTPS(r.Context(), destination)
+	}
 
 	createdLink, err := h.LinkService.CreateShortLink(
 		r.Context(),
 		userID,
-		reqBody.URL,
+		destination,
 		reqBody.Shortcode,
 		reqBody.ExpiresAt,
+		reqBody.Visibility,
+		reqBody.CaptureEmail,
+		reqBody.RedirectDelay,
+		reqBody.InterstitialMessage,
+		reqBody.AppendClickID,
+		reqBody.Title,
+		reqBody.Shield,
+		reqBody.ReferrerPolicy,
+		reqBody.TagIDs,
+		reqBody.TagNames,
 	)
 	if err != nil {
 		h.handleError(w, r, err)
@@ -103,15 +621,101 @@ func (h *LinkHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
 		zap.String("original_url", createdLink.OriginalUrl),
 	)
 
+	autoTag := h.autoTag
+	if reqBody.AutoTag != nil {
+		autoTag = *reqBody.AutoTag
+	}
+	if autoTag {
+		h.applySuggestedTags(r, userID, createdLink)
+	}
+
 	render.Status(r, http.StatusCreated)
-	render.JSON(w, r, &dto.SuccessResponse[db.TryCreateLinkRow]{
-		Data: createdLink,
+	render.JSON(w, r, &dto.SuccessResponse[dto.LinkResponse]{
+		Data: dto.NewLinkResponse(createdLink, h.linkURLs(r)),
+	})
+}
+
+// applySuggestedTags tags a new link with the suggested tags.
+// Failures are only logged: the link itself was created successfully.
+func (h *LinkHandler) applySuggestedTags(r *http.Request, userID string, link db.TryCreateLinkRow) {
+	if h.tags == nil {
+		return
+	}
+
+	suggestions, err := h.tags.SuggestTags(r.Context(), userID, link.OriginalUrl)
+	if err != nil {
+		h.logger.Warn("Failed to suggest tags for new link",
+			zap.Error(err),
+			zap.String("link_id", link.ID.String()),
+		)
+		return
+	}
+
+	if len(suggestions) == 0 {
+		return
+	}
+
+	tagIDs := make([]uuid.UUID, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		tagIDs = append(tagIDs, suggestion.ID)
+	}
+
+	if _, err := h.LinkService.AddTagsToLink(r.Context(), userID, link.ID, tagIDs); err != nil {
+		h.logger.Warn("Failed to apply suggested tags to new link",
+			zap.Error(err),
+			zap.String("link_id", link.ID.String()),
+		)
+		return
+	}
+
+	h.logger.Debug("Suggested tags applied to new link",
+		zap.String("link_id", link.ID.String()),
+		zap.Int("tags", len(tagIDs)),
+	)
+}
+
+// SuggestTags: GET /api/v1/links/suggest-tags?url=
+func (h *LinkHandler) SuggestTags(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	suggestions, err := h.tags.SuggestTags(r.Context(), userID, r.URL.Query().Get("url"))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := make([]dto.TagSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		data = append(data, dto.TagSuggestion{
+			ID:     suggestion.ID,
+			Name:   suggestion.Name,
+			Reason: suggestion.Reason,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.TagSuggestion]{
+		Data: data,
 	})
 }
 
 // List links: GET /api/v1/links?tags=id1,id2&status=active|inactive|all
 func (h *LinkHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	// Batch lookup: ?ids=id1,id2,id3
+	if r.URL.Query().Has("ids") {
+		h.listLinksByIDs(w, r, userID)
+		return
+	}
 
 	// Parse query parameters
 	var isActive *bool
@@ -151,18 +755,27 @@ func (h *LinkHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Parse pagination parameters: ?page=1&limit=5
-	page := 1
-	limit := 5
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	// Parse pagination parameters: ?page=1&limit=5 (page_token is an alias of page)
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	// Parse field selection: ?fields=id,shortcode,original_url
+	fields, err := parseFields[dto.LinkWithTagsResponse](r)
+	if err != nil {
+		h.logger.Warn("Invalid fields query parameter",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid fields",
+				Detail: err.Error(),
+			},
+		})
+		return
 	}
 
 	h.logger.Info("Listing user links",
@@ -181,90 +794,256 @@ func (h *LinkHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ensure we always return an empty array (not null) when there are no links
-	if result.Links == nil {
-		result.Links = []db.ListUserLinksRow{}
-	}
+	links := dto.NewLinkWithTagsResponses(result.Links, h.linkURLs(r))
+	h.setClickCounts(r, links)
 
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[[]db.ListUserLinksRow]{
-		Data: result.Links,
-		Pagination: &dto.PaginationMeta{
-			Page:       result.Page,
-			Limit:      result.Limit,
-			Total:      result.Total,
-			TotalPages: result.TotalPages,
-		},
-	})
-}
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
 
-// Get link by shortcode: GET /api/v1/links/{shortcode}
-func (h *LinkHandler) GetLink(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
-	shortcode := chi.URLParam(r, "shortcode")
+	if fields != nil {
+		projected, err := selectFields(links, fields)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
 
-	link, err := h.LinkService.GetLinkByShortcode(r.Context(), userID, shortcode)
-	if err != nil {
-		h.handleError(w, r, err)
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, &dto.SuccessResponse[[]map[string]json.RawMessage]{
+			Data:       projected,
+			Pagination: &result.Meta,
+			Links:      &pageLinks,
+		})
 		return
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[db.GetLinkByShortcodeAndUserRow]{
-		Data: link,
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.LinkWithTagsResponse]{
+		Data:       links,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
 	})
 }
 
-// Update link (PATCH code/expiry): PATCH /api/v1/links/{id}
-func (h *LinkHandler) UpdateLink(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+// listLinksByIDs returns the user's links among ?ids= in one response, without pagination.
+// Unknown IDs are left out of the result rather than failing the request.
+func (h *LinkHandler) listLinksByIDs(w http.ResponseWriter, r *http.Request, userID string) {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
 
-	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
-	if uuidErr != nil {
-		h.logger.Warn("Invalid ID format",
-			zap.Error(uuidErr),
-			zap.String("provided_id", chi.URLParam(r, "id")),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-		)
+	for _, idStr := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Warn("Invalid link ID in ids query parameter",
+				zap.String("id", idStr),
+				zap.Error(err),
+			)
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, dto.ErrorResponse{
+				Error: dto.ErrorObject{
+					Code:   apperrors.CodeInvalidID,
+					Title:  "Invalid link ID",
+					Detail: fmt.Sprintf("%q is not a valid UUID", idStr),
+				},
+			})
+			return
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
 
+	if len(ids) == 0 || len(ids) > service.MaxBatchLinkIDs {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, dto.ErrorResponse{
 			Error: dto.ErrorObject{
-				Code:   apperrors.CodeInvalidID,
-				Title:  "Invalid ID format",
-				Detail: "ID must be a valid UUID format",
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid ids",
+				Detail: fmt.Sprintf("ids must contain between 1 and %d link IDs", service.MaxBatchLinkIDs),
 			},
 		})
 		return
 	}
 
-	body := mw.GetRequestBodyFromContext[dto.UpdateLink](r.Context())
-
-	updatedLink, err := h.LinkService.UpdateLink(
-		r.Context(),
-		userID,
-		linkID,
-		body.Shortcode,
-		body.IsActive,
-		body.ExpiresAt,
-	)
-
+	fields, err := parseFields[dto.LinkWithTagsResponse](r)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid fields",
+				Detail: err.Error(),
+			},
+		})
+		return
+	}
+
+	rows, err := h.LinkService.GetLinksByIDs(r.Context(), userID, ids)
 	if err != nil {
-		// handleError logs the error and maps it to appropriate HTTP response
 		h.handleError(w, r, err)
 		return
 	}
 
+	urls := h.linkURLs(r)
+	links := make([]dto.LinkWithTagsResponse, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, dto.NewLinkWithTagsResponse(db.ListUserLinksRow(row), urls))
+	}
+	h.setClickCounts(r, links)
+
+	if fields != nil {
+		projected, err := selectFields(links, fields)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, &dto.SuccessResponse[[]map[string]json.RawMessage]{
+			Data: projected,
+		})
+		return
+	}
+
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, dto.SuccessResponse[db.UpdateLinkRow]{
-		Data: updatedLink,
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.LinkWithTagsResponse]{
+		Data: links,
+	})
+}
+
+// Get link by shortcode: GET /api/v1/links/{shortcode}
+func (h *LinkHandler) GetLink(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	shortcode := chi.URLParam(r, "shortcode")
+
+	link, err := h.LinkService.GetLinkByShortcode(r.Context(), userID, shortcode)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	links := []dto.LinkWithTagsResponse{dto.NewLinkWithTagsResponse(db.ListUserLinksRow(link), h.linkURLs(r))}
+	h.setClickCounts(r, links)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[linkDetail]{
+		Data: linkDetail{
+			LinkWithTagsResponse: links[0],
+			TrafficCap:           h.trafficCapStatus(r, userID, link.ID),
+		},
+	})
+}
+
+// setClickCounts fills in the links' click counts. They're best-effort: when they
+// can't be read the links are returned without them.
+func (h *LinkHandler) setClickCounts(r *http.Request, links []dto.LinkWithTagsResponse) {
+	if h.clicks == nil || len(links) == 0 {
+		return
+	}
+
+	counted := make([]service.CountedLink, len(links))
+	for i, link := range links {
+		counted[i] = service.CountedLink{ID: link.ID, Shortcode: link.Shortcode}
+	}
+
+	counts, err := h.clicks.LinkClickCounts(r.Context(), counted)
+	if err != nil {
+		h.logger.Error("Failed to get click counts",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		return
+	}
+	if counts == nil {
+		return
+	}
+
+	for i := range links {
+		count := counts[links[i].ID]
+		links[i].ClickCount = &count
+	}
+}
+
+// Update link (PATCH code/expiry): PATCH /api/v1/links/{id}
+func (h *LinkHandler) UpdateLink(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	body, err := mw.GetRequestBodyFromContext[dto.UpdateLink](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	updatedLink, err := h.LinkService.UpdateLink(
+		r.Context(),
+		userID,
+		linkID,
+		body.Shortcode,
+		body.IsActive,
+		body.ExpiresAt,
+		body.Visibility,
+		body.CaptureEmail,
+		body.RedirectDelay,
+		body.InterstitialMessage,
+		body.AppendClickID,
+		body.Shield,
+		body.ReferrerPolicy,
+	)
+
+	if err != nil {
+		// handleError logs the error and maps it to appropriate HTTP response
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, dto.SuccessResponse[dto.LinkResponse]{
+		Data: dto.NewUpdatedLinkResponse(updatedLink, h.linkURLs(r)),
 	})
 }
 
 // Delete link by ID: DELETE /api/v1/links/{id}
 func (h *LinkHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
 	if uuidErr != nil {
@@ -295,14 +1074,18 @@ func (h *LinkHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[db.DeleteLinkRow]{
-		Data: deletedLink,
+	render.JSON(w, r, &dto.SuccessResponse[dto.LinkResponse]{
+		Data: dto.NewUpdatedLinkResponse(db.UpdateLinkRow(deletedLink), h.linkURLs(r)),
 	})
 }
 
 // AddTagsToLink: POST /api/v1/links/{id}/tags
 func (h *LinkHandler) AddTagsToLink(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
 	if uuidErr != nil {
@@ -324,7 +1107,11 @@ func (h *LinkHandler) AddTagsToLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reqBody := mw.GetRequestBodyFromContext[dto.AddTagsToLink](r.Context())
+	reqBody, err := mw.GetRequestBodyFromContext[dto.AddTagsToLink](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	updatedLink, err := h.LinkService.AddTagsToLink(r.Context(), userID, linkID, reqBody.TagIDs)
 	if err != nil {
@@ -333,14 +1120,18 @@ func (h *LinkHandler) AddTagsToLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[db.GetLinkByIdAndUserWithTagsRow]{
-		Data: updatedLink,
+	render.JSON(w, r, &dto.SuccessResponse[dto.LinkWithTagsResponse]{
+		Data: dto.NewLinkWithTagsResponse(db.ListUserLinksRow(updatedLink), h.linkURLs(r)),
 	})
 }
 
 // RemoveTagsFromLink: DELETE /api/v1/links/{id}/tags
 func (h *LinkHandler) RemoveTagsFromLink(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
 	if uuidErr != nil {
@@ -362,7 +1153,11 @@ func (h *LinkHandler) RemoveTagsFromLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	reqBody := mw.GetRequestBodyFromContext[dto.RemoveTagsFromLink](r.Context())
+	reqBody, err := mw.GetRequestBodyFromContext[dto.RemoveTagsFromLink](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	updatedLink, err := h.LinkService.RemoveTagsFromLink(r.Context(), userID, linkID, reqBody.TagIDs)
 	if err != nil {
@@ -371,11 +1166,179 @@ func (h *LinkHandler) RemoveTagsFromLink(w http.ResponseWriter, r *http.Request)
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[db.GetLinkByIdAndUserWithTagsRow]{
-		Data: updatedLink,
+	render.JSON(w, r, &dto.SuccessResponse[dto.LinkWithTagsResponse]{
+		Data: dto.NewLinkWithTagsResponse(db.ListUserLinksRow(updatedLink), h.linkURLs(r)),
 	})
 }
 
+// CreateAccessToken: POST /api/v1/links/{id}/access-token
+// Issues a signed token that lets anyone holding it follow the private link.
+func (h *LinkHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	body, err := mw.GetRequestBodyFromContext[dto.CreateAccessToken](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(dto.DefaultAccessTokenTTL)
+	if body.ExpiresAt != nil {
+		expiresAt = *body.ExpiresAt
+	}
+
+	token, err := h.LinkService.CreateAccessToken(r.Context(), userID, linkID, expiresAt)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.AccessToken]{
+		Data: dto.AccessToken{
+			Token:     token,
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+// ListLeads: GET /api/v1/links/{id}/leads?format=json|csv
+// Lists the emails captured on an email-gated link, optionally as a CSV download.
+func (h *LinkHandler) ListLeads(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		h.logger.Warn("Invalid leads format",
+			zap.String("format", format),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid format",
+				Detail: "format must be one of: json, csv",
+			},
+		})
+		return
+	}
+
+	leads, err := h.LinkService.ListLeads(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if format == "csv" {
+		h.writeLeadsCSV(w, r, linkID, leads)
+		return
+	}
+
+	// Ensure we always return an empty array (not null) when there are no leads
+	if leads == nil {
+		leads = []db.ListLinkLeadsRow{}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ListLinkLeadsRow]{
+		Data: leads,
+	})
+}
+
+// writeLeadsCSV streams the leads as a CSV attachment
+func (h *LinkHandler) writeLeadsCSV(w http.ResponseWriter, r *http.Request, linkID uuid.UUID, leads []db.ListLinkLeadsRow) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="leads-%s.csv"`, linkID))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"email", "captured_at"})
+	for _, lead := range leads {
+		_ = cw.Write([]string{
+			service.CSVSafe(lead.Email),
+			lead.CreatedAt.Time.UTC().Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		h.logger.Warn("Failed to write leads CSV",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+	}
+}
+
+// Error codes of the link policy violations, keyed by their sentinel
+var linkPolicyErrorCodes = map[error]apperrors.ErrorCode{
+	apperrors.PolicyDestinationNotAllowed: apperrors.CodePolicyDestinationNotAllowed,
+	apperrors.PolicyShortcodeForbidden:    apperrors.CodePolicyShortcodeForbidden,
+	apperrors.PolicyRequiredTagsMissing:   apperrors.CodePolicyRequiredTagsMissing,
+	apperrors.PolicyExpiryTooLate:         apperrors.CodePolicyExpiryTooLate,
+}
+
+// linkPolicyViolation returns the link policy sentinel err wraps, nil when it isn't a policy violation
+func linkPolicyViolation(err error) error {
+	for violation := range linkPolicyErrorCodes {
+		if errors.Is(err, violation) {
+			return violation
+		}
+	}
+	return nil
+}
+
 // handleError maps errors to HTTP responses and writes them directly
 func (h *LinkHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
 	switch {
@@ -394,6 +1357,216 @@ func (h *LinkHandler) handleError(w http.ResponseWriter, r *http.Request, err er
 			},
 		})
 
+	case errors.Is(err, apperrors.LinkRetired):
+		h.logger.Warn("Link is retired",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkRetired,
+				Title:  apperrors.LinkRetired.Error(),
+				Detail: "Retired links can't be changed or deleted, only retired again",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkPreviewNotFound):
+		h.logger.Warn("Link preview not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkPreviewNotFound,
+				Title:  apperrors.LinkPreviewNotFound.Error(),
+				Detail: "The link has no preview overrides",
+			},
+		})
+
+	case errors.Is(err, apperrors.TrafficCapNotFound):
+		h.logger.Warn("Traffic cap not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTrafficCapNotFound,
+				Title:  apperrors.TrafficCapNotFound.Error(),
+				Detail: "The link has no traffic cap",
+			},
+		})
+
+	case errors.Is(err, apperrors.ResponseHeadersNotFound):
+		h.logger.Warn("Response headers not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeResponseHeadersNotFound,
+				Title:  apperrors.ResponseHeadersNotFound.Error(),
+				Detail: "The link has no extra response headers",
+			},
+		})
+
+	case errors.Is(err, apperrors.PublicStatsNotEnabled):
+		h.logger.Warn("Public stats not enabled",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodePublicStatsNotEnabled,
+				Title:  apperrors.PublicStatsNotEnabled.Error(),
+				Detail: "The link's stats page isn't public",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidResponseHeader):
+		h.logger.Warn("Invalid response header",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidResponseHeader,
+				Title:  apperrors.InvalidResponseHeader.Error(),
+				Detail: fmt.Sprintf("Up to 10 headers with non-empty values can be set, among: %s", strings.Join(service.AllowedResponseHeaders(), ", ")),
+			},
+		})
+
+	case errors.Is(err, apperrors.DynamicLinkNotFound):
+		h.logger.Warn("Dynamic link not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeDynamicLinkNotFound,
+				Title:  apperrors.DynamicLinkNotFound.Error(),
+				Detail: "The link is not dynamic",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinksNotMergeable):
+		h.logger.Warn("Links can't be merged",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinksNotMergeable,
+				Title:  apperrors.LinksNotMergeable.Error(),
+				Detail: "Only links to the same destination that aren't merged into another link yet can be merged",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkNotDynamic):
+		h.logger.Warn("Destination change on a link that isn't dynamic",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkNotDynamic,
+				Title:  apperrors.LinkNotDynamic.Error(),
+				Detail: "Make the link dynamic before changing its destination",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkDestinationLocked):
+		h.logger.Warn("Destination change on a locked link",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkDestinationLocked,
+				Title:  apperrors.LinkDestinationLocked.Error(),
+				Detail: "Unlock the link before changing its destination",
+			},
+		})
+
+	case errors.Is(err, apperrors.DestinationChangeNotFound):
+		h.logger.Warn("Destination change not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeDestinationChangeNotFound,
+				Title:  apperrors.DestinationChangeNotFound.Error(),
+				Detail: "No pending destination change with this ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.WaitingRoomNotFound):
+		h.logger.Warn("Waiting room not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeWaitingRoomNotFound,
+				Title:  apperrors.WaitingRoomNotFound.Error(),
+				Detail: "The link has no waiting room",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidWaitingRoomToken):
+		h.logger.Warn("Invalid waiting room token",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidWaitingRoomToken,
+				Title:  apperrors.InvalidWaitingRoomToken.Error(),
+				Detail: "The waiting room token is missing, invalid or its link was deleted",
+			},
+		})
+
+	case errors.Is(err, apperrors.CommentNotFound):
+		h.logger.Warn("Comment not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCommentNotFound,
+				Title:  apperrors.CommentNotFound.Error(),
+				Detail: "Unable to find a comment with the provided ID on this link",
+			},
+		})
+
 	case errors.Is(err, apperrors.InvalidURL):
 		h.logger.Warn("Invalid URL",
 			zap.Error(err),
@@ -409,6 +1582,21 @@ func (h *LinkHandler) handleError(w http.ResponseWriter, r *http.Request, err er
 			},
 		})
 
+	case errors.Is(err, apperrors.InvalidCursor):
+		h.logger.Warn("Invalid cursor",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidCursor,
+				Title:  apperrors.InvalidCursor.Error(),
+				Detail: "Use the next_cursor of a previous response, or start over with since",
+			},
+		})
+
 	case errors.Is(err, sql.ErrNoRows):
 		h.logger.Warn("Resource not found",
 			zap.Error(err),
@@ -439,6 +1627,112 @@ func (h *LinkHandler) handleError(w http.ResponseWriter, r *http.Request, err er
 			},
 		})
 
+	case errors.Is(err, apperrors.ShortcodeReserved):
+		h.logger.Warn("Shortcode is reserved",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCodeReserved,
+				Title:  apperrors.ShortcodeReserved.Error(),
+				Detail: "The provided shortcode is reserved by the system",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkQuotaExceeded):
+		h.logger.Warn("Link quota exceeded",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkQuotaExceeded,
+				Title:  apperrors.LinkQuotaExceeded.Error(),
+				Detail: "You have reached the maximum number of links for your account",
+			},
+		})
+
+	case linkPolicyViolation(err) != nil:
+		violation := linkPolicyViolation(err)
+		h.logger.Warn("Link policy violated",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   linkPolicyErrorCodes[violation],
+				Title:  violation.Error(),
+				Detail: err.Error(),
+			},
+		})
+
+	case errors.Is(err, apperrors.TagNotFound):
+		h.logger.Warn("Tag not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTagNotFound,
+				Title:  apperrors.TagNotFound.Error(),
+				Detail: "One or more of the provided tag_ids do not exist",
+			},
+		})
+
+	case errors.Is(err, apperrors.TagQuotaExceeded):
+		h.logger.Warn("Tag quota exceeded",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTagQuotaExceeded,
+				Title:  apperrors.TagQuotaExceeded.Error(),
+				Detail: "You have reached the maximum number of tags for your account",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidTagName):
+		h.logger.Warn("Invalid tag name",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidTagName,
+				Title:  apperrors.InvalidTagName.Error(),
+				Detail: err.Error(),
+			},
+		})
+
+	case errors.Is(err, apperrors.AccessTokensDisabled):
+		h.logger.Warn("Access tokens are not configured",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotImplemented)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeAccessTokensDisabled,
+				Title:  apperrors.AccessTokensDisabled.Error(),
+				Detail: "Signed access tokens are disabled on this server",
+			},
+		})
+
 	default:
 		h.logger.Error("Internal server error",
 			zap.Error(err),
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+/*
+ListLinkChanges: GET /api/v1/links/changes?since=&cursor=&limit=
+
+Polling feed for automation tools: links created, updated or deleted after
+?since= (RFC3339 or YYYY-MM-DD, defaults to the beginning), oldest first.
+Clients store cursor.next_cursor and pass it as ?cursor= on the next poll.
+*/
+func (h *LinkHandler) ListLinkChanges(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	query := r.URL.Query()
+
+	var since time.Time
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		t, err := parseStatsTime(sinceStr, time.UTC)
+		if err != nil {
+			h.logger.Warn("Invalid since query parameter",
+				zap.Error(err),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, dto.ErrorResponse{
+				Error: dto.ErrorObject{
+					Code:   apperrors.CodeInvalidRequest,
+					Title:  "Invalid since",
+					Detail: "since must be an RFC3339 timestamp or a YYYY-MM-DD date",
+				},
+			})
+			return
+		}
+		since = t
+	}
+
+	_, limit := pagination.FromQuery(query)
+
+	result, err := h.LinkService.ListLinkChanges(r.Context(), userID, since, query.Get(pagination.CursorParam), limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var pageLinks *dto.PageLinks
+	if result.HasMore {
+		links := pagination.SetNextLink(w, r, result.NextCursor, "since")
+		pageLinks = &links
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.ListLinkChangesRow]{
+		Data: result.Changes,
+		Cursor: &dto.CursorMeta{
+			NextCursor: result.NextCursor,
+			HasMore:    result.HasMore,
+		},
+		Links: pageLinks,
+	})
+}
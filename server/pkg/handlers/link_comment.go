@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+// AddComment: POST /api/v1/links/{id}/comments
+func (h *LinkHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateLinkComment](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.LinkService.AddComment(r.Context(), userID, linkID, reqBody.Body)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[db.LinkComment]{
+		Data: comment,
+	})
+}
+
+// ListComments: GET /api/v1/links/{id}/comments?page=1&limit=20
+func (h *LinkHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.LinkService.ListComments(r.Context(), userID, linkID, page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Ensure we always return an empty array (not null) when there are no comments
+	if result.Comments == nil {
+		result.Comments = []db.LinkComment{}
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]db.LinkComment]{
+		Data:       result.Comments,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
+
+// DeleteComment: DELETE /api/v1/links/{id}/comments/{commentID}
+func (h *LinkHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentID"))
+	if err != nil {
+		h.logger.Warn("Invalid comment ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "commentID")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	comment, err := h.LinkService.DeleteComment(r.Context(), userID, linkID, commentID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.LinkComment]{
+		Data: comment,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_ListComments(t *testing.T) {
+	linkID := uuid.New()
+	var gotPage, gotLimit int
+
+	mockService := &mockLinkService{
+		ListCommentsFunc: func(ctx context.Context, userID string, id uuid.UUID, page, limit int) (*service.ListCommentsResult, error) {
+			gotPage, gotLimit = page, limit
+			return &service.ListCommentsResult{Meta: pagination.Meta{Page: page, Limit: limit}}, nil
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/links/"+linkID.String()+"/comments?page=2", nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/links/{id}/comments", handler.ListComments)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	// Without ?limit= the service picks the page size
+	if gotPage != 2 || gotLimit != 0 {
+		t.Errorf("ListComments() called with page %d limit %d, want 2 and 0", gotPage, gotLimit)
+	}
+
+	var resp dto.SuccessResponse[[]db.LinkComment]
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data == nil || resp.Pagination == nil {
+		t.Errorf("response = %+v, want an empty array and pagination", resp)
+	}
+}
+
+func TestLinkHandler_DeleteComment(t *testing.T) {
+	linkID := uuid.New()
+
+	tests := []struct {
+		name           string
+		commentID      string
+		expectedStatus int
+	}{
+		{name: "invalid comment ID", commentID: "not-a-uuid", expectedStatus: http.StatusBadRequest},
+		{name: "comment not found", commentID: uuid.NewString(), expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				DeleteCommentFunc: func(ctx context.Context, userID string, id uuid.UUID, commentID uuid.UUID) (db.LinkComment, error) {
+					return db.LinkComment{}, apperrors.CommentNotFound
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/links/"+linkID.String()+"/comments/"+tt.commentID, nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Delete("/api/v1/links/{id}/comments/{commentID}", handler.DeleteComment)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+)
+
+// ListDestinationAlerts: GET /api/v1/links/destination-alerts
+func (h *LinkHandler) ListDestinationAlerts(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.LinkService.ListDestinationAlerts(r.Context(), userID, page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	base := shortURLBaseFor(h.shortURLBase, r)
+	// Always an array, never null
+	alerts := make([]dto.DestinationAlert, 0, len(result.Alerts))
+	for _, a := range result.Alerts {
+		alerts = append(alerts, dto.DestinationAlert{
+			ID:         a.ID,
+			LinkID:     a.LinkID,
+			Shortcode:  a.Shortcode,
+			ShortURL:   base + "/" + a.Shortcode,
+			Kind:       a.Kind,
+			URL:        a.Url,
+			FinalURL:   a.FinalUrl,
+			StatusCode: a.StatusCode,
+			Title:      a.Title,
+			CreatedAt:  a.CreatedAt.Time,
+		})
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.DestinationAlert]{
+		Data:       alerts,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
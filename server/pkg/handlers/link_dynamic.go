@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+func dynamicLinkResponse(d db.DynamicLink) dto.DynamicLink {
+	return dto.DynamicLink{
+		Locked:    d.Locked,
+		CreatedAt: d.CreatedAt.Time,
+		UpdatedAt: d.UpdatedAt.Time,
+	}
+}
+
+func destinationChangeResponse(c db.LinkDestinationChange) dto.DestinationChange {
+	resp := dto.DestinationChange{
+		ID:          c.ID,
+		URL:         c.Url,
+		PreviousURL: c.PreviousUrl,
+		ScheduledAt: c.ScheduledAt.Time,
+		CreatedAt:   c.CreatedAt.Time,
+	}
+	if c.AppliedAt.Valid {
+		resp.AppliedAt = &c.AppliedAt.Time
+	}
+	return resp
+}
+
+// GetDynamicLink: GET /api/v1/links/{id}/dynamic
+func (h *LinkHandler) GetDynamicLink(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	dynamic, err := h.LinkService.GetDynamicLink(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.DynamicLink]{
+		Data: dynamicLinkResponse(dynamic),
+	})
+}
+
+// SetDynamicLink: PUT /api/v1/links/{id}/dynamic
+func (h *LinkHandler) SetDynamicLink(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.SetDynamicLink](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	dynamic, err := h.LinkService.SetDynamicLink(r.Context(), userID, linkID, reqBody.Locked)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.DynamicLink]{
+		Data: dynamicLinkResponse(dynamic),
+	})
+}
+
+// DeleteDynamicLink: DELETE /api/v1/links/{id}/dynamic
+func (h *LinkHandler) DeleteDynamicLink(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	dynamic, err := h.LinkService.DeleteDynamicLink(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.DynamicLink]{
+		Data: dynamicLinkResponse(dynamic),
+	})
+}
+
+// ChangeDestination: PUT /api/v1/links/{id}/destination
+func (h *LinkHandler) ChangeDestination(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.ChangeDestination](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := h.LinkService.ChangeDestination(r.Context(), userID, linkID, reqBody.URL)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, dto.SuccessResponse[dto.LinkResponse]{
+		Data: dto.NewUpdatedLinkResponse(link, h.linkURLs(r)),
+	})
+}
+
+// ScheduleDestinationChange: POST /api/v1/links/{id}/destination-changes
+func (h *LinkHandler) ScheduleDestinationChange(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.ScheduleDestinationChange](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	change, err := h.LinkService.ScheduleDestinationChange(r.Context(), userID, linkID, reqBody.URL, reqBody.ScheduledAt)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.DestinationChange]{
+		Data: destinationChangeResponse(change),
+	})
+}
+
+// ListDestinationChanges: GET /api/v1/links/{id}/destination-changes?page=1&limit=20
+func (h *LinkHandler) ListDestinationChanges(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.LinkService.ListDestinationChanges(r.Context(), userID, linkID, page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Always an array, never null
+	changes := make([]dto.DestinationChange, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		changes = append(changes, destinationChangeResponse(c))
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.DestinationChange]{
+		Data:       changes,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
+
+// CancelDestinationChange: DELETE /api/v1/links/{id}/destination-changes/{changeID}
+func (h *LinkHandler) CancelDestinationChange(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	changeID, err := uuid.Parse(chi.URLParam(r, "changeID"))
+	if err != nil {
+		h.logger.Warn("Invalid destination change ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "changeID")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	change, err := h.LinkService.CancelDestinationChange(r.Context(), userID, linkID, changeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.DestinationChange]{
+		Data: destinationChangeResponse(change),
+	})
+}
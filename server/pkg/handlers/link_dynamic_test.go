@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_ChangeDestination(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "changes the destination", expectedStatus: http.StatusOK},
+		{name: "link isn't dynamic", serviceErr: apperrors.LinkNotDynamic, expectedStatus: http.StatusConflict},
+		{name: "link is locked", serviceErr: apperrors.LinkDestinationLocked, expectedStatus: http.StatusConflict},
+		{name: "link not found", serviceErr: apperrors.LinkNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkID := uuid.New()
+			mockService := &mockLinkService{
+				ChangeDestinationFunc: func(ctx context.Context, userID string, id uuid.UUID, destination string) (db.UpdateLinkRow, error) {
+					if tt.serviceErr != nil {
+						return db.UpdateLinkRow{}, tt.serviceErr
+					}
+					return db.UpdateLinkRow{ID: id, Shortcode: "menu", OriginalUrl: destination}, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/links/"+linkID.String()+"/destination", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, dto.ChangeDestination{URL: "https://example.com/menu-winter"})
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Put("/api/v1/links/{id}/destination", handler.ChangeDestination)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("ChangeDestination() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.serviceErr != nil {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.LinkResponse]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.OriginalURL != "https://example.com/menu-winter" {
+				t.Errorf("ChangeDestination() original_url = %q, want https://example.com/menu-winter", resp.Data.OriginalURL)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_ListDestinationChanges(t *testing.T) {
+	mockService := &mockLinkService{
+		ListDestinationChangesFunc: func(ctx context.Context, userID string, id uuid.UUID, page, limit int) (*service.ListDestinationChangesResult, error) {
+			return &service.ListDestinationChangesResult{Meta: pagination.Meta{Page: page, Limit: limit}}, nil
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/links/"+uuid.NewString()+"/destination-changes", nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/links/{id}/destination-changes", handler.ListDestinationChanges)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp dto.SuccessResponse[[]dto.DestinationChange]
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data == nil || resp.Pagination == nil {
+		t.Errorf("response = %+v, want an empty array and pagination", resp)
+	}
+}
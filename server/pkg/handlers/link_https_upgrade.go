@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+)
+
+// ListHTTPSUpgrades: GET /api/v1/links/https-upgrades
+func (h *LinkHandler) ListHTTPSUpgrades(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.LinkService.ListHTTPSUpgrades(r.Context(), userID, page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	base := shortURLBaseFor(h.shortURLBase, r)
+	// Always an array, never null
+	upgrades := make([]dto.HTTPSUpgrade, 0, len(result.Upgrades))
+	for _, u := range result.Upgrades {
+		upgrades = append(upgrades, dto.HTTPSUpgrade{
+			LinkID:    u.LinkID,
+			Shortcode: u.Shortcode,
+			ShortURL:  base + "/" + u.Shortcode,
+			FromURL:   u.FromUrl,
+			ToURL:     u.ToUrl,
+			Status:    u.Status,
+			CheckedAt: u.CheckedAt.Time,
+		})
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.HTTPSUpgrade]{
+		Data:       upgrades,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
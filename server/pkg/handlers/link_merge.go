@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+)
+
+// ListDuplicateLinks: GET /api/v1/links/duplicates
+func (h *LinkHandler) ListDuplicateLinks(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.LinkService.ListDuplicateLinks(r.Context(), userID, page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	urls := h.linkURLs(r)
+	// Always an array, never null
+	groups := make([]dto.DuplicateLinks, 0, len(result.Groups))
+	for _, g := range result.Groups {
+		groups = append(groups, dto.NewDuplicateLinks(g.Destination, g.Links, urls))
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.DuplicateLinks]{
+		Data:       groups,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
+
+// MergeLinks: POST /api/v1/links/merge
+func (h *LinkHandler) MergeLinks(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.MergeLinks](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	result, err := h.LinkService.MergeLinks(r.Context(), userID, reqBody.Into, reqBody.LinkIDs)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.MergedLinks]{
+		Data: dto.NewMergedLinks(result.Link, result.Aliases, result.ClicksMoved, h.linkURLs(r)),
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_MergeLinks(t *testing.T) {
+	into := uuid.New()
+	dup := uuid.New()
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "merged", expectedStatus: http.StatusOK},
+		{name: "different destinations", serviceErr: fmt.Errorf("%w: dup and keep have different destinations", apperrors.LinksNotMergeable), expectedStatus: http.StatusConflict},
+		{name: "missing link", serviceErr: apperrors.LinkNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				MergeLinksFunc: func(ctx context.Context, userID string, gotInto uuid.UUID, ids []uuid.UUID) (*service.MergeLinksResult, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &service.MergeLinksResult{
+						Link:        db.GetLinkByIdAndUserRow{ID: gotInto, Shortcode: "keep"},
+						Aliases:     []db.MergeLinksRow{{ID: dup, Shortcode: "dup"}},
+						ClicksMoved: 3,
+					}, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger(), shortURLBase: "https://sho.rt"}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/links/merge", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, dto.MergeLinks{Into: into, LinkIDs: []uuid.UUID{dup}})
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			handler.MergeLinks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.serviceErr != nil {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.MergedLinks]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.Link.ID != into || resp.Data.ClicksMoved != 3 {
+				t.Errorf("data = %+v, want link %s with 3 clicks moved", resp.Data, into)
+			}
+			if len(resp.Data.Aliases) != 1 || resp.Data.Aliases[0].ShortURL != "https://sho.rt/dup" {
+				t.Errorf("aliases = %+v, want https://sho.rt/dup", resp.Data.Aliases)
+			}
+		})
+	}
+}
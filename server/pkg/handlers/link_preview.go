@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// previewTemplate is the page social crawlers get for links with preview overrides.
+// It never includes the destination, which email-gated links keep hidden.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+	<head>
+		<meta property="og:type" content="website">
+		<meta property="og:url" content="{{.URL}}">
+		{{- with .Title}}
+		<title>{{.}}</title>
+		<meta property="og:title" content="{{.}}">
+		<meta name="twitter:title" content="{{.}}">
+		{{- end}}
+		{{- with .Description}}
+		<meta name="description" content="{{.}}">
+		<meta property="og:description" content="{{.}}">
+		<meta name="twitter:description" content="{{.}}">
+		{{- end}}
+		{{- with .Image}}
+		<meta property="og:image" content="{{.}}">
+		<meta name="twitter:image" content="{{.}}">
+		<meta name="twitter:card" content="summary_large_image">
+		{{- else}}
+		<meta name="twitter:card" content="summary">
+		{{- end}}
+	</head>
+	<body></body>
+</html>`))
+
+// servePreview writes the preview page if the link has preview overrides and reports whether it did.
+// Crawler fetches aren't clicks, so none is recorded.
+func (h *LinkHandler) servePreview(w http.ResponseWriter, r *http.Request, shortcode string) bool {
+	preview, found, err := h.LinkService.PreviewForRedirect(r.Context(), shortcode)
+	if err != nil {
+		// Fall back to the redirect: the crawler still gets the destination's preview
+		h.logger.Error("Failed to get link preview",
+			zap.Error(err),
+			zap.String("shortcode", shortcode),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		return false
+	}
+	if !found {
+		return false
+	}
+
+	var buf bytes.Buffer
+	if err := previewTemplate.Execute(&buf, struct {
+		URL         string
+		Title       *string
+		Description *string
+		Image       *string
+	}{
+		URL:         shortURLBaseFor(h.shortURLBase, r) + "/" + shortcode,
+		Title:       preview.Title,
+		Description: preview.Description,
+		Image:       preview.ImageUrl,
+	}); err != nil {
+		h.logger.Error("Failed to render link preview",
+			zap.Error(err),
+			zap.String("shortcode", shortcode),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		return false
+	}
+
+	render.Status(r, http.StatusOK)
+	render.HTML(w, r, buf.String())
+	return true
+}
+
+// GetPreview: GET /api/v1/links/{id}/preview
+func (h *LinkHandler) GetPreview(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.LinkService.GetPreview(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.LinkPreview]{
+		Data: preview,
+	})
+}
+
+// SetPreview: PUT /api/v1/links/{id}/preview
+func (h *LinkHandler) SetPreview(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.SetLinkPreview](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.LinkService.SetPreview(r.Context(), userID, linkID, reqBody.Title, reqBody.Description, reqBody.ImageURL)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.LinkPreview]{
+		Data: preview,
+	})
+}
+
+// DeletePreview: DELETE /api/v1/links/{id}/preview
+func (h *LinkHandler) DeletePreview(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.LinkService.DeletePreview(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[db.LinkPreview]{
+		Data: preview,
+	})
+}
+
+// parseLinkID parses the {id} URL param. When it returns false, a 400 has already been written.
+func (h *LinkHandler) parseLinkID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	linkID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return uuid.UUID{}, false
+	}
+
+	return linkID, true
+}
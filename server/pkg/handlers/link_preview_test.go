@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func TestLinkHandler_RedirectPreview(t *testing.T) {
+	title := "Spring sale <50% off>"
+	image := "https://cdn.example.com/spring.png"
+	link := db.GetLinkForRedirectRow{ID: uuid.New(), OriginalUrl: "https://example.com/sale"}
+
+	tests := []struct {
+		name           string
+		userAgent      string
+		hasPreview     bool
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "crawler gets the preview overrides",
+			userAgent:      "Mozilla/5.0 (compatible; Twitterbot/1.0)",
+			hasPreview:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`<meta property="og:title" content="Spring sale &lt;50% off&gt;">`,
+				`<meta property="og:image" content="https://cdn.example.com/spring.png">`,
+				`<meta property="og:url" content="https://sho.rt/abc123">`,
+			},
+		},
+		{
+			name:           "crawler is redirected when the link has no preview",
+			userAgent:      "facebookexternalhit/1.1",
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name:           "visitors are redirected",
+			userAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)",
+			hasPreview:     true,
+			expectedStatus: http.StatusFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return link, nil
+				},
+				PreviewForRedirectFunc: func(ctx context.Context, shortcode string) (db.LinkPreview, bool, error) {
+					if !tt.hasPreview {
+						return db.LinkPreview{}, false, nil
+					}
+					return db.LinkPreview{LinkID: link.ID, Title: &title, ImageUrl: &image}, true, nil
+				},
+			}
+			handler := &LinkHandler{
+				LinkService:  mockService,
+				shortURLBase: "https://sho.rt",
+				logger:       createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Redirect() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(w.Body.String(), s) {
+					t.Errorf("Redirect() body missing %q:\n%s", s, w.Body.String())
+				}
+			}
+			if tt.expectedStatus == http.StatusOK && strings.Contains(w.Body.String(), link.OriginalUrl) {
+				t.Error("Redirect() preview page leaks the destination")
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+// publicStatsURL is the address of a link's stats page: its short URL followed by +
+func publicStatsURL(base, shortcode string) string {
+	return base + "/" + shortcode + "+"
+}
+
+func (h *LinkHandler) publicStatsResponse(r *http.Request, settings service.PublicStatsSettings) dto.PublicStats {
+	return dto.PublicStats{
+		URL:       publicStatsURL(shortURLBaseFor(h.shortURLBase, r), settings.Shortcode),
+		EnabledAt: settings.EnabledAt,
+	}
+}
+
+// GetPublicStats: GET /api/v1/links/{id}/public-stats
+func (h *LinkHandler) GetPublicStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.LinkService.GetPublicStats(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.PublicStats]{
+		Data: h.publicStatsResponse(r, settings),
+	})
+}
+
+// EnablePublicStats: PUT /api/v1/links/{id}/public-stats
+// Anyone can then see the link's click counts at its short URL followed by +.
+func (h *LinkHandler) EnablePublicStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.LinkService.EnablePublicStats(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.PublicStats]{
+		Data: h.publicStatsResponse(r, settings),
+	})
+}
+
+// DisablePublicStats: DELETE /api/v1/links/{id}/public-stats
+func (h *LinkHandler) DisablePublicStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.LinkService.DisablePublicStats(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.PublicStats]{
+		Data: h.publicStatsResponse(r, settings),
+	})
+}
+
+// CreatePublicStatsToken: POST /api/v1/links/{id}/public-stats/token
+// Issues an unguessable URL of the link's stats page for private sharing, valid whether the page is public or not.
+func (h *LinkHandler) CreatePublicStatsToken(w http.ResponseWriter, r *http.Request) {
+	body, err := mw.GetRequestBodyFromContext[dto.CreateAccessToken](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	expiresAt := time.Now().Add(dto.DefaultAccessTokenTTL)
+	if body.ExpiresAt != nil {
+		expiresAt = *body.ExpiresAt
+	}
+
+	token, err := h.LinkService.CreatePublicStatsToken(r.Context(), userID, linkID, expiresAt)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.PublicStatsToken]{
+		Data: dto.PublicStatsToken{
+			Token:     token.Token,
+			URL:       publicStatsURL(shortURLBaseFor(h.shortURLBase, r), token.Shortcode) + "?token=" + url.QueryEscape(token.Token),
+			ExpiresAt: token.ExpiresAt,
+		},
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_GetPublicStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "public", expectedStatus: http.StatusOK},
+		{name: "not public", serviceErr: fmt.Errorf("%w: no rows", apperrors.PublicStatsNotEnabled), expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetPublicStatsFunc: func(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error) {
+					if tt.serviceErr != nil {
+						return service.PublicStatsSettings{}, tt.serviceErr
+					}
+					return service.PublicStatsSettings{Shortcode: "docs", EnabledAt: time.Now()}, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger(), shortURLBase: "https://sho.rt"}
+
+			req := newPublicStatsRequest(http.MethodGet, "/api/v1/links/x/public-stats", nil)
+			w := httptest.NewRecorder()
+
+			handler.GetPublicStats(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.serviceErr != nil {
+				var resp dto.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Error.Code != apperrors.CodePublicStatsNotEnabled {
+					t.Errorf("code = %q, want %q", resp.Error.Code, apperrors.CodePublicStatsNotEnabled)
+				}
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.PublicStats]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.URL != "https://sho.rt/docs+" {
+				t.Errorf("url = %q, want https://sho.rt/docs+", resp.Data.URL)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_CreatePublicStatsToken(t *testing.T) {
+	mockService := &mockLinkService{
+		CreatePublicStatsTokenFunc: func(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (service.PublicStatsToken, error) {
+			return service.PublicStatsToken{Shortcode: "docs", Token: "1700000000.c2ln", ExpiresAt: expiresAt}, nil
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger(), shortURLBase: "https://sho.rt"}
+
+	req := newPublicStatsRequest(http.MethodPost, "/api/v1/links/x/public-stats/token", dto.CreateAccessToken{})
+	w := httptest.NewRecorder()
+
+	handler.CreatePublicStatsToken(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	var resp dto.SuccessResponse[dto.PublicStatsToken]
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data.URL != "https://sho.rt/docs+?token=1700000000.c2ln" {
+		t.Errorf("url = %q, want the stats page with the token", resp.Data.URL)
+	}
+	if time.Until(resp.Data.ExpiresAt) < dto.DefaultAccessTokenTTL-time.Minute {
+		t.Errorf("expires_at = %v, want the default lifetime", resp.Data.ExpiresAt)
+	}
+}
+
+func newPublicStatsRequest(method, target string, body any) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", uuid.NewString())
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = middleware.WithUserID(ctx, "user_123")
+	if body != nil {
+		ctx = middleware.WithRequestBody(ctx, body)
+	}
+	return req.WithContext(ctx)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// QuickShorten: POST /api/v1/quick-shorten
+// Shortens a URL with default settings for the browser extension, reusing the user's existing link when there is one.
+func (h *LinkHandler) QuickShorten(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.QuickShorten](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	link, deduplicated, err := h.LinkService.QuickShorten(r.Context(), userID, reqBody.URL, reqBody.Title)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.logger.Info("Quick shorten",
+		zap.String("user_id", userID),
+		zap.String("shortcode", link.Shortcode),
+		zap.Bool("deduplicated", deduplicated),
+	)
+
+	status := http.StatusCreated
+	if deduplicated {
+		status = http.StatusOK
+	}
+
+	render.Status(r, status)
+	render.JSON(w, r, &dto.SuccessResponse[dto.QuickShortenResult]{
+		Data: dto.QuickShortenResult{
+			ID:           link.ID,
+			Shortcode:    link.Shortcode,
+			ShortURL:     shortURLBaseFor(h.shortURLBase, r) + "/" + link.Shortcode,
+			OriginalURL:  link.OriginalUrl,
+			Title:        link.Title,
+			Deduplicated: deduplicated,
+		},
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func responseHeadersResponse(h db.LinkResponseHeader) dto.ResponseHeaders {
+	return dto.ResponseHeaders{
+		Headers:   service.DecodeResponseHeaders(h.Headers),
+		UpdatedAt: h.UpdatedAt.Time,
+	}
+}
+
+// setResponseHeaders adds the link's extra headers to the response.
+// Pages that set their own (e.g. Cache-Control: no-store) override them.
+func setResponseHeaders(w http.ResponseWriter, link db.GetLinkForRedirectRow) {
+	for name, value := range service.DecodeResponseHeaders(link.ResponseHeaders) {
+		w.Header().Set(name, value)
+	}
+}
+
+// GetResponseHeaders: GET /api/v1/links/{id}/headers
+func (h *LinkHandler) GetResponseHeaders(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	headers, err := h.LinkService.GetResponseHeaders(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ResponseHeaders]{
+		Data: responseHeadersResponse(headers),
+	})
+}
+
+// SetResponseHeaders: PUT /api/v1/links/{id}/headers
+func (h *LinkHandler) SetResponseHeaders(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.SetResponseHeaders](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	headers, err := h.LinkService.SetResponseHeaders(r.Context(), userID, linkID, reqBody.Headers)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ResponseHeaders]{
+		Data: responseHeadersResponse(headers),
+	})
+}
+
+// DeleteResponseHeaders: DELETE /api/v1/links/{id}/headers
+func (h *LinkHandler) DeleteResponseHeaders(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	headers, err := h.LinkService.DeleteResponseHeaders(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ResponseHeaders]{
+		Data: responseHeadersResponse(headers),
+	})
+}
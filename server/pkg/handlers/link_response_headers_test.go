@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_RedirectResponseHeaders(t *testing.T) {
+	mockService := &mockLinkService{
+		GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+			return db.GetLinkForRedirectRow{
+				ID:              uuid.New(),
+				OriginalUrl:     "https://example.com/docs",
+				Visibility:      service.LinkVisibilityPublic,
+				ResponseHeaders: []byte(`{"X-Robots-Tag":"noindex","Cache-Control":"public, max-age=300"}`),
+			}, nil
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/{shortcode}", handler.Redirect)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+	if got := w.Header().Get("X-Robots-Tag"); got != "noindex" {
+		t.Errorf("X-Robots-Tag = %q, want noindex", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q, want public, max-age=300", got)
+	}
+}
+
+func TestLinkHandler_SetResponseHeadersInvalid(t *testing.T) {
+	mockService := &mockLinkService{
+		SetResponseHeadersFunc: func(ctx context.Context, userID string, linkID uuid.UUID, headers map[string]string) (db.LinkResponseHeader, error) {
+			return db.LinkResponseHeader{}, apperrors.InvalidResponseHeader
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/links/"+uuid.NewString()+"/headers", nil)
+	ctx := middleware.WithUserID(req.Context(), "user_123")
+	ctx = middleware.WithRequestBody(ctx, dto.SetResponseHeaders{Headers: map[string]string{"Set-Cookie": "session=1"}})
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Put("/api/v1/links/{id}/headers", handler.SetResponseHeaders)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
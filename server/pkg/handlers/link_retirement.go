@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// sunsetTemplate is the page retired links serve instead of the destination
+var sunsetTemplate = template.Must(template.New("sunset").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head><title>{{.Title}}</title></head>
+	<body>
+		<h1>{{.Heading}}</h1>
+		<p>{{.Message}}</p>{{with .URL}}
+		<p><a href="{{.}}">{{$.Alternative}}</a></p>{{end}}
+	</body>
+</html>`))
+
+// renderSunset writes the sunset page of a retired link: its own message, or the default one,
+// and a link to the alternative URL when it has one. No click is recorded.
+func (h *LinkHandler) renderSunset(w http.ResponseWriter, r *http.Request, link db.GetLinkForRedirectRow) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	message := i18n.T(lang, "retired.message")
+	if link.SunsetMessage != nil && *link.SunsetMessage != "" {
+		message = *link.SunsetMessage
+	}
+	var alternative string
+	if link.SunsetUrl != nil {
+		alternative = *link.SunsetUrl
+	}
+
+	var buf bytes.Buffer
+	if err := sunsetTemplate.Execute(&buf, map[string]string{
+		"Lang":        lang,
+		"Title":       i18n.T(lang, "retired.title"),
+		"Heading":     i18n.T(lang, "retired.heading"),
+		"Message":     message,
+		"URL":         alternative,
+		"Alternative": i18n.T(lang, "retired.alternative"),
+	}); err != nil {
+		h.logger.Error("Failed to render sunset page",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+		return
+	}
+
+	render.Status(r, http.StatusGone)
+	render.HTML(w, r, buf.String())
+}
+
+// RetireLink: POST /api/v1/links/{id}/retire
+func (h *LinkHandler) RetireLink(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.RetireLink](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := h.LinkService.RetireLink(r.Context(), userID, linkID, reqBody.SunsetMessage, reqBody.SunsetURL)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.LinkResponse]{
+		Data: dto.NewUpdatedLinkResponse(db.UpdateLinkRow(link), h.linkURLs(r)),
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_RedirectRetired(t *testing.T) {
+	message := "This promotion has ended"
+	alternative := "https://example.com/summer"
+
+	tests := []struct {
+		name          string
+		sunsetMessage *string
+		sunsetURL     *string
+		expectedBody  []string
+	}{
+		{
+			name:         "default sunset page",
+			expectedBody: []string{"This link is no longer in use."},
+		},
+		{
+			name:          "custom message and alternative",
+			sunsetMessage: &message,
+			sunsetURL:     &alternative,
+			expectedBody:  []string{message, `<a href="https://example.com/summer">`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{
+						ID:            uuid.New(),
+						OriginalUrl:   "https://example.com/spring-sale",
+						Visibility:    service.LinkVisibilityPublic,
+						RetiredAt:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+						SunsetMessage: tt.sunsetMessage,
+						SunsetUrl:     tt.sunsetURL,
+					}, nil
+				},
+			}
+			clicks := &mockClickRecorder{clicks: make(chan service.Click, 1)}
+			handler := &LinkHandler{
+				LinkService: mockService,
+				clicks:      clicks,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusGone {
+				t.Fatalf("Redirect() status = %d, want %d", w.Code, http.StatusGone)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("Redirect() body missing %q:\n%s", s, body)
+				}
+			}
+			if strings.Contains(body, "spring-sale") {
+				t.Error("Redirect() sunset page leaks the destination")
+			}
+
+			select {
+			case <-clicks.clicks:
+				t.Error("click recorded for a retired link")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestLinkHandler_RetireLink(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "retires the link", expectedStatus: http.StatusOK},
+		{name: "link not found", serviceErr: apperrors.LinkNotFound, expectedStatus: http.StatusNotFound},
+		{name: "invalid alternative URL", serviceErr: apperrors.InvalidURL, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkID := uuid.New()
+			alternative := "https://example.com/summer"
+
+			mockService := &mockLinkService{
+				RetireLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, message *string, alternativeURL *string) (db.RetireLinkRow, error) {
+					if tt.serviceErr != nil {
+						return db.RetireLinkRow{}, tt.serviceErr
+					}
+					if id != linkID || alternativeURL == nil || *alternativeURL != alternative {
+						t.Errorf("RetireLink() called with id %v, alternative %v", id, alternativeURL)
+					}
+					return db.RetireLinkRow{ID: id, SunsetUrl: alternativeURL}, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/links/"+linkID.String()+"/retire", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, dto.RetireLink{SunsetURL: &alternative})
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/links/{id}/retire", handler.RetireLink)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("RetireLink() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_DeleteRetiredLink(t *testing.T) {
+	mockService := &mockLinkService{
+		DeleteLinkFunc: func(ctx context.Context, userID string, id uuid.UUID) (db.DeleteLinkRow, error) {
+			return db.DeleteLinkRow{}, apperrors.LinkRetired
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/links/"+uuid.NewString(), nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Delete("/api/v1/links/{id}", handler.DeleteLink)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("DeleteLink() status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
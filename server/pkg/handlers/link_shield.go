@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+/*
+challengeTemplate is the bot shield's challenge page. The script looks for a
+nonce whose "<challenge>.<nonce>" hashes to the required number of leading
+zeros, stores the solution in the shield cookie and loads the link again as a
+GET. Web Crypto needs a secure context, so shielded links must be served over
+HTTPS (or from localhost).
+*/
+var challengeTemplate = template.Must(template.New("challenge").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head>
+		<title>{{.Title}}</title>
+		<meta name="robots" content="noindex">
+	</head>
+	<body>
+		<h1>{{.Heading}}</h1>
+		<p>{{.Message}}</p>
+		<noscript><p>{{.NoScript}}</p></noscript>
+		<script>
+			(async function () {
+				var challenge = {{.Challenge}};
+				var prefix = "0".repeat({{.Difficulty}});
+				var encoder = new TextEncoder();
+				for (var nonce = 0; ; nonce++) {
+					var digest = await crypto.subtle.digest("SHA-256", encoder.encode(challenge + "." + nonce));
+					var hex = Array.from(new Uint8Array(digest), function (b) {
+						return b.toString(16).padStart(2, "0");
+					}).join("");
+					if (hex.startsWith(prefix)) {
+						document.cookie = {{.Cookie}} + "=" + challenge + "." + nonce + "; path=/; max-age=" + {{.MaxAge}} +
+							"; samesite=lax" + (location.protocol === "https:" ? "; secure" : "");
+						location.replace(location.href);
+						return;
+					}
+				}
+			})();
+		</script>
+	</body>
+</html>`))
+
+// challengeBot applies the bot shield to a request for a shielded link.
+// When it returns true, the challenge page has been written instead of the redirect.
+func (h *LinkHandler) challengeBot(w http.ResponseWriter, r *http.Request, shortcode string) bool {
+	if !h.shield.Enabled() {
+		return false
+	}
+
+	now := time.Now()
+	addr := h.shield.ClientIP(r)
+
+	// Checked first so that a solved challenge doesn't lift the rate limit
+	reason := ""
+	if h.shield.OverRate(r.Context(), addr) {
+		reason = service.ShieldReasonRate
+	} else {
+		if cookie, err := r.Cookie(service.ShieldCookie); err == nil && h.shield.VerifyPass(cookie.Value, addr, now) {
+			metrics.ShieldPassed.Add(1)
+			return false
+		}
+		reason = h.shield.Suspect(addr, r.UserAgent())
+	}
+	if reason == "" {
+		return false
+	}
+
+	metrics.ShieldChallenged.Add(reason, 1)
+	h.logger.Info("Suspected bot challenged",
+		zap.String("shortcode", shortcode),
+		zap.String("reason", reason),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+
+	h.renderChallenge(w, r, h.shield.Challenge(addr, now))
+	return true
+}
+
+// renderChallenge writes the challenge page with a 403, so crawlers don't index it
+func (h *LinkHandler) renderChallenge(w http.ResponseWriter, r *http.Request, challenge string) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	var buf bytes.Buffer
+	if err := challengeTemplate.Execute(&buf, struct {
+		Lang       string
+		Title      string
+		Heading    string
+		Message    string
+		NoScript   string
+		Challenge  string
+		Difficulty int
+		Cookie     string
+		MaxAge     int
+	}{
+		Lang:       lang,
+		Title:      i18n.T(lang, "shield.title"),
+		Heading:    i18n.T(lang, "shield.heading"),
+		Message:    i18n.T(lang, "shield.message"),
+		NoScript:   i18n.T(lang, "shield.noscript"),
+		Challenge:  challenge,
+		Difficulty: h.shield.Difficulty(),
+		Cookie:     service.ShieldCookie,
+		MaxAge:     int(h.shield.PassTTL().Seconds()),
+	}); err != nil {
+		h.logger.Error("Failed to render bot challenge",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.Status(r, http.StatusForbidden)
+	render.HTML(w, r, buf.String())
+}
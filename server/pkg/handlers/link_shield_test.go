@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_RedirectShield(t *testing.T) {
+	browser := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15"
+	shield := service.NewBotShield(nil, service.BotShieldOptions{
+		Secret:  "0123456789abcdef0123456789abcdef",
+		PassTTL: 30 * time.Minute,
+	}, createTestLogger())
+
+	// httptest requests come from 192.0.2.1
+	challenge := shield.Challenge(shield.ClientIP(httptest.NewRequest(http.MethodGet, "/", nil)), time.Now())
+	var pass string
+	for nonce := 0; ; nonce++ {
+		pass = challenge + "." + strconv.Itoa(nonce)
+		if shield.VerifyPass(pass, shield.ClientIP(httptest.NewRequest(http.MethodGet, "/", nil)), time.Now()) {
+			break
+		}
+	}
+
+	tests := []struct {
+		name           string
+		shielded       bool
+		shield         *service.BotShield
+		userAgent      string
+		cookie         string
+		expectedStatus int
+	}{
+		{name: "bot on a shielded link is challenged", shielded: true, shield: shield, userAgent: "curl/8.5.0", expectedStatus: http.StatusForbidden},
+		{name: "browser on a shielded link", shielded: true, shield: shield, userAgent: browser, expectedStatus: http.StatusFound},
+		{name: "solved challenge", shielded: true, shield: shield, userAgent: "curl/8.5.0", cookie: pass, expectedStatus: http.StatusFound},
+		{name: "bot on an unshielded link", shield: shield, userAgent: "curl/8.5.0", expectedStatus: http.StatusFound},
+		{name: "shield not configured", shielded: true, userAgent: "curl/8.5.0", expectedStatus: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{ID: uuid.New(), OriginalUrl: "https://example.com/sale", Shield: tt.shielded}, nil
+				},
+			}
+			handler := &LinkHandler{
+				LinkService: mockService,
+				shield:      tt.shield,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: service.ShieldCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Redirect() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code == http.StatusForbidden {
+				if strings.Contains(w.Body.String(), "example.com/sale") {
+					t.Error("Redirect() challenge page leaks the destination")
+				}
+				if !strings.Contains(w.Body.String(), "crypto.subtle.digest") {
+					t.Errorf("Redirect() body is not the challenge page:\n%s", w.Body.String())
+				}
+			}
+		})
+	}
+}
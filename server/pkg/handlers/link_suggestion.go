@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// shortcodeSuggestion is a link listed on a 404 page under "did you mean"
+type shortcodeSuggestion struct {
+	URL string
+	// Host of the destination, empty for email-gated links
+	Destination string
+}
+
+// renderNotFound writes the 404 page for a shortcode, with the links the visitor may have meant
+func (h *LinkHandler) renderNotFound(w http.ResponseWriter, r *http.Request, shortcode string) {
+	suggestions := h.suggestShortcodes(r, shortcode)
+	if len(suggestions) > 0 {
+		metrics.SuggestionsShown.Add(1)
+		// Suggestions depend on who's asking
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+
+	h.writeStatusPage(w, r, http.StatusNotFound, "not_found", suggestions)
+}
+
+// suggestShortcodes returns the live links one typo away from shortcode. Only shortcodes that
+// were typed in get suggestions: clicked links come with a referrer and their typos aren't the
+// visitor's. A failed lookup is logged and the 404 page shows none.
+func (h *LinkHandler) suggestShortcodes(r *http.Request, shortcode string) []shortcodeSuggestion {
+	if !h.suggester.Enabled() || r.Method != http.MethodGet || r.Referer() != "" {
+		return nil
+	}
+
+	viewerID, _ := mw.GetOptionalUserIDFromContext(r.Context())
+
+	links, err := h.suggester.Suggest(r.Context(), h.suggester.ClientIP(r), shortcode, viewerID)
+	if err != nil {
+		h.logger.Error("Failed to suggest shortcodes",
+			zap.Error(err),
+			zap.String("shortcode", shortcode),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		return nil
+	}
+
+	base := shortURLBaseFor(h.shortURLBase, r)
+	suggestions := make([]shortcodeSuggestion, 0, len(links))
+	for _, link := range links {
+		suggestion := shortcodeSuggestion{URL: base + "/" + link.Shortcode}
+		if u, err := url.Parse(link.OriginalUrl); err == nil && !link.CaptureEmail {
+			suggestion.Destination = u.Host
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_RedirectNotFoundSuggestions(t *testing.T) {
+	tests := []struct {
+		name            string
+		referer         string
+		enabled         bool
+		wantSuggestions bool
+	}{
+		{name: "typed-in shortcode", enabled: true, wantSuggestions: true},
+		{name: "clicked link", referer: "https://example.org/post", enabled: true},
+		{name: "suggestions disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			queries := &mocks.ShortcodeSuggestionQueries{
+				ListShortcodeSuggestionsFunc: func(ctx context.Context, arg db.ListShortcodeSuggestionsParams) ([]db.ListShortcodeSuggestionsRow, error) {
+					return []db.ListShortcodeSuggestionsRow{
+						{Shortcode: "docs", OriginalUrl: "https://example.com/docs"},
+						{Shortcode: "dosx", OriginalUrl: "https://secret.example.com/offer", CaptureEmail: true},
+					}, nil
+				},
+			}
+			suggester := service.NewShortcodeSuggester(queries, redis.NewClient(&redis.Options{Addr: mr.Addr()}), service.ShortcodeSuggesterOptions{
+				Enabled:       tt.enabled,
+				IncludePublic: true,
+			}, createTestLogger())
+
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{}, apperrors.LinkNotFound
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, suggester: suggester, shortURLBase: "https://sho.rt", logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodGet, "/doc", nil)
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+
+			body := w.Body.String()
+			if got := strings.Contains(body, "https://sho.rt/docs"); got != tt.wantSuggestions {
+				t.Errorf("page suggests https://sho.rt/docs = %v, want %v", got, tt.wantSuggestions)
+			}
+			if !tt.wantSuggestions {
+				return
+			}
+			if !strings.Contains(body, "example.com") {
+				t.Error("page is missing the destination of the suggestion")
+			}
+			if strings.Contains(body, "secret.example.com") {
+				t.Error("page shows the destination of an email-gated link")
+			}
+			if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+				t.Errorf("Cache-Control = %q, want private, no-store", got)
+			}
+		})
+	}
+}
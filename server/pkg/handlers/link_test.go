@@ -7,35 +7,84 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
 	"github.com/styltsou/url-shortener/server/pkg/db"
 	"github.com/styltsou/url-shortener/server/pkg/dto"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
 	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
 	"github.com/styltsou/url-shortener/server/pkg/service"
 )
 
 // mockLinkService is a mock implementation of LinkServiceInterface
 type mockLinkService struct {
-	CreateShortLinkFunc    func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time) (db.TryCreateLinkRow, error)
-	ListAllLinksFunc       func(ctx context.Context, userID string, isActive *bool, tagIDs []uuid.UUID, page, limit int) (*service.ListLinksResult, error)
-	GetLinkByShortcodeFunc func(ctx context.Context, userID string, shortcode string) (db.GetLinkByShortcodeAndUserRow, error)
-	GetOriginalURLFunc     func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error)
-	UpdateLinkFunc         func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error)
-	DeleteLinkFunc         func(ctx context.Context, userID string, id uuid.UUID) (db.DeleteLinkRow, error)
-	AddTagsToLinkFunc      func(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
-	RemoveTagsFromLinkFunc func(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
+	CreateShortLinkFunc             func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error)
+	ListAllLinksFunc                func(ctx context.Context, userID string, isActive *bool, tagIDs []uuid.UUID, page, limit int) (*service.ListLinksResult, error)
+	GetLinksByIDsFunc               func(ctx context.Context, userID string, ids []uuid.UUID) ([]db.ListUserLinksByIDsRow, error)
+	GetLinkByShortcodeFunc          func(ctx context.Context, userID string, shortcode string) (db.GetLinkByShortcodeAndUserRow, error)
+	GetOriginalURLFunc              func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error)
+	UpdateLinkFunc                  func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error)
+	DeleteLinkFunc                  func(ctx context.Context, userID string, id uuid.UUID) (db.DeleteLinkRow, error)
+	RetireLinkFunc                  func(ctx context.Context, userID string, id uuid.UUID, message *string, alternativeURL *string) (db.RetireLinkRow, error)
+	AddTagsToLinkFunc               func(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
+	RemoveTagsFromLinkFunc          func(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
+	CanAccessPrivateLinkFunc        func(link db.GetLinkForRedirectRow, viewerID string, token string) bool
+	CreateAccessTokenFunc           func(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (string, error)
+	CaptureLeadFunc                 func(ctx context.Context, linkID uuid.UUID, email string) error
+	ListLeadsFunc                   func(ctx context.Context, userID string, linkID uuid.UUID) ([]db.ListLinkLeadsRow, error)
+	QRCodesFunc                     func(ctx context.Context, userID string, ids []uuid.UUID, baseURL string) ([]service.QRCode, error)
+	ListLinkChangesFunc             func(ctx context.Context, userID string, since time.Time, cursor string, limit int) (*service.LinkChangesResult, error)
+	QuickShortenFunc                func(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error)
+	SetPreviewFunc                  func(ctx context.Context, userID string, linkID uuid.UUID, title *string, description *string, imageURL *string) (db.LinkPreview, error)
+	GetPreviewFunc                  func(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error)
+	DeletePreviewFunc               func(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error)
+	PreviewForRedirectFunc          func(ctx context.Context, shortcode string) (db.LinkPreview, bool, error)
+	SetTrafficCapFunc               func(ctx context.Context, userID string, linkID uuid.UUID, dailyCap *int32, totalCap *int32, overflowURL string) (db.LinkTrafficCap, error)
+	GetTrafficCapFunc               func(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error)
+	DeleteTrafficCapFunc            func(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error)
+	TakeTrafficCapFunc              func(ctx context.Context, link db.GetLinkForRedirectRow, now time.Time) bool
+	SetWaitingRoomFunc              func(ctx context.Context, userID string, linkID uuid.UUID, active bool, message *string, retryAfter int32) (db.UpsertLinkWaitingRoomRow, string, error)
+	GetWaitingRoomFunc              func(ctx context.Context, userID string, linkID uuid.UUID) (db.GetLinkWaitingRoomRow, error)
+	DeleteWaitingRoomFunc           func(ctx context.Context, userID string, linkID uuid.UUID) (db.DeleteLinkWaitingRoomRow, error)
+	SetWaitingRoomActiveByTokenFunc func(ctx context.Context, token string, active bool) (db.SetLinkWaitingRoomActiveByTokenRow, error)
+	AddCommentFunc                  func(ctx context.Context, userID string, linkID uuid.UUID, body string) (db.LinkComment, error)
+	ListCommentsFunc                func(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*service.ListCommentsResult, error)
+	DeleteCommentFunc               func(ctx context.Context, userID string, linkID uuid.UUID, commentID uuid.UUID) (db.LinkComment, error)
+	SetDynamicLinkFunc              func(ctx context.Context, userID string, linkID uuid.UUID, locked bool) (db.DynamicLink, error)
+	GetDynamicLinkFunc              func(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error)
+	DeleteDynamicLinkFunc           func(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error)
+	ChangeDestinationFunc           func(ctx context.Context, userID string, linkID uuid.UUID, destination string) (db.UpdateLinkRow, error)
+	ScheduleDestinationChangeFunc   func(ctx context.Context, userID string, linkID uuid.UUID, destination string, at time.Time) (db.LinkDestinationChange, error)
+	ListDestinationChangesFunc      func(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*service.ListDestinationChangesResult, error)
+	CancelDestinationChangeFunc     func(ctx context.Context, userID string, linkID uuid.UUID, changeID uuid.UUID) (db.LinkDestinationChange, error)
+	SenderVerificationFunc          func(ctx context.Context, shortcode string, destination string) (db.VerifiedSender, bool, error)
+	SetResponseHeadersFunc          func(ctx context.Context, userID string, linkID uuid.UUID, headers map[string]string) (db.LinkResponseHeader, error)
+	GetResponseHeadersFunc          func(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error)
+	DeleteResponseHeadersFunc       func(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error)
+	EnablePublicStatsFunc           func(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error)
+	GetPublicStatsFunc              func(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error)
+	DisablePublicStatsFunc          func(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error)
+	CreatePublicStatsTokenFunc      func(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (service.PublicStatsToken, error)
+	ListDuplicateLinksFunc          func(ctx context.Context, userID string, page, limit int) (*service.ListDuplicateLinksResult, error)
+	MergeLinksFunc                  func(ctx context.Context, userID string, into uuid.UUID, ids []uuid.UUID) (*service.MergeLinksResult, error)
+	UpgradeToHTTPSFunc              func(ctx context.Context, destination string) string
+	ListHTTPSUpgradesFunc           func(ctx context.Context, userID string, page, limit int) (*service.ListHTTPSUpgradesResult, error)
+	ListDestinationAlertsFunc       func(ctx context.Context, userID string, page, limit int) (*service.ListDestinationAlertsResult, error)
 }
 
-func (m *mockLinkService) CreateShortLink(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time) (db.TryCreateLinkRow, error) {
+func (m *mockLinkService) CreateShortLink(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error) {
 	if m.CreateShortLinkFunc != nil {
-		return m.CreateShortLinkFunc(ctx, userID, originalURL, customShortcode, expiresAt)
+		return m.CreateShortLinkFunc(ctx, userID, originalURL, customShortcode, expiresAt, visibility, captureEmail, redirectDelay, interstitialMessage, appendClickID, title, shield, referrerPolicy, tagIDs, tagNames)
 	}
 	return db.TryCreateLinkRow{}, errors.New("not implemented")
 }
@@ -47,6 +96,13 @@ func (m *mockLinkService) ListAllLinks(ctx context.Context, userID string, isAct
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockLinkService) GetLinksByIDs(ctx context.Context, userID string, ids []uuid.UUID) ([]db.ListUserLinksByIDsRow, error) {
+	if m.GetLinksByIDsFunc != nil {
+		return m.GetLinksByIDsFunc(ctx, userID, ids)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockLinkService) GetLinkByShortcode(ctx context.Context, userID string, shortcode string) (db.GetLinkByShortcodeAndUserRow, error) {
 	if m.GetLinkByShortcodeFunc != nil {
 		return m.GetLinkByShortcodeFunc(ctx, userID, shortcode)
@@ -61,9 +117,9 @@ func (m *mockLinkService) GetOriginalURL(ctx context.Context, code string) (db.G
 	return db.GetLinkForRedirectRow{}, errors.New("not implemented")
 }
 
-func (m *mockLinkService) UpdateLink(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error) {
+func (m *mockLinkService) UpdateLink(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error) {
 	if m.UpdateLinkFunc != nil {
-		return m.UpdateLinkFunc(ctx, userID, id, shortcode, isActive, expiresAt)
+		return m.UpdateLinkFunc(ctx, userID, id, shortcode, isActive, expiresAt, visibility, captureEmail, redirectDelay, interstitialMessage, appendClickID, shield, referrerPolicy)
 	}
 	return db.UpdateLinkRow{}, errors.New("not implemented")
 }
@@ -75,6 +131,13 @@ func (m *mockLinkService) DeleteLink(ctx context.Context, userID string, id uuid
 	return db.DeleteLinkRow{}, errors.New("not implemented")
 }
 
+func (m *mockLinkService) RetireLink(ctx context.Context, userID string, id uuid.UUID, message *string, alternativeURL *string) (db.RetireLinkRow, error) {
+	if m.RetireLinkFunc != nil {
+		return m.RetireLinkFunc(ctx, userID, id, message, alternativeURL)
+	}
+	return db.RetireLinkRow{}, errors.New("not implemented")
+}
+
 func (m *mockLinkService) AddTagsToLink(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error) {
 	if m.AddTagsToLinkFunc != nil {
 		return m.AddTagsToLinkFunc(ctx, userID, linkID, tagIDs)
@@ -89,6 +152,300 @@ func (m *mockLinkService) RemoveTagsFromLink(ctx context.Context, userID string,
 	return db.GetLinkByIdAndUserWithTagsRow{}, errors.New("not implemented")
 }
 
+func (m *mockLinkService) CanAccessPrivateLink(link db.GetLinkForRedirectRow, viewerID string, token string) bool {
+	if m.CanAccessPrivateLinkFunc != nil {
+		return m.CanAccessPrivateLinkFunc(link, viewerID, token)
+	}
+	return false
+}
+
+func (m *mockLinkService) CreateAccessToken(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (string, error) {
+	if m.CreateAccessTokenFunc != nil {
+		return m.CreateAccessTokenFunc(ctx, userID, linkID, expiresAt)
+	}
+	return "", errors.New("not implemented")
+}
+
+func (m *mockLinkService) CaptureLead(ctx context.Context, linkID uuid.UUID, email string) error {
+	if m.CaptureLeadFunc != nil {
+		return m.CaptureLeadFunc(ctx, linkID, email)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockLinkService) ListLeads(ctx context.Context, userID string, linkID uuid.UUID) ([]db.ListLinkLeadsRow, error) {
+	if m.ListLeadsFunc != nil {
+		return m.ListLeadsFunc(ctx, userID, linkID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) QRCodes(ctx context.Context, userID string, ids []uuid.UUID, baseURL string) ([]service.QRCode, error) {
+	if m.QRCodesFunc != nil {
+		return m.QRCodesFunc(ctx, userID, ids, baseURL)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ListLinkChanges(ctx context.Context, userID string, since time.Time, cursor string, limit int) (*service.LinkChangesResult, error) {
+	if m.ListLinkChangesFunc != nil {
+		return m.ListLinkChangesFunc(ctx, userID, since, cursor, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error) {
+	if m.QuickShortenFunc != nil {
+		return m.QuickShortenFunc(ctx, userID, originalURL, title)
+	}
+	return db.TryCreateLinkRow{}, false, errors.New("not implemented")
+}
+
+func (m *mockLinkService) SetPreview(ctx context.Context, userID string, linkID uuid.UUID, title *string, description *string, imageURL *string) (db.LinkPreview, error) {
+	if m.SetPreviewFunc != nil {
+		return m.SetPreviewFunc(ctx, userID, linkID, title, description, imageURL)
+	}
+	return db.LinkPreview{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) GetPreview(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error) {
+	if m.GetPreviewFunc != nil {
+		return m.GetPreviewFunc(ctx, userID, linkID)
+	}
+	return db.LinkPreview{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DeletePreview(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error) {
+	if m.DeletePreviewFunc != nil {
+		return m.DeletePreviewFunc(ctx, userID, linkID)
+	}
+	return db.LinkPreview{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) PreviewForRedirect(ctx context.Context, shortcode string) (db.LinkPreview, bool, error) {
+	if m.PreviewForRedirectFunc != nil {
+		return m.PreviewForRedirectFunc(ctx, shortcode)
+	}
+	return db.LinkPreview{}, false, errors.New("not implemented")
+}
+
+func (m *mockLinkService) SetTrafficCap(ctx context.Context, userID string, linkID uuid.UUID, dailyCap *int32, totalCap *int32, overflowURL string) (db.LinkTrafficCap, error) {
+	if m.SetTrafficCapFunc != nil {
+		return m.SetTrafficCapFunc(ctx, userID, linkID, dailyCap, totalCap, overflowURL)
+	}
+	return db.LinkTrafficCap{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) GetTrafficCap(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error) {
+	if m.GetTrafficCapFunc != nil {
+		return m.GetTrafficCapFunc(ctx, userID, linkID)
+	}
+	return db.LinkTrafficCap{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DeleteTrafficCap(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error) {
+	if m.DeleteTrafficCapFunc != nil {
+		return m.DeleteTrafficCapFunc(ctx, userID, linkID)
+	}
+	return db.LinkTrafficCap{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) TakeTrafficCap(ctx context.Context, link db.GetLinkForRedirectRow, now time.Time) bool {
+	if m.TakeTrafficCapFunc != nil {
+		return m.TakeTrafficCapFunc(ctx, link, now)
+	}
+	return true
+}
+
+func (m *mockLinkService) SetWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID, active bool, message *string, retryAfter int32) (db.UpsertLinkWaitingRoomRow, string, error) {
+	if m.SetWaitingRoomFunc != nil {
+		return m.SetWaitingRoomFunc(ctx, userID, linkID, active, message, retryAfter)
+	}
+	return db.UpsertLinkWaitingRoomRow{}, "", errors.New("not implemented")
+}
+
+func (m *mockLinkService) GetWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID) (db.GetLinkWaitingRoomRow, error) {
+	if m.GetWaitingRoomFunc != nil {
+		return m.GetWaitingRoomFunc(ctx, userID, linkID)
+	}
+	return db.GetLinkWaitingRoomRow{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DeleteWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID) (db.DeleteLinkWaitingRoomRow, error) {
+	if m.DeleteWaitingRoomFunc != nil {
+		return m.DeleteWaitingRoomFunc(ctx, userID, linkID)
+	}
+	return db.DeleteLinkWaitingRoomRow{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) SetWaitingRoomActiveByToken(ctx context.Context, token string, active bool) (db.SetLinkWaitingRoomActiveByTokenRow, error) {
+	if m.SetWaitingRoomActiveByTokenFunc != nil {
+		return m.SetWaitingRoomActiveByTokenFunc(ctx, token, active)
+	}
+	return db.SetLinkWaitingRoomActiveByTokenRow{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) AddComment(ctx context.Context, userID string, linkID uuid.UUID, body string) (db.LinkComment, error) {
+	if m.AddCommentFunc != nil {
+		return m.AddCommentFunc(ctx, userID, linkID, body)
+	}
+	return db.LinkComment{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ListComments(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*service.ListCommentsResult, error) {
+	if m.ListCommentsFunc != nil {
+		return m.ListCommentsFunc(ctx, userID, linkID, page, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DeleteComment(ctx context.Context, userID string, linkID uuid.UUID, commentID uuid.UUID) (db.LinkComment, error) {
+	if m.DeleteCommentFunc != nil {
+		return m.DeleteCommentFunc(ctx, userID, linkID, commentID)
+	}
+	return db.LinkComment{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) SetDynamicLink(ctx context.Context, userID string, linkID uuid.UUID, locked bool) (db.DynamicLink, error) {
+	if m.SetDynamicLinkFunc != nil {
+		return m.SetDynamicLinkFunc(ctx, userID, linkID, locked)
+	}
+	return db.DynamicLink{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) GetDynamicLink(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error) {
+	if m.GetDynamicLinkFunc != nil {
+		return m.GetDynamicLinkFunc(ctx, userID, linkID)
+	}
+	return db.DynamicLink{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DeleteDynamicLink(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error) {
+	if m.DeleteDynamicLinkFunc != nil {
+		return m.DeleteDynamicLinkFunc(ctx, userID, linkID)
+	}
+	return db.DynamicLink{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ChangeDestination(ctx context.Context, userID string, linkID uuid.UUID, destination string) (db.UpdateLinkRow, error) {
+	if m.ChangeDestinationFunc != nil {
+		return m.ChangeDestinationFunc(ctx, userID, linkID, destination)
+	}
+	return db.UpdateLinkRow{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ScheduleDestinationChange(ctx context.Context, userID string, linkID uuid.UUID, destination string, at time.Time) (db.LinkDestinationChange, error) {
+	if m.ScheduleDestinationChangeFunc != nil {
+		return m.ScheduleDestinationChangeFunc(ctx, userID, linkID, destination, at)
+	}
+	return db.LinkDestinationChange{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ListDestinationChanges(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*service.ListDestinationChangesResult, error) {
+	if m.ListDestinationChangesFunc != nil {
+		return m.ListDestinationChangesFunc(ctx, userID, linkID, page, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) CancelDestinationChange(ctx context.Context, userID string, linkID uuid.UUID, changeID uuid.UUID) (db.LinkDestinationChange, error) {
+	if m.CancelDestinationChangeFunc != nil {
+		return m.CancelDestinationChangeFunc(ctx, userID, linkID, changeID)
+	}
+	return db.LinkDestinationChange{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) SenderVerification(ctx context.Context, shortcode string, destination string) (db.VerifiedSender, bool, error) {
+	if m.SenderVerificationFunc != nil {
+		return m.SenderVerificationFunc(ctx, shortcode, destination)
+	}
+	return db.VerifiedSender{}, false, nil
+}
+
+func (m *mockLinkService) SetResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID, headers map[string]string) (db.LinkResponseHeader, error) {
+	if m.SetResponseHeadersFunc != nil {
+		return m.SetResponseHeadersFunc(ctx, userID, linkID, headers)
+	}
+	return db.LinkResponseHeader{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) GetResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error) {
+	if m.GetResponseHeadersFunc != nil {
+		return m.GetResponseHeadersFunc(ctx, userID, linkID)
+	}
+	return db.LinkResponseHeader{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DeleteResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error) {
+	if m.DeleteResponseHeadersFunc != nil {
+		return m.DeleteResponseHeadersFunc(ctx, userID, linkID)
+	}
+	return db.LinkResponseHeader{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) EnablePublicStats(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error) {
+	if m.EnablePublicStatsFunc != nil {
+		return m.EnablePublicStatsFunc(ctx, userID, linkID)
+	}
+	return service.PublicStatsSettings{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) GetPublicStats(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error) {
+	if m.GetPublicStatsFunc != nil {
+		return m.GetPublicStatsFunc(ctx, userID, linkID)
+	}
+	return service.PublicStatsSettings{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) DisablePublicStats(ctx context.Context, userID string, linkID uuid.UUID) (service.PublicStatsSettings, error) {
+	if m.DisablePublicStatsFunc != nil {
+		return m.DisablePublicStatsFunc(ctx, userID, linkID)
+	}
+	return service.PublicStatsSettings{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) CreatePublicStatsToken(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (service.PublicStatsToken, error) {
+	if m.CreatePublicStatsTokenFunc != nil {
+		return m.CreatePublicStatsTokenFunc(ctx, userID, linkID, expiresAt)
+	}
+	return service.PublicStatsToken{}, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ListDuplicateLinks(ctx context.Context, userID string, page, limit int) (*service.ListDuplicateLinksResult, error) {
+	if m.ListDuplicateLinksFunc != nil {
+		return m.ListDuplicateLinksFunc(ctx, userID, page, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) MergeLinks(ctx context.Context, userID string, into uuid.UUID, ids []uuid.UUID) (*service.MergeLinksResult, error) {
+	if m.MergeLinksFunc != nil {
+		return m.MergeLinksFunc(ctx, userID, into, ids)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) UpgradeToHTTPS(ctx context.Context, destination string) string {
+	if m.UpgradeToHTTPSFunc != nil {
+		return m.UpgradeToHTTPSFunc(ctx, destination)
+	}
+	return destination
+}
+
+func (m *mockLinkService) ListHTTPSUpgrades(ctx context.Context, userID string, page, limit int) (*service.ListHTTPSUpgradesResult, error) {
+	if m.ListHTTPSUpgradesFunc != nil {
+		return m.ListHTTPSUpgradesFunc(ctx, userID, page, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockLinkService) ListDestinationAlerts(ctx context.Context, userID string, page, limit int) (*service.ListDestinationAlertsResult, error) {
+	if m.ListDestinationAlertsFunc != nil {
+		return m.ListDestinationAlertsFunc(ctx, userID, page, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func createTestLogger() logger.Logger {
 	log, err := logger.New("test")
 	if err != nil {
@@ -103,9 +460,9 @@ func createTestLink(id uuid.UUID, shortcode, originalURL, userID string) db.Link
 		Shortcode:   shortcode,
 		OriginalUrl: originalURL,
 		UserID:      userID,
-		ExpiresAt:   pgtype.Timestamp{Valid: false},
-		CreatedAt:   pgtype.Timestamp{Valid: false},
-		UpdatedAt:   pgtype.Timestamp{Valid: false},
+		ExpiresAt:   pgtype.Timestamptz{Valid: false},
+		CreatedAt:   pgtype.Timestamptz{Valid: false},
+		UpdatedAt:   pgtype.Timestamptz{Valid: false},
 	}
 }
 
@@ -125,7 +482,7 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 			},
 			userID: "user_123",
 			mockService: &mockLinkService{
-				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time) (db.TryCreateLinkRow, error) {
+				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error) {
 					if userID != "user_123" {
 						t.Errorf("CreateShortLink called with wrong userID: got %s, want user_123", userID)
 					}
@@ -136,23 +493,51 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 						ID:          uuid.New(),
 						Shortcode:   "abc123",
 						OriginalUrl: originalURL,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
 						IsActive:    true,
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 					}, nil
 				},
 			},
 			expectedStatus: http.StatusCreated,
 			validateResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var response dto.SuccessResponse[db.TryCreateLinkRow]
+				var response dto.SuccessResponse[dto.LinkResponse]
 				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 					t.Fatalf("Failed to unmarshal response: %v", err)
 				}
-				if response.Data.OriginalUrl != "https://example.com" {
-					t.Errorf("Response OriginalUrl = %s, want https://example.com", response.Data.OriginalUrl)
+				if response.Data.OriginalURL != "https://example.com" {
+					t.Errorf("Response OriginalURL = %s, want https://example.com", response.Data.OriginalURL)
+				}
+				// Without a configured base, short URLs are built on the request's origin
+				if response.Data.ShortURL != "http://example.com/abc123" {
+					t.Errorf("Response ShortURL = %s, want http://example.com/abc123", response.Data.ShortURL)
 				}
+				// _links live under the version the request was served by
+				if response.Data.Links.Self.Href != "/api/v1/links/abc123" {
+					t.Errorf("Response _links.self = %s, want /api/v1/links/abc123", response.Data.Links.Self.Href)
+				}
+			},
+		},
+		{
+			name: "upgrade_https creates the link with the upgraded destination",
+			requestBody: dto.CreateLink{
+				URL:          "http://example.com/docs",
+				UpgradeHTTPS: true,
+			},
+			userID: "user_123",
+			mockService: &mockLinkService{
+				UpgradeToHTTPSFunc: func(ctx context.Context, destination string) string {
+					return "https://example.com/docs"
+				},
+				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error) {
+					if originalURL != "https://example.com/docs" {
+						t.Errorf("CreateShortLink called with URL %s, want https://example.com/docs", originalURL)
+					}
+					return db.TryCreateLinkRow{ID: uuid.New(), Shortcode: "abc123", OriginalUrl: originalURL, IsActive: true}, nil
+				},
 			},
+			expectedStatus: http.StatusCreated,
 		},
 		// Note: "invalid JSON body" test is not applicable for handler unit tests
 		// as the RequestValidator middleware would reject it before reaching the handler.
@@ -164,7 +549,7 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 			},
 			userID: "user_123",
 			mockService: &mockLinkService{
-				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time) (db.TryCreateLinkRow, error) {
+				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error) {
 					return db.TryCreateLinkRow{}, apperrors.InvalidURL
 				},
 			},
@@ -186,7 +571,7 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 			},
 			userID: "user_123",
 			mockService: &mockLinkService{
-				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time) (db.TryCreateLinkRow, error) {
+				CreateShortLinkFunc: func(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error) {
 					return db.TryCreateLinkRow{}, errors.New("database error")
 				},
 			},
@@ -227,7 +612,7 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 			// Set request body in context (same way RequestValidator middleware does)
 			// Only set if not testing invalid JSON (that would fail validation middleware)
 			if tt.name != "invalid JSON body" {
-				ctx = context.WithValue(ctx, middleware.ReqBodyKey(), tt.requestBody)
+				ctx = middleware.WithRequestBody(ctx, tt.requestBody)
 			}
 			req = req.WithContext(ctx)
 
@@ -267,27 +652,24 @@ func TestLinkHandler_ListLinks(t *testing.T) {
 								ID:          uuid.New(),
 								Shortcode:   "abc123",
 								OriginalUrl: "https://example.com",
-								ExpiresAt:   pgtype.Timestamp{Valid: false},
+								ExpiresAt:   pgtype.Timestamptz{Valid: false},
 								IsActive:    true,
-								CreatedAt:   pgtype.Timestamp{Valid: false},
-								UpdatedAt:   pgtype.Timestamp{Valid: false},
+								CreatedAt:   pgtype.Timestamptz{Valid: false},
+								UpdatedAt:   pgtype.Timestamptz{Valid: false},
 								Tags:        nil,
 							},
 							{
 								ID:          uuid.New(),
 								Shortcode:   "xyz789",
 								OriginalUrl: "https://example.org",
-								ExpiresAt:   pgtype.Timestamp{Valid: false},
+								ExpiresAt:   pgtype.Timestamptz{Valid: false},
 								IsActive:    true,
-								CreatedAt:   pgtype.Timestamp{Valid: false},
-								UpdatedAt:   pgtype.Timestamp{Valid: false},
+								CreatedAt:   pgtype.Timestamptz{Valid: false},
+								UpdatedAt:   pgtype.Timestamptz{Valid: false},
 								Tags:        nil,
 							},
 						},
-						Total:      2,
-						Page:       1,
-						Limit:      5,
-						TotalPages: 1,
+						Meta: pagination.Meta{Page: 1, Limit: 5, Total: 2, TotalPages: 1},
 					}, nil
 				},
 			},
@@ -313,11 +695,8 @@ func TestLinkHandler_ListLinks(t *testing.T) {
 			mockService: &mockLinkService{
 				ListAllLinksFunc: func(ctx context.Context, userID string, isActive *bool, tagIDs []uuid.UUID, page, limit int) (*service.ListLinksResult, error) {
 					return &service.ListLinksResult{
-						Links:      []db.ListUserLinksRow{},
-						Total:      0,
-						Page:       1,
-						Limit:      5,
-						TotalPages: 0,
+						Links: []db.ListUserLinksRow{},
+						Meta:  pagination.Meta{Page: 1, Limit: 5, Total: 0, TotalPages: 0},
 					}, nil
 				},
 			},
@@ -383,6 +762,79 @@ func TestLinkHandler_ListLinks(t *testing.T) {
 	}
 }
 
+func TestLinkHandler_ListLinksByIDs(t *testing.T) {
+	id1 := uuid.New()
+	id2 := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []uuid.UUID
+	}{
+		{
+			name:           "deduplicates requested ids",
+			query:          "?ids=" + id1.String() + "," + id2.String() + "," + id1.String(),
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uuid.UUID{id1, id2},
+		},
+		{
+			name:           "invalid id",
+			query:          "?ids=" + id1.String() + ",not-a-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty ids",
+			query:          "?ids=",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []uuid.UUID
+			handler := &LinkHandler{
+				LinkService: &mockLinkService{
+					GetLinksByIDsFunc: func(ctx context.Context, userID string, ids []uuid.UUID) ([]db.ListUserLinksByIDsRow, error) {
+						requested = ids
+						return []db.ListUserLinksByIDsRow{{ID: id1, Shortcode: "abc123"}}, nil
+					},
+					ListAllLinksFunc: func(ctx context.Context, userID string, isActive *bool, tagIDs []uuid.UUID, page, limit int) (*service.ListLinksResult, error) {
+						t.Error("ListAllLinks() should not be called for a batch lookup")
+						return nil, errors.New("unexpected call")
+					},
+				},
+				logger: createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links"+tt.query, nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.ListLinks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("ListLinks() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if !reflect.DeepEqual(requested, tt.expectedIDs) {
+				t.Errorf("GetLinksByIDs() ids = %v, want %v", requested, tt.expectedIDs)
+			}
+
+			var response dto.SuccessResponse[[]db.ListUserLinksByIDsRow]
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Data) != 1 || response.Pagination != nil {
+				t.Errorf("ListLinks() = %+v, want one link without pagination", response)
+			}
+		})
+	}
+}
+
 func TestLinkHandler_UpdateLink(t *testing.T) {
 	linkID := uuid.New()
 	userID := "user_123"
@@ -406,7 +858,7 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 				Shortcode: &newShortcode,
 			},
 			mockService: &mockLinkService{
-				UpdateLinkFunc: func(ctx context.Context, userIDParam string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error) {
+				UpdateLinkFunc: func(ctx context.Context, userIDParam string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error) {
 					if id != linkID {
 						t.Errorf("UpdateLink called with wrong ID")
 					}
@@ -418,9 +870,9 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 						Shortcode:   *shortcode,
 						OriginalUrl: "https://example.com",
 						IsActive:    true,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 					}, nil
 				},
 			},
@@ -443,15 +895,15 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 				IsActive: &isActive,
 			},
 			mockService: &mockLinkService{
-				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error) {
+				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error) {
 					return db.UpdateLinkRow{
 						ID:          id,
 						Shortcode:   "oldcode",
 						OriginalUrl: "https://example.com",
 						IsActive:    *isActive,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 					}, nil
 				},
 			},
@@ -493,7 +945,7 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 				Shortcode: &newShortcode,
 			},
 			mockService: &mockLinkService{
-				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error) {
+				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error) {
 					return db.UpdateLinkRow{}, apperrors.LinkNotFound
 				},
 			},
@@ -516,7 +968,7 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 				Shortcode: &newShortcode,
 			},
 			mockService: &mockLinkService{
-				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error) {
+				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error) {
 					return db.UpdateLinkRow{}, apperrors.LinkShortcodeTaken
 				},
 			},
@@ -539,7 +991,7 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 				Shortcode: &newShortcode,
 			},
 			mockService: &mockLinkService{
-				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time) (db.UpdateLinkRow, error) {
+				UpdateLinkFunc: func(ctx context.Context, userID string, id uuid.UUID, shortcode *string, isActive *bool, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, shield *bool, referrerPolicy *string) (db.UpdateLinkRow, error) {
 					return db.UpdateLinkRow{}, errors.New("database error")
 				},
 			},
@@ -567,7 +1019,7 @@ func TestLinkHandler_UpdateLink(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPatch, "/api/v1/links/"+tt.linkID, bytes.NewBuffer(bodyBytes))
 			ctx := middleware.WithUserID(req.Context(), tt.userID)
 			// Set request body in context (same way RequestValidator middleware does)
-			ctx = context.WithValue(ctx, middleware.ReqBodyKey(), tt.requestBody)
+			ctx = middleware.WithRequestBody(ctx, tt.requestBody)
 			req = req.WithContext(ctx)
 
 			w := httptest.NewRecorder()
@@ -618,9 +1070,9 @@ func TestLinkHandler_GetLink(t *testing.T) {
 						Shortcode:   shortcode,
 						OriginalUrl: "https://example.com",
 						IsActive:    true,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 					}, nil
 				},
 			},
@@ -706,6 +1158,75 @@ func TestLinkHandler_GetLink(t *testing.T) {
 	}
 }
 
+func TestLinkHandler_GetLinkClickCount(t *testing.T) {
+	linkID := uuid.New()
+	fortyTwo := int64(42)
+	mockService := &mockLinkService{
+		GetLinkByShortcodeFunc: func(ctx context.Context, uid string, code string) (db.GetLinkByShortcodeAndUserRow, error) {
+			return db.GetLinkByShortcodeAndUserRow{ID: linkID, Shortcode: code, IsActive: true}, nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		clicks    *mockClickRecorder
+		wantCount *int64
+	}{
+		{
+			name:      "counted",
+			clicks:    &mockClickRecorder{counts: map[uuid.UUID]int64{linkID: 42}},
+			wantCount: &fortyTwo,
+		},
+		{
+			name:      "no clicks yet",
+			clicks:    &mockClickRecorder{counts: map[uuid.UUID]int64{}},
+			wantCount: new(int64),
+		},
+		{
+			name:   "counting off",
+			clicks: &mockClickRecorder{},
+		},
+		{
+			name:   "count lookup fails",
+			clicks: &mockClickRecorder{countsErr: errors.New("database error")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &LinkHandler{
+				LinkService: mockService,
+				clicks:      tt.clicks,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links/abc123", nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/links/{shortcode}", handler.GetLink)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var response dto.SuccessResponse[dto.LinkResponse]
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			got := response.Data.ClickCount
+			switch {
+			case tt.wantCount == nil && got != nil:
+				t.Errorf("click_count = %d, want it omitted", *got)
+			case tt.wantCount != nil && (got == nil || *got != *tt.wantCount):
+				t.Errorf("click_count = %v, want %d", got, *tt.wantCount)
+			}
+		})
+	}
+}
+
 func TestLinkHandler_DeleteLink(t *testing.T) {
 	linkID := uuid.New()
 	userID := "user_123"
@@ -735,9 +1256,9 @@ func TestLinkHandler_DeleteLink(t *testing.T) {
 						Shortcode:   "abc123",
 						OriginalUrl: "https://example.com",
 						IsActive:    true,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 					}, nil
 				},
 			},
@@ -877,9 +1398,9 @@ func TestLinkHandler_AddTagsToLink(t *testing.T) {
 						Shortcode:   "abc123",
 						OriginalUrl: "https://example.com",
 						IsActive:    true,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 						Tags:        []interface{}{},
 					}, nil
 				},
@@ -950,7 +1471,7 @@ func TestLinkHandler_AddTagsToLink(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/api/v1/links/"+tt.linkID+"/tags", bytes.NewBuffer(bodyBytes))
 			req.Header.Set("Content-Type", "application/json")
 			ctx := middleware.WithUserID(req.Context(), tt.userID)
-			ctx = context.WithValue(ctx, middleware.ReqBodyKey(), tt.requestBody)
+			ctx = middleware.WithRequestBody(ctx, tt.requestBody)
 			req = req.WithContext(ctx)
 
 			w := httptest.NewRecorder()
@@ -1009,9 +1530,9 @@ func TestLinkHandler_RemoveTagsFromLink(t *testing.T) {
 						Shortcode:   "abc123",
 						OriginalUrl: "https://example.com",
 						IsActive:    true,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 						Tags:        []interface{}{},
 					}, nil
 				},
@@ -1082,7 +1603,7 @@ func TestLinkHandler_RemoveTagsFromLink(t *testing.T) {
 			req := httptest.NewRequest(http.MethodDelete, "/api/v1/links/"+tt.linkID+"/tags", bytes.NewBuffer(bodyBytes))
 			req.Header.Set("Content-Type", "application/json")
 			ctx := middleware.WithUserID(req.Context(), tt.userID)
-			ctx = context.WithValue(ctx, middleware.ReqBodyKey(), tt.requestBody)
+			ctx = middleware.WithRequestBody(ctx, tt.requestBody)
 			req = req.WithContext(ctx)
 
 			w := httptest.NewRecorder()
@@ -1172,5 +1693,769 @@ func TestLinkHandler_Redirect(t *testing.T) {
 	}
 }
 
-// Note: Error mapping is now tested in pkg/errors/errors_test.go via TestMapError
-// The error handling middleware is tested through integration tests
+func TestLinkHandler_RedirectPrivate(t *testing.T) {
+	ownerID := "user_owner"
+	privateLink := db.GetLinkForRedirectRow{
+		ID:          uuid.New(),
+		OriginalUrl: "https://internal.example.com",
+		UserID:      ownerID,
+		Visibility:  service.LinkVisibilityPrivate,
+	}
+
+	tests := []struct {
+		name           string
+		viewerID       string
+		query          string
+		canAccess      bool
+		expectedStatus int
+	}{
+		{
+			name:           "owner is redirected",
+			viewerID:       ownerID,
+			canAccess:      true,
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name:           "valid token is redirected",
+			query:          "?token=valid",
+			canAccess:      true,
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name:           "anonymous visitor gets 401",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return privateLink, nil
+				},
+				CanAccessPrivateLinkFunc: func(link db.GetLinkForRedirectRow, viewerID string, token string) bool {
+					if viewerID != tt.viewerID {
+						t.Errorf("CanAccessPrivateLink viewerID = %q, want %q", viewerID, tt.viewerID)
+					}
+					if tt.query != "" && token != "valid" {
+						t.Errorf("CanAccessPrivateLink token = %q, want %q", token, "valid")
+					}
+					return tt.canAccess
+				},
+			}
+
+			handler := &LinkHandler{
+				LinkService: mockService,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123"+tt.query, nil)
+			if tt.viewerID != "" {
+				req = req.WithContext(middleware.WithUserID(req.Context(), tt.viewerID))
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Redirect() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+
+			if tt.expectedStatus == http.StatusFound && w.Header().Get("Location") != privateLink.OriginalUrl {
+				t.Errorf("Redirect() Location = %s, want %s", w.Header().Get("Location"), privateLink.OriginalUrl)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_EmailGatedRedirect(t *testing.T) {
+	gatedLink := db.GetLinkForRedirectRow{
+		ID:           uuid.New(),
+		OriginalUrl:  "https://example.com/whitepaper",
+		Visibility:   service.LinkVisibilityPublic,
+		CaptureEmail: true,
+	}
+
+	tests := []struct {
+		name             string
+		method           string
+		form             string
+		captureErr       error
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:           "GET shows the lead form",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "POST with valid email forwards",
+			method:           http.MethodPost,
+			form:             "email=visitor%40example.com",
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: gatedLink.OriginalUrl,
+		},
+		{
+			name:           "POST with invalid email shows the form again",
+			method:         http.MethodPost,
+			form:           "email=not-an-email",
+			captureErr:     apperrors.InvalidEmail,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "POST with storage failure",
+			method:         http.MethodPost,
+			form:           "email=visitor%40example.com",
+			captureErr:     errors.New("database error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedEmail string
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return gatedLink, nil
+				},
+				CaptureLeadFunc: func(ctx context.Context, linkID uuid.UUID, email string) error {
+					capturedEmail = email
+					return tt.captureErr
+				},
+			}
+
+			handler := &LinkHandler{
+				LinkService: mockService,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(tt.method, "/abc123", strings.NewReader(tt.form))
+			if tt.method == http.MethodPost {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.Post("/{shortcode}", handler.CaptureLead)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+
+			if w.Header().Get("Location") != tt.expectedLocation {
+				t.Errorf("Location = %q, want %q", w.Header().Get("Location"), tt.expectedLocation)
+			}
+
+			if tt.method == http.MethodPost && capturedEmail != "visitor@example.com" && tt.captureErr == nil {
+				t.Errorf("CaptureLead email = %q, want %q", capturedEmail, "visitor@example.com")
+			}
+
+			if tt.expectedStatus != http.StatusSeeOther && !strings.Contains(w.Body.String(), `name="email"`) {
+				t.Errorf("expected the lead form in the response body")
+			}
+		})
+	}
+}
+
+func TestLinkHandler_ListLeadsCSV(t *testing.T) {
+	linkID := uuid.New()
+	capturedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockService := &mockLinkService{
+		ListLeadsFunc: func(ctx context.Context, userID string, id uuid.UUID) ([]db.ListLinkLeadsRow, error) {
+			return []db.ListLinkLeadsRow{
+				{ID: 2, Email: "=cmd@example.com", CreatedAt: pgtype.Timestamptz{Time: capturedAt, Valid: true}},
+				{ID: 1, Email: "visitor@example.com", CreatedAt: pgtype.Timestamptz{Time: capturedAt, Valid: true}},
+			}, nil
+		},
+	}
+
+	handler := &LinkHandler{
+		LinkService: mockService,
+		logger:      createTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/links/"+linkID.String()+"/leads?format=csv", nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/links/{id}/leads", handler.ListLeads)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+
+	expected := "email,captured_at\n'=cmd@example.com,2026-03-01T12:00:00Z\nvisitor@example.com,2026-03-01T12:00:00Z\n"
+	if w.Body.String() != expected {
+		t.Errorf("body = %q, want %q", w.Body.String(), expected)
+	}
+}
+
+func TestLinkHandler_RedirectWithDelay(t *testing.T) {
+	message := "Sponsored by <Example>"
+	mockService := &mockLinkService{
+		GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+			return db.GetLinkForRedirectRow{
+				ID:                  uuid.New(),
+				OriginalUrl:         "https://example.com/page?a=1&b=2",
+				Visibility:          service.LinkVisibilityPublic,
+				RedirectDelay:       5,
+				InterstitialMessage: &message,
+			}, nil
+		},
+	}
+
+	handler := &LinkHandler{
+		LinkService: mockService,
+		logger:      createTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/{shortcode}", handler.Redirect)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`content="5;url=https://example.com/page?a=1&amp;b=2"`,
+		"Sponsored by &lt;Example&gt;",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("interstitial body missing %q", want)
+		}
+	}
+}
+
+func TestLinkHandler_RedirectPagesAreTranslated(t *testing.T) {
+	languages, err := i18n.NewNegotiator("en", []string{"go.example.de=de"})
+	if err != nil {
+		t.Fatalf("NewNegotiator() error = %v", err)
+	}
+
+	handler := &LinkHandler{
+		LinkService: &mockLinkService{
+			GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+				if code == "delayed" {
+					return db.GetLinkForRedirectRow{ID: uuid.New(), OriginalUrl: "https://example.com", RedirectDelay: 3}, nil
+				}
+				return db.GetLinkForRedirectRow{}, apperrors.LinkNotFound
+			},
+		},
+		logger: createTestLogger(),
+	}
+
+	r := chi.NewRouter()
+	r.Use(middleware.Language(languages))
+	r.Get("/{shortcode}", handler.Redirect)
+
+	tests := []struct {
+		name           string
+		path           string
+		host           string
+		acceptLanguage string
+		wantLang       string
+		wantText       []string
+	}{
+		{
+			name:     "default language",
+			path:     "/missing",
+			wantLang: "en",
+			wantText: []string{`<html lang="en">`, "404 - Link Not Found"},
+		},
+		{
+			name:           "Accept-Language",
+			path:           "/missing",
+			acceptLanguage: "fr-FR,fr;q=0.9,en;q=0.8",
+			wantLang:       "fr",
+			wantText:       []string{`<html lang="fr">`, "404 - Lien introuvable"},
+		},
+		{
+			name:     "domain default",
+			path:     "/missing",
+			host:     "go.example.de",
+			wantLang: "de",
+			wantText: []string{`<html lang="de">`, "404 - Link nicht gefunden"},
+		},
+		{
+			name:           "interstitial",
+			path:           "/delayed",
+			acceptLanguage: "es",
+			wantLang:       "es",
+			wantText:       []string{`Serás redirigido en <span id="countdown">3</span> segundos.`, "Continuar ahora", "Estás saliendo de este sitio."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Language"); got != tt.wantLang {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLang)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("body missing %q:\n%s", want, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// mockClickRecorder hands recorded clicks over a channel (RecordClick runs in a goroutine)
+type mockClickRecorder struct {
+	clicks    chan service.Click
+	counts    map[uuid.UUID]int64
+	countsErr error
+}
+
+func (m *mockClickRecorder) RecordClick(ctx context.Context, click service.Click) error {
+	m.clicks <- click
+	return nil
+}
+
+func (m *mockClickRecorder) LinkClickCounts(ctx context.Context, links []service.CountedLink) (map[uuid.UUID]int64, error) {
+	return m.counts, m.countsErr
+}
+
+func TestLinkHandler_RedirectTemplate(t *testing.T) {
+	mockService := &mockLinkService{
+		GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+			return db.GetLinkForRedirectRow{
+				ID:          uuid.New(),
+				OriginalUrl: "https://example.com/?uid={click_id}&src={shortcode}",
+				Visibility:  service.LinkVisibilityPublic,
+			}, nil
+		},
+	}
+	clicks := &mockClickRecorder{clicks: make(chan service.Click, 1)}
+
+	handler := &LinkHandler{
+		LinkService: mockService,
+		clicks:      clicks,
+		logger:      createTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/{shortcode}", handler.Redirect)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	select {
+	case click := <-clicks.clicks:
+		expected := "https://example.com/?uid=" + click.ID.String() + "&src=abc123"
+		if location := w.Header().Get("Location"); location != expected {
+			t.Errorf("Location = %q, want %q", location, expected)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("click was not recorded")
+	}
+}
+
+func TestLinkHandler_RedirectClickSource(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		expectedSource string
+	}{
+		{name: "web click", target: "/abc123", expectedSource: analytics.SourceWeb},
+		{name: "QR scan", target: "/abc123?src=qr", expectedSource: analytics.SourceQR},
+		{name: "unknown source", target: "/abc123?src=newsletter", expectedSource: analytics.SourceWeb},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{
+						ID:          uuid.New(),
+						OriginalUrl: "https://example.com/menu",
+						Visibility:  service.LinkVisibilityPublic,
+					}, nil
+				},
+			}
+			clicks := &mockClickRecorder{clicks: make(chan service.Click, 1)}
+			handler := &LinkHandler{LinkService: mockService, clicks: clicks, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if location := w.Header().Get("Location"); location != "https://example.com/menu" {
+				t.Errorf("Location = %q, want the destination without the source marker", location)
+			}
+
+			select {
+			case click := <-clicks.clicks:
+				if click.Source != tt.expectedSource {
+					t.Errorf("click source = %q, want %q", click.Source, tt.expectedSource)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("click was not recorded")
+			}
+		})
+	}
+}
+
+func TestLinkHandler_RedirectAppendClickID(t *testing.T) {
+	mockService := &mockLinkService{
+		GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+			return db.GetLinkForRedirectRow{
+				ID:            uuid.New(),
+				OriginalUrl:   "https://example.com/pricing?plan=pro#faq",
+				Visibility:    service.LinkVisibilityPublic,
+				AppendClickID: true,
+			}, nil
+		},
+	}
+	clicks := &mockClickRecorder{clicks: make(chan service.Click, 1)}
+
+	handler := &LinkHandler{
+		LinkService: mockService,
+		clicks:      clicks,
+		logger:      createTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/{shortcode}", handler.Redirect)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	select {
+	case click := <-clicks.clicks:
+		expected := "https://example.com/pricing?plan=pro&click_id=" + click.ID.String() + "#faq"
+		if location := w.Header().Get("Location"); location != expected {
+			t.Errorf("Location = %q, want %q", location, expected)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("click was not recorded")
+	}
+}
+
+func TestLinkHandler_RedirectReferrerPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		redirectDelay  int32
+		expectedStatus int
+		expectedHeader string
+		expectedBody   string
+	}{
+		{name: "default redirects directly", policy: service.ReferrerPolicyDefault, expectedStatus: http.StatusFound},
+		{name: "cached link redirects directly", policy: "", expectedStatus: http.StatusFound},
+		{
+			name:           "no-referrer goes through the hop",
+			policy:         service.ReferrerPolicyNoReferrer,
+			expectedStatus: http.StatusOK,
+			expectedHeader: "no-referrer",
+			expectedBody:   `<meta name="referrer" content="no-referrer">`,
+		},
+		{
+			name:           "interstitial applies the policy",
+			policy:         service.ReferrerPolicyOrigin,
+			redirectDelay:  5,
+			expectedStatus: http.StatusOK,
+			expectedHeader: "origin",
+			expectedBody:   `id="countdown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{
+						ID:             uuid.New(),
+						OriginalUrl:    "https://example.com/landing",
+						Visibility:     service.LinkVisibilityPublic,
+						RedirectDelay:  tt.redirectDelay,
+						ReferrerPolicy: tt.policy,
+					}, nil
+				},
+			}
+			handler := &LinkHandler{
+				LinkService: mockService,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if got := w.Header().Get("Referrer-Policy"); got != tt.expectedHeader {
+				t.Errorf("Referrer-Policy = %q, want %q", got, tt.expectedHeader)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("body missing %q:\n%s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// Note: Error mapping is now tested in pkg/errors/errors_test.go via TestMapError
+// The error handling middleware is tested through integration tests
+
+func TestLinkHandler_QRBatch(t *testing.T) {
+	linkID := uuid.New()
+
+	var gotBaseURL string
+	mockService := &mockLinkService{
+		QRCodesFunc: func(ctx context.Context, userID string, ids []uuid.UUID, baseURL string) ([]service.QRCode, error) {
+			gotBaseURL = baseURL
+			if ids[0] != linkID {
+				return nil, apperrors.LinkNotFound
+			}
+			code, err := service.EncodeQRCode(linkID, "abc123", baseURL+"/abc123")
+			return []service.QRCode{code}, err
+		},
+	}
+
+	tests := []struct {
+		name            string
+		shortURLBase    string
+		body            dto.QRBatch
+		expectedStatus  int
+		expectedType    string
+		expectedBaseURL string
+	}{
+		{
+			name:            "zip by default",
+			shortURLBase:    "https://sho.rt/",
+			body:            dto.QRBatch{LinkIDs: []uuid.UUID{linkID}},
+			expectedStatus:  http.StatusOK,
+			expectedType:    "application/zip",
+			expectedBaseURL: "https://sho.rt",
+		},
+		{
+			name:            "pdf sheet on the request origin",
+			body:            dto.QRBatch{LinkIDs: []uuid.UUID{linkID}, Format: "pdf"},
+			expectedStatus:  http.StatusOK,
+			expectedType:    "application/pdf",
+			expectedBaseURL: "http://example.com",
+		},
+		{
+			name:           "unknown link",
+			body:           dto.QRBatch{LinkIDs: []uuid.UUID{uuid.New()}},
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBaseURL = ""
+			handler := &LinkHandler{
+				LinkService:  mockService,
+				shortURLBase: tt.shortURLBase,
+				logger:       createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/links/qr-batch", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, tt.body)
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.QRBatch(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.expectedType) {
+				t.Errorf("Content-Type = %q, want %s", ct, tt.expectedType)
+			}
+			if tt.expectedBaseURL != "" && gotBaseURL != tt.expectedBaseURL {
+				t.Errorf("base URL = %q, want %q", gotBaseURL, tt.expectedBaseURL)
+			}
+			if tt.expectedStatus == http.StatusOK && !strings.Contains(w.Header().Get("Content-Disposition"), "attachment") {
+				t.Error("response isn't an attachment")
+			}
+		})
+	}
+}
+
+func TestLinkHandler_ListLinkChanges(t *testing.T) {
+	var gotSince time.Time
+	var gotCursor string
+	mockService := &mockLinkService{
+		ListLinkChangesFunc: func(ctx context.Context, userID string, since time.Time, cursor string, limit int) (*service.LinkChangesResult, error) {
+			gotSince, gotCursor = since, cursor
+			if cursor == "bad" {
+				return nil, apperrors.InvalidCursor
+			}
+			return &service.LinkChangesResult{
+				Changes:    []db.ListLinkChangesRow{{ID: uuid.New(), Change: service.LinkChangeCreated}},
+				NextCursor: "next",
+				HasMore:    true,
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedSince  time.Time
+		expectedCursor string
+	}{
+		{name: "from the beginning", expectedStatus: http.StatusOK},
+		{name: "since", query: "?since=2026-03-01T12:00:00%2B02:00", expectedStatus: http.StatusOK, expectedSince: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{name: "cursor", query: "?cursor=abc", expectedStatus: http.StatusOK, expectedCursor: "abc"},
+		{name: "invalid since", query: "?since=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "invalid cursor", query: "?cursor=bad", expectedStatus: http.StatusBadRequest, expectedCursor: "bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSince, gotCursor = time.Time{}, ""
+			handler := &LinkHandler{
+				LinkService: mockService,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links/changes"+tt.query, nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.ListLinkChanges(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if !gotSince.Equal(tt.expectedSince) || gotCursor != tt.expectedCursor {
+				t.Errorf("service called with since %s, cursor %q, want %s, %q", gotSince, gotCursor, tt.expectedSince, tt.expectedCursor)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var resp dto.SuccessResponse[[]db.ListLinkChangesRow]
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Cursor == nil || resp.Cursor.NextCursor != "next" || !resp.Cursor.HasMore {
+				t.Errorf("cursor = %+v, want next_cursor next with more changes", resp.Cursor)
+			}
+			if link := w.Header().Get("Link"); !strings.Contains(link, "cursor=next") || strings.Contains(link, "since=") {
+				t.Errorf("Link = %q, want a next link resuming from the cursor", link)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_QuickShorten(t *testing.T) {
+	mockService := &mockLinkService{
+		QuickShortenFunc: func(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error) {
+			if originalURL == "invalid" {
+				return db.TryCreateLinkRow{}, false, apperrors.InvalidURL
+			}
+			link := db.TryCreateLinkRow{ID: uuid.New(), Shortcode: "abc123", OriginalUrl: originalURL, Title: title}
+			return link, originalURL == "https://example.com/seen", nil
+		},
+	}
+
+	tests := []struct {
+		name             string
+		body             dto.QuickShorten
+		expectedStatus   int
+		wantDeduplicated bool
+	}{
+		{
+			name:           "new link",
+			body:           dto.QuickShorten{URL: "https://example.com/new"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:             "existing link",
+			body:             dto.QuickShorten{URL: "https://example.com/seen"},
+			expectedStatus:   http.StatusOK,
+			wantDeduplicated: true,
+		},
+		{
+			name:           "invalid URL",
+			body:           dto.QuickShorten{URL: "invalid"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &LinkHandler{
+				LinkService:  mockService,
+				shortURLBase: "https://sho.rt",
+				logger:       createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/quick-shorten", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, tt.body)
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.QuickShorten(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code >= http.StatusBadRequest {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.QuickShortenResult]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.ShortURL != "https://sho.rt/abc123" {
+				t.Errorf("short_url = %q, want https://sho.rt/abc123", resp.Data.ShortURL)
+			}
+			if resp.Data.Deduplicated != tt.wantDeduplicated {
+				t.Errorf("deduplicated = %v, want %v", resp.Data.Deduplicated, tt.wantDeduplicated)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// linkDetail is a link as GET /api/v1/links/{shortcode} returns it
+type linkDetail struct {
+	dto.LinkWithTagsResponse
+	// Null when the link has no traffic cap
+	TrafficCap *dto.TrafficCap `json:"traffic_cap"`
+}
+
+func trafficCapResponse(c db.LinkTrafficCap) dto.TrafficCap {
+	return dto.TrafficCap{
+		DailyCap:    c.DailyCap,
+		TotalCap:    c.TotalCap,
+		OverflowURL: c.OverflowUrl,
+		DailyClicks: c.DayClicks,
+		TotalClicks: c.TotalClicks,
+		Reached:     service.TrafficCapReached(c),
+	}
+}
+
+// trafficCapStatus returns the link's traffic cap for its detail response, nil when it has none.
+// A failed lookup is logged and left out rather than failing the whole response.
+func (h *LinkHandler) trafficCapStatus(r *http.Request, userID string, linkID uuid.UUID) *dto.TrafficCap {
+	trafficCap, err := h.LinkService.GetTrafficCap(r.Context(), userID, linkID)
+	if err != nil {
+		if !errors.Is(err, apperrors.TrafficCapNotFound) {
+			h.logger.Error("Failed to get traffic cap",
+				zap.Error(err),
+				zap.String("link_id", linkID.String()),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+		}
+		return nil
+	}
+
+	resp := trafficCapResponse(trafficCap)
+	return &resp
+}
+
+// GetTrafficCap: GET /api/v1/links/{id}/traffic-cap
+func (h *LinkHandler) GetTrafficCap(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	trafficCap, err := h.LinkService.GetTrafficCap(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.TrafficCap]{
+		Data: trafficCapResponse(trafficCap),
+	})
+}
+
+// SetTrafficCap: PUT /api/v1/links/{id}/traffic-cap
+func (h *LinkHandler) SetTrafficCap(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.SetTrafficCap](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	trafficCap, err := h.LinkService.SetTrafficCap(r.Context(), userID, linkID, reqBody.DailyCap, reqBody.TotalCap, reqBody.OverflowURL)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.TrafficCap]{
+		Data: trafficCapResponse(trafficCap),
+	})
+}
+
+// DeleteTrafficCap: DELETE /api/v1/links/{id}/traffic-cap
+func (h *LinkHandler) DeleteTrafficCap(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	trafficCap, err := h.LinkService.DeleteTrafficCap(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.TrafficCap]{
+		Data: trafficCapResponse(trafficCap),
+	})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_RedirectTrafficCap(t *testing.T) {
+	dailyCap := int32(100)
+	overflow := "https://example.com/backup-offer"
+
+	tests := []struct {
+		name             string
+		withinCap        bool
+		expectedLocation string
+	}{
+		{name: "within the cap", withinCap: true, expectedLocation: "https://example.com/offer"},
+		{name: "over the cap", withinCap: false, expectedLocation: overflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{
+						ID:          uuid.New(),
+						OriginalUrl: "https://example.com/offer",
+						Visibility:  service.LinkVisibilityPublic,
+						DailyCap:    &dailyCap,
+						OverflowUrl: &overflow,
+					}, nil
+				},
+				TakeTrafficCapFunc: func(ctx context.Context, link db.GetLinkForRedirectRow, now time.Time) bool {
+					return tt.withinCap
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusFound {
+				t.Fatalf("Redirect() status = %d, want %d", w.Code, http.StatusFound)
+			}
+			if location := w.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("Location = %q, want %q", location, tt.expectedLocation)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_GetLinkTrafficCap(t *testing.T) {
+	totalCap := int32(10)
+	linkID := uuid.New()
+
+	mockService := &mockLinkService{
+		GetLinkByShortcodeFunc: func(ctx context.Context, userID string, shortcode string) (db.GetLinkByShortcodeAndUserRow, error) {
+			return db.GetLinkByShortcodeAndUserRow{ID: linkID, Shortcode: shortcode}, nil
+		},
+		GetTrafficCapFunc: func(ctx context.Context, userID string, id uuid.UUID) (db.LinkTrafficCap, error) {
+			return db.LinkTrafficCap{LinkID: id, TotalCap: &totalCap, OverflowUrl: "https://example.com/backup", TotalClicks: 10, DayClicks: 4}, nil
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/links/abc123", nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/links/{shortcode}", handler.GetLink)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetLink() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp dto.SuccessResponse[linkDetail]
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data.Shortcode != "abc123" {
+		t.Errorf("GetLink() shortcode = %q, want abc123", resp.Data.Shortcode)
+	}
+	capStatus := resp.Data.TrafficCap
+	if capStatus == nil || capStatus.TotalClicks != 10 || capStatus.DailyClicks != 4 || !capStatus.Reached {
+		t.Errorf("GetLink() traffic_cap = %+v, want 10 total clicks, 4 today and reached", capStatus)
+	}
+}
+
+func TestLinkHandler_SetTrafficCap(t *testing.T) {
+	dailyCap := int32(50)
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "sets the cap", expectedStatus: http.StatusOK},
+		{name: "link not found", serviceErr: apperrors.LinkNotFound, expectedStatus: http.StatusNotFound},
+		{name: "invalid overflow URL", serviceErr: apperrors.InvalidURL, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkID := uuid.New()
+			mockService := &mockLinkService{
+				SetTrafficCapFunc: func(ctx context.Context, userID string, id uuid.UUID, daily *int32, total *int32, overflowURL string) (db.LinkTrafficCap, error) {
+					if tt.serviceErr != nil {
+						return db.LinkTrafficCap{}, tt.serviceErr
+					}
+					if id != linkID || daily == nil || *daily != dailyCap || total != nil {
+						t.Errorf("SetTrafficCap() called with id %v, daily %v, total %v", id, daily, total)
+					}
+					return db.LinkTrafficCap{LinkID: id, DailyCap: daily, OverflowUrl: overflowURL}, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/links/"+linkID.String()+"/traffic-cap", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, dto.SetTrafficCap{DailyCap: &dailyCap, OverflowURL: "https://example.com/backup"})
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Put("/api/v1/links/{id}/traffic-cap", handler.SetTrafficCap)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("SetTrafficCap() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_DeleteTrafficCapNotFound(t *testing.T) {
+	mockService := &mockLinkService{
+		DeleteTrafficCapFunc: func(ctx context.Context, userID string, id uuid.UUID) (db.LinkTrafficCap, error) {
+			return db.LinkTrafficCap{}, apperrors.TrafficCapNotFound
+		},
+	}
+	handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/links/"+uuid.NewString()+"/traffic-cap", nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Delete("/api/v1/links/{id}/traffic-cap", handler.DeleteTrafficCap)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("DeleteTrafficCap() status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
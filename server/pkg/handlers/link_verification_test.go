@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_InterstitialVerifiedBadge(t *testing.T) {
+	name := "Example Corp"
+	tests := []struct {
+		name     string
+		verified bool
+		want     string
+	}{
+		{name: "verified sender", verified: true, want: "Verified sender: Example Corp"},
+		{name: "unverified sender"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{ID: uuid.New(), OriginalUrl: "https://example.com", RedirectDelay: 3}, nil
+				},
+				SenderVerificationFunc: func(ctx context.Context, shortcode string, destination string) (db.VerifiedSender, bool, error) {
+					if !tt.verified {
+						return db.VerifiedSender{}, false, nil
+					}
+					return db.VerifiedSender{Kind: service.VerifiedSenderDomain, Value: "example.com", Name: &name}, true, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			body := w.Body.String()
+			hasBadge := strings.Contains(body, `class="verified-sender"`)
+			if hasBadge != tt.verified {
+				t.Fatalf("interstitial has badge = %v, want %v", hasBadge, tt.verified)
+			}
+			if tt.want != "" && !strings.Contains(body, tt.want) {
+				t.Errorf("interstitial body missing %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_PublicPreview(t *testing.T) {
+	name := "Example Corp"
+	tests := []struct {
+		name            string
+		link            db.GetLinkForRedirectRow
+		linkErr         error
+		verified        bool
+		expectedStatus  int
+		wantDestination bool
+	}{
+		{
+			name:            "verified sender",
+			link:            db.GetLinkForRedirectRow{OriginalUrl: "https://example.com/offer"},
+			verified:        true,
+			expectedStatus:  http.StatusOK,
+			wantDestination: true,
+		},
+		{
+			name:           "email-gated link hides its destination",
+			link:           db.GetLinkForRedirectRow{OriginalUrl: "https://example.com/offer", CaptureEmail: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "private link",
+			link:           db.GetLinkForRedirectRow{OriginalUrl: "https://example.com/offer", Visibility: service.LinkVisibilityPrivate},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "link not found",
+			linkErr:        apperrors.LinkNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return tt.link, tt.linkErr
+				},
+				CanAccessPrivateLinkFunc: func(link db.GetLinkForRedirectRow, viewerID string, token string) bool {
+					return false
+				},
+				SenderVerificationFunc: func(ctx context.Context, shortcode string, destination string) (db.VerifiedSender, bool, error) {
+					if !tt.verified {
+						return db.VerifiedSender{}, false, nil
+					}
+					return db.VerifiedSender{Kind: service.VerifiedSenderUser, Value: "user_123", Name: &name}, true, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, shortURLBase: "https://sho.rt", logger: createTestLogger()}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123/preview", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}/preview", handler.PublicPreview)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("PublicPreview() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.PublicLinkPreview]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.ShortURL != "https://sho.rt/abc123" {
+				t.Errorf("short_url = %q, want https://sho.rt/abc123", resp.Data.ShortURL)
+			}
+			if (resp.Data.Destination != nil) != tt.wantDestination {
+				t.Errorf("destination = %v, want shown = %v", resp.Data.Destination, tt.wantDestination)
+			}
+			if resp.Data.Verified != tt.verified {
+				t.Errorf("verified = %v, want %v", resp.Data.Verified, tt.verified)
+			}
+			if tt.verified && (resp.Data.VerifiedSender == nil || *resp.Data.VerifiedSender != name) {
+				t.Errorf("verified_sender = %v, want %q", resp.Data.VerifiedSender, name)
+			}
+		})
+	}
+}
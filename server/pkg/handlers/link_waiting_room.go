@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// waitingRoomTemplate is the holding page links serve while their waiting room is active.
+// It reloads itself every RetryAfter seconds, through the meta refresh when scripts are off.
+var waitingRoomTemplate = template.Must(template.New("waiting-room").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head>
+		<title>{{.Title}}</title>
+		<meta http-equiv="refresh" content="{{.RetryAfter}}">
+		<style>
+			body { font-family: system-ui, sans-serif; max-width: 32rem; margin: 4rem auto; padding: 0 1rem; text-align: center; color: #1f2933; }
+			.spinner { width: 2.5rem; height: 2.5rem; margin: 2rem auto; border: 4px solid #e4e7eb; border-top-color: #3e4c59; border-radius: 50%; animation: spin 1s linear infinite; }
+			@keyframes spin { to { transform: rotate(360deg); } }
+		</style>
+	</head>
+	<body>
+		<h1>{{.Heading}}</h1>
+		<p>{{.Message}}</p>
+		<div class="spinner"></div>
+		<p>{{.Retrying}} <span id="countdown">{{.RetryAfter}}</span></p>
+		<script>
+			var remaining = {{.RetryAfter}};
+			setInterval(function () {
+				remaining = Math.max(remaining - 1, 0);
+				document.getElementById("countdown").textContent = remaining;
+				if (remaining === 0) { window.location.reload(); }
+			}, 1000);
+		</script>
+	</body>
+</html>`))
+
+func waitingRoomResponse(room db.GetLinkWaitingRoomRow) dto.WaitingRoom {
+	resp := dto.WaitingRoom{
+		Active:     room.Active,
+		Message:    room.Message,
+		RetryAfter: room.RetryAfter,
+		UpdatedAt:  room.UpdatedAt.Time,
+	}
+	if room.ActivatedAt.Valid {
+		resp.ActivatedAt = &room.ActivatedAt.Time
+	}
+	return resp
+}
+
+// renderWaitingRoom writes the holding page of a link whose waiting room is active.
+// Nothing is sent to the destination and no click is recorded.
+func (h *LinkHandler) renderWaitingRoom(w http.ResponseWriter, r *http.Request, link db.GetLinkForRedirectRow) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	message := i18n.T(lang, "waiting_room.message")
+	if link.WaitingRoomMessage != nil && *link.WaitingRoomMessage != "" {
+		message = *link.WaitingRoomMessage
+	}
+	retryAfter := int32(service.DefaultWaitingRoomRetryAfter)
+	if link.WaitingRoomRetryAfter != nil {
+		retryAfter = *link.WaitingRoomRetryAfter
+	}
+
+	metrics.WaitingRoomServed.Add(1)
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	var buf bytes.Buffer
+	if err := waitingRoomTemplate.Execute(&buf, map[string]any{
+		"Lang":       lang,
+		"Title":      i18n.T(lang, "waiting_room.title"),
+		"Heading":    i18n.T(lang, "waiting_room.heading"),
+		"Message":    message,
+		"Retrying":   i18n.T(lang, "waiting_room.retrying"),
+		"RetryAfter": retryAfter,
+	}); err != nil {
+		h.logger.Error("Failed to render waiting room page",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	render.Status(r, http.StatusServiceUnavailable)
+	render.HTML(w, r, buf.String())
+}
+
+// GetWaitingRoom: GET /api/v1/links/{id}/waiting-room
+func (h *LinkHandler) GetWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := h.LinkService.GetWaitingRoom(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WaitingRoom]{
+		Data: waitingRoomResponse(room),
+	})
+}
+
+// SetWaitingRoom: PUT /api/v1/links/{id}/waiting-room
+// The response that creates the waiting room is the only one that includes its webhook token.
+func (h *LinkHandler) SetWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.SetWaitingRoom](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	retryAfter := int32(service.DefaultWaitingRoomRetryAfter)
+	if reqBody.RetryAfter != nil {
+		retryAfter = *reqBody.RetryAfter
+	}
+
+	room, token, err := h.LinkService.SetWaitingRoom(r.Context(), userID, linkID, reqBody.Active, reqBody.Message, retryAfter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := waitingRoomResponse(db.GetLinkWaitingRoomRow(room))
+	data.WebhookToken = token
+
+	status := http.StatusOK
+	if token != "" {
+		status = http.StatusCreated
+	}
+
+	render.Status(r, status)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WaitingRoom]{
+		Data: data,
+	})
+}
+
+// DeleteWaitingRoom: DELETE /api/v1/links/{id}/waiting-room
+func (h *LinkHandler) DeleteWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, ok := h.parseLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := h.LinkService.DeleteWaitingRoom(r.Context(), userID, linkID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WaitingRoom]{
+		Data: waitingRoomResponse(db.GetLinkWaitingRoomRow(room)),
+	})
+}
+
+/*
+WaitingRoomWebhook: POST /integrations/waiting-room
+
+Called by a destination's monitoring to open the link's waiting room when the
+destination is overloaded, and to close it once it recovers. The room's webhook
+token is sent as a bearer token, or as ?token= for tools that can't set headers.
+*/
+func (h *LinkHandler) WaitingRoomWebhook(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.WaitingRoomEvent](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+
+	room, err := h.LinkService.SetWaitingRoomActiveByToken(r.Context(), token, *reqBody.Active)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WaitingRoom]{
+		Data: waitingRoomResponse(db.GetLinkWaitingRoomRow{
+			LinkID:      room.LinkID,
+			Active:      room.Active,
+			Message:     room.Message,
+			RetryAfter:  room.RetryAfter,
+			ActivatedAt: room.ActivatedAt,
+			UpdatedAt:   room.UpdatedAt,
+		}),
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestLinkHandler_RedirectWaitingRoom(t *testing.T) {
+	message := "Tickets are selling fast, hang tight"
+	retryAfter := int32(10)
+
+	tests := []struct {
+		name         string
+		active       bool
+		expectedCode int
+		expectedBody []string
+	}{
+		{
+			name:         "active room serves the holding page",
+			active:       true,
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: []string{message, `content="10"`},
+		},
+		{
+			name:         "inactive room redirects",
+			active:       false,
+			expectedCode: http.StatusFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{
+						ID:                    uuid.New(),
+						OriginalUrl:           "https://tickets.example.com",
+						Visibility:            service.LinkVisibilityPublic,
+						WaitingRoom:           tt.active,
+						WaitingRoomMessage:    &message,
+						WaitingRoomRetryAfter: &retryAfter,
+					}, nil
+				},
+			}
+			clicks := &mockClickRecorder{clicks: make(chan service.Click, 1)}
+			handler := &LinkHandler{
+				LinkService: mockService,
+				clicks:      clicks,
+				logger:      createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Redirect() status = %d, want %d", w.Code, tt.expectedCode)
+			}
+			if !tt.active {
+				return
+			}
+
+			if got := w.Header().Get("Retry-After"); got != "10" {
+				t.Errorf("Redirect() Retry-After = %q, want %q", got, "10")
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("Redirect() body missing %q:\n%s", s, body)
+				}
+			}
+			if strings.Contains(body, "tickets.example.com") {
+				t.Error("Redirect() holding page leaks the destination")
+			}
+
+			select {
+			case <-clicks.clicks:
+				t.Error("click recorded while the waiting room is active")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestLinkHandler_WaitingRoomWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		header       string
+		expectedCode int
+	}{
+		{name: "bearer token", target: "/integrations/waiting-room", header: "Bearer wr_valid", expectedCode: http.StatusOK},
+		{name: "query token", target: "/integrations/waiting-room?token=wr_valid", expectedCode: http.StatusOK},
+		{name: "invalid token", target: "/integrations/waiting-room?token=wr_invalid", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				SetWaitingRoomActiveByTokenFunc: func(ctx context.Context, token string, active bool) (db.SetLinkWaitingRoomActiveByTokenRow, error) {
+					if token != "wr_valid" {
+						return db.SetLinkWaitingRoomActiveByTokenRow{}, apperrors.InvalidWaitingRoomToken
+					}
+					return db.SetLinkWaitingRoomActiveByTokenRow{LinkID: uuid.New(), Active: active, RetryAfter: 30}, nil
+				},
+			}
+			handler := &LinkHandler{LinkService: mockService, logger: createTestLogger()}
+
+			active := true
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			req = req.WithContext(middleware.WithRequestBody(req.Context(), dto.WaitingRoomEvent{Active: &active}))
+			w := httptest.NewRecorder()
+
+			handler.WaitingRoomWebhook(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("WaitingRoomWebhook() status = %d, want %d: %s", w.Code, tt.expectedCode, w.Body.String())
+			}
+		})
+	}
+}
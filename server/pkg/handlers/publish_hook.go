@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// PublishHookService defines the service methods needed by PublishHookHandler
+type PublishHookService interface {
+	CreateHook(ctx context.Context, userID string, name string, tagID *uuid.UUID, callbackURL *string) (db.CreatePublishHookRow, string, error)
+	ListHooks(ctx context.Context, userID string) ([]db.ListUserPublishHooksRow, error)
+	DeleteHook(ctx context.Context, userID string, id uuid.UUID) (db.DeletePublishHookRow, error)
+	Authenticate(ctx context.Context, token string) (db.UsePublishHookRow, error)
+	SendCallback(ctx context.Context, callbackURL string, callback service.PublishCallback) error
+}
+
+// LinkPublisher shortens published articles on behalf of a hook's owner
+type LinkPublisher interface {
+	QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error)
+	AddTagsToLink(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error)
+}
+
+type PublishHookHandler struct {
+	PublishHookService PublishHookService
+	links              LinkPublisher
+	// Origin short URLs are built on, the request's origin when empty
+	shortURLBase string
+	logger       logger.Logger
+}
+
+func NewPublishHookHandler(publishHookService PublishHookService, links LinkPublisher, shortURLBase string, logger logger.Logger) *PublishHookHandler {
+	return &PublishHookHandler{
+		PublishHookService: publishHookService,
+		links:              links,
+		shortURLBase:       shortURLBase,
+		logger:             logger,
+	}
+}
+
+/*
+Publish: POST /integrations/publish-hook
+
+Called by a CMS when an article is published. The hook token is sent as a bearer
+token, or as ?token= for CMSes that can't set headers. The article URL is
+shortened with default settings, reusing the owner's existing link to it, and
+tagged with the hook's tag. When the hook has a callback URL, the short URL is
+posted to it in the background.
+*/
+func (h *PublishHookHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.PublishEvent](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+
+	hook, err := h.PublishHookService.Authenticate(r.Context(), token)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	link, deduplicated, err := h.links.QuickShorten(r.Context(), hook.UserID, reqBody.URL, reqBody.Title)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if hook.TagID.Valid {
+		if _, err := h.links.AddTagsToLink(r.Context(), hook.UserID, link.ID, []uuid.UUID{hook.TagID.Bytes}); err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+	}
+
+	shortURL := shortURLBaseFor(h.shortURLBase, r) + "/" + link.Shortcode
+
+	h.logger.Info("Article shortened by publish hook",
+		zap.String("user_id", hook.UserID),
+		zap.String("hook_id", hook.ID.String()),
+		zap.String("shortcode", link.Shortcode),
+		zap.Bool("deduplicated", deduplicated),
+	)
+
+	if hook.CallbackUrl != nil {
+		h.sendCallback(r, *hook.CallbackUrl, service.PublishCallback{
+			HookID:       hook.ID,
+			LinkID:       link.ID,
+			URL:          reqBody.URL,
+			Shortcode:    link.Shortcode,
+			ShortURL:     shortURL,
+			Deduplicated: deduplicated,
+		})
+	}
+
+	status := http.StatusCreated
+	if deduplicated {
+		status = http.StatusOK
+	}
+
+	render.Status(r, status)
+	render.JSON(w, r, &dto.SuccessResponse[dto.QuickShortenResult]{
+		Data: dto.QuickShortenResult{
+			ID:           link.ID,
+			Shortcode:    link.Shortcode,
+			ShortURL:     shortURL,
+			OriginalURL:  link.OriginalUrl,
+			Title:        link.Title,
+			Deduplicated: deduplicated,
+		},
+	})
+}
+
+// sendCallback posts the callback without holding up the CMS's request
+func (h *PublishHookHandler) sendCallback(r *http.Request, callbackURL string, callback service.PublishCallback) {
+	ctx := context.WithoutCancel(r.Context())
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, service.PublishCallbackTimeout)
+		defer cancel()
+
+		if err := h.PublishHookService.SendCallback(ctx, callbackURL, callback); err != nil {
+			h.logger.Warn("Publish hook callback failed",
+				zap.Error(err),
+				zap.String("hook_id", callback.HookID.String()),
+				zap.String("callback_url", callbackURL),
+			)
+		}
+	}()
+}
+
+// ListHooks: GET /api/v1/integrations/publish-hooks
+func (h *PublishHookHandler) ListHooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hooks, err := h.PublishHookService.ListHooks(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := make([]dto.PublishHook, 0, len(hooks))
+	for _, hook := range hooks {
+		data = append(data, publishHookResponse(hook))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.PublishHook]{
+		Data: data,
+	})
+}
+
+// CreateHook: POST /api/v1/integrations/publish-hooks
+// The response is the only one that includes the hook's token.
+func (h *PublishHookHandler) CreateHook(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreatePublishHook](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hook, token, err := h.PublishHookService.CreateHook(r.Context(), userID, reqBody.Name, reqBody.TagID, reqBody.CallbackURL)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := publishHookResponse(db.ListUserPublishHooksRow(hook))
+	data.Token = token
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.PublishHook]{
+		Data: data,
+	})
+}
+
+// DeleteHook: DELETE /api/v1/integrations/publish-hooks/{id}
+func (h *PublishHookHandler) DeleteHook(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	hook, err := h.PublishHookService.DeleteHook(r.Context(), userID, hookID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.PublishHook]{
+		Data: publishHookResponse(db.ListUserPublishHooksRow(hook)),
+	})
+}
+
+func publishHookResponse(hook db.ListUserPublishHooksRow) dto.PublishHook {
+	resp := dto.PublishHook{
+		ID:          hook.ID,
+		Name:        hook.Name,
+		CallbackURL: hook.CallbackUrl,
+		CreatedAt:   hook.CreatedAt.Time,
+	}
+	if hook.TagID.Valid {
+		tagID := uuid.UUID(hook.TagID.Bytes)
+		resp.TagID = &tagID
+	}
+	if hook.LastUsedAt.Valid {
+		resp.LastUsedAt = &hook.LastUsedAt.Time
+	}
+	return resp
+}
+
+func (h *PublishHookHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.InvalidPublishHookToken):
+		h.logger.Warn("Invalid publish hook token",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidPublishHookToken,
+				Title:  apperrors.InvalidPublishHookToken.Error(),
+				Detail: "Send the hook token as a bearer token or the token query parameter",
+			},
+		})
+
+	case errors.Is(err, apperrors.PublishHookNotFound):
+		h.logger.Warn("Publish hook not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodePublishHookNotFound,
+				Title:  apperrors.PublishHookNotFound.Error(),
+				Detail: "Unable to find publish hook with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.TagNotFound):
+		h.logger.Warn("Tag not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTagNotFound,
+				Title:  apperrors.TagNotFound.Error(),
+				Detail: "tag_id must be one of your tags",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidURL):
+		h.logger.Warn("Invalid URL",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidURL,
+				Title:  apperrors.InvalidURL.Error(),
+				Detail: "",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkQuotaExceeded):
+		h.logger.Warn("Link quota exceeded",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkQuotaExceeded,
+				Title:  apperrors.LinkQuotaExceeded.Error(),
+				Detail: "The hook owner has reached the maximum number of links for their account",
+			},
+		})
+
+	case linkPolicyViolation(err) != nil:
+		violation := linkPolicyViolation(err)
+		h.logger.Warn("Link policy violated",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   linkPolicyErrorCodes[violation],
+				Title:  violation.Error(),
+				Detail: err.Error(),
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type mockPublishHookService struct {
+	hook      db.UsePublishHookRow
+	callbacks chan service.PublishCallback
+}
+
+func (m *mockPublishHookService) CreateHook(ctx context.Context, userID string, name string, tagID *uuid.UUID, callbackURL *string) (db.CreatePublishHookRow, string, error) {
+	return db.CreatePublishHookRow{ID: m.hook.ID, Name: name}, "ph_token", nil
+}
+
+func (m *mockPublishHookService) ListHooks(ctx context.Context, userID string) ([]db.ListUserPublishHooksRow, error) {
+	return nil, nil
+}
+
+func (m *mockPublishHookService) DeleteHook(ctx context.Context, userID string, id uuid.UUID) (db.DeletePublishHookRow, error) {
+	return db.DeletePublishHookRow{}, apperrors.PublishHookNotFound
+}
+
+func (m *mockPublishHookService) Authenticate(ctx context.Context, token string) (db.UsePublishHookRow, error) {
+	if token != "ph_valid" {
+		return db.UsePublishHookRow{}, apperrors.InvalidPublishHookToken
+	}
+	return m.hook, nil
+}
+
+func (m *mockPublishHookService) SendCallback(ctx context.Context, callbackURL string, callback service.PublishCallback) error {
+	m.callbacks <- callback
+	return nil
+}
+
+type mockLinkPublisher struct {
+	taggedWith []uuid.UUID
+}
+
+func (m *mockLinkPublisher) QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error) {
+	return db.TryCreateLinkRow{ID: uuid.New(), Shortcode: "abc123", OriginalUrl: originalURL}, false, nil
+}
+
+func (m *mockLinkPublisher) AddTagsToLink(ctx context.Context, userID string, linkID uuid.UUID, tagIDs []uuid.UUID) (db.GetLinkByIdAndUserWithTagsRow, error) {
+	m.taggedWith = tagIDs
+	return db.GetLinkByIdAndUserWithTagsRow{ID: linkID}, nil
+}
+
+func TestPublishHookHandler_Publish(t *testing.T) {
+	tagID := uuid.New()
+	callbackURL := "https://cms.example.com/short-links"
+
+	tests := []struct {
+		name           string
+		authorization  string
+		query          string
+		expectedStatus int
+		expectTagged   bool
+	}{
+		{
+			name:           "bearer token",
+			authorization:  "Bearer ph_valid",
+			expectedStatus: http.StatusCreated,
+			expectTagged:   true,
+		},
+		{
+			name:           "token query parameter",
+			query:          "?token=ph_valid",
+			expectedStatus: http.StatusCreated,
+			expectTagged:   true,
+		},
+		{
+			name:           "invalid token",
+			authorization:  "Bearer ph_forged",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := &mockPublishHookService{
+				hook: db.UsePublishHookRow{
+					ID:          uuid.New(),
+					UserID:      "user_123",
+					TagID:       pgtype.UUID{Bytes: tagID, Valid: true},
+					CallbackUrl: &callbackURL,
+				},
+				callbacks: make(chan service.PublishCallback, 1),
+			}
+			links := &mockLinkPublisher{}
+			handler := NewPublishHookHandler(hooks, links, "https://sho.rt", createTestLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/integrations/publish-hook"+tt.query, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			ctx := middleware.WithRequestBody(req.Context(), dto.PublishEvent{URL: "https://blog.example.com/post"})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.Publish(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tagged := len(links.taggedWith) == 1 && links.taggedWith[0] == tagID; tagged != tt.expectTagged {
+				t.Errorf("tagged with %v, want tagged %v", links.taggedWith, tt.expectTagged)
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.QuickShortenResult]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.ShortURL != "https://sho.rt/abc123" {
+				t.Errorf("short_url = %q, want https://sho.rt/abc123", resp.Data.ShortURL)
+			}
+
+			select {
+			case callback := <-hooks.callbacks:
+				if callback.ShortURL != resp.Data.ShortURL || callback.URL != "https://blog.example.com/post" {
+					t.Errorf("callback = %+v, want the short URL of the article", callback)
+				}
+			case <-time.After(time.Second):
+				t.Error("callback wasn't sent")
+			}
+		})
+	}
+}
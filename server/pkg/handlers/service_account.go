@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// ServiceAccountService defines the service methods needed by ServiceAccountHandler
+type ServiceAccountService interface {
+	Create(ctx context.Context, adminID string, ownerID string, name string, scopes []string) (db.ServiceAccount, string, error)
+	List(ctx context.Context, ownerID *string) ([]db.ServiceAccount, error)
+	Get(ctx context.Context, id uuid.UUID) (db.ServiceAccount, error)
+	Update(ctx context.Context, adminID string, id uuid.UUID, name *string, scopes []string) (db.ServiceAccount, error)
+	RotateToken(ctx context.Context, adminID string, id uuid.UUID, grace time.Duration) (db.ServiceAccount, string, error)
+	RevokePreviousToken(ctx context.Context, adminID string, id uuid.UUID) (db.ServiceAccount, error)
+	Delete(ctx context.Context, adminID string, id uuid.UUID) (db.ServiceAccount, error)
+}
+
+// ServiceAccountHandler serves the admin routes managing service accounts
+type ServiceAccountHandler struct {
+	ServiceAccountService ServiceAccountService
+	logger                logger.Logger
+}
+
+func NewServiceAccountHandler(serviceAccountService ServiceAccountService, logger logger.Logger) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		ServiceAccountService: serviceAccountService,
+		logger:                logger,
+	}
+}
+
+// ListServiceAccounts: GET /api/v1/admin/service-accounts?owner_id=user_123
+func (h *ServiceAccountHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	var ownerID *string
+	if owner := r.URL.Query().Get("owner_id"); owner != "" {
+		ownerID = &owner
+	}
+
+	accounts, err := h.ServiceAccountService.List(r.Context(), ownerID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Always an array, never null
+	data := make([]dto.ServiceAccount, 0, len(accounts))
+	for _, account := range accounts {
+		data = append(data, serviceAccountResponse(account))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.ServiceAccount]{
+		Data: data,
+	})
+}
+
+/*
+CreateServiceAccount: POST /api/v1/admin/service-accounts
+
+Creates a service account working on the owner's links, limited to its
+scopes. The token is only returned here; it's sent as a bearer token.
+*/
+func (h *ServiceAccountHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateServiceAccount](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	account, token, err := h.ServiceAccountService.Create(r.Context(), adminID, reqBody.OwnerID, reqBody.Name, reqBody.Scopes)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := serviceAccountResponse(account)
+	data.Token = token
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ServiceAccount]{
+		Data: data,
+	})
+}
+
+// UpdateServiceAccount: PATCH /api/v1/admin/service-accounts/{id}
+func (h *ServiceAccountHandler) UpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.UpdateServiceAccount](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	id, ok := h.parseServiceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.ServiceAccountService.Update(r.Context(), adminID, id, reqBody.Name, reqBody.Scopes)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ServiceAccount]{
+		Data: serviceAccountResponse(account),
+	})
+}
+
+/*
+RotateToken: POST /api/v1/admin/service-accounts/{id}/rotate-token
+
+Returns the account's new token. The previous one keeps working for
+previous_token_ttl seconds (a day by default) so clients can switch over;
+GET .../usage shows whether they still use it.
+*/
+func (h *ServiceAccountHandler) RotateToken(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.RotateServiceAccountToken](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	id, ok := h.parseServiceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	grace := service.DefaultServiceAccountTokenGrace
+	if reqBody.PreviousTokenTTL != nil {
+		grace = time.Duration(*reqBody.PreviousTokenTTL) * time.Second
+	}
+
+	account, token, err := h.ServiceAccountService.RotateToken(r.Context(), adminID, id, grace)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := serviceAccountResponse(account)
+	data.Token = token
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ServiceAccount]{
+		Data: data,
+	})
+}
+
+// RevokePreviousToken: DELETE /api/v1/admin/service-accounts/{id}/previous-token
+func (h *ServiceAccountHandler) RevokePreviousToken(w http.ResponseWriter, r *http.Request) {
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	id, ok := h.parseServiceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.ServiceAccountService.RevokePreviousToken(r.Context(), adminID, id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ServiceAccountUsage]{
+		Data: serviceAccountUsageResponse(account, time.Now()),
+	})
+}
+
+// GetUsage: GET /api/v1/admin/service-accounts/{id}/usage
+func (h *ServiceAccountHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseServiceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.ServiceAccountService.Get(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ServiceAccountUsage]{
+		Data: serviceAccountUsageResponse(account, time.Now()),
+	})
+}
+
+// DeleteServiceAccount: DELETE /api/v1/admin/service-accounts/{id}
+func (h *ServiceAccountHandler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	id, ok := h.parseServiceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.ServiceAccountService.Delete(r.Context(), adminID, id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ServiceAccount]{
+		Data: serviceAccountResponse(account),
+	})
+}
+
+// parseServiceAccountID reads the {id} URL parameter, answering with a 400 when it isn't a UUID
+func (h *ServiceAccountHandler) parseServiceAccountID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return uuid.UUID{}, false
+	}
+
+	return id, true
+}
+
+func serviceAccountResponse(account db.ServiceAccount) dto.ServiceAccount {
+	resp := dto.ServiceAccount{
+		ID:        account.ID,
+		OwnerID:   account.OwnerID,
+		Name:      account.Name,
+		Scopes:    account.Scopes,
+		CreatedBy: account.CreatedBy,
+		CreatedAt: account.CreatedAt.Time,
+	}
+	if account.LastUsedAt.Valid {
+		resp.LastUsedAt = &account.LastUsedAt.Time
+	}
+	return resp
+}
+
+// serviceAccountUsageResponse reports the account's use; a previous token whose grace period ended at now is left out
+func serviceAccountUsageResponse(account db.ServiceAccount, now time.Time) dto.ServiceAccountUsage {
+	resp := dto.ServiceAccountUsage{
+		ID:         account.ID,
+		LastUsedIP: account.LastUsedIp,
+		UseCount:   account.UseCount,
+	}
+	if account.LastUsedAt.Valid {
+		resp.LastUsedAt = &account.LastUsedAt.Time
+	}
+	if account.TokenRotatedAt.Valid {
+		resp.TokenRotatedAt = &account.TokenRotatedAt.Time
+	}
+	if account.PreviousTokenHash != nil && account.PreviousTokenExpiresAt.Valid && account.PreviousTokenExpiresAt.Time.After(now) {
+		resp.PreviousToken = &dto.PreviousServiceAccountToken{ExpiresAt: account.PreviousTokenExpiresAt.Time}
+		if account.PreviousTokenLastUsedAt.Valid {
+			resp.PreviousToken.LastUsedAt = &account.PreviousTokenLastUsedAt.Time
+		}
+	}
+	return resp
+}
+
+func (h *ServiceAccountHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.ServiceAccountNotFound):
+		h.logger.Warn("Service account not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeServiceAccountNotFound,
+				Title:  apperrors.ServiceAccountNotFound.Error(),
+				Detail: "",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
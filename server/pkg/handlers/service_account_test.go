@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func TestServiceAccountUsageResponse(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	previous := "5f2c..."
+	ip := "203.0.113.7"
+	account := db.ServiceAccount{
+		ID:                      uuid.New(),
+		LastUsedAt:              pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true},
+		LastUsedIp:              &ip,
+		UseCount:                42,
+		TokenRotatedAt:          pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true},
+		PreviousTokenHash:       &previous,
+		PreviousTokenExpiresAt:  pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true},
+		PreviousTokenLastUsedAt: pgtype.Timestamptz{Time: now.Add(-30 * time.Minute), Valid: true},
+	}
+
+	usage := serviceAccountUsageResponse(account, now)
+	if usage.UseCount != 42 || usage.LastUsedIP == nil || *usage.LastUsedIP != ip {
+		t.Errorf("usage = %+v, want 42 uses, last from %s", usage, ip)
+	}
+	if usage.PreviousToken == nil || usage.PreviousToken.LastUsedAt == nil {
+		t.Fatalf("previous token = %+v, want it reported with its last use", usage.PreviousToken)
+	}
+
+	// Once its grace period is over, the previous token no longer works and isn't reported
+	if usage := serviceAccountUsageResponse(account, now.Add(2*time.Hour)); usage.PreviousToken != nil {
+		t.Errorf("previous token = %+v after its grace period, want nil", usage.PreviousToken)
+	}
+}
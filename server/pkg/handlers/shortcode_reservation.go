@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// ShortcodeReservationService defines the service methods needed by ShortcodeReservationHandler
+type ShortcodeReservationService interface {
+	Reserve(ctx context.Context, userID string, shortcode *string) (db.ShortcodeReservation, error)
+	ListReservations(ctx context.Context, userID string) ([]db.ShortcodeReservation, error)
+	Release(ctx context.Context, userID string, shortcode string) (db.ShortcodeReservation, error)
+}
+
+type ShortcodeReservationHandler struct {
+	ReservationService ShortcodeReservationService
+	// Origin short URLs are built on, the request's origin when empty
+	shortURLBase string
+	logger       logger.Logger
+}
+
+func NewShortcodeReservationHandler(reservationService ShortcodeReservationService, shortURLBase string, logger logger.Logger) *ShortcodeReservationHandler {
+	return &ShortcodeReservationHandler{
+		ReservationService: reservationService,
+		shortURLBase:       shortURLBase,
+		logger:             logger,
+	}
+}
+
+/*
+Reserve: POST /api/v1/shortcodes/reserve
+
+Holds a shortcode before its destination is known. Its short URL serves a
+placeholder page until the destination is attached by creating a link with
+the shortcode (POST /api/v1/links).
+*/
+func (h *ShortcodeReservationHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.ReserveShortcode](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	reservation, err := h.ReservationService.Reserve(r.Context(), userID, reqBody.Shortcode)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ShortcodeReservation]{
+		Data: h.reservationResponse(r, reservation),
+	})
+}
+
+// ListReservations: GET /api/v1/shortcodes/reserved
+func (h *ShortcodeReservationHandler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	reservations, err := h.ReservationService.ListReservations(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := make([]dto.ShortcodeReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		resp = append(resp, h.reservationResponse(r, reservation))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.ShortcodeReservation]{
+		Data: resp,
+	})
+}
+
+// Release: DELETE /api/v1/shortcodes/reserved/{shortcode}
+func (h *ShortcodeReservationHandler) Release(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	reservation, err := h.ReservationService.Release(r.Context(), userID, chi.URLParam(r, "shortcode"))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.ShortcodeReservation]{
+		Data: h.reservationResponse(r, reservation),
+	})
+}
+
+func (h *ShortcodeReservationHandler) reservationResponse(r *http.Request, reservation db.ShortcodeReservation) dto.ShortcodeReservation {
+	return dto.ShortcodeReservation{
+		Shortcode: reservation.Shortcode,
+		ShortURL:  shortURLBaseFor(h.shortURLBase, r) + "/" + reservation.Shortcode,
+		CreatedAt: reservation.CreatedAt.Time,
+	}
+}
+
+func (h *ShortcodeReservationHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.LinkShortcodeTaken):
+		h.logger.Warn("Shortcode already taken",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCodeTaken,
+				Title:  apperrors.LinkShortcodeTaken.Error(),
+				Detail: "The provided shortcode is already in use or reserved",
+			},
+		})
+
+	case errors.Is(err, apperrors.ShortcodeReserved):
+		h.logger.Warn("Shortcode is reserved",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCodeReserved,
+				Title:  apperrors.ShortcodeReserved.Error(),
+				Detail: "The provided shortcode is reserved by the system",
+			},
+		})
+
+	case errors.Is(err, apperrors.ReservationNotFound):
+		h.logger.Warn("Shortcode reservation not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeReservationNotFound,
+				Title:  apperrors.ReservationNotFound.Error(),
+				Detail: "You have no reservation of this shortcode, or a link already uses it",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+)
+
+type mockReservationService struct {
+	taken string
+}
+
+func (m *mockReservationService) Reserve(ctx context.Context, userID string, shortcode *string) (db.ShortcodeReservation, error) {
+	code := "Xy12Ab34Z"
+	if shortcode != nil {
+		code = *shortcode
+	}
+	if code == m.taken {
+		return db.ShortcodeReservation{}, apperrors.LinkShortcodeTaken
+	}
+	return db.ShortcodeReservation{Shortcode: code, UserID: userID}, nil
+}
+
+func (m *mockReservationService) ListReservations(ctx context.Context, userID string) ([]db.ShortcodeReservation, error) {
+	return nil, nil
+}
+
+func (m *mockReservationService) Release(ctx context.Context, userID string, shortcode string) (db.ShortcodeReservation, error) {
+	return db.ShortcodeReservation{}, apperrors.ReservationNotFound
+}
+
+func TestShortcodeReservationHandler_Reserve(t *testing.T) {
+	spring := "spring"
+	taken := "taken"
+
+	tests := []struct {
+		name             string
+		shortcode        *string
+		expectedStatus   int
+		expectedShortURL string
+	}{
+		{name: "custom shortcode", shortcode: &spring, expectedStatus: http.StatusCreated, expectedShortURL: "https://sho.rt/spring"},
+		{name: "random shortcode", expectedStatus: http.StatusCreated, expectedShortURL: "https://sho.rt/Xy12Ab34Z"},
+		{name: "taken shortcode", shortcode: &taken, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewShortcodeReservationHandler(&mockReservationService{taken: taken}, "https://sho.rt", createTestLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/shortcodes/reserve", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, dto.ReserveShortcode{Shortcode: tt.shortcode})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.Reserve(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.ShortcodeReservation]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.ShortURL != tt.expectedShortURL {
+				t.Errorf("short_url = %q, want %q", resp.Data.ShortURL, tt.expectedShortURL)
+			}
+		})
+	}
+}
+
+func TestShortcodeReservationHandler_Release(t *testing.T) {
+	handler := NewShortcodeReservationHandler(&mockReservationService{}, "https://sho.rt", createTestLogger())
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/shortcodes/reserved/spring", nil)
+	req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Delete("/api/v1/shortcodes/reserved/{shortcode}", handler.Release)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestLinkHandler_RedirectPending(t *testing.T) {
+	tests := []struct {
+		name           string
+		placeholderURL string
+		expectedStatus int
+	}{
+		{name: "built-in placeholder page", expectedStatus: http.StatusOK},
+		{name: "configured placeholder page", placeholderURL: "https://example.com/coming-soon", expectedStatus: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockLinkService{
+				GetOriginalURLFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+					return db.GetLinkForRedirectRow{}, apperrors.LinkPending
+				},
+			}
+			handler := &LinkHandler{
+				LinkService:    mockService,
+				placeholderURL: tt.placeholderURL,
+				logger:         createTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/spring", nil)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/{shortcode}", handler.Redirect)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Redirect() status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			if tt.placeholderURL != "" {
+				if got := w.Header().Get("Location"); got != tt.placeholderURL {
+					t.Errorf("Location = %q, want %q", got, tt.placeholderURL)
+				}
+			} else if !strings.Contains(w.Body.String(), "Coming Soon") {
+				t.Errorf("Redirect() body = %s, want the pending page", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+// How long browsers may cache robots.txt and noise path answers
+const siteCacheMaxAge = 24 * 60 * 60
+
+// Crawlers that fetch short links to build link previews, allowed even when crawling is disallowed
+var previewCrawlers = []string{
+	"Twitterbot",
+	"facebookexternalhit",
+	"LinkedInBot",
+	"Slackbot-LinkExpanding",
+	"Discordbot",
+	"WhatsApp",
+	"TelegramBot",
+	"Pinterestbot",
+}
+
+// SiteHandler serves the files browsers and crawlers request on their own,
+// so they never reach the shortcode lookup
+type SiteHandler struct {
+	robots string
+	// Where icon requests are redirected; they get a 404 when empty
+	faviconURL string
+	logger     logger.Logger
+}
+
+func NewSiteHandler(allowCrawling bool, sitemapURL string, faviconURL string, logger logger.Logger) *SiteHandler {
+	return &SiteHandler{
+		robots:     robotsTxt(allowCrawling, sitemapURL),
+		faviconURL: faviconURL,
+		logger:     logger,
+	}
+}
+
+// Robots: GET /robots.txt
+func (h *SiteHandler) Robots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", siteCacheMaxAge))
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, h.robots)
+}
+
+// NoisePath: GET /favicon.ico, /apple-touch-icon*.png, /sitemap.xml and similar
+// Answered without a shortcode lookup: icons redirect to the configured favicon, the rest are 404s.
+func (h *SiteHandler) NoisePath(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", siteCacheMaxAge))
+
+	if h.faviconURL != "" && isIconPath(r.URL.Path) {
+		http.Redirect(w, r, h.faviconURL, http.StatusMovedPermanently)
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// isIconPath reports whether the path is one of the icons browsers fetch unprompted
+func isIconPath(path string) bool {
+	name := strings.ToLower(strings.TrimPrefix(path, "/"))
+	return name == "favicon.ico" || (strings.HasPrefix(name, "apple-touch-icon") && strings.HasSuffix(name, ".png"))
+}
+
+// isPreviewCrawler reports whether the user agent is one of the link-preview crawlers
+func isPreviewCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, crawler := range previewCrawlers {
+		if strings.Contains(userAgent, strings.ToLower(crawler)) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+robotsTxt disallows crawling short links unless allowCrawling is set: crawling
+them only follows redirects to other sites. Link-preview crawlers stay allowed
+so shared links still unfurl.
+*/
+func robotsTxt(allowCrawling bool, sitemapURL string) string {
+	var b strings.Builder
+
+	if allowCrawling {
+		b.WriteString("User-agent: *\nAllow: /\n")
+	} else {
+		for _, crawler := range previewCrawlers {
+			b.WriteString("User-agent: " + crawler + "\n")
+		}
+		b.WriteString("Allow: /\n\nUser-agent: *\nDisallow: /\n")
+	}
+
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+
+	return b.String()
+}
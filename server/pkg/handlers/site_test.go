@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSiteHandler_Robots(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowCrawling bool
+		sitemapURL    string
+		contains      []string
+		excludes      []string
+	}{
+		{
+			name:     "disallows crawling by default",
+			contains: []string{"User-agent: *\nDisallow: /\n", "User-agent: Twitterbot\n"},
+			excludes: []string{"Sitemap:"},
+		},
+		{
+			name:          "allows crawling when configured",
+			allowCrawling: true,
+			contains:      []string{"User-agent: *\nAllow: /\n"},
+			excludes:      []string{"Disallow"},
+		},
+		{
+			name:       "adds the sitemap",
+			sitemapURL: "https://example.com/sitemap.xml",
+			contains:   []string{"Sitemap: https://example.com/sitemap.xml\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewSiteHandler(tt.allowCrawling, tt.sitemapURL, "", createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+			w := httptest.NewRecorder()
+			h.Robots(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+				t.Errorf("Content-Type = %q, want text/plain", ct)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(w.Body.String(), s) {
+					t.Errorf("robots.txt missing %q:\n%s", s, w.Body.String())
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(w.Body.String(), s) {
+					t.Errorf("robots.txt contains %q:\n%s", s, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSiteHandler_NoisePath(t *testing.T) {
+	tests := []struct {
+		name             string
+		faviconURL       string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:           "favicon without configured icon is a 404",
+			path:           "/favicon.ico",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:             "favicon redirects to configured icon",
+			faviconURL:       "https://cdn.example.com/icon.png",
+			path:             "/favicon.ico",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://cdn.example.com/icon.png",
+		},
+		{
+			name:             "touch icon redirects to configured icon",
+			faviconURL:       "https://cdn.example.com/icon.png",
+			path:             "/apple-touch-icon-120x120-precomposed.png",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://cdn.example.com/icon.png",
+		},
+		{
+			name:           "manifest is a 404 even with configured icon",
+			faviconURL:     "https://cdn.example.com/icon.png",
+			path:           "/site.webmanifest",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewSiteHandler(false, "", tt.faviconURL, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			h.NoisePath(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.expectedLocation {
+				t.Errorf("Location = %q, want %q", loc, tt.expectedLocation)
+			}
+			if w.Header().Get("Cache-Control") == "" {
+				t.Error("expected a Cache-Control header")
+			}
+		})
+	}
+}
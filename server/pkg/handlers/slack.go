@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// Largest slash command payload accepted from Slack
+const maxSlackCommandBytes = 16 << 10
+
+// Slack message visibility
+const (
+	slackInChannel = "in_channel"
+	slackEphemeral = "ephemeral"
+)
+
+// SlackService defines the service methods needed by SlackHandler
+type SlackService interface {
+	VerifyRequest(timestamp, signature string, body []byte, now time.Time) error
+	AccountUser(ctx context.Context, teamID, slackUserID string) (string, error)
+	LinkToken(teamID, slackUserID string, now time.Time) string
+	LinkAccount(ctx context.Context, userID string, token string, now time.Time) (db.SlackAccount, error)
+}
+
+// LinkCreator creates short links on behalf of a user
+type LinkCreator interface {
+	CreateShortLink(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error)
+}
+
+type SlackHandler struct {
+	SlackService SlackService
+	links        LinkCreator
+	// Web app page that redeems a link token (passed as ?token=)
+	linkURL string
+	// Origin short URLs are built on, the request's origin when empty
+	shortURLBase string
+	logger       logger.Logger
+}
+
+func NewSlackHandler(slackService SlackService, links LinkCreator, linkURL string, shortURLBase string, logger logger.Logger) *SlackHandler {
+	return &SlackHandler{
+		SlackService: slackService,
+		links:        links,
+		linkURL:      linkURL,
+		shortURLBase: shortURLBase,
+		logger:       logger,
+	}
+}
+
+/*
+Command: POST /integrations/slack/commands
+
+Handles "/shorten <url>". Slack only shows responses sent with a 200, so
+every outcome past signature verification is answered with a message:
+the short link in the channel on success, otherwise a note only the caller sees.
+*/
+func (h *SlackHandler) Command(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSlackCommandBytes))
+	if err != nil {
+		h.logger.Warn("Failed to read Slack command",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid request body",
+				Detail: "The command payload couldn't be read",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	if err := h.SlackService.VerifyRequest(r.Header.Get("X-Slack-Request-Timestamp"), r.Header.Get("X-Slack-Signature"), body, now); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		h.reply(w, r, slackEphemeral, "Slack sent a command this server couldn't read. Please try again.")
+		return
+	}
+
+	teamID, slackUserID := form.Get("team_id"), form.Get("user_id")
+	target := slackCommandURL(form.Get("text"))
+	if target == "" || target == "help" {
+		h.reply(w, r, slackEphemeral, fmt.Sprintf("Usage: `%s <url>` shortens the URL and posts the short link here.", form.Get("command")))
+		return
+	}
+
+	userID, err := h.SlackService.AccountUser(r.Context(), teamID, slackUserID)
+	if errors.Is(err, apperrors.SlackAccountNotLinked) {
+		token := h.SlackService.LinkToken(teamID, slackUserID, now)
+		h.reply(w, r, slackEphemeral, fmt.Sprintf(
+			"Your Slack account isn't connected yet. <%s|Connect it> (the link expires in 15 minutes), then run the command again.",
+			h.linkURL+"?token="+url.QueryEscape(token),
+		))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to resolve Slack account",
+			zap.Error(err),
+			zap.String("team_id", teamID),
+			zap.String("slack_user_id", slackUserID),
+		)
+		h.reply(w, r, slackEphemeral, "Something went wrong. Please try again.")
+		return
+	}
+
+	link, err := h.links.CreateShortLink(r.Context(), userID, target, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	switch {
+	case errors.Is(err, apperrors.InvalidURL):
+		h.reply(w, r, slackEphemeral, "That doesn't look like a valid URL: "+target)
+		return
+	case errors.Is(err, apperrors.LinkQuotaExceeded):
+		h.reply(w, r, slackEphemeral, "You've reached your link quota, so no link was created.")
+		return
+	case linkPolicyViolation(err) != nil:
+		h.reply(w, r, slackEphemeral, "The link policy doesn't allow that link: "+err.Error())
+		return
+	case err != nil:
+		h.logger.Error("Failed to create link from Slack",
+			zap.Error(err),
+			zap.String("user_id", userID),
+			zap.String("team_id", teamID),
+		)
+		h.reply(w, r, slackEphemeral, "Something went wrong. Please try again.")
+		return
+	}
+
+	h.logger.Info("Link created from Slack",
+		zap.String("user_id", userID),
+		zap.String("team_id", teamID),
+		zap.String("shortcode", link.Shortcode),
+	)
+
+	h.reply(w, r, slackInChannel, shortURLBaseFor(h.shortURLBase, r)+"/"+link.Shortcode)
+}
+
+// LinkAccount: POST /api/v1/integrations/slack/link
+// Redeems the link token sent to a Slack user, connecting them to the signed-in account.
+func (h *SlackHandler) LinkAccount(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.LinkSlackAccount](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	account, err := h.SlackService.LinkAccount(r.Context(), userID, reqBody.Token, time.Now())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.SlackAccount]{
+		Data: dto.SlackAccount{
+			TeamID:      account.TeamID,
+			SlackUserID: account.SlackUserID,
+			LinkedAt:    account.CreatedAt.Time,
+		},
+	})
+}
+
+func (h *SlackHandler) reply(w http.ResponseWriter, r *http.Request, responseType, text string) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, dto.SlackMessage{
+		ResponseType: responseType,
+		Text:         text,
+	})
+}
+
+// slackCommandURL extracts the URL from the command text.
+// Slack may send links escaped as "<https://example.com>" or "<https://example.com|example.com>".
+func slackCommandURL(text string) string {
+	text = strings.TrimSpace(text)
+	if inner, ok := strings.CutPrefix(text, "<"); ok {
+		if inner, ok = strings.CutSuffix(inner, ">"); ok {
+			text, _, _ = strings.Cut(inner, "|")
+		}
+	}
+	return text
+}
+
+func (h *SlackHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.InvalidSignature):
+		h.logger.Warn("Invalid Slack request signature",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidSignature,
+				Title:  apperrors.InvalidSignature.Error(),
+				Detail: "The request isn't signed with the Slack signing secret or is too old",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidSlackLinkToken):
+		h.logger.Warn("Invalid Slack link token",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidSlackLinkToken,
+				Title:  apperrors.InvalidSlackLinkToken.Error(),
+				Detail: "Run the command in Slack again to get a new link",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type mockSlackService struct {
+	accounts map[string]string
+}
+
+func (m *mockSlackService) VerifyRequest(timestamp, signature string, body []byte, now time.Time) error {
+	if signature != "valid" {
+		return apperrors.InvalidSignature
+	}
+	return nil
+}
+
+func (m *mockSlackService) AccountUser(ctx context.Context, teamID, slackUserID string) (string, error) {
+	userID, ok := m.accounts[teamID+"/"+slackUserID]
+	if !ok {
+		return "", apperrors.SlackAccountNotLinked
+	}
+	return userID, nil
+}
+
+func (m *mockSlackService) LinkToken(teamID, slackUserID string, now time.Time) string {
+	return "token-" + slackUserID
+}
+
+func (m *mockSlackService) LinkAccount(ctx context.Context, userID string, token string, now time.Time) (db.SlackAccount, error) {
+	return db.SlackAccount{}, apperrors.InvalidSlackLinkToken
+}
+
+type mockLinkCreator struct {
+	userID string
+	url    string
+}
+
+func (m *mockLinkCreator) CreateShortLink(ctx context.Context, userID string, originalURL string, customShortcode *string, expiresAt *time.Time, visibility *string, captureEmail *bool, redirectDelay *int32, interstitialMessage *string, appendClickID *bool, title *string, shield *bool, referrerPolicy *string, tagIDs []uuid.UUID, tagNames []string) (db.TryCreateLinkRow, error) {
+	m.userID, m.url = userID, originalURL
+	if !strings.HasPrefix(originalURL, "https://") {
+		return db.TryCreateLinkRow{}, apperrors.InvalidURL
+	}
+	return db.TryCreateLinkRow{Shortcode: "abc123"}, nil
+}
+
+func TestSlackHandler_Command(t *testing.T) {
+	tests := []struct {
+		name           string
+		signature      string
+		slackUserID    string
+		text           string
+		expectedStatus int
+		expectedType   string
+		expectedText   string
+		expectedUserID string
+	}{
+		{
+			name:           "invalid signature",
+			signature:      "forged",
+			slackUserID:    "U1",
+			text:           "https://example.com",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "shortens in channel",
+			signature:      "valid",
+			slackUserID:    "U1",
+			text:           "https://example.com/article",
+			expectedStatus: http.StatusOK,
+			expectedType:   slackInChannel,
+			expectedText:   "https://sho.rt/abc123",
+			expectedUserID: "user_1",
+		},
+		{
+			name:           "unescapes Slack links",
+			signature:      "valid",
+			slackUserID:    "U1",
+			text:           " <https://example.com/article|example.com/article> ",
+			expectedStatus: http.StatusOK,
+			expectedType:   slackInChannel,
+			expectedText:   "https://sho.rt/abc123",
+			expectedUserID: "user_1",
+		},
+		{
+			name:           "invalid URL",
+			signature:      "valid",
+			slackUserID:    "U1",
+			text:           "not a url",
+			expectedStatus: http.StatusOK,
+			expectedType:   slackEphemeral,
+			expectedText:   "doesn't look like a valid URL",
+		},
+		{
+			name:           "unlinked user gets a link",
+			signature:      "valid",
+			slackUserID:    "U2",
+			text:           "https://example.com",
+			expectedStatus: http.StatusOK,
+			expectedType:   slackEphemeral,
+			expectedText:   "https://app.example.com/slack/link?token=token-U2",
+		},
+		{
+			name:           "usage",
+			signature:      "valid",
+			slackUserID:    "U1",
+			expectedStatus: http.StatusOK,
+			expectedType:   slackEphemeral,
+			expectedText:   "Usage: `/shorten <url>`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := &mockLinkCreator{}
+			handler := NewSlackHandler(
+				&mockSlackService{accounts: map[string]string{"T1/U1": "user_1"}},
+				links,
+				"https://app.example.com/slack/link",
+				"https://sho.rt",
+				createTestLogger(),
+			)
+
+			form := url.Values{
+				"team_id": {"T1"},
+				"user_id": {tt.slackUserID},
+				"command": {"/shorten"},
+				"text":    {tt.text},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/integrations/slack/commands", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Slack-Request-Timestamp", "1700000000")
+			req.Header.Set("X-Slack-Signature", tt.signature)
+			w := httptest.NewRecorder()
+
+			handler.Command(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var msg dto.SlackMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if msg.ResponseType != tt.expectedType {
+				t.Errorf("response_type = %q, want %q", msg.ResponseType, tt.expectedType)
+			}
+			if !strings.Contains(msg.Text, tt.expectedText) {
+				t.Errorf("text = %q, want it to contain %q", msg.Text, tt.expectedText)
+			}
+			if tt.expectedUserID != "" {
+				if links.userID != tt.expectedUserID || links.url != "https://example.com/article" {
+					t.Errorf("created %q for %q, want https://example.com/article for %q", links.url, links.userID, tt.expectedUserID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SnapshotService defines the service methods needed by SnapshotHandler
+type SnapshotService interface {
+	Export(ctx context.Context, w io.Writer, since time.Time) error
+}
+
+// SnapshotHandler serves the admin route exporting snapshots of the redirect mappings
+type SnapshotHandler struct {
+	SnapshotService SnapshotService
+	logger          logger.Logger
+}
+
+func NewSnapshotHandler(snapshotService SnapshotService, logger logger.Logger) *SnapshotHandler {
+	return &SnapshotHandler{
+		SnapshotService: snapshotService,
+		logger:          logger,
+	}
+}
+
+/*
+ExportSnapshot: GET /api/v1/admin/snapshot?since=
+
+Streams a snapshot of the shortcode to URL mappings (see package snapshot) for
+edge instances and restores to load. With ?since= (RFC3339 or YYYY-MM-DD) it's
+a diff of the changes after it; pass the taken at time of the last snapshot
+loaded to bring it up to date.
+*/
+func (h *SnapshotHandler) ExportSnapshot(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	filename := "snapshot.csv"
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		t, err := parseStatsTime(sinceStr, time.UTC)
+		if err != nil {
+			h.logger.Warn("Invalid since query parameter",
+				zap.Error(err),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, dto.ErrorResponse{
+				Error: dto.ErrorObject{
+					Code:   apperrors.CodeInvalidRequest,
+					Title:  "Invalid since",
+					Detail: "since must be an RFC3339 timestamp or a YYYY-MM-DD date",
+				},
+			})
+			return
+		}
+		since = t
+		filename = "snapshot-diff.csv"
+	}
+
+	out := &csvAttachment{w: w, filename: filename}
+	if err := h.SnapshotService.Export(r.Context(), out, since); err != nil {
+		if out.started {
+			// Headers are gone: all we can do is cut the download short, which loaders refuse
+			h.logger.Error("Snapshot export failed mid-stream",
+				zap.Error(err),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+			return
+		}
+
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+		return
+	}
+
+	h.logger.Info("Snapshot exported",
+		zap.Time("since", since),
+	)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockSnapshotService struct {
+	since time.Time
+	err   error
+}
+
+func (m *mockSnapshotService) Export(ctx context.Context, w io.Writer, since time.Time) error {
+	m.since = since
+	if m.err != nil {
+		return m.err
+	}
+	_, err := io.WriteString(w, "snapshot,1,2026-10-15T09:00:00Z,\nend,0,e3b0\n")
+	return err
+}
+
+func TestSnapshotHandler_ExportSnapshot(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		err              error
+		expectedStatus   int
+		expectedType     string
+		expectedFilename string
+		expectedSince    time.Time
+	}{
+		{
+			name:             "full snapshot",
+			expectedStatus:   http.StatusOK,
+			expectedType:     "text/csv; charset=utf-8",
+			expectedFilename: "snapshot.csv",
+		},
+		{
+			name:             "diff",
+			query:            "?since=2026-10-15T08:00:00Z",
+			expectedStatus:   http.StatusOK,
+			expectedType:     "text/csv; charset=utf-8",
+			expectedFilename: "snapshot-diff.csv",
+			expectedSince:    time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "invalid since",
+			query:          "?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   "application/json",
+		},
+		{
+			name:           "export fails before writing",
+			err:            errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedType:   "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockSnapshotService{err: tt.err}
+			h := NewSnapshotHandler(svc, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/snapshot"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			h.ExportSnapshot(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.expectedType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.expectedType)
+			}
+			if tt.expectedFilename != "" && !strings.Contains(rr.Header().Get("Content-Disposition"), tt.expectedFilename) {
+				t.Errorf("Content-Disposition = %q, want %s", rr.Header().Get("Content-Disposition"), tt.expectedFilename)
+			}
+			if tt.expectedStatus == http.StatusOK && !svc.since.Equal(tt.expectedSince) {
+				t.Errorf("since = %v, want %v", svc.since, tt.expectedSince)
+			}
+		})
+	}
+}
@@ -0,0 +1,617 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+const (
+	// Period used when the client doesn't provide ?from=
+	defaultStatsPeriod = 30 * 24 * time.Hour
+	// Longest period a single stats request can cover
+	maxStatsPeriod = 366 * 24 * time.Hour
+	// Longest period link stats can cover by the hour, to keep the series short
+	maxHourlyStatsPeriod = 31 * 24 * time.Hour
+	// Links returned by the top links endpoint when the client doesn't provide ?limit=
+	defaultTopLinksLimit = 10
+)
+
+// StatsService defines the service methods needed by StatsHandler
+type StatsService interface {
+	GetLinkStats(ctx context.Context, userID string, linkID uuid.UUID, from, to time.Time, granularity string, loc *time.Location) (*service.LinkStatsResult, error)
+	GetTagStats(ctx context.Context, userID string, tagID uuid.UUID, from, to time.Time, loc *time.Location) (*service.TagStatsResult, error)
+	GetCampaignStats(ctx context.Context, userID string, campaignID uuid.UUID, from, to time.Time, loc *time.Location) (*service.CampaignStatsResult, error)
+	ExportClicks(ctx context.Context, req service.ExportRequest, w io.Writer) error
+	GetPublicLinkStats(ctx context.Context, shortcode, token string, now time.Time) (*service.PublicLinkStatsResult, error)
+	TopLinks(ctx context.Context, userID string, day time.Time, limit int) ([]service.TopLink, error)
+}
+
+// ExportJobs defines the background export methods needed by StatsHandler
+type ExportJobs interface {
+	Start(ctx context.Context, req service.ExportRequest) (service.ExportJob, error)
+	Get(userID string, id uuid.UUID) (service.ExportJob, error)
+	Open(ctx context.Context, job service.ExportJob) (io.ReadCloser, error)
+	DownloadURL(ctx context.Context, job service.ExportJob, filename string) (string, error)
+}
+
+type StatsHandler struct {
+	StatsService StatsService
+	Exports      ExportJobs
+	logger       logger.Logger
+}
+
+func NewStatsHandler(statsService StatsService, exports ExportJobs, logger logger.Logger) *StatsHandler {
+	return &StatsHandler{
+		StatsService: statsService,
+		Exports:      exports,
+		logger:       logger,
+	}
+}
+
+// LinkStats: GET /api/v1/links/{id}/stats?from=&to=&tz=&granularity=day|hour
+func (h *StatsHandler) LinkStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	loc, err := parseStatsTimeZone(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	from, to, err := parseStatsPeriod(r, loc)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	granularity, err := parseStatsGranularity(r, from, to)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	stats, err := h.StatsService.GetLinkStats(r.Context(), userID, linkID, from, to, granularity, loc)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := dto.LinkStats{
+		LinkID:         stats.Link.ID,
+		Shortcode:      stats.Link.Shortcode,
+		From:           stats.From.In(loc),
+		To:             stats.To.In(loc),
+		Granularity:    stats.Granularity,
+		TotalClicks:    stats.TotalClicks,
+		UniqueVisitors: stats.UniqueVisitors,
+		TopReferrers:   make([]dto.ReferrerClicks, 0, len(stats.TopReferrers)),
+		Clicks:         make([]dto.BucketClicks, 0, len(stats.Clicks)),
+	}
+	for _, ref := range stats.TopReferrers {
+		resp.TopReferrers = append(resp.TopReferrers, dto.ReferrerClicks{Referrer: ref.Referrer, Clicks: ref.Clicks})
+	}
+	for _, b := range stats.Clicks {
+		resp.Clicks = append(resp.Clicks, dto.BucketClicks{Start: b.Start.In(loc), Clicks: b.Clicks, UniqueVisitors: b.UniqueVisitors})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.LinkStats]{
+		Data: resp,
+	})
+}
+
+// TagStats: GET /api/v1/tags/{id}/stats?from=&to=&tz=
+func (h *StatsHandler) TagStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	tagID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	loc, err := parseStatsTimeZone(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	from, to, err := parseStatsPeriod(r, loc)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	stats, err := h.StatsService.GetTagStats(r.Context(), userID, tagID, from, to, loc)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := dto.TagStats{
+		TagID:          stats.Tag.ID,
+		TagName:        stats.Tag.Name,
+		From:           stats.From.In(loc),
+		To:             stats.To.In(loc),
+		TotalClicks:    stats.TotalClicks,
+		QRClicks:       stats.QRClicks,
+		LinksClicked:   stats.LinksClicked,
+		Conversions:    stats.Conversions,
+		RevenueCents:   stats.RevenueCents,
+		ConversionRate: conversionRate(stats.Conversions, stats.TotalClicks),
+		ClicksByDay:    make([]dto.DailyClicks, 0, len(stats.ClicksByDay)),
+		TopLinks:       make([]dto.LinkClicks, 0, len(stats.TopLinks)),
+	}
+	for _, d := range stats.ClicksByDay {
+		resp.ClicksByDay = append(resp.ClicksByDay, dto.DailyClicks{Day: d.Day.Time.In(loc), Clicks: d.Clicks, QRClicks: d.QrClicks})
+	}
+	for _, l := range stats.TopLinks {
+		resp.TopLinks = append(resp.TopLinks, dto.LinkClicks{
+			ID:          l.ID,
+			Shortcode:   l.Shortcode,
+			OriginalURL: l.OriginalUrl,
+			Clicks:      l.Clicks,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.TagStats]{
+		Data: resp,
+	})
+}
+
+/*
+TopLinks: GET /api/v1/stats/top-links?day=&limit=
+
+The user's links with the most clicks on a day, today by default. Days start at
+midnight UTC; ?day= (YYYY-MM-DD) can go back as far as yesterday.
+*/
+func (h *StatsHandler) TopLinks(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	day, err := parseTopLinksDay(r, time.Now())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Out of range limits are clamped, like pagination's
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultTopLinksLimit
+	}
+	limit = min(limit, service.MaxLeaderboardLinks)
+
+	links, err := h.StatsService.TopLinks(r.Context(), userID, day, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := dto.TopLinks{
+		Day:   day,
+		Links: make([]dto.LinkClicks, 0, len(links)),
+	}
+	for _, l := range links {
+		resp.Links = append(resp.Links, dto.LinkClicks{
+			ID:          l.ID,
+			Shortcode:   l.Shortcode,
+			OriginalURL: l.OriginalURL,
+			Clicks:      l.Clicks,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.TopLinks]{
+		Data: resp,
+	})
+}
+
+// parseTopLinksDay reads ?day=, a day leaderboards are kept for. Defaults to the day of now.
+func parseTopLinksDay(r *http.Request, now time.Time) (time.Time, error) {
+	today := service.LeaderboardDay(now)
+	dayStr := r.URL.Query().Get("day")
+	if dayStr == "" {
+		return today, nil
+	}
+
+	day, err := time.Parse(time.DateOnly, dayStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: day: %v", errInvalidPeriod, err)
+	}
+	if day.After(today) || day.Before(today.AddDate(0, 0, 1-service.LeaderboardDays)) {
+		return time.Time{}, fmt.Errorf("%w: day must be today or yesterday (UTC)", errInvalidPeriod)
+	}
+	return day, nil
+}
+
+// CampaignStats: GET /api/v1/campaigns/{id}/stats?from=&to=&tz=
+// Without ?from= and ?to= the campaign's own date range is reported.
+func (h *StatsHandler) CampaignStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	campaignID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	loc, err := parseStatsTimeZone(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Zero times let the service fall back to the campaign's date range
+	var from, to time.Time
+	if r.URL.Query().Has("from") || r.URL.Query().Has("to") {
+		from, to, err = parseStatsPeriod(r, loc)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+	}
+
+	stats, err := h.StatsService.GetCampaignStats(r.Context(), userID, campaignID, from, to, loc)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := dto.CampaignStats{
+		CampaignID:     stats.Campaign.ID,
+		CampaignName:   stats.Campaign.Name,
+		From:           stats.From.In(loc),
+		To:             stats.To.In(loc),
+		TotalClicks:    stats.TotalClicks,
+		QRClicks:       stats.QRClicks,
+		LinksClicked:   stats.LinksClicked,
+		Conversions:    stats.Conversions,
+		RevenueCents:   stats.RevenueCents,
+		ConversionRate: conversionRate(stats.Conversions, stats.TotalClicks),
+		ClicksByDay:    make([]dto.DailyClicks, 0, len(stats.ClicksByDay)),
+		TopLinks:       make([]dto.LinkClicks, 0, len(stats.TopLinks)),
+	}
+	for _, d := range stats.ClicksByDay {
+		resp.ClicksByDay = append(resp.ClicksByDay, dto.DailyClicks{Day: d.Day.Time.In(loc), Clicks: d.Clicks, QRClicks: d.QrClicks})
+	}
+	for _, l := range stats.TopLinks {
+		resp.TopLinks = append(resp.TopLinks, dto.LinkClicks{
+			ID:          l.ID,
+			Shortcode:   l.Shortcode,
+			OriginalURL: l.OriginalUrl,
+			Clicks:      l.Clicks,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.CampaignStats]{
+		Data: resp,
+	})
+}
+
+// errInvalidPeriod is returned when ?from= / ?to= can't be parsed or are out of bounds
+var errInvalidPeriod = errors.New("invalid stats period")
+
+// errInvalidTimeZone is returned when ?tz= isn't an IANA time zone name
+var errInvalidTimeZone = errors.New("invalid time zone")
+
+// errInvalidGranularity is returned when ?granularity= isn't day or hour, or hour over a period too long for it
+var errInvalidGranularity = errors.New("invalid granularity")
+
+// parseStatsGranularity reads ?granularity=, the size of the buckets clicks are counted in. Defaults to day.
+func parseStatsGranularity(r *http.Request, from, to time.Time) (string, error) {
+	switch granularity := r.URL.Query().Get("granularity"); granularity {
+	case "", analytics.GranularityDay:
+		return analytics.GranularityDay, nil
+	case analytics.GranularityHour:
+		if to.Sub(from) > maxHourlyStatsPeriod {
+			return "", fmt.Errorf("%w: period cannot exceed 31 days by the hour", errInvalidGranularity)
+		}
+		return analytics.GranularityHour, nil
+	default:
+		return "", fmt.Errorf("%w: %q must be day or hour", errInvalidGranularity, granularity)
+	}
+}
+
+// parseStatsTimeZone reads ?tz=, the IANA time zone (such as Europe/Athens) days
+// start in. There are no per-user settings to read it from, so clients pass their
+// user's zone on each request. Defaults to UTC.
+func parseStatsTimeZone(r *http.Request) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	// LoadLocation also accepts "Local", the server's zone, which means nothing to clients
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, fmt.Errorf("%w: %q is not an IANA time zone name", errInvalidTimeZone, tz)
+	}
+	return loc, nil
+}
+
+// parseStatsPeriod reads ?from= and ?to= (RFC3339, or YYYY-MM-DD for midnight in loc).
+// Defaults to the last 30 days.
+func parseStatsPeriod(r *http.Request, loc *time.Location) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		t, err := parseStatsTime(toStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to: %v", errInvalidPeriod, err)
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultStatsPeriod)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		t, err := parseStatsTime(fromStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from: %v", errInvalidPeriod, err)
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be before to", errInvalidPeriod)
+	}
+
+	if to.Sub(from) > maxStatsPeriod {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: period cannot exceed 366 days", errInvalidPeriod)
+	}
+
+	return from, to, nil
+}
+
+// parseStatsTime parses an RFC3339 time, or a YYYY-MM-DD date as its midnight in loc, and returns it in UTC
+func parseStatsTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, s, loc)
+	return t.UTC(), err
+}
+
+// handleError maps errors to HTTP responses and writes them directly
+func (h *StatsHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errInvalidPeriod):
+		h.logger.Warn("Invalid stats period",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid stats period",
+				Detail: err.Error(),
+			},
+		})
+
+	case errors.Is(err, errInvalidTimeZone):
+		h.logger.Warn("Invalid time zone",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid time zone",
+				Detail: err.Error(),
+			},
+		})
+
+	case errors.Is(err, errInvalidGranularity):
+		h.logger.Warn("Invalid granularity",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid granularity",
+				Detail: err.Error(),
+			},
+		})
+
+	case errors.Is(err, errInvalidExport):
+		h.logger.Warn("Invalid export request",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid export request",
+				Detail: err.Error(),
+			},
+		})
+
+	case errors.Is(err, apperrors.LeaderboardDisabled):
+		h.logger.Warn("Top links leaderboard is not configured",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotImplemented)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLeaderboardDisabled,
+				Title:  apperrors.LeaderboardDisabled.Error(),
+				Detail: "Top links need Redis, which this server isn't connected to",
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkNotFound):
+		h.logger.Warn("Link not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkNotFound,
+				Title:  apperrors.LinkNotFound.Error(),
+				Detail: "Unable to find link with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.ExportNotFound):
+		h.logger.Warn("Export not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeExportNotFound,
+				Title:  apperrors.ExportNotFound.Error(),
+				Detail: "Unable to find an export with the provided ID; exports expire after 24 hours",
+			},
+		})
+
+	case errors.Is(err, apperrors.TagNotFound):
+		h.logger.Warn("Tag not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTagNotFound,
+				Title:  apperrors.TagNotFound.Error(),
+				Detail: "Unable to find tag with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.CampaignNotFound):
+		h.logger.Warn("Campaign not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCampaignNotFound,
+				Title:  apperrors.CampaignNotFound.Error(),
+				Detail: "Unable to find campaign with the provided ID",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
+
+// conversionRate is conversions per click, 0 when there were no clicks
+func conversionRate(conversions, clicks int64) float64 {
+	if clicks == 0 {
+		return 0
+	}
+	return float64(conversions) / float64(clicks)
+}
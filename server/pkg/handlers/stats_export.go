@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// Where finished export jobs are downloaded from
+const exportsPath = "/api/v1/exports/"
+
+// errInvalidExport is returned when the export query parameters are invalid
+var errInvalidExport = errors.New("invalid export request")
+
+// ExportLinkStats: GET /api/v1/links/{id}/stats/export?from=&to=&format=csv&granularity=raw|day&async=
+func (h *StatsHandler) ExportLinkStats(w http.ResponseWriter, r *http.Request) {
+	linkID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	h.export(w, r, &linkID)
+}
+
+// ExportAccountStats: GET /api/v1/stats/export?from=&to=&format=csv&granularity=raw|day&async=
+func (h *StatsHandler) ExportAccountStats(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, nil)
+}
+
+/*
+export streams the clicks as a CSV attachment, or starts an export job and
+answers 202 with its download URL when ?async=true is set or when a raw export
+covers more than service.MaxSyncRawExportPeriod.
+*/
+func (h *StatsHandler) export(w http.ResponseWriter, r *http.Request, linkID *uuid.UUID) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	req, async, err := parseExportRequest(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	req.UserID = userID
+	req.LinkID = linkID
+
+	if async {
+		job, err := h.Exports.Start(r.Context(), req)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+
+		h.logger.Info("Export job started",
+			zap.String("user_id", userID),
+			zap.String("job_id", job.ID.String()),
+			zap.String("granularity", req.Granularity),
+		)
+
+		w.Header().Set("Location", exportsPath+job.ID.String())
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, &dto.SuccessResponse[dto.ExportJob]{
+			Data: exportJobResponse(job),
+		})
+		return
+	}
+
+	out := &csvAttachment{w: w, filename: exportFilename(req)}
+	if err := h.StatsService.ExportClicks(r.Context(), req, out); err != nil {
+		if !out.started {
+			h.handleError(w, r, err)
+			return
+		}
+
+		// Headers are gone: all we can do is cut the download short
+		h.logger.Error("Clicks export failed mid-stream",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		return
+	}
+
+	// An empty result still gets its CSV response
+	out.start()
+}
+
+// GetExport: GET /api/v1/exports/{id}
+// Returns the job while it's running and the CSV once it's done, redirecting
+// to a presigned URL when the asset store links to its files.
+func (h *StatsHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	jobID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	job, err := h.Exports.Get(userID, jobID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if job.Status != service.ExportJobDone {
+		status := http.StatusOK
+		if job.Status == service.ExportJobPending {
+			status = http.StatusAccepted
+		}
+
+		render.Status(r, status)
+		render.JSON(w, r, &dto.SuccessResponse[dto.ExportJob]{
+			Data: exportJobResponse(job),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("clicks-%s.csv", job.ID)
+	url, err := h.Exports.DownloadURL(r.Context(), job, filename)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if url != "" {
+		http.Redirect(w, r, url, http.StatusSeeOther)
+		return
+	}
+
+	f, err := h.Exports.Open(r.Context(), job)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	out := &csvAttachment{w: w, filename: filename}
+	out.start()
+	if _, err := io.Copy(out, f); err != nil {
+		h.logger.Warn("Failed to send export file",
+			zap.Error(err),
+			zap.String("job_id", job.ID.String()),
+		)
+	}
+}
+
+// parseExportRequest reads the period, time zone, format, granularity and async mode of an export
+func parseExportRequest(r *http.Request) (service.ExportRequest, bool, error) {
+	loc, err := parseStatsTimeZone(r)
+	if err != nil {
+		return service.ExportRequest{}, false, err
+	}
+
+	from, to, err := parseStatsPeriod(r, loc)
+	if err != nil {
+		return service.ExportRequest{}, false, err
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		return service.ExportRequest{}, false, fmt.Errorf("%w: format must be csv", errInvalidExport)
+	}
+
+	granularity := r.URL.Query().Get("granularity")
+	switch granularity {
+	case "":
+		granularity = service.ExportGranularityRaw
+	case service.ExportGranularityRaw, service.ExportGranularityDay:
+	default:
+		return service.ExportRequest{}, false, fmt.Errorf("%w: granularity must be one of: raw, day", errInvalidExport)
+	}
+
+	async := false
+	if asyncStr := r.URL.Query().Get("async"); asyncStr != "" {
+		async, err = strconv.ParseBool(asyncStr)
+		if err != nil {
+			return service.ExportRequest{}, false, fmt.Errorf("%w: async must be true or false", errInvalidExport)
+		}
+	}
+
+	// Too many rows to hold a request open for
+	if granularity == service.ExportGranularityRaw && to.Sub(from) > service.MaxSyncRawExportPeriod {
+		async = true
+	}
+
+	return service.ExportRequest{
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Location:    loc,
+	}, async, nil
+}
+
+func exportFilename(req service.ExportRequest) string {
+	scope := "account"
+	if req.LinkID != nil {
+		scope = req.LinkID.String()
+	}
+
+	return fmt.Sprintf("clicks-%s-%s-%s.csv", scope, req.From.Format(time.DateOnly), req.To.Format(time.DateOnly))
+}
+
+func exportJobResponse(job service.ExportJob) dto.ExportJob {
+	return dto.ExportJob{
+		ID:          job.ID,
+		Status:      string(job.Status),
+		CreatedAt:   job.CreatedAt,
+		ExpiresAt:   job.ExpiresAt,
+		DownloadURL: exportsPath + job.ID.String(),
+	}
+}
+
+// csvAttachment sends the CSV response headers with the first write, so an
+// export that fails before producing output can still get a JSON error
+type csvAttachment struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (a *csvAttachment) start() {
+	if a.started {
+		return
+	}
+	a.started = true
+
+	a.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	a.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, a.filename))
+	a.w.WriteHeader(http.StatusOK)
+}
+
+func (a *csvAttachment) Write(p []byte) (int, error) {
+	a.start()
+	return a.w.Write(p)
+}
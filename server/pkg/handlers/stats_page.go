@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+const (
+	// Size of the clicks per day chart, in SVG user units
+	statsChartBarWidth = 20
+	statsChartHeight   = 120
+)
+
+// statsPageTemplate is a link's public stats page. The chart is plain SVG, so the page works without JavaScript.
+var statsPageTemplate = template.Must(template.New("stats").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head>
+		<title>{{.Title}}</title>
+		<style>
+			.bar { fill: #4a6cf7; }
+			.qr { fill: #9db0fb; }
+		</style>
+	</head>
+	<body>
+		<h1>{{.Heading}}</h1>
+		<p>{{.Period}}</p>
+		<dl>
+			<dt>{{.TotalClicksLabel}}</dt>
+			<dd>{{.TotalClicks}}</dd>
+			<dt>{{.QRClicksLabel}}</dt>
+			<dd>{{.QRClicks}}</dd>
+		</dl>
+		<h2>{{.ClicksByDayLabel}}</h2>
+		<svg viewBox="0 0 {{.ChartWidth}} {{.ChartHeight}}" width="{{.ChartWidth}}" height="{{.ChartHeight}}" role="img" aria-label="{{.ClicksByDayLabel}}">
+			{{- range .Bars}}
+			<g>
+				<title>{{.Day}}: {{.Clicks}}</title>
+				<rect class="bar" x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"></rect>
+				<rect class="qr" x="{{.X}}" y="{{.QRY}}" width="{{.Width}}" height="{{.QRHeight}}"></rect>
+			</g>
+			{{- end}}
+		</svg>
+	</body>
+</html>`))
+
+// statsChartBar is one day of the clicks per day chart; QR code scans are stacked at the bottom of the bar
+type statsChartBar struct {
+	Day      string
+	Clicks   int64
+	X        int
+	Y        int
+	Width    int
+	Height   int
+	QRY      int
+	QRHeight int
+}
+
+// statsChartBars scales the days of the stats to the chart's height, the busiest day filling it
+func statsChartBars(stats *service.PublicLinkStatsResult) []statsChartBar {
+	var busiest int64
+	for _, d := range stats.ClicksByDay {
+		busiest = max(busiest, d.Clicks)
+	}
+
+	scale := func(clicks int64) int {
+		if busiest == 0 {
+			return 0
+		}
+		return int(clicks * statsChartHeight / busiest)
+	}
+
+	bars := make([]statsChartBar, 0, len(stats.ClicksByDay))
+	for i, d := range stats.ClicksByDay {
+		height, qrHeight := scale(d.Clicks), scale(d.QrClicks)
+		bars = append(bars, statsChartBar{
+			Day:      d.Day.Time.UTC().Format(time.DateOnly),
+			Clicks:   d.Clicks,
+			X:        i * statsChartBarWidth,
+			Y:        statsChartHeight - height,
+			Width:    statsChartBarWidth - 2,
+			Height:   height,
+			QRY:      statsChartHeight - qrHeight,
+			QRHeight: qrHeight,
+		})
+	}
+	return bars
+}
+
+// PublicLinkStats: GET /{shortcode}+
+// Renders the link's click counts when its owner made them public, or with a ?token= they signed.
+func (h *StatsHandler) PublicLinkStats(w http.ResponseWriter, r *http.Request) {
+	shortcode := chi.URLParam(r, "shortcode")
+	token := r.URL.Query().Get("token")
+
+	stats, err := h.StatsService.GetPublicLinkStats(r.Context(), shortcode, token, time.Now())
+	if err != nil {
+		if errors.Is(err, apperrors.LinkNotFound) {
+			h.logger.Info("Stats page not found",
+				zap.Error(err),
+				zap.String("shortcode", shortcode),
+				zap.String("remote_addr", r.RemoteAddr),
+			)
+			h.renderStatsNotFound(w, r)
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+
+	lang := mw.GetLanguageFromContext(r.Context())
+	bars := statsChartBars(stats)
+
+	var buf bytes.Buffer
+	if err := statsPageTemplate.Execute(&buf, map[string]any{
+		"Lang":             lang,
+		"Title":            i18n.T(lang, "stats.title"),
+		"Heading":          strings.ReplaceAll(i18n.T(lang, "stats.heading"), "{link}", r.Host+"/"+shortcode),
+		"Period":           i18n.T(lang, "stats.period"),
+		"TotalClicksLabel": i18n.T(lang, "stats.total_clicks"),
+		"TotalClicks":      strconv.FormatInt(stats.TotalClicks, 10),
+		"QRClicksLabel":    i18n.T(lang, "stats.qr_clicks"),
+		"QRClicks":         strconv.FormatInt(stats.QRClicks, 10),
+		"ClicksByDayLabel": i18n.T(lang, "stats.clicks_by_day"),
+		"ChartWidth":       len(bars) * statsChartBarWidth,
+		"ChartHeight":      statsChartHeight,
+		"Bars":             bars,
+	}); err != nil {
+		h.logger.Error("Failed to render stats page",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// Pages shared with a token stay out of search engines and shared caches
+	if token != "" {
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.HTML(w, r, buf.String())
+}
+
+// renderStatsNotFound writes the 404 page of unknown links, which private stats pages can't be told from
+func (h *StatsHandler) renderStatsNotFound(w http.ResponseWriter, r *http.Request) {
+	lang := mw.GetLanguageFromContext(r.Context())
+
+	var buf bytes.Buffer
+	if err := statusPageTemplate.Execute(&buf, map[string]any{
+		"Lang":    lang,
+		"Title":   i18n.T(lang, "not_found.title"),
+		"Heading": i18n.T(lang, "not_found.heading"),
+		"Message": i18n.T(lang, "not_found.message"),
+	}); err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	render.Status(r, http.StatusNotFound)
+	render.HTML(w, r, buf.String())
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type mockStatsService struct {
+	GetLinkStatsFunc       func(ctx context.Context, userID string, linkID uuid.UUID, from, to time.Time, granularity string, loc *time.Location) (*service.LinkStatsResult, error)
+	GetPublicLinkStatsFunc func(ctx context.Context, shortcode, token string, now time.Time) (*service.PublicLinkStatsResult, error)
+	TopLinksFunc           func(ctx context.Context, userID string, day time.Time, limit int) ([]service.TopLink, error)
+}
+
+func (m *mockStatsService) GetLinkStats(ctx context.Context, userID string, linkID uuid.UUID, from, to time.Time, granularity string, loc *time.Location) (*service.LinkStatsResult, error) {
+	if m.GetLinkStatsFunc != nil {
+		return m.GetLinkStatsFunc(ctx, userID, linkID, from, to, granularity, loc)
+	}
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockStatsService) GetTagStats(ctx context.Context, userID string, tagID uuid.UUID, from, to time.Time, loc *time.Location) (*service.TagStatsResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockStatsService) GetCampaignStats(ctx context.Context, userID string, campaignID uuid.UUID, from, to time.Time, loc *time.Location) (*service.CampaignStatsResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockStatsService) ExportClicks(ctx context.Context, req service.ExportRequest, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockStatsService) GetPublicLinkStats(ctx context.Context, shortcode, token string, now time.Time) (*service.PublicLinkStatsResult, error) {
+	if m.GetPublicLinkStatsFunc != nil {
+		return m.GetPublicLinkStatsFunc(ctx, shortcode, token, now)
+	}
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockStatsService) TopLinks(ctx context.Context, userID string, day time.Time, limit int) ([]service.TopLink, error) {
+	if m.TopLinksFunc != nil {
+		return m.TopLinksFunc(ctx, userID, day, limit)
+	}
+	return nil, fmt.Errorf("not implemented")
+}
+
+func TestStatsHandler_PublicLinkStats(t *testing.T) {
+	day := time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   []string
+		expectNoindex  bool
+	}{
+		{name: "public page", expectedStatus: http.StatusOK, expectedBody: []string{"Clicks on sho.rt/docs", "<dd>42</dd>", "<title>2025-03-30: 42</title>"}},
+		{name: "shared with a token", query: "?token=abc", expectedStatus: http.StatusOK, expectNoindex: true},
+		{name: "private page", serviceErr: fmt.Errorf("%w: stats of docs aren't public", apperrors.LinkNotFound), expectedStatus: http.StatusNotFound, expectedBody: []string{"404 - Link Not Found"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotToken string
+			mockService := &mockStatsService{
+				GetPublicLinkStatsFunc: func(ctx context.Context, shortcode, token string, now time.Time) (*service.PublicLinkStatsResult, error) {
+					gotToken = token
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &service.PublicLinkStatsResult{
+						Shortcode:   shortcode,
+						TotalClicks: 42,
+						ClicksByDay: []db.GetLinkClicksByDayRow{{Day: pgtype.Timestamptz{Time: day, Valid: true}, Clicks: 42, QrClicks: 2}},
+					}, nil
+				},
+			}
+			handler := NewStatsHandler(mockService, nil, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "http://sho.rt/docs+"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("shortcode", "docs")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.PublicLinkStats(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if gotToken != strings.TrimPrefix(tt.query, "?token=") {
+				t.Errorf("token = %q, want the one from the query", gotToken)
+			}
+			for _, want := range tt.expectedBody {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("body doesn't contain %q:\n%s", want, w.Body.String())
+				}
+			}
+			if got := w.Header().Get("X-Robots-Tag") == "noindex"; got != tt.expectNoindex {
+				t.Errorf("noindex = %v, want %v", got, tt.expectNoindex)
+			}
+		})
+	}
+}
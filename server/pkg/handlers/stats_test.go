@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+func TestParseStatsPeriod(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		expectErr   bool
+		expectedLen time.Duration
+	}{
+		{name: "defaults to last 30 days", query: "", expectedLen: defaultStatsPeriod},
+		{name: "date only", query: "?from=2025-01-01&to=2025-01-08", expectedLen: 7 * 24 * time.Hour},
+		{name: "rfc3339", query: "?from=2025-01-01T00:00:00Z&to=2025-01-01T12:00:00Z", expectedLen: 12 * time.Hour},
+		{name: "from after to", query: "?from=2025-01-08&to=2025-01-01", expectErr: true},
+		{name: "invalid from", query: "?from=yesterday", expectErr: true},
+		{name: "period too long", query: "?from=2020-01-01&to=2025-01-01", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/tags/x/stats"+tt.query, nil)
+
+			from, to, err := parseStatsPeriod(req, time.UTC)
+
+			if tt.expectErr {
+				if !errors.Is(err, errInvalidPeriod) {
+					t.Errorf("parseStatsPeriod() error = %v, want %v", err, errInvalidPeriod)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStatsPeriod() unexpected error = %v", err)
+			}
+			if to.Sub(from) != tt.expectedLen {
+				t.Errorf("parseStatsPeriod() period = %v, want %v", to.Sub(from), tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestParseStatsTimeZone(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		expectErr bool
+		wantFrom  time.Time
+	}{
+		{name: "defaults to UTC", query: "?from=2025-01-01&to=2025-01-08", wantFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "dates start at midnight in the zone", query: "?from=2025-01-01&to=2025-01-08&tz=Europe/Athens", wantFrom: time.Date(2024, 12, 31, 22, 0, 0, 0, time.UTC)},
+		{name: "rfc3339 keeps its offset", query: "?from=2025-01-01T00:00:00Z&to=2025-01-08&tz=America/New_York", wantFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "unknown zone", query: "?tz=Mars/Olympus", expectErr: true},
+		{name: "server zone", query: "?tz=Local", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/tags/x/stats"+tt.query, nil)
+
+			loc, err := parseStatsTimeZone(req)
+
+			if tt.expectErr {
+				if !errors.Is(err, errInvalidTimeZone) {
+					t.Errorf("parseStatsTimeZone() error = %v, want %v", err, errInvalidTimeZone)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStatsTimeZone() unexpected error = %v", err)
+			}
+
+			from, _, err := parseStatsPeriod(req, loc)
+			if err != nil {
+				t.Fatalf("parseStatsPeriod() unexpected error = %v", err)
+			}
+			if !from.Equal(tt.wantFrom) || from.Location() != time.UTC {
+				t.Errorf("parseStatsPeriod() from = %v, want %v", from, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestParseExportRequest(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		expectErr       bool
+		wantGranularity string
+		wantAsync       bool
+	}{
+		{name: "defaults to raw and streaming", query: "?from=2025-01-01&to=2025-01-08", wantGranularity: "raw"},
+		{name: "daily aggregates", query: "?from=2025-01-01&to=2025-01-08&granularity=day", wantGranularity: "day"},
+		{name: "explicit async", query: "?from=2025-01-01&to=2025-01-08&async=true", wantGranularity: "raw", wantAsync: true},
+		{name: "long raw range runs as a job", query: "?from=2025-01-01&to=2025-06-01", wantGranularity: "raw", wantAsync: true},
+		{name: "long daily range streams", query: "?from=2025-01-01&to=2025-06-01&granularity=day", wantGranularity: "day"},
+		{name: "unsupported format", query: "?format=xlsx", expectErr: true},
+		{name: "unknown granularity", query: "?granularity=hour", expectErr: true},
+		{name: "invalid async", query: "?async=later", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/stats/export"+tt.query, nil)
+
+			exportReq, async, err := parseExportRequest(req)
+
+			if tt.expectErr {
+				if !errors.Is(err, errInvalidExport) {
+					t.Errorf("parseExportRequest() error = %v, want %v", err, errInvalidExport)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExportRequest() unexpected error = %v", err)
+			}
+			if exportReq.Granularity != tt.wantGranularity || async != tt.wantAsync {
+				t.Errorf("parseExportRequest() = %s, async %v, want %s, async %v",
+					exportReq.Granularity, async, tt.wantGranularity, tt.wantAsync)
+			}
+		})
+	}
+}
+
+func TestParseStatsGranularity(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		want      string
+		expectErr bool
+	}{
+		{name: "defaults to day", query: "?from=2025-01-01&to=2025-03-01", want: "day"},
+		{name: "hour", query: "?from=2025-01-01&to=2025-01-08&granularity=hour", want: "hour"},
+		{name: "hour over a long period", query: "?from=2025-01-01&to=2025-03-01&granularity=hour", expectErr: true},
+		{name: "unknown", query: "?granularity=week", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/links/x/stats"+tt.query, nil)
+			from, to, err := parseStatsPeriod(req, time.UTC)
+			if err != nil {
+				t.Fatalf("parseStatsPeriod() unexpected error = %v", err)
+			}
+
+			got, err := parseStatsGranularity(req, from, to)
+
+			if tt.expectErr {
+				if !errors.Is(err, errInvalidGranularity) {
+					t.Errorf("parseStatsGranularity() error = %v, want %v", err, errInvalidGranularity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStatsGranularity() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseStatsGranularity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsHandler_LinkStats(t *testing.T) {
+	linkID := uuid.New()
+	hour := time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		id             string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "stats by the hour",
+			id:             linkID.String(),
+			query:          "?from=2025-03-30&to=2025-03-31&granularity=hour&tz=Europe/Athens",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"granularity":"hour"`, `"unique_visitors":3`, `{"referrer":"news.ycombinator.com","clicks":4}`,
+				`{"start":"2025-03-30T12:00:00+03:00","clicks":5,"unique_visitors":3}`,
+			},
+		},
+		{name: "invalid ID", id: "abc", expectedStatus: http.StatusBadRequest},
+		{name: "invalid granularity", id: linkID.String(), query: "?granularity=minute", expectedStatus: http.StatusBadRequest},
+		{name: "not found", id: linkID.String(), serviceErr: apperrors.LinkNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockStatsService{
+				GetLinkStatsFunc: func(ctx context.Context, userID string, id uuid.UUID, from, to time.Time, granularity string, loc *time.Location) (*service.LinkStatsResult, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &service.LinkStatsResult{
+						Link:           db.GetLinkByIdAndUserRow{ID: id, Shortcode: "docs"},
+						From:           from,
+						To:             to,
+						Granularity:    granularity,
+						TotalClicks:    5,
+						UniqueVisitors: 3,
+						TopReferrers:   []analytics.ReferrerClicks{{Referrer: "news.ycombinator.com", Clicks: 4}, {Clicks: 1}},
+						Clicks:         []analytics.BucketClicks{{Start: hour, Clicks: 5, UniqueVisitors: 3}},
+					}, nil
+				},
+			}
+			handler := NewStatsHandler(mockService, nil, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links/"+tt.id+"/stats"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middleware.WithUserID(ctx, "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.LinkStats(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			for _, want := range tt.expectedBody {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("body doesn't contain %s:\n%s", want, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestParseTopLinksDay(t *testing.T) {
+	now := time.Date(2025, 3, 30, 0, 30, 0, 0, time.FixedZone("EEST", 3*60*60))
+	today := time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		expectErr bool
+		want      time.Time
+	}{
+		{name: "defaults to today in UTC", query: "", want: today},
+		{name: "yesterday", query: "?day=2025-03-28", want: today.AddDate(0, 0, -1)},
+		{name: "before yesterday", query: "?day=2025-03-27", expectErr: true},
+		{name: "tomorrow", query: "?day=2025-03-30", expectErr: true},
+		{name: "not a date", query: "?day=today", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/stats/top-links"+tt.query, nil)
+
+			day, err := parseTopLinksDay(req, now)
+
+			if tt.expectErr {
+				if !errors.Is(err, errInvalidPeriod) {
+					t.Errorf("parseTopLinksDay() error = %v, want %v", err, errInvalidPeriod)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTopLinksDay() unexpected error = %v", err)
+			}
+			if !day.Equal(tt.want) {
+				t.Errorf("parseTopLinksDay() = %v, want %v", day, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsHandler_TopLinks(t *testing.T) {
+	linkID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedLimit  int
+	}{
+		{name: "default limit", expectedStatus: http.StatusOK, expectedLimit: 10},
+		{name: "limit clamped", query: "?limit=1000", expectedStatus: http.StatusOK, expectedLimit: service.MaxLeaderboardLinks},
+		{name: "invalid day", query: "?day=2020-01-01", expectedStatus: http.StatusBadRequest},
+		{name: "no leaderboard", serviceErr: apperrors.LeaderboardDisabled, expectedStatus: http.StatusNotImplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLimit int
+			mockService := &mockStatsService{
+				TopLinksFunc: func(ctx context.Context, userID string, day time.Time, limit int) ([]service.TopLink, error) {
+					gotLimit = limit
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return []service.TopLink{{ID: linkID, Shortcode: "docs", OriginalURL: "https://example.com/docs", Clicks: 42}}, nil
+				},
+			}
+			handler := NewStatsHandler(mockService, nil, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/top-links"+tt.query, nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.TopLinks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if gotLimit != tt.expectedLimit {
+				t.Errorf("TopLinks() limit = %d, want %d", gotLimit, tt.expectedLimit)
+			}
+
+			var resp dto.SuccessResponse[dto.TopLinks]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Data.Links) != 1 || resp.Data.Links[0].ID != linkID || resp.Data.Links[0].Clicks != 42 {
+				t.Errorf("links = %+v, want the link with 42 clicks", resp.Data.Links)
+			}
+		})
+	}
+}
@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -13,16 +18,21 @@ import (
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
 	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
 	"go.uber.org/zap"
 )
 
 // TagService defines the service methods needed by TagHandler
 type TagService interface {
-	ListAllTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error)
+	ListTags(ctx context.Context, userID string, opts service.TagListOptions, page, limit int) (*service.ListTagsResult, error)
 	CreateTag(ctx context.Context, userID string, name string) (db.CreateTagRow, error)
+	UpsertTag(ctx context.Context, userID string, name string) (db.UpsertTagRow, error)
 	UpdateTag(ctx context.Context, userID string, tagID uuid.UUID, name string) (db.UpdateTagRow, error)
 	DeleteTag(ctx context.Context, userID string, tagID uuid.UUID) (db.DeleteTagRow, error)
 	DeleteTags(ctx context.Context, userID string, tagIDs []uuid.UUID) ([]db.DeleteTagsRow, error)
+	ListDeletedTags(ctx context.Context, userID string) ([]db.ListDeletedTagsRow, error)
+	RestoreTag(ctx context.Context, userID string, tagID uuid.UUID) (db.RestoreTagRow, error)
 }
 
 type TagHandler struct {
@@ -37,26 +47,154 @@ func NewTagHandler(tagService TagService, logger logger.Logger) *TagHandler {
 	}
 }
 
-// ListTags: GET /api/v1/tags
+// ListTags: GET /api/v1/tags?q=prefix&sort=name|created_at|usage
+// All the tags are returned unless ?page= or ?limit= asks for a page.
 func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := service.TagListOptions{
+		Prefix: strings.TrimSpace(query.Get("q")),
+		Sort:   query.Get("sort"),
+	}
+	switch opts.Sort {
+	case "", service.TagSortName, service.TagSortCreatedAt, service.TagSortUsage:
+	default:
+		h.renderInvalidListParam(w, r, "Invalid sort", "sort must be name, created_at or usage")
+		return
+	}
+	if utf8.RuneCountInString(opts.Prefix) > maxTagNameLength {
+		h.renderInvalidListParam(w, r, "Invalid q", fmt.Sprintf("q must be at most %d characters", maxTagNameLength))
+		return
+	}
+
+	// Parse pagination parameters: ?page=1&limit=50 (page_token is an alias of page)
+	page, limit := pagination.FromQuery(query)
+
+	// Parse field selection: ?fields=id,name
+	fields, err := parseFields[dto.TagResponse](r)
+	if err != nil {
+		h.logger.Warn("Invalid fields query parameter",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidRequest,
+				Title:  "Invalid fields",
+				Detail: err.Error(),
+			},
+		})
+		return
+	}
 
-	tags, err := h.TagService.ListAllTags(r.Context(), userID)
+	result, err := h.TagService.ListTags(r.Context(), userID, opts, page, limit)
 	if err != nil {
 		h.handleError(w, r, err)
 		return
 	}
 
+	data := dto.NewTagListResponses(result.Tags)
+
+	var pageLinks *pagination.Links
+	if result.Meta != nil {
+		links := pagination.SetLinks(w, r, *result.Meta)
+		pageLinks = &links
+	}
+
+	if fields != nil {
+		projected, err := selectFields(data, fields)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, &dto.SuccessResponse[[]map[string]json.RawMessage]{
+			Data:       projected,
+			Pagination: result.Meta,
+			Links:      pageLinks,
+		})
+		return
+	}
+
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[[]db.ListUserTagsRow]{
-		Data: tags,
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.TagResponse]{
+		Data:       data,
+		Pagination: result.Meta,
+		Links:      pageLinks,
+	})
+}
+
+// Longest tag name, and so longest useful ?q= prefix
+const maxTagNameLength = 30
+
+// renderInvalidListParam answers a listing request with an invalid query parameter
+func (h *TagHandler) renderInvalidListParam(w http.ResponseWriter, r *http.Request, title, detail string) {
+	h.logger.Warn("Invalid tag listing query parameter",
+		zap.String("detail", detail),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+
+	render.Status(r, http.StatusBadRequest)
+	render.JSON(w, r, dto.ErrorResponse{
+		Error: dto.ErrorObject{
+			Code:   apperrors.CodeInvalidRequest,
+			Title:  title,
+			Detail: detail,
+		},
 	})
 }
 
 // CreateTag: POST /api/v1/tags
+// With ?upsert=true an existing tag with the same name is returned (200) instead of a 409.
 func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
-	reqBody := mw.GetRequestBodyFromContext[dto.CreateTag](r.Context())
-	userID := mw.GetUserIDFromContext(r.Context())
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateTag](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	upsert := false
+	if upsertStr := r.URL.Query().Get("upsert"); upsertStr != "" {
+		val, err := strconv.ParseBool(upsertStr)
+		if err != nil {
+			h.logger.Warn("Invalid upsert query parameter",
+				zap.String("upsert", upsertStr),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, dto.ErrorResponse{
+				Error: dto.ErrorObject{
+					Code:   apperrors.CodeInvalidRequest,
+					Title:  "Invalid upsert value",
+					Detail: "upsert must be true or false",
+				},
+			})
+			return
+		}
+		upsert = val
+	}
+
+	if upsert {
+		h.upsertTag(w, r, userID, reqBody.Name)
+		return
+	}
 
 	createdTag, err := h.TagService.CreateTag(r.Context(), userID, reqBody.Name)
 	if err != nil {
@@ -71,14 +209,48 @@ func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
 	)
 
 	render.Status(r, http.StatusCreated)
-	render.JSON(w, r, &dto.SuccessResponse[db.CreateTagRow]{
-		Data: createdTag,
+	render.JSON(w, r, &dto.SuccessResponse[dto.TagResponse]{
+		Data: dto.NewTagResponse(db.ListUserTagsRow(createdTag)),
+	})
+}
+
+// upsertTag: POST /api/v1/tags?upsert=true
+func (h *TagHandler) upsertTag(w http.ResponseWriter, r *http.Request, userID string, name string) {
+	tag, err := h.TagService.UpsertTag(r.Context(), userID, name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if tag.Created {
+		status = http.StatusCreated
+		h.logger.Info("Tag created successfully",
+			zap.String("user_id", userID),
+			zap.String("tag_id", tag.ID.String()),
+			zap.String("tag_name", tag.Name),
+		)
+	}
+
+	// Same shape as a plain create; the status code tells whether the tag is new
+	render.Status(r, status)
+	render.JSON(w, r, &dto.SuccessResponse[dto.TagResponse]{
+		Data: dto.NewTagResponse(db.ListUserTagsRow{
+			ID:        tag.ID,
+			Name:      tag.Name,
+			CreatedAt: tag.CreatedAt,
+			UpdatedAt: tag.UpdatedAt,
+		}),
 	})
 }
 
 // UpdateTag: PATCH /api/v1/tags/{id}
 func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	tagID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
 	if uuidErr != nil {
@@ -100,7 +272,11 @@ func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reqBody := mw.GetRequestBodyFromContext[dto.UpdateTag](r.Context())
+	reqBody, err := mw.GetRequestBodyFromContext[dto.UpdateTag](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	updatedTag, err := h.TagService.UpdateTag(r.Context(), userID, tagID, reqBody.Name)
 	if err != nil {
@@ -109,14 +285,19 @@ func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[db.UpdateTagRow]{
-		Data: updatedTag,
+	render.JSON(w, r, &dto.SuccessResponse[dto.TagResponse]{
+		Data: dto.NewTagResponse(db.ListUserTagsRow(updatedTag)),
 	})
 }
 
 // DeleteTag: DELETE /api/v1/tags/{id}
+// The tag goes to the trash, see RestoreTag.
 func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	tagID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
 	if uuidErr != nil {
@@ -145,16 +326,24 @@ func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[db.DeleteTagRow]{
-		Data: deletedTag,
+	render.JSON(w, r, &dto.SuccessResponse[dto.TagResponse]{
+		Data: dto.NewTagResponse(db.ListUserTagsRow(deletedTag)),
 	})
 }
 
 // DeleteTags: DELETE /api/v1/tags/bulk
 func (h *TagHandler) DeleteTags(w http.ResponseWriter, r *http.Request) {
-	userID := mw.GetUserIDFromContext(r.Context())
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
-	reqBody := mw.GetRequestBodyFromContext[dto.DeleteTags](r.Context())
+	reqBody, err := mw.GetRequestBodyFromContext[dto.DeleteTags](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
 
 	deletedTags, err := h.TagService.DeleteTags(r.Context(), userID, reqBody.TagIDs)
 	if err != nil {
@@ -162,9 +351,75 @@ func (h *TagHandler) DeleteTags(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data := make([]dto.TagResponse, 0, len(deletedTags))
+	for _, tag := range deletedTags {
+		data = append(data, dto.NewTagResponse(db.ListUserTagsRow(tag)))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.TagResponse]{
+		Data: data,
+	})
+}
+
+// ListDeletedTags: GET /api/v1/tags/trash
+func (h *TagHandler) ListDeletedTags(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	tags, err := h.TagService.ListDeletedTags(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.TagResponse]{
+		Data: dto.NewDeletedTagResponses(tags),
+	})
+}
+
+// RestoreTag: POST /api/v1/tags/{id}/restore
+// Takes the tag out of the trash and puts it back on the links it was on.
+func (h *TagHandler) RestoreTag(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	tagID, uuidErr := uuid.Parse(chi.URLParam(r, "id"))
+	if uuidErr != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(uuidErr),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	restoredTag, err := h.TagService.RestoreTag(r.Context(), userID, tagID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &dto.SuccessResponse[[]db.DeleteTagsRow]{
-		Data: deletedTags,
+	render.JSON(w, r, &dto.SuccessResponse[dto.TagResponse]{
+		Data: dto.NewTagResponse(db.ListUserTagsRow(restoredTag)),
 	})
 }
 
@@ -201,6 +456,36 @@ func (h *TagHandler) handleError(w http.ResponseWriter, r *http.Request, err err
 			},
 		})
 
+	case errors.Is(err, apperrors.TagQuotaExceeded):
+		h.logger.Warn("Tag quota exceeded",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTagQuotaExceeded,
+				Title:  apperrors.TagQuotaExceeded.Error(),
+				Detail: "You have reached the maximum number of tags for your account",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidTagName):
+		h.logger.Warn("Invalid tag name",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidTagName,
+				Title:  apperrors.InvalidTagName.Error(),
+				Detail: err.Error(),
+			},
+		})
+
 	default:
 		h.logger.Error("Internal server error",
 			zap.Error(err),
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+// mockTagService implements the listing and restoring only; the other methods aren't called
+type mockTagService struct {
+	TagService
+	ListTagsFunc   func(ctx context.Context, userID string, opts service.TagListOptions, page, limit int) (*service.ListTagsResult, error)
+	RestoreTagFunc func(ctx context.Context, userID string, tagID uuid.UUID) (db.RestoreTagRow, error)
+}
+
+func (m *mockTagService) ListTags(ctx context.Context, userID string, opts service.TagListOptions, page, limit int) (*service.ListTagsResult, error) {
+	return m.ListTagsFunc(ctx, userID, opts, page, limit)
+}
+
+func (m *mockTagService) RestoreTag(ctx context.Context, userID string, tagID uuid.UUID) (db.RestoreTagRow, error) {
+	return m.RestoreTagFunc(ctx, userID, tagID)
+}
+
+func TestTagHandler_ListTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		wantOpts       service.TagListOptions
+		wantPage       bool
+		expectedStatus int
+	}{
+		{name: "all", query: "", expectedStatus: http.StatusOK},
+		{name: "prefix and sort", query: "?q=+wor+&sort=usage", wantOpts: service.TagListOptions{Prefix: "wor", Sort: service.TagSortUsage}, expectedStatus: http.StatusOK},
+		{name: "page", query: "?sort=created_at&limit=1", wantOpts: service.TagListOptions{Sort: service.TagSortCreatedAt}, wantPage: true, expectedStatus: http.StatusOK},
+		{name: "invalid sort", query: "?sort=popularity", expectedStatus: http.StatusBadRequest},
+		{name: "prefix too long", query: "?q=aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTagService{
+				ListTagsFunc: func(ctx context.Context, userID string, opts service.TagListOptions, page, limit int) (*service.ListTagsResult, error) {
+					if opts != tt.wantOpts {
+						t.Errorf("options = %+v, want %+v", opts, tt.wantOpts)
+					}
+					result := &service.ListTagsResult{Tags: []db.ListUserTagsPageRow{{ID: uuid.New(), Name: "work", LinkCount: 2}}}
+					if page > 0 || limit > 0 {
+						meta := pagination.Meta{Page: 1, Limit: limit, Total: 3, TotalPages: 3}
+						result.Meta = &meta
+					}
+					return result, nil
+				},
+			}
+			handler := NewTagHandler(mockService, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tags"+tt.query, nil)
+			req = req.WithContext(middleware.WithUserID(req.Context(), "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.ListTags(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp dto.SuccessResponse[[]dto.TagResponse]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Data) != 1 || resp.Data[0].LinkCount == nil || *resp.Data[0].LinkCount != 2 {
+				t.Errorf("data = %+v, want the work tag with 2 links", resp.Data)
+			}
+			if (resp.Pagination != nil) != tt.wantPage {
+				t.Errorf("pagination = %+v, want it only for a page", resp.Pagination)
+			}
+			if (w.Header().Get("Link") != "") != tt.wantPage {
+				t.Errorf("Link header = %q, want it only for a page", w.Header().Get("Link"))
+			}
+		})
+	}
+}
+
+func TestTagHandler_RestoreTag(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		err            error
+		expectedStatus int
+	}{
+		{name: "restored", id: uuid.NewString(), expectedStatus: http.StatusOK},
+		{name: "invalid id", id: "nope", expectedStatus: http.StatusBadRequest},
+		{name: "not in the trash", id: uuid.NewString(), err: apperrors.TagNotFound, expectedStatus: http.StatusNotFound},
+		{name: "name taken", id: uuid.NewString(), err: apperrors.TagNameTaken, expectedStatus: http.StatusConflict},
+		{name: "quota", id: uuid.NewString(), err: apperrors.TagQuotaExceeded, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTagService{
+				RestoreTagFunc: func(ctx context.Context, userID string, tagID uuid.UUID) (db.RestoreTagRow, error) {
+					if tt.err != nil {
+						return db.RestoreTagRow{}, fmt.Errorf("%w: test", tt.err)
+					}
+					return db.RestoreTagRow{ID: tagID, Name: "work"}, nil
+				},
+			}
+			handler := NewTagHandler(mockService, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/tags/"+tt.id+"/restore", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middleware.WithUserID(ctx, "user_123"))
+			w := httptest.NewRecorder()
+
+			handler.RestoreTag(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.TagResponse]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.ID.String() != tt.id || resp.Data.Name != "work" {
+				t.Errorf("data = %+v, want the restored work tag", resp.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// VerificationService defines the service methods needed by VerificationHandler
+type VerificationService interface {
+	Verify(ctx context.Context, adminID string, kind string, value string, name *string) (db.VerifiedSender, error)
+	Unverify(ctx context.Context, adminID string, kind string, value string) (db.VerifiedSender, error)
+	ListVerifiedSenders(ctx context.Context, page, limit int) (*service.ListVerifiedSendersResult, error)
+}
+
+// VerificationHandler serves the admin routes managing verified senders
+type VerificationHandler struct {
+	VerificationService VerificationService
+	logger              logger.Logger
+}
+
+func NewVerificationHandler(verificationService VerificationService, logger logger.Logger) *VerificationHandler {
+	return &VerificationHandler{
+		VerificationService: verificationService,
+		logger:              logger,
+	}
+}
+
+func verifiedSenderResponse(s db.VerifiedSender) dto.VerifiedSender {
+	return dto.VerifiedSender{
+		Kind:       s.Kind,
+		Value:      s.Value,
+		Name:       s.Name,
+		VerifiedBy: s.VerifiedBy,
+		CreatedAt:  s.CreatedAt.Time,
+	}
+}
+
+// ListVerifiedSenders: GET /api/v1/admin/verified-senders?page=1&limit=20
+func (h *VerificationHandler) ListVerifiedSenders(w http.ResponseWriter, r *http.Request) {
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.VerificationService.ListVerifiedSenders(r.Context(), page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Always an array, never null
+	senders := make([]dto.VerifiedSender, 0, len(result.Senders))
+	for _, s := range result.Senders {
+		senders = append(senders, verifiedSenderResponse(s))
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.VerifiedSender]{
+		Data:       senders,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
+
+/*
+VerifySender: PUT /api/v1/admin/verified-senders
+
+Marks a user, or a destination domain and its subdomains, as a verified
+sender. Their links show a "verified sender" badge on the interstitial page
+and in the public preview (GET /{shortcode}/preview).
+*/
+func (h *VerificationHandler) VerifySender(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.VerifySender](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	sender, err := h.VerificationService.Verify(r.Context(), adminID, reqBody.Kind, reqBody.Value, reqBody.Name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.VerifiedSender]{
+		Data: verifiedSenderResponse(sender),
+	})
+}
+
+// UnverifySender: DELETE /api/v1/admin/verified-senders/{kind}/{value}
+func (h *VerificationHandler) UnverifySender(w http.ResponseWriter, r *http.Request) {
+	adminID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	sender, err := h.VerificationService.Unverify(r.Context(), adminID, chi.URLParam(r, "kind"), chi.URLParam(r, "value"))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.VerifiedSender]{
+		Data: verifiedSenderResponse(sender),
+	})
+}
+
+func (h *VerificationHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.InvalidDomain):
+		h.logger.Warn("Invalid domain",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidDomain,
+				Title:  apperrors.InvalidDomain.Error(),
+				Detail: "The value must be a hostname, such as example.com",
+			},
+		})
+
+	case errors.Is(err, apperrors.VerifiedSenderNotFound):
+		h.logger.Warn("Verified sender not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeVerifiedSenderNotFound,
+				Title:  apperrors.VerifiedSenderNotFound.Error(),
+				Detail: "This sender isn't verified",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
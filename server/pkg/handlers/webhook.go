@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// WebhookService defines the service methods needed by WebhookHandler
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, userID string, url string) (db.Webhook, error)
+	ListWebhooks(ctx context.Context, userID string) ([]db.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID string, id uuid.UUID) (db.Webhook, error)
+	RotateSecret(ctx context.Context, userID string, id uuid.UUID, grace time.Duration) (db.Webhook, error)
+	SendTest(ctx context.Context, userID string, id uuid.UUID) (db.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, userID string, id uuid.UUID, page, limit int) (*service.ListWebhookDeliveriesResult, error)
+	RetryDelivery(ctx context.Context, userID string, id uuid.UUID, deliveryID uuid.UUID) (db.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	WebhookService WebhookService
+	logger         logger.Logger
+}
+
+func NewWebhookHandler(webhookService WebhookService, logger logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		WebhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// ListWebhooks: GET /api/v1/webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hooks, err := h.WebhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := make([]dto.Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		data = append(data, webhookResponse(hook))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.Webhook]{
+		Data: data,
+	})
+}
+
+// CreateWebhook: POST /api/v1/webhooks
+// The response includes the signing secret, as does the one of every rotation.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.CreateWebhook](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hook, err := h.WebhookService.CreateWebhook(r.Context(), userID, reqBody.URL)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := webhookResponse(hook)
+	data.Secret = hook.Secret
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, &dto.SuccessResponse[dto.Webhook]{
+		Data: data,
+	})
+}
+
+// DeleteWebhook: DELETE /api/v1/webhooks/{id}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hookID, ok := h.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	hook, err := h.WebhookService.DeleteWebhook(r.Context(), userID, hookID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.Webhook]{
+		Data: webhookResponse(hook),
+	})
+}
+
+/*
+RotateSecret: POST /api/v1/webhooks/{id}/rotate-secret
+
+Replaces the webhook's secret and returns the new one. The previous secret keeps
+signing deliveries next to it for previous_secret_ttl seconds (a day by default).
+*/
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.RotateWebhookSecret](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hookID, ok := h.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	grace := service.DefaultWebhookSecretGrace
+	if reqBody.PreviousSecretTTL != nil {
+		grace = time.Duration(*reqBody.PreviousSecretTTL) * time.Second
+	}
+
+	hook, err := h.WebhookService.RotateSecret(r.Context(), userID, hookID, grace)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := webhookResponse(hook)
+	data.Secret = hook.Secret
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.Webhook]{
+		Data: data,
+	})
+}
+
+/*
+TestWebhook: POST /api/v1/webhooks/{id}/test
+
+Delivers a signed webhook.test event to the webhook right away. The endpoint
+failing is not an error of this request: the response reports the delivery.
+*/
+func (h *WebhookHandler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hookID, ok := h.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.WebhookService.SendTest(r.Context(), userID, hookID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WebhookDelivery]{
+		Data: webhookDeliveryResponse(delivery),
+	})
+}
+
+// ListDeliveries: GET /api/v1/webhooks/{id}/deliveries?page=1&limit=20
+// The webhook's deliveries, newest first, with the payloads as sent.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hookID, ok := h.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	page, limit := pagination.FromQuery(r.URL.Query())
+
+	result, err := h.WebhookService.ListDeliveries(r.Context(), userID, hookID, page, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	data := make([]dto.WebhookDelivery, 0, len(result.Deliveries))
+	for _, delivery := range result.Deliveries {
+		data = append(data, webhookDeliveryResponse(delivery))
+	}
+
+	pageLinks := pagination.SetLinks(w, r, result.Meta)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[[]dto.WebhookDelivery]{
+		Data:       data,
+		Pagination: &result.Meta,
+		Links:      &pageLinks,
+	})
+}
+
+/*
+RetryDelivery: POST /api/v1/webhooks/{id}/deliveries/{deliveryID}/retry
+
+Sends the delivery's payload again, freshly signed and under the same event ID.
+Like a test, the endpoint failing doesn't fail this request.
+*/
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	hookID, ok := h.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "deliveryID")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "Delivery ID must be a valid UUID format",
+			},
+		})
+		return
+	}
+
+	delivery, err := h.WebhookService.RetryDelivery(r.Context(), userID, hookID, deliveryID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WebhookDelivery]{
+		Data: webhookDeliveryResponse(delivery),
+	})
+}
+
+// parseWebhookID reads the {id} URL parameter, answering with a 400 when it isn't a UUID
+func (h *WebhookHandler) parseWebhookID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	hookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn("Invalid ID format",
+			zap.Error(err),
+			zap.String("provided_id", chi.URLParam(r, "id")),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidID,
+				Title:  "Invalid ID format",
+				Detail: "ID must be a valid UUID format",
+			},
+		})
+		return uuid.UUID{}, false
+	}
+
+	return hookID, true
+}
+
+func webhookResponse(hook db.Webhook) dto.Webhook {
+	resp := dto.Webhook{
+		ID:        hook.ID,
+		URL:       hook.Url,
+		CreatedAt: hook.CreatedAt.Time,
+	}
+	if hook.SecretRotatedAt.Valid {
+		resp.SecretRotatedAt = &hook.SecretRotatedAt.Time
+	}
+	if hook.PreviousSecret != nil && hook.PreviousSecretExpiresAt.Valid && hook.PreviousSecretExpiresAt.Time.After(time.Now()) {
+		resp.PreviousSecretExpiresAt = &hook.PreviousSecretExpiresAt.Time
+	}
+	if hook.FailingSince.Valid {
+		resp.FailingSince = &hook.FailingSince.Time
+	}
+	if hook.DisabledAt.Valid {
+		resp.DisabledAt = &hook.DisabledAt.Time
+	}
+	return resp
+}
+
+func webhookDeliveryResponse(delivery db.WebhookDelivery) dto.WebhookDelivery {
+	resp := dto.WebhookDelivery{
+		ID:         delivery.ID,
+		EventID:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Payload:    delivery.Payload,
+		Pending:    delivery.Pending,
+		Succeeded:  !delivery.Pending && delivery.Error == nil,
+		StatusCode: delivery.StatusCode,
+		DurationMs: delivery.DurationMs,
+		Error:      delivery.Error,
+		Attempt:    delivery.Attempt,
+		CreatedAt:  delivery.CreatedAt.Time,
+	}
+	if delivery.RetryOf.Valid {
+		retryOf := uuid.UUID(delivery.RetryOf.Bytes)
+		resp.RetryOf = &retryOf
+	}
+	if delivery.NextRetryAt.Valid {
+		resp.NextRetryAt = &delivery.NextRetryAt.Time
+	}
+	return resp
+}
+
+func (h *WebhookHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.WebhookNotFound):
+		h.logger.Warn("Webhook not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeWebhookNotFound,
+				Title:  apperrors.WebhookNotFound.Error(),
+				Detail: "Unable to find webhook with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.WebhookDeliveryNotFound):
+		h.logger.Warn("Webhook delivery not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeWebhookDeliveryNotFound,
+				Title:  apperrors.WebhookDeliveryNotFound.Error(),
+				Detail: "Unable to find a delivery of this webhook with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.InvalidURL):
+		h.logger.Warn("Invalid URL",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInvalidURL,
+				Title:  apperrors.InvalidURL.Error(),
+				Detail: "",
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type mockWebhookService struct {
+	hookID uuid.UUID
+	grace  time.Duration
+}
+
+func (m *mockWebhookService) CreateWebhook(ctx context.Context, userID string, url string) (db.Webhook, error) {
+	return db.Webhook{ID: m.hookID, Url: url, Secret: "whsec_new"}, nil
+}
+
+func (m *mockWebhookService) ListWebhooks(ctx context.Context, userID string) ([]db.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockWebhookService) DeleteWebhook(ctx context.Context, userID string, id uuid.UUID) (db.Webhook, error) {
+	return db.Webhook{}, apperrors.WebhookNotFound
+}
+
+func (m *mockWebhookService) RotateSecret(ctx context.Context, userID string, id uuid.UUID, grace time.Duration) (db.Webhook, error) {
+	if id != m.hookID {
+		return db.Webhook{}, apperrors.WebhookNotFound
+	}
+	m.grace = grace
+	return db.Webhook{ID: id, Secret: "whsec_rotated"}, nil
+}
+
+func (m *mockWebhookService) SendTest(ctx context.Context, userID string, id uuid.UUID) (db.WebhookDelivery, error) {
+	if id != m.hookID {
+		return db.WebhookDelivery{}, apperrors.WebhookNotFound
+	}
+	status := int32(500)
+	failure := "endpoint returned status 500"
+	return db.WebhookDelivery{ID: uuid.New(), EventID: "evt_1", Payload: []byte(`{"id":"evt_1"}`), DurationMs: 40, Error: &failure, StatusCode: &status}, nil
+}
+
+func (m *mockWebhookService) ListDeliveries(ctx context.Context, userID string, id uuid.UUID, page, limit int) (*service.ListWebhookDeliveriesResult, error) {
+	return &service.ListWebhookDeliveriesResult{}, nil
+}
+
+func (m *mockWebhookService) RetryDelivery(ctx context.Context, userID string, id uuid.UUID, deliveryID uuid.UUID) (db.WebhookDelivery, error) {
+	return db.WebhookDelivery{}, apperrors.WebhookDeliveryNotFound
+}
+
+func webhookRequest(method, path, id string, body any) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = middleware.WithUserID(ctx, "user_123")
+	if body != nil {
+		ctx = middleware.WithRequestBody(ctx, body)
+	}
+	return req.WithContext(ctx)
+}
+
+func TestWebhookHandler_RotateSecret(t *testing.T) {
+	hookID := uuid.New()
+	zero := 0
+
+	tests := []struct {
+		name           string
+		id             string
+		body           dto.RotateWebhookSecret
+		expectedStatus int
+		expectedGrace  time.Duration
+	}{
+		{name: "default grace period", id: hookID.String(), expectedStatus: http.StatusOK, expectedGrace: service.DefaultWebhookSecretGrace},
+		{name: "previous secret dropped", id: hookID.String(), body: dto.RotateWebhookSecret{PreviousSecretTTL: &zero}, expectedStatus: http.StatusOK},
+		{name: "unknown webhook", id: uuid.NewString(), expectedStatus: http.StatusNotFound},
+		{name: "invalid ID", id: "nope", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := &mockWebhookService{hookID: hookID, grace: -1}
+			handler := NewWebhookHandler(hooks, createTestLogger())
+
+			w := httptest.NewRecorder()
+			handler.RotateSecret(w, webhookRequest(http.MethodPost, "/api/v1/webhooks/"+tt.id+"/rotate-secret", tt.id, tt.body))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			if hooks.grace != tt.expectedGrace {
+				t.Errorf("grace = %v, want %v", hooks.grace, tt.expectedGrace)
+			}
+			var resp dto.SuccessResponse[dto.Webhook]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.Secret != "whsec_rotated" {
+				t.Errorf("secret = %q, want the new secret", resp.Data.Secret)
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_TestWebhook(t *testing.T) {
+	hookID := uuid.New()
+	handler := NewWebhookHandler(&mockWebhookService{hookID: hookID}, createTestLogger())
+
+	w := httptest.NewRecorder()
+	handler.TestWebhook(w, webhookRequest(http.MethodPost, "/api/v1/webhooks/"+hookID.String()+"/test", hookID.String(), nil))
+
+	// A failing endpoint is reported, not an error of the request
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp dto.SuccessResponse[dto.WebhookDelivery]
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	got := resp.Data
+	if got.EventID != "evt_1" || got.Succeeded || got.StatusCode == nil || *got.StatusCode != 500 || got.DurationMs != 40 || got.Error == nil {
+		t.Errorf("delivery = %+v, want the failed delivery", got)
+	}
+	if string(got.Payload) != `{"id":"evt_1"}` {
+		t.Errorf("payload = %s, want the body as sent", got.Payload)
+	}
+}
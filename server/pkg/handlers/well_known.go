@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Association files apps request to enable universal links, with their content type
+var associationFiles = map[string]string{
+	"apple-app-site-association": "application/json",
+	"assetlinks.json":            "application/json",
+}
+
+var (
+	// ACME tokens are base64url
+	acmeTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// Hosts association files can be configured for
+	wellKnownHostPattern = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)*$`)
+)
+
+/*
+WellKnownHandler serves /.well-known/ paths from a directory, so domain
+verification works on short domains without the paths ever being looked up as
+shortcodes. The directory is laid out as:
+
+	acme-challenge/<token>                ACME HTTP-01 tokens, e.g. written by certbot --webroot
+	<file>                                association file served on every domain
+	domains/<host>/<file>                 association file for one domain, takes precedence
+
+where <file> is apple-app-site-association or assetlinks.json. Any other
+/.well-known/ path is a 404.
+*/
+type WellKnownHandler struct {
+	// Nil when no directory is configured: every path is a 404
+	root   *os.Root
+	logger logger.Logger
+}
+
+// NewWellKnownHandler serves files from dir; an empty dir serves nothing
+func NewWellKnownHandler(dir string, logger logger.Logger) (*WellKnownHandler, error) {
+	h := &WellKnownHandler{logger: logger}
+	if dir == "" {
+		return h, nil
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	h.root = root
+
+	return h, nil
+}
+
+// Serve: GET /.well-known/*
+func (h *WellKnownHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "*")
+
+	if token, ok := strings.CutPrefix(name, "acme-challenge/"); ok {
+		if !acmeTokenPattern.MatchString(token) {
+			http.NotFound(w, r)
+			return
+		}
+		// Tokens are short-lived: never cache them
+		w.Header().Set("Cache-Control", "no-store")
+		if !h.serveFile(w, r, "text/plain", path.Join("acme-challenge", token)) {
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	contentType, ok := associationFiles[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if host := wellKnownHost(r.Host); host != "" {
+		if h.serveFile(w, r, contentType, path.Join("domains", host, name)) {
+			return
+		}
+	}
+	if !h.serveFile(w, r, contentType, name) {
+		http.NotFound(w, r)
+	}
+}
+
+// serveFile writes the file if it exists and reports whether it did
+func (h *WellKnownHandler) serveFile(w http.ResponseWriter, r *http.Request, contentType string, name string) bool {
+	if h.root == nil {
+		return false
+	}
+
+	data, err := h.root.ReadFile(name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			h.logger.Error("Failed to read well-known file",
+				zap.Error(err),
+				zap.String("file", name),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+		}
+		return false
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+	return true
+}
+
+// wellKnownHost returns the request's host without port, or "" if it can't name a directory
+func wellKnownHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if !wellKnownHostPattern.MatchString(host) {
+		return ""
+	}
+	return host
+}
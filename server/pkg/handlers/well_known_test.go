@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestWellKnownHandler_Serve(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"acme-challenge/tok_123":                          "tok_123.key",
+		"apple-app-site-association":                      `{"default":true}`,
+		"domains/go.brand.com/apple-app-site-association": `{"brand":true}`,
+		"assetlinks.json":                                 `[]`,
+		"domains/go.brand.com/unlisted.json":              `{}`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		dir            string
+		host           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "serves acme token",
+			dir:            dir,
+			host:           "sho.rt",
+			path:           "acme-challenge/tok_123",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "tok_123.key",
+		},
+		{
+			name:           "unknown acme token is a 404",
+			dir:            dir,
+			host:           "sho.rt",
+			path:           "acme-challenge/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "acme token can't traverse",
+			dir:            dir,
+			host:           "sho.rt",
+			path:           "acme-challenge/../apple-app-site-association",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "serves default association file",
+			dir:            dir,
+			host:           "sho.rt",
+			path:           "apple-app-site-association",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"default":true}`,
+		},
+		{
+			name:           "domain association file takes precedence",
+			dir:            dir,
+			host:           "GO.BRAND.COM:443",
+			path:           "apple-app-site-association",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"brand":true}`,
+		},
+		{
+			name:           "domain falls back to default file",
+			dir:            dir,
+			host:           "go.brand.com",
+			path:           "assetlinks.json",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "unlisted file is a 404",
+			dir:            dir,
+			host:           "go.brand.com",
+			path:           "unlisted.json",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "no directory serves nothing",
+			host:           "sho.rt",
+			path:           "apple-app-site-association",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewWellKnownHandler(tt.dir, createTestLogger())
+			if err != nil {
+				t.Fatalf("NewWellKnownHandler() error = %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/.well-known/"+tt.path, nil)
+			req.Host = tt.host
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("*", tt.path)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			h.Serve(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
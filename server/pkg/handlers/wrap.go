@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"go.uber.org/zap"
+)
+
+// LinkWrapper shortens the URLs linked from an email
+type LinkWrapper interface {
+	QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error)
+}
+
+// CampaignLinker groups wrapped links under a campaign
+type CampaignLinker interface {
+	GetCampaign(ctx context.Context, userID string, id uuid.UUID) (db.GetCampaignByIdAndUserRow, error)
+	AddLinksToCampaign(ctx context.Context, userID string, id uuid.UUID, linkIDs []uuid.UUID) ([]db.ListCampaignLinksRow, error)
+}
+
+type WrapHandler struct {
+	links     LinkWrapper
+	campaigns CampaignLinker
+	// Origin short URLs are built on, the request's origin when empty
+	shortURLBase string
+	logger       logger.Logger
+}
+
+func NewWrapHandler(links LinkWrapper, campaigns CampaignLinker, shortURLBase string, logger logger.Logger) *WrapHandler {
+	return &WrapHandler{
+		links:        links,
+		campaigns:    campaigns,
+		shortURLBase: shortURLBase,
+		logger:       logger,
+	}
+}
+
+/*
+Wrap: POST /api/v1/wrap
+
+Replaces the links of an HTML email with short links grouped under a campaign,
+so clicks from the email are tracked. Each distinct URL gets one short link,
+reusing the user's existing default-settings link to it. If creating a link
+fails midway, the links created so far are kept.
+*/
+func (h *WrapHandler) Wrap(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := mw.GetRequestBodyFromContext[dto.WrapLinks](r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+	userID, err := mw.GetUserIDFromContext(r.Context())
+	if err != nil {
+		mw.RequestContextError(w, r, h.logger, err)
+		return
+	}
+
+	// Resolve the campaign first so an unknown ID creates no links
+	if _, err := h.campaigns.GetCampaign(r.Context(), userID, reqBody.CampaignID); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	urls := service.EmailLinkURLs(reqBody.HTML)
+	if len(urls) > service.MaxWrapLinks {
+		h.handleError(w, r, fmt.Errorf("%w: the email links %d URLs, at most %d can be wrapped", apperrors.TooManyLinks, len(urls), service.MaxWrapLinks))
+		return
+	}
+
+	base := shortURLBaseFor(h.shortURLBase, r)
+	wrapped := make([]dto.WrappedLink, 0, len(urls))
+	linkIDs := make([]uuid.UUID, 0, len(urls))
+	replacements := make(map[string]string, len(urls))
+
+	for _, u := range urls {
+		link, deduplicated, err := h.links.QuickShorten(r.Context(), userID, u, nil)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+
+		shortURL := base + "/" + link.Shortcode
+		replacements[u] = shortURL
+		linkIDs = append(linkIDs, link.ID)
+		wrapped = append(wrapped, dto.WrappedLink{
+			URL:          u,
+			LinkID:       link.ID,
+			Shortcode:    link.Shortcode,
+			ShortURL:     shortURL,
+			Deduplicated: deduplicated,
+		})
+	}
+
+	if len(linkIDs) > 0 {
+		if _, err := h.campaigns.AddLinksToCampaign(r.Context(), userID, reqBody.CampaignID, linkIDs); err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+	}
+
+	h.logger.Info("Email links wrapped",
+		zap.String("user_id", userID),
+		zap.String("campaign_id", reqBody.CampaignID.String()),
+		zap.Int("links", len(wrapped)),
+	)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &dto.SuccessResponse[dto.WrappedEmail]{
+		Data: dto.WrappedEmail{
+			HTML:  service.RewriteEmailLinks(reqBody.HTML, replacements),
+			Links: wrapped,
+		},
+	})
+}
+
+func (h *WrapHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apperrors.CampaignNotFound):
+		h.logger.Warn("Campaign not found",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeCampaignNotFound,
+				Title:  apperrors.CampaignNotFound.Error(),
+				Detail: "Unable to find campaign with the provided ID",
+			},
+		})
+
+	case errors.Is(err, apperrors.TooManyLinks):
+		h.logger.Warn("Too many links to wrap",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeTooManyLinks,
+				Title:  apperrors.TooManyLinks.Error(),
+				Detail: fmt.Sprintf("At most %d distinct URLs can be wrapped in one email", service.MaxWrapLinks),
+			},
+		})
+
+	case errors.Is(err, apperrors.LinkQuotaExceeded):
+		h.logger.Warn("Link quota exceeded",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeLinkQuotaExceeded,
+				Title:  apperrors.LinkQuotaExceeded.Error(),
+				Detail: "You have reached the maximum number of links for your account",
+			},
+		})
+
+	case linkPolicyViolation(err) != nil:
+		violation := linkPolicyViolation(err)
+		h.logger.Warn("Link policy violated",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   linkPolicyErrorCodes[violation],
+				Title:  violation.Error(),
+				Detail: err.Error(),
+			},
+		})
+
+	default:
+		h.logger.Error("Internal server error",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeInternalError,
+				Title:  apperrors.InternalError.Error(),
+				Detail: "",
+			},
+		})
+	}
+}
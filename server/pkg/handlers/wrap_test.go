@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type mockLinkWrapper struct {
+	shortened []string
+}
+
+func (m *mockLinkWrapper) QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (db.TryCreateLinkRow, bool, error) {
+	m.shortened = append(m.shortened, originalURL)
+	return db.TryCreateLinkRow{ID: uuid.New(), Shortcode: "s" + string(rune('0'+len(m.shortened))), OriginalUrl: originalURL}, false, nil
+}
+
+type mockCampaignLinker struct {
+	campaignID uuid.UUID
+	added      []uuid.UUID
+}
+
+func (m *mockCampaignLinker) GetCampaign(ctx context.Context, userID string, id uuid.UUID) (db.GetCampaignByIdAndUserRow, error) {
+	if id != m.campaignID {
+		return db.GetCampaignByIdAndUserRow{}, apperrors.CampaignNotFound
+	}
+	return db.GetCampaignByIdAndUserRow{ID: id}, nil
+}
+
+func (m *mockCampaignLinker) AddLinksToCampaign(ctx context.Context, userID string, id uuid.UUID, linkIDs []uuid.UUID) ([]db.ListCampaignLinksRow, error) {
+	m.added = append(m.added, linkIDs...)
+	return nil, nil
+}
+
+func TestWrapHandler_Wrap(t *testing.T) {
+	campaignID := uuid.New()
+	body := `<p><a href="https://example.com/a">A</a> <a href="https://example.com/b">B</a> <a href="https://example.com/a">A again</a></p>`
+
+	tests := []struct {
+		name           string
+		campaignID     uuid.UUID
+		html           string
+		expectedStatus int
+		expectedLinks  int
+	}{
+		{
+			name:           "wraps each distinct URL once",
+			campaignID:     campaignID,
+			html:           body,
+			expectedStatus: http.StatusOK,
+			expectedLinks:  2,
+		},
+		{
+			name:           "unknown campaign",
+			campaignID:     uuid.New(),
+			html:           body,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "too many links",
+			campaignID:     campaignID,
+			html:           manyLinks(service.MaxWrapLinks + 1),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := &mockLinkWrapper{}
+			campaigns := &mockCampaignLinker{campaignID: campaignID}
+			handler := NewWrapHandler(links, campaigns, "https://sho.rt", createTestLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wrap", nil)
+			ctx := middleware.WithUserID(req.Context(), "user_123")
+			ctx = middleware.WithRequestBody(ctx, dto.WrapLinks{HTML: tt.html, CampaignID: tt.campaignID})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.Wrap(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if w.Code != http.StatusOK {
+				if len(links.shortened) != 0 {
+					t.Errorf("shortened %d URLs on a failed request, want none", len(links.shortened))
+				}
+				return
+			}
+
+			var resp dto.SuccessResponse[dto.WrappedEmail]
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Data.Links) != tt.expectedLinks || len(campaigns.added) != tt.expectedLinks {
+				t.Fatalf("links = %d, added to campaign = %d, want %d", len(resp.Data.Links), len(campaigns.added), tt.expectedLinks)
+			}
+			wantHTML := `<p><a href="https://sho.rt/s1">A</a> <a href="https://sho.rt/s2">B</a> <a href="https://sho.rt/s1">A again</a></p>`
+			if resp.Data.HTML != wantHTML {
+				t.Errorf("html = %s, want %s", resp.Data.HTML, wantHTML)
+			}
+		})
+	}
+}
+
+func manyLinks(n int) string {
+	var b strings.Builder
+	for range n {
+		b.WriteString(`<a href="https://example.com/` + uuid.NewString() + `">x</a>`)
+	}
+	return b.String()
+}
@@ -0,0 +1,336 @@
+/*
+Package httpclient builds the HTTP clients of outbound integrations (webhooks,
+publish callbacks, anomaly notifications), with the defaults they should all
+share: bounded timeouts, pooled connections, retries with backoff, a cap on
+response sizes and counters on the internal /metrics endpoint.
+
+Clients refuse to connect to private, loopback and link-local addresses unless
+AllowPrivate is set. The check runs on the address actually dialed, after DNS
+resolution and on every redirect, so a public hostname resolving to an internal
+address (or redirecting to one) can't be used to reach internal services.
+*/
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/dnscache"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+)
+
+// Defaults of the zero Options
+const (
+	DefaultTimeout          = 10 * time.Second
+	DefaultMaxResponseBytes = 1 << 20
+	DefaultRetries          = 2
+	DefaultRetryBackoff     = 200 * time.Millisecond
+
+	dialTimeout         = 5 * time.Second
+	tlsHandshakeTimeout = 5 * time.Second
+	idleConnTimeout     = 90 * time.Second
+	maxIdleConnsPerHost = 10
+	maxRedirects        = 5
+)
+
+var (
+	// The destination resolved to a private, loopback or otherwise internal address
+	ErrBlockedAddress = errors.New("destination address is not allowed")
+	// The response body is larger than MaxResponseBytes
+	ErrResponseTooLarge = errors.New("response body too large")
+)
+
+// Options configures a client
+type Options struct {
+	// Name of the integration, which the client's counters are keyed by
+	Name string
+	// Whole-request timeout, retries included; zero means DefaultTimeout
+	Timeout time.Duration
+	// Largest response body read; zero means DefaultMaxResponseBytes
+	MaxResponseBytes int64
+	// Extra attempts at retryable requests; zero means DefaultRetries, negative disables retries
+	Retries int
+	// Wait before the first retry, doubled before each next one; zero means DefaultRetryBackoff
+	RetryBackoff time.Duration
+	// Allow private and loopback destinations, for integrations configured by the operator
+	AllowPrivate bool
+	// Resolves destinations through a cache; nil resolves them on every new connection
+	Resolver *dnscache.Resolver
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.MaxResponseBytes <= 0 {
+		o.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if o.Retries == 0 {
+		o.Retries = DefaultRetries
+	} else if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = DefaultRetryBackoff
+	}
+	return o
+}
+
+// New returns a client with the options' guardrails
+func New(opts Options) *http.Client {
+	opts = opts.withDefaults()
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if !opts.AllowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			if err := checkAddress(address); err != nil {
+				metrics.OutboundBlocked.Add(opts.Name, 1)
+				return err
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		// No proxy from the environment: the dialed address would be the proxy's, and unchecked
+		Proxy:                 nil,
+		DialContext:           dialContext(dialer, opts.Resolver),
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ResponseHeaderTimeout: opts.Timeout,
+	}
+
+	return &http.Client{
+		Transport: &roundTripper{next: transport, opts: opts},
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// dialContext dials through the resolver's cache, trying each address in turn
+func dialContext(dialer *net.Dialer, resolver *dnscache.Resolver) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if resolver == nil {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := resolver.LookupNetIP(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+
+		var errs []error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+// IsPublic reports whether addr is a publicly routable address, the only kind clients dial by default
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Special-purpose ranges that IsGlobalUnicast and IsPrivate don't exclude. The
+IPv6 transition ranges embed IPv4 addresses, which could be internal ones.
+*/
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // discard-only
+	netip.MustParsePrefix("2001::/32"),      // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+}
+
+// checkAddress fails with ErrBlockedAddress unless the dialed host:port is public
+func checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !IsPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// roundTripper counts requests, retries the retryable ones and caps response bodies
+type roundTripper struct {
+	next http.RoundTripper
+	opts Options
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	metrics.OutboundRequests.Add(t.opts.Name, 1)
+
+	retries := 0
+	if retryable(req) {
+		retries = t.opts.Retries
+	}
+
+	backoff := t.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewound(req); err != nil {
+				metrics.OutboundFailed.Add(t.opts.Name, 1)
+				return nil, err
+			}
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= retries || !shouldRetry(resp, err) {
+			if err != nil {
+				metrics.OutboundFailed.Add(t.opts.Name, 1)
+				return nil, err
+			}
+			return t.limit(resp)
+		}
+
+		if resp != nil {
+			// Drained so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		metrics.OutboundRetried.Add(t.opts.Name, 1)
+
+		// Jitter keeps clients that failed together from retrying together
+		wait := time.Duration(rand.Int64N(int64(backoff))) + backoff/2
+		backoff *= 2
+		select {
+		case <-req.Context().Done():
+			metrics.OutboundFailed.Add(t.opts.Name, 1)
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// limit fails responses announcing a body over MaxResponseBytes and cuts the others there
+func (t *roundTripper) limit(resp *http.Response) (*http.Response, error) {
+	if resp.ContentLength > t.opts.MaxResponseBytes {
+		resp.Body.Close()
+		metrics.OutboundTooLarge.Add(t.opts.Name, 1)
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.opts.MaxResponseBytes, name: t.opts.Name}
+	return resp, nil
+}
+
+/*
+retryable reports whether the request can be sent twice without side effects:
+its method is idempotent, or it carries an Idempotency-Key (the same rule
+http.Transport uses to retry on broken connections). Its body must also be
+replayable.
+*/
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// shouldRetry reports whether the attempt failed in a way another attempt could fix
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// Blocked destinations stay blocked, and expired contexts stay expired
+		return !errors.Is(err, ErrBlockedAddress) &&
+			!errors.Is(err, context.Canceled) &&
+			!errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewound returns a copy of the request, with a fresh body, for another attempt
+func rewound(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody == nil {
+		return clone, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+// limitedBody fails reads past remaining bytes with ErrResponseTooLarge
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	name      string
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// A byte more tells a body of exactly the limit from a larger one
+		var probe [1]byte
+		if n, err := b.ReadCloser.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		metrics.OutboundTooLarge.Add(b.name, 1)
+		return 0, ErrResponseTooLarge
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
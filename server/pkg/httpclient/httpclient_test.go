@@ -0,0 +1,142 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestClient_BlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := New(Options{Name: "test"}).Get(server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Get() on a loopback server error = %v, want ErrBlockedAddress", err)
+	}
+
+	resp, err := New(Options{Name: "test", AllowPrivate: true}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() with AllowPrivate error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestClient_Retries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPut && string(body) != "payload" {
+			t.Errorf("attempt %d body = %q, want the original body", attempts.Load()+1, body)
+		}
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{Name: "test", AllowPrivate: true, RetryBackoff: time.Millisecond})
+
+	tests := []struct {
+		name         string
+		method       string
+		header       string
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{name: "idempotent request", method: http.MethodPut, wantStatus: http.StatusOK, wantAttempts: 3},
+		{name: "POST", method: http.MethodPost, wantStatus: http.StatusServiceUnavailable, wantAttempts: 1},
+		{name: "POST with an idempotency key", method: http.MethodPost, header: "key-1", wantStatus: http.StatusOK, wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts.Store(0)
+			req, _ := http.NewRequest(tt.method, server.URL, strings.NewReader("payload"))
+			if tt.header != "" {
+				req.Header.Set("Idempotency-Key", tt.header)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestClient_MaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chunked, so the size is only known by reading
+		w.Write([]byte(strings.Repeat("a", 10)))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("a", 10)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		max     int64
+		wantErr error
+	}{
+		{name: "within the limit", max: 20},
+		{name: "over the limit", max: 15, wantErr: ErrResponseTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := New(Options{Name: "test", AllowPrivate: true, MaxResponseBytes: tt.max}).Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if _, err := io.ReadAll(resp.Body); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
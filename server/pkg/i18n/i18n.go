@@ -0,0 +1,172 @@
+// Package i18n translates the server-rendered HTML pages (404, private link,
+// interstitial, lead form, rate limit) using catalogs embedded in the binary.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when neither the visitor nor the domain selects a supported language.
+// Its catalog is also the fallback for keys missing from other catalogs.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps a language to its messages
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read locales: %v", err))
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", f.Name(), err))
+		}
+
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", f.Name(), err))
+		}
+		loaded[strings.TrimSuffix(f.Name(), ".json")] = messages
+	}
+
+	return loaded
+}
+
+// Languages returns the supported languages, sorted
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Supported reports whether there's a catalog for lang
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// T returns the message for key in lang, falling back to the default language and then to the key itself
+func T(lang, key string) string {
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[DefaultLanguage][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Negotiator picks the language of a page from the visitor's Accept-Language,
+// then the default language of the domain the request was made on.
+type Negotiator struct {
+	fallback string
+	domains  map[string]string
+}
+
+/*
+NewNegotiator builds a Negotiator. domainLanguages holds "host=lang" pairs
+(e.g. "go.example.de=de") giving a short domain its own default language;
+fallback applies to every other host.
+*/
+func NewNegotiator(fallback string, domainLanguages []string) (*Negotiator, error) {
+	if !Supported(fallback) {
+		return nil, fmt.Errorf("unsupported language %q (supported: %s)", fallback, strings.Join(Languages(), ", "))
+	}
+
+	domains := make(map[string]string, len(domainLanguages))
+	for _, pair := range domainLanguages {
+		host, lang, ok := strings.Cut(pair, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if !ok || host == "" {
+			return nil, fmt.Errorf("invalid domain language %q, expected host=lang", pair)
+		}
+		if !Supported(lang) {
+			return nil, fmt.Errorf("unsupported language %q for %s (supported: %s)", lang, host, strings.Join(Languages(), ", "))
+		}
+		domains[host] = lang
+	}
+
+	return &Negotiator{fallback: fallback, domains: domains}, nil
+}
+
+// Language returns the language to render r in
+func (n *Negotiator) Language(r *http.Request) string {
+	if lang := match(r.Header.Get("Accept-Language")); lang != "" {
+		return lang
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if lang, ok := n.domains[strings.ToLower(host)]; ok {
+		return lang
+	}
+
+	return n.fallback
+}
+
+// match returns the supported language the visitor prefers most, or "" if there is none.
+// Regional tags match their base language ("fr-CH" is served "fr").
+func match(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var prefs []weighted
+	for part := range strings.SplitSeq(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if qs, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(qs, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		prefs = append(prefs, weighted{tag: tag, q: q})
+	}
+
+	// Equal weights keep the visitor's order
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		if Supported(p.tag) {
+			return p.tag
+		}
+		if base, _, ok := strings.Cut(p.tag, "-"); ok && Supported(base) {
+			return base
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsAreComplete(t *testing.T) {
+	base := catalogs[DefaultLanguage]
+	if len(base) == 0 {
+		t.Fatalf("no %s catalog", DefaultLanguage)
+	}
+
+	for _, lang := range Languages() {
+		for key := range base {
+			msg, ok := catalogs[lang][key]
+			if !ok || msg == "" {
+				t.Errorf("%s: missing %q", lang, key)
+			}
+		}
+		for key := range catalogs[lang] {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: %q isn't in the %s catalog", lang, key, DefaultLanguage)
+			}
+		}
+
+		// The interstitial inserts the live countdown at the placeholder
+		if !strings.Contains(catalogs[lang]["interstitial.countdown"], "{seconds}") {
+			t.Errorf("%s: interstitial.countdown has no {seconds} placeholder", lang)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("fr", "not_found.title"); got != "Lien introuvable" {
+		t.Errorf("T(fr) = %q", got)
+	}
+	if got := T("xx", "not_found.title"); got != "Link Not Found" {
+		t.Errorf("T(unsupported) = %q, want the default language", got)
+	}
+	if got := T("fr", "no.such.key"); got != "no.such.key" {
+		t.Errorf("T(missing key) = %q, want the key", got)
+	}
+}
+
+func TestNegotiator_Language(t *testing.T) {
+	n, err := NewNegotiator("en", []string{"go.example.de=de", "GO.example.fr = fr"})
+	if err != nil {
+		t.Fatalf("NewNegotiator() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		host           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "no header", host: "sho.rt", want: "en"},
+		{name: "exact match", host: "sho.rt", acceptLanguage: "es", want: "es"},
+		{name: "regional tag matches its base", host: "sho.rt", acceptLanguage: "fr-CH", want: "fr"},
+		{name: "case insensitive", host: "sho.rt", acceptLanguage: "DE-de", want: "de"},
+		{name: "highest weight wins", host: "sho.rt", acceptLanguage: "es;q=0.5, el;q=0.9, fr;q=0.7", want: "el"},
+		{name: "order breaks ties", host: "sho.rt", acceptLanguage: "fr, es", want: "fr"},
+		{name: "unsupported languages are skipped", host: "sho.rt", acceptLanguage: "ja, zh-CN;q=0.9, es;q=0.1", want: "es"},
+		{name: "q=0 means not acceptable", host: "sho.rt", acceptLanguage: "fr;q=0, de;q=0.2", want: "de"},
+		{name: "malformed weight is ignored", host: "sho.rt", acceptLanguage: "fr;q=high, de", want: "de"},
+		{name: "wildcard falls back to the domain", host: "go.example.de", acceptLanguage: "ja, *;q=0.5", want: "de"},
+		{name: "domain default", host: "go.example.fr", want: "fr"},
+		{name: "domain default with port", host: "go.example.fr:8080", want: "fr"},
+		{name: "visitor preference beats the domain", host: "go.example.fr", acceptLanguage: "es", want: "es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/abc123", nil)
+			r.Host = tt.host
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			if got := n.Language(r); got != tt.want {
+				t.Errorf("Language() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNegotiator_Invalid(t *testing.T) {
+	if _, err := NewNegotiator("xx", nil); err == nil {
+		t.Error("NewNegotiator() accepted an unsupported default language")
+	}
+	for _, pair := range []string{"go.example.de", "=de", "go.example.jp=ja"} {
+		if _, err := NewNegotiator("en", []string{pair}); err == nil {
+			t.Errorf("NewNegotiator() accepted %q", pair)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Package loadshed tells when the server is saturated, so low-priority traffic
+(the management API) can be delayed or shed while redirects keep their
+database connections and goroutines.
+
+The Monitor samples two signals every interval: the average time requests
+waited for a Postgres connection since the previous sample, and the number of
+running goroutines. The server is saturated while either is over its
+threshold.
+*/
+package loadshed
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// PoolStatsFunc returns the connection pool's cumulative acquire count and
+// the total time acquires spent waiting for a free connection
+type PoolStatsFunc func() (acquires int64, waited time.Duration)
+
+// Options configures a Monitor. A zero threshold disables its signal.
+type Options struct {
+	// Average wait for a database connection over an interval
+	MaxPoolWait time.Duration
+	// Running goroutines
+	MaxGoroutines int
+	// Time between samples
+	Interval time.Duration
+}
+
+// Monitor samples the saturation signals in the background
+type Monitor struct {
+	opts      Options
+	poolStats PoolStatsFunc
+	logger    logger.Logger
+
+	saturated atomic.Bool
+
+	// Pool counters at the previous sample
+	lastAcquires int64
+	lastWaited   time.Duration
+}
+
+// NewMonitor creates a monitor; poolStats may be nil when there's no pool to watch
+func NewMonitor(opts Options, poolStats PoolStatsFunc, logger logger.Logger) *Monitor {
+	return &Monitor{
+		opts:      opts,
+		poolStats: poolStats,
+		logger:    logger,
+	}
+}
+
+// Saturated reports whether the last sample was over a threshold
+func (m *Monitor) Saturated() bool {
+	return m.saturated.Load()
+}
+
+// Start samples now and then every interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			m.sample()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sample reads the signals and updates the saturation state, logging its changes
+func (m *Monitor) sample() {
+	var poolWait time.Duration
+	if m.poolStats != nil {
+		acquires, waited := m.poolStats()
+		if n := acquires - m.lastAcquires; n > 0 {
+			poolWait = (waited - m.lastWaited) / time.Duration(n)
+		}
+		m.lastAcquires, m.lastWaited = acquires, waited
+	}
+	goroutines := runtime.NumGoroutine()
+
+	saturated := (m.opts.MaxPoolWait > 0 && poolWait > m.opts.MaxPoolWait) ||
+		(m.opts.MaxGoroutines > 0 && goroutines > m.opts.MaxGoroutines)
+
+	if m.saturated.Swap(saturated) == saturated {
+		return
+	}
+	if saturated {
+		metrics.LoadSaturated.Set(1)
+		m.logger.Warn("Server saturated, shedding API traffic",
+			zap.Duration("pool_wait", poolWait),
+			zap.Int("goroutines", goroutines),
+		)
+		return
+	}
+	metrics.LoadSaturated.Set(0)
+	m.logger.Info("Server no longer saturated",
+		zap.Duration("pool_wait", poolWait),
+		zap.Int("goroutines", goroutines),
+	)
+}
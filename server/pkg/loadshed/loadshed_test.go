@@ -0,0 +1,55 @@
+package loadshed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+func TestMonitor_Sample(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	var acquires int64
+	var waited time.Duration
+	m := NewMonitor(Options{MaxPoolWait: 50 * time.Millisecond, Interval: time.Second}, func() (int64, time.Duration) {
+		return acquires, waited
+	}, log)
+
+	steps := []struct {
+		name     string
+		acquires int64
+		waited   time.Duration
+		want     bool
+	}{
+		{"idle pool", 0, 0, false},
+		{"short waits", 100, time.Second, false},
+		{"long waits", 110, 2 * time.Second, true},
+		{"no acquires since", 110, 2 * time.Second, false},
+		{"waits are averaged over the interval only", 210, 3 * time.Second, false},
+	}
+
+	for _, step := range steps {
+		acquires, waited = step.acquires, step.waited
+		m.sample()
+		if got := m.Saturated(); got != step.want {
+			t.Errorf("%s: Saturated() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestMonitor_SampleGoroutines(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	m := NewMonitor(Options{MaxGoroutines: 1, Interval: time.Second}, nil, log)
+	m.sample()
+	if !m.Saturated() {
+		t.Error("Saturated() = false with more goroutines than allowed, want true")
+	}
+}
@@ -45,7 +45,7 @@ func New(env string) (*ZapLogger, error) {
 	var zapLogger *zap.Logger
 	var err error
 
-	isDev := env == "dev" || env == "development"
+	isDev := env == "dev" || env == "development" || env == "local"
 
 	if isDev {
 		config := zap.NewDevelopmentConfig()
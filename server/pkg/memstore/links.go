@@ -0,0 +1,301 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// linkTag is one element of the tags column, as json_build_object renders it
+type linkTag struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// live reports whether the link isn't deleted
+func live(l db.Link) bool {
+	return !l.DeletedAt.Valid
+}
+
+// activeNow reports whether the link redirects: active and not expired
+func activeNow(l db.Link) bool {
+	return l.IsActive && (!l.ExpiresAt.Valid || l.ExpiresAt.Time.After(time.Now().UTC()))
+}
+
+// liveShortcodeTaken reports whether another live link uses the shortcode
+func (d data) liveShortcodeTaken(shortcode string, except uuid.UUID) bool {
+	for _, l := range d.links {
+		if l.ID != except && live(l) && l.Shortcode == shortcode {
+			return true
+		}
+	}
+	return false
+}
+
+// tagsOf returns the link's tags, by name
+func (d data) tagsOf(linkID uuid.UUID) []linkTag {
+	tags := []linkTag{}
+	for lt := range d.linkTags {
+		if lt.LinkID != linkID {
+			continue
+		}
+		if t, ok := d.tags[lt.TagID]; ok {
+			tags = append(tags, linkTag{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt})
+		}
+	}
+	slices.SortFunc(tags, func(a, b linkTag) int { return strings.Compare(a.Name, b.Name) })
+	return tags
+}
+
+// hasAnyTag reports whether the link has one of the tags
+func (d data) hasAnyTag(linkID uuid.UUID, tagIDs []uuid.UUID) bool {
+	for _, id := range tagIDs {
+		if _, ok := d.linkTags[db.LinkTag{LinkID: linkID, TagID: id}]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// userLinks returns the user's live links matching the filters of ListUserLinks, newest first
+func (d data) userLinks(userID string, isActive *bool, tagIDs []uuid.UUID) []db.Link {
+	var links []db.Link
+	for _, l := range d.links {
+		if l.UserID != userID || !live(l) {
+			continue
+		}
+		if isActive != nil && activeNow(l) != *isActive {
+			continue
+		}
+		if tagIDs != nil && !d.hasAnyTag(l.ID, tagIDs) {
+			continue
+		}
+		links = append(links, l)
+	}
+	sortNewestFirst(links)
+	return links
+}
+
+func sortNewestFirst(links []db.Link) {
+	slices.SortFunc(links, func(a, b db.Link) int { return b.CreatedAt.Time.Compare(a.CreatedAt.Time) })
+}
+
+// userLink returns the user's live link with the id
+func (d data) userLink(id uuid.UUID, userID string) (db.Link, bool) {
+	l, ok := d.links[id]
+	return l, ok && l.UserID == userID && live(l)
+}
+
+func (s *Store) TryCreateLink(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	if d.liveShortcodeTaken(arg.Shortcode, uuid.Nil) {
+		return db.TryCreateLinkRow{}, pgx.ErrNoRows
+	}
+
+	created := now()
+	link := db.Link{
+		ID:                  uuid.New(),
+		Shortcode:           arg.Shortcode,
+		OriginalUrl:         arg.OriginalUrl,
+		UserID:              arg.UserID,
+		ExpiresAt:           arg.ExpiresAt,
+		CreatedAt:           created,
+		UpdatedAt:           created,
+		IsActive:            true,
+		Visibility:          arg.Visibility,
+		CaptureEmail:        arg.CaptureEmail,
+		RedirectDelay:       arg.RedirectDelay,
+		InterstitialMessage: arg.InterstitialMessage,
+		RawUrl:              arg.RawUrl,
+		AppendClickID:       arg.AppendClickID,
+		Title:               arg.Title,
+		Shield:              arg.Shield,
+		ReferrerPolicy:      arg.ReferrerPolicy,
+	}
+	d.links[link.ID] = link
+
+	return project[db.TryCreateLinkRow](link), nil
+}
+
+func (s *Store) GetLinkForRedirect(ctx context.Context, shortcode string) (db.GetLinkForRedirectRow, error) {
+	defer s.lock()()
+
+	for _, l := range s.state.data.links {
+		if l.Shortcode != shortcode || !live(l) {
+			continue
+		}
+		if l.RetiredAt.Valid || activeNow(l) {
+			row := project[db.GetLinkForRedirectRow](l)
+			if l.RawUrl != nil {
+				row.OriginalUrl = *l.RawUrl
+			}
+			return row, nil
+		}
+	}
+	return db.GetLinkForRedirectRow{}, pgx.ErrNoRows
+}
+
+func (s *Store) ListUserLinks(ctx context.Context, arg db.ListUserLinksParams) ([]db.ListUserLinksRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	links := d.userLinks(arg.UserID, arg.IsActive, arg.TagIds)
+	start := min(int(arg.Offset), len(links))
+	end := min(start+int(arg.Limit), len(links))
+
+	var rows []db.ListUserLinksRow
+	for _, l := range links[start:end] {
+		row := project[db.ListUserLinksRow](l)
+		row.Tags = d.tagsOf(l.ID)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *Store) ListUserLinksByIDs(ctx context.Context, arg db.ListUserLinksByIDsParams) ([]db.ListUserLinksByIDsRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	var links []db.Link
+	for _, id := range arg.Ids {
+		if l, ok := d.userLink(id, arg.UserID); ok && !slices.ContainsFunc(links, func(x db.Link) bool { return x.ID == id }) {
+			links = append(links, l)
+		}
+	}
+	sortNewestFirst(links)
+
+	var rows []db.ListUserLinksByIDsRow
+	for _, l := range links {
+		row := project[db.ListUserLinksByIDsRow](l)
+		row.Tags = d.tagsOf(l.ID)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *Store) CountUserLinks(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
+	defer s.lock()()
+	return int64(len(s.state.data.userLinks(arg.UserID, arg.IsActive, arg.TagIds))), nil
+}
+
+func (s *Store) GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+	defer s.lock()()
+
+	l, ok := s.state.data.userLink(arg.ID, arg.UserID)
+	if !ok {
+		return db.GetLinkByIdAndUserRow{}, pgx.ErrNoRows
+	}
+	return project[db.GetLinkByIdAndUserRow](l), nil
+}
+
+func (s *Store) GetLinkByIdAndUserWithTags(ctx context.Context, arg db.GetLinkByIdAndUserWithTagsParams) (db.GetLinkByIdAndUserWithTagsRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	l, ok := d.userLink(arg.ID, arg.UserID)
+	if !ok {
+		return db.GetLinkByIdAndUserWithTagsRow{}, pgx.ErrNoRows
+	}
+	row := project[db.GetLinkByIdAndUserWithTagsRow](l)
+	row.Tags = d.tagsOf(l.ID)
+	return row, nil
+}
+
+func (s *Store) GetLinkByShortcodeAndUser(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	for _, l := range d.links {
+		if l.Shortcode == arg.Shortcode && l.UserID == arg.UserID && live(l) {
+			row := project[db.GetLinkByShortcodeAndUserRow](l)
+			row.Tags = d.tagsOf(l.ID)
+			return row, nil
+		}
+	}
+	return db.GetLinkByShortcodeAndUserRow{}, pgx.ErrNoRows
+}
+
+func (s *Store) UpdateLink(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	l, ok := d.userLink(arg.ID, arg.UserID)
+	if !ok || l.RetiredAt.Valid {
+		return db.UpdateLinkRow{}, pgx.ErrNoRows
+	}
+	if arg.Shortcode != nil && d.liveShortcodeTaken(*arg.Shortcode, l.ID) {
+		return db.UpdateLinkRow{}, uniqueViolation("idx_links_shortcode")
+	}
+
+	setIfNotNil(&l.Shortcode, arg.Shortcode)
+	setIfNotNil(&l.IsActive, arg.IsActive)
+	if arg.ExpiresAt.Valid {
+		l.ExpiresAt = arg.ExpiresAt
+	}
+	setIfNotNil(&l.Visibility, arg.Visibility)
+	setIfNotNil(&l.CaptureEmail, arg.CaptureEmail)
+	setIfNotNil(&l.RedirectDelay, arg.RedirectDelay)
+	if arg.InterstitialMessage != nil {
+		l.InterstitialMessage = arg.InterstitialMessage
+	}
+	setIfNotNil(&l.AppendClickID, arg.AppendClickID)
+	setIfNotNil(&l.Shield, arg.Shield)
+	setIfNotNil(&l.ReferrerPolicy, arg.ReferrerPolicy)
+	l.UpdatedAt = now()
+	d.links[l.ID] = l
+
+	return project[db.UpdateLinkRow](l), nil
+}
+
+// setIfNotNil mirrors COALESCE(sqlc.narg(x), x)
+func setIfNotNil[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func (s *Store) DeleteLink(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	l, ok := d.userLink(arg.ID, arg.UserID)
+	if !ok || l.RetiredAt.Valid {
+		return db.DeleteLinkRow{}, pgx.ErrNoRows
+	}
+	l.DeletedAt = now()
+	l.UpdatedAt = l.DeletedAt
+	d.links[l.ID] = l
+
+	return project[db.DeleteLinkRow](l), nil
+}
+
+func (s *Store) GetUserLinkByURL(ctx context.Context, arg db.GetUserLinkByURLParams) (db.GetUserLinkByURLRow, error) {
+	defer s.lock()()
+
+	for _, l := range s.state.data.userLinks(arg.UserID, nil, nil) {
+		if l.OriginalUrl == arg.OriginalUrl && activeNow(l) && l.Visibility == "public" && !l.CaptureEmail &&
+			l.RedirectDelay == 0 && !l.AppendClickID && !l.Shield && l.ReferrerPolicy == "default" && !l.RetiredAt.Valid {
+			return project[db.GetUserLinkByURLRow](l), nil
+		}
+	}
+	return db.GetUserLinkByURLRow{}, pgx.ErrNoRows
+}
+
+// GetShortcodeReservation finds nothing: reservations need Postgres
+func (s *Store) GetShortcodeReservation(ctx context.Context, shortcode string) (db.ShortcodeReservation, error) {
+	return db.ShortcodeReservation{}, pgx.ErrNoRows
+}
+
+// CreateActivityEvent drops the event: the activity feed needs Postgres
+func (s *Store) CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error {
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Package memstore keeps links and tags in memory, for running the server
+without Postgres (APP_ENV=local). Everything is lost on restart.
+
+Store implements the link and tag queries with the same semantics as the SQL
+ones: the same not-found errors (pgx.ErrNoRows) and unique violations
+(SQLSTATE 23505), so services behave as they do on Postgres. Every other query
+comes from an embedded *db.Queries that fails with db.ErrUnsupported, so features
+beyond links and tags (campaigns, stats, previews, ...) answer with an error
+instead of crashing the server.
+*/
+package memstore
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// Store holds links and tags in memory
+type Store struct {
+	// Queries the store doesn't implement; they fail with db.ErrUnsupported
+	*db.Queries
+
+	state *state
+	// Set on the store handed to a unit of work, which already holds the lock
+	inTx bool
+}
+
+type state struct {
+	mu   sync.Mutex
+	data data
+}
+
+// data is copied whole to roll back a failed unit of work.
+// Rows are stored by value, so a shallow copy of each map is enough.
+type data struct {
+	links    map[uuid.UUID]db.Link
+	tags     map[uuid.UUID]db.Tag
+	linkTags map[db.LinkTag]struct{}
+}
+
+func (d data) clone() data {
+	return data{
+		links:    maps.Clone(d.links),
+		tags:     maps.Clone(d.tags),
+		linkTags: maps.Clone(d.linkTags),
+	}
+}
+
+func New() *Store {
+	return &Store{
+		Queries: db.New(db.Unsupported()),
+		state: &state{data: data{
+			links:    make(map[uuid.UUID]db.Link),
+			tags:     make(map[uuid.UUID]db.Tag),
+			linkTags: make(map[db.LinkTag]struct{}),
+		}},
+	}
+}
+
+// lock locks the store for one query, unless it's part of a unit of work that holds the lock already
+func (s *Store) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.state.mu.Lock()
+	return s.state.mu.Unlock
+}
+
+/*
+WithTx runs fn as a unit of work: it has the store to itself until it returns,
+and its writes are undone if it returns an error.
+*/
+func (s *Store) WithTx(ctx context.Context, fn func(q *Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	snapshot := s.state.data.clone()
+	if err := fn(&Store{Queries: s.Queries, state: s.state, inTx: true}); err != nil {
+		s.state.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// uniqueViolation is the error Postgres returns when a unique constraint is violated
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{Code: "23505", ConstraintName: constraint, Message: "duplicate key value violates unique constraint"}
+}
+
+// now mirrors NOW() stored in a TIMESTAMP column
+func now() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+}
+
+/*
+project copies the fields of src into a new T by name, the way the generated
+row types select a subset of a table's columns. Fields of T that src doesn't
+have are left zero.
+*/
+func project[T any](src any) T {
+	var dst T
+	dv := reflect.ValueOf(&dst).Elem()
+	sv := reflect.ValueOf(src)
+
+	for i := range dv.NumField() {
+		field := dv.Type().Field(i)
+		if v := sv.FieldByName(field.Name); v.IsValid() && v.Type() == field.Type {
+			dv.Field(i).Set(v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,311 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func createLink(t *testing.T, s *Store, userID, shortcode string) db.TryCreateLinkRow {
+	t.Helper()
+	link, err := s.TryCreateLink(context.Background(), db.TryCreateLinkParams{
+		Shortcode:   shortcode,
+		OriginalUrl: "https://example.com/" + shortcode,
+		UserID:      userID,
+		Visibility:  "public",
+	})
+	if err != nil {
+		t.Fatalf("TryCreateLink(%q) failed: %v", shortcode, err)
+	}
+	return link
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func TestStore_CreateAndRedirect(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	link := createLink(t, s, "user_1", "abc123")
+	if !link.IsActive {
+		t.Error("expected a new link to be active")
+	}
+
+	redirect, err := s.GetLinkForRedirect(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("GetLinkForRedirect failed: %v", err)
+	}
+	if redirect.OriginalUrl != "https://example.com/abc123" {
+		t.Errorf("expected the original URL, got %q", redirect.OriginalUrl)
+	}
+
+	// A taken shortcode is reported as no row, like ON CONFLICT DO NOTHING
+	_, err = s.TryCreateLink(ctx, db.TryCreateLinkParams{Shortcode: "abc123", OriginalUrl: "https://example.org", UserID: "user_2"})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for a taken shortcode, got %v", err)
+	}
+
+	if _, err := s.GetLinkForRedirect(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for an unknown shortcode, got %v", err)
+	}
+}
+
+func TestStore_LinksAreScopedToUser(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	link := createLink(t, s, "user_1", "abc123")
+
+	_, err := s.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{ID: link.ID, UserID: "user_2"})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for another user's link, got %v", err)
+	}
+
+	if _, err := s.DeleteLink(ctx, db.DeleteLinkParams{ID: link.ID, UserID: "user_2"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows deleting another user's link, got %v", err)
+	}
+	if _, err := s.DeleteLink(ctx, db.DeleteLinkParams{ID: link.ID, UserID: "user_1"}); err != nil {
+		t.Fatalf("DeleteLink failed: %v", err)
+	}
+
+	// Deleted links free their shortcode
+	createLink(t, s, "user_2", "abc123")
+}
+
+func TestStore_UpdateLinkShortcodeConflict(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	createLink(t, s, "user_1", "first")
+	second := createLink(t, s, "user_1", "second")
+
+	taken := "first"
+	_, err := s.UpdateLink(ctx, db.UpdateLinkParams{ID: second.ID, UserID: "user_1", Shortcode: &taken})
+	if !isUniqueViolation(err) {
+		t.Fatalf("expected a unique violation, got %v", err)
+	}
+
+	free := "third"
+	updated, err := s.UpdateLink(ctx, db.UpdateLinkParams{ID: second.ID, UserID: "user_1", Shortcode: &free})
+	if err != nil {
+		t.Fatalf("UpdateLink failed: %v", err)
+	}
+	if updated.Shortcode != "third" || updated.OriginalUrl != "https://example.com/second" {
+		t.Errorf("expected only the shortcode to change, got %+v", updated)
+	}
+}
+
+func TestStore_ListFiltersByTag(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	tagged := createLink(t, s, "user_1", "tagged")
+	createLink(t, s, "user_1", "untagged")
+	createLink(t, s, "user_2", "other")
+
+	tag, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	if _, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_1"}); !isUniqueViolation(err) {
+		t.Errorf("expected a unique violation for a duplicate tag name, got %v", err)
+	}
+	if err := s.AddTagsToLink(ctx, db.AddTagsToLinkParams{LinkID: tagged.ID, UserID: "user_1", TagIDs: []uuid.UUID{tag.ID}}); err != nil {
+		t.Fatalf("AddTagsToLink failed: %v", err)
+	}
+
+	all, err := s.ListUserLinks(ctx, db.ListUserLinksParams{UserID: "user_1", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUserLinks failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected 2 links for user_1, got %d", len(all))
+	}
+
+	filtered, err := s.ListUserLinks(ctx, db.ListUserLinksParams{UserID: "user_1", TagIds: []uuid.UUID{tag.ID}, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUserLinks failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != tagged.ID {
+		t.Fatalf("expected only the tagged link, got %+v", filtered)
+	}
+	if tags, ok := filtered[0].Tags.([]linkTag); !ok || len(tags) != 1 || tags[0].Name != "work" {
+		t.Errorf("expected the link's tags, got %#v", filtered[0].Tags)
+	}
+
+	// Deleting the tag untags its links
+	if _, err := s.DeleteTag(ctx, db.DeleteTagParams{ID: tag.ID, UserID: "user_1"}); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	filtered, err = s.ListUserLinks(ctx, db.ListUserLinksParams{UserID: "user_1", TagIds: []uuid.UUID{tag.ID}, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUserLinks failed: %v", err)
+	}
+	if len(filtered) != 0 {
+		t.Errorf("expected no links for a deleted tag, got %d", len(filtered))
+	}
+}
+
+func TestStore_WithTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	failure := errors.New("boom")
+
+	err := s.WithTx(ctx, func(q *Store) error {
+		createLink(t, q, "user_1", "abc123")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the unit of work's error, got %v", err)
+	}
+
+	if _, err := s.GetLinkForRedirect(ctx, "abc123"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the link to be rolled back, got %v", err)
+	}
+
+	err = s.WithTx(ctx, func(q *Store) error {
+		createLink(t, q, "user_1", "abc123")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+	if _, err := s.GetLinkForRedirect(ctx, "abc123"); err != nil {
+		t.Errorf("expected the link to be committed, got %v", err)
+	}
+}
+
+func TestStore_UnsupportedQueries(t *testing.T) {
+	_, err := New().ListUserCampaigns(context.Background(), "user_1")
+	if !errors.Is(err, db.ErrUnsupported) {
+		t.Errorf("expected db.ErrUnsupported, got %v", err)
+	}
+}
+
+func TestStore_ListUserTagsPage(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	link := createLink(t, s, "user_1", "tagged")
+	var ids []uuid.UUID
+	for _, name := range []string{"Work", "workshop", "home"} {
+		tag, err := s.CreateTag(ctx, db.CreateTagParams{Name: name, UserID: "user_1"})
+		if err != nil {
+			t.Fatalf("CreateTag(%q) failed: %v", name, err)
+		}
+		ids = append(ids, tag.ID)
+	}
+	if _, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_2"}); err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	if err := s.AddTagsToLink(ctx, db.AddTagsToLinkParams{LinkID: link.ID, UserID: "user_1", TagIDs: ids[1:2]}); err != nil {
+		t.Fatalf("AddTagsToLink failed: %v", err)
+	}
+
+	names := func(sort string, limit, offset int32) []string {
+		t.Helper()
+		rows, err := s.ListUserTagsPage(ctx, db.ListUserTagsPageParams{UserID: "user_1", Prefix: "WOR", Sort: sort, RowLimit: limit, RowOffset: offset})
+		if err != nil {
+			t.Fatalf("ListUserTagsPage failed: %v", err)
+		}
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Name)
+		}
+		return names
+	}
+
+	if got := names("name", 10, 0); len(got) != 2 || got[0] != "Work" || got[1] != "workshop" {
+		t.Errorf("expected the tags starting with wor by name, got %v", got)
+	}
+	if got := names("usage", 1, 0); len(got) != 1 || got[0] != "workshop" {
+		t.Errorf("expected the most used tag first, got %v", got)
+	}
+	if got := names("name", 10, 1); len(got) != 1 || got[0] != "workshop" {
+		t.Errorf("expected the second page, got %v", got)
+	}
+
+	total, err := s.CountUserTagsByPrefix(ctx, db.CountUserTagsByPrefixParams{UserID: "user_1", Prefix: "wor"})
+	if err != nil {
+		t.Fatalf("CountUserTagsByPrefix failed: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("expected 2 tags starting with wor, got %d", total)
+	}
+}
+
+func TestStore_DeleteAndRestoreTag(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	link := createLink(t, s, "user_1", "tagged")
+	tag, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	if err := s.AddTagsToLink(ctx, db.AddTagsToLinkParams{LinkID: link.ID, UserID: "user_1", TagIDs: []uuid.UUID{tag.ID}}); err != nil {
+		t.Fatalf("AddTagsToLink failed: %v", err)
+	}
+	linkCount := func() int64 {
+		t.Helper()
+		rows, err := s.ListUserTagsPage(ctx, db.ListUserTagsPageParams{UserID: "user_1", Sort: "name", RowLimit: 10})
+		if err != nil {
+			t.Fatalf("ListUserTagsPage failed: %v", err)
+		}
+		if len(rows) != 1 {
+			t.Fatalf("expected 1 live tag, got %d", len(rows))
+		}
+		return rows[0].LinkCount
+	}
+
+	if _, err := s.DeleteTag(ctx, db.DeleteTagParams{ID: tag.ID, UserID: "user_1"}); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if tags, _ := s.ListUserTags(ctx, "user_1"); len(tags) != 0 {
+		t.Errorf("expected no live tags after the delete, got %v", tags)
+	}
+	trashed, err := s.ListDeletedTags(ctx, "user_1")
+	if err != nil {
+		t.Fatalf("ListDeletedTags failed: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].LinkCount != 1 || !trashed[0].DeletedAt.Valid {
+		t.Fatalf("expected the tag in the trash with its link, got %+v", trashed)
+	}
+
+	// The name is free while the tag is in the trash
+	clash, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("CreateTag with a trashed tag's name failed: %v", err)
+	}
+	if _, err := s.RestoreTag(ctx, db.RestoreTagParams{ID: tag.ID, UserID: "user_1"}); !isUniqueViolation(err) {
+		t.Errorf("expected a unique violation restoring over a live tag, got %v", err)
+	}
+	if _, err := s.DeleteTag(ctx, db.DeleteTagParams{ID: clash.ID, UserID: "user_1"}); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+
+	if _, err := s.RestoreTag(ctx, db.RestoreTagParams{ID: tag.ID, UserID: "user_2"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected another user's restore to find nothing, got %v", err)
+	}
+	restored, err := s.RestoreTag(ctx, db.RestoreTagParams{ID: tag.ID, UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("RestoreTag failed: %v", err)
+	}
+	if restored.ID != tag.ID || restored.Name != "work" {
+		t.Errorf("expected the work tag back, got %+v", restored)
+	}
+	if got := linkCount(); got != 1 {
+		t.Errorf("expected the restored tag back on its link, got %d links", got)
+	}
+	if _, err := s.RestoreTag(ctx, db.RestoreTagParams{ID: tag.ID, UserID: "user_1"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected restoring a live tag to find nothing, got %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// userTagByName returns the user's live tag with the name
+func (d data) userTagByName(userID, name string) (db.Tag, bool) {
+	for _, t := range d.tags {
+		if t.UserID == userID && t.Name == name && !t.DeletedAt.Valid {
+			return t, true
+		}
+	}
+	return db.Tag{}, false
+}
+
+// userTag returns the user's live tag with the id
+func (d data) userTag(id uuid.UUID, userID string) (db.Tag, bool) {
+	t, ok := d.tags[id]
+	return t, ok && t.UserID == userID && !t.DeletedAt.Valid
+}
+
+func (d data) createTag(userID, name string) db.Tag {
+	created := now()
+	t := db.Tag{ID: uuid.New(), Name: name, UserID: userID, CreatedAt: created, UpdatedAt: created}
+	d.tags[t.ID] = t
+	return t
+}
+
+// trashTag moves the tag to the trash, detaching it from its links and remembering them
+func (d data) trashTag(t db.Tag) db.Tag {
+	t.DetachedLinkIds = []uuid.UUID{}
+	for lt := range d.linkTags {
+		if lt.TagID == t.ID {
+			t.DetachedLinkIds = append(t.DetachedLinkIds, lt.LinkID)
+			delete(d.linkTags, lt)
+		}
+	}
+	t.DeletedAt = now()
+	t.UpdatedAt = t.DeletedAt
+	d.tags[t.ID] = t
+	return t
+}
+
+func (s *Store) ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+	defer s.lock()()
+
+	var rows []db.ListUserTagsRow
+	for _, t := range s.state.data.tags {
+		if t.UserID == userID && !t.DeletedAt.Valid {
+			rows = append(rows, project[db.ListUserTagsRow](t))
+		}
+	}
+	slices.SortFunc(rows, func(a, b db.ListUserTagsRow) int { return strings.Compare(a.Name, b.Name) })
+	return rows, nil
+}
+
+// userTagsByPrefix returns the user's tags whose name starts with prefix, without case
+func (d data) userTagsByPrefix(userID, prefix string) []db.Tag {
+	prefix = strings.ToLower(prefix)
+	var tags []db.Tag
+	for _, t := range d.tags {
+		if t.UserID == userID && !t.DeletedAt.Valid && strings.HasPrefix(strings.ToLower(t.Name), prefix) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func (s *Store) ListUserTagsPage(ctx context.Context, arg db.ListUserTagsPageParams) ([]db.ListUserTagsPageRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	var rows []db.ListUserTagsPageRow
+	for _, t := range d.userTagsByPrefix(arg.UserID, arg.Prefix) {
+		row := project[db.ListUserTagsPageRow](t)
+		for lt := range d.linkTags {
+			if l, ok := d.links[lt.LinkID]; ok && lt.TagID == t.ID && live(l) {
+				row.LinkCount++
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	slices.SortFunc(rows, func(a, b db.ListUserTagsPageRow) int {
+		switch {
+		case arg.Sort == "usage" && a.LinkCount != b.LinkCount:
+			return cmp.Compare(b.LinkCount, a.LinkCount)
+		case arg.Sort == "created_at" && !a.CreatedAt.Time.Equal(b.CreatedAt.Time):
+			return b.CreatedAt.Time.Compare(a.CreatedAt.Time)
+		}
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID.String(), b.ID.String()))
+	})
+
+	start := min(int(arg.RowOffset), len(rows))
+	end := min(start+int(arg.RowLimit), len(rows))
+	return rows[start:end], nil
+}
+
+func (s *Store) CountUserTagsByPrefix(ctx context.Context, arg db.CountUserTagsByPrefixParams) (int64, error) {
+	defer s.lock()()
+	return int64(len(s.state.data.userTagsByPrefix(arg.UserID, arg.Prefix))), nil
+}
+
+func (s *Store) CreateTag(ctx context.Context, arg db.CreateTagParams) (db.CreateTagRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	if _, ok := d.userTagByName(arg.UserID, arg.Name); ok {
+		return db.CreateTagRow{}, uniqueViolation("index_tags_user_id_name")
+	}
+	return project[db.CreateTagRow](d.createTag(arg.UserID, arg.Name)), nil
+}
+
+func (s *Store) UpsertTag(ctx context.Context, arg db.UpsertTagParams) (db.UpsertTagRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	if t, ok := d.userTagByName(arg.UserID, arg.Name); ok {
+		return project[db.UpsertTagRow](t), nil
+	}
+	row := project[db.UpsertTagRow](d.createTag(arg.UserID, arg.Name))
+	row.Created = true
+	return row, nil
+}
+
+func (s *Store) UpsertTagsByName(ctx context.Context, arg db.UpsertTagsByNameParams) ([]db.UpsertTagsByNameRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	var rows []db.UpsertTagsByNameRow
+	seen := make(map[string]bool)
+	for _, name := range arg.Names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		t, ok := d.userTagByName(arg.UserID, name)
+		if !ok {
+			t = d.createTag(arg.UserID, name)
+		}
+		rows = append(rows, project[db.UpsertTagsByNameRow](t))
+	}
+	return rows, nil
+}
+
+func (s *Store) UpdateTag(ctx context.Context, arg db.UpdateTagParams) (db.UpdateTagRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	t, ok := d.userTag(arg.ID, arg.UserID)
+	if !ok {
+		return db.UpdateTagRow{}, pgx.ErrNoRows
+	}
+	if other, ok := d.userTagByName(arg.UserID, arg.Name); ok && other.ID != t.ID {
+		return db.UpdateTagRow{}, uniqueViolation("index_tags_user_id_name")
+	}
+	t.Name = arg.Name
+	t.UpdatedAt = now()
+	d.tags[t.ID] = t
+
+	return project[db.UpdateTagRow](t), nil
+}
+
+func (s *Store) DeleteTag(ctx context.Context, arg db.DeleteTagParams) (db.DeleteTagRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	t, ok := d.userTag(arg.ID, arg.UserID)
+	if !ok {
+		return db.DeleteTagRow{}, pgx.ErrNoRows
+	}
+	return project[db.DeleteTagRow](d.trashTag(t)), nil
+}
+
+func (s *Store) DeleteTags(ctx context.Context, arg db.DeleteTagsParams) ([]db.DeleteTagsRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	var rows []db.DeleteTagsRow
+	for _, id := range arg.TagIDs {
+		if t, ok := d.userTag(id, arg.UserID); ok {
+			rows = append(rows, project[db.DeleteTagsRow](d.trashTag(t)))
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) ListDeletedTags(ctx context.Context, userID string) ([]db.ListDeletedTagsRow, error) {
+	defer s.lock()()
+
+	var rows []db.ListDeletedTagsRow
+	for _, t := range s.state.data.tags {
+		if t.UserID == userID && t.DeletedAt.Valid {
+			row := project[db.ListDeletedTagsRow](t)
+			row.LinkCount = int64(len(t.DetachedLinkIds))
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b db.ListDeletedTagsRow) int {
+		return cmp.Or(b.DeletedAt.Time.Compare(a.DeletedAt.Time), strings.Compare(a.Name, b.Name))
+	})
+	return rows, nil
+}
+
+// RestoreTag takes the tag out of the trash and puts it back on the user's links it was detached from
+func (s *Store) RestoreTag(ctx context.Context, arg db.RestoreTagParams) (db.RestoreTagRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	t, ok := d.tags[arg.ID]
+	if !ok || t.UserID != arg.UserID || !t.DeletedAt.Valid {
+		return db.RestoreTagRow{}, pgx.ErrNoRows
+	}
+	if _, ok := d.userTagByName(t.UserID, t.Name); ok {
+		return db.RestoreTagRow{}, uniqueViolation("index_tags_user_id_name")
+	}
+
+	for _, linkID := range t.DetachedLinkIds {
+		if l, ok := d.links[linkID]; ok && l.UserID == t.UserID {
+			d.linkTags[db.LinkTag{LinkID: linkID, TagID: t.ID}] = struct{}{}
+		}
+	}
+	t.DeletedAt = pgtype.Timestamptz{}
+	t.DetachedLinkIds = nil
+	t.UpdatedAt = now()
+	d.tags[t.ID] = t
+	return project[db.RestoreTagRow](t), nil
+}
+
+func (s *Store) CountUserTagsByIDs(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	var count int64
+	for id := range d.tags {
+		if _, ok := d.userTag(id, arg.UserID); ok && slices.Contains(arg.Ids, id) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) ListUserTagNamesByIDs(ctx context.Context, arg db.ListUserTagNamesByIDsParams) ([]string, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	var names []string
+	for _, id := range arg.Ids {
+		if t, ok := d.userTag(id, arg.UserID); ok {
+			names = append(names, t.Name)
+		}
+	}
+	return names, nil
+}
+
+// AddTagsToLink assigns the user's tags to the user's live link; others are ignored
+func (s *Store) AddTagsToLink(ctx context.Context, arg db.AddTagsToLinkParams) error {
+	defer s.lock()()
+	d := s.state.data
+
+	if _, ok := d.userLink(arg.LinkID, arg.UserID); !ok {
+		return nil
+	}
+	for _, id := range arg.TagIDs {
+		if _, ok := d.userTag(id, arg.UserID); ok {
+			d.linkTags[db.LinkTag{LinkID: arg.LinkID, TagID: id}] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (s *Store) RemoveTagsFromLink(ctx context.Context, arg db.RemoveTagsFromLinkParams) error {
+	defer s.lock()()
+	d := s.state.data
+
+	if _, ok := d.userLink(arg.LinkID, arg.UserID); !ok {
+		return nil
+	}
+	for _, id := range arg.TagIDs {
+		delete(d.linkTags, db.LinkTag{LinkID: arg.LinkID, TagID: id})
+	}
+	return nil
+}
+
+// SuggestTagsForHost ranks the user's tags by how many of their links to the host (ignoring a leading www.) carry them
+func (s *Store) SuggestTagsForHost(ctx context.Context, arg db.SuggestTagsForHostParams) ([]db.SuggestTagsForHostRow, error) {
+	defer s.lock()()
+	d := s.state.data
+
+	counts := make(map[uuid.UUID]int64)
+	for _, l := range d.userLinks(arg.UserID, nil, nil) {
+		u, err := url.Parse(l.OriginalUrl)
+		if err != nil || strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") != arg.Host {
+			continue
+		}
+		for _, t := range d.tagsOf(l.ID) {
+			counts[t.ID]++
+		}
+	}
+
+	var rows []db.SuggestTagsForHostRow
+	for id, n := range counts {
+		rows = append(rows, db.SuggestTagsForHostRow{ID: id, Name: d.tags[id].Name, LinkCount: n})
+	}
+	slices.SortFunc(rows, func(a, b db.SuggestTagsForHostRow) int {
+		if a.LinkCount != b.LinkCount {
+			return int(b.LinkCount - a.LinkCount)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(rows) > int(arg.MaxResults) {
+		rows = rows[:arg.MaxResults]
+	}
+	return rows, nil
+}
@@ -0,0 +1,93 @@
+// Package metrics defines the application counters served on the internal /metrics endpoint.
+// Counters are published with expvar, next to the Go runtime stats.
+package metrics
+
+import "expvar"
+
+// Shortcode enumeration guard (see middleware.EnumerationGuard)
+var (
+	// Redirects that ended in a 404
+	EnumerationNotFound = expvar.NewInt("enumeration_not_found_total")
+	// Clients put on the temporary block list
+	EnumerationBlocked = expvar.NewInt("enumeration_blocked_total")
+	// Requests rejected because the client was on the block list
+	EnumerationRejected = expvar.NewInt("enumeration_rejected_total")
+)
+
+// Bot shield on links with shield mode (see service.BotShield)
+var (
+	// Challenge pages served instead of the redirect, by the heuristic that flagged the client
+	ShieldChallenged = expvar.NewMap("shield_challenged_total")
+	// Redirects let through on a solved challenge
+	ShieldPassed = expvar.NewInt("shield_passed_total")
+)
+
+// "Did you mean" suggestions on 404 pages (see service.ShortcodeSuggester)
+var (
+	// 404 pages that suggested at least one link
+	SuggestionsShown = expvar.NewInt("suggestions_shown_total")
+)
+
+// Traffic caps (see service.LinkService.TakeTrafficCap)
+var (
+	// Clicks sent to a link's overflow URL because it reached its cap
+	TrafficCapOverflow = expvar.NewInt("traffic_cap_overflow_total")
+)
+
+// Waiting rooms (see handlers.LinkHandler.renderWaitingRoom)
+var (
+	// Holding pages served instead of the redirect while a link's waiting room is active
+	WaitingRoomServed = expvar.NewInt("waiting_room_served_total")
+)
+
+// Buffered click writes (see analytics.Buffer)
+var (
+	// Clicks written to the analytics backend in batches
+	ClicksWritten = expvar.NewInt("clicks_written_total")
+	// Clicks given up on, by reason: buffer_full or write_failed
+	ClicksDropped = expvar.NewMap("clicks_dropped_total")
+)
+
+// Webhook deliveries (see service.WebhookService.Notify)
+var (
+	// Deliveries left to the retry job because their webhook had too many being sent already
+	WebhookDeliveriesDeferred = expvar.NewInt("webhook_deliveries_deferred_total")
+)
+
+// Expensive operation throttling (see throttle.Limiter)
+var (
+	// Operations turned away, by reason: user_limit, queue_full or queue_timeout
+	ThrottleRejected = expvar.NewMap("throttle_rejected_total")
+)
+
+// Load shedding (see loadshed.Monitor and middleware.ShedLoad)
+var (
+	// 1 while the server is saturated, 0 otherwise
+	LoadSaturated = expvar.NewInt("load_saturated")
+	// API requests held back until the server was no longer saturated
+	LoadShedDelayed = expvar.NewInt("load_shed_delayed_total")
+	// API requests rejected because the server stayed saturated
+	LoadShedRejected = expvar.NewInt("load_shed_rejected_total")
+)
+
+// Outbound requests of integrations, by integration (see httpclient.New)
+var (
+	// Requests sent, retries not counted
+	OutboundRequests = expvar.NewMap("outbound_requests_total")
+	// Requests that failed without a response, after their retries
+	OutboundFailed = expvar.NewMap("outbound_failed_total")
+	// Attempts retried after a network error or a 429, 502, 503 or 504
+	OutboundRetried = expvar.NewMap("outbound_retried_total")
+	// Connections refused because the destination was a private or internal address
+	OutboundBlocked = expvar.NewMap("outbound_blocked_total")
+	// Responses cut at the client's maximum response size
+	OutboundTooLarge = expvar.NewMap("outbound_too_large_total")
+)
+
+// DNS cache of outbound checks (see dnscache.Resolver)
+var (
+	// Lookups answered from the cache, negative answers included
+	DNSCacheHits = expvar.NewInt("dns_cache_hits_total")
+	// Lookups sent to the nameservers
+	DNSCacheMisses = expvar.NewInt("dns_cache_misses_total")
+)
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+/*
+RequireAdmin limits the routes it wraps to the given users, answering 403 to
+everyone else. It must run after RequireAuth. With no admins configured the
+routes are closed to all.
+*/
+func RequireAdmin(adminIDs []string, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := GetUserIDFromContext(r.Context())
+			if err != nil {
+				RequestContextError(w, r, log, err)
+				return
+			}
+
+			if !slices.Contains(adminIDs, userID) {
+				log.Warn("Admin route requested by non-admin",
+					zap.String("user_id", userID),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, dto.ErrorResponse{
+					Error: dto.ErrorObject{
+						Code:   apperrors.CodeAdminRequired,
+						Title:  apperrors.AdminRequired.Error(),
+						Detail: "Only admins can perform this action",
+					},
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		adminIDs       []string
+		userID         string
+		expectedStatus int
+	}{
+		{name: "admin", adminIDs: []string{"admin_1", "admin_2"}, userID: "admin_2", expectedStatus: http.StatusOK},
+		{name: "not an admin", adminIDs: []string{"admin_1"}, userID: "user_123", expectedStatus: http.StatusForbidden},
+		{name: "no admins configured", userID: "user_123", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireAdmin(tt.adminIDs, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/verified-senders", nil)
+			req = req.WithContext(WithUserID(req.Context(), tt.userID))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
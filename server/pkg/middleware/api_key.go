@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries API keys, so they never mix with the session tokens of the Authorization header
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyFunc resolves the API key a key sent from clientIP is,
+// an error wrapping apperrors.InvalidAPIKey if there's none
+type APIKeyFunc func(ctx context.Context, key string, clientIP netip.Addr) (reqctx.APIKey, error)
+
+/*
+APIKeyAuth authenticates requests sending an API key in the X-API-Key header.
+The key's owner becomes the request's user, so RequireAuth and the handlers
+after it treat the request as the owner's, but like ServiceAccountAuth only
+routes whose scope (see scopeFor) the key was granted are served; the others
+are answered with 403.
+
+Requests without the header are left to RequireAuth, which must run after it.
+*/
+func APIKeyAuth(authenticate APIKeyFunc, scopeFor func(r *http.Request) string, log logger.Logger) func(http.Handler) http.Handler {
+	failure := authFailureHandler(log)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rc, _ := reqctx.From(r.Context())
+			apiKey, err := authenticate(r.Context(), key, rc.ClientIP)
+			if err != nil {
+				if errors.Is(err, apperrors.InvalidAPIKey) {
+					log.Debug("Ignoring invalid API key",
+						zap.String("method", r.Method),
+						zap.String("path", r.URL.Path),
+					)
+				} else {
+					log.Error("Failed to verify API key",
+						zap.Error(err),
+						zap.String("method", r.Method),
+						zap.String("path", r.URL.Path),
+					)
+				}
+				failure.ServeHTTP(w, r)
+				return
+			}
+
+			scope := scopeFor(r)
+			if scope == "" || !slices.Contains(apiKey.Scopes, scope) {
+				log.Warn("Route requested with an API key without its scope",
+					zap.String("api_key_id", apiKey.ID),
+					zap.String("scope", scope),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+
+				detail := "API keys can't use this route"
+				if scope != "" {
+					detail = "This route requires the " + scope + " scope"
+				}
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, dto.ErrorResponse{
+					Error: dto.ErrorObject{
+						Code:   apperrors.CodeInsufficientScope,
+						Title:  apperrors.InsufficientScope.Error(),
+						Detail: detail,
+					},
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(reqctx.WithAPIKey(r.Context(), apiKey)))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	keys := func(ctx context.Context, key string, clientIP netip.Addr) (reqctx.APIKey, error) {
+		if key != "ak_valid" {
+			return reqctx.APIKey{}, apperrors.InvalidAPIKey
+		}
+		return reqctx.APIKey{ID: "key_1", UserID: "user_123", Scopes: []string{"links:read"}}, nil
+	}
+	scopeFor := func(r *http.Request) string {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1/links"):
+			return "links:read"
+		case strings.HasPrefix(r.URL.Path, "/api/v1/tags"):
+			return "tags:read"
+		}
+		return ""
+	}
+	provider := fakeProvider{"valid": "user_456"}
+
+	tests := []struct {
+		name           string
+		path           string
+		apiKey         string
+		authorization  string
+		expectedStatus int
+		expectedUserID string
+		expectedKeyID  string
+	}{
+		{name: "links route", path: "/api/v1/links", apiKey: "ak_valid", expectedStatus: http.StatusOK, expectedUserID: "user_123", expectedKeyID: "key_1"},
+		{name: "route without the scope", path: "/api/v1/tags", apiKey: "ak_valid", expectedStatus: http.StatusForbidden},
+		{name: "unscoped route", path: "/api/v1/admin/users", apiKey: "ak_valid", expectedStatus: http.StatusForbidden},
+		{name: "revoked key", path: "/api/v1/links", apiKey: "ak_revoked", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid key with a valid session", path: "/api/v1/links", apiKey: "ak_revoked", authorization: "Bearer valid", expectedStatus: http.StatusUnauthorized},
+		{name: "session is left to RequireAuth", path: "/api/v1/tags", authorization: "Bearer valid", expectedStatus: http.StatusOK, expectedUserID: "user_456"},
+		{name: "no credentials", path: "/api/v1/links", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID, keyID string
+			handler := APIKeyAuth(keys, scopeFor, log)(RequireAuth(provider, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = GetUserIDFromContext(r.Context())
+				if key, ok := reqctx.APIKeyFrom(r.Context()); ok {
+					keyID = key.ID
+				}
+				w.WriteHeader(http.StatusOK)
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if userID != tt.expectedUserID {
+				t.Errorf("user ID = %q, want %q", userID, tt.expectedUserID)
+			}
+			if keyID != tt.expectedKeyID {
+				t.Errorf("API key ID = %q, want %q", keyID, tt.expectedKeyID)
+			}
+		})
+	}
+}
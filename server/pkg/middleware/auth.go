@@ -2,21 +2,22 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
-	"github.com/clerk/clerk-sdk-go/v2"
-	clerkhttp "github.com/clerk/clerk-sdk-go/v2/http"
 	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
 	"github.com/styltsou/url-shortener/server/pkg/dto"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
 	"go.uber.org/zap"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "user_id"
-
 // authFailureHandler returns an HTTP handler that writes authentication failure
 // responses using our API error schema format.
 func authFailureHandler(log logger.Logger) http.Handler {
@@ -39,51 +40,129 @@ func authFailureHandler(log logger.Logger) http.Handler {
 
 /*
 RequireAuth is a middleware that:
-1. Requires and validates the Authorization header (via Clerk's RequireHeaderAuthorization)
-2. Extracts session claims from context (added by Clerk)
-3. Adds userID to context for handlers to use
+1. Requires a session token in the Authorization header
+2. Verifies it with the auth provider (Clerk or an OIDC issuer, see AUTH_PROVIDER)
+3. Adds the user ID it belongs to to the context for handlers to use
+
+Requests already authenticated by ServiceAccountAuth or APIKeyAuth pass through.
 */
-func RequireAuth(log logger.Logger) func(http.Handler) http.Handler {
-	clerkAuth := clerkhttp.RequireHeaderAuthorization(
-		clerkhttp.AuthorizationFailureHandler(authFailureHandler(log)),
-	)
+func RequireAuth(provider auth.Provider, log logger.Logger) func(http.Handler) http.Handler {
+	failure := authFailureHandler(log)
 
 	return func(next http.Handler) http.Handler {
-		return clerkAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			claims, ok := clerk.SessionClaimsFromContext(r.Context())
-
-			if !ok || claims == nil {
-				log.Error("Session claims missing after successful authentication",
-					zap.String("method", r.Method),
-					zap.String("path", r.URL.Path),
-				)
-
-				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, dto.ErrorResponse{
-					Error: dto.ErrorObject{
-						Code:   apperrors.CodeInternalError,
-						Title:  apperrors.InternalError.Error(),
-						Detail: "An internal error occurred while processing your request",
-					},
-				})
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := reqctx.ServiceAccountFrom(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, ok := reqctx.APIKeyFrom(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := bearerToken(r)
+			if token == "" {
+				failure.ServeHTTP(w, r)
+				return
+			}
+
+			userID, err := provider.Authenticate(r.Context(), token)
+			if err != nil {
+				logAuthError(log, r, err)
+				failure.ServeHTTP(w, r)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), userIDKey, claims.Subject)
+			ctx := reqctx.WithUserID(r.Context(), userID)
 			next.ServeHTTP(w, r.WithContext(ctx))
-		}))
+		})
+	}
+}
+
+// sessionCookieName is the cookie Clerk's frontend SDKs store the session token in
+const sessionCookieName = "__session"
+
+/*
+OptionalAuth adds the user ID to the context when the request carries a valid
+session token, either as a Bearer token or as the __session cookie.
+Requests without a session, or with an invalid one, continue anonymously.
+
+Use GetOptionalUserIDFromContext to read the user ID in handlers.
+*/
+func OptionalAuth(provider auth.Provider, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := sessionToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// An invalid session is treated like no session at all
+			userID, err := provider.Authenticate(r.Context(), token)
+			if err != nil {
+				logAuthError(log, r, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(reqctx.WithUserID(r.Context(), userID)))
+		})
 	}
 }
 
-// GetUserID extracts the user ID from the request context.
-func GetUserIDFromContext(ctx context.Context) string {
-	userID, ok := ctx.Value(userIDKey).(string)
+// logAuthError logs a rejected token at debug level, and the provider failing to verify one as an error
+func logAuthError(log logger.Logger, r *http.Request, err error) {
+	if errors.Is(err, auth.ErrInvalidToken) {
+		log.Debug("Ignoring invalid session",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		return
+	}
+
+	log.Error("Failed to verify session",
+		zap.Error(err),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+}
+
+// bearerToken reads the session token from the Authorization header
+func bearerToken(r *http.Request) string {
+	authorization := strings.TrimSpace(r.Header.Get("Authorization"))
+	return strings.TrimPrefix(authorization, "Bearer ")
+}
+
+// sessionToken reads the session token from the Authorization header or the session cookie
+func sessionToken(r *http.Request) string {
+	if token := bearerToken(r); token != "" {
+		return token
+	}
+
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+// GetOptionalUserIDFromContext returns the user ID set by OptionalAuth, if any
+func GetOptionalUserIDFromContext(ctx context.Context) (string, bool) {
+	userID, err := reqctx.UserID(ctx)
+	return userID, err == nil
+}
 
-	if !ok {
-		panic("user ID not found in context: make sure that the handler is authenticated")
+// GetUserIDFromContext extracts the user ID from the request context. It's an error
+// (reqctx.ErrNoUser) when the handler isn't behind RequireAuth: see RequestContextError.
+func GetUserIDFromContext(ctx context.Context) (string, error) {
+	userID, err := reqctx.UserID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: make sure that the handler is authenticated", err)
 	}
 
-	return userID
+	return userID, nil
 }
 
 /*
@@ -93,7 +172,7 @@ This is primarily used for testing, but can also be used when you need
 to manually set the user ID in context (e.g., in tests or when bypassing auth).
 */
 func WithUserID(ctx context.Context, userID string) context.Context {
-	return context.WithValue(ctx, userIDKey, userID)
+	return reqctx.WithUserID(ctx, userID)
 }
 
 // TODO: Remove this before production - development/testing only
@@ -109,7 +188,7 @@ func BypassAuth(log logger.Logger) func(http.Handler) http.Handler {
 				zap.String("path", r.URL.Path),
 			)
 
-			ctx := context.WithValue(r.Context(), userIDKey, hardcodedUserID)
+			ctx := reqctx.WithUserID(r.Context(), hardcodedUserID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
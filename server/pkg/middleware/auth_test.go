@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+// fakeProvider accepts the tokens it maps to a user ID
+type fakeProvider map[string]string
+
+func (p fakeProvider) Authenticate(ctx context.Context, token string) (string, error) {
+	userID, ok := p[token]
+	if !ok {
+		return "", auth.ErrInvalidToken
+	}
+	return userID, nil
+}
+
+func TestRequireAuth(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	provider := fakeProvider{"valid": "user_123"}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		cookie         string
+		expectedStatus int
+		expectedUserID string
+	}{
+		{name: "valid token", authorization: "Bearer valid", expectedStatus: http.StatusOK, expectedUserID: "user_123"},
+		{name: "invalid token", authorization: "Bearer forged", expectedStatus: http.StatusUnauthorized},
+		{name: "no token", expectedStatus: http.StatusUnauthorized},
+		{name: "cookie only", cookie: "valid", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID string
+			handler := RequireAuth(provider, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = GetUserIDFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if userID != tt.expectedUserID {
+				t.Errorf("user ID = %q, want %q", userID, tt.expectedUserID)
+			}
+		})
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	provider := fakeProvider{"valid": "user_123"}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		cookie         string
+		expectedUserID string
+	}{
+		{name: "bearer token", authorization: "Bearer valid", expectedUserID: "user_123"},
+		{name: "session cookie", cookie: "valid", expectedUserID: "user_123"},
+		{name: "invalid token continues anonymously", authorization: "Bearer forged"},
+		{name: "no session"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID string
+			handler := OptionalAuth(provider, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = GetOptionalUserIDFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if userID != tt.expectedUserID {
+				t.Errorf("user ID = %q, want %q", userID, tt.expectedUserID)
+			}
+		})
+	}
+}
+
+// A provider that can't verify tokens, e.g. because the issuer is down, fails closed
+func TestRequireAuth_ProviderError(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	failing := providerFunc(func(ctx context.Context, token string) (string, error) {
+		return "", errors.New("issuer unreachable")
+	})
+	handler := RequireAuth(failing, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called, want the request rejected")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+type providerFunc func(ctx context.Context, token string) (string, error)
+
+func (f providerFunc) Authenticate(ctx context.Context, token string) (string, error) {
+	return f(ctx, token)
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/cors"
+)
+
+// CORSByPath applies the route CORS policy to requests whose path matches and the fallback policy to all others.
+// Each request passes exactly one of the two, so their headers never mix.
+func CORSByPath(match func(path string) bool, route, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		routeNext, fallbackNext := route(next), fallback(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if match(r.URL.Path) {
+				routeNext.ServeHTTP(w, r)
+				return
+			}
+			fallbackNext.ServeHTTP(w, r)
+		})
+	}
+}
+
+/*
+OriginMatcher decides which origins a CORS policy allows. Origins are either
+exact (https://app.example.com), a wildcard subdomain (https://*.example.com)
+or * for any origin. A wildcard matches one or more subdomain labels under the
+domain with the same scheme and port, but never the domain itself, and unlike
+the substring match of go-chi/cors it can't be satisfied by an origin that
+merely ends with the domain (https://evil-example.com).
+*/
+type OriginMatcher struct {
+	any       bool
+	exact     map[string]bool
+	wildcards []wildcardOrigin
+}
+
+// wildcardOrigin is a parsed scheme://*.domain[:port] origin
+type wildcardOrigin struct {
+	scheme string
+	domain string
+	port   string
+}
+
+// NewOriginMatcher parses the allowed origins, rejecting any that isn't a bare scheme://host[:port]
+func NewOriginMatcher(origins []string) (*OriginMatcher, error) {
+	m := &OriginMatcher{exact: make(map[string]bool)}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			m.any = true
+			continue
+		}
+
+		scheme, host, port, err := splitOrigin(origin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin %q: %w", origin, err)
+		}
+
+		if !strings.Contains(host, "*") {
+			m.exact[joinOrigin(scheme, host, port)] = true
+			continue
+		}
+
+		domain, ok := strings.CutPrefix(host, "*.")
+		if !ok || strings.Contains(domain, "*") {
+			return nil, fmt.Errorf("invalid origin %q: a wildcard must be the whole leftmost label, as in https://*.example.com", origin)
+		}
+		if !isDomainName(domain) || !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("invalid origin %q: a wildcard needs a domain of at least two labels", origin)
+		}
+		m.wildcards = append(m.wildcards, wildcardOrigin{scheme: scheme, domain: domain, port: port})
+	}
+
+	return m, nil
+}
+
+// AllowsAny reports whether the matcher was configured with *
+func (m *OriginMatcher) AllowsAny() bool {
+	return m.any
+}
+
+// Allow reports whether a request's Origin header is allowed. Its signature fits cors.Options.AllowOriginFunc.
+func (m *OriginMatcher) Allow(_ *http.Request, origin string) bool {
+	if m.any {
+		return true
+	}
+
+	scheme, host, port, err := splitOrigin(origin)
+	if err != nil || strings.Contains(host, "*") {
+		return false
+	}
+	if m.exact[joinOrigin(scheme, host, port)] {
+		return true
+	}
+
+	for _, w := range m.wildcards {
+		if w.scheme != scheme || w.port != port {
+			continue
+		}
+		sub, ok := strings.CutSuffix(host, "."+w.domain)
+		if ok && sub != "" && isDomainName(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSPolicy builds a CORS handler that allows the matcher's origins. The origin list in opts is ignored.
+func CORSPolicy(origins *OriginMatcher, opts cors.Options) func(http.Handler) http.Handler {
+	opts.AllowedOrigins = nil
+	opts.AllowOriginFunc = origins.Allow
+	return cors.Handler(opts)
+}
+
+// splitOrigin splits an origin into its lower-cased scheme, host and port.
+// Anything beyond scheme://host[:port], such as a path or credentials, is an error.
+func splitOrigin(origin string) (scheme, host, port string, err error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", "", "", fmt.Errorf("must be scheme://host")
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", "", fmt.Errorf("must not have anything after the host and port")
+	}
+	return strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port(), nil
+}
+
+func joinOrigin(scheme, host, port string) string {
+	if port == "" {
+		return scheme + "://" + host
+	}
+	return scheme + "://" + host + ":" + port
+}
+
+// isDomainName reports whether name is a dot-separated list of non-empty
+// labels made of letters, digits and hyphens
+func isDomainName(name string) bool {
+	for label := range strings.SplitSeq(name, ".") {
+		if label == "" || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/cors"
+)
+
+func TestCORSByPath(t *testing.T) {
+	const extension = "chrome-extension://abcdefghijklmnop"
+
+	fallback := cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowCredentials: true,
+	})
+	route := cors.Handler(cors.Options{
+		AllowedOrigins: []string{"https://app.example.com", extension},
+		AllowedMethods: []string{"POST"},
+	})
+	isQuickShorten := func(path string) bool { return strings.HasSuffix(path, "/quick-shorten") }
+
+	h := CORSByPath(isQuickShorten, route, fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	preflight := func(path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{"extension on its route", "/api/v1/quick-shorten", extension, extension, ""},
+		{"extension elsewhere", "/api/v1/links", extension, "", ""},
+		{"app on the extension route", "/api/v1/quick-shorten", "https://app.example.com", "https://app.example.com", ""},
+		{"app elsewhere", "/api/v1/links", "https://app.example.com", "https://app.example.com", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := preflight(tt.path, tt.origin)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestNewOriginMatcher_RejectsMalformedOrigins(t *testing.T) {
+	tests := []string{
+		"app.example.com",
+		"https://app.example.com/path",
+		"https://user@app.example.com",
+		"https://app.*.example.com",
+		"https://*example.com",
+		"https://*.*.example.com",
+		"https://*.com",
+	}
+
+	for _, origin := range tests {
+		t.Run(origin, func(t *testing.T) {
+			if _, err := NewOriginMatcher([]string{origin}); err == nil {
+				t.Errorf("NewOriginMatcher(%q) succeeded, want an error", origin)
+			}
+		})
+	}
+}
+
+func TestOriginMatcher_Allow(t *testing.T) {
+	m, err := NewOriginMatcher([]string{"https://app.example.com", "https://*.example.org", "http://*.dev.test:8080"})
+	if err != nil {
+		t.Fatalf("NewOriginMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://App.Example.com", true},
+		{"https://api.example.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil-example.org", false},
+		{"https://a.example.org.evil.com", false},
+		{"http://a.example.org", false},
+		{"https://a.example.org:8443", false},
+		{"http://web.dev.test:8080", true},
+		{"http://web.dev.test", false},
+		{"https://.example.org", false},
+		{"null", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := m.Allow(nil, tt.origin); got != tt.want {
+				t.Errorf("Allow(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSPolicy_EmptyOriginsAllowNone(t *testing.T) {
+	m, err := NewOriginMatcher(nil)
+	if err != nil {
+		t.Fatalf("NewOriginMatcher() error = %v", err)
+	}
+	h := CORSPolicy(m, cors.Options{AllowedMethods: []string{"GET"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key prefixes used by the enumeration guard
+	enumerationCounterKeyPrefix = "enum:404:"
+	enumerationBlockKeyPrefix   = "enum:block:"
+)
+
+// EnumerationGuardOptions configures EnumerationGuard
+type EnumerationGuardOptions struct {
+	// Number of 404s allowed from one client within Window before it gets blocked
+	MaxNotFound int64
+	Window      time.Duration
+	// How long a blocked client is rejected for
+	BlockDuration time.Duration
+	// Proxies whose X-Forwarded-For is trusted to identify the client
+	TrustedProxies []netip.Prefix
+}
+
+/*
+EnumerationGuard throttles clients that enumerate shortcodes.
+
+Every redirect answered with a 404 increments a per-client counter in Redis
+that expires after opts.Window. Once a client reaches opts.MaxNotFound misses,
+it is put on a temporary block list and gets 429s for opts.BlockDuration,
+even for shortcodes that exist. IPv6 clients are counted per /64, since
+rotating addresses within one is free. Guessing codes would otherwise leak links that
+were only meant to be shared privately.
+
+Without Redis (degraded mode) the guard is a no-op, and Redis errors fail open.
+*/
+func EnumerationGuard(rdb *redis.Client, opts EnumerationGuardOptions, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if rdb == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := netutil.RateLimitKey(netutil.ClientIP(r, opts.TrustedProxies))
+
+			ttl, err := rdb.TTL(r.Context(), enumerationBlockKeyPrefix+ip).Result()
+			if err != nil {
+				log.Warn("Failed to check enumeration block list",
+					zap.Error(err),
+					zap.String("client_ip", ip),
+				)
+			} else if ttl > 0 {
+				metrics.EnumerationRejected.Add(1)
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(ttl.Round(time.Second).Seconds())))
+				renderBlockedPage(w, r)
+				return
+			}
+
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			if ww.Status() == http.StatusNotFound {
+				metrics.EnumerationNotFound.Add(1)
+				recordNotFound(r.Context(), rdb, opts, ip, log)
+			}
+		})
+	}
+}
+
+// recordNotFound counts a miss for the client and blocks it once it reaches the limit
+func recordNotFound(ctx context.Context, rdb *redis.Client, opts EnumerationGuardOptions, ip string, log logger.Logger) {
+	counterKey := enumerationCounterKeyPrefix + ip
+
+	pipe := rdb.TxPipeline()
+	incr := pipe.Incr(ctx, counterKey)
+	// Only set the expiry on the first miss so the window is fixed, not sliding
+	pipe.ExpireNX(ctx, counterKey, opts.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn("Failed to record shortcode miss",
+			zap.Error(err),
+			zap.String("client_ip", ip),
+		)
+		return
+	}
+
+	count := incr.Val()
+	if count < opts.MaxNotFound {
+		return
+	}
+
+	pipe = rdb.TxPipeline()
+	pipe.Set(ctx, enumerationBlockKeyPrefix+ip, count, opts.BlockDuration)
+	pipe.Del(ctx, counterKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn("Failed to block enumerating client",
+			zap.Error(err),
+			zap.String("client_ip", ip),
+		)
+		return
+	}
+
+	metrics.EnumerationBlocked.Add(1)
+	log.Warn("Client blocked for shortcode enumeration",
+		zap.String("client_ip", ip),
+		zap.Int64("not_found_count", count),
+		zap.String("window", opts.Window.String()),
+		zap.String("block_duration", opts.BlockDuration.String()),
+	)
+}
+
+var blockedPageTemplate = template.Must(template.New("blocked").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+	<head><title>{{.Title}}</title></head>
+	<body>
+		<h1>{{.Heading}}</h1>
+		<p>{{.Message}}</p>
+	</body>
+</html>`))
+
+// renderBlockedPage writes the 429 page in the language picked by Language
+func renderBlockedPage(w http.ResponseWriter, r *http.Request) {
+	lang := GetLanguageFromContext(r.Context())
+
+	var buf bytes.Buffer
+	_ = blockedPageTemplate.Execute(&buf, map[string]string{
+		"Lang":    lang,
+		"Title":   i18n.T(lang, "rate_limited.title"),
+		"Heading": i18n.T(lang, "rate_limited.heading"),
+		"Message": i18n.T(lang, "rate_limited.message"),
+	})
+
+	render.Status(r, http.StatusTooManyRequests)
+	render.HTML(w, r, buf.String())
+}
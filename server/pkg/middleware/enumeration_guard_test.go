@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+)
+
+func TestEnumerationGuard(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	opts := EnumerationGuardOptions{
+		MaxNotFound:   3,
+		Window:        time.Minute,
+		BlockDuration: 10 * time.Minute,
+	}
+
+	// "/known" redirects, everything else is a 404
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/known" {
+			http.Redirect(w, r, "https://example.com", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	h := EnumerationGuard(rdb, opts, log)(next)
+
+	get := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Misses below the limit are served normally
+	for i := 0; i < 2; i++ {
+		if w := get("/guess", "10.0.0.1:1234"); w.Code != http.StatusNotFound {
+			t.Fatalf("miss %d: status = %d, want %d", i, w.Code, http.StatusNotFound)
+		}
+	}
+	if w := get("/known", "10.0.0.1:1234"); w.Code != http.StatusFound {
+		t.Fatalf("known link before limit: status = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	// The miss that reaches the limit blocks the client
+	get("/guess", "10.0.0.1:1234")
+
+	w := get("/known", "10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("blocked client: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") != "600" {
+		t.Errorf("Retry-After = %q, want %q", w.Header().Get("Retry-After"), "600")
+	}
+
+	// Other clients are unaffected
+	if w := get("/known", "10.0.0.2:1234"); w.Code != http.StatusFound {
+		t.Errorf("other client: status = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	// The block expires
+	mr.FastForward(opts.BlockDuration)
+	if w := get("/known", "10.0.0.1:1234"); w.Code != http.StatusFound {
+		t.Errorf("after block expired: status = %d, want %d", w.Code, http.StatusFound)
+	}
+}
+
+func TestEnumerationGuard_MissesExpireWithWindow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	opts := EnumerationGuardOptions{MaxNotFound: 2, Window: time.Minute, BlockDuration: time.Hour}
+	h := EnumerationGuard(rdb, opts, log)(http.NotFoundHandler())
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/guess", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("miss %d: status = %d, want %d", i, w.Code, http.StatusNotFound)
+		}
+
+		// Each miss lands in a fresh window, so the limit is never reached
+		mr.FastForward(opts.Window)
+	}
+}
+
+func TestEnumerationGuard_IPv6AndProxies(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	trusted, err := netutil.ParsePrefixes([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParsePrefixes() error = %v", err)
+	}
+	opts := EnumerationGuardOptions{MaxNotFound: 2, Window: time.Minute, BlockDuration: time.Hour, TrustedProxies: trusted}
+	h := EnumerationGuard(rdb, opts, log)(http.NotFoundHandler())
+
+	get := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/guess", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(netutil.ForwardedForHeader, forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Rotating addresses within a /64 doesn't reset the count
+	get("[2001:db8:1:2::1]:1234", "")
+	get("[2001:db8:1:2::2]:1234", "")
+	if code := get("[2001:db8:1:2::3]:1234", ""); code != http.StatusTooManyRequests {
+		t.Errorf("same /64: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := get("[2001:db8:1:3::1]:1234", ""); code != http.StatusNotFound {
+		t.Errorf("other /64: status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	// Clients behind a trusted proxy are told apart, mapped or not
+	get("10.0.0.2:8080", "198.51.100.1")
+	get("10.0.0.2:8080", "::ffff:198.51.100.1")
+	if code := get("10.0.0.2:8080", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("proxied client: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := get("10.0.0.2:8080", "198.51.100.2"); code != http.StatusNotFound {
+		t.Errorf("other proxied client: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestEnumerationGuard_WithoutRedis(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	next := http.NotFoundHandler()
+	h := EnumerationGuard(nil, EnumerationGuardOptions{MaxNotFound: 1}, log)(next)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/guess", nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Field naming of JSON responses
+const (
+	SnakeCase = "snake_case"
+	CamelCase = "camelCase"
+)
+
+// Tokens of the Accept profile parameter that pick a JSON style for one request
+const (
+	profileOmitNulls    = "omit-nulls"
+	profileIncludeNulls = "include-nulls"
+)
+
+// JSONStyle is how JSON responses are shaped. The zero value is the API's own
+// shape: snake_case fields, with empty optionals as null.
+type JSONStyle struct {
+	// SnakeCase or CamelCase; empty is SnakeCase
+	Naming string
+	// Leave out object members that are null instead of encoding them
+	OmitNulls bool
+}
+
+func (s JSONStyle) isDefault() bool {
+	return s.Naming != CamelCase && !s.OmitNulls
+}
+
+/*
+ResponseJSONStyle reshapes JSON responses to a field naming and null policy:
+fallback unless the request picks its own with a profile on its Accept header,
+e.g. Accept: application/json; profile="camelCase omit-nulls". Profile tokens
+are camelCase or snake_case, and omit-nulls or include-nulls; a token that isn't
+given keeps fallback's setting.
+
+Responses are rewritten once they're encoded, so handlers and DTOs only know the
+snake_case shape and every endpoint follows the policy. Member order is kept.
+Renaming applies to every object key, map keys included. Request bodies are
+always snake_case. Responses that aren't JSON are passed through untouched.
+*/
+func ResponseJSONStyle(fallback JSONStyle, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+
+			style := requestedJSONStyle(r.Header.Get("Accept"), fallback)
+			if style.isDefault() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sw := &styledWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			if !sw.buffering {
+				return
+			}
+
+			body := sw.buf.Bytes()
+			styled, err := restyleJSON(body, style)
+			if err != nil {
+				// Not JSON after all: send it as the handler wrote it
+				log.Warn("Failed to restyle JSON response",
+					zap.Error(err),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+				styled = body
+			}
+
+			w.Header().Del("Content-Length")
+			w.WriteHeader(sw.status)
+			_, _ = w.Write(styled)
+		})
+	}
+}
+
+// requestedJSONStyle applies the profile of the first JSON media range in accept to fallback
+func requestedJSONStyle(accept string, fallback JSONStyle) JSONStyle {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			continue
+		}
+
+		style := fallback
+		for _, token := range strings.Fields(params["profile"]) {
+			switch token {
+			case CamelCase, SnakeCase:
+				style.Naming = token
+			case profileOmitNulls:
+				style.OmitNulls = true
+			case profileIncludeNulls:
+				style.OmitNulls = false
+			}
+		}
+		return style
+	}
+	return fallback
+}
+
+// styledWriter holds back JSON responses so they can be restyled once complete; others are written through
+type styledWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (sw *styledWriter) WriteHeader(status int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	sw.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(sw.Header().Get("Content-Type"))
+	sw.buffering = mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if !sw.buffering {
+		sw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (sw *styledWriter) Write(p []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.buffering {
+		return sw.buf.Write(p)
+	}
+	return sw.ResponseWriter.Write(p)
+}
+
+// Flush passes through for streamed (non-JSON) responses; JSON ones are written whole at the end
+func (sw *styledWriter) Flush() {
+	if sw.buffering {
+		return
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sw *styledWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// restyleJSON rewrites the JSON document (or documents, as render.JSON ends them with a newline) in body
+func restyleJSON(body []byte, style JSONStyle) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	for {
+		err := restyleValue(dec, &out, style)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+func restyleValue(dec *json.Decoder, out *bytes.Buffer, style JSONStyle) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			out.WriteByte('[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				if err := restyleValue(dec, out, style); err != nil {
+					return err
+				}
+			}
+			out.WriteByte(']')
+		} else {
+			out.WriteByte('{')
+			written := 0
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+
+				var value bytes.Buffer
+				if err := restyleValue(dec, &value, style); err != nil {
+					return err
+				}
+				if style.OmitNulls && value.String() == "null" {
+					continue
+				}
+
+				if written > 0 {
+					out.WriteByte(',')
+				}
+				name := key.(string)
+				if style.Naming == CamelCase {
+					name = camelCase(name)
+				}
+				writeJSONString(out, name)
+				out.WriteByte(':')
+				out.Write(value.Bytes())
+				written++
+			}
+			out.WriteByte('}')
+		}
+		// The closing delimiter
+		_, err := dec.Token()
+		return err
+	case string:
+		writeJSONString(out, t)
+	case json.Number:
+		out.WriteString(t.String())
+	case bool:
+		out.WriteString(strconv.FormatBool(t))
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// writeJSONString encodes s the way render.JSON does, HTML characters escaped
+func writeJSONString(out *bytes.Buffer, s string) {
+	encoded, _ := json.Marshal(s)
+	out.Write(encoded)
+}
+
+// camelCase converts a snake_case name (short_url) to camelCase (shortUrl).
+// Leading underscores are kept, so _links stays _links.
+func camelCase(name string) string {
+	trimmed := strings.TrimLeft(name, "_")
+	if !strings.Contains(trimmed, "_") {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteString(name[:len(name)-len(trimmed)])
+	for i, part := range strings.Split(trimmed, "_") {
+		if part == "" {
+			continue
+		}
+		if i > 0 {
+			part = strings.ToUpper(part[:1]) + part[1:]
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+func TestResponseJSONStyle(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	jsonHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, map[string]any{
+			"data": []map[string]any{{"short_url": "https://sho.rt/a<b", "expires_at": nil, "_links": map[string]any{"self": 1}}},
+		})
+	})
+
+	tests := []struct {
+		name     string
+		fallback JSONStyle
+		accept   string
+		expected string
+	}{
+		{
+			name:     "default style is untouched",
+			expected: `{"data":[{"_links":{"self":1},"expires_at":null,"short_url":"https://sho.rt/a\u003cb"}]}` + "\n",
+		},
+		{
+			name:     "configured camelCase",
+			fallback: JSONStyle{Naming: CamelCase},
+			expected: `{"data":[{"_links":{"self":1},"expiresAt":null,"shortUrl":"https://sho.rt/a\u003cb"}]}` + "\n",
+		},
+		{
+			name:     "Accept profile overrides the configuration",
+			fallback: JSONStyle{Naming: CamelCase},
+			accept:   `application/json; profile="snake_case omit-nulls"`,
+			expected: `{"data":[{"_links":{"self":1},"short_url":"https://sho.rt/a\u003cb"}]}` + "\n",
+		},
+		{
+			name:     "profile on a vendor media type",
+			accept:   `text/html, application/vnd.url-shortener.v1+json; profile="camelCase"`,
+			expected: `{"data":[{"_links":{"self":1},"expiresAt":null,"shortUrl":"https://sho.rt/a\u003cb"}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			ResponseJSONStyle(tt.fallback, log)(jsonHandler).ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+			}
+			if got := w.Body.String(); got != tt.expected {
+				t.Errorf("body = %s\nwant   %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResponseJSONStyle_NonJSONPassesThrough(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	h := ResponseJSONStyle(JSONStyle{Naming: CamelCase, OmitNulls: true}, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("short_url,expires_at\n"))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/links/x/stats/export", nil))
+
+	if got := w.Body.String(); got != "short_url,expires_at\n" {
+		t.Errorf("body = %q, want the CSV unchanged", got)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"short_url":         "shortUrl",
+		"id":                "id",
+		"_links":            "_links",
+		"total_pages":       "totalPages",
+		"utm_source__x":     "utmSourceX",
+		"already_camelCase": "alreadyCamelCase",
+	}
+	for in, want := range tests {
+		if got := camelCase(in); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
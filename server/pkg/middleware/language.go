@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+)
+
+const languageKey contextKey = "language"
+
+// Language picks the language of server-rendered pages for the request and stores it in the context.
+// Responses vary on Accept-Language, so caches keep one copy per language.
+func Language(n *i18n.Negotiator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lang := n.Language(r)
+
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", lang)
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), languageKey, lang)))
+		})
+	}
+}
+
+// GetLanguageFromContext returns the page language, i18n.DefaultLanguage when Language didn't run
+func GetLanguageFromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey).(string); ok {
+		return lang
+	}
+	return i18n.DefaultLanguage
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RequestLimits bound the requests of a group of routes
+type RequestLimits struct {
+	// Largest request body in bytes; 0 leaves bodies unbounded
+	MaxBodyBytes int64
+	// How long the handler has to answer; 0 leaves it to the server's write timeout
+	Timeout time.Duration
+}
+
+/*
+Limit applies the limits limitsFor picks for each request. Bodies over
+MaxBodyBytes fail to read with an *http.MaxBytesError (RequestValidator answers
+it with a 413). With a Timeout, the handler's context gets that deadline and
+the response's write deadline is moved to match, so a route can run longer or
+shorter than the server's write timeout; a handler that ran out of time without
+answering gets a 504.
+
+Limits are picked once, by the outermost Limit: nesting them would cap every
+route at the smallest limits of the chain.
+*/
+func Limit(limitsFor func(r *http.Request) RequestLimits, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limits := limitsFor(r)
+
+			if limits.MaxBodyBytes > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBodyBytes)
+			}
+
+			if limits.Timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Not every writer supports deadlines (e.g. in tests); the context deadline still applies
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(limits.Timeout + timeoutResponseGrace))
+
+			ctx, cancel := context.WithTimeout(r.Context(), limits.Timeout)
+			defer cancel()
+
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && ww.Status() == 0 {
+				log.Warn("Request timed out",
+					zap.Duration("timeout", limits.Timeout),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+
+				render.Status(r, http.StatusGatewayTimeout)
+				render.JSON(w, r, dto.ErrorResponse{
+					Error: dto.ErrorObject{
+						Code:   apperrors.CodeTimeout,
+						Title:  "Request timed out",
+						Detail: "The request took too long to process",
+					},
+				})
+			}
+		})
+	}
+}
+
+// Time left after a route's timeout to write its 504
+const timeoutResponseGrace = time.Second
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+type limitedBody struct {
+	URL string `json:"url"`
+}
+
+func TestLimit_BodySize(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	limits := func(r *http.Request) RequestLimits { return RequestLimits{MaxBodyBytes: 16} }
+	h := Limit(limits, log)(RequestValidator[limitedBody](log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{body: `{"url":"a"}`, want: http.StatusNoContent},
+		{body: `{"url":"https://example.com/long"}`, want: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+		if w.Code != tt.want {
+			t.Errorf("body %s: status = %d, want %d", tt.body, w.Code, tt.want)
+		}
+	}
+}
+
+func TestLimit_Timeout(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	limits := func(r *http.Request) RequestLimits { return RequestLimits{Timeout: 10 * time.Millisecond} }
+	h := Limit(limits, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+	if !strings.Contains(w.Body.String(), `"timeout"`) {
+		t.Errorf("body = %s, want a timeout error", w.Body.String())
+	}
+
+	// A handler that answered in time keeps its response
+	h = Limit(limits, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("handler context has no deadline")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(context.Background()))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// How often a held back request checks whether the server recovered
+	loadShedPollInterval = 25 * time.Millisecond
+	// Seconds shed clients are told to wait before retrying
+	loadShedRetryAfter = "1"
+)
+
+// SaturatedFunc reports whether the server is saturated (see loadshed.Monitor)
+type SaturatedFunc func() bool
+
+/*
+ShedLoad gives way to redirects while the server is saturated: requests are
+held back for up to maxDelay in case it recovers, and answered with a 503
+server_busy and Retry-After if it doesn't. Unsaturated, it only costs the
+check. Held back and shed requests are counted in the load_shed_delayed_total
+and load_shed_rejected_total metrics.
+
+It wraps low-priority routes only; the redirect path never passes through it.
+*/
+func ShedLoad(saturated SaturatedFunc, maxDelay time.Duration, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !saturated() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if waitUntilUnsaturated(r, saturated, maxDelay) {
+				metrics.LoadShedDelayed.Add(1)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.Context().Err() != nil {
+				// The client went away while held back
+				return
+			}
+
+			metrics.LoadShedRejected.Add(1)
+			log.Warn("API request shed under load",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+
+			w.Header().Set("Retry-After", loadShedRetryAfter)
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, dto.ErrorResponse{
+				Error: dto.ErrorObject{
+					Code:   apperrors.CodeServerBusy,
+					Title:  apperrors.ServerBusy.Error(),
+					Detail: "The server is under heavy load, retry shortly",
+				},
+			})
+		})
+	}
+}
+
+// waitUntilUnsaturated polls saturated until it clears (true), maxDelay passes or the request ends
+func waitUntilUnsaturated(r *http.Request, saturated SaturatedFunc, maxDelay time.Duration) bool {
+	if maxDelay <= 0 {
+		return false
+	}
+
+	deadline := time.NewTimer(maxDelay)
+	defer deadline.Stop()
+	poll := time.NewTicker(loadShedPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return false
+		case <-deadline.C:
+			return !saturated()
+		case <-poll.C:
+			if !saturated() {
+				return true
+			}
+		}
+	}
+}
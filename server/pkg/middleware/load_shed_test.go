@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+func TestShedLoad(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	var saturated atomic.Bool
+	h := ShedLoad(saturated.Load, 100*time.Millisecond, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/links", nil))
+		return w
+	}
+
+	t.Run("passes requests through while unsaturated", func(t *testing.T) {
+		if w := serve(); w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", w.Code)
+		}
+	})
+
+	t.Run("sheds requests while saturated", func(t *testing.T) {
+		saturated.Store(true)
+		defer saturated.Store(false)
+
+		w := serve()
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want 503", w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Retry-After header missing")
+		}
+	})
+
+	t.Run("lets held back requests through on recovery", func(t *testing.T) {
+		saturated.Store(true)
+		time.AfterFunc(20*time.Millisecond, func() { saturated.Store(false) })
+
+		if w := serve(); w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", w.Code)
+		}
+	})
+}
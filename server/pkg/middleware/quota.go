@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Number of links the user can still create before reaching their quota
+const LinkQuotaRemainingHeader = "X-Quota-Links-Remaining"
+
+// LinksRemainingFunc returns how many more links the user can create
+type LinksRemainingFunc func(ctx context.Context, userID string) (int64, error)
+
+/*
+LinkQuotaHeaders reports the user's remaining link quota on every response
+(X-Quota-Links-Remaining), so UIs can warn before creates start failing.
+
+The quota is looked up when the handler writes its response, so a create or
+delete made by the request itself is already reflected. It must run after
+RequireAuth; lookup failures only drop the header.
+*/
+func LinkQuotaHeaders(remaining LinksRemainingFunc, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := GetUserIDFromContext(r.Context())
+			if err != nil {
+				RequestContextError(w, r, log, err)
+				return
+			}
+
+			hw := &beforeWriteHeader{ResponseWriter: w}
+			hw.before = func() {
+				n, err := remaining(r.Context(), userID)
+				if err != nil {
+					log.Warn("Failed to look up link quota",
+						zap.Error(err),
+						zap.String("user_id", userID),
+					)
+					return
+				}
+				w.Header().Set(LinkQuotaRemainingHeader, strconv.FormatInt(n, 10))
+			}
+
+			next.ServeHTTP(hw, r)
+		})
+	}
+}
+
+// beforeWriteHeader runs before once, right before the response headers are sent
+type beforeWriteHeader struct {
+	http.ResponseWriter
+	before func()
+	done   bool
+}
+
+func (w *beforeWriteHeader) run() {
+	if !w.done {
+		w.done = true
+		w.before()
+	}
+}
+
+func (w *beforeWriteHeader) WriteHeader(code int) {
+	w.run()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *beforeWriteHeader) Write(b []byte) (int, error) {
+	w.run()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *beforeWriteHeader) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
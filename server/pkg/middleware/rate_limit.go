@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key prefix for per-user API request counters
+	rateLimitKeyPrefix = "ratelimit:api:"
+
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	// Unix time (seconds) at which the current window ends
+	RateLimitResetHeader = "X-RateLimit-Reset"
+)
+
+// RateLimitOptions configures RateLimit
+type RateLimitOptions struct {
+	// Number of requests a user can make within Window
+	Limit  int64
+	Window time.Duration
+}
+
+/*
+RateLimit limits how many API requests each user can make per window and
+reports the state of the limit on every response, so clients can throttle
+themselves before they hit it:
+
+	X-RateLimit-Limit: 600
+	X-RateLimit-Remaining: 599
+	X-RateLimit-Reset: 1767225600
+
+Requests over the limit get a 429 with Retry-After. The window is fixed: it
+starts with the user's first request and the counter resets when it expires.
+
+It must run after RequireAuth. Without Redis (degraded mode) it is a no-op,
+and Redis errors fail open without headers.
+*/
+func RateLimit(rdb *redis.Client, opts RateLimitOptions, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if rdb == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := GetUserIDFromContext(r.Context())
+			if err != nil {
+				RequestContextError(w, r, log, err)
+				return
+			}
+			key := rateLimitKeyPrefix + userID
+
+			pipe := rdb.TxPipeline()
+			incr := pipe.Incr(r.Context(), key)
+			// Only set the expiry on the first request so the window is fixed, not sliding
+			pipe.ExpireNX(r.Context(), key, opts.Window)
+			ttl := pipe.PTTL(r.Context(), key)
+			if _, err := pipe.Exec(r.Context()); err != nil {
+				log.Warn("Failed to count API request for rate limiting",
+					zap.Error(err),
+					zap.String("user_id", userID),
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			count := incr.Val()
+			resetIn := ttl.Val()
+			if resetIn < 0 {
+				resetIn = opts.Window
+			}
+
+			w.Header().Set(RateLimitLimitHeader, strconv.FormatInt(opts.Limit, 10))
+			w.Header().Set(RateLimitRemainingHeader, strconv.FormatInt(max(opts.Limit-count, 0), 10))
+			w.Header().Set(RateLimitResetHeader, strconv.FormatInt(time.Now().Add(resetIn).Unix(), 10))
+
+			if count > opts.Limit {
+				log.Warn("API rate limit exceeded",
+					zap.String("user_id", userID),
+					zap.Int64("limit", opts.Limit),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+
+				// Round up so clients retrying on time don't land just before the reset
+				w.Header().Set("Retry-After", strconv.FormatInt(int64((resetIn+time.Second-1)/time.Second), 10))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, dto.ErrorResponse{
+					Error: dto.ErrorObject{
+						Code:   apperrors.CodeRateLimited,
+						Title:  apperrors.RateLimited.Error(),
+						Detail: "API rate limit exceeded, retry after the window resets",
+					},
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+func TestRateLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	opts := RateLimitOptions{Limit: 2, Window: time.Minute}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := RateLimit(rdb, opts, log)(next)
+
+	get := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+		req = req.WithContext(WithUserID(req.Context(), userID))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := get("user_1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != "2" {
+			t.Errorf("request %d: %s = %q, want %q", i, RateLimitLimitHeader, got, "2")
+		}
+		if got := w.Header().Get(RateLimitRemainingHeader); got != wantRemaining {
+			t.Errorf("request %d: %s = %q, want %q", i, RateLimitRemainingHeader, got, wantRemaining)
+		}
+
+		reset, err := strconv.ParseInt(w.Header().Get(RateLimitResetHeader), 10, 64)
+		if err != nil {
+			t.Fatalf("request %d: invalid %s: %v", i, RateLimitResetHeader, err)
+		}
+		if until := time.Until(time.Unix(reset, 0)); until <= 0 || until > opts.Window+time.Second {
+			t.Errorf("request %d: window resets in %s, want within %s", i, until, opts.Window)
+		}
+	}
+
+	// Over the limit
+	w := get("user_1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over limit: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get(RateLimitRemainingHeader); got != "0" {
+		t.Errorf("over limit: %s = %q, want %q", RateLimitRemainingHeader, got, "0")
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want %q", w.Header().Get("Retry-After"), "60")
+	}
+
+	// Other users have their own budget
+	if w := get("user_2"); w.Code != http.StatusOK {
+		t.Errorf("other user: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// The window resets
+	mr.FastForward(opts.Window)
+	if w := get("user_1"); w.Code != http.StatusOK || w.Header().Get(RateLimitRemainingHeader) != "1" {
+		t.Errorf("after reset: status = %d, remaining = %q, want %d and %q",
+			w.Code, w.Header().Get(RateLimitRemainingHeader), http.StatusOK, "1")
+	}
+}
+
+func TestLinkQuotaHeaders(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create test logger: %v", err)
+	}
+
+	links := int64(3)
+	remaining := func(ctx context.Context, userID string) (int64, error) {
+		return 5 - links, nil
+	}
+
+	// The handler creates a link before responding
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		links++
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := LinkQuotaHeaders(remaining, log)(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/links", nil)
+	req = req.WithContext(WithUserID(req.Context(), "user_1"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get(LinkQuotaRemainingHeader); got != "1" {
+		t.Errorf("%s = %q, want %q", LinkQuotaRemainingHeader, got, "1")
+	}
+}
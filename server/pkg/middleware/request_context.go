@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+/*
+RequestContextError answers a request whose user or validated body isn't in its
+context (see GetUserIDFromContext, GetRequestBodyFromContext) with a 500.
+
+That only happens when a route is mounted without the middleware its handler
+relies on (RequireAuth, or a RequestValidator of the handler's DTO type), so
+the client is told nothing more than with any internal error while the log
+names what is missing and where.
+*/
+func RequestContextError(w http.ResponseWriter, r *http.Request, log logger.Logger, err error) {
+	log.Error("Request context value missing: check the route's middleware",
+		zap.Error(err),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+
+	render.Status(r, http.StatusInternalServerError)
+	render.JSON(w, r, dto.ErrorResponse{
+		Error: dto.ErrorObject{
+			Code:   apperrors.CodeInternalError,
+			Title:  apperrors.InternalError.Error(),
+			Detail: "An internal error occurred while processing your request",
+		},
+	})
+}
@@ -14,17 +14,17 @@ import (
 	"github.com/styltsou/url-shortener/server/pkg/dto"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+	"github.com/styltsou/url-shortener/server/pkg/validation"
 	"go.uber.org/zap"
 )
 
-var validate = validator.New()
+var validate = validation.New()
 
-const reqBodyKey contextKey = "request_body"
-
-// ReqBodyKey returns the context key used for request body storage.
-// This is exported for testing purposes.
-func ReqBodyKey() contextKey {
-	return reqBodyKey
+// WithRequestBody stores a validated request body in the request context, as
+// RequestValidator does. This is exported for testing purposes.
+func WithRequestBody(ctx context.Context, body any) context.Context {
+	return reqctx.WithBody(ctx, body)
 }
 
 // Validator defines the interface for custom validation logic on DTOs.
@@ -33,14 +33,11 @@ type Validator interface {
 	Validate() error
 }
 
-const maxBodySize = 1 << 20 // 1MB - prevents memory exhaustion from large request bodies
-
+// RequestValidator decodes and validates the JSON body of a request as a T. The body's size is
+// bounded by the route's RequestLimits (see Limit); larger bodies are answered with a 413.
 func RequestValidator[T any](logger logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Limit request body size to prevent memory exhaustion attacks
-			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
-
 			var bodyDTO T
 
 			if err := json.NewDecoder(r.Body).Decode(&bodyDTO); err != nil {
@@ -51,14 +48,14 @@ func RequestValidator[T any](logger logger.Logger) func(http.Handler) http.Handl
 						zap.Error(err),
 						zap.String("method", r.Method),
 						zap.String("path", r.URL.Path),
-						zap.Int64("max_size", maxBodySize),
+						zap.Int64("max_size", maxBytesError.Limit),
 					)
 					render.Status(r, http.StatusRequestEntityTooLarge)
 					render.JSON(w, r, dto.ErrorResponse{
 						Error: dto.ErrorObject{
 							Code:   apperrors.CodeInvalidRequest,
 							Title:  "Request body too large",
-							Detail: fmt.Sprintf("Request body exceeds maximum size of %d bytes", maxBodySize),
+							Detail: fmt.Sprintf("Request body exceeds maximum size of %d bytes", maxBytesError.Limit),
 						},
 					})
 					return
@@ -124,7 +121,7 @@ func RequestValidator[T any](logger logger.Logger) func(http.Handler) http.Handl
 					if fieldName == "" {
 						fieldName = fieldErr.StructField()
 					}
-					errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", fieldName, fieldErr.Error()))
+					errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", fieldName, validation.Message(fieldErr)))
 				}
 
 				logger.Warn("Request validation failed",
@@ -164,7 +161,7 @@ func RequestValidator[T any](logger logger.Logger) func(http.Handler) http.Handl
 				}
 			}
 
-			ctx := context.WithValue(r.Context(), reqBodyKey, bodyDTO)
+			ctx := reqctx.WithBody(r.Context(), bodyDTO)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -176,21 +173,16 @@ GetRequestBodyFromContext extracts the validated request body from the request c
 This provides a type-safe way to retrieve the request body without exposing
 the context key implementation details.
 
-This function panics if the request body is not found in context, which
-indicates a programming error (e.g., handler called without RequestValidator
-middleware, or wrong DTO type specified). The panic will be recovered by the
-Recoverer middleware and logged appropriately.
+It returns an error (reqctx.ErrNoBody) if the request body is not found in
+context, which indicates a programming error (e.g., handler called without
+RequestValidator middleware, or wrong DTO type specified). Handlers answer it
+with RequestContextError.
 */
-func GetRequestBodyFromContext[T any](ctx context.Context) T {
-	val := ctx.Value(reqBodyKey)
-	if val == nil {
-		panic("request body not found in context: RequestValidator middleware must be applied before this handler")
-	}
-
-	dto, ok := val.(T)
-	if !ok {
-		panic("request body type mismatch: ensure RequestValidator middleware uses the same DTO type")
+func GetRequestBodyFromContext[T any](ctx context.Context) (T, error) {
+	body, err := reqctx.Body[T](ctx)
+	if err != nil {
+		return body, fmt.Errorf("%w: RequestValidator middleware must be applied before this handler, with the same DTO type", err)
 	}
 
-	return dto
+	return body, nil
 }
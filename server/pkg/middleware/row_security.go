@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+/*
+RowSecurity scopes the database queries of the request to the authenticated
+user (see db.RowSecurity), so Postgres row-level security hides every other
+user's rows from them. It must run after RequireAuth, and only on routes that
+act on the user's own data: redirects look links up across users.
+*/
+func RowSecurity(log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := GetUserIDFromContext(r.Context())
+			if err != nil {
+				RequestContextError(w, r, log, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(db.WithRowSecurityUser(r.Context(), userID)))
+		})
+	}
+}
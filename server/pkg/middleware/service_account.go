@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+	"go.uber.org/zap"
+)
+
+// ServiceAccountFunc resolves the service account a token sent from clientIP belongs to,
+// an error wrapping apperrors.InvalidServiceAccountToken if there's none
+type ServiceAccountFunc func(ctx context.Context, token string, clientIP netip.Addr) (reqctx.ServiceAccount, error)
+
+/*
+ServiceAccountAuth authenticates requests whose bearer token is a service
+account token (sa_...). The account's owner becomes the request's user, so
+RequireAuth and the handlers after it treat the request as the owner's, but
+only routes whose scope (see scopeFor) the account was granted are served;
+the others are answered with 403. Routes with no scope, like the admin routes,
+are closed to service accounts.
+
+Other tokens are left to RequireAuth, which must run after it.
+*/
+func ServiceAccountAuth(authenticate ServiceAccountFunc, scopeFor func(r *http.Request) string, log logger.Logger) func(http.Handler) http.Handler {
+	failure := authFailureHandler(log)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if !strings.HasPrefix(token, auth.ServiceAccountTokenPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rc, _ := reqctx.From(r.Context())
+			account, err := authenticate(r.Context(), token, rc.ClientIP)
+			if err != nil {
+				if errors.Is(err, apperrors.InvalidServiceAccountToken) {
+					log.Debug("Ignoring invalid service account token",
+						zap.String("method", r.Method),
+						zap.String("path", r.URL.Path),
+					)
+				} else {
+					log.Error("Failed to verify service account token",
+						zap.Error(err),
+						zap.String("method", r.Method),
+						zap.String("path", r.URL.Path),
+					)
+				}
+				failure.ServeHTTP(w, r)
+				return
+			}
+
+			scope := scopeFor(r)
+			if scope == "" || !slices.Contains(account.Scopes, scope) {
+				log.Warn("Route requested by service account without its scope",
+					zap.String("service_account_id", account.ID),
+					zap.String("scope", scope),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+
+				detail := "Service accounts can't use this route"
+				if scope != "" {
+					detail = "This route requires the " + scope + " scope"
+				}
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, dto.ErrorResponse{
+					Error: dto.ErrorObject{
+						Code:   apperrors.CodeInsufficientScope,
+						Title:  apperrors.InsufficientScope.Error(),
+						Detail: detail,
+					},
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(reqctx.WithServiceAccount(r.Context(), account)))
+		})
+	}
+}
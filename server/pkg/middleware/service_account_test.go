@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+)
+
+func TestServiceAccountAuth(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	var usedFrom netip.Addr
+	accounts := func(ctx context.Context, token string, clientIP netip.Addr) (reqctx.ServiceAccount, error) {
+		usedFrom = clientIP
+		if token != "sa_valid" {
+			return reqctx.ServiceAccount{}, apperrors.InvalidServiceAccountToken
+		}
+		return reqctx.ServiceAccount{ID: "sa_1", OwnerID: "user_123", Scopes: []string{"links:create"}}, nil
+	}
+	scopes := map[string]string{
+		"/api/v1/links":        "links:create",
+		"/api/v1/stats/export": "stats:read",
+	}
+	scopeFor := func(r *http.Request) string { return scopes[r.URL.Path] }
+	provider := fakeProvider{"valid": "user_456"}
+
+	tests := []struct {
+		name           string
+		path           string
+		authorization  string
+		expectedStatus int
+		expectedUserID string
+	}{
+		{name: "granted scope", path: "/api/v1/links", authorization: "Bearer sa_valid", expectedStatus: http.StatusOK, expectedUserID: "user_123"},
+		{name: "missing scope", path: "/api/v1/stats/export", authorization: "Bearer sa_valid", expectedStatus: http.StatusForbidden},
+		{name: "route without a scope", path: "/api/v1/admin/service-accounts", authorization: "Bearer sa_valid", expectedStatus: http.StatusForbidden},
+		{name: "revoked token", path: "/api/v1/links", authorization: "Bearer sa_revoked", expectedStatus: http.StatusUnauthorized},
+		{name: "session token is left to RequireAuth", path: "/api/v1/stats/export", authorization: "Bearer valid", expectedStatus: http.StatusOK, expectedUserID: "user_456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID string
+			handler := ServiceAccountAuth(accounts, scopeFor, log)(RequireAuth(provider, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = GetUserIDFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})))
+
+			clientIP := netip.MustParseAddr("203.0.113.7")
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(reqctx.With(req.Context(), reqctx.RequestContext{ClientIP: clientIP}))
+			req.Header.Set("Authorization", tt.authorization)
+			usedFrom = netip.Addr{}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if userID != tt.expectedUserID {
+				t.Errorf("user ID = %q, want %q", userID, tt.expectedUserID)
+			}
+			if usedFrom.IsValid() && usedFrom != clientIP {
+				t.Errorf("token checked for %v, want the client's address %v", usedFrom, clientIP)
+			}
+		})
+	}
+}
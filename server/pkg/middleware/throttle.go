@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"github.com/styltsou/url-shortener/server/pkg/throttle"
+	"go.uber.org/zap"
+)
+
+/*
+Throttle runs the route as an expensive operation of the given weight in the
+limiter (see throttle.Limiter). Requests over the user's limit get a 429 and
+requests that find the queue full or wait too long get a 503, both with
+Retry-After. The slot is released when the handler returns, unless the
+handler hands it to background work with throttle.Detach.
+
+It must run after RequireAuth. With a nil limiter it is a no-op.
+*/
+func Throttle(limiter *throttle.Limiter, weight int64, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := GetUserIDFromContext(r.Context())
+			if err != nil {
+				RequestContextError(w, r, log, err)
+				return
+			}
+
+			slot, err := limiter.Acquire(r.Context(), userID, weight)
+			if err != nil {
+				if r.Context().Err() != nil {
+					// The client went away while waiting
+					return
+				}
+
+				writeThrottled(w, r, err)
+				log.Warn("Expensive request throttled",
+					zap.Error(err),
+					zap.String("user_id", userID),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+				)
+				return
+			}
+
+			defer func() {
+				if !slot.Detached() {
+					slot.Release()
+				}
+			}()
+
+			next.ServeHTTP(w, r.WithContext(throttle.WithSlot(r.Context(), slot)))
+		})
+	}
+}
+
+func writeThrottled(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(throttle.RetryAfter.Seconds())))
+
+	if errors.Is(err, throttle.ErrUserLimit) {
+		metrics.ThrottleRejected.Add("user_limit", 1)
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, dto.ErrorResponse{
+			Error: dto.ErrorObject{
+				Code:   apperrors.CodeRateLimited,
+				Title:  apperrors.RateLimited.Error(),
+				Detail: "Too many exports or stats requests in progress, retry once one finishes",
+			},
+		})
+		return
+	}
+
+	reason := "queue_full"
+	if errors.Is(err, throttle.ErrQueueTimeout) {
+		reason = "queue_timeout"
+	}
+	metrics.ThrottleRejected.Add(reason, 1)
+
+	render.Status(r, http.StatusServiceUnavailable)
+	render.JSON(w, r, dto.ErrorResponse{
+		Error: dto.ErrorObject{
+			Code:   apperrors.CodeServerBusy,
+			Title:  apperrors.ServerBusy.Error(),
+			Detail: "The server is busy with other exports and stats requests, retry later",
+		},
+	})
+}
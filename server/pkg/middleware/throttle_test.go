@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/throttle"
+)
+
+func TestThrottle(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	limiter := throttle.New(throttle.Options{Capacity: 1, MaxQueue: 0, QueueTimeout: time.Second, PerUser: 1})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var detached *throttle.Slot
+	h := Throttle(limiter, throttle.WeightStats, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/block":
+			close(started)
+			<-release
+		case "/detach":
+			detached = throttle.Detach(r.Context())
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(WithUserID(req.Context(), userID))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan struct{})
+	go func() {
+		serve("/block", "user_1")
+		close(done)
+	}()
+	<-started
+
+	if w := serve("/", "user_1"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("same user: status = %d, Retry-After = %q, want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("/", "user_2"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("full queue: status = %d, Retry-After = %q, want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	<-done
+
+	if w := serve("/detach", "user_2"); w.Code != http.StatusOK {
+		t.Fatalf("detach: status = %d, want 200", w.Code)
+	}
+	// The detached slot is still held after the request
+	if w := serve("/", "user_3"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("after detach: status = %d, want 503", w.Code)
+	}
+	detached.Release()
+	if w := serve("/", "user_3"); w.Code != http.StatusOK {
+		t.Errorf("after release: status = %d, want 200", w.Code)
+	}
+}
@@ -2,7 +2,16 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
 )
 
 // This test demonstrates the behavior of unexported context keys
@@ -12,9 +21,9 @@ func TestUnexportedContextKey(t *testing.T) {
 
 	// Test: Using the type-safe helper function
 	ctx = WithUserID(ctx, "test-user-id")
-	userID := GetUserIDFromContext(ctx)
+	userID, err := GetUserIDFromContext(ctx)
 
-	if userID != "test-user-id" {
+	if err != nil || userID != "test-user-id" {
 		t.Errorf("Expected 'test-user-id', got %v", userID)
 	}
 
@@ -24,14 +33,39 @@ func TestUnexportedContextKey(t *testing.T) {
 		t.Errorf("String literal should return nil (different type), got %v", val)
 	}
 
-	// Test: Empty context panics (fail-fast behavior for missing authentication)
+	// Test: Empty context is an error (missing authentication is a wiring mistake)
 	emptyCtx := context.Background()
-	func() {
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("Expected panic for empty context, but no panic occurred")
-			}
-		}()
-		GetUserIDFromContext(emptyCtx)
-	}()
+	if _, err := GetUserIDFromContext(emptyCtx); !errors.Is(err, reqctx.ErrNoUser) {
+		t.Errorf("Expected reqctx.ErrNoUser for empty context, got %v", err)
+	}
+}
+
+// A handler mounted without RequireAuth answers with a structured 500 instead of panicking
+func TestRequestContextError(t *testing.T) {
+	log, err := logger.New("test")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := GetUserIDFromContext(r.Context()); err != nil {
+			RequestContextError(w, r, log, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/links", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	var resp dto.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Error.Code != apperrors.CodeInternalError {
+		t.Errorf("error code = %s, want %s", resp.Error.Code, apperrors.CodeInternalError)
+	}
 }
@@ -0,0 +1,86 @@
+package netutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Names of the sockets systemd passes with socket activation (FileDescriptorName= in the .socket unit)
+const (
+	PublicSocketName   = "public"
+	InternalSocketName = "internal"
+)
+
+// First file descriptor passed by systemd (after stdin, stdout and stderr)
+const listenFDsStart = 3
+
+/*
+Listen opens a TCP listener on addr. With reusePort it sets SO_REUSEPORT, so
+the next release can bind the same port while this process drains: both accept
+connections until this one shuts down, and none are refused in between.
+*/
+func Listen(ctx context.Context, addr string, reusePort bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reusePort {
+		lc.Control = setReusePort
+	}
+	return lc.Listen(ctx, "tcp", addr)
+}
+
+/*
+ActivatedListeners returns the sockets passed by systemd socket activation
+(LISTEN_PID and LISTEN_FDS), keyed by their LISTEN_FDNAMES name. Sockets not
+named public or internal take those names in order: the first is the public
+listener and the second the internal one. Without socket activation it returns
+nil.
+
+systemd keeps the sockets open across restarts and queues connections while
+no process is accepting, so deploys don't refuse any.
+*/
+func ActivatedListeners() (map[string]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// Child processes must not take the sockets for their own
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	positional := []string{PublicSocketName, InternalSocketName}
+	listeners := make(map[string]net.Listener, count)
+	for i := range count {
+		f := os.NewFile(uintptr(listenFDsStart+i), fmt.Sprintf("LISTEN_FD_%d", listenFDsStart+i))
+		ln, err := net.FileListener(f)
+		// FileListener dups the descriptor
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket %d passed by systemd is not a listener: %w", listenFDsStart+i, err)
+		}
+
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		if name != PublicSocketName && name != InternalSocketName {
+			if i >= len(positional) {
+				_ = ln.Close()
+				continue
+			}
+			name = positional[i]
+		}
+		listeners[name] = ln
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package netutil
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListen_ReusePort(t *testing.T) {
+	first, err := Listen(context.Background(), "127.0.0.1:0", true)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer first.Close()
+
+	// The next release binds the same port while this one still listens
+	second, err := Listen(context.Background(), first.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("Listen() on the same port with SO_REUSEPORT error = %v", err)
+	}
+	second.Close()
+
+	if ln, err := Listen(context.Background(), first.Addr().String(), false); err == nil {
+		ln.Close()
+		t.Error("Listen() on the same port without SO_REUSEPORT succeeded, want an error")
+	}
+}
+
+func TestActivatedListeners_WithoutActivation(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "2")
+
+	listeners, err := ActivatedListeners()
+	if err != nil || listeners != nil {
+		t.Errorf("ActivatedListeners() for another process = %v, %v, want nil, nil", listeners, err)
+	}
+}
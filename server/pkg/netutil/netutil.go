@@ -0,0 +1,144 @@
+// Package netutil resolves and normalizes client IP addresses so that IPv4,
+// IPv6 and IPv4-mapped IPv6 clients are keyed, hashed and looked up consistently,
+// and opens the server's listeners.
+package netutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ForwardedForHeader is the header reverse proxies append the client IP to
+const ForwardedForHeader = "X-Forwarded-For"
+
+// IPv6 clients usually get a whole /64, so per-address limits are trivially sidestepped
+const ipv6BucketBits = 64
+
+/*
+ParseAddr parses an IP address with or without a port ("203.0.113.7",
+"203.0.113.7:443", "[2001:db8::1]:443"). Zones are dropped and IPv4-mapped
+IPv6 addresses ("::ffff:203.0.113.7") are unmapped to plain IPv4, so the same
+client always yields the same address.
+*/
+func ParseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	} else {
+		// A bracketed IPv6 address without a port
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid IP address %q: %w", s, err)
+	}
+
+	return addr.WithZone("").Unmap(), nil
+}
+
+// ParsePrefixes parses CIDRs such as trusted proxy ranges. A bare address is a single-host prefix.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			addr, err := ParseAddr(v)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+		}
+		// Mapped IPv4 ranges ("::ffff:10.0.0.0/104") must match unmapped addresses
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+/*
+ClientIP returns the address of the client that sent r.
+
+X-Forwarded-For is only honored when the peer is one of the trusted proxies:
+entries are read right to left, skipping trusted hops, and the first
+untrusted address is the client. Anything left of it could have been made up
+by the client. A malformed entry stops the walk at the last hop that could be
+verified. The address is invalid when RemoteAddr isn't an IP (e.g. a Unix socket).
+*/
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	addr, err := ParseAddr(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	hops := forwardedFor(r)
+	for i := len(hops) - 1; i >= 0 && isTrusted(addr, trustedProxies); i-- {
+		hop, err := ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		addr = hop
+	}
+
+	return addr
+}
+
+// forwardedFor returns the X-Forwarded-For entries of all header lines, in order
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, line := range r.Header.Values(ForwardedForHeader) {
+		for hop := range strings.SplitSeq(line, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitKey groups addresses for per-client limits: IPv4 addresses are
+// keyed individually, IPv6 addresses by their /64.
+func RateLimitKey(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return "unknown"
+	}
+
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return addr.String()
+	}
+
+	return netip.PrefixFrom(addr.WithZone(""), ipv6BucketBits).Masked().String()
+}
+
+// HashIP returns a keyed hash of the address (64 hex chars), for counting
+// unique visitors without storing their IPs. Mapped and plain IPv4 hash the same.
+func HashIP(addr netip.Addr, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(addr.WithZone("").Unmap().AsSlice())
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,234 @@
+package netutil
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "203.0.113.7", want: "203.0.113.7"},
+		{in: "203.0.113.7:443", want: "203.0.113.7"},
+		{in: " 203.0.113.7 ", want: "203.0.113.7"},
+		{in: "2001:db8::1", want: "2001:db8::1"},
+		{in: "[2001:db8::1]:443", want: "2001:db8::1"},
+		{in: "[2001:db8::1]", want: "2001:db8::1"},
+		{in: "2001:DB8:0:0::1", want: "2001:db8::1"},
+		{in: "fe80::1%eth0", want: "fe80::1"},
+		{in: "[fe80::1%eth0]:443", want: "fe80::1"},
+		{in: "::ffff:203.0.113.7", want: "203.0.113.7"},
+		{in: "[::ffff:203.0.113.7]:443", want: "203.0.113.7"},
+		{in: "::1", want: "::1"},
+		{in: "", wantErr: true},
+		{in: "example.com", wantErr: true},
+		{in: "example.com:443", wantErr: true},
+		{in: "@", wantErr: true},
+		{in: "203.0.113.256", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAddr(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseAddr(%q) = %s, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAddr(%q) error = %v", tt.in, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseAddr(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	got, err := ParsePrefixes([]string{"10.0.0.0/8", "10.1.2.3/8", "2001:db8::/32", "192.0.2.1", "::1", "::ffff:172.16.0.0/108"})
+	if err != nil {
+		t.Fatalf("ParsePrefixes() error = %v", err)
+	}
+
+	want := []string{"10.0.0.0/8", "10.0.0.0/8", "2001:db8::/32", "192.0.2.1/32", "::1/128", "172.16.0.0/12"}
+	if len(got) != len(want) {
+		t.Fatalf("ParsePrefixes() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("prefix %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "proxy.internal", "2001:db8::/129"} {
+		if _, err := ParsePrefixes([]string{invalid}); err == nil {
+			t.Errorf("ParsePrefixes(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes([]string{"10.0.0.0/8", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParsePrefixes() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{
+			name:       "direct IPv4 client",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "direct IPv6 client",
+			remoteAddr: "[2001:db8::7]:51234",
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "IPv4-mapped peer",
+			remoteAddr: "[::ffff:203.0.113.7]:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer can't spoof the header",
+			remoteAddr: "203.0.113.7:51234",
+			xff:        []string{"198.51.100.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.2:8080",
+			xff:        []string{"198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "spoofed entries left of the client are ignored",
+			remoteAddr: "10.0.0.2:8080",
+			xff:        []string{"1.1.1.1, 198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.2:8080",
+			xff:        []string{"2001:db8::7, 10.0.0.9", "fd00::3"},
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "mapped address in the header",
+			remoteAddr: "[fd00::1]:8080",
+			xff:        []string{"::ffff:198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "bracketed address with port in the header",
+			remoteAddr: "10.0.0.2:8080",
+			xff:        []string{"[2001:db8::7]:443"},
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "malformed entry stops at the last verified hop",
+			remoteAddr: "10.0.0.2:8080",
+			xff:        []string{"198.51.100.1, unknown, 10.0.0.3"},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "only proxies",
+			remoteAddr: "10.0.0.2:8080",
+			xff:        []string{"10.0.0.3"},
+			want:       "10.0.0.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				r.Header.Add(ForwardedForHeader, v)
+			}
+
+			if got := ClientIP(r, trusted); got.String() != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no trusted proxies", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.2:8080"
+		r.Header.Set(ForwardedForHeader, "198.51.100.1")
+
+		if got := ClientIP(r, nil); got.String() != "10.0.0.2" {
+			t.Errorf("ClientIP() = %s, want 10.0.0.2", got)
+		}
+	})
+
+	t.Run("non-IP peer", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "@"
+
+		if got := ClientIP(r, trusted); got.IsValid() {
+			t.Errorf("ClientIP() = %s, want an invalid address", got)
+		}
+	})
+}
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "203.0.113.7", want: "203.0.113.7"},
+		{addr: "::ffff:203.0.113.7", want: "203.0.113.7"},
+		{addr: "2001:db8:1:2:3:4:5:6", want: "2001:db8:1:2::/64"},
+		{addr: "2001:db8:1:2::ffff", want: "2001:db8:1:2::/64"},
+		{addr: "2001:db8:1:3::1", want: "2001:db8:1:3::/64"},
+		{addr: "fe80::1%eth0", want: "fe80::/64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := RateLimitKey(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("RateLimitKey(%s) = %s, want %s", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if got := RateLimitKey(netip.Addr{}); got != "unknown" {
+		t.Errorf("RateLimitKey(invalid) = %s, want unknown", got)
+	}
+}
+
+func TestHashIP(t *testing.T) {
+	key := []byte("secret")
+	v4 := HashIP(netip.MustParseAddr("203.0.113.7"), key)
+
+	if len(v4) != 64 {
+		t.Errorf("HashIP() length = %d, want 64", len(v4))
+	}
+	if mapped := HashIP(netip.MustParseAddr("::ffff:203.0.113.7"), key); mapped != v4 {
+		t.Errorf("mapped and plain IPv4 hash differently: %s != %s", mapped, v4)
+	}
+	if other := HashIP(netip.MustParseAddr("203.0.113.8"), key); other == v4 {
+		t.Error("different addresses hash the same")
+	}
+	if otherKey := HashIP(netip.MustParseAddr("203.0.113.7"), []byte("other")); otherKey == v4 {
+		t.Error("hash doesn't depend on the key")
+	}
+
+	long := HashIP(netip.MustParseAddr("2001:db8::1"), key)
+	if short := HashIP(netip.MustParseAddr("2001:0db8:0000::0001"), key); short != long {
+		t.Errorf("equivalent IPv6 spellings hash differently: %s != %s", short, long)
+	}
+}
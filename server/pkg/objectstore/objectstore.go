@@ -0,0 +1,398 @@
+/*
+Package objectstore is a minimal client of S3-compatible object storage (AWS S3,
+MinIO, Cloudflare R2, Backblaze B2, ...): putting, getting, listing and deleting
+the objects of one bucket, all backups need.
+
+Requests are signed with AWS Signature Version 4 and address the bucket
+path-style (https://endpoint/bucket/key), which every S3-compatible service
+accepts, whatever DNS the bucket name has.
+*/
+package objectstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SHA-256 of an empty payload, the payload hash of requests without a body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// Payload hash of presigned URLs, whose requests are signed before their bodies exist
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	// Longest lifetime S3 allows presigned URLs
+	maxPresignTTL = 7 * 24 * time.Hour
+	// Most of an error response read into the error
+	maxErrorBytes = 4 << 10
+)
+
+// The object doesn't exist
+var ErrNotFound = errors.New("object not found")
+
+type Config struct {
+	// Base URL of the service, e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Endpoint string
+	// Base URL presigned URLs point to when clients reach the service elsewhere than Endpoint,
+	// e.g. a MinIO the server reaches at http://minio:9000; empty uses Endpoint
+	PublicEndpoint  string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Client talks to one bucket. It's safe for concurrent use.
+type Client struct {
+	endpoint       *url.URL
+	publicEndpoint *url.URL
+	config         Config
+	http           *http.Client
+}
+
+// New returns a client of the config's bucket, sending its requests with httpClient
+func New(config Config, httpClient *http.Client) (*Client, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q", config.Endpoint)
+	}
+	var publicEndpoint *url.URL
+	if config.PublicEndpoint != "" {
+		publicEndpoint, err = url.Parse(strings.TrimSuffix(config.PublicEndpoint, "/"))
+		if err != nil || publicEndpoint.Host == "" || (publicEndpoint.Scheme != "http" && publicEndpoint.Scheme != "https") {
+			return nil, fmt.Errorf("invalid public endpoint %q", config.PublicEndpoint)
+		}
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if config.Region == "" {
+		// What S3-compatible services that have no regions expect
+		config.Region = "us-east-1"
+	}
+
+	return &Client{
+		endpoint:       endpoint,
+		publicEndpoint: publicEndpoint,
+		config:         config,
+		http:           httpClient,
+	}, nil
+}
+
+// Put stores body under key, replacing the object there. body is read twice: once to hash it, once to send it.
+func (c *Client) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return fmt.Errorf("failed to hash object: %w", err)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind object: %w", err)
+	}
+
+	req, err := c.request(ctx, http.MethodPut, key, nil, io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	// Lets the client retry the upload
+	req.GetBody = func() (io.ReadCloser, error) {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(body), nil
+	}
+
+	resp, err := c.do(req, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get returns the content of the object at key, which the caller must close
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := c.request(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object at key. Deleting an object that doesn't exist succeeds.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	req, err := c.request(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, emptyPayloadHash)
+	if errors.Is(err, ErrNotFound) {
+		// Some services answer deletes of missing objects with 404 rather than 204
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listBucketResult is the ListObjectsV2 response
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the objects whose key starts with prefix, in key order
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := c.request(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object list: %w", err)
+		}
+
+		for _, o := range page.Contents {
+			objects = append(objects, Object{Key: o.Key, Size: o.Size, LastModified: o.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// request builds an unsigned request for the object at key, or the bucket when key is empty
+func (c *Client) request(ctx context.Context, method, key string, query url.Values, body io.ReadCloser) (*http.Request, error) {
+	u := *c.endpoint
+	u.Path = u.Path + "/" + c.config.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	// Sent escaped as signed
+	u.RawPath = encodePath(u.Path)
+	u.RawQuery = ""
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	// Encoded as signed, url.Values.Encode writes spaces as +
+	req.URL.RawQuery = canonicalQuery(query)
+	return req, nil
+}
+
+// do signs and sends req, turning error responses into errors
+func (c *Client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	req.Header.Set("x-amz-date", time.Now().UTC().Format(amzDateFormat))
+	c.sign(req, payloadHash)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", req.Method, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, maxErrorBytes)).Decode(&e)
+	return nil, fmt.Errorf("%s request failed with status %d: %s %s", req.Method, resp.StatusCode, e.Code, e.Message)
+}
+
+/*
+sign adds the Authorization header of AWS Signature Version 4 to req, whose
+x-amz-date is set. Every header the request has is signed, along with its host.
+See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+*/
+func (c *Client) sign(req *http.Request, payloadHash string) {
+	amzDate := req.Header.Get("x-amz-date")
+	scope := amzDate[:8] + "/" + c.config.Region + "/s3/aws4_request"
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	signature := c.signature(amzDate, scope, strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+/*
+PresignGet returns a URL the object at key can be downloaded from without
+credentials until ttl has passed (7 days at most), on PublicEndpoint when it's
+set. A non-empty filename has the download saved under it.
+See https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+*/
+func (c *Client) PresignGet(key string, ttl time.Duration, filename string) (string, error) {
+	if ttl <= 0 || ttl > maxPresignTTL {
+		return "", fmt.Errorf("presigned URL lifetime must be between 1s and %s", maxPresignTTL)
+	}
+
+	u := *c.endpoint
+	if c.publicEndpoint != nil {
+		u = *c.publicEndpoint
+	}
+	u.Path = u.Path + "/" + c.config.Bucket + "/" + key
+
+	query := url.Values{}
+	if filename != "" {
+		query.Set("response-content-disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	}
+	return c.presign(&u, ttl, time.Now(), query), nil
+}
+
+// presign returns u with the query, signed for GET requests until ttl after now
+func (c *Client) presign(u *url.URL, ttl time.Duration, now time.Time, query url.Values) string {
+	amzDate := now.UTC().Format(amzDateFormat)
+	scope := amzDate[:8] + "/" + c.config.Region + "/s3/aws4_request"
+
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", c.config.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	rawQuery := canonicalQuery(query)
+
+	signature := c.signature(amzDate, scope, strings.Join([]string{
+		http.MethodGet,
+		encodePath(u.Path),
+		rawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n"))
+
+	return u.Scheme + "://" + u.Host + encodePath(u.Path) + "?" + rawQuery + "&X-Amz-Signature=" + signature
+}
+
+// signature signs a canonical request with the key of the date and region
+func (c *Client) signature(amzDate, scope, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.config.SecretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, c.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by name, escaped the way signatures expect
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, encode(name, true)+"="+encode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func encodePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return encode(path, false)
+}
+
+// encode percent-encodes all but the unreserved characters, and slashes unless encodeSlash
+func encode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+/*
+Cursors encodes the positions of cursor-paginated endpoints as opaque tokens
+and decodes them back, rejecting any token it didn't sign.
+
+A token has the form "<base64url JSON position>.<base64url HMAC-SHA256(JSON position)>",
+so positions need no storage and clients can't point a cursor somewhere the
+endpoint never sent them.
+*/
+type Cursors struct {
+	secret []byte
+}
+
+/*
+NewCursors returns a Cursors signing with secret. Without one it signs with a
+random key, so cursors stop working when the process restarts and aren't
+accepted by other instances: set a secret when running more than one.
+*/
+func NewCursors(secret string) *Cursors {
+	if secret == "" {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		return &Cursors{secret: key}
+	}
+	return &Cursors{secret: []byte(secret)}
+}
+
+// Encode returns the token of a position, any value encoding/json can marshal
+func (c *Cursors) Encode(position any) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.mac(payload)), nil
+}
+
+// Decode reads the position of a token into position; tokens that weren't signed by c, or don't
+// hold a position of its type, are apperrors.InvalidCursor
+func (c *Cursors) Decode(token string, position any) error {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return apperrors.InvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return apperrors.InvalidCursor
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, c.mac(payload)) {
+		return apperrors.InvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(position); err != nil {
+		return apperrors.InvalidCursor
+	}
+	return nil
+}
+
+func (c *Cursors) mac(payload []byte) []byte {
+	m := hmac.New(sha256.New, c.secret)
+	m.Write(payload)
+	return m.Sum(nil)
+}
@@ -0,0 +1,75 @@
+package pagination
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type testPosition struct {
+	After time.Time `json:"after"`
+	ID    string    `json:"id"`
+}
+
+func TestCursors_RoundTrip(t *testing.T) {
+	cursors := NewCursors("test-secret-test-secret-test-secret")
+	want := testPosition{After: time.Date(2026, 3, 1, 12, 0, 0, 123000, time.UTC), ID: "abc"}
+
+	token, err := cursors.Encode(want)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var got testPosition
+	if err := cursors.Decode(token, &got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !got.After.Equal(want.After) || got.ID != want.ID {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCursors_RejectsForgedTokens(t *testing.T) {
+	cursors := NewCursors("test-secret-test-secret-test-secret")
+	token, err := cursors.Encode(testPosition{ID: "abc"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+
+	other, _ := NewCursors("another-secret-another-secret-another").Encode(testPosition{ID: "abc"})
+	forged, _ := cursors.Encode(map[string]string{"id": "abc", "extra": "x"})
+
+	tests := map[string]string{
+		"empty":               "",
+		"no signature":        payload,
+		"not base64":          "!!." + sig,
+		"tampered payload":    "eyJpZCI6Inh5eiJ9." + sig,
+		"other secret":        other,
+		"other position type": forged,
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got testPosition
+			if err := cursors.Decode(token, &got); !errors.Is(err, apperrors.InvalidCursor) {
+				t.Errorf("Decode(%q) error = %v, want InvalidCursor", token, err)
+			}
+		})
+	}
+}
+
+func TestNewCursors_RandomKeyWithoutSecret(t *testing.T) {
+	token, err := NewCursors("").Encode(testPosition{ID: "abc"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var got testPosition
+	if err := NewCursors("").Decode(token, &got); !errors.Is(err, apperrors.InvalidCursor) {
+		t.Errorf("Decode() with another random key error = %v, want InvalidCursor", err)
+	}
+}
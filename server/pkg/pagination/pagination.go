@@ -0,0 +1,178 @@
+/*
+Package pagination is shared by the list endpoints: it reads the page a client
+asks for, keeps its size within the endpoint's bounds, builds the response
+metadata and Link header, and encodes the opaque cursors of cursor-paginated
+endpoints.
+
+Offset-paginated endpoints take ?page= (or its alias ?page_token=) and ?limit=
+and answer with a Meta; cursor-paginated ones take ?cursor= and ?limit= and
+answer with the token of the next page, signed by a Cursors so clients can't
+forge positions.
+*/
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query parameters selecting the page of a paginated endpoint.
+// page_token is an alias of page for clients that follow opaque page tokens.
+const (
+	PageParam      = "page"
+	PageTokenParam = "page_token"
+	LimitParam     = "limit"
+	CursorParam    = "cursor"
+)
+
+// Bounds are the page size of an endpoint when the client doesn't ask for one, and the largest it allows
+type Bounds struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Default are the bounds of list endpoints without their own
+var Default = Bounds{DefaultLimit: 20, MaxLimit: 100}
+
+// Limit returns the page size to use for a requested one; 0 or less is the default
+func (b Bounds) Limit(limit int) int {
+	if limit < 1 {
+		return b.DefaultLimit
+	}
+	return min(limit, b.MaxLimit)
+}
+
+// Page returns the page to serve for a requested page number and size; pages start at 1
+func (b Bounds) Page(number, limit int) Page {
+	return Page{Number: max(number, 1), Limit: b.Limit(limit)}
+}
+
+// Page is a page of an offset-paginated list, within its endpoint's bounds
+type Page struct {
+	Number int
+	Limit  int
+}
+
+// Offset is the number of items before the page
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+// Meta returns the response metadata of the page of a list of total items
+func (p Page) Meta(total int64) Meta {
+	return Meta{
+		Page:       p.Number,
+		Limit:      p.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(p.Limit) - 1) / int64(p.Limit)), // Ceiling division
+	}
+}
+
+// Meta describes the page of an offset-paginated response
+type Meta struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// FromQuery reads the requested page number and size. Missing or invalid values are 0,
+// which Bounds.Page replaces with the defaults. When both page and page_token are given, page wins.
+func FromQuery(query url.Values) (page, limit int) {
+	value := query.Get(PageParam)
+	if value == "" {
+		value = query.Get(PageTokenParam)
+	}
+	if p, err := strconv.Atoi(value); err == nil && p > 0 {
+		page = p
+	}
+
+	if l, err := strconv.Atoi(query.Get(LimitParam)); err == nil && l > 0 {
+		limit = l
+	}
+
+	return page, limit
+}
+
+// Link is the target of a pagination relation
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Links are the pagination relations of a response, served as the _links of its body.
+// Relations that don't apply to the page (prev on the first one, ...) are nil.
+type Links struct {
+	First *Link `json:"first,omitempty"`
+	Prev  *Link `json:"prev,omitempty"`
+	Next  *Link `json:"next,omitempty"`
+	Last  *Link `json:"last,omitempty"`
+}
+
+/*
+SetLinks sets a Link header (RFC 8288, formerly RFC 5988) with the first, prev,
+next and last pages of a paginated response, so clients can paginate without
+parsing the body, and returns the same relations for the body's _links.
+
+The URLs keep the request's query (filters included), use the effective limit
+and are relative to the request URL. The page is set under the parameter the
+client used, page or page_token.
+*/
+func SetLinks(w http.ResponseWriter, r *http.Request, meta Meta) Links {
+	query := r.URL.Query()
+
+	name := PageParam
+	if query.Get(PageParam) == "" && query.Get(PageTokenParam) != "" {
+		name = PageTokenParam
+	}
+	query.Del(PageParam)
+	query.Del(PageTokenParam)
+	query.Set(LimitParam, strconv.Itoa(meta.Limit))
+
+	pageLink := func(page int) *Link {
+		query.Set(name, strconv.Itoa(page))
+		return &Link{Href: r.URL.Path + "?" + query.Encode()}
+	}
+
+	// An empty result still has a (empty) first page
+	last := max(meta.TotalPages, 1)
+
+	links := Links{First: pageLink(1), Last: pageLink(last)}
+	if meta.Page > 1 {
+		links.Prev = pageLink(min(meta.Page-1, last))
+	}
+	if meta.Page < last {
+		links.Next = pageLink(meta.Page + 1)
+	}
+
+	header := []string{fmt.Sprintf(`<%s>; rel="first"`, links.First.Href)}
+	if links.Prev != nil {
+		header = append(header, fmt.Sprintf(`<%s>; rel="prev"`, links.Prev.Href))
+	}
+	if links.Next != nil {
+		header = append(header, fmt.Sprintf(`<%s>; rel="next"`, links.Next.Href))
+	}
+	header = append(header, fmt.Sprintf(`<%s>; rel="last"`, links.Last.Href))
+
+	// Add rather than Set: the version layer may already have set a successor-version link
+	w.Header().Add("Link", strings.Join(header, ", "))
+
+	return links
+}
+
+// SetNextLink sets a Link header to the next page of a cursor-paginated response, and returns it for the body's _links.
+// The query parameters named in drop (such as the start of the feed) are left out, since the cursor replaces them.
+func SetNextLink(w http.ResponseWriter, r *http.Request, cursor string, drop ...string) Links {
+	query := r.URL.Query()
+	for _, name := range drop {
+		query.Del(name)
+	}
+	query.Set(CursorParam, cursor)
+
+	next := &Link{Href: r.URL.Path + "?" + query.Encode()}
+	w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, next.Href))
+
+	return Links{Next: next}
+}
@@ -0,0 +1,169 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantPage  int
+		wantLimit int
+	}{
+		{name: "default", target: "/api/v1/links"},
+		{name: "page and limit", target: "/api/v1/links?page=3&limit=20", wantPage: 3, wantLimit: 20},
+		{name: "page_token alias", target: "/api/v1/links?page_token=4", wantPage: 4},
+		{name: "page wins over page_token", target: "/api/v1/links?page=2&page_token=4", wantPage: 2},
+		{name: "invalid values are left to the defaults", target: "/api/v1/links?page=-1&limit=abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			page, limit := FromQuery(req.URL.Query())
+			if page != tt.wantPage || limit != tt.wantLimit {
+				t.Errorf("FromQuery() = %d, %d, want %d, %d", page, limit, tt.wantPage, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestSetLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		meta     Meta
+		expected string
+	}{
+		{
+			name:   "middle page keeps filters and uses effective limit",
+			target: "/api/v1/links?is_active=true&page=2&limit=500",
+			meta:   Meta{Page: 2, Limit: 100, Total: 250, TotalPages: 3},
+			expected: `</api/v1/links?is_active=true&limit=100&page=1>; rel="first", ` +
+				`</api/v1/links?is_active=true&limit=100&page=1>; rel="prev", ` +
+				`</api/v1/links?is_active=true&limit=100&page=3>; rel="next", ` +
+				`</api/v1/links?is_active=true&limit=100&page=3>; rel="last"`,
+		},
+		{
+			name:   "first page has no prev",
+			target: "/api/v1/links",
+			meta:   Meta{Page: 1, Limit: 5, Total: 7, TotalPages: 2},
+			expected: `</api/v1/links?limit=5&page=1>; rel="first", ` +
+				`</api/v1/links?limit=5&page=2>; rel="next", ` +
+				`</api/v1/links?limit=5&page=2>; rel="last"`,
+		},
+		{
+			name:   "page_token is echoed back",
+			target: "/api/v1/links?page_token=2&limit=5",
+			meta:   Meta{Page: 2, Limit: 5, Total: 7, TotalPages: 2},
+			expected: `</api/v1/links?limit=5&page_token=1>; rel="first", ` +
+				`</api/v1/links?limit=5&page_token=1>; rel="prev", ` +
+				`</api/v1/links?limit=5&page_token=2>; rel="last"`,
+		},
+		{
+			name:   "empty result",
+			target: "/api/v1/links",
+			meta:   Meta{Page: 1, Limit: 5},
+			expected: `</api/v1/links?limit=5&page=1>; rel="first", ` +
+				`</api/v1/links?limit=5&page=1>; rel="last"`,
+		},
+		{
+			name:   "page past the end points prev at the last page",
+			target: "/api/v1/links?page=9",
+			meta:   Meta{Page: 9, Limit: 5, Total: 7, TotalPages: 2},
+			expected: `</api/v1/links?limit=5&page=1>; rel="first", ` +
+				`</api/v1/links?limit=5&page=2>; rel="prev", ` +
+				`</api/v1/links?limit=5&page=2>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			links := SetLinks(w, req, tt.meta)
+
+			if got := w.Header().Get("Link"); got != tt.expected {
+				t.Errorf("Link = %q\nwant   %q", got, tt.expected)
+			}
+			// The body's _links hold the header's relations
+			if got := headerOf(links); got != tt.expected {
+				t.Errorf("_links = %q\nwant     %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBounds_Page(t *testing.T) {
+	bounds := Bounds{DefaultLimit: 5, MaxLimit: 100}
+
+	tests := []struct {
+		name          string
+		number, limit int
+		want          Page
+	}{
+		{name: "defaults", want: Page{Number: 1, Limit: 5}},
+		{name: "requested", number: 3, limit: 20, want: Page{Number: 3, Limit: 20}},
+		{name: "limit capped", number: 1, limit: 500, want: Page{Number: 1, Limit: 100}},
+		{name: "negative values", number: -2, limit: -1, want: Page{Number: 1, Limit: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bounds.Page(tt.number, tt.limit); got != tt.want {
+				t.Errorf("Page(%d, %d) = %+v, want %+v", tt.number, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPage_Meta(t *testing.T) {
+	page := Page{Number: 3, Limit: 5}
+
+	if got := page.Offset(); got != 10 {
+		t.Errorf("Offset() = %d, want 10", got)
+	}
+
+	want := Meta{Page: 3, Limit: 5, Total: 11, TotalPages: 3}
+	if got := page.Meta(11); got != want {
+		t.Errorf("Meta(11) = %+v, want %+v", got, want)
+	}
+	if got := page.Meta(0).TotalPages; got != 0 {
+		t.Errorf("Meta(0).TotalPages = %d, want 0", got)
+	}
+}
+
+func TestSetNextLink(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/links/changes?since=2026-03-01&limit=2", nil)
+	w := httptest.NewRecorder()
+
+	links := SetNextLink(w, req, "abc.def", "since")
+
+	want := `</api/v1/links/changes?cursor=abc.def&limit=2>; rel="next"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+	if got := headerOf(links); got != want {
+		t.Errorf("_links = %q, want %q", got, want)
+	}
+}
+
+// headerOf formats links as a Link header, in first, prev, next, last order
+func headerOf(links Links) string {
+	var parts []string
+	for _, rel := range []struct {
+		name string
+		link *Link
+	}{{"first", links.First}, {"prev", links.Prev}, {"next", links.Next}, {"last", links.Last}} {
+		if rel.link != nil {
+			parts = append(parts, fmt.Sprintf(`<%s>; rel="%s"`, rel.link.Href, rel.name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
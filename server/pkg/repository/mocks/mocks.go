@@ -0,0 +1,38 @@
+/*
+Package mocks has mocks of the repository interfaces for service tests. Each
+method calls the mock's func field of the same name (TryCreateLinkFunc, ...)
+and fails with ErrNotImplemented when it isn't set.
+
+repository.go is generated from pkg/repository/repository.go by cmd/mockgen;
+run go generate ./pkg/repository after changing an interface.
+*/
+package mocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotImplemented is returned by the mocked methods a test didn't set
+var ErrNotImplemented = errors.New("not implemented")
+
+func notImplemented(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotImplemented, method)
+}
+
+// Transactor runs fn directly on Queries and records how the unit of work ended
+type Transactor[Q any] struct {
+	Queries    Q
+	Committed  bool
+	RolledBack bool
+}
+
+func (m *Transactor[Q]) WithTx(ctx context.Context, fn func(q Q) error) error {
+	if err := fn(m.Queries); err != nil {
+		m.RolledBack = true
+		return err
+	}
+	m.Committed = true
+	return nil
+}
@@ -0,0 +1,316 @@
+/*
+Package repository declares what each service needs from storage, one queries
+interface per service. *db.Queries (Postgres) implements all of them; the
+in-memory and SQLite stores implement the link and tag ones.
+
+Services depend on these interfaces rather than on *db.Queries, so their tests
+can use the mocks in repository/mocks, generated from this file:
+
+	go generate ./pkg/repository
+*/
+package repository
+
+//go:generate go run ../../cmd/mockgen -source repository.go -destination mocks/repository.go -package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// Links and tags
+
+type LinkQueries interface {
+	TryCreateLink(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error)
+	GetLinkForRedirect(ctx context.Context, shortcode string) (db.GetLinkForRedirectRow, error)
+	ListUserLinks(ctx context.Context, arg db.ListUserLinksParams) ([]db.ListUserLinksRow, error)
+	ListUserLinksByIDs(ctx context.Context, arg db.ListUserLinksByIDsParams) ([]db.ListUserLinksByIDsRow, error)
+	CountUserLinks(ctx context.Context, arg db.CountUserLinksParams) (int64, error)
+	GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error)
+	GetLinkByShortcodeAndUser(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error)
+	UpdateLink(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error)
+	DeleteLink(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error)
+	RetireLink(ctx context.Context, arg db.RetireLinkParams) (db.RetireLinkRow, error)
+	AddTagsToLink(ctx context.Context, arg db.AddTagsToLinkParams) error
+	CountUserTagsByIDs(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error)
+	UpsertTagsByName(ctx context.Context, arg db.UpsertTagsByNameParams) ([]db.UpsertTagsByNameRow, error)
+	ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error)
+	ListUserTagNamesByIDs(ctx context.Context, arg db.ListUserTagNamesByIDsParams) ([]string, error)
+	RemoveTagsFromLink(ctx context.Context, arg db.RemoveTagsFromLinkParams) error
+	GetLinkByIdAndUserWithTags(ctx context.Context, arg db.GetLinkByIdAndUserWithTagsParams) (db.GetLinkByIdAndUserWithTagsRow, error)
+	CreateLinkLead(ctx context.Context, arg db.CreateLinkLeadParams) error
+	ListLinkLeads(ctx context.Context, linkID uuid.UUID) ([]db.ListLinkLeadsRow, error)
+	UpsertLinkPreview(ctx context.Context, arg db.UpsertLinkPreviewParams) (db.LinkPreview, error)
+	GetLinkPreview(ctx context.Context, linkID uuid.UUID) (db.LinkPreview, error)
+	GetLinkPreviewByShortcode(ctx context.Context, shortcode string) (db.LinkPreview, error)
+	DeleteLinkPreview(ctx context.Context, linkID uuid.UUID) (db.LinkPreview, error)
+	UpsertLinkTrafficCap(ctx context.Context, arg db.UpsertLinkTrafficCapParams) (db.LinkTrafficCap, error)
+	GetLinkTrafficCap(ctx context.Context, linkID uuid.UUID) (db.LinkTrafficCap, error)
+	DeleteLinkTrafficCap(ctx context.Context, linkID uuid.UUID) (db.LinkTrafficCap, error)
+	TakeLinkTrafficCap(ctx context.Context, arg db.TakeLinkTrafficCapParams) (int64, error)
+	UpsertLinkResponseHeaders(ctx context.Context, arg db.UpsertLinkResponseHeadersParams) (db.LinkResponseHeader, error)
+	GetLinkResponseHeaders(ctx context.Context, linkID uuid.UUID) (db.LinkResponseHeader, error)
+	DeleteLinkResponseHeaders(ctx context.Context, linkID uuid.UUID) (db.LinkResponseHeader, error)
+	EnableLinkPublicStats(ctx context.Context, linkID uuid.UUID) (db.LinkPublicStat, error)
+	GetLinkPublicStats(ctx context.Context, linkID uuid.UUID) (db.LinkPublicStat, error)
+	DisableLinkPublicStats(ctx context.Context, linkID uuid.UUID) (db.LinkPublicStat, error)
+	UpsertLinkWaitingRoom(ctx context.Context, arg db.UpsertLinkWaitingRoomParams) (db.UpsertLinkWaitingRoomRow, error)
+	GetLinkWaitingRoom(ctx context.Context, linkID uuid.UUID) (db.GetLinkWaitingRoomRow, error)
+	DeleteLinkWaitingRoom(ctx context.Context, linkID uuid.UUID) (db.DeleteLinkWaitingRoomRow, error)
+	SetLinkWaitingRoomActiveByToken(ctx context.Context, arg db.SetLinkWaitingRoomActiveByTokenParams) (db.SetLinkWaitingRoomActiveByTokenRow, error)
+	CreateLinkComment(ctx context.Context, arg db.CreateLinkCommentParams) (db.LinkComment, error)
+	ListLinkComments(ctx context.Context, arg db.ListLinkCommentsParams) ([]db.LinkComment, error)
+	CountLinkComments(ctx context.Context, linkID uuid.UUID) (int64, error)
+	DeleteLinkComment(ctx context.Context, arg db.DeleteLinkCommentParams) (db.LinkComment, error)
+	ListLinkChanges(ctx context.Context, arg db.ListLinkChangesParams) ([]db.ListLinkChangesRow, error)
+	UpsertDynamicLink(ctx context.Context, arg db.UpsertDynamicLinkParams) (db.DynamicLink, error)
+	GetDynamicLink(ctx context.Context, linkID uuid.UUID) (db.DynamicLink, error)
+	DeleteDynamicLink(ctx context.Context, linkID uuid.UUID) (db.DynamicLink, error)
+	ChangeLinkDestination(ctx context.Context, arg db.ChangeLinkDestinationParams) (db.ChangeLinkDestinationRow, error)
+	ScheduleLinkDestinationChange(ctx context.Context, arg db.ScheduleLinkDestinationChangeParams) (db.LinkDestinationChange, error)
+	ListLinkDestinationChanges(ctx context.Context, arg db.ListLinkDestinationChangesParams) ([]db.LinkDestinationChange, error)
+	CountLinkDestinationChanges(ctx context.Context, linkID uuid.UUID) (int64, error)
+	CancelLinkDestinationChange(ctx context.Context, arg db.CancelLinkDestinationChangeParams) (db.LinkDestinationChange, error)
+	GetSenderVerification(ctx context.Context, arg db.GetSenderVerificationParams) (db.VerifiedSender, error)
+	ListDuplicateLinks(ctx context.Context, arg db.ListDuplicateLinksParams) ([]db.ListDuplicateLinksRow, error)
+	CountDuplicateDestinations(ctx context.Context, userID string) (int64, error)
+	ListLinksForMerge(ctx context.Context, arg db.ListLinksForMergeParams) ([]db.ListLinksForMergeRow, error)
+	MoveLinkClicks(ctx context.Context, arg db.MoveLinkClicksParams) (int64, error)
+	MoveLinkDailyStats(ctx context.Context, arg db.MoveLinkDailyStatsParams) error
+	MergeLinks(ctx context.Context, arg db.MergeLinksParams) ([]db.MergeLinksRow, error)
+	ListUserHTTPSUpgrades(ctx context.Context, arg db.ListUserHTTPSUpgradesParams) ([]db.ListUserHTTPSUpgradesRow, error)
+	CountUserHTTPSUpgrades(ctx context.Context, userID string) (int64, error)
+	ListUserDestinationAlerts(ctx context.Context, arg db.ListUserDestinationAlertsParams) ([]db.ListUserDestinationAlertsRow, error)
+	CountUserDestinationAlerts(ctx context.Context, userID string) (int64, error)
+	GetUserLinkByURL(ctx context.Context, arg db.GetUserLinkByURLParams) (db.GetUserLinkByURLRow, error)
+	GetShortcodeReservation(ctx context.Context, shortcode string) (db.ShortcodeReservation, error)
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+type TagQueries interface {
+	ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error)
+	ListUserTagsPage(ctx context.Context, arg db.ListUserTagsPageParams) ([]db.ListUserTagsPageRow, error)
+	CountUserTagsByPrefix(ctx context.Context, arg db.CountUserTagsByPrefixParams) (int64, error)
+	CreateTag(ctx context.Context, arg db.CreateTagParams) (db.CreateTagRow, error)
+	UpsertTag(ctx context.Context, arg db.UpsertTagParams) (db.UpsertTagRow, error)
+	UpdateTag(ctx context.Context, arg db.UpdateTagParams) (db.UpdateTagRow, error)
+	DeleteTag(ctx context.Context, arg db.DeleteTagParams) (db.DeleteTagRow, error)
+	DeleteTags(ctx context.Context, arg db.DeleteTagsParams) ([]db.DeleteTagsRow, error)
+	ListDeletedTags(ctx context.Context, userID string) ([]db.ListDeletedTagsRow, error)
+	RestoreTag(ctx context.Context, arg db.RestoreTagParams) (db.RestoreTagRow, error)
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+type TagSuggestionQueries interface {
+	ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error)
+	SuggestTagsForHost(ctx context.Context, arg db.SuggestTagsForHostParams) ([]db.SuggestTagsForHostRow, error)
+}
+
+// Campaigns, conversions and integrations
+
+type CampaignQueries interface {
+	ListUserCampaigns(ctx context.Context, userID string) ([]db.ListUserCampaignsRow, error)
+	GetCampaignByIdAndUser(ctx context.Context, arg db.GetCampaignByIdAndUserParams) (db.GetCampaignByIdAndUserRow, error)
+	CreateCampaign(ctx context.Context, arg db.CreateCampaignParams) (db.CreateCampaignRow, error)
+	UpdateCampaign(ctx context.Context, arg db.UpdateCampaignParams) (db.UpdateCampaignRow, error)
+	DeleteCampaign(ctx context.Context, arg db.DeleteCampaignParams) (db.DeleteCampaignRow, error)
+	AddLinksToCampaign(ctx context.Context, arg db.AddLinksToCampaignParams) error
+	RemoveLinksFromCampaign(ctx context.Context, arg db.RemoveLinksFromCampaignParams) error
+	ListCampaignLinks(ctx context.Context, arg db.ListCampaignLinksParams) ([]db.ListCampaignLinksRow, error)
+}
+
+type ConversionQueries interface {
+	CreateConversion(ctx context.Context, arg db.CreateConversionParams) (db.CreateConversionRow, error)
+}
+
+type PublishHookQueries interface {
+	CreatePublishHook(ctx context.Context, arg db.CreatePublishHookParams) (db.CreatePublishHookRow, error)
+	ListUserPublishHooks(ctx context.Context, userID string) ([]db.ListUserPublishHooksRow, error)
+	DeletePublishHook(ctx context.Context, arg db.DeletePublishHookParams) (db.DeletePublishHookRow, error)
+	UsePublishHook(ctx context.Context, tokenHash string) (db.UsePublishHookRow, error)
+	CountUserTagsByIDs(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error)
+}
+
+type ServiceAccountQueries interface {
+	CreateServiceAccount(ctx context.Context, arg db.CreateServiceAccountParams) (db.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context, ownerID *string) ([]db.ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, id uuid.UUID) (db.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, arg db.UpdateServiceAccountParams) (db.ServiceAccount, error)
+	RotateServiceAccountToken(ctx context.Context, arg db.RotateServiceAccountTokenParams) (db.ServiceAccount, error)
+	RevokePreviousServiceAccountToken(ctx context.Context, id uuid.UUID) (db.ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, id uuid.UUID) (db.ServiceAccount, error)
+	UseServiceAccount(ctx context.Context, arg db.UseServiceAccountParams) (db.ServiceAccount, error)
+}
+
+type APIKeyQueries interface {
+	CreateApiKey(ctx context.Context, arg db.CreateApiKeyParams) (db.ApiKey, error)
+	ListUserApiKeys(ctx context.Context, userID string) ([]db.ApiKey, error)
+	GetUserApiKey(ctx context.Context, arg db.GetUserApiKeyParams) (db.ApiKey, error)
+	RevokeApiKey(ctx context.Context, arg db.RevokeApiKeyParams) (db.ApiKey, error)
+	RotateApiKey(ctx context.Context, arg db.RotateApiKeyParams) (db.ApiKey, error)
+	RevokePreviousApiKey(ctx context.Context, arg db.RevokePreviousApiKeyParams) (db.ApiKey, error)
+	UseApiKey(ctx context.Context, arg db.UseApiKeyParams) (db.ApiKey, error)
+}
+
+type WebhookQueries interface {
+	CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error)
+	ListUserWebhooks(ctx context.Context, userID string) ([]db.Webhook, error)
+	GetUserWebhook(ctx context.Context, arg db.GetUserWebhookParams) (db.Webhook, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (db.Webhook, error)
+	DeleteWebhook(ctx context.Context, arg db.DeleteWebhookParams) (db.Webhook, error)
+	RotateWebhookSecret(ctx context.Context, arg db.RotateWebhookSecretParams) (db.Webhook, error)
+	RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error
+	RecordWebhookFailure(ctx context.Context, id uuid.UUID) (pgtype.Timestamptz, error)
+	DisableWebhook(ctx context.Context, id uuid.UUID) (int64, error)
+	CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error)
+	CountWebhookDeliveries(ctx context.Context, webhookID uuid.UUID) (int64, error)
+	GetWebhookDelivery(ctx context.Context, arg db.GetWebhookDeliveryParams) (db.WebhookDelivery, error)
+	ClaimDueWebhookRetries(ctx context.Context, arg db.ClaimDueWebhookRetriesParams) ([]db.WebhookDelivery, error)
+	EnqueueWebhookEvent(ctx context.Context, arg db.EnqueueWebhookEventParams) ([]db.WebhookDelivery, error)
+	ClaimWebhookDelivery(ctx context.Context, arg db.ClaimWebhookDeliveryParams) (int64, error)
+	CompleteWebhookDelivery(ctx context.Context, arg db.CompleteWebhookDeliveryParams) (db.WebhookDelivery, error)
+	CancelWebhookDeliveryRetry(ctx context.Context, id uuid.UUID) error
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+type ShortcodeReservationQueries interface {
+	ReserveShortcode(ctx context.Context, arg db.ReserveShortcodeParams) (db.ShortcodeReservation, error)
+	ListUserShortcodeReservations(ctx context.Context, userID string) ([]db.ShortcodeReservation, error)
+	DeleteShortcodeReservation(ctx context.Context, arg db.DeleteShortcodeReservationParams) (db.ShortcodeReservation, error)
+}
+
+type VerificationQueries interface {
+	UpsertVerifiedSender(ctx context.Context, arg db.UpsertVerifiedSenderParams) (db.VerifiedSender, error)
+	DeleteVerifiedSender(ctx context.Context, arg db.DeleteVerifiedSenderParams) (db.VerifiedSender, error)
+	ListVerifiedSenders(ctx context.Context, arg db.ListVerifiedSendersParams) ([]db.VerifiedSender, error)
+	CountVerifiedSenders(ctx context.Context) (int64, error)
+}
+
+type ShortcodeSuggestionQueries interface {
+	ListShortcodeSuggestions(ctx context.Context, arg db.ListShortcodeSuggestionsParams) ([]db.ListShortcodeSuggestionsRow, error)
+}
+
+type SlackQueries interface {
+	GetSlackAccountUser(ctx context.Context, arg db.GetSlackAccountUserParams) (string, error)
+	LinkSlackAccount(ctx context.Context, arg db.LinkSlackAccountParams) (db.SlackAccount, error)
+}
+
+// Activity and anomalies
+
+type ActivityQueries interface {
+	ListUserActivity(ctx context.Context, arg db.ListUserActivityParams) ([]db.ActivityEvent, error)
+	CountUserActivity(ctx context.Context, userID string) (int64, error)
+	ListUserActivityChain(ctx context.Context, arg db.ListUserActivityChainParams) ([]db.ActivityEvent, error)
+}
+
+type ActivityChainVerifierQueries interface {
+	ListActivityUsers(ctx context.Context, arg db.ListActivityUsersParams) ([]string, error)
+	ListUserActivityChain(ctx context.Context, arg db.ListUserActivityChainParams) ([]db.ActivityEvent, error)
+}
+
+type AnomalyQueries interface {
+	GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error)
+	ListLinkAnomalies(ctx context.Context, linkID uuid.UUID) ([]db.LinkAnomaly, error)
+}
+
+type AnomalyDetectorQueries interface {
+	TryLockAnomalyDetection(ctx context.Context) (bool, error)
+	GetHourlyLinkClicks(ctx context.Context, arg db.GetHourlyLinkClicksParams) ([]db.GetHourlyLinkClicksRow, error)
+	CreateLinkAnomaly(ctx context.Context, arg db.CreateLinkAnomalyParams) (db.LinkAnomaly, error)
+}
+
+// Stats and background jobs
+
+type StatsQueries interface {
+	GetTagByIdAndUser(ctx context.Context, arg db.GetTagByIdAndUserParams) (db.GetTagByIdAndUserRow, error)
+	GetTagClickTotals(ctx context.Context, arg db.GetTagClickTotalsParams) (db.GetTagClickTotalsRow, error)
+	GetTagClicksByDay(ctx context.Context, arg db.GetTagClicksByDayParams) ([]db.GetTagClicksByDayRow, error)
+	GetTagTopLinks(ctx context.Context, arg db.GetTagTopLinksParams) ([]db.GetTagTopLinksRow, error)
+	GetCampaignByIdAndUser(ctx context.Context, arg db.GetCampaignByIdAndUserParams) (db.GetCampaignByIdAndUserRow, error)
+	GetCampaignClickTotals(ctx context.Context, arg db.GetCampaignClickTotalsParams) (db.GetCampaignClickTotalsRow, error)
+	GetCampaignClicksByDay(ctx context.Context, arg db.GetCampaignClicksByDayParams) ([]db.GetCampaignClicksByDayRow, error)
+	GetCampaignTopLinks(ctx context.Context, arg db.GetCampaignTopLinksParams) ([]db.GetCampaignTopLinksRow, error)
+	GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error)
+	GetLinkForPublicStats(ctx context.Context, shortcode string) (db.GetLinkForPublicStatsRow, error)
+	GetLinkClicksByDay(ctx context.Context, arg db.GetLinkClicksByDayParams) ([]db.GetLinkClicksByDayRow, error)
+	ExportClicks(ctx context.Context, arg db.ExportClicksParams) ([]db.ExportClicksRow, error)
+	ExportClicksByDay(ctx context.Context, arg db.ExportClicksByDayParams) ([]db.ExportClicksByDayRow, error)
+	GetStatsRollupWatermark(ctx context.Context) (pgtype.Date, error)
+}
+
+type StatsRollupQueries interface {
+	TryLockStatsRollup(ctx context.Context) (bool, error)
+	GetStatsRollupWatermark(ctx context.Context) (pgtype.Date, error)
+	GetFirstClickDay(ctx context.Context) (pgtype.Date, error)
+	RollupDailyStats(ctx context.Context, arg db.RollupDailyStatsParams) (int64, error)
+	SetStatsRollupWatermark(ctx context.Context, rolledUpUntil pgtype.Date) error
+}
+
+type ClickBackfillQueries interface {
+	ListClicksForBackfill(ctx context.Context, arg db.ListClicksForBackfillParams) ([]db.ListClicksForBackfillRow, error)
+}
+
+type TrafficCapSyncQueries interface {
+	ListTrafficCappedLinkIDs(ctx context.Context) ([]uuid.UUID, error)
+	SyncLinkTrafficCapCounters(ctx context.Context, arg db.SyncLinkTrafficCapCountersParams) error
+}
+
+type ClickCounterQueries interface {
+	AddLinkClickCounts(ctx context.Context, arg db.AddLinkClickCountsParams) error
+	ListLinkClickCounts(ctx context.Context, linkIDs []uuid.UUID) ([]db.ListLinkClickCountsRow, error)
+}
+
+type LeaderboardQueries interface {
+	ListLinkOwners(ctx context.Context, linkIDs []uuid.UUID) ([]db.ListLinkOwnersRow, error)
+	ListUserLinksByIDs(ctx context.Context, arg db.ListUserLinksByIDsParams) ([]db.ListUserLinksByIDsRow, error)
+}
+
+type DestinationSchedulerQueries interface {
+	ListDueLinkDestinationChanges(ctx context.Context, limit int32) ([]uuid.UUID, error)
+	ApplyLinkDestinationChange(ctx context.Context, id uuid.UUID) (db.ApplyLinkDestinationChangeRow, error)
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+type HTTPSUpgradeQueries interface {
+	ListHTTPSUpgradeCandidates(ctx context.Context, arg db.ListHTTPSUpgradeCandidatesParams) ([]db.ListHTTPSUpgradeCandidatesRow, error)
+	UpgradeLinkToHTTPS(ctx context.Context, arg db.UpgradeLinkToHTTPSParams) (db.UpgradeLinkToHTTPSRow, error)
+	UpsertHTTPSUpgradeCheck(ctx context.Context, arg db.UpsertHTTPSUpgradeCheckParams) error
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+type DestinationMonitorQueries interface {
+	ListDestinationsToMonitor(ctx context.Context, arg db.ListDestinationsToMonitorParams) ([]db.ListDestinationsToMonitorRow, error)
+	UpsertDestinationCheck(ctx context.Context, arg db.UpsertDestinationCheckParams) error
+	TouchDestinationCheck(ctx context.Context, linkID uuid.UUID) error
+	CreateDestinationAlert(ctx context.Context, arg db.CreateDestinationAlertParams) (db.LinkDestinationAlert, error)
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+type URLEncryptionQueries interface {
+	ListLinkURLs(ctx context.Context, arg db.ListLinkURLsParams) ([]db.ListLinkURLsRow, error)
+	SetLinkURLs(ctx context.Context, arg db.SetLinkURLsParams) (int64, error)
+	ListDestinationChangeURLs(ctx context.Context, arg db.ListDestinationChangeURLsParams) ([]db.ListDestinationChangeURLsRow, error)
+	SetDestinationChangeURLs(ctx context.Context, arg db.SetDestinationChangeURLsParams) (int64, error)
+	ListDestinationCheckURLs(ctx context.Context, arg db.ListDestinationCheckURLsParams) ([]db.ListDestinationCheckURLsRow, error)
+	SetDestinationCheckURLs(ctx context.Context, arg db.SetDestinationCheckURLsParams) (int64, error)
+	ListDestinationAlertURLs(ctx context.Context, arg db.ListDestinationAlertURLsParams) ([]db.ListDestinationAlertURLsRow, error)
+	SetDestinationAlertURLs(ctx context.Context, arg db.SetDestinationAlertURLsParams) (int64, error)
+	ListHTTPSUpgradeURLs(ctx context.Context, arg db.ListHTTPSUpgradeURLsParams) ([]db.ListHTTPSUpgradeURLsRow, error)
+	SetHTTPSUpgradeURLs(ctx context.Context, arg db.SetHTTPSUpgradeURLsParams) (int64, error)
+}
+
+type SnapshotQueries interface {
+	ListSnapshotLinks(ctx context.Context, arg db.ListSnapshotLinksParams) ([]db.ListSnapshotLinksRow, error)
+}
+
+type BackupQueries interface {
+	BeginBackupSnapshot(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (string, error)
+	ExportBackupTable(ctx context.Context, table db.BackupTable, fn func(row []byte) error) error
+	IsBackupTableEmpty(ctx context.Context, table db.BackupTable) (bool, error)
+	RestoreBackupRows(ctx context.Context, table db.BackupTable, rows [][]byte) error
+	ResetBackupSerial(ctx context.Context, table db.BackupTable) error
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+/*
+Transactor runs fn as a unit of work: the queries it receives are bound to one
+transaction, committed when fn returns nil and rolled back otherwise.
+
+Q is the service's own queries interface (LinkQueries, TagQueries, ...), so
+tests can pass mocks through fn without a database.
+*/
+type Transactor[Q any] interface {
+	WithTx(ctx context.Context, fn func(q Q) error) error
+}
+
+// NewTransactor adapts a db.Store to the queries interface of a service.
+// bind converts the transaction-bound *db.Queries, usually by returning it as is.
+func NewTransactor[Q any](store *db.Store, bind func(q *db.Queries) Q) Transactor[Q] {
+	return NewTransactorFunc(store.WithTx, bind)
+}
+
+// NewTransactorFunc adapts the WithTx of any store whose unit of work receives an S,
+// such as the in-memory and SQLite ones, to the queries interface of a service
+func NewTransactorFunc[S, Q any](withTx func(ctx context.Context, fn func(q S) error) error, bind func(q S) Q) Transactor[Q] {
+	return &funcTransactor[S, Q]{withTx: withTx, bind: bind}
+}
+
+type funcTransactor[S, Q any] struct {
+	withTx func(ctx context.Context, fn func(q S) error) error
+	bind   func(q S) Q
+}
+
+func (t *funcTransactor[S, Q]) WithTx(ctx context.Context, fn func(q Q) error) error {
+	return t.withTx(ctx, func(q S) error {
+		return fn(t.bind(q))
+	})
+}
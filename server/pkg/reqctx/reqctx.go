@@ -0,0 +1,166 @@
+/*
+Package reqctx holds the values middleware learns about a request (its ID, the
+client's address, the authenticated user, the validated body) in one typed
+RequestContext stored on the request's context.
+
+Middleware adds to it with the With* functions, which copy it, so a context
+never sees values set further down the chain. Readers get errors rather than
+panics when a value is missing, so a handler mounted without the middleware it
+needs fails its request instead of the process.
+*/
+package reqctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+)
+
+var (
+	// The context has no RequestContext: Middleware didn't run
+	ErrMissing = errors.New("request context not set")
+	// The request isn't authenticated: the route isn't behind auth middleware
+	ErrNoUser = errors.New("no authenticated user in request context")
+	// The route has no request validator, or it validates another type
+	ErrNoBody = errors.New("no request body in request context")
+)
+
+// RequestContext is what's known about a request. Values that don't apply to it are zero.
+type RequestContext struct {
+	RequestID string
+	ClientIP  netip.Addr
+	// Empty for anonymous requests
+	UserID string
+	// Set when the request authenticated with a service account rather than a user session
+	ServiceAccount *ServiceAccount
+	// Set when the request authenticated with an API key rather than a user session
+	APIKey *APIKey
+	// The validated request body, a DTO of the route's validator
+	Body any
+}
+
+// ServiceAccount is the service account a request authenticated with
+type ServiceAccount struct {
+	ID string
+	// The user whose links the account works on
+	OwnerID string
+	Scopes  []string
+}
+
+// APIKey is the API key a request authenticated with
+type APIKey struct {
+	ID string
+	// The user who created the key, whose links it works on
+	UserID string
+	Scopes []string
+}
+
+type contextKey struct{}
+
+// From returns the request context stored on ctx
+func From(ctx context.Context) (RequestContext, error) {
+	rc, ok := ctx.Value(contextKey{}).(RequestContext)
+	if !ok {
+		return RequestContext{}, ErrMissing
+	}
+	return rc, nil
+}
+
+// With stores rc on ctx, replacing any request context it had
+func With(ctx context.Context, rc RequestContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, rc)
+}
+
+// update stores a copy of ctx's request context (empty if it has none) changed by set
+func update(ctx context.Context, set func(*RequestContext)) context.Context {
+	rc, _ := From(ctx)
+	set(&rc)
+	return With(ctx, rc)
+}
+
+// WithUserID records the authenticated user of the request
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return update(ctx, func(rc *RequestContext) { rc.UserID = userID })
+}
+
+// WithServiceAccount records the service account the request authenticated with; its owner becomes the request's user
+func WithServiceAccount(ctx context.Context, account ServiceAccount) context.Context {
+	return update(ctx, func(rc *RequestContext) {
+		rc.UserID = account.OwnerID
+		rc.ServiceAccount = &account
+	})
+}
+
+// WithAPIKey records the API key the request authenticated with; its user becomes the request's user
+func WithAPIKey(ctx context.Context, key APIKey) context.Context {
+	return update(ctx, func(rc *RequestContext) {
+		rc.UserID = key.UserID
+		rc.APIKey = &key
+	})
+}
+
+// WithBody records the validated request body
+func WithBody(ctx context.Context, body any) context.Context {
+	return update(ctx, func(rc *RequestContext) { rc.Body = body })
+}
+
+// UserID returns the authenticated user of the request, ErrNoUser for anonymous ones
+func UserID(ctx context.Context) (string, error) {
+	rc, _ := From(ctx)
+	if rc.UserID == "" {
+		return "", ErrNoUser
+	}
+	return rc.UserID, nil
+}
+
+// ServiceAccountFrom returns the service account the request authenticated with, if any
+func ServiceAccountFrom(ctx context.Context) (ServiceAccount, bool) {
+	rc, _ := From(ctx)
+	if rc.ServiceAccount == nil {
+		return ServiceAccount{}, false
+	}
+	return *rc.ServiceAccount, true
+}
+
+// APIKeyFrom returns the API key the request authenticated with, if any
+func APIKeyFrom(ctx context.Context) (APIKey, bool) {
+	rc, _ := From(ctx)
+	if rc.APIKey == nil {
+		return APIKey{}, false
+	}
+	return *rc.APIKey, true
+}
+
+// Body returns the validated request body, ErrNoBody when there's none of type T
+func Body[T any](ctx context.Context) (T, error) {
+	rc, _ := From(ctx)
+
+	body, ok := rc.Body.(T)
+	if !ok {
+		var zero T
+		if rc.Body == nil {
+			return zero, ErrNoBody
+		}
+		return zero, fmt.Errorf("%w: the body is a %T, not a %T", ErrNoBody, rc.Body, zero)
+	}
+	return body, nil
+}
+
+// Middleware starts the request context of every request with its ID (set by chi's RequestID,
+// which must run first) and client address; X-Forwarded-For is trusted from trustedProxies only.
+func Middleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := With(r.Context(), RequestContext{
+				RequestID: chimw.GetReqID(r.Context()),
+				ClientIP:  netutil.ClientIP(r, trustedProxies),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
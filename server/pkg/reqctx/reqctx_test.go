@@ -0,0 +1,71 @@
+package reqctx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+type createLink struct{ URL string }
+
+func TestMiddleware(t *testing.T) {
+	var got RequestContext
+	h := chimw.RequestID(Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if got, err = From(r.Context()); err != nil {
+			t.Errorf("From() error = %v", err)
+		}
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.RequestID == "" {
+		t.Error("RequestID is empty, want chi's request ID")
+	}
+	if got.ClientIP != netip.MustParseAddr("203.0.113.7") {
+		t.Errorf("ClientIP = %v, want 203.0.113.7", got.ClientIP)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := From(ctx); !errors.Is(err, ErrMissing) {
+		t.Errorf("From() error = %v, want ErrMissing", err)
+	}
+	if _, err := UserID(ctx); !errors.Is(err, ErrNoUser) {
+		t.Errorf("UserID() error = %v, want ErrNoUser", err)
+	}
+	if _, err := Body[createLink](ctx); !errors.Is(err, ErrNoBody) {
+		t.Errorf("Body() error = %v, want ErrNoBody", err)
+	}
+
+	base := With(ctx, RequestContext{RequestID: "req-1"})
+	withUser := WithUserID(base, "user_1")
+	withBody := WithBody(withUser, createLink{URL: "https://example.com"})
+
+	if userID, err := UserID(withBody); err != nil || userID != "user_1" {
+		t.Errorf("UserID() = %q, %v, want user_1", userID, err)
+	}
+	if body, err := Body[createLink](withBody); err != nil || body.URL != "https://example.com" {
+		t.Errorf("Body() = %+v, %v, want the stored body", body, err)
+	}
+	if _, err := Body[string](withBody); !errors.Is(err, ErrNoBody) {
+		t.Errorf("Body[string]() error = %v, want ErrNoBody", err)
+	}
+	if rc, _ := From(withBody); rc.RequestID != "req-1" {
+		t.Errorf("RequestID = %q, want it kept by the With* functions", rc.RequestID)
+	}
+
+	// Copies: outer contexts don't see values set further down the chain
+	if _, err := UserID(base); !errors.Is(err, ErrNoUser) {
+		t.Errorf("UserID(base) error = %v, want ErrNoUser", err)
+	}
+}
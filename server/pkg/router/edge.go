@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/styltsou/url-shortener/server/pkg/handlers"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+// NewEdge builds the router of edge instances. Redirects of locally cached links are served
+// on the spot and the management API isn't served at all; everything else goes to the primary.
+func NewEdge(h *handlers.EdgeHandler, logger logger.Logger) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.NotFound(h.Forward)
+	r.MethodNotAllowed(h.Forward)
+
+	r.Handle("/api/*", notFoundHandler(logger))
+	r.Get("/{shortcode}", h.Redirect)
+
+	return r
+}
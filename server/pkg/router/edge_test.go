@@ -0,0 +1,91 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/handlers"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+type edgeLinks map[string]db.GetLinkForRedirectRow
+
+func (e edgeLinks) Resolve(ctx context.Context, code string) (db.GetLinkForRedirectRow, bool) {
+	link, ok := e[code]
+	return link, ok
+}
+
+type edgeClicks []service.Click
+
+func (e *edgeClicks) RecordClick(ctx context.Context, click service.Click) error {
+	*e = append(*e, click)
+	return nil
+}
+
+func TestEdge(t *testing.T) {
+	var forwarded []*http.Request
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r)
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer primary.Close()
+	primaryURL, _ := url.Parse(primary.URL)
+
+	clicks := &edgeClicks{}
+	links := edgeLinks{"abc": {OriginalUrl: "https://example.com/{shortcode}", UserID: "user_1"}}
+	h := handlers.NewEdgeHandler(links, clicks, primaryURL, createTestLogger())
+	r := reqctx.Middleware(nil)(NewEdge(h, createTestLogger()))
+
+	request := func(method, path, userAgent string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://sho.rt"+path, nil)
+		req.RemoteAddr = "203.0.113.7:4321"
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Cached links are redirected on the spot
+	w := request(http.MethodGet, "/abc", "Mozilla/5.0")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/abc" {
+		t.Errorf("GET /abc = %d to %q, want 302 to the destination", w.Code, w.Header().Get("Location"))
+	}
+	if len(*clicks) != 1 || (*clicks)[0].UserID != "user_1" || (*clicks)[0].ClientIP != netip.MustParseAddr("203.0.113.7") {
+		t.Errorf("clicks = %+v, want the redirect's", *clicks)
+	}
+	if len(forwarded) != 0 {
+		t.Errorf("forwarded %d requests, want none", len(forwarded))
+	}
+
+	// Misses, crawlers and the other routes go to the primary, on the same host
+	for _, tt := range []struct{ method, path, userAgent string }{
+		{http.MethodGet, "/missing", "Mozilla/5.0"},
+		{http.MethodGet, "/abc", "Slackbot-LinkExpanding 1.0"},
+		{http.MethodPost, "/abc", "Mozilla/5.0"},
+		{http.MethodGet, "/abc/preview", "Mozilla/5.0"},
+	} {
+		forwarded = nil
+		if w := request(tt.method, tt.path, tt.userAgent); w.Code != http.StatusTeapot {
+			t.Errorf("%s %s = %d, want the primary's response", tt.method, tt.path, w.Code)
+			continue
+		}
+		if len(forwarded) != 1 || forwarded[0].Host != "sho.rt" || forwarded[0].Header.Get("X-Forwarded-For") != "203.0.113.7" {
+			t.Errorf("%s %s forwarded %+v, want it on sho.rt for 203.0.113.7", tt.method, tt.path, forwarded)
+		}
+	}
+	if len(*clicks) != 1 {
+		t.Errorf("clicks = %d, want forwarded requests left to the primary", len(*clicks))
+	}
+
+	// No management API
+	forwarded = nil
+	if w := request(http.MethodGet, "/api/v1/links", "Mozilla/5.0"); w.Code != http.StatusNotFound || len(forwarded) != 0 {
+		t.Errorf("GET /api/v1/links = %d with %d forwarded, want a 404 on the edge", w.Code, len(forwarded))
+	}
+}
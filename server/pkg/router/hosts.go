@@ -0,0 +1,104 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+)
+
+/*
+Hosts configures host-based routing.
+
+Precedence rules:
+ 1. A request for APIHost is served by the API router
+ 2. A request for one of ShortDomains is served by the redirect router
+ 3. Any other host falls back to the side that has no host configured
+    (e.g. only ShortDomains set => unknown hosts get the API), or 404 if both are set
+
+When neither is configured, host-based routing is disabled and a single
+combined router serves both redirects and the API (compatibility mode).
+*/
+type Hosts struct {
+	ShortDomains []string
+	APIHost      string
+}
+
+func (h Hosts) enabled() bool {
+	return len(h.ShortDomains) > 0 || h.APIHost != ""
+}
+
+// newHostRouter dispatches requests to the redirect or API router based on the Host header
+func newHostRouter(hosts Hosts, redirect, api http.Handler, logger logger.Logger) http.Handler {
+	shortDomains := make(map[string]struct{}, len(hosts.ShortDomains))
+	for _, d := range hosts.ShortDomains {
+		shortDomains[normalizeHost(d)] = struct{}{}
+	}
+	apiHost := normalizeHost(hosts.APIHost)
+
+	var fallback http.Handler
+	switch {
+	case apiHost == "":
+		fallback = api
+	case len(shortDomains) == 0:
+		fallback = redirect
+	default:
+		fallback = notFoundHandler(logger)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := normalizeHost(r.Host)
+
+		if apiHost != "" && host == apiHost {
+			api.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := shortDomains[host]; ok {
+			redirect.ServeHTTP(w, r)
+			return
+		}
+
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// newCombined serves redirects and the API from a single host.
+// Only /{shortcode} and /{shortcode}/preview paths whose shortcode isn't reserved
+// go to the redirect router, so /api, /metrics etc. can never be shadowed by a link.
+func newCombined(redirect, api http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isShortcodePath(r.URL.Path) {
+			redirect.ServeHTTP(w, r)
+			return
+		}
+
+		api.ServeHTTP(w, r)
+	})
+}
+
+// isShortcodePath reports whether the path has the shape /{shortcode} or
+// /{shortcode}/preview and the shortcode is not a reserved word
+func isShortcodePath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/")
+	if !ok {
+		return false
+	}
+	code, sub, nested := strings.Cut(rest, "/")
+	if code == "" || (nested && sub != "preview") {
+		return false
+	}
+
+	return !service.IsReservedShortcode(code)
+}
+
+// normalizeHost lowercases the host and strips the port, if any
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
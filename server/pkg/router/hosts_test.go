@@ -0,0 +1,183 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+)
+
+func createTestLogger() logger.Logger {
+	log, err := logger.New("test")
+	if err != nil {
+		panic("failed to create test logger: " + err.Error())
+	}
+	return log
+}
+
+// namedHandler writes its name to the body so tests can tell which router served the request
+func namedHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(name))
+	})
+}
+
+func TestHostRouter_Precedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		hosts        Hosts
+		host         string
+		path         string
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "api host serves api",
+			hosts:        Hosts{ShortDomains: []string{"sho.rt"}, APIHost: "api.example.com"},
+			host:         "api.example.com",
+			path:         "/api/v1/links",
+			expectedBody: "api",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "api host never serves shortcodes",
+			hosts:        Hosts{ShortDomains: []string{"sho.rt"}, APIHost: "api.example.com"},
+			host:         "api.example.com",
+			path:         "/abc123",
+			expectedBody: "api",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "short domain serves redirects",
+			hosts:        Hosts{ShortDomains: []string{"sho.rt"}, APIHost: "api.example.com"},
+			host:         "sho.rt",
+			path:         "/abc123",
+			expectedBody: "redirect",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "short domain serves api paths as redirects",
+			hosts:        Hosts{ShortDomains: []string{"sho.rt"}, APIHost: "api.example.com"},
+			host:         "sho.rt",
+			path:         "/api/v1/links",
+			expectedBody: "redirect",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "host matching ignores port and case",
+			hosts:        Hosts{ShortDomains: []string{"Sho.RT"}, APIHost: "api.example.com"},
+			host:         "SHO.rt:8080",
+			path:         "/abc123",
+			expectedBody: "redirect",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown host is rejected when both sides are configured",
+			hosts:        Hosts{ShortDomains: []string{"sho.rt"}, APIHost: "api.example.com"},
+			host:         "evil.com",
+			path:         "/abc123",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown host falls back to api when only short domains are configured",
+			hosts:        Hosts{ShortDomains: []string{"sho.rt"}},
+			host:         "localhost:8080",
+			path:         "/api/v1/links",
+			expectedBody: "api",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown host falls back to redirects when only api host is configured",
+			hosts:        Hosts{APIHost: "api.example.com"},
+			host:         "sho.rt",
+			path:         "/abc123",
+			expectedBody: "redirect",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHostRouter(tt.hosts, namedHandler("redirect"), namedHandler("api"), createTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedCode)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("served by %q, want %q", w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestCombinedRouter_Precedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		expectedBody string
+	}{
+		{name: "shortcode", path: "/abc123", expectedBody: "redirect"},
+		{name: "api route", path: "/api/v1/links", expectedBody: "api"},
+		{name: "reserved word is not a shortcode", path: "/api", expectedBody: "api"},
+		{name: "reserved word is case insensitive", path: "/METRICS", expectedBody: "api"},
+		{name: "robots.txt is not a shortcode", path: "/robots.txt", expectedBody: "api"},
+		{name: "touch icon is not a shortcode", path: "/apple-touch-icon-180x180.png", expectedBody: "api"},
+		{name: "root is not a shortcode", path: "/", expectedBody: "api"},
+		{name: "nested path is not a shortcode", path: "/abc/def", expectedBody: "api"},
+		{name: "link preview", path: "/abc123/preview", expectedBody: "redirect"},
+		{name: "preview of a reserved word", path: "/api/preview", expectedBody: "api"},
+		{name: "path under a preview", path: "/abc123/preview/x", expectedBody: "api"},
+	}
+
+	h := newCombined(namedHandler("redirect"), namedHandler("api"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("path %s served by %q, want %q", tt.path, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+// Without hosts configured, the routes of the redirect router under a shortcode reach it through New
+func TestNew_CombinedShortcodeRoutes(t *testing.T) {
+	// Stands in for the redirect handlers, which the test doesn't need
+	reached := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	h := New(Handlers{}, Middlewares{Redirect: []func(http.Handler) http.Handler{reached}}, Hosts{}, createTestLogger())
+
+	tests := []struct {
+		path     string
+		redirect bool
+	}{
+		{path: "/abc123", redirect: true},
+		{path: "/abc123/preview", redirect: true},
+		{path: "/api/preview", redirect: false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Code == http.StatusNoContent; got != tt.redirect {
+			t.Errorf("GET %s served by the redirect router = %v, want %v (status %d)", tt.path, got, tt.redirect, w.Code)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package router
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// HealthCheck reports whether a dependency (Postgres, Redis, ...) is reachable
+type HealthCheck func(ctx context.Context) error
+
+/*
+Drain takes the instance out of load balancer rotation: while draining,
+readiness fails so no new traffic is routed to it, but requests keep being
+served. It is started on shutdown and with POST /drain on the internal port.
+*/
+type Drain struct {
+	draining atomic.Bool
+}
+
+func (d *Drain) Start() {
+	d.draining.Store(true)
+}
+
+func (d *Drain) Stop() {
+	d.draining.Store(false)
+}
+
+func (d *Drain) Draining() bool {
+	return d.draining.Load()
+}
+
+// NewInternal builds the router served on the internal port.
+// It exposes operational endpoints (health, metrics, config, drain, pprof) that must never
+// be reachable through the public ingress. configDump is the effective configuration,
+// secrets redacted (see config.Config.Dump).
+func NewInternal(checks map[string]HealthCheck, configDump map[string]any, drain *Drain, logger logger.Logger) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.NotFound(notFoundHandler(logger))
+	r.MethodNotAllowed(methodNotAllowedHandler(logger))
+
+	// Liveness: the process is up and serving requests
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, map[string]string{"status": "ok"})
+	})
+
+	// Readiness: all dependencies are reachable and the instance isn't draining
+	r.Get("/ready", readinessHandler(checks, drain, logger))
+
+	// Take the instance out of rotation before stopping it, and back in if the deploy is called off
+	r.Post("/drain", func(w http.ResponseWriter, r *http.Request) {
+		drain.Start()
+		logger.Info("Draining: readiness now fails")
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, map[string]string{"status": "draining"})
+	})
+	r.Delete("/drain", func(w http.ResponseWriter, r *http.Request) {
+		drain.Stop()
+		logger.Info("Drain cancelled: readiness checks resume")
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, map[string]string{"status": "ok"})
+	})
+
+	r.Handle("/metrics", expvar.Handler())
+
+	// Effective configuration after profile defaults and overrides
+	r.Get("/config", func(w http.ResponseWriter, r *http.Request) {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, configDump)
+	})
+
+	r.Mount("/debug", chimw.Profiler())
+
+	return r
+}
+
+// readinessHandler runs every health check and reports 503 if any of them fails or the instance is draining
+func readinessHandler(checks map[string]HealthCheck, drain *Drain, logger logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if drain.Draining() {
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, map[string]string{"status": "draining"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		status := http.StatusOK
+		results := make(map[string]string, len(checks))
+
+		for name, check := range checks {
+			if err := check(ctx); err != nil {
+				logger.Warn("Readiness check failed",
+					zap.String("dependency", name),
+					zap.Error(err),
+				)
+				results[name] = "unavailable"
+				status = http.StatusServiceUnavailable
+				continue
+			}
+			results[name] = "ok"
+		}
+
+		render.Status(r, status)
+		render.JSON(w, r, results)
+	}
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInternal_Drain(t *testing.T) {
+	drain := &Drain{}
+	checks := map[string]HealthCheck{
+		"postgres": func(ctx context.Context) error { return nil },
+	}
+	r := NewInternal(checks, nil, drain, createTestLogger())
+
+	request := func(method, path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+
+	if code := request(http.MethodGet, "/ready"); code != http.StatusOK {
+		t.Fatalf("GET /ready = %d, want 200", code)
+	}
+
+	if code := request(http.MethodPost, "/drain"); code != http.StatusOK {
+		t.Fatalf("POST /drain = %d, want 200", code)
+	}
+	if code := request(http.MethodGet, "/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("GET /ready while draining = %d, want 503", code)
+	}
+	// Liveness is unaffected: the instance still serves requests
+	if code := request(http.MethodGet, "/health"); code != http.StatusOK {
+		t.Errorf("GET /health while draining = %d, want 200", code)
+	}
+
+	if code := request(http.MethodDelete, "/drain"); code != http.StatusOK {
+		t.Fatalf("DELETE /drain = %d, want 200", code)
+	}
+	if code := request(http.MethodGet, "/ready"); code != http.StatusOK {
+		t.Errorf("GET /ready after cancelling the drain = %d, want 200", code)
+	}
+}
@@ -0,0 +1,251 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/assets"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/handlers"
+	"github.com/styltsou/url-shortener/server/pkg/memstore"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/service"
+	"github.com/styltsou/url-shortener/server/pkg/urlnorm"
+)
+
+// tokenAuth takes the bearer token for the user ID
+type tokenAuth struct{}
+
+func (tokenAuth) Authenticate(ctx context.Context, token string) (string, error) {
+	return token, nil
+}
+
+// newIsolationAPI serves the link and tag routes from one in-memory store shared by every user
+func newIsolationAPI(t *testing.T) http.Handler {
+	t.Helper()
+	log := createTestLogger()
+	mem := memstore.New()
+
+	policy, err := service.NewLinkPolicy(nil, nil, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("NewLinkPolicy() error = %v", err)
+	}
+	tokens := service.NewAccessTokens("isolation-test-secret")
+	linkSvc := service.NewLinkService(
+		mem,
+		repository.NewTransactorFunc(mem.WithTx, func(q *memstore.Store) repository.LinkQueries { return q }),
+		cache.NewMemory(),
+		nil,
+		tokens,
+		pagination.NewCursors(""),
+		urlnorm.New(urlnorm.Options{}),
+		0,
+		0,
+		nil,
+		policy,
+		nil,
+		nil,
+		log,
+	)
+	statsSvc := service.NewStatsService(mem.Queries, analytics.Noop{}, analytics.Noop{}, nil, tokens, nil, nil, nil, nil, log)
+	exportStore, err := assets.NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDisk() error = %v", err)
+	}
+
+	return NewAPI(Handlers{
+		Link: handlers.NewLinkHandler(linkSvc, statsSvc, service.NewTagSuggestionService(mem, log), false,
+			"https://sho.rt", "", service.NewBotShield(nil, service.BotShieldOptions{}, log),
+			service.NewShortcodeSuggester(mem.Queries, nil, service.ShortcodeSuggesterOptions{}, log), log),
+		Tag:     handlers.NewTagHandler(service.NewTagService(mem, nil, log), log),
+		Stats:   handlers.NewStatsHandler(statsSvc, service.NewExportJobs(statsSvc, exportStore, time.Minute, log), log),
+		Anomaly: handlers.NewAnomalyHandler(service.NewAnomalyService(mem.Queries, log), log),
+	}, Middlewares{Auth: tokenAuth{}}, log)
+}
+
+func call(t *testing.T, api http.Handler, userID, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+userID)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	return w
+}
+
+// decodeID reads data.id of a created resource
+func decodeID(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+		t.Fatalf("create status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Data.ID == "" {
+		t.Fatalf("failed to read the created ID: %v", err)
+	}
+	return resp.Data.ID
+}
+
+/*
+isolationBodies are valid request bodies for the routes that act on a link or tag,
+so requests reach the handler instead of failing validation. {link} and {tag} are
+replaced with the other user's link and tag IDs.
+*/
+var isolationBodies = map[string]string{
+	"PATCH /links/{id}":                       `{"visibility": "private"}`,
+	"POST /links/{id}/retire":                 `{"sunset_message": "gone"}`,
+	"POST /links/{id}/access-token":           `{}`,
+	"PUT /links/{id}/preview":                 `{"title": "taken over"}`,
+	"PUT /links/{id}/traffic-cap":             `{"daily_cap": 1, "overflow_url": "https://evil.example"}`,
+	"PUT /links/{id}/headers":                 `{"headers": {"X-Robots-Tag": "noindex"}}`,
+	"POST /links/{id}/public-stats/token":     `{}`,
+	"PUT /links/{id}/waiting-room":            `{"active": true}`,
+	"POST /links/{id}/comments":               `{"body": "hello"}`,
+	"PUT /links/{id}/dynamic":                 `{"locked": false}`,
+	"PUT /links/{id}/destination":             `{"url": "https://evil.example"}`,
+	"POST /links/{id}/destination-changes":    `{"url": "https://evil.example", "scheduled_at": "2100-01-01T00:00:00Z"}`,
+	"POST /links/{id}/tags":                   `{"tag_ids": ["{tag}"]}`,
+	"POST /links/{id}/tags/remove":            `{"tag_ids": ["{tag}"]}`,
+	"POST /links/qr-batch":                    `{"link_ids": ["{link}"]}`,
+	"POST /links/merge":                       `{"into": "{link}", "link_ids": ["{link}"]}`,
+	"PATCH /tags/{id}":                        `{"name": "renamed"}`,
+	"POST /tags/bulk-delete":                  `{"tag_ids": ["{tag}"]}`,
+	"POST /tags/{id}/restore":                 ``,
+	"PUT /links/{id}/public-stats":            ``,
+	"DELETE /links/{id}/comments/{commentID}": ``,
+}
+
+// Routes taking the other user's IDs in their body: they skip IDs that aren't the caller's instead of failing
+var isolationBodyRoutes = []string{"POST /links/qr-batch", "POST /links/merge", "POST /tags/bulk-delete"}
+
+/*
+User B sends every request that acts on a link or tag to user A's: each must fail
+(or, for the bulk routes, skip A's IDs) without revealing or changing anything of A's. Routes are read from the router,
+so new ones are covered as they're added (they need a body above if they take one).
+*/
+func TestTenantIsolation_LinksAndTags(t *testing.T) {
+	api := newIsolationAPI(t)
+	const alice, bob = "user_alice", "user_bob"
+
+	aliceTag := decodeID(t, call(t, api, alice, http.MethodPost, "/api/v1/tags/", map[string]any{"name": "alice-secret"}))
+	aliceLink := decodeID(t, call(t, api, alice, http.MethodPost, "/api/v1/links/", map[string]any{
+		"url":       "https://alice.example/secret",
+		"shortcode": "alice1",
+		"tag_ids":   []string{aliceTag},
+	}))
+	bobLink := decodeID(t, call(t, api, bob, http.MethodPost, "/api/v1/links/", map[string]any{"url": "https://bob.example"}))
+
+	replacer := strings.NewReplacer(
+		"{link}", aliceLink,
+		"{tag}", aliceTag,
+		"{shortcode}", "alice1",
+		"{commentID}", uuid.NewString(),
+		"{changeID}", uuid.NewString(),
+	)
+
+	checked := 0
+	err := chi.Walk(api.(chi.Routes), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		for _, version := range apiVersions {
+			pattern, ok := strings.CutPrefix(route, apiPrefix+version.name)
+			if !ok {
+				continue
+			}
+			key := method + " " + pattern
+
+			var path string
+			switch {
+			case strings.HasPrefix(pattern, "/links/{"):
+				path = strings.Replace(pattern, "{id}", aliceLink, 1)
+			case strings.HasPrefix(pattern, "/tags/{"):
+				path = strings.Replace(pattern, "{id}", aliceTag, 1)
+			case containsString(isolationBodyRoutes, key):
+				path = pattern
+			default:
+				continue
+			}
+			path = apiPrefix + version.name + replacer.Replace(path)
+
+			var body any
+			if raw, ok := isolationBodies[key]; ok && raw != "" {
+				body = json.RawMessage(replacer.Replace(raw))
+			} else if !ok && method != http.MethodGet && method != http.MethodDelete {
+				t.Errorf("%s: no request body in isolationBodies, add one so the route reaches its handler", key)
+				continue
+			}
+
+			checked++
+			w := call(t, api, bob, method, path, body)
+			if w.Code < 400 && !containsString(isolationBodyRoutes, key) {
+				t.Errorf("%s %s by another user: status = %d, want an error", method, path, w.Code)
+			}
+			for _, secret := range []string{"alice.example", "alice-secret", aliceLink} {
+				if strings.Contains(w.Body.String(), secret) {
+					t.Errorf("%s %s by another user: response reveals %q: %s", method, path, secret, w.Body)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk() error = %v", err)
+	}
+	if checked == 0 {
+		t.Fatal("no link or tag routes found")
+	}
+
+	// Alice's tag can't go on Bob's link, and Bob's lists don't hold anything of Alice's
+	call(t, api, bob, http.MethodPost, "/api/v1/links/"+bobLink+"/tags", map[string]any{"tag_ids": []string{aliceTag}})
+	for _, path := range []string{"/api/v1/links/", "/api/v1/tags/", "/api/v1/tags/trash"} {
+		if w := call(t, api, bob, http.MethodGet, path, nil); strings.Contains(w.Body.String(), "alice") {
+			t.Errorf("GET %s by another user reveals the user's data: %s", path, w.Body)
+		}
+	}
+
+	// Alice's link and tag are as she left them
+	w := call(t, api, alice, http.MethodGet, "/api/v1/links/alice1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET own link: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	for _, want := range []string{"https://alice.example/secret", "alice-secret", `"visibility":"public"`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("own link after the other user's requests = %s, want it to still hold %s", w.Body, want)
+		}
+	}
+	if w := call(t, api, alice, http.MethodGet, "/api/v1/tags/", nil); !strings.Contains(w.Body.String(), "alice-secret") {
+		t.Errorf("own tags after the other user's requests = %s, want alice-secret", w.Body)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
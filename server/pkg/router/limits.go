@@ -0,0 +1,86 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+)
+
+// RouteLimits holds the request size and time limits of each group of public routes.
+// A zero RequestLimits leaves its group unbounded.
+type RouteLimits struct {
+	// Shortcode redirects and lead form submissions
+	Redirect mw.RequestLimits
+	// Every API route outside the groups below
+	API mw.RequestLimits
+	// Routes taking many items in one request
+	Bulk mw.RequestLimits
+	// Stats exports and mapping snapshots, which stream for as long as the data takes
+	Export mw.RequestLimits
+}
+
+type routeGroup int
+
+const (
+	groupAPI routeGroup = iota
+	groupBulk
+	groupExport
+)
+
+// apiRouteGroups assigns versioned API routes to a group other than groupAPI,
+// keyed by method and route pattern below the version prefix
+var apiRouteGroups = map[string]routeGroup{
+	"POST /links/qr-batch":              groupBulk,
+	"POST /wrap":                        groupBulk,
+	"POST /tags/bulk-delete":            groupBulk,
+	"POST /campaigns/{id}/links":        groupBulk,
+	"POST /campaigns/{id}/links/remove": groupBulk,
+
+	"GET /links/{id}/stats/export": groupExport,
+	"GET /stats/export":            groupExport,
+	"GET /admin/snapshot":          groupExport,
+}
+
+// forGroup returns the limits of a group
+func (l RouteLimits) forGroup(g routeGroup) mw.RequestLimits {
+	switch g {
+	case groupBulk:
+		return l.Bulk
+	case groupExport:
+		return l.Export
+	default:
+		return l.API
+	}
+}
+
+// forAPI returns the picker of API limits for mw.Limit. It resolves the route
+// pattern of each request on mux, so it must run after the path is final
+// (after negotiateVersion); paths matching no route get the API limits.
+func (l RouteLimits) forAPI(mux *chi.Mux) func(r *http.Request) mw.RequestLimits {
+	return func(r *http.Request) mw.RequestLimits {
+		return l.forGroup(apiRouteGroups[apiRouteKey(mux, r)])
+	}
+}
+
+// apiRouteKey returns the method and route pattern below the version prefix of a
+// versioned API request, resolved on mux, e.g. "POST /links/qr-batch".
+// It's empty for paths matching no versioned route.
+func apiRouteKey(mux *chi.Mux, r *http.Request) string {
+	pattern := mux.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+
+	// /api/v1/links/qr-batch -> /links/qr-batch
+	rest, ok := strings.CutPrefix(pattern, apiPrefix)
+	if !ok {
+		return ""
+	}
+	_, route, _ := strings.Cut(rest, "/")
+
+	return r.Method + " /" + route
+}
+
+// forRedirect returns the picker of redirect limits for mw.Limit
+func (l RouteLimits) forRedirect(*http.Request) mw.RequestLimits {
+	return l.Redirect
+}
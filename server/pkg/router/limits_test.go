@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+)
+
+func TestAPIRouteGroups_Routed(t *testing.T) {
+	r := NewAPI(Handlers{}, Middlewares{}, createTestLogger())
+
+	for key := range apiRouteGroups {
+		method, pattern, _ := strings.Cut(key, " ")
+		path := strings.ReplaceAll(pattern, "{id}", "8f14e45f-ceea-467f-a8d4-1d1e4b5c9a10")
+
+		for _, version := range apiVersions {
+			want := apiPrefix + version.name + pattern
+			if got := r.Find(chi.NewRouteContext(), method, apiPrefix+version.name+path); got != want {
+				t.Errorf("%s routes to %q, want %q", key, got, want)
+			}
+		}
+	}
+}
+
+func TestRouteLimits_ForAPI(t *testing.T) {
+	limits := RouteLimits{
+		API:    mw.RequestLimits{MaxBodyBytes: 1 << 20, Timeout: 15 * time.Second},
+		Bulk:   mw.RequestLimits{MaxBodyBytes: 10 << 20, Timeout: time.Minute},
+		Export: mw.RequestLimits{Timeout: 5 * time.Minute},
+	}
+	pick := limits.forAPI(NewAPI(Handlers{}, Middlewares{}, createTestLogger()))
+
+	tests := []struct {
+		method string
+		path   string
+		want   mw.RequestLimits
+	}{
+		{method: http.MethodPost, path: "/api/v1/links/", want: limits.API},
+		{method: http.MethodPost, path: "/api/v1/links/qr-batch", want: limits.Bulk},
+		{method: http.MethodPost, path: "/api/v2/tags/bulk-delete", want: limits.Bulk},
+		{method: http.MethodGet, path: "/api/v1/links/abc/stats/export", want: limits.Export},
+		{method: http.MethodGet, path: "/api/v1/stats/export", want: limits.Export},
+		{method: http.MethodGet, path: "/api/v1/nope", want: limits.API},
+	}
+
+	for _, tt := range tests {
+		if got := pick(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s limits = %+v, want %+v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
@@ -7,15 +7,130 @@ import (
 	"github.com/MarceloPetrucio/go-scalar-api-reference"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
 	"github.com/styltsou/url-shortener/server/pkg/dto"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/handlers"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
 	mw "github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/routes"
+	"github.com/styltsou/url-shortener/server/pkg/throttle"
 	"go.uber.org/zap"
 )
 
-func New(linkH *handlers.LinkHandler, tagH *handlers.TagHandler, logger logger.Logger) *chi.Mux {
+// Handlers groups the HTTP handlers mounted by the public router
+type Handlers struct {
+	Link           *handlers.LinkHandler
+	Tag            *handlers.TagHandler
+	Campaign       *handlers.CampaignHandler
+	Stats          *handlers.StatsHandler
+	Conversion     *handlers.ConversionHandler
+	PublishHook    *handlers.PublishHookHandler
+	Webhook        *handlers.WebhookHandler
+	Wrap           *handlers.WrapHandler
+	Reservation    *handlers.ShortcodeReservationHandler
+	Activity       *handlers.ActivityHandler
+	Verification   *handlers.VerificationHandler
+	ServiceAccount *handlers.ServiceAccountHandler
+	Snapshot       *handlers.SnapshotHandler
+	APIKey         *handlers.APIKeyHandler
+	Anomaly        *handlers.AnomalyHandler
+	Site           *handlers.SiteHandler
+	WellKnown      *handlers.WellKnownHandler
+	// Nil when the Slack integration isn't configured
+	Slack *handlers.SlackHandler
+}
+
+// Middlewares groups extra middleware applied to one side of the public router
+type Middlewares struct {
+	// Auth verifies the session tokens of RequireAuth and OptionalAuth
+	Auth auth.Provider
+	// ServiceAccounts resolves service account tokens on API routes; nil disables service accounts
+	ServiceAccounts mw.ServiceAccountFunc
+	// APIKeys resolves the API keys sent as X-API-Key on API routes; nil disables API keys
+	APIKeys mw.APIKeyFunc
+	// Redirect wraps the shortcode redirect route
+	Redirect []func(http.Handler) http.Handler
+	// API wraps the authenticated API routes (runs after RequireAuth)
+	API []func(http.Handler) http.Handler
+	// Admin wraps the admin routes, on top of API
+	Admin []func(http.Handler) http.Handler
+	// Expensive wraps exports and stats aggregation with the given weight; nil runs them unthrottled
+	Expensive func(weight int64) func(http.Handler) http.Handler
+	// Limits bounds the body size and handling time of each group of routes
+	Limits RouteLimits
+}
+
+// expensive wraps an expensive route, if throttling is configured
+func (m Middlewares) expensive(weight int64) func(http.Handler) http.Handler {
+	if m.Expensive == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return m.Expensive(weight)
+}
+
+/*
+New builds the public router.
+
+When short domains and an API host are configured, requests are dispatched by
+Host header: short domains only serve redirects and the API host only serves
+the management API. Otherwise (single-host deployments, local development) both
+are served from one router, where API routes take precedence over shortcodes.
+*/
+func New(h Handlers, mws Middlewares, hosts Hosts, logger logger.Logger) http.Handler {
+	redirectRouter := NewRedirect(h, mws, logger)
+	apiRouter := NewAPI(h, mws, logger)
+
+	if !hosts.enabled() {
+		return newCombined(redirectRouter, apiRouter)
+	}
+
+	return newHostRouter(hosts, redirectRouter, apiRouter, logger)
+}
+
+// NewRedirect builds the router served on short domains.
+// It only resolves shortcodes; every other path is a 404.
+func NewRedirect(h Handlers, mws Middlewares, logger logger.Logger) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.NotFound(notFoundHandler(logger))
+	r.MethodNotAllowed(methodNotAllowedHandler(logger))
+
+	r.Use(mw.Limit(mws.Limits.forRedirect, logger))
+
+	// Outside the redirect middleware so they never count as shortcode misses
+	siteRoutes(r, h)
+
+	// Sessions are optional here: they only matter for private links
+	r.Group(func(r chi.Router) {
+		r.Use(mws.Redirect...)
+		r.Use(mw.OptionalAuth(mws.Auth, logger))
+
+		r.Get("/{shortcode}", h.Link.Redirect)
+		// What a link leads to and whether its sender is verified, without following it
+		r.Get("/{shortcode}/preview", h.Link.PublicPreview)
+		// Click counts of links whose owner made them public, or shared with a signed token
+		r.Get("/{shortcode}+", h.Stats.PublicLinkStats)
+		// Lead form submissions on email-gated links
+		r.Post("/{shortcode}", h.Link.CaptureLead)
+	})
+
+	return r
+}
+
+// siteRoutes serves robots.txt, /.well-known/ and the icons and manifests browsers request on their own
+func siteRoutes(r chi.Router, h Handlers) {
+	r.Get("/.well-known/*", h.WellKnown.Serve)
+	r.Get("/robots.txt", h.Site.Robots)
+	r.Get("/favicon.ico", h.Site.NoisePath)
+	r.Get(`/{icon:apple-touch-icon[A-Za-z0-9-]*\.png}`, h.Site.NoisePath)
+	r.Get("/sitemap.xml", h.Site.NoisePath)
+	r.Get("/browserconfig.xml", h.Site.NoisePath)
+	r.Get("/site.webmanifest", h.Site.NoisePath)
+}
+
+// NewAPI builds the router for the management API
+func NewAPI(h Handlers, mws Middlewares, logger logger.Logger) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Set custom NotFound handler
@@ -24,15 +139,14 @@ func New(linkH *handlers.LinkHandler, tagH *handlers.TagHandler, logger logger.L
 	// Set custom MethodNotAllowed handler
 	r.MethodNotAllowed(methodNotAllowedHandler(logger))
 
-	r.Get("/{code}", linkH.Redirect)
+	// Unversioned paths (/api/links) are served by the negotiated version
+	r.Use(negotiateVersion(apiVersions, defaultAPIVersion))
 
-	r.Get("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
-		render.Status(r, http.StatusOK)
-		render.JSON(w, r, map[string]string{
-			"status":  "ok",
-			"service": "URL Shortener API",
-		})
-	})
+	// Limits are picked by route group, so they need the negotiated path
+	r.Use(mw.Limit(mws.Limits.forAPI(r), logger))
+
+	// Reserved paths reach this router when redirects and the API share a host
+	siteRoutes(r, h)
 
 	r.Get("/api/v1/reference", func(w http.ResponseWriter, r *http.Request) {
 		htmlContent, err := scalar.ApiReferenceHTML(&scalar.Options{
@@ -67,33 +181,207 @@ func New(linkH *handlers.LinkHandler, tagH *handlers.TagHandler, logger logger.L
 		fmt.Fprint(w, htmlContent)
 	})
 
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(mw.RequireAuth(logger))
+	// Slack authenticates slash commands with a request signature instead of a session
+	if h.Slack != nil {
+		r.Post("/integrations/slack/commands", h.Slack.Command)
+	}
 
-		r.Route("/links", func(r chi.Router) {
-			r.With(mw.RequestValidator[dto.CreateLink](logger)).Post("/", linkH.CreateLink)
-			r.Get("/", linkH.ListLinks)
-			r.Get("/{shortcode}", linkH.GetLink)
-			r.With(mw.RequestValidator[dto.UpdateLink](logger)).Patch("/{id}", linkH.UpdateLink)
-			r.Delete("/{id}", linkH.DeleteLink)
+	// CMSes authenticate with the publish hook's own token instead of a session
+	r.With(mw.RequestValidator[dto.PublishEvent](logger)).Post("/integrations/publish-hook", h.PublishHook.Publish)
 
-			// Tag assignment endpoints
-			r.With(mw.RequestValidator[dto.AddTagsToLink](logger)).Post("/{id}/tags", linkH.AddTagsToLink)
-			r.With(mw.RequestValidator[dto.RemoveTagsFromLink](logger)).Post("/{id}/tags/remove", linkH.RemoveTagsFromLink)
-		})
+	// Monitoring opens and closes a link's waiting room with the room's webhook token
+	r.With(mw.RequestValidator[dto.WaitingRoomEvent](logger)).Post("/integrations/waiting-room", h.Link.WaitingRoomWebhook)
 
-		r.Route("/tags", func(r chi.Router) {
-			r.Get("/", tagH.ListTags)
-			r.With(mw.RequestValidator[dto.CreateTag](logger)).Post("/", tagH.CreateTag)
-			r.With(mw.RequestValidator[dto.DeleteTags](logger)).Post("/bulk-delete", tagH.DeleteTags)
-			r.With(mw.RequestValidator[dto.UpdateTag](logger)).Patch("/{id}", tagH.UpdateTag)
-			r.Delete("/{id}", tagH.DeleteTag)
+	// Service accounts and API keys are limited to the routes their scopes cover, looked up by route pattern
+	routeScope := scopeFor(r)
+
+	// Every version gets the same middleware; only its routes differ
+	for i, version := range apiVersions {
+		r.Route(apiPrefix+version.name, func(r chi.Router) {
+			r.Use(versionHeaders(version, successorVersion(apiVersions, i)))
+			if mws.ServiceAccounts != nil {
+				r.Use(mw.ServiceAccountAuth(mws.ServiceAccounts, routeScope, logger))
+			}
+			if mws.APIKeys != nil {
+				r.Use(mw.APIKeyAuth(mws.APIKeys, routeScope, logger))
+			}
+			r.Use(mw.RequireAuth(mws.Auth, logger))
+			r.Use(mws.API...)
+
+			version.routes(r, h, mws, logger)
 		})
-	})
+	}
 
 	return r
 }
 
+// v1Routes registers the routes of API version 1 (mounted under /api/v1, behind auth)
+func v1Routes(r chi.Router, h Handlers, mws Middlewares, logger logger.Logger) {
+	// Link responses carry _links built from the routes.Link* patterns
+	r.Route(routes.Links, func(r chi.Router) {
+		r.With(mw.RequestValidator[dto.CreateLink](logger)).Post("/", h.Link.CreateLink)
+		r.Get("/", h.Link.ListLinks)
+		r.Get("/suggest-tags", h.Link.SuggestTags)
+		r.With(mw.RequestValidator[dto.QRBatch](logger)).Post("/qr-batch", h.Link.QRBatch)
+		r.Get("/changes", h.Link.ListLinkChanges)
+		r.Get("/duplicates", h.Link.ListDuplicateLinks)
+		r.Get("/https-upgrades", h.Link.ListHTTPSUpgrades)
+		r.Get("/destination-alerts", h.Link.ListDestinationAlerts)
+		r.With(mw.RequestValidator[dto.MergeLinks](logger)).Post("/merge", h.Link.MergeLinks)
+		r.Get(routes.Link, h.Link.GetLink)
+		r.With(mw.RequestValidator[dto.UpdateLink](logger)).Patch(routes.LinkByID, h.Link.UpdateLink)
+		r.Delete(routes.LinkByID, h.Link.DeleteLink)
+		r.With(mw.RequestValidator[dto.RetireLink](logger)).Post("/{id}/retire", h.Link.RetireLink)
+		r.With(mw.RequestValidator[dto.CreateAccessToken](logger)).Post("/{id}/access-token", h.Link.CreateAccessToken)
+		r.Get("/{id}/leads", h.Link.ListLeads)
+		r.Get("/{id}/preview", h.Link.GetPreview)
+		r.With(mw.RequestValidator[dto.SetLinkPreview](logger)).Put("/{id}/preview", h.Link.SetPreview)
+		r.Delete("/{id}/preview", h.Link.DeletePreview)
+		r.Get("/{id}/traffic-cap", h.Link.GetTrafficCap)
+		r.With(mw.RequestValidator[dto.SetTrafficCap](logger)).Put("/{id}/traffic-cap", h.Link.SetTrafficCap)
+		r.Delete("/{id}/traffic-cap", h.Link.DeleteTrafficCap)
+		r.Get("/{id}/headers", h.Link.GetResponseHeaders)
+		r.With(mw.RequestValidator[dto.SetResponseHeaders](logger)).Put("/{id}/headers", h.Link.SetResponseHeaders)
+		r.Delete("/{id}/headers", h.Link.DeleteResponseHeaders)
+		r.With(mws.expensive(throttle.WeightStats)).Get("/{id}/stats", h.Stats.LinkStats)
+		r.Get("/{id}/public-stats", h.Link.GetPublicStats)
+		r.Put("/{id}/public-stats", h.Link.EnablePublicStats)
+		r.Delete("/{id}/public-stats", h.Link.DisablePublicStats)
+		r.With(mw.RequestValidator[dto.CreateAccessToken](logger)).Post("/{id}/public-stats/token", h.Link.CreatePublicStatsToken)
+		r.Get("/{id}/waiting-room", h.Link.GetWaitingRoom)
+		r.With(mw.RequestValidator[dto.SetWaitingRoom](logger)).Put("/{id}/waiting-room", h.Link.SetWaitingRoom)
+		r.Delete("/{id}/waiting-room", h.Link.DeleteWaitingRoom)
+		r.Get("/{id}/comments", h.Link.ListComments)
+		r.With(mw.RequestValidator[dto.CreateLinkComment](logger)).Post("/{id}/comments", h.Link.AddComment)
+		r.Delete("/{id}/comments/{commentID}", h.Link.DeleteComment)
+		r.Get("/{id}/dynamic", h.Link.GetDynamicLink)
+		r.With(mw.RequestValidator[dto.SetDynamicLink](logger)).Put("/{id}/dynamic", h.Link.SetDynamicLink)
+		r.Delete("/{id}/dynamic", h.Link.DeleteDynamicLink)
+		r.With(mw.RequestValidator[dto.ChangeDestination](logger)).Put("/{id}/destination", h.Link.ChangeDestination)
+		r.Get("/{id}/destination-changes", h.Link.ListDestinationChanges)
+		r.With(mw.RequestValidator[dto.ScheduleDestinationChange](logger)).Post("/{id}/destination-changes", h.Link.ScheduleDestinationChange)
+		r.Delete("/{id}/destination-changes/{changeID}", h.Link.CancelDestinationChange)
+		r.Get(routes.LinkQR, h.Link.QRCode)
+		r.Get("/{id}/anomalies", h.Anomaly.ListLinkAnomalies)
+		r.With(mws.expensive(throttle.WeightExport)).Get(routes.LinkStats, h.Stats.ExportLinkStats)
+
+		// Tag assignment endpoints
+		r.With(mw.RequestValidator[dto.AddTagsToLink](logger)).Post("/{id}/tags", h.Link.AddTagsToLink)
+		r.With(mw.RequestValidator[dto.RemoveTagsFromLink](logger)).Post("/{id}/tags/remove", h.Link.RemoveTagsFromLink)
+	})
+
+	// Default-settings shortening for the browser extension
+	r.With(mw.RequestValidator[dto.QuickShorten](logger)).Post("/quick-shorten", h.Link.QuickShorten)
+
+	// Email link wrapping, for click tracking
+	r.With(mw.RequestValidator[dto.WrapLinks](logger)).Post("/wrap", h.Wrap.Wrap)
+
+	// Shortcodes held before their link exists; creating a link with one attaches its destination
+	r.Route("/shortcodes", func(r chi.Router) {
+		r.With(mw.RequestValidator[dto.ReserveShortcode](logger)).Post("/reserve", h.Reservation.Reserve)
+		r.Get("/reserved", h.Reservation.ListReservations)
+		r.Delete("/reserved/{shortcode}", h.Reservation.Release)
+	})
+
+	r.Route("/tags", func(r chi.Router) {
+		r.Get("/", h.Tag.ListTags)
+		r.With(mw.RequestValidator[dto.CreateTag](logger)).Post("/", h.Tag.CreateTag)
+		r.With(mw.RequestValidator[dto.DeleteTags](logger)).Post("/bulk-delete", h.Tag.DeleteTags)
+		r.Get("/trash", h.Tag.ListDeletedTags)
+		r.Post("/{id}/restore", h.Tag.RestoreTag)
+		r.With(mw.RequestValidator[dto.UpdateTag](logger)).Patch("/{id}", h.Tag.UpdateTag)
+		r.Delete("/{id}", h.Tag.DeleteTag)
+		r.With(mws.expensive(throttle.WeightStats)).Get("/{id}/stats", h.Stats.TagStats)
+	})
+
+	r.Route("/campaigns", func(r chi.Router) {
+		r.Get("/", h.Campaign.ListCampaigns)
+		r.With(mw.RequestValidator[dto.CreateCampaign](logger)).Post("/", h.Campaign.CreateCampaign)
+		r.Get("/{id}", h.Campaign.GetCampaign)
+		r.With(mw.RequestValidator[dto.UpdateCampaign](logger)).Patch("/{id}", h.Campaign.UpdateCampaign)
+		r.Delete("/{id}", h.Campaign.DeleteCampaign)
+		r.With(mws.expensive(throttle.WeightStats)).Get("/{id}/stats", h.Stats.CampaignStats)
+
+		// Link attachment endpoints
+		r.Get("/{id}/links", h.Campaign.ListCampaignLinks)
+		r.With(mw.RequestValidator[dto.AddLinksToCampaign](logger)).Post("/{id}/links", h.Campaign.AddLinksToCampaign)
+		r.With(mw.RequestValidator[dto.RemoveLinksFromCampaign](logger)).Post("/{id}/links/remove", h.Campaign.RemoveLinksFromCampaign)
+	})
+
+	r.Route("/conversions", func(r chi.Router) {
+		r.With(mw.RequestValidator[dto.CreateConversion](logger)).Post("/", h.Conversion.CreateConversion)
+	})
+
+	r.Route("/activity", func(r chi.Router) {
+		r.Get("/", h.Activity.ListActivity)
+		r.With(mws.expensive(throttle.WeightStats)).Get("/verify", h.Activity.VerifyActivity)
+	})
+
+	r.Route("/stats", func(r chi.Router) {
+		r.With(mws.expensive(throttle.WeightExport)).Get("/export", h.Stats.ExportAccountStats)
+		r.Get("/top-links", h.Stats.TopLinks)
+	})
+
+	r.Route("/exports", func(r chi.Router) {
+		r.Get("/{id}", h.Stats.GetExport)
+	})
+
+	r.Route("/integrations/publish-hooks", func(r chi.Router) {
+		r.Get("/", h.PublishHook.ListHooks)
+		r.With(mw.RequestValidator[dto.CreatePublishHook](logger)).Post("/", h.PublishHook.CreateHook)
+		r.Delete("/{id}", h.PublishHook.DeleteHook)
+	})
+
+	// Keys for scripts, sent as X-API-Key on the routes their scopes cover
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Get("/", h.APIKey.ListAPIKeys)
+		r.With(mw.RequestValidator[dto.CreateAPIKey](logger)).Post("/", h.APIKey.CreateAPIKey)
+		r.Delete("/{id}", h.APIKey.RevokeAPIKey)
+		r.Get("/{id}/usage", h.APIKey.GetUsage)
+		r.With(mw.RequestValidator[dto.RotateAPIKey](logger)).Post("/{id}/rotate", h.APIKey.RotateAPIKey)
+		r.Delete("/{id}/previous-key", h.APIKey.RevokePreviousAPIKey)
+	})
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", h.Webhook.ListWebhooks)
+		r.With(mw.RequestValidator[dto.CreateWebhook](logger)).Post("/", h.Webhook.CreateWebhook)
+		r.Delete("/{id}", h.Webhook.DeleteWebhook)
+		r.With(mw.RequestValidator[dto.RotateWebhookSecret](logger)).Post("/{id}/rotate-secret", h.Webhook.RotateSecret)
+		r.Post("/{id}/test", h.Webhook.TestWebhook)
+		r.Get("/{id}/deliveries", h.Webhook.ListDeliveries)
+		r.Post("/{id}/deliveries/{deliveryID}/retry", h.Webhook.RetryDelivery)
+	})
+
+	if h.Slack != nil {
+		r.Route("/integrations/slack", func(r chi.Router) {
+			r.With(mw.RequestValidator[dto.LinkSlackAccount](logger)).Post("/link", h.Slack.LinkAccount)
+		})
+	}
+
+	// Limited to the users in ADMIN_USER_IDS
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(mws.Admin...)
+
+		r.Route("/verified-senders", func(r chi.Router) {
+			r.Get("/", h.Verification.ListVerifiedSenders)
+			r.With(mw.RequestValidator[dto.VerifySender](logger)).Put("/", h.Verification.VerifySender)
+			r.Delete("/{kind}/{value}", h.Verification.UnverifySender)
+		})
+
+		r.Route("/service-accounts", func(r chi.Router) {
+			r.Get("/", h.ServiceAccount.ListServiceAccounts)
+			r.With(mw.RequestValidator[dto.CreateServiceAccount](logger)).Post("/", h.ServiceAccount.CreateServiceAccount)
+			r.With(mw.RequestValidator[dto.UpdateServiceAccount](logger)).Patch("/{id}", h.ServiceAccount.UpdateServiceAccount)
+			r.Delete("/{id}", h.ServiceAccount.DeleteServiceAccount)
+			r.Get("/{id}/usage", h.ServiceAccount.GetUsage)
+			r.With(mw.RequestValidator[dto.RotateServiceAccountToken](logger)).Post("/{id}/rotate-token", h.ServiceAccount.RotateToken)
+			r.Delete("/{id}/previous-token", h.ServiceAccount.RevokePreviousToken)
+		})
+
+		r.With(mws.expensive(throttle.WeightExport)).Get("/snapshot", h.Snapshot.ExportSnapshot)
+	})
+}
+
 // notFoundHandler returns a handler for 404 Not Found errors
 func notFoundHandler(logger logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dto"
+	"github.com/styltsou/url-shortener/server/pkg/routes"
+)
+
+// Every _links relation of a link resource reaches the route it names, with its method
+func TestLinkRelations_Routed(t *testing.T) {
+	r := NewAPI(Handlers{}, Middlewares{}, createTestLogger())
+
+	for _, version := range apiVersions {
+		api := apiPrefix + version.name
+		link := dto.NewLinkResponse(db.TryCreateLinkRow{ID: uuid.New(), Shortcode: "abc123"}, dto.LinkURLs{API: api})
+
+		tests := []struct {
+			rel     string
+			target  dto.Hyperlink
+			pattern string
+		}{
+			{rel: "self", target: link.Links.Self, pattern: routes.Link},
+			{rel: "edit", target: link.Links.Edit, pattern: routes.LinkByID},
+			{rel: "stats", target: link.Links.Stats, pattern: routes.LinkStats},
+			{rel: "qr", target: link.Links.QR, pattern: routes.LinkQR},
+		}
+
+		for _, tt := range tests {
+			method := tt.target.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			want := api + routes.Links + tt.pattern
+			if got := r.Find(chi.NewRouteContext(), method, tt.target.Href); got != want {
+				t.Errorf("%s: %s %s routes to %q, want %q", tt.rel, method, tt.target.Href, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+)
+
+// apiRouteScopes assigns versioned API routes the scope a service account or API
+// key needs to call them, keyed like apiRouteGroups. Routes missing here are
+// closed to both: the admin routes, routes minting credentials (access and
+// share tokens), lead data, and integrations and webhooks, which belong to users.
+var apiRouteScopes = map[string]string{
+	"GET /links/":                         auth.ScopeLinksRead,
+	"GET /links/changes":                  auth.ScopeLinksRead,
+	"GET /links/destination-alerts":       auth.ScopeLinksRead,
+	"GET /links/duplicates":               auth.ScopeLinksRead,
+	"GET /links/https-upgrades":           auth.ScopeLinksRead,
+	"GET /links/suggest-tags":             auth.ScopeLinksRead,
+	"POST /links/qr-batch":                auth.ScopeLinksRead,
+	"GET /links/{shortcode}":              auth.ScopeLinksRead,
+	"GET /links/{id}/comments":            auth.ScopeLinksRead,
+	"GET /links/{id}/destination-changes": auth.ScopeLinksRead,
+	"GET /links/{id}/dynamic":             auth.ScopeLinksRead,
+	"GET /links/{id}/headers":             auth.ScopeLinksRead,
+	"GET /links/{id}/preview":             auth.ScopeLinksRead,
+	"GET /links/{id}/qr":                  auth.ScopeLinksRead,
+	"GET /links/{id}/traffic-cap":         auth.ScopeLinksRead,
+	"GET /links/{id}/waiting-room":        auth.ScopeLinksRead,
+
+	"POST /links/":        auth.ScopeLinksCreate,
+	"POST /quick-shorten": auth.ScopeLinksCreate,
+	"POST /wrap":          auth.ScopeLinksCreate,
+
+	"PATCH /links/{id}":                                 auth.ScopeLinksWrite,
+	"DELETE /links/{id}":                                auth.ScopeLinksWrite,
+	"POST /links/merge":                                 auth.ScopeLinksWrite,
+	"POST /links/{id}/retire":                           auth.ScopeLinksWrite,
+	"POST /links/{id}/comments":                         auth.ScopeLinksWrite,
+	"DELETE /links/{id}/comments/{commentID}":           auth.ScopeLinksWrite,
+	"PUT /links/{id}/destination":                       auth.ScopeLinksWrite,
+	"POST /links/{id}/destination-changes":              auth.ScopeLinksWrite,
+	"DELETE /links/{id}/destination-changes/{changeID}": auth.ScopeLinksWrite,
+	"PUT /links/{id}/dynamic":                           auth.ScopeLinksWrite,
+	"DELETE /links/{id}/dynamic":                        auth.ScopeLinksWrite,
+	"PUT /links/{id}/headers":                           auth.ScopeLinksWrite,
+	"DELETE /links/{id}/headers":                        auth.ScopeLinksWrite,
+	"PUT /links/{id}/preview":                           auth.ScopeLinksWrite,
+	"DELETE /links/{id}/preview":                        auth.ScopeLinksWrite,
+	"PUT /links/{id}/public-stats":                      auth.ScopeLinksWrite,
+	"DELETE /links/{id}/public-stats":                   auth.ScopeLinksWrite,
+	"PUT /links/{id}/traffic-cap":                       auth.ScopeLinksWrite,
+	"DELETE /links/{id}/traffic-cap":                    auth.ScopeLinksWrite,
+	"PUT /links/{id}/waiting-room":                      auth.ScopeLinksWrite,
+	"DELETE /links/{id}/waiting-room":                   auth.ScopeLinksWrite,
+	"POST /links/{id}/tags":                             auth.ScopeLinksWrite,
+	"POST /links/{id}/tags/remove":                      auth.ScopeLinksWrite,
+
+	"GET /links/{id}/stats/export": auth.ScopeStatsRead,
+	"GET /links/{id}/anomalies":    auth.ScopeStatsRead,
+	"GET /links/{id}/public-stats": auth.ScopeStatsRead,
+	"GET /stats/export":            auth.ScopeStatsRead,
+	"GET /stats/top-links":         auth.ScopeStatsRead,
+	"GET /tags/{id}/stats":         auth.ScopeStatsRead,
+	"GET /campaigns/{id}/stats":    auth.ScopeStatsRead,
+	"GET /exports/{id}":            auth.ScopeStatsRead,
+
+	"GET /tags/": auth.ScopeTagsRead,
+
+	"POST /tags/":            auth.ScopeTagsWrite,
+	"POST /tags/bulk-delete": auth.ScopeTagsWrite,
+	"PATCH /tags/{id}":       auth.ScopeTagsWrite,
+	"DELETE /tags/{id}":      auth.ScopeTagsWrite,
+}
+
+// scopeFor returns the picker of route scopes for mw.ServiceAccountAuth and
+// mw.APIKeyAuth. Like RouteLimits.forAPI, it resolves the route pattern of each
+// request on mux.
+func scopeFor(mux *chi.Mux) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return apiRouteScopes[apiRouteKey(mux, r)]
+	}
+}
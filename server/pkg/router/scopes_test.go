@@ -0,0 +1,196 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
+)
+
+// Every scoped route exists, so a renamed route doesn't silently close it to service accounts
+func TestAPIRouteScopes_Routed(t *testing.T) {
+	r := NewAPI(Handlers{}, Middlewares{}, createTestLogger())
+
+	for key := range apiRouteScopes {
+		method, pattern, _ := strings.Cut(key, " ")
+		path := strings.NewReplacer("{id}", "8f14e45f-ceea-467f-a8d4-1d1e4b5c9a10", "{shortcode}", "abc123").Replace(pattern)
+
+		for _, version := range apiVersions {
+			want := apiPrefix + version.name + pattern
+			if got := r.Find(chi.NewRouteContext(), method, apiPrefix+version.name+path); got != want {
+				t.Errorf("%s routes to %q, want %q", key, got, want)
+			}
+		}
+	}
+}
+
+func TestScopeFor(t *testing.T) {
+	pick := scopeFor(NewAPI(Handlers{}, Middlewares{}, createTestLogger()))
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: http.MethodPost, path: "/api/v1/links/", want: auth.ScopeLinksCreate},
+		{method: http.MethodGet, path: "/api/v1/links/abc123", want: auth.ScopeLinksRead},
+		{method: http.MethodPatch, path: "/api/v1/links/8f14e45f-ceea-467f-a8d4-1d1e4b5c9a10", want: auth.ScopeLinksWrite},
+		{method: http.MethodGet, path: "/api/v1/stats/export", want: auth.ScopeStatsRead},
+		{method: http.MethodGet, path: "/api/v1/admin/service-accounts/", want: ""},
+		{method: http.MethodGet, path: "/api/v1/nope", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := pick(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s scope = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// Every route of every version is checked with a service account holding each scope:
+// only its mapped scope gets through, and unmapped routes are closed to all of them
+func TestServiceAccountScopes_Enforced(t *testing.T) {
+	accounts := func(ctx context.Context, token string, clientIP netip.Addr) (reqctx.ServiceAccount, error) {
+		scope, ok := strings.CutPrefix(token, auth.ServiceAccountTokenPrefix)
+		if !ok || !slices.Contains(auth.Scopes, scope) {
+			return reqctx.ServiceAccount{}, apperrors.InvalidServiceAccountToken
+		}
+		return reqctx.ServiceAccount{ID: "sa_1", OwnerID: "user_123", Scopes: []string{scope}}, nil
+	}
+	// Stands in for the handlers, which the test doesn't need
+	reached := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	r := NewAPI(Handlers{}, Middlewares{
+		ServiceAccounts: accounts,
+		API:             []func(http.Handler) http.Handler{reached},
+	}, createTestLogger())
+
+	// Served outside the authenticated group
+	public := map[string]bool{"GET /reference": true}
+	param := regexp.MustCompile(`\{[^}]+\}`)
+
+	checked := 0
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		rest, ok := strings.CutPrefix(route, apiPrefix)
+		if !ok {
+			return nil
+		}
+		_, pattern, _ := strings.Cut(rest, "/")
+		key := method + " /" + pattern
+		if public[key] {
+			return nil
+		}
+		checked++
+
+		path := param.ReplaceAllString(route, "8f14e45f-ceea-467f-a8d4-1d1e4b5c9a10")
+		for _, scope := range auth.Scopes {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+auth.ServiceAccountTokenPrefix+scope)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			want := http.StatusForbidden
+			if apiRouteScopes[key] == scope {
+				want = http.StatusNoContent
+			}
+			if w.Code != want {
+				t.Errorf("%s %s with %s: status = %d, want %d", method, route, scope, w.Code, want)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if checked == 0 {
+		t.Fatal("no API routes checked")
+	}
+}
+
+// Every scope opens some route, and routes only use known scopes
+func TestAPIRouteScopes_Known(t *testing.T) {
+	used := map[string]bool{}
+	for key, scope := range apiRouteScopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			t.Errorf("%s has unknown scope %q", key, scope)
+		}
+		used[scope] = true
+	}
+	for _, scope := range auth.Scopes {
+		if !used[scope] {
+			t.Errorf("scope %q opens no route", scope)
+		}
+	}
+}
+
+// API keys are held to the same route scopes as service accounts
+func TestAPIKeyScopes_Enforced(t *testing.T) {
+	keys := func(ctx context.Context, key string, clientIP netip.Addr) (reqctx.APIKey, error) {
+		scope, ok := strings.CutPrefix(key, "ak_")
+		if !ok || !slices.Contains(auth.Scopes, scope) {
+			return reqctx.APIKey{}, apperrors.InvalidAPIKey
+		}
+		return reqctx.APIKey{ID: "key_1", UserID: "user_123", Scopes: []string{scope}}, nil
+	}
+	reached := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	r := NewAPI(Handlers{}, Middlewares{
+		APIKeys: keys,
+		API:     []func(http.Handler) http.Handler{reached},
+	}, createTestLogger())
+
+	public := map[string]bool{"GET /reference": true}
+	param := regexp.MustCompile(`\{[^}]+\}`)
+
+	checked := 0
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		rest, ok := strings.CutPrefix(route, apiPrefix)
+		if !ok {
+			return nil
+		}
+		_, pattern, _ := strings.Cut(rest, "/")
+		key := method + " /" + pattern
+		if public[key] {
+			return nil
+		}
+		checked++
+
+		path := param.ReplaceAllString(route, "8f14e45f-ceea-467f-a8d4-1d1e4b5c9a10")
+		for _, scope := range auth.Scopes {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("X-API-Key", "ak_"+scope)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			want := http.StatusForbidden
+			if apiRouteScopes[key] == scope {
+				want = http.StatusNoContent
+			}
+			if w.Code != want {
+				t.Errorf("%s %s with an API key for %s: status = %d, want %d", method, route, scope, w.Code, want)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if checked == 0 {
+		t.Fatal("no API routes checked")
+	}
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/routes"
+)
+
+const (
+	// apiPrefix is the common prefix of every versioned API path: /api/v1, /api/v2, ...
+	apiPrefix = routes.APIPrefix
+	// Media type for picking a version through the Accept header: application/vnd.url-shortener.v2+json
+	versionMediaTypePrefix = "application/vnd.url-shortener."
+	// Response header naming the version that served the request
+	versionHeader = "API-Version"
+)
+
+/*
+apiVersion is one major version of the management API, mounted under /api/<name>.
+
+A new version is added when a response shape has to change in a breaking way
+(error format, renamed fields, ...): its routes start as a copy of the previous
+version's and diverge from there, while clients of the old version keep working.
+Once a version is deprecated, its responses carry Deprecation, Sunset and
+successor-version Link headers (RFC 9745, RFC 8594, RFC 5829).
+*/
+type apiVersion struct {
+	name   string
+	routes func(r chi.Router, h Handlers, mws Middlewares, logger logger.Logger)
+	// Preview versions may still change in breaking ways
+	preview bool
+	// Zero when not deprecated
+	deprecatedAt time.Time
+	sunsetAt     time.Time
+}
+
+func (v apiVersion) deprecated() bool {
+	return !v.deprecatedAt.IsZero()
+}
+
+// apiVersions lists the mounted API versions, oldest first.
+// defaultAPIVersion is served to clients that don't ask for one.
+var (
+	apiVersions = []apiVersion{
+		{name: "v1", routes: v1Routes},
+		// v2 is the home of upcoming breaking changes; it serves the v1 routes until they diverge
+		{name: "v2", routes: v1Routes, preview: true},
+	}
+	defaultAPIVersion = "v1"
+)
+
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+/*
+negotiateVersion routes unversioned API requests (/api/links) to a version:
+the one named in the Accept header (application/vnd.url-shortener.v2+json)
+if it is mounted, else the default one. A version in the path always wins
+over the Accept header.
+*/
+func negotiateVersion(versions []apiVersion, fallback string) func(http.Handler) http.Handler {
+	mounted := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		mounted[v.name] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rest, ok := strings.CutPrefix(r.URL.Path, apiPrefix)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			first, _, _ := strings.Cut(rest, "/")
+			if versionSegment.MatchString(first) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			version := fallback
+			if requested := acceptedVersion(r.Header.Get("Accept")); mounted[requested] {
+				version = requested
+			}
+
+			r.URL.Path = apiPrefix + version + "/" + rest
+			r.URL.RawPath = ""
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acceptedVersion returns the version named by a vendor media type in the Accept header, or ""
+func acceptedVersion(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		rest, ok := strings.CutPrefix(mediaType, versionMediaTypePrefix)
+		if !ok {
+			continue
+		}
+
+		version, _, _ := strings.Cut(rest, "+")
+		if versionSegment.MatchString(version) {
+			return version
+		}
+	}
+
+	return ""
+}
+
+// versionHeaders marks responses with the version that served them and, for
+// deprecated versions, when it goes away and what replaces it
+func versionHeaders(v apiVersion, successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(versionHeader, v.name)
+
+			if v.deprecated() {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(v.deprecatedAt.Unix(), 10))
+				if !v.sunsetAt.IsZero() {
+					w.Header().Set("Sunset", v.sunsetAt.UTC().Format(http.TimeFormat))
+				}
+				if successor != "" {
+					w.Header().Add("Link", "<"+apiPrefix+successor+">; rel=\"successor-version\"")
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// successorVersion returns the first stable version mounted after versions[i], or ""
+func successorVersion(versions []apiVersion, i int) string {
+	for _, v := range versions[i+1:] {
+		if !v.preview {
+			return v.name
+		}
+	}
+	return ""
+}
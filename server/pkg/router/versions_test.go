@@ -0,0 +1,121 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newVersionedRouter mounts a handler echoing the version and path under each version
+func newVersionedRouter(versions []apiVersion, fallback string) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(negotiateVersion(versions, fallback))
+
+	for i, version := range versions {
+		r.Route(apiPrefix+version.name, func(r chi.Router) {
+			r.Use(versionHeaders(version, successorVersion(versions, i)))
+			r.Get("/links", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.URL.Path))
+			})
+		})
+	}
+
+	return r
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	versions := []apiVersion{{name: "v1"}, {name: "v2", preview: true}}
+	h := newVersionedRouter(versions, "v1")
+
+	tests := []struct {
+		name            string
+		path            string
+		accept          string
+		expectedPath    string
+		expectedVersion string
+	}{
+		{name: "versioned path", path: "/api/v2/links", expectedPath: "/api/v2/links", expectedVersion: "v2"},
+		{name: "unversioned path uses the default", path: "/api/links", expectedPath: "/api/v1/links", expectedVersion: "v1"},
+		{
+			name:            "unversioned path honors Accept",
+			path:            "/api/links",
+			accept:          "text/html, application/vnd.url-shortener.v2+json;q=0.9",
+			expectedPath:    "/api/v2/links",
+			expectedVersion: "v2",
+		},
+		{
+			name:            "unknown version in Accept falls back to the default",
+			path:            "/api/links",
+			accept:          "application/vnd.url-shortener.v9+json",
+			expectedPath:    "/api/v1/links",
+			expectedVersion: "v1",
+		},
+		{
+			name:            "path wins over Accept",
+			path:            "/api/v1/links",
+			accept:          "application/vnd.url-shortener.v2+json",
+			expectedPath:    "/api/v1/links",
+			expectedVersion: "v1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if w.Body.String() != tt.expectedPath {
+				t.Errorf("served path = %q, want %q", w.Body.String(), tt.expectedPath)
+			}
+			if got := w.Header().Get(versionHeader); got != tt.expectedVersion {
+				t.Errorf("%s = %q, want %q", versionHeader, got, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+func TestVersionHeaders_Deprecated(t *testing.T) {
+	deprecatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	versions := []apiVersion{
+		{name: "v1", deprecatedAt: deprecatedAt, sunsetAt: sunsetAt},
+		{name: "v2"},
+		{name: "v3", preview: true},
+	}
+	h := newVersionedRouter(versions, "v1")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Deprecation = %q, want %q", got, "@1767225600")
+	}
+	if got := w.Header().Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v2>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+
+	// Current versions carry no deprecation headers
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/links", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Deprecation"); got != "" {
+		t.Errorf("Deprecation = %q for a current version, want none", got)
+	}
+}
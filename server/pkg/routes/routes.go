@@ -0,0 +1,52 @@
+/*
+Package routes holds the patterns of the API routes that responses link to.
+The router registers those routes with these patterns and hypermedia links
+(_links) are built from the same strings, so the URLs in responses can't drift
+from the routes that serve them.
+*/
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// APIPrefix is the common prefix of every versioned API path: /api/v1, /api/v2, ...
+const APIPrefix = "/api/"
+
+// Links is the mount of the link routes within an API version; the Link* patterns are relative to it
+const (
+	Links = "/links"
+
+	Link      = "/{shortcode}"
+	LinkByID  = "/{id}"
+	LinkStats = "/{id}/stats/export"
+	LinkQR    = "/{id}/qr"
+)
+
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// Version returns the mount of the API version serving r (e.g. /api/v1), read from its path.
+// It's empty outside the versioned API.
+func Version(r *http.Request) string {
+	rest, ok := strings.CutPrefix(r.URL.Path, APIPrefix)
+	if !ok {
+		return ""
+	}
+
+	version, _, _ := strings.Cut(rest, "/")
+	if !versionSegment.MatchString(version) {
+		return ""
+	}
+	return APIPrefix + version
+}
+
+// Path fills the {name} parameters of pattern. params are name, value pairs; values are path-escaped.
+func Path(pattern string, params ...string) string {
+	for i := 0; i+1 < len(params); i += 2 {
+		pattern = strings.ReplaceAll(pattern, "{"+params[i]+"}", url.PathEscape(params[i+1]))
+	}
+	return pattern
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/v1/links", want: "/api/v1"},
+		{path: "/api/v2", want: "/api/v2"},
+		{path: "/api/links", want: ""},
+		{path: "/abc123", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := Version(httptest.NewRequest("GET", tt.path, nil)); got != tt.want {
+			t.Errorf("Version(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	if got := Path(LinkStats, "id", "42"); got != "/42/stats/export" {
+		t.Errorf("Path(LinkStats) = %q, want /42/stats/export", got)
+	}
+	if got := Path(Link, "shortcode", "a/b c"); got != "/a%2Fb%20c" {
+		t.Errorf("Path(Link) = %q, want /a%%2Fb%%20c", got)
+	}
+}
@@ -4,21 +4,45 @@ package server
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/assets"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
 	"github.com/styltsou/url-shortener/server/pkg/config"
 	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/dnscache"
+	"github.com/styltsou/url-shortener/server/pkg/geoip"
 	"github.com/styltsou/url-shortener/server/pkg/handlers"
+	"github.com/styltsou/url-shortener/server/pkg/httpclient"
+	"github.com/styltsou/url-shortener/server/pkg/i18n"
+	"github.com/styltsou/url-shortener/server/pkg/loadshed"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/memstore"
 	"github.com/styltsou/url-shortener/server/pkg/middleware"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+	"github.com/styltsou/url-shortener/server/pkg/objectstore"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/reqctx"
 	"github.com/styltsou/url-shortener/server/pkg/router"
 	"github.com/styltsou/url-shortener/server/pkg/service"
+	"github.com/styltsou/url-shortener/server/pkg/sqlitestore"
+	"github.com/styltsou/url-shortener/server/pkg/throttle"
+	"github.com/styltsou/url-shortener/server/pkg/urlnorm"
 	"go.uber.org/zap"
 )
 
@@ -27,35 +51,714 @@ type Server struct {
 	Context     context.Context
 	Pool        *pgxpool.Pool
 	RedisClient *redis.Client
-	Router      *chi.Mux
-	Logger      logger.Logger
+	// Redirect cache and create dedupe of links; nil when running without a cache
+	Cache          cache.Cache
+	Router         *chi.Mux
+	InternalRouter *chi.Mux // health, metrics and pprof; served on the internal port only
+	Logger         logger.Logger
+	// Fails readiness while the instance is taken out of rotation
+	Drain *router.Drain
+
+	// Stops the background jobs
+	stopJobs context.CancelFunc
+	// Batches clicks on their way to ClickHouse; written out on close
+	clickBuffer *analytics.Buffer
+	// Running click counts of links; flushed on close
+	clickCounter *service.ClickCounter
+	// Sends the clicks an edge instance serves to the primary; drained on close
+	edgeClicks *service.EdgeClicks
+	// Delivers link events to webhooks; the deliveries being sent are waited for on close
+	webhooks *service.WebhookService
+	// In-process Redis of the in-memory storage backend
+	miniRedis *miniredis.Miniredis
+	// Links and tags of the SQLite storage backend
+	sqlite *sqlitestore.Store
 }
 
 // New creates and initializes a new Server instance
 // It automatically connects to the database and mounts handlers
 // Logger and config should be initialized in the caller (main.go)
 func New(config *config.Config, log logger.Logger) (*Server, error) {
-	clerk.SetKey(config.ClerkSecretKey)
-
 	s := &Server{
 		Context: context.Background(),
 		Router:  chi.NewRouter(),
 		Logger:  log,
 	}
 
-	pool, pgErr := pgxpool.New(s.Context, config.PostgresConnectionString)
+	// Edge instances don't connect to storage, see newEdge
+	if config.ServerMode == "edge" {
+		if err := s.newEdge(config); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	// SQLite and memory only hold links and tags; in memory mode Redis is an in-process server too
+	var mem *memstore.Store
+	redisURL := config.RedisURL
+	switch config.StorageBackend {
+	case "memory":
+		mem = memstore.New()
+		miniRedis, err := miniredis.Run()
+		if err != nil {
+			return nil, fmt.Errorf("failed to start in-process Redis: %w", err)
+		}
+		s.miniRedis = miniRedis
+		redisURL = miniRedis.Addr()
+		log.Warn("Running on in-memory storage, data is lost on restart")
+	case "sqlite":
+		lite, err := sqlitestore.Open(s.Context, config.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		s.sqlite = lite
+		log.Info("SQLite opened successfully",
+			zap.String("sqlite_path", config.SQLitePath),
+		)
+	default:
+		pool, pgErr := db.NewPool(s.Context, config.PostgresConnectionString)
 
-	if pgErr != nil {
-		return nil, fmt.Errorf("failed to create Postgres pool: %w", pgErr)
+		if pgErr != nil {
+			return nil, fmt.Errorf("failed to create Postgres pool: %w", pgErr)
+		}
+		s.Pool = pool
+		log.Info("Postgres connected successfully",
+			zap.String("pg_connection_str", config.PostgresConnectionString),
+		)
 	}
-	s.Pool = pool
-	log.Info("Postgres connected successfully",
-		zap.String("pg_connection_str", config.PostgresConnectionString),
-	)
 
 	// Try to connect to Redis, but don't fail if it's unavailable (degraded mode)
+	if err := s.connectRedis(config, redisURL); err != nil {
+		log.Warn("Redis connection failed, running without cache",
+			zap.Error(err),
+			zap.String("redis_url", redisURL),
+		)
+	}
+
+	// The in-memory backend keeps its cache in the process too; otherwise it's shared through
+	// Redis, and the keys it invalidates are published to edge instances' local caches
+	switch {
+	case mem != nil:
+		s.Cache = cache.NewMemory()
+	case s.RedisClient != nil:
+		s.Cache = cache.NewBroadcast(cache.NewRedis(s.RedisClient), s.RedisClient)
+	}
+
+	// Services run single queries through the store and multi-statement units of work with store.WithTx
+	var store *db.Store
+	var queries *db.Queries
+	checks := map[string]router.HealthCheck{}
+	switch {
+	case mem != nil:
+		queries = mem.Queries
+	case s.sqlite != nil:
+		queries = s.sqlite.Queries
+		checks["sqlite"] = s.sqlite.Ping
+	default:
+		var conn interface {
+			db.DBTX
+			db.TxBeginner
+		} = s.Pool
+		if config.PostgresRowLevelSecurity {
+			conn = db.RowSecurity(conn)
+			log.Info("API queries are scoped by row-level security")
+		}
+
+		keyring, err := config.URLEncryptionKeyring()
+		if err != nil {
+			return nil, err
+		}
+		if keyring != nil {
+			conn = db.Encrypted(conn, keyring)
+			log.Info("Destination URLs are encrypted at rest",
+				zap.String("active_key", keyring.ActiveKeyID()),
+			)
+		}
+		store = db.NewStore(conn)
+		queries = store.Queries
+		checks["postgres"] = s.Pool.Ping
+	}
+	log.Info("Storage backend selected",
+		zap.String("backend", config.StorageBackend),
+	)
+
+	var clicks analytics.Store
+	var clickStats analytics.StatsReader
+	// Leaderboards are reconciled with ClickHouse only, when it's the analytics backend
+	var clicksByLink analytics.ClicksByLinkReader
+	switch config.AnalyticsBackend {
+	case analytics.BackendClickHouse:
+		clickHouse := s.newClickHouse(config)
+		checks["clickhouse"] = clickHouse.Check
+		clicks = s.newClickBuffer(config, clickHouse)
+		clickStats = clickHouse
+		clicksByLink = clickHouse
+	case analytics.BackendNone:
+		clicks = analytics.Noop{}
+		clickStats = analytics.Noop{}
+	default:
+		clicks = analytics.NewPostgresStore(queries)
+		// Postgres holds every click even while double-writing, so stats are read from it
+		clickStats = analytics.NewPostgresStats(queries)
+		// ClickHouse gets new clicks too while history is copied to it with cmd/backfill
+		if config.AnalyticsDoubleWrite {
+			clickHouse := s.newClickHouse(config)
+			checks["clickhouse"] = clickHouse.Check
+			clicks = analytics.NewDoubleWrite(clicks, s.newClickBuffer(config, clickHouse), s.Logger)
+		}
+	}
+	log.Info("Analytics backend selected",
+		zap.String("backend", config.AnalyticsBackend),
+		zap.Bool("double_write", config.AnalyticsDoubleWrite),
+	)
+
+	var countries *geoip.Countries
+	if config.GeoIPCountryDB != "" {
+		loaded, err := geoip.Open(config.GeoIPCountryDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load country database: %w", err)
+		}
+		countries = loaded
+		log.Info("Country database loaded",
+			zap.String("path", config.GeoIPCountryDB),
+			zap.Int("ranges", countries.Len()),
+		)
+	}
+
+	resolver := dnscache.New(dnscache.Options{
+		MaxTTL:      time.Duration(config.DNSCacheMaxTTL) * time.Second,
+		NegativeTTL: time.Duration(config.DNSCacheNegativeTTL) * time.Second,
+	})
+	webhookSvc := service.NewWebhookService(queries, httpclient.New(httpclient.Options{
+		Name:         "webhook",
+		Timeout:      service.WebhookDeliveryTimeout,
+		AllowPrivate: config.OutboundAllowPrivate,
+		Resolver:     resolver,
+	}), service.WebhookOptions{
+		DisableAfter: time.Duration(config.WebhookDisableAfterDays) * 24 * time.Hour,
+	}, s.Logger)
+	s.webhooks = webhookSvc
+	// Webhooks are kept in Postgres only, so link events go nowhere without it
+	var linkWebhooks *service.WebhookService
+	if store != nil {
+		linkWebhooks = webhookSvc
+	}
+
+	linkTokens := service.NewAccessTokens(config.LinkTokenSecret)
+	visitors := analytics.NewVisitors(config.VisitorIDSecret)
+	// Click counts are kept in Postgres only
+	if config.ClickCountFlushInterval > 0 && store != nil {
+		s.clickCounter = service.NewClickCounter(queries, s.RedisClient, s.Logger)
+	}
+	var leaderboard *service.Leaderboard
+	if s.RedisClient != nil {
+		leaderboard = service.NewLeaderboard(queries, s.RedisClient, clicksByLink, s.Logger)
+	}
+	statsSvc := service.NewStatsService(queries, clicks, clickStats, visitors, linkTokens, countries, s.clickCounter, leaderboard, linkWebhooks, s.Logger)
+	var assetStore assets.Store
+	switch config.AssetsStorage {
+	case "s3":
+		// The bucket is the operator's, so it may be on the local network
+		client, err := objectstore.New(config.AssetsS3Storage(), httpclient.New(httpclient.Options{
+			Name:             "assets",
+			Timeout:          assets.TransferTimeout,
+			MaxResponseBytes: assets.MaxBytes,
+			AllowPrivate:     true,
+			Resolver:         resolver,
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("invalid assets storage: %w", err)
+		}
+		assetStore = assets.NewS3(client, config.AssetsPrefix)
+	default:
+		dir := config.AssetsDir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "url-shortener-assets")
+		}
+		disk, err := assets.NewDisk(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assets storage: %w", err)
+		}
+		assetStore = disk
+	}
+	exportJobs := service.NewExportJobs(statsSvc, assetStore, time.Duration(config.AssetsDownloadURLTTL)*time.Second, s.Logger)
+	statsHandler := handlers.NewStatsHandler(statsSvc, exportJobs, s.Logger)
+
+	// Checks users' destinations, so internal addresses are only reached when allowed
+	reachability := service.NewReachabilityChecker(httpclient.New(httpclient.Options{
+		Name:         "reachability",
+		Timeout:      service.ReachabilityTimeout,
+		Retries:      -1,
+		AllowPrivate: config.OutboundAllowPrivate,
+		Resolver:     resolver,
+	}))
+
+	var authProvider auth.Provider
+	switch config.AuthProvider {
+	case auth.ProviderOIDC:
+		// The issuer is the operator's, so it may well be an internal identity server
+		authProvider = auth.NewOIDC(auth.OIDCOptions{
+			Issuer:      config.OIDCIssuerURL,
+			Audience:    config.OIDCAudience,
+			UserIDClaim: config.OIDCUserIDClaim,
+		}, httpclient.New(httpclient.Options{
+			Name:         "oidc",
+			AllowPrivate: true,
+			Resolver:     resolver,
+		}))
+	default:
+		authProvider = auth.NewClerk(config.ClerkSecretKey)
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(s.Context)
+	exportJobs.StartCleanup(jobsCtx, time.Duration(config.AssetsCleanupInterval)*time.Minute)
+	s.stopJobs = stopJobs
+	if config.StatsRollupInterval > 0 && store != nil {
+		statsRollup := service.NewStatsRollup(
+			repository.NewTransactor(store, func(q *db.Queries) repository.StatsRollupQueries { return q }),
+			s.Logger,
+		)
+		statsRollup.Start(jobsCtx, time.Duration(config.StatsRollupInterval)*time.Minute)
+	}
+	if config.AnomalyCheckInterval > 0 && config.AnalyticsBackend == analytics.BackendPostgres && store != nil {
+		var notifier service.AnomalyNotifier
+		if config.AnomalyWebhookURL != "" {
+			// The URL is the operator's, so it may well be an internal alerting service
+			notifier = service.NewAnomalyWebhook(config.AnomalyWebhookURL, httpclient.New(httpclient.Options{
+				Name:         "anomaly_webhook",
+				Timeout:      service.AnomalyWebhookTimeout,
+				AllowPrivate: true,
+				Resolver:     resolver,
+			}))
+		}
+		anomalyDetector := service.NewAnomalyDetector(
+			repository.NewTransactor(store, func(q *db.Queries) repository.AnomalyDetectorQueries { return q }),
+			queries,
+			notifier,
+			service.AnomalyOptions{
+				Window:    config.AnomalyWindowHours,
+				Threshold: config.AnomalyZThreshold,
+				MinClicks: config.AnomalyMinClicks,
+			},
+			s.Logger,
+		)
+		anomalyDetector.Start(jobsCtx, time.Duration(config.AnomalyCheckInterval)*time.Minute)
+	}
+
+	if s.clickCounter != nil {
+		s.clickCounter.Start(jobsCtx, time.Duration(config.ClickCountFlushInterval)*time.Second)
+	}
+
+	if leaderboard != nil && clicksByLink != nil && store != nil {
+		leaderboard.Start(jobsCtx)
+	}
+
+	if config.TrafficCapSyncInterval > 0 && s.RedisClient != nil && store != nil {
+		trafficCapSync := service.NewTrafficCapSync(queries, s.RedisClient, s.Logger)
+		trafficCapSync.Start(jobsCtx, time.Duration(config.TrafficCapSyncInterval)*time.Second)
+	}
+
+	if config.DestinationScheduleInterval > 0 && store != nil {
+		destinationScheduler := service.NewDestinationScheduler(queries, s.Cache, s.Logger)
+		destinationScheduler.Start(jobsCtx, time.Duration(config.DestinationScheduleInterval)*time.Second)
+	}
+
+	if config.HTTPSUpgradeInterval > 0 && store != nil {
+		httpsUpgrade := service.NewHTTPSUpgradeJob(queries, reachability, s.Cache, service.HTTPSUpgradeOptions{
+			Apply:        config.HTTPSUpgradeApply,
+			RecheckAfter: time.Duration(config.HTTPSUpgradeRecheckDays) * 24 * time.Hour,
+		}, s.Logger)
+		httpsUpgrade.Start(jobsCtx, time.Duration(config.HTTPSUpgradeInterval)*time.Minute)
+	}
+
+	if config.DestinationMonitorInterval > 0 && store != nil {
+		destinationMonitor := service.NewDestinationMonitor(queries, reachability,
+			time.Duration(config.DestinationRecheckHours)*time.Hour, s.Logger)
+		destinationMonitor.Start(jobsCtx, time.Duration(config.DestinationMonitorInterval)*time.Minute)
+	}
+
+	if config.ActivityVerifyInterval > 0 && store != nil {
+		activityVerifier := service.NewActivityChainVerifier(queries, s.Logger)
+		activityVerifier.Start(jobsCtx, time.Duration(config.ActivityVerifyInterval)*time.Minute)
+	}
+
+	if config.WebhookRetryInterval > 0 && store != nil {
+		webhookSvc.Start(jobsCtx, time.Duration(config.WebhookRetryInterval)*time.Second)
+	}
+
+	if config.BackupS3Bucket != "" && config.BackupInterval > 0 && store != nil {
+		// The bucket is the operator's, so it may be on the local network
+		backupStorage, err := objectstore.New(config.BackupStorage(), httpclient.New(httpclient.Options{
+			Name:             "backup",
+			Timeout:          service.BackupTransferTimeout,
+			MaxResponseBytes: service.MaxBackupBytes,
+			AllowPrivate:     true,
+			Resolver:         resolver,
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup storage: %w", err)
+		}
+		backups := service.NewBackupService(
+			repository.NewTransactor(store, func(q *db.Queries) repository.BackupQueries { return q }),
+			backupStorage,
+			service.BackupOptions{
+				Prefix:        config.BackupPrefix,
+				Interval:      time.Duration(config.BackupInterval) * time.Hour,
+				Retention:     time.Duration(config.BackupRetentionDays) * 24 * time.Hour,
+				IncludeClicks: config.BackupIncludeClicks,
+			},
+			s.Logger,
+		)
+		backups.Start(jobsCtx)
+	}
+
+	trustedProxies, err := netutil.ParsePrefixes(config.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	normalizer := urlnorm.New(urlnorm.Options{
+		StripParams: config.URLStripParams,
+		SortParams:  config.URLSortQueryParams,
+	})
+	var linkQueries repository.LinkQueries = queries
+	var linkTx repository.Transactor[repository.LinkQueries]
+	var tagQueries repository.TagQueries = queries
+	var tagSuggestionQueries repository.TagSuggestionQueries = queries
+	switch {
+	case mem != nil:
+		linkQueries, tagQueries, tagSuggestionQueries = mem, mem, mem
+		linkTx = repository.NewTransactorFunc(mem.WithTx, func(q *memstore.Store) repository.LinkQueries { return q })
+	case s.sqlite != nil:
+		linkQueries, tagQueries, tagSuggestionQueries = s.sqlite, s.sqlite, s.sqlite
+		linkTx = repository.NewTransactorFunc(s.sqlite.WithTx, func(q *sqlitestore.Store) repository.LinkQueries { return q })
+	default:
+		linkTx = repository.NewTransactor(store, func(q *db.Queries) repository.LinkQueries { return q })
+	}
+	if config.PaginationSecret == "" {
+		log.Info("PAGINATION_SECRET is not set, cursors are signed with a random key and won't survive a restart")
+	}
+	linkPolicy, err := service.NewLinkPolicy(
+		config.LinkPolicyAllowedDomains,
+		config.LinkPolicyDeniedDomains,
+		config.LinkPolicyForbiddenCodes,
+		config.LinkPolicyRequiredTags,
+		time.Duration(config.LinkPolicyMaxExpiryDays)*24*time.Hour,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_POLICY_FORBIDDEN_SHORTCODES: %w", err)
+	}
+	tagPolicy := service.NewTagPolicy(config.TagQuota, config.TagSlugs)
+	linkSvc := service.NewLinkService(
+		linkQueries,
+		linkTx,
+		s.Cache,
+		s.RedisClient,
+		linkTokens,
+		pagination.NewCursors(config.PaginationSecret),
+		normalizer,
+		time.Duration(config.CreateDedupeWindow)*time.Second,
+		int64(config.LinkQuota),
+		reachability,
+		linkPolicy,
+		tagPolicy,
+		linkWebhooks,
+		s.Logger,
+	)
+	tagSuggestionSvc := service.NewTagSuggestionService(tagSuggestionQueries, s.Logger)
+	shortURLBase := config.ShortURLBase()
+	datacenterRanges, err := netutil.ParsePrefixes(config.BotShieldDatacenterCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BOT_SHIELD_DATACENTER_CIDRS: %w", err)
+	}
+	botShield := service.NewBotShield(s.RedisClient, service.BotShieldOptions{
+		Secret:           config.BotShieldSecret,
+		DatacenterRanges: datacenterRanges,
+		RateLimit:        int64(config.BotShieldRateLimit),
+		RateWindow:       time.Duration(config.BotShieldRateWindow) * time.Second,
+		PassTTL:          time.Duration(config.BotShieldPassTTL) * time.Minute,
+		TrustedProxies:   trustedProxies,
+	}, s.Logger)
+	if !botShield.Enabled() {
+		log.Info("Bot shield disabled, BOT_SHIELD_SECRET is not set")
+	}
+	suggester := service.NewShortcodeSuggester(queries, s.RedisClient, service.ShortcodeSuggesterOptions{
+		Enabled:        config.DidYouMeanEnabled,
+		IncludePublic:  config.DidYouMeanPublic,
+		RateLimit:      int64(config.DidYouMeanRateLimit),
+		RateWindow:     time.Duration(config.DidYouMeanRateWindow) * time.Second,
+		TrustedProxies: trustedProxies,
+	}, s.Logger)
+	if config.DidYouMeanEnabled && !suggester.Enabled() {
+		log.Info("Shortcode suggestions disabled, they need Redis")
+	}
+	linkHandler := handlers.NewLinkHandler(linkSvc, statsSvc, tagSuggestionSvc, config.AutoTagLinks, shortURLBase, config.ReservedPlaceholderURL, botShield, suggester, s.Logger)
+
+	tagSvc := service.NewTagService(tagQueries, tagPolicy, s.Logger)
+	tagHandler := handlers.NewTagHandler(tagSvc, s.Logger)
+
+	campaignSvc := service.NewCampaignService(queries, s.Logger)
+	campaignHandler := handlers.NewCampaignHandler(campaignSvc, s.Logger)
+
+	conversionSvc := service.NewConversionService(queries, s.Logger)
+	conversionHandler := handlers.NewConversionHandler(conversionSvc, s.Logger)
+
+	publishHookSvc := service.NewPublishHookService(queries, httpclient.New(httpclient.Options{
+		Name:         "publish_callback",
+		Timeout:      service.PublishCallbackTimeout,
+		AllowPrivate: config.OutboundAllowPrivate,
+		Resolver:     resolver,
+	}), s.Logger)
+	publishHookHandler := handlers.NewPublishHookHandler(publishHookSvc, linkSvc, shortURLBase, s.Logger)
+
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc, s.Logger)
+
+	wrapHandler := handlers.NewWrapHandler(linkSvc, campaignSvc, shortURLBase, s.Logger)
+
+	activitySvc := service.NewActivityService(queries, s.Logger)
+	activityHandler := handlers.NewActivityHandler(activitySvc, s.Logger)
+
+	anomalySvc := service.NewAnomalyService(queries, s.Logger)
+	anomalyHandler := handlers.NewAnomalyHandler(anomalySvc, s.Logger)
+
+	reservationSvc := service.NewShortcodeReservationService(queries, s.Logger)
+	reservationHandler := handlers.NewShortcodeReservationHandler(reservationSvc, shortURLBase, s.Logger)
+
+	verificationSvc := service.NewVerificationService(queries, s.Logger)
+	verificationHandler := handlers.NewVerificationHandler(verificationSvc, s.Logger)
+
+	serviceAccountSvc := service.NewServiceAccountService(queries, s.Logger)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(serviceAccountSvc, s.Logger)
+
+	snapshotSvc := service.NewSnapshotService(queries, s.Logger)
+	snapshotHandler := handlers.NewSnapshotHandler(snapshotSvc, s.Logger)
+	// Service account tokens authenticate API requests as the account's owner, within its scopes
+	serviceAccounts := func(ctx context.Context, token string, clientIP netip.Addr) (reqctx.ServiceAccount, error) {
+		account, err := serviceAccountSvc.Authenticate(ctx, token, clientIP)
+		if err != nil {
+			return reqctx.ServiceAccount{}, err
+		}
+		return reqctx.ServiceAccount{
+			ID:      account.ID.String(),
+			OwnerID: account.OwnerID,
+			Scopes:  account.Scopes,
+		}, nil
+	}
+
+	apiKeySvc := service.NewAPIKeyService(queries, s.Logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc, s.Logger)
+	// API keys authenticate API requests as the key's owner, within its scopes
+	apiKeys := func(ctx context.Context, key string, clientIP netip.Addr) (reqctx.APIKey, error) {
+		apiKey, err := apiKeySvc.Authenticate(ctx, key, clientIP)
+		if err != nil {
+			return reqctx.APIKey{}, err
+		}
+		return reqctx.APIKey{
+			ID:     apiKey.ID.String(),
+			UserID: apiKey.UserID,
+			Scopes: apiKey.Scopes,
+		}, nil
+	}
+
+	siteHandler := handlers.NewSiteHandler(config.RobotsAllowCrawling, config.RobotsSitemapURL, config.FaviconURL, s.Logger)
+	wellKnownHandler, err := handlers.NewWellKnownHandler(config.WellKnownDir, s.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WELL_KNOWN_DIR: %w", err)
+	}
+
+	// The Slack integration is only served once a signing secret is configured
+	var slackHandler *handlers.SlackHandler
+	if config.SlackSigningSecret != "" {
+		slackSvc := service.NewSlackService(queries, config.SlackSigningSecret, s.Logger)
+		slackHandler = handlers.NewSlackHandler(slackSvc, linkSvc, config.SlackLinkURL, shortURLBase, s.Logger)
+	}
+
+	apiOrigins, err := middleware.NewOriginMatcher(config.CORSAllowedOrigins)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_ALLOWED_ORIGINS: %w", err)
+	}
+	publicOrigins, err := middleware.NewOriginMatcher(config.CORSPublicAllowedOrigins)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_PUBLIC_ALLOWED_ORIGINS: %w", err)
+	}
+
+	apiPolicy := middleware.CORSPolicy(apiOrigins, cors.Options{
+		AllowedMethods:   config.CORSAllowedMethods,
+		AllowedHeaders:   config.CORSAllowedHeaders,
+		ExposedHeaders:   config.CORSExposedHeaders,
+		AllowCredentials: config.CORSAllowCredentials,
+		MaxAge:           config.CORSMaxAge,
+	})
+	if len(config.ExtensionAllowedOrigins) > 0 {
+		extensionOrigins, err := middleware.NewOriginMatcher(slices.Concat(config.CORSAllowedOrigins, config.ExtensionAllowedOrigins))
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXTENSION_ALLOWED_ORIGINS: %w", err)
+		}
+		// The extension authenticates with a bearer token, so it never needs credentialed requests
+		extensionPolicy := middleware.CORSPolicy(extensionOrigins, cors.Options{
+			AllowedMethods: []string{http.MethodPost, http.MethodOptions},
+			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+			MaxAge:         config.CORSMaxAge,
+		})
+		apiPolicy = middleware.CORSByPath(isQuickShortenPath, extensionPolicy, apiPolicy)
+	}
+	// Redirects and the other public routes are read-only and never see credentials
+	publicPolicy := middleware.CORSPolicy(publicOrigins, cors.Options{
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		AllowedHeaders: []string{"Accept"},
+		MaxAge:         config.CORSMaxAge,
+	})
+	corsPolicy := middleware.CORSByPath(isAPIPath, apiPolicy, publicPolicy)
+	s.Router.Use(corsPolicy)
+	s.Router.Use(chimw.RequestID)
+	s.Router.Use(reqctx.Middleware(trustedProxies))
+	s.Router.Use(middleware.RequestLogger(s.Logger))
+	s.Router.Use(chimw.Recoverer)
+
+	languages, err := i18n.NewNegotiator(config.DefaultLanguage, config.DomainLanguages)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page language config: %w", err)
+	}
+
+	// Language runs first so every page of the redirect routes is translated, including the guard's
+	redirectMiddlewares := []func(http.Handler) http.Handler{
+		middleware.Language(languages),
+	}
+	if config.EnumerationGuardEnabled {
+		redirectMiddlewares = append(redirectMiddlewares, middleware.EnumerationGuard(s.RedisClient, middleware.EnumerationGuardOptions{
+			MaxNotFound:    int64(config.EnumerationMaxNotFound),
+			Window:         time.Duration(config.EnumerationWindow) * time.Second,
+			BlockDuration:  time.Duration(config.EnumerationBlockDuration) * time.Second,
+			TrustedProxies: trustedProxies,
+		}, s.Logger))
+	}
+
+	var apiMiddlewares []func(http.Handler) http.Handler
+	if config.LoadShedPoolWait > 0 || config.LoadShedGoroutines > 0 {
+		var poolStats loadshed.PoolStatsFunc
+		if s.Pool != nil {
+			poolStats = func() (int64, time.Duration) {
+				stat := s.Pool.Stat()
+				return stat.AcquireCount(), stat.EmptyAcquireWaitTime()
+			}
+		}
+		monitor := loadshed.NewMonitor(loadshed.Options{
+			MaxPoolWait:   time.Duration(config.LoadShedPoolWait) * time.Millisecond,
+			MaxGoroutines: config.LoadShedGoroutines,
+			Interval:      time.Duration(config.LoadShedInterval) * time.Millisecond,
+		}, poolStats, s.Logger)
+		monitor.Start(jobsCtx)
+
+		// First, so shed requests cost nothing else (not even a rate limit count)
+		apiMiddlewares = append(apiMiddlewares, middleware.ShedLoad(monitor.Saturated,
+			time.Duration(config.LoadShedMaxDelay)*time.Millisecond, s.Logger))
+	}
+	if config.APIRateLimit > 0 {
+		apiMiddlewares = append(apiMiddlewares, middleware.RateLimit(s.RedisClient, middleware.RateLimitOptions{
+			Limit:  int64(config.APIRateLimit),
+			Window: time.Duration(config.APIRateLimitWindow) * time.Second,
+		}, s.Logger))
+	}
+	// Before the first query, so the link quota lookup is scoped too
+	if config.PostgresRowLevelSecurity {
+		apiMiddlewares = append(apiMiddlewares, middleware.RowSecurity(s.Logger))
+	}
+	if config.LinkQuota > 0 {
+		apiMiddlewares = append(apiMiddlewares, middleware.LinkQuotaHeaders(linkSvc.LinksRemaining, s.Logger))
+	}
+	apiMiddlewares = append(apiMiddlewares, middleware.ResponseJSONStyle(middleware.JSONStyle{
+		Naming:    config.JSONFieldNaming,
+		OmitNulls: config.JSONNulls == "omit",
+	}, s.Logger))
+
+	var expensive func(weight int64) func(http.Handler) http.Handler
+	if config.ExpensiveMaxConcurrency > 0 {
+		limiter := throttle.New(throttle.Options{
+			Capacity:     int64(config.ExpensiveMaxConcurrency),
+			MaxQueue:     int64(config.ExpensiveMaxQueue),
+			QueueTimeout: time.Duration(config.ExpensiveQueueTimeout) * time.Second,
+			PerUser:      config.ExpensiveMaxPerUser,
+		})
+		expensive = func(weight int64) func(http.Handler) http.Handler {
+			return middleware.Throttle(limiter, weight, s.Logger)
+		}
+	}
+
+	publicRouter := router.New(router.Handlers{
+		Link:           linkHandler,
+		Tag:            tagHandler,
+		Campaign:       campaignHandler,
+		Stats:          statsHandler,
+		Conversion:     conversionHandler,
+		PublishHook:    publishHookHandler,
+		Webhook:        webhookHandler,
+		Wrap:           wrapHandler,
+		Reservation:    reservationHandler,
+		Activity:       activityHandler,
+		Verification:   verificationHandler,
+		ServiceAccount: serviceAccountHandler,
+		Snapshot:       snapshotHandler,
+		APIKey:         apiKeyHandler,
+		Anomaly:        anomalyHandler,
+		Site:           siteHandler,
+		WellKnown:      wellKnownHandler,
+		Slack:          slackHandler,
+	}, router.Middlewares{
+		Auth:            authProvider,
+		ServiceAccounts: serviceAccounts,
+		APIKeys:         apiKeys,
+		Redirect:        redirectMiddlewares,
+		API:             apiMiddlewares,
+		Admin:           []func(http.Handler) http.Handler{middleware.RequireAdmin(config.AdminUserIDs, s.Logger)},
+		Expensive:       expensive,
+		Limits: router.RouteLimits{
+			Redirect: middleware.RequestLimits{
+				MaxBodyBytes: config.RedirectMaxBodyBytes,
+				Timeout:      time.Duration(config.RedirectTimeout) * time.Second,
+			},
+			API: middleware.RequestLimits{
+				MaxBodyBytes: config.APIMaxBodyBytes,
+				Timeout:      time.Duration(config.APITimeout) * time.Second,
+			},
+			Bulk: middleware.RequestLimits{
+				MaxBodyBytes: config.BulkMaxBodyBytes,
+				Timeout:      time.Duration(config.BulkTimeout) * time.Second,
+			},
+			Export: middleware.RequestLimits{
+				MaxBodyBytes: config.APIMaxBodyBytes,
+				Timeout:      time.Duration(config.ExportTimeout) * time.Second,
+			},
+		},
+	}, router.Hosts{
+		ShortDomains: config.ShortDomains,
+		APIHost:      config.APIHost,
+	}, s.Logger)
+	s.Router.Mount("/", publicRouter)
+
+	if s.RedisClient != nil {
+		checks["redis"] = func(ctx context.Context) error {
+			return s.RedisClient.Ping(ctx).Err()
+		}
+	}
+
+	s.InternalRouter = chi.NewRouter()
+	s.InternalRouter.Use(chimw.Recoverer)
+	s.Drain = &router.Drain{}
+	internalRouter := router.NewInternal(checks, config.Dump(), s.Drain, s.Logger)
+	// Edge instances send the clicks they serve here, see newEdge
+	if config.EdgeToken != "" {
+		edgeClicksHandler := handlers.NewEdgeClicksHandler(statsSvc, config.EdgeToken, s.Logger)
+		internalRouter.Post(service.EdgeClicksPath, edgeClicksHandler.RecordClicks)
+	}
+	s.InternalRouter.Mount("/", internalRouter)
+
+	return s, nil
+}
+
+// connectRedis sets RedisClient once Redis at addr answers a ping, leaving it nil otherwise
+func (s *Server) connectRedis(config *config.Config, addr string) error {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:         config.RedisURL,
+		Addr:         addr,
 		Username:     config.RedisUsername,
 		Password:     config.RedisPassword,
 		DB:           config.RedisDB,
@@ -65,54 +768,142 @@ func New(config *config.Config, log logger.Logger) (*Server, error) {
 		WriteTimeout: time.Duration(config.RedisWriteTimeout) * time.Second,
 	})
 
-	// TODO: Need to understand this better
 	// Ping Redis with a timeout to avoid hanging
 	pingCtx, cancel := context.WithTimeout(s.Context, 3*time.Second)
 	defer cancel()
 
 	if err := rdb.Ping(pingCtx).Err(); err != nil {
-		s.RedisClient = nil
-		log.Warn("Redis connection failed, running without cache",
+		rdb.Close()
+		return err
+	}
+
+	s.RedisClient = rdb
+	s.Logger.Info("Redis connected successfully",
+		zap.String("redis_url", addr),
+	)
+	return nil
+}
+
+// newClickHouse connects the ClickHouse analytics store, applying its migrations unless
+// CLICKHOUSE_MIGRATE is off. Clicks are written once the schema check passes; the
+// returned gate's Check is the readiness check that re-runs it.
+func (s *Server) newClickHouse(config *config.Config) *analytics.SchemaGate {
+	clickHouse := analytics.NewClickHouseStore(analytics.ClickHouseOptions{
+		URL:      config.ClickhouseURL,
+		Username: config.ClickhouseUsername,
+		Password: config.ClickhousePassword,
+		Database: config.ClickhouseDatabase,
+	})
+
+	if config.ClickhouseMigrate {
+		migrateCtx, cancel := context.WithTimeout(s.Context, 30*time.Second)
+		applied, err := clickHouse.Migrate(migrateCtx)
+		cancel()
+		if err != nil {
+			s.Logger.Error("ClickHouse migrations failed",
+				zap.Error(err),
+				zap.Strings("applied", applied),
+			)
+		} else if len(applied) > 0 {
+			s.Logger.Info("ClickHouse migrations applied",
+				zap.Strings("applied", applied),
+			)
+		}
+	}
+
+	gate := analytics.NewSchemaGate(clickHouse, clickHouse.CheckSchema)
+	checkCtx, cancel := context.WithTimeout(s.Context, 3*time.Second)
+	defer cancel()
+	if err := gate.Check(checkCtx); err != nil {
+		s.Logger.Error("ClickHouse schema check failed, analytics writes disabled until it passes",
 			zap.Error(err),
-			zap.String("redis_url", config.RedisURL),
-		)
-	} else {
-		s.RedisClient = rdb
-		log.Info("Redis connected successfully",
-			zap.String("redis_url", config.RedisURL),
 		)
 	}
 
-	queries := db.New(s.Pool)
-	linkSvc := service.NewLinkService(queries, s.RedisClient, s.Logger)
-	linkHandler := handlers.NewLinkHandler(linkSvc, s.Logger)
-
-	tagSvc := service.NewTagService(queries, s.Logger)
-	tagHandler := handlers.NewTagHandler(tagSvc, s.Logger)
+	return gate
+}
 
-	s.Router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   config.CORSAllowedOrigins,
-		AllowedMethods:   config.CORSAllowedMethods,
-		AllowedHeaders:   config.CORSAllowedHeaders,
-		ExposedHeaders:   config.CORSExposedHeaders,
-		AllowCredentials: config.CORSAllowCredentials,
-		MaxAge:           config.CORSMaxAge,
-	}))
-	s.Router.Use(chimw.RequestID)
-	s.Router.Use(middleware.RequestLogger(s.Logger))
-	s.Router.Use(chimw.Recoverer)
+// newClickBuffer batches the clicks written to store, see analytics.Buffer
+func (s *Server) newClickBuffer(config *config.Config, store analytics.Store) *analytics.Buffer {
+	s.clickBuffer = analytics.NewBuffer(store, analytics.BufferOptions{
+		BatchSize:     config.ClickhouseBatchSize,
+		FlushInterval: time.Duration(config.ClickhouseFlushInterval) * time.Second,
+		QueueSize:     config.ClickhouseQueueSize,
+	}, s.Logger)
+	return s.clickBuffer
+}
 
-	apiRouter := router.New(linkHandler, tagHandler, s.Logger)
-	s.Router.Mount("/", apiRouter)
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/")
+}
 
-	return s, nil
+// isQuickShortenPath reports whether path is the quick-shorten endpoint of any API version
+func isQuickShortenPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") && strings.HasSuffix(path, "/quick-shorten")
 }
 
 func (s *Server) CloseConnections() {
+	if s.stopJobs != nil {
+		s.stopJobs()
+	}
+
+	// Clicks still queued are written before the process exits
+	if s.clickBuffer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := s.clickBuffer.Close(ctx); err != nil {
+			s.Logger.Error("Error writing buffered clicks",
+				zap.Error(err),
+			)
+		}
+		cancel()
+	}
+
+	// Clicks served by an edge instance are only counted once the primary has them
+	if s.edgeClicks != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := s.edgeClicks.Close(ctx); err != nil {
+			s.Logger.Error("Error sending clicks to the primary",
+				zap.Error(err),
+			)
+		}
+		cancel()
+	}
+
+	// Clicks counted in the process would be lost, and Redis's may not be flushed by another instance
+	if s.clickCounter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := s.clickCounter.Flush(ctx); err != nil {
+			s.Logger.Error("Error flushing click counts",
+				zap.Error(err),
+			)
+		}
+		cancel()
+	}
+
+	// Webhook deliveries still being sent are recorded before the pool closes; those cut short
+	// stay pending for the retry job
+	if s.webhooks != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := s.webhooks.Close(ctx); err != nil {
+			s.Logger.Error("Error waiting for webhook deliveries",
+				zap.Error(err),
+			)
+		}
+		cancel()
+	}
+
 	if s.Pool != nil {
 		s.Pool.Close()
 	}
 
+	if s.sqlite != nil {
+		if err := s.sqlite.Close(); err != nil {
+			s.Logger.Error("Error closing SQLite database",
+				zap.Error(err),
+			)
+		}
+	}
+
 	if s.RedisClient != nil {
 		if err := s.RedisClient.Close(); err != nil {
 			s.Logger.Error("Error closing Redis pool",
@@ -120,4 +911,8 @@ func (s *Server) CloseConnections() {
 			)
 		}
 	}
+
+	if s.miniRedis != nil {
+		s.miniRedis.Close()
+	}
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func TestAccessTokens(t *testing.T) {
+	tokens := NewAccessTokens("0123456789abcdef0123456789abcdef")
+	linkID := uuid.New()
+	now := time.Now()
+
+	token := tokens.Sign(linkID, now.Add(time.Hour))
+
+	tests := []struct {
+		name     string
+		tokens   *AccessTokens
+		linkID   uuid.UUID
+		token    string
+		now      time.Time
+		expected bool
+	}{
+		{name: "valid token", tokens: tokens, linkID: linkID, token: token, now: now, expected: true},
+		{name: "expired token", tokens: tokens, linkID: linkID, token: token, now: now.Add(2 * time.Hour), expected: false},
+		{name: "token for another link", tokens: tokens, linkID: uuid.New(), token: token, now: now, expected: false},
+		{name: "token signed with another secret", tokens: NewAccessTokens("another-secret-another-secret-xx"), linkID: linkID, token: token, now: now, expected: false},
+		{name: "token verified for another purpose", tokens: tokens.Scoped(publicStatsTokenScope), linkID: linkID, token: token, now: now, expected: false},
+		{name: "tampered expiry", tokens: tokens, linkID: linkID, token: "9999999999" + token[len("9999999999"):], now: now, expected: false},
+		{name: "malformed token", tokens: tokens, linkID: linkID, token: "not-a-token", now: now, expected: false},
+		{name: "empty token", tokens: tokens, linkID: linkID, token: "", now: now, expected: false},
+		{name: "disabled without secret", tokens: NewAccessTokens(""), linkID: linkID, token: token, now: now, expected: false},
+		{name: "nil tokens", tokens: nil, linkID: linkID, token: token, now: now, expected: false},
+		{name: "scoped without secret", tokens: NewAccessTokens("").Scoped(publicStatsTokenScope), linkID: linkID, token: token, now: now, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tokens.Verify(tt.linkID, tt.token, tt.now); got != tt.expected {
+				t.Errorf("Verify() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLinkService_CanAccessPrivateLink(t *testing.T) {
+	tokens := NewAccessTokens("0123456789abcdef0123456789abcdef")
+	link := db.GetLinkForRedirectRow{
+		ID:         uuid.New(),
+		UserID:     "user_owner",
+		Visibility: LinkVisibilityPrivate,
+	}
+	s := &LinkService{tokens: tokens, logger: createTestLogger()}
+
+	if !s.CanAccessPrivateLink(link, "user_owner", "") {
+		t.Error("owner should be able to access their private link")
+	}
+	if s.CanAccessPrivateLink(link, "user_other", "") {
+		t.Error("another user should not be able to access a private link")
+	}
+	if s.CanAccessPrivateLink(link, "", "") {
+		t.Error("anonymous visitor should not be able to access a private link")
+	}
+	if !s.CanAccessPrivateLink(link, "", tokens.Sign(link.ID, time.Now().Add(time.Hour))) {
+		t.Error("visitor with a valid token should be able to access a private link")
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Actions recorded in the activity feed
+const (
+	ActivityLinkCreated     = "link.created"
+	ActivityLinkUpdated     = "link.updated"
+	ActivityLinkDeleted     = "link.deleted"
+	ActivityLinkRetired     = "link.retired"
+	ActivityLinkTagsAdded   = "link.tags_added"
+	ActivityLinkTagsRemoved = "link.tags_removed"
+	// Recorded by AnomalyDetector, not a change the user made
+	ActivityLinkClickAnomaly = "link.click_anomaly"
+	// Recorded by DestinationMonitor when a destination redirects elsewhere, returns 404 or is parked
+	ActivityLinkDestinationAlert = "link.destination_alert"
+	ActivityTagCreated           = "tag.created"
+	ActivityTagRenamed           = "tag.renamed"
+	ActivityTagDeleted           = "tag.deleted"
+	ActivityTagRestored          = "tag.restored"
+	// Recorded by WebhookService when it gives up on a failing webhook
+	ActivityWebhookDisabled = "webhook.disabled"
+)
+
+// ActivityRecorder stores activity events
+type ActivityRecorder interface {
+	CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error
+}
+
+/*
+recordActivity adds an event to the user's activity feed. The summary is
+written in full when the event happens, so it keeps reading right after the
+link or tag it names is renamed or deleted.
+
+Recording is best-effort: the change has already been made, so a failure is
+logged rather than returned.
+*/
+func recordActivity(ctx context.Context, q ActivityRecorder, logger logger.Logger, userID string, action string, targetID uuid.UUID, summary string) {
+	if err := q.CreateActivityEvent(ctx, db.CreateActivityEventParams{
+		UserID:   userID,
+		Action:   action,
+		TargetID: targetID,
+		Summary:  summary,
+	}); err != nil {
+		logger.Warn("Failed to record activity",
+			zap.Error(err),
+			zap.String("user_id", userID),
+			zap.String("action", action),
+			zap.String("target_id", targetID.String()),
+		)
+	}
+}
+
+// pluralize returns "1 tag", "2 tags"
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// linkUpdateSummary lists the fields an update changed, e.g. `Edited link abc123 (shortcode, expires_at)`
+func linkUpdateSummary(shortcode string, fields []string) string {
+	if len(fields) == 0 {
+		return "Edited link " + shortcode
+	}
+	return fmt.Sprintf("Edited link %s (%s)", shortcode, strings.Join(fields, ", "))
+}
+
+// ActivityService reads users' activity feeds, the audit log of their changes to links and tags
+type ActivityService struct {
+	queries repository.ActivityQueries
+	logger  logger.Logger
+}
+
+func NewActivityService(queries repository.ActivityQueries, logger logger.Logger) *ActivityService {
+	return &ActivityService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+type ListActivityResult struct {
+	Events []db.ActivityEvent
+	pagination.Meta
+}
+
+// ListActivity returns a page of the user's activity feed, newest first
+func (s *ActivityService) ListActivity(ctx context.Context, userID string, page, limit int) (*ListActivityResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	total, err := s.queries.CountUserActivity(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	events, err := s.queries.ListUserActivity(ctx, db.ListUserActivityParams{
+		UserID: userID,
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+
+	s.logger.Debug("Database query completed for ListUserActivity",
+		zap.String("user_id", userID),
+		zap.Int("events_found", len(events)),
+		zap.Int64("total", total),
+	)
+
+	return &ListActivityResult{
+		Events: events,
+		Meta:   p.Meta(total),
+	}, nil
+}
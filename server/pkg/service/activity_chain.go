@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Events read per query while walking a chain
+	activityChainBatch = 1000
+	// Users whose chains are verified per query of the verification job
+	activityChainUserBatch = 100
+	// Upper bound for one verification job run
+	activityChainVerifyTimeout = 30 * time.Minute
+)
+
+// prev_hash of the first event of every chain
+var activityChainGenesis = strings.Repeat("0", 64)
+
+// Why a chain failed verification
+const (
+	// The event's fields don't hash to its hash: it was edited
+	ActivityChainHashMismatch = "hash_mismatch"
+	// The event doesn't carry the hash of the event before it: that one was replaced
+	ActivityChainBrokenLink = "broken_link"
+	// An event is missing from the sequence: it was deleted
+	ActivityChainSequenceGap = "sequence_gap"
+	// The chain doesn't hold the anchor: it was rewritten or events were deleted from its end
+	ActivityChainAnchorMismatch = "anchor_mismatch"
+)
+
+// ActivityChainAnchor is a head of the chain saved outside the service, e.g. from an earlier verification
+type ActivityChainAnchor struct {
+	Seq  int64
+	Hash string
+}
+
+// ActivityChainVerification is the outcome of walking a user's activity chain
+type ActivityChainVerification struct {
+	Valid bool
+	// Events verified, up to where the chain breaks when it isn't valid
+	Events int64
+	// Last verified event; saved outside the service, it anchors later verifications
+	HeadSeq  int64
+	HeadHash string
+	// seq of the event where the chain breaks and why, when it isn't valid
+	BrokenAtSeq int64
+	Reason      string
+}
+
+func (v *ActivityChainVerification) broken(seq int64, reason string) *ActivityChainVerification {
+	v.Valid = false
+	v.BrokenAtSeq = seq
+	v.Reason = reason
+	return v
+}
+
+/*
+activityEventHash is the hash of an event, over the hash of the event before it
+and its own fields. It must match activity_event_hash() in the migrations, which
+computes it on insert: text fields are length-prefixed so none can pass for
+another, and created_at is taken to the microsecond in UTC.
+*/
+func activityEventHash(event db.ActivityEvent) string {
+	var b strings.Builder
+	b.WriteString(event.PrevHash + "\n")
+	b.WriteString(strconv.FormatInt(event.Seq, 10) + "\n")
+	b.WriteString(strconv.Itoa(len(event.UserID)) + ":" + event.UserID + "\n")
+	b.WriteString(strconv.Itoa(len(event.Action)) + ":" + event.Action + "\n")
+	b.WriteString(event.TargetID.String() + "\n")
+	b.WriteString(event.CreatedAt.Time.UTC().Format("2006-01-02T15:04:05.000000Z") + "\n")
+	b.WriteString(strconv.Itoa(len(event.Summary)) + ":" + event.Summary)
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+type activityChainReader interface {
+	ListUserActivityChain(ctx context.Context, arg db.ListUserActivityChainParams) ([]db.ActivityEvent, error)
+}
+
+/*
+verifyActivityChain walks the user's activity events in order and recomputes
+their hashes. Anyone able to write to the database could recompute the whole
+chain after altering it, so the chain alone only shows it's consistent; an
+anchor saved outside the service proves nothing up to it changed since.
+*/
+func verifyActivityChain(ctx context.Context, q activityChainReader, userID string, anchor *ActivityChainAnchor) (*ActivityChainVerification, error) {
+	result := &ActivityChainVerification{Valid: true, HeadHash: activityChainGenesis}
+
+	for {
+		events, err := q.ListUserActivityChain(ctx, db.ListUserActivityChainParams{
+			UserID:   userID,
+			AfterSeq: result.HeadSeq,
+			RowLimit: activityChainBatch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get activity chain: %w", err)
+		}
+
+		for _, event := range events {
+			switch {
+			case event.Seq != result.HeadSeq+1:
+				return result.broken(result.HeadSeq+1, ActivityChainSequenceGap), nil
+			case event.PrevHash != result.HeadHash:
+				return result.broken(event.Seq, ActivityChainBrokenLink), nil
+			case activityEventHash(event) != event.Hash:
+				return result.broken(event.Seq, ActivityChainHashMismatch), nil
+			case anchor != nil && event.Seq == anchor.Seq && event.Hash != anchor.Hash:
+				return result.broken(event.Seq, ActivityChainAnchorMismatch), nil
+			}
+
+			result.Events++
+			result.HeadSeq = event.Seq
+			result.HeadHash = event.Hash
+		}
+
+		if len(events) < activityChainBatch {
+			break
+		}
+	}
+
+	if anchor != nil && anchor.Seq > result.HeadSeq {
+		return result.broken(anchor.Seq, ActivityChainAnchorMismatch), nil
+	}
+	return result, nil
+}
+
+// VerifyChain checks the user's activity events against their hash chain and, if given, an anchor saved earlier
+func (s *ActivityService) VerifyChain(ctx context.Context, userID string, anchor *ActivityChainAnchor) (*ActivityChainVerification, error) {
+	result, err := verifyActivityChain(ctx, s.queries, userID, anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Valid {
+		s.logger.Warn("Activity chain verification failed",
+			zap.String("user_id", userID),
+			zap.Int64("seq", result.BrokenAtSeq),
+			zap.String("reason", result.Reason),
+		)
+	}
+	return result, nil
+}
+
+// ActivityChainVerifier periodically verifies the activity chains of every user and reports broken ones
+type ActivityChainVerifier struct {
+	queries repository.ActivityChainVerifierQueries
+	logger  logger.Logger
+}
+
+func NewActivityChainVerifier(queries repository.ActivityChainVerifierQueries, logger logger.Logger) *ActivityChainVerifier {
+	return &ActivityChainVerifier{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Run verifies every user's chain and returns the IDs of the users whose chain is broken
+func (v *ActivityChainVerifier) Run(ctx context.Context) ([]string, error) {
+	var broken []string
+	var verified int
+	after := ""
+
+	for {
+		userIDs, err := v.queries.ListActivityUsers(ctx, db.ListActivityUsersParams{
+			AfterUserID: after,
+			RowLimit:    activityChainUserBatch,
+		})
+		if err != nil {
+			return broken, fmt.Errorf("failed to list users with activity: %w", err)
+		}
+
+		for _, userID := range userIDs {
+			result, err := verifyActivityChain(ctx, v.queries, userID, nil)
+			if err != nil {
+				return broken, err
+			}
+			verified++
+
+			if !result.Valid {
+				broken = append(broken, userID)
+				v.logger.Error("Activity chain is broken",
+					zap.String("user_id", userID),
+					zap.Int64("seq", result.BrokenAtSeq),
+					zap.String("reason", result.Reason),
+				)
+			}
+		}
+
+		if len(userIDs) < activityChainUserBatch {
+			break
+		}
+		after = userIDs[len(userIDs)-1]
+	}
+
+	v.logger.Info("Activity chains verified",
+		zap.Int("users", verified),
+		zap.Int("broken", len(broken)),
+	)
+	return broken, nil
+}
+
+// Start verifies every chain now and then every interval until ctx is done
+func (v *ActivityChainVerifier) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			v.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (v *ActivityChainVerifier) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, activityChainVerifyTimeout)
+	defer cancel()
+
+	if _, err := v.Run(ctx); err != nil && ctx.Err() == nil {
+		v.logger.Error("Activity chain verification failed",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+// testActivityChain builds a chain the way the insert trigger does
+func testActivityChain(userID string, summaries ...string) []db.ActivityEvent {
+	events := make([]db.ActivityEvent, 0, len(summaries))
+	prev := activityChainGenesis
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, summary := range summaries {
+		event := db.ActivityEvent{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Action:    ActivityLinkCreated,
+			TargetID:  uuid.New(),
+			Summary:   summary,
+			CreatedAt: pgtype.Timestamptz{Time: start.Add(time.Duration(i) * time.Minute), Valid: true},
+			Seq:       int64(i + 1),
+			PrevHash:  prev,
+		}
+		event.Hash = activityEventHash(event)
+		prev = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func chainQueries(chains map[string][]db.ActivityEvent) *mocks.ActivityChainVerifierQueries {
+	return &mocks.ActivityChainVerifierQueries{
+		ListActivityUsersFunc: func(ctx context.Context, arg db.ListActivityUsersParams) ([]string, error) {
+			var users []string
+			for userID := range chains {
+				if userID > arg.AfterUserID {
+					users = append(users, userID)
+				}
+			}
+			return users, nil
+		},
+		ListUserActivityChainFunc: func(ctx context.Context, arg db.ListUserActivityChainParams) ([]db.ActivityEvent, error) {
+			var events []db.ActivityEvent
+			for _, event := range chains[arg.UserID] {
+				if event.Seq > arg.AfterSeq && len(events) < int(arg.RowLimit) {
+					events = append(events, event)
+				}
+			}
+			return events, nil
+		},
+	}
+}
+
+// The hash must stay the one activity_event_hash() computes in Postgres
+func TestActivityEventHash(t *testing.T) {
+	event := db.ActivityEvent{
+		UserID:    "user_123",
+		Action:    ActivityLinkCreated,
+		TargetID:  uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Summary:   "Created link spring → café",
+		CreatedAt: pgtype.Timestamptz{Time: time.Date(2026, 3, 1, 14, 30, 45, 123456000, time.FixedZone("EET", 2*60*60)), Valid: true},
+		Seq:       1,
+		PrevHash:  activityChainGenesis,
+	}
+
+	want := "cd56676b84b23a7ada1bae7a0f37da97b03c0ae3aed177017065231315cc72f3"
+	if got := activityEventHash(event); got != want {
+		t.Errorf("activityEventHash() = %s, want %s", got, want)
+	}
+}
+
+func TestActivityService_VerifyChain(t *testing.T) {
+	tampered := func(edit func(events []db.ActivityEvent) []db.ActivityEvent) []db.ActivityEvent {
+		return edit(testActivityChain("user_123", "one", "two", "three", "four"))
+	}
+	intact := testActivityChain("user_123", "one", "two", "three", "four")
+
+	tests := []struct {
+		name       string
+		events     []db.ActivityEvent
+		anchor     *ActivityChainAnchor
+		wantValid  bool
+		wantEvents int64
+		wantBroken int64
+		wantReason string
+	}{
+		{name: "intact chain", events: intact, wantValid: true, wantEvents: 4},
+		{name: "no events", wantValid: true},
+		{name: "matching anchor", events: intact, anchor: &ActivityChainAnchor{Seq: 2, Hash: intact[1].Hash}, wantValid: true, wantEvents: 4},
+		{
+			name: "edited summary",
+			events: tampered(func(events []db.ActivityEvent) []db.ActivityEvent {
+				events[2].Summary = "something else"
+				return events
+			}),
+			wantEvents: 2, wantBroken: 3, wantReason: ActivityChainHashMismatch,
+		},
+		{
+			name: "deleted event",
+			events: tampered(func(events []db.ActivityEvent) []db.ActivityEvent {
+				return append(events[:1], events[2:]...)
+			}),
+			wantEvents: 1, wantBroken: 2, wantReason: ActivityChainSequenceGap,
+		},
+		{
+			name: "replaced event",
+			events: tampered(func(events []db.ActivityEvent) []db.ActivityEvent {
+				events[1].PrevHash = activityChainGenesis
+				events[1].Hash = activityEventHash(events[1])
+				return events
+			}),
+			wantEvents: 1, wantBroken: 2, wantReason: ActivityChainBrokenLink,
+		},
+		{
+			name:       "rewritten chain",
+			events:     testActivityChain("user_123", "one", "forged", "three", "four"),
+			anchor:     &ActivityChainAnchor{Seq: 3, Hash: intact[2].Hash},
+			wantEvents: 2, wantBroken: 3, wantReason: ActivityChainAnchorMismatch,
+		},
+		{
+			name: "truncated chain",
+			events: tampered(func(events []db.ActivityEvent) []db.ActivityEvent {
+				return events[:2]
+			}),
+			anchor:     &ActivityChainAnchor{Seq: 4, Hash: intact[3].Hash},
+			wantEvents: 2, wantBroken: 4, wantReason: ActivityChainAnchorMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := chainQueries(map[string][]db.ActivityEvent{"user_123": tt.events})
+			s := NewActivityService(&mocks.ActivityQueries{ListUserActivityChainFunc: queries.ListUserActivityChainFunc}, createTestLogger())
+
+			result, err := s.VerifyChain(context.Background(), "user_123", tt.anchor)
+			if err != nil {
+				t.Fatalf("VerifyChain() error = %v, want nil", err)
+			}
+			if result.Valid != tt.wantValid || result.Events != tt.wantEvents || result.BrokenAtSeq != tt.wantBroken || result.Reason != tt.wantReason {
+				t.Errorf("VerifyChain() = %+v, want valid %v, %d events, broken at %d (%q)",
+					result, tt.wantValid, tt.wantEvents, tt.wantBroken, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestActivityChainVerifier_Run(t *testing.T) {
+	broken := testActivityChain("user_b", "one", "two")
+	broken[0].Summary = "edited"
+
+	// More events than a batch, to walk the chain across queries
+	long := make([]string, activityChainBatch+5)
+	for i := range long {
+		long[i] = "event"
+	}
+
+	queries := chainQueries(map[string][]db.ActivityEvent{
+		"user_a": testActivityChain("user_a", long...),
+		"user_b": broken,
+		"user_c": testActivityChain("user_c", "one"),
+	})
+
+	got, err := NewActivityChainVerifier(queries, createTestLogger()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if len(got) != 1 || got[0] != "user_b" {
+		t.Errorf("Run() broken = %v, want [user_b]", got)
+	}
+}
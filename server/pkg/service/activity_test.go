@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+type mockActivityQueries struct {
+	total  int64
+	listed db.ListUserActivityParams
+}
+
+func (m *mockActivityQueries) ListUserActivity(ctx context.Context, arg db.ListUserActivityParams) ([]db.ActivityEvent, error) {
+	m.listed = arg
+	return []db.ActivityEvent{}, nil
+}
+
+func (m *mockActivityQueries) CountUserActivity(ctx context.Context, userID string) (int64, error) {
+	return m.total, nil
+}
+
+func (m *mockActivityQueries) ListUserActivityChain(ctx context.Context, arg db.ListUserActivityChainParams) ([]db.ActivityEvent, error) {
+	return nil, nil
+}
+
+func TestActivityService_ListActivity(t *testing.T) {
+	queries := &mockActivityQueries{total: 41}
+	s := NewActivityService(queries, createTestLogger())
+
+	result, err := s.ListActivity(context.Background(), "user_123", 2, 500)
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v, want nil", err)
+	}
+	if queries.listed.UserID != "user_123" || queries.listed.Limit != 100 || queries.listed.Offset != 100 {
+		t.Errorf("ListActivity() queried %+v, want the user's second page of 100", queries.listed)
+	}
+	if result.TotalPages != 1 {
+		t.Errorf("ListActivity() total pages = %d, want 1", result.TotalPages)
+	}
+}
+
+func TestLinkService_RecordsActivity(t *testing.T) {
+	linkID := uuid.New()
+	future := time.Now().Add(time.Hour)
+	shortcode := "spring"
+
+	t.Run("update lists the changed fields", func(t *testing.T) {
+		var recorded db.CreateActivityEventParams
+		mockQueries := &mocks.LinkQueries{
+			GetShortcodeReservationFunc: noReservation,
+			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
+				return createTestUpdateLinkRow(linkID, shortcode, "https://example.com", true), nil
+			},
+			CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+				recorded = arg
+				return nil
+			},
+		}
+		service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+		if _, err := service.UpdateLink(context.Background(), "user_123", linkID, &shortcode, nil, &future, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("UpdateLink() error = %v, want nil", err)
+		}
+
+		want := db.CreateActivityEventParams{
+			UserID:   "user_123",
+			Action:   ActivityLinkUpdated,
+			TargetID: linkID,
+			Summary:  "Edited link spring (shortcode, expires_at)",
+		}
+		if recorded != want {
+			t.Errorf("recorded %+v, want %+v", recorded, want)
+		}
+	})
+
+	t.Run("recording failures don't fail the change", func(t *testing.T) {
+		mockQueries := &mocks.LinkQueries{
+			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
+				return db.DeleteLinkRow{ID: arg.ID, Shortcode: shortcode}, nil
+			},
+			CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+				return errors.New("connection reset")
+			},
+		}
+		service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+		if _, err := service.DeleteLink(context.Background(), "user_123", linkID); err != nil {
+			t.Errorf("DeleteLink() error = %v, want nil", err)
+		}
+	})
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Kinds of click anomalies
+const (
+	AnomalySpike = "spike"
+	AnomalyDrop  = "drop"
+)
+
+const (
+	// Upper bound for one detection run
+	anomalyDetectionTimeout = 5 * time.Minute
+	// Upper bound for delivering one anomaly to the webhook
+	AnomalyWebhookTimeout = 10 * time.Second
+)
+
+type AnomalyOptions struct {
+	// Hours each hour is compared against
+	Window int
+	// Distance from the window's mean, in standard deviations, that counts as an anomaly
+	Threshold float64
+	// Spikes need at least this many clicks in the hour, drops at least this many on average before,
+	// so links with a handful of clicks don't alert on noise
+	MinClicks int64
+}
+
+// Anomaly is a newly detected anomaly, as notified
+type Anomaly struct {
+	db.LinkAnomaly
+	UserID    string
+	Shortcode string
+}
+
+// AnomalyNotifier tells the outside world about new anomalies
+type AnomalyNotifier interface {
+	NotifyAnomaly(ctx context.Context, anomaly Anomaly) error
+}
+
+/*
+AnomalyDetector looks for links whose clicks in the last complete hour are
+far from their usual level: a z-score of the hour's clicks against the hours
+of the rolling window before it. Spikes tell users a link went viral, drops
+that it suddenly died (e.g. it was removed from a page).
+
+New anomalies are recorded in link_anomalies, added to the owner's activity
+feed and sent to the notifier. Reads clicks from Postgres, so it only works
+with the postgres analytics backend.
+*/
+type AnomalyDetector struct {
+	tx       repository.Transactor[repository.AnomalyDetectorQueries]
+	activity ActivityRecorder
+	// Nil when only the activity feed is notified
+	notifier AnomalyNotifier
+	opts     AnomalyOptions
+	logger   logger.Logger
+}
+
+func NewAnomalyDetector(tx repository.Transactor[repository.AnomalyDetectorQueries], activity ActivityRecorder, notifier AnomalyNotifier, opts AnomalyOptions, logger logger.Logger) *AnomalyDetector {
+	return &AnomalyDetector{
+		tx:       tx,
+		activity: activity,
+		notifier: notifier,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+// Run checks the last complete hour before now. It's a no-op when another instance holds the detection lock.
+func (d *AnomalyDetector) Run(ctx context.Context, now time.Time) error {
+	hour := now.UTC().Truncate(time.Hour).Add(-time.Hour)
+	from := hour.Add(-time.Duration(d.opts.Window) * time.Hour)
+
+	var found []Anomaly
+	err := d.tx.WithTx(ctx, func(q repository.AnomalyDetectorQueries) error {
+		locked, err := q.TryLockAnomalyDetection(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock anomaly detection: %w", err)
+		}
+		if !locked {
+			d.logger.Debug("Anomaly detection already running elsewhere")
+			return nil
+		}
+
+		rows, err := q.GetHourlyLinkClicks(ctx, db.GetHourlyLinkClicksParams{
+			FromTime: pgtype.Timestamptz{Time: from, Valid: true},
+			ToTime:   pgtype.Timestamptz{Time: hour.Add(time.Hour), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get hourly clicks: %w", err)
+		}
+
+		for _, link := range hourlySeries(rows, from, d.opts.Window) {
+			kind, baseline, z, ok := detectAnomaly(link.baseline, link.current, d.opts)
+			if !ok {
+				continue
+			}
+
+			anomaly, err := q.CreateLinkAnomaly(ctx, db.CreateLinkAnomalyParams{
+				LinkID:   link.id,
+				Kind:     kind,
+				Hour:     pgtype.Timestamptz{Time: hour, Valid: true},
+				Clicks:   link.current,
+				Baseline: baseline,
+				ZScore:   z,
+			})
+			if err != nil {
+				// Already recorded by an earlier run
+				if errors.Is(err, sql.ErrNoRows) {
+					continue
+				}
+				return fmt.Errorf("failed to record anomaly: %w", err)
+			}
+
+			found = append(found, Anomaly{LinkAnomaly: anomaly, UserID: link.userID, Shortcode: link.shortcode})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Notified once committed, so a rolled back run doesn't alert
+	for _, anomaly := range found {
+		d.notify(ctx, anomaly)
+	}
+
+	d.logger.Info("Anomaly detection completed",
+		zap.String("hour", hour.Format(time.RFC3339)),
+		zap.Int("anomalies", len(found)),
+	)
+	return nil
+}
+
+func (d *AnomalyDetector) notify(ctx context.Context, anomaly Anomaly) {
+	verb := "spiked"
+	if anomaly.Kind == AnomalyDrop {
+		verb = "dropped"
+	}
+	recordActivity(ctx, d.activity, d.logger, anomaly.UserID, ActivityLinkClickAnomaly, anomaly.LinkID,
+		fmt.Sprintf("Clicks on link %s %s: %d in the hour from %s UTC, usually %.1f",
+			anomaly.Shortcode, verb, anomaly.Clicks, anomaly.Hour.Time.Format("2006-01-02 15:04"), anomaly.Baseline))
+
+	if d.notifier == nil {
+		return
+	}
+	if err := d.notifier.NotifyAnomaly(ctx, anomaly); err != nil {
+		d.logger.Warn("Failed to notify anomaly",
+			zap.Error(err),
+			zap.String("link_id", anomaly.LinkID.String()),
+			zap.String("kind", anomaly.Kind),
+		)
+	}
+}
+
+// linkClickSeries holds a link's clicks per hour of the window and in the checked hour
+type linkClickSeries struct {
+	id        uuid.UUID
+	userID    string
+	shortcode string
+	baseline  []int64
+	current   int64
+}
+
+// hourlySeries turns the per-hour click counts (ordered by link) into one series per link,
+// with zeros for hours without clicks. The hour after the window is the checked one.
+func hourlySeries(rows []db.GetHourlyLinkClicksRow, from time.Time, window int) []*linkClickSeries {
+	var series []*linkClickSeries
+	var cur *linkClickSeries
+
+	for _, row := range rows {
+		if cur == nil || cur.id != row.LinkID {
+			cur = &linkClickSeries{
+				id:        row.LinkID,
+				userID:    row.UserID,
+				shortcode: row.Shortcode,
+				baseline:  make([]int64, window),
+			}
+			series = append(series, cur)
+		}
+
+		i := int(row.Hour.Time.Sub(from) / time.Hour)
+		switch {
+		case i >= 0 && i < window:
+			cur.baseline[i] = row.Clicks
+		case i == window:
+			cur.current = row.Clicks
+		}
+	}
+
+	return series
+}
+
+/*
+detectAnomaly reports whether current is an anomaly against the baseline
+hours, returning its kind, the baseline's mean and the z-score. The standard
+deviation is floored at 1 so a perfectly flat baseline doesn't make every
+change infinitely anomalous.
+*/
+func detectAnomaly(baseline []int64, current int64, opts AnomalyOptions) (kind string, mean float64, z float64, ok bool) {
+	if len(baseline) == 0 {
+		return "", 0, 0, false
+	}
+
+	var sum float64
+	for _, c := range baseline {
+		sum += float64(c)
+	}
+	mean = sum / float64(len(baseline))
+
+	var variance float64
+	for _, c := range baseline {
+		variance += (float64(c) - mean) * (float64(c) - mean)
+	}
+	stddev := math.Max(math.Sqrt(variance/float64(len(baseline))), 1)
+
+	z = (float64(current) - mean) / stddev
+
+	switch {
+	case z >= opts.Threshold && current >= opts.MinClicks:
+		return AnomalySpike, mean, z, true
+	case z <= -opts.Threshold && mean >= float64(opts.MinClicks):
+		return AnomalyDrop, mean, z, true
+	}
+	return "", mean, z, false
+}
+
+// Start runs the detector now and then every interval until ctx is done
+func (d *AnomalyDetector) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			d.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (d *AnomalyDetector) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, anomalyDetectionTimeout)
+	defer cancel()
+
+	if err := d.Run(ctx, time.Now()); err != nil && ctx.Err() == nil {
+		d.logger.Error("Anomaly detection failed",
+			zap.Error(err),
+		)
+	}
+}
+
+// AnomalyWebhook posts each new anomaly as JSON to a fixed URL, e.g. a chat or alerting integration
+type AnomalyWebhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewAnomalyWebhook(url string, client *http.Client) *AnomalyWebhook {
+	return &AnomalyWebhook{
+		url:    url,
+		client: client,
+	}
+}
+
+type anomalyWebhookPayload struct {
+	Event     string    `json:"event"`
+	UserID    string    `json:"user_id"`
+	LinkID    uuid.UUID `json:"link_id"`
+	Shortcode string    `json:"shortcode"`
+	Kind      string    `json:"kind"`
+	Hour      time.Time `json:"hour"`
+	Clicks    int64     `json:"clicks"`
+	Baseline  float64   `json:"baseline"`
+	ZScore    float64   `json:"z_score"`
+}
+
+func (h *AnomalyWebhook) NotifyAnomaly(ctx context.Context, anomaly Anomaly) error {
+	body, err := json.Marshal(anomalyWebhookPayload{
+		Event:     ActivityLinkClickAnomaly,
+		UserID:    anomaly.UserID,
+		LinkID:    anomaly.LinkID,
+		Shortcode: anomaly.Shortcode,
+		Kind:      anomaly.Kind,
+		Hour:      anomaly.Hour.Time,
+		Clicks:    anomaly.Clicks,
+		Baseline:  anomaly.Baseline,
+		ZScore:    anomaly.ZScore,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode anomaly: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// AnomalyService reads the anomalies recorded by AnomalyDetector
+type AnomalyService struct {
+	queries repository.AnomalyQueries
+	logger  logger.Logger
+}
+
+func NewAnomalyService(queries repository.AnomalyQueries, logger logger.Logger) *AnomalyService {
+	return &AnomalyService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// ListLinkAnomalies returns the latest anomalies of one of the user's links, newest first
+func (s *AnomalyService) ListLinkAnomalies(ctx context.Context, userID string, linkID uuid.UUID) ([]db.LinkAnomaly, error) {
+	if _, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	anomalies, err := s.queries.ListLinkAnomalies(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get anomalies: %w", err)
+	}
+
+	return anomalies, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+)
+
+func TestDetectAnomaly(t *testing.T) {
+	opts := AnomalyOptions{Window: 6, Threshold: 3, MinClicks: 10}
+
+	tests := []struct {
+		name     string
+		baseline []int64
+		current  int64
+		wantKind string
+	}{
+		{name: "steady traffic", baseline: []int64{20, 22, 18, 21, 19, 20}, current: 23},
+		{name: "spike", baseline: []int64{20, 22, 18, 21, 19, 20}, current: 90, wantKind: AnomalySpike},
+		{name: "drop", baseline: []int64{20, 22, 18, 21, 19, 20}, current: 0, wantKind: AnomalyDrop},
+		{name: "spike below min clicks", baseline: []int64{0, 0, 1, 0, 0, 0}, current: 8},
+		{name: "drop from a quiet link", baseline: []int64{2, 3, 2, 1, 2, 3}, current: 0},
+		{name: "flat baseline spike", baseline: []int64{0, 0, 0, 0, 0, 0}, current: 12, wantKind: AnomalySpike},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, _, z, ok := detectAnomaly(tt.baseline, tt.current, opts)
+			if ok != (tt.wantKind != "") || kind != tt.wantKind {
+				t.Errorf("detectAnomaly() = %q (z %.2f, ok %v), want %q", kind, z, ok, tt.wantKind)
+			}
+		})
+	}
+}
+
+type mockAnomalyDetectorQueries struct {
+	rows     []db.GetHourlyLinkClicksRow
+	existing map[uuid.UUID]bool
+	queried  db.GetHourlyLinkClicksParams
+	created  []db.CreateLinkAnomalyParams
+}
+
+func (m *mockAnomalyDetectorQueries) TryLockAnomalyDetection(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *mockAnomalyDetectorQueries) GetHourlyLinkClicks(ctx context.Context, arg db.GetHourlyLinkClicksParams) ([]db.GetHourlyLinkClicksRow, error) {
+	m.queried = arg
+	return m.rows, nil
+}
+
+func (m *mockAnomalyDetectorQueries) CreateLinkAnomaly(ctx context.Context, arg db.CreateLinkAnomalyParams) (db.LinkAnomaly, error) {
+	if m.existing[arg.LinkID] {
+		return db.LinkAnomaly{}, sql.ErrNoRows
+	}
+	m.created = append(m.created, arg)
+	return db.LinkAnomaly{ID: uuid.New(), LinkID: arg.LinkID, Kind: arg.Kind, Hour: arg.Hour, Clicks: arg.Clicks, Baseline: arg.Baseline, ZScore: arg.ZScore}, nil
+}
+
+type mockAnomalyDetectorTransactor struct {
+	queries repository.AnomalyDetectorQueries
+}
+
+func (m *mockAnomalyDetectorTransactor) WithTx(ctx context.Context, fn func(q repository.AnomalyDetectorQueries) error) error {
+	return fn(m.queries)
+}
+
+type mockActivityRecorder struct {
+	events []db.CreateActivityEventParams
+}
+
+func (m *mockActivityRecorder) CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error {
+	m.events = append(m.events, arg)
+	return nil
+}
+
+type mockAnomalyNotifier struct {
+	notified []Anomaly
+}
+
+func (m *mockAnomalyNotifier) NotifyAnomaly(ctx context.Context, anomaly Anomaly) error {
+	m.notified = append(m.notified, anomaly)
+	return nil
+}
+
+func TestAnomalyDetector_Run(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	checked := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	viral, known, steady := uuid.New(), uuid.New(), uuid.New()
+
+	// Three hours of ~20 clicks, then the checked hour
+	var rows []db.GetHourlyLinkClicksRow
+	for _, link := range []struct {
+		id        uuid.UUID
+		shortcode string
+		current   int64
+	}{{viral, "viral", 500}, {known, "known", 500}, {steady, "steady", 21}} {
+		for i, clicks := range []int64{19, 20, 21, link.current} {
+			rows = append(rows, db.GetHourlyLinkClicksRow{
+				LinkID:    link.id,
+				UserID:    "user_123",
+				Shortcode: link.shortcode,
+				Hour:      pgtype.Timestamptz{Time: checked.Add(time.Duration(i-3) * time.Hour), Valid: true},
+				Clicks:    clicks,
+			})
+		}
+	}
+
+	queries := &mockAnomalyDetectorQueries{rows: rows, existing: map[uuid.UUID]bool{known: true}}
+	activity := &mockActivityRecorder{}
+	notifier := &mockAnomalyNotifier{}
+	detector := NewAnomalyDetector(
+		&mockAnomalyDetectorTransactor{queries: queries},
+		activity,
+		notifier,
+		AnomalyOptions{Window: 3, Threshold: 3, MinClicks: 10},
+		createTestLogger(),
+	)
+
+	if err := detector.Run(context.Background(), now); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !queries.queried.FromTime.Time.Equal(checked.Add(-3*time.Hour)) || !queries.queried.ToTime.Time.Equal(checked.Add(time.Hour)) {
+		t.Errorf("Run() queried %v - %v", queries.queried.FromTime.Time, queries.queried.ToTime.Time)
+	}
+	if len(queries.created) != 1 {
+		t.Fatalf("Run() recorded %d anomalies, want 1 (the known one is skipped)", len(queries.created))
+	}
+	if queries.created[0].LinkID != viral || queries.created[0].Kind != AnomalySpike || !queries.created[0].Hour.Time.Equal(checked) {
+		t.Errorf("Run() recorded %+v", queries.created[0])
+	}
+	if len(notifier.notified) != 1 || notifier.notified[0].Shortcode != "viral" || notifier.notified[0].UserID != "user_123" {
+		t.Errorf("Run() notified %+v, want only the new anomaly", notifier.notified)
+	}
+	if len(activity.events) != 1 || activity.events[0].Action != ActivityLinkClickAnomaly || activity.events[0].TargetID != viral {
+		t.Errorf("Run() recorded activity %+v", activity.events)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Prefix of API keys, so leaked keys are easy to recognize
+	apiKeyPrefix = "ak_"
+	// How long a rotated-out key keeps working, unless the rotation says otherwise
+	DefaultAPIKeyGrace = 24 * time.Hour
+	// Longest a rotated-out key can be kept
+	MaxAPIKeyGrace = 7 * 24 * time.Hour
+)
+
+// DefaultAPIKeyScopes are granted to keys created without scopes: those of the links routes
+var DefaultAPIKeyScopes = []string{auth.ScopeLinksRead, auth.ScopeLinksCreate, auth.ScopeLinksWrite, auth.ScopeStatsRead}
+
+/*
+APIKeyService manages API keys: credentials users create for scripts and other
+programmatic clients that can't hold a session. A key works on its owner's
+links, limited to the scopes it was granted (see auth.Scopes), and is rotated
+and audited like a service account's token. It's stored only as a SHA-256
+hash and shown once, when it's created or rotated. Revoked keys stop working
+but stay listed.
+*/
+type APIKeyService struct {
+	queries repository.APIKeyQueries
+	logger  logger.Logger
+}
+
+func NewAPIKeyService(queries repository.APIKeyQueries, logger logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Create creates an API key for userID and returns it with the key, which can't be retrieved later.
+// Without scopes it gets DefaultAPIKeyScopes.
+func (s *APIKeyService) Create(ctx context.Context, userID string, name string, scopes []string) (db.ApiKey, string, error) {
+	if len(scopes) == 0 {
+		scopes = DefaultAPIKeyScopes
+	}
+
+	key, err := newSecretToken(apiKeyPrefix)
+	if err != nil {
+		return db.ApiKey{}, "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	apiKey, err := s.queries.CreateApiKey(ctx, db.CreateApiKeyParams{
+		UserID:  userID,
+		Name:    name,
+		Scopes:  normalizeScopes(scopes),
+		KeyHash: hashSecretToken(key),
+	})
+	if err != nil {
+		return db.ApiKey{}, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	s.logger.Info("API key created",
+		zap.String("user_id", userID),
+		zap.String("api_key_id", apiKey.ID.String()),
+		zap.Strings("scopes", apiKey.Scopes),
+	)
+
+	return apiKey, key, nil
+}
+
+// List returns the user's API keys, revoked ones included
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]db.ApiKey, error) {
+	keys, err := s.queries.ListUserApiKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Get returns one of the user's API keys, revoked or not, with what's recorded about its use
+func (s *APIKeyService) Get(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error) {
+	apiKey, err := s.queries.GetUserApiKey(ctx, db.GetUserApiKeyParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ApiKey{}, fmt.Errorf("%w: %v", apperrors.APIKeyNotFound, err)
+		}
+		return db.ApiKey{}, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+/*
+Rotate gives one of the user's API keys a new key and returns it. The current
+one keeps working for grace (at most MaxAPIKeyGrace), so clients can switch
+without downtime; a zero grace revokes it right away. A key rotated out
+earlier is revoked. Revoked keys are APIKeyNotFound.
+*/
+func (s *APIKeyService) Rotate(ctx context.Context, userID string, id uuid.UUID, grace time.Duration) (db.ApiKey, string, error) {
+	grace = min(max(grace, 0), MaxAPIKeyGrace)
+
+	key, err := newSecretToken(apiKeyPrefix)
+	if err != nil {
+		return db.ApiKey{}, "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	apiKey, err := s.queries.RotateApiKey(ctx, db.RotateApiKeyParams{
+		PreviousKeyExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(grace), Valid: true},
+		KeyHash:              hashSecretToken(key),
+		ID:                   id,
+		UserID:               userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ApiKey{}, "", fmt.Errorf("%w: %v", apperrors.APIKeyNotFound, err)
+		}
+		return db.ApiKey{}, "", fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	s.logger.Info("API key rotated",
+		zap.String("user_id", userID),
+		zap.String("api_key_id", id.String()),
+		zap.Duration("grace", grace),
+	)
+
+	return apiKey, key, nil
+}
+
+// RevokePrevious revokes the key replaced by the last rotation before its grace period ends
+func (s *APIKeyService) RevokePrevious(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error) {
+	apiKey, err := s.queries.RevokePreviousApiKey(ctx, db.RevokePreviousApiKeyParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ApiKey{}, fmt.Errorf("%w: %v", apperrors.APIKeyNotFound, err)
+		}
+		return db.ApiKey{}, fmt.Errorf("failed to revoke previous API key: %w", err)
+	}
+
+	s.logger.Info("Previous API key revoked",
+		zap.String("user_id", userID),
+		zap.String("api_key_id", id.String()),
+	)
+
+	return apiKey, nil
+}
+
+// Revoke revokes one of the user's API keys; it stops working immediately, along with a key it
+// replaced that's still in its grace period. Revoked keys are APIKeyNotFound.
+func (s *APIKeyService) Revoke(ctx context.Context, userID string, id uuid.UUID) (db.ApiKey, error) {
+	apiKey, err := s.queries.RevokeApiKey(ctx, db.RevokeApiKeyParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ApiKey{}, fmt.Errorf("%w: %v", apperrors.APIKeyNotFound, err)
+		}
+		return db.ApiKey{}, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.logger.Info("API key revoked",
+		zap.String("user_id", userID),
+		zap.String("api_key_id", id.String()),
+	)
+
+	return apiKey, nil
+}
+
+/*
+Authenticate resolves the live API key a key belongs to, its current key or
+one rotated out that's still in its grace period, and records the use and the
+client's address. It's InvalidAPIKey if there's none.
+*/
+func (s *APIKeyService) Authenticate(ctx context.Context, key string, clientIP netip.Addr) (db.ApiKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return db.ApiKey{}, apperrors.InvalidAPIKey
+	}
+
+	var ip *string
+	if clientIP.IsValid() {
+		addr := clientIP.String()
+		ip = &addr
+	}
+
+	apiKey, err := s.queries.UseApiKey(ctx, db.UseApiKeyParams{
+		Ip:      ip,
+		KeyHash: hashSecretToken(key),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ApiKey{}, apperrors.InvalidAPIKey
+		}
+		return db.ApiKey{}, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return apiKey, nil
+}
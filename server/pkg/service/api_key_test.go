@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type mockAPIKeyQueries struct {
+	keys map[uuid.UUID]db.ApiKey
+}
+
+func (m *mockAPIKeyQueries) CreateApiKey(ctx context.Context, arg db.CreateApiKeyParams) (db.ApiKey, error) {
+	key := db.ApiKey{ID: uuid.New(), UserID: arg.UserID, Name: arg.Name, Scopes: arg.Scopes, KeyHash: arg.KeyHash}
+	m.keys[key.ID] = key
+	return key, nil
+}
+
+func (m *mockAPIKeyQueries) ListUserApiKeys(ctx context.Context, userID string) ([]db.ApiKey, error) {
+	var keys []db.ApiKey
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyQueries) GetUserApiKey(ctx context.Context, arg db.GetUserApiKeyParams) (db.ApiKey, error) {
+	key, ok := m.keys[arg.ID]
+	if !ok || key.UserID != arg.UserID {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+	return key, nil
+}
+
+// live returns the user's unrevoked key with id
+func (m *mockAPIKeyQueries) live(id uuid.UUID, userID string) (db.ApiKey, bool) {
+	key, ok := m.keys[id]
+	return key, ok && key.UserID == userID && !key.RevokedAt.Valid
+}
+
+func (m *mockAPIKeyQueries) RevokeApiKey(ctx context.Context, arg db.RevokeApiKeyParams) (db.ApiKey, error) {
+	key, ok := m.live(arg.ID, arg.UserID)
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+	key.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	m.keys[key.ID] = key
+	return key, nil
+}
+
+func (m *mockAPIKeyQueries) RevokePreviousApiKey(ctx context.Context, arg db.RevokePreviousApiKeyParams) (db.ApiKey, error) {
+	key, ok := m.live(arg.ID, arg.UserID)
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+	key.PreviousKeyHash = nil
+	key.PreviousKeyExpiresAt = pgtype.Timestamptz{}
+	m.keys[key.ID] = key
+	return key, nil
+}
+
+func (m *mockAPIKeyQueries) RotateApiKey(ctx context.Context, arg db.RotateApiKeyParams) (db.ApiKey, error) {
+	key, ok := m.live(arg.ID, arg.UserID)
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+	previous := key.KeyHash
+	key.PreviousKeyHash = &previous
+	key.PreviousKeyExpiresAt = arg.PreviousKeyExpiresAt
+	key.PreviousKeyLastUsedAt = pgtype.Timestamptz{}
+	key.KeyHash = arg.KeyHash
+	key.KeyRotatedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	m.keys[key.ID] = key
+	return key, nil
+}
+
+func (m *mockAPIKeyQueries) UseApiKey(ctx context.Context, arg db.UseApiKeyParams) (db.ApiKey, error) {
+	now := time.Now()
+	for _, key := range m.keys {
+		current := key.KeyHash == arg.KeyHash
+		previous := key.PreviousKeyHash != nil && *key.PreviousKeyHash == arg.KeyHash && key.PreviousKeyExpiresAt.Time.After(now)
+		if (!current && !previous) || key.RevokedAt.Valid {
+			continue
+		}
+		key.LastUsedAt = pgtype.Timestamptz{Time: now, Valid: true}
+		key.LastUsedIp = arg.Ip
+		key.UseCount++
+		if previous {
+			key.PreviousKeyLastUsedAt = key.LastUsedAt
+		}
+		m.keys[key.ID] = key
+		return key, nil
+	}
+	return db.ApiKey{}, sql.ErrNoRows
+}
+
+func TestAPIKeyService_CreateAuthenticateRevoke(t *testing.T) {
+	queries := &mockAPIKeyQueries{keys: map[uuid.UUID]db.ApiKey{}}
+	s := NewAPIKeyService(queries, createTestLogger())
+	ctx := context.Background()
+
+	created, key, err := s.Create(ctx, "user_1", "Deploy script", nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		t.Errorf("key = %q, want the %q prefix", key, apiKeyPrefix)
+	}
+	if stored := queries.keys[created.ID].KeyHash; stored == key || stored != hashSecretToken(key) {
+		t.Errorf("stored key hash = %q, want the SHA-256 of the key", stored)
+	}
+	// Keys created without scopes get those of the links routes
+	if !slices.Equal(created.Scopes, normalizeScopes(DefaultAPIKeyScopes)) {
+		t.Errorf("scopes = %v, want %v", created.Scopes, DefaultAPIKeyScopes)
+	}
+
+	got, err := s.Authenticate(ctx, key, netip.Addr{})
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if got.ID != created.ID || got.UserID != "user_1" {
+		t.Errorf("Authenticate() = %+v, want the created key of user_1", got)
+	}
+
+	for _, bad := range []string{"", "ak_unknown", strings.TrimPrefix(key, apiKeyPrefix)} {
+		if _, err := s.Authenticate(ctx, bad, netip.Addr{}); !errors.Is(err, apperrors.InvalidAPIKey) {
+			t.Errorf("Authenticate(%q) error = %v, want InvalidAPIKey", bad, err)
+		}
+	}
+
+	// Only the owner can revoke a key
+	if _, err := s.Revoke(ctx, "user_2", created.ID); !errors.Is(err, apperrors.APIKeyNotFound) {
+		t.Errorf("Revoke() by another user error = %v, want APIKeyNotFound", err)
+	}
+	if _, err := s.Revoke(ctx, "user_1", created.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := s.Authenticate(ctx, key, netip.Addr{}); !errors.Is(err, apperrors.InvalidAPIKey) {
+		t.Errorf("Authenticate() with a revoked key error = %v, want InvalidAPIKey", err)
+	}
+	if _, err := s.Revoke(ctx, "user_1", created.ID); !errors.Is(err, apperrors.APIKeyNotFound) {
+		t.Errorf("Revoke() of a revoked key error = %v, want APIKeyNotFound", err)
+	}
+
+	// Revoked keys stay listed
+	keys, err := s.List(ctx, "user_1")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(keys) != 1 || !keys[0].RevokedAt.Valid {
+		t.Errorf("List() = %+v, want the revoked key", keys)
+	}
+}
+
+func TestAPIKeyService_Rotate(t *testing.T) {
+	queries := &mockAPIKeyQueries{keys: map[uuid.UUID]db.ApiKey{}}
+	s := NewAPIKeyService(queries, createTestLogger())
+	ctx := context.Background()
+
+	created, original, err := s.Create(ctx, "user_1", "Deploy script", []string{auth.ScopeLinksRead})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, _, err := s.Rotate(ctx, "user_2", created.ID, time.Hour); !errors.Is(err, apperrors.APIKeyNotFound) {
+		t.Errorf("Rotate() by another user error = %v, want %v", err, apperrors.APIKeyNotFound)
+	}
+	_, rotated, err := s.Rotate(ctx, "user_1", created.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if rotated == original {
+		t.Fatal("Rotate() returned the same key")
+	}
+	for name, key := range map[string]string{"new": rotated, "previous": original} {
+		if _, err := s.Authenticate(ctx, key, netip.MustParseAddr("203.0.113.7")); err != nil {
+			t.Errorf("Authenticate() with the %s key during the grace period error = %v", name, err)
+		}
+	}
+
+	// Both uses are recorded, and the previous key shows as still in use
+	usage, err := s.Get(ctx, "user_1", created.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if usage.UseCount != 2 || usage.LastUsedIp == nil || *usage.LastUsedIp != "203.0.113.7" || !usage.PreviousKeyLastUsedAt.Valid {
+		t.Errorf("Get() = %+v, want 2 uses from 203.0.113.7, the previous key's included", usage)
+	}
+
+	if _, err := s.RevokePrevious(ctx, "user_1", created.ID); err != nil {
+		t.Fatalf("RevokePrevious() error = %v", err)
+	}
+	if _, err := s.Authenticate(ctx, original, netip.Addr{}); !errors.Is(err, apperrors.InvalidAPIKey) {
+		t.Errorf("Authenticate() with a revoked previous key error = %v, want %v", err, apperrors.InvalidAPIKey)
+	}
+
+	// A zero grace revokes the current key with the rotation
+	_, latest, err := s.Rotate(ctx, "user_1", created.ID, 0)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := s.Authenticate(ctx, rotated, netip.Addr{}); !errors.Is(err, apperrors.InvalidAPIKey) {
+		t.Errorf("Authenticate() with a key rotated out without grace error = %v, want %v", err, apperrors.InvalidAPIKey)
+	}
+	if _, err := s.Authenticate(ctx, latest, netip.Addr{}); err != nil {
+		t.Errorf("Authenticate() with the latest key error = %v", err)
+	}
+
+	// The grace period is capped
+	before := time.Now()
+	if _, _, err := s.Rotate(ctx, "user_1", created.ID, 30*24*time.Hour); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if expires := queries.keys[created.ID].PreviousKeyExpiresAt.Time; expires.After(before.Add(MaxAPIKeyGrace).Add(time.Minute)) {
+		t.Errorf("previous key expires at %v, want at most %v after rotation", expires, MaxAPIKeyGrace)
+	}
+
+	// Revoking the key ends the previous one's grace period too
+	_, current, err := s.Rotate(ctx, "user_1", created.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := s.Revoke(ctx, "user_1", created.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	for _, key := range []string{latest, current} {
+		if _, err := s.Authenticate(ctx, key, netip.Addr{}); !errors.Is(err, apperrors.InvalidAPIKey) {
+			t.Errorf("Authenticate() after revoking error = %v, want %v", err, apperrors.InvalidAPIKey)
+		}
+	}
+	if _, _, err := s.Rotate(ctx, "user_1", created.ID, time.Hour); !errors.Is(err, apperrors.APIKeyNotFound) {
+		t.Errorf("Rotate() of a revoked key error = %v, want %v", err, apperrors.APIKeyNotFound)
+	}
+}
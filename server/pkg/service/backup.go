@@ -0,0 +1,394 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/backup"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/objectstore"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Longest an upload or download of a backup may take
+	BackupTransferTimeout = time.Hour
+	// Largest backup downloaded
+	MaxBackupBytes = 64 << 30
+
+	// Rows inserted per statement of a restore
+	backupRestoreBatch = 500
+	// Longest a scheduled backup may take, upload and pruning included
+	backupTimeout = 2 * time.Hour
+	// How often the backup job checks whether a backup is due, at most
+	backupCheckInterval = 15 * time.Minute
+
+	backupKeyName       = "backup-"
+	backupKeyExt        = ".gz"
+	backupKeyTimeFormat = "20060102T150405Z"
+)
+
+var (
+	// The database restored to already holds data
+	ErrRestoreNotEmpty = errors.New("database to restore to is not empty")
+	// The database restored to isn't at the backup's migration
+	ErrBackupSchemaMismatch = errors.New("backup and database schema versions differ")
+)
+
+// BackupStorage is where backups are kept, an S3-compatible bucket (see package objectstore)
+type BackupStorage interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	List(ctx context.Context, prefix string) ([]objectstore.Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type BackupOptions struct {
+	// Prepended to the keys of the backups, e.g. "url-shortener/"
+	Prefix string
+	// How often the backup job takes a backup
+	Interval time.Duration
+	// How long backups are kept; 0 keeps them all. The latest is kept whatever its age
+	Retention time.Duration
+	// Back up the raw clicks and the conversions attributed to them, usually the bulk of the
+	// database. Without them, restored stats go as far as link_daily_stats had rolled up
+	IncludeClicks bool
+}
+
+/*
+BackupService backs up the Postgres metadata (links, tags, campaigns, webhooks
+and the rest of db.BackupTables) to object storage, and restores it. A backup is
+a logical export in the format of package backup, read in one REPEATABLE READ
+transaction so its tables are consistent with each other, and stored under
+<Prefix>backup-<UTC time>.gz. Destination URLs are backed up as stored: with
+URL_ENCRYPTION_KEYS set, restores need the same keys to read them.
+
+The backup job (see Start) takes a backup once the latest is Interval old and
+then deletes the ones older than Retention.
+*/
+type BackupService struct {
+	tx      repository.Transactor[repository.BackupQueries]
+	storage BackupStorage
+	opts    BackupOptions
+	logger  logger.Logger
+}
+
+func NewBackupService(tx repository.Transactor[repository.BackupQueries], storage BackupStorage, opts BackupOptions, logger logger.Logger) *BackupService {
+	return &BackupService{
+		tx:      tx,
+		storage: storage,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Backup takes a backup and uploads it, returning its key
+func (s *BackupService) Backup(ctx context.Context) (string, error) {
+	// Backups cover every user's rows, whatever the context is scoped to
+	ctx = db.WithRowSecurityUser(ctx, "")
+
+	// Spooled to disk, as uploads need the size and hash of the whole backup up front
+	f, err := os.CreateTemp("", "backup-*"+backupKeyExt)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	createdAt := time.Now().UTC()
+	var tables []backup.Table
+	err = s.tx.WithTx(ctx, func(q repository.BackupQueries) error {
+		if err := q.BeginBackupSnapshot(ctx); err != nil {
+			return fmt.Errorf("failed to begin backup snapshot: %w", err)
+		}
+		version, err := q.GetSchemaVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get schema version: %w", err)
+		}
+
+		w, err := backup.NewWriter(f, backup.Header{CreatedAt: createdAt, SchemaVersion: version})
+		if err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+		for _, table := range db.BackupTables {
+			if table.Clicks && !s.opts.IncludeClicks {
+				continue
+			}
+			if err := w.Table(table.Name); err != nil {
+				return fmt.Errorf("failed to write backup: %w", err)
+			}
+			if err := q.ExportBackupTable(ctx, table, w.Row); err != nil {
+				return fmt.Errorf("failed to export %s: %w", table.Name, err)
+			}
+		}
+		tables = w.Tables()
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind backup file: %w", err)
+	}
+	key := s.opts.Prefix + backupKeyName + createdAt.Format(backupKeyTimeFormat) + backupKeyExt
+	if err := s.storage.Put(ctx, key, f); err != nil {
+		return "", fmt.Errorf("failed to upload backup: %w", err)
+	}
+
+	s.logger.Info("Backup taken",
+		zap.String("key", key),
+		zap.Int64("rows", totalRows(tables)),
+	)
+	return key, nil
+}
+
+// List returns the backups in storage, oldest first
+func (s *BackupService) List(ctx context.Context) ([]objectstore.Object, error) {
+	objects, err := s.storage.List(ctx, s.opts.Prefix+backupKeyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := objects[:0]
+	for _, o := range objects {
+		if _, ok := backupTime(strings.TrimPrefix(o.Key, s.opts.Prefix)); ok {
+			backups = append(backups, o)
+		}
+	}
+	// Keys sort by the time they hold
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Key < backups[j].Key })
+	return backups, nil
+}
+
+// Prune deletes the backups taken over Retention before now, except the latest, and returns how many it deleted
+func (s *BackupService) Prune(ctx context.Context, now time.Time) (int, error) {
+	if s.opts.Retention <= 0 {
+		return 0, nil
+	}
+
+	backups, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, o := range backups[:max(len(backups)-1, 0)] {
+		takenAt, _ := backupTime(strings.TrimPrefix(o.Key, s.opts.Prefix))
+		if now.Sub(takenAt) <= s.opts.Retention {
+			continue
+		}
+		if err := s.storage.Delete(ctx, o.Key); err != nil {
+			return deleted, fmt.Errorf("failed to delete backup %s: %w", o.Key, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Fetch returns the content of the backup at key, or of the latest backup when key is empty
+func (s *BackupService) Fetch(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	if key == "" {
+		backups, err := s.List(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(backups) == 0 {
+			return nil, "", fmt.Errorf("no backups under %q: %w", s.opts.Prefix, objectstore.ErrNotFound)
+		}
+		key = backups[len(backups)-1].Key
+	}
+
+	body, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, key, fmt.Errorf("failed to download backup %s: %w", key, err)
+	}
+	return body, key, nil
+}
+
+/*
+Restore restores a backup into the database, which must be at the backup's
+migration and hold no data: a freshly migrated one. The backup is checked
+while it's inserted in one transaction, which is rolled back unless all of it
+is intact. It returns the backup's header and tables.
+*/
+func (s *BackupService) Restore(ctx context.Context, r io.Reader) (backup.Header, []backup.Table, error) {
+	ctx = db.WithRowSecurityUser(ctx, "")
+
+	br, err := backup.NewReader(r)
+	if err != nil {
+		return backup.Header{}, nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	header := br.Header()
+
+	err = s.tx.WithTx(ctx, func(q repository.BackupQueries) error {
+		version, err := q.GetSchemaVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get schema version: %w", err)
+		}
+		if version != header.SchemaVersion {
+			return fmt.Errorf("%w: backup is at migration %s, database at %q", ErrBackupSchemaMismatch, header.SchemaVersion, version)
+		}
+		for _, table := range db.BackupTables {
+			empty, err := q.IsBackupTableEmpty(ctx, table)
+			if err != nil {
+				return fmt.Errorf("failed to check %s: %w", table.Name, err)
+			}
+			if !empty {
+				return fmt.Errorf("%w: %s has rows", ErrRestoreNotEmpty, table.Name)
+			}
+		}
+
+		var table db.BackupTable
+		batch := make([][]byte, 0, backupRestoreBatch)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			if err := q.RestoreBackupRows(ctx, table, batch); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", table.Name, err)
+			}
+			batch = batch[:0]
+			return nil
+		}
+
+		for {
+			name, row, err := br.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read backup: %w", err)
+			}
+
+			if name != table.Name {
+				if err := flush(); err != nil {
+					return err
+				}
+				var ok bool
+				if table, ok = db.LookupBackupTable(name); !ok {
+					return fmt.Errorf("failed to read backup: %w: unknown table %s", backup.ErrFormat, name)
+				}
+			}
+			batch = append(batch, bytes.Clone(row))
+			if len(batch) == backupRestoreBatch {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		for _, table := range db.BackupTables {
+			if err := q.ResetBackupSerial(ctx, table); err != nil {
+				return fmt.Errorf("failed to reset the sequence of %s: %w", table.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return header, nil, err
+	}
+
+	tables := br.Tables()
+	s.logger.Info("Backup restored",
+		zap.Time("created_at", header.CreatedAt),
+		zap.String("schema_version", header.SchemaVersion),
+		zap.Int64("rows", totalRows(tables)),
+	)
+	return header, tables, nil
+}
+
+// Start checks whether a backup is due now and then every so often until ctx is done, see BackupService
+func (s *BackupService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(min(s.opts.Interval, backupCheckInterval))
+		defer ticker.Stop()
+
+		for {
+			s.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *BackupService) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+
+	// Going by the backups in storage rather than a timer, restarts don't postpone them
+	backups, err := s.List(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Backup check failed",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+	if len(backups) > 0 {
+		takenAt, _ := backupTime(strings.TrimPrefix(backups[len(backups)-1].Key, s.opts.Prefix))
+		if time.Since(takenAt) < s.opts.Interval {
+			return
+		}
+	}
+
+	if _, err := s.Backup(ctx); err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Backup failed",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	deleted, err := s.Prune(ctx, time.Now())
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Backup pruning failed",
+			zap.Error(err),
+		)
+	}
+	if deleted > 0 {
+		s.logger.Info("Old backups deleted",
+			zap.Int("deleted", deleted),
+		)
+	}
+}
+
+// backupTime returns the time a backup's key (without the prefix) holds, and false if it isn't a backup's
+func backupTime(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, backupKeyName) || !strings.HasSuffix(name, backupKeyExt) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupKeyTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, backupKeyName), backupKeyExt))
+	return t, err == nil
+}
+
+func totalRows(tables []backup.Table) int64 {
+	var rows int64
+	for _, t := range tables {
+		rows += t.Rows
+	}
+	return rows
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/backup"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/objectstore"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+// memoryBackupStorage keeps backups in memory
+type memoryBackupStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memoryBackupStorage) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryBackupStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, objectstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryBackupStorage) List(ctx context.Context, prefix string) ([]objectstore.Object, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var objects []objectstore.Object
+	for key, data := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, objectstore.Object{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (m *memoryBackupStorage) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// backupDatabase mocks the backup queries over tables of JSON rows
+func backupDatabase(version string, tables map[string][]string) (*mocks.BackupQueries, map[string][]string, *[]string) {
+	restored := map[string][]string{}
+	var serials []string
+	return &mocks.BackupQueries{
+		BeginBackupSnapshotFunc: func(ctx context.Context) error {
+			return nil
+		},
+		GetSchemaVersionFunc: func(ctx context.Context) (string, error) {
+			return version, nil
+		},
+		ExportBackupTableFunc: func(ctx context.Context, table db.BackupTable, fn func(row []byte) error) error {
+			for _, row := range tables[table.Name] {
+				if err := fn([]byte(row)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		IsBackupTableEmptyFunc: func(ctx context.Context, table db.BackupTable) (bool, error) {
+			return len(tables[table.Name]) == 0, nil
+		},
+		RestoreBackupRowsFunc: func(ctx context.Context, table db.BackupTable, rows [][]byte) error {
+			for _, row := range rows {
+				restored[table.Name] = append(restored[table.Name], string(row))
+			}
+			return nil
+		},
+		ResetBackupSerialFunc: func(ctx context.Context, table db.BackupTable) error {
+			if table.Serial != "" {
+				serials = append(serials, table.Name)
+			}
+			return nil
+		},
+	}, restored, &serials
+}
+
+func TestBackupService_BackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	storage := &memoryBackupStorage{objects: map[string][]byte{}}
+	source, _, _ := backupDatabase("000048", map[string][]string{
+		"links":      {`{"id":"a","shortcode":"abc"}`, `{"id":"b","merged_into":"a"}`},
+		"tags":       {`{"id":"t","name":"news"}`},
+		"clicks":     {`{"id":1,"link_id":"a"}`},
+		"link_leads": {`{"id":7,"email":"someone@example.com"}`},
+	})
+	backups := NewBackupService(&mocks.Transactor[repository.BackupQueries]{Queries: source}, storage,
+		BackupOptions{Prefix: "prod/"}, createTestLogger())
+
+	key, err := backups.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if !strings.HasPrefix(key, "prod/backup-") {
+		t.Errorf("key = %q, want it under the prefix", key)
+	}
+
+	body, fetched, err := backups.Fetch(ctx, "")
+	if err != nil || fetched != key {
+		t.Fatalf("Fetch() of the latest = %q, %v, want %q", fetched, err, key)
+	}
+	data, _ := io.ReadAll(body)
+
+	target, restored, serials := backupDatabase("000048", nil)
+	tx := &mocks.Transactor[repository.BackupQueries]{Queries: target}
+	restores := NewBackupService(tx, storage, BackupOptions{}, createTestLogger())
+	header, tables, err := restores.Restore(ctx, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if header.SchemaVersion != "000048" || !tx.Committed {
+		t.Errorf("Restore() = %+v, committed %t", header, tx.Committed)
+	}
+	if len(restored["links"]) != 2 || restored["links"][1] != `{"id":"b","merged_into":"a"}` || len(restored["tags"]) != 1 {
+		t.Errorf("restored = %v, want the links, in order, and the tag", restored)
+	}
+	if _, ok := restored["clicks"]; ok {
+		t.Error("clicks were backed up without IncludeClicks")
+	}
+	if totalRows(tables) != 4 {
+		t.Errorf("tables = %+v, want 4 rows", tables)
+	}
+	// Sequences are moved past the restored rows, of the tables that were left out too
+	if len(*serials) != 3 {
+		t.Errorf("serials reset = %v, want clicks, link_leads and conversions", *serials)
+	}
+
+	t.Run("not empty", func(t *testing.T) {
+		target, _, _ := backupDatabase("000048", map[string][]string{"api_keys": {`{"id":"k"}`}})
+		tx := &mocks.Transactor[repository.BackupQueries]{Queries: target}
+		_, _, err := NewBackupService(tx, storage, BackupOptions{}, createTestLogger()).Restore(ctx, bytes.NewReader(data))
+		if !errors.Is(err, ErrRestoreNotEmpty) || !tx.RolledBack {
+			t.Errorf("Restore() error = %v, want ErrRestoreNotEmpty and a rollback", err)
+		}
+	})
+
+	t.Run("other schema version", func(t *testing.T) {
+		target, _, _ := backupDatabase("000049", nil)
+		_, _, err := NewBackupService(&mocks.Transactor[repository.BackupQueries]{Queries: target}, storage, BackupOptions{}, createTestLogger()).
+			Restore(ctx, bytes.NewReader(data))
+		if !errors.Is(err, ErrBackupSchemaMismatch) {
+			t.Errorf("Restore() error = %v, want ErrBackupSchemaMismatch", err)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		target, _, _ := backupDatabase("000048", nil)
+		tx := &mocks.Transactor[repository.BackupQueries]{Queries: target}
+		_, _, err := NewBackupService(tx, storage, BackupOptions{}, createTestLogger()).Restore(ctx, bytes.NewReader(data[:len(data)-20]))
+		if !errors.Is(err, backup.ErrTruncated) || !tx.RolledBack {
+			t.Errorf("Restore() error = %v, want ErrTruncated and a rollback", err)
+		}
+	})
+}
+
+func TestBackupService_Prune(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	storage := &memoryBackupStorage{objects: map[string][]byte{
+		"prod/backup-20261001T090000Z.gz":    nil,
+		"prod/backup-20261010T090000Z.gz":    nil,
+		"prod/backup-20261014T090000Z.gz":    nil,
+		"prod/notes.txt":                     nil,
+		"staging/backup-20261001T090000Z.gz": nil,
+	}}
+	s := NewBackupService(nil, storage, BackupOptions{Prefix: "prod/", Retention: 7 * 24 * time.Hour}, createTestLogger())
+
+	deleted, err := s.Prune(ctx, now)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Prune() = %d, want the backup of Oct 1 deleted", deleted)
+	}
+	backups, _ := s.List(ctx)
+	if len(backups) != 2 || backups[0].Key != "prod/backup-20261010T090000Z.gz" {
+		t.Errorf("List() = %+v, want the two recent backups", backups)
+	}
+
+	// The latest backup is kept however old it is
+	if deleted, _ := s.Prune(ctx, now.AddDate(1, 0, 0)); deleted != 1 {
+		t.Errorf("Prune() a year later = %d, want all but the latest deleted", deleted)
+	}
+	if _, ok := storage.objects["prod/backup-20261014T090000Z.gz"]; !ok {
+		t.Error("the latest backup was deleted")
+	}
+	if len(storage.objects) != 3 {
+		t.Errorf("objects = %d, want the other prefix and files untouched", len(storage.objects))
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+	"go.uber.org/zap"
+)
+
+// Why BotShield challenged a request
+const (
+	ShieldReasonUserAgent  = "user_agent"
+	ShieldReasonDatacenter = "datacenter"
+	ShieldReasonRate       = "rate"
+)
+
+const (
+	// ShieldCookie carries the solved challenge
+	ShieldCookie = "shield_pass"
+	// Redis key prefix for per-client request counters on shielded links
+	shieldRateKeyPrefix = "shield:rate:"
+	// Leading zero hex digits the SHA-256 of a solved challenge must have
+	shieldDifficulty = 3
+)
+
+// User agent fragments of headless browsers and HTTP libraries; real browsers send none of them
+var automatedUserAgents = []string{
+	"headlesschrome",
+	"phantomjs",
+	"puppeteer",
+	"playwright",
+	"selenium",
+	"webdriver",
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"aiohttp",
+	"go-http-client",
+	"okhttp",
+	"java/",
+	"apache-httpclient",
+	"libwww-perl",
+	"node-fetch",
+	"axios/",
+	"scrapy",
+}
+
+type BotShieldOptions struct {
+	// Signs challenges; the shield is off without one
+	Secret string
+	// Networks of hosting providers, e.g. the prefixes announced by their ASNs
+	DatacenterRanges []netip.Prefix
+	// Requests one client can make to shielded links per RateWindow before it's challenged
+	// even with a solved challenge; 0 disables the check
+	RateLimit  int64
+	RateWindow time.Duration
+	// How long a solved challenge lets the client through
+	PassTTL time.Duration
+	// Proxies whose X-Forwarded-For is trusted to identify the client
+	TrustedProxies []netip.Prefix
+}
+
+/*
+BotShield protects links with shield mode on (typically paid campaign
+destinations) from click fraud. A client is suspected of being a bot when its
+user agent is a headless browser or HTTP library, when it connects from a
+datacenter network or when it requests shielded links faster than a person
+would.
+
+Suspects get a challenge page instead of the redirect: a small proof of work
+in JavaScript, bound to the client's network, whose solution is stored in the
+ShieldCookie. Requests carrying a valid solution are let through until it
+expires, so people only solve it once per PassTTL and clients that don't run
+JavaScript never reach the destination.
+
+Without Redis the rate check is skipped, and Redis errors fail open.
+*/
+type BotShield struct {
+	cache  *redis.Client
+	opts   BotShieldOptions
+	logger logger.Logger
+}
+
+func NewBotShield(cache *redis.Client, opts BotShieldOptions, logger logger.Logger) *BotShield {
+	return &BotShield{
+		cache:  cache,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Enabled reports whether a signing secret is configured
+func (s *BotShield) Enabled() bool {
+	return s != nil && s.opts.Secret != ""
+}
+
+// ClientIP returns the address the heuristics and challenges apply to
+func (s *BotShield) ClientIP(r *http.Request) netip.Addr {
+	return netutil.ClientIP(r, s.opts.TrustedProxies)
+}
+
+// PassTTL is how long a solved challenge is valid
+func (s *BotShield) PassTTL() time.Duration {
+	return s.opts.PassTTL
+}
+
+// Difficulty is the number of leading zero hex digits a solution's SHA-256 must have
+func (s *BotShield) Difficulty() int {
+	return shieldDifficulty
+}
+
+// Suspect returns why the client looks automated, or "" when it doesn't
+func (s *BotShield) Suspect(addr netip.Addr, userAgent string) string {
+	if isAutomatedUserAgent(userAgent) {
+		return ShieldReasonUserAgent
+	}
+
+	for _, prefix := range s.opts.DatacenterRanges {
+		if prefix.Contains(addr) {
+			return ShieldReasonDatacenter
+		}
+	}
+
+	return ""
+}
+
+// OverRate counts a request from the client and reports whether it went over the rate limit
+func (s *BotShield) OverRate(ctx context.Context, addr netip.Addr) bool {
+	if s.cache == nil || s.opts.RateLimit <= 0 {
+		return false
+	}
+
+	key := shieldRateKeyPrefix + netutil.RateLimitKey(addr)
+
+	pipe := s.cache.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	// Fixed window, like the enumeration guard
+	pipe.ExpireNX(ctx, key, s.opts.RateWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.Warn("Failed to count shielded request",
+			zap.Error(err),
+		)
+		return false
+	}
+
+	return incr.Val() > s.opts.RateLimit
+}
+
+// Challenge returns a challenge for the client: "<issued unix seconds>.<base64url HMAC>"
+func (s *BotShield) Challenge(addr netip.Addr, now time.Time) string {
+	issued := strconv.FormatInt(now.Unix(), 10)
+	return issued + "." + base64.RawURLEncoding.EncodeToString(s.mac(addr, issued))
+}
+
+// VerifyPass reports whether pass is a solved challenge ("<challenge>.<nonce>")
+// issued to the client's network less than PassTTL ago
+func (s *BotShield) VerifyPass(pass string, addr netip.Addr, now time.Time) bool {
+	if !s.Enabled() || pass == "" {
+		return false
+	}
+
+	parts := strings.Split(pass, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	issued, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || now.After(time.Unix(issued, 0).Add(s.opts.PassTTL)) {
+		return false
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, s.mac(addr, parts[0])) {
+		return false
+	}
+
+	return solvesChallenge(pass, shieldDifficulty)
+}
+
+func (s *BotShield) mac(addr netip.Addr, issued string) []byte {
+	m := hmac.New(sha256.New, []byte(s.opts.Secret))
+	m.Write([]byte(netutil.RateLimitKey(addr)))
+	m.Write([]byte{0})
+	m.Write([]byte(issued))
+	return m.Sum(nil)
+}
+
+// solvesChallenge reports whether the hex SHA-256 of the solved challenge starts with difficulty zeros
+func solvesChallenge(pass string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(pass))
+	return strings.HasPrefix(hex.EncodeToString(sum[:]), strings.Repeat("0", difficulty))
+}
+
+func isAutomatedUserAgent(userAgent string) bool {
+	// Browsers always send one
+	if strings.TrimSpace(userAgent) == "" {
+		return true
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	for _, fragment := range automatedUserAgents {
+		if strings.Contains(userAgent, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testShieldSecret = "0123456789abcdef0123456789abcdef"
+
+// solveChallenge does what the challenge page's script does
+func solveChallenge(challenge string) string {
+	for nonce := 0; ; nonce++ {
+		pass := challenge + "." + strconv.Itoa(nonce)
+		if solvesChallenge(pass, shieldDifficulty) {
+			return pass
+		}
+	}
+}
+
+func TestBotShield_Suspect(t *testing.T) {
+	shield := NewBotShield(nil, BotShieldOptions{
+		Secret:           testShieldSecret,
+		DatacenterRanges: []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+	}, createTestLogger())
+
+	browser := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"
+
+	tests := []struct {
+		name      string
+		addr      string
+		userAgent string
+		want      string
+	}{
+		{name: "browser", addr: "203.0.113.7", userAgent: browser},
+		{name: "headless chrome", addr: "203.0.113.7", userAgent: "Mozilla/5.0 HeadlessChrome/124.0", want: ShieldReasonUserAgent},
+		{name: "http library", addr: "203.0.113.7", userAgent: "python-requests/2.31", want: ShieldReasonUserAgent},
+		{name: "no user agent", addr: "203.0.113.7", want: ShieldReasonUserAgent},
+		{name: "datacenter network", addr: "198.51.100.20", userAgent: browser, want: ShieldReasonDatacenter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shield.Suspect(netip.MustParseAddr(tt.addr), tt.userAgent); got != tt.want {
+				t.Errorf("Suspect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotShield_VerifyPass(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	client := netip.MustParseAddr("2001:db8:1:2::10")
+	shield := NewBotShield(nil, BotShieldOptions{Secret: testShieldSecret, PassTTL: 30 * time.Minute}, createTestLogger())
+
+	pass := solveChallenge(shield.Challenge(client, now))
+
+	tests := []struct {
+		name string
+		pass string
+		addr string
+		at   time.Time
+		want bool
+	}{
+		{name: "solved", pass: pass, addr: "2001:db8:1:2::10", at: now.Add(time.Minute), want: true},
+		{name: "same /64", pass: pass, addr: "2001:db8:1:2::99", at: now, want: true},
+		{name: "other network", pass: pass, addr: "2001:db8:1:3::10", at: now},
+		{name: "expired", pass: pass, addr: "2001:db8:1:2::10", at: now.Add(31 * time.Minute)},
+		{name: "unsolved", pass: shield.Challenge(client, now) + ".x", addr: "2001:db8:1:2::10", at: now},
+		{name: "forged", pass: solveChallenge(strconv.FormatInt(now.Unix(), 10) + ".bm9wZQ"), addr: "2001:db8:1:2::10", at: now},
+		{name: "empty", addr: "2001:db8:1:2::10", at: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shield.VerifyPass(tt.pass, netip.MustParseAddr(tt.addr), tt.at); got != tt.want {
+				t.Errorf("VerifyPass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotShield_OverRate(t *testing.T) {
+	mr := miniredis.RunT(t)
+	shield := NewBotShield(redis.NewClient(&redis.Options{Addr: mr.Addr()}), BotShieldOptions{
+		Secret:     testShieldSecret,
+		RateLimit:  2,
+		RateWindow: time.Minute,
+	}, createTestLogger())
+
+	ctx := context.Background()
+	client := netip.MustParseAddr("203.0.113.7")
+
+	for i := range 2 {
+		if shield.OverRate(ctx, client) {
+			t.Fatalf("OverRate() request %d = true, want false", i+1)
+		}
+	}
+	if !shield.OverRate(ctx, client) {
+		t.Error("OverRate() over the limit = false, want true")
+	}
+	if shield.OverRate(ctx, netip.MustParseAddr("203.0.113.8")) {
+		t.Error("OverRate() for another client = true, want false")
+	}
+
+	mr.FastForward(time.Minute)
+	if shield.OverRate(ctx, client) {
+		t.Error("OverRate() after the window = true, want false")
+	}
+}
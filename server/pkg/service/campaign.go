@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// CampaignService manages campaigns: time-bounded groups of links with their own reports.
+// Unlike tags, a campaign carries a date range and a budget note.
+type CampaignService struct {
+	queries repository.CampaignQueries
+	logger  logger.Logger
+}
+
+func NewCampaignService(queries repository.CampaignQueries, logger logger.Logger) *CampaignService {
+	return &CampaignService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (s *CampaignService) ListCampaigns(ctx context.Context, userID string) ([]db.ListUserCampaignsRow, error) {
+	campaigns, err := s.queries.ListUserCampaigns(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+
+	s.logger.Debug("Database query completed for ListUserCampaigns",
+		zap.String("user_id", userID),
+		zap.Int("campaigns_found", len(campaigns)),
+	)
+
+	return campaigns, nil
+}
+
+func (s *CampaignService) GetCampaign(ctx context.Context, userID string, id uuid.UUID) (db.GetCampaignByIdAndUserRow, error) {
+	campaign, err := s.queries.GetCampaignByIdAndUser(ctx, db.GetCampaignByIdAndUserParams{
+		ID:     id,
+		UserID: userID,
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.GetCampaignByIdAndUserRow{}, fmt.Errorf("%w: %v", apperrors.CampaignNotFound, err)
+		}
+
+		return db.GetCampaignByIdAndUserRow{}, fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	return campaign, nil
+}
+
+func (s *CampaignService) CreateCampaign(
+	ctx context.Context,
+	userID string,
+	name string,
+	budgetNote *string,
+	startsAt *time.Time,
+	endsAt *time.Time,
+) (db.CreateCampaignRow, error) {
+	createdCampaign, err := s.queries.CreateCampaign(ctx, db.CreateCampaignParams{
+		Name:       name,
+		BudgetNote: budgetNote,
+		StartsAt:   toTimestamp(startsAt),
+		EndsAt:     toTimestamp(endsAt),
+		UserID:     userID,
+	})
+
+	if err != nil {
+		if cErr := campaignConstraintError(err, name); cErr != nil {
+			return db.CreateCampaignRow{}, cErr
+		}
+
+		return db.CreateCampaignRow{}, fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	return createdCampaign, nil
+}
+
+// UpdateCampaign applies a partial update; nil fields are left unchanged
+func (s *CampaignService) UpdateCampaign(
+	ctx context.Context,
+	userID string,
+	id uuid.UUID,
+	name *string,
+	budgetNote *string,
+	startsAt *time.Time,
+	endsAt *time.Time,
+) (db.UpdateCampaignRow, error) {
+	updatedCampaign, err := s.queries.UpdateCampaign(ctx, db.UpdateCampaignParams{
+		Name:       name,
+		BudgetNote: budgetNote,
+		StartsAt:   toTimestamp(startsAt),
+		EndsAt:     toTimestamp(endsAt),
+		ID:         id,
+		UserID:     userID,
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.UpdateCampaignRow{}, fmt.Errorf("%w: %v", apperrors.CampaignNotFound, err)
+		}
+
+		nameStr := "n/a"
+		if name != nil {
+			nameStr = *name
+		}
+		if cErr := campaignConstraintError(err, nameStr); cErr != nil {
+			return db.UpdateCampaignRow{}, cErr
+		}
+
+		return db.UpdateCampaignRow{}, fmt.Errorf("failed to update campaign: %w", err)
+	}
+
+	return updatedCampaign, nil
+}
+
+func (s *CampaignService) DeleteCampaign(ctx context.Context, userID string, id uuid.UUID) (db.DeleteCampaignRow, error) {
+	deletedCampaign, err := s.queries.DeleteCampaign(ctx, db.DeleteCampaignParams{
+		ID:     id,
+		UserID: userID,
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DeleteCampaignRow{}, fmt.Errorf("%w: %v", apperrors.CampaignNotFound, err)
+		}
+
+		return db.DeleteCampaignRow{}, fmt.Errorf("failed to delete campaign: %w", err)
+	}
+
+	return deletedCampaign, nil
+}
+
+// ListCampaignLinks returns the links attached to the campaign
+func (s *CampaignService) ListCampaignLinks(ctx context.Context, userID string, id uuid.UUID) ([]db.ListCampaignLinksRow, error) {
+	// Resolve the campaign first so an unknown ID is a 404 rather than an empty list
+	if _, err := s.GetCampaign(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	links, err := s.queries.ListCampaignLinks(ctx, db.ListCampaignLinksParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign links: %w", err)
+	}
+
+	return links, nil
+}
+
+// AddLinksToCampaign attaches links to the campaign, ignoring links that don't belong to the user.
+// Returns the campaign's links after the change.
+func (s *CampaignService) AddLinksToCampaign(ctx context.Context, userID string, id uuid.UUID, linkIDs []uuid.UUID) ([]db.ListCampaignLinksRow, error) {
+	if _, err := s.GetCampaign(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	if len(linkIDs) > 0 {
+		err := s.queries.AddLinksToCampaign(ctx, db.AddLinksToCampaignParams{
+			CampaignID: id,
+			UserID:     userID,
+			LinkIDs:    linkIDs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add links to campaign: %w", err)
+		}
+	}
+
+	return s.ListCampaignLinks(ctx, userID, id)
+}
+
+// RemoveLinksFromCampaign detaches links from the campaign.
+// Returns the campaign's links after the change.
+func (s *CampaignService) RemoveLinksFromCampaign(ctx context.Context, userID string, id uuid.UUID, linkIDs []uuid.UUID) ([]db.ListCampaignLinksRow, error) {
+	if _, err := s.GetCampaign(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	if len(linkIDs) > 0 {
+		err := s.queries.RemoveLinksFromCampaign(ctx, db.RemoveLinksFromCampaignParams{
+			CampaignID: id,
+			UserID:     userID,
+			LinkIDs:    linkIDs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove links from campaign: %w", err)
+		}
+	}
+
+	return s.ListCampaignLinks(ctx, userID, id)
+}
+
+// campaignConstraintError maps constraint violations on the campaigns table to app errors.
+// Returns nil if err is not a known constraint violation.
+func campaignConstraintError(err error, name string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation: (user_id, name)
+		return fmt.Errorf("%w: campaign name '%s' already exists", apperrors.CampaignNameTaken, name)
+	case "23514": // check_violation: campaigns_period_check
+		return fmt.Errorf("%w: %v", apperrors.InvalidCampaignPeriod, err)
+	}
+
+	return nil
+}
+
+// toTimestamp maps a nil time to NULL
+func toTimestamp(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{Valid: false}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
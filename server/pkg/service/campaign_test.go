@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+func TestCampaignPeriod(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	ts := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t, Valid: true} }
+
+	tests := []struct {
+		name         string
+		campaign     db.GetCampaignByIdAndUserRow
+		expectedFrom time.Time
+		expectedTo   time.Time
+	}{
+		{
+			name:         "no date range defaults to last 30 days",
+			campaign:     db.GetCampaignByIdAndUserRow{},
+			expectedFrom: now.Add(-campaignDefaultStatsPeriod),
+			expectedTo:   now,
+		},
+		{
+			name: "running campaign reports from start to now",
+			campaign: db.GetCampaignByIdAndUserRow{
+				StartsAt: ts(now.AddDate(0, 0, -5)),
+				EndsAt:   ts(now.AddDate(0, 0, 5)),
+			},
+			expectedFrom: now.AddDate(0, 0, -5),
+			expectedTo:   now,
+		},
+		{
+			name: "finished campaign reports its full range",
+			campaign: db.GetCampaignByIdAndUserRow{
+				StartsAt: ts(now.AddDate(0, -2, 0)),
+				EndsAt:   ts(now.AddDate(0, -1, 0)),
+			},
+			expectedFrom: now.AddDate(0, -2, 0),
+			expectedTo:   now.AddDate(0, -1, 0),
+		},
+		{
+			name: "future campaign reports an empty period",
+			campaign: db.GetCampaignByIdAndUserRow{
+				StartsAt: ts(now.AddDate(0, 0, 1)),
+			},
+			expectedFrom: now,
+			expectedTo:   now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := campaignPeriod(tt.campaign, now)
+
+			if !from.Equal(tt.expectedFrom) {
+				t.Errorf("from = %v, want %v", from, tt.expectedFrom)
+			}
+			if !to.Equal(tt.expectedTo) {
+				t.Errorf("to = %v, want %v", to, tt.expectedTo)
+			}
+		})
+	}
+}
+
+func TestCampaignConstraintError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expectedErr error
+	}{
+		{
+			name:        "unique violation maps to name taken",
+			err:         &pgconn.PgError{Code: "23505"},
+			expectedErr: apperrors.CampaignNameTaken,
+		},
+		{
+			name:        "check violation maps to invalid period",
+			err:         &pgconn.PgError{Code: "23514"},
+			expectedErr: apperrors.InvalidCampaignPeriod,
+		},
+		{
+			name:        "other postgres errors are not mapped",
+			err:         &pgconn.PgError{Code: "23503"},
+			expectedErr: nil,
+		},
+		{
+			name:        "non postgres errors are not mapped",
+			err:         errors.New("connection refused"),
+			expectedErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := campaignConstraintError(tt.err, "Spring launch")
+
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("expected nil, got %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
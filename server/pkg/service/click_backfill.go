@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Clicks copied per batch unless the caller sets it
+const DefaultBackfillBatchSize = 5000
+
+// ClickBatchStore is an analytics backend that takes clicks in bulk
+type ClickBatchStore interface {
+	RecordClicks(ctx context.Context, clicks []analytics.Click) error
+}
+
+// BackfillCheckpoint is how far a backfill got, saved after every batch so it can be resumed
+type BackfillCheckpoint struct {
+	// Clicks from before Until are copied; it's fixed for the whole backfill
+	Until time.Time `json:"until"`
+	// ID of the last Postgres click copied
+	AfterID int64 `json:"after_id"`
+	Copied  int64 `json:"copied"`
+}
+
+/*
+ClickBackfill copies the raw clicks recorded in Postgres to another analytics
+backend (ClickHouse), in click ID order and in batches, so a deployment moving
+to it keeps its history. Raw clicks are never pruned, so they hold the full
+history; link_daily_stats is derived from them and isn't copied.
+
+Run it with Until set to when double-writing was turned on (see
+analytics.DoubleWrite): clicks from then on already reach the new backend.
+The checkpoint file is rewritten after every batch and a later run resumes
+from it. If the process dies between an insert and its checkpoint, that one
+batch is copied again.
+*/
+type ClickBackfill struct {
+	queries        repository.ClickBackfillQueries
+	dest           ClickBatchStore
+	checkpointPath string
+	batchSize      int32
+	logger         logger.Logger
+}
+
+func NewClickBackfill(queries repository.ClickBackfillQueries, dest ClickBatchStore, checkpointPath string, batchSize int32, logger logger.Logger) *ClickBackfill {
+	if batchSize <= 0 {
+		batchSize = DefaultBackfillBatchSize
+	}
+
+	return &ClickBackfill{
+		queries:        queries,
+		dest:           dest,
+		checkpointPath: checkpointPath,
+		batchSize:      batchSize,
+		logger:         logger,
+	}
+}
+
+/*
+Checkpoint returns the saved checkpoint for a backfill up to until, or a new one.
+A zero until resumes the saved checkpoint whatever its Until; a different one is
+an error, as resuming with another bound would leave a gap or copy clicks twice.
+*/
+func (b *ClickBackfill) Checkpoint(until time.Time) (BackfillCheckpoint, error) {
+	data, err := os.ReadFile(b.checkpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		if until.IsZero() {
+			return BackfillCheckpoint{}, errors.New("no checkpoint to resume, the until time is required")
+		}
+		return BackfillCheckpoint{Until: until.UTC()}, nil
+	}
+	if err != nil {
+		return BackfillCheckpoint{}, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint BackfillCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return BackfillCheckpoint{}, fmt.Errorf("invalid checkpoint %s: %w", b.checkpointPath, err)
+	}
+	if !until.IsZero() && !until.Equal(checkpoint.Until) {
+		return BackfillCheckpoint{}, fmt.Errorf("checkpoint %s copies clicks until %s, not %s",
+			b.checkpointPath, checkpoint.Until.Format(time.RFC3339), until.UTC().Format(time.RFC3339))
+	}
+
+	return checkpoint, nil
+}
+
+// Run copies the clicks after the checkpoint, batch by batch, until there are none left
+// or ctx is canceled. It returns the last checkpoint saved.
+func (b *ClickBackfill) Run(ctx context.Context, checkpoint BackfillCheckpoint) (BackfillCheckpoint, error) {
+	for ctx.Err() == nil {
+		rows, err := b.queries.ListClicksForBackfill(ctx, db.ListClicksForBackfillParams{
+			AfterID:   checkpoint.AfterID,
+			Until:     pgtype.Timestamptz{Time: checkpoint.Until, Valid: true},
+			BatchSize: b.batchSize,
+		})
+		if err != nil {
+			return checkpoint, fmt.Errorf("failed to list clicks: %w", err)
+		}
+		if len(rows) == 0 {
+			return checkpoint, nil
+		}
+
+		clicks := make([]analytics.Click, 0, len(rows))
+		for _, row := range rows {
+			clicks = append(clicks, backfillClick(row))
+		}
+		if err := b.dest.RecordClicks(ctx, clicks); err != nil {
+			return checkpoint, fmt.Errorf("failed to copy clicks after %d: %w", checkpoint.AfterID, err)
+		}
+
+		checkpoint.AfterID = rows[len(rows)-1].ID
+		checkpoint.Copied += int64(len(rows))
+		if err := b.save(checkpoint); err != nil {
+			return checkpoint, err
+		}
+
+		b.logger.Info("Clicks backfilled",
+			zap.Int("batch", len(rows)),
+			zap.Int64("copied", checkpoint.Copied),
+			zap.Int64("after_id", checkpoint.AfterID),
+		)
+	}
+
+	return checkpoint, ctx.Err()
+}
+
+// save replaces the checkpoint file atomically, so a crash never leaves half of one
+func (b *ClickBackfill) save(checkpoint BackfillCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.checkpointPath), filepath.Base(b.checkpointPath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.checkpointPath); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func backfillClick(row db.ListClicksForBackfillRow) analytics.Click {
+	click := analytics.Click{
+		ID:        row.ClickID,
+		Shortcode: row.Shortcode,
+		LinkID:    row.LinkID,
+		Source:    row.Source,
+		// pgx reads TIMESTAMP columns as UTC
+		ClickedAt: row.ClickedAt.Time,
+	}
+	if row.Referrer != nil {
+		click.Referrer = *row.Referrer
+	}
+	if row.UserAgent != nil {
+		click.UserAgent = *row.UserAgent
+	}
+	if row.VisitorID != nil {
+		click.VisitorID = *row.VisitorID
+	}
+	return click
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// mockBackfillQueries serves clicks with IDs 1..total
+type mockBackfillQueries struct {
+	total int64
+}
+
+func (m *mockBackfillQueries) ListClicksForBackfill(ctx context.Context, arg db.ListClicksForBackfillParams) ([]db.ListClicksForBackfillRow, error) {
+	var rows []db.ListClicksForBackfillRow
+	for id := arg.AfterID + 1; id <= m.total && len(rows) < int(arg.BatchSize); id++ {
+		rows = append(rows, db.ListClicksForBackfillRow{
+			ID:        id,
+			ClickID:   uuid.New(),
+			Shortcode: "abc123",
+			ClickedAt: pgtype.Timestamptz{Time: arg.Until.Time.Add(-time.Hour), Valid: true},
+		})
+	}
+	return rows, nil
+}
+
+type mockBatchStore struct {
+	copied  []analytics.Click
+	failAt  int
+	batches int
+}
+
+func (m *mockBatchStore) RecordClicks(ctx context.Context, clicks []analytics.Click) error {
+	m.batches++
+	if m.batches == m.failAt {
+		return errors.New("clickhouse unavailable")
+	}
+	m.copied = append(m.copied, clicks...)
+	return nil
+}
+
+func TestClickBackfill_Resume(t *testing.T) {
+	until := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+	queries := &mockBackfillQueries{total: 25}
+
+	// The second batch fails: the first one is saved in the checkpoint
+	dest := &mockBatchStore{failAt: 2}
+	backfill := NewClickBackfill(queries, dest, checkpointPath, 10, createTestLogger())
+
+	checkpoint, err := backfill.Checkpoint(until)
+	if err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	if _, err := backfill.Run(context.Background(), checkpoint); err == nil {
+		t.Fatal("Run() error = nil, want the insert failure")
+	}
+
+	// Resuming without until picks up after the first batch
+	dest.failAt = 0
+	checkpoint, err = backfill.Checkpoint(time.Time{})
+	if err != nil {
+		t.Fatalf("Checkpoint() resume error = %v", err)
+	}
+	if checkpoint.AfterID != 10 || !checkpoint.Until.Equal(until) {
+		t.Fatalf("Checkpoint() = %+v, want after_id 10 until %s", checkpoint, until)
+	}
+
+	checkpoint, err = backfill.Run(context.Background(), checkpoint)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if checkpoint.Copied != 25 || len(dest.copied) != 25 {
+		t.Errorf("Run() copied %d (checkpoint %d), want 25 clicks once each", len(dest.copied), checkpoint.Copied)
+	}
+
+	// Another bound would leave a gap or copy clicks twice
+	if _, err := backfill.Checkpoint(until.Add(time.Hour)); err == nil {
+		t.Error("Checkpoint() with a different until = nil error, want a mismatch")
+	}
+}
+
+func TestClickBackfill_CheckpointRequiresUntil(t *testing.T) {
+	backfill := NewClickBackfill(&mockBackfillQueries{}, &mockBatchStore{}, filepath.Join(t.TempDir(), "checkpoint.json"), 0, createTestLogger())
+
+	if _, err := backfill.Checkpoint(time.Time{}); err == nil {
+		t.Error("Checkpoint() without until or a saved checkpoint = nil error")
+	}
+}
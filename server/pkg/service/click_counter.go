@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key prefix of the clicks of a shortcode not yet added to the database
+	clickCountKeyPrefix = "click-count:"
+	// Redis set of the shortcodes with clicks not yet added to the database
+	clickCountDirtyKey = "click-count:dirty"
+	// Most links whose clicks are added to the database at once
+	clickCountFlushBatch   = 500
+	clickCountFlushTimeout = 30 * time.Second
+)
+
+func clickCountKey(shortcode string) string {
+	return clickCountKeyPrefix + shortcode
+}
+
+// CountedLink is a link whose click count is asked for: its count in the database
+// is found by ID, the clicks not yet added to it by shortcode
+type CountedLink struct {
+	ID        uuid.UUID
+	Shortcode string
+}
+
+/*
+ClickCounter keeps a running click count of each link. Redirects count their
+click in Redis, by shortcode as cached redirects don't know the link's ID, and
+Flush adds the clicks counted since the last flush to the database in batches,
+so a redirect never waits for a database write. Counts read the database plus
+what's still in Redis, so they include clicks made a moment ago.
+
+Without Redis, or when it fails, clicks are counted in the process instead until
+the next flush. Those are lost if the process dies without flushing, so the
+server flushes on shutdown; counts are best-effort either way, the clicks table
+holds the exact numbers.
+*/
+type ClickCounter struct {
+	queries repository.ClickCounterQueries
+	redis   *redis.Client
+	logger  logger.Logger
+
+	mu sync.Mutex
+	// Clicks counted in the process since the last flush, by shortcode
+	pending map[string]int64
+}
+
+func NewClickCounter(queries repository.ClickCounterQueries, redis *redis.Client, logger logger.Logger) *ClickCounter {
+	return &ClickCounter{
+		queries: queries,
+		redis:   redis,
+		logger:  logger,
+		pending: map[string]int64{},
+	}
+}
+
+// Add counts a click on the link with the shortcode; a nil ClickCounter counts nothing
+func (c *ClickCounter) Add(ctx context.Context, shortcode string) {
+	if c == nil {
+		return
+	}
+	c.add(ctx, map[string]int64{shortcode: 1})
+}
+
+// add counts the clicks in Redis, or in the process when it's unavailable
+func (c *ClickCounter) add(ctx context.Context, clicks map[string]int64) {
+	if c.redis != nil {
+		pipe := c.redis.TxPipeline()
+		for shortcode, n := range clicks {
+			pipe.IncrBy(ctx, clickCountKey(shortcode), n)
+			pipe.SAdd(ctx, clickCountDirtyKey, shortcode)
+		}
+		_, err := pipe.Exec(ctx)
+		if err == nil {
+			return
+		}
+		c.logger.Warn("Failed to count clicks in Redis, counting them in the process",
+			zap.Error(err),
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for shortcode, n := range clicks {
+		c.pending[shortcode] += n
+	}
+}
+
+// Counts returns the click count of each link, 0 for links without clicks; nil from a nil ClickCounter
+func (c *ClickCounter) Counts(ctx context.Context, links []CountedLink) (map[uuid.UUID]int64, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	counts := make(map[uuid.UUID]int64, len(links))
+	if len(links) == 0 {
+		return counts, nil
+	}
+
+	linkIDs := make([]uuid.UUID, len(links))
+	for i, link := range links {
+		linkIDs[i] = link.ID
+	}
+	rows, err := c.queries.ListLinkClickCounts(ctx, linkIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get click counts: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.LinkID] = row.Clicks
+	}
+
+	c.mu.Lock()
+	for _, link := range links {
+		counts[link.ID] += c.pending[link.Shortcode]
+	}
+	c.mu.Unlock()
+
+	if c.redis != nil {
+		keys := make([]string, len(links))
+		for i, link := range links {
+			keys[i] = clickCountKey(link.Shortcode)
+		}
+		values, err := c.redis.MGet(ctx, keys...).Result()
+		if err != nil {
+			// Best-effort: the count is only behind by the clicks since the last flush
+			c.logger.Warn("Failed to read click counts from Redis",
+				zap.Error(err),
+			)
+			return counts, nil
+		}
+		for i, value := range values {
+			if n, ok := counterValue(value); ok {
+				counts[links[i].ID] += n
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+/*
+Flush adds the clicks counted since the last flush to the database. Clicks are
+taken out of Redis before they're written, so instances flushing at the same
+time don't add them twice; when the write fails they're counted again, to be
+added by a later flush.
+*/
+func (c *ClickCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	clicks := c.pending
+	c.pending = map[string]int64{}
+	c.mu.Unlock()
+
+	if c.redis != nil {
+		if err := c.takeRedisClicks(ctx, clicks); err != nil {
+			c.logger.Warn("Failed to take click counts out of Redis",
+				zap.Error(err),
+			)
+		}
+	}
+
+	shortcodes := make([]string, 0, len(clicks))
+	for shortcode := range clicks {
+		shortcodes = append(shortcodes, shortcode)
+	}
+
+	for start := 0; start < len(shortcodes); start += clickCountFlushBatch {
+		batch := shortcodes[start:min(start+clickCountFlushBatch, len(shortcodes))]
+		arg := db.AddLinkClickCountsParams{
+			Shortcodes: batch,
+			Clicks:     make([]int64, len(batch)),
+		}
+		for i, shortcode := range batch {
+			arg.Clicks[i] = clicks[shortcode]
+		}
+
+		if err := c.queries.AddLinkClickCounts(ctx, arg); err != nil {
+			unwritten := make(map[string]int64, len(shortcodes)-start)
+			for _, shortcode := range shortcodes[start:] {
+				unwritten[shortcode] = clicks[shortcode]
+			}
+			// Counted again with a context of its own: ctx may be why the write failed
+			restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), clickCountFlushTimeout)
+			c.add(restoreCtx, unwritten)
+			cancel()
+			return fmt.Errorf("failed to add click counts: %w", err)
+		}
+	}
+
+	if len(shortcodes) > 0 {
+		c.logger.Debug("Click counts flushed",
+			zap.Int("links", len(shortcodes)),
+		)
+	}
+	return nil
+}
+
+// takeRedisClicks moves the clicks counted in Redis into clicks, a batch of shortcodes at a time
+func (c *ClickCounter) takeRedisClicks(ctx context.Context, clicks map[string]int64) error {
+	for {
+		members, err := c.redis.SPopN(ctx, clickCountDirtyKey, clickCountFlushBatch).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		pipe := c.redis.TxPipeline()
+		cmds := make([]*redis.StringCmd, len(members))
+		for i, shortcode := range members {
+			cmds[i] = pipe.GetDel(ctx, clickCountKey(shortcode))
+		}
+		if len(cmds) > 0 {
+			// Shortcodes taken by an earlier flush have no count left; their redis.Nil replies are expected
+			if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+				// Put the shortcodes back so their clicks are taken by the next flush
+				back := make([]any, len(members))
+				for i, member := range members {
+					back[i] = member
+				}
+				c.redis.SAdd(context.WithoutCancel(ctx), clickCountDirtyKey, back...)
+				return err
+			}
+		}
+
+		for i, cmd := range cmds {
+			n, err := strconv.ParseInt(cmd.Val(), 10, 64)
+			if err == nil && n > 0 {
+				clicks[members[i]] += n
+			}
+		}
+
+		if len(members) < clickCountFlushBatch {
+			return nil
+		}
+	}
+}
+
+// Start flushes every interval until ctx is done
+func (c *ClickCounter) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.flushOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (c *ClickCounter) flushOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, clickCountFlushTimeout)
+	defer cancel()
+
+	if err := c.Flush(ctx); err != nil && ctx.Err() == nil {
+		c.logger.Error("Click count flush failed",
+			zap.Error(err),
+		)
+	}
+}
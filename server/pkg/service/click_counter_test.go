@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+// clickCountStore stands in for link_click_counts, joining shortcodes to link IDs like the queries do
+type clickCountStore struct {
+	counts  map[string]int64
+	ids     map[uuid.UUID]string
+	failing bool
+}
+
+func newClickCountStore(links ...CountedLink) *clickCountStore {
+	s := &clickCountStore{counts: map[string]int64{}, ids: map[uuid.UUID]string{}}
+	for _, link := range links {
+		s.ids[link.ID] = link.Shortcode
+	}
+	return s
+}
+
+func (s *clickCountStore) queries() *mocks.ClickCounterQueries {
+	return &mocks.ClickCounterQueries{
+		AddLinkClickCountsFunc: func(ctx context.Context, arg db.AddLinkClickCountsParams) error {
+			if s.failing {
+				return errors.New("database error")
+			}
+			for i, shortcode := range arg.Shortcodes {
+				s.counts[shortcode] += arg.Clicks[i]
+			}
+			return nil
+		},
+		ListLinkClickCountsFunc: func(ctx context.Context, linkIDs []uuid.UUID) ([]db.ListLinkClickCountsRow, error) {
+			var rows []db.ListLinkClickCountsRow
+			for _, id := range linkIDs {
+				if n, ok := s.counts[s.ids[id]]; ok {
+					rows = append(rows, db.ListLinkClickCountsRow{LinkID: id, Clicks: n})
+				}
+			}
+			return rows, nil
+		},
+	}
+}
+
+func TestClickCounter(t *testing.T) {
+	a := CountedLink{ID: uuid.New(), Shortcode: "aaa111"}
+	b := CountedLink{ID: uuid.New(), Shortcode: "bbb222"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		useRedis bool
+	}{
+		{name: "redis", useRedis: true},
+		{name: "in process", useRedis: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var client *redis.Client
+			if tt.useRedis {
+				client = redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			}
+			store := newClickCountStore(a, b)
+			counter := NewClickCounter(store.queries(), client, createTestLogger())
+
+			wantCounts := func(wantA, wantB int64) {
+				t.Helper()
+				counts, err := counter.Counts(ctx, []CountedLink{a, b})
+				if err != nil {
+					t.Fatalf("Counts() error = %v", err)
+				}
+				if counts[a.ID] != wantA || counts[b.ID] != wantB {
+					t.Errorf("Counts() = %v, want %s: %d, %s: %d", counts, a.Shortcode, wantA, b.Shortcode, wantB)
+				}
+			}
+
+			counter.Add(ctx, a.Shortcode)
+			counter.Add(ctx, a.Shortcode)
+			counter.Add(ctx, b.Shortcode)
+			// Counted before they reach the database
+			wantCounts(2, 1)
+
+			if err := counter.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if store.counts[a.Shortcode] != 2 || store.counts[b.Shortcode] != 1 {
+				t.Errorf("database counts = %v, want %s: 2, %s: 1", store.counts, a.Shortcode, b.Shortcode)
+			}
+			// Not counted twice once they're in the database
+			wantCounts(2, 1)
+
+			// A flush with nothing counted since writes nothing
+			if err := counter.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			wantCounts(2, 1)
+
+			// Clicks whose write fails are kept for the next flush
+			counter.Add(ctx, b.Shortcode)
+			store.failing = true
+			if err := counter.Flush(ctx); err == nil {
+				t.Fatal("Flush() error = nil with the database failing, want an error")
+			}
+			wantCounts(2, 2)
+
+			store.failing = false
+			if err := counter.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if store.counts[b.Shortcode] != 2 {
+				t.Errorf("database count of %s = %d, want 2", b.Shortcode, store.counts[b.Shortcode])
+			}
+			wantCounts(2, 2)
+		})
+	}
+}
+
+func TestClickCounter_RedisDown(t *testing.T) {
+	link := CountedLink{ID: uuid.New(), Shortcode: "abc123"}
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	store := newClickCountStore(link)
+	// No retries, so the calls to the closed server fail fast
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	counter := NewClickCounter(store.queries(), client, createTestLogger())
+
+	counter.Add(ctx, link.Shortcode)
+	mr.Close()
+	// Counted in the process while Redis is down, and Counts still answers from the database
+	counter.Add(ctx, link.Shortcode)
+
+	counts, err := counter.Counts(ctx, []CountedLink{link})
+	if err != nil {
+		t.Fatalf("Counts() error = %v", err)
+	}
+	if counts[link.ID] != 1 {
+		t.Errorf("Counts() = %d, want the 1 click counted in the process", counts[link.ID])
+	}
+
+	if err := counter.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if store.counts[link.Shortcode] != 1 {
+		t.Errorf("database count = %d, want 1", store.counts[link.Shortcode])
+	}
+}
+
+func TestClickCounter_Nil(t *testing.T) {
+	var counter *ClickCounter
+	counter.Add(context.Background(), "abc123")
+
+	counts, err := counter.Counts(context.Background(), []CountedLink{{ID: uuid.New(), Shortcode: "abc123"}})
+	if err != nil || counts != nil {
+		t.Errorf("Counts() = %v, %v, want nil, nil", counts, err)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// ConversionService records conversions posted back for clicks on links with append_click_id.
+// Conversions are counted in the tag and campaign stats.
+type ConversionService struct {
+	queries repository.ConversionQueries
+	logger  logger.Logger
+}
+
+func NewConversionService(queries repository.ConversionQueries, logger logger.Logger) *ConversionService {
+	return &ConversionService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// CreateConversion records a conversion for a click on one of the user's links.
+// Clicks are recorded asynchronously, so a postback sent right after the redirect
+// may briefly get ClickNotFound and should be retried.
+func (s *ConversionService) CreateConversion(
+	ctx context.Context,
+	userID string,
+	clickID uuid.UUID,
+	event string,
+	revenueCents *int64,
+	externalID *string,
+) (db.CreateConversionRow, error) {
+	conversion, err := s.queries.CreateConversion(ctx, db.CreateConversionParams{
+		Event:        event,
+		RevenueCents: revenueCents,
+		ExternalID:   externalID,
+		ClickID:      clickID,
+		UserID:       userID,
+	})
+
+	if err != nil {
+		// INSERT ... SELECT returned no rows: unknown click or not the user's link
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.CreateConversionRow{}, fmt.Errorf("%w: %v", apperrors.ClickNotFound, err)
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return db.CreateConversionRow{}, fmt.Errorf("%w: %v", apperrors.ConversionAlreadyRecorded, err)
+		}
+
+		return db.CreateConversionRow{}, fmt.Errorf("failed to create conversion: %w", err)
+	}
+
+	s.logger.Debug("Conversion recorded",
+		zap.String("user_id", userID),
+		zap.String("click_id", clickID.String()),
+		zap.String("event", event),
+	)
+
+	return conversion, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type mockConversionQueries struct {
+	err    error
+	params db.CreateConversionParams
+}
+
+func (m *mockConversionQueries) CreateConversion(ctx context.Context, arg db.CreateConversionParams) (db.CreateConversionRow, error) {
+	m.params = arg
+	if m.err != nil {
+		return db.CreateConversionRow{}, m.err
+	}
+	return db.CreateConversionRow{
+		ID:           1,
+		ClickID:      arg.ClickID,
+		Event:        arg.Event,
+		RevenueCents: arg.RevenueCents,
+		ExternalID:   arg.ExternalID,
+	}, nil
+}
+
+func TestConversionService_CreateConversion(t *testing.T) {
+	ctx := context.Background()
+	clickID := uuid.New()
+	revenue := int64(4999)
+
+	t.Run("records conversion for the user's click", func(t *testing.T) {
+		queries := &mockConversionQueries{}
+		s := NewConversionService(queries, createTestLogger())
+
+		conversion, err := s.CreateConversion(ctx, "user_123", clickID, "purchase", &revenue, nil)
+		if err != nil {
+			t.Fatalf("CreateConversion() error = %v, want nil", err)
+		}
+		if queries.params.UserID != "user_123" || queries.params.ClickID != clickID {
+			t.Errorf("CreateConversion() params = %+v, want user and click scoped", queries.params)
+		}
+		if conversion.Event != "purchase" || conversion.RevenueCents == nil || *conversion.RevenueCents != revenue {
+			t.Errorf("CreateConversion() = %+v", conversion)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "unknown or foreign click",
+			err:      sql.ErrNoRows,
+			expected: apperrors.ClickNotFound,
+		},
+		{
+			name:     "duplicate external id",
+			err:      &pgconn.PgError{Code: "23505"},
+			expected: apperrors.ConversionAlreadyRecorded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewConversionService(&mockConversionQueries{err: tt.err}, createTestLogger())
+
+			if _, err := s.CreateConversion(ctx, "user_123", clickID, "signup", nil, nil); !errors.Is(err, tt.expected) {
+				t.Errorf("CreateConversion() error = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Placeholders expanded in destination URLs at redirect time
+const (
+	PlaceholderClickID   = "{click_id}"
+	PlaceholderShortcode = "{shortcode}"
+	PlaceholderTimestamp = "{timestamp}"
+)
+
+/*
+Referrer policies stored in links.referrer_policy. Except for the default,
+they're sent as the Referrer-Policy of the redirect, which goes through an
+HTML hop on the short domain: browsers apply the policy to the hop's
+navigation, so the destination sees no referrer ("no-referrer") or only the
+short domain ("origin") instead of the page and campaign the click came from.
+*/
+const (
+	ReferrerPolicyDefault    = "default"
+	ReferrerPolicyNoReferrer = "no-referrer"
+	ReferrerPolicyOrigin     = "origin"
+)
+
+// DestinationVars are the values substituted into a destination template
+type DestinationVars struct {
+	ClickID   uuid.UUID
+	Shortcode string
+	Time      time.Time
+}
+
+// IsDestinationTemplate reports whether the destination contains any placeholder
+func IsDestinationTemplate(destination string) bool {
+	return strings.Contains(destination, PlaceholderClickID) ||
+		strings.Contains(destination, PlaceholderShortcode) ||
+		strings.Contains(destination, PlaceholderTimestamp)
+}
+
+// ExpandDestination substitutes the placeholders of a destination template.
+// Values are query-escaped; unknown placeholders are left as they are.
+func ExpandDestination(destination string, vars DestinationVars) string {
+	if !IsDestinationTemplate(destination) {
+		return destination
+	}
+
+	return strings.NewReplacer(
+		PlaceholderClickID, vars.ClickID.String(),
+		PlaceholderShortcode, url.QueryEscape(vars.Shortcode),
+		PlaceholderTimestamp, strconv.FormatInt(vars.Time.Unix(), 10),
+	).Replace(destination)
+}
+
+// ClickIDParam is the query parameter carrying the click ID for links with append_click_id,
+// to be sent back with POST /api/v1/conversions
+const ClickIDParam = "click_id"
+
+// AppendClickID adds the click ID to the destination's query string, keeping the
+// existing parameters (and their encoding) and the fragment as they are
+func AppendClickID(destination string, clickID uuid.UUID) string {
+	base, fragment, hasFragment := strings.Cut(destination, "#")
+
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+		if strings.HasSuffix(base, "?") || strings.HasSuffix(base, "&") {
+			separator = ""
+		}
+	}
+
+	result := base + separator + ClickIDParam + "=" + clickID.String()
+	if hasFragment {
+		result += "#" + fragment
+	}
+
+	return result
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+	xhtml "golang.org/x/net/html"
+)
+
+const (
+	// Most of a destination page read for its title and canonical URL
+	maxDestinationPageBytes = 512 << 10
+	// Longest page title stored
+	maxDestinationTitleLen = 255
+	// Most links checked per monitor run; the rest wait for the next one
+	destinationMonitorBatch = 50
+	// Upper bound for one monitor run
+	destinationMonitorTimeout = 10 * time.Minute
+)
+
+// States of a monitored destination, stored in link_destination_checks.state.
+// Redirected, not found and parked destinations are also the kinds of alerts.
+const (
+	DestinationOK = "ok"
+	// The destination lands on another host
+	DestinationRedirected = "redirected"
+	// The destination answers 404 or 410
+	DestinationNotFound = "not_found"
+	// The destination's domain is parked or for sale, usually once it expired
+	DestinationParked      = "parked"
+	DestinationUnreachable = "unreachable"
+)
+
+// Hosts of the parking and domain aftermarket services expired domains get pointed at
+var parkingHosts = []string{
+	"above.com",
+	"afternic.com",
+	"bodis.com",
+	"dan.com",
+	"domainmarket.com",
+	"hugedomains.com",
+	"parkingcrew.net",
+	"parklogic.com",
+	"sedoparking.com",
+	"undeveloped.com",
+}
+
+// Phrases in the titles of parked pages
+var parkingTitlePhrases = []string{
+	"buy this domain",
+	"domain for sale",
+	"domain has expired",
+	"domain is for sale",
+	"domain may be for sale",
+	"domain parking",
+	"parked domain",
+	"parked free",
+}
+
+// DestinationPage is what a destination answered with
+type DestinationPage struct {
+	// The URL the redirects ended on
+	FinalURL   *url.URL
+	StatusCode int
+	Title      string
+	// The page's <link rel="canonical">, empty when it has none
+	Canonical string
+}
+
+// Fetch gets destination, following redirects, and reads the title and canonical URL of HTML pages
+func (c *ReachabilityChecker) Fetch(ctx context.Context, destination string) (DestinationPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, ReachabilityTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, destination, nil)
+	if err != nil {
+		return DestinationPage{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return DestinationPage{}, err
+	}
+	defer resp.Body.Close()
+
+	page := DestinationPage{
+		FinalURL:   resp.Request.URL,
+		StatusCode: resp.StatusCode,
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		page.Title, page.Canonical = pageMeta(io.LimitReader(resp.Body, maxDestinationPageBytes))
+	}
+	return page, nil
+}
+
+// pageMeta returns the title and canonical URL in the head of an HTML page
+func pageMeta(r io.Reader) (title string, canonical string) {
+	z := xhtml.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return title, canonical
+		case xhtml.TextToken:
+			if inTitle && title == "" {
+				title = strings.Join(strings.Fields(string(z.Text())), " ")
+				if len(title) > maxDestinationTitleLen {
+					title = strings.ToValidUTF8(title[:maxDestinationTitleLen], "")
+				}
+			}
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return title, canonical
+			}
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				return title, canonical
+			case "link":
+				var rel, href string
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					switch string(key) {
+					case "rel":
+						rel = strings.ToLower(string(val))
+					case "href":
+						href = strings.TrimSpace(string(val))
+					}
+				}
+				if rel == "canonical" && canonical == "" {
+					canonical = href
+				}
+			}
+		}
+	}
+}
+
+// sameSite reports whether two hosts are the same, ignoring case and a www. prefix
+func sameSite(a, b string) bool {
+	a = strings.TrimPrefix(strings.ToLower(a), "www.")
+	b = strings.TrimPrefix(strings.ToLower(b), "www.")
+	return a == b
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func isParked(page DestinationPage) bool {
+	host := strings.ToLower(page.FinalURL.Hostname())
+	for _, parking := range parkingHosts {
+		if host == parking || strings.HasSuffix(host, "."+parking) {
+			return true
+		}
+	}
+	title := strings.ToLower(page.Title)
+	for _, phrase := range parkingTitlePhrases {
+		if strings.Contains(title, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyDestination returns the state of a destination from what fetching it returned
+func classifyDestination(destination string, page DestinationPage, err error) string {
+	switch {
+	case err != nil:
+		return DestinationUnreachable
+	case page.StatusCode == http.StatusNotFound || page.StatusCode == http.StatusGone:
+		return DestinationNotFound
+	case isParked(page):
+		return DestinationParked
+	case page.StatusCode >= http.StatusBadRequest:
+		return DestinationUnreachable
+	case !sameSite(hostOf(destination), page.FinalURL.Hostname()):
+		return DestinationRedirected
+	default:
+		return DestinationOK
+	}
+}
+
+// pageFingerprint hashes the page's canonical URL, or the URL it was served from, and its title
+func pageFingerprint(page DestinationPage) string {
+	canonical := page.Canonical
+	if canonical == "" {
+		canonical = page.FinalURL.String()
+	}
+	sum := sha256.Sum256([]byte(canonical + "\n" + page.Title))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+destinationAlertKind returns the kind of alert a check calls for, empty for
+none. prev is the destination's last snapshot, nil on its first check. Only
+changes are alerted: a destination that was already not found or parked isn't
+alerted again, and one that redirected elsewhere from the start is taken as
+it is, until it lands on yet another host.
+*/
+func destinationAlertKind(prev *db.ListDestinationsToMonitorRow, state string, page DestinationPage) string {
+	switch state {
+	case DestinationNotFound, DestinationParked:
+		if prev == nil || *prev.State != state {
+			return state
+		}
+	case DestinationRedirected:
+		if prev != nil && (prev.FinalUrl == nil || !sameSite(hostOf(*prev.FinalUrl), page.FinalURL.Hostname())) {
+			return state
+		}
+	}
+	return ""
+}
+
+type ListDestinationAlertsResult struct {
+	Alerts []db.ListUserDestinationAlertsRow
+	pagination.Meta
+}
+
+// ListDestinationAlerts returns a page of the alerts about the user's destinations, newest first
+func (s *LinkService) ListDestinationAlerts(ctx context.Context, userID string, page, limit int) (*ListDestinationAlertsResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	total, err := s.queries.CountUserDestinationAlerts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count destination alerts: %w", err)
+	}
+
+	alerts, err := s.queries.ListUserDestinationAlerts(ctx, db.ListUserDestinationAlertsParams{
+		UserID:    userID,
+		RowLimit:  int32(p.Limit),
+		RowOffset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination alerts: %w", err)
+	}
+
+	return &ListDestinationAlertsResult{
+		Alerts: alerts,
+		Meta:   p.Meta(total),
+	}, nil
+}
+
+/*
+DestinationMonitor periodically fetches the destination pages of live links and
+alerts their owners when one starts redirecting to another host, returns 404 or
+gets parked, which is what old links look like once their domain expired.
+Alerts are recorded in link_destination_alerts and the owner's activity feed.
+
+Each check keeps a snapshot of the page (where it landed, its status, title and
+a fingerprint of its canonical URL and title) to compare the next check
+against. Failed fetches keep the last snapshot: they're often transient.
+*/
+type DestinationMonitor struct {
+	queries repository.DestinationMonitorQueries
+	checker *ReachabilityChecker
+	// Links are checked again once their last check is older
+	recheckAfter time.Duration
+	logger       logger.Logger
+}
+
+func NewDestinationMonitor(queries repository.DestinationMonitorQueries, checker *ReachabilityChecker, recheckAfter time.Duration, logger logger.Logger) *DestinationMonitor {
+	return &DestinationMonitor{
+		queries:      queries,
+		checker:      checker,
+		recheckAfter: recheckAfter,
+		logger:       logger,
+	}
+}
+
+// Run checks a batch of links, never checked ones first
+func (m *DestinationMonitor) Run(ctx context.Context) error {
+	links, err := m.queries.ListDestinationsToMonitor(ctx, db.ListDestinationsToMonitorParams{
+		CheckedBefore: pgtype.Timestamptz{Time: time.Now().Add(-m.recheckAfter), Valid: true},
+		RowLimit:      destinationMonitorBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list destinations to monitor: %w", err)
+	}
+
+	alerts := 0
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		alerted, err := m.check(ctx, link)
+		if err != nil {
+			return err
+		}
+		if alerted {
+			alerts++
+		}
+	}
+
+	if len(links) > 0 {
+		m.logger.Info("Destinations checked",
+			zap.Int("links", len(links)),
+			zap.Int("alerts", alerts),
+		)
+	}
+	return nil
+}
+
+// check fetches one link's destination, alerts its owner when it calls for it and records the snapshot
+func (m *DestinationMonitor) check(ctx context.Context, link db.ListDestinationsToMonitorRow) (bool, error) {
+	// A new destination, or one never fetched successfully, starts a new baseline
+	prev := &link
+	if link.CheckedUrl == nil || *link.CheckedUrl != link.Destination || *link.State == DestinationUnreachable {
+		prev = nil
+	}
+
+	// Templates are fetched the way they redirect
+	target := ExpandDestination(link.Destination, DestinationVars{
+		ClickID:   uuid.Nil,
+		Shortcode: link.Shortcode,
+		Time:      time.Now(),
+	})
+	page, fetchErr := m.checker.Fetch(ctx, target)
+	state := classifyDestination(target, page, fetchErr)
+
+	if state == DestinationUnreachable && prev != nil {
+		if err := m.queries.TouchDestinationCheck(ctx, link.ID); err != nil {
+			return false, fmt.Errorf("failed to record destination check: %w", err)
+		}
+		return false, nil
+	}
+
+	check := db.UpsertDestinationCheckParams{
+		LinkID: link.ID,
+		Url:    link.Destination,
+		State:  state,
+	}
+	if fetchErr == nil {
+		finalURL := page.FinalURL.String()
+		statusCode := int32(page.StatusCode)
+		fingerprint := pageFingerprint(page)
+		check.FinalUrl = &finalURL
+		check.StatusCode = &statusCode
+		check.Fingerprint = &fingerprint
+		if page.Title != "" {
+			check.Title = &page.Title
+		}
+	}
+
+	kind := destinationAlertKind(prev, state, page)
+	if kind != "" {
+		if _, err := m.queries.CreateDestinationAlert(ctx, db.CreateDestinationAlertParams{
+			LinkID:     link.ID,
+			UserID:     link.UserID,
+			Kind:       kind,
+			Url:        link.Destination,
+			FinalUrl:   check.FinalUrl,
+			StatusCode: check.StatusCode,
+			Title:      check.Title,
+		}); err != nil {
+			return false, fmt.Errorf("failed to record destination alert: %w", err)
+		}
+
+		recordActivity(ctx, m.queries, m.logger, link.UserID, ActivityLinkDestinationAlert, link.ID,
+			destinationAlertSummary(link.Shortcode, kind, page))
+	}
+
+	if err := m.queries.UpsertDestinationCheck(ctx, check); err != nil {
+		return false, fmt.Errorf("failed to record destination check: %w", err)
+	}
+	return kind != "", nil
+}
+
+func destinationAlertSummary(shortcode string, kind string, page DestinationPage) string {
+	switch kind {
+	case DestinationRedirected:
+		return fmt.Sprintf("Destination of link %s now redirects to %s", shortcode, page.FinalURL.Hostname())
+	case DestinationNotFound:
+		return fmt.Sprintf("Destination of link %s returns %d", shortcode, page.StatusCode)
+	default:
+		return fmt.Sprintf("Destination of link %s looks parked (%s)", shortcode, page.FinalURL.Hostname())
+	}
+}
+
+// Start checks a batch of links now and then every interval until ctx is done
+func (m *DestinationMonitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			m.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (m *DestinationMonitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, destinationMonitorTimeout)
+	defer cancel()
+
+	if err := m.Run(ctx); err != nil && ctx.Err() == nil {
+		m.logger.Error("Destination monitor failed",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestPageMeta(t *testing.T) {
+	page := `<!doctype html><html><head>
+		<title>
+			Spring   sale
+		</title>
+		<link rel="Canonical" href=" https://example.com/sale ">
+		</head><body><title>Not this one</title></body></html>`
+
+	title, canonical := pageMeta(strings.NewReader(page))
+	if title != "Spring sale" {
+		t.Errorf("title = %q, want %q", title, "Spring sale")
+	}
+	if canonical != "https://example.com/sale" {
+		t.Errorf("canonical = %q, want https://example.com/sale", canonical)
+	}
+}
+
+func TestClassifyDestination(t *testing.T) {
+	final := func(raw string) *url.URL {
+		u, _ := url.Parse(raw)
+		return u
+	}
+
+	tests := []struct {
+		name string
+		page DestinationPage
+		err  error
+		want string
+	}{
+		{name: "same host", page: DestinationPage{FinalURL: final("https://www.example.com/sale"), StatusCode: 200}, want: DestinationOK},
+		{name: "other host", page: DestinationPage{FinalURL: final("https://elsewhere.com/"), StatusCode: 200}, want: DestinationRedirected},
+		{name: "gone", page: DestinationPage{FinalURL: final("https://example.com/sale"), StatusCode: 410}, want: DestinationNotFound},
+		{name: "parking host", page: DestinationPage{FinalURL: final("https://ww1.sedoparking.com/example.com"), StatusCode: 200}, want: DestinationParked},
+		{name: "parked title", page: DestinationPage{FinalURL: final("https://example.com/"), StatusCode: 200, Title: "Example.com - This Domain Is For Sale"}, want: DestinationParked},
+		{name: "server error", page: DestinationPage{FinalURL: final("https://example.com/sale"), StatusCode: 503}, want: DestinationUnreachable},
+		{name: "fetch failed", err: errors.New("no such host"), want: DestinationUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyDestination("https://example.com/sale", tt.page, tt.err); got != tt.want {
+				t.Errorf("classifyDestination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDestinationMonitor_Run(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/moved":
+			http.Redirect(w, r, "https://localhost:1/", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><title>Docs</title></head></html>"))
+		}
+	}))
+	defer srv.Close()
+
+	state := func(s string) *string { return &s }
+
+	tests := []struct {
+		name          string
+		path          string
+		prevState     *string
+		prevFinal     *string
+		expectedState string
+		expectedAlert string
+		expectTouch   bool
+	}{
+		{name: "first check of a live page", path: "/docs", expectedState: DestinationOK},
+		{name: "first check of a missing page", path: "/gone", expectedState: DestinationNotFound, expectedAlert: DestinationNotFound},
+		{name: "still missing", path: "/gone", prevState: state(DestinationNotFound), prevFinal: state(srv.URL + "/gone"), expectedState: DestinationNotFound},
+		{name: "failed fetch keeps the snapshot", path: "/moved", prevState: state(DestinationOK), prevFinal: state(srv.URL + "/moved"), expectTouch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := db.ListDestinationsToMonitorRow{
+				ID:          uuid.New(),
+				UserID:      "user_123",
+				Shortcode:   "docs",
+				Destination: srv.URL + tt.path,
+				State:       tt.prevState,
+				FinalUrl:    tt.prevFinal,
+			}
+			if tt.prevState != nil {
+				link.CheckedUrl = &link.Destination
+			}
+
+			var recorded *db.UpsertDestinationCheckParams
+			var alert *db.CreateDestinationAlertParams
+			touched := false
+			queries := &mocks.DestinationMonitorQueries{
+				ListDestinationsToMonitorFunc: func(ctx context.Context, arg db.ListDestinationsToMonitorParams) ([]db.ListDestinationsToMonitorRow, error) {
+					return []db.ListDestinationsToMonitorRow{link}, nil
+				},
+				UpsertDestinationCheckFunc: func(ctx context.Context, arg db.UpsertDestinationCheckParams) error {
+					recorded = &arg
+					return nil
+				},
+				TouchDestinationCheckFunc: func(ctx context.Context, linkID uuid.UUID) error {
+					touched = true
+					return nil
+				},
+				CreateDestinationAlertFunc: func(ctx context.Context, arg db.CreateDestinationAlertParams) (db.LinkDestinationAlert, error) {
+					alert = &arg
+					return db.LinkDestinationAlert{}, nil
+				},
+				CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+					return nil
+				},
+			}
+			monitor := NewDestinationMonitor(queries, NewReachabilityChecker(srv.Client()), 0, createTestLogger())
+
+			if err := monitor.Run(context.Background()); err != nil {
+				t.Fatalf("Run() error = %v, want nil", err)
+			}
+
+			// The redirect lands on a closed port
+			if tt.expectTouch {
+				if !touched || recorded != nil || alert != nil {
+					t.Errorf("touched = %v, recorded = %v, alerted = %v, want the check touched only", touched, recorded != nil, alert != nil)
+				}
+				return
+			}
+
+			if recorded == nil || recorded.State != tt.expectedState {
+				t.Fatalf("recorded check = %+v, want state %q", recorded, tt.expectedState)
+			}
+			switch {
+			case tt.expectedAlert == "" && alert != nil:
+				t.Errorf("alerted %q, want no alert", alert.Kind)
+			case tt.expectedAlert != "" && (alert == nil || alert.Kind != tt.expectedAlert):
+				t.Errorf("alert = %+v, want %q", alert, tt.expectedAlert)
+			}
+		})
+	}
+}
+
+func TestDestinationAlertKind(t *testing.T) {
+	elsewhere, _ := url.Parse("https://elsewhere.com/")
+	page := DestinationPage{FinalURL: elsewhere, StatusCode: 200}
+	ok := DestinationOK
+	redirected := DestinationRedirected
+	sameFinal := "https://www.elsewhere.com/landing"
+	original := "https://example.com/"
+
+	tests := []struct {
+		name string
+		prev *db.ListDestinationsToMonitorRow
+		want string
+	}{
+		{name: "redirecting from the first check", prev: nil, want: ""},
+		{name: "starts redirecting elsewhere", prev: &db.ListDestinationsToMonitorRow{State: &ok, FinalUrl: &original}, want: DestinationRedirected},
+		{name: "still redirecting to the same host", prev: &db.ListDestinationsToMonitorRow{State: &redirected, FinalUrl: &sameFinal}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := destinationAlertKind(tt.prev, DestinationRedirected, page); got != tt.want {
+				t.Errorf("destinationAlertKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestExpandDestination(t *testing.T) {
+	vars := DestinationVars{
+		ClickID:   uuid.MustParse("6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10"),
+		Shortcode: "spring sale",
+		Time:      time.Unix(1767225600, 0),
+	}
+
+	tests := []struct {
+		name        string
+		destination string
+		expected    string
+	}{
+		{
+			name:        "no placeholders",
+			destination: "https://example.com/?a=1",
+			expected:    "https://example.com/?a=1",
+		},
+		{
+			name:        "all placeholders",
+			destination: "https://example.com/?uid={click_id}&src={shortcode}&ts={timestamp}",
+			expected:    "https://example.com/?uid=6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10&src=spring+sale&ts=1767225600",
+		},
+		{
+			name:        "repeated placeholder",
+			destination: "https://example.com/{shortcode}?ref={shortcode}",
+			expected:    "https://example.com/spring+sale?ref=spring+sale",
+		},
+		{
+			name:        "unknown placeholder is left alone",
+			destination: "https://example.com/?a={unknown}&b={shortcode}",
+			expected:    "https://example.com/?a={unknown}&b=spring+sale",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandDestination(tt.destination, vars); got != tt.expected {
+				t.Errorf("ExpandDestination() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAppendClickID(t *testing.T) {
+	clickID := uuid.MustParse("6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10")
+
+	tests := []struct {
+		name        string
+		destination string
+		expected    string
+	}{
+		{
+			name:        "no query",
+			destination: "https://example.com/path",
+			expected:    "https://example.com/path?click_id=6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10",
+		},
+		{
+			name:        "existing query keeps its encoding",
+			destination: "https://example.com/?q=hello%20world",
+			expected:    "https://example.com/?q=hello%20world&click_id=6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10",
+		},
+		{
+			name:        "trailing question mark",
+			destination: "https://example.com/?",
+			expected:    "https://example.com/?click_id=6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10",
+		},
+		{
+			name:        "fragment stays last",
+			destination: "https://example.com/?a=1#top",
+			expected:    "https://example.com/?a=1&click_id=6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10#top",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendClickID(tt.destination, clickID); got != tt.expected {
+				t.Errorf("AppendClickID() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Most due destination changes applied per scheduler run; the rest wait for the next one
+	destinationSchedulerBatch = 500
+	// Upper bound for one scheduler run
+	destinationSchedulerTimeout = time.Minute
+)
+
+type ListDestinationChangesResult struct {
+	Changes []db.LinkDestinationChange
+	pagination.Meta
+}
+
+/*
+SetDynamicLink makes one of the user's links dynamic, so its destination can be
+swapped (now or on a schedule) while its shortcode, and every QR code printed
+with it, stays the same. Setting it again locks or unlocks the link: locked
+links refuse destination changes, so a printed code can't be pointed elsewhere
+by accident. Changes scheduled before locking still apply.
+*/
+func (s *LinkService) SetDynamicLink(ctx context.Context, userID string, linkID uuid.UUID, locked bool) (db.DynamicLink, error) {
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DynamicLink{}, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return db.DynamicLink{}, fmt.Errorf("failed to get link: %w", err)
+	}
+	if link.RetiredAt.Valid {
+		return db.DynamicLink{}, fmt.Errorf("%w: %s", apperrors.LinkRetired, link.Shortcode)
+	}
+
+	dynamic, err := s.queries.UpsertDynamicLink(ctx, db.UpsertDynamicLinkParams{
+		LinkID: linkID,
+		Locked: locked,
+	})
+	if err != nil {
+		return db.DynamicLink{}, fmt.Errorf("failed to store dynamic link: %w", err)
+	}
+
+	s.logger.Info("Dynamic link set",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+		zap.Bool("locked", locked),
+	)
+
+	return dynamic, nil
+}
+
+// GetDynamicLink returns the dynamic mode of one of the user's links
+func (s *LinkService) GetDynamicLink(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.DynamicLink{}, err
+	}
+
+	dynamic, err := s.queries.GetDynamicLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DynamicLink{}, fmt.Errorf("%w: %v", apperrors.DynamicLinkNotFound, err)
+		}
+		return db.DynamicLink{}, fmt.Errorf("failed to get dynamic link: %w", err)
+	}
+
+	return dynamic, nil
+}
+
+// DeleteDynamicLink turns dynamic mode off for one of the user's links, canceling its scheduled
+// destination changes. The link keeps its current destination and its change history.
+func (s *LinkService) DeleteDynamicLink(ctx context.Context, userID string, linkID uuid.UUID) (db.DynamicLink, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.DynamicLink{}, err
+	}
+
+	dynamic, err := s.queries.DeleteDynamicLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DynamicLink{}, fmt.Errorf("%w: %v", apperrors.DynamicLinkNotFound, err)
+		}
+		return db.DynamicLink{}, fmt.Errorf("failed to delete dynamic link: %w", err)
+	}
+
+	return dynamic, nil
+}
+
+// ChangeDestination points one of the user's dynamic links at a new destination right away
+func (s *LinkService) ChangeDestination(ctx context.Context, userID string, linkID uuid.UUID, destination string) (db.UpdateLinkRow, error) {
+	normalizedURL, err := s.destinationURL(destination)
+	if err != nil {
+		return db.UpdateLinkRow{}, err
+	}
+	if err := s.checkDestinationChangeable(ctx, userID, linkID); err != nil {
+		return db.UpdateLinkRow{}, err
+	}
+
+	changed, err := s.queries.ChangeLinkDestination(ctx, db.ChangeLinkDestinationParams{
+		ID:              linkID,
+		UserID:          userID,
+		RawUrl:          destination,
+		Url:             normalizedURL,
+		HttpDestination: isHTTPDestination(normalizedURL),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.UpdateLinkRow{}, s.missingOrRetired(ctx, userID, linkID, err)
+		}
+		return db.UpdateLinkRow{}, fmt.Errorf("failed to change link destination: %w", err)
+	}
+
+	s.invalidateCache(ctx, changed.Shortcode)
+
+	s.logger.Info("Link destination changed",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+	)
+
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkUpdated, changed.ID,
+		fmt.Sprintf("Changed destination of link %s to %s", changed.Shortcode, destination))
+
+	return db.UpdateLinkRow(changed), nil
+}
+
+// ScheduleDestinationChange schedules one of the user's dynamic links to point at a new destination at a later time
+func (s *LinkService) ScheduleDestinationChange(ctx context.Context, userID string, linkID uuid.UUID, destination string, at time.Time) (db.LinkDestinationChange, error) {
+	normalizedURL, err := s.destinationURL(destination)
+	if err != nil {
+		return db.LinkDestinationChange{}, err
+	}
+	if err := s.checkDestinationChangeable(ctx, userID, linkID); err != nil {
+		return db.LinkDestinationChange{}, err
+	}
+
+	change, err := s.queries.ScheduleLinkDestinationChange(ctx, db.ScheduleLinkDestinationChangeParams{
+		LinkID:          linkID,
+		UserID:          userID,
+		RawUrl:          destination,
+		Url:             normalizedURL,
+		ScheduledAt:     pgtype.Timestamptz{Time: at, Valid: true},
+		HttpDestination: isHTTPDestination(normalizedURL),
+	})
+	if err != nil {
+		return db.LinkDestinationChange{}, fmt.Errorf("failed to schedule destination change: %w", err)
+	}
+
+	s.logger.Info("Link destination change scheduled",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+		zap.Time("scheduled_at", at),
+	)
+
+	return change, nil
+}
+
+// ListDestinationChanges returns a page of the scheduled and applied destination changes of one of the user's links, latest first
+func (s *LinkService) ListDestinationChanges(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*ListDestinationChangesResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return nil, err
+	}
+
+	total, err := s.queries.CountLinkDestinationChanges(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count destination changes: %w", err)
+	}
+
+	changes, err := s.queries.ListLinkDestinationChanges(ctx, db.ListLinkDestinationChangesParams{
+		LinkID: linkID,
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination changes: %w", err)
+	}
+
+	return &ListDestinationChangesResult{
+		Changes: changes,
+		Meta:    p.Meta(total),
+	}, nil
+}
+
+// CancelDestinationChange cancels a destination change of one of the user's links that hasn't been applied yet
+func (s *LinkService) CancelDestinationChange(ctx context.Context, userID string, linkID uuid.UUID, changeID uuid.UUID) (db.LinkDestinationChange, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkDestinationChange{}, err
+	}
+
+	change, err := s.queries.CancelLinkDestinationChange(ctx, db.CancelLinkDestinationChangeParams{
+		ID:     changeID,
+		LinkID: linkID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkDestinationChange{}, fmt.Errorf("%w: %v", apperrors.DestinationChangeNotFound, err)
+		}
+		return db.LinkDestinationChange{}, fmt.Errorf("failed to cancel destination change: %w", err)
+	}
+
+	return change, nil
+}
+
+// destinationURL validates a new destination and returns its canonical form, as CreateShortLink stores it
+func (s *LinkService) destinationURL(destination string) (string, error) {
+	if err := validateURL(destination); err != nil {
+		return "", err
+	}
+	if err := s.policy.CheckDestination(destination); err != nil {
+		return "", err
+	}
+
+	normalizedURL, err := s.normalizer.Normalize(destination)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", apperrors.InvalidURL, err)
+	}
+	return normalizedURL, nil
+}
+
+// checkDestinationChangeable fails unless the link is one of the user's dynamic links and isn't locked
+func (s *LinkService) checkDestinationChangeable(ctx context.Context, userID string, linkID uuid.UUID) error {
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+	if link.RetiredAt.Valid {
+		return fmt.Errorf("%w: %s", apperrors.LinkRetired, link.Shortcode)
+	}
+
+	dynamic, err := s.queries.GetDynamicLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", apperrors.LinkNotDynamic, link.Shortcode)
+		}
+		return fmt.Errorf("failed to get dynamic link: %w", err)
+	}
+	if dynamic.Locked {
+		return fmt.Errorf("%w: %s", apperrors.LinkDestinationLocked, link.Shortcode)
+	}
+
+	return nil
+}
+
+// DestinationScheduler applies the destination changes of dynamic links once they're due
+type DestinationScheduler struct {
+	queries repository.DestinationSchedulerQueries
+	cache   cache.Cache
+	logger  logger.Logger
+}
+
+func NewDestinationScheduler(queries repository.DestinationSchedulerQueries, cache cache.Cache, logger logger.Logger) *DestinationScheduler {
+	return &DestinationScheduler{
+		queries: queries,
+		cache:   cache,
+		logger:  logger,
+	}
+}
+
+/*
+Run applies the changes due, oldest first, so of several changes due for a link
+the latest scheduled wins. Each change is applied by a single statement that
+skips it once applied, so instances running the scheduler at the same time
+don't apply a change twice.
+*/
+func (d *DestinationScheduler) Run(ctx context.Context) error {
+	ids, err := d.queries.ListDueLinkDestinationChanges(ctx, destinationSchedulerBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list due destination changes: %w", err)
+	}
+
+	applied := 0
+	for _, id := range ids {
+		link, err := d.queries.ApplyLinkDestinationChange(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// Applied by another instance, or its link was deleted or retired since
+				continue
+			}
+			return fmt.Errorf("failed to apply destination change: %w", err)
+		}
+		applied++
+
+		invalidateLinkCache(ctx, d.cache, d.logger, link.Shortcode)
+
+		recordActivity(ctx, d.queries, d.logger, link.UserID, ActivityLinkUpdated, link.ID,
+			fmt.Sprintf("Changed destination of link %s to %s, as scheduled", link.Shortcode, link.OriginalUrl))
+	}
+
+	if applied > 0 {
+		d.logger.Info("Scheduled link destination changes applied",
+			zap.Int("changes", applied),
+		)
+	}
+	return nil
+}
+
+// Start applies the changes due now and then every interval until ctx is done
+func (d *DestinationScheduler) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			d.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (d *DestinationScheduler) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, destinationSchedulerTimeout)
+	defer cancel()
+
+	if err := d.Run(ctx); err != nil && ctx.Err() == nil {
+		d.logger.Error("Destination scheduler failed",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+	"github.com/styltsou/url-shortener/server/pkg/urlnorm"
+)
+
+func TestLinkService_ChangeDestination(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		dynamic     bool
+		locked      bool
+		retired     bool
+		expectedErr error
+	}{
+		{name: "changes the destination", destination: "https://example.com/menu-winter", dynamic: true},
+		{name: "link isn't dynamic", destination: "https://example.com/menu-winter", expectedErr: apperrors.LinkNotDynamic},
+		{name: "link is locked", destination: "https://example.com/menu-winter", dynamic: true, locked: true, expectedErr: apperrors.LinkDestinationLocked},
+		{name: "link is retired", destination: "https://example.com/menu-winter", dynamic: true, retired: true, expectedErr: apperrors.LinkRetired},
+		{name: "invalid destination", destination: "ftp://example.com/menu", dynamic: true, expectedErr: apperrors.InvalidURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changed *db.ChangeLinkDestinationParams
+			mockQueries := &mocks.LinkQueries{
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					link := db.GetLinkByIdAndUserRow{ID: arg.ID, Shortcode: "menu"}
+					if tt.retired {
+						link.RetiredAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+					}
+					return link, nil
+				},
+				GetDynamicLinkFunc: func(ctx context.Context, linkID uuid.UUID) (db.DynamicLink, error) {
+					if !tt.dynamic {
+						return db.DynamicLink{}, sql.ErrNoRows
+					}
+					return db.DynamicLink{LinkID: linkID, Locked: tt.locked}, nil
+				},
+				ChangeLinkDestinationFunc: func(ctx context.Context, arg db.ChangeLinkDestinationParams) (db.ChangeLinkDestinationRow, error) {
+					changed = &arg
+					return db.ChangeLinkDestinationRow{ID: arg.ID, Shortcode: "menu", OriginalUrl: arg.Url}, nil
+				},
+				CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+					return nil
+				},
+			}
+			service := &LinkService{
+				queries:    mockQueries,
+				normalizer: urlnorm.New(urlnorm.Options{}),
+				logger:     createTestLogger(),
+			}
+
+			link, err := service.ChangeDestination(context.Background(), "user_123", uuid.New(), tt.destination)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("ChangeDestination() error = %v, want %v", err, tt.expectedErr)
+				}
+				if changed != nil {
+					t.Error("ChangeDestination() changed the destination despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangeDestination() unexpected error = %v", err)
+			}
+			if changed == nil || changed.RawUrl != tt.destination || changed.UserID != "user_123" {
+				t.Errorf("ChangeDestination() stored %+v, want the destination for user_123", changed)
+			}
+			if link.OriginalUrl != tt.destination {
+				t.Errorf("ChangeDestination() original_url = %q, want %q", link.OriginalUrl, tt.destination)
+			}
+		})
+	}
+}
+
+func TestLinkService_ScheduleDestinationChange(t *testing.T) {
+	at := time.Now().Add(24 * time.Hour)
+
+	var scheduled db.ScheduleLinkDestinationChangeParams
+	mockQueries := &mocks.LinkQueries{
+		GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+			return db.GetLinkByIdAndUserRow{ID: arg.ID, Shortcode: "menu"}, nil
+		},
+		GetDynamicLinkFunc: func(ctx context.Context, linkID uuid.UUID) (db.DynamicLink, error) {
+			return db.DynamicLink{LinkID: linkID}, nil
+		},
+		ScheduleLinkDestinationChangeFunc: func(ctx context.Context, arg db.ScheduleLinkDestinationChangeParams) (db.LinkDestinationChange, error) {
+			scheduled = arg
+			return db.LinkDestinationChange{ID: uuid.New(), LinkID: arg.LinkID, Url: arg.Url, ScheduledAt: arg.ScheduledAt}, nil
+		},
+	}
+	service := &LinkService{
+		queries:    mockQueries,
+		normalizer: urlnorm.New(urlnorm.Options{}),
+		logger:     createTestLogger(),
+	}
+
+	linkID := uuid.New()
+	change, err := service.ScheduleDestinationChange(context.Background(), "user_123", linkID, "https://example.com/menu-summer", at)
+	if err != nil {
+		t.Fatalf("ScheduleDestinationChange() unexpected error = %v", err)
+	}
+	if scheduled.LinkID != linkID || !scheduled.ScheduledAt.Time.Equal(at) {
+		t.Errorf("ScheduleDestinationChange() stored %+v, want link %s at %s", scheduled, linkID, at)
+	}
+	if change.Url != "https://example.com/menu-summer" {
+		t.Errorf("ScheduleDestinationChange() url = %q, want https://example.com/menu-summer", change.Url)
+	}
+}
+
+func TestDestinationScheduler_Run(t *testing.T) {
+	applied := uuid.New()
+	raced := uuid.New()
+
+	linkCache := cache.NewMemory()
+	linkCache.Set(context.Background(), cacheKeyPrefix+"menu", "https://example.com/menu-summer", cacheTTL)
+
+	var activity []db.CreateActivityEventParams
+	mockQueries := &mocks.DestinationSchedulerQueries{
+		ListDueLinkDestinationChangesFunc: func(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+			return []uuid.UUID{applied, raced}, nil
+		},
+		ApplyLinkDestinationChangeFunc: func(ctx context.Context, id uuid.UUID) (db.ApplyLinkDestinationChangeRow, error) {
+			if id == raced {
+				// Applied by another instance in the meantime
+				return db.ApplyLinkDestinationChangeRow{}, sql.ErrNoRows
+			}
+			return db.ApplyLinkDestinationChangeRow{ID: uuid.New(), Shortcode: "menu", UserID: "user_123", OriginalUrl: "https://example.com/menu-winter"}, nil
+		},
+		CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+			activity = append(activity, arg)
+			return nil
+		},
+	}
+	scheduler := NewDestinationScheduler(mockQueries, linkCache, createTestLogger())
+
+	if err := scheduler.Run(context.Background()); err != nil {
+		t.Fatalf("Run() unexpected error = %v", err)
+	}
+
+	if _, err := linkCache.Get(context.Background(), cacheKeyPrefix+"menu"); !errors.Is(err, cache.ErrMiss) {
+		t.Error("Run() left the old destination cached")
+	}
+	if len(activity) != 1 || activity[0].UserID != "user_123" {
+		t.Errorf("Run() recorded activity %+v, want one event for user_123", activity)
+	}
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// Keys read from Redis per SCAN and MGET of a snapshot
+	edgeSnapshotBatch = 1000
+	// Upper bound for one snapshot
+	edgeSnapshotTimeout = time.Minute
+	// Path of the primary's internal route edge clicks are sent to
+	EdgeClicksPath = "/edge/clicks"
+	// Most clicks sent to the primary at once
+	MaxEdgeClicksBatch = 500
+	// Longest an edge click waits for its batch to fill
+	edgeClicksFlushInterval = time.Second
+	// Clicks held while a batch is sent, past which new ones are dropped
+	edgeClicksQueueSize = 20 * MaxEdgeClicksBatch
+	// Longest a batch can take to send before its clicks are given up on
+	edgeClicksSendTimeout = 10 * time.Second
+)
+
+/*
+EdgeLinks resolves redirects on an edge instance from a local copy of the
+primary's redirect cache. The copy is filled from the cache's Redis (the
+primary's, or a replica of it in the edge's region) by a snapshot on start and
+every interval, and keys the primary invalidates are dropped as it publishes
+them. Entries expire after ttl, which bounds how long an invalidation missed
+while disconnected leaves a link stale.
+
+Only links the redirect cache holds resolve; the others are the primary's to
+serve, as are links it has yet to cache until the next snapshot.
+*/
+type EdgeLinks struct {
+	redis  *redis.Client
+	local  cache.Cache
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+func NewEdgeLinks(redis *redis.Client, local cache.Cache, ttl time.Duration, logger logger.Logger) *EdgeLinks {
+	return &EdgeLinks{
+		redis:  redis,
+		local:  local,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Resolve returns the link behind a shortcode if it's cached locally
+func (e *EdgeLinks) Resolve(ctx context.Context, code string) (db.GetLinkForRedirectRow, bool) {
+	cached, err := e.local.Get(ctx, cacheKeyPrefix+code)
+	if err != nil {
+		return db.GetLinkForRedirectRow{}, false
+	}
+
+	link, err := decodeCachedRedirect(cached)
+	if err != nil {
+		return db.GetLinkForRedirectRow{}, false
+	}
+	return link, true
+}
+
+// Snapshot copies the redirect cache into the local cache, returning how many links it copied
+func (e *EdgeLinks) Snapshot(ctx context.Context) (int, error) {
+	copied := 0
+	var cursor uint64
+	for {
+		keys, next, err := e.redis.Scan(ctx, cursor, cacheKeyPrefix+"*", edgeSnapshotBatch).Result()
+		if err != nil {
+			return copied, fmt.Errorf("failed to list cached links: %w", err)
+		}
+
+		if len(keys) > 0 {
+			values, err := e.redis.MGet(ctx, keys...).Result()
+			if err != nil {
+				return copied, fmt.Errorf("failed to read cached links: %w", err)
+			}
+			for i, value := range values {
+				// Keys invalidated since the scan come back nil
+				cached, ok := value.(string)
+				if !ok {
+					continue
+				}
+				if err := e.local.Set(ctx, keys[i], cached, e.ttl); err != nil {
+					return copied, fmt.Errorf("failed to cache link: %w", err)
+				}
+				copied++
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return copied, nil
+		}
+	}
+}
+
+// Load loads a snapshot of the mappings (see LoadSnapshot) into the local cache, returning how many entries it applied
+func (e *EdgeLinks) Load(ctx context.Context, r io.Reader) (int, error) {
+	header, applied, err := LoadSnapshot(ctx, e.local, r, e.ttl)
+	if err != nil {
+		return applied, err
+	}
+
+	e.logger.Info("Mapping snapshot loaded",
+		zap.Time("taken_at", header.TakenAt),
+		zap.Bool("diff", header.IsDiff()),
+		zap.Int("entries", applied),
+	)
+	return applied, nil
+}
+
+// Start follows the primary's invalidations and snapshots its redirect cache now and every interval, until ctx is done
+func (e *EdgeLinks) Start(ctx context.Context, interval time.Duration) {
+	// Subscribed first, so nothing invalidated during the first snapshot is missed
+	if err := cache.Subscribe(ctx, e.redis, e.local); err != nil {
+		e.logger.Warn("Failed to subscribe to cache invalidations, retrying in the background",
+			zap.Error(err),
+		)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		e.snapshotOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.snapshotOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (e *EdgeLinks) snapshotOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, edgeSnapshotTimeout)
+	defer cancel()
+
+	copied, err := e.Snapshot(ctx)
+	if err != nil && ctx.Err() == nil {
+		e.logger.Error("Redirect cache snapshot failed",
+			zap.Error(err),
+			zap.Int("copied", copied),
+		)
+		return
+	}
+	e.logger.Debug("Redirect cache snapshot taken",
+		zap.Int("copied", copied),
+	)
+}
+
+// ErrEdgeClicksFull means the click was dropped because the edge's queue was full
+var ErrEdgeClicksFull = errors.New("edge click queue is full")
+
+/*
+EdgeClicks sends the clicks an edge instance serves to the primary, which
+records them like its own. Clicks are queued and sent in batches of
+MaxEdgeClicksBatch or every second, so redirects never wait for the primary;
+when the queue is full, or a batch fails to send, the clicks are dropped and
+counted. Close sends what's still queued.
+*/
+type EdgeClicks struct {
+	url       string
+	token     string
+	client    *http.Client
+	queue     chan Click
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    logger.Logger
+}
+
+// NewEdgeClicks starts sending clicks queued with RecordClick to the primary's internal origin, until Close
+func NewEdgeClicks(primaryInternalURL, token string, client *http.Client, logger logger.Logger) *EdgeClicks {
+	c := &EdgeClicks{
+		url:    primaryInternalURL + EdgeClicksPath,
+		token:  token,
+		client: client,
+		queue:  make(chan Click, edgeClicksQueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+	go c.run()
+	return c
+}
+
+// RecordClick queues the click, returning ErrEdgeClicksFull when the queue has no room for it
+func (c *EdgeClicks) RecordClick(ctx context.Context, click Click) error {
+	select {
+	case c.queue <- click:
+		return nil
+	default:
+		metrics.ClicksDropped.Add("edge_queue_full", 1)
+		return ErrEdgeClicksFull
+	}
+}
+
+// Close sends the queued clicks and stops, or gives up waiting when ctx ends
+func (c *EdgeClicks) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.stop) })
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *EdgeClicks) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(edgeClicksFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Click, 0, MaxEdgeClicksBatch)
+	add := func(click Click) {
+		batch = append(batch, click)
+		if len(batch) >= MaxEdgeClicksBatch {
+			batch = c.send(batch)
+		}
+	}
+
+	for {
+		select {
+		case click := <-c.queue:
+			add(click)
+		case <-ticker.C:
+			batch = c.send(batch)
+		case <-c.stop:
+			for {
+				select {
+				case click := <-c.queue:
+					add(click)
+				default:
+					c.send(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts the batch to the primary and returns it emptied for reuse
+func (c *EdgeClicks) send(batch []Click) []Click {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), edgeClicksSendTimeout)
+	defer cancel()
+
+	if err := c.post(ctx, batch); err != nil {
+		metrics.ClicksDropped.Add("edge_send_failed", int64(len(batch)))
+		c.logger.Warn("Failed to send clicks to the primary",
+			zap.Error(err),
+			zap.Int("clicks", len(batch)),
+		)
+	}
+
+	return batch[:0]
+}
+
+func (c *EdgeClicks) post(ctx context.Context, batch []Click) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode clicks: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send clicks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("primary answered %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+)
+
+func TestEdgeLinks_SnapshotResolve(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	id := uuid.New()
+	cached, _ := json.Marshal(cachedRedirect{ID: id, UserID: "user_1", OriginalURL: "https://example.com"})
+	mr.Set(cacheKeyPrefix+"abc", string(cached))
+	mr.Set(cacheKeyPrefix+"old", "https://example.org")
+	mr.Set("create-dedupe:xyz", "abc")
+
+	local := cache.NewMemory()
+	e := NewEdgeLinks(redis.NewClient(&redis.Options{Addr: mr.Addr()}), local, time.Minute, createTestLogger())
+
+	if _, ok := e.Resolve(ctx, "abc"); ok {
+		t.Error("Resolve() before a snapshot = true, want a miss")
+	}
+
+	copied, err := e.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if copied != 2 {
+		t.Errorf("Snapshot() copied %d, want the 2 cached links", copied)
+	}
+	if _, err := local.Get(ctx, "create-dedupe:xyz"); err == nil {
+		t.Error("Snapshot() copied a key that isn't a cached link")
+	}
+	if ttl, err := local.TTL(ctx, cacheKeyPrefix+"abc"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("local TTL = %v, %v, want up to a minute", ttl, err)
+	}
+
+	link, ok := e.Resolve(ctx, "abc")
+	if !ok || link.ID != id || link.UserID != "user_1" || link.OriginalUrl != "https://example.com" {
+		t.Errorf("Resolve() = %+v, %v, want the cached link", link, ok)
+	}
+	// Plain URLs of older entries are the primary's to resolve
+	if _, ok := e.Resolve(ctx, "old"); ok {
+		t.Error("Resolve() of a plain URL entry = true, want a miss")
+	}
+}
+
+func TestEdgeClicks(t *testing.T) {
+	var mu sync.Mutex
+	var received []Click
+	var auth string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != EdgeClicksPath {
+			http.NotFound(w, r)
+			return
+		}
+		var batch []Click
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, batch...)
+		auth = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer primary.Close()
+
+	c := NewEdgeClicks(primary.URL, "secret", primary.Client(), createTestLogger())
+	click := Click{
+		ID:        uuid.New(),
+		Shortcode: "abc",
+		LinkID:    uuid.New(),
+		UserID:    "user_1",
+		Source:    "web",
+		ClientIP:  netip.MustParseAddr("203.0.113.7"),
+	}
+	if err := c.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0] != click {
+		t.Errorf("primary received %+v, want %+v", received, click)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the edge token", auth)
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// How long a destination may take to answer a reachability check
+	ReachabilityTimeout = 5 * time.Second
+	// Most links checked per HTTPS upgrade run; the rest wait for the next one
+	httpsUpgradeBatch = 50
+	// Upper bound for one HTTPS upgrade run
+	httpsUpgradeTimeout = 10 * time.Minute
+)
+
+// Outcomes of an HTTPS upgrade check, stored in link_https_upgrades.status
+const (
+	// The link was switched to its https:// destination
+	HTTPSUpgradeUpgraded = "upgraded"
+	// https:// is reachable, but the job only reports
+	HTTPSUpgradeAvailable   = "available"
+	HTTPSUpgradeUnreachable = "unreachable"
+	// Destination templates aren't checked, their placeholders are only expanded at redirect time
+	HTTPSUpgradeSkipped = "skipped"
+)
+
+// ReachabilityChecker tells whether destinations answer
+type ReachabilityChecker struct {
+	client *http.Client
+}
+
+func NewReachabilityChecker(client *http.Client) *ReachabilityChecker {
+	return &ReachabilityChecker{client: client}
+}
+
+/*
+ReachableOverHTTPS reports whether destination answers over HTTPS with a
+non-error status, following redirects: one that ends up on plain http:// isn't.
+HEAD is tried first, then GET for servers that don't support it.
+*/
+func (c *ReachabilityChecker) ReachableOverHTTPS(ctx context.Context, destination string) bool {
+	ctx, cancel := context.WithTimeout(ctx, ReachabilityTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodHead, destination)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = c.do(ctx, http.MethodGet, destination)
+	}
+	if err != nil {
+		return false
+	}
+
+	return resp.StatusCode < http.StatusBadRequest && resp.Request.URL.Scheme == "https"
+}
+
+func (c *ReachabilityChecker) do(ctx context.Context, method string, destination string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, destination, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only the status matters; drained so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// httpsVariant returns the https:// variant of an http:// destination, and false for other destinations
+func httpsVariant(destination string) (string, bool) {
+	const scheme = "http://"
+	if len(destination) <= len(scheme) || !strings.EqualFold(destination[:len(scheme)], scheme) {
+		return "", false
+	}
+	return "https://" + destination[len(scheme):], true
+}
+
+// isHTTPDestination tells the http_destination flag of a link's stored (normalized) destination,
+// by which the HTTPS upgrade job finds its candidates even when destinations are encrypted
+func isHTTPDestination(normalizedURL string) bool {
+	return strings.HasPrefix(normalizedURL, "http://")
+}
+
+// UpgradeToHTTPS returns the https:// variant of an http:// destination when it's reachable,
+// and the destination as it is otherwise. Users opt in when creating links (upgrade_https).
+func (s *LinkService) UpgradeToHTTPS(ctx context.Context, destination string) string {
+	variant, ok := httpsVariant(destination)
+	if !ok || s.reachability == nil || IsDestinationTemplate(destination) {
+		return destination
+	}
+
+	if !s.reachability.ReachableOverHTTPS(ctx, variant) {
+		s.logger.Info("Destination kept on HTTP, HTTPS isn't reachable",
+			zap.String("destination", destination),
+		)
+		return destination
+	}
+
+	return variant
+}
+
+type ListHTTPSUpgradesResult struct {
+	Upgrades []db.ListUserHTTPSUpgradesRow
+	pagination.Meta
+}
+
+// ListHTTPSUpgrades returns a page of the HTTPS upgrade report of the user's links, latest checks first
+func (s *LinkService) ListHTTPSUpgrades(ctx context.Context, userID string, page, limit int) (*ListHTTPSUpgradesResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	total, err := s.queries.CountUserHTTPSUpgrades(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count HTTPS upgrades: %w", err)
+	}
+
+	upgrades, err := s.queries.ListUserHTTPSUpgrades(ctx, db.ListUserHTTPSUpgradesParams{
+		UserID:    userID,
+		RowLimit:  int32(p.Limit),
+		RowOffset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HTTPS upgrades: %w", err)
+	}
+
+	return &ListHTTPSUpgradesResult{
+		Upgrades: upgrades,
+		Meta:     p.Meta(total),
+	}, nil
+}
+
+type HTTPSUpgradeOptions struct {
+	// Switch links to their https:// destination; otherwise the job only reports which could be
+	Apply bool
+	// Links are checked again once their last check is older
+	RecheckAfter time.Duration
+}
+
+/*
+HTTPSUpgradeJob checks whether the http:// destinations of live links are
+reachable over HTTPS and, with Apply, switches the links to https://. Every
+check is recorded in link_https_upgrades, the report users see with GET
+/api/v1/links/https-upgrades. Links whose destination is locked aren't checked.
+*/
+type HTTPSUpgradeJob struct {
+	queries repository.HTTPSUpgradeQueries
+	checker *ReachabilityChecker
+	cache   cache.Cache
+	opts    HTTPSUpgradeOptions
+	logger  logger.Logger
+}
+
+func NewHTTPSUpgradeJob(queries repository.HTTPSUpgradeQueries, checker *ReachabilityChecker, cache cache.Cache, opts HTTPSUpgradeOptions, logger logger.Logger) *HTTPSUpgradeJob {
+	return &HTTPSUpgradeJob{
+		queries: queries,
+		checker: checker,
+		cache:   cache,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Run checks a batch of links, never checked ones first
+func (j *HTTPSUpgradeJob) Run(ctx context.Context) error {
+	links, err := j.queries.ListHTTPSUpgradeCandidates(ctx, db.ListHTTPSUpgradeCandidatesParams{
+		CheckedBefore: pgtype.Timestamptz{Time: time.Now().Add(-j.opts.RecheckAfter), Valid: true},
+		RowLimit:      httpsUpgradeBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list links to upgrade: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Redirects go to the URL as submitted
+		destination := link.OriginalUrl
+		if link.RawUrl != nil {
+			destination = *link.RawUrl
+		}
+		variant, _ := httpsVariant(destination)
+
+		status, err := j.check(ctx, link, destination, variant)
+		if err != nil {
+			return err
+		}
+		if status == "" {
+			continue
+		}
+		counts[status]++
+
+		if err := j.queries.UpsertHTTPSUpgradeCheck(ctx, db.UpsertHTTPSUpgradeCheckParams{
+			LinkID:  link.ID,
+			UserID:  link.UserID,
+			FromUrl: destination,
+			ToUrl:   variant,
+			Status:  status,
+		}); err != nil {
+			return fmt.Errorf("failed to record HTTPS upgrade check: %w", err)
+		}
+	}
+
+	if len(counts) > 0 {
+		j.logger.Info("HTTPS upgrade checks done",
+			zap.Int("upgraded", counts[HTTPSUpgradeUpgraded]),
+			zap.Int("available", counts[HTTPSUpgradeAvailable]),
+			zap.Int("unreachable", counts[HTTPSUpgradeUnreachable]),
+			zap.Int("skipped", counts[HTTPSUpgradeSkipped]),
+		)
+	}
+	return nil
+}
+
+// check checks one link and upgrades it with Apply. The status is empty when the link
+// changed or went away since it was listed, and isn't worth recording.
+func (j *HTTPSUpgradeJob) check(ctx context.Context, link db.ListHTTPSUpgradeCandidatesRow, destination, variant string) (string, error) {
+	if variant == "" || IsDestinationTemplate(destination) {
+		return HTTPSUpgradeSkipped, nil
+	}
+	if !j.checker.ReachableOverHTTPS(ctx, variant) {
+		return HTTPSUpgradeUnreachable, nil
+	}
+	if !j.opts.Apply {
+		return HTTPSUpgradeAvailable, nil
+	}
+
+	// Candidates are listed by their stored URL, which starts with http://
+	upgradedURL, _ := httpsVariant(link.OriginalUrl)
+	var upgradedRaw *string
+	if link.RawUrl != nil {
+		upgradedRaw = &variant
+	}
+
+	upgraded, err := j.queries.UpgradeLinkToHTTPS(ctx, db.UpgradeLinkToHTTPSParams{
+		Url:         upgradedURL,
+		RawUrl:      upgradedRaw,
+		ID:          link.ID,
+		PreviousUrl: link.OriginalUrl,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The destination changed, or the link was deleted or retired since
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to upgrade link to HTTPS: %w", err)
+	}
+
+	invalidateLinkCache(ctx, j.cache, j.logger, upgraded.Shortcode)
+
+	recordActivity(ctx, j.queries, j.logger, upgraded.UserID, ActivityLinkUpdated, upgraded.ID,
+		fmt.Sprintf("Upgraded destination of link %s to %s", upgraded.Shortcode, variant))
+
+	return HTTPSUpgradeUpgraded, nil
+}
+
+// Start checks a batch of links now and then every interval until ctx is done
+func (j *HTTPSUpgradeJob) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			j.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *HTTPSUpgradeJob) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, httpsUpgradeTimeout)
+	defer cancel()
+
+	if err := j.Run(ctx); err != nil && ctx.Err() == nil {
+		j.logger.Error("HTTPS upgrade job failed",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestHTTPSVariant(t *testing.T) {
+	tests := []struct {
+		destination string
+		want        string
+		wantOK      bool
+	}{
+		{destination: "http://example.com/a?b=c", want: "https://example.com/a?b=c", wantOK: true},
+		{destination: "HTTP://Example.com/", want: "https://Example.com/", wantOK: true},
+		{destination: "https://example.com/", wantOK: false},
+		{destination: "http://", wantOK: false},
+		{destination: "ftp://example.com/", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			got, ok := httpsVariant(tt.destination)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("httpsVariant(%q) = %q, %v, want %q, %v", tt.destination, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReachabilityChecker_ReachableOverHTTPS(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/downgrade":
+			http.Redirect(w, r, plain.URL, http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewReachabilityChecker(srv.Client())
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/ok", want: true},
+		{path: "/missing", want: false},
+		{path: "/get-only", want: true},
+		{path: "/downgrade", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := checker.ReachableOverHTTPS(context.Background(), srv.URL+tt.path); got != tt.want {
+				t.Errorf("ReachableOverHTTPS(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSUpgradeJob_Run(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	// The server's address over plain HTTP, which the job upgrades
+	insecure := "http://" + strings.TrimPrefix(srv.URL, "https://")
+
+	tests := []struct {
+		name           string
+		destination    string
+		apply          bool
+		expectedStatus string
+		expectUpgrade  bool
+	}{
+		{name: "upgrades reachable destinations", destination: insecure + "/page", apply: true, expectedStatus: HTTPSUpgradeUpgraded, expectUpgrade: true},
+		{name: "only reports without apply", destination: insecure + "/page", apply: false, expectedStatus: HTTPSUpgradeAvailable},
+		{name: "unreachable destination", destination: insecure + "/gone", apply: true, expectedStatus: HTTPSUpgradeUnreachable},
+		{name: "destination template", destination: insecure + "/{shortcode}", apply: true, expectedStatus: HTTPSUpgradeSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := db.ListHTTPSUpgradeCandidatesRow{Shortcode: "docs", OriginalUrl: tt.destination}
+
+			var upgraded *db.UpgradeLinkToHTTPSParams
+			var recorded db.UpsertHTTPSUpgradeCheckParams
+			queries := &mocks.HTTPSUpgradeQueries{
+				ListHTTPSUpgradeCandidatesFunc: func(ctx context.Context, arg db.ListHTTPSUpgradeCandidatesParams) ([]db.ListHTTPSUpgradeCandidatesRow, error) {
+					return []db.ListHTTPSUpgradeCandidatesRow{link}, nil
+				},
+				UpgradeLinkToHTTPSFunc: func(ctx context.Context, arg db.UpgradeLinkToHTTPSParams) (db.UpgradeLinkToHTTPSRow, error) {
+					upgraded = &arg
+					return db.UpgradeLinkToHTTPSRow{ID: arg.ID, Shortcode: link.Shortcode}, nil
+				},
+				UpsertHTTPSUpgradeCheckFunc: func(ctx context.Context, arg db.UpsertHTTPSUpgradeCheckParams) error {
+					recorded = arg
+					return nil
+				},
+				CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+					return nil
+				},
+			}
+			job := NewHTTPSUpgradeJob(queries, NewReachabilityChecker(srv.Client()), nil, HTTPSUpgradeOptions{Apply: tt.apply}, createTestLogger())
+
+			if err := job.Run(context.Background()); err != nil {
+				t.Fatalf("Run() error = %v, want nil", err)
+			}
+			if recorded.Status != tt.expectedStatus {
+				t.Errorf("recorded status = %q, want %q", recorded.Status, tt.expectedStatus)
+			}
+			if tt.expectUpgrade != (upgraded != nil) {
+				t.Fatalf("upgraded = %v, want %v", upgraded != nil, tt.expectUpgrade)
+			}
+			if upgraded != nil && (upgraded.Url != srv.URL+"/page" || upgraded.PreviousUrl != tt.destination) {
+				t.Errorf("UpgradeLinkToHTTPS called with %s (from %s), want %s/page", upgraded.Url, upgraded.PreviousUrl, srv.URL)
+			}
+		})
+	}
+}
+
+// sqlConn answers queries by their sqlc name with canned rows, recording the arguments each was
+// run with, so queries can be run through db.Encrypted. It's also its own transaction; methods
+// the tests don't use are left to the embedded (nil) interface.
+type sqlConn struct {
+	pgx.Tx
+	results map[string]*sqlRows
+	args    map[string][]any
+}
+
+func (c *sqlConn) record(sql string, args []any) string {
+	rest, _ := strings.CutPrefix(sql, "-- name: ")
+	name, _, _ := strings.Cut(rest, " ")
+	c.args[name] = args
+	return name
+}
+
+func (c *sqlConn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	c.record(sql, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *sqlConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	result, ok := c.results[c.record(sql, args)]
+	if !ok {
+		return &sqlRows{index: -1}, nil
+	}
+	return &sqlRows{columns: result.columns, rows: result.rows, index: -1}, nil
+}
+
+func (c *sqlConn) Begin(ctx context.Context) (pgx.Tx, error) { return c, nil }
+
+type sqlRows struct {
+	pgx.Rows
+	columns []string
+	rows    [][]any
+	index   int
+}
+
+func (r *sqlRows) Next() bool { r.index++; return r.index < len(r.rows) }
+func (r *sqlRows) Close()     {}
+func (r *sqlRows) Err() error { return nil }
+func (r *sqlRows) Scan(dest ...any) error {
+	for i, value := range r.rows[r.index] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func (r *sqlRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, name := range r.columns {
+		fields[i].Name = name
+	}
+	return fields
+}
+
+// With destinations encrypted at rest, the job sees them in plain text and stores what it writes encrypted
+func TestHTTPSUpgradeJob_Run_Encrypted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	destination := "http://" + strings.TrimPrefix(srv.URL, "https://") + "/page"
+	upgradedURL := srv.URL + "/page"
+
+	keys := testURLKeys(t, "k1")
+	linkID := uuid.New()
+	conn := &sqlConn{
+		args: map[string][]any{},
+		results: map[string]*sqlRows{
+			"ListHTTPSUpgradeCandidates": {
+				columns: []string{"id", "user_id", "shortcode", "original_url", "raw_url"},
+				rows:    [][]any{{linkID, "user_123", "docs", keys.Encrypt(destination), (*string)(nil)}},
+			},
+			"UpgradeLinkToHTTPS": {
+				columns: []string{"id", "user_id", "shortcode"},
+				rows:    [][]any{{linkID, "user_123", "docs"}},
+			},
+		},
+	}
+	queries := db.New(db.Encrypted(conn, keys))
+	job := NewHTTPSUpgradeJob(queries, NewReachabilityChecker(srv.Client()), nil, HTTPSUpgradeOptions{Apply: true}, createTestLogger())
+
+	if err := job.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// The update only applies while the stored destination is the one checked, as stored
+	upgrade := conn.args["UpgradeLinkToHTTPS"]
+	if upgrade == nil || upgrade[0] != keys.Encrypt(upgradedURL) || upgrade[3] != keys.Encrypt(destination) {
+		t.Errorf("UpgradeLinkToHTTPS args = %v, want the new and the previous destinations encrypted", upgrade)
+	}
+	check := conn.args["UpsertHTTPSUpgradeCheck"]
+	if check == nil || check[2] != keys.Encrypt(destination) || check[3] != keys.Encrypt(upgradedURL) || check[4] != HTTPSUpgradeUpgraded {
+		t.Errorf("UpsertHTTPSUpgradeCheck args = %v, want the upgrade recorded with its URLs encrypted", check)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key prefix of the sorted sets ranking a user's links by their clicks on a day: "<prefix><user id>:<day>"
+	leaderboardKeyPrefix = "top-links:"
+	// Days a leaderboard can be read for, today included
+	LeaderboardDays = 2
+	// Most links a leaderboard read returns
+	MaxLeaderboardLinks = 50
+	// Time after midnight UTC the previous day's leaderboards are reconciled, so buffered clicks have reached the analytics store
+	leaderboardReconcileDelay = 15 * time.Minute
+	// Upper bound for one reconciliation
+	leaderboardReconcileTimeout = 10 * time.Minute
+	// Most links whose owners are looked up at once
+	leaderboardOwnersBatch = 1000
+)
+
+// LeaderboardDay is the day a leaderboard counts clicks for; days start at midnight UTC
+func LeaderboardDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func leaderboardKey(userID string, day time.Time) string {
+	return leaderboardKeyPrefix + userID + ":" + day.Format(time.DateOnly)
+}
+
+// leaderboardExpiry is when a day's leaderboard stops being readable
+func leaderboardExpiry(day time.Time) time.Time {
+	return day.AddDate(0, 0, LeaderboardDays)
+}
+
+// TopLink is a link ranked by its clicks on a day
+type TopLink struct {
+	ID          uuid.UUID
+	Shortcode   string
+	OriginalURL string
+	Clicks      int64
+}
+
+/*
+Leaderboard ranks each user's links by their clicks of the day, in a Redis sorted
+set per user and day that redirects add their click to, so the top links of today
+or yesterday are read without querying the analytics store.
+
+Clicks counted while Redis is down are missing from the sets, and redirects may
+count clicks the analytics store drops (e.g. when its buffer is full), so once a
+day is over its sets are rebuilt from the analytics store's counts when it can
+count clicks by link.
+*/
+type Leaderboard struct {
+	queries repository.LeaderboardQueries
+	redis   *redis.Client
+	// Clicks the sets are reconciled with; nil leaves them as counted
+	clicks analytics.ClicksByLinkReader
+	logger logger.Logger
+}
+
+func NewLeaderboard(queries repository.LeaderboardQueries, redis *redis.Client, clicks analytics.ClicksByLinkReader, logger logger.Logger) *Leaderboard {
+	return &Leaderboard{
+		queries: queries,
+		redis:   redis,
+		clicks:  clicks,
+		logger:  logger,
+	}
+}
+
+// Add counts a click at t on the user's link. Clicks without a link or owner, e.g. on links
+// cached before the cache held them, aren't ranked; a nil Leaderboard counts nothing.
+func (l *Leaderboard) Add(ctx context.Context, userID string, linkID uuid.UUID, t time.Time) {
+	if l == nil || userID == "" || linkID == uuid.Nil {
+		return
+	}
+
+	day := LeaderboardDay(t)
+	key := leaderboardKey(userID, day)
+	pipe := l.redis.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, linkID.String())
+	pipe.ExpireAt(ctx, key, leaderboardExpiry(day))
+	if _, err := pipe.Exec(ctx); err != nil {
+		// Best-effort: the day's reconciliation adds the click back
+		l.logger.Warn("Failed to count click in leaderboard",
+			zap.Error(err),
+			zap.String("link_id", linkID.String()),
+		)
+	}
+}
+
+// Top returns the user's links with the most clicks on day, most first. Links deleted since
+// are left out, so fewer than limit may be returned; LeaderboardDisabled without Redis.
+func (l *Leaderboard) Top(ctx context.Context, userID string, day time.Time, limit int) ([]TopLink, error) {
+	if l == nil {
+		return nil, apperrors.LeaderboardDisabled
+	}
+
+	key := leaderboardKey(userID, LeaderboardDay(day))
+	ranked, err := l.redis.ZRevRangeWithScores(ctx, key, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leaderboard: %w", err)
+	}
+	if len(ranked) == 0 {
+		return []TopLink{}, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(ranked))
+	for _, z := range ranked {
+		if id, err := uuid.Parse(z.Member.(string)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows, err := l.queries.ListUserLinksByIDs(ctx, db.ListUserLinksByIDsParams{
+		UserID: userID,
+		Ids:    ids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links: %w", err)
+	}
+	links := make(map[string]db.ListUserLinksByIDsRow, len(rows))
+	for _, row := range rows {
+		links[row.ID.String()] = row
+	}
+
+	top := make([]TopLink, 0, len(ranked))
+	var gone []any
+	for _, z := range ranked {
+		link, ok := links[z.Member.(string)]
+		if !ok {
+			gone = append(gone, z.Member)
+			continue
+		}
+		top = append(top, TopLink{
+			ID:          link.ID,
+			Shortcode:   link.Shortcode,
+			OriginalURL: link.OriginalUrl,
+			Clicks:      int64(z.Score),
+		})
+	}
+
+	// Deleted links are taken out so the next read has them replaced
+	if len(gone) > 0 {
+		if err := l.redis.ZRem(ctx, key, gone...).Err(); err != nil {
+			l.logger.Warn("Failed to remove deleted links from leaderboard",
+				zap.Error(err),
+			)
+		}
+	}
+
+	return top, nil
+}
+
+/*
+Reconcile rebuilds the leaderboards of day from the clicks the analytics store
+counted on it, replacing what redirects counted. Clicks on deleted links, and
+clicks recorded without a link ID, aren't ranked; leaderboards of users left
+without clicks are removed. Rebuilding is idempotent, so instances reconciling
+at the same time are harmless.
+*/
+func (l *Leaderboard) Reconcile(ctx context.Context, day time.Time) error {
+	day = LeaderboardDay(day)
+	clicks, err := l.clicks.ClicksByLink(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]int64, len(clicks))
+	linkIDs := make([]uuid.UUID, len(clicks))
+	for i, c := range clicks {
+		counts[c.LinkID] = c.Clicks
+		linkIDs[i] = c.LinkID
+	}
+
+	boards := map[string][]redis.Z{}
+	for start := 0; start < len(linkIDs); start += leaderboardOwnersBatch {
+		owners, err := l.queries.ListLinkOwners(ctx, linkIDs[start:min(start+leaderboardOwnersBatch, len(linkIDs))])
+		if err != nil {
+			return fmt.Errorf("failed to get link owners: %w", err)
+		}
+		for _, owner := range owners {
+			key := leaderboardKey(owner.UserID, day)
+			boards[key] = append(boards[key], redis.Z{Score: float64(counts[owner.ID]), Member: owner.ID.String()})
+		}
+	}
+
+	var stale []string
+	iter := l.redis.Scan(ctx, 0, leaderboardKeyPrefix+"*:"+day.Format(time.DateOnly), 1000).Iterator()
+	for iter.Next(ctx) {
+		if _, ok := boards[iter.Val()]; !ok {
+			stale = append(stale, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to list leaderboards: %w", err)
+	}
+
+	pipe := l.redis.TxPipeline()
+	for key, board := range boards {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, board...)
+		pipe.ExpireAt(ctx, key, leaderboardExpiry(day))
+	}
+	if len(stale) > 0 {
+		pipe.Del(ctx, stale...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to write leaderboards: %w", err)
+	}
+
+	l.logger.Info("Leaderboards reconciled",
+		zap.String("day", day.Format(time.DateOnly)),
+		zap.Int("users", len(boards)),
+		zap.Int("removed", len(stale)),
+	)
+	return nil
+}
+
+// nextLeaderboardReconcile is when the leaderboards of the day before are reconciled next after now
+func nextLeaderboardReconcile(now time.Time) time.Time {
+	next := LeaderboardDay(now).Add(leaderboardReconcileDelay)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Start reconciles the previous day's leaderboards every night until ctx is done
+func (l *Leaderboard) Start(ctx context.Context) {
+	go func() {
+		for {
+			next := nextLeaderboardReconcile(time.Now())
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				l.reconcileOnce(ctx, next.AddDate(0, 0, -1))
+			}
+		}
+	}()
+}
+
+func (l *Leaderboard) reconcileOnce(ctx context.Context, day time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, leaderboardReconcileTimeout)
+	defer cancel()
+
+	if err := l.Reconcile(ctx, day); err != nil && ctx.Err() == nil {
+		l.logger.Error("Leaderboard reconciliation failed",
+			zap.Error(err),
+		)
+	}
+}
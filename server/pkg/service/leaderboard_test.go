@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+// leaderboardLinks stands in for the links table: live links by ID, with their owner
+func leaderboardLinks(links map[uuid.UUID]string) *mocks.LeaderboardQueries {
+	return &mocks.LeaderboardQueries{
+		ListLinkOwnersFunc: func(ctx context.Context, linkIDs []uuid.UUID) ([]db.ListLinkOwnersRow, error) {
+			var rows []db.ListLinkOwnersRow
+			for _, id := range linkIDs {
+				if owner, ok := links[id]; ok {
+					rows = append(rows, db.ListLinkOwnersRow{ID: id, UserID: owner})
+				}
+			}
+			return rows, nil
+		},
+		ListUserLinksByIDsFunc: func(ctx context.Context, arg db.ListUserLinksByIDsParams) ([]db.ListUserLinksByIDsRow, error) {
+			var rows []db.ListUserLinksByIDsRow
+			for _, id := range arg.Ids {
+				if links[id] == arg.UserID {
+					rows = append(rows, db.ListUserLinksByIDsRow{ID: id, Shortcode: id.String()[:6]})
+				}
+			}
+			return rows, nil
+		},
+	}
+}
+
+type fakeClicksByLink []analytics.LinkClicks
+
+func (f fakeClicksByLink) ClicksByLink(ctx context.Context, from, to time.Time) ([]analytics.LinkClicks, error) {
+	return f, nil
+}
+
+func TestLeaderboard_AddTop(t *testing.T) {
+	a, b, deleted := uuid.New(), uuid.New(), uuid.New()
+	links := map[uuid.UUID]string{a: "user_1", b: "user_1", deleted: "user_1"}
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	l := NewLeaderboard(leaderboardLinks(links), redis.NewClient(&redis.Options{Addr: mr.Addr()}), nil, createTestLogger())
+
+	// Leaderboards expire at a fixed time, so the clicks are made today
+	now := LeaderboardDay(time.Now()).Add(time.Hour)
+	for range 3 {
+		l.Add(ctx, "user_1", b, now)
+	}
+	l.Add(ctx, "user_1", a, now)
+	l.Add(ctx, "user_1", deleted, now)
+	l.Add(ctx, "user_1", deleted, now)
+	// Yesterday's clicks and other users' don't count
+	l.Add(ctx, "user_1", a, now.AddDate(0, 0, -1))
+	l.Add(ctx, "user_2", a, now)
+	// Nor do clicks on links cached without their IDs
+	l.Add(ctx, "", a, now)
+	l.Add(ctx, "user_1", uuid.Nil, now)
+	delete(links, deleted)
+
+	top, err := l.Top(ctx, "user_1", now, 10)
+	if err != nil {
+		t.Fatalf("Top() error = %v", err)
+	}
+	if len(top) != 2 || top[0].ID != b || top[0].Clicks != 3 || top[1].ID != a || top[1].Clicks != 1 {
+		t.Errorf("Top() = %+v, want %s with 3 clicks, then %s with 1", top, b, a)
+	}
+
+	// The deleted link is taken out of the set
+	if members, _ := mr.ZMembers(leaderboardKey("user_1", LeaderboardDay(now))); len(members) != 2 {
+		t.Errorf("leaderboard members = %v, want the 2 live links", members)
+	}
+	if ttl := mr.TTL(leaderboardKey("user_1", LeaderboardDay(now))); ttl <= 0 {
+		t.Errorf("leaderboard TTL = %v, want it to expire", ttl)
+	}
+
+	top, err = l.Top(ctx, "user_1", now, 1)
+	if err != nil || len(top) != 1 || top[0].ID != b {
+		t.Errorf("Top() with limit 1 = %+v, %v, want %s", top, err, b)
+	}
+}
+
+func TestLeaderboard_Reconcile(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	links := map[uuid.UUID]string{a: "user_1", b: "user_1", c: "user_2"}
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	clicks := fakeClicksByLink{{LinkID: a, Clicks: 7}, {LinkID: b, Clicks: 2}, {LinkID: uuid.New(), Clicks: 4}}
+	l := NewLeaderboard(leaderboardLinks(links), redis.NewClient(&redis.Options{Addr: mr.Addr()}), clicks, createTestLogger())
+
+	day := LeaderboardDay(time.Now()).AddDate(0, 0, -1)
+	// Counted by redirects: b's clicks overcounted, a's missed, and user_2's c has none in ClickHouse
+	for range 5 {
+		l.Add(ctx, "user_1", b, day.Add(time.Hour))
+	}
+	l.Add(ctx, "user_2", c, day.Add(time.Hour))
+
+	if err := l.Reconcile(ctx, day.Add(12*time.Hour)); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	top, err := l.Top(ctx, "user_1", day, 10)
+	if err != nil {
+		t.Fatalf("Top() error = %v", err)
+	}
+	if len(top) != 2 || top[0].ID != a || top[0].Clicks != 7 || top[1].ID != b || top[1].Clicks != 2 {
+		t.Errorf("Top() after Reconcile() = %+v, want %s with 7 clicks, then %s with 2", top, a, b)
+	}
+	if mr.Exists(leaderboardKey("user_2", day)) {
+		t.Error("user_2's leaderboard exists after Reconcile(), want it removed")
+	}
+	if ttl := mr.TTL(leaderboardKey("user_1", day)); ttl <= 0 {
+		t.Errorf("reconciled leaderboard TTL = %v, want it to expire", ttl)
+	}
+}
+
+func TestLeaderboard_Nil(t *testing.T) {
+	var l *Leaderboard
+	l.Add(context.Background(), "user_1", uuid.New(), time.Now())
+
+	if _, err := l.Top(context.Background(), "user_1", time.Now(), 10); !errors.Is(err, apperrors.LeaderboardDisabled) {
+		t.Errorf("Top() error = %v, want LeaderboardDisabled", err)
+	}
+}
+
+func TestNextLeaderboardReconcile(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{now: time.Date(2026, 3, 10, 0, 5, 0, 0, time.UTC), want: time.Date(2026, 3, 10, 0, 15, 0, 0, time.UTC)},
+		{now: time.Date(2026, 3, 10, 0, 15, 0, 0, time.UTC), want: time.Date(2026, 3, 11, 0, 15, 0, 0, time.UTC)},
+		{now: time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC), want: time.Date(2026, 3, 11, 0, 15, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := nextLeaderboardReconcile(tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextLeaderboardReconcile(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -4,19 +4,26 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
-	"net/url"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
 	"github.com/styltsou/url-shortener/server/pkg/db"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/urlnorm"
+	"github.com/styltsou/url-shortener/server/pkg/validation"
 	"go.uber.org/zap"
 )
 
@@ -25,6 +32,10 @@ const (
 	cacheKeyPrefix = "link:"
 	// Cache TTL: 24 hours
 	cacheTTL = 24 * time.Hour
+	// Longest email address accepted on email-gated links (RFC 5321 path limit)
+	maxEmailLength = 254
+	// Most links that can be fetched in one batch lookup
+	MaxBatchLinkIDs = 100
 )
 
 func generateRandomCode(n int) (string, error) {
@@ -46,31 +57,47 @@ func generateRandomCode(n int) (string, error) {
 	return string(b), nil
 }
 
-type LinkQueries interface {
-	TryCreateLink(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error)
-	GetLinkForRedirect(ctx context.Context, shortcode string) (db.GetLinkForRedirectRow, error)
-	ListUserLinks(ctx context.Context, arg db.ListUserLinksParams) ([]db.ListUserLinksRow, error)
-	CountUserLinks(ctx context.Context, arg db.CountUserLinksParams) (int64, error)
-	GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error)
-	GetLinkByShortcodeAndUser(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error)
-	UpdateLink(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error)
-	DeleteLink(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error)
-	AddTagsToLink(ctx context.Context, arg db.AddTagsToLinkParams) error
-	RemoveTagsFromLink(ctx context.Context, arg db.RemoveTagsFromLinkParams) error
-	GetLinkByIdAndUserWithTags(ctx context.Context, arg db.GetLinkByIdAndUserWithTagsParams) (db.GetLinkByIdAndUserWithTagsRow, error)
-}
-
 type LinkService struct {
-	queries LinkQueries
-	cache   *redis.Client
-	logger  logger.Logger
+	queries repository.LinkQueries
+	tx      repository.Transactor[repository.LinkQueries]
+	cache   cache.Cache
+	// Traffic cap counters, updated by Lua scripts; nil counts clicks in the database
+	counters *redis.Client
+	tokens   *AccessTokens
+	// Signs the cursors of the changes feed
+	cursors    *pagination.Cursors
+	normalizer *urlnorm.Normalizer
+	// Window in which identical creates return the first link; 0 disables dedupe
+	createDedupeWindow time.Duration
+	// Most links a user can have; 0 means unlimited
+	linkQuota int64
+	// Checks destinations of links created with upgrade_https
+	reachability *ReachabilityChecker
+	// Rules all links must follow; nil allows everything
+	policy *LinkPolicy
+	// Applied to the tags links are created with by name
+	tagPolicy *TagPolicy
+	// Tells the owner's webhooks about link changes; nil tells no one
+	webhooks *WebhookService
+	logger   logger.Logger
 }
 
-func NewLinkService(queries LinkQueries, cache *redis.Client, logger logger.Logger) *LinkService {
+func NewLinkService(queries repository.LinkQueries, tx repository.Transactor[repository.LinkQueries], cache cache.Cache, counters *redis.Client, tokens *AccessTokens, cursors *pagination.Cursors, normalizer *urlnorm.Normalizer, createDedupeWindow time.Duration, linkQuota int64, reachability *ReachabilityChecker, policy *LinkPolicy, tagPolicy *TagPolicy, webhooks *WebhookService, logger logger.Logger) *LinkService {
 	return &LinkService{
-		queries: queries,
-		cache:   cache,
-		logger:  logger,
+		queries:            queries,
+		tx:                 tx,
+		cache:              cache,
+		counters:           counters,
+		tokens:             tokens,
+		cursors:            cursors,
+		normalizer:         normalizer,
+		createDedupeWindow: createDedupeWindow,
+		linkQuota:          linkQuota,
+		reachability:       reachability,
+		policy:             policy,
+		tagPolicy:          tagPolicy,
+		webhooks:           webhooks,
+		logger:             logger,
 	}
 }
 
@@ -80,38 +107,180 @@ func (s *LinkService) CreateShortLink(
 	originalURL string,
 	customShortcode *string,
 	expiresAt *time.Time,
-) (db.TryCreateLinkRow, error) {
+	visibility *string,
+	captureEmail *bool,
+	redirectDelay *int32,
+	interstitialMessage *string,
+	appendClickID *bool,
+	title *string,
+	shield *bool,
+	referrerPolicy *string,
+	tagIDs []uuid.UUID,
+	tagNames []string,
+) (created db.TryCreateLinkRow, err error) {
 	// Validate URL - return sentinel error that handlers will map
 	if err := validateURL(originalURL); err != nil {
 		return db.TryCreateLinkRow{}, err
 	}
 
+	// Store the canonical form, keep the URL as submitted for redirects
+	normalizedURL, err := s.normalizer.Normalize(originalURL)
+	if err != nil {
+		return db.TryCreateLinkRow{}, fmt.Errorf("%w: %v", apperrors.InvalidURL, err)
+	}
+
 	// Validate expiration date if provided
 	if expiresAt != nil && expiresAt.Before(time.Now()) {
 		return db.TryCreateLinkRow{},
 			fmt.Errorf("%w: expires_at must be set to a future time", apperrors.InvalidURL)
 	}
 
+	// Normalized first, so the link policy checks the names the tags get
+	tagNames, err = s.tagPolicy.NormalizeNames(tagNames)
+	if err != nil {
+		return db.TryCreateLinkRow{}, err
+	}
+
+	if err := s.checkNewLinkPolicy(ctx, userID, originalURL, customShortcode, expiresAt, tagIDs, tagNames); err != nil {
+		return db.TryCreateLinkRow{}, err
+	}
+	if expiresAt == nil {
+		expiresAt = s.policy.DefaultExpiry(time.Now())
+	}
+
 	// Prepare expires_at for database
-	// When expiresAt is nil, pgtype.Timestamp{Valid: false} will be converted to NULL in PostgreSQL
-	var expiresAtTimestamp pgtype.Timestamp
+	// When expiresAt is nil, pgtype.Timestamptz{Valid: false} will be converted to NULL in PostgreSQL
+	var expiresAtTimestamp pgtype.Timestamptz
 	if expiresAt != nil {
-		expiresAtTimestamp = pgtype.Timestamp{
+		expiresAtTimestamp = pgtype.Timestamptz{
 			Time:  *expiresAt,
 			Valid: true,
 		}
 	} else {
-		expiresAtTimestamp = pgtype.Timestamp{Valid: false} // NULL expiration date
+		expiresAtTimestamp = pgtype.Timestamptz{Valid: false} // NULL expiration date
+	}
+
+	linkVisibility := LinkVisibilityPublic
+	if visibility != nil {
+		linkVisibility = *visibility
+	}
+
+	linkCaptureEmail := captureEmail != nil && *captureEmail
+	linkAppendClickID := appendClickID != nil && *appendClickID
+	linkShield := shield != nil && *shield
+
+	linkReferrerPolicy := ReferrerPolicyDefault
+	if referrerPolicy != nil {
+		linkReferrerPolicy = *referrerPolicy
+	}
+
+	var linkRedirectDelay int32
+	if redirectDelay != nil {
+		linkRedirectDelay = *redirectDelay
+	}
+
+	// Return the first result to a double-submitted create
+	if s.cache != nil && s.createDedupeWindow > 0 {
+		key := createDedupeKey(userID, normalizedURL, customShortcode)
+
+		first, claimed := s.claimCreate(ctx, key)
+		if first != nil {
+			s.logger.Info("Duplicate create request, returning the existing link",
+				zap.String("user_id", userID),
+				zap.String("shortcode", first.Shortcode),
+			)
+			return *first, nil
+		}
+		if claimed {
+			defer func() { s.completeCreate(ctx, key, created, err) }()
+		}
+	}
+
+	if s.linkQuota > 0 {
+		// Checked before the insert, so concurrent creates can overshoot the quota slightly
+		remaining, err := s.LinksRemaining(ctx, userID)
+		if err != nil {
+			return db.TryCreateLinkRow{}, err
+		}
+		if remaining == 0 {
+			return db.TryCreateLinkRow{},
+				fmt.Errorf("%w: user has reached the limit of %d links", apperrors.LinkQuotaExceeded, s.linkQuota)
+		}
+	}
+
+	if customShortcode != nil && IsReservedShortcode(*customShortcode) {
+		return db.TryCreateLinkRow{},
+			fmt.Errorf("%w: %s", apperrors.ShortcodeReserved, *customShortcode)
+	}
+
+	params := db.TryCreateLinkParams{
+		OriginalUrl:         normalizedURL,
+		UserID:              userID,
+		ExpiresAt:           expiresAtTimestamp,
+		Visibility:          linkVisibility,
+		CaptureEmail:        linkCaptureEmail,
+		RedirectDelay:       linkRedirectDelay,
+		InterstitialMessage: interstitialMessage,
+		RawUrl:              &originalURL,
+		AppendClickID:       linkAppendClickID,
+		Title:               title,
+		Shield:              linkShield,
+		ReferrerPolicy:      linkReferrerPolicy,
+		HttpDestination:     isHTTPDestination(normalizedURL),
+	}
+
+	if len(tagIDs) == 0 && len(tagNames) == 0 {
+		created, err = s.insertLink(ctx, s.queries, params, customShortcode)
+	} else {
+		// Link and tags are created together: an unknown tag leaves no untagged link behind
+		err = s.tx.WithTx(ctx, func(q repository.LinkQueries) error {
+			link, err := s.insertLink(ctx, q, params, customShortcode)
+			if err != nil {
+				return err
+			}
+
+			if err := tagNewLink(ctx, q, s.tagPolicy, userID, link.ID, tagIDs, tagNames); err != nil {
+				return err
+			}
+
+			created = link
+			return nil
+		})
+	}
+	if err != nil {
+		return db.TryCreateLinkRow{}, err
+	}
+
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkCreated, created.ID,
+		fmt.Sprintf("Created link %s to %s", created.Shortcode, originalURL))
+	s.webhooks.Notify(ctx, userID, WebhookEventLinkCreated, WebhookLinkData{
+		ID:          created.ID,
+		Shortcode:   created.Shortcode,
+		OriginalURL: created.OriginalUrl,
+	})
+
+	return created, nil
+}
+
+// LinksRemaining returns how many more links the user can create under the link quota.
+// Deleted links don't count towards the quota.
+func (s *LinkService) LinksRemaining(ctx context.Context, userID string) (int64, error) {
+	count, err := s.queries.CountUserLinks(ctx, db.CountUserLinksParams{
+		UserID: userID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count links: %w", err)
 	}
 
+	return max(s.linkQuota-count, 0), nil
+}
+
+// insertLink inserts the link with the custom shortcode, or with a generated one
+func (s *LinkService) insertLink(ctx context.Context, q repository.LinkQueries, params db.TryCreateLinkParams, customShortcode *string) (db.TryCreateLinkRow, error) {
 	// If custom shortcode is provided, try once and return error on conflict
 	if customShortcode != nil {
-		link, err := s.queries.TryCreateLink(ctx, db.TryCreateLinkParams{
-			Shortcode:   *customShortcode,
-			OriginalUrl: originalURL,
-			UserID:      userID,
-			ExpiresAt:   expiresAtTimestamp,
-		})
+		params.Shortcode = *customShortcode
+		link, err := q.TryCreateLink(ctx, params)
 
 		if err == nil {
 			return link, nil
@@ -141,12 +310,8 @@ func (s *LinkService) CreateShortLink(
 				fmt.Errorf("failed to generate short code: %w", err)
 		}
 
-		link, err := s.queries.TryCreateLink(ctx, db.TryCreateLinkParams{
-			Shortcode:   code,
-			OriginalUrl: originalURL,
-			UserID:      userID,
-			ExpiresAt:   expiresAtTimestamp,
-		})
+		params.Shortcode = code
+		link, err := q.TryCreateLink(ctx, params)
 
 		if err == nil {
 			return link, nil
@@ -168,39 +333,102 @@ func (s *LinkService) CreateShortLink(
 		fmt.Errorf("failed to create link after %d attempts: %w", maxAttempts, fmt.Errorf("code collision retry limit exceeded"))
 }
 
-// validateURL validates that the URL is well-formed and uses http/https
-// Returns sentinel error ErrInvalidURL that handlers will map to HTTP response
-func validateURL(rawURL string) error {
-	if rawURL == "" {
-		return fmt.Errorf("%w: URL is required", apperrors.InvalidURL)
+// uniqueIDs drops repeated IDs, keeping the first occurrence
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
 	}
+	return unique
+}
 
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return fmt.Errorf("%w: %v", apperrors.InvalidURL, err)
+// tagNewLink attaches existing tags (by ID) and tags created on the fly (by normalized name) to a new link.
+// Every tag ID must belong to the user; names that already exist, without case, reuse the user's tag.
+func tagNewLink(ctx context.Context, q repository.LinkQueries, policy *TagPolicy, userID string, linkID uuid.UUID, tagIDs []uuid.UUID, tagNames []string) error {
+	ids := uniqueIDs(tagIDs)
+
+	if len(ids) > 0 {
+		// AddTagsToLink silently skips tags it can't attach, so check ownership up front
+		owned, err := q.CountUserTagsByIDs(ctx, db.CountUserTagsByIDsParams{
+			UserID: userID,
+			Ids:    ids,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check tags: %w", err)
+		}
+		if owned != int64(len(ids)) {
+			return fmt.Errorf("%w: %d of %d tags not found", apperrors.TagNotFound, int64(len(ids))-owned, len(ids))
+		}
 	}
 
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return fmt.Errorf("%w: URL must use http or https scheme", apperrors.InvalidURL)
+	if len(tagNames) > 0 {
+		existing, err := userTagsByName(ctx, q, userID)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(tagNames))
+		created := 0
+		for _, name := range tagNames {
+			if tag, ok := existing[strings.ToLower(name)]; ok {
+				name = tag.Name
+			} else {
+				created++
+			}
+			names = append(names, name)
+		}
+		if err := policy.checkQuota(len(existing), created); err != nil {
+			return err
+		}
+
+		tags, err := q.UpsertTagsByName(ctx, db.UpsertTagsByNameParams{
+			Names:  names,
+			UserID: userID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+		for _, tag := range tags {
+			ids = append(ids, tag.ID)
+		}
+		ids = uniqueIDs(ids)
 	}
 
-	if parsedURL.Host == "" {
-		return fmt.Errorf("%w: URL must have a valid host", apperrors.InvalidURL)
+	if len(ids) == 0 {
+		return nil
 	}
 
-	if len(rawURL) > 2048 {
-		return fmt.Errorf("%w: URL is too long (max 2048 characters)", apperrors.InvalidURL)
+	if err := q.AddTagsToLink(ctx, db.AddTagsToLinkParams{
+		LinkID: linkID,
+		UserID: userID,
+		TagIDs: ids,
+	}); err != nil {
+		return fmt.Errorf("failed to add tags to link: %w", err)
+	}
+
+	return nil
+}
+
+// validateURL validates that the URL is well-formed and uses http/https, by the rules
+// of the httpurl validator tag. Returns sentinel error ErrInvalidURL that handlers will map to HTTP response
+func validateURL(rawURL string) error {
+	if err := validation.CheckHTTPURL(rawURL); err != nil {
+		return fmt.Errorf("%w: %v", apperrors.InvalidURL, err)
 	}
 
 	return nil
 }
 
+// linkListBounds are the page sizes of ListAllLinks
+var linkListBounds = pagination.Bounds{DefaultLimit: 5, MaxLimit: 100}
+
 type ListLinksResult struct {
-	Links      []db.ListUserLinksRow
-	Total      int64
-	Page       int
-	Limit      int
-	TotalPages int
+	Links []db.ListUserLinksRow
+	pagination.Meta
 }
 
 func (s *LinkService) ListAllLinks(ctx context.Context, userID string, isActive *bool, tagIDs []uuid.UUID, page, limit int) (*ListLinksResult, error) {
@@ -212,18 +440,7 @@ func (s *LinkService) ListAllLinks(ctx context.Context, userID string, isActive
 		zap.Int("limit", limit),
 	)
 
-	// Validate and set defaults
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 5
-	}
-	if limit > 100 {
-		limit = 100 // Max limit
-	}
-
-	offset := (page - 1) * limit
+	p := linkListBounds.Page(page, limit)
 
 	// Get total count
 	countParams := db.CountUserLinksParams{
@@ -245,8 +462,8 @@ func (s *LinkService) ListAllLinks(ctx context.Context, userID string, isActive
 		UserID:   userID,
 		IsActive: isActive,
 		TagIds:   tagIDs,
-		Offset:   int32(offset),
-		Limit:    int32(limit),
+		Offset:   int32(p.Offset()),
+		Limit:    int32(p.Limit),
 	}
 
 	links, err := s.queries.ListUserLinks(ctx, params)
@@ -258,24 +475,45 @@ func (s *LinkService) ListAllLinks(ctx context.Context, userID string, isActive
 		return nil, fmt.Errorf("failed to get links: %w", err)
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit)) // Ceiling division
+	meta := p.Meta(total)
 
 	s.logger.Debug("Database query completed for ListUserLinks",
 		zap.String("user_id", userID),
 		zap.Int("links_found", len(links)),
 		zap.Int64("total", total),
-		zap.Int("total_pages", totalPages),
+		zap.Int("total_pages", meta.TotalPages),
 	)
 
 	return &ListLinksResult{
-		Links:      links,
-		Total:      total,
-		Page:       page,
-		Limit:      limit,
-		TotalPages: totalPages,
+		Links: links,
+		Meta:  meta,
 	}, nil
 }
 
+// GetLinksByIDs returns the user's links among ids, newest first.
+// IDs that don't exist, were deleted or belong to another user are skipped.
+func (s *LinkService) GetLinksByIDs(ctx context.Context, userID string, ids []uuid.UUID) ([]db.ListUserLinksByIDsRow, error) {
+	if len(ids) == 0 {
+		return []db.ListUserLinksByIDsRow{}, nil
+	}
+
+	links, err := s.queries.ListUserLinksByIDs(ctx, db.ListUserLinksByIDsParams{
+		UserID: userID,
+		Ids:    ids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links: %w", err)
+	}
+
+	s.logger.Debug("Database query completed for ListUserLinksByIDs",
+		zap.String("user_id", userID),
+		zap.Int("ids_requested", len(ids)),
+		zap.Int("links_found", len(links)),
+	)
+
+	return links, nil
+}
+
 func (s *LinkService) GetLinkByShortcode(ctx context.Context, userID string, shortcode string) (db.GetLinkByShortcodeAndUserRow, error) {
 	link, err := s.queries.GetLinkByShortcodeAndUser(ctx, db.GetLinkByShortcodeAndUserParams{
 		Shortcode: shortcode,
@@ -294,45 +532,83 @@ func (s *LinkService) GetLinkByShortcode(ctx context.Context, userID string, sho
 	return link, nil
 }
 
+// cachedRedirect is what the redirect cache holds for a link; the IDs let clicks served
+// from the cache be attributed to the link and its owner
+type cachedRedirect struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      string    `json:"user_id"`
+	OriginalURL string    `json:"url"`
+}
+
+// decodeCachedRedirect returns the link a redirect cache entry holds. Entries written
+// before the cache held IDs are plain URLs, failing with a *json.SyntaxError.
+func decodeCachedRedirect(value string) (db.GetLinkForRedirectRow, error) {
+	var redirect cachedRedirect
+	if err := json.Unmarshal([]byte(value), &redirect); err != nil {
+		return db.GetLinkForRedirectRow{}, err
+	}
+
+	// Only links that redirect straight away are cached, see isCacheable
+	return db.GetLinkForRedirectRow{
+		ID:             redirect.ID,
+		OriginalUrl:    redirect.OriginalURL,
+		UserID:         redirect.UserID,
+		Visibility:     LinkVisibilityPublic,
+		ReferrerPolicy: ReferrerPolicyDefault,
+	}, nil
+}
+
 func (s *LinkService) GetOriginalURL(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
 	// Cache-aside pattern: Check cache first
 	cacheKey := cacheKeyPrefix + code
 
-	// Try to get from cache if Redis is available
+	// Try to get from cache if there is one
 	if s.cache != nil {
-		cachedURL, err := s.cache.Get(ctx, cacheKey).Result()
+		cached, err := s.cache.Get(ctx, cacheKey)
+		var link db.GetLinkForRedirectRow
+		if err == nil {
+			// Plain URLs of older entries are looked up again like a miss
+			link, err = decodeCachedRedirect(cached)
+		}
 		if err == nil {
 			// Cache hit - return immediately
 			s.logger.Debug("Cache hit for link redirect",
 				zap.String("shortcode", code),
 			)
-			return db.GetLinkForRedirectRow{
-				OriginalUrl: cachedURL,
-			}, nil
+			return link, nil
 		}
-		// Cache miss or Redis error - continue to database lookup
+		// Cache miss or cache error - continue to database lookup
 		// (We don't log cache misses as errors, they're expected)
-		if !errors.Is(err, redis.Nil) {
-			// Redis error (not a cache miss) - log but continue
-			s.logger.Warn("Redis cache error, falling back to database",
+		var syntaxErr *json.SyntaxError
+		if !errors.Is(err, cache.ErrMiss) && !errors.As(err, &syntaxErr) {
+			// Cache error (not a cache miss) - log but continue
+			s.logger.Warn("Cache error, falling back to database",
 				zap.String("shortcode", code),
 				zap.Error(err),
 			)
 		}
 	}
 
-	// Cache miss or Redis unavailable - query database
+	// Cache miss or no cache - query database
 	link, err := s.queries.GetLinkForRedirect(ctx, code)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			if s.isReservedForLater(ctx, code) {
+				return db.GetLinkForRedirectRow{}, fmt.Errorf("%w: code %s", apperrors.LinkPending, code)
+			}
 			return db.GetLinkForRedirectRow{}, fmt.Errorf("%w: code %s", apperrors.LinkNotFound, code)
 		}
 		return db.GetLinkForRedirectRow{}, fmt.Errorf("failed to get link: %w", err)
 	}
 
 	// Populate cache for next time (non-blocking - don't fail if cache write fails)
-	if s.cache != nil {
-		if err := s.cache.Set(ctx, cacheKey, link.OriginalUrl, cacheTTL).Err(); err != nil {
+	if s.cache != nil && isCacheable(link) {
+		cached, _ := json.Marshal(cachedRedirect{
+			ID:          link.ID,
+			UserID:      link.UserID,
+			OriginalURL: link.OriginalUrl,
+		})
+		if err := s.cache.Set(ctx, cacheKey, string(cached), cacheTTL); err != nil {
 			// Log but don't fail - cache write errors shouldn't break the request
 			s.logger.Warn("Failed to populate cache",
 				zap.String("shortcode", code),
@@ -348,6 +624,32 @@ func (s *LinkService) GetOriginalURL(ctx context.Context, code string) (db.GetLi
 	return link, nil
 }
 
+// isReservedForLater reports whether the shortcode is reserved for a link that doesn't exist yet.
+// Lookup errors count as not reserved: the visitor gets a 404 either way.
+func (s *LinkService) isReservedForLater(ctx context.Context, code string) bool {
+	_, err := s.queries.GetShortcodeReservation(ctx, code)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.logger.Warn("Failed to check shortcode reservation",
+			zap.String("shortcode", code),
+			zap.Error(err),
+		)
+	}
+	return err == nil
+}
+
+// isCacheable reports whether a redirect can be served from the cache.
+// The cache only holds the URL and IDs, so a hit would skip the access check, the lead form,
+// the interstitial, the click ID, the bot shield, the referrer policy, the sunset page, the traffic cap
+// or the extra response headers in the redirect handler.
+// Merged links aren't cached either: changes to the link they were merged into only
+// invalidate that link's shortcode.
+func isCacheable(link db.GetLinkForRedirectRow) bool {
+	return link.Visibility == LinkVisibilityPublic && !link.CaptureEmail && link.RedirectDelay == 0 &&
+		!link.AppendClickID && !link.Shield && link.ReferrerPolicy == ReferrerPolicyDefault &&
+		!link.RetiredAt.Valid && link.DailyCap == nil && link.TotalCap == nil &&
+		link.WaitingRoomRetryAfter == nil && link.ResponseHeaders == nil && !link.Merged
+}
+
 func (s *LinkService) UpdateLink(
 	ctx context.Context,
 	userID string,
@@ -355,30 +657,70 @@ func (s *LinkService) UpdateLink(
 	shortcode *string,
 	isActive *bool,
 	expiresAt *time.Time,
+	visibility *string,
+	captureEmail *bool,
+	redirectDelay *int32,
+	interstitialMessage *string,
+	appendClickID *bool,
+	shield *bool,
+	referrerPolicy *string,
 ) (db.UpdateLinkRow, error) {
+	if shortcode != nil && IsReservedShortcode(*shortcode) {
+		return db.UpdateLinkRow{},
+			fmt.Errorf("%w: %s", apperrors.ShortcodeReserved, *shortcode)
+	}
+	if shortcode != nil {
+		if err := s.policy.CheckShortcode(*shortcode); err != nil {
+			return db.UpdateLinkRow{}, err
+		}
+	}
+	if expiresAt != nil {
+		if err := s.policy.CheckExpiry(*expiresAt, time.Now()); err != nil {
+			return db.UpdateLinkRow{}, err
+		}
+	}
+
+	// Another user's reservation holds the shortcode like a link would
+	if shortcode != nil {
+		reservation, err := s.queries.GetShortcodeReservation(ctx, *shortcode)
+		if err == nil && reservation.UserID != userID {
+			return db.UpdateLinkRow{},
+				fmt.Errorf("%w: %s", apperrors.LinkShortcodeTaken, *shortcode)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return db.UpdateLinkRow{},
+				fmt.Errorf("failed to check shortcode reservation: %w", err)
+		}
+	}
 
-	var expiresAtTimestamp pgtype.Timestamp
+	var expiresAtTimestamp pgtype.Timestamptz
 	if expiresAt != nil {
-		expiresAtTimestamp = pgtype.Timestamp{
+		expiresAtTimestamp = pgtype.Timestamptz{
 			Time:  *expiresAt,
 			Valid: true,
 		}
 	} else {
-		expiresAtTimestamp = pgtype.Timestamp{Valid: false}
+		expiresAtTimestamp = pgtype.Timestamptz{Valid: false}
 	}
 
 	updatedLink, err := s.queries.UpdateLink(ctx, db.UpdateLinkParams{
-		UserID:    userID,
-		ID:        id,
-		Shortcode: shortcode,
-		IsActive:  isActive,
-		ExpiresAt: expiresAtTimestamp,
+		UserID:              userID,
+		ID:                  id,
+		Shortcode:           shortcode,
+		IsActive:            isActive,
+		ExpiresAt:           expiresAtTimestamp,
+		Visibility:          visibility,
+		CaptureEmail:        captureEmail,
+		RedirectDelay:       redirectDelay,
+		InterstitialMessage: interstitialMessage,
+		AppendClickID:       appendClickID,
+		Shield:              shield,
+		ReferrerPolicy:      referrerPolicy,
 	})
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return db.UpdateLinkRow{},
-				fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+			return db.UpdateLinkRow{}, s.missingOrRetired(ctx, userID, id, err)
 		}
 
 		var pgErr *pgconn.PgError
@@ -406,6 +748,34 @@ func (s *LinkService) UpdateLink(
 	// We invalidate using the new shortcode to ensure fresh data
 	s.invalidateCache(ctx, updatedLink.Shortcode)
 
+	var fields []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"shortcode", shortcode != nil},
+		{"is_active", isActive != nil},
+		{"expires_at", expiresAt != nil},
+		{"visibility", visibility != nil},
+		{"capture_email", captureEmail != nil},
+		{"redirect_delay", redirectDelay != nil},
+		{"interstitial_message", interstitialMessage != nil},
+		{"append_click_id", appendClickID != nil},
+		{"shield", shield != nil},
+		{"referrer_policy", referrerPolicy != nil},
+	} {
+		if f.set {
+			fields = append(fields, f.name)
+		}
+	}
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkUpdated, updatedLink.ID,
+		linkUpdateSummary(updatedLink.Shortcode, fields))
+	s.webhooks.Notify(ctx, userID, WebhookEventLinkUpdated, WebhookLinkData{
+		ID:          updatedLink.ID,
+		Shortcode:   updatedLink.Shortcode,
+		OriginalURL: updatedLink.OriginalUrl,
+	})
+
 	return updatedLink, nil
 }
 
@@ -417,7 +787,7 @@ func (s *LinkService) DeleteLink(ctx context.Context, userID string, id uuid.UUI
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return db.DeleteLinkRow{}, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+			return db.DeleteLinkRow{}, s.missingOrRetired(ctx, userID, id, err)
 		}
 
 		return db.DeleteLinkRow{}, fmt.Errorf("failed to delete link: %w", err)
@@ -426,6 +796,14 @@ func (s *LinkService) DeleteLink(ctx context.Context, userID string, id uuid.UUI
 	// Invalidate cache after successful deletion
 	s.invalidateCache(ctx, deletedLink.Shortcode)
 
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkDeleted, deletedLink.ID,
+		"Deleted link "+deletedLink.Shortcode)
+	s.webhooks.Notify(ctx, userID, WebhookEventLinkDeleted, WebhookLinkData{
+		ID:          deletedLink.ID,
+		Shortcode:   deletedLink.Shortcode,
+		OriginalURL: deletedLink.OriginalUrl,
+	})
+
 	return deletedLink, nil
 }
 
@@ -466,6 +844,9 @@ func (s *LinkService) AddTagsToLink(ctx context.Context, userID string, linkID u
 		return db.GetLinkByIdAndUserWithTagsRow{}, fmt.Errorf("failed to get link after adding tags: %w", err)
 	}
 
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkTagsAdded, link.ID,
+		fmt.Sprintf("Added %s to link %s", pluralize(len(tagIDs), "tag"), link.Shortcode))
+
 	return link, nil
 }
 
@@ -506,25 +887,120 @@ func (s *LinkService) RemoveTagsFromLink(ctx context.Context, userID string, lin
 		return db.GetLinkByIdAndUserWithTagsRow{}, fmt.Errorf("failed to get link after removing tags: %w", err)
 	}
 
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkTagsRemoved, link.ID,
+		fmt.Sprintf("Removed %s from link %s", pluralize(len(tagIDs), "tag"), link.Shortcode))
+
 	return link, nil
 }
 
+// CanAccessPrivateLink reports whether a visitor may follow a private link:
+// either they are signed in as its owner or they present a valid access token
+func (s *LinkService) CanAccessPrivateLink(link db.GetLinkForRedirectRow, viewerID string, token string) bool {
+	if viewerID != "" && viewerID == link.UserID {
+		return true
+	}
+
+	return s.tokens.Verify(link.ID, token, time.Now())
+}
+
+// CreateAccessToken signs a token that lets anyone holding it follow the (private) link until expiresAt
+func (s *LinkService) CreateAccessToken(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (string, error) {
+	if !s.tokens.Enabled() {
+		return "", apperrors.AccessTokensDisabled
+	}
+
+	if _, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return "", fmt.Errorf("failed to get link: %w", err)
+	}
+
+	return s.tokens.Sign(linkID, expiresAt), nil
+}
+
+// CaptureLead stores the email a visitor submitted on an email-gated link.
+// Submitting the same address twice is not an error.
+func (s *LinkService) CaptureLead(ctx context.Context, linkID uuid.UUID, email string) error {
+	address, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.CreateLinkLead(ctx, db.CreateLinkLeadParams{
+		LinkID: linkID,
+		Email:  address,
+	}); err != nil {
+		return fmt.Errorf("failed to store lead: %w", err)
+	}
+
+	s.logger.Debug("Lead captured",
+		zap.String("link_id", linkID.String()),
+	)
+
+	return nil
+}
+
+// ListLeads returns the emails captured on one of the user's links, newest first
+func (s *LinkService) ListLeads(ctx context.Context, userID string, linkID uuid.UUID) ([]db.ListLinkLeadsRow, error) {
+	if _, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	leads, err := s.queries.ListLinkLeads(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leads: %w", err)
+	}
+
+	return leads, nil
+}
+
+// normalizeEmail accepts a bare address (no display name) and lowercases it
+// so the same visitor isn't stored twice with different casing
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" || len(email) > maxEmailLength {
+		return "", fmt.Errorf("%w: %q", apperrors.InvalidEmail, email)
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("%w: %q", apperrors.InvalidEmail, email)
+	}
+
+	return strings.ToLower(address.Address), nil
+}
+
 // invalidateCache removes a link from the cache
 // This is called after updates and deletes to ensure cache consistency
 func (s *LinkService) invalidateCache(ctx context.Context, shortcode string) {
-	if s.cache == nil {
+	invalidateLinkCache(ctx, s.cache, s.logger, shortcode)
+}
+
+// invalidateLinkCache removes a link from the redirect cache, for changes made outside LinkService
+func invalidateLinkCache(ctx context.Context, cache cache.Cache, logger logger.Logger, shortcode string) {
+	if cache == nil {
 		return
 	}
 
 	cacheKey := cacheKeyPrefix + shortcode
-	if err := s.cache.Del(ctx, cacheKey).Err(); err != nil {
+	if err := cache.Del(ctx, cacheKey); err != nil {
 		// Log but don't fail - cache invalidation errors shouldn't break the request
-		s.logger.Warn("Failed to invalidate cache",
+		logger.Warn("Failed to invalidate cache",
 			zap.String("shortcode", shortcode),
 			zap.Error(err),
 		)
 	} else {
-		s.logger.Debug("Cache invalidated",
+		logger.Debug("Cache invalidated",
 			zap.String("shortcode", shortcode),
 		)
 	}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+// linkChangesBounds are the page sizes of ListLinkChanges
+var linkChangesBounds = pagination.Bounds{DefaultLimit: 100, MaxLimit: 500}
+
+// Change values of a link in the changes feed
+const (
+	LinkChangeCreated = "created"
+	LinkChangeUpdated = "updated"
+	LinkChangeDeleted = "deleted"
+)
+
+type LinkChangesResult struct {
+	Changes []db.ListLinkChangesRow
+	// Where the next poll resumes; set even when there were no changes
+	NextCursor string
+	// More changes are ready right away
+	HasMore bool
+}
+
+/*
+linkChangesCursor is a position in a user's changes feed. It carries the
+original since so links created after it keep being reported as created
+across pages.
+*/
+type linkChangesCursor struct {
+	Since     time.Time `json:"since"`
+	AfterTime time.Time `json:"after_time"`
+	AfterID   uuid.UUID `json:"after_id"`
+}
+
+/*
+ListLinkChanges returns the user's links created, updated or deleted after since,
+oldest change first, for clients polling for changes. A cursor from a previous
+result resumes right after its last change and takes precedence over since.
+
+Changes are ordered by (changed_at, id), so every change is returned exactly once
+while clients keep following cursors, and changes of the last few seconds are held
+back until the transactions that may still be writing them have committed.
+*/
+func (s *LinkService) ListLinkChanges(ctx context.Context, userID string, since time.Time, cursor string, limit int) (*LinkChangesResult, error) {
+	limit = linkChangesBounds.Limit(limit)
+
+	// Changes at exactly since aren't after it: start past every ID at that instant
+	pos := linkChangesCursor{Since: since.UTC(), AfterTime: since.UTC(), AfterID: uuid.Max}
+	if cursor != "" {
+		if err := s.cursors.Decode(cursor, &pos); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether there's another page
+	rows, err := s.queries.ListLinkChanges(ctx, db.ListLinkChangesParams{
+		Since:     pgtype.Timestamptz{Time: pos.Since, Valid: true},
+		UserID:    userID,
+		AfterTime: pgtype.Timestamptz{Time: pos.AfterTime, Valid: true},
+		AfterID:   pos.AfterID,
+		RowLimit:  int32(limit + 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link changes: %w", err)
+	}
+
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if rows == nil {
+		rows = []db.ListLinkChangesRow{}
+	}
+
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		pos.AfterTime = last.ChangedAt.Time
+		pos.AfterID = last.ID
+	}
+	next, err := s.cursors.Encode(pos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	s.logger.Debug("Database query completed for ListLinkChanges",
+		zap.String("user_id", userID),
+		zap.Int("changes", len(rows)),
+		zap.Bool("has_more", hasMore),
+	)
+
+	return &LinkChangesResult{
+		Changes:    rows,
+		NextCursor: next,
+		HasMore:    hasMore,
+	}, nil
+}
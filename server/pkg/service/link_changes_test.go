@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_ListLinkChanges(t *testing.T) {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	changedAt := func(minutes int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: since.Add(time.Duration(minutes) * time.Minute), Valid: true}
+	}
+	rows := []db.ListLinkChangesRow{
+		{ID: uuid.New(), Change: LinkChangeCreated, ChangedAt: changedAt(1)},
+		{ID: uuid.New(), Change: LinkChangeUpdated, ChangedAt: changedAt(2)},
+		{ID: uuid.New(), Change: LinkChangeDeleted, ChangedAt: changedAt(3)},
+	}
+
+	var got []db.ListLinkChangesParams
+	s := &LinkService{
+		queries: &mocks.LinkQueries{
+			ListLinkChangesFunc: func(ctx context.Context, arg db.ListLinkChangesParams) ([]db.ListLinkChangesRow, error) {
+				got = append(got, arg)
+
+				// Rows after the position, like the query
+				var page []db.ListLinkChangesRow
+				for _, row := range rows {
+					if row.ChangedAt.Time.After(arg.AfterTime.Time) && len(page) < int(arg.RowLimit) {
+						page = append(page, row)
+					}
+				}
+				return page, nil
+			},
+		},
+		cursors: pagination.NewCursors("test-secret-test-secret-test-secret"),
+		logger:  createTestLogger(),
+	}
+	ctx := context.Background()
+
+	first, err := s.ListLinkChanges(ctx, "user_1", since, "", 2)
+	if err != nil {
+		t.Fatalf("ListLinkChanges() error = %v", err)
+	}
+	if len(first.Changes) != 2 || !first.HasMore {
+		t.Fatalf("first page = %d changes, has_more %v, want 2 and true", len(first.Changes), first.HasMore)
+	}
+	if !got[0].AfterTime.Time.Equal(since) || got[0].AfterID != uuid.Max || got[0].RowLimit != 3 {
+		t.Errorf("first query = %+v, want to start after since and fetch one extra row", got[0])
+	}
+
+	second, err := s.ListLinkChanges(ctx, "user_1", time.Time{}, first.NextCursor, 2)
+	if err != nil {
+		t.Fatalf("ListLinkChanges(cursor) error = %v", err)
+	}
+	if len(second.Changes) != 1 || second.Changes[0].ID != rows[2].ID || second.HasMore {
+		t.Fatalf("second page = %+v, want the last change only", second.Changes)
+	}
+	if !got[1].Since.Time.Equal(since) {
+		t.Errorf("cursor since = %s, want the original %s", got[1].Since.Time, since)
+	}
+	if !got[1].AfterTime.Time.Equal(rows[1].ChangedAt.Time) || got[1].AfterID != rows[1].ID {
+		t.Errorf("cursor position = (%s, %s), want the last change of the first page", got[1].AfterTime.Time, got[1].AfterID)
+	}
+
+	// Polling an exhausted feed returns nothing and keeps the position
+	empty, err := s.ListLinkChanges(ctx, "user_1", time.Time{}, second.NextCursor, 2)
+	if err != nil {
+		t.Fatalf("ListLinkChanges(exhausted) error = %v", err)
+	}
+	if len(empty.Changes) != 0 || empty.NextCursor != second.NextCursor {
+		t.Errorf("exhausted feed = %d changes, cursor %q, want none and %q", len(empty.Changes), empty.NextCursor, second.NextCursor)
+	}
+
+	// Cursors signed with another key, like those of another deployment, are rejected too
+	forged := &LinkService{cursors: pagination.NewCursors(""), queries: s.queries, logger: s.logger}
+	other, err := forged.ListLinkChanges(ctx, "user_1", since, "", 2)
+	if err != nil {
+		t.Fatalf("ListLinkChanges() error = %v", err)
+	}
+
+	for _, cursor := range []string{"!!", "bm90LWEtY3Vyc29y", other.NextCursor} {
+		if _, err := s.ListLinkChanges(ctx, "user_1", time.Time{}, cursor, 2); !errors.Is(err, apperrors.InvalidCursor) {
+			t.Errorf("ListLinkChanges(%q) error = %v, want InvalidCursor", cursor, err)
+		}
+	}
+}
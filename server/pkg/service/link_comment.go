@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+// mentionPattern matches @handles not preceded by a word character, so email addresses aren't mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w[\w.-]*)`)
+
+// ParseMentions returns the distinct @handles in a comment body, in order of appearance
+func ParseMentions(body string) []string {
+	mentions := []string{}
+	seen := make(map[string]bool)
+
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// A sentence can end right after a mention ("thanks @ana.")
+		handle := strings.TrimRight(m[1], ".-")
+		key := strings.ToLower(handle)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		mentions = append(mentions, handle)
+	}
+
+	return mentions
+}
+
+type ListCommentsResult struct {
+	Comments []db.LinkComment
+	pagination.Meta
+}
+
+// AddComment posts a comment on one of the user's links, recording the handles it mentions
+func (s *LinkService) AddComment(ctx context.Context, userID string, linkID uuid.UUID, body string) (db.LinkComment, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkComment{}, err
+	}
+
+	comment, err := s.queries.CreateLinkComment(ctx, db.CreateLinkCommentParams{
+		LinkID:   linkID,
+		UserID:   userID,
+		Body:     body,
+		Mentions: ParseMentions(body),
+	})
+	if err != nil {
+		return db.LinkComment{}, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	s.logger.Info("Link comment added",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+		zap.String("comment_id", comment.ID.String()),
+		zap.Int("mentions", len(comment.Mentions)),
+	)
+
+	return comment, nil
+}
+
+// ListComments returns a page of the comment thread of one of the user's links, oldest first
+func (s *LinkService) ListComments(ctx context.Context, userID string, linkID uuid.UUID, page, limit int) (*ListCommentsResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return nil, err
+	}
+
+	total, err := s.queries.CountLinkComments(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	comments, err := s.queries.ListLinkComments(ctx, db.ListLinkCommentsParams{
+		LinkID: linkID,
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	return &ListCommentsResult{
+		Comments: comments,
+		Meta:     p.Meta(total),
+	}, nil
+}
+
+// DeleteComment removes a comment from the thread of one of the user's links
+func (s *LinkService) DeleteComment(ctx context.Context, userID string, linkID uuid.UUID, commentID uuid.UUID) (db.LinkComment, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkComment{}, err
+	}
+
+	comment, err := s.queries.DeleteLinkComment(ctx, db.DeleteLinkCommentParams{
+		ID:     commentID,
+		LinkID: linkID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkComment{}, fmt.Errorf("%w: %v", apperrors.CommentNotFound, err)
+		}
+		return db.LinkComment{}, fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	s.logger.Info("Link comment deleted",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+		zap.String("comment_id", commentID.String()),
+	)
+
+	return comment, nil
+}
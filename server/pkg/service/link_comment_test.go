@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{body: "@ana can you check the UTM tags?", want: []string{"ana"}},
+		{body: "thanks @ana.lopez and @bob_k.", want: []string{"ana.lopez", "bob_k"}},
+		{body: "@ana @Ana (@bob)", want: []string{"ana", "bob"}},
+		{body: "mail ana@example.com about it", want: []string{}},
+		{body: "no mentions @ here", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMentions(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestLinkService_AddComment(t *testing.T) {
+	linkID := uuid.New()
+	var stored db.CreateLinkCommentParams
+
+	mockQueries := &mocks.LinkQueries{
+		GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+			return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+		},
+		CreateLinkCommentFunc: func(ctx context.Context, arg db.CreateLinkCommentParams) (db.LinkComment, error) {
+			stored = arg
+			return db.LinkComment{ID: uuid.New(), LinkID: arg.LinkID, UserID: arg.UserID, Body: arg.Body, Mentions: arg.Mentions}, nil
+		},
+	}
+	service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+	_, err := service.AddComment(context.Background(), "user_123", linkID, "@ana the landing page moved")
+	if err != nil {
+		t.Fatalf("AddComment() error = %v, want nil", err)
+	}
+	if stored.LinkID != linkID || stored.UserID != "user_123" || !reflect.DeepEqual(stored.Mentions, []string{"ana"}) {
+		t.Errorf("AddComment() stored %+v", stored)
+	}
+}
+
+func TestLinkService_ListComments(t *testing.T) {
+	var listed db.ListLinkCommentsParams
+
+	mockQueries := &mocks.LinkQueries{
+		GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+			return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+		},
+		CountLinkCommentsFunc: func(ctx context.Context, linkID uuid.UUID) (int64, error) {
+			return 45, nil
+		},
+		ListLinkCommentsFunc: func(ctx context.Context, arg db.ListLinkCommentsParams) ([]db.LinkComment, error) {
+			listed = arg
+			return []db.LinkComment{}, nil
+		},
+	}
+	service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+	result, err := service.ListComments(context.Background(), "user_123", uuid.New(), 3, 20)
+	if err != nil {
+		t.Fatalf("ListComments() error = %v, want nil", err)
+	}
+	if listed.Limit != 20 || listed.Offset != 40 {
+		t.Errorf("ListComments() queried limit %d offset %d, want 20 and 40", listed.Limit, listed.Offset)
+	}
+	if result.Total != 45 || result.TotalPages != 3 {
+		t.Errorf("ListComments() total = %d, pages = %d; want 45 and 3", result.Total, result.TotalPages)
+	}
+}
+
+func TestLinkService_DeleteComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		ownsLink    bool
+		expectedErr error
+	}{
+		{name: "comment not on the link", ownsLink: true, expectedErr: apperrors.CommentNotFound},
+		{name: "other user's link", ownsLink: false, expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := &mocks.LinkQueries{
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					if !tt.ownsLink {
+						return db.GetLinkByIdAndUserRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+				},
+				DeleteLinkCommentFunc: func(ctx context.Context, arg db.DeleteLinkCommentParams) (db.LinkComment, error) {
+					return db.LinkComment{}, sql.ErrNoRows
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			_, err := service.DeleteComment(context.Background(), "user_123", uuid.New(), uuid.New())
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("DeleteComment() error = %v, want %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
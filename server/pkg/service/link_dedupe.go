@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// Cache key prefix for in-flight and recent link creations
+	createDedupeKeyPrefix = "create-dedupe:"
+	// Value held while the first request is still creating the link
+	createDedupePending = "pending"
+	// How long a duplicate waits for the first request to finish before creating its own link
+	createDedupeWait         = 2 * time.Second
+	createDedupePollInterval = 50 * time.Millisecond
+)
+
+/*
+Create deduplication catches double-submitted creates (a double click in a flaky UI).
+
+The first request claims a key for (user, normalized URL, custom shortcode) with
+SetNX and stores the created link under it; a duplicate arriving within the
+window gets that link back instead of creating a second one. Dedupe fails open:
+without a cache, on cache errors or when the first request takes too long, the
+duplicate creates its link as usual.
+*/
+
+// createDedupeKey identifies a create request; hashing keeps URLs out of cache keys
+func createDedupeKey(userID, normalizedURL string, customShortcode *string) string {
+	h := sha256.New()
+	h.Write([]byte(userID))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizedURL))
+	h.Write([]byte{0})
+	if customShortcode != nil {
+		h.Write([]byte(*customShortcode))
+	}
+
+	return createDedupeKeyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// claimCreate claims key for this request. It returns claimed=true when the caller
+// must create the link (and call completeCreate), or the link created by an
+// earlier identical request.
+func (s *LinkService) claimCreate(ctx context.Context, key string) (first *db.TryCreateLinkRow, claimed bool) {
+	ok, err := s.cache.SetNX(ctx, key, createDedupePending, s.createDedupeWindow)
+	if err != nil {
+		s.logger.Warn("Failed to claim create dedupe key, creating without dedupe",
+			zap.Error(err),
+		)
+		return nil, false
+	}
+	if ok {
+		return nil, true
+	}
+
+	deadline := time.Now().Add(createDedupeWait)
+	for {
+		value, err := s.cache.Get(ctx, key)
+		if err != nil {
+			// cache.ErrMiss: the first request failed or the window expired
+			if !errors.Is(err, cache.ErrMiss) {
+				s.logger.Warn("Failed to read create dedupe key, creating without dedupe",
+					zap.Error(err),
+				)
+			}
+			return nil, false
+		}
+
+		if value != createDedupePending {
+			var link db.TryCreateLinkRow
+			if err := json.Unmarshal([]byte(value), &link); err != nil {
+				s.logger.Warn("Invalid create dedupe entry, creating without dedupe",
+					zap.Error(err),
+				)
+				return nil, false
+			}
+			return &link, false
+		}
+
+		if time.Now().After(deadline) {
+			return nil, false
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(createDedupePollInterval):
+		}
+	}
+}
+
+// completeCreate records the outcome of a claimed create: the link for duplicates
+// to reuse, or nothing so that a retry after a failure isn't deduplicated
+func (s *LinkService) completeCreate(ctx context.Context, key string, link db.TryCreateLinkRow, createErr error) {
+	if createErr != nil {
+		if err := s.cache.Del(ctx, key); err != nil {
+			s.logger.Warn("Failed to release create dedupe key", zap.Error(err))
+		}
+		return
+	}
+
+	value, err := json.Marshal(link)
+	if err != nil {
+		s.logger.Warn("Failed to encode link for create dedupe", zap.Error(err))
+		return
+	}
+
+	// The link is kept for what's left of the window the claim opened
+	ttl, err := s.cache.TTL(ctx, key)
+	if err != nil {
+		// cache.ErrMiss: the window expired while the link was being created
+		if !errors.Is(err, cache.ErrMiss) {
+			s.logger.Warn("Failed to read create dedupe key", zap.Error(err))
+		}
+		return
+	}
+	if err := s.cache.Set(ctx, key, string(value), ttl); err != nil {
+		s.logger.Warn("Failed to store link for create dedupe", zap.Error(err))
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_CreateDedupe(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(t *testing.T, queries *mocks.LinkQueries) (*LinkService, *miniredis.Miniredis) {
+		mr := miniredis.RunT(t)
+		return &LinkService{
+			queries:            queries,
+			cache:              cache.NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})),
+			createDedupeWindow: 10 * time.Second,
+			logger:             createTestLogger(),
+		}, mr
+	}
+
+	t.Run("duplicate create returns the first link", func(t *testing.T) {
+		creates := 0
+		s, _ := newService(t, &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				creates++
+				return db.TryCreateLinkRow{
+					ID:          uuid.New(),
+					Shortcode:   arg.Shortcode,
+					OriginalUrl: arg.OriginalUrl,
+					CreatedAt:   pgtype.Timestamptz{Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), Valid: true},
+				}, nil
+			},
+		})
+
+		first, err := s.CreateShortLink(ctx, "user_123", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("CreateShortLink() error = %v", err)
+		}
+		second, err := s.CreateShortLink(ctx, "user_123", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("CreateShortLink() duplicate error = %v", err)
+		}
+
+		if creates != 1 {
+			t.Errorf("TryCreateLink() called %d times, want 1", creates)
+		}
+		if second.ID != first.ID || second.Shortcode != first.Shortcode || !second.CreatedAt.Time.Equal(first.CreatedAt.Time) {
+			t.Errorf("duplicate CreateShortLink() = %+v, want %+v", second, first)
+		}
+
+		// Another user or another URL is not a duplicate
+		if _, err := s.CreateShortLink(ctx, "user_456", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() error = %v", err)
+		}
+		if _, err := s.CreateShortLink(ctx, "user_123", "https://example.org/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() error = %v", err)
+		}
+		if creates != 3 {
+			t.Errorf("TryCreateLink() called %d times, want 3", creates)
+		}
+	})
+
+	t.Run("window expiry allows a new link", func(t *testing.T) {
+		creates := 0
+		s, mr := newService(t, &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				creates++
+				return db.TryCreateLinkRow{ID: uuid.New(), Shortcode: arg.Shortcode}, nil
+			},
+		})
+
+		if _, err := s.CreateShortLink(ctx, "user_123", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() error = %v", err)
+		}
+		mr.FastForward(11 * time.Second)
+		if _, err := s.CreateShortLink(ctx, "user_123", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() error = %v", err)
+		}
+
+		if creates != 2 {
+			t.Errorf("TryCreateLink() called %d times, want 2", creates)
+		}
+	})
+
+	t.Run("failed create is not deduplicated", func(t *testing.T) {
+		creates := 0
+		s, _ := newService(t, &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				creates++
+				if creates == 1 {
+					return db.TryCreateLinkRow{}, errors.New("database error")
+				}
+				return db.TryCreateLinkRow{ID: uuid.New(), Shortcode: arg.Shortcode}, nil
+			},
+		})
+
+		if _, err := s.CreateShortLink(ctx, "user_123", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err == nil {
+			t.Fatal("CreateShortLink() error = nil, want database error")
+		}
+		if _, err := s.CreateShortLink(ctx, "user_123", "https://example.com/", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() retry error = %v", err)
+		}
+
+		if creates != 2 {
+			t.Errorf("TryCreateLink() called %d times, want 2", creates)
+		}
+	})
+}
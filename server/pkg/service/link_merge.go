@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// DuplicateLinkGroup is the user's links to one (normalized) destination
+type DuplicateLinkGroup struct {
+	Destination string
+	Links       []db.ListDuplicateLinksRow
+}
+
+type ListDuplicateLinksResult struct {
+	Groups []DuplicateLinkGroup
+	pagination.Meta
+}
+
+// ListDuplicateLinks returns a page of the destinations the user has more than one link to,
+// most duplicated first. Links are compared by their normalized URL, so https://example.com/?utm_source=x
+// and https://example.com/ are duplicates when utm_source is stripped.
+func (s *LinkService) ListDuplicateLinks(ctx context.Context, userID string, page, limit int) (*ListDuplicateLinksResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	total, err := s.queries.CountDuplicateDestinations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count duplicate links: %w", err)
+	}
+
+	rows, err := s.queries.ListDuplicateLinks(ctx, db.ListDuplicateLinksParams{
+		UserID:    userID,
+		RowLimit:  int32(p.Limit),
+		RowOffset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate links: %w", err)
+	}
+
+	// Rows come ordered by destination, so each group is a run of rows
+	groups := []DuplicateLinkGroup{}
+	for _, row := range rows {
+		if n := len(groups); n > 0 && groups[n-1].Destination == row.OriginalUrl {
+			groups[n-1].Links = append(groups[n-1].Links, row)
+			continue
+		}
+		groups = append(groups, DuplicateLinkGroup{
+			Destination: row.OriginalUrl,
+			Links:       []db.ListDuplicateLinksRow{row},
+		})
+	}
+
+	return &ListDuplicateLinksResult{
+		Groups: groups,
+		Meta:   p.Meta(total),
+	}, nil
+}
+
+type MergeLinksResult struct {
+	// The link the others were merged into
+	Link db.GetLinkByIdAndUserRow
+	// Shortcodes that now redirect as Link, including those of links already merged into the merged ones
+	Aliases []db.MergeLinksRow
+	// Clicks moved onto Link
+	ClicksMoved int64
+}
+
+/*
+MergeLinks consolidates redundant links into one of the user's links, into.
+The merged links become its aliases: their shortcodes keep working but redirect
+with into's destination and settings, and their clicks and daily stats are moved
+onto it, as are the clicks of later redirects. Aliases are left out of link
+listings and the link quota; deleting one frees its shortcode.
+
+Only links to the same normalized destination can be merged, and none of them
+can be retired or already merged into another link.
+*/
+func (s *LinkService) MergeLinks(ctx context.Context, userID string, into uuid.UUID, ids []uuid.UUID) (*MergeLinksResult, error) {
+	ids = slices.DeleteFunc(slices.Clone(ids), func(id uuid.UUID) bool { return id == into })
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: nothing to merge into the link", apperrors.LinksNotMergeable)
+	}
+
+	var result MergeLinksResult
+	err := s.tx.WithTx(ctx, func(q repository.LinkQueries) error {
+		links, err := q.ListLinksForMerge(ctx, db.ListLinksForMergeParams{
+			UserID: userID,
+			Ids:    append([]uuid.UUID{into}, ids...),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get links to merge: %w", err)
+		}
+		if len(links) != len(ids)+1 {
+			return fmt.Errorf("%w: %d of %d links to merge found", apperrors.LinkNotFound, len(links), len(ids)+1)
+		}
+
+		primary := links[slices.IndexFunc(links, func(l db.ListLinksForMergeRow) bool { return l.ID == into })]
+		for _, link := range links {
+			switch {
+			case link.RetiredAt.Valid:
+				return fmt.Errorf("%w: %s", apperrors.LinkRetired, link.Shortcode)
+			case link.MergedInto.Valid:
+				return fmt.Errorf("%w: %s is already merged into another link", apperrors.LinksNotMergeable, link.Shortcode)
+			case link.OriginalUrl != primary.OriginalUrl:
+				return fmt.Errorf("%w: %s and %s have different destinations", apperrors.LinksNotMergeable, link.Shortcode, primary.Shortcode)
+			}
+		}
+
+		result.ClicksMoved, err = q.MoveLinkClicks(ctx, db.MoveLinkClicksParams{
+			IntoID:  into,
+			FromIds: ids,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move clicks: %w", err)
+		}
+
+		if err := q.MoveLinkDailyStats(ctx, db.MoveLinkDailyStatsParams{
+			FromIds: ids,
+			IntoID:  into,
+		}); err != nil {
+			return fmt.Errorf("failed to move daily stats: %w", err)
+		}
+
+		result.Aliases, err = q.MergeLinks(ctx, db.MergeLinksParams{
+			IntoID: into,
+			UserID: userID,
+			Ids:    ids,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to merge links: %w", err)
+		}
+
+		result.Link, err = q.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+			ID:     into,
+			UserID: userID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+			}
+			return fmt.Errorf("failed to get link: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached redirects of the aliases may skip into's settings
+	for _, alias := range result.Aliases {
+		s.invalidateCache(ctx, alias.Shortcode)
+	}
+
+	s.logger.Info("Links merged",
+		zap.String("user_id", userID),
+		zap.String("link_id", into.String()),
+		zap.Int("aliases", len(result.Aliases)),
+		zap.Int64("clicks_moved", result.ClicksMoved),
+	)
+
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkUpdated, into,
+		fmt.Sprintf("Merged %d links into %s", len(ids), result.Link.Shortcode))
+
+	return &result, nil
+}
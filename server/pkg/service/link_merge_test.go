@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_ListDuplicateLinks(t *testing.T) {
+	rows := []db.ListDuplicateLinksRow{
+		{ID: uuid.New(), Shortcode: "a1", OriginalUrl: "https://example.com/a", LinkCount: 3},
+		{ID: uuid.New(), Shortcode: "a2", OriginalUrl: "https://example.com/a", LinkCount: 3},
+		{ID: uuid.New(), Shortcode: "a3", OriginalUrl: "https://example.com/a", LinkCount: 3},
+		{ID: uuid.New(), Shortcode: "b1", OriginalUrl: "https://example.com/b", LinkCount: 2},
+		{ID: uuid.New(), Shortcode: "b2", OriginalUrl: "https://example.com/b", LinkCount: 2},
+	}
+	queries := &mocks.LinkQueries{
+		CountDuplicateDestinationsFunc: func(ctx context.Context, userID string) (int64, error) {
+			return 2, nil
+		},
+		ListDuplicateLinksFunc: func(ctx context.Context, arg db.ListDuplicateLinksParams) ([]db.ListDuplicateLinksRow, error) {
+			return rows, nil
+		},
+	}
+	service := &LinkService{queries: queries, logger: createTestLogger()}
+
+	result, err := service.ListDuplicateLinks(context.Background(), "user_123", 1, 20)
+	if err != nil {
+		t.Fatalf("ListDuplicateLinks() error = %v, want nil", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("Total = %d, want 2", result.Total)
+	}
+	if len(result.Groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(result.Groups))
+	}
+	if g := result.Groups[0]; g.Destination != "https://example.com/a" || len(g.Links) != 3 {
+		t.Errorf("first group = %s with %d links, want https://example.com/a with 3", g.Destination, len(g.Links))
+	}
+	if g := result.Groups[1]; g.Destination != "https://example.com/b" || len(g.Links) != 2 {
+		t.Errorf("second group = %s with %d links, want https://example.com/b with 2", g.Destination, len(g.Links))
+	}
+}
+
+func TestLinkService_MergeLinks(t *testing.T) {
+	into := uuid.New()
+	dup := uuid.New()
+	destination := "https://example.com/page"
+
+	mergeable := func() []db.ListLinksForMergeRow {
+		return []db.ListLinksForMergeRow{
+			{ID: into, Shortcode: "keep", OriginalUrl: destination},
+			{ID: dup, Shortcode: "dup", OriginalUrl: destination},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		ids         []uuid.UUID
+		links       func() []db.ListLinksForMergeRow
+		expectedErr error
+	}{
+		{name: "merges the links", ids: []uuid.UUID{dup, dup, into}, links: mergeable},
+		{name: "nothing but the link itself", ids: []uuid.UUID{into}, links: mergeable, expectedErr: apperrors.LinksNotMergeable},
+		{
+			name: "other user's link",
+			ids:  []uuid.UUID{dup},
+			links: func() []db.ListLinksForMergeRow {
+				return mergeable()[:1]
+			},
+			expectedErr: apperrors.LinkNotFound,
+		},
+		{
+			name: "different destinations",
+			ids:  []uuid.UUID{dup},
+			links: func() []db.ListLinksForMergeRow {
+				links := mergeable()
+				links[1].OriginalUrl = "https://example.com/other"
+				return links
+			},
+			expectedErr: apperrors.LinksNotMergeable,
+		},
+		{
+			name: "already merged",
+			ids:  []uuid.UUID{dup},
+			links: func() []db.ListLinksForMergeRow {
+				links := mergeable()
+				links[1].MergedInto = pgtype.UUID{Bytes: uuid.New(), Valid: true}
+				return links
+			},
+			expectedErr: apperrors.LinksNotMergeable,
+		},
+		{
+			name: "retired link",
+			ids:  []uuid.UUID{dup},
+			links: func() []db.ListLinksForMergeRow {
+				links := mergeable()
+				links[0].RetiredAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				return links
+			},
+			expectedErr: apperrors.LinkRetired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var merged db.MergeLinksParams
+			queries := &mocks.LinkQueries{
+				ListLinksForMergeFunc: func(ctx context.Context, arg db.ListLinksForMergeParams) ([]db.ListLinksForMergeRow, error) {
+					return tt.links(), nil
+				},
+				MoveLinkClicksFunc: func(ctx context.Context, arg db.MoveLinkClicksParams) (int64, error) {
+					return 7, nil
+				},
+				MoveLinkDailyStatsFunc: func(ctx context.Context, arg db.MoveLinkDailyStatsParams) error {
+					return nil
+				},
+				MergeLinksFunc: func(ctx context.Context, arg db.MergeLinksParams) ([]db.MergeLinksRow, error) {
+					merged = arg
+					return []db.MergeLinksRow{{ID: dup, Shortcode: "dup"}}, nil
+				},
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					return db.GetLinkByIdAndUserRow{ID: into, Shortcode: "keep", OriginalUrl: destination}, nil
+				},
+			}
+			tx := &mocks.Transactor[repository.LinkQueries]{Queries: queries}
+			service := &LinkService{queries: queries, tx: tx, logger: createTestLogger()}
+
+			result, err := service.MergeLinks(context.Background(), "user_123", into, tt.ids)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("MergeLinks() error = %v, want %v", err, tt.expectedErr)
+				}
+				if tx.Committed {
+					t.Error("MergeLinks() committed a failed merge")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeLinks() error = %v, want nil", err)
+			}
+			if !tx.Committed {
+				t.Error("MergeLinks() did not commit the transaction")
+			}
+			if merged.IntoID != into || !reflect.DeepEqual(merged.Ids, []uuid.UUID{dup}) {
+				t.Errorf("MergeLinks called with into %s ids %v, want into %s ids [%s]", merged.IntoID, merged.Ids, into, dup)
+			}
+			if result.ClicksMoved != 7 || len(result.Aliases) != 1 || result.Link.ID != into {
+				t.Errorf("result = %+v, want link %s with 1 alias and 7 clicks moved", result, into)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+)
+
+/*
+LinkPolicy holds the rules every link of the deployment has to follow, whoever
+creates it. LinkService enforces it in CreateShortLink, UpdateLink and on
+destination changes; a nil policy allows everything.
+*/
+type LinkPolicy struct {
+	// Destinations must be on one of these domains or their subdomains; empty allows all
+	allowedDomains []string
+	// Destinations can't be on these domains or their subdomains, even when allowed
+	deniedDomains []string
+	// Custom shortcodes can't match any of these
+	forbiddenShortcodes []*regexp.Regexp
+	// Tags every link must carry, matched without case
+	requiredTags []string
+	// Latest a link can expire after it's saved; 0 means no limit
+	maxExpiry time.Duration
+}
+
+// NewLinkPolicy builds the policy from its settings, failing on shortcode patterns that aren't valid regular expressions
+func NewLinkPolicy(allowedDomains, deniedDomains, forbiddenShortcodes, requiredTags []string, maxExpiry time.Duration) (*LinkPolicy, error) {
+	p := &LinkPolicy{
+		allowedDomains: normalizeDomains(allowedDomains),
+		deniedDomains:  normalizeDomains(deniedDomains),
+		requiredTags:   requiredTags,
+		maxExpiry:      maxExpiry,
+	}
+
+	for _, pattern := range forbiddenShortcodes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid forbidden shortcode pattern %q: %w", pattern, err)
+		}
+		p.forbiddenShortcodes = append(p.forbiddenShortcodes, re)
+	}
+
+	return p, nil
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		normalized = append(normalized, strings.TrimSuffix(strings.ToLower(d), "."))
+	}
+	return normalized
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one
+func matchesDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckDestination returns apperrors.PolicyDestinationNotAllowed when links can't point at rawURL
+func (p *LinkPolicy) CheckDestination(rawURL string) error {
+	if p == nil || (len(p.allowedDomains) == 0 && len(p.deniedDomains) == 0) {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", apperrors.InvalidURL, err)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if matchesDomain(host, p.deniedDomains) {
+		return fmt.Errorf("%w: %s is on a denied domain", apperrors.PolicyDestinationNotAllowed, host)
+	}
+	if len(p.allowedDomains) > 0 && !matchesDomain(host, p.allowedDomains) {
+		return fmt.Errorf("%w: %s isn't on an allowed domain", apperrors.PolicyDestinationNotAllowed, host)
+	}
+	return nil
+}
+
+// CheckShortcode returns apperrors.PolicyShortcodeForbidden when shortcode matches a forbidden pattern
+func (p *LinkPolicy) CheckShortcode(shortcode string) error {
+	if p == nil {
+		return nil
+	}
+
+	for _, re := range p.forbiddenShortcodes {
+		if re.MatchString(shortcode) {
+			return fmt.Errorf("%w: %s matches %s", apperrors.PolicyShortcodeForbidden, shortcode, re)
+		}
+	}
+	return nil
+}
+
+// CheckExpiry returns apperrors.PolicyExpiryTooLate when expiresAt is further from now than the policy allows
+func (p *LinkPolicy) CheckExpiry(expiresAt time.Time, now time.Time) error {
+	if p == nil || p.maxExpiry == 0 {
+		return nil
+	}
+
+	if latest := now.Add(p.maxExpiry); expiresAt.After(latest) {
+		return fmt.Errorf("%w: links must expire by %s", apperrors.PolicyExpiryTooLate, latest.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// DefaultExpiry returns when links saved without an expiry expire under the policy, nil when they don't have to
+func (p *LinkPolicy) DefaultExpiry(now time.Time) *time.Time {
+	if p == nil || p.maxExpiry == 0 {
+		return nil
+	}
+
+	expiresAt := now.Add(p.maxExpiry)
+	return &expiresAt
+}
+
+// CheckTags returns apperrors.PolicyRequiredTagsMissing when names lacks any of the required tags
+func (p *LinkPolicy) CheckTags(names []string) error {
+	if p == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, required := range p.requiredTags {
+		found := false
+		for _, name := range names {
+			if strings.EqualFold(name, required) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, required)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", apperrors.PolicyRequiredTagsMissing, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// RequiresTags reports whether links must carry tags, so callers can skip looking them up
+func (p *LinkPolicy) RequiresTags() bool {
+	return p != nil && len(p.requiredTags) > 0
+}
+
+// checkNewLinkPolicy checks a link about to be created against the policy
+func (s *LinkService) checkNewLinkPolicy(ctx context.Context, userID, originalURL string, customShortcode *string, expiresAt *time.Time, tagIDs []uuid.UUID, tagNames []string) error {
+	if s.policy == nil {
+		return nil
+	}
+
+	if err := s.policy.CheckDestination(originalURL); err != nil {
+		return err
+	}
+	if customShortcode != nil {
+		if err := s.policy.CheckShortcode(*customShortcode); err != nil {
+			return err
+		}
+	}
+	if expiresAt != nil {
+		if err := s.policy.CheckExpiry(*expiresAt, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if s.policy.RequiresTags() {
+		names, err := newLinkTagNames(ctx, s.queries, userID, tagIDs, tagNames)
+		if err != nil {
+			return err
+		}
+		if err := s.policy.CheckTags(names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLinkTagNames returns the names of the tags a new link is created with, by ID or by name
+func newLinkTagNames(ctx context.Context, q repository.LinkQueries, userID string, tagIDs []uuid.UUID, tagNames []string) ([]string, error) {
+	names := append([]string(nil), tagNames...)
+	if len(tagIDs) == 0 {
+		return names, nil
+	}
+
+	byID, err := q.ListUserTagNamesByIDs(ctx, db.ListUserTagNamesByIDsParams{
+		UserID: userID,
+		Ids:    tagIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag names: %w", err)
+	}
+	return append(names, byID...), nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkPolicy_CheckDestination(t *testing.T) {
+	policy, err := NewLinkPolicy([]string{"Example.com", "docs.example.org"}, []string{"private.example.com"}, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("NewLinkPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		destination string
+		wantErr     error
+	}{
+		{destination: "https://example.com/a", wantErr: nil},
+		{destination: "https://www.EXAMPLE.com:8443/a", wantErr: nil},
+		{destination: "https://docs.example.org/", wantErr: nil},
+		{destination: "https://example.org/", wantErr: apperrors.PolicyDestinationNotAllowed},
+		{destination: "https://notexample.com/", wantErr: apperrors.PolicyDestinationNotAllowed},
+		{destination: "https://private.example.com/", wantErr: apperrors.PolicyDestinationNotAllowed},
+		{destination: "https://a.private.example.com/", wantErr: apperrors.PolicyDestinationNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			if err := policy.CheckDestination(tt.destination); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckDestination(%q) error = %v, want %v", tt.destination, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLinkPolicy_Rules(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	policy, err := NewLinkPolicy(nil, nil, []string{"^admin", "(?i)login"}, []string{"team", "Campaign"}, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewLinkPolicy() error = %v", err)
+	}
+
+	if err := policy.CheckShortcode("administrator"); !errors.Is(err, apperrors.PolicyShortcodeForbidden) {
+		t.Errorf("CheckShortcode(administrator) error = %v, want %v", err, apperrors.PolicyShortcodeForbidden)
+	}
+	if err := policy.CheckShortcode("my-LOGIN-page"); !errors.Is(err, apperrors.PolicyShortcodeForbidden) {
+		t.Errorf("CheckShortcode(my-LOGIN-page) error = %v, want %v", err, apperrors.PolicyShortcodeForbidden)
+	}
+	if err := policy.CheckShortcode("sale"); err != nil {
+		t.Errorf("CheckShortcode(sale) error = %v, want nil", err)
+	}
+
+	if err := policy.CheckExpiry(now.AddDate(0, 0, 30), now); err != nil {
+		t.Errorf("CheckExpiry(30 days) error = %v, want nil", err)
+	}
+	if err := policy.CheckExpiry(now.AddDate(0, 0, 31), now); !errors.Is(err, apperrors.PolicyExpiryTooLate) {
+		t.Errorf("CheckExpiry(31 days) error = %v, want %v", err, apperrors.PolicyExpiryTooLate)
+	}
+	if got := policy.DefaultExpiry(now); got == nil || !got.Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("DefaultExpiry() = %v, want %v", got, now.AddDate(0, 0, 30))
+	}
+
+	if err := policy.CheckTags([]string{"Team", "campaign", "extra"}); err != nil {
+		t.Errorf("CheckTags() with all required tags error = %v, want nil", err)
+	}
+	if err := policy.CheckTags([]string{"team"}); !errors.Is(err, apperrors.PolicyRequiredTagsMissing) {
+		t.Errorf("CheckTags() without campaign error = %v, want %v", err, apperrors.PolicyRequiredTagsMissing)
+	}
+
+	if _, err := NewLinkPolicy(nil, nil, []string{"("}, nil, 0); err == nil {
+		t.Error("NewLinkPolicy() with an invalid pattern error = nil, want an error")
+	}
+}
+
+func TestLinkPolicy_Nil(t *testing.T) {
+	var policy *LinkPolicy
+
+	if err := policy.CheckDestination("https://example.com"); err != nil {
+		t.Errorf("CheckDestination() error = %v, want nil", err)
+	}
+	if err := policy.CheckShortcode("admin"); err != nil {
+		t.Errorf("CheckShortcode() error = %v, want nil", err)
+	}
+	if err := policy.CheckExpiry(time.Now().AddDate(10, 0, 0), time.Now()); err != nil {
+		t.Errorf("CheckExpiry() error = %v, want nil", err)
+	}
+	if got := policy.DefaultExpiry(time.Now()); got != nil {
+		t.Errorf("DefaultExpiry() = %v, want nil", got)
+	}
+}
+
+func TestLinkService_LinkPolicy(t *testing.T) {
+	ctx := context.Background()
+	userID := "user_123"
+	tagID := uuid.New()
+
+	policy, err := NewLinkPolicy([]string{"example.com"}, nil, []string{"^admin"}, []string{"team"}, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewLinkPolicy() error = %v", err)
+	}
+
+	var created *db.TryCreateLinkParams
+	queries := &mocks.LinkQueries{
+		ListUserTagNamesByIDsFunc: func(ctx context.Context, arg db.ListUserTagNamesByIDsParams) ([]string, error) {
+			if len(arg.Ids) != 1 || arg.Ids[0] != tagID {
+				t.Errorf("ListUserTagNamesByIDs called with %v, want [%s]", arg.Ids, tagID)
+			}
+			return []string{"Team"}, nil
+		},
+		TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+			created = &arg
+			return createTestTryCreateLinkRow(uuid.New(), arg.Shortcode, arg.OriginalUrl, arg.UserID), nil
+		},
+		CountUserTagsByIDsFunc: func(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error) {
+			return int64(len(arg.Ids)), nil
+		},
+		AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error {
+			return nil
+		},
+		CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+			return nil
+		},
+	}
+	service := &LinkService{
+		queries: queries,
+		tx:      &mocks.Transactor[repository.LinkQueries]{Queries: queries},
+		policy:  policy,
+		logger:  createTestLogger(),
+	}
+
+	admin := "admin-panel"
+	lateExpiry := time.Now().AddDate(0, 0, 8)
+	violations := []struct {
+		name        string
+		destination string
+		shortcode   *string
+		expiresAt   *time.Time
+		tagIDs      []uuid.UUID
+		wantErr     error
+	}{
+		{name: "destination", destination: "https://example.org", tagIDs: []uuid.UUID{tagID}, wantErr: apperrors.PolicyDestinationNotAllowed},
+		{name: "shortcode", destination: "https://example.com", shortcode: &admin, tagIDs: []uuid.UUID{tagID}, wantErr: apperrors.PolicyShortcodeForbidden},
+		{name: "expiry", destination: "https://example.com", expiresAt: &lateExpiry, tagIDs: []uuid.UUID{tagID}, wantErr: apperrors.PolicyExpiryTooLate},
+		{name: "tags", destination: "https://example.com", wantErr: apperrors.PolicyRequiredTagsMissing},
+	}
+	for _, tt := range violations {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateShortLink(ctx, userID, tt.destination, tt.shortcode, tt.expiresAt, nil, nil, nil, nil, nil, nil, nil, nil, tt.tagIDs, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateShortLink() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if created != nil {
+		t.Fatalf("links created in violation of the policy: %+v", created)
+	}
+
+	if _, err := service.CreateShortLink(ctx, userID, "https://www.example.com", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []uuid.UUID{tagID}, nil); err != nil {
+		t.Fatalf("CreateShortLink() following the policy error = %v, want nil", err)
+	}
+	if created == nil || !created.ExpiresAt.Valid || created.ExpiresAt.Time.After(time.Now().AddDate(0, 0, 7)) {
+		t.Errorf("created link expires at %+v, want within the policy's 7 days", created.ExpiresAt)
+	}
+}
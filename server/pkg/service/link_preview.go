@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"go.uber.org/zap"
+)
+
+/*
+SetPreview replaces the preview overrides of one of the user's links. Social
+crawlers following the short URL are shown these instead of the destination's
+OpenGraph tags, so the same destination can be shared with a different preview
+per link. Fields left nil are not overridden.
+*/
+func (s *LinkService) SetPreview(ctx context.Context, userID string, linkID uuid.UUID, title *string, description *string, imageURL *string) (db.LinkPreview, error) {
+	if imageURL != nil {
+		if err := validateURL(*imageURL); err != nil {
+			return db.LinkPreview{}, err
+		}
+	}
+
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkPreview{}, err
+	}
+
+	preview, err := s.queries.UpsertLinkPreview(ctx, db.UpsertLinkPreviewParams{
+		LinkID:      linkID,
+		Title:       title,
+		Description: description,
+		ImageUrl:    imageURL,
+	})
+	if err != nil {
+		return db.LinkPreview{}, fmt.Errorf("failed to store link preview: %w", err)
+	}
+
+	s.logger.Info("Link preview set",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+	)
+
+	return preview, nil
+}
+
+// GetPreview returns the preview overrides of one of the user's links
+func (s *LinkService) GetPreview(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkPreview{}, err
+	}
+
+	preview, err := s.queries.GetLinkPreview(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkPreview{}, fmt.Errorf("%w: %v", apperrors.LinkPreviewNotFound, err)
+		}
+		return db.LinkPreview{}, fmt.Errorf("failed to get link preview: %w", err)
+	}
+
+	return preview, nil
+}
+
+// DeletePreview removes the preview overrides of one of the user's links
+func (s *LinkService) DeletePreview(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkPreview, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkPreview{}, err
+	}
+
+	preview, err := s.queries.DeleteLinkPreview(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkPreview{}, fmt.Errorf("%w: %v", apperrors.LinkPreviewNotFound, err)
+		}
+		return db.LinkPreview{}, fmt.Errorf("failed to delete link preview: %w", err)
+	}
+
+	return preview, nil
+}
+
+// PreviewForRedirect returns the preview overrides of the link behind a shortcode, if it has any
+func (s *LinkService) PreviewForRedirect(ctx context.Context, shortcode string) (db.LinkPreview, bool, error) {
+	preview, err := s.queries.GetLinkPreviewByShortcode(ctx, shortcode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkPreview{}, false, nil
+		}
+		return db.LinkPreview{}, false, fmt.Errorf("failed to get link preview: %w", err)
+	}
+
+	return preview, true, nil
+}
+
+func (s *LinkService) checkLinkOwner(ctx context.Context, userID string, linkID uuid.UUID) error {
+	if _, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_SetPreview(t *testing.T) {
+	linkID := uuid.New()
+	title := "Spring sale"
+	badImage := "javascript:alert(1)"
+	image := "https://cdn.example.com/spring.png"
+
+	tests := []struct {
+		name        string
+		ownsLink    bool
+		imageURL    *string
+		expectedErr error
+	}{
+		{name: "stores overrides", ownsLink: true, imageURL: &image},
+		{name: "rejects non-http image", ownsLink: true, imageURL: &badImage, expectedErr: apperrors.InvalidURL},
+		{name: "other user's link", ownsLink: false, expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored db.UpsertLinkPreviewParams
+			mockQueries := &mocks.LinkQueries{
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					if !tt.ownsLink {
+						return db.GetLinkByIdAndUserRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+				},
+				UpsertLinkPreviewFunc: func(ctx context.Context, arg db.UpsertLinkPreviewParams) (db.LinkPreview, error) {
+					stored = arg
+					return db.LinkPreview{LinkID: arg.LinkID, Title: arg.Title, ImageUrl: arg.ImageUrl}, nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			preview, err := service.SetPreview(context.Background(), "user_123", linkID, &title, nil, tt.imageURL)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("SetPreview() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetPreview() error = %v, want nil", err)
+			}
+			if stored.LinkID != linkID || stored.Title == nil || *stored.Title != title || stored.Description != nil {
+				t.Errorf("SetPreview() stored %+v", stored)
+			}
+			if preview.ImageUrl == nil || *preview.ImageUrl != image {
+				t.Errorf("SetPreview() image = %v, want %q", preview.ImageUrl, image)
+			}
+		})
+	}
+}
+
+func TestLinkService_DeletePreview(t *testing.T) {
+	mockQueries := &mocks.LinkQueries{
+		GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+			return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+		},
+		DeleteLinkPreviewFunc: func(ctx context.Context, linkID uuid.UUID) (db.LinkPreview, error) {
+			return db.LinkPreview{}, sql.ErrNoRows
+		},
+	}
+	service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+	_, err := service.DeletePreview(context.Background(), "user_123", uuid.New())
+	if !errors.Is(err, apperrors.LinkPreviewNotFound) {
+		t.Errorf("DeletePreview() error = %v, want %v", err, apperrors.LinkPreviewNotFound)
+	}
+}
+
+func TestLinkService_PreviewForRedirect(t *testing.T) {
+	mockQueries := &mocks.LinkQueries{
+		GetLinkPreviewByShortcodeFunc: func(ctx context.Context, shortcode string) (db.LinkPreview, error) {
+			return db.LinkPreview{}, sql.ErrNoRows
+		},
+	}
+	service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+	_, found, err := service.PreviewForRedirect(context.Background(), "abc123")
+	if err != nil || found {
+		t.Errorf("PreviewForRedirect() = found %v, error %v; want no preview and no error", found, err)
+	}
+}
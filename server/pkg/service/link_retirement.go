@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"go.uber.org/zap"
+)
+
+/*
+RetireLink takes one of the user's links out of service without deleting it.
+The shortcode keeps resolving, but to a sunset page with the optional message
+and alternative URL instead of the destination. Unlike a deleted link a retired
+link keeps its shortcode for good and can no longer be updated or deleted, so the
+code is never handed out again. Retiring an already retired link only replaces
+its sunset page.
+*/
+func (s *LinkService) RetireLink(ctx context.Context, userID string, id uuid.UUID, message *string, alternativeURL *string) (db.RetireLinkRow, error) {
+	if alternativeURL != nil {
+		if err := validateURL(*alternativeURL); err != nil {
+			return db.RetireLinkRow{}, err
+		}
+	}
+
+	retired, err := s.queries.RetireLink(ctx, db.RetireLinkParams{
+		ID:            id,
+		UserID:        userID,
+		SunsetMessage: message,
+		SunsetUrl:     alternativeURL,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.RetireLinkRow{}, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return db.RetireLinkRow{}, fmt.Errorf("failed to retire link: %w", err)
+	}
+
+	s.invalidateCache(ctx, retired.Shortcode)
+
+	s.logger.Info("Link retired",
+		zap.String("user_id", userID),
+		zap.String("link_id", id.String()),
+	)
+
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityLinkRetired, retired.ID,
+		"Retired link "+retired.Shortcode)
+
+	return retired, nil
+}
+
+// missingOrRetired tells apart the two reasons an update or delete matches no row:
+// the link doesn't exist for the user, or it is retired and therefore read-only
+func (s *LinkService) missingOrRetired(ctx context.Context, userID string, id uuid.UUID, cause error) error {
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err == nil && link.RetiredAt.Valid {
+		return fmt.Errorf("%w: %s", apperrors.LinkRetired, link.Shortcode)
+	}
+	return fmt.Errorf("%w: %v", apperrors.LinkNotFound, cause)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_RetireLink(t *testing.T) {
+	message := "This promotion has ended"
+	alternative := "https://example.com/summer"
+	badAlternative := "ftp://example.com/summer"
+
+	tests := []struct {
+		name           string
+		ownsLink       bool
+		alternativeURL *string
+		expectedErr    error
+	}{
+		{name: "retires the link", ownsLink: true, alternativeURL: &alternative},
+		{name: "rejects non-http alternative", ownsLink: true, alternativeURL: &badAlternative, expectedErr: apperrors.InvalidURL},
+		{name: "other user's link", ownsLink: false, expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored db.RetireLinkParams
+			mockQueries := &mocks.LinkQueries{
+				RetireLinkFunc: func(ctx context.Context, arg db.RetireLinkParams) (db.RetireLinkRow, error) {
+					if !tt.ownsLink {
+						return db.RetireLinkRow{}, sql.ErrNoRows
+					}
+					stored = arg
+					return db.RetireLinkRow{
+						ID:            arg.ID,
+						Shortcode:     "spring",
+						RetiredAt:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+						SunsetMessage: arg.SunsetMessage,
+						SunsetUrl:     arg.SunsetUrl,
+					}, nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			retired, err := service.RetireLink(context.Background(), "user_123", uuid.New(), &message, tt.alternativeURL)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("RetireLink() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RetireLink() error = %v, want nil", err)
+			}
+			if stored.SunsetMessage == nil || *stored.SunsetMessage != message || stored.SunsetUrl == nil || *stored.SunsetUrl != alternative {
+				t.Errorf("RetireLink() stored %+v", stored)
+			}
+			if !retired.RetiredAt.Valid {
+				t.Error("RetireLink() returned a link that isn't retired")
+			}
+		})
+	}
+}
+
+func TestLinkService_RetiredLinkIsReadOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		retired     bool
+		expectedErr error
+	}{
+		{name: "retired link", retired: true, expectedErr: apperrors.LinkRetired},
+		{name: "missing link", retired: false, expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := &mocks.LinkQueries{
+				UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
+					return db.UpdateLinkRow{}, sql.ErrNoRows
+				},
+				DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
+					return db.DeleteLinkRow{}, sql.ErrNoRows
+				},
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					if !tt.retired {
+						return db.GetLinkByIdAndUserRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkByIdAndUserRow{
+						ID:        arg.ID,
+						Shortcode: "spring",
+						RetiredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+					}, nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+			isActive := true
+
+			_, err := service.UpdateLink(context.Background(), "user_123", uuid.New(), nil, &isActive, nil, nil, nil, nil, nil, nil, nil, nil)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("UpdateLink() error = %v, want %v", err, tt.expectedErr)
+			}
+
+			_, err = service.DeleteLink(context.Background(), "user_123", uuid.New())
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("DeleteLink() error = %v, want %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestIsCacheable_Retired(t *testing.T) {
+	link := db.GetLinkForRedirectRow{
+		Visibility:     LinkVisibilityPublic,
+		ReferrerPolicy: ReferrerPolicyDefault,
+		RetiredAt:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	if isCacheable(link) {
+		t.Error("isCacheable() = true for a retired link")
+	}
+}
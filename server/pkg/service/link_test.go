@@ -4,109 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
 	"github.com/styltsou/url-shortener/server/pkg/db"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+	"github.com/styltsou/url-shortener/server/pkg/urlnorm"
 )
 
-// mockQueries is a mock implementation of the database queries
-type mockQueries struct {
-	TryCreateLinkFunc              func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error)
-	ListUserLinksFunc              func(ctx context.Context, arg db.ListUserLinksParams) ([]db.ListUserLinksRow, error)
-	CountUserLinksFunc             func(ctx context.Context, arg db.CountUserLinksParams) (int64, error)
-	GetLinkByIdAndUserFunc         func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error)
-	GetLinkByShortcodeAndUserFunc  func(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error)
-	GetLinkForRedirectFunc         func(ctx context.Context, shortcode string) (db.GetLinkForRedirectRow, error)
-	UpdateLinkFunc                 func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error)
-	DeleteLinkFunc                 func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error)
-	AddTagsToLinkFunc              func(ctx context.Context, arg db.AddTagsToLinkParams) error
-	RemoveTagsFromLinkFunc         func(ctx context.Context, arg db.RemoveTagsFromLinkParams) error
-	GetLinkByIdAndUserWithTagsFunc func(ctx context.Context, arg db.GetLinkByIdAndUserWithTagsParams) (db.GetLinkByIdAndUserWithTagsRow, error)
-}
-
-func (m *mockQueries) TryCreateLink(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
-	if m.TryCreateLinkFunc != nil {
-		return m.TryCreateLinkFunc(ctx, arg)
-	}
-	return db.TryCreateLinkRow{}, errors.New("not implemented")
-}
-
-func (m *mockQueries) ListUserLinks(ctx context.Context, arg db.ListUserLinksParams) ([]db.ListUserLinksRow, error) {
-	if m.ListUserLinksFunc != nil {
-		return m.ListUserLinksFunc(ctx, arg)
-	}
-	return nil, errors.New("not implemented")
-}
-
-func (m *mockQueries) CountUserLinks(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
-	if m.CountUserLinksFunc != nil {
-		return m.CountUserLinksFunc(ctx, arg)
-	}
-	return 0, errors.New("not implemented")
-}
-
-func (m *mockQueries) GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
-	if m.GetLinkByIdAndUserFunc != nil {
-		return m.GetLinkByIdAndUserFunc(ctx, arg)
-	}
-	return db.GetLinkByIdAndUserRow{}, errors.New("not implemented")
-}
-
-func (m *mockQueries) GetLinkByShortcodeAndUser(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error) {
-	if m.GetLinkByShortcodeAndUserFunc != nil {
-		return m.GetLinkByShortcodeAndUserFunc(ctx, arg)
-	}
-	return db.GetLinkByShortcodeAndUserRow{}, errors.New("not implemented")
-}
-
-func (m *mockQueries) GetLinkForRedirect(ctx context.Context, shortcode string) (db.GetLinkForRedirectRow, error) {
-	if m.GetLinkForRedirectFunc != nil {
-		return m.GetLinkForRedirectFunc(ctx, shortcode)
-	}
-	return db.GetLinkForRedirectRow{}, errors.New("not implemented")
-}
-
-func (m *mockQueries) UpdateLink(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
-	if m.UpdateLinkFunc != nil {
-		return m.UpdateLinkFunc(ctx, arg)
-	}
-	return db.UpdateLinkRow{}, errors.New("not implemented")
-}
-
-func (m *mockQueries) DeleteLink(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
-	if m.DeleteLinkFunc != nil {
-		return m.DeleteLinkFunc(ctx, arg)
-	}
-	return db.DeleteLinkRow{}, errors.New("not implemented")
-}
-
-func (m *mockQueries) AddTagsToLink(ctx context.Context, arg db.AddTagsToLinkParams) error {
-	if m.AddTagsToLinkFunc != nil {
-		return m.AddTagsToLinkFunc(ctx, arg)
-	}
-	return errors.New("not implemented")
-}
-
-func (m *mockQueries) RemoveTagsFromLink(ctx context.Context, arg db.RemoveTagsFromLinkParams) error {
-	if m.RemoveTagsFromLinkFunc != nil {
-		return m.RemoveTagsFromLinkFunc(ctx, arg)
-	}
-	return errors.New("not implemented")
-}
-
-func (m *mockQueries) GetLinkByIdAndUserWithTags(ctx context.Context, arg db.GetLinkByIdAndUserWithTagsParams) (db.GetLinkByIdAndUserWithTagsRow, error) {
-	if m.GetLinkByIdAndUserWithTagsFunc != nil {
-		return m.GetLinkByIdAndUserWithTagsFunc(ctx, arg)
-	}
-	return db.GetLinkByIdAndUserWithTagsRow{}, errors.New("not implemented")
-}
-
 // createTestLogger creates a test logger that can be used in tests
 func createTestLogger() logger.Logger {
 	log, err := logger.New("test")
@@ -117,16 +31,21 @@ func createTestLogger() logger.Logger {
 	return log
 }
 
+// noReservation is a GetShortcodeReservationFunc for shortcodes nobody has reserved
+func noReservation(ctx context.Context, shortcode string) (db.ShortcodeReservation, error) {
+	return db.ShortcodeReservation{}, sql.ErrNoRows
+}
+
 // Helper functions for creating test data with Row types
 func createTestTryCreateLinkRow(id uuid.UUID, shortcode, originalURL, userID string) db.TryCreateLinkRow {
 	return db.TryCreateLinkRow{
 		ID:          id,
 		Shortcode:   shortcode,
 		OriginalUrl: originalURL,
-		ExpiresAt:   pgtype.Timestamp{Valid: false},
+		ExpiresAt:   pgtype.Timestamptz{Valid: false},
 		IsActive:    true,
-		CreatedAt:   pgtype.Timestamp{Valid: false},
-		UpdatedAt:   pgtype.Timestamp{Valid: false},
+		CreatedAt:   pgtype.Timestamptz{Valid: false},
+		UpdatedAt:   pgtype.Timestamptz{Valid: false},
 	}
 }
 
@@ -135,10 +54,10 @@ func createTestGetLinkByIdAndUserRow(id uuid.UUID, shortcode, originalURL, userI
 		ID:          id,
 		Shortcode:   shortcode,
 		OriginalUrl: originalURL,
-		ExpiresAt:   pgtype.Timestamp{Valid: false},
+		ExpiresAt:   pgtype.Timestamptz{Valid: false},
 		IsActive:    true,
-		CreatedAt:   pgtype.Timestamp{Valid: false},
-		UpdatedAt:   pgtype.Timestamp{Valid: false},
+		CreatedAt:   pgtype.Timestamptz{Valid: false},
+		UpdatedAt:   pgtype.Timestamptz{Valid: false},
 	}
 }
 
@@ -147,10 +66,10 @@ func createTestListUserLinksRow(id uuid.UUID, shortcode, originalURL, userID str
 		ID:          id,
 		Shortcode:   shortcode,
 		OriginalUrl: originalURL,
-		ExpiresAt:   pgtype.Timestamp{Valid: false},
+		ExpiresAt:   pgtype.Timestamptz{Valid: false},
 		IsActive:    true,
-		CreatedAt:   pgtype.Timestamp{Valid: false},
-		UpdatedAt:   pgtype.Timestamp{Valid: false},
+		CreatedAt:   pgtype.Timestamptz{Valid: false},
+		UpdatedAt:   pgtype.Timestamptz{Valid: false},
 		Tags:        nil, // Empty tags for now
 	}
 }
@@ -161,9 +80,9 @@ func createTestUpdateLinkRow(id uuid.UUID, shortcode, originalURL string, isActi
 		Shortcode:   shortcode,
 		OriginalUrl: originalURL,
 		IsActive:    isActive,
-		ExpiresAt:   pgtype.Timestamp{Valid: false},
-		CreatedAt:   pgtype.Timestamp{Valid: false},
-		UpdatedAt:   pgtype.Timestamp{Valid: false},
+		ExpiresAt:   pgtype.Timestamptz{Valid: false},
+		CreatedAt:   pgtype.Timestamptz{Valid: false},
+		UpdatedAt:   pgtype.Timestamptz{Valid: false},
 	}
 }
 
@@ -174,16 +93,16 @@ func createTestLink(id uuid.UUID, shortcode, originalURL, userID string) db.Link
 		Shortcode:   shortcode,
 		OriginalUrl: originalURL,
 		UserID:      userID,
-		ExpiresAt:   pgtype.Timestamp{Valid: false},
-		CreatedAt:   pgtype.Timestamp{Valid: false},
-		UpdatedAt:   pgtype.Timestamp{Valid: false},
-		DeletedAt:   pgtype.Timestamp{Valid: false}, // Not deleted by default
+		ExpiresAt:   pgtype.Timestamptz{Valid: false},
+		CreatedAt:   pgtype.Timestamptz{Valid: false},
+		UpdatedAt:   pgtype.Timestamptz{Valid: false},
+		DeletedAt:   pgtype.Timestamptz{Valid: false}, // Not deleted by default
 	}
 }
 
 func createDeletedTestLink(id uuid.UUID, shortcode, originalURL, userID string) db.Link {
 	link := createTestLink(id, shortcode, originalURL, userID)
-	link.DeletedAt = pgtype.Timestamp{Valid: true} // Mark as deleted
+	link.DeletedAt = pgtype.Timestamptz{Valid: true} // Mark as deleted
 	return link
 }
 
@@ -352,10 +271,8 @@ func contains(s string, char byte) bool {
 	return false
 }
 
-// Note: For proper Redis mocking, consider using miniredis or a Redis test container.
-// For now, we test cache behavior with nil cache (cache unavailable) and verify
-// that the code handles it gracefully. Full Redis integration tests should use
-// a real Redis instance or miniredis.
+// Most tests run without a cache (cache unavailable) to check the code handles it
+// gracefully; the cache tests use cache.NewMemory.
 
 func TestLinkService_CreateShortLink(t *testing.T) {
 	ctx := context.Background()
@@ -363,7 +280,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 	originalURL := "https://example.com"
 
 	t.Run("successful creation", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
 				if arg.OriginalUrl != originalURL {
 					t.Errorf("TryCreateLink called with wrong URL: got %s, want %s", arg.OriginalUrl, originalURL)
@@ -382,7 +299,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 			queries: mockQueries,
 			logger:  createTestLogger(),
 		}
-		link, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil)
+		link, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("CreateShortLink() error = %v, want nil", err)
@@ -395,12 +312,39 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 		}
 	})
 
+	t.Run("stores normalized URL and keeps raw URL", func(t *testing.T) {
+		rawURL := "HTTPS://Example.com:443/page?utm_source=newsletter&id=1#top"
+		var params db.TryCreateLinkParams
+		mockQueries := &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				params = arg
+				return db.TryCreateLinkRow{ID: uuid.New(), Shortcode: arg.Shortcode, OriginalUrl: arg.OriginalUrl, RawUrl: arg.RawUrl}, nil
+			},
+		}
+
+		service := &LinkService{
+			queries:    mockQueries,
+			normalizer: urlnorm.New(urlnorm.Options{StripParams: urlnorm.DefaultStripParams, SortParams: true}),
+			logger:     createTestLogger(),
+		}
+		if _, err := service.CreateShortLink(ctx, userID, rawURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() error = %v, want nil", err)
+		}
+
+		if expected := "https://example.com/page?id=1"; params.OriginalUrl != expected {
+			t.Errorf("CreateShortLink() stored OriginalUrl = %s, want %s", params.OriginalUrl, expected)
+		}
+		if params.RawUrl == nil || *params.RawUrl != rawURL {
+			t.Errorf("CreateShortLink() stored RawUrl = %v, want %s", params.RawUrl, rawURL)
+		}
+	})
+
 	t.Run("invalid URL", func(t *testing.T) {
 		service := &LinkService{
-			queries: &mockQueries{},
+			queries: &mocks.LinkQueries{},
 			logger:  createTestLogger(),
 		}
-		_, err := service.CreateShortLink(ctx, userID, "invalid-url", nil, nil)
+		_, err := service.CreateShortLink(ctx, userID, "invalid-url", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Errorf("CreateShortLink() expected error for invalid URL")
@@ -410,9 +354,22 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 		}
 	})
 
+	t.Run("rejects reserved custom shortcode", func(t *testing.T) {
+		service := &LinkService{
+			queries: &mocks.LinkQueries{},
+			logger:  createTestLogger(),
+		}
+		reserved := "api"
+		_, err := service.CreateShortLink(ctx, userID, originalURL, &reserved, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+		if !errors.Is(err, apperrors.ShortcodeReserved) {
+			t.Errorf("CreateShortLink() error = %v, want %v", err, apperrors.ShortcodeReserved)
+		}
+	})
+
 	t.Run("handles code collision and retries", func(t *testing.T) {
 		attempts := 0
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
 				attempts++
 				if attempts < 2 {
@@ -428,7 +385,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 			queries: mockQueries,
 			logger:  createTestLogger(),
 		}
-		link, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil)
+		link, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("CreateShortLink() error = %v, want nil", err)
@@ -442,7 +399,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 	})
 
 	t.Run("fails after max retries", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
 				// Always return collision
 				return db.TryCreateLinkRow{}, sql.ErrNoRows
@@ -453,7 +410,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 			queries: mockQueries,
 			logger:  createTestLogger(),
 		}
-		_, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil)
+		_, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Errorf("CreateShortLink() expected error after max retries")
@@ -462,7 +419,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 
 	t.Run("handles database errors", func(t *testing.T) {
 		dbError := errors.New("database connection failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
 				return db.TryCreateLinkRow{}, dbError
 			},
@@ -472,7 +429,7 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 			queries: mockQueries,
 			logger:  createTestLogger(),
 		}
-		_, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil)
+		_, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Errorf("CreateShortLink() expected error for database failure")
@@ -483,6 +440,158 @@ func TestLinkService_CreateShortLink(t *testing.T) {
 	})
 }
 
+func TestLinkService_LinkQuota(t *testing.T) {
+	ctx := context.Background()
+
+	count := int64(4)
+	queries := &mocks.LinkQueries{
+		CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
+			if arg.IsActive != nil || arg.TagIds != nil {
+				t.Errorf("CountUserLinks called with filters %+v, want all links", arg)
+			}
+			return count, nil
+		},
+		TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+			count++
+			return createTestTryCreateLinkRow(uuid.New(), arg.Shortcode, arg.OriginalUrl, arg.UserID), nil
+		},
+	}
+	service := &LinkService{
+		queries:   queries,
+		linkQuota: 5,
+		logger:    createTestLogger(),
+	}
+
+	if remaining, err := service.LinksRemaining(ctx, "user_123"); err != nil || remaining != 1 {
+		t.Fatalf("LinksRemaining() = %d, %v, want 1, nil", remaining, err)
+	}
+
+	if _, err := service.CreateShortLink(ctx, "user_123", "https://example.com", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+		t.Fatalf("CreateShortLink() under quota error = %v, want nil", err)
+	}
+
+	_, err := service.CreateShortLink(ctx, "user_123", "https://example.org", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if !errors.Is(err, apperrors.LinkQuotaExceeded) {
+		t.Fatalf("CreateShortLink() at quota error = %v, want %v", err, apperrors.LinkQuotaExceeded)
+	}
+	if count != 5 {
+		t.Errorf("links after hitting the quota = %d, want 5", count)
+	}
+}
+
+func TestLinkService_CreateShortLinkWithTags(t *testing.T) {
+	ctx := context.Background()
+	userID := "user_123"
+	originalURL := "https://example.com"
+
+	t.Run("adds existing and new tags in one transaction", func(t *testing.T) {
+		linkID := uuid.New()
+		existingTag := uuid.New()
+		newTag := uuid.New()
+		var added db.AddTagsToLinkParams
+
+		queries := &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				return createTestTryCreateLinkRow(linkID, arg.Shortcode, arg.OriginalUrl, arg.UserID), nil
+			},
+			CountUserTagsByIDsFunc: func(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error) {
+				if len(arg.Ids) != 1 || arg.Ids[0] != existingTag {
+					t.Errorf("CountUserTagsByIDs called with %v, want [%s]", arg.Ids, existingTag)
+				}
+				return int64(len(arg.Ids)), nil
+			},
+			ListUserTagsFunc: func(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+				return []db.ListUserTagsRow{{ID: existingTag, Name: "news"}}, nil
+			},
+			UpsertTagsByNameFunc: func(ctx context.Context, arg db.UpsertTagsByNameParams) ([]db.UpsertTagsByNameRow, error) {
+				if !reflect.DeepEqual(arg.Names, []string{"news", "go"}) {
+					t.Errorf("UpsertTagsByName called with %v, want [news go]", arg.Names)
+				}
+				// "news" already belongs to the user and is returned as is
+				return []db.UpsertTagsByNameRow{{ID: existingTag, Name: "news"}, {ID: newTag, Name: "go"}}, nil
+			},
+			AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error {
+				added = arg
+				return nil
+			},
+		}
+		tx := &mocks.Transactor[repository.LinkQueries]{Queries: queries}
+		service := &LinkService{
+			queries: queries,
+			tx:      tx,
+			logger:  createTestLogger(),
+		}
+
+		link, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			[]uuid.UUID{existingTag, existingTag}, []string{" news ", "go", ""})
+		if err != nil {
+			t.Fatalf("CreateShortLink() error = %v, want nil", err)
+		}
+		if link.ID != linkID {
+			t.Errorf("CreateShortLink() ID = %s, want %s", link.ID, linkID)
+		}
+
+		if !tx.Committed {
+			t.Error("CreateShortLink() did not commit the transaction")
+		}
+		if added.LinkID != linkID || !reflect.DeepEqual(added.TagIDs, []uuid.UUID{existingTag, newTag}) {
+			t.Errorf("AddTagsToLink called with link %s tags %v, want link %s tags [%s %s]",
+				added.LinkID, added.TagIDs, linkID, existingTag, newTag)
+		}
+	})
+
+	t.Run("unknown tag ID rolls back the link", func(t *testing.T) {
+		queries := &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				return createTestTryCreateLinkRow(uuid.New(), arg.Shortcode, arg.OriginalUrl, arg.UserID), nil
+			},
+			CountUserTagsByIDsFunc: func(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error) {
+				return 1, nil
+			},
+			AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error {
+				t.Error("AddTagsToLink should not be called when a tag is missing")
+				return nil
+			},
+		}
+		tx := &mocks.Transactor[repository.LinkQueries]{Queries: queries}
+		service := &LinkService{
+			queries: queries,
+			tx:      tx,
+			logger:  createTestLogger(),
+		}
+
+		_, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			[]uuid.UUID{uuid.New(), uuid.New()}, nil)
+		if !errors.Is(err, apperrors.TagNotFound) {
+			t.Fatalf("CreateShortLink() error = %v, want %v", err, apperrors.TagNotFound)
+		}
+		if !tx.RolledBack || tx.Committed {
+			t.Errorf("rolledBack = %v, committed = %v, want rollback only", tx.RolledBack, tx.Committed)
+		}
+	})
+
+	t.Run("without tags no transaction is used", func(t *testing.T) {
+		queries := &mocks.LinkQueries{
+			TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+				return createTestTryCreateLinkRow(uuid.New(), arg.Shortcode, arg.OriginalUrl, arg.UserID), nil
+			},
+		}
+		tx := &mocks.Transactor[repository.LinkQueries]{Queries: queries}
+		service := &LinkService{
+			queries: queries,
+			tx:      tx,
+			logger:  createTestLogger(),
+		}
+
+		if _, err := service.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateShortLink() error = %v, want nil", err)
+		}
+		if tx.Committed || tx.RolledBack {
+			t.Error("CreateShortLink() without tags should not open a transaction")
+		}
+	})
+}
+
 func TestLinkService_ListAllLinks(t *testing.T) {
 	ctx := context.Background()
 	userID := "user_123"
@@ -494,7 +603,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 		}
 		total := int64(2)
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				if arg.UserID != userID {
 					t.Errorf("CountUserLinks called with wrong UserID: got %s, want %s", arg.UserID, userID)
@@ -546,7 +655,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 	})
 
 	t.Run("successful list with no links", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				return 0, nil
 			},
@@ -582,7 +691,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 		}
 		total := int64(2)
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				return total, nil
 			},
@@ -622,7 +731,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 	})
 
 	t.Run("defaults page and limit when invalid", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				return 0, nil
 			},
@@ -659,7 +768,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 	})
 
 	t.Run("max limit is enforced", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				return 0, nil
 			},
@@ -691,7 +800,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 
 	t.Run("handles database errors on count", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				return 0, dbError
 			},
@@ -711,7 +820,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 
 	t.Run("handles database errors on list", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				return 0, nil
 			},
@@ -734,7 +843,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 
 	t.Run("filters by is_active", func(t *testing.T) {
 		isActive := true
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				if arg.IsActive == nil || *arg.IsActive != isActive {
 					t.Errorf("CountUserLinks called with wrong IsActive: got %v, want %v", arg.IsActive, &isActive)
@@ -766,7 +875,7 @@ func TestLinkService_ListAllLinks(t *testing.T) {
 		tagID2 := uuid.New()
 		tagIDs := []uuid.UUID{tagID1, tagID2}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			CountUserLinksFunc: func(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
 				if len(arg.TagIds) != 2 || arg.TagIds[0] != tagID1 || arg.TagIds[1] != tagID2 {
 					t.Errorf("CountUserLinks called with wrong TagIDs")
@@ -807,7 +916,7 @@ func TestLinkService_GetOriginalURL(t *testing.T) {
 			OriginalUrl: originalURL,
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
 				if code != shortcode {
 					t.Errorf("GetLinkForRedirect called with wrong shortcode: got %s, want %s", code, shortcode)
@@ -831,12 +940,82 @@ func TestLinkService_GetOriginalURL(t *testing.T) {
 		}
 	})
 
-	// Note: Full Redis cache testing (cache hit/miss) requires a real Redis instance
-	// or a library like miniredis. These tests verify graceful degradation when
-	// cache is unavailable (nil), which is the most important behavior.
+	t.Run("cache miss populates the cache for the next redirect", func(t *testing.T) {
+		lookups := 0
+		linkID := uuid.New()
+		mockQueries := &mocks.LinkQueries{
+			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+				lookups++
+				return db.GetLinkForRedirectRow{
+					ID:             linkID,
+					OriginalUrl:    originalURL,
+					UserID:         "user_1",
+					Visibility:     LinkVisibilityPublic,
+					ReferrerPolicy: ReferrerPolicyDefault,
+				}, nil
+			},
+		}
+
+		linkCache := cache.NewMemory()
+		service := &LinkService{
+			queries: mockQueries,
+			cache:   linkCache,
+			logger:  createTestLogger(),
+		}
+
+		for range 2 {
+			row, err := service.GetOriginalURL(ctx, shortcode)
+			if err != nil {
+				t.Fatalf("GetOriginalURL() error = %v, want nil", err)
+			}
+			if row.OriginalUrl != originalURL {
+				t.Errorf("GetOriginalURL() OriginalUrl = %s, want %s", row.OriginalUrl, originalURL)
+			}
+			// Cache hits know the link and its owner too, so their clicks can be attributed
+			if row.ID != linkID || row.UserID != "user_1" {
+				t.Errorf("GetOriginalURL() ID, UserID = %s, %s, want %s, user_1", row.ID, row.UserID, linkID)
+			}
+		}
+		if lookups != 1 {
+			t.Errorf("GetLinkForRedirect called %d times, want 1", lookups)
+		}
+	})
+
+	t.Run("cached plain URL is looked up again", func(t *testing.T) {
+		lookups := 0
+		mockQueries := &mocks.LinkQueries{
+			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+				lookups++
+				return db.GetLinkForRedirectRow{
+					ID:             uuid.New(),
+					OriginalUrl:    originalURL,
+					Visibility:     LinkVisibilityPublic,
+					ReferrerPolicy: ReferrerPolicyDefault,
+				}, nil
+			},
+		}
+
+		// As cached before the cache held IDs
+		linkCache := cache.NewMemory()
+		linkCache.Set(ctx, cacheKeyPrefix+shortcode, originalURL, cacheTTL)
+		service := &LinkService{
+			queries: mockQueries,
+			cache:   linkCache,
+			logger:  createTestLogger(),
+		}
+
+		for range 2 {
+			if _, err := service.GetOriginalURL(ctx, shortcode); err != nil {
+				t.Fatalf("GetOriginalURL() error = %v, want nil", err)
+			}
+		}
+		if lookups != 1 {
+			t.Errorf("GetLinkForRedirect called %d times, want 1 before the entry is replaced", lookups)
+		}
+	})
 
 	t.Run("link not found", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
 				return db.GetLinkForRedirectRow{}, sql.ErrNoRows
 			},
@@ -859,7 +1038,7 @@ func TestLinkService_GetOriginalURL(t *testing.T) {
 
 	t.Run("handles database errors", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
 				return db.GetLinkForRedirectRow{}, dbError
 			},
@@ -880,7 +1059,7 @@ func TestLinkService_GetOriginalURL(t *testing.T) {
 	t.Run("deleted links cannot be used for redirect", func(t *testing.T) {
 		// Simulate deleted link: SQL query filters WHERE deleted_at IS NULL
 		// So deleted links return sql.ErrNoRows
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
 				// Deleted links are filtered by SQL, so they return ErrNoRows
 				return db.GetLinkForRedirectRow{}, sql.ErrNoRows
@@ -910,7 +1089,7 @@ func TestLinkService_GetOriginalURL(t *testing.T) {
 			OriginalUrl: originalURL,
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
 				return expectedRow, nil
 			},
@@ -947,7 +1126,7 @@ func TestSoftDeleteFlow(t *testing.T) {
 		createdLink := createTestGetLinkByIdAndUserRow(linkID, shortcode, originalURL, userID)
 
 		// Step 2: Verify link can be retrieved
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
 				if arg.ID == linkID && arg.UserID == userID {
 					return createdLink, nil
@@ -988,9 +1167,9 @@ func TestSoftDeleteFlow(t *testing.T) {
 					Shortcode:   "abc123",
 					OriginalUrl: "https://example.com",
 					IsActive:    true,
-					ExpiresAt:   pgtype.Timestamp{Valid: false},
-					CreatedAt:   pgtype.Timestamp{Valid: false},
-					UpdatedAt:   pgtype.Timestamp{Valid: false},
+					ExpiresAt:   pgtype.Timestamptz{Valid: false},
+					CreatedAt:   pgtype.Timestamptz{Valid: false},
+					UpdatedAt:   pgtype.Timestamptz{Valid: false},
 				}, nil
 			}
 			return db.DeleteLinkRow{}, sql.ErrNoRows
@@ -1061,7 +1240,7 @@ func TestSoftDeleteFlow(t *testing.T) {
 
 		// Delete the first link (soft delete)
 		deleteCalled := false
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
 				if arg.ID == oldLinkID {
 					deleteCalled = true
@@ -1070,9 +1249,9 @@ func TestSoftDeleteFlow(t *testing.T) {
 						Shortcode:   "old123",
 						OriginalUrl: "https://old.com",
 						IsActive:    true,
-						ExpiresAt:   pgtype.Timestamp{Valid: false},
-						CreatedAt:   pgtype.Timestamp{Valid: false},
-						UpdatedAt:   pgtype.Timestamp{Valid: false},
+						ExpiresAt:   pgtype.Timestamptz{Valid: false},
+						CreatedAt:   pgtype.Timestamptz{Valid: false},
+						UpdatedAt:   pgtype.Timestamptz{Valid: false},
 					}, nil
 				}
 				return db.DeleteLinkRow{}, sql.ErrNoRows
@@ -1100,7 +1279,7 @@ func TestSoftDeleteFlow(t *testing.T) {
 		}
 
 		// Create new link with same shortcode (should succeed due to partial unique index)
-		newLink, err := service.CreateShortLink(ctx, userID, "https://new.com", nil, nil)
+		newLink, err := service.CreateShortLink(ctx, userID, "https://new.com", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 		if err != nil {
 			// Note: This might fail due to collision in mock, but in real DB it would work
 			// because the partial unique index allows reusing shortcodes after deletion
@@ -1139,7 +1318,8 @@ func TestLinkService_UpdateLink(t *testing.T) {
 	t.Run("successful update shortcode only", func(t *testing.T) {
 		expectedRow := createTestUpdateLinkRow(linkID, newShortcode, originalURL, true)
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
+			GetShortcodeReservationFunc: noReservation,
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				if arg.ID != linkID {
 					t.Errorf("UpdateLink called with wrong ID: got %s, want %s", arg.ID, linkID)
@@ -1166,7 +1346,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		}
 
 		shortcodePtr := &newShortcode
-		updatedLink, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil)
+		updatedLink, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("UpdateLink() error = %v, want nil", err)
@@ -1180,7 +1360,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		isActive := false
 		expectedRow := createTestUpdateLinkRow(linkID, "oldcode", originalURL, false)
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				if arg.IsActive == nil || *arg.IsActive != false {
 					t.Errorf("UpdateLink called with wrong IsActive: got %v, want false", arg.IsActive)
@@ -1197,7 +1377,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 			logger:  createTestLogger(),
 		}
 
-		updatedLink, err := service.UpdateLink(ctx, userID, linkID, nil, &isActive, nil)
+		updatedLink, err := service.UpdateLink(ctx, userID, linkID, nil, &isActive, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("UpdateLink() error = %v, want nil", err)
@@ -1209,9 +1389,9 @@ func TestLinkService_UpdateLink(t *testing.T) {
 
 	t.Run("successful update expires_at only", func(t *testing.T) {
 		expectedRow := createTestUpdateLinkRow(linkID, "oldcode", originalURL, true)
-		expectedRow.ExpiresAt = pgtype.Timestamp{Time: futureTime, Valid: true}
+		expectedRow.ExpiresAt = pgtype.Timestamptz{Time: futureTime, Valid: true}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				if !arg.ExpiresAt.Valid || !arg.ExpiresAt.Time.Equal(futureTime) {
 					t.Errorf("UpdateLink called with wrong ExpiresAt: got %v, want %v", arg.ExpiresAt, futureTime)
@@ -1228,7 +1408,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 			logger:  createTestLogger(),
 		}
 
-		updatedLink, err := service.UpdateLink(ctx, userID, linkID, nil, nil, &futureTime)
+		updatedLink, err := service.UpdateLink(ctx, userID, linkID, nil, nil, &futureTime, nil, nil, nil, nil, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("UpdateLink() error = %v, want nil", err)
@@ -1241,9 +1421,10 @@ func TestLinkService_UpdateLink(t *testing.T) {
 	t.Run("successful update all fields", func(t *testing.T) {
 		isActive := false
 		expectedRow := createTestUpdateLinkRow(linkID, newShortcode, originalURL, false)
-		expectedRow.ExpiresAt = pgtype.Timestamp{Time: futureTime, Valid: true}
+		expectedRow.ExpiresAt = pgtype.Timestamptz{Time: futureTime, Valid: true}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
+			GetShortcodeReservationFunc: noReservation,
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				if arg.Shortcode == nil || *arg.Shortcode != newShortcode {
 					t.Errorf("UpdateLink called with wrong shortcode")
@@ -1264,7 +1445,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		}
 
 		shortcodePtr := &newShortcode
-		updatedLink, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, &isActive, &futureTime)
+		updatedLink, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, &isActive, &futureTime, nil, nil, nil, nil, nil, nil, nil)
 
 		if err != nil {
 			t.Errorf("UpdateLink() error = %v, want nil", err)
@@ -1275,7 +1456,8 @@ func TestLinkService_UpdateLink(t *testing.T) {
 	})
 
 	t.Run("link not found", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
+			GetShortcodeReservationFunc: noReservation,
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				return db.UpdateLinkRow{}, sql.ErrNoRows
 			},
@@ -1287,7 +1469,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		}
 
 		shortcodePtr := &newShortcode
-		_, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil)
+		_, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Errorf("UpdateLink() expected error for not found")
@@ -1302,7 +1484,8 @@ func TestLinkService_UpdateLink(t *testing.T) {
 			Code: "23505", // Unique constraint violation
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
+			GetShortcodeReservationFunc: noReservation,
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				return db.UpdateLinkRow{}, pgErr
 			},
@@ -1314,7 +1497,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		}
 
 		shortcodePtr := &newShortcode
-		_, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil)
+		_, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Errorf("UpdateLink() expected error for shortcode conflict")
@@ -1327,7 +1510,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		dbError := errors.New("database connection failed")
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				return db.UpdateLinkRow{}, dbError
 			},
@@ -1339,7 +1522,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		}
 
 		shortcodePtr := &newShortcode
-		_, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil)
+		_, err := service.UpdateLink(ctx, userID, linkID, shortcodePtr, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Errorf("UpdateLink() expected error for database failure")
@@ -1352,7 +1535,7 @@ func TestLinkService_UpdateLink(t *testing.T) {
 	t.Run("nil expires_at converts to invalid timestamp", func(t *testing.T) {
 		expectedRow := createTestUpdateLinkRow(linkID, "oldcode", originalURL, true)
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
 				if arg.ExpiresAt.Valid {
 					t.Errorf("UpdateLink should pass invalid ExpiresAt when nil pointer provided")
@@ -1367,14 +1550,13 @@ func TestLinkService_UpdateLink(t *testing.T) {
 			logger:  createTestLogger(),
 		}
 
-		_, err := service.UpdateLink(ctx, userID, linkID, nil, nil, nil)
+		_, err := service.UpdateLink(ctx, userID, linkID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 		if err != nil {
 			t.Errorf("UpdateLink() error = %v, want nil", err)
 		}
 	})
 
-	// Note: Cache invalidation tests require a real Redis instance or miniredis.
-	// The code handles nil cache gracefully (no-op), which is tested above.
+	// Cache invalidation is tested with DeleteLink; the code handles a nil cache gracefully (no-op), which is tested above.
 }
 
 func TestLinkService_DeleteLink(t *testing.T) {
@@ -1383,7 +1565,7 @@ func TestLinkService_DeleteLink(t *testing.T) {
 	linkID := uuid.New()
 
 	t.Run("successful delete", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
 				if arg.ID != linkID {
 					t.Errorf("DeleteLink called with wrong ID: got %s, want %s", arg.ID, linkID)
@@ -1396,9 +1578,9 @@ func TestLinkService_DeleteLink(t *testing.T) {
 					Shortcode:   "abc123",
 					OriginalUrl: "https://example.com",
 					IsActive:    true,
-					ExpiresAt:   pgtype.Timestamp{Valid: false},
-					CreatedAt:   pgtype.Timestamp{Valid: false},
-					UpdatedAt:   pgtype.Timestamp{Valid: false},
+					ExpiresAt:   pgtype.Timestamptz{Valid: false},
+					CreatedAt:   pgtype.Timestamptz{Valid: false},
+					UpdatedAt:   pgtype.Timestamptz{Valid: false},
 				}, nil
 			},
 		}
@@ -1415,7 +1597,7 @@ func TestLinkService_DeleteLink(t *testing.T) {
 	})
 
 	t.Run("link not found", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
 				return db.DeleteLinkRow{}, sql.ErrNoRows
 			},
@@ -1437,7 +1619,7 @@ func TestLinkService_DeleteLink(t *testing.T) {
 
 	t.Run("handles database errors", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
 				return db.DeleteLinkRow{}, dbError
 			},
@@ -1455,7 +1637,7 @@ func TestLinkService_DeleteLink(t *testing.T) {
 	})
 
 	t.Run("trying to delete already deleted link returns not found", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
 				// Simulate soft delete: link already deleted
 				return db.DeleteLinkRow{}, sql.ErrNoRows
@@ -1477,8 +1659,29 @@ func TestLinkService_DeleteLink(t *testing.T) {
 		}
 	})
 
-	// Note: Cache invalidation tests require a real Redis instance or miniredis.
-	// The code handles nil cache gracefully (no-op), which is the most important behavior.
+	t.Run("successful delete removes the link from the cache", func(t *testing.T) {
+		mockQueries := &mocks.LinkQueries{
+			DeleteLinkFunc: func(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
+				return db.DeleteLinkRow{ID: arg.ID, Shortcode: "docs"}, nil
+			},
+			CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error { return nil },
+		}
+
+		linkCache := cache.NewMemory()
+		linkCache.Set(ctx, cacheKeyPrefix+"docs", "https://example.com/docs", cacheTTL)
+		service := &LinkService{
+			queries: mockQueries,
+			cache:   linkCache,
+			logger:  createTestLogger(),
+		}
+
+		if _, err := service.DeleteLink(ctx, userID, linkID); err != nil {
+			t.Fatalf("DeleteLink() error = %v, want nil", err)
+		}
+		if _, err := linkCache.Get(ctx, cacheKeyPrefix+"docs"); !errors.Is(err, cache.ErrMiss) {
+			t.Errorf("cache after DeleteLink() error = %v, want %v", err, cache.ErrMiss)
+		}
+	})
 }
 
 func TestLinkService_GetLinkByShortcode(t *testing.T) {
@@ -1494,12 +1697,12 @@ func TestLinkService_GetLinkByShortcode(t *testing.T) {
 			Shortcode:   shortcode,
 			OriginalUrl: originalURL,
 			IsActive:    true,
-			ExpiresAt:   pgtype.Timestamp{Valid: false},
-			CreatedAt:   pgtype.Timestamp{Valid: false},
-			UpdatedAt:   pgtype.Timestamp{Valid: false},
+			ExpiresAt:   pgtype.Timestamptz{Valid: false},
+			CreatedAt:   pgtype.Timestamptz{Valid: false},
+			UpdatedAt:   pgtype.Timestamptz{Valid: false},
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkByShortcodeAndUserFunc: func(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error) {
 				if arg.Shortcode != shortcode {
 					t.Errorf("GetLinkByShortcodeAndUser called with wrong shortcode: got %s, want %s", arg.Shortcode, shortcode)
@@ -1529,7 +1732,7 @@ func TestLinkService_GetLinkByShortcode(t *testing.T) {
 	})
 
 	t.Run("link not found", func(t *testing.T) {
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkByShortcodeAndUserFunc: func(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error) {
 				return db.GetLinkByShortcodeAndUserRow{}, sql.ErrNoRows
 			},
@@ -1551,7 +1754,7 @@ func TestLinkService_GetLinkByShortcode(t *testing.T) {
 
 	t.Run("handles database errors", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			GetLinkByShortcodeAndUserFunc: func(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error) {
 				return db.GetLinkByShortcodeAndUserRow{}, dbError
 			},
@@ -1582,13 +1785,13 @@ func TestLinkService_AddTagsToLink(t *testing.T) {
 			Shortcode:   "abc123",
 			OriginalUrl: "https://example.com",
 			IsActive:    true,
-			ExpiresAt:   pgtype.Timestamp{Valid: false},
-			CreatedAt:   pgtype.Timestamp{Valid: false},
-			UpdatedAt:   pgtype.Timestamp{Valid: false},
+			ExpiresAt:   pgtype.Timestamptz{Valid: false},
+			CreatedAt:   pgtype.Timestamptz{Valid: false},
+			UpdatedAt:   pgtype.Timestamptz{Valid: false},
 			Tags:        []interface{}{},
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error {
 				if arg.LinkID != linkID {
 					t.Errorf("AddTagsToLink called with wrong LinkID: got %s, want %s", arg.LinkID, linkID)
@@ -1632,13 +1835,13 @@ func TestLinkService_AddTagsToLink(t *testing.T) {
 			Shortcode:   "abc123",
 			OriginalUrl: "https://example.com",
 			IsActive:    true,
-			ExpiresAt:   pgtype.Timestamp{Valid: false},
-			CreatedAt:   pgtype.Timestamp{Valid: false},
-			UpdatedAt:   pgtype.Timestamp{Valid: false},
+			ExpiresAt:   pgtype.Timestamptz{Valid: false},
+			CreatedAt:   pgtype.Timestamptz{Valid: false},
+			UpdatedAt:   pgtype.Timestamptz{Valid: false},
 			Tags:        []interface{}{},
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error {
 				t.Errorf("AddTagsToLink should not be called with empty tag list")
 				return nil
@@ -1664,7 +1867,7 @@ func TestLinkService_AddTagsToLink(t *testing.T) {
 
 	t.Run("handles database errors", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error {
 				return dbError
 			},
@@ -1695,13 +1898,13 @@ func TestLinkService_RemoveTagsFromLink(t *testing.T) {
 			Shortcode:   "abc123",
 			OriginalUrl: "https://example.com",
 			IsActive:    true,
-			ExpiresAt:   pgtype.Timestamp{Valid: false},
-			CreatedAt:   pgtype.Timestamp{Valid: false},
-			UpdatedAt:   pgtype.Timestamp{Valid: false},
+			ExpiresAt:   pgtype.Timestamptz{Valid: false},
+			CreatedAt:   pgtype.Timestamptz{Valid: false},
+			UpdatedAt:   pgtype.Timestamptz{Valid: false},
 			Tags:        []interface{}{},
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			RemoveTagsFromLinkFunc: func(ctx context.Context, arg db.RemoveTagsFromLinkParams) error {
 				if arg.LinkID != linkID {
 					t.Errorf("RemoveTagsFromLink called with wrong LinkID: got %s, want %s", arg.LinkID, linkID)
@@ -1745,13 +1948,13 @@ func TestLinkService_RemoveTagsFromLink(t *testing.T) {
 			Shortcode:   "abc123",
 			OriginalUrl: "https://example.com",
 			IsActive:    true,
-			ExpiresAt:   pgtype.Timestamp{Valid: false},
-			CreatedAt:   pgtype.Timestamp{Valid: false},
-			UpdatedAt:   pgtype.Timestamp{Valid: false},
+			ExpiresAt:   pgtype.Timestamptz{Valid: false},
+			CreatedAt:   pgtype.Timestamptz{Valid: false},
+			UpdatedAt:   pgtype.Timestamptz{Valid: false},
 			Tags:        []interface{}{},
 		}
 
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			RemoveTagsFromLinkFunc: func(ctx context.Context, arg db.RemoveTagsFromLinkParams) error {
 				t.Errorf("RemoveTagsFromLink should not be called with empty tag list")
 				return nil
@@ -1777,7 +1980,7 @@ func TestLinkService_RemoveTagsFromLink(t *testing.T) {
 
 	t.Run("handles database errors", func(t *testing.T) {
 		dbError := errors.New("database query failed")
-		mockQueries := &mockQueries{
+		mockQueries := &mocks.LinkQueries{
 			RemoveTagsFromLinkFunc: func(ctx context.Context, arg db.RemoveTagsFromLinkParams) error {
 				return dbError
 			},
@@ -1794,3 +1997,50 @@ func TestLinkService_RemoveTagsFromLink(t *testing.T) {
 		}
 	})
 }
+
+func TestLinkService_CaptureLead(t *testing.T) {
+	linkID := uuid.New()
+
+	tests := []struct {
+		name          string
+		email         string
+		expectedEmail string
+		expectedErr   error
+	}{
+		{name: "valid email", email: "visitor@example.com", expectedEmail: "visitor@example.com"},
+		{name: "email is trimmed and lowercased", email: "  Visitor@Example.COM ", expectedEmail: "visitor@example.com"},
+		{name: "empty email", email: "", expectedErr: apperrors.InvalidEmail},
+		{name: "missing domain", email: "visitor@", expectedErr: apperrors.InvalidEmail},
+		{name: "display name is rejected", email: "Visitor <visitor@example.com>", expectedErr: apperrors.InvalidEmail},
+		{name: "too long", email: strings.Repeat("a", 250) + "@example.com", expectedErr: apperrors.InvalidEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored db.CreateLinkLeadParams
+			mockQueries := &mocks.LinkQueries{
+				CreateLinkLeadFunc: func(ctx context.Context, arg db.CreateLinkLeadParams) error {
+					stored = arg
+					return nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			err := service.CaptureLead(context.Background(), linkID, tt.email)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("CaptureLead() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CaptureLead() error = %v, want nil", err)
+			}
+			if stored.LinkID != linkID || stored.Email != tt.expectedEmail {
+				t.Errorf("CaptureLead() stored %+v, want email %q for link %s", stored, tt.expectedEmail, linkID)
+			}
+		})
+	}
+}
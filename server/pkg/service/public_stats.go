@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// Purpose of the tokens that open a link's stats page, see AccessTokens.Scoped
+	publicStatsTokenScope = "public-stats"
+	// Days of clicks shown on a public stats page, today included
+	PublicStatsDays = 30
+)
+
+// PublicStatsSettings tells where a link's public stats page is and since when it's public
+type PublicStatsSettings struct {
+	Shortcode string
+	EnabledAt time.Time
+}
+
+// PublicStatsToken opens the stats page of a link until it expires, public or not
+type PublicStatsToken struct {
+	Shortcode string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// EnablePublicStats makes the stats page of one of the user's links (/{shortcode}+) public
+func (s *LinkService) EnablePublicStats(ctx context.Context, userID string, linkID uuid.UUID) (PublicStatsSettings, error) {
+	link, err := s.getUserLink(ctx, userID, linkID)
+	if err != nil {
+		return PublicStatsSettings{}, err
+	}
+
+	stats, err := s.queries.EnableLinkPublicStats(ctx, linkID)
+	if err != nil {
+		return PublicStatsSettings{}, fmt.Errorf("failed to enable public stats: %w", err)
+	}
+
+	s.logger.Info("Link public stats enabled",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+	)
+
+	return PublicStatsSettings{Shortcode: link.Shortcode, EnabledAt: stats.EnabledAt.Time}, nil
+}
+
+// GetPublicStats returns the public stats page settings of one of the user's links
+func (s *LinkService) GetPublicStats(ctx context.Context, userID string, linkID uuid.UUID) (PublicStatsSettings, error) {
+	link, err := s.getUserLink(ctx, userID, linkID)
+	if err != nil {
+		return PublicStatsSettings{}, err
+	}
+
+	stats, err := s.queries.GetLinkPublicStats(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PublicStatsSettings{}, fmt.Errorf("%w: %v", apperrors.PublicStatsNotEnabled, err)
+		}
+		return PublicStatsSettings{}, fmt.Errorf("failed to get public stats: %w", err)
+	}
+
+	return PublicStatsSettings{Shortcode: link.Shortcode, EnabledAt: stats.EnabledAt.Time}, nil
+}
+
+// DisablePublicStats makes the stats page of one of the user's links private again.
+// Signed tokens keep opening it until they expire.
+func (s *LinkService) DisablePublicStats(ctx context.Context, userID string, linkID uuid.UUID) (PublicStatsSettings, error) {
+	link, err := s.getUserLink(ctx, userID, linkID)
+	if err != nil {
+		return PublicStatsSettings{}, err
+	}
+
+	stats, err := s.queries.DisableLinkPublicStats(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PublicStatsSettings{}, fmt.Errorf("%w: %v", apperrors.PublicStatsNotEnabled, err)
+		}
+		return PublicStatsSettings{}, fmt.Errorf("failed to disable public stats: %w", err)
+	}
+
+	s.logger.Info("Link public stats disabled",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+	)
+
+	return PublicStatsSettings{Shortcode: link.Shortcode, EnabledAt: stats.EnabledAt.Time}, nil
+}
+
+// CreatePublicStatsToken signs a token that opens the stats page of one of the user's links
+// until expiresAt, whether the page is public or not. It can't be used to follow private links.
+func (s *LinkService) CreatePublicStatsToken(ctx context.Context, userID string, linkID uuid.UUID, expiresAt time.Time) (PublicStatsToken, error) {
+	if !s.tokens.Enabled() {
+		return PublicStatsToken{}, apperrors.AccessTokensDisabled
+	}
+
+	link, err := s.getUserLink(ctx, userID, linkID)
+	if err != nil {
+		return PublicStatsToken{}, err
+	}
+
+	return PublicStatsToken{
+		Shortcode: link.Shortcode,
+		Token:     s.tokens.Scoped(publicStatsTokenScope).Sign(linkID, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// getUserLink is checkLinkOwner for callers that need the link
+func (s *LinkService) getUserLink(ctx context.Context, userID string, linkID uuid.UUID) (db.GetLinkByIdAndUserRow, error) {
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.GetLinkByIdAndUserRow{}, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return db.GetLinkByIdAndUserRow{}, fmt.Errorf("failed to get link: %w", err)
+	}
+	return link, nil
+}
+
+type PublicLinkStatsResult struct {
+	Shortcode   string
+	From        time.Time
+	To          time.Time
+	TotalClicks int64
+	QRClicks    int64
+	// One entry per UTC day of the period, days without clicks included
+	ClicksByDay []db.GetLinkClicksByDayRow
+}
+
+/*
+GetPublicLinkStats returns the clicks of the last PublicStatsDays days on the
+link behind shortcode, for its public stats page. The page of a link whose
+owner didn't make it public is only served with a token from
+CreatePublicStatsToken; without one it doesn't exist (apperrors.LinkNotFound),
+so private pages can't be told from unknown shortcodes.
+*/
+func (s *StatsService) GetPublicLinkStats(ctx context.Context, shortcode, token string, now time.Time) (*PublicLinkStatsResult, error) {
+	link, err := s.queries.GetLinkForPublicStats(ctx, shortcode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	if !link.PublicStats && !s.tokens.Scoped(publicStatsTokenScope).Verify(link.ID, token, now) {
+		return nil, fmt.Errorf("%w: stats of %s aren't public", apperrors.LinkNotFound, shortcode)
+	}
+
+	to := now.UTC()
+	from := to.Truncate(24*time.Hour).AddDate(0, 0, 1-PublicStatsDays)
+
+	rollupFrom, rollupTo, err := s.rollupRange(ctx, from, to, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay, err := s.queries.GetLinkClicksByDay(ctx, db.GetLinkClicksByDayParams{
+		LinkID:     link.ID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		FromTime:   pgtype.Timestamptz{Time: from, Valid: true},
+		ToTime:     pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link clicks by day: %w", err)
+	}
+
+	result := &PublicLinkStatsResult{
+		Shortcode:   shortcode,
+		From:        from,
+		To:          to,
+		ClicksByDay: fillDays(byDay, from, PublicStatsDays),
+	}
+	for _, day := range byDay {
+		result.TotalClicks += day.Clicks
+		result.QRClicks += day.QrClicks
+	}
+
+	return result, nil
+}
+
+// fillDays returns one entry per UTC day starting at from, with zero clicks on the days missing from byDay
+func fillDays(byDay []db.GetLinkClicksByDayRow, from time.Time, days int) []db.GetLinkClicksByDayRow {
+	clicks := make(map[time.Time]db.GetLinkClicksByDayRow, len(byDay))
+	for _, day := range byDay {
+		clicks[day.Day.Time.UTC()] = day
+	}
+
+	filled := make([]db.GetLinkClicksByDayRow, days)
+	for i := range filled {
+		day := from.AddDate(0, 0, i)
+		filled[i] = clicks[day]
+		filled[i].Day = pgtype.Timestamptz{Time: day, Valid: true}
+	}
+	return filled
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestStatsService_GetPublicLinkStats(t *testing.T) {
+	tokens := NewAccessTokens("0123456789abcdef0123456789abcdef")
+	linkID := uuid.New()
+	now := time.Date(2025, 3, 30, 15, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	today := time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		public      bool
+		missing     bool
+		token       string
+		expectedErr error
+	}{
+		{name: "public page", public: true},
+		{name: "private page with a stats token", token: tokens.Scoped(publicStatsTokenScope).Sign(linkID, expiresAt)},
+		{name: "private page", expectedErr: apperrors.LinkNotFound},
+		{name: "private page with a link access token", token: tokens.Sign(linkID, expiresAt), expectedErr: apperrors.LinkNotFound},
+		{name: "unknown shortcode", missing: true, expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params db.GetLinkClicksByDayParams
+			queries := &mocks.StatsQueries{
+				GetLinkForPublicStatsFunc: func(ctx context.Context, shortcode string) (db.GetLinkForPublicStatsRow, error) {
+					if tt.missing {
+						return db.GetLinkForPublicStatsRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkForPublicStatsRow{ID: linkID, Shortcode: shortcode, PublicStats: tt.public}, nil
+				},
+				GetStatsRollupWatermarkFunc: func(ctx context.Context) (pgtype.Date, error) {
+					return pgtype.Date{Time: today.AddDate(0, 0, -1), Valid: true}, nil
+				},
+				GetLinkClicksByDayFunc: func(ctx context.Context, arg db.GetLinkClicksByDayParams) ([]db.GetLinkClicksByDayRow, error) {
+					params = arg
+					return []db.GetLinkClicksByDayRow{
+						{Day: pgtype.Timestamptz{Time: today.AddDate(0, 0, -10), Valid: true}, Clicks: 4, QrClicks: 1},
+						{Day: pgtype.Timestamptz{Time: today, Valid: true}, Clicks: 2},
+					}, nil
+				},
+			}
+			s := NewStatsService(queries, nil, nil, nil, tokens, nil, nil, nil, nil, createTestLogger())
+
+			stats, err := s.GetPublicLinkStats(context.Background(), "docs", tt.token, now)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("GetPublicLinkStats() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPublicLinkStats() error = %v, want nil", err)
+			}
+
+			if stats.TotalClicks != 6 || stats.QRClicks != 1 {
+				t.Errorf("clicks = %d (%d QR), want 6 (1 QR)", stats.TotalClicks, stats.QRClicks)
+			}
+			if len(stats.ClicksByDay) != PublicStatsDays {
+				t.Fatalf("got %d days, want %d", len(stats.ClicksByDay), PublicStatsDays)
+			}
+			first, last := stats.ClicksByDay[0], stats.ClicksByDay[PublicStatsDays-1]
+			if !first.Day.Time.Equal(today.AddDate(0, 0, 1-PublicStatsDays)) || first.Clicks != 0 {
+				t.Errorf("first day = %v with %d clicks, want %v with none", first.Day.Time, first.Clicks, today.AddDate(0, 0, 1-PublicStatsDays))
+			}
+			if !last.Day.Time.Equal(today) || last.Clicks != 2 {
+				t.Errorf("last day = %v with %d clicks, want today with 2", last.Day.Time, last.Clicks)
+			}
+			// Whole days up to the watermark come from rollups
+			if !params.RollupTo.Time.Equal(today.AddDate(0, 0, -1)) || !params.ToTime.Time.Equal(now) {
+				t.Errorf("rollups read until %v and clicks until %v, want %v and %v", params.RollupTo.Time, params.ToTime.Time, today.AddDate(0, 0, -1), now)
+			}
+		})
+	}
+}
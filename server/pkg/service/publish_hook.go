@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Prefix of publish hook tokens, so leaked tokens are easy to recognize
+	publishHookTokenPrefix = "ph_"
+	// How long a CMS callback may take
+	PublishCallbackTimeout = 10 * time.Second
+)
+
+/*
+PublishHookService manages publish hooks: endpoints a CMS calls when an article
+is published, so its URL is shortened on the hook owner's behalf. Each hook has
+its own token, stored only as a SHA-256 hash, and optionally a tag applied to
+the links it creates and a callback URL the short URL is posted to.
+*/
+type PublishHookService struct {
+	queries repository.PublishHookQueries
+	client  *http.Client
+	logger  logger.Logger
+}
+
+func NewPublishHookService(queries repository.PublishHookQueries, client *http.Client, logger logger.Logger) *PublishHookService {
+	return &PublishHookService{
+		queries: queries,
+		client:  client,
+		logger:  logger,
+	}
+}
+
+// PublishCallback is posted as JSON to a hook's callback URL once the article's short link is ready
+type PublishCallback struct {
+	HookID       uuid.UUID `json:"hook_id"`
+	LinkID       uuid.UUID `json:"link_id"`
+	URL          string    `json:"url"`
+	Shortcode    string    `json:"shortcode"`
+	ShortURL     string    `json:"short_url"`
+	Deduplicated bool      `json:"deduplicated"`
+}
+
+// CreateHook creates a publish hook and returns it with its token, which can't be retrieved later
+func (s *PublishHookService) CreateHook(ctx context.Context, userID string, name string, tagID *uuid.UUID, callbackURL *string) (db.CreatePublishHookRow, string, error) {
+	if callbackURL != nil {
+		if err := validateURL(*callbackURL); err != nil {
+			return db.CreatePublishHookRow{}, "", err
+		}
+	}
+
+	var hookTag pgtype.UUID
+	if tagID != nil {
+		count, err := s.queries.CountUserTagsByIDs(ctx, db.CountUserTagsByIDsParams{
+			UserID: userID,
+			Ids:    []uuid.UUID{*tagID},
+		})
+		if err != nil {
+			return db.CreatePublishHookRow{}, "", fmt.Errorf("failed to check tag: %w", err)
+		}
+		if count == 0 {
+			return db.CreatePublishHookRow{}, "", fmt.Errorf("%w: %s", apperrors.TagNotFound, *tagID)
+		}
+		hookTag = pgtype.UUID{Bytes: *tagID, Valid: true}
+	}
+
+	token, err := newSecretToken(publishHookTokenPrefix)
+	if err != nil {
+		return db.CreatePublishHookRow{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	hook, err := s.queries.CreatePublishHook(ctx, db.CreatePublishHookParams{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashSecretToken(token),
+		TagID:       hookTag,
+		CallbackUrl: callbackURL,
+	})
+	if err != nil {
+		return db.CreatePublishHookRow{}, "", fmt.Errorf("failed to create publish hook: %w", err)
+	}
+
+	s.logger.Info("Publish hook created",
+		zap.String("user_id", userID),
+		zap.String("hook_id", hook.ID.String()),
+	)
+
+	return hook, token, nil
+}
+
+func (s *PublishHookService) ListHooks(ctx context.Context, userID string) ([]db.ListUserPublishHooksRow, error) {
+	hooks, err := s.queries.ListUserPublishHooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publish hooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (s *PublishHookService) DeleteHook(ctx context.Context, userID string, id uuid.UUID) (db.DeletePublishHookRow, error) {
+	hook, err := s.queries.DeletePublishHook(ctx, db.DeletePublishHookParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DeletePublishHookRow{}, fmt.Errorf("%w: %v", apperrors.PublishHookNotFound, err)
+		}
+		return db.DeletePublishHookRow{}, fmt.Errorf("failed to delete publish hook: %w", err)
+	}
+
+	return hook, nil
+}
+
+// Authenticate resolves the hook a token belongs to, InvalidPublishHookToken if there's none
+func (s *PublishHookService) Authenticate(ctx context.Context, token string) (db.UsePublishHookRow, error) {
+	if !strings.HasPrefix(token, publishHookTokenPrefix) {
+		return db.UsePublishHookRow{}, apperrors.InvalidPublishHookToken
+	}
+
+	hook, err := s.queries.UsePublishHook(ctx, hashSecretToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.UsePublishHookRow{}, apperrors.InvalidPublishHookToken
+		}
+		return db.UsePublishHookRow{}, fmt.Errorf("failed to get publish hook: %w", err)
+	}
+
+	return hook, nil
+}
+
+// SendCallback posts the callback to callbackURL; any non-2xx response is an error
+func (s *PublishHookService) SendCallback(ctx context.Context, callbackURL string, callback PublishCallback) error {
+	body, err := json.Marshal(callback)
+	if err != nil {
+		return fmt.Errorf("failed to encode callback: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("callback request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// newSecretToken returns a random token starting with prefix, for credentials stored only as a hash
+func newSecretToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecretToken returns the hash a token made by newSecretToken is stored as
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
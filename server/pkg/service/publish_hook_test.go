@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type mockPublishHookQueries struct {
+	hooks map[string]db.UsePublishHookRow
+	tags  map[uuid.UUID]string
+}
+
+func (m *mockPublishHookQueries) CreatePublishHook(ctx context.Context, arg db.CreatePublishHookParams) (db.CreatePublishHookRow, error) {
+	id := uuid.New()
+	m.hooks[arg.TokenHash] = db.UsePublishHookRow{ID: id, UserID: arg.UserID, TagID: arg.TagID, CallbackUrl: arg.CallbackUrl}
+	return db.CreatePublishHookRow{ID: id, Name: arg.Name, TagID: arg.TagID, CallbackUrl: arg.CallbackUrl}, nil
+}
+
+func (m *mockPublishHookQueries) ListUserPublishHooks(ctx context.Context, userID string) ([]db.ListUserPublishHooksRow, error) {
+	return nil, nil
+}
+
+func (m *mockPublishHookQueries) DeletePublishHook(ctx context.Context, arg db.DeletePublishHookParams) (db.DeletePublishHookRow, error) {
+	return db.DeletePublishHookRow{}, sql.ErrNoRows
+}
+
+func (m *mockPublishHookQueries) UsePublishHook(ctx context.Context, tokenHash string) (db.UsePublishHookRow, error) {
+	hook, ok := m.hooks[tokenHash]
+	if !ok {
+		return db.UsePublishHookRow{}, sql.ErrNoRows
+	}
+	return hook, nil
+}
+
+func (m *mockPublishHookQueries) CountUserTagsByIDs(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error) {
+	var count int64
+	for _, id := range arg.Ids {
+		if m.tags[id] == arg.UserID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestPublishHookService_CreateAndAuthenticate(t *testing.T) {
+	tagID := uuid.New()
+	queries := &mockPublishHookQueries{
+		hooks: map[string]db.UsePublishHookRow{},
+		tags:  map[uuid.UUID]string{tagID: "user_1"},
+	}
+	s := NewPublishHookService(queries, http.DefaultClient, createTestLogger())
+	ctx := context.Background()
+
+	hook, token, err := s.CreateHook(ctx, "user_1", "Blog", &tagID, nil)
+	if err != nil {
+		t.Fatalf("CreateHook() error = %v", err)
+	}
+	if !strings.HasPrefix(token, publishHookTokenPrefix) {
+		t.Errorf("token = %q, want the %q prefix", token, publishHookTokenPrefix)
+	}
+	if _, stored := queries.hooks[token]; stored {
+		t.Error("the token was stored in plain text")
+	}
+
+	got, err := s.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if got.ID != hook.ID || got.UserID != "user_1" || got.TagID.Bytes != tagID {
+		t.Errorf("Authenticate() = %+v, want the created hook", got)
+	}
+
+	for _, bad := range []string{"", "ph_unknown", strings.TrimPrefix(token, publishHookTokenPrefix)} {
+		if _, err := s.Authenticate(ctx, bad); !errors.Is(err, apperrors.InvalidPublishHookToken) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", bad, err, apperrors.InvalidPublishHookToken)
+		}
+	}
+
+	otherTag := uuid.New()
+	if _, _, err := s.CreateHook(ctx, "user_1", "Blog", &otherTag, nil); !errors.Is(err, apperrors.TagNotFound) {
+		t.Errorf("CreateHook(other user's tag) error = %v, want %v", err, apperrors.TagNotFound)
+	}
+
+	callback := "ftp://example.com/hook"
+	if _, _, err := s.CreateHook(ctx, "user_1", "Blog", nil, &callback); !errors.Is(err, apperrors.InvalidURL) {
+		t.Errorf("CreateHook(ftp callback) error = %v, want %v", err, apperrors.InvalidURL)
+	}
+}
+
+func TestPublishHookService_SendCallback(t *testing.T) {
+	var got PublishCallback
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode callback: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := NewPublishHookService(nil, srv.Client(), createTestLogger())
+	callback := PublishCallback{HookID: uuid.New(), LinkID: uuid.New(), URL: "https://blog.example.com/post", Shortcode: "abc123", ShortURL: "https://sho.rt/abc123"}
+
+	if err := s.SendCallback(context.Background(), srv.URL, callback); err != nil {
+		t.Fatalf("SendCallback() error = %v", err)
+	}
+	if got != callback {
+		t.Errorf("callback = %+v, want %+v", got, callback)
+	}
+
+	status = http.StatusInternalServerError
+	if err := s.SendCallback(context.Background(), srv.URL, callback); err == nil {
+		t.Error("SendCallback() error = nil, want an error on a 500")
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"rsc.io/qr"
+)
+
+const (
+	// A ZIP of PNG images, one per link
+	QRFormatZIP = "zip"
+	// A printable A4 PDF sheet with a grid of codes
+	QRFormatPDF = "pdf"
+
+	// Most links in one QR batch
+	MaxQRBatchSize = 100
+	// Image pixels per QR module in PNGs
+	DefaultQRScale = 10
+
+	// Query parameter marking the short URLs encoded in QR codes, so their scans are told from clicks
+	qrSourceParam = "src"
+)
+
+// QRCode is the QR code of a short link
+type QRCode struct {
+	LinkID    uuid.UUID
+	Shortcode string
+	// The short URL, as printed under the code; the code itself adds ?src=qr
+	URL string
+
+	code *qr.Code
+}
+
+/*
+QRCodes encodes the short URLs (baseURL + "/" + shortcode) of the user's links,
+in the order of ids. Any ID that isn't one of the user's links fails the whole
+batch with LinkNotFound, so nothing is written for a partial selection.
+*/
+func (s *LinkService) QRCodes(ctx context.Context, userID string, ids []uuid.UUID, baseURL string) ([]QRCode, error) {
+	ids = uniqueIDs(ids)
+
+	links, err := s.GetLinksByIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	shortcodes := make(map[uuid.UUID]string, len(links))
+	for _, l := range links {
+		shortcodes[l.ID] = l.Shortcode
+	}
+
+	baseURL = strings.TrimRight(baseURL, "/")
+	codes := make([]QRCode, 0, len(ids))
+	for _, id := range ids {
+		shortcode, ok := shortcodes[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", apperrors.LinkNotFound, id)
+		}
+
+		code, err := EncodeQRCode(id, shortcode, baseURL+"/"+shortcode)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// EncodeQRCode encodes url, the short URL of a link, with medium error correction.
+// The encoded URL carries ?src=qr so the link's stats can count scans apart from clicks.
+func EncodeQRCode(linkID uuid.UUID, shortcode, url string) (QRCode, error) {
+	code, err := qr.Encode(url+"?"+qrSourceParam+"="+analytics.SourceQR, qr.M)
+	if err != nil {
+		return QRCode{}, fmt.Errorf("failed to encode QR code for %s: %w", shortcode, err)
+	}
+	return QRCode{LinkID: linkID, Shortcode: shortcode, URL: url, code: code}, nil
+}
+
+// PNG renders the code as a PNG image; scale is the size of a QR module in pixels
+func (c QRCode) PNG(scale int) []byte {
+	code := *c.code
+	code.Scale = scale
+	return code.PNG()
+}
+
+// WriteQRZip streams a ZIP with one "<shortcode>.png" per code.
+// scale is the size of a QR module in pixels.
+func WriteQRZip(w io.Writer, codes []QRCode, scale int) error {
+	zw := zip.NewWriter(w)
+	modified := time.Now()
+
+	for _, c := range codes {
+		// PNGs are already compressed
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     c.Shortcode + ".png",
+			Method:   zip.Store,
+			Modified: modified,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to QR archive: %w", c.Shortcode, err)
+		}
+
+		if _, err := f.Write(c.PNG(scale)); err != nil {
+			return fmt.Errorf("failed to write QR archive: %w", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write QR archive: %w", err)
+	}
+	return nil
+}
+
+// QR sheet layout, in PDF points (1/72 inch) on A4 paper
+const (
+	qrSheetWidth   = 595
+	qrSheetHeight  = 842
+	qrSheetMargin  = 36
+	qrSheetColumns = 3
+	qrSheetRows    = 4
+	// Side of a code including its quiet zone
+	qrSheetCodeSize = 140
+	qrSheetFontSize = 9
+)
+
+/*
+WriteQRSheet writes a printable PDF with a 3x4 grid of codes per A4 page, each
+labeled with its short URL. Codes are drawn as vectors so they stay sharp at
+any print size. Pages are written as they're laid out.
+*/
+func WriteQRSheet(w io.Writer, codes []QRCode) error {
+	perPage := qrSheetColumns * qrSheetRows
+	pages := max((len(codes)+perPage-1)/perPage, 1)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content stream per page
+	pdf := &pdfWriter{w: w, offsets: make([]int64, 4+2*pages)}
+	pdf.printf("%%PDF-1.4\n")
+
+	pdf.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, pages)
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	pdf.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages))
+	pdf.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	for i := range pages {
+		pageID, contentID := 4+2*i, 5+2*i
+		content := qrSheetPage(codes[min(i*perPage, len(codes)):min((i+1)*perPage, len(codes))])
+
+		pdf.object(pageID, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			qrSheetWidth, qrSheetHeight, contentID,
+		))
+		pdf.object(contentID, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := pdf.n
+	pdf.printf("xref\n0 %d\n0000000000 65535 f \n", len(pdf.offsets))
+	for _, offset := range pdf.offsets[1:] {
+		pdf.printf("%010d 00000 n \n", offset)
+	}
+	pdf.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pdf.offsets), xref)
+
+	if pdf.err != nil {
+		return fmt.Errorf("failed to write QR sheet: %w", pdf.err)
+	}
+	return nil
+}
+
+// qrSheetPage returns the content stream drawing codes on one page
+func qrSheetPage(codes []QRCode) []byte {
+	cellWidth := float64(qrSheetWidth-2*qrSheetMargin) / qrSheetColumns
+	cellHeight := float64(qrSheetHeight-2*qrSheetMargin) / qrSheetRows
+
+	var buf bytes.Buffer
+	for i, c := range codes {
+		col, row := i%qrSheetColumns, i/qrSheetColumns
+
+		// Top-left corner of the code, centered horizontally in its cell
+		x := qrSheetMargin + float64(col)*cellWidth + (cellWidth-qrSheetCodeSize)/2
+		top := qrSheetHeight - qrSheetMargin - float64(row)*cellHeight
+
+		// The quiet zone is 4 modules on each side
+		module := qrSheetCodeSize / float64(c.code.Size+8)
+		originX, originY := x+4*module, top-4*module
+
+		// One rectangle per horizontal run of dark modules
+		for y := range c.code.Size {
+			for start := 0; start < c.code.Size; {
+				if !c.code.Black(start, y) {
+					start++
+					continue
+				}
+				end := start
+				for end < c.code.Size && c.code.Black(end, y) {
+					end++
+				}
+				fmt.Fprintf(&buf, "%.2f %.2f %.2f %.2f re\n",
+					originX+float64(start)*module, originY-float64(y+1)*module, float64(end-start)*module, module)
+				start = end
+			}
+		}
+		buf.WriteString("f\n")
+
+		fmt.Fprintf(&buf, "BT /F1 %d Tf %.2f %.2f Td (%s) Tj ET\n",
+			qrSheetFontSize, originX, top-qrSheetCodeSize-qrSheetFontSize, pdfEscape(c.URL))
+	}
+
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a string literal for a PDF content stream
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// pdfWriter tracks the byte offset of every object for the cross-reference table
+type pdfWriter struct {
+	w       io.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (p *pdfWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.n += int64(n)
+	p.err = err
+}
+
+func (p *pdfWriter) object(id int, body string) {
+	p.offsets[id] = p.n
+	p.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_QRCodes(t *testing.T) {
+	first, second, missing := uuid.New(), uuid.New(), uuid.New()
+
+	s := &LinkService{
+		queries: &mocks.LinkQueries{
+			ListUserLinksByIDsFunc: func(ctx context.Context, arg db.ListUserLinksByIDsParams) ([]db.ListUserLinksByIDsRow, error) {
+				var rows []db.ListUserLinksByIDsRow
+				for _, id := range arg.Ids {
+					switch id {
+					case first:
+						rows = append(rows, db.ListUserLinksByIDsRow{ID: first, Shortcode: "abc123"})
+					case second:
+						rows = append(rows, db.ListUserLinksByIDsRow{ID: second, Shortcode: "xyz789"})
+					}
+				}
+				return rows, nil
+			},
+		},
+		logger: createTestLogger(),
+	}
+
+	t.Run("request order without duplicates", func(t *testing.T) {
+		codes, err := s.QRCodes(context.Background(), "user_1", []uuid.UUID{second, first, second}, "https://sho.rt/")
+		if err != nil {
+			t.Fatalf("QRCodes() error = %v", err)
+		}
+
+		if len(codes) != 2 {
+			t.Fatalf("got %d codes, want 2", len(codes))
+		}
+		if codes[0].URL != "https://sho.rt/xyz789" || codes[1].URL != "https://sho.rt/abc123" {
+			t.Errorf("URLs = %s, %s", codes[0].URL, codes[1].URL)
+		}
+	})
+
+	t.Run("unknown link fails the batch", func(t *testing.T) {
+		_, err := s.QRCodes(context.Background(), "user_1", []uuid.UUID{first, missing}, "https://sho.rt")
+		if !errors.Is(err, apperrors.LinkNotFound) {
+			t.Fatalf("QRCodes() error = %v, want LinkNotFound", err)
+		}
+		if !strings.Contains(err.Error(), missing.String()) {
+			t.Errorf("error %q doesn't name the missing link", err)
+		}
+	})
+}
+
+func testQRCodes(t *testing.T, n int) []QRCode {
+	t.Helper()
+
+	codes := make([]QRCode, n)
+	for i := range codes {
+		shortcode := "code" + strconv.Itoa(i)
+		code, err := EncodeQRCode(uuid.New(), shortcode, "https://sho.rt/"+shortcode)
+		if err != nil {
+			t.Fatalf("EncodeQRCode() error = %v", err)
+		}
+		codes[i] = code
+	}
+	return codes
+}
+
+func TestWriteQRZip(t *testing.T) {
+	codes := testQRCodes(t, 3)
+
+	var buf bytes.Buffer
+	if err := WriteQRZip(&buf, codes, 4); err != nil {
+		t.Fatalf("WriteQRZip() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid ZIP: %v", err)
+	}
+	if len(zr.File) != len(codes) {
+		t.Fatalf("ZIP has %d files, want %d", len(zr.File), len(codes))
+	}
+
+	for i, f := range zr.File {
+		if want := codes[i].Shortcode + ".png"; f.Name != want {
+			t.Errorf("file %d = %s, want %s", i, f.Name, want)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		img, err := png.Decode(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s isn't a PNG: %v", f.Name, err)
+		}
+
+		// Quiet zone of 4 modules on each side, 4 pixels per module
+		if want := (codes[i].code.Size + 8) * 4; img.Bounds().Dx() != want {
+			t.Errorf("%s is %dpx wide, want %d", f.Name, img.Bounds().Dx(), want)
+		}
+	}
+}
+
+func TestWriteQRSheet(t *testing.T) {
+	// 13 codes spill onto a second page
+	codes := testQRCodes(t, 13)
+	codes[0].URL = `https://sho.rt/(a\b)`
+
+	var buf bytes.Buffer
+	if err := WriteQRSheet(&buf, codes); err != nil {
+		t.Fatalf("WriteQRSheet() error = %v", err)
+	}
+	pdf := buf.Bytes()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if !bytes.Contains(pdf, []byte("/Count 2")) {
+		t.Error("want 2 pages")
+	}
+	if !bytes.Contains(pdf, []byte(`(https://sho.rt/\(a\\b\)) Tj`)) {
+		t.Error("label isn't escaped")
+	}
+
+	// startxref points at the table, and every entry at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for id := 1; id < count; id++ {
+		offset, _ := strconv.Atoi(strings.Fields(lines[2+id])[0])
+		if !bytes.HasPrefix(pdf[offset:], []byte(strconv.Itoa(id)+" 0 obj\n")) {
+			t.Errorf("xref entry %d points at offset %d, not at its object", id, offset)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"go.uber.org/zap"
+)
+
+/*
+QuickShorten shortens a URL with default settings, for clients like the browser
+extension that fire on every click of a button. When the user already has a
+live default-settings link to the same URL, that link is returned with
+deduplicated set instead of creating another one.
+*/
+func (s *LinkService) QuickShorten(ctx context.Context, userID string, originalURL string, title *string) (link db.TryCreateLinkRow, deduplicated bool, err error) {
+	if err := validateURL(originalURL); err != nil {
+		return db.TryCreateLinkRow{}, false, err
+	}
+
+	normalizedURL, err := s.normalizer.Normalize(originalURL)
+	if err != nil {
+		return db.TryCreateLinkRow{}, false, fmt.Errorf("%w: %v", apperrors.InvalidURL, err)
+	}
+
+	existing, err := s.queries.GetUserLinkByURL(ctx, db.GetUserLinkByURLParams{
+		UserID:      userID,
+		OriginalUrl: normalizedURL,
+	})
+	if err == nil {
+		s.logger.Info("Quick shorten returned an existing link",
+			zap.String("user_id", userID),
+			zap.String("shortcode", existing.Shortcode),
+		)
+		return db.TryCreateLinkRow(existing), true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return db.TryCreateLinkRow{}, false, fmt.Errorf("failed to look up existing link: %w", err)
+	}
+
+	link, err = s.CreateShortLink(ctx, userID, originalURL, nil, nil, nil, nil, nil, nil, nil, title, nil, nil, nil, nil)
+	if err != nil {
+		return db.TryCreateLinkRow{}, false, err
+	}
+
+	return link, false, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_QuickShorten(t *testing.T) {
+	ctx := context.Background()
+	title := "Example Domain"
+
+	t.Run("existing link is reused", func(t *testing.T) {
+		existingID := uuid.New()
+		var lookup db.GetUserLinkByURLParams
+		s := &LinkService{
+			queries: &mocks.LinkQueries{
+				GetUserLinkByURLFunc: func(ctx context.Context, arg db.GetUserLinkByURLParams) (db.GetUserLinkByURLRow, error) {
+					lookup = arg
+					return db.GetUserLinkByURLRow{ID: existingID, Shortcode: "abc123", OriginalUrl: arg.OriginalUrl}, nil
+				},
+				TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+					t.Error("TryCreateLink should not be called when the link exists")
+					return db.TryCreateLinkRow{}, nil
+				},
+			},
+			logger: createTestLogger(),
+		}
+
+		link, deduplicated, err := s.QuickShorten(ctx, "user_123", "https://example.com/", &title)
+		if err != nil {
+			t.Fatalf("QuickShorten() error = %v", err)
+		}
+		if !deduplicated || link.ID != existingID {
+			t.Errorf("QuickShorten() = %s, deduplicated %v, want %s and true", link.ID, deduplicated, existingID)
+		}
+		if lookup.UserID != "user_123" || lookup.OriginalUrl != "https://example.com/" {
+			t.Errorf("lookup = %+v, want the user's normalized URL", lookup)
+		}
+	})
+
+	t.Run("new link gets the title and default settings", func(t *testing.T) {
+		var created db.TryCreateLinkParams
+		s := &LinkService{
+			queries: &mocks.LinkQueries{
+				GetUserLinkByURLFunc: func(ctx context.Context, arg db.GetUserLinkByURLParams) (db.GetUserLinkByURLRow, error) {
+					return db.GetUserLinkByURLRow{}, sql.ErrNoRows
+				},
+				TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+					created = arg
+					row := createTestTryCreateLinkRow(uuid.New(), arg.Shortcode, arg.OriginalUrl, arg.UserID)
+					row.Title = arg.Title
+					return row, nil
+				},
+			},
+			logger: createTestLogger(),
+		}
+
+		link, deduplicated, err := s.QuickShorten(ctx, "user_123", "https://example.com/", &title)
+		if err != nil {
+			t.Fatalf("QuickShorten() error = %v", err)
+		}
+		if deduplicated {
+			t.Error("QuickShorten() deduplicated = true, want false")
+		}
+		if link.Title == nil || *link.Title != title {
+			t.Errorf("QuickShorten() title = %v, want %q", link.Title, title)
+		}
+		if created.Visibility != LinkVisibilityPublic || created.CaptureEmail || created.RedirectDelay != 0 || created.AppendClickID {
+			t.Errorf("created with %+v, want default settings", created)
+		}
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		s := &LinkService{queries: &mocks.LinkQueries{}, logger: createTestLogger()}
+
+		if _, _, err := s.QuickShorten(ctx, "user_123", "not a url", nil); !errors.Is(err, apperrors.InvalidURL) {
+			t.Errorf("QuickShorten() error = %v, want %v", err, apperrors.InvalidURL)
+		}
+	})
+
+	t.Run("lookup failure", func(t *testing.T) {
+		s := &LinkService{
+			queries: &mocks.LinkQueries{
+				GetUserLinkByURLFunc: func(ctx context.Context, arg db.GetUserLinkByURLParams) (db.GetUserLinkByURLRow, error) {
+					return db.GetUserLinkByURLRow{}, errors.New("connection reset")
+				},
+			},
+			logger: createTestLogger(),
+		}
+
+		if _, _, err := s.QuickShorten(ctx, "user_123", "https://example.com/", nil); err == nil {
+			t.Error("QuickShorten() error = nil, want lookup error")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/net/http/httpguts"
+)
+
+// allowedResponseHeaders are the headers links may add to their redirects: indexing and caching
+// directives. Headers that change how browsers treat the short domain (cookies, CSP, CORS, ...) aren't allowed.
+var allowedResponseHeaders = map[string]bool{
+	"Cache-Control":     true,
+	"Cdn-Cache-Control": true,
+	"Expires":           true,
+	"Link":              true,
+	"Surrogate-Control": true,
+	"X-Robots-Tag":      true,
+}
+
+const (
+	maxResponseHeaders        = 10
+	maxResponseHeaderValueLen = 1000
+)
+
+// AllowedResponseHeaders returns the names of the headers links may set, sorted
+func AllowedResponseHeaders() []string {
+	names := make([]string, 0, len(allowedResponseHeaders))
+	for name := range allowedResponseHeaders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateResponseHeaders checks the headers against the allowlist and returns them with canonical names
+func validateResponseHeaders(headers map[string]string) (map[string]string, error) {
+	if len(headers) == 0 || len(headers) > maxResponseHeaders {
+		return nil, fmt.Errorf("%w: between 1 and %d headers are allowed", apperrors.InvalidResponseHeader, maxResponseHeaders)
+	}
+
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		key := http.CanonicalHeaderKey(name)
+		if !allowedResponseHeaders[key] {
+			return nil, fmt.Errorf("%w: %q isn't allowed", apperrors.InvalidResponseHeader, name)
+		}
+		if _, dup := canonical[key]; dup {
+			return nil, fmt.Errorf("%w: %q is set twice", apperrors.InvalidResponseHeader, key)
+		}
+		if value == "" || len(value) > maxResponseHeaderValueLen || !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("%w: invalid value for %q", apperrors.InvalidResponseHeader, key)
+		}
+		canonical[key] = value
+	}
+	return canonical, nil
+}
+
+/*
+SetResponseHeaders replaces the extra headers sent with one of the user's links'
+redirects, e.g. X-Robots-Tag: noindex or Cache-Control directives. Only the
+headers in allowedResponseHeaders can be set; names are stored canonicalized.
+*/
+func (s *LinkService) SetResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID, headers map[string]string) (db.LinkResponseHeader, error) {
+	headers, err := validateResponseHeaders(headers)
+	if err != nil {
+		return db.LinkResponseHeader{}, err
+	}
+
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkResponseHeader{}, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return db.LinkResponseHeader{}, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return db.LinkResponseHeader{}, fmt.Errorf("failed to encode response headers: %w", err)
+	}
+
+	stored, err := s.queries.UpsertLinkResponseHeaders(ctx, db.UpsertLinkResponseHeadersParams{
+		LinkID:  linkID,
+		Headers: encoded,
+	})
+	if err != nil {
+		return db.LinkResponseHeader{}, fmt.Errorf("failed to store response headers: %w", err)
+	}
+
+	// Cached redirects don't carry the headers
+	s.invalidateCache(ctx, link.Shortcode)
+
+	s.logger.Info("Link response headers set",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+	)
+
+	return stored, nil
+}
+
+// GetResponseHeaders returns the extra redirect headers of one of the user's links
+func (s *LinkService) GetResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkResponseHeader{}, err
+	}
+
+	headers, err := s.queries.GetLinkResponseHeaders(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkResponseHeader{}, fmt.Errorf("%w: %v", apperrors.ResponseHeadersNotFound, err)
+		}
+		return db.LinkResponseHeader{}, fmt.Errorf("failed to get response headers: %w", err)
+	}
+
+	return headers, nil
+}
+
+// DeleteResponseHeaders removes the extra redirect headers of one of the user's links
+func (s *LinkService) DeleteResponseHeaders(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkResponseHeader, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkResponseHeader{}, err
+	}
+
+	headers, err := s.queries.DeleteLinkResponseHeaders(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkResponseHeader{}, fmt.Errorf("%w: %v", apperrors.ResponseHeadersNotFound, err)
+		}
+		return db.LinkResponseHeader{}, fmt.Errorf("failed to delete response headers: %w", err)
+	}
+
+	return headers, nil
+}
+
+// DecodeResponseHeaders decodes stored response headers; nil or invalid JSON decodes to none
+func DecodeResponseHeaders(encoded []byte) map[string]string {
+	if len(encoded) == 0 {
+		return nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(encoded, &headers); err != nil {
+		return nil
+	}
+	return headers
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_SetResponseHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		want        map[string]string
+		expectedErr error
+	}{
+		{
+			name:    "names are canonicalized",
+			headers: map[string]string{"x-robots-tag": "noindex", "cache-control": "public, max-age=300"},
+			want:    map[string]string{"X-Robots-Tag": "noindex", "Cache-Control": "public, max-age=300"},
+		},
+		{name: "header not on the allowlist", headers: map[string]string{"Set-Cookie": "session=1"}, expectedErr: apperrors.InvalidResponseHeader},
+		{name: "header injection", headers: map[string]string{"X-Robots-Tag": "noindex\r\nSet-Cookie: session=1"}, expectedErr: apperrors.InvalidResponseHeader},
+		{name: "empty value", headers: map[string]string{"X-Robots-Tag": ""}, expectedErr: apperrors.InvalidResponseHeader},
+		{name: "same header twice", headers: map[string]string{"X-Robots-Tag": "noindex", "x-robots-tag": "nofollow"}, expectedErr: apperrors.InvalidResponseHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkCache := cache.NewMemory()
+			linkCache.Set(context.Background(), cacheKeyPrefix+"docs", "https://example.com/docs", cacheTTL)
+
+			var stored *db.UpsertLinkResponseHeadersParams
+			mockQueries := &mocks.LinkQueries{
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					return db.GetLinkByIdAndUserRow{ID: arg.ID, Shortcode: "docs"}, nil
+				},
+				UpsertLinkResponseHeadersFunc: func(ctx context.Context, arg db.UpsertLinkResponseHeadersParams) (db.LinkResponseHeader, error) {
+					stored = &arg
+					return db.LinkResponseHeader{LinkID: arg.LinkID, Headers: arg.Headers}, nil
+				},
+			}
+			service := &LinkService{
+				queries: mockQueries,
+				cache:   linkCache,
+				logger:  createTestLogger(),
+			}
+
+			_, err := service.SetResponseHeaders(context.Background(), "user_123", uuid.New(), tt.headers)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("SetResponseHeaders() error = %v, want %v", err, tt.expectedErr)
+				}
+				if stored != nil {
+					t.Error("SetResponseHeaders() stored headers despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetResponseHeaders() unexpected error = %v", err)
+			}
+			got := DecodeResponseHeaders(stored.Headers)
+			if len(got) != len(tt.want) {
+				t.Fatalf("SetResponseHeaders() stored %v, want %v", got, tt.want)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("SetResponseHeaders() stored %s = %q, want %q", name, got[name], value)
+				}
+			}
+			if _, err := linkCache.Get(context.Background(), cacheKeyPrefix+"docs"); !errors.Is(err, cache.ErrMiss) {
+				t.Error("SetResponseHeaders() left the redirect cached")
+			}
+		})
+	}
+}
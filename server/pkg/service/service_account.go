@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// How long a rotated-out token keeps working, unless the rotation says otherwise
+	DefaultServiceAccountTokenGrace = 24 * time.Hour
+	// Longest a rotated-out token can be kept
+	MaxServiceAccountTokenGrace = 7 * 24 * time.Hour
+)
+
+/*
+ServiceAccountService manages service accounts: credentials admins hand to CI
+systems and integrations instead of a user's session. An account works on its
+owner's links, limited to the scopes it was granted (see auth.Scopes). Its
+token is stored only as a SHA-256 hash and shown once, when it's created.
+*/
+type ServiceAccountService struct {
+	queries repository.ServiceAccountQueries
+	logger  logger.Logger
+}
+
+func NewServiceAccountService(queries repository.ServiceAccountQueries, logger logger.Logger) *ServiceAccountService {
+	return &ServiceAccountService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Create creates a service account acting for ownerID and returns it with its token, which can't be retrieved later
+func (s *ServiceAccountService) Create(ctx context.Context, adminID string, ownerID string, name string, scopes []string) (db.ServiceAccount, string, error) {
+	token, err := newSecretToken(auth.ServiceAccountTokenPrefix)
+	if err != nil {
+		return db.ServiceAccount{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	account, err := s.queries.CreateServiceAccount(ctx, db.CreateServiceAccountParams{
+		OwnerID:   ownerID,
+		Name:      name,
+		Scopes:    normalizeScopes(scopes),
+		TokenHash: hashSecretToken(token),
+		CreatedBy: adminID,
+	})
+	if err != nil {
+		return db.ServiceAccount{}, "", fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	s.logger.Info("Service account created",
+		zap.String("admin_id", adminID),
+		zap.String("service_account_id", account.ID.String()),
+		zap.String("owner_id", ownerID),
+		zap.Strings("scopes", account.Scopes),
+	)
+
+	return account, token, nil
+}
+
+// List returns every service account, or only the ones of ownerID when it's set
+func (s *ServiceAccountService) List(ctx context.Context, ownerID *string) ([]db.ServiceAccount, error) {
+	accounts, err := s.queries.ListServiceAccounts(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// Get returns a service account, with what's recorded about its use
+func (s *ServiceAccountService) Get(ctx context.Context, id uuid.UUID) (db.ServiceAccount, error) {
+	account, err := s.queries.GetServiceAccount(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ServiceAccount{}, fmt.Errorf("%w: %v", apperrors.ServiceAccountNotFound, err)
+		}
+		return db.ServiceAccount{}, fmt.Errorf("failed to get service account: %w", err)
+	}
+
+	return account, nil
+}
+
+// Update renames a service account or replaces its scopes; nil leaves a field unchanged
+func (s *ServiceAccountService) Update(ctx context.Context, adminID string, id uuid.UUID, name *string, scopes []string) (db.ServiceAccount, error) {
+	if scopes != nil {
+		scopes = normalizeScopes(scopes)
+	}
+
+	account, err := s.queries.UpdateServiceAccount(ctx, db.UpdateServiceAccountParams{
+		Name:   name,
+		Scopes: scopes,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ServiceAccount{}, fmt.Errorf("%w: %v", apperrors.ServiceAccountNotFound, err)
+		}
+		return db.ServiceAccount{}, fmt.Errorf("failed to update service account: %w", err)
+	}
+
+	s.logger.Info("Service account updated",
+		zap.String("admin_id", adminID),
+		zap.String("service_account_id", id.String()),
+		zap.Strings("scopes", account.Scopes),
+	)
+
+	return account, nil
+}
+
+/*
+RotateToken gives the service account a new token and returns it. The current
+one keeps working for grace (at most MaxServiceAccountTokenGrace), so clients
+can switch without downtime; a zero grace revokes it right away. A token
+rotated out earlier is revoked.
+*/
+func (s *ServiceAccountService) RotateToken(ctx context.Context, adminID string, id uuid.UUID, grace time.Duration) (db.ServiceAccount, string, error) {
+	grace = min(max(grace, 0), MaxServiceAccountTokenGrace)
+
+	token, err := newSecretToken(auth.ServiceAccountTokenPrefix)
+	if err != nil {
+		return db.ServiceAccount{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	account, err := s.queries.RotateServiceAccountToken(ctx, db.RotateServiceAccountTokenParams{
+		PreviousTokenExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(grace), Valid: true},
+		TokenHash:              hashSecretToken(token),
+		ID:                     id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ServiceAccount{}, "", fmt.Errorf("%w: %v", apperrors.ServiceAccountNotFound, err)
+		}
+		return db.ServiceAccount{}, "", fmt.Errorf("failed to rotate service account token: %w", err)
+	}
+
+	s.logger.Info("Service account token rotated",
+		zap.String("admin_id", adminID),
+		zap.String("service_account_id", id.String()),
+		zap.Duration("grace", grace),
+	)
+
+	return account, token, nil
+}
+
+// RevokePreviousToken revokes the token replaced by the last rotation before its grace period ends
+func (s *ServiceAccountService) RevokePreviousToken(ctx context.Context, adminID string, id uuid.UUID) (db.ServiceAccount, error) {
+	account, err := s.queries.RevokePreviousServiceAccountToken(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ServiceAccount{}, fmt.Errorf("%w: %v", apperrors.ServiceAccountNotFound, err)
+		}
+		return db.ServiceAccount{}, fmt.Errorf("failed to revoke previous service account token: %w", err)
+	}
+
+	s.logger.Info("Previous service account token revoked",
+		zap.String("admin_id", adminID),
+		zap.String("service_account_id", id.String()),
+	)
+
+	return account, nil
+}
+
+// Delete deletes a service account; its token stops working immediately
+func (s *ServiceAccountService) Delete(ctx context.Context, adminID string, id uuid.UUID) (db.ServiceAccount, error) {
+	account, err := s.queries.DeleteServiceAccount(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ServiceAccount{}, fmt.Errorf("%w: %v", apperrors.ServiceAccountNotFound, err)
+		}
+		return db.ServiceAccount{}, fmt.Errorf("failed to delete service account: %w", err)
+	}
+
+	s.logger.Info("Service account deleted",
+		zap.String("admin_id", adminID),
+		zap.String("service_account_id", id.String()),
+	)
+
+	return account, nil
+}
+
+/*
+Authenticate resolves the service account a token belongs to, its current
+token or one rotated out that's still in its grace period, and records the use
+and the client's address. It's InvalidServiceAccountToken if there's none.
+*/
+func (s *ServiceAccountService) Authenticate(ctx context.Context, token string, clientIP netip.Addr) (db.ServiceAccount, error) {
+	if !strings.HasPrefix(token, auth.ServiceAccountTokenPrefix) {
+		return db.ServiceAccount{}, apperrors.InvalidServiceAccountToken
+	}
+
+	var ip *string
+	if clientIP.IsValid() {
+		addr := clientIP.String()
+		ip = &addr
+	}
+
+	account, err := s.queries.UseServiceAccount(ctx, db.UseServiceAccountParams{
+		Ip:        ip,
+		TokenHash: hashSecretToken(token),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ServiceAccount{}, apperrors.InvalidServiceAccountToken
+		}
+		return db.ServiceAccount{}, fmt.Errorf("failed to get service account: %w", err)
+	}
+
+	return account, nil
+}
+
+// normalizeScopes sorts scopes and drops duplicates
+func normalizeScopes(scopes []string) []string {
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
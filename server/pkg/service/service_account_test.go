@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/auth"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestServiceAccountService_CreateAndAuthenticate(t *testing.T) {
+	accounts := map[string]db.ServiceAccount{}
+	queries := &mocks.ServiceAccountQueries{
+		CreateServiceAccountFunc: func(ctx context.Context, arg db.CreateServiceAccountParams) (db.ServiceAccount, error) {
+			account := db.ServiceAccount{ID: uuid.New(), OwnerID: arg.OwnerID, Name: arg.Name, Scopes: arg.Scopes, TokenHash: arg.TokenHash, CreatedBy: arg.CreatedBy}
+			accounts[arg.TokenHash] = account
+			return account, nil
+		},
+		UseServiceAccountFunc: func(ctx context.Context, arg db.UseServiceAccountParams) (db.ServiceAccount, error) {
+			account, ok := accounts[arg.TokenHash]
+			if !ok {
+				return db.ServiceAccount{}, sql.ErrNoRows
+			}
+			account.LastUsedIp = arg.Ip
+			return account, nil
+		},
+	}
+	s := NewServiceAccountService(queries, createTestLogger())
+	ctx := context.Background()
+
+	account, token, err := s.Create(ctx, "admin_1", "user_1", "CI", []string{auth.ScopeStatsRead, auth.ScopeLinksCreate, auth.ScopeStatsRead})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(token, auth.ServiceAccountTokenPrefix) {
+		t.Errorf("token = %q, want the %q prefix", token, auth.ServiceAccountTokenPrefix)
+	}
+	if _, stored := accounts[token]; stored {
+		t.Error("the token was stored in plain text")
+	}
+	if want := []string{auth.ScopeLinksCreate, auth.ScopeStatsRead}; !slices.Equal(account.Scopes, want) {
+		t.Errorf("scopes = %v, want %v", account.Scopes, want)
+	}
+
+	got, err := s.Authenticate(ctx, token, netip.MustParseAddr("203.0.113.7"))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if got.ID != account.ID || got.OwnerID != "user_1" {
+		t.Errorf("Authenticate() = %+v, want the created account", got)
+	}
+	if got.LastUsedIp == nil || *got.LastUsedIp != "203.0.113.7" {
+		t.Errorf("last used IP = %v, want 203.0.113.7", got.LastUsedIp)
+	}
+
+	for _, bad := range []string{token + "x", "ph_" + strings.TrimPrefix(token, auth.ServiceAccountTokenPrefix), ""} {
+		if _, err := s.Authenticate(ctx, bad, netip.Addr{}); !errors.Is(err, apperrors.InvalidServiceAccountToken) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", bad, err, apperrors.InvalidServiceAccountToken)
+		}
+	}
+}
+
+func TestServiceAccountService_NotFound(t *testing.T) {
+	queries := &mocks.ServiceAccountQueries{
+		UpdateServiceAccountFunc: func(ctx context.Context, arg db.UpdateServiceAccountParams) (db.ServiceAccount, error) {
+			return db.ServiceAccount{}, sql.ErrNoRows
+		},
+		DeleteServiceAccountFunc: func(ctx context.Context, id uuid.UUID) (db.ServiceAccount, error) {
+			return db.ServiceAccount{}, sql.ErrNoRows
+		},
+	}
+	s := NewServiceAccountService(queries, createTestLogger())
+	ctx := context.Background()
+
+	if _, err := s.Update(ctx, "admin_1", uuid.New(), nil, []string{auth.ScopeLinksRead}); !errors.Is(err, apperrors.ServiceAccountNotFound) {
+		t.Errorf("Update() error = %v, want %v", err, apperrors.ServiceAccountNotFound)
+	}
+	if _, err := s.Delete(ctx, "admin_1", uuid.New()); !errors.Is(err, apperrors.ServiceAccountNotFound) {
+		t.Errorf("Delete() error = %v, want %v", err, apperrors.ServiceAccountNotFound)
+	}
+}
+
+// A rotated-out token keeps working through its grace period, next to the new one
+func TestServiceAccountService_RotateToken(t *testing.T) {
+	account := db.ServiceAccount{ID: uuid.New(), OwnerID: "user_1"}
+	queries := &mocks.ServiceAccountQueries{
+		CreateServiceAccountFunc: func(ctx context.Context, arg db.CreateServiceAccountParams) (db.ServiceAccount, error) {
+			account.TokenHash = arg.TokenHash
+			return account, nil
+		},
+		RotateServiceAccountTokenFunc: func(ctx context.Context, arg db.RotateServiceAccountTokenParams) (db.ServiceAccount, error) {
+			if arg.ID != account.ID {
+				return db.ServiceAccount{}, sql.ErrNoRows
+			}
+			previous := account.TokenHash
+			account.PreviousTokenHash = &previous
+			account.PreviousTokenExpiresAt = arg.PreviousTokenExpiresAt
+			account.TokenHash = arg.TokenHash
+			return account, nil
+		},
+		UseServiceAccountFunc: func(ctx context.Context, arg db.UseServiceAccountParams) (db.ServiceAccount, error) {
+			current := arg.TokenHash == account.TokenHash
+			previous := account.PreviousTokenHash != nil && arg.TokenHash == *account.PreviousTokenHash &&
+				account.PreviousTokenExpiresAt.Time.After(time.Now())
+			if !current && !previous {
+				return db.ServiceAccount{}, sql.ErrNoRows
+			}
+			return account, nil
+		},
+	}
+	s := NewServiceAccountService(queries, createTestLogger())
+	ctx := context.Background()
+
+	_, original, err := s.Create(ctx, "admin_1", "user_1", "CI", []string{auth.ScopeLinksRead})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	_, rotated, err := s.RotateToken(ctx, "admin_1", account.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("RotateToken() error = %v", err)
+	}
+	if rotated == original {
+		t.Fatal("RotateToken() returned the same token")
+	}
+	for name, token := range map[string]string{"new": rotated, "previous": original} {
+		if _, err := s.Authenticate(ctx, token, netip.Addr{}); err != nil {
+			t.Errorf("Authenticate() with the %s token during the grace period error = %v", name, err)
+		}
+	}
+
+	// A zero grace revokes the current token with the rotation
+	_, latest, err := s.RotateToken(ctx, "admin_1", account.ID, 0)
+	if err != nil {
+		t.Fatalf("RotateToken() error = %v", err)
+	}
+	if _, err := s.Authenticate(ctx, rotated, netip.Addr{}); !errors.Is(err, apperrors.InvalidServiceAccountToken) {
+		t.Errorf("Authenticate() with a token rotated out without grace error = %v, want %v", err, apperrors.InvalidServiceAccountToken)
+	}
+	if _, err := s.Authenticate(ctx, original, netip.Addr{}); !errors.Is(err, apperrors.InvalidServiceAccountToken) {
+		t.Errorf("Authenticate() with a token rotated out twice error = %v, want %v", err, apperrors.InvalidServiceAccountToken)
+	}
+	if _, err := s.Authenticate(ctx, latest, netip.Addr{}); err != nil {
+		t.Errorf("Authenticate() with the latest token error = %v", err)
+	}
+
+	// The grace period is capped
+	before := time.Now()
+	if _, _, err := s.RotateToken(ctx, "admin_1", account.ID, 30*24*time.Hour); err != nil {
+		t.Fatalf("RotateToken() error = %v", err)
+	}
+	if expires := account.PreviousTokenExpiresAt.Time; expires.After(before.Add(MaxServiceAccountTokenGrace).Add(time.Minute)) {
+		t.Errorf("previous token expires at %v, want at most %v after rotation", expires, MaxServiceAccountTokenGrace)
+	}
+
+	if _, _, err := s.RotateToken(ctx, "admin_1", uuid.New(), time.Hour); !errors.Is(err, apperrors.ServiceAccountNotFound) {
+		t.Errorf("RotateToken() of an unknown account error = %v, want %v", err, apperrors.ServiceAccountNotFound)
+	}
+}
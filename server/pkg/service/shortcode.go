@@ -0,0 +1,35 @@
+package service
+
+import "strings"
+
+// reservedShortcodes are path segments used by the server itself.
+// They can never be used as custom shortcodes, otherwise a link could
+// shadow a route when redirects and the API share a host.
+var reservedShortcodes = map[string]struct{}{
+	"api":          {},
+	"debug":        {},
+	"health":       {},
+	"integrations": {},
+	"metrics":      {},
+	"ready":        {},
+	"favicon.ico":  {},
+	"robots.txt":   {},
+	".well-known":  {},
+	// Fetched by browsers and crawlers on their own
+	"sitemap.xml":       {},
+	"browserconfig.xml": {},
+	"site.webmanifest":  {},
+}
+
+// Prefix of the icons iOS requests in several sizes (apple-touch-icon-180x180.png, ...)
+const appleTouchIconPrefix = "apple-touch-icon"
+
+// IsReservedShortcode reports whether the shortcode collides with a server route
+func IsReservedShortcode(code string) bool {
+	code = strings.ToLower(code)
+	if strings.HasPrefix(code, appleTouchIconPrefix) {
+		return true
+	}
+	_, ok := reservedShortcodes[code]
+	return ok
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+/*
+ShortcodeReservationService holds shortcodes for users before their links exist,
+e.g. to print QR codes before the landing page is live. Until a link is created
+with the shortcode, its short URL shows a placeholder page. Creating the link
+(or renaming one of the user's links to it) consumes the reservation.
+*/
+type ShortcodeReservationService struct {
+	queries repository.ShortcodeReservationQueries
+	logger  logger.Logger
+}
+
+func NewShortcodeReservationService(queries repository.ShortcodeReservationQueries, logger logger.Logger) *ShortcodeReservationService {
+	return &ShortcodeReservationService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Reserve holds the given shortcode for the user, or a random one when shortcode is nil
+func (s *ShortcodeReservationService) Reserve(ctx context.Context, userID string, shortcode *string) (db.ShortcodeReservation, error) {
+	if shortcode != nil {
+		if IsReservedShortcode(*shortcode) {
+			return db.ShortcodeReservation{},
+				fmt.Errorf("%w: %s", apperrors.ShortcodeReserved, *shortcode)
+		}
+
+		reservation, err := s.queries.ReserveShortcode(ctx, db.ReserveShortcodeParams{
+			Shortcode: *shortcode,
+			UserID:    userID,
+		})
+		if err != nil {
+			// No row: a link or another reservation already holds the shortcode
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ShortcodeReservation{},
+					fmt.Errorf("%w: %s", apperrors.LinkShortcodeTaken, *shortcode)
+			}
+			return db.ShortcodeReservation{}, fmt.Errorf("failed to reserve shortcode: %w", err)
+		}
+
+		s.logReserved(userID, reservation.Shortcode)
+		return reservation, nil
+	}
+
+	// Same length and retry budget as links with generated shortcodes
+	const (
+		codeLen     = 9
+		maxAttempts = 3
+	)
+
+	for range maxAttempts {
+		code, err := generateRandomCode(codeLen)
+		if err != nil {
+			return db.ShortcodeReservation{}, fmt.Errorf("failed to generate short code: %w", err)
+		}
+
+		reservation, err := s.queries.ReserveShortcode(ctx, db.ReserveShortcodeParams{
+			Shortcode: code,
+			UserID:    userID,
+		})
+		if err == nil {
+			s.logReserved(userID, reservation.Shortcode)
+			return reservation, nil
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+
+		return db.ShortcodeReservation{}, fmt.Errorf("failed to reserve shortcode: %w", err)
+	}
+
+	return db.ShortcodeReservation{},
+		fmt.Errorf("failed to reserve shortcode after %d attempts: code collision retry limit exceeded", maxAttempts)
+}
+
+// ListReservations returns the user's reserved shortcodes that have no link yet, newest first
+func (s *ShortcodeReservationService) ListReservations(ctx context.Context, userID string) ([]db.ShortcodeReservation, error) {
+	reservations, err := s.queries.ListUserShortcodeReservations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shortcode reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// Release gives up one of the user's reservations, freeing the shortcode for anyone
+func (s *ShortcodeReservationService) Release(ctx context.Context, userID string, shortcode string) (db.ShortcodeReservation, error) {
+	reservation, err := s.queries.DeleteShortcodeReservation(ctx, db.DeleteShortcodeReservationParams{
+		Shortcode: shortcode,
+		UserID:    userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ShortcodeReservation{}, fmt.Errorf("%w: %v", apperrors.ReservationNotFound, err)
+		}
+		return db.ShortcodeReservation{}, fmt.Errorf("failed to release shortcode reservation: %w", err)
+	}
+
+	s.logger.Info("Shortcode reservation released",
+		zap.String("user_id", userID),
+		zap.String("shortcode", shortcode),
+	)
+
+	return reservation, nil
+}
+
+func (s *ShortcodeReservationService) logReserved(userID string, shortcode string) {
+	s.logger.Info("Shortcode reserved",
+		zap.String("user_id", userID),
+		zap.String("shortcode", shortcode),
+	)
+}
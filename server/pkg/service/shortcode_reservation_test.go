@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+type mockReservationQueries struct {
+	// Shortcodes already held by a link or another reservation
+	taken    map[string]bool
+	reserved []string
+}
+
+func (m *mockReservationQueries) ReserveShortcode(ctx context.Context, arg db.ReserveShortcodeParams) (db.ShortcodeReservation, error) {
+	if m.taken[arg.Shortcode] {
+		return db.ShortcodeReservation{}, sql.ErrNoRows
+	}
+	m.reserved = append(m.reserved, arg.Shortcode)
+	return db.ShortcodeReservation{Shortcode: arg.Shortcode, UserID: arg.UserID}, nil
+}
+
+func (m *mockReservationQueries) ListUserShortcodeReservations(ctx context.Context, userID string) ([]db.ShortcodeReservation, error) {
+	return nil, nil
+}
+
+func (m *mockReservationQueries) DeleteShortcodeReservation(ctx context.Context, arg db.DeleteShortcodeReservationParams) (db.ShortcodeReservation, error) {
+	return db.ShortcodeReservation{}, sql.ErrNoRows
+}
+
+func TestShortcodeReservationService_Reserve(t *testing.T) {
+	ctx := context.Background()
+	spring := "spring"
+	api := "API"
+
+	tests := []struct {
+		name        string
+		shortcode   *string
+		taken       map[string]bool
+		expectedErr error
+	}{
+		{name: "custom shortcode", shortcode: &spring},
+		{name: "random shortcode"},
+		{name: "taken shortcode", shortcode: &spring, taken: map[string]bool{"spring": true}, expectedErr: apperrors.LinkShortcodeTaken},
+		{name: "system shortcode", shortcode: &api, expectedErr: apperrors.ShortcodeReserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := &mockReservationQueries{taken: tt.taken}
+			s := NewShortcodeReservationService(queries, createTestLogger())
+
+			reservation, err := s.Reserve(ctx, "user_123", tt.shortcode)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Reserve() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Reserve() error = %v, want nil", err)
+			}
+			if tt.shortcode != nil && reservation.Shortcode != *tt.shortcode {
+				t.Errorf("Reserve() shortcode = %q, want %q", reservation.Shortcode, *tt.shortcode)
+			}
+			if tt.shortcode == nil && len(reservation.Shortcode) != 9 {
+				t.Errorf("Reserve() shortcode = %q, want a random 9 character code", reservation.Shortcode)
+			}
+		})
+	}
+}
+
+func TestShortcodeReservationService_Release(t *testing.T) {
+	s := NewShortcodeReservationService(&mockReservationQueries{}, createTestLogger())
+
+	_, err := s.Release(context.Background(), "user_123", "spring")
+	if !errors.Is(err, apperrors.ReservationNotFound) {
+		t.Errorf("Release() error = %v, want %v", err, apperrors.ReservationNotFound)
+	}
+}
+
+func TestLinkService_GetOriginalURL_Reserved(t *testing.T) {
+	mockQueries := &mocks.LinkQueries{
+		GetLinkForRedirectFunc: func(ctx context.Context, code string) (db.GetLinkForRedirectRow, error) {
+			return db.GetLinkForRedirectRow{}, sql.ErrNoRows
+		},
+		GetShortcodeReservationFunc: func(ctx context.Context, shortcode string) (db.ShortcodeReservation, error) {
+			return db.ShortcodeReservation{Shortcode: shortcode, UserID: "user_123"}, nil
+		},
+	}
+	service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+	_, err := service.GetOriginalURL(context.Background(), "spring")
+	if !errors.Is(err, apperrors.LinkPending) {
+		t.Errorf("GetOriginalURL() error = %v, want %v", err, apperrors.LinkPending)
+	}
+}
+
+func TestLinkService_UpdateLink_ReservedShortcode(t *testing.T) {
+	shortcode := "spring"
+
+	tests := []struct {
+		name        string
+		reservedBy  string
+		expectedErr error
+	}{
+		{name: "own reservation is attached", reservedBy: "user_123"},
+		{name: "another user's reservation", reservedBy: "user_456", expectedErr: apperrors.LinkShortcodeTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := false
+			mockQueries := &mocks.LinkQueries{
+				GetShortcodeReservationFunc: func(ctx context.Context, code string) (db.ShortcodeReservation, error) {
+					return db.ShortcodeReservation{Shortcode: code, UserID: tt.reservedBy}, nil
+				},
+				UpdateLinkFunc: func(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
+					updated = true
+					return createTestUpdateLinkRow(arg.ID, *arg.Shortcode, "https://example.com", true), nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			_, err := service.UpdateLink(context.Background(), "user_123", uuid.New(), &shortcode, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("UpdateLink() error = %v, want %v", err, tt.expectedErr)
+				}
+				if updated {
+					t.Error("UpdateLink() updated the link despite the reservation")
+				}
+				return
+			}
+			if err != nil || !updated {
+				t.Errorf("UpdateLink() error = %v, updated = %v; want the link updated", err, updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/netutil"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/validation"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key prefix for per-client counters of 404 pages with suggestions
+	suggestionRateKeyPrefix = "suggest:rate:"
+	// Characters custom shortcodes are made of (see validation.CheckShortcode)
+	shortcodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	// Suggestions shown on one 404 page
+	maxShortcodeSuggestions = 3
+)
+
+type ShortcodeSuggesterOptions struct {
+	// Suggestions are off unless enabled
+	Enabled bool
+	// Also suggest other users' public links, not only the visitor's own
+	IncludePublic bool
+	// 404 pages with suggestions one client can get per RateWindow
+	RateLimit  int64
+	RateWindow time.Duration
+	// Proxies whose X-Forwarded-For is trusted to identify the client
+	TrustedProxies []netip.Prefix
+}
+
+/*
+ShortcodeSuggester finds the links a visitor probably meant when a shortcode
+isn't found: the live ones whose shortcode is at edit distance 1 from it (one
+character added, removed or replaced). The 404 page shows them as "did you
+mean" suggestions.
+
+Suggestions tell visitors which shortcodes exist, so they're off by default,
+only cover the visitor's own links unless IncludePublic is set, and each
+client gets at most RateLimit pages with suggestions per RateWindow. Without
+Redis there's no rate limit to enforce, so there are no suggestions either, and
+Redis errors fail closed.
+*/
+type ShortcodeSuggester struct {
+	queries repository.ShortcodeSuggestionQueries
+	cache   *redis.Client
+	opts    ShortcodeSuggesterOptions
+	logger  logger.Logger
+}
+
+func NewShortcodeSuggester(queries repository.ShortcodeSuggestionQueries, cache *redis.Client, opts ShortcodeSuggesterOptions, logger logger.Logger) *ShortcodeSuggester {
+	return &ShortcodeSuggester{
+		queries: queries,
+		cache:   cache,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Enabled reports whether suggestions are turned on and can be rate limited
+func (s *ShortcodeSuggester) Enabled() bool {
+	return s != nil && s.opts.Enabled && s.cache != nil
+}
+
+// ClientIP returns the address the rate limit applies to
+func (s *ShortcodeSuggester) ClientIP(r *http.Request) netip.Addr {
+	return netutil.ClientIP(r, s.opts.TrustedProxies)
+}
+
+// Suggest returns the links the client may have meant instead of shortcode, none once
+// it's over the rate limit. viewerID is the signed-in visitor, empty for anonymous ones.
+func (s *ShortcodeSuggester) Suggest(ctx context.Context, addr netip.Addr, shortcode string, viewerID string) ([]db.ListShortcodeSuggestionsRow, error) {
+	if !s.Enabled() || validation.CheckShortcode(shortcode) != nil {
+		return nil, nil
+	}
+	// Anonymous visitors have no links of their own
+	if viewerID == "" && !s.opts.IncludePublic {
+		return nil, nil
+	}
+
+	if s.overRate(ctx, addr) {
+		return nil, nil
+	}
+
+	suggestions, err := s.queries.ListShortcodeSuggestions(ctx, db.ListShortcodeSuggestionsParams{
+		Candidates:    shortcodeNeighbors(shortcode),
+		ViewerID:      viewerID,
+		IncludePublic: s.opts.IncludePublic,
+		RowLimit:      maxShortcodeSuggestions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shortcode suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// overRate counts a lookup for the client and reports whether it went over the rate limit
+func (s *ShortcodeSuggester) overRate(ctx context.Context, addr netip.Addr) bool {
+	if s.opts.RateLimit <= 0 {
+		return false
+	}
+
+	key := suggestionRateKeyPrefix + netutil.RateLimitKey(addr)
+
+	pipe := s.cache.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	// Fixed window, like the enumeration guard
+	pipe.ExpireNX(ctx, key, s.opts.RateWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.Warn("Failed to count shortcode suggestion lookup",
+			zap.Error(err),
+		)
+		return true
+	}
+
+	return incr.Val() > s.opts.RateLimit
+}
+
+// shortcodeNeighbors returns the valid shortcodes at edit distance 1 from code
+func shortcodeNeighbors(code string) []string {
+	seen := make(map[string]struct{})
+	neighbors := make([]string, 0, (2*len(shortcodeAlphabet)+1)*(len(code)+1))
+	add := func(candidate string) {
+		if candidate == "" || candidate == code || len(candidate) > validation.MaxShortcodeLength {
+			return
+		}
+		if _, ok := seen[candidate]; ok {
+			return
+		}
+		seen[candidate] = struct{}{}
+		neighbors = append(neighbors, candidate)
+	}
+
+	for i := 0; i <= len(code); i++ {
+		for _, c := range []byte(shortcodeAlphabet) {
+			add(code[:i] + string(c) + code[i:])
+			if i < len(code) {
+				add(code[:i] + string(c) + code[i+1:])
+			}
+		}
+		if i < len(code) {
+			add(code[:i] + code[i+1:])
+		}
+	}
+
+	return neighbors
+}
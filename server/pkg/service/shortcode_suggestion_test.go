@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestShortcodeNeighbors(t *testing.T) {
+	neighbors := shortcodeNeighbors("docs")
+
+	for _, want := range []string{"doc", "dos", "docs1", "xdocs", "dogs", "Docs"} {
+		if !slices.Contains(neighbors, want) {
+			t.Errorf("shortcodeNeighbors(docs) is missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"docs", "odcs", "do", "documents"} {
+		if slices.Contains(neighbors, unwanted) {
+			t.Errorf("shortcodeNeighbors(docs) contains %q", unwanted)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, n := range neighbors {
+		if seen[n] {
+			t.Fatalf("shortcodeNeighbors(docs) contains %q twice", n)
+		}
+		seen[n] = true
+	}
+
+	if got := shortcodeNeighbors("a"); slices.Contains(got, "") {
+		t.Error("shortcodeNeighbors(a) contains the empty shortcode")
+	}
+}
+
+func TestShortcodeSuggester_Suggest(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          ShortcodeSuggesterOptions
+		shortcode     string
+		viewerID      string
+		lookups       int
+		wantQueried   bool
+		wantSuggested bool
+	}{
+		{
+			name:          "public links for anonymous visitors",
+			opts:          ShortcodeSuggesterOptions{Enabled: true, IncludePublic: true},
+			shortcode:     "doc",
+			lookups:       1,
+			wantQueried:   true,
+			wantSuggested: true,
+		},
+		{
+			name:          "own links for signed-in visitors",
+			opts:          ShortcodeSuggesterOptions{Enabled: true},
+			shortcode:     "doc",
+			viewerID:      "user_123",
+			lookups:       1,
+			wantQueried:   true,
+			wantSuggested: true,
+		},
+		{
+			name:      "nothing to suggest to anonymous visitors without public links",
+			opts:      ShortcodeSuggesterOptions{Enabled: true},
+			shortcode: "doc",
+			lookups:   1,
+		},
+		{
+			name:      "disabled",
+			opts:      ShortcodeSuggesterOptions{IncludePublic: true},
+			shortcode: "doc",
+			lookups:   1,
+		},
+		{
+			name:      "not a shortcode",
+			opts:      ShortcodeSuggesterOptions{Enabled: true, IncludePublic: true},
+			shortcode: "wp-login.php",
+			lookups:   1,
+		},
+		{
+			name:        "over the rate limit",
+			opts:        ShortcodeSuggesterOptions{Enabled: true, IncludePublic: true, RateLimit: 2, RateWindow: time.Minute},
+			shortcode:   "doc",
+			lookups:     3,
+			wantQueried: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+
+			var asked *db.ListShortcodeSuggestionsParams
+			queries := &mocks.ShortcodeSuggestionQueries{
+				ListShortcodeSuggestionsFunc: func(ctx context.Context, arg db.ListShortcodeSuggestionsParams) ([]db.ListShortcodeSuggestionsRow, error) {
+					asked = &arg
+					return []db.ListShortcodeSuggestionsRow{{Shortcode: "docs", OriginalUrl: "https://example.com/docs"}}, nil
+				},
+			}
+			suggester := NewShortcodeSuggester(queries, redis.NewClient(&redis.Options{Addr: mr.Addr()}), tt.opts, createTestLogger())
+
+			var suggestions []db.ListShortcodeSuggestionsRow
+			for range tt.lookups {
+				var err error
+				suggestions, err = suggester.Suggest(context.Background(), netip.MustParseAddr("192.0.2.1"), tt.shortcode, tt.viewerID)
+				if err != nil {
+					t.Fatalf("Suggest() unexpected error = %v", err)
+				}
+			}
+
+			if (asked != nil) != tt.wantQueried {
+				t.Fatalf("Suggest() queried = %v, want %v", asked != nil, tt.wantQueried)
+			}
+			if (len(suggestions) > 0) != tt.wantSuggested {
+				t.Errorf("Suggest() = %v, want suggestions: %v", suggestions, tt.wantSuggested)
+			}
+			if asked == nil {
+				return
+			}
+			if asked.ViewerID != tt.viewerID || asked.IncludePublic != tt.opts.IncludePublic {
+				t.Errorf("Suggest() asked for viewer %q, public %v", asked.ViewerID, asked.IncludePublic)
+			}
+			if !slices.Contains(asked.Candidates, "docs") {
+				t.Error("Suggest() candidates don't include docs")
+			}
+		})
+	}
+}
+
+func TestShortcodeSuggester_NoRedis(t *testing.T) {
+	suggester := NewShortcodeSuggester(&mocks.ShortcodeSuggestionQueries{}, nil, ShortcodeSuggesterOptions{Enabled: true, IncludePublic: true}, createTestLogger())
+
+	if suggester.Enabled() {
+		t.Error("Enabled() = true without Redis")
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Oldest Slack request timestamp accepted, against replays
+	SlackRequestMaxAge = 5 * time.Minute
+	// How long the account link sent to an unlinked Slack user stays valid
+	SlackLinkTokenTTL = 15 * time.Minute
+)
+
+/*
+SlackService verifies requests from Slack and maps Slack users to accounts.
+
+A Slack user is linked by following a link token sent to them in Slack and
+redeeming it while signed in. Tokens are signed with a key derived from the
+signing secret, so Slack request signatures and link tokens never share a key.
+*/
+type SlackService struct {
+	queries       repository.SlackQueries
+	signingSecret []byte
+	linkKey       []byte
+	logger        logger.Logger
+}
+
+func NewSlackService(queries repository.SlackQueries, signingSecret string, logger logger.Logger) *SlackService {
+	m := hmac.New(sha256.New, []byte(signingSecret))
+	m.Write([]byte("slack-account-link"))
+
+	return &SlackService{
+		queries:       queries,
+		signingSecret: []byte(signingSecret),
+		linkKey:       m.Sum(nil),
+		logger:        logger,
+	}
+}
+
+/*
+VerifyRequest checks Slack's request signature: signature is "v0=" followed by
+the hex HMAC-SHA256 of "v0:<timestamp>:<body>" under the signing secret.
+Requests older than SlackRequestMaxAge are rejected.
+*/
+func (s *SlackService) VerifyRequest(timestamp, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", apperrors.InvalidSignature)
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > SlackRequestMaxAge || age < -SlackRequestMaxAge {
+		return fmt.Errorf("%w: timestamp outside the allowed window", apperrors.InvalidSignature)
+	}
+
+	sig, ok := strings.CutPrefix(signature, "v0=")
+	if !ok {
+		return fmt.Errorf("%w: unsupported signature version", apperrors.InvalidSignature)
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", apperrors.InvalidSignature)
+	}
+
+	m := hmac.New(sha256.New, s.signingSecret)
+	m.Write([]byte("v0:" + timestamp + ":"))
+	m.Write(body)
+	if !hmac.Equal(got, m.Sum(nil)) {
+		return apperrors.InvalidSignature
+	}
+
+	return nil
+}
+
+// AccountUser returns the user a Slack user is linked to, SlackAccountNotLinked if there's none
+func (s *SlackService) AccountUser(ctx context.Context, teamID, slackUserID string) (string, error) {
+	userID, err := s.queries.GetSlackAccountUser(ctx, db.GetSlackAccountUserParams{
+		TeamID:      teamID,
+		SlackUserID: slackUserID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", apperrors.SlackAccountNotLinked
+		}
+		return "", fmt.Errorf("failed to get Slack account: %w", err)
+	}
+
+	return userID, nil
+}
+
+/*
+LinkToken returns a token that links the Slack user to whoever redeems it.
+It has the form "<base64url team:user:expiry>.<base64url HMAC-SHA256>".
+*/
+func (s *SlackService) LinkToken(teamID, slackUserID string, now time.Time) string {
+	payload := teamID + ":" + slackUserID + ":" + strconv.FormatInt(now.Add(SlackLinkTokenTTL).Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.linkMAC(payload))
+}
+
+// LinkAccount redeems a link token, linking its Slack user to userID
+func (s *SlackService) LinkAccount(ctx context.Context, userID string, token string, now time.Time) (db.SlackAccount, error) {
+	teamID, slackUserID, ok := s.parseLinkToken(token, now)
+	if !ok {
+		return db.SlackAccount{}, apperrors.InvalidSlackLinkToken
+	}
+
+	account, err := s.queries.LinkSlackAccount(ctx, db.LinkSlackAccountParams{
+		TeamID:      teamID,
+		SlackUserID: slackUserID,
+		UserID:      userID,
+	})
+	if err != nil {
+		return db.SlackAccount{}, fmt.Errorf("failed to link Slack account: %w", err)
+	}
+
+	s.logger.Info("Slack account linked",
+		zap.String("user_id", userID),
+		zap.String("team_id", teamID),
+		zap.String("slack_user_id", slackUserID),
+	)
+
+	return account, nil
+}
+
+func (s *SlackService) parseLinkToken(token string, now time.Time) (teamID, slackUserID string, ok bool) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.linkMAC(string(payload))) {
+		return "", "", false
+	}
+
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() >= exp {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+func (s *SlackService) linkMAC(payload string) []byte {
+	m := hmac.New(sha256.New, s.linkKey)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
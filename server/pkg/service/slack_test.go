@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type mockSlackQueries struct {
+	accounts map[string]string
+}
+
+func (m *mockSlackQueries) GetSlackAccountUser(ctx context.Context, arg db.GetSlackAccountUserParams) (string, error) {
+	userID, ok := m.accounts[arg.TeamID+"/"+arg.SlackUserID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return userID, nil
+}
+
+func (m *mockSlackQueries) LinkSlackAccount(ctx context.Context, arg db.LinkSlackAccountParams) (db.SlackAccount, error) {
+	m.accounts[arg.TeamID+"/"+arg.SlackUserID] = arg.UserID
+	return db.SlackAccount{TeamID: arg.TeamID, SlackUserID: arg.SlackUserID, UserID: arg.UserID}, nil
+}
+
+func slackSignature(secret, timestamp, body string) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte("v0:" + timestamp + ":" + body))
+	return "v0=" + hex.EncodeToString(m.Sum(nil))
+}
+
+func TestSlackService_VerifyRequest(t *testing.T) {
+	s := NewSlackService(nil, "signing-secret", createTestLogger())
+	now := time.Unix(1_700_000_000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	body := "team_id=T1&user_id=U1&text=https%3A%2F%2Fexample.com"
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      string
+		wantErr   bool
+	}{
+		{name: "valid", timestamp: ts, signature: slackSignature("signing-secret", ts, body), body: body},
+		{name: "tampered body", timestamp: ts, signature: slackSignature("signing-secret", ts, body), body: body + "x", wantErr: true},
+		{name: "wrong secret", timestamp: ts, signature: slackSignature("other", ts, body), body: body, wantErr: true},
+		{name: "replayed", timestamp: "1699999000", signature: slackSignature("signing-secret", "1699999000", body), body: body, wantErr: true},
+		{name: "unsupported version", timestamp: ts, signature: strings.Replace(slackSignature("signing-secret", ts, body), "v0=", "v1=", 1), body: body, wantErr: true},
+		{name: "missing headers", body: body, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.VerifyRequest(tt.timestamp, tt.signature, []byte(tt.body), now)
+			if tt.wantErr && !errors.Is(err, apperrors.InvalidSignature) {
+				t.Errorf("VerifyRequest() error = %v, want InvalidSignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("VerifyRequest() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSlackService_LinkAccount(t *testing.T) {
+	queries := &mockSlackQueries{accounts: map[string]string{}}
+	s := NewSlackService(queries, "signing-secret", createTestLogger())
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := s.AccountUser(ctx, "T1", "U1"); !errors.Is(err, apperrors.SlackAccountNotLinked) {
+		t.Fatalf("AccountUser() error = %v, want SlackAccountNotLinked", err)
+	}
+
+	token := s.LinkToken("T1", "U1", now)
+
+	for name, bad := range map[string]string{
+		"tampered":  strings.Replace(token, token[:4], "AAAA", 1),
+		"other key": NewSlackService(queries, "other", createTestLogger()).LinkToken("T1", "U1", now),
+		"malformed": "not-a-token",
+	} {
+		if _, err := s.LinkAccount(ctx, "user_1", bad, now); !errors.Is(err, apperrors.InvalidSlackLinkToken) {
+			t.Errorf("%s token: LinkAccount() error = %v, want InvalidSlackLinkToken", name, err)
+		}
+	}
+	if _, err := s.LinkAccount(ctx, "user_1", token, now.Add(SlackLinkTokenTTL)); !errors.Is(err, apperrors.InvalidSlackLinkToken) {
+		t.Errorf("expired token: LinkAccount() error = %v, want InvalidSlackLinkToken", err)
+	}
+
+	account, err := s.LinkAccount(ctx, "user_1", token, now)
+	if err != nil {
+		t.Fatalf("LinkAccount() error = %v", err)
+	}
+	if account.TeamID != "T1" || account.SlackUserID != "U1" {
+		t.Errorf("linked %s/%s, want T1/U1", account.TeamID, account.SlackUserID)
+	}
+
+	userID, err := s.AccountUser(ctx, "T1", "U1")
+	if err != nil || userID != "user_1" {
+		t.Errorf("AccountUser() = %q, %v, want user_1", userID, err)
+	}
+}
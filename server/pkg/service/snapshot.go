@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/snapshot"
+	"go.uber.org/zap"
+)
+
+const (
+	// Links read per query of a snapshot
+	snapshotPageSize = 5000
+	// How long before its first read a snapshot's TakenAt is, so the next diff overlaps
+	// the changes still committing while it was taken
+	snapshotClockSlack = 5 * time.Second
+)
+
+/*
+SnapshotService exports the shortcode to URL mappings of the redirect cache, in
+the format of package snapshot: all of them, or a diff of the changes since an
+earlier snapshot. Only links that redirect straight away are mapped (see
+isCacheable), as they're all a redirect cache can serve; diffs delete the
+mappings of links that stopped doing so.
+
+A renamed link's old shortcode isn't in diffs: like the redirect cache's own
+entries, its mapping is left to expire from the cache it was loaded into.
+*/
+type SnapshotService struct {
+	queries repository.SnapshotQueries
+	logger  logger.Logger
+}
+
+func NewSnapshotService(queries repository.SnapshotQueries, logger logger.Logger) *SnapshotService {
+	return &SnapshotService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Export writes a snapshot of the mappings to w, a diff of the changes after since unless it's zero.
+// Nothing is written before the first links are read, so an error then leaves w untouched.
+func (s *SnapshotService) Export(ctx context.Context, w io.Writer, since time.Time) error {
+	// Snapshots cover every user's links, whatever the request is scoped to
+	ctx = db.WithRowSecurityUser(ctx, "")
+
+	header := snapshot.Header{TakenAt: time.Now().Add(-snapshotClockSlack), Since: since}
+	sw, err := snapshot.NewWriter(w, header)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	after := ""
+	for {
+		links, err := s.queries.ListSnapshotLinks(ctx, db.ListSnapshotLinksParams{
+			AfterShortcode: after,
+			Since:          pgtype.Timestamptz{Time: since, Valid: header.IsDiff()},
+			RowLimit:       snapshotPageSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list snapshot links: %w", err)
+		}
+
+		for _, link := range links {
+			entry := snapshot.Entry{
+				Op:        snapshot.OpSet,
+				Shortcode: link.Shortcode,
+				LinkID:    link.ID,
+				UserID:    link.UserID,
+				URL:       link.OriginalUrl,
+			}
+			if !link.Redirectable {
+				if !header.IsDiff() {
+					continue
+				}
+				entry = snapshot.Entry{Op: snapshot.OpDelete, Shortcode: link.Shortcode}
+			}
+
+			err := sw.Write(entry)
+			if errors.Is(err, snapshot.ErrFormat) {
+				// Loaders fall back to the primary for the links they don't have
+				s.logger.Warn("Link left out of snapshot",
+					zap.Error(err),
+					zap.String("shortcode", link.Shortcode),
+				)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write snapshot: %w", err)
+			}
+		}
+
+		// Hand each page to the client as it's read instead of buffering the snapshot
+		if err := sw.Flush(); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+
+		if len(links) < snapshotPageSize {
+			break
+		}
+		after = links[len(links)-1].Shortcode
+	}
+
+	if err := sw.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+/*
+LoadSnapshot loads a snapshot into a redirect cache, its entries expiring after
+ttl, and returns its header and how many entries it applied. The whole snapshot
+is read and checked first, so a damaged one changes nothing.
+*/
+func LoadSnapshot(ctx context.Context, c cache.Cache, r io.Reader, ttl time.Duration) (snapshot.Header, int, error) {
+	header, entries, err := snapshot.ReadAll(r)
+	if err != nil {
+		return snapshot.Header{}, 0, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	for i, entry := range entries {
+		key := cacheKeyPrefix + entry.Shortcode
+		if entry.Op == snapshot.OpDelete {
+			err = c.Del(ctx, key)
+		} else {
+			cached, _ := json.Marshal(cachedRedirect{
+				ID:          entry.LinkID,
+				UserID:      entry.UserID,
+				OriginalURL: entry.URL,
+			})
+			err = c.Set(ctx, key, string(cached), ttl)
+		}
+		if err != nil {
+			return header, i, fmt.Errorf("failed to load %q: %w", entry.Shortcode, err)
+		}
+	}
+
+	return header, len(entries), nil
+}
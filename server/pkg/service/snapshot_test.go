@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/cache"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+	"github.com/styltsou/url-shortener/server/pkg/snapshot"
+)
+
+func TestSnapshotService_ExportAndLoad(t *testing.T) {
+	ctx := context.Background()
+	abc := db.ListSnapshotLinksRow{Shortcode: "abc", ID: uuid.New(), UserID: "user_1", OriginalUrl: "https://example.com", Redirectable: true}
+	gated := db.ListSnapshotLinksRow{Shortcode: "gated", ID: uuid.New(), UserID: "user_1", OriginalUrl: "https://example.org"}
+	since := time.Now().Add(-time.Hour)
+
+	queries := &mocks.SnapshotQueries{
+		ListSnapshotLinksFunc: func(ctx context.Context, arg db.ListSnapshotLinksParams) ([]db.ListSnapshotLinksRow, error) {
+			if arg.Since.Valid {
+				// abc was deleted since
+				deleted := abc
+				deleted.Redirectable = false
+				return []db.ListSnapshotLinksRow{deleted}, nil
+			}
+			return []db.ListSnapshotLinksRow{abc, gated}, nil
+		},
+	}
+	s := NewSnapshotService(queries, createTestLogger())
+
+	var full bytes.Buffer
+	if err := s.Export(ctx, &full, time.Time{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	local := cache.NewMemory()
+	header, applied, err := LoadSnapshot(ctx, local, bytes.NewReader(full.Bytes()), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if header.IsDiff() || applied != 1 {
+		t.Errorf("LoadSnapshot() = %+v, %d, want a full snapshot of the one redirectable link", header, applied)
+	}
+	cached, err := local.Get(ctx, cacheKeyPrefix+"abc")
+	if err != nil {
+		t.Fatalf("Get() error = %v, want abc loaded", err)
+	}
+	if link, err := decodeCachedRedirect(cached); err != nil || link.ID != abc.ID || link.UserID != abc.UserID || link.OriginalUrl != abc.OriginalUrl {
+		t.Errorf("loaded link = %+v, %v, want abc's", link, err)
+	}
+	if _, err := local.Get(ctx, cacheKeyPrefix+"gated"); err == nil {
+		t.Error("link that doesn't redirect straight away was loaded")
+	}
+
+	var diff bytes.Buffer
+	if err := s.Export(ctx, &diff, since); err != nil {
+		t.Fatalf("Export() of a diff error = %v", err)
+	}
+
+	// A damaged diff changes nothing
+	damaged := bytes.Replace(diff.Bytes(), []byte("del,abc"), []byte("del,abd"), 1)
+	if _, _, err := LoadSnapshot(ctx, local, bytes.NewReader(damaged), time.Minute); !errors.Is(err, snapshot.ErrChecksum) {
+		t.Errorf("LoadSnapshot() of a damaged diff error = %v, want ErrChecksum", err)
+	}
+	if _, err := local.Get(ctx, cacheKeyPrefix+"abc"); err != nil {
+		t.Errorf("Get() after a damaged diff error = %v, want abc still loaded", err)
+	}
+
+	header, _, err = LoadSnapshot(ctx, local, bytes.NewReader(diff.Bytes()), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() of the diff error = %v", err)
+	}
+	if !header.Since.Equal(since) {
+		t.Errorf("diff since = %v, want %v", header.Since, since)
+	}
+	if _, err := local.Get(ctx, cacheKeyPrefix+"abc"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("Get() after the diff error = %v, want abc deleted", err)
+	}
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/geoip"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Number of links returned in the "top links" section of stats
+	statsTopLinksLimit = 10
+	// Number of referrers returned in the "top referrers" section of link stats
+	statsTopReferrersLimit = 10
+	// Period reported for a campaign without a start date
+	campaignDefaultStatsPeriod = 30 * 24 * time.Hour
+)
+
+type StatsService struct {
+	queries repository.StatsQueries
+	clicks  analytics.Store
+	// Aggregates the clicks of single links
+	stats analytics.StatsReader
+	// Gives clicks the visitor IDs unique visitors are counted by
+	visitors *analytics.Visitors
+	// Verify the tokens opening stats pages that aren't public
+	tokens *AccessTokens
+	// Tells the country clicks come from; nil leaves it unknown
+	countries *geoip.Countries
+	// Running click counts of links; nil doesn't count them
+	counter *ClickCounter
+	// Ranks each user's links by their clicks of the day; nil doesn't rank them
+	leaderboard *Leaderboard
+	// Tells the owner's webhooks about clicks; nil tells no one
+	webhooks *WebhookService
+	logger   logger.Logger
+}
+
+func NewStatsService(queries repository.StatsQueries, clicks analytics.Store, stats analytics.StatsReader, visitors *analytics.Visitors, tokens *AccessTokens, countries *geoip.Countries, counter *ClickCounter, leaderboard *Leaderboard, webhooks *WebhookService, logger logger.Logger) *StatsService {
+	return &StatsService{
+		queries:     queries,
+		clicks:      clicks,
+		stats:       stats,
+		visitors:    visitors,
+		tokens:      tokens,
+		countries:   countries,
+		counter:     counter,
+		leaderboard: leaderboard,
+		webhooks:    webhooks,
+		logger:      logger,
+	}
+}
+
+// Click describes a single redirect event; edge instances send them to the primary as JSON
+type Click struct {
+	// Generated at redirect time so it can be substituted into the destination
+	ID        uuid.UUID `json:"id"`
+	Shortcode string    `json:"shortcode"`
+	LinkID    uuid.UUID `json:"link_id"`
+	// Owner of the link, whose leaderboard the click ranks it on
+	UserID    string `json:"user_id"`
+	Referrer  string `json:"referrer"`
+	UserAgent string `json:"user_agent"`
+	// analytics.SourceWeb or analytics.SourceQR, see ClickSource
+	Source string `json:"source"`
+	// Looked up in the country database and hashed into the visitor ID; the address itself isn't stored
+	ClientIP netip.Addr `json:"client_ip"`
+}
+
+// ClickSource tells QR code scans from other clicks by the query of the short URL they followed
+func ClickSource(query url.Values) string {
+	if query.Get(qrSourceParam) == analytics.SourceQR {
+		return analytics.SourceQR
+	}
+	return analytics.SourceWeb
+}
+
+// RecordClick stores a click for the link with the given shortcode in the analytics backend,
+// and counts it in the link's running click count and its owner's leaderboard
+func (s *StatsService) RecordClick(ctx context.Context, click Click) error {
+	now := time.Now().UTC()
+	s.counter.Add(ctx, click.Shortcode)
+	s.leaderboard.Add(ctx, click.UserID, click.LinkID, now)
+
+	err := s.clicks.RecordClick(ctx, analytics.Click{
+		ID:        click.ID,
+		Shortcode: click.Shortcode,
+		LinkID:    click.LinkID,
+		Referrer:  click.Referrer,
+		UserAgent: click.UserAgent,
+		Source:    click.Source,
+		Country:   s.countries.Country(click.ClientIP),
+		VisitorID: s.visitors.ID(click.ClientIP, click.UserAgent, now),
+		ClickedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record click: %w", err)
+	}
+
+	s.webhooks.Notify(ctx, click.UserID, WebhookEventLinkClicked, WebhookClickData{
+		ClickID:   click.ID,
+		LinkID:    click.LinkID,
+		Shortcode: click.Shortcode,
+		Referrer:  click.Referrer,
+		Source:    click.Source,
+	})
+
+	return nil
+}
+
+// LinkClickCounts returns the running click counts of the links, nil when clicks aren't counted
+func (s *StatsService) LinkClickCounts(ctx context.Context, links []CountedLink) (map[uuid.UUID]int64, error) {
+	return s.counter.Counts(ctx, links)
+}
+
+// TopLinks returns the user's links with the most clicks on day, see Leaderboard
+func (s *StatsService) TopLinks(ctx context.Context, userID string, day time.Time, limit int) ([]TopLink, error) {
+	return s.leaderboard.Top(ctx, userID, day, limit)
+}
+
+type LinkStatsResult struct {
+	Link           db.GetLinkByIdAndUserRow
+	From           time.Time
+	To             time.Time
+	Granularity    string
+	TotalClicks    int64
+	UniqueVisitors int64
+	TopReferrers   []analytics.ReferrerClicks
+	// Only the buckets with clicks, oldest first
+	Clicks []analytics.BucketClicks
+}
+
+// GetLinkStats aggregates the clicks of one of the user's links over [from, to), in
+// buckets of analytics.GranularityDay or analytics.GranularityHour starting in loc
+func (s *StatsService) GetLinkStats(ctx context.Context, userID string, linkID uuid.UUID, from, to time.Time, granularity string, loc *time.Location) (*LinkStatsResult, error) {
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	stats, err := s.stats.LinkStats(ctx, analytics.LinkStatsQuery{
+		LinkID:       link.ID,
+		Shortcode:    link.Shortcode,
+		From:         from,
+		To:           to,
+		Granularity:  granularity,
+		Location:     loc,
+		TopReferrers: statsTopReferrersLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link stats: %w", err)
+	}
+
+	s.logger.Debug("Link stats computed",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+		zap.Int64("total_clicks", stats.TotalClicks),
+	)
+
+	return &LinkStatsResult{
+		Link:           link,
+		From:           from,
+		To:             to,
+		Granularity:    granularity,
+		TotalClicks:    stats.TotalClicks,
+		UniqueVisitors: stats.UniqueVisitors,
+		TopReferrers:   stats.TopReferrers,
+		Clicks:         stats.Clicks,
+	}, nil
+}
+
+type TagStatsResult struct {
+	Tag          db.GetTagByIdAndUserRow
+	From         time.Time
+	To           time.Time
+	TotalClicks  int64
+	QRClicks     int64
+	LinksClicked int64
+	Conversions  int64
+	RevenueCents int64
+	ClicksByDay  []db.GetTagClicksByDayRow
+	TopLinks     []db.GetTagTopLinksRow
+}
+
+// GetTagStats aggregates clicks across all links carrying the tag over [from, to),
+// with days starting at midnight in loc
+func (s *StatsService) GetTagStats(ctx context.Context, userID string, tagID uuid.UUID, from, to time.Time, loc *time.Location) (*TagStatsResult, error) {
+	tag, err := s.queries.GetTagByIdAndUser(ctx, db.GetTagByIdAndUserParams{
+		ID:     tagID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", apperrors.TagNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	fromTs := pgtype.Timestamptz{Time: from, Valid: true}
+	toTs := pgtype.Timestamptz{Time: to, Valid: true}
+
+	rollupFrom, rollupTo, err := s.rollupRange(ctx, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.queries.GetTagClickTotals(ctx, db.GetTagClickTotalsParams{
+		TagID:      tagID,
+		UserID:     userID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		FromTime:   fromTs,
+		ToTime:     toTs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag click totals: %w", err)
+	}
+
+	byDay, err := s.queries.GetTagClicksByDay(ctx, db.GetTagClicksByDayParams{
+		TagID:      tagID,
+		UserID:     userID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		TimeZone:   loc.String(),
+		FromTime:   fromTs,
+		ToTime:     toTs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag clicks by day: %w", err)
+	}
+
+	topLinks, err := s.queries.GetTagTopLinks(ctx, db.GetTagTopLinksParams{
+		TagID:      tagID,
+		UserID:     userID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		FromTime:   fromTs,
+		ToTime:     toTs,
+		Limit:      statsTopLinksLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag top links: %w", err)
+	}
+
+	s.logger.Debug("Tag stats computed",
+		zap.String("user_id", userID),
+		zap.String("tag_id", tagID.String()),
+		zap.Int64("total_clicks", totals.TotalClicks),
+	)
+
+	return &TagStatsResult{
+		Tag:          tag,
+		From:         from,
+		To:           to,
+		TotalClicks:  totals.TotalClicks,
+		QRClicks:     totals.QrClicks,
+		LinksClicked: totals.LinksClicked,
+		Conversions:  totals.Conversions,
+		RevenueCents: totals.RevenueCents,
+		ClicksByDay:  byDay,
+		TopLinks:     topLinks,
+	}, nil
+}
+
+type CampaignStatsResult struct {
+	Campaign     db.GetCampaignByIdAndUserRow
+	From         time.Time
+	To           time.Time
+	TotalClicks  int64
+	QRClicks     int64
+	LinksClicked int64
+	Conversions  int64
+	RevenueCents int64
+	ClicksByDay  []db.GetCampaignClicksByDayRow
+	TopLinks     []db.GetCampaignTopLinksRow
+}
+
+// GetCampaignStats aggregates clicks across all links attached to the campaign over [from, to),
+// with days starting at midnight in loc.
+// When from and to are both zero, the campaign's own date range is used (see campaignPeriod).
+func (s *StatsService) GetCampaignStats(ctx context.Context, userID string, campaignID uuid.UUID, from, to time.Time, loc *time.Location) (*CampaignStatsResult, error) {
+	campaign, err := s.queries.GetCampaignByIdAndUser(ctx, db.GetCampaignByIdAndUserParams{
+		ID:     campaignID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", apperrors.CampaignNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	if from.IsZero() && to.IsZero() {
+		from, to = campaignPeriod(campaign, time.Now().UTC())
+	}
+
+	fromTs := pgtype.Timestamptz{Time: from, Valid: true}
+	toTs := pgtype.Timestamptz{Time: to, Valid: true}
+
+	rollupFrom, rollupTo, err := s.rollupRange(ctx, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.queries.GetCampaignClickTotals(ctx, db.GetCampaignClickTotalsParams{
+		CampaignID: campaignID,
+		UserID:     userID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		FromTime:   fromTs,
+		ToTime:     toTs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign click totals: %w", err)
+	}
+
+	byDay, err := s.queries.GetCampaignClicksByDay(ctx, db.GetCampaignClicksByDayParams{
+		CampaignID: campaignID,
+		UserID:     userID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		TimeZone:   loc.String(),
+		FromTime:   fromTs,
+		ToTime:     toTs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign clicks by day: %w", err)
+	}
+
+	topLinks, err := s.queries.GetCampaignTopLinks(ctx, db.GetCampaignTopLinksParams{
+		CampaignID: campaignID,
+		UserID:     userID,
+		RollupFrom: rollupFrom,
+		RollupTo:   rollupTo,
+		FromTime:   fromTs,
+		ToTime:     toTs,
+		Limit:      statsTopLinksLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign top links: %w", err)
+	}
+
+	s.logger.Debug("Campaign stats computed",
+		zap.String("user_id", userID),
+		zap.String("campaign_id", campaignID.String()),
+		zap.Int64("total_clicks", totals.TotalClicks),
+	)
+
+	return &CampaignStatsResult{
+		Campaign:     campaign,
+		From:         from,
+		To:           to,
+		TotalClicks:  totals.TotalClicks,
+		QRClicks:     totals.QrClicks,
+		LinksClicked: totals.LinksClicked,
+		Conversions:  totals.Conversions,
+		RevenueCents: totals.RevenueCents,
+		ClicksByDay:  byDay,
+		TopLinks:     topLinks,
+	}, nil
+}
+
+/*
+rollupRange returns the days of [from, to) that can be read from the daily
+rollups: whole days only, and only those before the rollup watermark. The
+rest of the period is counted from raw clicks. The range is empty when the
+rollup job hasn't run yet, and when days start in another time zone than UTC,
+since rollups hold UTC days.
+*/
+func (s *StatsService) rollupRange(ctx context.Context, from, to time.Time, loc *time.Location) (pgtype.Date, pgtype.Date, error) {
+	if loc != time.UTC {
+		start, _ := rollupDays(from, to, pgtype.Date{})
+		return pgtype.Date{Time: start, Valid: true}, pgtype.Date{Time: start, Valid: true}, nil
+	}
+
+	rolledUpUntil, err := s.queries.GetStatsRollupWatermark(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return pgtype.Date{}, pgtype.Date{}, fmt.Errorf("failed to get stats rollup watermark: %w", err)
+	}
+
+	start, end := rollupDays(from, to, rolledUpUntil)
+	return pgtype.Date{Time: start, Valid: true}, pgtype.Date{Time: end, Valid: true}, nil
+}
+
+// rollupDays is the day range of rollupRange; start == end when there's nothing to read from rollups
+func rollupDays(from, to time.Time, rolledUpUntil pgtype.Date) (time.Time, time.Time) {
+	start := from.UTC().Truncate(24 * time.Hour)
+	if start.Before(from) {
+		start = start.AddDate(0, 0, 1)
+	}
+
+	end := to.UTC().Truncate(24 * time.Hour)
+	if !rolledUpUntil.Valid {
+		end = start
+	} else if rolledUpUntil.Time.Before(end) {
+		end = rolledUpUntil.Time
+	}
+
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+// campaignPeriod returns the reporting period of a campaign: from its start
+// (or 30 days back if it has none) up to its end, or now if it's still running
+func campaignPeriod(campaign db.GetCampaignByIdAndUserRow, now time.Time) (time.Time, time.Time) {
+	to := now
+	if campaign.EndsAt.Valid && campaign.EndsAt.Time.Before(now) {
+		to = campaign.EndsAt.Time
+	}
+
+	from := to.Add(-campaignDefaultStatsPeriod)
+	if campaign.StartsAt.Valid {
+		from = campaign.StartsAt.Time
+	}
+
+	// A campaign that hasn't started yet has no clicks to report
+	if from.After(to) {
+		from = to
+	}
+
+	return from, to
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/assets"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/throttle"
+	"go.uber.org/zap"
+)
+
+const (
+	// One row per click
+	ExportGranularityRaw = "raw"
+	// One row per link per day
+	ExportGranularityDay = "day"
+
+	// Raw exports covering more than this have to run as export jobs
+	MaxSyncRawExportPeriod = 31 * 24 * time.Hour
+
+	// Clicks fetched per query while exporting
+	exportPageSize = 5000
+	// How long a finished export job can be downloaded
+	exportJobTTL = 24 * time.Hour
+	// Upper bound for running one export job
+	exportJobTimeout = 30 * time.Minute
+	// Upper bound for one run of the export cleanup job
+	exportCleanupTimeout = 5 * time.Minute
+	// Asset keys of export files
+	exportAssetPrefix = "exports/"
+)
+
+// ExportRequest selects the clicks to export
+type ExportRequest struct {
+	UserID string
+	// nil exports every link of the user
+	LinkID      *uuid.UUID
+	From        time.Time
+	To          time.Time
+	Granularity string
+	// Days of daily exports start at midnight there, and raw click times are written in it; nil is UTC
+	Location *time.Location
+}
+
+// ExportClicks writes the requested clicks to w as CSV. The link is checked before
+// anything is written, so a LinkNotFound error leaves w untouched.
+func (s *StatsService) ExportClicks(ctx context.Context, req ExportRequest, w io.Writer) error {
+	if err := s.checkExportLink(ctx, req); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if req.Granularity == ExportGranularityDay {
+		if err := s.writeDailyClicks(ctx, req, cw); err != nil {
+			return err
+		}
+	} else if err := s.writeRawClicks(ctx, req, cw); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write clicks export: %w", err)
+	}
+
+	return nil
+}
+
+// checkExportLink makes sure a per-link export targets one of the user's links
+func (s *StatsService) checkExportLink(ctx context.Context, req ExportRequest) error {
+	if req.LinkID == nil {
+		return nil
+	}
+
+	_, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     *req.LinkID,
+		UserID: req.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	return nil
+}
+
+func (s *StatsService) writeRawClicks(ctx context.Context, req ExportRequest, cw *csv.Writer) error {
+	_ = cw.Write([]string{"clicked_at", "click_id", "link_id", "shortcode", "referrer", "user_agent", "source"})
+
+	var afterID int64
+	for {
+		clicks, err := s.queries.ExportClicks(ctx, db.ExportClicksParams{
+			UserID:   req.UserID,
+			LinkID:   exportLinkID(req),
+			FromTime: pgtype.Timestamptz{Time: req.From, Valid: true},
+			ToTime:   pgtype.Timestamptz{Time: req.To, Valid: true},
+			AfterID:  afterID,
+			Limit:    exportPageSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export clicks: %w", err)
+		}
+
+		for _, c := range clicks {
+			_ = cw.Write([]string{
+				c.ClickedAt.Time.In(exportLocation(req)).Format(time.RFC3339),
+				c.ClickID.String(),
+				c.LinkID.String(),
+				c.Shortcode,
+				CSVSafe(derefString(c.Referrer)),
+				CSVSafe(derefString(c.UserAgent)),
+				c.Source,
+			})
+		}
+
+		// Hand each page to the client as it's read instead of buffering the export
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("failed to write clicks export: %w", err)
+		}
+
+		if len(clicks) < exportPageSize {
+			return nil
+		}
+		afterID = clicks[len(clicks)-1].ID
+	}
+}
+
+func (s *StatsService) writeDailyClicks(ctx context.Context, req ExportRequest, cw *csv.Writer) error {
+	loc := exportLocation(req)
+	days, err := s.queries.ExportClicksByDay(ctx, db.ExportClicksByDayParams{
+		TimeZone: loc.String(),
+		UserID:   req.UserID,
+		LinkID:   exportLinkID(req),
+		FromTime: pgtype.Timestamptz{Time: req.From, Valid: true},
+		ToTime:   pgtype.Timestamptz{Time: req.To, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export clicks by day: %w", err)
+	}
+
+	_ = cw.Write([]string{"day", "link_id", "shortcode", "clicks", "qr_clicks", "conversions"})
+	for _, d := range days {
+		_ = cw.Write([]string{
+			d.Day.Time.In(loc).Format(time.DateOnly),
+			d.LinkID.String(),
+			d.Shortcode,
+			strconv.FormatInt(d.Clicks, 10),
+			strconv.FormatInt(d.QrClicks, 10),
+			strconv.FormatInt(d.Conversions, 10),
+		})
+	}
+
+	return nil
+}
+
+func exportLocation(req ExportRequest) *time.Location {
+	if req.Location == nil {
+		return time.UTC
+	}
+	return req.Location
+}
+
+func exportLinkID(req ExportRequest) pgtype.UUID {
+	if req.LinkID == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *req.LinkID, Valid: true}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// CSVSafe neutralizes values that spreadsheet apps would evaluate as formulas.
+// Visitor-controlled values (emails, referrers, user agents) may start with any of these characters.
+func CSVSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type ExportJobStatus string
+
+const (
+	ExportJobPending ExportJobStatus = "pending"
+	ExportJobDone    ExportJobStatus = "done"
+	ExportJobFailed  ExportJobStatus = "failed"
+)
+
+// ExportJob is a clicks export running in the background
+type ExportJob struct {
+	ID        uuid.UUID
+	UserID    string
+	Status    ExportJobStatus
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	key string
+}
+
+/*
+ExportJobs runs large clicks exports in the background and keeps the CSV in the
+asset store until it expires (24h), when the cleanup job deletes it.
+
+Jobs are tracked in memory, so the download must be served by the instance
+that ran the export and jobs don't survive a restart.
+*/
+type ExportJobs struct {
+	stats       *StatsService
+	store       assets.Store
+	downloadTTL time.Duration
+	logger      logger.Logger
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*ExportJob
+}
+
+// NewExportJobs keeps export files in store; download URLs it hands out stay valid for downloadTTL
+func NewExportJobs(stats *StatsService, store assets.Store, downloadTTL time.Duration, logger logger.Logger) *ExportJobs {
+	return &ExportJobs{
+		stats:       stats,
+		store:       store,
+		downloadTTL: downloadTTL,
+		logger:      logger,
+		jobs:        make(map[uuid.UUID]*ExportJob),
+	}
+}
+
+// Start validates the request and runs the export in the background
+func (j *ExportJobs) Start(ctx context.Context, req ExportRequest) (ExportJob, error) {
+	if err := j.stats.checkExportLink(ctx, req); err != nil {
+		return ExportJob{}, err
+	}
+
+	now := time.Now().UTC()
+	job := &ExportJob{
+		ID:        uuid.New(),
+		UserID:    req.UserID,
+		Status:    ExportJobPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(exportJobTTL),
+	}
+	job.key = exportAssetPrefix + job.ID.String() + ".csv"
+
+	j.mu.Lock()
+	j.removeExpired(now)
+	j.jobs[job.ID] = job
+	snapshot := *job
+	j.mu.Unlock()
+
+	// The export outlives the request that started it, and so does its throttle slot
+	slot := throttle.Detach(ctx)
+	go func() {
+		defer slot.Release()
+		j.run(context.WithoutCancel(ctx), job, req)
+	}()
+
+	return snapshot, nil
+}
+
+func (j *ExportJobs) run(ctx context.Context, job *ExportJob, req ExportRequest) {
+	ctx, cancel := context.WithTimeout(ctx, exportJobTimeout)
+	defer cancel()
+
+	err := j.writeAsset(ctx, job.key, req)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err != nil {
+		job.Status = ExportJobFailed
+		j.logger.Error("Export job failed",
+			zap.Error(err),
+			zap.String("job_id", job.ID.String()),
+			zap.String("user_id", job.UserID),
+		)
+		return
+	}
+
+	job.Status = ExportJobDone
+	j.logger.Info("Export job finished",
+		zap.String("job_id", job.ID.String()),
+		zap.String("user_id", job.UserID),
+	)
+}
+
+// writeAsset exports to a temporary file, then stores it under key
+func (j *ExportJobs) writeAsset(ctx context.Context, key string, req ExportRequest) error {
+	f, err := os.CreateTemp("", "url-shortener-export-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	if err := j.stats.ExportClicks(ctx, req, f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read export file: %w", err)
+	}
+	if err := j.store.Put(ctx, key, f); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the user's export job
+func (j *ExportJobs) Get(userID string, id uuid.UUID) (ExportJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok || job.UserID != userID || time.Now().After(job.ExpiresAt) {
+		return ExportJob{}, fmt.Errorf("%w: %s", apperrors.ExportNotFound, id)
+	}
+
+	return *job, nil
+}
+
+// Open returns the CSV of a finished export job
+func (j *ExportJobs) Open(ctx context.Context, job ExportJob) (io.ReadCloser, error) {
+	f, err := j.store.Open(ctx, job.key)
+	if err != nil {
+		if errors.Is(err, assets.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", apperrors.ExportNotFound, job.ID)
+		}
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+
+	return f, nil
+}
+
+// DownloadURL returns a short-lived URL the CSV of a finished export job can be downloaded
+// from directly, or "" when the asset store can't link to it and Open has to serve it
+func (j *ExportJobs) DownloadURL(ctx context.Context, job ExportJob, filename string) (string, error) {
+	url, err := j.store.DownloadURL(ctx, job.key, filename, j.downloadTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign export download URL: %w", err)
+	}
+	return url, nil
+}
+
+// StartCleanup deletes the export files that expired every interval, in the background
+func (j *ExportJobs) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			j.cleanupOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *ExportJobs) cleanupOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, exportCleanupTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	j.mu.Lock()
+	j.removeExpired(now)
+	j.mu.Unlock()
+
+	// Going by the files rather than the jobs, it also deletes exports of earlier runs
+	deleted, err := assets.Cleanup(ctx, j.store, exportAssetPrefix, now.Add(-exportJobTTL))
+	if err != nil && ctx.Err() == nil {
+		j.logger.Error("Export cleanup failed",
+			zap.Error(err),
+		)
+	}
+	if deleted > 0 {
+		j.logger.Info("Expired exports deleted",
+			zap.Int("deleted", deleted),
+		)
+	}
+}
+
+// removeExpired forgets expired jobs; their files are left to the cleanup job. j.mu must be held
+func (j *ExportJobs) removeExpired(now time.Time) {
+	for id, job := range j.jobs {
+		if now.After(job.ExpiresAt) && job.Status != ExportJobPending {
+			delete(j.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/assets"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+)
+
+// mockExportQueries serves clicks from memory; other StatsQueries methods are left unimplemented
+type mockExportQueries struct {
+	repository.StatsQueries
+	clicks  []db.ExportClicksRow
+	links   map[uuid.UUID]bool
+	queries int
+}
+
+func (m *mockExportQueries) GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+	if !m.links[arg.ID] {
+		return db.GetLinkByIdAndUserRow{}, sql.ErrNoRows
+	}
+	return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+}
+
+func (m *mockExportQueries) ExportClicks(ctx context.Context, arg db.ExportClicksParams) ([]db.ExportClicksRow, error) {
+	m.queries++
+	var page []db.ExportClicksRow
+	for _, c := range m.clicks {
+		if c.ID > arg.AfterID && len(page) < int(arg.Limit) {
+			page = append(page, c)
+		}
+	}
+	return page, nil
+}
+
+func newExportClicks(n int) []db.ExportClicksRow {
+	clicks := make([]db.ExportClicksRow, n)
+	for i := range clicks {
+		clicks[i] = db.ExportClicksRow{
+			ID:        int64(i + 1),
+			ClickID:   uuid.New(),
+			ClickedAt: pgtype.Timestamptz{Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), Valid: true},
+			LinkID:    uuid.New(),
+			Shortcode: "abc",
+		}
+	}
+	return clicks
+}
+
+func TestStatsService_ExportClicks(t *testing.T) {
+	ctx := context.Background()
+	req := ExportRequest{
+		UserID:      "user_123",
+		From:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity: ExportGranularityRaw,
+	}
+
+	t.Run("pages through all clicks", func(t *testing.T) {
+		queries := &mockExportQueries{clicks: newExportClicks(exportPageSize + 1)}
+		s := &StatsService{queries: queries, logger: createTestLogger()}
+
+		var out bytes.Buffer
+		if err := s.ExportClicks(ctx, req, &out); err != nil {
+			t.Fatalf("ExportClicks() error = %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != exportPageSize+2 {
+			t.Errorf("ExportClicks() wrote %d lines, want header + %d clicks", len(lines), exportPageSize+1)
+		}
+		if lines[0] != "clicked_at,click_id,link_id,shortcode,referrer,user_agent,source" {
+			t.Errorf("header = %q", lines[0])
+		}
+		if queries.queries != 2 {
+			t.Errorf("ExportClicks queried %d pages, want 2", queries.queries)
+		}
+	})
+
+	t.Run("neutralizes formulas in visitor values", func(t *testing.T) {
+		clicks := newExportClicks(1)
+		referrer := "=HYPERLINK(\"https://evil.example\")"
+		clicks[0].Referrer = &referrer
+		s := &StatsService{queries: &mockExportQueries{clicks: clicks}, logger: createTestLogger()}
+
+		var out bytes.Buffer
+		if err := s.ExportClicks(ctx, req, &out); err != nil {
+			t.Fatalf("ExportClicks() error = %v", err)
+		}
+		if !strings.Contains(out.String(), `"'=HYPERLINK(""https://evil.example"")"`) {
+			t.Errorf("ExportClicks() = %q, want the referrer prefixed with a quote", out.String())
+		}
+	})
+
+	t.Run("unknown link writes nothing", func(t *testing.T) {
+		s := &StatsService{queries: &mockExportQueries{}, logger: createTestLogger()}
+		linkReq := req
+		linkID := uuid.New()
+		linkReq.LinkID = &linkID
+
+		var out bytes.Buffer
+		err := s.ExportClicks(ctx, linkReq, &out)
+		if !errors.Is(err, apperrors.LinkNotFound) {
+			t.Fatalf("ExportClicks() error = %v, want %v", err, apperrors.LinkNotFound)
+		}
+		if out.Len() != 0 {
+			t.Errorf("ExportClicks() wrote %d bytes before failing, want 0", out.Len())
+		}
+	})
+}
+
+func TestExportJobs(t *testing.T) {
+	ctx := context.Background()
+	stats := &StatsService{queries: &mockExportQueries{clicks: newExportClicks(3)}, logger: createTestLogger()}
+	storeDir := t.TempDir()
+	store, err := assets.NewDisk(storeDir)
+	if err != nil {
+		t.Fatalf("NewDisk() error = %v", err)
+	}
+	jobs := NewExportJobs(stats, store, time.Minute, createTestLogger())
+
+	job, err := jobs.Start(ctx, ExportRequest{
+		UserID:      "user_123",
+		From:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Granularity: ExportGranularityRaw,
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Wait for the background export
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err = jobs.Get("user_123", job.ID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Status != ExportJobPending || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != ExportJobDone {
+		t.Fatalf("job status = %s, want %s", job.Status, ExportJobDone)
+	}
+
+	if url, err := jobs.DownloadURL(ctx, job, "clicks.csv"); url != "" || err != nil {
+		t.Errorf("DownloadURL() = %q, %v, want none from disk", url, err)
+	}
+	f, err := jobs.Open(ctx, job)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	content, _ := io.ReadAll(f)
+	if lines := strings.Count(string(content), "\n"); lines != 4 {
+		t.Errorf("export file has %d lines, want header + 3 clicks", lines)
+	}
+
+	// Jobs are private to the user who started them
+	if _, err := jobs.Get("user_456", job.ID); !errors.Is(err, apperrors.ExportNotFound) {
+		t.Errorf("Get() by another user error = %v, want %v", err, apperrors.ExportNotFound)
+	}
+
+	// Cleanup forgets the job and deletes its file once it expired
+	jobs.mu.Lock()
+	jobs.jobs[job.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	jobs.mu.Unlock()
+	stale := time.Now().Add(-exportJobTTL - time.Hour)
+	os.Chtimes(filepath.Join(storeDir, job.key), stale, stale)
+
+	jobs.cleanupOnce(ctx)
+	if _, err := jobs.Get("user_123", job.ID); !errors.Is(err, apperrors.ExportNotFound) {
+		t.Errorf("Get() after cleanup error = %v, want %v", err, apperrors.ExportNotFound)
+	}
+	if _, err := jobs.Open(ctx, job); !errors.Is(err, apperrors.ExportNotFound) {
+		t.Errorf("Open() after cleanup error = %v, want the file deleted", err)
+	}
+}
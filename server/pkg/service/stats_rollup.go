@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Upper bound for one rollup run
+const statsRollupTimeout = 10 * time.Minute
+
+/*
+StatsRollup materializes per-link daily click counts into link_daily_stats so
+stats over past days don't scan raw clicks.
+
+Every run recomputes the days from the day before the watermark up to today
+(clicks can be committed a little after midnight) and then moves the watermark
+to today: days before it are final and read from the rollups, today is always
+counted from raw clicks. The first run backfills from the oldest click.
+*/
+type StatsRollup struct {
+	tx     repository.Transactor[repository.StatsRollupQueries]
+	logger logger.Logger
+}
+
+func NewStatsRollup(tx repository.Transactor[repository.StatsRollupQueries], logger logger.Logger) *StatsRollup {
+	return &StatsRollup{
+		tx:     tx,
+		logger: logger,
+	}
+}
+
+// Run rolls up clicks as of now. It's a no-op when another instance holds the rollup lock.
+func (r *StatsRollup) Run(ctx context.Context, now time.Time) error {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	return r.tx.WithTx(ctx, func(q repository.StatsRollupQueries) error {
+		locked, err := q.TryLockStatsRollup(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock stats rollup: %w", err)
+		}
+		if !locked {
+			r.logger.Debug("Stats rollup already running elsewhere")
+			return nil
+		}
+
+		from, err := r.rollupStart(ctx, q, today)
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.RollupDailyStats(ctx, db.RollupDailyStatsParams{
+			FromDay: pgtype.Date{Time: from, Valid: true},
+			ToDay:   pgtype.Date{Time: today.AddDate(0, 0, 1), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to roll up daily stats: %w", err)
+		}
+
+		if err := q.SetStatsRollupWatermark(ctx, pgtype.Date{Time: today, Valid: true}); err != nil {
+			return fmt.Errorf("failed to set stats rollup watermark: %w", err)
+		}
+
+		r.logger.Info("Daily stats rolled up",
+			zap.String("from", from.Format(time.DateOnly)),
+			zap.String("until", today.Format(time.DateOnly)),
+			zap.Int64("rows", rows),
+		)
+		return nil
+	})
+}
+
+// rollupStart returns the first day to recompute
+func (r *StatsRollup) rollupStart(ctx context.Context, q repository.StatsRollupQueries, today time.Time) (time.Time, error) {
+	watermark, err := q.GetStatsRollupWatermark(ctx)
+	if err == nil && watermark.Valid {
+		from := watermark.Time.AddDate(0, 0, -1)
+		if from.After(today) {
+			from = today
+		}
+		return from, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("failed to get stats rollup watermark: %w", err)
+	}
+
+	// Never ran: backfill everything
+	first, err := q.GetFirstClickDay(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get first click day: %w", err)
+	}
+	if !first.Valid {
+		return today, nil
+	}
+	return first.Time, nil
+}
+
+// Start runs the rollup now and then every interval until ctx is done
+func (r *StatsRollup) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *StatsRollup) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, statsRollupTimeout)
+	defer cancel()
+
+	if err := r.Run(ctx, time.Now()); err != nil && ctx.Err() == nil {
+		r.logger.Error("Stats rollup failed",
+			zap.Error(err),
+		)
+	}
+}
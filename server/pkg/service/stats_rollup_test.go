@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+)
+
+type mockStatsRollupQueries struct {
+	locked    bool
+	watermark pgtype.Date
+	firstDay  pgtype.Date
+	rolledUp  *db.RollupDailyStatsParams
+	setTo     pgtype.Date
+}
+
+func (m *mockStatsRollupQueries) TryLockStatsRollup(ctx context.Context) (bool, error) {
+	return m.locked, nil
+}
+
+func (m *mockStatsRollupQueries) GetStatsRollupWatermark(ctx context.Context) (pgtype.Date, error) {
+	if !m.watermark.Valid {
+		return pgtype.Date{}, sql.ErrNoRows
+	}
+	return m.watermark, nil
+}
+
+func (m *mockStatsRollupQueries) GetFirstClickDay(ctx context.Context) (pgtype.Date, error) {
+	return m.firstDay, nil
+}
+
+func (m *mockStatsRollupQueries) RollupDailyStats(ctx context.Context, arg db.RollupDailyStatsParams) (int64, error) {
+	m.rolledUp = &arg
+	return 1, nil
+}
+
+func (m *mockStatsRollupQueries) SetStatsRollupWatermark(ctx context.Context, rolledUpUntil pgtype.Date) error {
+	m.setTo = rolledUpUntil
+	return nil
+}
+
+type mockStatsRollupTransactor struct {
+	queries repository.StatsRollupQueries
+}
+
+func (m *mockStatsRollupTransactor) WithTx(ctx context.Context, fn func(q repository.StatsRollupQueries) error) error {
+	return fn(m.queries)
+}
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestStatsRollup_Run(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		queries   *mockStatsRollupQueries
+		wantFrom  string
+		wantRolls bool
+	}{
+		{
+			name:      "first run backfills from the oldest click",
+			queries:   &mockStatsRollupQueries{locked: true, firstDay: pgtype.Date{Time: day("2025-12-01"), Valid: true}},
+			wantFrom:  "2025-12-01",
+			wantRolls: true,
+		},
+		{
+			name:      "first run without clicks",
+			queries:   &mockStatsRollupQueries{locked: true},
+			wantFrom:  "2026-03-10",
+			wantRolls: true,
+		},
+		{
+			name:      "recomputes the day before the watermark",
+			queries:   &mockStatsRollupQueries{locked: true, watermark: pgtype.Date{Time: day("2026-03-10"), Valid: true}},
+			wantFrom:  "2026-03-09",
+			wantRolls: true,
+		},
+		{
+			name:    "skips when another instance holds the lock",
+			queries: &mockStatsRollupQueries{locked: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewStatsRollup(&mockStatsRollupTransactor{queries: tt.queries}, createTestLogger())
+			if err := r.Run(context.Background(), now); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if !tt.wantRolls {
+				if tt.queries.rolledUp != nil || tt.queries.setTo.Valid {
+					t.Errorf("Run() rolled up without holding the lock")
+				}
+				return
+			}
+
+			if got := tt.queries.rolledUp.FromDay.Time.Format(time.DateOnly); got != tt.wantFrom {
+				t.Errorf("rolled up from %s, want %s", got, tt.wantFrom)
+			}
+			// Today is rolled up too so the hourly runs keep it fresh
+			if got := tt.queries.rolledUp.ToDay.Time.Format(time.DateOnly); got != "2026-03-11" {
+				t.Errorf("rolled up until %s, want 2026-03-11", got)
+			}
+			if got := tt.queries.setTo.Time.Format(time.DateOnly); got != "2026-03-10" {
+				t.Errorf("watermark = %s, want 2026-03-10", got)
+			}
+		})
+	}
+}
+
+func TestRollupDays(t *testing.T) {
+	watermark := pgtype.Date{Time: day("2026-03-10"), Valid: true}
+
+	tests := []struct {
+		name          string
+		from, to      time.Time
+		rolledUpUntil pgtype.Date
+		wantStart     string
+		wantEnd       string
+	}{
+		{
+			name:          "whole days before the watermark",
+			from:          day("2026-03-01"),
+			to:            time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC),
+			rolledUpUntil: watermark,
+			wantStart:     "2026-03-01",
+			wantEnd:       "2026-03-10",
+		},
+		{
+			name:          "partial first and last days are counted from raw clicks",
+			from:          time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+			to:            time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC),
+			rolledUpUntil: watermark,
+			wantStart:     "2026-03-02",
+			wantEnd:       "2026-03-05",
+		},
+		{
+			name:          "period after the watermark",
+			from:          day("2026-03-10"),
+			to:            day("2026-03-11"),
+			rolledUpUntil: watermark,
+			wantStart:     "2026-03-10",
+			wantEnd:       "2026-03-10",
+		},
+		{
+			name:      "rollup never ran",
+			from:      day("2026-03-01"),
+			to:        day("2026-03-08"),
+			wantStart: "2026-03-01",
+			wantEnd:   "2026-03-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := rollupDays(tt.from, tt.to, tt.rolledUpUntil)
+			if got := start.Format(time.DateOnly); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format(time.DateOnly); got != tt.wantEnd {
+				t.Errorf("end = %s, want %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/analytics"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+type recordingClickStore struct {
+	got analytics.Click
+}
+
+func (s *recordingClickStore) RecordClick(ctx context.Context, click analytics.Click) error {
+	s.got = click
+	return nil
+}
+
+type mockStatsReader struct {
+	got analytics.LinkStatsQuery
+}
+
+func (m *mockStatsReader) LinkStats(ctx context.Context, q analytics.LinkStatsQuery) (*analytics.LinkStats, error) {
+	m.got = q
+	return &analytics.LinkStats{TotalClicks: 3, UniqueVisitors: 2}, nil
+}
+
+func TestStatsService_RecordClickVisitorID(t *testing.T) {
+	clicks := &recordingClickStore{}
+	s := NewStatsService(nil, clicks, nil, analytics.NewVisitors("0123456789abcdef0123456789abcdef"), nil, nil, nil, nil, nil, createTestLogger())
+
+	click := Click{ID: uuid.New(), Shortcode: "docs", UserAgent: "curl/8.0", ClientIP: netip.MustParseAddr("203.0.113.7")}
+	if err := s.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+	if clicks.got.VisitorID == "" {
+		t.Error("click recorded without a visitor ID")
+	}
+
+	click.ClientIP = netip.Addr{}
+	if err := s.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("RecordClick() error = %v", err)
+	}
+	if clicks.got.VisitorID != "" {
+		t.Errorf("click without an address recorded with visitor ID %q, want none", clicks.got.VisitorID)
+	}
+}
+
+func TestStatsService_GetLinkStats(t *testing.T) {
+	linkID := uuid.New()
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		missing     bool
+		expectedErr error
+	}{
+		{name: "own link"},
+		{name: "someone else's link", missing: true, expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := &mocks.StatsQueries{
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					if tt.missing {
+						return db.GetLinkByIdAndUserRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkByIdAndUserRow{ID: arg.ID, Shortcode: "docs"}, nil
+				},
+			}
+			reader := &mockStatsReader{}
+			s := NewStatsService(queries, nil, reader, nil, nil, nil, nil, nil, nil, createTestLogger())
+
+			stats, err := s.GetLinkStats(context.Background(), "user_123", linkID, from, to, analytics.GranularityHour, time.UTC)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("GetLinkStats() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLinkStats() error = %v, want nil", err)
+			}
+
+			if reader.got.LinkID != linkID || reader.got.Shortcode != "docs" || reader.got.Granularity != analytics.GranularityHour {
+				t.Errorf("LinkStats() query = %+v", reader.got)
+			}
+			if stats.TotalClicks != 3 || stats.UniqueVisitors != 2 || stats.Link.ID != linkID {
+				t.Errorf("GetLinkStats() = %+v", stats)
+			}
+		})
+	}
+}
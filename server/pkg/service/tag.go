@@ -5,58 +5,132 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/styltsou/url-shortener/server/pkg/db"
 	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
 	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
 	"go.uber.org/zap"
 )
 
-type TagQueries interface {
-	ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error)
-	CreateTag(ctx context.Context, arg db.CreateTagParams) (db.CreateTagRow, error)
-	UpdateTag(ctx context.Context, arg db.UpdateTagParams) (db.UpdateTagRow, error)
-	DeleteTag(ctx context.Context, arg db.DeleteTagParams) (db.DeleteTagRow, error)
-	DeleteTags(ctx context.Context, arg db.DeleteTagsParams) ([]db.DeleteTagsRow, error)
-}
-
 type TagService struct {
-	queries TagQueries
-	logger  logger.Logger
+	queries repository.TagQueries
+	// How names are normalized and how many tags a user can have; nil only tidies whitespace
+	policy *TagPolicy
+	logger logger.Logger
 }
 
-func NewTagService(queries TagQueries, logger logger.Logger) *TagService {
+func NewTagService(queries repository.TagQueries, policy *TagPolicy, logger logger.Logger) *TagService {
 	return &TagService{
 		queries: queries,
+		policy:  policy,
 		logger:  logger,
 	}
 }
 
-func (s *TagService) ListAllTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+// Orders of ListTags
+const (
+	TagSortName      = "name"
+	TagSortCreatedAt = "created_at"
+	TagSortUsage     = "usage"
+)
+
+var tagListBounds = pagination.Bounds{DefaultLimit: 50, MaxLimit: 200}
+
+// TagListOptions narrows down and orders the tags ListTags returns
+type TagListOptions struct {
+	// Only tags whose name starts with Prefix, without case
+	Prefix string
+	// TagSortName (the default), TagSortCreatedAt (newest first) or TagSortUsage (most links first)
+	Sort string
+}
+
+type ListTagsResult struct {
+	// Each with the number of live links carrying it
+	Tags []db.ListUserTagsPageRow
+	// Nil when all the tags were asked for
+	Meta *pagination.Meta
+}
+
+// ListTags returns a page of the user's tags, or all of them when neither page nor limit is given
+func (s *TagService) ListTags(ctx context.Context, userID string, opts TagListOptions, page, limit int) (*ListTagsResult, error) {
+	if opts.Sort == "" {
+		opts.Sort = TagSortName
+	}
+
+	params := db.ListUserTagsPageParams{
+		UserID:   userID,
+		Prefix:   opts.Prefix,
+		Sort:     opts.Sort,
+		RowLimit: math.MaxInt32,
+	}
+	paginated := page > 0 || limit > 0
+	p := tagListBounds.Page(page, limit)
+	if paginated {
+		params.RowLimit = int32(p.Limit)
+		params.RowOffset = int32(p.Offset())
+	}
+
 	s.logger.Debug("Querying database for user tags",
 		zap.String("user_id", userID),
+		zap.String("prefix", opts.Prefix),
+		zap.String("sort", opts.Sort),
 	)
 
-	tags, err := s.queries.ListUserTags(ctx, userID)
+	tags, err := s.queries.ListUserTagsPage(ctx, params)
 	if err != nil {
-		s.logger.Error("Database query failed for ListUserTags",
+		s.logger.Error("Database query failed for ListUserTagsPage",
 			zap.Error(err),
 			zap.String("user_id", userID),
 		)
 		return nil, fmt.Errorf("failed to get tags: %w", err)
 	}
 
-	s.logger.Debug("Database query completed for ListUserTags",
+	result := &ListTagsResult{Tags: tags}
+	if paginated {
+		total, err := s.queries.CountUserTagsByPrefix(ctx, db.CountUserTagsByPrefixParams{
+			UserID: userID,
+			Prefix: opts.Prefix,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tags: %w", err)
+		}
+		meta := p.Meta(total)
+		result.Meta = &meta
+	}
+
+	s.logger.Debug("Database query completed for ListUserTagsPage",
 		zap.String("user_id", userID),
 		zap.Int("tags_found", len(tags)),
 	)
 
-	return tags, nil
+	return result, nil
 }
 
 func (s *TagService) CreateTag(ctx context.Context, userID string, name string) (db.CreateTagRow, error) {
+	name, err := s.policy.NormalizeName(name)
+	if err != nil {
+		return db.CreateTagRow{}, err
+	}
+
+	existing, err := userTagsByName(ctx, s.queries, userID)
+	if err != nil {
+		return db.CreateTagRow{}, err
+	}
+	if _, ok := existing[strings.ToLower(name)]; ok {
+		return db.CreateTagRow{},
+			fmt.Errorf("%w: tag name '%s' already exists", apperrors.TagNameTaken, name)
+	}
+	if err := s.policy.checkQuota(len(existing), 1); err != nil {
+		return db.CreateTagRow{}, err
+	}
+
 	createdTag, err := s.queries.CreateTag(ctx, db.CreateTagParams{
 		Name:   name,
 		UserID: userID,
@@ -73,10 +147,57 @@ func (s *TagService) CreateTag(ctx context.Context, userID string, name string)
 		return db.CreateTagRow{}, fmt.Errorf("failed to create tag: %w", err)
 	}
 
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityTagCreated, createdTag.ID,
+		fmt.Sprintf("Created tag %q", createdTag.Name))
+
 	return createdTag, nil
 }
 
+// UpsertTag creates the tag, or returns the user's tag with the same name (without case) if it already exists
+func (s *TagService) UpsertTag(ctx context.Context, userID string, name string) (db.UpsertTagRow, error) {
+	name, err := s.policy.NormalizeName(name)
+	if err != nil {
+		return db.UpsertTagRow{}, err
+	}
+
+	existing, err := userTagsByName(ctx, s.queries, userID)
+	if err != nil {
+		return db.UpsertTagRow{}, err
+	}
+	if tag, ok := existing[strings.ToLower(name)]; ok {
+		return db.UpsertTagRow{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt, UpdatedAt: tag.UpdatedAt}, nil
+	}
+	if err := s.policy.checkQuota(len(existing), 1); err != nil {
+		return db.UpsertTagRow{}, err
+	}
+
+	tag, err := s.queries.UpsertTag(ctx, db.UpsertTagParams{
+		Name:   name,
+		UserID: userID,
+	})
+	if err != nil {
+		return db.UpsertTagRow{}, fmt.Errorf("failed to upsert tag: %w", err)
+	}
+
+	return tag, nil
+}
+
 func (s *TagService) UpdateTag(ctx context.Context, userID string, tagID uuid.UUID, name string) (db.UpdateTagRow, error) {
+	name, err := s.policy.NormalizeName(name)
+	if err != nil {
+		return db.UpdateTagRow{}, err
+	}
+
+	// Renaming a tag to another case of its own name is fine
+	existing, err := userTagsByName(ctx, s.queries, userID)
+	if err != nil {
+		return db.UpdateTagRow{}, err
+	}
+	if other, ok := existing[strings.ToLower(name)]; ok && other.ID != tagID {
+		return db.UpdateTagRow{},
+			fmt.Errorf("%w: tag name '%s' already exists", apperrors.TagNameTaken, name)
+	}
+
 	updatedTag, err := s.queries.UpdateTag(ctx, db.UpdateTagParams{
 		Name:   name,
 		ID:     tagID,
@@ -98,9 +219,13 @@ func (s *TagService) UpdateTag(ctx context.Context, userID string, tagID uuid.UU
 		return db.UpdateTagRow{}, fmt.Errorf("failed to update tag: %w", err)
 	}
 
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityTagRenamed, updatedTag.ID,
+		fmt.Sprintf("Renamed tag to %q", updatedTag.Name))
+
 	return updatedTag, nil
 }
 
+// DeleteTag moves the tag to the trash, detaching it from its links until RestoreTag
 func (s *TagService) DeleteTag(ctx context.Context, userID string, tagID uuid.UUID) (db.DeleteTagRow, error) {
 	deletedTag, err := s.queries.DeleteTag(ctx, db.DeleteTagParams{
 		ID:     tagID,
@@ -115,9 +240,74 @@ func (s *TagService) DeleteTag(ctx context.Context, userID string, tagID uuid.UU
 		return db.DeleteTagRow{}, fmt.Errorf("failed to delete tag: %w", err)
 	}
 
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityTagDeleted, deletedTag.ID,
+		fmt.Sprintf("Deleted tag %q", deletedTag.Name))
+
 	return deletedTag, nil
 }
 
+// ListDeletedTags returns the user's tags in the trash, most recently deleted first
+func (s *TagService) ListDeletedTags(ctx context.Context, userID string) ([]db.ListDeletedTagsRow, error) {
+	tags, err := s.queries.ListDeletedTags(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted tags: %w", err)
+	}
+	return tags, nil
+}
+
+/*
+RestoreTag takes the tag out of the trash and puts it back on the links it was
+detached from, except those deleted for good since. It fails with
+apperrors.TagNameTaken when a live tag has its name by then, and with
+apperrors.TagQuotaExceeded when the user has no room left for it.
+*/
+func (s *TagService) RestoreTag(ctx context.Context, userID string, tagID uuid.UUID) (db.RestoreTagRow, error) {
+	trashed, err := s.queries.ListDeletedTags(ctx, userID)
+	if err != nil {
+		return db.RestoreTagRow{}, fmt.Errorf("failed to get deleted tags: %w", err)
+	}
+	i := slices.IndexFunc(trashed, func(t db.ListDeletedTagsRow) bool { return t.ID == tagID })
+	if i < 0 {
+		return db.RestoreTagRow{}, fmt.Errorf("%w: tag %s is not in the trash", apperrors.TagNotFound, tagID)
+	}
+
+	existing, err := userTagsByName(ctx, s.queries, userID)
+	if err != nil {
+		return db.RestoreTagRow{}, err
+	}
+	if _, ok := existing[strings.ToLower(trashed[i].Name)]; ok {
+		return db.RestoreTagRow{},
+			fmt.Errorf("%w: tag name '%s' already exists", apperrors.TagNameTaken, trashed[i].Name)
+	}
+	if err := s.policy.checkQuota(len(existing), 1); err != nil {
+		return db.RestoreTagRow{}, err
+	}
+
+	restoredTag, err := s.queries.RestoreTag(ctx, db.RestoreTagParams{
+		ID:     tagID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.RestoreTagRow{}, fmt.Errorf("%w: %v", apperrors.TagNotFound, err)
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return db.RestoreTagRow{},
+				fmt.Errorf("%w: tag name '%s' already exists", apperrors.TagNameTaken, trashed[i].Name)
+		}
+
+		return db.RestoreTagRow{}, fmt.Errorf("failed to restore tag: %w", err)
+	}
+
+	recordActivity(ctx, s.queries, s.logger, userID, ActivityTagRestored, restoredTag.ID,
+		fmt.Sprintf("Restored tag %q", restoredTag.Name))
+
+	return restoredTag, nil
+}
+
+// DeleteTags is DeleteTag for several tags; the ones that aren't the user's live tags are skipped
 func (s *TagService) DeleteTags(ctx context.Context, userID string, tagIDs []uuid.UUID) ([]db.DeleteTagsRow, error) {
 	if len(tagIDs) == 0 {
 		return []db.DeleteTagsRow{}, nil
@@ -132,5 +322,10 @@ func (s *TagService) DeleteTags(ctx context.Context, userID string, tagIDs []uui
 		return nil, fmt.Errorf("failed to delete tags: %w", err)
 	}
 
+	for _, tag := range deletedTags {
+		recordActivity(ctx, s.queries, s.logger, userID, ActivityTagDeleted, tag.ID,
+			fmt.Sprintf("Deleted tag %q", tag.Name))
+	}
+
 	return deletedTags, nil
 }
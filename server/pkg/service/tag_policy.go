@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+/*
+TagPolicy is how tag names are tidied up and how many tags a user can have.
+TagService applies it to every tag created or renamed, and LinkService to the
+tags links are created with by name. A nil policy only tidies whitespace.
+
+Names are unique per user without case: "News" can't be created next to "news",
+and creating links with the tag name "NEWS" reuses the existing tag.
+*/
+type TagPolicy struct {
+	// Most tags a user can have; 0 means unlimited. Checked before creating, so
+	// concurrent creates can overshoot it slightly
+	quota int
+	// Names become slugs: lowercase letters and digits joined by hyphens
+	slugs bool
+}
+
+func NewTagPolicy(quota int, slugs bool) *TagPolicy {
+	return &TagPolicy{quota: quota, slugs: slugs}
+}
+
+/*
+NormalizeName trims the name and collapses each run of whitespace into one space,
+or turns it into a slug ("Q3 Launch!" becomes "q3-launch") when the policy says
+so. It returns apperrors.InvalidTagName when nothing is left of the name.
+*/
+func (p *TagPolicy) NormalizeName(name string) (string, error) {
+	normalized := strings.Join(strings.Fields(name), " ")
+	if p != nil && p.slugs {
+		normalized = slugify(normalized)
+	}
+
+	if normalized == "" {
+		return "", fmt.Errorf("%w: %q has no letters or digits", apperrors.InvalidTagName, name)
+	}
+	return normalized, nil
+}
+
+// NormalizeNames normalizes each name, dropping blank ones and the ones that end up the same as an earlier one without case
+func (p *TagPolicy) NormalizeNames(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		name, err := p.NormalizeName(name)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized, nil
+}
+
+// checkQuota returns apperrors.TagQuotaExceeded when adding tags to the ones the user has goes past the quota
+func (p *TagPolicy) checkQuota(existing, adding int) error {
+	if p == nil || p.quota == 0 || adding == 0 {
+		return nil
+	}
+	if existing+adding > p.quota {
+		return fmt.Errorf("%w: user has reached the limit of %d tags", apperrors.TagQuotaExceeded, p.quota)
+	}
+	return nil
+}
+
+func slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+type userTagLister interface {
+	ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error)
+}
+
+// userTagsByName returns the user's tags by lowercase name, to match names without case
+func userTagsByName(ctx context.Context, q userTagLister, userID string) (map[string]db.ListUserTagsRow, error) {
+	tags, err := q.ListUserTags(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	byName := make(map[string]db.ListUserTagsRow, len(tags))
+	for _, tag := range tags {
+		byName[strings.ToLower(tag.Name)] = tag
+	}
+	return byName, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestTagPolicy_NormalizeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		slugs   bool
+		want    string
+		wantErr error
+	}{
+		{name: "  Q3   Launch\t", want: "Q3 Launch"},
+		{name: "  Q3   Launch!\t", slugs: true, want: "q3-launch"},
+		{name: "Café -- Menü", slugs: true, want: "café-menü"},
+		{name: "!!!", want: "!!!"},
+		{name: "!!!", slugs: true, wantErr: apperrors.InvalidTagName},
+		{name: "   ", wantErr: apperrors.InvalidTagName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTagPolicy(0, tt.slugs).NormalizeName(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeName(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	var policy *TagPolicy
+	if got, _ := policy.NormalizeName(" a  b "); got != "a b" {
+		t.Errorf("nil policy NormalizeName() = %q, want %q", got, "a b")
+	}
+	if got, _ := policy.NormalizeNames([]string{"News", " ", "news ", "go"}); !reflect.DeepEqual(got, []string{"News", "go"}) {
+		t.Errorf("NormalizeNames() = %q, want [News go]", got)
+	}
+}
+
+func TestTagService_NamePolicy(t *testing.T) {
+	ctx := context.Background()
+	newsID := uuid.New()
+	existing := []db.ListUserTagsRow{{ID: newsID, Name: "News"}, {ID: uuid.New(), Name: "go"}}
+
+	newService := func(policy *TagPolicy) *TagService {
+		return NewTagService(&mocks.TagQueries{
+			ListUserTagsFunc: func(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+				return existing, nil
+			},
+			CreateTagFunc: func(ctx context.Context, arg db.CreateTagParams) (db.CreateTagRow, error) {
+				return db.CreateTagRow{ID: uuid.New(), Name: arg.Name}, nil
+			},
+			UpsertTagFunc: func(ctx context.Context, arg db.UpsertTagParams) (db.UpsertTagRow, error) {
+				return db.UpsertTagRow{ID: uuid.New(), Name: arg.Name, Created: true}, nil
+			},
+			UpdateTagFunc: func(ctx context.Context, arg db.UpdateTagParams) (db.UpdateTagRow, error) {
+				return db.UpdateTagRow{ID: arg.ID, Name: arg.Name}, nil
+			},
+			CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error { return nil },
+		}, policy, createTestLogger())
+	}
+
+	t.Run("names are unique without case", func(t *testing.T) {
+		s := newService(nil)
+
+		if _, err := s.CreateTag(ctx, "user_123", " NEWS "); !errors.Is(err, apperrors.TagNameTaken) {
+			t.Errorf("CreateTag(NEWS) error = %v, want %v", err, apperrors.TagNameTaken)
+		}
+		if _, err := s.UpdateTag(ctx, "user_123", uuid.New(), "news"); !errors.Is(err, apperrors.TagNameTaken) {
+			t.Errorf("UpdateTag() to another tag's name error = %v, want %v", err, apperrors.TagNameTaken)
+		}
+		if tag, err := s.UpdateTag(ctx, "user_123", newsID, "news"); err != nil || tag.Name != "news" {
+			t.Errorf("UpdateTag() to its own name = %q, %v, want news", tag.Name, err)
+		}
+
+		tag, err := s.UpsertTag(ctx, "user_123", "news")
+		if err != nil {
+			t.Fatalf("UpsertTag(news) error = %v", err)
+		}
+		if tag.ID != newsID || tag.Created {
+			t.Errorf("UpsertTag(news) = %+v, want the existing News tag", tag)
+		}
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		s := newService(NewTagPolicy(2, false))
+
+		if _, err := s.CreateTag(ctx, "user_123", "docs"); !errors.Is(err, apperrors.TagQuotaExceeded) {
+			t.Errorf("CreateTag() past the quota error = %v, want %v", err, apperrors.TagQuotaExceeded)
+		}
+		if _, err := s.UpsertTag(ctx, "user_123", "docs"); !errors.Is(err, apperrors.TagQuotaExceeded) {
+			t.Errorf("UpsertTag() of a new tag past the quota error = %v, want %v", err, apperrors.TagQuotaExceeded)
+		}
+		if _, err := s.UpsertTag(ctx, "user_123", "GO"); err != nil {
+			t.Errorf("UpsertTag() of an existing tag at the quota error = %v, want nil", err)
+		}
+	})
+
+	t.Run("slugs", func(t *testing.T) {
+		tag, err := newService(NewTagPolicy(0, true)).CreateTag(ctx, "user_123", "Q3 Launch")
+		if err != nil {
+			t.Fatalf("CreateTag() error = %v", err)
+		}
+		if tag.Name != "q3-launch" {
+			t.Errorf("CreateTag() name = %q, want q3-launch", tag.Name)
+		}
+	})
+}
+
+func TestLinkService_CreateShortLinkTagQuota(t *testing.T) {
+	queries := &mocks.LinkQueries{
+		TryCreateLinkFunc: func(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+			return createTestTryCreateLinkRow(uuid.New(), arg.Shortcode, arg.OriginalUrl, arg.UserID), nil
+		},
+		ListUserTagsFunc: func(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+			return []db.ListUserTagsRow{{ID: uuid.New(), Name: "News"}}, nil
+		},
+		UpsertTagsByNameFunc: func(ctx context.Context, arg db.UpsertTagsByNameParams) ([]db.UpsertTagsByNameRow, error) {
+			if !reflect.DeepEqual(arg.Names, []string{"News"}) {
+				t.Errorf("UpsertTagsByName called with %q, want the existing tag's name", arg.Names)
+			}
+			return []db.UpsertTagsByNameRow{{ID: uuid.New(), Name: "News"}}, nil
+		},
+		AddTagsToLinkFunc: func(ctx context.Context, arg db.AddTagsToLinkParams) error { return nil },
+	}
+	service := &LinkService{
+		queries:   queries,
+		tx:        &mocks.Transactor[repository.LinkQueries]{Queries: queries},
+		tagPolicy: NewTagPolicy(1, false),
+		logger:    createTestLogger(),
+	}
+
+	if _, err := service.CreateShortLink(context.Background(), "user_123", "https://example.com", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, []string{"news", "NEWS "}); err != nil {
+		t.Errorf("CreateShortLink() with an existing tag at the quota error = %v, want nil", err)
+	}
+
+	_, err := service.CreateShortLink(context.Background(), "user_123", "https://example.com", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, []string{"news", "docs"})
+	if !errors.Is(err, apperrors.TagQuotaExceeded) {
+		t.Errorf("CreateShortLink() with a new tag past the quota error = %v, want %v", err, apperrors.TagQuotaExceeded)
+	}
+}
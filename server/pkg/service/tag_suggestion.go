@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Most tags suggested for a single URL
+const maxTagSuggestions = 5
+
+// Why a tag was suggested
+const (
+	// The user already tagged links to the same host with it
+	TagSuggestionReasonHistory = "history"
+	// The tag name matches a label of the destination host (e.g. "github" for docs.github.com)
+	TagSuggestionReasonDomain = "domain"
+)
+
+type TagSuggestionService struct {
+	queries repository.TagSuggestionQueries
+	logger  logger.Logger
+}
+
+func NewTagSuggestionService(queries repository.TagSuggestionQueries, logger logger.Logger) *TagSuggestionService {
+	return &TagSuggestionService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// TagSuggestion is one of the user's existing tags that likely fits a new link
+type TagSuggestion struct {
+	ID     uuid.UUID
+	Name   string
+	Reason string
+}
+
+// SuggestTags suggests existing tags for a link to rawURL: first the tags the user
+// applied to earlier links to the same host, then tags named after the host itself.
+// It never creates tags.
+func (s *TagSuggestionService) SuggestTags(ctx context.Context, userID string, rawURL string) ([]TagSuggestion, error) {
+	if err := validateURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	// validateURL guarantees the URL parses and has a host
+	parsed, _ := url.Parse(rawURL)
+	host := suggestionHost(parsed.Hostname())
+
+	history, err := s.queries.SuggestTagsForHost(ctx, db.SuggestTagsForHostParams{
+		UserID:     userID,
+		Host:       host,
+		MaxResults: maxTagSuggestions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags used for host: %w", err)
+	}
+
+	suggestions := make([]TagSuggestion, 0, maxTagSuggestions)
+	seen := make(map[uuid.UUID]bool, maxTagSuggestions)
+
+	for _, tag := range history {
+		suggestions = append(suggestions, TagSuggestion{
+			ID:     tag.ID,
+			Name:   tag.Name,
+			Reason: TagSuggestionReasonHistory,
+		})
+		seen[tag.ID] = true
+	}
+
+	if len(suggestions) < maxTagSuggestions {
+		tags, err := s.queries.ListUserTags(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags: %w", err)
+		}
+
+		labels := hostLabels(host)
+		for _, tag := range tags {
+			if len(suggestions) == maxTagSuggestions {
+				break
+			}
+			if seen[tag.ID] || !labels[normalizeTagForMatch(tag.Name)] {
+				continue
+			}
+
+			suggestions = append(suggestions, TagSuggestion{
+				ID:     tag.ID,
+				Name:   tag.Name,
+				Reason: TagSuggestionReasonDomain,
+			})
+			seen[tag.ID] = true
+		}
+	}
+
+	s.logger.Debug("Tag suggestions computed",
+		zap.String("user_id", userID),
+		zap.String("host", host),
+		zap.Int("suggestions", len(suggestions)),
+	)
+
+	return suggestions, nil
+}
+
+// suggestionHost lowercases the host and drops a leading "www."
+// (must match the normalization in the SuggestTagsForHost query)
+func suggestionHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// hostLabels returns the labels of the host that can name a tag, leaving out the
+// top-level domain: "docs.github.com" gives {"docs", "github"}
+func hostLabels(host string) map[string]bool {
+	parts := strings.Split(host, ".")
+	if len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+
+	labels := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			labels[normalizeTagForMatch(part)] = true
+		}
+	}
+
+	return labels
+}
+
+// normalizeTagForMatch makes "GitHub", "git-hub" and "git_hub" compare equal
+func normalizeTagForMatch(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
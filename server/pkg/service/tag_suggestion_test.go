@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+)
+
+type mockTagSuggestionQueries struct {
+	listUserTags       []db.ListUserTagsRow
+	suggestTagsForHost []db.SuggestTagsForHostRow
+	requestedHost      string
+}
+
+func (m *mockTagSuggestionQueries) ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+	return m.listUserTags, nil
+}
+
+func (m *mockTagSuggestionQueries) SuggestTagsForHost(ctx context.Context, arg db.SuggestTagsForHostParams) ([]db.SuggestTagsForHostRow, error) {
+	m.requestedHost = arg.Host
+	return m.suggestTagsForHost, nil
+}
+
+func TestTagSuggestionService_SuggestTags(t *testing.T) {
+	docsTag := uuid.New()
+	githubTag := uuid.New()
+	newsTag := uuid.New()
+
+	queries := &mockTagSuggestionQueries{
+		suggestTagsForHost: []db.SuggestTagsForHostRow{
+			{ID: docsTag, Name: "docs", LinkCount: 3},
+		},
+		listUserTags: []db.ListUserTagsRow{
+			{ID: docsTag, Name: "docs"},
+			{ID: githubTag, Name: "Git-Hub"},
+			{ID: newsTag, Name: "news"},
+		},
+	}
+	s := NewTagSuggestionService(queries, createTestLogger())
+
+	suggestions, err := s.SuggestTags(context.Background(), "user_123", "https://WWW.GitHub.com/styltsou/url-shortener")
+	if err != nil {
+		t.Fatalf("SuggestTags() error = %v, want nil", err)
+	}
+
+	if queries.requestedHost != "github.com" {
+		t.Errorf("SuggestTagsForHost() host = %q, want %q", queries.requestedHost, "github.com")
+	}
+
+	expected := []TagSuggestion{
+		{ID: docsTag, Name: "docs", Reason: TagSuggestionReasonHistory},
+		{ID: githubTag, Name: "Git-Hub", Reason: TagSuggestionReasonDomain},
+	}
+	if len(suggestions) != len(expected) {
+		t.Fatalf("SuggestTags() returned %d suggestions, want %d: %+v", len(suggestions), len(expected), suggestions)
+	}
+	for i := range expected {
+		if suggestions[i] != expected[i] {
+			t.Errorf("SuggestTags()[%d] = %+v, want %+v", i, suggestions[i], expected[i])
+		}
+	}
+}
+
+func TestTagSuggestionService_SuggestTagsInvalidURL(t *testing.T) {
+	s := NewTagSuggestionService(&mockTagSuggestionQueries{}, createTestLogger())
+
+	if _, err := s.SuggestTags(context.Background(), "user_123", "not a url"); !errors.Is(err, apperrors.InvalidURL) {
+		t.Errorf("SuggestTags() error = %v, want %v", err, apperrors.InvalidURL)
+	}
+}
+
+func TestHostLabels(t *testing.T) {
+	labels := hostLabels("docs.github.com")
+
+	for _, label := range []string{"docs", "github"} {
+		if !labels[label] {
+			t.Errorf("hostLabels() missing %q", label)
+		}
+	}
+	if labels["com"] {
+		t.Errorf("hostLabels() should not include the top-level domain")
+	}
+}
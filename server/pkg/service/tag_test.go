@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestTagService_ListTags(t *testing.T) {
+	var got db.ListUserTagsPageParams
+	counted := false
+	s := NewTagService(&mocks.TagQueries{
+		ListUserTagsPageFunc: func(ctx context.Context, arg db.ListUserTagsPageParams) ([]db.ListUserTagsPageRow, error) {
+			got = arg
+			return []db.ListUserTagsPageRow{{Name: "work"}}, nil
+		},
+		CountUserTagsByPrefixFunc: func(ctx context.Context, arg db.CountUserTagsByPrefixParams) (int64, error) {
+			counted = true
+			return 120, nil
+		},
+	}, nil, createTestLogger())
+
+	result, err := s.ListTags(context.Background(), "user_123", TagListOptions{Prefix: "wo"}, 0, 0)
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if got.Sort != TagSortName || got.RowLimit != math.MaxInt32 || got.RowOffset != 0 || got.Prefix != "wo" {
+		t.Errorf("ListTags() without a page queried %+v, want all the tags by name", got)
+	}
+	if result.Meta != nil || counted {
+		t.Errorf("ListTags() without a page meta = %+v, want none", result.Meta)
+	}
+
+	result, err = s.ListTags(context.Background(), "user_123", TagListOptions{Sort: TagSortUsage}, 3, 500)
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if got.Sort != TagSortUsage || got.RowLimit != 200 || got.RowOffset != 400 {
+		t.Errorf("ListTags() of page 3 queried %+v, want 200 tags from 400", got)
+	}
+	if result.Meta == nil || result.Meta.Total != 120 || result.Meta.Page != 3 {
+		t.Errorf("ListTags() of page 3 meta = %+v, want page 3 of 120 tags", result.Meta)
+	}
+}
+
+func TestTagService_RestoreTag(t *testing.T) {
+	ctx := context.Background()
+	trashedID := uuid.New()
+
+	newService := func(live []db.ListUserTagsRow, policy *TagPolicy, recorded *[]string) *TagService {
+		return NewTagService(&mocks.TagQueries{
+			ListDeletedTagsFunc: func(ctx context.Context, userID string) ([]db.ListDeletedTagsRow, error) {
+				return []db.ListDeletedTagsRow{{ID: trashedID, Name: "Work", LinkCount: 2}}, nil
+			},
+			ListUserTagsFunc: func(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+				return live, nil
+			},
+			RestoreTagFunc: func(ctx context.Context, arg db.RestoreTagParams) (db.RestoreTagRow, error) {
+				return db.RestoreTagRow{ID: arg.ID, Name: "Work"}, nil
+			},
+			CreateActivityEventFunc: func(ctx context.Context, arg db.CreateActivityEventParams) error {
+				*recorded = append(*recorded, arg.Action)
+				return nil
+			},
+		}, policy, createTestLogger())
+	}
+
+	var recorded []string
+	if _, err := newService(nil, nil, &recorded).RestoreTag(ctx, "user_123", uuid.New()); !errors.Is(err, apperrors.TagNotFound) {
+		t.Errorf("RestoreTag() of a tag not in the trash error = %v, want %v", err, apperrors.TagNotFound)
+	}
+
+	live := []db.ListUserTagsRow{{ID: uuid.New(), Name: "work"}}
+	if _, err := newService(live, nil, &recorded).RestoreTag(ctx, "user_123", trashedID); !errors.Is(err, apperrors.TagNameTaken) {
+		t.Errorf("RestoreTag() over a live tag's name error = %v, want %v", err, apperrors.TagNameTaken)
+	}
+
+	live = []db.ListUserTagsRow{{ID: uuid.New(), Name: "home"}}
+	if _, err := newService(live, NewTagPolicy(1, false), &recorded).RestoreTag(ctx, "user_123", trashedID); !errors.Is(err, apperrors.TagQuotaExceeded) {
+		t.Errorf("RestoreTag() past the quota error = %v, want %v", err, apperrors.TagQuotaExceeded)
+	}
+	if len(recorded) != 0 {
+		t.Errorf("failed restores recorded %v, want nothing", recorded)
+	}
+
+	tag, err := newService(live, nil, &recorded).RestoreTag(ctx, "user_123", trashedID)
+	if err != nil {
+		t.Fatalf("RestoreTag() error = %v", err)
+	}
+	if tag.ID != trashedID {
+		t.Errorf("RestoreTag() = %+v, want the trashed tag", tag)
+	}
+	if len(recorded) != 1 || recorded[0] != ActivityTagRestored {
+		t.Errorf("RestoreTag() recorded %v, want %s", recorded, ActivityTagRestored)
+	}
+}
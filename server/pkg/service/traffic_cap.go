@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key prefix for traffic cap counters: "<prefix><link id>:total" and "<prefix><link id>:<day>"
+	trafficCapKeyPrefix = "traffic_cap:"
+	// Daily counters outlive their day so the last sync of the day still finds them
+	trafficCapDayTTL = 48 * time.Hour
+	// Upper bound for one counter sync
+	trafficCapSyncTimeout = time.Minute
+)
+
+// takeTrafficCapScript counts a click unless it would go over a cap.
+// KEYS: total counter, today's counter. ARGV: total cap, daily cap (0 for none), daily counter TTL in seconds.
+// Returns 1 when the click was counted, 0 when a cap is reached and -1 when the counters need seeding.
+var takeTrafficCapScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+local total = tonumber(redis.call("GET", KEYS[1]))
+local today = tonumber(redis.call("GET", KEYS[2]) or 0)
+local totalCap = tonumber(ARGV[1])
+local dailyCap = tonumber(ARGV[2])
+if (totalCap > 0 and total >= totalCap) or (dailyCap > 0 and today >= dailyCap) then
+	return 0
+end
+redis.call("INCR", KEYS[1])
+redis.call("INCR", KEYS[2])
+redis.call("EXPIRE", KEYS[2], ARGV[3])
+return 1
+`)
+
+func trafficCapKeys(linkID uuid.UUID, day time.Time) (total string, daily string) {
+	prefix := trafficCapKeyPrefix + linkID.String() + ":"
+	return prefix + "total", prefix + day.Format(time.DateOnly)
+}
+
+// trafficCapDay is the day daily caps count clicks for; days start at midnight UTC
+func trafficCapDay(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
+
+// TrafficCapReached reports whether the link's clicks currently go to its overflow URL.
+// The counters must be the live ones, see GetTrafficCap.
+func TrafficCapReached(c db.LinkTrafficCap) bool {
+	return (c.TotalCap != nil && c.TotalClicks >= int64(*c.TotalCap)) ||
+		(c.DailyCap != nil && c.DayClicks >= int64(*c.DailyCap))
+}
+
+/*
+SetTrafficCap caps the clicks one of the user's links sends to its destination,
+per day (UTC), in total or both; once a cap is reached its clicks go to the
+overflow URL instead, e.g. when an affiliate offer's budget is used up. Changing
+the caps of a capped link keeps the clicks already counted.
+*/
+func (s *LinkService) SetTrafficCap(ctx context.Context, userID string, linkID uuid.UUID, dailyCap *int32, totalCap *int32, overflowURL string) (db.LinkTrafficCap, error) {
+	if err := validateURL(overflowURL); err != nil {
+		return db.LinkTrafficCap{}, err
+	}
+
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkTrafficCap{}, fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return db.LinkTrafficCap{}, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	trafficCap, err := s.queries.UpsertLinkTrafficCap(ctx, db.UpsertLinkTrafficCapParams{
+		LinkID:      linkID,
+		DailyCap:    dailyCap,
+		TotalCap:    totalCap,
+		OverflowUrl: overflowURL,
+		Day:         pgtype.Date{Time: trafficCapDay(time.Now()), Valid: true},
+	})
+	if err != nil {
+		return db.LinkTrafficCap{}, fmt.Errorf("failed to store traffic cap: %w", err)
+	}
+
+	// A cached redirect would skip the cap
+	s.invalidateCache(ctx, link.Shortcode)
+
+	s.logger.Info("Traffic cap set",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+	)
+
+	return s.liveTrafficCap(ctx, trafficCap, time.Now()), nil
+}
+
+// GetTrafficCap returns the traffic cap of one of the user's links with its live counters
+func (s *LinkService) GetTrafficCap(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkTrafficCap{}, err
+	}
+
+	trafficCap, err := s.queries.GetLinkTrafficCap(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkTrafficCap{}, fmt.Errorf("%w: %v", apperrors.TrafficCapNotFound, err)
+		}
+		return db.LinkTrafficCap{}, fmt.Errorf("failed to get traffic cap: %w", err)
+	}
+
+	return s.liveTrafficCap(ctx, trafficCap, time.Now()), nil
+}
+
+// DeleteTrafficCap removes the traffic cap of one of the user's links, and its counters
+func (s *LinkService) DeleteTrafficCap(ctx context.Context, userID string, linkID uuid.UUID) (db.LinkTrafficCap, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.LinkTrafficCap{}, err
+	}
+
+	trafficCap, err := s.queries.DeleteLinkTrafficCap(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.LinkTrafficCap{}, fmt.Errorf("%w: %v", apperrors.TrafficCapNotFound, err)
+		}
+		return db.LinkTrafficCap{}, fmt.Errorf("failed to delete traffic cap: %w", err)
+	}
+
+	if s.counters != nil {
+		total, daily := trafficCapKeys(linkID, trafficCapDay(time.Now()))
+		if err := s.counters.Del(ctx, total, daily).Err(); err != nil {
+			s.logger.Warn("Failed to delete traffic cap counters",
+				zap.String("link_id", linkID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return trafficCap, nil
+}
+
+/*
+TakeTrafficCap counts a click on a capped link and reports whether it may go to
+the link's destination; once a cap is reached it returns false and the click
+belongs to the overflow URL. Links without caps always return true.
+
+Clicks are counted in Redis, seeded from the database counters the first time
+and synced back by TrafficCapSync. Without Redis they're counted in the database
+directly; when both fail the click goes to the destination.
+*/
+func (s *LinkService) TakeTrafficCap(ctx context.Context, link db.GetLinkForRedirectRow, now time.Time) bool {
+	if link.DailyCap == nil && link.TotalCap == nil {
+		return true
+	}
+
+	day := trafficCapDay(now)
+
+	if s.counters != nil {
+		taken, err := s.takeTrafficCapCached(ctx, link, day)
+		if err == nil {
+			return taken
+		}
+		s.logger.Warn("Redis traffic cap counter failed, counting in the database",
+			zap.String("link_id", link.ID.String()),
+			zap.Error(err),
+		)
+	}
+
+	rows, err := s.queries.TakeLinkTrafficCap(ctx, db.TakeLinkTrafficCapParams{
+		LinkID: link.ID,
+		Day:    pgtype.Date{Time: day, Valid: true},
+	})
+	if err != nil {
+		s.logger.Error("Failed to count capped click",
+			zap.String("link_id", link.ID.String()),
+			zap.Error(err),
+		)
+		return true
+	}
+	return rows > 0
+}
+
+func (s *LinkService) takeTrafficCapCached(ctx context.Context, link db.GetLinkForRedirectRow, day time.Time) (bool, error) {
+	total, daily := trafficCapKeys(link.ID, day)
+	args := []any{capValue(link.TotalCap), capValue(link.DailyCap), int(trafficCapDayTTL.Seconds())}
+
+	result, err := takeTrafficCapScript.Run(ctx, s.counters, []string{total, daily}, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	if result >= 0 {
+		return result == 1, nil
+	}
+
+	if err := s.seedTrafficCapCounters(ctx, link.ID, day); err != nil {
+		return false, err
+	}
+	result, err = takeTrafficCapScript.Run(ctx, s.counters, []string{total, daily}, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+// seedTrafficCapCounters starts the Redis counters off the database ones, e.g. after a Redis restart.
+// SETNX, so a counter another instance seeded or already counted on is kept.
+func (s *LinkService) seedTrafficCapCounters(ctx context.Context, linkID uuid.UUID, day time.Time) error {
+	trafficCap, err := s.queries.GetLinkTrafficCap(ctx, linkID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get traffic cap: %w", err)
+	}
+
+	total, daily := trafficCapKeys(linkID, day)
+	pipe := s.counters.TxPipeline()
+	pipe.SetNX(ctx, total, trafficCap.TotalClicks, 0)
+	if trafficCap.Day.Valid && trafficCap.Day.Time.Equal(day) {
+		pipe.SetNX(ctx, daily, trafficCap.DayClicks, trafficCapDayTTL)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// liveTrafficCap replaces the database counters, which lag behind, with the Redis ones when there are any
+func (s *LinkService) liveTrafficCap(ctx context.Context, trafficCap db.LinkTrafficCap, now time.Time) db.LinkTrafficCap {
+	day := trafficCapDay(now)
+	if !trafficCap.Day.Valid || !trafficCap.Day.Time.Equal(day) {
+		trafficCap.Day = pgtype.Date{Time: day, Valid: true}
+		trafficCap.DayClicks = 0
+	}
+
+	if s.counters == nil {
+		return trafficCap
+	}
+
+	total, daily := trafficCapKeys(trafficCap.LinkID, day)
+	values, err := s.counters.MGet(ctx, total, daily).Result()
+	if err != nil {
+		s.logger.Warn("Failed to read traffic cap counters",
+			zap.String("link_id", trafficCap.LinkID.String()),
+			zap.Error(err),
+		)
+		return trafficCap
+	}
+	if n, ok := counterValue(values[0]); ok {
+		trafficCap.TotalClicks = max(trafficCap.TotalClicks, n)
+	}
+	if n, ok := counterValue(values[1]); ok {
+		trafficCap.DayClicks = max(trafficCap.DayClicks, n)
+	}
+	return trafficCap
+}
+
+// capValue is a cap as the counting script takes it, 0 for none
+func capValue(c *int32) int64 {
+	if c == nil {
+		return 0
+	}
+	return int64(*c)
+}
+
+// counterValue parses a counter read with MGET; missing keys come back as nil
+func counterValue(v any) (int64, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+/*
+TrafficCapSync copies the traffic cap counters kept in Redis to the database,
+so they survive Redis restarts and show in exports and backups. Counters in the
+database only move forward, so every instance can run it without a lock.
+*/
+type TrafficCapSync struct {
+	queries repository.TrafficCapSyncQueries
+	cache   *redis.Client
+	logger  logger.Logger
+}
+
+func NewTrafficCapSync(queries repository.TrafficCapSyncQueries, cache *redis.Client, logger logger.Logger) *TrafficCapSync {
+	return &TrafficCapSync{
+		queries: queries,
+		cache:   cache,
+		logger:  logger,
+	}
+}
+
+// Run syncs the counters of every capped link as of now
+func (t *TrafficCapSync) Run(ctx context.Context, now time.Time) error {
+	linkIDs, err := t.queries.ListTrafficCappedLinkIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list capped links: %w", err)
+	}
+
+	day := trafficCapDay(now)
+	synced := 0
+	for _, linkID := range linkIDs {
+		total, daily := trafficCapKeys(linkID, day)
+		values, err := t.cache.MGet(ctx, total, daily).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read traffic cap counters: %w", err)
+		}
+
+		totalClicks, ok := counterValue(values[0])
+		if !ok {
+			// Not counted in Redis since it was last seeded
+			continue
+		}
+		dayClicks, _ := counterValue(values[1])
+
+		if err := t.queries.SyncLinkTrafficCapCounters(ctx, db.SyncLinkTrafficCapCountersParams{
+			LinkID:      linkID,
+			TotalClicks: totalClicks,
+			Day:         pgtype.Date{Time: day, Valid: true},
+			DayClicks:   dayClicks,
+		}); err != nil {
+			return fmt.Errorf("failed to sync traffic cap counters: %w", err)
+		}
+		synced++
+	}
+
+	t.logger.Debug("Traffic cap counters synced",
+		zap.Int("links", synced),
+	)
+	return nil
+}
+
+// Start syncs now and then every interval until ctx is done
+func (t *TrafficCapSync) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			t.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (t *TrafficCapSync) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, trafficCapSyncTimeout)
+	defer cancel()
+
+	if err := t.Run(ctx, time.Now()); err != nil && ctx.Err() == nil {
+		t.logger.Error("Traffic cap sync failed",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_TakeTrafficCap(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	dailyCap := int32(2)
+	totalCap := int32(3)
+	overflow := "https://example.com/backup"
+	link := db.GetLinkForRedirectRow{ID: uuid.New(), DailyCap: &dailyCap, TotalCap: &totalCap, OverflowUrl: &overflow}
+
+	mr := miniredis.RunT(t)
+	mockQueries := &mocks.LinkQueries{
+		// Seeds the counters with one click already counted today
+		GetLinkTrafficCapFunc: func(ctx context.Context, linkID uuid.UUID) (db.LinkTrafficCap, error) {
+			return db.LinkTrafficCap{
+				LinkID:      linkID,
+				TotalClicks: 1,
+				Day:         pgtype.Date{Time: trafficCapDay(now), Valid: true},
+				DayClicks:   1,
+			}, nil
+		},
+	}
+	service := &LinkService{
+		queries:  mockQueries,
+		counters: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		logger:   createTestLogger(),
+	}
+	ctx := context.Background()
+
+	if !service.TakeTrafficCap(ctx, link, now) {
+		t.Fatal("TakeTrafficCap() = false for the second click of the day, want true")
+	}
+	if service.TakeTrafficCap(ctx, link, now) {
+		t.Fatal("TakeTrafficCap() = true over the daily cap, want false")
+	}
+
+	// A new day resets the daily cap but not the total one
+	tomorrow := now.AddDate(0, 0, 1)
+	if !service.TakeTrafficCap(ctx, link, tomorrow) {
+		t.Fatal("TakeTrafficCap() = false on a new day, want true")
+	}
+	if service.TakeTrafficCap(ctx, link, tomorrow) {
+		t.Fatal("TakeTrafficCap() = true over the total cap, want false")
+	}
+
+	total, _ := trafficCapKeys(link.ID, now)
+	if got, _ := mr.Get(total); got != "3" {
+		t.Errorf("total counter = %s, want 3 (overflow clicks don't count)", got)
+	}
+
+	if !service.TakeTrafficCap(ctx, db.GetLinkForRedirectRow{ID: uuid.New()}, now) {
+		t.Error("TakeTrafficCap() = false for a link without caps, want true")
+	}
+}
+
+func TestLinkService_TakeTrafficCapWithoutRedis(t *testing.T) {
+	dailyCap := int32(10)
+	link := db.GetLinkForRedirectRow{ID: uuid.New(), DailyCap: &dailyCap}
+
+	tests := []struct {
+		name     string
+		rows     int64
+		err      error
+		expected bool
+	}{
+		{name: "counted in the database", rows: 1, expected: true},
+		{name: "cap reached", rows: 0, expected: false},
+		{name: "database error lets the click through", err: errors.New("connection refused"), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := &mocks.LinkQueries{
+				TakeLinkTrafficCapFunc: func(ctx context.Context, arg db.TakeLinkTrafficCapParams) (int64, error) {
+					if arg.LinkID != link.ID {
+						t.Errorf("TakeLinkTrafficCap() link = %v, want %v", arg.LinkID, link.ID)
+					}
+					return tt.rows, tt.err
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			if got := service.TakeTrafficCap(context.Background(), link, time.Now()); got != tt.expected {
+				t.Errorf("TakeTrafficCap() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLinkService_GetTrafficCap(t *testing.T) {
+	now := time.Now()
+	totalCap := int32(5)
+	linkID := uuid.New()
+
+	mr := miniredis.RunT(t)
+	total, daily := trafficCapKeys(linkID, trafficCapDay(now))
+	mr.Set(total, "5")
+	mr.Set(daily, "2")
+
+	mockQueries := &mocks.LinkQueries{
+		GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+			return db.GetLinkByIdAndUserRow{ID: arg.ID}, nil
+		},
+		// Synced before the last clicks, and on an earlier day
+		GetLinkTrafficCapFunc: func(ctx context.Context, id uuid.UUID) (db.LinkTrafficCap, error) {
+			return db.LinkTrafficCap{
+				LinkID:      id,
+				TotalCap:    &totalCap,
+				TotalClicks: 3,
+				Day:         pgtype.Date{Time: trafficCapDay(now).AddDate(0, 0, -1), Valid: true},
+				DayClicks:   3,
+			}, nil
+		},
+	}
+	service := &LinkService{
+		queries:  mockQueries,
+		counters: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		logger:   createTestLogger(),
+	}
+
+	trafficCap, err := service.GetTrafficCap(context.Background(), "user_123", linkID)
+	if err != nil {
+		t.Fatalf("GetTrafficCap() error = %v, want nil", err)
+	}
+	if trafficCap.TotalClicks != 5 || trafficCap.DayClicks != 2 {
+		t.Errorf("GetTrafficCap() clicks = %d total, %d today; want 5 and 2", trafficCap.TotalClicks, trafficCap.DayClicks)
+	}
+	if !TrafficCapReached(trafficCap) {
+		t.Error("TrafficCapReached() = false, want true")
+	}
+}
+
+type mockTrafficCapSyncQueries struct {
+	linkIDs []uuid.UUID
+	synced  []db.SyncLinkTrafficCapCountersParams
+}
+
+func (m *mockTrafficCapSyncQueries) ListTrafficCappedLinkIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return m.linkIDs, nil
+}
+
+func (m *mockTrafficCapSyncQueries) SyncLinkTrafficCapCounters(ctx context.Context, arg db.SyncLinkTrafficCapCountersParams) error {
+	m.synced = append(m.synced, arg)
+	return nil
+}
+
+func TestTrafficCapSync_Run(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	counted, untouched := uuid.New(), uuid.New()
+
+	mr := miniredis.RunT(t)
+	total, daily := trafficCapKeys(counted, now)
+	mr.Set(total, "42")
+	mr.Set(daily, "7")
+
+	queries := &mockTrafficCapSyncQueries{linkIDs: []uuid.UUID{counted, untouched}}
+	sync := NewTrafficCapSync(queries, redis.NewClient(&redis.Options{Addr: mr.Addr()}), createTestLogger())
+
+	if err := sync.Run(context.Background(), now); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+
+	if len(queries.synced) != 1 {
+		t.Fatalf("Run() synced %d links, want 1 (links without Redis counters are skipped)", len(queries.synced))
+	}
+	got := queries.synced[0]
+	if got.LinkID != counted || got.TotalClicks != 42 || got.DayClicks != 7 || !got.Day.Time.Equal(trafficCapDay(now)) {
+		t.Errorf("Run() synced %+v", got)
+	}
+}
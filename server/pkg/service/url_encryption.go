@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/urlcrypt"
+	"go.uber.org/zap"
+)
+
+// Rows read per batch unless the caller sets it
+const DefaultURLEncryptionBatchSize = 500
+
+// URLEncryptionTally is what a run did to one table
+type URLEncryptionTally struct {
+	Table     string
+	Rows      int64
+	Rewritten int64
+}
+
+/*
+URLEncryption rewrites the destination URLs already stored, for cmd/encrypturls:
+after encryption is turned on it encrypts the rows written before, and after a
+new key is put first in the keyring it re-encrypts the rows sealed with the old
+ones, which can then be dropped. With decrypt set it writes every URL back in
+plain text instead, before encryption is turned off.
+
+It needs queries that see the stored values, not ones wrapped by db.Encrypted.
+Runs are idempotent, so an interrupted one is simply started again; rows the
+app changes while it runs are left to the app, which writes them with the active key.
+*/
+type URLEncryption struct {
+	queries   repository.URLEncryptionQueries
+	keys      *urlcrypt.Keyring
+	decrypt   bool
+	batchSize int32
+	logger    logger.Logger
+}
+
+func NewURLEncryption(queries repository.URLEncryptionQueries, keys *urlcrypt.Keyring, decrypt bool, batchSize int32, logger logger.Logger) *URLEncryption {
+	if batchSize <= 0 {
+		batchSize = DefaultURLEncryptionBatchSize
+	}
+
+	return &URLEncryption{
+		queries:   queries,
+		keys:      keys,
+		decrypt:   decrypt,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run goes through every table holding destination URLs, batch by batch, until all are done or ctx is canceled
+func (e *URLEncryption) Run(ctx context.Context) ([]URLEncryptionTally, error) {
+	tables := []struct {
+		name  string
+		batch func(ctx context.Context, after uuid.UUID, tally *URLEncryptionTally) (uuid.UUID, int, error)
+	}{
+		{"links", e.linksBatch},
+		{"link_destination_changes", e.destinationChangesBatch},
+		{"link_destination_checks", e.destinationChecksBatch},
+		{"link_destination_alerts", e.destinationAlertsBatch},
+		{"link_https_upgrades", e.httpsUpgradesBatch},
+	}
+
+	tallies := make([]URLEncryptionTally, 0, len(tables))
+	for _, table := range tables {
+		tally := URLEncryptionTally{Table: table.name}
+		after := uuid.Nil
+		for {
+			if err := ctx.Err(); err != nil {
+				return append(tallies, tally), err
+			}
+
+			last, n, err := table.batch(ctx, after, &tally)
+			if err != nil {
+				return append(tallies, tally), fmt.Errorf("%s: %w", table.name, err)
+			}
+			if n == 0 {
+				break
+			}
+			after = last
+		}
+
+		e.logger.Info("Destination URLs rewritten",
+			zap.String("table", tally.Table),
+			zap.Int64("rows", tally.Rows),
+			zap.Int64("rewritten", tally.Rewritten),
+		)
+		tallies = append(tallies, tally)
+	}
+
+	return tallies, nil
+}
+
+func (e *URLEncryption) linksBatch(ctx context.Context, after uuid.UUID, tally *URLEncryptionTally) (uuid.UUID, int, error) {
+	rows, err := e.queries.ListLinkURLs(ctx, db.ListLinkURLsParams{AfterID: after, RowLimit: e.batchSize})
+	if err != nil || len(rows) == 0 {
+		return after, 0, err
+	}
+
+	for _, row := range rows {
+		tally.Rows++
+		originalURL, changed, err := e.rewrite(row.OriginalUrl, false)
+		if err != nil {
+			return after, 0, fmt.Errorf("link %s: %w", row.ID, err)
+		}
+		rawURL, changed, err := e.rewriteOptional(row.RawUrl, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("link %s: %w", row.ID, err)
+		}
+		httpDestination, changed, err := e.httpDestination(row.OriginalUrl, row.HttpDestination, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("link %s: %w", row.ID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := e.queries.SetLinkURLs(ctx, db.SetLinkURLsParams{
+			OriginalUrl:     originalURL,
+			RawUrl:          rawURL,
+			HttpDestination: httpDestination,
+			ID:              row.ID,
+			OldOriginalUrl:  row.OriginalUrl,
+			OldRawUrl:       row.RawUrl,
+		})
+		if err != nil {
+			return after, 0, fmt.Errorf("failed to update link %s: %w", row.ID, err)
+		}
+		tally.Rewritten += n
+	}
+
+	return rows[len(rows)-1].ID, len(rows), nil
+}
+
+func (e *URLEncryption) destinationChangesBatch(ctx context.Context, after uuid.UUID, tally *URLEncryptionTally) (uuid.UUID, int, error) {
+	rows, err := e.queries.ListDestinationChangeURLs(ctx, db.ListDestinationChangeURLsParams{AfterID: after, RowLimit: e.batchSize})
+	if err != nil || len(rows) == 0 {
+		return after, 0, err
+	}
+
+	for _, row := range rows {
+		tally.Rows++
+		rawURL, changed, err := e.rewrite(row.RawUrl, false)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination change %s: %w", row.ID, err)
+		}
+		url, changed, err := e.rewrite(row.Url, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination change %s: %w", row.ID, err)
+		}
+		previousURL, changed, err := e.rewriteOptional(row.PreviousUrl, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination change %s: %w", row.ID, err)
+		}
+		httpDestination, changed, err := e.httpDestination(row.Url, row.HttpDestination, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination change %s: %w", row.ID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := e.queries.SetDestinationChangeURLs(ctx, db.SetDestinationChangeURLsParams{
+			RawUrl:          rawURL,
+			Url:             url,
+			PreviousUrl:     previousURL,
+			HttpDestination: httpDestination,
+			ID:              row.ID,
+			OldRawUrl:       row.RawUrl,
+			OldUrl:          row.Url,
+			OldPreviousUrl:  row.PreviousUrl,
+		})
+		if err != nil {
+			return after, 0, fmt.Errorf("failed to update destination change %s: %w", row.ID, err)
+		}
+		tally.Rewritten += n
+	}
+
+	return rows[len(rows)-1].ID, len(rows), nil
+}
+
+func (e *URLEncryption) destinationChecksBatch(ctx context.Context, after uuid.UUID, tally *URLEncryptionTally) (uuid.UUID, int, error) {
+	rows, err := e.queries.ListDestinationCheckURLs(ctx, db.ListDestinationCheckURLsParams{AfterID: after, RowLimit: e.batchSize})
+	if err != nil || len(rows) == 0 {
+		return after, 0, err
+	}
+
+	for _, row := range rows {
+		tally.Rows++
+		url, changed, err := e.rewrite(row.Url, false)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination check of link %s: %w", row.LinkID, err)
+		}
+		finalURL, changed, err := e.rewriteOptional(row.FinalUrl, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination check of link %s: %w", row.LinkID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := e.queries.SetDestinationCheckURLs(ctx, db.SetDestinationCheckURLsParams{
+			Url:         url,
+			FinalUrl:    finalURL,
+			LinkID:      row.LinkID,
+			OldUrl:      row.Url,
+			OldFinalUrl: row.FinalUrl,
+		})
+		if err != nil {
+			return after, 0, fmt.Errorf("failed to update destination check of link %s: %w", row.LinkID, err)
+		}
+		tally.Rewritten += n
+	}
+
+	return rows[len(rows)-1].LinkID, len(rows), nil
+}
+
+func (e *URLEncryption) destinationAlertsBatch(ctx context.Context, after uuid.UUID, tally *URLEncryptionTally) (uuid.UUID, int, error) {
+	rows, err := e.queries.ListDestinationAlertURLs(ctx, db.ListDestinationAlertURLsParams{AfterID: after, RowLimit: e.batchSize})
+	if err != nil || len(rows) == 0 {
+		return after, 0, err
+	}
+
+	for _, row := range rows {
+		tally.Rows++
+		url, changed, err := e.rewrite(row.Url, false)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination alert %s: %w", row.ID, err)
+		}
+		finalURL, changed, err := e.rewriteOptional(row.FinalUrl, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("destination alert %s: %w", row.ID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := e.queries.SetDestinationAlertURLs(ctx, db.SetDestinationAlertURLsParams{
+			Url:         url,
+			FinalUrl:    finalURL,
+			ID:          row.ID,
+			OldUrl:      row.Url,
+			OldFinalUrl: row.FinalUrl,
+		})
+		if err != nil {
+			return after, 0, fmt.Errorf("failed to update destination alert %s: %w", row.ID, err)
+		}
+		tally.Rewritten += n
+	}
+
+	return rows[len(rows)-1].ID, len(rows), nil
+}
+
+func (e *URLEncryption) httpsUpgradesBatch(ctx context.Context, after uuid.UUID, tally *URLEncryptionTally) (uuid.UUID, int, error) {
+	rows, err := e.queries.ListHTTPSUpgradeURLs(ctx, db.ListHTTPSUpgradeURLsParams{AfterID: after, RowLimit: e.batchSize})
+	if err != nil || len(rows) == 0 {
+		return after, 0, err
+	}
+
+	for _, row := range rows {
+		tally.Rows++
+		fromURL, changed, err := e.rewrite(row.FromUrl, false)
+		if err != nil {
+			return after, 0, fmt.Errorf("HTTPS upgrade of link %s: %w", row.LinkID, err)
+		}
+		toURL, changed, err := e.rewrite(row.ToUrl, changed)
+		if err != nil {
+			return after, 0, fmt.Errorf("HTTPS upgrade of link %s: %w", row.LinkID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := e.queries.SetHTTPSUpgradeURLs(ctx, db.SetHTTPSUpgradeURLsParams{
+			FromUrl:    fromURL,
+			ToUrl:      toURL,
+			LinkID:     row.LinkID,
+			OldFromUrl: row.FromUrl,
+			OldToUrl:   row.ToUrl,
+		})
+		if err != nil {
+			return after, 0, fmt.Errorf("failed to update HTTPS upgrade of link %s: %w", row.LinkID, err)
+		}
+		tally.Rewritten += n
+	}
+
+	return rows[len(rows)-1].LinkID, len(rows), nil
+}
+
+// rewrite returns the value as it should be stored, and whether it or one rewritten before it in the row changed
+func (e *URLEncryption) rewrite(value string, changed bool) (string, bool, error) {
+	plain, err := e.keys.Decrypt(value)
+	if err != nil {
+		return value, changed, err
+	}
+
+	if e.decrypt {
+		return plain, changed || plain != value, nil
+	}
+	if e.keys.Current(value) {
+		return value, changed, nil
+	}
+	return e.keys.Encrypt(plain), true, nil
+}
+
+// httpDestination returns the http_destination flag of the stored URL, and whether it or a value
+// rewritten before it in the row changed, correcting a flag that doesn't match the URL
+func (e *URLEncryption) httpDestination(stored string, flagged bool, changed bool) (bool, bool, error) {
+	plain, err := e.keys.Decrypt(stored)
+	if err != nil {
+		return flagged, changed, err
+	}
+	isHTTP := isHTTPDestination(plain)
+	return isHTTP, changed || isHTTP != flagged, nil
+}
+
+func (e *URLEncryption) rewriteOptional(value *string, changed bool) (*string, bool, error) {
+	if value == nil {
+		return nil, changed, nil
+	}
+	rewritten, changed, err := e.rewrite(*value, changed)
+	return &rewritten, changed, err
+}
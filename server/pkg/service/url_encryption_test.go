@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+	"github.com/styltsou/url-shortener/server/pkg/urlcrypt"
+)
+
+func testURLKeys(t *testing.T, ids ...string) *urlcrypt.Keyring {
+	t.Helper()
+	var entries []string
+	for _, id := range ids {
+		entries = append(entries, id+":"+base64.StdEncoding.EncodeToString([]byte(strings.Repeat(id[:1], urlcrypt.KeySize))))
+	}
+	keys, err := urlcrypt.ParseKeys(strings.Join(entries, ","))
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	return keys
+}
+
+// urlEncryptionQueries serves links from a map, in batches; the other tables are empty
+func urlEncryptionQueries(links map[uuid.UUID]db.ListLinkURLsRow) *mocks.URLEncryptionQueries {
+	return &mocks.URLEncryptionQueries{
+		ListLinkURLsFunc: func(ctx context.Context, arg db.ListLinkURLsParams) ([]db.ListLinkURLsRow, error) {
+			var rows []db.ListLinkURLsRow
+			for _, row := range links {
+				if strings.Compare(row.ID.String(), arg.AfterID.String()) > 0 {
+					rows = append(rows, row)
+				}
+			}
+			slices.SortFunc(rows, func(a, b db.ListLinkURLsRow) int {
+				return strings.Compare(a.ID.String(), b.ID.String())
+			})
+			if len(rows) > int(arg.RowLimit) {
+				rows = rows[:arg.RowLimit]
+			}
+			return rows, nil
+		},
+		SetLinkURLsFunc: func(ctx context.Context, arg db.SetLinkURLsParams) (int64, error) {
+			row := links[arg.ID]
+			if row.OriginalUrl != arg.OldOriginalUrl {
+				return 0, nil
+			}
+			links[arg.ID] = db.ListLinkURLsRow{ID: arg.ID, OriginalUrl: arg.OriginalUrl, RawUrl: arg.RawUrl, HttpDestination: arg.HttpDestination}
+			return 1, nil
+		},
+		ListDestinationChangeURLsFunc: func(ctx context.Context, arg db.ListDestinationChangeURLsParams) ([]db.ListDestinationChangeURLsRow, error) {
+			return nil, nil
+		},
+		ListDestinationCheckURLsFunc: func(ctx context.Context, arg db.ListDestinationCheckURLsParams) ([]db.ListDestinationCheckURLsRow, error) {
+			return nil, nil
+		},
+		ListDestinationAlertURLsFunc: func(ctx context.Context, arg db.ListDestinationAlertURLsParams) ([]db.ListDestinationAlertURLsRow, error) {
+			return nil, nil
+		},
+		ListHTTPSUpgradeURLsFunc: func(ctx context.Context, arg db.ListHTTPSUpgradeURLsParams) ([]db.ListHTTPSUpgradeURLsRow, error) {
+			return nil, nil
+		},
+	}
+}
+
+func TestURLEncryption_Run(t *testing.T) {
+	oldKeys := testURLKeys(t, "k1")
+	keys := testURLKeys(t, "k2", "k1")
+	raw := "https://example.com/{path}"
+
+	plain, sealedOld, current, unflagged := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	links := map[uuid.UUID]db.ListLinkURLsRow{
+		plain:     {ID: plain, OriginalUrl: "https://example.com/a", RawUrl: &raw},
+		sealedOld: {ID: sealedOld, OriginalUrl: oldKeys.Encrypt("https://example.com/b")},
+		current:   {ID: current, OriginalUrl: keys.Encrypt("https://example.com/c")},
+		// Its flag doesn't match the URL, as if written by hand
+		unflagged: {ID: unflagged, OriginalUrl: keys.Encrypt("http://example.com/d")},
+	}
+	queries := urlEncryptionQueries(links)
+
+	tallies, err := NewURLEncryption(queries, keys, false, 2, createTestLogger()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if tallies[0].Table != "links" || tallies[0].Rows != 4 || tallies[0].Rewritten != 3 {
+		t.Errorf("links tally = %+v, want 4 rows, 3 rewritten", tallies[0])
+	}
+	if !links[unflagged].HttpDestination || links[unflagged].OriginalUrl != keys.Encrypt("http://example.com/d") {
+		t.Errorf("unflagged link = %+v, want it flagged as an http:// destination", links[unflagged])
+	}
+
+	for id, want := range map[uuid.UUID]string{plain: "https://example.com/a", sealedOld: "https://example.com/b", current: "https://example.com/c"} {
+		if stored := links[id].OriginalUrl; stored != keys.Encrypt(want) {
+			t.Errorf("link %s original_url = %q, want %s encrypted with the active key", id, stored, want)
+		}
+	}
+	if stored := links[plain].RawUrl; stored == nil || *stored != keys.Encrypt(raw) {
+		t.Errorf("raw_url = %v, want it encrypted with the active key", stored)
+	}
+
+	// A second run has nothing left to do
+	tallies, err = NewURLEncryption(queries, keys, false, 2, createTestLogger()).Run(context.Background())
+	if err != nil || tallies[0].Rewritten != 0 {
+		t.Errorf("second Run() = %+v, %v, want nothing rewritten", tallies, err)
+	}
+
+	// Decrypting writes the plain URLs back
+	if _, err := NewURLEncryption(queries, keys, true, 2, createTestLogger()).Run(context.Background()); err != nil {
+		t.Fatalf("Run() decrypt error = %v", err)
+	}
+	if stored := links[sealedOld].OriginalUrl; stored != "https://example.com/b" {
+		t.Errorf("decrypted original_url = %q, want the plain URL", stored)
+	}
+}
+
+// Values sealed with a key that was dropped too early stop the run instead of being skipped
+func TestURLEncryption_UnknownKey(t *testing.T) {
+	id := uuid.New()
+	links := map[uuid.UUID]db.ListLinkURLsRow{
+		id: {ID: id, OriginalUrl: testURLKeys(t, "k1").Encrypt("https://example.com/")},
+	}
+
+	_, err := NewURLEncryption(urlEncryptionQueries(links), testURLKeys(t, "k2"), false, 10, createTestLogger()).Run(context.Background())
+	if !errors.Is(err, urlcrypt.ErrUnknownKey) {
+		t.Errorf("Run() error = %v, want %v", err, urlcrypt.ErrUnknownKey)
+	}
+}
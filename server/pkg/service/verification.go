@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Kinds of verified senders
+const (
+	// The value is a user ID: every link the user creates is verified
+	VerifiedSenderUser = "user"
+	// The value is a hostname: every link pointing at it, or at one of its subdomains, is verified
+	VerifiedSenderDomain = "domain"
+)
+
+// VerificationService manages verified senders, the users and destination domains
+// admins vouch for. Their links show a "verified sender" badge to visitors.
+type VerificationService struct {
+	queries repository.VerificationQueries
+	logger  logger.Logger
+}
+
+func NewVerificationService(queries repository.VerificationQueries, logger logger.Logger) *VerificationService {
+	return &VerificationService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Verify marks a user or domain as a verified sender, or renames one that already is
+func (s *VerificationService) Verify(ctx context.Context, adminID string, kind string, value string, name *string) (db.VerifiedSender, error) {
+	value, err := normalizeSenderValue(kind, value)
+	if err != nil {
+		return db.VerifiedSender{}, err
+	}
+
+	sender, err := s.queries.UpsertVerifiedSender(ctx, db.UpsertVerifiedSenderParams{
+		Kind:       kind,
+		Value:      value,
+		Name:       name,
+		VerifiedBy: adminID,
+	})
+	if err != nil {
+		return db.VerifiedSender{}, fmt.Errorf("failed to store verified sender: %w", err)
+	}
+
+	s.logger.Info("Sender verified",
+		zap.String("admin_id", adminID),
+		zap.String("kind", kind),
+		zap.String("value", value),
+	)
+
+	return sender, nil
+}
+
+// Unverify removes a sender's verification; its links stop showing the badge
+func (s *VerificationService) Unverify(ctx context.Context, adminID string, kind string, value string) (db.VerifiedSender, error) {
+	value, err := normalizeSenderValue(kind, value)
+	if err != nil {
+		return db.VerifiedSender{}, err
+	}
+
+	sender, err := s.queries.DeleteVerifiedSender(ctx, db.DeleteVerifiedSenderParams{
+		Kind:  kind,
+		Value: value,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.VerifiedSender{}, fmt.Errorf("%w: %v", apperrors.VerifiedSenderNotFound, err)
+		}
+		return db.VerifiedSender{}, fmt.Errorf("failed to delete verified sender: %w", err)
+	}
+
+	s.logger.Info("Sender verification removed",
+		zap.String("admin_id", adminID),
+		zap.String("kind", kind),
+		zap.String("value", value),
+	)
+
+	return sender, nil
+}
+
+type ListVerifiedSendersResult struct {
+	Senders []db.VerifiedSender
+	pagination.Meta
+}
+
+// ListVerifiedSenders returns a page of verified senders, most recently verified first
+func (s *VerificationService) ListVerifiedSenders(ctx context.Context, page, limit int) (*ListVerifiedSendersResult, error) {
+	p := pagination.Default.Page(page, limit)
+
+	total, err := s.queries.CountVerifiedSenders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count verified senders: %w", err)
+	}
+
+	senders, err := s.queries.ListVerifiedSenders(ctx, db.ListVerifiedSendersParams{
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verified senders: %w", err)
+	}
+
+	return &ListVerifiedSendersResult{
+		Senders: senders,
+		Meta:    p.Meta(total),
+	}, nil
+}
+
+// SenderVerification returns the verification covering the link behind a shortcode, if any:
+// its owner's, else that of the domain of destination. The owner is looked up by shortcode
+// since cached redirects don't carry it.
+func (s *LinkService) SenderVerification(ctx context.Context, shortcode string, destination string) (db.VerifiedSender, bool, error) {
+	sender, err := s.queries.GetSenderVerification(ctx, db.GetSenderVerificationParams{
+		Shortcode: shortcode,
+		Domains:   senderDomains(destination),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.VerifiedSender{}, false, nil
+		}
+		return db.VerifiedSender{}, false, fmt.Errorf("failed to get sender verification: %w", err)
+	}
+
+	return sender, true, nil
+}
+
+// normalizeSenderValue lowercases domains, so they compare equal to destination hostnames
+func normalizeSenderValue(kind string, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if kind != VerifiedSenderDomain {
+		return value, nil
+	}
+
+	domain := strings.TrimSuffix(strings.ToLower(value), ".")
+	u, err := url.Parse("http://" + domain)
+	if err != nil || u.Host != domain || u.Port() != "" || !strings.Contains(domain, ".") || net.ParseIP(domain) != nil {
+		return "", fmt.Errorf("%w: %q", apperrors.InvalidDomain, value)
+	}
+	return domain, nil
+}
+
+// senderDomains returns the destination's hostname and its parent domains,
+// e.g. shop.example.com and example.com. IP addresses have no parents.
+func senderDomains(destination string) []string {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return []string{}
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return []string{}
+	}
+	if net.ParseIP(host) != nil {
+		return []string{host}
+	}
+
+	domains := []string{host}
+	for {
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+		// Top-level domains can't be verified
+		if !strings.Contains(host, ".") {
+			break
+		}
+		domains = append(domains, host)
+	}
+	return domains
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestVerificationService_Verify(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind        string
+		value       string
+		wantValue   string
+		expectedErr error
+	}{
+		{name: "user", kind: VerifiedSenderUser, value: "user_123", wantValue: "user_123"},
+		{name: "domain is lowercased", kind: VerifiedSenderDomain, value: "Example.COM.", wantValue: "example.com"},
+		{name: "URL isn't a domain", kind: VerifiedSenderDomain, value: "https://example.com/", expectedErr: apperrors.InvalidDomain},
+		{name: "top-level domain", kind: VerifiedSenderDomain, value: "com", expectedErr: apperrors.InvalidDomain},
+		{name: "IP address", kind: VerifiedSenderDomain, value: "93.184.216.34", expectedErr: apperrors.InvalidDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored *db.UpsertVerifiedSenderParams
+			mockQueries := &mocks.VerificationQueries{
+				UpsertVerifiedSenderFunc: func(ctx context.Context, arg db.UpsertVerifiedSenderParams) (db.VerifiedSender, error) {
+					stored = &arg
+					return db.VerifiedSender{Kind: arg.Kind, Value: arg.Value, VerifiedBy: arg.VerifiedBy}, nil
+				},
+			}
+			service := NewVerificationService(mockQueries, createTestLogger())
+
+			_, err := service.Verify(context.Background(), "admin_1", tt.kind, tt.value, nil)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Verify() error = %v, want %v", err, tt.expectedErr)
+				}
+				if stored != nil {
+					t.Error("Verify() stored a sender despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() unexpected error = %v", err)
+			}
+			if stored == nil || stored.Value != tt.wantValue || stored.VerifiedBy != "admin_1" {
+				t.Errorf("Verify() stored %+v, want %q verified by admin_1", stored, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestVerificationService_UnverifyNotFound(t *testing.T) {
+	mockQueries := &mocks.VerificationQueries{
+		DeleteVerifiedSenderFunc: func(ctx context.Context, arg db.DeleteVerifiedSenderParams) (db.VerifiedSender, error) {
+			return db.VerifiedSender{}, sql.ErrNoRows
+		},
+	}
+	service := NewVerificationService(mockQueries, createTestLogger())
+
+	if _, err := service.Unverify(context.Background(), "admin_1", VerifiedSenderUser, "user_123"); !errors.Is(err, apperrors.VerifiedSenderNotFound) {
+		t.Errorf("Unverify() error = %v, want %v", err, apperrors.VerifiedSenderNotFound)
+	}
+}
+
+func TestLinkService_SenderVerification(t *testing.T) {
+	var asked db.GetSenderVerificationParams
+	mockQueries := &mocks.LinkQueries{
+		GetSenderVerificationFunc: func(ctx context.Context, arg db.GetSenderVerificationParams) (db.VerifiedSender, error) {
+			asked = arg
+			return db.VerifiedSender{}, sql.ErrNoRows
+		},
+	}
+	service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+	_, verified, err := service.SenderVerification(context.Background(), "abc123", "https://Shop.Example.com:8443/offer")
+	if err != nil {
+		t.Fatalf("SenderVerification() unexpected error = %v", err)
+	}
+	if verified {
+		t.Error("SenderVerification() verified = true, want false")
+	}
+	if asked.Shortcode != "abc123" || !slices.Equal(asked.Domains, []string{"shop.example.com", "example.com"}) {
+		t.Errorf("SenderVerification() looked up %+v, want abc123 and shop.example.com, example.com", asked)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	waitingRoomTokenPrefix = "wr_"
+	// Seconds the holding page waits before retrying, unless the owner sets it
+	DefaultWaitingRoomRetryAfter = 30
+)
+
+/*
+SetWaitingRoom configures the waiting room of one of the user's links. While it is
+active, redirects serve a holding page that retries every retryAfter seconds
+instead of sending visitors on to the destination. The owner opens and closes it
+here, or the destination's monitoring does with the room's webhook token.
+
+The token is generated when the room is first set up and returned only then; the
+returned string is empty for rooms that already existed.
+*/
+func (s *LinkService) SetWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID, active bool, message *string, retryAfter int32) (db.UpsertLinkWaitingRoomRow, string, error) {
+	link, err := s.queries.GetLinkByIdAndUser(ctx, db.GetLinkByIdAndUserParams{
+		ID:     linkID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.UpsertLinkWaitingRoomRow{}, "", fmt.Errorf("%w: %v", apperrors.LinkNotFound, err)
+		}
+		return db.UpsertLinkWaitingRoomRow{}, "", fmt.Errorf("failed to get link: %w", err)
+	}
+
+	var token string
+	_, err = s.queries.GetLinkWaitingRoom(ctx, linkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if token, err = generateWaitingRoomToken(); err != nil {
+			return db.UpsertLinkWaitingRoomRow{}, "", fmt.Errorf("failed to generate waiting room token: %w", err)
+		}
+	} else if err != nil {
+		return db.UpsertLinkWaitingRoomRow{}, "", fmt.Errorf("failed to get waiting room: %w", err)
+	}
+
+	room, err := s.queries.UpsertLinkWaitingRoom(ctx, db.UpsertLinkWaitingRoomParams{
+		LinkID:     linkID,
+		Active:     active,
+		Message:    message,
+		RetryAfter: retryAfter,
+		// Ignored when the room already exists
+		WebhookTokenHash: hashWaitingRoomToken(token),
+	})
+	if err != nil {
+		return db.UpsertLinkWaitingRoomRow{}, "", fmt.Errorf("failed to store waiting room: %w", err)
+	}
+
+	// A cached redirect would skip the room
+	s.invalidateCache(ctx, link.Shortcode)
+
+	s.logger.Info("Waiting room set",
+		zap.String("user_id", userID),
+		zap.String("link_id", linkID.String()),
+		zap.Bool("active", active),
+	)
+
+	return room, token, nil
+}
+
+// GetWaitingRoom returns the waiting room of one of the user's links
+func (s *LinkService) GetWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID) (db.GetLinkWaitingRoomRow, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.GetLinkWaitingRoomRow{}, err
+	}
+
+	room, err := s.queries.GetLinkWaitingRoom(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.GetLinkWaitingRoomRow{}, fmt.Errorf("%w: %v", apperrors.WaitingRoomNotFound, err)
+		}
+		return db.GetLinkWaitingRoomRow{}, fmt.Errorf("failed to get waiting room: %w", err)
+	}
+
+	return room, nil
+}
+
+// DeleteWaitingRoom removes the waiting room of one of the user's links, and with it its webhook token
+func (s *LinkService) DeleteWaitingRoom(ctx context.Context, userID string, linkID uuid.UUID) (db.DeleteLinkWaitingRoomRow, error) {
+	if err := s.checkLinkOwner(ctx, userID, linkID); err != nil {
+		return db.DeleteLinkWaitingRoomRow{}, err
+	}
+
+	room, err := s.queries.DeleteLinkWaitingRoom(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DeleteLinkWaitingRoomRow{}, fmt.Errorf("%w: %v", apperrors.WaitingRoomNotFound, err)
+		}
+		return db.DeleteLinkWaitingRoomRow{}, fmt.Errorf("failed to delete waiting room: %w", err)
+	}
+
+	return room, nil
+}
+
+// SetWaitingRoomActiveByToken opens or closes the waiting room a webhook token belongs to
+func (s *LinkService) SetWaitingRoomActiveByToken(ctx context.Context, token string, active bool) (db.SetLinkWaitingRoomActiveByTokenRow, error) {
+	if !strings.HasPrefix(token, waitingRoomTokenPrefix) {
+		return db.SetLinkWaitingRoomActiveByTokenRow{}, apperrors.InvalidWaitingRoomToken
+	}
+
+	room, err := s.queries.SetLinkWaitingRoomActiveByToken(ctx, db.SetLinkWaitingRoomActiveByTokenParams{
+		Active:           active,
+		WebhookTokenHash: hashWaitingRoomToken(token),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.SetLinkWaitingRoomActiveByTokenRow{}, fmt.Errorf("%w: %v", apperrors.InvalidWaitingRoomToken, err)
+		}
+		return db.SetLinkWaitingRoomActiveByTokenRow{}, fmt.Errorf("failed to update waiting room: %w", err)
+	}
+
+	s.logger.Info("Waiting room toggled by webhook",
+		zap.String("user_id", room.UserID),
+		zap.String("link_id", room.LinkID.String()),
+		zap.Bool("active", active),
+	)
+
+	return room, nil
+}
+
+func generateWaitingRoomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return waitingRoomTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashWaitingRoomToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/repository/mocks"
+)
+
+func TestLinkService_SetWaitingRoom(t *testing.T) {
+	tests := []struct {
+		name        string
+		ownsLink    bool
+		existing    bool
+		wantToken   bool
+		expectedErr error
+	}{
+		{name: "creates the room with a token", ownsLink: true, wantToken: true},
+		{name: "updates the room without a new token", ownsLink: true, existing: true},
+		{name: "other user's link", expectedErr: apperrors.LinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored db.UpsertLinkWaitingRoomParams
+			mockQueries := &mocks.LinkQueries{
+				GetLinkByIdAndUserFunc: func(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+					if !tt.ownsLink {
+						return db.GetLinkByIdAndUserRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkByIdAndUserRow{ID: arg.ID, Shortcode: "launch"}, nil
+				},
+				GetLinkWaitingRoomFunc: func(ctx context.Context, linkID uuid.UUID) (db.GetLinkWaitingRoomRow, error) {
+					if !tt.existing {
+						return db.GetLinkWaitingRoomRow{}, sql.ErrNoRows
+					}
+					return db.GetLinkWaitingRoomRow{LinkID: linkID, RetryAfter: 30}, nil
+				},
+				UpsertLinkWaitingRoomFunc: func(ctx context.Context, arg db.UpsertLinkWaitingRoomParams) (db.UpsertLinkWaitingRoomRow, error) {
+					stored = arg
+					return db.UpsertLinkWaitingRoomRow{LinkID: arg.LinkID, Active: arg.Active, RetryAfter: arg.RetryAfter}, nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			room, token, err := service.SetWaitingRoom(context.Background(), "user_123", uuid.New(), true, nil, 15)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("SetWaitingRoom() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetWaitingRoom() error = %v, want nil", err)
+			}
+			if !room.Active || room.RetryAfter != 15 {
+				t.Errorf("SetWaitingRoom() = %+v", room)
+			}
+			if tt.wantToken {
+				if !strings.HasPrefix(token, waitingRoomTokenPrefix) {
+					t.Errorf("SetWaitingRoom() token = %q, want %s prefix", token, waitingRoomTokenPrefix)
+				}
+				if stored.WebhookTokenHash != hashWaitingRoomToken(token) {
+					t.Error("SetWaitingRoom() didn't store the token's hash")
+				}
+			} else if token != "" {
+				t.Errorf("SetWaitingRoom() token = %q for an existing room, want none", token)
+			}
+		})
+	}
+}
+
+func TestLinkService_SetWaitingRoomActiveByToken(t *testing.T) {
+	token, err := generateWaitingRoomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{name: "opens the room", token: token},
+		{name: "unknown token", token: waitingRoomTokenPrefix + "unknown", expectedErr: apperrors.InvalidWaitingRoomToken},
+		{name: "wrong prefix", token: "ph_" + token, expectedErr: apperrors.InvalidWaitingRoomToken},
+		{name: "missing token", token: "", expectedErr: apperrors.InvalidWaitingRoomToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := &mocks.LinkQueries{
+				SetLinkWaitingRoomActiveByTokenFunc: func(ctx context.Context, arg db.SetLinkWaitingRoomActiveByTokenParams) (db.SetLinkWaitingRoomActiveByTokenRow, error) {
+					if arg.WebhookTokenHash != hashWaitingRoomToken(token) {
+						return db.SetLinkWaitingRoomActiveByTokenRow{}, sql.ErrNoRows
+					}
+					return db.SetLinkWaitingRoomActiveByTokenRow{LinkID: uuid.New(), Shortcode: "launch", Active: arg.Active}, nil
+				},
+			}
+			service := &LinkService{queries: mockQueries, logger: createTestLogger()}
+
+			room, err := service.SetWaitingRoomActiveByToken(context.Background(), tt.token, true)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("SetWaitingRoomActiveByToken() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetWaitingRoomActiveByToken() error = %v, want nil", err)
+			}
+			if !room.Active {
+				t.Error("SetWaitingRoomActiveByToken() didn't open the room")
+			}
+		})
+	}
+}
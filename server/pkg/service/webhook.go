@@ -0,0 +1,799 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/logger"
+	"github.com/styltsou/url-shortener/server/pkg/metrics"
+	"github.com/styltsou/url-shortener/server/pkg/pagination"
+	"github.com/styltsou/url-shortener/server/pkg/repository"
+	"github.com/styltsou/url-shortener/server/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	// How long a webhook endpoint may take to answer a delivery
+	WebhookDeliveryTimeout = 10 * time.Second
+	// How long a rotated-out secret keeps signing deliveries, unless the rotation says otherwise
+	DefaultWebhookSecretGrace = 24 * time.Hour
+	// Longest a rotated-out secret can be kept
+	MaxWebhookSecretGrace = 7 * 24 * time.Hour
+	// Event sent by test deliveries
+	WebhookEventTest = "webhook.test"
+	// Events of the user's links, see WebhookLinkData and WebhookClickData
+	WebhookEventLinkCreated = "link.created"
+	WebhookEventLinkUpdated = "link.updated"
+	WebhookEventLinkDeleted = "link.deleted"
+	WebhookEventLinkClicked = "link.clicked"
+	// Most deliveries sent to one webhook at once; Notify leaves the rest to the retry job
+	maxWebhookDeliveriesInFlight = 4
+	// How long a delivery being sent is kept from other instances, after which it's due again
+	webhookDeliveryLease = WebhookDeliveryTimeout + time.Minute
+	// How long Notify may take to record an event
+	webhookEnqueueTimeout = 5 * time.Second
+	// How long users found to have no enabled webhooks are remembered, see Notify
+	webhookUsersTTL = 30 * time.Second
+	// Most users remembered to have no enabled webhooks
+	maxWebhookUsersCached = 100_000
+	// Most due retries sent per run of the retry job
+	webhookRetryBatch = 100
+	// Upper bound for one run of the retry job
+	webhookRetryTimeout = 5 * time.Minute
+)
+
+// Wait before each automatic retry of a failed delivery, after the first attempt, the second, ...
+var webhookRetryDelays = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+// webhookRetryDelay returns how long after a failed attempt at an event it's retried, false when it isn't.
+// Test events aren't retried automatically.
+func webhookRetryDelay(eventType string, attempt int32) (time.Duration, bool) {
+	if eventType == WebhookEventTest || attempt < 1 || int(attempt) > len(webhookRetryDelays) {
+		return 0, false
+	}
+	return webhookRetryDelays[attempt-1], true
+}
+
+type WebhookOptions struct {
+	// How long a webhook may keep failing before it's disabled; 0 never disables webhooks
+	DisableAfter time.Duration
+}
+
+/*
+WebhookService manages webhook endpoints and delivers events to them. Every
+delivery is signed with the endpoint's secret (see package webhook). Rotating
+the secret keeps the old one signing deliveries alongside the new one for a
+grace period, so receivers can switch over without missing deliveries.
+
+Deliveries are recorded with the payload as sent, so they can be inspected and
+retried. Link events are recorded as pending deliveries before they're sent
+(see Notify), and failed deliveries of them are retried automatically, after
+each of webhookRetryDelays, by the retry job (see Start). A webhook whose
+deliveries have failed for DisableAfter is disabled, and its owner told so in
+their activity feed; a successful delivery (a test or a retry) enables it again.
+*/
+type WebhookService struct {
+	queries repository.WebhookQueries
+	client  *http.Client
+	opts    WebhookOptions
+	// Guards slots and withoutWebhooks
+	mu sync.Mutex
+	// Slots of the deliveries being sent to each webhook it has sent to, see slot
+	slots map[uuid.UUID]chan struct{}
+	// Until when users are known to have no enabled webhooks, see Notify
+	withoutWebhooks map[string]time.Time
+	// Deliveries being sent in the background, see Close
+	pending sync.WaitGroup
+	logger  logger.Logger
+}
+
+func NewWebhookService(queries repository.WebhookQueries, client *http.Client, opts WebhookOptions, logger logger.Logger) *WebhookService {
+	return &WebhookService{
+		queries:         queries,
+		client:          client,
+		opts:            opts,
+		slots:           make(map[uuid.UUID]chan struct{}),
+		withoutWebhooks: make(map[string]time.Time),
+		logger:          logger,
+	}
+}
+
+// WebhookEvent is the JSON body of a delivery
+type WebhookEvent struct {
+	// Identifies the event across deliveries, also sent as the Webhook-Id header
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookLinkData is the data of link.created, link.updated and link.deleted events
+type WebhookLinkData struct {
+	ID          uuid.UUID `json:"id"`
+	Shortcode   string    `json:"shortcode"`
+	OriginalURL string    `json:"original_url"`
+}
+
+// WebhookClickData is the data of link.clicked events
+type WebhookClickData struct {
+	ClickID   uuid.UUID `json:"click_id"`
+	LinkID    uuid.UUID `json:"link_id"`
+	Shortcode string    `json:"shortcode"`
+	Referrer  string    `json:"referrer"`
+	// analytics.SourceWeb or analytics.SourceQR
+	Source string `json:"source"`
+}
+
+type ListWebhookDeliveriesResult struct {
+	Deliveries []db.WebhookDelivery
+	pagination.Meta
+}
+
+// CreateWebhook creates a webhook endpoint with a new signing secret
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID string, url string) (db.Webhook, error) {
+	if err := validateURL(url); err != nil {
+		return db.Webhook{}, err
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return db.Webhook{}, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	hook, err := s.queries.CreateWebhook(ctx, db.CreateWebhookParams{
+		UserID: userID,
+		Url:    url,
+		Secret: secret,
+	})
+	if err != nil {
+		return db.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	s.forgetWithoutWebhooks(userID)
+
+	s.logger.Info("Webhook created",
+		zap.String("user_id", userID),
+		zap.String("webhook_id", hook.ID.String()),
+	)
+
+	return hook, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID string) ([]db.Webhook, error) {
+	hooks, err := s.queries.ListUserWebhooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID string, id uuid.UUID) (db.Webhook, error) {
+	hook, err := s.queries.DeleteWebhook(ctx, db.DeleteWebhookParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Webhook{}, fmt.Errorf("%w: %v", apperrors.WebhookNotFound, err)
+		}
+		return db.Webhook{}, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+/*
+RotateSecret gives the webhook a new secret. The current one keeps signing
+deliveries for grace (at most MaxWebhookSecretGrace), so both verify until the
+receiver has switched; a zero grace drops it right away.
+*/
+func (s *WebhookService) RotateSecret(ctx context.Context, userID string, id uuid.UUID, grace time.Duration) (db.Webhook, error) {
+	grace = min(max(grace, 0), MaxWebhookSecretGrace)
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return db.Webhook{}, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	hook, err := s.queries.RotateWebhookSecret(ctx, db.RotateWebhookSecretParams{
+		PreviousSecretExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(grace), Valid: true},
+		Secret:                  secret,
+		ID:                      id,
+		UserID:                  userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Webhook{}, fmt.Errorf("%w: %v", apperrors.WebhookNotFound, err)
+		}
+		return db.Webhook{}, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	s.logger.Info("Webhook secret rotated",
+		zap.String("user_id", userID),
+		zap.String("webhook_id", hook.ID.String()),
+		zap.Duration("grace", grace),
+	)
+
+	return hook, nil
+}
+
+// SendTest delivers a webhook.test event to the webhook and returns the recorded delivery
+func (s *WebhookService) SendTest(ctx context.Context, userID string, id uuid.UUID) (db.WebhookDelivery, error) {
+	hook, err := s.getWebhook(ctx, userID, id)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	return s.deliver(ctx, hook, WebhookEvent{
+		ID:        newWebhookEventID(),
+		Type:      WebhookEventTest,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]string{"webhook_id": hook.ID.String()},
+	})
+}
+
+// ListDeliveries returns a page of the webhook's deliveries, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID string, id uuid.UUID, page, limit int) (*ListWebhookDeliveriesResult, error) {
+	if _, err := s.getWebhook(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	p := pagination.Default.Page(page, limit)
+
+	total, err := s.queries.CountWebhookDeliveries(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	deliveries, err := s.queries.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		WebhookID: id,
+		Limit:     int32(p.Limit),
+		Offset:    int32(p.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return &ListWebhookDeliveriesResult{
+		Deliveries: deliveries,
+		Meta:       p.Meta(total),
+	}, nil
+}
+
+// RetryDelivery sends a delivery's payload again, under the same event ID so receivers
+// can tell it's a repeat, and returns the new delivery
+func (s *WebhookService) RetryDelivery(ctx context.Context, userID string, id uuid.UUID, deliveryID uuid.UUID) (db.WebhookDelivery, error) {
+	hook, err := s.getWebhook(ctx, userID, id)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	previous, err := s.queries.GetWebhookDelivery(ctx, db.GetWebhookDeliveryParams{
+		ID:        deliveryID,
+		WebhookID: hook.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.WebhookDelivery{}, fmt.Errorf("%w: %v", apperrors.WebhookDeliveryNotFound, err)
+		}
+		return db.WebhookDelivery{}, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	// A pending delivery is sent now rather than by the retry job, unless it's being sent already
+	if previous.Pending {
+		claimed, err := s.claim(ctx, previous.ID)
+		if err != nil || !claimed {
+			return previous, err
+		}
+		return s.complete(ctx, hook, previous)
+	}
+
+	return s.send(ctx, hook, previous.EventID, previous.EventType, previous.Payload, pgtype.UUID{Bytes: previous.ID, Valid: true}, previous.Attempt+1)
+}
+
+/*
+Notify records an event as a pending delivery to each of the user's enabled
+webhooks, then sends them in the background, so the change or click it's about
+waits on that query but never on their endpoints. A delivery whose webhook already has
+maxWebhookDeliveriesInFlight being sent is left to the retry job, as are
+failed ones. Users found to have no enabled webhooks are remembered for
+webhookUsersTTL, sparing their events the query: a webhook added through
+another instance meanwhile misses those. A nil WebhookService delivers nothing.
+*/
+func (s *WebhookService) Notify(ctx context.Context, userID string, eventType string, data any) {
+	if s == nil || userID == "" || s.knownWithoutWebhooks(userID) {
+		return
+	}
+
+	event := WebhookEvent{
+		ID:        newWebhookEventID(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Warn("Failed to encode webhook event",
+			zap.Error(err),
+			zap.String("event_type", eventType),
+		)
+		return
+	}
+
+	// Detach from the request context: it is canceled as soon as the response is written
+	ctx = context.WithoutCancel(ctx)
+	enqueueCtx, cancel := context.WithTimeout(ctx, webhookEnqueueTimeout)
+	defer cancel()
+
+	deliveries, err := s.queries.EnqueueWebhookEvent(enqueueCtx, db.EnqueueWebhookEventParams{
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   body,
+		UserID:    userID,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to record webhook event",
+			zap.Error(err),
+			zap.String("user_id", userID),
+			zap.String("event_type", eventType),
+		)
+		return
+	}
+	if len(deliveries) == 0 {
+		s.rememberWithoutWebhooks(userID)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.dispatch(ctx, delivery)
+	}
+}
+
+// dispatch sends a pending delivery in the background, unless its webhook has no free slot
+func (s *WebhookService) dispatch(ctx context.Context, delivery db.WebhookDelivery) {
+	slot := s.slot(delivery.WebhookID)
+	select {
+	case slot <- struct{}{}:
+	default:
+		metrics.WebhookDeliveriesDeferred.Add(1)
+		return
+	}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		defer func() { <-slot }()
+
+		// The retry job may have taken it already
+		claimed, err := s.claim(ctx, delivery.ID)
+		if err != nil || !claimed {
+			return
+		}
+
+		hook, err := s.queries.GetWebhook(ctx, delivery.WebhookID)
+		if err != nil {
+			s.logger.Warn("Failed to get webhook for event",
+				zap.Error(err),
+				zap.String("webhook_id", delivery.WebhookID.String()),
+				zap.String("event_id", delivery.EventID),
+			)
+			return
+		}
+
+		if _, err := s.complete(ctx, hook, delivery); err != nil {
+			s.logger.Warn("Failed to deliver webhook event",
+				zap.Error(err),
+				zap.String("webhook_id", hook.ID.String()),
+				zap.String("event_id", delivery.EventID),
+			)
+		}
+	}()
+}
+
+// Close waits for the deliveries being sent in the background until ctx is done. Those it
+// doesn't wait for are still pending, and sent by the retry job once their lease runs out.
+func (s *WebhookService) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+RetryDue sends the pending deliveries left to it and the failed ones whose
+retry is due, returning how many it sent. Each webhook is sent at most
+maxWebhookDeliveriesInFlight of them at once, so a slow endpoint only holds up
+its own deliveries.
+*/
+func (s *WebhookService) RetryDue(ctx context.Context) (int, error) {
+	due, err := s.queries.ClaimDueWebhookRetries(ctx, db.ClaimDueWebhookRetriesParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(webhookDeliveryLease), Valid: true},
+		Limit:      webhookRetryBatch,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook retries: %w", err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		retried int
+	)
+	hooks := make(map[uuid.UUID]db.Webhook)
+	for _, previous := range due {
+		hook, ok := hooks[previous.WebhookID]
+		if !ok {
+			hook, err = s.queries.GetWebhook(ctx, previous.WebhookID)
+			if err != nil {
+				// Deleted webhooks take their deliveries with them, so this is a lookup failure
+				s.logger.Warn("Failed to get webhook for retry",
+					zap.Error(err),
+					zap.String("webhook_id", previous.WebhookID.String()),
+				)
+				continue
+			}
+			hooks[hook.ID] = hook
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			slot := s.slot(hook.ID)
+			select {
+			case slot <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-slot }()
+
+			var err error
+			if previous.Pending {
+				_, err = s.complete(ctx, hook, previous)
+			} else {
+				_, err = s.send(ctx, hook, previous.EventID, previous.EventType, previous.Payload, pgtype.UUID{Bytes: previous.ID, Valid: true}, previous.Attempt+1)
+			}
+			if err != nil {
+				s.logger.Warn("Failed to retry webhook delivery",
+					zap.Error(err),
+					zap.String("webhook_id", hook.ID.String()),
+					zap.String("event_id", previous.EventID),
+				)
+				return
+			}
+
+			mu.Lock()
+			retried++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return retried, nil
+}
+
+// Start retries failed deliveries every interval until ctx is done
+func (s *WebhookService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.retryOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (s *WebhookService) retryOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, webhookRetryTimeout)
+	defer cancel()
+
+	retried, err := s.RetryDue(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Webhook retries failed",
+			zap.Error(err),
+		)
+		return
+	}
+	if retried > 0 {
+		s.logger.Info("Webhook deliveries retried",
+			zap.Int("retried", retried),
+		)
+	}
+}
+
+func (s *WebhookService) getWebhook(ctx context.Context, userID string, id uuid.UUID) (db.Webhook, error) {
+	hook, err := s.queries.GetUserWebhook(ctx, db.GetUserWebhookParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Webhook{}, fmt.Errorf("%w: %v", apperrors.WebhookNotFound, err)
+		}
+		return db.Webhook{}, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+// deliver posts the signed event to the webhook, see send
+func (s *WebhookService) deliver(ctx context.Context, hook db.Webhook, event WebhookEvent) (db.WebhookDelivery, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return db.WebhookDelivery{}, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	return s.send(ctx, hook, event.ID, event.Type, body, pgtype.UUID{}, 1)
+}
+
+/*
+send posts a signed payload to the webhook and records the delivery as the
+event's attempt-th, see post. Endpoint failures are reported in the delivery,
+not as errors: the error is only set when the request couldn't be built or the
+delivery recorded.
+*/
+func (s *WebhookService) send(ctx context.Context, hook db.Webhook, eventID, eventType string, body []byte, retryOf pgtype.UUID, attempt int32) (db.WebhookDelivery, error) {
+	arg, err := s.post(ctx, hook, eventID, eventType, body, attempt)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	arg.RetryOf = retryOf
+
+	delivery, err := s.queries.CreateWebhookDelivery(ctx, arg)
+	if err != nil {
+		return db.WebhookDelivery{}, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	// This delivery replaces the retry the previous one had scheduled, or the retry job's lease on it
+	if retryOf.Valid {
+		if err := s.queries.CancelWebhookDeliveryRetry(ctx, retryOf.Bytes); err != nil {
+			s.logger.Warn("Failed to cancel webhook delivery retry",
+				zap.Error(err),
+				zap.String("delivery_id", uuid.UUID(retryOf.Bytes).String()),
+			)
+		}
+	}
+
+	s.recordHealth(ctx, hook, arg.Error == nil)
+
+	return delivery, nil
+}
+
+// complete sends a pending delivery claimed by the caller and fills it in, like send records one
+func (s *WebhookService) complete(ctx context.Context, hook db.Webhook, pending db.WebhookDelivery) (db.WebhookDelivery, error) {
+	arg, err := s.post(ctx, hook, pending.EventID, pending.EventType, pending.Payload, pending.Attempt)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.queries.CompleteWebhookDelivery(ctx, db.CompleteWebhookDeliveryParams{
+		ID:          pending.ID,
+		StatusCode:  arg.StatusCode,
+		DurationMs:  arg.DurationMs,
+		Error:       arg.Error,
+		NextRetryAt: arg.NextRetryAt,
+	})
+	if err != nil {
+		return db.WebhookDelivery{}, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	s.recordHealth(ctx, hook, arg.Error == nil)
+
+	return delivery, nil
+}
+
+// post posts a signed payload to the webhook, returning the event's attempt-th delivery to
+// record, with its automatic retry when it failed
+func (s *WebhookService) post(ctx context.Context, hook db.Webhook, eventID, eventType string, body []byte, attempt int32) (db.CreateWebhookDeliveryParams, error) {
+	sendCtx, cancel := context.WithTimeout(ctx, WebhookDeliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return db.CreateWebhookDeliveryParams{}, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.IDHeader, eventID)
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(body, signingSecrets(hook, now), now))
+
+	arg := db.CreateWebhookDeliveryParams{
+		WebhookID: hook.ID,
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   body,
+		Attempt:   attempt,
+	}
+
+	resp, err := s.client.Do(req)
+	arg.DurationMs = int32(time.Since(now).Milliseconds())
+	if err != nil {
+		failure := err.Error()
+		arg.Error = &failure
+	} else {
+		defer resp.Body.Close()
+		// Drained so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+		status := int32(resp.StatusCode)
+		arg.StatusCode = &status
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			failure := fmt.Sprintf("endpoint returned status %d", resp.StatusCode)
+			arg.Error = &failure
+		}
+	}
+
+	if arg.Error != nil {
+		if delay, ok := webhookRetryDelay(eventType, attempt); ok {
+			arg.NextRetryAt = pgtype.Timestamptz{Time: now.Add(delay), Valid: true}
+		}
+		s.logger.Warn("Webhook delivery failed",
+			zap.String("webhook_id", hook.ID.String()),
+			zap.String("event_id", eventID),
+			zap.String("event_type", eventType),
+			zap.String("error", *arg.Error),
+		)
+	}
+
+	return arg, nil
+}
+
+// claim takes a pending delivery for the caller to send, false when another instance or the
+// retry job has it
+func (s *WebhookService) claim(ctx context.Context, id uuid.UUID) (bool, error) {
+	claimed, err := s.queries.ClaimWebhookDelivery(ctx, db.ClaimWebhookDeliveryParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(webhookDeliveryLease), Valid: true},
+		ID:         id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	return claimed > 0, nil
+}
+
+// slot returns the semaphore bounding the deliveries sent to the webhook at once
+func (s *WebhookService) slot(id uuid.UUID) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot, ok := s.slots[id]
+	if !ok {
+		slot = make(chan struct{}, maxWebhookDeliveriesInFlight)
+		s.slots[id] = slot
+	}
+	return slot
+}
+
+// knownWithoutWebhooks reports whether the user was found to have no enabled webhooks
+// less than webhookUsersTTL ago
+func (s *WebhookService) knownWithoutWebhooks(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.withoutWebhooks[userID]
+	return ok && time.Now().Before(until)
+}
+
+// rememberWithoutWebhooks remembers the user has no enabled webhooks for webhookUsersTTL.
+// Past maxWebhookUsersCached users the expired ones are dropped, and if none were the user isn't remembered.
+func (s *WebhookService) rememberWithoutWebhooks(userID string) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.withoutWebhooks) >= maxWebhookUsersCached {
+		for id, until := range s.withoutWebhooks {
+			if !now.Before(until) {
+				delete(s.withoutWebhooks, id)
+			}
+		}
+		if len(s.withoutWebhooks) >= maxWebhookUsersCached {
+			return
+		}
+	}
+	s.withoutWebhooks[userID] = now.Add(webhookUsersTTL)
+}
+
+// forgetWithoutWebhooks is called when the user may have gained an enabled webhook
+func (s *WebhookService) forgetWithoutWebhooks(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.withoutWebhooks, userID)
+}
+
+/*
+recordHealth tracks how long the webhook has been failing and disables it once
+that's DisableAfter, telling its owner through their activity feed. Like
+activity, it's best-effort: failures are logged, the delivery already happened.
+*/
+func (s *WebhookService) recordHealth(ctx context.Context, hook db.Webhook, succeeded bool) {
+	if succeeded {
+		if err := s.queries.RecordWebhookSuccess(ctx, hook.ID); err != nil {
+			s.logger.Warn("Failed to record webhook success",
+				zap.Error(err),
+				zap.String("webhook_id", hook.ID.String()),
+			)
+		}
+		// It's enabled again if it was disabled
+		s.forgetWithoutWebhooks(hook.UserID)
+		return
+	}
+
+	failingSince, err := s.queries.RecordWebhookFailure(ctx, hook.ID)
+	if err != nil {
+		s.logger.Warn("Failed to record webhook failure",
+			zap.Error(err),
+			zap.String("webhook_id", hook.ID.String()),
+		)
+		return
+	}
+
+	if s.opts.DisableAfter <= 0 || !failingSince.Valid || time.Since(failingSince.Time) < s.opts.DisableAfter {
+		return
+	}
+
+	disabled, err := s.queries.DisableWebhook(ctx, hook.ID)
+	if err != nil {
+		s.logger.Warn("Failed to disable webhook",
+			zap.Error(err),
+			zap.String("webhook_id", hook.ID.String()),
+		)
+		return
+	}
+	// Already disabled by an earlier failure
+	if disabled == 0 {
+		return
+	}
+
+	s.logger.Info("Webhook disabled",
+		zap.String("user_id", hook.UserID),
+		zap.String("webhook_id", hook.ID.String()),
+		zap.Time("failing_since", failingSince.Time),
+	)
+
+	days := int(s.opts.DisableAfter.Hours() / 24)
+	recordActivity(ctx, s.queries, s.logger, hook.UserID, ActivityWebhookDisabled, hook.ID,
+		fmt.Sprintf("Disabled webhook %s after its deliveries failed for %s", hook.Url, pluralize(max(days, 1), "day")))
+}
+
+// signingSecrets returns the secrets deliveries are signed with at now: the current one,
+// and the previous one until it expires
+func signingSecrets(hook db.Webhook, now time.Time) []string {
+	secrets := []string{hook.Secret}
+	if hook.PreviousSecret != nil && hook.PreviousSecretExpiresAt.Valid && now.Before(hook.PreviousSecretExpiresAt.Time) {
+		secrets = append(secrets, *hook.PreviousSecret)
+	}
+	return secrets
+}
+
+func newWebhookEventID() string {
+	return "evt_" + uuid.NewString()
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+	apperrors "github.com/styltsou/url-shortener/server/pkg/errors"
+	"github.com/styltsou/url-shortener/server/pkg/webhook"
+)
+
+type mockWebhookQueries struct {
+	// Deliveries are sent concurrently by the retry job
+	mu         sync.Mutex
+	hooks      map[uuid.UUID]db.Webhook
+	deliveries []db.WebhookDelivery
+	activity   []db.CreateActivityEventParams
+	// Calls of EnqueueWebhookEvent
+	enqueued int
+}
+
+func (m *mockWebhookQueries) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	hook := db.Webhook{ID: uuid.New(), UserID: arg.UserID, Url: arg.Url, Secret: arg.Secret}
+	m.hooks[hook.ID] = hook
+	return hook, nil
+}
+
+func (m *mockWebhookQueries) ListUserWebhooks(ctx context.Context, userID string) ([]db.Webhook, error) {
+	var hooks []db.Webhook
+	for _, hook := range m.hooks {
+		if hook.UserID == userID {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (m *mockWebhookQueries) GetWebhook(ctx context.Context, id uuid.UUID) (db.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hook, ok := m.hooks[id]
+	if !ok {
+		return db.Webhook{}, sql.ErrNoRows
+	}
+	return hook, nil
+}
+
+func (m *mockWebhookQueries) GetUserWebhook(ctx context.Context, arg db.GetUserWebhookParams) (db.Webhook, error) {
+	hook, ok := m.hooks[arg.ID]
+	if !ok || hook.UserID != arg.UserID {
+		return db.Webhook{}, sql.ErrNoRows
+	}
+	return hook, nil
+}
+
+func (m *mockWebhookQueries) DeleteWebhook(ctx context.Context, arg db.DeleteWebhookParams) (db.Webhook, error) {
+	return db.Webhook{}, sql.ErrNoRows
+}
+
+func (m *mockWebhookQueries) RotateWebhookSecret(ctx context.Context, arg db.RotateWebhookSecretParams) (db.Webhook, error) {
+	hook, ok := m.hooks[arg.ID]
+	if !ok || hook.UserID != arg.UserID {
+		return db.Webhook{}, sql.ErrNoRows
+	}
+	previous := hook.Secret
+	hook.PreviousSecret = &previous
+	hook.PreviousSecretExpiresAt = arg.PreviousSecretExpiresAt
+	hook.Secret = arg.Secret
+	m.hooks[hook.ID] = hook
+	return hook, nil
+}
+
+func (m *mockWebhookQueries) RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hook := m.hooks[id]
+	hook.FailingSince = pgtype.Timestamptz{}
+	hook.DisabledAt = pgtype.Timestamptz{}
+	m.hooks[id] = hook
+	return nil
+}
+
+func (m *mockWebhookQueries) RecordWebhookFailure(ctx context.Context, id uuid.UUID) (pgtype.Timestamptz, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hook := m.hooks[id]
+	if !hook.FailingSince.Valid {
+		hook.FailingSince = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	m.hooks[id] = hook
+	return hook.FailingSince, nil
+}
+
+func (m *mockWebhookQueries) DisableWebhook(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hook := m.hooks[id]
+	if hook.DisabledAt.Valid {
+		return 0, nil
+	}
+	hook.DisabledAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	m.hooks[id] = hook
+	return 1, nil
+}
+
+func (m *mockWebhookQueries) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delivery := db.WebhookDelivery{
+		ID:          uuid.New(),
+		WebhookID:   arg.WebhookID,
+		EventID:     arg.EventID,
+		EventType:   arg.EventType,
+		Payload:     arg.Payload,
+		StatusCode:  arg.StatusCode,
+		DurationMs:  arg.DurationMs,
+		Error:       arg.Error,
+		RetryOf:     arg.RetryOf,
+		Attempt:     arg.Attempt,
+		NextRetryAt: arg.NextRetryAt,
+	}
+	m.deliveries = append(m.deliveries, delivery)
+	return delivery, nil
+}
+
+func (m *mockWebhookQueries) ClaimDueWebhookRetries(ctx context.Context, arg db.ClaimDueWebhookRetriesParams) ([]db.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []db.WebhookDelivery
+	for i, delivery := range m.deliveries {
+		if !delivery.NextRetryAt.Valid || delivery.NextRetryAt.Time.After(time.Now()) || m.hooks[delivery.WebhookID].DisabledAt.Valid {
+			continue
+		}
+		m.deliveries[i].NextRetryAt = arg.LeaseUntil
+		due = append(due, m.deliveries[i])
+	}
+	return due, nil
+}
+
+func (m *mockWebhookQueries) EnqueueWebhookEvent(ctx context.Context, arg db.EnqueueWebhookEventParams) ([]db.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enqueued++
+	var pending []db.WebhookDelivery
+	for _, hook := range m.hooks {
+		if hook.UserID != arg.UserID || hook.DisabledAt.Valid {
+			continue
+		}
+		delivery := db.WebhookDelivery{
+			ID:          uuid.New(),
+			WebhookID:   hook.ID,
+			EventID:     arg.EventID,
+			EventType:   arg.EventType,
+			Payload:     arg.Payload,
+			Attempt:     1,
+			NextRetryAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Pending:     true,
+		}
+		m.deliveries = append(m.deliveries, delivery)
+		pending = append(pending, delivery)
+	}
+	return pending, nil
+}
+
+func (m *mockWebhookQueries) ClaimWebhookDelivery(ctx context.Context, arg db.ClaimWebhookDeliveryParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, delivery := range m.deliveries {
+		if delivery.ID == arg.ID && delivery.Pending && delivery.NextRetryAt.Valid && !delivery.NextRetryAt.Time.After(time.Now()) {
+			m.deliveries[i].NextRetryAt = arg.LeaseUntil
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *mockWebhookQueries) CompleteWebhookDelivery(ctx context.Context, arg db.CompleteWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, delivery := range m.deliveries {
+		if delivery.ID != arg.ID {
+			continue
+		}
+		delivery.StatusCode = arg.StatusCode
+		delivery.DurationMs = arg.DurationMs
+		delivery.Error = arg.Error
+		delivery.NextRetryAt = arg.NextRetryAt
+		delivery.Pending = false
+		m.deliveries[i] = delivery
+		return delivery, nil
+	}
+	return db.WebhookDelivery{}, sql.ErrNoRows
+}
+
+func (m *mockWebhookQueries) CancelWebhookDeliveryRetry(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == id {
+			m.deliveries[i].NextRetryAt = pgtype.Timestamptz{}
+		}
+	}
+	return nil
+}
+
+func (m *mockWebhookQueries) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *mockWebhookQueries) CountWebhookDeliveries(ctx context.Context, webhookID uuid.UUID) (int64, error) {
+	return int64(len(m.deliveries)), nil
+}
+
+func (m *mockWebhookQueries) GetWebhookDelivery(ctx context.Context, arg db.GetWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.ID == arg.ID && delivery.WebhookID == arg.WebhookID {
+			return delivery, nil
+		}
+	}
+	return db.WebhookDelivery{}, sql.ErrNoRows
+}
+
+func (m *mockWebhookQueries) CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error {
+	m.activity = append(m.activity, arg)
+	return nil
+}
+
+func TestWebhookService_RotateAndTest(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	deliveries := make(chan received, 1)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- received{header: r.Header, body: body}
+	}))
+	defer endpoint.Close()
+
+	queries := &mockWebhookQueries{hooks: map[uuid.UUID]db.Webhook{}}
+	s := NewWebhookService(queries, endpoint.Client(), WebhookOptions{}, createTestLogger())
+	ctx := context.Background()
+
+	hook, err := s.CreateWebhook(ctx, "user_1", endpoint.URL)
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	oldSecret := hook.Secret
+
+	rotated, err := s.RotateSecret(ctx, "user_1", hook.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("RotateSecret() error = %v", err)
+	}
+	if rotated.Secret == oldSecret {
+		t.Fatal("RotateSecret() kept the secret")
+	}
+
+	delivery, err := s.SendTest(ctx, "user_1", hook.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if delivery.Error != nil || delivery.StatusCode == nil || *delivery.StatusCode != http.StatusOK {
+		t.Errorf("delivery = %+v, want a successful one", delivery)
+	}
+
+	got := <-deliveries
+	if got.header.Get(webhook.IDHeader) != delivery.EventID {
+		t.Errorf("%s = %q, want %q", webhook.IDHeader, got.header.Get(webhook.IDHeader), delivery.EventID)
+	}
+	// Receivers holding either secret verify the delivery during the grace period
+	for _, secret := range []string{rotated.Secret, oldSecret} {
+		if err := webhook.Verify(got.body, got.header.Get(webhook.SignatureHeader), secret, webhook.DefaultTolerance, time.Now()); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(got.body, &event); err != nil || event.Type != WebhookEventTest {
+		t.Errorf("event = %+v, %v, want a %s event", event, err, WebhookEventTest)
+	}
+
+	if _, err := s.SendTest(ctx, "user_2", hook.ID); !errors.Is(err, apperrors.WebhookNotFound) {
+		t.Errorf("SendTest() as another user error = %v, want WebhookNotFound", err)
+	}
+}
+
+func TestWebhookService_FailingWebhook(t *testing.T) {
+	status := http.StatusBadGateway
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer endpoint.Close()
+
+	hook := db.Webhook{
+		ID:     uuid.New(),
+		UserID: "user_1",
+		Url:    endpoint.URL,
+		Secret: "whsec_test",
+		// Failing for four days already
+		FailingSince: pgtype.Timestamptz{Time: time.Now().Add(-96 * time.Hour), Valid: true},
+	}
+	queries := &mockWebhookQueries{hooks: map[uuid.UUID]db.Webhook{hook.ID: hook}}
+	s := NewWebhookService(queries, endpoint.Client(), WebhookOptions{DisableAfter: 72 * time.Hour}, createTestLogger())
+	ctx := context.Background()
+
+	failed, err := s.SendTest(ctx, "user_1", hook.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if failed.Error == nil || failed.StatusCode == nil || *failed.StatusCode != http.StatusBadGateway {
+		t.Errorf("delivery = %+v, want a failed one with status 502", failed)
+	}
+	if !queries.hooks[hook.ID].DisabledAt.Valid {
+		t.Error("webhook failing past DisableAfter wasn't disabled")
+	}
+	if len(queries.activity) != 1 || queries.activity[0].Action != ActivityWebhookDisabled || queries.activity[0].UserID != "user_1" {
+		t.Errorf("activity = %+v, want the owner told about the disabled webhook", queries.activity)
+	}
+
+	// Once the endpoint is fixed, a retry goes through and enables the webhook again
+	status = http.StatusOK
+	retried, err := s.RetryDelivery(ctx, "user_1", hook.ID, failed.ID)
+	if err != nil {
+		t.Fatalf("RetryDelivery() error = %v", err)
+	}
+	if retried.Error != nil || retried.EventID != failed.EventID || retried.RetryOf.Bytes != failed.ID {
+		t.Errorf("retry = %+v, want a successful delivery of the same event", retried)
+	}
+	if got := queries.hooks[hook.ID]; got.DisabledAt.Valid || got.FailingSince.Valid {
+		t.Errorf("webhook = %+v, want it enabled and healthy", got)
+	}
+
+	if _, err := s.RetryDelivery(ctx, "user_1", hook.ID, uuid.New()); !errors.Is(err, apperrors.WebhookDeliveryNotFound) {
+		t.Errorf("RetryDelivery() of an unknown delivery error = %v, want WebhookDeliveryNotFound", err)
+	}
+}
+
+func TestWebhookService_NotifyAndRetryDue(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusServiceUnavailable
+	var events []WebhookEvent
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+		w.WriteHeader(status)
+	}))
+	defer endpoint.Close()
+
+	hook := db.Webhook{ID: uuid.New(), UserID: "user_1", Url: endpoint.URL, Secret: "whsec_test"}
+	disabled := db.Webhook{
+		ID:         uuid.New(),
+		UserID:     "user_1",
+		Url:        endpoint.URL,
+		Secret:     "whsec_test",
+		DisabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	queries := &mockWebhookQueries{hooks: map[uuid.UUID]db.Webhook{hook.ID: hook, disabled.ID: disabled}}
+	s := NewWebhookService(queries, endpoint.Client(), WebhookOptions{}, createTestLogger())
+	ctx := context.Background()
+
+	link := WebhookLinkData{ID: uuid.New(), Shortcode: "abc", OriginalURL: "https://example.com"}
+	s.Notify(ctx, "user_1", WebhookEventLinkCreated, link)
+	s.pending.Wait()
+
+	if len(events) != 1 || events[0].Type != WebhookEventLinkCreated {
+		t.Fatalf("endpoint received %+v, want one link.created event", events)
+	}
+	if len(queries.deliveries) != 1 {
+		t.Fatalf("deliveries = %+v, want only the enabled webhook's", queries.deliveries)
+	}
+	failed := queries.deliveries[0]
+	if failed.Pending || failed.Attempt != 1 || !failed.NextRetryAt.Valid {
+		t.Fatalf("delivery = %+v, want a failed first attempt with a retry scheduled", failed)
+	}
+	if delay := time.Until(failed.NextRetryAt.Time); delay <= 0 || delay > webhookRetryDelays[0] {
+		t.Errorf("retry in %v, want within %v", delay, webhookRetryDelays[0])
+	}
+
+	// Nothing is due until the retry time
+	if retried, err := s.RetryDue(ctx); err != nil || retried != 0 {
+		t.Errorf("RetryDue() before the retry time = %d, %v, want 0", retried, err)
+	}
+
+	queries.deliveries[0].NextRetryAt.Time = time.Now().Add(-time.Second)
+	status = http.StatusOK
+	retried, err := s.RetryDue(ctx)
+	if err != nil || retried != 1 {
+		t.Fatalf("RetryDue() = %d, %v, want 1 retried", retried, err)
+	}
+	retry := queries.deliveries[1]
+	if retry.Error != nil || retry.Attempt != 2 || retry.RetryOf.Bytes != failed.ID || retry.EventID != failed.EventID || retry.NextRetryAt.Valid {
+		t.Errorf("retry = %+v, want a successful second attempt at the same event", retry)
+	}
+	if queries.deliveries[0].NextRetryAt.Valid {
+		t.Error("retried delivery still has a retry scheduled")
+	}
+
+	// Nil services and anonymous links notify no one
+	var none *WebhookService
+	none.Notify(ctx, "user_1", WebhookEventLinkClicked, WebhookClickData{})
+	s.Notify(ctx, "", WebhookEventLinkClicked, WebhookClickData{})
+	s.pending.Wait()
+	if len(events) != 2 {
+		t.Errorf("endpoint received %d events, want only the event and its retry", len(events))
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		attempt   int32
+		want      time.Duration
+		wantRetry bool
+	}{
+		{name: "first attempt", eventType: WebhookEventLinkClicked, attempt: 1, want: webhookRetryDelays[0], wantRetry: true},
+		{name: "last retry", eventType: WebhookEventLinkDeleted, attempt: int32(len(webhookRetryDelays)), want: webhookRetryDelays[len(webhookRetryDelays)-1], wantRetry: true},
+		{name: "retries exhausted", eventType: WebhookEventLinkDeleted, attempt: int32(len(webhookRetryDelays)) + 1},
+		{name: "test event", eventType: WebhookEventTest, attempt: 1},
+	}
+
+	for _, tt := range tests {
+		got, retry := webhookRetryDelay(tt.eventType, tt.attempt)
+		if got != tt.want || retry != tt.wantRetry {
+			t.Errorf("%s: webhookRetryDelay() = %v, %v, want %v, %v", tt.name, got, retry, tt.want, tt.wantRetry)
+		}
+	}
+}
+
+func TestSigningSecrets(t *testing.T) {
+	now := time.Now()
+	previous := "whsec_previous"
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      int
+	}{
+		{name: "previous secret in its grace period", expiresAt: now.Add(time.Minute), want: 2},
+		{name: "previous secret expired", expiresAt: now.Add(-time.Minute), want: 1},
+	}
+
+	for _, tt := range tests {
+		hook := db.Webhook{
+			Secret:                  "whsec_current",
+			PreviousSecret:          &previous,
+			PreviousSecretExpiresAt: pgtype.Timestamptz{Time: tt.expiresAt, Valid: true},
+		}
+		if got := signingSecrets(hook, now); len(got) != tt.want || got[0] != hook.Secret {
+			t.Errorf("%s: signingSecrets() = %v, want %d secrets, the current one first", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookService_NotifyDeferred(t *testing.T) {
+	var received atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer endpoint.Close()
+
+	queries := &mockWebhookQueries{hooks: map[uuid.UUID]db.Webhook{}}
+	s := NewWebhookService(queries, endpoint.Client(), WebhookOptions{}, createTestLogger())
+	ctx := context.Background()
+	click := WebhookClickData{ClickID: uuid.New(), LinkID: uuid.New(), Shortcode: "abc"}
+
+	// Users without webhooks are looked up once, then remembered
+	s.Notify(ctx, "user_1", WebhookEventLinkClicked, click)
+	s.Notify(ctx, "user_1", WebhookEventLinkClicked, click)
+	if queries.enqueued != 1 {
+		t.Errorf("EnqueueWebhookEvent called %d times, want 1", queries.enqueued)
+	}
+
+	// Until they add one
+	hook, err := s.CreateWebhook(ctx, "user_1", endpoint.URL)
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	// A webhook with all its slots taken is left to the retry job
+	slot := s.slot(hook.ID)
+	for range maxWebhookDeliveriesInFlight {
+		slot <- struct{}{}
+	}
+	s.Notify(ctx, "user_1", WebhookEventLinkClicked, click)
+	s.pending.Wait()
+	if queries.enqueued != 2 || len(queries.deliveries) != 1 || !queries.deliveries[0].Pending || received.Load() != 0 {
+		t.Fatalf("deliveries = %+v, want one pending and nothing sent", queries.deliveries)
+	}
+
+	for range maxWebhookDeliveriesInFlight {
+		<-slot
+	}
+	retried, err := s.RetryDue(ctx)
+	if err != nil || retried != 1 {
+		t.Fatalf("RetryDue() = %d, %v, want the pending delivery sent", retried, err)
+	}
+	sent := queries.deliveries[0]
+	if len(queries.deliveries) != 1 || sent.Pending || sent.Error != nil || sent.NextRetryAt.Valid || received.Load() != 1 {
+		t.Errorf("deliveries = %+v, want the pending one filled in as sent", queries.deliveries)
+	}
+}
+
+func TestWebhookService_Close(t *testing.T) {
+	release := make(chan struct{})
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer endpoint.Close()
+
+	hook := db.Webhook{ID: uuid.New(), UserID: "user_1", Url: endpoint.URL, Secret: "whsec_test"}
+	queries := &mockWebhookQueries{hooks: map[uuid.UUID]db.Webhook{hook.ID: hook}}
+	s := NewWebhookService(queries, endpoint.Client(), WebhookOptions{}, createTestLogger())
+
+	s.Notify(context.Background(), "user_1", WebhookEventLinkDeleted, WebhookLinkData{ID: uuid.New()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() while a delivery is being sent error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(queries.deliveries) != 1 || queries.deliveries[0].Pending || queries.deliveries[0].Error != nil {
+		t.Errorf("deliveries = %+v, want the delivery sent before Close returned", queries.deliveries)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// Most distinct URLs wrapped in one email
+const MaxWrapLinks = 200
+
+// Merge tag markers of common email senders; hrefs containing them are filled in per recipient
+var mergeTagMarkers = []string{"{{", "{%", "*|", "%%"}
+
+// The href attribute of a raw start tag, with its value in any quoting style
+var hrefAttrPattern = regexp.MustCompile(`(?i)(\shref\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+
+/*
+EmailLinkURLs returns the distinct URLs linked from an HTML email, in order of
+first appearance. Only absolute http(s) hrefs of <a> and <area> tags count:
+anchors, mailto: links and hrefs with merge tags (e.g. {{unsubscribe_url}})
+are left alone.
+*/
+func EmailLinkURLs(body string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+
+	walkEmailHrefs(body, func(href string) (string, bool) {
+		if !seen[href] {
+			seen[href] = true
+			urls = append(urls, href)
+		}
+		return "", false
+	})
+
+	return urls
+}
+
+// RewriteEmailLinks replaces the hrefs EmailLinkURLs finds with their entry in replacements.
+// The rest of the email is returned byte for byte.
+func RewriteEmailLinks(body string, replacements map[string]string) string {
+	return walkEmailHrefs(body, func(href string) (string, bool) {
+		replacement, ok := replacements[href]
+		return replacement, ok
+	})
+}
+
+// walkEmailHrefs calls fn with each wrappable href and returns body with the hrefs fn replaced
+func walkEmailHrefs(body string, fn func(href string) (string, bool)) string {
+	var out strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(body))
+
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			// Anything the tokenizer didn't consume is kept as is
+			if z.Err() == io.EOF {
+				return out.String()
+			}
+			out.Write(z.Raw())
+			return out.String()
+		}
+
+		raw := string(z.Raw())
+		if tt == xhtml.StartTagToken || tt == xhtml.SelfClosingTagToken {
+			raw = rewriteTagHref(z, raw, fn)
+		}
+		out.WriteString(raw)
+	}
+}
+
+func rewriteTagHref(z *xhtml.Tokenizer, raw string, fn func(href string) (string, bool)) string {
+	name, hasAttr := z.TagName()
+	if !hasAttr || (string(name) != "a" && string(name) != "area") {
+		return raw
+	}
+
+	var href string
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		if string(key) == "href" {
+			href = strings.TrimSpace(string(val))
+			break
+		}
+	}
+	if !isWrappableHref(href) {
+		return raw
+	}
+
+	replacement, ok := fn(href)
+	if !ok {
+		return raw
+	}
+
+	replaced := false
+	return hrefAttrPattern.ReplaceAllStringFunc(raw, func(attr string) string {
+		if replaced {
+			return attr
+		}
+		replaced = true
+		m := hrefAttrPattern.FindStringSubmatch(attr)
+		return m[1] + `"` + html.EscapeString(replacement) + `"`
+	})
+}
+
+func isWrappableHref(href string) bool {
+	if validateURL(href) != nil {
+		return false
+	}
+	for _, marker := range mergeTagMarkers {
+		if strings.Contains(href, marker) {
+			return false
+		}
+	}
+	return true
+}
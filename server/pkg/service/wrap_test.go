@@ -0,0 +1,45 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmailLinkURLs(t *testing.T) {
+	body := `<html><body>
+<p>Read <a href="https://example.com/post?a=1&amp;b=2">the post</a> or
+<a class='btn' href='https://example.com/shop' target=_blank>shop</a>.</p>
+<p>href="https://example.com/in-text" isn't a link</p>
+<a href="https://example.com/post?a=1&amp;b=2">again</a>
+<a href="mailto:hi@example.com">mail</a> <a href="#top">top</a>
+<a href="{{unsubscribe_url}}">unsubscribe</a> <a href="https://example.com/u?id=*|UNIQID|*">prefs</a>
+<map><area href=https://example.com/map shape="rect"></map>
+</body></html>`
+
+	want := []string{
+		"https://example.com/post?a=1&b=2",
+		"https://example.com/shop",
+		"https://example.com/map",
+	}
+	if got := EmailLinkURLs(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("EmailLinkURLs() = %v, want %v", got, want)
+	}
+}
+
+func TestRewriteEmailLinks(t *testing.T) {
+	body := `<!-- header --><p>Read <a  href = "https://example.com/post?a=1&amp;b=2" data-x="1">the post</a>,
+<A HREF='https://example.com/shop'>shop</A> <a href="https://example.com/other">other</a>
+<a href="mailto:hi@example.com">mail</a></p>`
+
+	got := RewriteEmailLinks(body, map[string]string{
+		"https://example.com/post?a=1&b=2": "https://sho.rt/abc",
+		"https://example.com/shop":         "https://sho.rt/def",
+	})
+
+	want := `<!-- header --><p>Read <a  href = "https://sho.rt/abc" data-x="1">the post</a>,
+<A HREF="https://sho.rt/def">shop</A> <a href="https://example.com/other">other</a>
+<a href="mailto:hi@example.com">mail</a></p>`
+	if got != want {
+		t.Errorf("RewriteEmailLinks() =\n%s\nwant\n%s", got, want)
+	}
+}
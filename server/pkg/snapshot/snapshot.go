@@ -0,0 +1,316 @@
+/*
+Package snapshot writes and reads snapshots of the shortcode to URL mappings,
+which edge instances and restores load into a redirect cache.
+
+A snapshot is CSV, one record per line:
+
+	snapshot,1,2026-10-15T09:00:00Z,
+	set,abc,0b8f3c4e-5a49-4d2c-9a53-2f4c2a8e1d10,user_1,https://example.com
+	del,old,,,
+	end,2,9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+
+The header gives the format version, when the snapshot was taken and, for a
+diff, the time it's a diff from (empty for a full snapshot). A full snapshot
+only sets mappings; a diff also deletes the ones removed since. The footer gives
+the number of entries and the hex SHA-256 of every line before it, so truncated
+or corrupted snapshots are refused.
+
+A diff taken since the TakenAt of the last snapshot loaded brings it up to date.
+*/
+package snapshot
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Version of the format written
+	Version = 1
+	// Entry setting a shortcode's mapping
+	OpSet = "set"
+	// Entry removing a shortcode's mapping, only found in diffs
+	OpDelete = "del"
+
+	headerRecord = "snapshot"
+	footerRecord = "end"
+	// Longest line read, well above the longest URL links take
+	maxLineBytes = 64 << 10
+)
+
+var (
+	// The snapshot isn't in the format, or in a version this package doesn't read
+	ErrFormat = errors.New("invalid snapshot")
+	// The snapshot ends before its footer
+	ErrTruncated = errors.New("snapshot is truncated")
+	// The snapshot's checksum or entry count doesn't match its footer
+	ErrChecksum = errors.New("snapshot checksum mismatch")
+)
+
+type Header struct {
+	Version int
+	// A little before the mappings were read, so a diff since it misses nothing
+	TakenAt time.Time
+	// The time a diff is from; zero for a full snapshot
+	Since time.Time
+}
+
+// IsDiff reports whether the snapshot only holds the changes since Header.Since
+func (h Header) IsDiff() bool {
+	return !h.Since.IsZero()
+}
+
+type Entry struct {
+	Op        string
+	Shortcode string
+	// Link and owner the redirect's clicks are attributed to; unset for OpDelete
+	LinkID uuid.UUID
+	UserID string
+	URL    string
+}
+
+// Writer writes a snapshot. Entries are buffered; call Flush to hand them on and Close to finish the snapshot.
+type Writer struct {
+	out   io.Writer
+	csv   *csv.Writer
+	sum   hash.Hash
+	count int64
+}
+
+// NewWriter starts a snapshot with h, whose Version is set to Version
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	sum := sha256.New()
+	sw := &Writer{
+		out: w,
+		csv: csv.NewWriter(io.MultiWriter(w, sum)),
+		sum: sum,
+	}
+
+	since := ""
+	if h.IsDiff() {
+		since = h.Since.UTC().Format(time.RFC3339Nano)
+	}
+	record := []string{headerRecord, strconv.Itoa(Version), h.TakenAt.UTC().Format(time.RFC3339Nano), since}
+	if err := sw.csv.Write(record); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (w *Writer) Write(e Entry) error {
+	var record []string
+	switch e.Op {
+	case OpSet:
+		record = []string{OpSet, e.Shortcode, e.LinkID.String(), e.UserID, e.URL}
+	case OpDelete:
+		record = []string{OpDelete, e.Shortcode, "", "", ""}
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrFormat, e.Op)
+	}
+	// Records are read line by line, so fields can't span lines
+	for _, field := range record {
+		if strings.ContainsAny(field, "\r\n") {
+			return fmt.Errorf("%w: line break in the entry of %q", ErrFormat, e.Shortcode)
+		}
+	}
+
+	if err := w.csv.Write(record); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Flush writes the buffered entries
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Close writes the footer. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Not part of the checksum it holds
+	footer := csv.NewWriter(w.out)
+	if err := footer.Write([]string{footerRecord, strconv.FormatInt(w.count, 10), hex.EncodeToString(w.sum.Sum(nil))}); err != nil {
+		return err
+	}
+	footer.Flush()
+	return footer.Error()
+}
+
+// Reader reads a snapshot, checking it against its footer
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	sum    hash.Hash
+	count  int64
+	done   bool
+}
+
+// NewReader reads the header of the snapshot
+func NewReader(r io.Reader) (*Reader, error) {
+	sr := &Reader{
+		r:   bufio.NewReader(r),
+		sum: sha256.New(),
+	}
+
+	record, err := sr.record()
+	if err != nil {
+		return nil, err
+	}
+	if len(record) != 4 || record[0] != headerRecord {
+		return nil, fmt.Errorf("%w: missing header", ErrFormat)
+	}
+	if version, err := strconv.Atoi(record[1]); err != nil || version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrFormat, record[1])
+	}
+
+	sr.header.Version = Version
+	if sr.header.TakenAt, err = time.Parse(time.RFC3339Nano, record[2]); err != nil {
+		return nil, fmt.Errorf("%w: invalid taken at time: %v", ErrFormat, err)
+	}
+	if record[3] != "" {
+		if sr.header.Since, err = time.Parse(time.RFC3339Nano, record[3]); err != nil {
+			return nil, fmt.Errorf("%w: invalid since time: %v", ErrFormat, err)
+		}
+	}
+	return sr, nil
+}
+
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next entry, or io.EOF once the footer is read and matches
+func (r *Reader) Next() (Entry, error) {
+	if r.done {
+		return Entry{}, io.EOF
+	}
+
+	// The footer isn't part of the checksum, so it's told apart before hashing
+	line, err := r.line()
+	if err != nil {
+		return Entry{}, err
+	}
+	if strings.HasPrefix(line, footerRecord+",") {
+		return Entry{}, r.footer(line)
+	}
+	r.sum.Write([]byte(line))
+
+	record, err := parseLine(line)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var e Entry
+	switch {
+	case len(record) == 5 && record[0] == OpSet:
+		linkID, err := uuid.Parse(record[2])
+		if err != nil {
+			return Entry{}, fmt.Errorf("%w: invalid link ID of %q", ErrFormat, record[1])
+		}
+		e = Entry{Op: OpSet, Shortcode: record[1], LinkID: linkID, UserID: record[3], URL: record[4]}
+	case len(record) == 5 && record[0] == OpDelete && r.header.IsDiff():
+		e = Entry{Op: OpDelete, Shortcode: record[1]}
+	default:
+		return Entry{}, fmt.Errorf("%w: unexpected %q record", ErrFormat, record[0])
+	}
+	if e.Shortcode == "" {
+		return Entry{}, fmt.Errorf("%w: entry without a shortcode", ErrFormat)
+	}
+
+	r.count++
+	return e, nil
+}
+
+// ReadAll reads a whole snapshot, so nothing of it is loaded unless it's intact
+func ReadAll(r io.Reader) (Header, []Entry, error) {
+	sr, err := NewReader(r)
+	if err != nil {
+		return Header{}, nil, err
+	}
+
+	var entries []Entry
+	for {
+		e, err := sr.Next()
+		if errors.Is(err, io.EOF) {
+			return sr.Header(), entries, nil
+		}
+		if err != nil {
+			return Header{}, nil, err
+		}
+		entries = append(entries, e)
+	}
+}
+
+func (r *Reader) footer(line string) error {
+	record, err := parseLine(line)
+	if err != nil {
+		return err
+	}
+	if len(record) != 3 {
+		return fmt.Errorf("%w: invalid footer", ErrFormat)
+	}
+	count, err := strconv.ParseInt(record[1], 10, 64)
+	if err != nil || count != r.count || record[2] != hex.EncodeToString(r.sum.Sum(nil)) {
+		return ErrChecksum
+	}
+
+	r.done = true
+	return io.EOF
+}
+
+// record reads and hashes the next line, for the header
+func (r *Reader) record() ([]string, error) {
+	line, err := r.line()
+	if err != nil {
+		return nil, err
+	}
+	r.sum.Write([]byte(line))
+	return parseLine(line)
+}
+
+// line returns the next line, its line break included
+func (r *Reader) line() (string, error) {
+	var b strings.Builder
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		b.Write(chunk)
+		if b.Len() > maxLineBytes {
+			return "", fmt.Errorf("%w: line too long", ErrFormat)
+		}
+		switch {
+		case err == nil:
+			return b.String(), nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			return "", ErrTruncated
+		default:
+			return "", err
+		}
+	}
+}
+
+func parseLine(line string) ([]string, error) {
+	record, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	return record, nil
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func writeSnapshot(t *testing.T, h Header, entries ...Entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	for _, e := range entries {
+		if err := w.Write(e); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	takenAt := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	since := takenAt.Add(-time.Hour)
+	entries := []Entry{
+		{Op: OpSet, Shortcode: "abc", LinkID: uuid.New(), UserID: "user_1", URL: "https://example.com/a,b?q=\"x\""},
+		{Op: OpDelete, Shortcode: "old"},
+	}
+
+	h, got, err := ReadAll(bytes.NewReader(writeSnapshot(t, Header{TakenAt: takenAt, Since: since}, entries...)))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if h.Version != Version || !h.TakenAt.Equal(takenAt) || !h.Since.Equal(since) || !h.IsDiff() {
+		t.Errorf("Header = %+v, want the diff's", h)
+	}
+	if len(got) != len(entries) || got[0] != entries[0] || got[1] != entries[1] {
+		t.Errorf("entries = %+v, want %+v", got, entries)
+	}
+}
+
+func TestReadAll_Invalid(t *testing.T) {
+	takenAt := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	set := Entry{Op: OpSet, Shortcode: "abc", LinkID: uuid.New(), UserID: "user_1", URL: "https://example.com"}
+	full := string(writeSnapshot(t, Header{TakenAt: takenAt}, set))
+	lines := strings.SplitAfter(full, "\n")
+
+	tests := []struct {
+		name     string
+		snapshot string
+		want     error
+	}{
+		{name: "empty", snapshot: "", want: ErrTruncated},
+		{name: "no footer", snapshot: lines[0] + lines[1], want: ErrTruncated},
+		{name: "corrupted entry", snapshot: strings.Replace(full, "example.com", "example.org", 1), want: ErrChecksum},
+		{name: "dropped entry", snapshot: lines[0] + lines[2], want: ErrChecksum},
+		{name: "not a snapshot", snapshot: "day,link_id\n", want: ErrFormat},
+		{name: "delete in a full snapshot", snapshot: strings.Replace(full, lines[1], "del,abc,,,\n", 1), want: ErrFormat},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := ReadAll(strings.NewReader(tt.snapshot)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: ReadAll() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestWriter_RejectsLineBreaks(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, Header{TakenAt: time.Now()})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	err = w.Write(Entry{Op: OpSet, Shortcode: "abc", UserID: "user\n1", URL: "https://example.com"})
+	if !errors.Is(err, ErrFormat) {
+		t.Errorf("Write() error = %v, want ErrFormat", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package sqlitestore
+
+// The pure Go SQLite driver, registered as "sqlite". It isn't a default dependency:
+// run go get modernc.org/sqlite before building with -tags sqlite.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,255 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// linkColumns are the columns the link queries return, in the order of the sqlc ones
+const linkColumns = `id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url`
+
+// linkTagsColumn is the tags column of the links aliased l, as json_agg builds it on Postgres
+const linkTagsColumn = `(
+    SELECT json_group_array(json_object('id', t.id, 'name', t.name, 'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', t.created_at)))
+    FROM (
+        SELECT t.id, t.name, t.created_at FROM link_tags lt
+        JOIN tags t ON t.id = lt.tag_id
+        WHERE lt.link_id = l.id
+        ORDER BY t.name
+    ) t
+) AS tags`
+
+// activeFilter is the is_active filter of ListUserLinks and CountUserLinks: NULL shows all links,
+// true the ones that redirect, false the deactivated or expired ones
+const activeFilter = `(
+    @is_active IS NULL
+    OR (@is_active = 1 AND l.is_active = 1 AND (l.expires_at IS NULL OR l.expires_at > @now))
+    OR (@is_active = 0 AND (l.is_active = 0 OR (l.expires_at IS NOT NULL AND l.expires_at <= @now)))
+)`
+
+// tagFilter keeps the links with any of the tags; NULL keeps all
+const tagFilter = `(
+    @tag_ids IS NULL
+    OR l.id IN (SELECT link_id FROM link_tags WHERE tag_id IN (SELECT value FROM json_each(@tag_ids)))
+)`
+
+func (s *Store) TryCreateLink(ctx context.Context, arg db.TryCreateLinkParams) (db.TryCreateLinkRow, error) {
+	return queryRow[db.TryCreateLinkRow](ctx, s, `
+INSERT INTO links (id, shortcode, original_url, user_id, expires_at, created_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy)
+SELECT @id, @shortcode, @original_url, @user_id, @expires_at, @now, @visibility, @capture_email, @redirect_delay, @interstitial_message, @raw_url, @append_click_id, @title, @shield, @referrer_policy
+WHERE NOT EXISTS (
+    SELECT 1 FROM links
+    WHERE shortcode = @shortcode AND deleted_at IS NULL
+)
+RETURNING `+linkColumns,
+		sql.Named("id", uuid.New()),
+		sql.Named("shortcode", arg.Shortcode),
+		sql.Named("original_url", arg.OriginalUrl),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("expires_at", timestamp(arg.ExpiresAt)),
+		sql.Named("now", now()),
+		sql.Named("visibility", arg.Visibility),
+		sql.Named("capture_email", arg.CaptureEmail),
+		sql.Named("redirect_delay", arg.RedirectDelay),
+		sql.Named("interstitial_message", arg.InterstitialMessage),
+		sql.Named("raw_url", arg.RawUrl),
+		sql.Named("append_click_id", arg.AppendClickID),
+		sql.Named("title", arg.Title),
+		sql.Named("shield", arg.Shield),
+		sql.Named("referrer_policy", arg.ReferrerPolicy),
+	)
+}
+
+// GetLinkForRedirect has no traffic caps or waiting rooms to join: those need Postgres
+func (s *Store) GetLinkForRedirect(ctx context.Context, shortcode string) (db.GetLinkForRedirectRow, error) {
+	return queryRow[db.GetLinkForRedirectRow](ctx, s, `
+SELECT id, COALESCE(raw_url, original_url) AS original_url, user_id, visibility, capture_email, redirect_delay, interstitial_message, append_click_id, shield, referrer_policy, retired_at, sunset_message, sunset_url
+FROM links
+WHERE shortcode = @shortcode
+AND deleted_at IS NULL
+AND (
+    retired_at IS NOT NULL
+    OR (is_active = 1 AND (expires_at IS NULL OR expires_at > @now))
+)
+LIMIT 1`,
+		sql.Named("shortcode", shortcode),
+		sql.Named("now", now()),
+	)
+}
+
+func (s *Store) ListUserLinks(ctx context.Context, arg db.ListUserLinksParams) ([]db.ListUserLinksRow, error) {
+	return queryRows[db.ListUserLinksRow](ctx, s, `
+SELECT `+linkColumns+`, `+linkTagsColumn+`
+FROM links l
+WHERE l.user_id = @user_id
+  AND l.deleted_at IS NULL
+  AND `+activeFilter+`
+  AND `+tagFilter+`
+ORDER BY l.created_at DESC
+LIMIT @limit OFFSET @offset`,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("is_active", arg.IsActive),
+		sql.Named("now", now()),
+		sql.Named("tag_ids", idList(arg.TagIds)),
+		sql.Named("limit", arg.Limit),
+		sql.Named("offset", arg.Offset),
+	)
+}
+
+func (s *Store) ListUserLinksByIDs(ctx context.Context, arg db.ListUserLinksByIDsParams) ([]db.ListUserLinksByIDsRow, error) {
+	return queryRows[db.ListUserLinksByIDsRow](ctx, s, `
+SELECT `+linkColumns+`, `+linkTagsColumn+`
+FROM links l
+WHERE l.user_id = @user_id
+  AND l.id IN (SELECT value FROM json_each(@ids))
+  AND l.deleted_at IS NULL
+ORDER BY l.created_at DESC`,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("ids", idList(arg.Ids)),
+	)
+}
+
+func (s *Store) CountUserLinks(ctx context.Context, arg db.CountUserLinksParams) (int64, error) {
+	return queryRow[int64](ctx, s, `
+SELECT COUNT(*) AS total
+FROM links l
+WHERE l.user_id = @user_id
+  AND l.deleted_at IS NULL
+  AND `+activeFilter+`
+  AND `+tagFilter,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("is_active", arg.IsActive),
+		sql.Named("now", now()),
+		sql.Named("tag_ids", idList(arg.TagIds)),
+	)
+}
+
+func (s *Store) GetLinkByIdAndUser(ctx context.Context, arg db.GetLinkByIdAndUserParams) (db.GetLinkByIdAndUserRow, error) {
+	return queryRow[db.GetLinkByIdAndUserRow](ctx, s, `
+SELECT `+linkColumns+`
+FROM links
+WHERE id = @id AND user_id = @user_id AND deleted_at IS NULL
+LIMIT 1`,
+		sql.Named("id", arg.ID),
+		sql.Named("user_id", arg.UserID),
+	)
+}
+
+func (s *Store) GetLinkByIdAndUserWithTags(ctx context.Context, arg db.GetLinkByIdAndUserWithTagsParams) (db.GetLinkByIdAndUserWithTagsRow, error) {
+	return queryRow[db.GetLinkByIdAndUserWithTagsRow](ctx, s, `
+SELECT `+linkColumns+`, `+linkTagsColumn+`
+FROM links l
+WHERE l.id = @id AND l.user_id = @user_id AND l.deleted_at IS NULL`,
+		sql.Named("id", arg.ID),
+		sql.Named("user_id", arg.UserID),
+	)
+}
+
+func (s *Store) GetLinkByShortcodeAndUser(ctx context.Context, arg db.GetLinkByShortcodeAndUserParams) (db.GetLinkByShortcodeAndUserRow, error) {
+	return queryRow[db.GetLinkByShortcodeAndUserRow](ctx, s, `
+SELECT `+linkColumns+`, `+linkTagsColumn+`
+FROM links l
+WHERE l.shortcode = @shortcode AND l.user_id = @user_id AND l.deleted_at IS NULL`,
+		sql.Named("shortcode", arg.Shortcode),
+		sql.Named("user_id", arg.UserID),
+	)
+}
+
+func (s *Store) UpdateLink(ctx context.Context, arg db.UpdateLinkParams) (db.UpdateLinkRow, error) {
+	return queryRow[db.UpdateLinkRow](ctx, s, `
+UPDATE links
+SET
+    shortcode = COALESCE(@shortcode, shortcode),
+    is_active = COALESCE(@is_active, is_active),
+    expires_at = COALESCE(@expires_at, expires_at),
+    visibility = COALESCE(@visibility, visibility),
+    capture_email = COALESCE(@capture_email, capture_email),
+    redirect_delay = COALESCE(@redirect_delay, redirect_delay),
+    interstitial_message = COALESCE(@interstitial_message, interstitial_message),
+    append_click_id = COALESCE(@append_click_id, append_click_id),
+    shield = COALESCE(@shield, shield),
+    referrer_policy = COALESCE(@referrer_policy, referrer_policy),
+    updated_at = @now
+WHERE id = @id AND user_id = @user_id AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING `+linkColumns,
+		sql.Named("id", arg.ID),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("shortcode", arg.Shortcode),
+		sql.Named("is_active", arg.IsActive),
+		sql.Named("expires_at", timestamp(arg.ExpiresAt)),
+		sql.Named("visibility", arg.Visibility),
+		sql.Named("capture_email", arg.CaptureEmail),
+		sql.Named("redirect_delay", arg.RedirectDelay),
+		sql.Named("interstitial_message", arg.InterstitialMessage),
+		sql.Named("append_click_id", arg.AppendClickID),
+		sql.Named("shield", arg.Shield),
+		sql.Named("referrer_policy", arg.ReferrerPolicy),
+		sql.Named("now", now()),
+	)
+}
+
+func (s *Store) DeleteLink(ctx context.Context, arg db.DeleteLinkParams) (db.DeleteLinkRow, error) {
+	return queryRow[db.DeleteLinkRow](ctx, s, `
+UPDATE links
+SET deleted_at = @now, updated_at = @now
+WHERE id = @id AND user_id = @user_id AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING `+linkColumns,
+		sql.Named("id", arg.ID),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("now", now()),
+	)
+}
+
+func (s *Store) RetireLink(ctx context.Context, arg db.RetireLinkParams) (db.RetireLinkRow, error) {
+	return queryRow[db.RetireLinkRow](ctx, s, `
+UPDATE links
+SET retired_at = COALESCE(retired_at, @now),
+    sunset_message = @sunset_message,
+    sunset_url = @sunset_url,
+    updated_at = @now
+WHERE id = @id AND user_id = @user_id AND deleted_at IS NULL
+RETURNING `+linkColumns,
+		sql.Named("id", arg.ID),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("sunset_message", arg.SunsetMessage),
+		sql.Named("sunset_url", arg.SunsetUrl),
+		sql.Named("now", now()),
+	)
+}
+
+func (s *Store) GetUserLinkByURL(ctx context.Context, arg db.GetUserLinkByURLParams) (db.GetUserLinkByURLRow, error) {
+	return queryRow[db.GetUserLinkByURLRow](ctx, s, `
+SELECT `+linkColumns+`
+FROM links
+WHERE user_id = @user_id
+  AND original_url = @original_url
+  AND deleted_at IS NULL
+  AND is_active = 1
+  AND (expires_at IS NULL OR expires_at > @now)
+  AND visibility = 'public'
+  AND capture_email = 0
+  AND redirect_delay = 0
+  AND append_click_id = 0
+  AND shield = 0
+  AND referrer_policy = 'default'
+  AND retired_at IS NULL
+ORDER BY created_at DESC
+LIMIT 1`,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("original_url", arg.OriginalUrl),
+		sql.Named("now", now()),
+	)
+}
+
+// GetShortcodeReservation finds nothing: reservations need Postgres
+func (s *Store) GetShortcodeReservation(ctx context.Context, shortcode string) (db.ShortcodeReservation, error) {
+	return db.ShortcodeReservation{}, sql.ErrNoRows
+}
+
+// CreateActivityEvent drops the event: the activity feed needs Postgres
+func (s *Store) CreateActivityEvent(ctx context.Context, arg db.CreateActivityEventParams) error {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !sqlite
+
+package sqlitestore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpen_WithoutDriver(t *testing.T) {
+	_, err := Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err == nil || !strings.Contains(err.Error(), "-tags sqlite") {
+		t.Errorf("expected an error pointing at the sqlite build tag, got %v", err)
+	}
+}
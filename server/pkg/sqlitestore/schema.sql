@@ -0,0 +1,54 @@
+-- SQLite counterpart of the links, tags and link_tags tables of the Postgres migrations.
+-- UUIDs and timestamps are TEXT, booleans INTEGER. Applied on every start, so it only
+-- creates what doesn't exist yet.
+
+CREATE TABLE IF NOT EXISTS links (
+	id TEXT PRIMARY KEY,
+	shortcode TEXT NOT NULL,
+	original_url TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	expires_at TEXT DEFAULT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT DEFAULT NULL,
+	deleted_at TEXT DEFAULT NULL,
+	is_active INTEGER NOT NULL DEFAULT 1,
+	visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'private')),
+	capture_email INTEGER NOT NULL DEFAULT 0,
+	redirect_delay INTEGER NOT NULL DEFAULT 0 CHECK (redirect_delay BETWEEN 0 AND 30),
+	interstitial_message TEXT DEFAULT NULL,
+	raw_url TEXT DEFAULT NULL,
+	append_click_id INTEGER NOT NULL DEFAULT 0,
+	title TEXT DEFAULT NULL,
+	shield INTEGER NOT NULL DEFAULT 0,
+	referrer_policy TEXT NOT NULL DEFAULT 'default' CHECK (referrer_policy IN ('default', 'no-referrer', 'origin')),
+	retired_at TEXT DEFAULT NULL,
+	sunset_message TEXT DEFAULT NULL,
+	sunset_url TEXT DEFAULT NULL
+);
+
+-- Deleted links free their shortcode
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_shortcode ON links(shortcode) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_id ON links(user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT DEFAULT NULL,
+	-- Set while the tag is in the trash, with the JSON array of the links it was detached from
+	deleted_at TEXT DEFAULT NULL,
+	detached_link_ids TEXT DEFAULT NULL
+);
+
+-- Names only need to be unique among live tags; replaces the index on every tag
+DROP INDEX IF EXISTS index_tags_user_id_name;
+CREATE UNIQUE INDEX IF NOT EXISTS index_tags_user_id_name_live ON tags(user_id, name) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS link_tags (
+	link_id TEXT NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+	tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY (link_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_tags_tag_id ON link_tags(tag_id);
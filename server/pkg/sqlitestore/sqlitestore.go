@@ -0,0 +1,292 @@
+/*
+Package sqlitestore keeps links and tags in a SQLite file, for self-hosting on a
+single machine without Postgres (STORAGE_BACKEND=sqlite).
+
+Store implements the link and tag queries with hand-written SQLite versions of
+the sqlc ones and the same semantics: not-found is sql.ErrNoRows and unique
+violations are reported with the Postgres SQLSTATE 23505, so services behave as
+they do on Postgres. Every other query comes from an embedded *db.Queries that
+fails with db.ErrUnsupported.
+
+The driver (modernc.org/sqlite, pure Go) is only compiled in with the sqlite
+build tag; without it Open fails.
+*/
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+// DriverName is the database/sql driver the store opens
+const DriverName = "sqlite"
+
+//go:embed schema.sql
+var schema string
+
+// conn runs queries; *sql.DB and *sql.Tx implement it
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Store holds links and tags in a SQLite database
+type Store struct {
+	// Queries the store doesn't implement; they fail with db.ErrUnsupported
+	*db.Queries
+
+	db *sql.DB
+	// The database, or the transaction of a unit of work
+	conn conn
+}
+
+// Open opens (creating it if needed) the database file at path and applies the schema
+func Open(ctx context.Context, path string) (*Store, error) {
+	if !slices.Contains(sql.Drivers(), DriverName) {
+		return nil, errors.New("SQLite support isn't compiled in, build with -tags sqlite")
+	}
+
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	sqlDB, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite has a single writer: one connection queues writes instead of failing them with SQLITE_BUSY
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := addColumns(ctx, sqlDB); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to upgrade SQLite schema: %w", err)
+	}
+	if _, err := sqlDB.ExecContext(ctx, schema); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to apply SQLite schema: %w", err)
+	}
+
+	return &Store{
+		Queries: db.New(db.Unsupported()),
+		db:      sqlDB,
+		conn:    sqlDB,
+	}, nil
+}
+
+// addedColumns are the columns added to schema.sql after their table, which CREATE TABLE IF NOT EXISTS doesn't add to existing databases
+var addedColumns = []struct{ table, column, definition string }{
+	{"tags", "deleted_at", "TEXT DEFAULT NULL"},
+	{"tags", "detached_link_ids", "TEXT DEFAULT NULL"},
+}
+
+// addColumns adds the missing addedColumns to the tables that exist already
+func addColumns(ctx context.Context, sqlDB *sql.DB) error {
+	for _, c := range addedColumns {
+		var columns, found int
+		err := sqlDB.QueryRowContext(ctx,
+			`SELECT COUNT(*), COUNT(*) FILTER (WHERE name = ?) FROM pragma_table_info(?)`, c.column, c.table,
+		).Scan(&columns, &found)
+		if err != nil {
+			return err
+		}
+		// A table that doesn't exist yet is created with the column
+		if columns == 0 || found > 0 {
+			continue
+		}
+		if _, err := sqlDB.ExecContext(ctx, "ALTER TABLE "+c.table+" ADD COLUMN "+c.column+" "+c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping is the store's readiness check
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+/*
+WithTx runs fn with a store bound to a single transaction, committed when fn
+returns nil and rolled back when it returns an error or panics. Like
+db.Store.WithTx, fn must only use the store it is given: with the one
+connection held by the transaction, queries through the outer store would wait
+for it forever.
+*/
+func (s *Store) WithTx(ctx context.Context, fn func(q *Store) error) (err error) {
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Store{Queries: s.Queries, db: s.db, conn: tx}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// timeLayout has fixed-width fractions and every timestamp is stored in UTC, so they compare correctly as text
+const timeLayout = "2006-01-02 15:04:05.000000Z07:00"
+
+// timestamp binds a TIMESTAMPTZ parameter, stored in UTC
+func timestamp(ts pgtype.Timestamptz) any {
+	if !ts.Valid {
+		return nil
+	}
+	return ts.Time.UTC().Format(timeLayout)
+}
+
+// now stands in for NOW(), which SQLite doesn't have at the precision of the stored timestamps
+func now() string {
+	return time.Now().UTC().Format(timeLayout)
+}
+
+// idList binds a uuid[] parameter as a JSON array for json_each; nil stays NULL
+func idList(ids []uuid.UUID) any {
+	if ids == nil {
+		return nil
+	}
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+// translate reports SQLite unique violations the way Postgres does, since that's what services check
+func translate(err error) error {
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return &pgconn.PgError{Code: "23505", Message: err.Error()}
+	}
+	return err
+}
+
+func (s *Store) exec(ctx context.Context, query string, args ...any) error {
+	_, err := s.conn.ExecContext(ctx, query, args...)
+	return translate(err)
+}
+
+// queryRows runs a query and scans every row into a T (see scanRow)
+func queryRows[T any](ctx context.Context, s *Store, query string, args ...any) ([]T, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translate(err)
+	}
+	defer rows.Close()
+
+	var items []T
+	for rows.Next() {
+		var item T
+		if err := scanRow(rows, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translate(err)
+	}
+	return items, nil
+}
+
+// queryRow is queryRows for a single row; no row is sql.ErrNoRows
+func queryRow[T any](ctx context.Context, s *Store, query string, args ...any) (T, error) {
+	items, err := queryRows[T](ctx, s, query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if len(items) == 0 {
+		var zero T
+		return zero, sql.ErrNoRows
+	}
+	return items[0], nil
+}
+
+/*
+scanRow scans the current row into dst. A struct gets each column in the field
+whose json tag names it, which is how sqlc tags its row types, and fields without
+a column are left zero; the interface{} fields sqlc generates for JSON columns
+get the raw JSON. Anything else gets the first column.
+*/
+func scanRow(rows *sql.Rows, dst any) error {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Struct || v.Type().ConvertibleTo(reflect.TypeFor[pgtype.Timestamptz]()) {
+		return rows.Scan(dst)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	targets := make([]any, len(columns))
+	for i, column := range columns {
+		field, ok := fieldByColumn(v, column)
+		if !ok {
+			return fmt.Errorf("%s has no field for column %s", v.Type(), column)
+		}
+		if field.Kind() == reflect.Interface {
+			targets[i] = jsonColumn{field}
+		} else {
+			targets[i] = field.Addr().Interface()
+		}
+	}
+	return rows.Scan(targets...)
+}
+
+func fieldByColumn(v reflect.Value, column string) (reflect.Value, bool) {
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name == column {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// jsonColumn scans a JSON column into an interface{} field as json.RawMessage
+type jsonColumn struct {
+	field reflect.Value
+}
+
+func (c jsonColumn) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case string:
+		c.field.Set(reflect.ValueOf(json.RawMessage(src)))
+	case []byte:
+		c.field.Set(reflect.ValueOf(json.RawMessage(slices.Clone(src))))
+	default:
+		return fmt.Errorf("cannot scan %T into a JSON column", src)
+	}
+	return nil
+}
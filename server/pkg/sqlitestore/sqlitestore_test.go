@@ -0,0 +1,238 @@
+//go:build sqlite
+
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func createLink(t *testing.T, s *Store, userID, shortcode string) db.TryCreateLinkRow {
+	t.Helper()
+	link, err := s.TryCreateLink(context.Background(), db.TryCreateLinkParams{
+		Shortcode:      shortcode,
+		OriginalUrl:    "https://example.com/" + shortcode,
+		UserID:         userID,
+		Visibility:     "public",
+		ReferrerPolicy: "default",
+	})
+	if err != nil {
+		t.Fatalf("TryCreateLink(%q) failed: %v", shortcode, err)
+	}
+	return link
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func TestStore_CreateAndRedirect(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	link := createLink(t, s, "user_1", "abc123")
+	if !link.IsActive || !link.CreatedAt.Valid {
+		t.Errorf("expected an active link with a creation time, got %+v", link)
+	}
+
+	redirect, err := s.GetLinkForRedirect(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("GetLinkForRedirect failed: %v", err)
+	}
+	if redirect.ID != link.ID || redirect.OriginalUrl != "https://example.com/abc123" {
+		t.Errorf("expected the created link, got %+v", redirect)
+	}
+
+	// A taken shortcode is reported as no row, like on Postgres
+	_, err = s.TryCreateLink(ctx, db.TryCreateLinkParams{Shortcode: "abc123", OriginalUrl: "https://example.org", UserID: "user_2", Visibility: "public", ReferrerPolicy: "default"})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for a taken shortcode, got %v", err)
+	}
+
+	if _, err := s.DeleteLink(ctx, db.DeleteLinkParams{ID: link.ID, UserID: "user_1"}); err != nil {
+		t.Fatalf("DeleteLink failed: %v", err)
+	}
+	if _, err := s.GetLinkForRedirect(ctx, "abc123"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for a deleted link, got %v", err)
+	}
+	// Deleted links free their shortcode
+	createLink(t, s, "user_2", "abc123")
+}
+
+func TestStore_UpdateLink(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	createLink(t, s, "user_1", "first")
+	second := createLink(t, s, "user_1", "second")
+
+	taken := "first"
+	_, err := s.UpdateLink(ctx, db.UpdateLinkParams{ID: second.ID, UserID: "user_1", Shortcode: &taken})
+	if !isUniqueViolation(err) {
+		t.Fatalf("expected a unique violation, got %v", err)
+	}
+
+	inactive := false
+	updated, err := s.UpdateLink(ctx, db.UpdateLinkParams{ID: second.ID, UserID: "user_1", IsActive: &inactive})
+	if err != nil {
+		t.Fatalf("UpdateLink failed: %v", err)
+	}
+	if updated.IsActive || updated.Shortcode != "second" || !updated.UpdatedAt.Valid {
+		t.Errorf("expected only is_active to change, got %+v", updated)
+	}
+
+	if _, err := s.UpdateLink(ctx, db.UpdateLinkParams{ID: second.ID, UserID: "user_2", IsActive: &inactive}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows updating another user's link, got %v", err)
+	}
+
+	count, err := s.CountUserLinks(ctx, db.CountUserLinksParams{UserID: "user_1", IsActive: &inactive})
+	if err != nil {
+		t.Fatalf("CountUserLinks failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 inactive link, got %d", count)
+	}
+}
+
+func TestStore_Tags(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	tagged := createLink(t, s, "user_1", "tagged")
+	createLink(t, s, "user_1", "untagged")
+
+	tag, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	if _, err := s.CreateTag(ctx, db.CreateTagParams{Name: "work", UserID: "user_1"}); !isUniqueViolation(err) {
+		t.Errorf("expected a unique violation for a duplicate tag name, got %v", err)
+	}
+
+	upserted, err := s.UpsertTag(ctx, db.UpsertTagParams{Name: "work", UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("UpsertTag failed: %v", err)
+	}
+	if upserted.ID != tag.ID || upserted.Created {
+		t.Errorf("expected the existing tag, got %+v", upserted)
+	}
+
+	if err := s.AddTagsToLink(ctx, db.AddTagsToLinkParams{LinkID: tagged.ID, UserID: "user_1", TagIDs: []uuid.UUID{tag.ID}}); err != nil {
+		t.Fatalf("AddTagsToLink failed: %v", err)
+	}
+
+	filtered, err := s.ListUserLinks(ctx, db.ListUserLinksParams{UserID: "user_1", TagIds: []uuid.UUID{tag.ID}, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUserLinks failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != tagged.ID {
+		t.Fatalf("expected only the tagged link, got %+v", filtered)
+	}
+	var tags []struct {
+		ID   uuid.UUID `json:"id"`
+		Name string    `json:"name"`
+	}
+	if err := json.Unmarshal(filtered[0].Tags.(json.RawMessage), &tags); err != nil || len(tags) != 1 || tags[0].ID != tag.ID {
+		t.Errorf("expected the link's tags, got %s (%v)", filtered[0].Tags, err)
+	}
+
+	suggested, err := s.SuggestTagsForHost(ctx, db.SuggestTagsForHostParams{UserID: "user_1", Host: "example.com", MaxResults: 5})
+	if err != nil {
+		t.Fatalf("SuggestTagsForHost failed: %v", err)
+	}
+	if len(suggested) != 1 || suggested[0].Name != "work" || suggested[0].LinkCount != 1 {
+		t.Errorf("expected the work tag, got %+v", suggested)
+	}
+
+	if _, err := s.CreateTag(ctx, db.CreateTagParams{Name: "Workshop", UserID: "user_1"}); err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	page, err := s.ListUserTagsPage(ctx, db.ListUserTagsPageParams{UserID: "user_1", Prefix: "WORK", Sort: "usage", RowLimit: 10})
+	if err != nil {
+		t.Fatalf("ListUserTagsPage failed: %v", err)
+	}
+	if len(page) != 2 || page[0].Name != "work" || page[0].LinkCount != 1 || page[1].LinkCount != 0 {
+		t.Errorf("expected both tags, most used first, got %+v", page)
+	}
+	total, err := s.CountUserTagsByPrefix(ctx, db.CountUserTagsByPrefixParams{UserID: "user_1", Prefix: "works"})
+	if err != nil || total != 1 {
+		t.Errorf("expected 1 tag starting with works, got %d (%v)", total, err)
+	}
+
+	// Deleting the tag untags its links until it's restored
+	if _, err := s.DeleteTag(ctx, db.DeleteTagParams{ID: tag.ID, UserID: "user_1"}); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	withTags, err := s.GetLinkByIdAndUserWithTags(ctx, db.GetLinkByIdAndUserWithTagsParams{ID: tagged.ID, UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("GetLinkByIdAndUserWithTags failed: %v", err)
+	}
+	if string(withTags.Tags.(json.RawMessage)) != "[]" {
+		t.Errorf("expected no tags, got %s", withTags.Tags)
+	}
+	trashed, err := s.ListDeletedTags(ctx, "user_1")
+	if err != nil {
+		t.Fatalf("ListDeletedTags failed: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != tag.ID || trashed[0].LinkCount != 1 {
+		t.Fatalf("expected the work tag in the trash with its link, got %+v", trashed)
+	}
+
+	restored, err := s.RestoreTag(ctx, db.RestoreTagParams{ID: tag.ID, UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("RestoreTag failed: %v", err)
+	}
+	if restored.ID != tag.ID {
+		t.Errorf("expected the work tag back, got %+v", restored)
+	}
+	withTags, err = s.GetLinkByIdAndUserWithTags(ctx, db.GetLinkByIdAndUserWithTagsParams{ID: tagged.ID, UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("GetLinkByIdAndUserWithTags failed: %v", err)
+	}
+	if err := json.Unmarshal(withTags.Tags.(json.RawMessage), &tags); err != nil || len(tags) != 1 || tags[0].ID != tag.ID {
+		t.Errorf("expected the restored tag back on the link, got %s (%v)", withTags.Tags, err)
+	}
+}
+
+func TestStore_WithTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	failure := errors.New("boom")
+
+	err := s.WithTx(ctx, func(q *Store) error {
+		createLink(t, q, "user_1", "abc123")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the unit of work's error, got %v", err)
+	}
+	if _, err := s.GetLinkForRedirect(ctx, "abc123"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the link to be rolled back, got %v", err)
+	}
+}
+
+func TestStore_UnsupportedQueries(t *testing.T) {
+	_, err := openTestStore(t).ListUserCampaigns(context.Background(), "user_1")
+	if !errors.Is(err, db.ErrUnsupported) {
+		t.Errorf("expected db.ErrUnsupported, got %v", err)
+	}
+}
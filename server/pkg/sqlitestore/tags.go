@@ -0,0 +1,339 @@
+package sqlitestore
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/styltsou/url-shortener/server/pkg/db"
+)
+
+const tagColumns = `id, name, created_at, updated_at`
+
+func (s *Store) ListUserTags(ctx context.Context, userID string) ([]db.ListUserTagsRow, error) {
+	return queryRows[db.ListUserTagsRow](ctx, s, `
+SELECT `+tagColumns+` FROM tags
+WHERE user_id = @user_id AND deleted_at IS NULL
+ORDER BY name`,
+		sql.Named("user_id", userID),
+	)
+}
+
+// tagPrefixFilter matches the tags whose name starts with @prefix; SQLite's lower() only folds ASCII
+const tagPrefixFilter = `substr(lower(t.name), 1, length(@prefix)) = lower(@prefix)`
+
+func (s *Store) ListUserTagsPage(ctx context.Context, arg db.ListUserTagsPageParams) ([]db.ListUserTagsPageRow, error) {
+	return queryRows[db.ListUserTagsPageRow](ctx, s, `
+SELECT t.id, t.name, t.created_at, t.updated_at, COUNT(l.id) AS link_count
+FROM tags t
+LEFT JOIN link_tags lt ON lt.tag_id = t.id
+LEFT JOIN links l ON l.id = lt.link_id AND l.deleted_at IS NULL
+WHERE t.user_id = @user_id
+  AND t.deleted_at IS NULL
+  AND `+tagPrefixFilter+`
+GROUP BY t.id
+ORDER BY
+    CASE WHEN @sort = 'usage' THEN COUNT(l.id) END DESC,
+    CASE WHEN @sort = 'created_at' THEN t.created_at END DESC,
+    t.name, t.id
+LIMIT @limit OFFSET @offset`,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("prefix", arg.Prefix),
+		sql.Named("sort", arg.Sort),
+		sql.Named("limit", arg.RowLimit),
+		sql.Named("offset", arg.RowOffset),
+	)
+}
+
+func (s *Store) CountUserTagsByPrefix(ctx context.Context, arg db.CountUserTagsByPrefixParams) (int64, error) {
+	return queryRow[int64](ctx, s, `
+SELECT COUNT(*) FROM tags t
+WHERE t.user_id = @user_id
+  AND t.deleted_at IS NULL
+  AND `+tagPrefixFilter,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("prefix", arg.Prefix),
+	)
+}
+
+func (s *Store) CreateTag(ctx context.Context, arg db.CreateTagParams) (db.CreateTagRow, error) {
+	return queryRow[db.CreateTagRow](ctx, s, `
+INSERT INTO tags (id, name, user_id, created_at)
+VALUES (@id, @name, @user_id, @now)
+RETURNING `+tagColumns,
+		sql.Named("id", uuid.New()),
+		sql.Named("name", arg.Name),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("now", now()),
+	)
+}
+
+// upsertTag is the statement behind UpsertTag and UpsertTagsByName; created tells whether the tag is new
+const upsertTag = `
+INSERT INTO tags (id, name, user_id, created_at)
+VALUES (@id, @name, @user_id, @now)
+ON CONFLICT (user_id, name) WHERE deleted_at IS NULL DO UPDATE SET name = excluded.name
+RETURNING ` + tagColumns + `, id = @id AS created`
+
+func (s *Store) UpsertTag(ctx context.Context, arg db.UpsertTagParams) (db.UpsertTagRow, error) {
+	return queryRow[db.UpsertTagRow](ctx, s, upsertTag,
+		sql.Named("id", uuid.New()),
+		sql.Named("name", arg.Name),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("now", now()),
+	)
+}
+
+// UpsertTagsByName upserts the tags one by one, in a transaction so it is all or nothing like the single statement on Postgres
+func (s *Store) UpsertTagsByName(ctx context.Context, arg db.UpsertTagsByNameParams) ([]db.UpsertTagsByNameRow, error) {
+	var rows []db.UpsertTagsByNameRow
+	err := s.WithTx(ctx, func(q *Store) error {
+		seen := make(map[string]bool)
+		for _, name := range arg.Names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			row, err := queryRow[db.UpsertTagRow](ctx, q, upsertTag,
+				sql.Named("id", uuid.New()),
+				sql.Named("name", name),
+				sql.Named("user_id", arg.UserID),
+				sql.Named("now", now()),
+			)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, db.UpsertTagsByNameRow{ID: row.ID, Name: row.Name, CreatedAt: row.CreatedAt, UpdatedAt: row.UpdatedAt})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s *Store) UpdateTag(ctx context.Context, arg db.UpdateTagParams) (db.UpdateTagRow, error) {
+	return queryRow[db.UpdateTagRow](ctx, s, `
+UPDATE tags
+SET name = @name, updated_at = @now
+WHERE id = @id AND user_id = @user_id AND deleted_at IS NULL
+RETURNING `+tagColumns,
+		sql.Named("id", arg.ID),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("name", arg.Name),
+		sql.Named("now", now()),
+	)
+}
+
+// trashTags moves the user's live tags among @tag_ids to the trash, remembering the links they're on
+const trashTags = `
+UPDATE tags
+SET deleted_at = @now,
+    updated_at = @now,
+    detached_link_ids = (SELECT json_group_array(lt.link_id) FROM link_tags lt WHERE lt.tag_id = tags.id)
+WHERE id IN (SELECT value FROM json_each(@tag_ids)) AND user_id = @user_id AND deleted_at IS NULL
+RETURNING ` + tagColumns
+
+// DeleteTag moves the tag to the trash and detaches it from its links, in a transaction like the single statement on Postgres
+func (s *Store) DeleteTag(ctx context.Context, arg db.DeleteTagParams) (db.DeleteTagRow, error) {
+	rows, err := s.DeleteTags(ctx, db.DeleteTagsParams{TagIDs: []uuid.UUID{arg.ID}, UserID: arg.UserID})
+	if err != nil {
+		return db.DeleteTagRow{}, err
+	}
+	if len(rows) == 0 {
+		return db.DeleteTagRow{}, sql.ErrNoRows
+	}
+	return db.DeleteTagRow(rows[0]), nil
+}
+
+func (s *Store) DeleteTags(ctx context.Context, arg db.DeleteTagsParams) ([]db.DeleteTagsRow, error) {
+	var rows []db.DeleteTagsRow
+	err := s.WithTx(ctx, func(q *Store) error {
+		trashed, err := queryRows[db.DeleteTagsRow](ctx, q, trashTags,
+			sql.Named("tag_ids", idList(arg.TagIDs)),
+			sql.Named("user_id", arg.UserID),
+			sql.Named("now", now()),
+		)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(trashed))
+		for _, t := range trashed {
+			ids = append(ids, t.ID)
+		}
+		if err := q.exec(ctx, `
+DELETE FROM link_tags
+WHERE tag_id IN (SELECT value FROM json_each(@tag_ids))`,
+			sql.Named("tag_ids", idList(ids)),
+		); err != nil {
+			return err
+		}
+
+		rows = trashed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s *Store) ListDeletedTags(ctx context.Context, userID string) ([]db.ListDeletedTagsRow, error) {
+	return queryRows[db.ListDeletedTagsRow](ctx, s, `
+SELECT `+tagColumns+`, deleted_at, COALESCE(json_array_length(detached_link_ids), 0) AS link_count
+FROM tags
+WHERE user_id = @user_id AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, name`,
+		sql.Named("user_id", userID),
+	)
+}
+
+// RestoreTag takes the tag out of the trash and puts it back on the links it was detached from, in a transaction
+func (s *Store) RestoreTag(ctx context.Context, arg db.RestoreTagParams) (db.RestoreTagRow, error) {
+	var row db.RestoreTagRow
+	err := s.WithTx(ctx, func(q *Store) error {
+		if err := q.exec(ctx, `
+INSERT INTO link_tags (link_id, tag_id)
+SELECT l.id, t.id
+FROM tags t
+JOIN links l ON l.id IN (SELECT value FROM json_each(t.detached_link_ids)) AND l.user_id = t.user_id
+WHERE t.id = @id AND t.user_id = @user_id AND t.deleted_at IS NOT NULL
+ON CONFLICT (link_id, tag_id) DO NOTHING`,
+			sql.Named("id", arg.ID),
+			sql.Named("user_id", arg.UserID),
+		); err != nil {
+			return err
+		}
+
+		restored, err := queryRow[db.RestoreTagRow](ctx, q, `
+UPDATE tags
+SET deleted_at = NULL, detached_link_ids = NULL, updated_at = @now
+WHERE id = @id AND user_id = @user_id AND deleted_at IS NOT NULL
+RETURNING `+tagColumns,
+			sql.Named("id", arg.ID),
+			sql.Named("user_id", arg.UserID),
+			sql.Named("now", now()),
+		)
+		row = restored
+		return err
+	})
+	return row, err
+}
+
+func (s *Store) CountUserTagsByIDs(ctx context.Context, arg db.CountUserTagsByIDsParams) (int64, error) {
+	return queryRow[int64](ctx, s, `
+SELECT COUNT(*) FROM tags
+WHERE user_id = @user_id
+  AND deleted_at IS NULL
+  AND id IN (SELECT value FROM json_each(@ids))`,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("ids", idList(arg.Ids)),
+	)
+}
+
+func (s *Store) ListUserTagNamesByIDs(ctx context.Context, arg db.ListUserTagNamesByIDsParams) ([]string, error) {
+	return queryRows[string](ctx, s, `
+SELECT name FROM tags
+WHERE user_id = @user_id
+  AND deleted_at IS NULL
+  AND id IN (SELECT value FROM json_each(@ids))`,
+		sql.Named("user_id", arg.UserID),
+		sql.Named("ids", idList(arg.Ids)),
+	)
+}
+
+// AddTagsToLink assigns the user's tags to the user's live link; others are ignored
+func (s *Store) AddTagsToLink(ctx context.Context, arg db.AddTagsToLinkParams) error {
+	return s.exec(ctx, `
+INSERT INTO link_tags (link_id, tag_id)
+SELECT @link_id, t.id FROM tags t
+WHERE t.id IN (SELECT value FROM json_each(@tag_ids))
+  AND t.user_id = @user_id
+  AND t.deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM links l
+      WHERE l.id = @link_id AND l.user_id = @user_id AND l.deleted_at IS NULL
+  )
+ON CONFLICT (link_id, tag_id) DO NOTHING`,
+		sql.Named("link_id", arg.LinkID),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("tag_ids", idList(arg.TagIDs)),
+	)
+}
+
+func (s *Store) RemoveTagsFromLink(ctx context.Context, arg db.RemoveTagsFromLinkParams) error {
+	return s.exec(ctx, `
+DELETE FROM link_tags
+WHERE link_id = @link_id
+  AND tag_id IN (SELECT value FROM json_each(@tag_ids))
+  AND EXISTS (
+      SELECT 1 FROM links l
+      WHERE l.id = @link_id AND l.user_id = @user_id AND l.deleted_at IS NULL
+  )`,
+		sql.Named("link_id", arg.LinkID),
+		sql.Named("user_id", arg.UserID),
+		sql.Named("tag_ids", idList(arg.TagIDs)),
+	)
+}
+
+// taggedLink is one tag on one of the user's links, for SuggestTagsForHost
+type taggedLink struct {
+	OriginalUrl string    `json:"original_url"`
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+}
+
+/*
+SuggestTagsForHost ranks the user's tags by how many of their links to the host
+(ignoring a leading www.) carry them. SQLite has no regular expressions to pull
+the host out of the URL, so the links are matched here instead of in SQL.
+*/
+func (s *Store) SuggestTagsForHost(ctx context.Context, arg db.SuggestTagsForHostParams) ([]db.SuggestTagsForHostRow, error) {
+	tagged, err := queryRows[taggedLink](ctx, s, `
+SELECT l.original_url, t.id, t.name
+FROM links l
+JOIN link_tags lt ON lt.link_id = l.id
+JOIN tags t ON t.id = lt.tag_id
+WHERE l.user_id = @user_id AND l.deleted_at IS NULL`,
+		sql.Named("user_id", arg.UserID),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]*db.SuggestTagsForHostRow)
+	for _, tl := range tagged {
+		u, err := url.Parse(tl.OriginalUrl)
+		if err != nil || strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") != arg.Host {
+			continue
+		}
+		row, ok := counts[tl.ID]
+		if !ok {
+			row = &db.SuggestTagsForHostRow{ID: tl.ID, Name: tl.Name}
+			counts[tl.ID] = row
+		}
+		row.LinkCount++
+	}
+
+	var rows []db.SuggestTagsForHostRow
+	for _, row := range counts {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b db.SuggestTagsForHostRow) int {
+		if c := cmp.Compare(b.LinkCount, a.LinkCount); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(rows) > int(arg.MaxResults) {
+		rows = rows[:arg.MaxResults]
+	}
+	return rows, nil
+}
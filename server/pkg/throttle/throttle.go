@@ -0,0 +1,173 @@
+/*
+Package throttle bounds how many expensive operations (clicks exports, stats
+aggregation) run at once, so a few large ones can't take every database
+connection and starve the redirects.
+
+Operations have a weight and run while the weights in flight fit the
+limiter's capacity. Others wait in a bounded queue, and are turned away when
+the queue is full, when they waited too long, or when their user already has
+as many operations running or waiting as allowed.
+*/
+package throttle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Weights of the throttled operations, relative to each other
+const (
+	// Clicks exports scan every click in their period
+	WeightExport int64 = 4
+	// Tag and campaign stats aggregate daily rollups; activity verification rehashes the user's log
+	WeightStats int64 = 1
+)
+
+// RetryAfter is what turned away clients are told to wait before retrying
+const RetryAfter = 5 * time.Second
+
+var (
+	// The user has as many operations running or waiting as allowed
+	ErrUserLimit = errors.New("too many operations in progress for the user")
+	// Too many operations are already waiting for capacity
+	ErrQueueFull = errors.New("operation queue is full")
+	// The operation waited QueueTimeout without getting capacity
+	ErrQueueTimeout = errors.New("timed out waiting for capacity")
+)
+
+// Options configures a Limiter
+type Options struct {
+	// Total weight of the operations that can run at once
+	Capacity int64
+	// Operations that can wait for capacity; more are rejected with ErrQueueFull
+	MaxQueue int64
+	// How long an operation waits for capacity before ErrQueueTimeout
+	QueueTimeout time.Duration
+	// Operations one user can have running or waiting; zero means no limit
+	PerUser int
+}
+
+// Limiter admits expensive operations while they fit its capacity
+type Limiter struct {
+	opts   Options
+	sem    *semaphore.Weighted
+	queued atomic.Int64
+
+	mu    sync.Mutex
+	users map[string]int
+}
+
+func New(opts Options) *Limiter {
+	return &Limiter{
+		opts:  opts,
+		sem:   semaphore.NewWeighted(opts.Capacity),
+		users: make(map[string]int),
+	}
+}
+
+/*
+Acquire waits until an operation of the given weight fits and returns its
+slot, which must be released once the operation is done. Weights above the
+capacity are capped to it, so such an operation runs alone instead of never.
+When ctx ends while waiting, its error is returned.
+*/
+func (l *Limiter) Acquire(ctx context.Context, userID string, weight int64) (*Slot, error) {
+	weight = min(weight, l.opts.Capacity)
+
+	if !l.enter(userID) {
+		return nil, ErrUserLimit
+	}
+
+	if !l.sem.TryAcquire(weight) {
+		if l.queued.Add(1) > l.opts.MaxQueue {
+			l.queued.Add(-1)
+			l.leave(userID)
+			return nil, ErrQueueFull
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, l.opts.QueueTimeout)
+		err := l.sem.Acquire(waitCtx, weight)
+		cancel()
+		l.queued.Add(-1)
+
+		if err != nil {
+			l.leave(userID)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, ErrQueueTimeout
+		}
+	}
+
+	return &Slot{release: func() {
+		l.sem.Release(weight)
+		l.leave(userID)
+	}}, nil
+}
+
+// enter counts an operation against the user's limit, unless they reached it
+func (l *Limiter) enter(userID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.opts.PerUser > 0 && l.users[userID] >= l.opts.PerUser {
+		return false
+	}
+	l.users[userID]++
+	return true
+}
+
+func (l *Limiter) leave(userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.users[userID]--; l.users[userID] <= 0 {
+		delete(l.users, userID)
+	}
+}
+
+// Slot is the capacity held by one running operation
+type Slot struct {
+	release  func()
+	once     sync.Once
+	detached atomic.Bool
+}
+
+// Release gives the capacity back. It's safe to call more than once and on a nil slot.
+func (s *Slot) Release() {
+	if s == nil {
+		return
+	}
+	s.once.Do(s.release)
+}
+
+type slotKey struct{}
+
+// WithSlot stores the slot of the request's operation in its context
+func WithSlot(ctx context.Context, slot *Slot) context.Context {
+	return context.WithValue(ctx, slotKey{}, slot)
+}
+
+/*
+Detach takes over the slot stored in ctx, for work that outlives the request
+(a background export): whoever acquired it no longer releases it, and the
+caller must release it once the work is done. Returns nil, which is safe to
+release, when ctx holds no slot.
+*/
+func Detach(ctx context.Context) *Slot {
+	slot, _ := ctx.Value(slotKey{}).(*Slot)
+	if slot != nil {
+		slot.detached.Store(true)
+	}
+	return slot
+}
+
+// Detached reports whether the slot was taken over with Detach
+func (s *Slot) Detached() bool {
+	return s.detached.Load()
+}
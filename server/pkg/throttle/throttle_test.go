@@ -0,0 +1,125 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Acquire(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs operations that fit and queues the rest", func(t *testing.T) {
+		l := New(Options{Capacity: 4, MaxQueue: 1, QueueTimeout: time.Second})
+
+		export, err := l.Acquire(ctx, "user_1", WeightExport)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+
+		acquired := make(chan error, 1)
+		go func() {
+			slot, err := l.Acquire(ctx, "user_2", WeightStats)
+			slot.Release()
+			acquired <- err
+		}()
+
+		select {
+		case err := <-acquired:
+			t.Fatalf("queued Acquire() returned %v before capacity was released", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		export.Release()
+		if err := <-acquired; err != nil {
+			t.Errorf("queued Acquire() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("rejects when the queue is full", func(t *testing.T) {
+		l := New(Options{Capacity: 1, MaxQueue: 0, QueueTimeout: time.Second})
+
+		slot, err := l.Acquire(ctx, "user_1", WeightStats)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		defer slot.Release()
+
+		if _, err := l.Acquire(ctx, "user_2", WeightStats); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("Acquire() error = %v, want ErrQueueFull", err)
+		}
+	})
+
+	t.Run("gives up after the queue timeout", func(t *testing.T) {
+		l := New(Options{Capacity: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+
+		slot, err := l.Acquire(ctx, "user_1", WeightStats)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		defer slot.Release()
+
+		if _, err := l.Acquire(ctx, "user_2", WeightStats); !errors.Is(err, ErrQueueTimeout) {
+			t.Errorf("Acquire() error = %v, want ErrQueueTimeout", err)
+		}
+	})
+
+	t.Run("limits operations per user", func(t *testing.T) {
+		l := New(Options{Capacity: 8, MaxQueue: 8, QueueTimeout: time.Second, PerUser: 1})
+
+		slot, err := l.Acquire(ctx, "user_1", WeightStats)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+
+		if _, err := l.Acquire(ctx, "user_1", WeightStats); !errors.Is(err, ErrUserLimit) {
+			t.Errorf("Acquire() error = %v, want ErrUserLimit", err)
+		}
+		if other, err := l.Acquire(ctx, "user_2", WeightStats); err != nil {
+			t.Errorf("Acquire() for another user error = %v, want nil", err)
+		} else {
+			other.Release()
+		}
+
+		slot.Release()
+		if again, err := l.Acquire(ctx, "user_1", WeightStats); err != nil {
+			t.Errorf("Acquire() after release error = %v, want nil", err)
+		} else {
+			again.Release()
+		}
+	})
+
+	t.Run("caps weights to the capacity", func(t *testing.T) {
+		l := New(Options{Capacity: 2, MaxQueue: 0, QueueTimeout: time.Second})
+
+		slot, err := l.Acquire(ctx, "user_1", WeightExport)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		slot.Release()
+		slot.Release()
+
+		if slot, err := l.Acquire(ctx, "user_1", 2); err != nil {
+			t.Errorf("Acquire() after a double release error = %v, want nil", err)
+		} else {
+			slot.Release()
+		}
+	})
+}
+
+func TestDetach(t *testing.T) {
+	if slot := Detach(context.Background()); slot != nil {
+		t.Errorf("Detach() without a slot = %v, want nil", slot)
+	}
+
+	l := New(Options{Capacity: 1, QueueTimeout: time.Second})
+	slot, err := l.Acquire(context.Background(), "user_1", WeightStats)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	if got := Detach(WithSlot(context.Background(), slot)); got != slot || !slot.Detached() {
+		t.Errorf("Detach() = %v (detached %v), want the stored slot, detached", got, slot.Detached())
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Package urlcrypt encrypts destination URLs before they're stored, for
+deployments that mustn't keep them readable in the database or its backups.
+
+Values are sealed with AES-256-GCM. The nonce is derived from the URL itself,
+so a URL always encrypts to the same value under a key: lookups and grouping by
+destination keep working in SQL, at the cost of revealing which rows share a
+destination. Each value names the key it was sealed with, so keys can be
+rotated: new values use the first key of the keyring, the others only decrypt.
+*/
+package urlcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prefix marks encrypted values: "enc:v1:<key ID>:<base64url nonce and ciphertext>"
+const Prefix = "enc:v1:"
+
+// Length of the keys in the keyring, before derivation
+const KeySize = 32
+
+// ErrUnknownKey is returned for values sealed with a key that isn't in the keyring
+var ErrUnknownKey = errors.New("encryption key not in keyring")
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Keyring holds the keys URLs are encrypted and decrypted with
+type Keyring struct {
+	active *key
+	keys   map[string]*key
+}
+
+type key struct {
+	id       string
+	aead     cipher.AEAD
+	nonceKey []byte
+}
+
+/*
+ParseKeys reads a keyring from "id:key,id:key,...", where each key is 32 bytes
+encoded in base64 (standard or URL alphabet); keys can also be on separate
+lines. The first key encrypts; keep the ones it replaced after it until every
+value is re-encrypted.
+*/
+func ParseKeys(s string) (*Keyring, error) {
+	kr := &Keyring{keys: make(map[string]*key)}
+
+	entries := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("key %q must be <id>:<base64 key>, the ID made of letters, digits, - and _", redactEntry(entry))
+		}
+		if _, dup := kr.keys[id]; dup {
+			return nil, fmt.Errorf("key ID %q is used twice", id)
+		}
+
+		secret, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k, err := newKey(id, secret)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+
+		kr.keys[id] = k
+		if kr.active == nil {
+			kr.active = k
+		}
+	}
+
+	if kr.active == nil {
+		return nil, errors.New("no keys")
+	}
+	return kr, nil
+}
+
+// ActiveKeyID returns the ID of the key new values are encrypted with
+func (kr *Keyring) ActiveKeyID() string {
+	return kr.active.id
+}
+
+// Encrypt seals the URL with the active key. Equal URLs give equal values.
+func (kr *Keyring) Encrypt(url string) string {
+	k := kr.active
+	nonce := k.nonce(url)
+	sealed := k.aead.Seal(nonce, nonce, []byte(url), k.additionalData())
+	return Prefix + k.id + ":" + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// Decrypt opens a value made by Encrypt with any key of the keyring. Values that
+// aren't encrypted, e.g. rows written before encryption was turned on, are returned as they are.
+func (kr *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	k, ok := kr.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():]
+	plaintext, err := k.aead.Open(nil, nonce, ciphertext, k.additionalData())
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// Current reports whether the value is already encrypted with the active key
+func (kr *Keyring) Current(value string) bool {
+	return strings.HasPrefix(value, Prefix+kr.active.id+":")
+}
+
+// IsEncrypted reports whether the value was made by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// newKey derives separate encryption and nonce keys from the configured secret
+func newKey(id string, secret []byte) (*key, error) {
+	block, err := aes.NewCipher(derive(secret, "url encryption"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &key{id: id, aead: aead, nonceKey: derive(secret, "url nonce")}, nil
+}
+
+// nonce is the URL's HMAC, so encryption is deterministic without reusing a nonce for different URLs
+func (k *key) nonce(url string) []byte {
+	return hmacSum(k.nonceKey, []byte(url))[:k.aead.NonceSize()]
+}
+
+// additionalData binds the ciphertext to the key ID in front of it
+func (k *key) additionalData() []byte {
+	return []byte(Prefix + k.id)
+}
+
+func derive(secret []byte, purpose string) []byte {
+	return hmacSum(secret, []byte(purpose))
+}
+
+func hmacSum(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if secret, err := enc.DecodeString(encoded); err == nil {
+			if len(secret) != KeySize {
+				return nil, fmt.Errorf("must be %d bytes, got %d", KeySize, len(secret))
+			}
+			return secret, nil
+		}
+	}
+	return nil, errors.New("must be base64")
+}
+
+// redactEntry keeps a key's secret out of error messages
+func redactEntry(entry string) string {
+	if id, _, ok := strings.Cut(entry, ":"); ok {
+		return id + ":..."
+	}
+	return "..."
+}
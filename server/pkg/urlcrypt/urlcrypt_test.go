@@ -0,0 +1,112 @@
+package urlcrypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), KeySize)))
+}
+
+func mustParse(t *testing.T, s string) *Keyring {
+	t.Helper()
+	kr, err := ParseKeys(s)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	return kr
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	kr := mustParse(t, "k1:"+testKey('a'))
+	url := "https://example.com/page?q=1"
+
+	encrypted := kr.Encrypt(url)
+	if !strings.HasPrefix(encrypted, "enc:v1:k1:") || strings.Contains(encrypted, "example.com") {
+		t.Fatalf("Encrypt() = %q, want an enc:v1:k1: value hiding the URL", encrypted)
+	}
+	if again := kr.Encrypt(url); again != encrypted {
+		t.Errorf("Encrypt() twice = %q and %q, want the same value", encrypted, again)
+	}
+	if other := kr.Encrypt(url + "2"); other == encrypted {
+		t.Error("Encrypt() of different URLs gave the same value")
+	}
+
+	decrypted, err := kr.Decrypt(encrypted)
+	if err != nil || decrypted != url {
+		t.Errorf("Decrypt() = %q, %v, want %q, nil", decrypted, err, url)
+	}
+
+	// Rows written before encryption was turned on are read as they are
+	if plain, err := kr.Decrypt(url); err != nil || plain != url {
+		t.Errorf("Decrypt() of a plain URL = %q, %v, want it unchanged", plain, err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old := mustParse(t, "k1:"+testKey('a'))
+	rotated := mustParse(t, "k2:"+testKey('b')+", k1:"+testKey('a'))
+	url := "https://example.com"
+
+	sealedOld := old.Encrypt(url)
+	if rotated.Current(sealedOld) {
+		t.Error("Current() = true for a value sealed with the previous key")
+	}
+	if decrypted, err := rotated.Decrypt(sealedOld); err != nil || decrypted != url {
+		t.Errorf("Decrypt() with the previous key = %q, %v, want %q, nil", decrypted, err, url)
+	}
+
+	sealedNew := rotated.Encrypt(url)
+	if rotated.ActiveKeyID() != "k2" || !rotated.Current(sealedNew) {
+		t.Errorf("Encrypt() = %q, want it sealed with the active key k2", sealedNew)
+	}
+
+	// Once the previous key is dropped, what it sealed can't be read
+	if _, err := mustParse(t, "k2:"+testKey('b')).Decrypt(sealedOld); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() without the key error = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestKeyring_DecryptTampered(t *testing.T) {
+	kr := mustParse(t, "k1:"+testKey('a')+"\nk2:"+testKey('b'))
+	encrypted := kr.Encrypt("https://example.com")
+
+	tests := map[string]string{
+		"flipped byte": encrypted[:len(encrypted)-2] + "AA",
+		"other key ID": strings.Replace(encrypted, ":k1:", ":k2:", 1),
+		"truncated":    "enc:v1:k1:AAAA",
+		"no key ID":    "enc:v1:AAAA",
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := kr.Decrypt(value); err == nil {
+				t.Error("Decrypt() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseKeys_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":        " , ",
+		"no ID":        testKey('a'),
+		"bad ID":       "k/1:" + testKey('a'),
+		"short key":    "k1:" + base64.StdEncoding.EncodeToString([]byte("too short")),
+		"not base64":   "k1:not base64!",
+		"duplicate ID": "k1:" + testKey('a') + ",k1:" + testKey('b'),
+	}
+	for name, keys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseKeys(keys)
+			if err == nil {
+				t.Fatal("ParseKeys() error = nil, want an error")
+			}
+			if strings.Contains(err.Error(), testKey('a')) {
+				t.Errorf("ParseKeys() error = %q, want the key left out", err)
+			}
+		})
+	}
+}
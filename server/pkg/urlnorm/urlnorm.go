@@ -0,0 +1,164 @@
+// Package urlnorm canonicalizes destination URLs so that equivalent URLs
+// compare equal when stored and when looking for duplicates.
+package urlnorm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultStripParams are the tracking parameters removed when no list is configured.
+// A trailing "*" matches any parameter with that prefix.
+var DefaultStripParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+}
+
+// Options configures a Normalizer
+type Options struct {
+	// Query parameters to remove; a trailing "*" matches by prefix
+	StripParams []string
+	// Sort the remaining query parameters by name
+	SortParams bool
+}
+
+// Normalizer canonicalizes URLs. A nil *Normalizer returns URLs unchanged.
+type Normalizer struct {
+	exact    map[string]bool
+	prefixes []string
+	sort     bool
+}
+
+func New(opts Options) *Normalizer {
+	n := &Normalizer{
+		exact: make(map[string]bool, len(opts.StripParams)),
+		sort:  opts.SortParams,
+	}
+
+	for _, param := range opts.StripParams {
+		param = strings.ToLower(strings.TrimSpace(param))
+		if param == "" {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			n.prefixes = append(n.prefixes, prefix)
+		} else {
+			n.exact[param] = true
+		}
+	}
+
+	return n
+}
+
+// Normalize returns the canonical form of rawURL:
+//   - scheme and host are lowercased
+//   - default ports (:80 for http, :443 for https) are removed
+//   - an empty path becomes "/"
+//   - the fragment is removed
+//   - tracking parameters are removed and, if configured, the rest sorted by name
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	if n == nil {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = normalizeHost(u.Scheme, u.Host)
+
+	if u.Path == "" && u.Opaque == "" {
+		u.Path = "/"
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.RawQuery = n.normalizeQuery(u.RawQuery)
+
+	return u.String(), nil
+}
+
+// normalizeHost lowercases the host and drops the scheme's default port
+func normalizeHost(scheme, host string) string {
+	host = strings.ToLower(host)
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		// No port
+		return host
+	}
+
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		// Keep the brackets around IPv6 literals
+		if strings.Contains(hostname, ":") {
+			return "[" + hostname + "]"
+		}
+		return hostname
+	}
+
+	return host
+}
+
+// normalizeQuery removes tracking parameters and optionally sorts the rest.
+// Parameters are split on the raw string so that the encoding of values is kept.
+func (n *Normalizer) normalizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+
+	for _, param := range params {
+		if param == "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(param, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+
+		if n.strip(strings.ToLower(name)) {
+			continue
+		}
+
+		kept = append(kept, param)
+	}
+
+	if n.sort {
+		// Stable so repeated parameters keep their relative order
+		sort.SliceStable(kept, func(i, j int) bool {
+			nameI, _, _ := strings.Cut(kept[i], "=")
+			nameJ, _, _ := strings.Cut(kept[j], "=")
+			return nameI < nameJ
+		})
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func (n *Normalizer) strip(name string) bool {
+	if n.exact[name] {
+		return true
+	}
+
+	for _, prefix := range n.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
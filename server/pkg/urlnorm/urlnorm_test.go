@@ -0,0 +1,106 @@
+package urlnorm
+
+import "testing"
+
+func TestNormalizer_Normalize(t *testing.T) {
+	n := New(Options{StripParams: DefaultStripParams, SortParams: true})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "lowercases scheme and host but not path",
+			input:    "HTTPS://Example.COM/Some/Path",
+			expected: "https://example.com/Some/Path",
+		},
+		{
+			name:     "strips default https port",
+			input:    "https://example.com:443/a",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "strips default http port",
+			input:    "http://example.com:80/a",
+			expected: "http://example.com/a",
+		},
+		{
+			name:     "keeps non-default port",
+			input:    "https://example.com:8443/a",
+			expected: "https://example.com:8443/a",
+		},
+		{
+			name:     "keeps IPv6 brackets when stripping port",
+			input:    "http://[::1]:80/a",
+			expected: "http://[::1]/a",
+		},
+		{
+			name:     "adds root path",
+			input:    "https://example.com",
+			expected: "https://example.com/",
+		},
+		{
+			name:     "strips fragment",
+			input:    "https://example.com/a#section",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "strips tracking params by name and prefix",
+			input:    "https://example.com/a?utm_source=x&id=1&UTM_Medium=y&fbclid=z",
+			expected: "https://example.com/a?id=1",
+		},
+		{
+			name:     "sorts remaining params and keeps repeated ones in order",
+			input:    "https://example.com/a?b=2&a=1&b=1",
+			expected: "https://example.com/a?a=1&b=2&b=1",
+		},
+		{
+			name:     "keeps value encoding",
+			input:    "https://example.com/a?q=hello%20world&r=a+b",
+			expected: "https://example.com/a?q=hello%20world&r=a+b",
+		},
+		{
+			name:     "drops query made only of tracking params",
+			input:    "https://example.com/a?utm_campaign=spring",
+			expected: "https://example.com/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := n.Normalize(tt.input)
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Normalize() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizer_NoSort(t *testing.T) {
+	n := New(Options{StripParams: []string{"ref"}})
+
+	got, err := n.Normalize("https://example.com/?b=2&ref=x&a=1")
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if expected := "https://example.com/?b=2&a=1"; got != expected {
+		t.Errorf("Normalize() = %q, want %q", got, expected)
+	}
+}
+
+func TestNormalizer_Nil(t *testing.T) {
+	var n *Normalizer
+
+	input := "HTTPS://Example.com:443/a?utm_source=x#frag"
+	got, err := n.Normalize(input)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if got != input {
+		t.Errorf("Normalize() = %q, want input unchanged", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Package validation holds the request validation rules shared across DTOs,
+// registered as go-playground/validator tags so DTOs declare them in struct tags.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Custom validator tags
+const (
+	// An absolute http or https URL with a host, of at most MaxURLLength characters
+	TagHTTPURL = "httpurl"
+	// A custom shortcode: 1 to MaxShortcodeLength letters, digits, '-' or '_'
+	TagShortcode = "shortcode"
+	// A time after the moment of validation
+	TagFutureTime = "future_time"
+)
+
+const (
+	MaxURLLength       = 2048
+	MaxShortcodeLength = 20
+)
+
+// Shortcodes are path segments of short URLs, so they're limited to characters that need no escaping
+var shortcodePattern = regexp.MustCompile(fmt.Sprintf(`^[A-Za-z0-9_-]{1,%d}$`, MaxShortcodeLength))
+
+// New returns a validator with the custom tags registered
+func New() *validator.Validate {
+	v := validator.New()
+
+	// Registration only fails on empty tags or nil functions
+	_ = v.RegisterValidation(TagHTTPURL, func(fl validator.FieldLevel) bool {
+		return CheckHTTPURL(fl.Field().String()) == nil
+	})
+	_ = v.RegisterValidation(TagShortcode, func(fl validator.FieldLevel) bool {
+		return CheckShortcode(fl.Field().String()) == nil
+	})
+	_ = v.RegisterValidation(TagFutureTime, func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+
+	return v
+}
+
+// CheckHTTPURL returns why rawURL isn't an httpurl, or nil
+func CheckHTTPURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("URL is required")
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return errors.New("URL must use http or https scheme")
+	}
+
+	if parsedURL.Host == "" {
+		return errors.New("URL must have a valid host")
+	}
+
+	if len(rawURL) > MaxURLLength {
+		return fmt.Errorf("URL is too long (max %d characters)", MaxURLLength)
+	}
+
+	return nil
+}
+
+// CheckShortcode returns why shortcode isn't a valid custom shortcode, or nil
+func CheckShortcode(shortcode string) error {
+	if !shortcodePattern.MatchString(shortcode) {
+		return fmt.Errorf("shortcode must be 1 to %d letters, digits, '-' or '_'", MaxShortcodeLength)
+	}
+	return nil
+}
+
+// Message describes a failed rule for API clients. Custom tags get a sentence of their own;
+// the others keep the validator's message.
+func Message(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case TagHTTPURL:
+		if err := CheckHTTPURL(fmt.Sprint(fieldErr.Value())); err != nil {
+			return err.Error()
+		}
+	case TagShortcode:
+		return CheckShortcode("").Error()
+	case TagFutureTime:
+		return "must be set to a future time"
+	}
+	return fieldErr.Error()
+}
@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type createLink struct {
+	URL       string     `validate:"required,httpurl"`
+	Shortcode *string    `validate:"omitempty,shortcode"`
+	ExpiresAt *time.Time `validate:"omitempty,future_time"`
+}
+
+func TestNew_CustomTags(t *testing.T) {
+	v := New()
+	str := func(s string) *string { return &s }
+	at := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name    string
+		dto     createLink
+		wantTag string
+	}{
+		{name: "valid", dto: createLink{URL: "https://example.com/a?b={click_id}", Shortcode: str("my-code_1"), ExpiresAt: at(time.Now().Add(time.Hour))}},
+		{name: "optional fields omitted", dto: createLink{URL: "http://example.com"}},
+		{name: "ftp url", dto: createLink{URL: "ftp://example.com"}, wantTag: TagHTTPURL},
+		{name: "url without host", dto: createLink{URL: "https:///path"}, wantTag: TagHTTPURL},
+		{name: "url too long", dto: createLink{URL: "https://example.com/" + strings.Repeat("a", MaxURLLength)}, wantTag: TagHTTPURL},
+		{name: "shortcode with a slash", dto: createLink{URL: "https://example.com", Shortcode: str("a/b")}, wantTag: TagShortcode},
+		{name: "empty shortcode", dto: createLink{URL: "https://example.com", Shortcode: str("")}, wantTag: TagShortcode},
+		{name: "shortcode too long", dto: createLink{URL: "https://example.com", Shortcode: str(strings.Repeat("a", MaxShortcodeLength+1))}, wantTag: TagShortcode},
+		{name: "past expiry", dto: createLink{URL: "https://example.com", ExpiresAt: at(time.Now().Add(-time.Minute))}, wantTag: TagFutureTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.dto)
+			if tt.wantTag == "" {
+				if err != nil {
+					t.Fatalf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var fieldErrs validator.ValidationErrors
+			if !errors.As(err, &fieldErrs) || len(fieldErrs) != 1 || fieldErrs[0].Tag() != tt.wantTag {
+				t.Fatalf("Struct() error = %v, want a %s failure", err, tt.wantTag)
+			}
+			if msg := Message(fieldErrs[0]); msg == "" || strings.Contains(msg, "Field validation") {
+				t.Errorf("Message() = %q, want the rule's own message", msg)
+			}
+		})
+	}
+}
+
+func TestCheckHTTPURL_Messages(t *testing.T) {
+	tests := map[string]string{
+		"":                    "URL is required",
+		"mailto:a@b.c":        "URL must use http or https scheme",
+		"https://":            "URL must have a valid host",
+		"https://example.com": "",
+	}
+	for rawURL, want := range tests {
+		err := CheckHTTPURL(rawURL)
+		if (err == nil) != (want == "") || (err != nil && err.Error() != want) {
+			t.Errorf("CheckHTTPURL(%q) = %v, want %q", rawURL, err, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"webhook.test"}`)
+	signedAt := time.Unix(1700000000, 0)
+
+	current, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret() error = %v", err)
+	}
+	if !strings.HasPrefix(current, secretPrefix) {
+		t.Errorf("secret = %q, want the %q prefix", current, secretPrefix)
+	}
+	previous, _ := NewSecret()
+
+	// Signed with both secrets, as during a rotation
+	header := Sign(payload, []string{current, previous}, signedAt)
+	if strings.Count(header, SignatureScheme+"=") != 2 {
+		t.Errorf("header = %q, want a signature per secret", header)
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  string
+		secret  string
+		now     time.Time
+		want    error
+	}{
+		{name: "current secret", payload: payload, header: header, secret: current, now: signedAt.Add(time.Minute)},
+		{name: "previous secret", payload: payload, header: header, secret: previous, now: signedAt},
+		{name: "other secret", payload: payload, header: header, secret: "whsec_other", now: signedAt, want: ErrNoValidSignature},
+		{name: "tampered payload", payload: []byte(`{"id":"evt_2"}`), header: header, secret: current, now: signedAt, want: ErrNoValidSignature},
+		{name: "replayed later", payload: payload, header: header, secret: current, now: signedAt.Add(DefaultTolerance + time.Second), want: ErrTimestampOutsideTolerance},
+		{name: "from the future", payload: payload, header: header, secret: current, now: signedAt.Add(-DefaultTolerance - time.Second), want: ErrTimestampOutsideTolerance},
+		{name: "no timestamp", payload: payload, header: "v1=abcd", secret: current, now: signedAt, want: ErrInvalidHeader},
+		{name: "garbage", payload: payload, header: "nonsense", secret: current, now: signedAt, want: ErrInvalidHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.payload, tt.header, tt.secret, DefaultTolerance, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
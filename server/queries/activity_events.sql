@@ -0,0 +1,35 @@
+-- name: CreateActivityEvent :exec
+INSERT INTO activity_events (user_id, action, target_id, summary)
+VALUES ($1, $2, $3, $4);
+
+
+-- name: ListUserActivity :many
+SELECT id, user_id, action, target_id, summary, created_at, seq, prev_hash, hash
+FROM activity_events
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+
+-- name: CountUserActivity :one
+SELECT COUNT(*)
+FROM activity_events
+WHERE user_id = $1;
+
+
+-- name: ListUserActivityChain :many
+-- The user's activity events after after_seq, in chain order, for verifying the chain
+SELECT id, user_id, action, target_id, summary, created_at, seq, prev_hash, hash
+FROM activity_events
+WHERE user_id = sqlc.arg(user_id) AND seq > sqlc.arg(after_seq)
+ORDER BY seq
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: ListActivityUsers :many
+-- Users with activity events after after_user_id, in order, for the chain verification job
+SELECT DISTINCT user_id
+FROM activity_events
+WHERE user_id > sqlc.arg(after_user_id)
+ORDER BY user_id
+LIMIT sqlc.arg(row_limit);
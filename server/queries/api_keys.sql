@@ -0,0 +1,51 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (user_id, name, scopes, key_hash)
+VALUES (sqlc.arg(user_id)::TEXT, sqlc.arg(name)::VARCHAR(100), sqlc.arg(scopes)::TEXT[], sqlc.arg(key_hash)::VARCHAR(64))
+RETURNING id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at;
+
+-- name: ListUserApiKeys :many
+SELECT id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetUserApiKey :one
+SELECT id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at
+FROM api_keys
+WHERE id = $1 AND user_id = $2;
+
+-- name: RevokeApiKey :one
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at;
+
+-- name: RotateApiKey :one
+-- Replaces the key, keeping the current one valid until previous_key_expires_at
+UPDATE api_keys
+SET previous_key_hash = key_hash,
+    previous_key_expires_at = sqlc.arg(previous_key_expires_at),
+    previous_key_last_used_at = NULL,
+    key_hash = sqlc.arg(key_hash),
+    key_rotated_at = NOW()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND revoked_at IS NULL
+RETURNING id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at;
+
+-- name: RevokePreviousApiKey :one
+-- Ends the previous key's grace period early
+UPDATE api_keys
+SET previous_key_hash = NULL,
+    previous_key_expires_at = NULL
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at;
+
+-- name: UseApiKey :one
+-- Resolves a live key by its hash, or by its previous one while it's valid, recording the use
+UPDATE api_keys
+SET last_used_at = NOW(),
+    last_used_ip = sqlc.narg(ip)::TEXT,
+    use_count = use_count + 1,
+    previous_key_last_used_at = CASE WHEN key_hash = sqlc.arg(key_hash) THEN previous_key_last_used_at ELSE NOW() END
+WHERE (key_hash = sqlc.arg(key_hash) OR (previous_key_hash = sqlc.arg(key_hash) AND previous_key_expires_at > NOW()))
+  AND revoked_at IS NULL
+RETURNING id, user_id, name, key_hash, created_at, last_used_at, revoked_at, scopes, last_used_ip, use_count, previous_key_hash, previous_key_expires_at, previous_key_last_used_at, key_rotated_at;
@@ -0,0 +1,68 @@
+-- name: ListUserCampaigns :many
+SELECT id, name, budget_note, starts_at, ends_at, created_at, updated_at FROM campaigns
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetCampaignByIdAndUser :one
+SELECT id, name, budget_note, starts_at, ends_at, created_at, updated_at FROM campaigns
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: CreateCampaign :one
+INSERT INTO campaigns (name, budget_note, starts_at, ends_at, user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, budget_note, starts_at, ends_at, created_at, updated_at;
+
+-- name: UpdateCampaign :one
+UPDATE campaigns
+SET
+	name = COALESCE(sqlc.narg(name), name),
+	budget_note = COALESCE(sqlc.narg(budget_note), budget_note),
+	starts_at = COALESCE(sqlc.narg(starts_at), starts_at),
+	ends_at = COALESCE(sqlc.narg(ends_at), ends_at),
+	updated_at = NOW()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, name, budget_note, starts_at, ends_at, created_at, updated_at;
+
+-- name: DeleteCampaign :one
+DELETE FROM campaigns
+WHERE id = $1 AND user_id = $2
+RETURNING id, name, budget_note, starts_at, ends_at, created_at, updated_at;
+
+-- name: AddLinksToCampaign :exec
+-- Attaches the user's links to the campaign; links of other users are ignored
+INSERT INTO campaign_links (campaign_id, link_id)
+SELECT $1, l.id
+FROM links l
+WHERE l.id = ANY(sqlc.arg(link_i_ds)::uuid[])
+  AND l.user_id = $2
+  AND l.deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM campaigns c
+      WHERE c.id = $1 AND c.user_id = $2
+  )
+ON CONFLICT (campaign_id, link_id) DO NOTHING;
+
+-- name: RemoveLinksFromCampaign :exec
+DELETE FROM campaign_links
+WHERE campaign_id = $1
+  AND link_id = ANY(sqlc.arg(link_i_ds)::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM campaigns c
+      WHERE c.id = $1 AND c.user_id = $2
+  );
+
+-- name: ListCampaignLinks :many
+SELECT
+    l.id,
+    l.shortcode,
+    l.original_url,
+    l.expires_at,
+    l.is_active,
+    l.created_at,
+    l.updated_at
+FROM links l
+JOIN campaign_links cl ON cl.link_id = l.id
+JOIN campaigns c ON c.id = cl.campaign_id
+WHERE c.id = $1 AND c.user_id = $2 AND l.deleted_at IS NULL
+ORDER BY l.created_at DESC;
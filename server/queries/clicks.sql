@@ -0,0 +1,348 @@
+-- name: RecordClick :exec
+-- Records a click for the active link with the given shortcode
+INSERT INTO clicks (link_id, click_id, referrer, user_agent, source, visitor_id)
+SELECT id, sqlc.arg(click_id)::UUID, sqlc.narg(referrer)::TEXT, sqlc.narg(user_agent)::TEXT, sqlc.arg(source)::TEXT, sqlc.narg(visitor_id)::TEXT
+FROM links
+WHERE shortcode = sqlc.arg(shortcode) AND deleted_at IS NULL;
+
+-- name: GetTagClickTotals :one
+-- Days in [rollup_from, rollup_to) are read from link_daily_stats, the rest of the period from raw clicks.
+-- Conversions are always counted from raw events: postbacks keep arriving after a day is rolled up.
+WITH scope_links AS (
+    SELECT lt.link_id
+    FROM link_tags lt
+    JOIN tags t ON t.id = lt.tag_id
+    WHERE t.id = sqlc.arg(tag_id)
+      AND t.user_id = sqlc.arg(user_id)
+), daily AS (
+    SELECT s.link_id, s.day::TIMESTAMPTZ AS day, s.clicks, s.qr_clicks
+    FROM link_daily_stats s
+    JOIN scope_links sl ON sl.link_id = s.link_id
+    WHERE s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT c.link_id, date_trunc('day', c.clicked_at)::TIMESTAMPTZ AS day, COUNT(*) AS clicks, COUNT(*) FILTER (WHERE c.source = 'qr') AS qr_clicks
+    FROM clicks c
+    JOIN scope_links sl ON sl.link_id = c.link_id
+    WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY c.link_id, date_trunc('day', c.clicked_at)
+)
+SELECT
+    (SELECT COALESCE(SUM(d.clicks), 0) FROM daily d)::BIGINT AS total_clicks,
+    (SELECT COALESCE(SUM(d.qr_clicks), 0) FROM daily d)::BIGINT AS qr_clicks,
+    (SELECT COUNT(DISTINCT d.link_id) FROM daily d) AS links_clicked,
+    COUNT(cv.id) AS conversions,
+    COALESCE(SUM(cv.revenue_cents), 0)::BIGINT AS revenue_cents
+FROM conversions cv
+JOIN clicks c ON c.click_id = cv.click_id
+JOIN scope_links sl ON sl.link_id = c.link_id
+WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ;
+
+-- name: GetTagClicksByDay :many
+-- Days start at midnight in time_zone; rollups hold UTC days, so pass an empty rollup range for any other zone
+WITH scope_links AS (
+    SELECT lt.link_id
+    FROM link_tags lt
+    JOIN tags t ON t.id = lt.tag_id
+    WHERE t.id = sqlc.arg(tag_id)
+      AND t.user_id = sqlc.arg(user_id)
+), daily AS (
+    SELECT s.link_id, s.day::TIMESTAMPTZ AS day, s.clicks, s.qr_clicks
+    FROM link_daily_stats s
+    JOIN scope_links sl ON sl.link_id = s.link_id
+    WHERE s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT c.link_id, date_trunc('day', c.clicked_at, sqlc.arg(time_zone)::TEXT) AS day, COUNT(*) AS clicks, COUNT(*) FILTER (WHERE c.source = 'qr') AS qr_clicks
+    FROM clicks c
+    JOIN scope_links sl ON sl.link_id = c.link_id
+    WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY c.link_id, date_trunc('day', c.clicked_at, sqlc.arg(time_zone)::TEXT)
+)
+SELECT
+    day::TIMESTAMPTZ AS day,
+    SUM(clicks)::BIGINT AS clicks,
+    SUM(qr_clicks)::BIGINT AS qr_clicks
+FROM daily
+GROUP BY day
+ORDER BY day;
+
+-- name: GetTagTopLinks :many
+WITH scope_links AS (
+    SELECT lt.link_id
+    FROM link_tags lt
+    JOIN tags t ON t.id = lt.tag_id
+    WHERE t.id = sqlc.arg(tag_id)
+      AND t.user_id = sqlc.arg(user_id)
+), daily AS (
+    SELECT s.link_id, s.day::TIMESTAMPTZ AS day, s.clicks
+    FROM link_daily_stats s
+    JOIN scope_links sl ON sl.link_id = s.link_id
+    WHERE s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT c.link_id, date_trunc('day', c.clicked_at)::TIMESTAMPTZ AS day, COUNT(*) AS clicks
+    FROM clicks c
+    JOIN scope_links sl ON sl.link_id = c.link_id
+    WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY c.link_id, date_trunc('day', c.clicked_at)
+)
+SELECT
+    l.id,
+    l.shortcode,
+    l.original_url,
+    SUM(d.clicks)::BIGINT AS clicks
+FROM daily d
+JOIN links l ON l.id = d.link_id
+GROUP BY l.id
+ORDER BY clicks DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetCampaignClickTotals :one
+-- Days in [rollup_from, rollup_to) are read from link_daily_stats, the rest of the period from raw clicks.
+-- Conversions are always counted from raw events: postbacks keep arriving after a day is rolled up.
+WITH scope_links AS (
+    SELECT cl.link_id
+    FROM campaign_links cl
+    JOIN campaigns ca ON ca.id = cl.campaign_id
+    WHERE ca.id = sqlc.arg(campaign_id)
+      AND ca.user_id = sqlc.arg(user_id)
+), daily AS (
+    SELECT s.link_id, s.day::TIMESTAMPTZ AS day, s.clicks, s.qr_clicks
+    FROM link_daily_stats s
+    JOIN scope_links sl ON sl.link_id = s.link_id
+    WHERE s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT c.link_id, date_trunc('day', c.clicked_at)::TIMESTAMPTZ AS day, COUNT(*) AS clicks, COUNT(*) FILTER (WHERE c.source = 'qr') AS qr_clicks
+    FROM clicks c
+    JOIN scope_links sl ON sl.link_id = c.link_id
+    WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY c.link_id, date_trunc('day', c.clicked_at)
+)
+SELECT
+    (SELECT COALESCE(SUM(d.clicks), 0) FROM daily d)::BIGINT AS total_clicks,
+    (SELECT COALESCE(SUM(d.qr_clicks), 0) FROM daily d)::BIGINT AS qr_clicks,
+    (SELECT COUNT(DISTINCT d.link_id) FROM daily d) AS links_clicked,
+    COUNT(cv.id) AS conversions,
+    COALESCE(SUM(cv.revenue_cents), 0)::BIGINT AS revenue_cents
+FROM conversions cv
+JOIN clicks c ON c.click_id = cv.click_id
+JOIN scope_links sl ON sl.link_id = c.link_id
+WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ;
+
+-- name: GetCampaignClicksByDay :many
+-- Days start at midnight in time_zone; rollups hold UTC days, so pass an empty rollup range for any other zone
+WITH scope_links AS (
+    SELECT cl.link_id
+    FROM campaign_links cl
+    JOIN campaigns ca ON ca.id = cl.campaign_id
+    WHERE ca.id = sqlc.arg(campaign_id)
+      AND ca.user_id = sqlc.arg(user_id)
+), daily AS (
+    SELECT s.link_id, s.day::TIMESTAMPTZ AS day, s.clicks, s.qr_clicks
+    FROM link_daily_stats s
+    JOIN scope_links sl ON sl.link_id = s.link_id
+    WHERE s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT c.link_id, date_trunc('day', c.clicked_at, sqlc.arg(time_zone)::TEXT) AS day, COUNT(*) AS clicks, COUNT(*) FILTER (WHERE c.source = 'qr') AS qr_clicks
+    FROM clicks c
+    JOIN scope_links sl ON sl.link_id = c.link_id
+    WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY c.link_id, date_trunc('day', c.clicked_at, sqlc.arg(time_zone)::TEXT)
+)
+SELECT
+    day::TIMESTAMPTZ AS day,
+    SUM(clicks)::BIGINT AS clicks,
+    SUM(qr_clicks)::BIGINT AS qr_clicks
+FROM daily
+GROUP BY day
+ORDER BY day;
+
+-- name: GetCampaignTopLinks :many
+WITH scope_links AS (
+    SELECT cl.link_id
+    FROM campaign_links cl
+    JOIN campaigns ca ON ca.id = cl.campaign_id
+    WHERE ca.id = sqlc.arg(campaign_id)
+      AND ca.user_id = sqlc.arg(user_id)
+), daily AS (
+    SELECT s.link_id, s.day::TIMESTAMPTZ AS day, s.clicks
+    FROM link_daily_stats s
+    JOIN scope_links sl ON sl.link_id = s.link_id
+    WHERE s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT c.link_id, date_trunc('day', c.clicked_at)::TIMESTAMPTZ AS day, COUNT(*) AS clicks
+    FROM clicks c
+    JOIN scope_links sl ON sl.link_id = c.link_id
+    WHERE c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY c.link_id, date_trunc('day', c.clicked_at)
+)
+SELECT
+    l.id,
+    l.shortcode,
+    l.original_url,
+    SUM(d.clicks)::BIGINT AS clicks
+FROM daily d
+JOIN links l ON l.id = d.link_id
+GROUP BY l.id
+ORDER BY clicks DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetLinkClicksByDay :many
+-- Clicks on one link per UTC day. Days in [rollup_from, rollup_to) are read from link_daily_stats, the rest of the period from raw clicks.
+WITH daily AS (
+    SELECT s.day::TIMESTAMPTZ AS day, s.clicks, s.qr_clicks
+    FROM link_daily_stats s
+    WHERE s.link_id = sqlc.arg(link_id)
+      AND s.day >= sqlc.arg(rollup_from)::DATE
+      AND s.day < sqlc.arg(rollup_to)::DATE
+    UNION ALL
+    SELECT date_trunc('day', c.clicked_at, 'UTC') AS day, COUNT(*) AS clicks, COUNT(*) FILTER (WHERE c.source = 'qr') AS qr_clicks
+    FROM clicks c
+    WHERE c.link_id = sqlc.arg(link_id)
+      AND c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+      AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+      AND NOT (c.clicked_at >= sqlc.arg(rollup_from)::DATE AND c.clicked_at < sqlc.arg(rollup_to)::DATE)
+    GROUP BY date_trunc('day', c.clicked_at, 'UTC')
+)
+SELECT
+    day::TIMESTAMPTZ AS day,
+    SUM(clicks)::BIGINT AS clicks,
+    SUM(qr_clicks)::BIGINT AS qr_clicks
+FROM daily
+GROUP BY day
+ORDER BY day;
+
+-- name: GetLinkClickSummary :one
+-- Clicks on one link in [from_time, to_time) and the distinct visitors behind them.
+-- Clicks recorded without a visitor ID aren't counted as visitors.
+SELECT COUNT(*) AS clicks, COUNT(DISTINCT c.visitor_id) AS unique_visitors
+FROM clicks c
+WHERE c.link_id = sqlc.arg(link_id)
+  AND c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ;
+
+-- name: GetLinkClicksOverTime :many
+-- Clicks on one link per bucket ('hour' or 'day'), buckets starting on the hour or at midnight in time_zone
+SELECT
+    date_trunc(sqlc.arg(bucket)::TEXT, c.clicked_at, sqlc.arg(time_zone)::TEXT)::TIMESTAMPTZ AS bucket_start,
+    COUNT(*) AS clicks,
+    COUNT(DISTINCT c.visitor_id) AS unique_visitors
+FROM clicks c
+WHERE c.link_id = sqlc.arg(link_id)
+  AND c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+GROUP BY bucket_start
+ORDER BY bucket_start;
+
+-- name: GetLinkTopReferrers :many
+-- Hosts (without www.) of the pages linking to one link, most clicks first; an empty one gathers clicks without a referrer
+SELECT
+    COALESCE(regexp_replace(
+        lower(substring(c.referrer FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)')),
+        '^www\.', ''
+    ), '')::TEXT AS referrer,
+    COUNT(*) AS clicks
+FROM clicks c
+WHERE c.link_id = sqlc.arg(link_id)
+  AND c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+GROUP BY 1
+ORDER BY clicks DESC, referrer
+LIMIT sqlc.arg(max_results);
+
+-- name: ExportClicks :many
+-- One page of raw clicks on the user's links (or on one of them), in id order.
+-- Exports page through with after_id instead of OFFSET so large ranges stay cheap.
+SELECT
+    c.id,
+    c.click_id,
+    c.clicked_at,
+    l.id AS link_id,
+    l.shortcode,
+    c.referrer,
+    c.user_agent,
+    c.source
+FROM clicks c
+JOIN links l ON l.id = c.link_id
+WHERE l.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(link_id)::UUID IS NULL OR l.id = sqlc.narg(link_id)::UUID)
+  AND c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+  AND c.id > sqlc.arg(after_id)::BIGINT
+ORDER BY c.id
+LIMIT sqlc.arg('limit');
+
+-- name: ExportClicksByDay :many
+-- Clicks and conversions per link per day (starting at midnight in time_zone) on the user's links (or on one of them)
+SELECT
+    date_trunc('day', c.clicked_at, sqlc.arg(time_zone)::TEXT)::TIMESTAMPTZ AS day,
+    l.id AS link_id,
+    l.shortcode,
+    COUNT(DISTINCT c.id) AS clicks,
+    COUNT(DISTINCT c.id) FILTER (WHERE c.source = 'qr') AS qr_clicks,
+    COUNT(cv.id) AS conversions
+FROM clicks c
+JOIN links l ON l.id = c.link_id
+LEFT JOIN conversions cv ON cv.click_id = c.click_id
+WHERE l.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(link_id)::UUID IS NULL OR l.id = sqlc.narg(link_id)::UUID)
+  AND c.clicked_at >= sqlc.arg(from_time)::TIMESTAMPTZ
+  AND c.clicked_at < sqlc.arg(to_time)::TIMESTAMPTZ
+GROUP BY day, l.id
+ORDER BY day, l.shortcode;
+
+-- name: TryLockStatsRollup :one
+-- Transaction-scoped lock so only one instance runs the rollup at a time
+SELECT pg_try_advisory_xact_lock(hashtext('stats_rollup')) AS locked;
+
+-- name: GetStatsRollupWatermark :one
+SELECT rolled_up_until FROM stats_rollup_state;
+
+-- name: SetStatsRollupWatermark :exec
+INSERT INTO stats_rollup_state (rolled_up_until)
+VALUES (sqlc.arg(rolled_up_until)::DATE)
+ON CONFLICT (id) DO UPDATE
+SET rolled_up_until = EXCLUDED.rolled_up_until, updated_at = NOW();
+
+-- name: GetFirstClickDay :one
+SELECT MIN(clicked_at)::DATE AS day FROM clicks;
+
+-- name: RollupDailyStats :execrows
+-- Recomputes the per-link daily counts of [from_day, to_day) from raw clicks
+INSERT INTO link_daily_stats (link_id, day, clicks, qr_clicks)
+SELECT c.link_id, c.clicked_at::DATE, COUNT(*), COUNT(*) FILTER (WHERE c.source = 'qr')
+FROM clicks c
+WHERE c.clicked_at >= sqlc.arg(from_day)::DATE
+  AND c.clicked_at < sqlc.arg(to_day)::DATE
+GROUP BY c.link_id, c.clicked_at::DATE
+ON CONFLICT (link_id, day) DO UPDATE
+SET clicks = EXCLUDED.clicks, qr_clicks = EXCLUDED.qr_clicks, updated_at = NOW();
+
+-- name: ListClicksForBackfill :many
+-- Raw clicks after the given id, in id order, for copying to another analytics backend.
+-- Clicks of deleted links are included: they're part of the history.
+SELECT c.id, c.click_id, l.shortcode, c.link_id, c.referrer, c.user_agent, c.clicked_at, c.source, c.visitor_id
+FROM clicks c
+JOIN links l ON l.id = c.link_id
+WHERE c.id > sqlc.arg(after_id)
+  AND c.clicked_at < sqlc.arg(until)
+ORDER BY c.id
+LIMIT sqlc.arg(batch_size);
@@ -0,0 +1,9 @@
+-- name: CreateConversion :one
+-- Records a conversion for a click on one of the user's links; no row when the click isn't theirs
+INSERT INTO conversions (click_id, event, revenue_cents, external_id)
+SELECT c.click_id, sqlc.arg(event)::VARCHAR(100), sqlc.narg(revenue_cents)::BIGINT, sqlc.narg(external_id)::VARCHAR(255)
+FROM clicks c
+JOIN links l ON l.id = c.link_id
+WHERE c.click_id = sqlc.arg(click_id)::UUID
+  AND l.user_id = sqlc.arg(user_id)::TEXT
+RETURNING id, click_id, event, revenue_cents, external_id, created_at;
@@ -0,0 +1,48 @@
+-- name: ListDestinationsToMonitor :many
+-- Live links whose destination wasn't checked since checked_before or changed since, never checked
+-- first, with their latest check
+SELECT l.id, l.user_id, l.shortcode, COALESCE(l.raw_url, l.original_url)::TEXT AS destination,
+       c.url AS checked_url, c.state, c.final_url, c.fingerprint
+FROM links l
+LEFT JOIN link_destination_checks c ON c.link_id = l.id
+WHERE l.deleted_at IS NULL AND l.retired_at IS NULL AND l.merged_into IS NULL AND l.is_active
+  AND (c.link_id IS NULL OR c.checked_at < sqlc.arg(checked_before) OR c.url <> COALESCE(l.raw_url, l.original_url))
+ORDER BY c.checked_at NULLS FIRST, l.created_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpsertDestinationCheck :exec
+INSERT INTO link_destination_checks (link_id, url, final_url, status_code, title, fingerprint, state)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (link_id) DO UPDATE
+SET url = EXCLUDED.url,
+    final_url = EXCLUDED.final_url,
+    status_code = EXCLUDED.status_code,
+    title = EXCLUDED.title,
+    fingerprint = EXCLUDED.fingerprint,
+    state = EXCLUDED.state,
+    checked_at = NOW();
+
+-- name: CreateDestinationAlert :one
+INSERT INTO link_destination_alerts (link_id, user_id, kind, url, final_url, status_code, title)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, link_id, user_id, kind, url, final_url, status_code, title, created_at;
+
+-- name: ListUserDestinationAlerts :many
+SELECT a.id, a.link_id, l.shortcode, a.kind, a.url, a.final_url, a.status_code, a.title, a.created_at
+FROM link_destination_alerts a
+JOIN links l ON l.id = a.link_id
+WHERE a.user_id = sqlc.arg(user_id) AND l.deleted_at IS NULL
+ORDER BY a.created_at DESC, a.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountUserDestinationAlerts :one
+SELECT COUNT(*) AS total
+FROM link_destination_alerts a
+JOIN links l ON l.id = a.link_id
+WHERE a.user_id = $1 AND l.deleted_at IS NULL;
+
+-- name: TouchDestinationCheck :exec
+-- Records a failed check, which keeps the last snapshot
+UPDATE link_destination_checks
+SET checked_at = NOW()
+WHERE link_id = $1;
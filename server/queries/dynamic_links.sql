@@ -0,0 +1,106 @@
+-- name: UpsertDynamicLink :one
+INSERT INTO dynamic_links (link_id, locked)
+VALUES ($1, $2)
+ON CONFLICT (link_id) DO UPDATE SET
+    locked = EXCLUDED.locked,
+    updated_at = NOW()
+RETURNING link_id, locked, created_at, updated_at;
+
+
+-- name: GetDynamicLink :one
+SELECT link_id, locked, created_at, updated_at
+FROM dynamic_links
+WHERE link_id = $1;
+
+
+-- name: DeleteDynamicLink :one
+-- The link's scheduled destination changes are canceled with it; its history is kept
+WITH canceled AS (
+    DELETE FROM link_destination_changes
+    WHERE link_id = $1 AND applied_at IS NULL
+)
+DELETE FROM dynamic_links
+WHERE link_id = $1
+RETURNING link_id, locked, created_at, updated_at;
+
+
+-- name: ChangeLinkDestination :one
+-- Swaps the destination of a link right away, recording the change in its history
+WITH previous AS (
+    SELECT id, original_url
+    FROM links
+    WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL
+    FOR UPDATE
+), change AS (
+    INSERT INTO link_destination_changes (link_id, user_id, raw_url, url, previous_url, scheduled_at, applied_at, http_destination)
+    SELECT id, $2, sqlc.arg(raw_url)::TEXT, sqlc.arg(url)::TEXT, original_url, NOW(), NOW(), sqlc.arg(http_destination)::BOOLEAN
+    FROM previous
+)
+UPDATE links l
+SET original_url = sqlc.arg(url)::TEXT,
+    raw_url = sqlc.arg(raw_url)::TEXT,
+    http_destination = sqlc.arg(http_destination)::BOOLEAN,
+    updated_at = NOW()
+FROM previous
+WHERE l.id = previous.id
+RETURNING l.id, l.shortcode, l.original_url, l.is_active, l.expires_at, l.created_at, l.updated_at, l.visibility, l.capture_email, l.redirect_delay, l.interstitial_message, l.raw_url, l.append_click_id, l.title, l.shield, l.referrer_policy, l.retired_at, l.sunset_message, l.sunset_url;
+
+
+-- name: ScheduleLinkDestinationChange :one
+INSERT INTO link_destination_changes (link_id, user_id, raw_url, url, scheduled_at, http_destination)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, link_id, user_id, raw_url, url, previous_url, scheduled_at, applied_at, created_at, http_destination;
+
+
+-- name: ListLinkDestinationChanges :many
+-- Scheduled and applied changes, latest first
+SELECT id, link_id, user_id, raw_url, url, previous_url, scheduled_at, applied_at, created_at, http_destination
+FROM link_destination_changes
+WHERE link_id = $1
+ORDER BY scheduled_at DESC, created_at DESC
+LIMIT $2 OFFSET $3;
+
+
+-- name: CountLinkDestinationChanges :one
+SELECT COUNT(*)
+FROM link_destination_changes
+WHERE link_id = $1;
+
+
+-- name: CancelLinkDestinationChange :one
+-- Only changes that haven't been applied yet can be canceled
+DELETE FROM link_destination_changes
+WHERE id = $1 AND link_id = $2 AND applied_at IS NULL
+RETURNING id, link_id, user_id, raw_url, url, previous_url, scheduled_at, applied_at, created_at, http_destination;
+
+
+-- name: ListDueLinkDestinationChanges :many
+-- Scheduled changes whose time has come, of links that can still change, oldest first
+SELECT c.id
+FROM link_destination_changes c
+JOIN links l ON l.id = c.link_id
+WHERE c.applied_at IS NULL AND c.scheduled_at <= NOW()
+  AND l.deleted_at IS NULL AND l.retired_at IS NULL
+ORDER BY c.scheduled_at, c.created_at
+LIMIT $1;
+
+
+-- name: ApplyLinkDestinationChange :one
+-- Applies a scheduled change unless another instance already did
+WITH change AS (
+    UPDATE link_destination_changes c
+    SET applied_at = NOW(),
+        previous_url = l.original_url
+    FROM links l
+    WHERE c.id = $1 AND c.applied_at IS NULL
+      AND l.id = c.link_id AND l.deleted_at IS NULL AND l.retired_at IS NULL
+    RETURNING c.link_id, c.raw_url, c.url, c.http_destination
+)
+UPDATE links l
+SET original_url = change.url,
+    raw_url = change.raw_url,
+    http_destination = change.http_destination,
+    updated_at = NOW()
+FROM change
+WHERE l.id = change.link_id
+RETURNING l.id, l.shortcode, l.user_id, l.original_url;
@@ -0,0 +1,47 @@
+-- name: ListHTTPSUpgradeCandidates :many
+-- Live http:// links not checked since checked_before, or whose destination changed since,
+-- never checked first. Links whose destination is locked are left alone. Links are found by
+-- their http_destination flag rather than their URL, which may be encrypted (see db.Encrypted).
+SELECT l.id, l.user_id, l.shortcode, l.original_url, l.raw_url
+FROM links l
+LEFT JOIN link_https_upgrades u ON u.link_id = l.id
+WHERE l.http_destination
+  AND l.deleted_at IS NULL AND l.retired_at IS NULL AND l.merged_into IS NULL
+  AND NOT EXISTS (SELECT 1 FROM dynamic_links d WHERE d.link_id = l.id AND d.locked)
+  AND (u.link_id IS NULL OR u.checked_at < sqlc.arg(checked_before) OR u.from_url <> COALESCE(l.raw_url, l.original_url))
+ORDER BY u.checked_at NULLS FIRST, l.created_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpgradeLinkToHTTPS :one
+-- Switches a link to its https:// destination unless it changed since it was checked
+UPDATE links
+SET original_url = sqlc.arg(url)::TEXT,
+    raw_url = sqlc.narg(raw_url),
+    http_destination = FALSE,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND original_url = sqlc.arg(previous_url)::TEXT
+  AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING id, user_id, shortcode;
+
+-- name: UpsertHTTPSUpgradeCheck :exec
+INSERT INTO link_https_upgrades (link_id, user_id, from_url, to_url, status)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (link_id) DO UPDATE
+SET from_url = EXCLUDED.from_url,
+    to_url = EXCLUDED.to_url,
+    status = EXCLUDED.status,
+    checked_at = NOW();
+
+-- name: ListUserHTTPSUpgrades :many
+SELECT u.link_id, l.shortcode, u.from_url, u.to_url, u.status, u.checked_at
+FROM link_https_upgrades u
+JOIN links l ON l.id = u.link_id
+WHERE u.user_id = sqlc.arg(user_id) AND l.deleted_at IS NULL
+ORDER BY u.checked_at DESC, u.link_id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountUserHTTPSUpgrades :one
+SELECT COUNT(*) AS total
+FROM link_https_upgrades u
+JOIN links l ON l.id = u.link_id
+WHERE u.user_id = $1 AND l.deleted_at IS NULL;
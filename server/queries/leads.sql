@@ -0,0 +1,11 @@
+-- name: CreateLinkLead :exec
+INSERT INTO link_leads (link_id, email)
+VALUES ($1, $2)
+ON CONFLICT (link_id, email) DO NOTHING;
+
+
+-- name: ListLinkLeads :many
+SELECT id, email, created_at
+FROM link_leads
+WHERE link_id = $1
+ORDER BY created_at DESC, id DESC;
@@ -0,0 +1,36 @@
+-- name: TryLockAnomalyDetection :one
+-- Transaction-scoped lock so only one instance runs the detector at a time
+SELECT pg_try_advisory_xact_lock(hashtext('anomaly_detection')) AS locked;
+
+
+-- name: GetHourlyLinkClicks :many
+-- Hours without clicks are left out
+SELECT
+    c.link_id,
+    l.user_id,
+    l.shortcode,
+    date_trunc('hour', c.clicked_at)::TIMESTAMPTZ AS hour,
+    COUNT(*) AS clicks
+FROM clicks c
+JOIN links l ON l.id = c.link_id
+WHERE c.clicked_at >= sqlc.arg(from_time)
+    AND c.clicked_at < sqlc.arg(to_time)
+    AND l.deleted_at IS NULL
+GROUP BY c.link_id, l.user_id, l.shortcode, hour
+ORDER BY c.link_id, hour;
+
+
+-- name: CreateLinkAnomaly :one
+-- No row when the anomaly was already recorded
+INSERT INTO link_anomalies (link_id, kind, hour, clicks, baseline, z_score)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (link_id, hour) DO NOTHING
+RETURNING id, link_id, kind, hour, clicks, baseline, z_score, created_at;
+
+
+-- name: ListLinkAnomalies :many
+SELECT id, link_id, kind, hour, clicks, baseline, z_score, created_at
+FROM link_anomalies
+WHERE link_id = $1
+ORDER BY hour DESC
+LIMIT 100;
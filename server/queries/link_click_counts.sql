@@ -0,0 +1,15 @@
+-- name: AddLinkClickCounts :exec
+-- Adds the clicks of each shortcode to its live link's count; shortcodes of deleted links are skipped
+INSERT INTO link_click_counts (link_id, clicks)
+SELECT l.id, d.clicks
+FROM unnest(sqlc.arg(shortcodes)::text[], sqlc.arg(clicks)::bigint[]) AS d(shortcode, clicks)
+JOIN links l ON l.shortcode = d.shortcode AND l.deleted_at IS NULL
+ON CONFLICT (link_id) DO UPDATE SET
+    clicks = link_click_counts.clicks + EXCLUDED.clicks,
+    updated_at = NOW();
+
+
+-- name: ListLinkClickCounts :many
+SELECT link_id, clicks
+FROM link_click_counts
+WHERE link_id = ANY(sqlc.arg(link_i_ds)::uuid[]);
@@ -0,0 +1,25 @@
+-- name: CreateLinkComment :one
+INSERT INTO link_comments (link_id, user_id, body, mentions)
+VALUES ($1, $2, $3, $4)
+RETURNING id, link_id, user_id, body, mentions, created_at;
+
+
+-- name: ListLinkComments :many
+-- Oldest first, so a thread reads top to bottom
+SELECT id, link_id, user_id, body, mentions, created_at
+FROM link_comments
+WHERE link_id = $1
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+
+-- name: CountLinkComments :one
+SELECT COUNT(*)
+FROM link_comments
+WHERE link_id = $1;
+
+
+-- name: DeleteLinkComment :one
+DELETE FROM link_comments
+WHERE id = $1 AND link_id = $2
+RETURNING id, link_id, user_id, body, mentions, created_at;
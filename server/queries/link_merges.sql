@@ -0,0 +1,78 @@
+-- name: ListDuplicateLinks :many
+-- The user's live links that share their destination with another of theirs, for a page of
+-- destinations: most duplicated first, then oldest link first within a destination.
+-- Aliases of merged links aren't duplicates.
+WITH groups AS (
+    SELECT original_url, COUNT(*) AS link_count
+    FROM links
+    WHERE user_id = sqlc.arg(user_id)::TEXT
+      AND deleted_at IS NULL
+      AND merged_into IS NULL
+    GROUP BY original_url
+    HAVING COUNT(*) > 1
+    ORDER BY COUNT(*) DESC, original_url
+    LIMIT sqlc.arg(row_limit)::INT OFFSET sqlc.arg(row_offset)::INT
+)
+SELECT l.id, l.shortcode, l.original_url, l.is_active, l.expires_at, l.retired_at, l.created_at, g.link_count
+FROM groups g
+JOIN links l ON l.original_url = g.original_url
+WHERE l.user_id = sqlc.arg(user_id)::TEXT
+  AND l.deleted_at IS NULL
+  AND l.merged_into IS NULL
+ORDER BY g.link_count DESC, g.original_url, l.created_at, l.id;
+
+
+-- name: CountDuplicateDestinations :one
+SELECT COUNT(*) AS total
+FROM (
+    SELECT original_url
+    FROM links
+    WHERE user_id = $1
+      AND deleted_at IS NULL
+      AND merged_into IS NULL
+    GROUP BY original_url
+    HAVING COUNT(*) > 1
+) AS duplicated;
+
+
+-- name: ListLinksForMerge :many
+-- The user's live links among the IDs, with the link each is already merged into
+SELECT id, shortcode, original_url, merged_into, retired_at
+FROM links
+WHERE user_id = sqlc.arg(user_id)::TEXT
+  AND id = ANY(sqlc.arg(ids)::UUID[])
+  AND deleted_at IS NULL
+FOR UPDATE;
+
+
+-- name: MoveLinkClicks :execrows
+UPDATE clicks
+SET link_id = sqlc.arg(into_id)::UUID
+WHERE link_id = ANY(sqlc.arg(from_ids)::UUID[]);
+
+
+-- name: MoveLinkDailyStats :exec
+-- Adds the rolled up clicks of the links to the other link's, day by day
+WITH moved AS (
+    DELETE FROM link_daily_stats
+    WHERE link_id = ANY(sqlc.arg(from_ids)::UUID[])
+    RETURNING day, clicks, qr_clicks
+)
+INSERT INTO link_daily_stats (link_id, day, clicks, qr_clicks)
+SELECT sqlc.arg(into_id)::UUID, day, SUM(clicks)::BIGINT, SUM(qr_clicks)::BIGINT
+FROM moved
+GROUP BY day
+ON CONFLICT (link_id, day) DO UPDATE
+SET clicks = link_daily_stats.clicks + EXCLUDED.clicks,
+    qr_clicks = link_daily_stats.qr_clicks + EXCLUDED.qr_clicks,
+    updated_at = NOW();
+
+
+-- name: MergeLinks :many
+-- Makes the links, and the links already merged into them, aliases of the other link
+UPDATE links
+SET merged_into = sqlc.arg(into_id)::UUID, updated_at = NOW()
+WHERE user_id = sqlc.arg(user_id)::TEXT
+  AND deleted_at IS NULL
+  AND (id = ANY(sqlc.arg(ids)::UUID[]) OR merged_into = ANY(sqlc.arg(ids)::UUID[]))
+RETURNING id, shortcode;
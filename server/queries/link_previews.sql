@@ -0,0 +1,29 @@
+-- name: UpsertLinkPreview :one
+INSERT INTO link_previews (link_id, title, description, image_url)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (link_id) DO UPDATE SET
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    image_url = EXCLUDED.image_url,
+    updated_at = NOW()
+RETURNING link_id, title, description, image_url, updated_at;
+
+
+-- name: GetLinkPreview :one
+SELECT link_id, title, description, image_url, updated_at
+FROM link_previews
+WHERE link_id = $1;
+
+
+-- name: GetLinkPreviewByShortcode :one
+-- By shortcode rather than link ID: cached redirects don't carry the ID
+SELECT p.link_id, p.title, p.description, p.image_url, p.updated_at
+FROM link_previews p
+JOIN links l ON l.id = p.link_id
+WHERE l.shortcode = $1 AND l.deleted_at IS NULL;
+
+
+-- name: DeleteLinkPreview :one
+DELETE FROM link_previews
+WHERE link_id = $1
+RETURNING link_id, title, description, image_url, updated_at;
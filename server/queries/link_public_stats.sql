@@ -0,0 +1,32 @@
+-- name: EnableLinkPublicStats :one
+-- Enabling the page again keeps the time it was first enabled
+INSERT INTO link_public_stats (link_id)
+VALUES ($1)
+ON CONFLICT (link_id) DO UPDATE SET
+    link_id = EXCLUDED.link_id
+RETURNING link_id, enabled_at;
+
+
+-- name: GetLinkPublicStats :one
+SELECT link_id, enabled_at
+FROM link_public_stats
+WHERE link_id = $1;
+
+
+-- name: DisableLinkPublicStats :one
+DELETE FROM link_public_stats
+WHERE link_id = $1
+RETURNING link_id, enabled_at;
+
+
+-- name: GetLinkForPublicStats :one
+-- The stats page of a merged link's shortcode is the one of the link it was merged into.
+-- Pages of deleted links are gone; those of expired, paused or retired links are still served.
+SELECT l.id, l.shortcode, l.created_at, (p.link_id IS NOT NULL)::BOOLEAN AS public_stats
+FROM links s
+JOIN links l ON l.id = COALESCE(s.merged_into, s.id)
+LEFT JOIN link_public_stats p ON p.link_id = l.id
+WHERE s.shortcode = $1
+AND s.deleted_at IS NULL
+AND l.deleted_at IS NULL
+LIMIT 1;
@@ -0,0 +1,19 @@
+-- name: UpsertLinkResponseHeaders :one
+INSERT INTO link_response_headers (link_id, headers)
+VALUES ($1, $2)
+ON CONFLICT (link_id) DO UPDATE SET
+    headers = EXCLUDED.headers,
+    updated_at = NOW()
+RETURNING link_id, headers, updated_at;
+
+
+-- name: GetLinkResponseHeaders :one
+SELECT link_id, headers, updated_at
+FROM link_response_headers
+WHERE link_id = $1;
+
+
+-- name: DeleteLinkResponseHeaders :one
+DELETE FROM link_response_headers
+WHERE link_id = $1
+RETURNING link_id, headers, updated_at;
@@ -0,0 +1,56 @@
+-- name: UpsertLinkTrafficCap :one
+-- Changing the caps keeps the counters
+INSERT INTO link_traffic_caps (link_id, daily_cap, total_cap, overflow_url, day)
+VALUES ($1, $2, $3, $4, sqlc.arg(day)::DATE)
+ON CONFLICT (link_id) DO UPDATE SET
+    daily_cap = EXCLUDED.daily_cap,
+    total_cap = EXCLUDED.total_cap,
+    overflow_url = EXCLUDED.overflow_url,
+    updated_at = NOW()
+RETURNING link_id, daily_cap, total_cap, overflow_url, total_clicks, day, day_clicks, updated_at;
+
+
+-- name: GetLinkTrafficCap :one
+SELECT link_id, daily_cap, total_cap, overflow_url, total_clicks, day, day_clicks, updated_at
+FROM link_traffic_caps
+WHERE link_id = $1;
+
+
+-- name: DeleteLinkTrafficCap :one
+DELETE FROM link_traffic_caps
+WHERE link_id = $1
+RETURNING link_id, daily_cap, total_cap, overflow_url, total_clicks, day, day_clicks, updated_at;
+
+
+-- name: ListTrafficCappedLinkIDs :many
+SELECT c.link_id
+FROM link_traffic_caps c
+JOIN links l ON l.id = c.link_id
+WHERE l.deleted_at IS NULL
+ORDER BY c.link_id;
+
+
+-- name: TakeLinkTrafficCap :execrows
+-- Counts a click in the database when Redis is unavailable; no row is updated once a cap is reached
+UPDATE link_traffic_caps
+SET total_clicks = total_clicks + 1,
+    day_clicks = CASE WHEN day = sqlc.arg(day)::DATE THEN day_clicks + 1 ELSE 1 END,
+    day = sqlc.arg(day)::DATE,
+    updated_at = NOW()
+WHERE link_id = $1
+  AND (total_cap IS NULL OR total_clicks < total_cap)
+  AND (daily_cap IS NULL OR day <> sqlc.arg(day)::DATE OR day_clicks < daily_cap);
+
+
+-- name: SyncLinkTrafficCapCounters :exec
+-- Counters only move forward, so instances syncing the same values concurrently are harmless
+UPDATE link_traffic_caps
+SET total_clicks = GREATEST(total_clicks, sqlc.arg(total_clicks)::BIGINT),
+    day_clicks = CASE
+        WHEN day = sqlc.arg(day)::DATE THEN GREATEST(day_clicks, sqlc.arg(day_clicks)::BIGINT)
+        WHEN day < sqlc.arg(day)::DATE THEN sqlc.arg(day_clicks)::BIGINT
+        ELSE day_clicks
+    END,
+    day = GREATEST(day, sqlc.arg(day)::DATE),
+    updated_at = NOW()
+WHERE link_id = $1;
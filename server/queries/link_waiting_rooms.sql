@@ -0,0 +1,42 @@
+-- name: UpsertLinkWaitingRoom :one
+-- The webhook token of an existing waiting room is kept
+INSERT INTO link_waiting_rooms (link_id, active, message, retry_after, webhook_token_hash, activated_at)
+VALUES ($1, $2, $3, $4, $5, CASE WHEN $2 THEN NOW() END)
+ON CONFLICT (link_id) DO UPDATE SET
+    active = EXCLUDED.active,
+    message = EXCLUDED.message,
+    retry_after = EXCLUDED.retry_after,
+    activated_at = CASE
+        WHEN NOT EXCLUDED.active THEN NULL
+        ELSE COALESCE(link_waiting_rooms.activated_at, NOW())
+    END,
+    updated_at = NOW()
+RETURNING link_id, active, message, retry_after, activated_at, updated_at;
+
+
+-- name: GetLinkWaitingRoom :one
+SELECT link_id, active, message, retry_after, activated_at, updated_at
+FROM link_waiting_rooms
+WHERE link_id = $1;
+
+
+-- name: DeleteLinkWaitingRoom :one
+DELETE FROM link_waiting_rooms
+WHERE link_id = $1
+RETURNING link_id, active, message, retry_after, activated_at, updated_at;
+
+
+-- name: SetLinkWaitingRoomActiveByToken :one
+-- Opens or closes the waiting room a webhook token belongs to
+UPDATE link_waiting_rooms w
+SET active = sqlc.arg(active)::BOOLEAN,
+    activated_at = CASE
+        WHEN NOT sqlc.arg(active)::BOOLEAN THEN NULL
+        ELSE COALESCE(w.activated_at, NOW())
+    END,
+    updated_at = NOW()
+FROM links l
+WHERE w.webhook_token_hash = sqlc.arg(webhook_token_hash)::VARCHAR(64)
+  AND l.id = w.link_id
+  AND l.deleted_at IS NULL
+RETURNING w.link_id, l.shortcode, l.user_id, w.active, w.message, w.retry_after, w.activated_at, w.updated_at;
@@ -1,26 +1,45 @@
 -- name: TryCreateLink :one
--- sqlc.arg(shortcode) sqlc.arg(original_url) sqlc.arg(user_id) sqlc.narg(expires_at)
-INSERT INTO links (shortcode, original_url, user_id, expires_at)
-SELECT @shortcode::VARCHAR(20), @original_url::TEXT, @user_id::TEXT, @expires_at
-WHERE NOT EXISTS (
-    SELECT 1 FROM links 
-    WHERE shortcode = @shortcode::VARCHAR(20) AND deleted_at IS NULL
+-- sqlc.arg(shortcode) sqlc.arg(original_url) sqlc.arg(user_id) sqlc.narg(expires_at) sqlc.arg(visibility) sqlc.arg(capture_email) sqlc.arg(redirect_delay) sqlc.narg(interstitial_message) sqlc.narg(raw_url) sqlc.arg(append_click_id) sqlc.narg(title) sqlc.arg(shield) sqlc.arg(referrer_policy) sqlc.arg(http_destination)
+-- A shortcode reserved by another user is taken; the user's own reservation is consumed by the link.
+WITH claimed AS (
+    DELETE FROM shortcode_reservations
+    WHERE shortcode = @shortcode::VARCHAR(20) AND user_id = @user_id::TEXT
 )
-RETURNING id, shortcode, original_url, expires_at, is_active, created_at, updated_at;
+INSERT INTO links (shortcode, original_url, user_id, expires_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, http_destination)
+SELECT @shortcode::VARCHAR(20), @original_url::TEXT, @user_id::TEXT, @expires_at, @visibility::TEXT, @capture_email::BOOLEAN, @redirect_delay::INTEGER, @interstitial_message, @raw_url, @append_click_id::BOOLEAN, @title, @shield::BOOLEAN, @referrer_policy::VARCHAR(20), @http_destination::BOOLEAN
+WHERE NOT link_shortcode_live(@shortcode::VARCHAR(20))
+AND NOT EXISTS (
+    SELECT 1 FROM shortcode_reservations
+    WHERE shortcode = @shortcode::VARCHAR(20) AND user_id <> @user_id::TEXT
+)
+RETURNING id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url;
 
 
 -- name: GetLinkForRedirect :one
-SELECT id, original_url
-FROM links
-WHERE shortcode = $1
-AND deleted_at IS NULL
-AND is_active = true
-AND (expires_at IS NULL OR expires_at > NOW())
+-- Redirects go to the URL as submitted, tracking parameters included.
+-- Retired links are returned whatever their state, for the sunset page.
+-- Traffic caps come along so capped redirects don't need another query.
+-- So does the waiting room; waiting_room_retry_after is only set for links that have one.
+-- And the extra response headers, null for links without any.
+-- The shortcode of a merged link redirects as the link it was merged into, which is returned.
+SELECT l.id, COALESCE(l.raw_url, l.original_url) AS original_url, l.user_id, l.visibility, l.capture_email, l.redirect_delay, l.interstitial_message, l.append_click_id, l.shield, l.referrer_policy, l.retired_at, l.sunset_message, l.sunset_url, c.daily_cap, c.total_cap, c.overflow_url, COALESCE(w.active, false)::BOOLEAN AS waiting_room, w.message AS waiting_room_message, w.retry_after AS waiting_room_retry_after, h.headers AS response_headers, (s.merged_into IS NOT NULL)::BOOLEAN AS merged
+FROM links s
+JOIN links l ON l.id = COALESCE(s.merged_into, s.id)
+LEFT JOIN link_traffic_caps c ON c.link_id = l.id
+LEFT JOIN link_waiting_rooms w ON w.link_id = l.id
+LEFT JOIN link_response_headers h ON h.link_id = l.id
+WHERE s.shortcode = $1
+AND s.deleted_at IS NULL
+AND l.deleted_at IS NULL
+AND (
+    l.retired_at IS NOT NULL
+    OR (l.is_active = true AND (l.expires_at IS NULL OR l.expires_at > NOW()))
+)
 LIMIT 1;
 
 
 -- name: GetLinkByIdAndUser :one
-SELECT id, shortcode, original_url, expires_at, is_active, created_at, updated_at
+SELECT id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
 FROM links
 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 LIMIT 1;
@@ -35,6 +54,18 @@ SELECT
     l.is_active,
     l.created_at,
     l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
     COALESCE(
         json_agg(
             json_build_object(
@@ -63,6 +94,18 @@ SELECT
     l.is_active,
     l.created_at,
     l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
     COALESCE(
         json_agg(
             json_build_object(
@@ -91,6 +134,18 @@ SELECT
     l.is_active,
     l.created_at,
     l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
     COALESCE(
         json_agg(
             json_build_object(
@@ -106,6 +161,7 @@ LEFT JOIN link_tags lt ON l.id = lt.link_id
 LEFT JOIN tags t ON lt.tag_id = t.id
 WHERE l.user_id = $1 
   AND l.deleted_at IS NULL
+  AND l.merged_into IS NULL
   AND (
     -- If is_active filter is NULL, show all links
     sqlc.narg('is_active')::boolean IS NULL
@@ -132,12 +188,54 @@ HAVING (
 ORDER BY l.created_at DESC
 LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
+-- name: ListUserLinksByIDs :many
+-- Batch lookup of the user's links; IDs that don't exist or aren't theirs are skipped
+SELECT 
+    l.id,
+    l.shortcode,
+    l.original_url,
+    l.expires_at,
+    l.is_active,
+    l.created_at,
+    l.updated_at,
+    l.visibility,
+    l.capture_email,
+    l.redirect_delay,
+    l.interstitial_message,
+    l.raw_url,
+    l.append_click_id,
+    l.title,
+    l.shield,
+    l.referrer_policy,
+    l.retired_at,
+    l.sunset_message,
+    l.sunset_url,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', t.id,
+                'name', t.name,
+                'created_at', t.created_at
+            )
+        ) FILTER (WHERE t.id IS NOT NULL),
+        '[]'::json
+    ) as tags
+FROM links l
+LEFT JOIN link_tags lt ON l.id = lt.link_id
+LEFT JOIN tags t ON lt.tag_id = t.id
+WHERE l.user_id = sqlc.arg(user_id)
+  AND l.id = ANY(sqlc.arg(ids)::uuid[])
+  AND l.deleted_at IS NULL
+GROUP BY l.id
+ORDER BY l.created_at DESC;
+
 
 -- name: CountUserLinks :one
 SELECT COUNT(DISTINCT l.id) as total
 FROM links l
 WHERE l.user_id = $1 
   AND l.deleted_at IS NULL
+  AND l.merged_into IS NULL
   AND (
     -- If is_active filter is NULL, show all links
     sqlc.narg('is_active')::boolean IS NULL
@@ -167,18 +265,122 @@ WHERE l.user_id = $1
 
 
 -- name: UpdateLink :one
+-- The user's reservation of the new shortcode, if any, is consumed by the link
+WITH claimed AS (
+    DELETE FROM shortcode_reservations
+    WHERE shortcode = sqlc.narg('shortcode') AND user_id = $2
+    AND EXISTS (SELECT 1 FROM links WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL)
+)
 UPDATE links
 SET 
     shortcode = COALESCE(sqlc.narg('shortcode'), shortcode),
     is_active = COALESCE(sqlc.narg('is_active'), is_active),
     expires_at = COALESCE(sqlc.narg('expires_at'), expires_at),
+    visibility = COALESCE(sqlc.narg('visibility'), visibility),
+    capture_email = COALESCE(sqlc.narg('capture_email'), capture_email),
+    redirect_delay = COALESCE(sqlc.narg('redirect_delay'), redirect_delay),
+    interstitial_message = COALESCE(sqlc.narg('interstitial_message'), interstitial_message),
+    append_click_id = COALESCE(sqlc.narg('append_click_id'), append_click_id),
+    shield = COALESCE(sqlc.narg('shield'), shield),
+    referrer_policy = COALESCE(sqlc.narg('referrer_policy'), referrer_policy),
     updated_at = NOW()
-WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
-RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at;
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url;
 
 
 -- name: DeleteLink :one
 UPDATE links
 SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND retired_at IS NULL
+RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url;
+
+
+-- name: RetireLink :one
+-- Retiring again only changes the sunset page
+UPDATE links
+SET retired_at = COALESCE(retired_at, NOW()),
+    sunset_message = sqlc.narg('sunset_message'),
+    sunset_url = sqlc.narg('sunset_url'),
+    updated_at = NOW()
 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
-RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at;
+RETURNING id, shortcode, original_url, is_active, expires_at, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url;
+
+
+-- name: ListLinkChanges :many
+-- Links created, updated or deleted after the (changed_at, id) position, oldest change first.
+-- Changes of the last few seconds are held back so rows from transactions still committing,
+-- stamped with an earlier NOW(), can't land behind a position a client already polled past.
+SELECT
+    id,
+    shortcode,
+    original_url,
+    expires_at,
+    is_active,
+    created_at,
+    updated_at,
+    deleted_at,
+    visibility,
+    capture_email,
+    redirect_delay,
+    interstitial_message,
+    raw_url,
+    append_click_id,
+    title,
+    shield,
+    referrer_policy,
+    retired_at,
+    sunset_message,
+    sunset_url,
+    (CASE
+        WHEN deleted_at IS NOT NULL THEN 'deleted'
+        WHEN created_at > sqlc.arg(since)::TIMESTAMPTZ THEN 'created'
+        ELSE 'updated'
+    END)::TEXT AS change,
+    COALESCE(deleted_at, updated_at, created_at)::TIMESTAMPTZ AS changed_at
+FROM links
+WHERE user_id = sqlc.arg(user_id)::TEXT
+  AND (COALESCE(deleted_at, updated_at, created_at), id) > (sqlc.arg(after_time)::TIMESTAMPTZ, sqlc.arg(after_id)::UUID)
+  AND COALESCE(deleted_at, updated_at, created_at) <= NOW() - INTERVAL '5 seconds'
+ORDER BY COALESCE(deleted_at, updated_at, created_at), id
+LIMIT sqlc.arg(row_limit)::INT;
+
+
+-- name: GetUserLinkByURL :one
+-- The user's newest live link to the URL that redirects with default settings
+SELECT id, shortcode, original_url, expires_at, is_active, created_at, updated_at, visibility, capture_email, redirect_delay, interstitial_message, raw_url, append_click_id, title, shield, referrer_policy, retired_at, sunset_message, sunset_url
+FROM links
+WHERE user_id = $1
+  AND original_url = $2
+  AND deleted_at IS NULL
+  AND is_active = true
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND visibility = 'public'
+  AND capture_email = false
+  AND redirect_delay = 0
+  AND append_click_id = false
+  AND shield = false
+  AND referrer_policy = 'default'
+  AND retired_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM link_traffic_caps c WHERE c.link_id = links.id)
+  AND NOT EXISTS (SELECT 1 FROM link_response_headers h WHERE h.link_id = links.id)
+  AND merged_into IS NULL
+ORDER BY created_at DESC
+LIMIT 1;
+
+
+-- name: ListShortcodeSuggestions :many
+-- Live links whose shortcode is one of the candidates, the near misses of a shortcode
+-- that wasn't found: the viewer's own, and other users' public ones with include_public.
+SELECT shortcode, COALESCE(raw_url, original_url) AS original_url, capture_email
+FROM links
+WHERE shortcode = ANY(sqlc.arg(candidates)::TEXT[])
+  AND deleted_at IS NULL
+  AND retired_at IS NULL
+  AND is_active = true
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (
+    user_id = sqlc.arg(viewer_id)::TEXT
+    OR (sqlc.arg(include_public)::BOOLEAN AND visibility = 'public')
+  )
+ORDER BY shortcode
+LIMIT sqlc.arg(row_limit)::INT;
@@ -0,0 +1,22 @@
+-- name: CreatePublishHook :one
+INSERT INTO publish_hooks (user_id, name, token_hash, tag_id, callback_url)
+VALUES (sqlc.arg(user_id)::TEXT, sqlc.arg(name)::VARCHAR(100), sqlc.arg(token_hash)::VARCHAR(64), sqlc.narg(tag_id), sqlc.narg(callback_url))
+RETURNING id, name, tag_id, callback_url, created_at, last_used_at;
+
+-- name: ListUserPublishHooks :many
+SELECT id, name, tag_id, callback_url, created_at, last_used_at
+FROM publish_hooks
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeletePublishHook :one
+DELETE FROM publish_hooks
+WHERE id = $1 AND user_id = $2
+RETURNING id, name, tag_id, callback_url, created_at, last_used_at;
+
+-- name: UsePublishHook :one
+-- Resolves a hook by its token hash, recording the call
+UPDATE publish_hooks
+SET last_used_at = NOW()
+WHERE token_hash = $1
+RETURNING id, user_id, tag_id, callback_url;
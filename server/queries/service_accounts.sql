@@ -0,0 +1,59 @@
+-- name: CreateServiceAccount :one
+INSERT INTO service_accounts (owner_id, name, scopes, token_hash, created_by)
+VALUES (sqlc.arg(owner_id)::TEXT, sqlc.arg(name)::VARCHAR(100), sqlc.arg(scopes)::TEXT[], sqlc.arg(token_hash)::VARCHAR(64), sqlc.arg(created_by)::TEXT)
+RETURNING id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at;
+
+-- name: ListServiceAccounts :many
+-- All service accounts, or the ones of a single owner
+SELECT id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at
+FROM service_accounts
+WHERE sqlc.narg(owner_id)::TEXT IS NULL OR owner_id = sqlc.narg(owner_id)::TEXT
+ORDER BY created_at DESC;
+
+-- name: GetServiceAccount :one
+SELECT id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at
+FROM service_accounts
+WHERE id = $1;
+
+-- name: UpdateServiceAccount :one
+UPDATE service_accounts
+SET
+	name = COALESCE(sqlc.narg(name)::VARCHAR(100), name),
+	scopes = COALESCE(sqlc.narg(scopes)::TEXT[], scopes)
+WHERE id = sqlc.arg(id)
+RETURNING id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at;
+
+-- name: RotateServiceAccountToken :one
+-- Replaces the token, keeping the current one valid until previous_token_expires_at
+UPDATE service_accounts
+SET previous_token_hash = token_hash,
+    previous_token_expires_at = sqlc.arg(previous_token_expires_at),
+    previous_token_last_used_at = NULL,
+    token_hash = sqlc.arg(token_hash),
+    token_rotated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at;
+
+-- name: RevokePreviousServiceAccountToken :one
+-- Ends the previous token's grace period early
+UPDATE service_accounts
+SET previous_token_hash = NULL,
+    previous_token_expires_at = NULL
+WHERE id = $1
+RETURNING id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at;
+
+-- name: DeleteServiceAccount :one
+DELETE FROM service_accounts
+WHERE id = $1
+RETURNING id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at;
+
+-- name: UseServiceAccount :one
+-- Resolves a service account by its token hash, or by its previous one while it's valid, recording the call
+UPDATE service_accounts
+SET last_used_at = NOW(),
+    last_used_ip = sqlc.narg(ip)::TEXT,
+    use_count = use_count + 1,
+    previous_token_last_used_at = CASE WHEN token_hash = sqlc.arg(token_hash) THEN previous_token_last_used_at ELSE NOW() END
+WHERE token_hash = sqlc.arg(token_hash)
+   OR (previous_token_hash = sqlc.arg(token_hash) AND previous_token_expires_at > NOW())
+RETURNING id, owner_id, name, scopes, token_hash, created_by, created_at, last_used_at, last_used_ip, use_count, previous_token_hash, previous_token_expires_at, previous_token_last_used_at, token_rotated_at;
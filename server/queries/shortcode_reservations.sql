@@ -0,0 +1,26 @@
+-- name: ReserveShortcode :one
+-- No row when a live link or another reservation already holds the shortcode
+INSERT INTO shortcode_reservations (shortcode, user_id)
+SELECT @shortcode::VARCHAR(20), @user_id::TEXT
+WHERE NOT link_shortcode_live(@shortcode::VARCHAR(20))
+ON CONFLICT (shortcode) DO NOTHING
+RETURNING shortcode, user_id, created_at;
+
+
+-- name: GetShortcodeReservation :one
+SELECT shortcode, user_id, created_at
+FROM shortcode_reservations
+WHERE shortcode = $1;
+
+
+-- name: ListUserShortcodeReservations :many
+SELECT shortcode, user_id, created_at
+FROM shortcode_reservations
+WHERE user_id = $1
+ORDER BY created_at DESC, shortcode;
+
+
+-- name: DeleteShortcodeReservation :one
+DELETE FROM shortcode_reservations
+WHERE shortcode = $1 AND user_id = $2
+RETURNING shortcode, user_id, created_at;
@@ -0,0 +1,14 @@
+-- name: GetSlackAccountUser :one
+-- Returns the account a Slack user is linked to
+SELECT user_id FROM slack_accounts
+WHERE team_id = sqlc.arg(team_id)::VARCHAR(32)
+  AND slack_user_id = sqlc.arg(slack_user_id)::VARCHAR(32);
+
+-- name: LinkSlackAccount :one
+-- Links a Slack user to an account, replacing any previous link
+INSERT INTO slack_accounts (team_id, slack_user_id, user_id)
+VALUES (sqlc.arg(team_id)::VARCHAR(32), sqlc.arg(slack_user_id)::VARCHAR(32), sqlc.arg(user_id)::TEXT)
+ON CONFLICT (team_id, slack_user_id) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    created_at = NOW()
+RETURNING team_id, slack_user_id, user_id, created_at;
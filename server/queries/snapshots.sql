@@ -0,0 +1,41 @@
+-- name: ListSnapshotLinks :many
+-- Links of a snapshot of the redirect mappings, by shortcode after after_shortcode.
+-- A full snapshot (since null) reads the live links; a diff reads the links changed after since,
+-- deleted and expired ones included, the live link of a shortcode ahead of deleted ones.
+-- Changes to a link's traffic cap, waiting room or response headers count as changes of the link.
+-- redirectable matches service.isCacheable: only those links are served from a redirect cache.
+SELECT DISTINCT ON (l.shortcode)
+    l.shortcode,
+    l.id,
+    l.user_id,
+    COALESCE(l.raw_url, l.original_url) AS original_url,
+    (l.deleted_at IS NULL
+        AND l.merged_into IS NULL
+        AND l.is_active
+        AND (l.expires_at IS NULL OR l.expires_at > NOW())
+        AND l.retired_at IS NULL
+        AND l.visibility = 'public'
+        AND NOT l.capture_email
+        AND l.redirect_delay = 0
+        AND NOT l.append_click_id
+        AND NOT l.shield
+        AND l.referrer_policy = 'default'
+        AND c.daily_cap IS NULL
+        AND c.total_cap IS NULL
+        AND w.retry_after IS NULL
+        AND h.headers IS NULL
+    )::BOOLEAN AS redirectable
+FROM links l
+LEFT JOIN link_traffic_caps c ON c.link_id = l.id
+LEFT JOIN link_waiting_rooms w ON w.link_id = l.id
+LEFT JOIN link_response_headers h ON h.link_id = l.id
+WHERE l.shortcode > sqlc.arg(after_shortcode)::TEXT
+  AND (
+    (sqlc.narg(since)::TIMESTAMPTZ IS NULL AND l.deleted_at IS NULL)
+    OR GREATEST(
+        l.created_at, l.updated_at, l.deleted_at, c.updated_at, w.updated_at, h.updated_at,
+        CASE WHEN l.expires_at <= NOW() THEN l.expires_at END
+    ) > sqlc.narg(since)::TIMESTAMPTZ
+  )
+ORDER BY l.shortcode, (l.deleted_at IS NULL) DESC, l.deleted_at DESC
+LIMIT sqlc.arg(row_limit)::INT;
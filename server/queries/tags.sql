@@ -1,27 +1,157 @@
 -- name: ListUserTags :many
 SELECT id, name, created_at, updated_at FROM tags
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY name;
 
+-- name: ListUserTagsPage :many
+-- A page of the user's tags whose name starts with prefix (without case), each with the
+-- number of live links carrying it. sort is name, created_at (newest first) or usage (most used first).
+SELECT t.id, t.name, t.created_at, t.updated_at, COUNT(l.id) AS link_count
+FROM tags t
+LEFT JOIN link_tags lt ON lt.tag_id = t.id
+LEFT JOIN links l ON l.id = lt.link_id AND l.deleted_at IS NULL
+WHERE t.user_id = sqlc.arg(user_id)::TEXT
+  AND t.deleted_at IS NULL
+  AND starts_with(lower(t.name), lower(sqlc.arg(prefix)::TEXT))
+GROUP BY t.id
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::TEXT = 'usage' THEN COUNT(l.id) END DESC,
+    CASE WHEN sqlc.arg(sort)::TEXT = 'created_at' THEN t.created_at END DESC,
+    t.name, t.id
+LIMIT sqlc.arg(row_limit)::INT OFFSET sqlc.arg(row_offset)::INT;
+
+-- name: CountUserTagsByPrefix :one
+-- Number of the user's tags whose name starts with prefix, without case
+SELECT COUNT(*) AS total FROM tags
+WHERE user_id = sqlc.arg(user_id)::TEXT
+  AND deleted_at IS NULL
+  AND starts_with(lower(name), lower(sqlc.arg(prefix)::TEXT));
+
 -- name: CreateTag :one
 INSERT INTO tags (name, user_id)
 VALUES ($1, $2)
 RETURNING id, name, created_at, updated_at;
 
+-- name: UpsertTag :one
+-- Creates the tag or returns the user's existing tag with that name.
+-- created is false when the row already existed (xmax is set by the no-op update).
+INSERT INTO tags (name, user_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) WHERE deleted_at IS NULL DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name, created_at, updated_at, (xmax = 0)::boolean AS created;
+
 -- name: UpdateTag :one
 UPDATE tags
 SET 
 	name = $1, 
 	updated_at = NOW()
-WHERE id = $2 AND user_id = $3
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
 RETURNING id, name, created_at, updated_at;
 
 -- name: DeleteTag :one
-DELETE FROM tags
-WHERE id = $1 AND user_id = $2
+-- Moves the tag to the trash, detaching it from its links and remembering them for RestoreTag
+WITH detached AS (
+    DELETE FROM link_tags lt
+    USING tags t
+    WHERE lt.tag_id = t.id
+      AND t.id = sqlc.arg(id) AND t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+    RETURNING lt.link_id
+)
+UPDATE tags
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    detached_link_ids = ARRAY(SELECT link_id FROM detached)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
 RETURNING id, name, created_at, updated_at;
 
 -- name: DeleteTags :many
-DELETE FROM tags
-WHERE id = ANY(sqlc.arg(tag_i_ds)::uuid[]) AND user_id = sqlc.arg(user_id)
-RETURNING id, name, created_at, updated_at;
\ No newline at end of file
+-- DeleteTag for several tags
+WITH detached AS (
+    DELETE FROM link_tags lt
+    USING tags t
+    WHERE lt.tag_id = t.id
+      AND t.id = ANY(sqlc.arg(tag_i_ds)::uuid[]) AND t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+    RETURNING lt.tag_id, lt.link_id
+)
+UPDATE tags
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    detached_link_ids = ARRAY(SELECT d.link_id FROM detached d WHERE d.tag_id = tags.id)
+WHERE id = ANY(sqlc.arg(tag_i_ds)::uuid[]) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+RETURNING id, name, created_at, updated_at;
+
+-- name: ListDeletedTags :many
+-- The user's tags in the trash, most recently deleted first, with the number of links they were detached from
+SELECT id, name, created_at, updated_at, deleted_at,
+    COALESCE(cardinality(detached_link_ids), 0)::BIGINT AS link_count
+FROM tags
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, name;
+
+-- name: RestoreTag :one
+-- Takes the tag out of the trash and puts it back on the links it was detached from that still exist
+WITH trashed AS (
+    SELECT id, user_id, detached_link_ids FROM tags
+    WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NOT NULL
+    FOR UPDATE
+),
+restored AS (
+    UPDATE tags
+    SET deleted_at = NULL,
+        updated_at = NOW(),
+        detached_link_ids = NULL
+    FROM trashed
+    WHERE tags.id = trashed.id
+    RETURNING tags.id, tags.name, tags.created_at, tags.updated_at
+),
+reattached AS (
+    INSERT INTO link_tags (link_id, tag_id)
+    SELECT l.id, t.id
+    FROM trashed t
+    JOIN links l ON l.id = ANY(t.detached_link_ids) AND l.user_id = t.user_id
+    ON CONFLICT (link_id, tag_id) DO NOTHING
+)
+SELECT id, name, created_at, updated_at FROM restored;
+
+-- name: GetTagByIdAndUser :one
+SELECT id, name, created_at, updated_at FROM tags
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: SuggestTagsForHost :many
+-- Tags the user put on their links to the same host (ignoring a leading www.), most used first
+SELECT t.id, t.name, COUNT(*) AS link_count
+FROM links l
+JOIN link_tags lt ON lt.link_id = l.id
+JOIN tags t ON t.id = lt.tag_id
+WHERE l.user_id = sqlc.arg(user_id)
+  AND l.deleted_at IS NULL
+  AND regexp_replace(
+        lower(substring(l.original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)')),
+        '^www\.', ''
+      ) = sqlc.arg(host)::TEXT
+GROUP BY t.id, t.name
+ORDER BY link_count DESC, t.name
+LIMIT sqlc.arg(max_results);
+
+-- name: CountUserTagsByIDs :one
+-- Number of the given tags that belong to the user
+SELECT COUNT(*) FROM tags
+WHERE user_id = sqlc.arg(user_id)
+  AND deleted_at IS NULL
+  AND id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: ListUserTagNamesByIDs :many
+-- Names of the given tags that belong to the user
+SELECT name FROM tags
+WHERE user_id = sqlc.arg(user_id)
+  AND deleted_at IS NULL
+  AND id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: UpsertTagsByName :many
+-- Creates the user's tags that don't exist yet and returns all of them
+-- (the no-op update makes RETURNING include existing tags)
+INSERT INTO tags (name, user_id)
+SELECT DISTINCT unnest(sqlc.arg(names)::text[]), sqlc.arg(user_id)::text
+ON CONFLICT (user_id, name) WHERE deleted_at IS NULL DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name, created_at, updated_at;
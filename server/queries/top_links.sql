@@ -0,0 +1,6 @@
+-- name: ListLinkOwners :many
+-- Owners of the links, to rank clicks counted by link ID; deleted links are skipped
+SELECT id, user_id
+FROM links
+WHERE id = ANY(sqlc.arg(link_i_ds)::uuid[])
+  AND deleted_at IS NULL;
@@ -0,0 +1,83 @@
+-- Batches of the destination URLs of each table, for cmd/encrypturls to (re-)encrypt. The
+-- http_destination flags are set along, from the plain URLs, for rows encrypted before they existed.
+-- Updates only apply while the row still holds the values that were read, so URLs changed
+-- by the app in the meantime aren't overwritten.
+
+-- name: ListLinkURLs :many
+SELECT id, original_url, raw_url, http_destination
+FROM links
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SetLinkURLs :execrows
+UPDATE links
+SET original_url = sqlc.arg(original_url)::TEXT,
+    raw_url = sqlc.narg(raw_url),
+    http_destination = sqlc.arg(http_destination)::BOOLEAN
+WHERE id = sqlc.arg(id)
+  AND original_url = sqlc.arg(old_original_url)::TEXT
+  AND raw_url IS NOT DISTINCT FROM sqlc.narg(old_raw_url);
+
+-- name: ListDestinationChangeURLs :many
+SELECT id, raw_url, url, previous_url, http_destination
+FROM link_destination_changes
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SetDestinationChangeURLs :execrows
+UPDATE link_destination_changes
+SET raw_url = sqlc.arg(raw_url)::TEXT,
+    url = sqlc.arg(url)::TEXT,
+    previous_url = sqlc.narg(previous_url),
+    http_destination = sqlc.arg(http_destination)::BOOLEAN
+WHERE id = sqlc.arg(id)
+  AND raw_url = sqlc.arg(old_raw_url)::TEXT
+  AND url = sqlc.arg(old_url)::TEXT
+  AND previous_url IS NOT DISTINCT FROM sqlc.narg(old_previous_url);
+
+-- name: ListDestinationCheckURLs :many
+SELECT link_id, url, final_url
+FROM link_destination_checks
+WHERE link_id > sqlc.arg(after_id)
+ORDER BY link_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SetDestinationCheckURLs :execrows
+UPDATE link_destination_checks
+SET url = sqlc.arg(url)::TEXT,
+    final_url = sqlc.narg(final_url)
+WHERE link_id = sqlc.arg(link_id)
+  AND url = sqlc.arg(old_url)::TEXT
+  AND final_url IS NOT DISTINCT FROM sqlc.narg(old_final_url);
+
+-- name: ListDestinationAlertURLs :many
+SELECT id, url, final_url
+FROM link_destination_alerts
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SetDestinationAlertURLs :execrows
+UPDATE link_destination_alerts
+SET url = sqlc.arg(url)::TEXT,
+    final_url = sqlc.narg(final_url)
+WHERE id = sqlc.arg(id)
+  AND url = sqlc.arg(old_url)::TEXT
+  AND final_url IS NOT DISTINCT FROM sqlc.narg(old_final_url);
+
+-- name: ListHTTPSUpgradeURLs :many
+SELECT link_id, from_url, to_url
+FROM link_https_upgrades
+WHERE link_id > sqlc.arg(after_id)
+ORDER BY link_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SetHTTPSUpgradeURLs :execrows
+UPDATE link_https_upgrades
+SET from_url = sqlc.arg(from_url)::TEXT,
+    to_url = sqlc.arg(to_url)::TEXT
+WHERE link_id = sqlc.arg(link_id)
+  AND from_url = sqlc.arg(old_from_url)::TEXT
+  AND to_url = sqlc.arg(old_to_url)::TEXT;
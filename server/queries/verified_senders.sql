@@ -0,0 +1,38 @@
+-- name: UpsertVerifiedSender :one
+-- Verifying a sender again updates its name
+INSERT INTO verified_senders (kind, value, name, verified_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (kind, value) DO UPDATE
+SET name = EXCLUDED.name, verified_by = EXCLUDED.verified_by
+RETURNING kind, value, name, verified_by, created_at;
+
+
+-- name: DeleteVerifiedSender :one
+DELETE FROM verified_senders
+WHERE kind = $1 AND value = $2
+RETURNING kind, value, name, verified_by, created_at;
+
+
+-- name: ListVerifiedSenders :many
+SELECT kind, value, name, verified_by, created_at
+FROM verified_senders
+ORDER BY created_at DESC, kind, value
+LIMIT $1 OFFSET $2;
+
+
+-- name: CountVerifiedSenders :one
+SELECT COUNT(*) FROM verified_senders;
+
+
+-- name: GetSenderVerification :one
+-- The verification covering a link: its owner's, else its destination domain's.
+-- domains holds the destination's hostname and its parent domains.
+SELECT kind, value, name, verified_by, created_at
+FROM verified_senders
+WHERE (kind = 'user' AND value = (
+    SELECT user_id FROM links
+    WHERE shortcode = @shortcode::VARCHAR(20) AND deleted_at IS NULL
+))
+OR (kind = 'domain' AND value = ANY(@domains::TEXT[]))
+ORDER BY kind = 'user' DESC, length(value) DESC
+LIMIT 1;
@@ -0,0 +1,68 @@
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, attempt, next_retry_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, created_at, attempt, next_retry_at, pending;
+
+-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, created_at, attempt, next_retry_at, pending
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*)
+FROM webhook_deliveries
+WHERE webhook_id = $1;
+
+-- name: GetWebhookDelivery :one
+SELECT id, webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, created_at, attempt, next_retry_at, pending
+FROM webhook_deliveries
+WHERE id = $1 AND webhook_id = $2;
+
+-- name: ClaimDueWebhookRetries :many
+-- Takes the deliveries whose retry is due off the schedule until lease_until, so each is retried by
+-- one instance only; sending them clears it, and if the instance stops first they're due again then
+UPDATE webhook_deliveries
+SET next_retry_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT d.id
+    FROM webhook_deliveries d
+    JOIN webhooks w ON w.id = d.webhook_id
+    WHERE d.next_retry_at <= NOW() AND w.disabled_at IS NULL
+    ORDER BY d.next_retry_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE OF d SKIP LOCKED
+)
+RETURNING id, webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, created_at, attempt, next_retry_at, pending;
+
+-- name: EnqueueWebhookEvent :many
+-- Records the event as a pending first delivery to each of the user's enabled webhooks, due right away
+INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, duration_ms, pending, next_retry_at)
+SELECT id, sqlc.arg(event_id), sqlc.arg(event_type), sqlc.arg(payload), 0, TRUE, NOW()
+FROM webhooks
+WHERE user_id = sqlc.arg(user_id) AND disabled_at IS NULL
+RETURNING id, webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, created_at, attempt, next_retry_at, pending;
+
+-- name: ClaimWebhookDelivery :execrows
+-- Takes a pending delivery off the schedule until lease_until, unless another instance took it
+UPDATE webhook_deliveries
+SET next_retry_at = sqlc.arg(lease_until)
+WHERE id = sqlc.arg(id) AND pending AND next_retry_at <= NOW();
+
+-- name: CompleteWebhookDelivery :one
+-- Fills in a pending delivery once it's been sent
+UPDATE webhook_deliveries
+SET status_code = $2,
+    duration_ms = $3,
+    error = $4,
+    next_retry_at = $5,
+    pending = FALSE
+WHERE id = $1
+RETURNING id, webhook_id, event_id, event_type, payload, status_code, duration_ms, error, retry_of, created_at, attempt, next_retry_at, pending;
+
+-- name: CancelWebhookDeliveryRetry :exec
+-- The delivery was retried, so its scheduled retry, or the retry job's lease on it, is dropped
+UPDATE webhook_deliveries
+SET next_retry_at = NULL
+WHERE id = $1;
@@ -0,0 +1,54 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, url, secret, previous_secret, previous_secret_expires_at, created_at, secret_rotated_at, failing_since, disabled_at;
+
+-- name: ListUserWebhooks :many
+SELECT id, user_id, url, secret, previous_secret, previous_secret_expires_at, created_at, secret_rotated_at, failing_since, disabled_at
+FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetUserWebhook :one
+SELECT id, user_id, url, secret, previous_secret, previous_secret_expires_at, created_at, secret_rotated_at, failing_since, disabled_at
+FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetWebhook :one
+SELECT id, user_id, url, secret, previous_secret, previous_secret_expires_at, created_at, secret_rotated_at, failing_since, disabled_at
+FROM webhooks
+WHERE id = $1;
+
+-- name: DeleteWebhook :one
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, url, secret, previous_secret, previous_secret_expires_at, created_at, secret_rotated_at, failing_since, disabled_at;
+
+-- name: RotateWebhookSecret :one
+-- Replaces the secret, keeping the current one valid until previous_secret_expires_at
+UPDATE webhooks
+SET previous_secret = secret,
+    previous_secret_expires_at = sqlc.arg(previous_secret_expires_at),
+    secret = sqlc.arg(secret),
+    secret_rotated_at = NOW()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, user_id, url, secret, previous_secret, previous_secret_expires_at, created_at, secret_rotated_at, failing_since, disabled_at;
+
+-- name: RecordWebhookSuccess :exec
+-- A delivery went through: the webhook is healthy again
+UPDATE webhooks
+SET failing_since = NULL,
+    disabled_at = NULL
+WHERE id = $1;
+
+-- name: RecordWebhookFailure :one
+-- A delivery failed: starts the webhook's failure streak, unless one is running
+UPDATE webhooks
+SET failing_since = COALESCE(failing_since, NOW())
+WHERE id = $1
+RETURNING failing_since;
+
+-- name: DisableWebhook :execrows
+UPDATE webhooks
+SET disabled_at = NOW()
+WHERE id = $1 AND disabled_at IS NULL;
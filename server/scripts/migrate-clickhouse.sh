@@ -0,0 +1,61 @@
+#!/bin/bash
+set -e
+
+# Load environment variables
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+cd "$SCRIPT_DIR/.."
+source scripts/load-env.sh
+
+if [ -z "$CLICKHOUSE_URL" ]; then
+  echo "Error: CLICKHOUSE_URL environment variable is not set"
+  echo "Please set it in your .env file or export it:"
+  echo "  export CLICKHOUSE_URL=http://localhost:8123"
+  exit 1
+fi
+
+# Without CLICKHOUSE_DATABASE the user's default database is used
+ENDPOINT="${CLICKHOUSE_URL%/}/"
+if [ -n "$CLICKHOUSE_DATABASE" ]; then
+  ENDPOINT="${ENDPOINT}?database=${CLICKHOUSE_DATABASE}"
+fi
+
+# Runs one statement from stdin over the HTTP interface
+clickhouse() {
+  curl -sS --fail-with-body \
+    -H "X-ClickHouse-User: ${CLICKHOUSE_USERNAME}" \
+    -H "X-ClickHouse-Key: ${CLICKHOUSE_PASSWORD}" \
+    --data-binary @- \
+    "$ENDPOINT"
+}
+
+# Same tracking table the server uses when it migrates on startup (see pkg/analytics/clickhouse_migrations.go)
+echo "CREATE TABLE IF NOT EXISTS schema_migrations (version String, applied_at DateTime DEFAULT now()) ENGINE = MergeTree ORDER BY version" | clickhouse || exit 1
+
+echo "Checking for pending ClickHouse migrations..."
+PENDING_COUNT=0
+
+# ClickHouse migrations are forward-only, one statement per file
+for file in pkg/analytics/migrations/*.up.sql; do
+  if [ -f "$file" ]; then
+    VERSION=$(basename "$file" | sed 's/^\([0-9]*\)_.*/\1/')
+
+    EXISTS=$(echo "SELECT count() FROM schema_migrations WHERE version = '$VERSION'" | clickhouse)
+
+    if [ "$EXISTS" = "0" ]; then
+      echo "Running migration: $file (version: $VERSION)"
+      clickhouse < "$file" || exit 1
+      echo "INSERT INTO schema_migrations (version) VALUES ('$VERSION')" | clickhouse || exit 1
+
+      PENDING_COUNT=$((PENDING_COUNT + 1))
+      echo "✓ Migration $VERSION applied successfully"
+    else
+      echo "⊘ Migration $VERSION already applied, skipping"
+    fi
+  fi
+done
+
+if [ "$PENDING_COUNT" = "0" ]; then
+  echo "No pending migrations. ClickHouse is up to date!"
+else
+  echo "Applied $PENDING_COUNT migration(s) successfully!"
+fi